@@ -115,9 +115,11 @@ func main() {
 		crdClusterClient,
 		kcpClusterClient,
 		options.ApiResourceOptions.AutoPublishAPIs,
+		options.ApiResourceOptions.OrphanRetention,
 		kcpSharedInformerFactory.Apiresource().V1alpha1().NegotiatedAPIResources(),
 		kcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
 		crdSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
+		kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusters(),
 	)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
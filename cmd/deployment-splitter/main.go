@@ -18,17 +18,26 @@ package main
 
 import (
 	"flag"
+	"time"
 
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/deploymentsplitter"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/hpasplitter"
 )
 
 const numThreads = 2
+const resyncPeriod = 10 * time.Hour
 
 var kubeconfig = flag.String("kubeconfig", "", "Path to kubeconfig")
 var kubecontext = flag.String("context", "", "Context to use in the Kubeconfig file, instead of the current context")
+var enableHPASplitting = flag.Bool("enable-hpa-splitting", false, "Split the HorizontalPodAutoscalers of split Deployments across their clusters")
 
 func main() {
 	flag.Parse()
@@ -45,5 +54,25 @@ func main() {
 		klog.Fatal(err)
 	}
 
+	if *enableHPASplitting {
+		ctx := genericapiserver.SetupSignalContext()
+
+		kubeClient := kubernetes.NewForConfigOrDie(r)
+		dynamicInformers := dynamicinformer.NewDynamicSharedInformerFactory(dynamic.NewForConfigOrDie(r), resyncPeriod)
+		kubeInformers := informers.NewSharedInformerFactory(kubeClient, resyncPeriod)
+
+		hc := hpasplitter.NewController(
+			dynamic.NewForConfigOrDie(r),
+			kubeClient,
+			dynamicInformers.ForResource(hpasplitter.HorizontalPodAutoscalersGVR),
+			kubeInformers.Apps().V1().Deployments(),
+		)
+		dynamicInformers.Start(ctx.Done())
+		kubeInformers.Start(ctx.Done())
+		dynamicInformers.WaitForCacheSync(ctx.Done())
+		kubeInformers.WaitForCacheSync(ctx.Done())
+		go hc.Start(ctx, numThreads)
+	}
+
 	deploymentsplitter.NewController(r).Start(numThreads)
 }
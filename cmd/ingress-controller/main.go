@@ -26,14 +26,20 @@ import (
 	"github.com/spf13/pflag"
 
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/component-base/logs"
 
 	"github.com/kcp-dev/kcp/pkg/cmd/help"
+	"github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/localenvoy/controllers/ingress"
 	envoycontrolplane "github.com/kcp-dev/kcp/pkg/localenvoy/controlplane"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/endpointslices"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/httproutesplitter"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/ingresssplitter"
 )
 
@@ -100,12 +106,36 @@ func main() {
 				}
 			}
 
-			ic := ingresssplitter.NewController(kubeClient, ingressInformer, serviceInformer, options.Domain, aggregateLeavesStatus)
+			var ic *ingresssplitter.Controller
+			if utilfeature.DefaultFeatureGate.Enabled(features.IngressSplitting) {
+				ic = ingresssplitter.NewController(kubeClient, ingressInformer, serviceInformer, options.Domain, aggregateLeavesStatus)
+			}
+
+			if options.EnableHTTPRoutes && utilfeature.DefaultFeatureGate.Enabled(features.IngressSplitting) {
+				dynamicClient, err := dynamic.NewClusterForConfig(configLoader)
+				if err != nil {
+					return err
+				}
+				dynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient.Cluster(logicalcluster.Wildcard), resyncPeriod)
+				hc := httproutesplitter.NewController(dynamicClient, dynamicInformerFactory.ForResource(httproutesplitter.HTTPRoutesGVR), serviceInformer)
+
+				dynamicInformerFactory.Start(ctx.Done())
+				dynamicInformerFactory.WaitForCacheSync(ctx.Done())
+
+				go hc.Start(ctx, numThreads)
+			}
+
+			if options.EnableEndpointSlices {
+				ec := endpointslices.NewController(kubeClient, serviceInformer)
+				go ec.Start(ctx, numThreads)
+			}
 
 			kubeInformerFactory.Start(ctx.Done())
 			kubeInformerFactory.WaitForCacheSync(ctx.Done())
 
-			ic.Start(ctx, numThreads)
+			if ic != nil {
+				go ic.Start(ctx, numThreads)
+			}
 
 			<-ctx.Done()
 
@@ -121,12 +151,14 @@ func main() {
 }
 
 type Options struct {
-	Kubeconfig        string
-	Context           string
-	EnvoyXDSPort      uint
-	EnvoyListenerPort uint
-	Domain            string
-	Logs              *logs.Options
+	Kubeconfig           string
+	Context              string
+	EnvoyXDSPort         uint
+	EnvoyListenerPort    uint
+	Domain               string
+	EnableHTTPRoutes     bool
+	EnableEndpointSlices bool
+	Logs                 *logs.Options
 }
 
 func NewDefaultOptions() *Options {
@@ -146,6 +178,9 @@ func (o *Options) BindFlags(fs *pflag.FlagSet) {
 	fs.UintVar(&o.EnvoyXDSPort, "envoy-xds-port", o.EnvoyXDSPort, "Envoy control plane port. Set to 0 to disable")
 	fs.UintVar(&o.EnvoyListenerPort, "envoy-listener-port", o.EnvoyListenerPort, "Envoy listener port")
 	fs.StringVar(&o.Domain, "domain", o.Domain, "The domain to use to expose ingresses")
+	fs.BoolVar(&o.EnableEndpointSlices, "enable-endpointslices", o.EnableEndpointSlices, "Aggregate the ready endpoints of services across clusters into EndpointSlices")
+	fs.BoolVar(&o.EnableHTTPRoutes, "enable-httproutes", o.EnableHTTPRoutes, "Split Gateway API HTTPRoutes by the clusters of their backends")
 
+	utilfeature.DefaultMutableFeatureGate.AddFlag(fs)
 	o.Logs.AddFlags(fs)
 }
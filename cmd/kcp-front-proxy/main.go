@@ -33,9 +33,11 @@ import (
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	genericfilters "k8s.io/apiserver/pkg/server/filters"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	utilflag "k8s.io/component-base/cli/flag"
 	"k8s.io/component-base/logs"
 	"k8s.io/component-base/version"
+	"k8s.io/klog/v2"
 
 	frontproxyoptions "github.com/kcp-dev/kcp/cmd/kcp-front-proxy/options"
 	"github.com/kcp-dev/kcp/pkg/proxy"
@@ -85,6 +87,21 @@ routed based on paths.`,
 				return err
 			}
 
+			if options.Proxy.ShardsKubeconfig != "" {
+				shardsConfig, err := clientcmd.BuildConfigFromFlags("", options.Proxy.ShardsKubeconfig)
+				if err != nil {
+					return fmt.Errorf("failed to load shards kubeconfig %q: %w", options.Proxy.ShardsKubeconfig, err)
+				}
+				discoveryCache := proxy.NewDiscoveryCache(options.Proxy.DiscoveryCacheTTL)
+				index := proxy.NewShardIndex(restclient.AddUserAgent(shardsConfig, "kcp-front-proxy"), discoveryCache.Invalidate)
+				go func() {
+					if err := index.Start(ctx); err != nil {
+						klog.Errorf("Failed to watch the shards: %v", err)
+					}
+				}()
+				handler = proxy.WithShardDiscovery(handler, index, discoveryCache)
+			}
+
 			var servingInfo *genericapiserver.SecureServingInfo
 			var loopbackClientConfig *restclient.Config
 			if err := options.SecureServing.ApplyTo(&servingInfo, &loopbackClientConfig); err != nil {
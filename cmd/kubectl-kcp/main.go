@@ -27,6 +27,10 @@ import (
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/klog/v2"
 
+	accesscmd "github.com/kcp-dev/kcp/pkg/cliplugins/access/cmd"
+	crdcmd "github.com/kcp-dev/kcp/pkg/cliplugins/crd/cmd"
+	placementcmd "github.com/kcp-dev/kcp/pkg/cliplugins/placement/cmd"
+	scaffoldcmd "github.com/kcp-dev/kcp/pkg/cliplugins/scaffold/cmd"
 	"github.com/kcp-dev/kcp/pkg/cliplugins/workspace/cmd"
 	"github.com/kcp-dev/kcp/pkg/cmd/help"
 )
@@ -58,12 +62,18 @@ func main() {
 	klog.InitFlags(fs)
 	root.PersistentFlags().AddGoFlagSet(fs)
 
-	workspaceCmd, err := cmd.NewCmdWorkspace(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
+	streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+
+	workspaceCmd, err := cmd.NewCmdWorkspace(streams)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
 	root.AddCommand(workspaceCmd)
+	root.AddCommand(crdcmd.NewCmdCRD(streams))
+	root.AddCommand(scaffoldcmd.NewCmdInit(streams))
+	root.AddCommand(placementcmd.NewCmdExplainPlacement(streams))
+	root.AddCommand(accesscmd.NewCmdAccess(streams))
 
 	if err := root.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -18,12 +18,14 @@ package cmd
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 	"github.com/spf13/cobra"
 
 	"k8s.io/apimachinery/pkg/util/sets"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 
@@ -64,12 +66,51 @@ func NewSyncerCommand() *cobra.Command {
 }
 
 func Run(options *synceroptions.Options, ctx context.Context) error {
-	klog.Infof("Syncing the following resource types: %s", options.SyncedResourceTypes)
+	var targets []syncer.SyncTarget
+	for _, target := range options.Targets {
+		klog.Infof("Syncing the following resource types of WorkloadCluster %s|%s: %s", target.FromCluster, target.WorkloadClusterName, target.SyncResources)
+
+		kcpConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: target.FromKubeconfig}, nil).ClientConfig()
+		if err != nil {
+			return err
+		}
+
+		var targetToConfig *rest.Config
+		if target.ToKubeconfig != "" || target.ToContext != "" {
+			toKubeconfig := target.ToKubeconfig
+			if toKubeconfig == "" {
+				toKubeconfig = options.ToKubeconfig
+			}
+			targetToConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+				&clientcmd.ClientConfigLoadingRules{ExplicitPath: toKubeconfig},
+				&clientcmd.ConfigOverrides{
+					CurrentContext: target.ToContext,
+				}).ClientConfig()
+			if err != nil {
+				return err
+			}
+		}
+
+		// targets of a targets file have their own state directory
+		stateDir := options.StateDir
+		if stateDir != "" && options.TargetsFile != "" {
+			stateDir = filepath.Join(stateDir, target.FromCluster, target.WorkloadClusterName)
+		}
+
+		targets = append(targets, syncer.SyncTarget{
+			Upstream:            kcpConfig,
+			Downstream:          targetToConfig,
+			Resources:           sets.NewString(target.SyncResources...),
+			KCPClusterName:      logicalcluster.New(target.FromCluster),
+			WorkloadClusterName: target.WorkloadClusterName,
+			StateDir:            stateDir,
+			UpsyncPods:          target.UpsyncPods,
+		})
+	}
 
-	kcpConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		&clientcmd.ClientConfigLoadingRules{ExplicitPath: options.FromKubeconfig}, nil).ClientConfig()
-	if err != nil {
-		return err
+	if options.Simulate {
+		return syncer.StartSimulatedSyncers(ctx, targets, numThreads, options.APIImportPollInterval)
 	}
 
 	toConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
@@ -80,19 +121,5 @@ func Run(options *synceroptions.Options, ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-
-	if err := syncer.StartSyncer(
-		ctx,
-		kcpConfig,
-		toConfig,
-		sets.NewString(options.SyncedResourceTypes...),
-		logicalcluster.New(options.FromClusterName),
-		options.PclusterID,
-		numThreads,
-		options.APIImportPollInterval,
-	); err != nil {
-		return err
-	}
-
-	return nil
+	return syncer.StartSyncers(ctx, toConfig, targets, numThreads, options.APIImportPollInterval, options.MetricsBindAddress, options.DebugBindAddress, options.DebugToken)
 }
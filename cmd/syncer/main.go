@@ -20,6 +20,8 @@ import (
 	"os"
 
 	"k8s.io/component-base/cli"
+	_ "k8s.io/component-base/metrics/prometheus/clientgo"  // for client metrics
+	_ "k8s.io/component-base/metrics/prometheus/workqueue" // for workqueue metrics
 
 	"github.com/kcp-dev/kcp/cmd/syncer/cmd"
 )
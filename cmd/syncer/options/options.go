@@ -19,15 +19,39 @@ package options
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
 
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/component-base/logs"
+	"sigs.k8s.io/yaml"
 
+	_ "github.com/kcp-dev/kcp/pkg/features"
 	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 )
 
+// Target is a WorkloadCluster synced by a syncer serving multiple workload clusters.
+type Target struct {
+	FromKubeconfig      string   `json:"fromKubeconfig"`
+	FromCluster         string   `json:"fromCluster"`
+	WorkloadClusterName string   `json:"workloadClusterName"`
+	SyncResources       []string `json:"syncResources,omitempty"`
+	// ToKubeconfig and ToContext default to those of the syncer, e.g. to use other credentials for the
+	// physical cluster for this target.
+	ToKubeconfig string `json:"toKubeconfig,omitempty"`
+	ToContext    string `json:"toContext,omitempty"`
+	// UpsyncPods reflects the pods of the physical cluster into the workspace, read-only.
+	UpsyncPods bool `json:"upsyncPods,omitempty"`
+}
+
+// TargetsFile is the content of the file passed to --targets-file.
+type TargetsFile struct {
+	Targets []Target `json:"targets"`
+}
+
 type Options struct {
 	FromKubeconfig      string
 	FromClusterName     string
@@ -36,8 +60,21 @@ type Options struct {
 	PclusterID          string
 	Logs                *logs.Options
 	SyncedResourceTypes []string
+	UpsyncPods          bool
 
 	APIImportPollInterval time.Duration
+	StateDir              string
+	MetricsBindAddress    string
+	DebugBindAddress      string
+	DebugTokenFile        string
+	TargetsFile           string
+	Simulate              bool
+
+	// DebugToken is the content of --debug-token-file.
+	DebugToken string
+
+	// Targets are the workload clusters to sync, from --targets-file or from the single target flags.
+	Targets []Target
 }
 
 func NewOptions() *Options {
@@ -45,6 +82,8 @@ func NewOptions() *Options {
 		SyncedResourceTypes:   []string{},
 		Logs:                  logs.NewOptions(),
 		APIImportPollInterval: 1 * time.Minute,
+		MetricsBindAddress:    ":8080",
+		DebugBindAddress:      "127.0.0.1:8081",
 	}
 }
 
@@ -56,16 +95,88 @@ func (options *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&options.PclusterID, "workload-cluster-name", options.PclusterID,
 		fmt.Sprintf("ID of the -to cluster. Resources with this ID set in the '%s' label will be synced.", nscontroller.ClusterLabel))
 	fs.StringArrayVarP(&options.SyncedResourceTypes, "sync-resources", "r", options.SyncedResourceTypes, "Resources to be synchronized in kcp.")
+	fs.BoolVar(&options.UpsyncPods, "upsync-pods", options.UpsyncPods, "Reflect the pods running in the synced namespaces of the -to cluster into the -from logical cluster, read-only.")
 	fs.DurationVar(&options.APIImportPollInterval, "api-import-poll-interval", options.APIImportPollInterval, "Polling interval for API import.")
-
+	fs.StringVar(&options.StateDir, "state-dir", options.StateDir, "Directory to persist the resource versions of the synced objects to, such that a restarted syncer only syncs the objects which changed. If not set, all objects are synced again on restart.")
+	fs.StringVar(&options.TargetsFile, "targets-file", options.TargetsFile, "File listing the workload clusters to sync to the -to cluster, each with its own -from kubeconfig, cluster and resources. Replaces --from-kubeconfig, --from-cluster, --workload-cluster-name, --sync-resources and --upsync-pods.")
+	fs.StringVar(&options.MetricsBindAddress, "metrics-bind-address", options.MetricsBindAddress, "Address to serve the /metrics, /healthz and /readyz endpoints on. If empty, they are not served.")
+	fs.StringVar(&options.DebugBindAddress, "debug-bind-address", options.DebugBindAddress, "Loopback address to serve the /debug/pprof and /debug/controllers endpoints on, if --debug-token-file is set. They are served without TLS, reach them e.g. through kubectl port-forward.")
+	fs.StringVar(&options.DebugTokenFile, "debug-token-file", options.DebugTokenFile, "File containing a bearer token to access /debug/pprof and /debug/controllers on the --debug-bind-address. If not set, they are not served.")
+	fs.BoolVar(&options.Simulate, "simulate", options.Simulate, "Sync to a physical cluster simulated in memory instead of the -to cluster, reporting a plausible status for the synced resources, e.g. for development. The APIs of Deployments, Pods and other core resources are imported from built-in CRDs.")
+
+	utilfeature.DefaultMutableFeatureGate.AddFlag(fs)
 	options.Logs.AddFlags(fs)
 }
 
 func (options *Options) Complete() error {
+	if options.DebugTokenFile != "" {
+		raw, err := ioutil.ReadFile(options.DebugTokenFile)
+		if err != nil {
+			return err
+		}
+		options.DebugToken = strings.TrimSpace(string(raw))
+		if options.DebugToken == "" {
+			return fmt.Errorf("--debug-token-file %s is empty", options.DebugTokenFile)
+		}
+	}
+
+	if options.TargetsFile == "" {
+		options.Targets = []Target{{
+			FromKubeconfig:      options.FromKubeconfig,
+			FromCluster:         options.FromClusterName,
+			WorkloadClusterName: options.PclusterID,
+			SyncResources:       options.SyncedResourceTypes,
+			UpsyncPods:          options.UpsyncPods,
+		}}
+		return nil
+	}
+
+	raw, err := ioutil.ReadFile(options.TargetsFile)
+	if err != nil {
+		return err
+	}
+	var file TargetsFile
+	if err := yaml.UnmarshalStrict(raw, &file); err != nil {
+		return fmt.Errorf("invalid --targets-file %s: %w", options.TargetsFile, err)
+	}
+	options.Targets = file.Targets
 	return nil
 }
 
 func (options *Options) Validate() error {
+	if options.DebugTokenFile != "" && options.DebugBindAddress == "" {
+		return errors.New("--debug-token-file requires --debug-bind-address")
+	}
+
+	if options.Simulate {
+		if options.ToKubeconfig != "" || options.ToContext != "" || options.UpsyncPods || options.StateDir != "" || options.DebugTokenFile != "" {
+			return errors.New("--simulate cannot be combined with --to-kubeconfig, --to-context, --upsync-pods, --state-dir and --debug-token-file")
+		}
+		for i, target := range options.Targets {
+			if target.ToKubeconfig != "" || target.ToContext != "" || target.UpsyncPods {
+				return fmt.Errorf("targets[%d] cannot set toKubeconfig, toContext and upsyncPods with --simulate", i)
+			}
+		}
+	}
+
+	if options.TargetsFile != "" {
+		if options.FromClusterName != "" || options.FromKubeconfig != "" || options.PclusterID != "" || len(options.SyncedResourceTypes) > 0 || options.UpsyncPods {
+			return errors.New("--targets-file cannot be combined with --from-kubeconfig, --from-cluster, --workload-cluster-name, --sync-resources and --upsync-pods")
+		}
+		if len(options.Targets) == 0 {
+			return fmt.Errorf("--targets-file %s lists no targets", options.TargetsFile)
+		}
+		for i, target := range options.Targets {
+			if target.FromCluster == "" {
+				return fmt.Errorf("targets[%d].fromCluster is required", i)
+			}
+			if target.FromKubeconfig == "" {
+				return fmt.Errorf("targets[%d].fromKubeconfig is required", i)
+			}
+		}
+		return nil
+	}
+
 	if options.FromClusterName == "" {
 		return errors.New("--from-cluster is required")
 	}
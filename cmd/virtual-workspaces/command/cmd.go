@@ -108,6 +108,12 @@ func Run(o *options.Options, stopCh <-chan struct{}) error {
 	if err != nil {
 		return err
 	}
+	eventsInformerStarts, eventsVirtualWorkspaces, err := o.Events.NewVirtualWorkspaces(o.RootPathPrefix, kubeClusterClient, kcpClusterClient, wildcardKubeInformers, wildcardKcpInformers)
+	if err != nil {
+		return err
+	}
+	extraInformerStarts = append(extraInformerStarts, eventsInformerStarts...)
+	virtualWorkspaces = append(virtualWorkspaces, eventsVirtualWorkspaces...)
 	scheme := runtime.NewScheme()
 	metav1.AddToGroupVersion(scheme, schema.GroupVersion{Group: "", Version: "v1"})
 	codecs := serializer.NewCodecFactory(scheme)
@@ -29,6 +29,7 @@ import (
 	genericapiserveroptions "k8s.io/apiserver/pkg/server/options"
 	"k8s.io/component-base/logs"
 
+	eventsoptions "github.com/kcp-dev/kcp/pkg/virtual/events/options"
 	workspacesoptions "github.com/kcp-dev/kcp/pkg/virtual/workspaces/options"
 )
 
@@ -48,6 +49,7 @@ type Options struct {
 	Logs           logs.Options
 
 	Workspaces workspacesoptions.Workspaces
+	Events     eventsoptions.Events
 }
 
 func NewOptions() *Options {
@@ -61,6 +63,7 @@ func NewOptions() *Options {
 		Logs:           *logs.NewOptions(),
 
 		Workspaces: *workspacesoptions.NewWorkspaces(),
+		Events:     *eventsoptions.NewEvents(),
 	}
 
 	opts.SecureServing.ServerCert.CertKey.CertFile = filepath.Join(".", ".kcp", "apiserver.crt")
@@ -75,6 +78,7 @@ func (o *Options) AddFlags(flags *pflag.FlagSet) {
 	o.Authentication.AddFlags(flags)
 	o.Logs.AddFlags(flags)
 	o.Workspaces.AddFlags(flags, "")
+	o.Events.AddFlags(flags, "")
 
 	flags.StringVar(&o.KubeconfigFile, "kubeconfig", o.KubeconfigFile, ""+
 		"The kubeconfig file of the KCP instance that hosts workspaces.")
@@ -86,6 +90,7 @@ func (o *Options) Validate() error {
 	errs = append(errs, o.SecureServing.Validate()...)
 	errs = append(errs, o.Authentication.Validate()...)
 	errs = append(errs, o.Workspaces.Validate("")...)
+	errs = append(errs, o.Events.Validate("")...)
 
 	if len(o.KubeconfigFile) == 0 {
 		errs = append(errs, fmt.Errorf("--kubeconfig is required for this command"))
@@ -31,7 +31,11 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	apimachineryerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/discovery"
@@ -48,6 +52,9 @@ type TransformFileFunc func(bs []byte) ([]byte, error)
 type Option struct {
 	// TransformFileFunc is a function that transforms a resource file before being applied to the cluster.
 	TransformFile TransformFileFunc
+	// Owner is the name of the bootstrap set owning the resources. If set, the resources are
+	// labeled with it, and resources of the set which are not in the fs anymore are pruned.
+	Owner string
 }
 
 // ReplaceOption allows to customize the bootstrap process.
@@ -65,6 +72,14 @@ func ReplaceOption(pairs ...string) Option {
 	}
 }
 
+// OwnerOption records the ownership of the bootstrapped resources by the given bootstrap set
+// through the bootstrap.kcp.dev/owner label, and prunes the resources labeled for the set
+// which are not in the fs anymore, e.g. because they were removed from the set in an upgrade.
+// The owner must be unique per logical cluster.
+func OwnerOption(owner string) Option {
+	return Option{Owner: owner}
+}
+
 // Bootstrap creates resources in a package's fs by
 // continuously retrying the list. This is blocking, i.e. it only returns (with error)
 // when the context is closed or with nil when the bootstrapping is successfully completed.
@@ -74,42 +89,75 @@ func Bootstrap(ctx context.Context, discoveryClient discovery.DiscoveryInterface
 
 	// bootstrap non-crd resources
 	var transformers []TransformFileFunc
+	var owner string
 	for _, opt := range opts {
-		transformers = append(transformers, opt.TransformFile)
+		if opt.TransformFile != nil {
+			transformers = append(transformers, opt.TransformFile)
+		}
+		if opt.Owner != "" {
+			owner = opt.Owner
+		}
 	}
 	return wait.PollImmediateInfiniteWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
-		if err := CreateResourcesFromFS(ctx, dynamicClient, mapper, fs, transformers...); err != nil {
+		applied, err := createResourcesFromFS(ctx, dynamicClient, mapper, fs, owner, transformers)
+		if err != nil {
 			klog.Infof("Failed to bootstrap resources, retrying: %v", err)
 			// invalidate cache if resources not found
 			// xref: https://github.com/kcp-dev/kcp/issues/655
 			cache.Invalidate()
 			return false, nil
 		}
+		if owner == "" {
+			return true, nil
+		}
+
+		// Only prune when the whole set is applied, i.e. when we know what it consists of. Pruning
+		// is best effort, and must not block the bootstrapping. Left-overs are pruned next time.
+		resources, err := prunableResources(cache)
+		if err != nil {
+			klog.Errorf("Failed to discover the resources of bootstrap set %q to prune: %v", owner, err)
+			return true, nil
+		}
+		if err := pruneResources(ctx, dynamicClient, resources, owner, applied); err != nil {
+			klog.Errorf("Failed to prune resources of bootstrap set %q: %v", owner, err)
+		}
 		return true, nil
 	})
 }
 
 // CreateResourcesFromFS creates all resources from a filesystem.
 func CreateResourcesFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, fs embed.FS, transformers ...TransformFileFunc) error {
+	_, err := createResourcesFromFS(ctx, client, mapper, fs, "", transformers)
+	return err
+}
+
+func createResourcesFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, fs embed.FS, owner string, transformers []TransformFileFunc) (sets.String, error) {
 	files, err := fs.ReadDir(".")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	applied := sets.NewString()
 	var errs []error
 	for _, f := range files {
 		if f.IsDir() {
 			continue
 		}
-		if err := CreateResourceFromFS(ctx, client, mapper, f.Name(), fs, transformers...); err != nil {
+		if err := createResourceFileFromFS(ctx, client, mapper, f.Name(), fs, owner, applied, transformers); err != nil {
 			errs = append(errs, err)
 		}
 	}
-	return apimachineryerrors.NewAggregate(errs)
+	return applied, apimachineryerrors.NewAggregate(errs)
 }
 
 // CreateResourceFromFS creates given resource file.
 func CreateResourceFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, filename string, fs embed.FS, transformers ...TransformFileFunc) error {
+	return createResourceFileFromFS(ctx, client, mapper, filename, fs, "", sets.NewString(), transformers)
+}
+
+// createResourceFileFromFS creates the resources of the given file, labeled as owned by owner if
+// not empty, and adds their UIDs to applied.
+func createResourceFileFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, filename string, fs embed.FS, owner string, applied sets.String, transformers []TransformFileFunc) error {
 	raw, err := fs.ReadFile(filename)
 	if err != nil {
 		return fmt.Errorf("could not read %s: %w", filename, err)
@@ -139,28 +187,47 @@ func CreateResourceFromFS(ctx context.Context, client dynamic.Interface, mapper
 			}
 		}
 
-		if err := createResourceFromFS(ctx, client, mapper, doc); err != nil {
+		uid, err := createResourceFromFS(ctx, client, mapper, doc, owner)
+		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to create resource %s doc %d: %w", filename, i, err))
+			continue
 		}
+		applied.Insert(string(uid))
 	}
 	return apimachineryerrors.NewAggregate(errs)
 }
 
-const annotationCreateOnlyKey = "bootstrap.kcp.dev/create-only"
+const (
+	annotationCreateOnlyKey = "bootstrap.kcp.dev/create-only"
 
-func createResourceFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, raw []byte) error {
+	// LabelOwnerKey is the label holding the name of the bootstrap set owning a bootstrapped
+	// resource. Resources labeled for a set and not part of it anymore are pruned.
+	LabelOwnerKey = "bootstrap.kcp.dev/owner"
+)
+
+// createResourceFromFS creates or updates the given resource, and returns its UID.
+func createResourceFromFS(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, raw []byte, owner string) (types.UID, error) {
 	obj, gvk, err := extensionsapiserver.Codecs.UniversalDeserializer().Decode(raw, nil, &unstructured.Unstructured{})
 	if err != nil {
-		return fmt.Errorf("could not decode raw: %w", err)
+		return "", fmt.Errorf("could not decode raw: %w", err)
 	}
 	u, ok := obj.(*unstructured.Unstructured)
 	if !ok {
-		return fmt.Errorf("decoded into incorrect type, got %T, wanted %T", obj, &unstructured.Unstructured{})
+		return "", fmt.Errorf("decoded into incorrect type, got %T, wanted %T", obj, &unstructured.Unstructured{})
 	}
 
 	m, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if err != nil {
-		return fmt.Errorf("could not get REST mapping for %s: %w", gvk, err)
+		return "", fmt.Errorf("could not get REST mapping for %s: %w", gvk, err)
+	}
+
+	if owner != "" {
+		labels := u.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[LabelOwnerKey] = owner
+		u.SetLabels(labels)
 	}
 
 	// TODO(ncdc): replace with Maru's upcoming helper
@@ -177,7 +244,7 @@ func createResourceFromFS(ctx context.Context, client dynamic.Interface, mapper
 		if apierrors.IsAlreadyExists(err) {
 			existing, err := client.Resource(m.Resource).Namespace(u.GetNamespace()).Get(ctx, u.GetName(), metav1.GetOptions{})
 			if err != nil {
-				return err
+				return "", err
 			}
 
 			if _, exists := existing.GetAnnotations()[annotationCreateOnlyKey]; exists {
@@ -187,21 +254,77 @@ func createResourceFromFS(ctx context.Context, client dynamic.Interface, mapper
 					logName(existing),
 				)
 
-				return nil
+				return existing.GetUID(), nil
 			}
 
 			u.SetResourceVersion(existing.GetResourceVersion())
 			if _, err = client.Resource(m.Resource).Namespace(u.GetNamespace()).Update(ctx, u, metav1.UpdateOptions{}); err != nil {
-				return fmt.Errorf("could not update %s %s: %w", gvk.Kind, logName(existing), err)
+				return "", fmt.Errorf("could not update %s %s: %w", gvk.Kind, logName(existing), err)
 			} else {
 				klog.Infof("Updated %s %s", gvk, logName(existing))
-				return nil
+				return existing.GetUID(), nil
 			}
 		}
-		return err
+		return "", err
 	}
 
 	klog.Infof("Bootstrapped %s %s", gvk.Kind, logName(upserted))
 
-	return nil
+	return upserted.GetUID(), nil
+}
+
+// prunableResources returns the preferred versions of the resources which can be listed and
+// deleted, i.e. those that can hold pruned resources.
+func prunableResources(discoveryClient discovery.DiscoveryInterface) ([]schema.GroupVersionResource, error) {
+	lists, err := discovery.ServerPreferredResources(discoveryClient)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	} else if err != nil {
+		// resources of unavailable groups are pruned the next time
+		klog.Infof("Failed to discover some resources to prune: %v", err)
+	}
+	lists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list", "delete"}}, lists)
+	gvrs, err := discovery.GroupVersionResources(lists)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]schema.GroupVersionResource, 0, len(gvrs))
+	for gvr := range gvrs {
+		resources = append(resources, gvr)
+	}
+	return resources, nil
+}
+
+// pruneResources deletes the resources labeled as owned by owner whose UIDs are not in applied.
+// Resources are identified by UID because the same object can be served by several resources,
+// e.g. ClusterWorkspaces are projected to Workspaces.
+func pruneResources(ctx context.Context, client dynamic.Interface, resources []schema.GroupVersionResource, owner string, applied sets.String) error {
+	selector := labels.SelectorFromSet(labels.Set{LabelOwnerKey: owner}).String()
+
+	pruned := sets.NewString()
+	var errs []error
+	for _, gvr := range resources {
+		list, err := client.Resource(gvr).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not list %s: %w", gvr, err))
+			continue
+		}
+		for _, item := range list.Items {
+			uid := item.GetUID()
+			if applied.Has(string(uid)) || pruned.Has(string(uid)) {
+				continue
+			}
+			err := client.Resource(gvr).Namespace(item.GetNamespace()).Delete(ctx, item.GetName(), metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{UID: &uid},
+			})
+			if err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("could not prune %s %s/%s: %w", gvr.GroupResource(), item.GetNamespace(), item.GetName(), err))
+				continue
+			}
+			pruned.Insert(string(uid))
+			klog.Infof("Pruned %s %s|%s/%s which is not part of bootstrap set %q anymore", gvr.GroupResource(), item.GetClusterName(), item.GetNamespace(), item.GetName(), owner)
+		}
+	}
+	return apimachineryerrors.NewAggregate(errs)
 }
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestBootstrapOwnershipAndPruning(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	projections := schema.GroupVersionResource{Group: "test.kcp.dev", Version: "v1", Resource: "configmapprojections"}
+	configMap := func(name string, labels map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetUID(types.UID(name))
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace("default")
+		u.SetName(name)
+		u.SetLabels(labels)
+		return u
+	}
+
+	// the same object served by another resource
+	projection := configMap("kept", map[string]string{LabelOwnerKey: "root"})
+	projection.SetAPIVersion("test.kcp.dev/v1")
+	projection.SetKind("ConfigMapProjection")
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMaps:  "ConfigMapList",
+		projections: "ConfigMapProjectionList",
+	},
+		projection,
+		configMap("kept", map[string]string{LabelOwnerKey: "root"}),
+		configMap("adopted", nil),
+		configMap("removed", map[string]string{LabelOwnerKey: "root"}),
+		configMap("other-set", map[string]string{LabelOwnerKey: "universal"}),
+		configMap("unowned", nil),
+	)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+
+	ctx := context.Background()
+	applied := sets.NewString()
+	for _, name := range []string{"kept", "adopted", "created"} {
+		uid, err := createResourceFromFS(ctx, client, mapper, []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: `+name+`
+  namespace: default
+  labels:
+    app: kcp
+`), "root")
+		require.NoError(t, err)
+		applied.Insert(string(uid))
+	}
+
+	require.NoError(t, pruneResources(ctx, client, []schema.GroupVersionResource{configMaps, projections}, "root", applied))
+
+	list, err := client.Resource(configMaps).Namespace("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	remaining := map[string]map[string]string{}
+	for _, item := range list.Items {
+		remaining[item.GetName()] = item.GetLabels()
+	}
+	require.Equal(t, map[string]map[string]string{
+		"kept":      {"app": "kcp", LabelOwnerKey: "root"},
+		"adopted":   {"app": "kcp", LabelOwnerKey: "root"},
+		"created":   {"app": "kcp", LabelOwnerKey: "root"},
+		"other-set": {LabelOwnerKey: "universal"},
+		"unowned":   nil,
+	}, remaining)
+
+	list, err = client.Resource(projections).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+}
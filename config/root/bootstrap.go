@@ -32,9 +32,9 @@ import (
 //go:embed *.yaml
 var fs embed.FS
 
-// Bootstrap creates resources in this package by continuously retrying the list.
-// This is blocking, i.e. it only returns (with error) when the context is closed or with nil when
-// the bootstrapping is successfully completed.
+// Bootstrap creates resources in this package by continuously retrying the list, and prunes
+// those removed from it since they were created. This is blocking, i.e. it only returns (with
+// error) when the context is closed or with nil when the bootstrapping is successfully completed.
 func Bootstrap(ctx context.Context, rootDiscoveryClient discovery.DiscoveryInterface, rootDynamicClient dynamic.Interface, shardName string, kubeconfig clientcmdapi.Config) error {
 	kubeconfigRaw, err := clientcmd.Write(kubeconfig)
 	if err != nil {
@@ -44,5 +44,5 @@ func Bootstrap(ctx context.Context, rootDiscoveryClient discovery.DiscoveryInter
 	return confighelpers.Bootstrap(ctx, rootDiscoveryClient, rootDynamicClient, fs, confighelpers.ReplaceOption(
 		"SHARD_NAME", shardName,
 		"SHARD_KUBECONFIG", base64.StdEncoding.EncodeToString(kubeconfigRaw),
-	))
+	), confighelpers.OwnerOption("root"))
 }
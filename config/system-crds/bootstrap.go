@@ -40,9 +40,10 @@ import (
 //go:embed *.yaml
 var fs embed.FS
 
-// Bootstrap creates CRDs and the resources in this package by continuously retrying the list.
-// This is blocking, i.e. it only returns (with error) when the context is closed or with nil when
-// the bootstrapping is successfully completed.
+// Bootstrap creates CRDs and the resources in this package by continuously retrying the list,
+// and prunes the resources removed from it since they were created. This is blocking, i.e. it
+// only returns (with error) when the context is closed or with nil when the bootstrapping is
+// successfully completed.
 func Bootstrap(ctx context.Context, crdClient apiextensionsclient.Interface, discoveryClient discovery.DiscoveryInterface, dynamicClient dynamic.Interface) error {
 	// This is the full list of CRDs that kcp owns and manages in the system:system-crds logical cluster. Our custom CRD
 	// lister currently has a hard-coded list of which system CRDs are made available to which workspaces. See
@@ -53,13 +54,24 @@ func Bootstrap(ctx context.Context, crdClient apiextensionsclient.Interface, dis
 		{Group: tenancy.GroupName, Resource: "clusterworkspaces"},
 		{Group: tenancy.GroupName, Resource: "clusterworkspacetypes"},
 		{Group: tenancy.GroupName, Resource: "clusterworkspaceshards"},
+		{Group: tenancy.GroupName, Resource: "clusterworkspacequotas"},
+		{Group: tenancy.GroupName, Resource: "accessrequests"},
+		{Group: tenancy.GroupName, Resource: "workspacemigrations"},
+		{Group: tenancy.GroupName, Resource: "shardupgradeplans"},
+		{Group: tenancy.GroupName, Resource: "namereservations"},
+		{Group: tenancy.GroupName, Resource: "workspacerequestsets"},
+		{Group: tenancy.GroupName, Resource: "workspaceclones"},
 		{Group: tenancy.GroupName, Resource: "workspaces"},
 		{Group: apiresource.GroupName, Resource: "apiresourceimports"},
 		{Group: apiresource.GroupName, Resource: "negotiatedapiresources"},
 		{Group: workload.GroupName, Resource: "workloadclusters"},
+		{Group: workload.GroupName, Resource: "placements"},
+		{Group: workload.GroupName, Resource: "workloadclusterpools"},
+		{Group: workload.GroupName, Resource: "placementdecisions"},
 		{Group: apis.GroupName, Resource: "apiexports"},
 		{Group: apis.GroupName, Resource: "apibindings"},
 		{Group: apis.GroupName, Resource: "apiresourceschemas"},
+		{Group: apis.GroupName, Resource: "maintenancetasks"},
 	}
 
 	if err := wait.PollImmediateInfiniteWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
@@ -72,5 +84,5 @@ func Bootstrap(ctx context.Context, crdClient apiextensionsclient.Interface, dis
 		return fmt.Errorf("failed to bootstrap system CRDs: %w", err)
 	}
 
-	return confighelpers.Bootstrap(ctx, discoveryClient, dynamicClient, fs)
+	return confighelpers.Bootstrap(ctx, discoveryClient, dynamicClient, fs, confighelpers.OwnerOption("system-crds"))
 }
@@ -26,3 +26,8 @@ import "embed"
 
 //go:embed config
 var ConfigDir embed.FS
+
+// ContribCRDs are the CRDs of the legacy schema resources of contrib/crds, e.g. Deployments and Pods.
+//
+//go:embed contrib/crds/apps/*.yaml contrib/crds/core/*.yaml
+var ContribCRDs embed.FS
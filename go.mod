@@ -8,6 +8,7 @@ require (
 	github.com/emicklei/go-restful v2.9.5+incompatible
 	github.com/envoyproxy/go-control-plane v0.10.1
 	github.com/evanphx/json-patch v5.6.0+incompatible
+	github.com/go-logr/logr v1.2.0
 	github.com/google/go-cmp v0.5.6
 	github.com/google/uuid v1.1.2
 	github.com/googleapis/gnostic v0.5.5
@@ -0,0 +1,225 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessrequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/AccessRequest"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &accessRequest{
+				Handler:          admission.NewHandler(admission.Create, admission.Update),
+				createAuthorizer: delegated.NewDelegatedAuthorizer,
+			}, nil
+		})
+}
+
+// accessRequest records the requesting and the deciding user of AccessRequests, and makes
+// sure that only owners of the workspace decide about a request, and only once.
+type accessRequest struct {
+	*admission.Handler
+	kubeClusterClient *kubernetes.Cluster
+
+	createAuthorizer delegated.DelegatedAuthorizerFactory
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&accessRequest{})
+var _ = admission.MutationInterface(&accessRequest{})
+var _ = admission.InitializationValidator(&accessRequest{})
+
+// Admit sets spec.user to the requesting user on creation, and status.decidedBy to the deciding
+// user when the decision is set.
+func (o *accessRequest) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("accessrequests") {
+		return nil
+	}
+
+	u, request, err := accessRequestFrom(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	switch a.GetOperation() {
+	case admission.Create:
+		request.Spec.User = a.GetUserInfo().GetName()
+		request.Status = tenancyv1alpha1.AccessRequestStatus{}
+	case admission.Update:
+		_, old, err := accessRequestFrom(a.GetOldObject())
+		if err != nil {
+			return err
+		}
+		if old.Status.Decision != "" || request.Status.Decision == "" {
+			return nil
+		}
+		request.Status.DecidedBy = a.GetUserInfo().GetName()
+	}
+
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(request)
+	if err != nil {
+		return err
+	}
+	u.Object = raw
+
+	return nil
+}
+
+// Validate ensures that the spec is immutable, that only the controller sets the expiration time, and that the decision is set once by a user with
+// admin access to the requested workspace other than the requesting user.
+func (o *accessRequest) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("accessrequests") {
+		return nil
+	}
+
+	_, request, err := accessRequestFrom(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	if a.GetOperation() == admission.Create {
+		if request.Spec.User != a.GetUserInfo().GetName() {
+			return admission.NewForbidden(a, errors.New("spec.user must be the requesting user"))
+		}
+		return nil
+	}
+
+	_, old, err := accessRequestFrom(a.GetOldObject())
+	if err != nil {
+		return err
+	}
+
+	if !equality.Semantic.DeepEqual(old.Spec, request.Spec) {
+		return admission.NewForbidden(a, errors.New("spec is immutable"))
+	}
+	if !equality.Semantic.DeepEqual(old.Status.ExpirationTime, request.Status.ExpirationTime) {
+		if old.Status.ExpirationTime != nil {
+			return admission.NewForbidden(a, errors.New("status.expirationTime is immutable once set"))
+		}
+		// the controller computes the expiration time from spec.ttl when access is granted
+		if !sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup) {
+			return admission.NewForbidden(a, errors.New("status.expirationTime is set by the controller only"))
+		}
+	}
+
+	if old.Status.Decision == request.Status.Decision {
+		if old.Status.DecidedBy != request.Status.DecidedBy {
+			return admission.NewForbidden(a, errors.New("status.decidedBy is set on decision only"))
+		}
+		return nil
+	}
+
+	if old.Status.Decision != "" {
+		return admission.NewForbidden(a, fmt.Errorf("request has already been %s", old.Status.Decision))
+	}
+	if request.Status.DecidedBy != a.GetUserInfo().GetName() {
+		return admission.NewForbidden(a, errors.New("status.decidedBy must be the deciding user"))
+	}
+	if request.Spec.User == a.GetUserInfo().GetName() {
+		return admission.NewForbidden(a, errors.New("users cannot decide about their own requests"))
+	}
+
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error determining workspace: %w", err))
+	}
+	if err := o.checkWorkspaceAdmin(ctx, a.GetUserInfo(), cluster.Name, request.Spec.Workspace); err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("unable to decide about the request: %w", err))
+	}
+
+	return nil
+}
+
+func (o *accessRequest) checkWorkspaceAdmin(ctx context.Context, user user.Info, clusterName logicalcluster.LogicalCluster, workspace string) error {
+	authz, err := o.createAuthorizer(clusterName, o.kubeClusterClient)
+	if err != nil {
+		// Logging a more specific error for the operator
+		klog.Errorf("error creating authorizer from delegating authorizer config: %v", err)
+		// Returning a less specific error to the end user
+		return errors.New("unable to authorize request")
+	}
+
+	adminAttr := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            "admin",
+		APIGroup:        tenancyv1alpha1.SchemeGroupVersion.Group,
+		APIVersion:      tenancyv1alpha1.SchemeGroupVersion.Version,
+		Resource:        "clusterworkspaces",
+		Subresource:     "content",
+		Name:            workspace,
+		ResourceRequest: true,
+	}
+
+	if decision, _, err := authz.Authorize(ctx, adminAttr); err != nil {
+		return fmt.Errorf("unable to determine access to workspace %q: %w", workspace, err)
+	} else if decision != authorizer.DecisionAllow {
+		return fmt.Errorf("missing verb='admin' permission on clusterworkspaces/content of %q", workspace)
+	}
+
+	return nil
+}
+
+func accessRequestFrom(obj runtime.Object) (*unstructured.Unstructured, *tenancyv1alpha1.AccessRequest, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	request := &tenancyv1alpha1.AccessRequest{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, request); err != nil {
+		return nil, nil, fmt.Errorf("failed to convert unstructured to AccessRequest: %w", err)
+	}
+	return u, request, nil
+}
+
+// ValidateInitialization ensures the required injected fields are set.
+func (o *accessRequest) ValidateInitialization() error {
+	if o.kubeClusterClient == nil {
+		return fmt.Errorf(PluginName + " plugin needs a Kubernetes ClusterInterface")
+	}
+
+	return nil
+}
+
+// SetKubeClusterClient is an admission plugin initializer function that injects a Kubernetes cluster client into
+// this admission plugin.
+func (o *accessRequest) SetKubeClusterClient(clusterClient *kubernetes.Cluster) {
+	o.kubeClusterClient = clusterClient
+}
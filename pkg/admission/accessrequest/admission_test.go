@@ -0,0 +1,280 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessrequest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func createAttr(obj *tenancyv1alpha1.AccessRequest, userName string) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		nil,
+		tenancyv1alpha1.Kind("AccessRequest").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("accessrequests").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{Name: userName},
+	)
+}
+
+func updateStatusAttr(obj, old *tenancyv1alpha1.AccessRequest, userName string) admission.Attributes {
+	return updateStatusAttrAs(obj, old, &user.DefaultInfo{Name: userName})
+}
+
+func updateStatusAttrAs(obj, old *tenancyv1alpha1.AccessRequest, userInfo user.Info) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		helpers.ToUnstructuredOrDie(old),
+		tenancyv1alpha1.Kind("AccessRequest").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("accessrequests").WithVersion("v1alpha1"),
+		"status",
+		admission.Update,
+		&metav1.UpdateOptions{},
+		false,
+		userInfo,
+	)
+}
+
+func newAccessRequest() *tenancyv1alpha1.AccessRequest {
+	return &tenancyv1alpha1.AccessRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "request"},
+		Spec: tenancyv1alpha1.AccessRequestSpec{
+			Workspace: "team",
+			Role:      tenancyv1alpha1.AccessRequestRoleAccess,
+			User:      "alice",
+		},
+	}
+}
+
+func withDecision(r *tenancyv1alpha1.AccessRequest, decision tenancyv1alpha1.AccessRequestDecision, decidedBy string) *tenancyv1alpha1.AccessRequest {
+	r = r.DeepCopy()
+	r.Status.Decision = decision
+	r.Status.DecidedBy = decidedBy
+	return r
+}
+
+func TestAdmit(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        admission.Attributes
+		expected *tenancyv1alpha1.AccessRequest
+	}{
+		{
+			name: "sets the requesting user and clears the status on create",
+			a: createAttr(func() *tenancyv1alpha1.AccessRequest {
+				r := withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "alice")
+				r.Spec.User = "bob"
+				return r
+			}(), "alice"),
+			expected: newAccessRequest(),
+		},
+		{
+			name:     "sets the deciding user on decision",
+			a:        updateStatusAttr(withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "mallory"), newAccessRequest(), "bob"),
+			expected: withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"),
+		},
+		{
+			name: "keeps the deciding user after decision",
+			a: updateStatusAttr(
+				withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"),
+				withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"),
+				"system:kcp:controller"),
+			expected: withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &accessRequest{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
+			err := o.Admit(ctx, tt.a, nil)
+			require.NoError(t, err)
+
+			got := &tenancyv1alpha1.AccessRequest{}
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(tt.a.GetObject().(*unstructured.Unstructured).Object, got)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected.Spec, got.Spec)
+			require.Equal(t, tt.expected.Status, got.Status)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	expiration := metav1.NewTime(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	granted := withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob")
+	granted.Status.Phase = tenancyv1alpha1.AccessRequestPhaseGranted
+	granted.Status.ExpirationTime = &expiration
+
+	tests := []struct {
+		name          string
+		a             admission.Attributes
+		authzDecision authorizer.Decision
+		authzError    error
+		wantErr       bool
+	}{
+		{
+			name: "creates a request for the requesting user",
+			a:    createAttr(newAccessRequest(), "alice"),
+		},
+		{
+			name:    "rejects a request for another user",
+			a:       createAttr(newAccessRequest(), "bob"),
+			wantErr: true,
+		},
+		{
+			name: "rejects spec changes",
+			a: updateStatusAttr(func() *tenancyv1alpha1.AccessRequest {
+				r := newAccessRequest()
+				r.Spec.Role = tenancyv1alpha1.AccessRequestRoleAdmin
+				return r
+			}(), newAccessRequest(), "alice"),
+			wantErr: true,
+		},
+		{
+			name:          "approves as workspace admin",
+			a:             updateStatusAttr(withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"), newAccessRequest(), "bob"),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:          "denies as workspace admin",
+			a:             updateStatusAttr(withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestDenied, "bob"), newAccessRequest(), "bob"),
+			authzDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:          "rejects a decision without workspace admin access",
+			a:             updateStatusAttr(withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"), newAccessRequest(), "bob"),
+			authzDecision: authorizer.DecisionNoOpinion,
+			wantErr:       true,
+		},
+		{
+			name:          "rejects a decision on authorization errors",
+			a:             updateStatusAttr(withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"), newAccessRequest(), "bob"),
+			authzDecision: authorizer.DecisionAllow,
+			authzError:    errors.New("some error"),
+			wantErr:       true,
+		},
+		{
+			name:          "rejects a decision about the own request",
+			a:             updateStatusAttr(withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "alice"), newAccessRequest(), "alice"),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       true,
+		},
+		{
+			name:          "rejects a decision on behalf of another user",
+			a:             updateStatusAttr(withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "carol"), newAccessRequest(), "bob"),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       true,
+		},
+		{
+			name: "rejects changing the decision",
+			a: updateStatusAttr(
+				withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestDenied, "bob"),
+				withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"),
+				"bob"),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       true,
+		},
+		{
+			name: "rejects changing the deciding user",
+			a: updateStatusAttr(
+				withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "carol"),
+				withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"),
+				"carol"),
+			wantErr: true,
+		},
+		{
+			name: "updates the phase after decision",
+			a: updateStatusAttrAs(granted, withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob"),
+				&user.DefaultInfo{Name: "system:kcp:controller", Groups: []string{user.SystemPrivilegedGroup}}),
+		},
+		{
+			name: "rejects setting the expiration time as a user",
+			a: updateStatusAttr(func() *tenancyv1alpha1.AccessRequest {
+				r := withDecision(newAccessRequest(), tenancyv1alpha1.AccessRequestApproved, "bob")
+				r.Status.ExpirationTime = &expiration
+				return r
+			}(), newAccessRequest(), "bob"),
+			authzDecision: authorizer.DecisionAllow,
+			wantErr:       true,
+		},
+		{
+			name: "rejects changing the expiration time",
+			a: updateStatusAttr(func() *tenancyv1alpha1.AccessRequest {
+				r := granted.DeepCopy()
+				r.Status.ExpirationTime = nil
+				return r
+			}(), granted, "alice"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &accessRequest{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				createAuthorizer: func(clusterName logicalcluster.LogicalCluster, client kubernetes.ClusterInterface) (authorizer.Authorizer, error) {
+					require.Equal(t, "root:org", clusterName.String())
+					return &fakeAuthorizer{
+						tt.authzDecision,
+						tt.authzError,
+					}, nil
+				},
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
+			err := o.Validate(ctx, tt.a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+type fakeAuthorizer struct {
+	authorized authorizer.Decision
+	err        error
+}
+
+func (a *fakeAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorized authorizer.Decision, reason string, err error) {
+	return a.authorized, "reason", a.err
+}
@@ -95,6 +95,21 @@ func TestValidate(t *testing.T) {
 			),
 			expectedErrors: []string{"spec.reference.exportName: Required value"},
 		},
+		{
+			name: "Create: rename conflict policy without prefix fails",
+			attr: createAttr(
+				newAPIBinding().withName("test").withWorkspaceReference("workspaceName", "someExport").withConflictPolicy(apisv1alpha1.APIBindingConflictPolicyRenameWithPrefix, "").APIBinding,
+			),
+			authzDecision:  authorizer.DecisionAllow,
+			expectedErrors: []string{"spec.resourcePrefix: Required value"},
+		},
+		{
+			name: "Create: rename conflict policy with prefix passes",
+			attr: createAttr(
+				newAPIBinding().withName("test").withWorkspaceReference("workspaceName", "someExport").withConflictPolicy(apisv1alpha1.APIBindingConflictPolicyRenameWithPrefix, "acme").APIBinding,
+			),
+			authzDecision: authorizer.DecisionAllow,
+		},
 		{
 			name: "Create: complete workspace reference passes when authorized",
 			attr: createAttr(
@@ -303,6 +318,12 @@ func (b *bindingBuilder) withWorkspaceReference(workspaceName, exportName string
 	return b
 }
 
+func (b *bindingBuilder) withConflictPolicy(policy apisv1alpha1.APIBindingConflictPolicy, prefix string) *bindingBuilder {
+	b.Spec.ConflictPolicy = policy
+	b.Spec.ResourcePrefix = prefix
+	return b
+}
+
 func (b *bindingBuilder) withPhase(phase apisv1alpha1.APIBindingPhaseType) *bindingBuilder {
 	b.Status.Phase = phase
 	return b
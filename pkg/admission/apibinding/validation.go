@@ -30,6 +30,10 @@ func ValidateAPIBinding(apiBinding *apisv1alpha1.APIBinding) field.ErrorList {
 
 	allErrs = append(allErrs, ValidateAPIBindingReference(apiBinding.Spec.Reference, field.NewPath("spec", "reference"))...)
 
+	if apiBinding.Spec.ConflictPolicy == apisv1alpha1.APIBindingConflictPolicyRenameWithPrefix && apiBinding.Spec.ResourcePrefix == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("spec", "resourcePrefix"), "required for the RenameWithPrefix conflict policy"))
+	}
+
 	return allErrs
 }
 
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// Protect the identity of APIExports in their status from changes by users outside of system:masters.
+// The identity controller derives it from the identity secret. Wildcard requests for
+// <resource>:<identityHash> trust it, i.e. an owner of an APIExport writing the identity of another
+// APIExport would see the objects of the other APIExport.
+
+const (
+	PluginName = "apis.kcp.dev/APIExport"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &apiExport{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+type apiExport struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&apiExport{})
+
+// Validate ensures that only privileged users set status.identityHash and status.previousIdentityHashes.
+func (o *apiExport) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != apisv1alpha1.Resource("apiexports") || isPrivileged(a.GetUserInfo()) {
+		return nil
+	}
+
+	export, err := apiExportFrom(a.GetObject())
+	if err != nil {
+		return err
+	}
+	old := &apisv1alpha1.APIExport{}
+	if a.GetOperation() == admission.Update {
+		if old, err = apiExportFrom(a.GetOldObject()); err != nil {
+			return err
+		}
+	}
+
+	if export.Status.IdentityHash != old.Status.IdentityHash {
+		return admission.NewForbidden(a, errors.New("status.identityHash is set by kcp only"))
+	}
+	if !equality.Semantic.DeepEqual(export.Status.PreviousIdentityHashes, old.Status.PreviousIdentityHashes) {
+		return admission.NewForbidden(a, errors.New("status.previousIdentityHashes is set by kcp only"))
+	}
+	return nil
+}
+
+func apiExportFrom(obj runtime.Object) (*apisv1alpha1.APIExport, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	export := &apisv1alpha1.APIExport{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, export); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to APIExport: %w", err)
+	}
+	return export, nil
+}
+
+func isPrivileged(userInfo user.Info) bool {
+	if userInfo == nil {
+		return false
+	}
+	for _, group := range userInfo.GetGroups() {
+		if group == user.SystemPrivilegedGroup {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func newAttr(obj, old *apisv1alpha1.APIExport, subresource string, userInfo user.Info) admission.Attributes {
+	op := admission.Create
+	var oldObj runtime.Object
+	if old != nil {
+		op = admission.Update
+		oldObj = helpers.ToUnstructuredOrDie(old)
+	}
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		oldObj,
+		apisv1alpha1.Kind("APIExport").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		apisv1alpha1.Resource("apiexports").WithVersion("v1alpha1"),
+		subresource,
+		op,
+		nil,
+		false,
+		userInfo,
+	)
+}
+
+func TestValidate(t *testing.T) {
+	unprivileged := &user.DefaultInfo{Name: "user", Groups: []string{"system:authenticated"}}
+	controller := &user.DefaultInfo{Name: "system:apiserver", Groups: []string{user.SystemPrivilegedGroup}}
+
+	export := func(identityHash string, previous ...string) *apisv1alpha1.APIExport {
+		e := &apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "cowboys"},
+			Status:     apisv1alpha1.APIExportStatus{IdentityHash: identityHash},
+		}
+		for _, hash := range previous {
+			e.Status.PreviousIdentityHashes = append(e.Status.PreviousIdentityHashes, apisv1alpha1.APIExportPreviousIdentity{
+				IdentityHash: hash,
+				ValidUntil:   metav1.NewTime(time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)),
+			})
+		}
+		return e
+	}
+
+	tests := []struct {
+		name    string
+		a       admission.Attributes
+		wantErr bool
+	}{
+		{
+			name: "creates an APIExport without identity",
+			a:    newAttr(export(""), nil, "", unprivileged),
+		},
+		{
+			name:    "rejects creating an APIExport with identity",
+			a:       newAttr(export("other"), nil, "", unprivileged),
+			wantErr: true,
+		},
+		{
+			name: "updates the status keeping the identity",
+			a:    newAttr(export("hash", "previous"), export("hash", "previous"), "status", unprivileged),
+		},
+		{
+			name:    "rejects changing the identity",
+			a:       newAttr(export("other"), export("hash"), "status", unprivileged),
+			wantErr: true,
+		},
+		{
+			name:    "rejects adding a previous identity",
+			a:       newAttr(export("hash", "other"), export("hash"), "status", unprivileged),
+			wantErr: true,
+		},
+		{
+			name: "rotates the identity as controller",
+			a:    newAttr(export("new", "hash"), export("hash"), "status", controller),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &apiExport{Handler: admission.NewHandler(admission.Create, admission.Update)}
+			err := o.Validate(context.Background(), tt.a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
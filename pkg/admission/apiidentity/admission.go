@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apiidentity
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+// Label objects of resources bound through an APIBinding with the identity hash of the APIExport,
+// and remove the label from all other objects. Wildcard requests for <resource>:<identityHash>
+// select on this label, hence it must not be settable by users.
+
+const (
+	PluginName = "apis.kcp.dev/APIIdentity"
+
+	indexAPIBindingsByWorkspace = "apiIdentity-apiBindingsByWorkspace"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &apiIdentity{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+type apiIdentity struct {
+	*admission.Handler
+
+	apiBindingIndexer cache.Indexer
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.MutationInterface(&apiIdentity{})
+var _ = admission.InitializationValidator(&apiIdentity{})
+var _ = kcpinitializers.WantsKcpInformers(&apiIdentity{})
+
+// Admit sets or removes the identity label.
+func (o *apiIdentity) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	obj, err := meta.Accessor(a.GetObject())
+	if err != nil {
+		// not an object with metadata, e.g. a status or options object
+		return nil
+	}
+
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error determining workspace: %w", err))
+	}
+
+	identityHash, err := o.boundIdentityHash(cluster.Name, a.GetResource().GroupResource())
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+
+	objLabels := obj.GetLabels()
+	if identityHash == "" {
+		if _, found := objLabels[apisv1alpha1.IdentityHashLabelKey]; found {
+			delete(objLabels, apisv1alpha1.IdentityHashLabelKey)
+			obj.SetLabels(objLabels)
+		}
+		return nil
+	}
+
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	objLabels[apisv1alpha1.IdentityHashLabelKey] = identityHash
+	obj.SetLabels(objLabels)
+
+	return nil
+}
+
+// boundIdentityHash returns the identity hash of the APIExport the resource is bound from in the
+// given logical cluster, or an empty string if it is not bound.
+func (o *apiIdentity) boundIdentityHash(clusterName logicalcluster.LogicalCluster, gr schema.GroupResource) (string, error) {
+	apiBindings, err := o.apiBindingIndexer.ByIndex(indexAPIBindingsByWorkspace, clusterName.String())
+	if err != nil {
+		return "", err
+	}
+
+	for _, obj := range apiBindings {
+		apiBinding := obj.(*apisv1alpha1.APIBinding)
+		for _, boundResource := range apiBinding.Status.BoundResources {
+			if boundResource.Group == gr.Group && boundResource.Resource == gr.Resource {
+				return boundResource.IdentityHash, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func (o *apiIdentity) ValidateInitialization() error {
+	if o.apiBindingIndexer == nil {
+		return fmt.Errorf(PluginName + " plugin needs an APIBinding indexer")
+	}
+	return nil
+}
+
+func (o *apiIdentity) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	apiBindingInformer := informers.Apis().V1alpha1().APIBindings().Informer()
+	// the plugin is initialized for every admission chain sharing the informers
+	if _, found := apiBindingInformer.GetIndexer().GetIndexers()[indexAPIBindingsByWorkspace]; !found {
+		if err := apiBindingInformer.AddIndexers(cache.Indexers{
+			indexAPIBindingsByWorkspace: indexAPIBindingByWorkspace,
+		}); err != nil {
+			// fails initialization validation
+			utilruntime.HandleError(fmt.Errorf("%s plugin failed to add APIBinding index: %w", PluginName, err))
+			return
+		}
+	}
+	o.SetReadyFunc(apiBindingInformer.HasSynced)
+	o.apiBindingIndexer = apiBindingInformer.GetIndexer()
+}
+
+func indexAPIBindingByWorkspace(obj interface{}) ([]string, error) {
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be an APIBinding, but is %T", obj)
+	}
+	return []string{logicalcluster.From(apiBinding).String()}, nil
+}
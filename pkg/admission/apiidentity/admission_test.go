@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apiidentity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func createAttr(obj runtime.Object, resource schema.GroupVersionResource, subresource string) admission.Attributes {
+	return admission.NewAttributesRecord(
+		obj,
+		nil,
+		schema.GroupVersionKind{},
+		"default",
+		"test",
+		resource,
+		subresource,
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func widget(objLabels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("example.dev/v1")
+	u.SetKind("Widget")
+	u.SetName("test")
+	u.SetLabels(objLabels)
+	return u
+}
+
+func TestAdmit(t *testing.T) {
+	widgets := schema.GroupVersionResource{Group: "example.dev", Version: "v1", Resource: "widgets"}
+	binding := func(clusterName, identityHash string) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{ClusterName: clusterName, Name: "widgets"},
+			Status: apisv1alpha1.APIBindingStatus{
+				BoundResources: []apisv1alpha1.BoundAPIResource{
+					{Group: "example.dev", Resource: "widgets", IdentityHash: identityHash},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		bindings   []*apisv1alpha1.APIBinding
+		attr       admission.Attributes
+		wantLabels map[string]string
+	}{
+		{
+			name:       "bound resource gets labeled",
+			bindings:   []*apisv1alpha1.APIBinding{binding("root:org:ws", "abc")},
+			attr:       createAttr(widget(map[string]string{"a": "b"}), widgets, ""),
+			wantLabels: map[string]string{"a": "b", apisv1alpha1.IdentityHashLabelKey: "abc"},
+		},
+		{
+			name:       "forged label of bound resource is overwritten",
+			bindings:   []*apisv1alpha1.APIBinding{binding("root:org:ws", "abc")},
+			attr:       createAttr(widget(map[string]string{apisv1alpha1.IdentityHashLabelKey: "forged"}), widgets, ""),
+			wantLabels: map[string]string{apisv1alpha1.IdentityHashLabelKey: "abc"},
+		},
+		{
+			name:       "binding in another workspace is ignored",
+			bindings:   []*apisv1alpha1.APIBinding{binding("root:org:other", "abc")},
+			attr:       createAttr(widget(map[string]string{apisv1alpha1.IdentityHashLabelKey: "abc"}), widgets, ""),
+			wantLabels: map[string]string{},
+		},
+		{
+			name:       "label is removed from a resource that is not bound",
+			attr:       createAttr(widget(map[string]string{"a": "b", apisv1alpha1.IdentityHashLabelKey: "abc"}), widgets, ""),
+			wantLabels: map[string]string{"a": "b"},
+		},
+		{
+			name: "label is removed from native resources",
+			attr: createAttr(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{apisv1alpha1.IdentityHashLabelKey: "abc"}}},
+				schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, ""),
+			wantLabels: map[string]string{},
+		},
+		{
+			name:       "subresources are ignored",
+			bindings:   []*apisv1alpha1.APIBinding{binding("root:org:ws", "abc")},
+			attr:       createAttr(widget(map[string]string{"a": "b"}), widgets, "status"),
+			wantLabels: map[string]string{"a": "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &apiIdentity{
+				Handler:           admission.NewHandler(admission.Create, admission.Update),
+				apiBindingIndexer: apiBindingIndexer(t, tt.bindings...),
+			}
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org:ws")})
+			err := o.Admit(ctx, tt.attr, nil)
+			require.NoError(t, err)
+
+			var got map[string]string
+			switch obj := tt.attr.GetObject().(type) {
+			case *unstructured.Unstructured:
+				got = obj.GetLabels()
+			case *corev1.ConfigMap:
+				got = obj.Labels
+			}
+			if got == nil {
+				got = map[string]string{}
+			}
+			require.Equal(t, tt.wantLabels, got)
+		})
+	}
+}
+
+func apiBindingIndexer(t *testing.T, bindings ...*apisv1alpha1.APIBinding) cache.Indexer {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexAPIBindingsByWorkspace: indexAPIBindingByWorkspace})
+	for _, binding := range bindings {
+		require.NoError(t, indexer.Add(binding))
+	}
+	return indexer
+}
@@ -28,6 +28,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/features"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 )
@@ -126,12 +128,31 @@ func ValidateAPIResourceSchemaSpec(spec *apisv1alpha1.APIResourceSchemaSpec, fld
 		allErrs = append(allErrs, crdvalidation.ValidateCustomResourceDefinitionNames(&crdNames, fldPath.Child("names"))...)
 	}
 
+	if spec.Conversion != nil {
+		allErrs = append(allErrs, ValidateAPIResourceConversion(spec.Conversion, fldPath.Child("conversion"))...)
+	}
+
 	// TODO(sttts): validate predecessors
-	// TODO(sttts): validate conversions
 
 	return allErrs
 }
 
+// ValidateAPIResourceConversion validates the conversion of an APIResourceSchema like the conversion of a CRD. In
+// addition, webhooks must be given by url, because a service would have to be resolved in every workspace binding
+// the resource.
+func ValidateAPIResourceConversion(conversion *apiextensionsv1.CustomResourceConversion, fldPath *field.Path) field.ErrorList {
+	var crdConversion apiextensionsinternal.CustomResourceConversion
+	if err := apiextensionsv1.Convert_v1_CustomResourceConversion_To_apiextensions_CustomResourceConversion(conversion, &crdConversion, nil); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, conversion, err.Error())}
+	}
+
+	allErrs := crdvalidation.ValidateCustomResourceConversion(&crdConversion, fldPath)
+	if conversion.Webhook != nil && conversion.Webhook.ClientConfig != nil && conversion.Webhook.ClientConfig.Service != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("webhook", "clientConfig", "service"), "conversion webhooks must be given by url"))
+	}
+	return allErrs
+}
+
 var defaultValidationOpts = crdvalidation.ValidationOptions{
 	AllowDefaults:                            true,
 	RequireRecognizedConversionReviewVersion: true,
@@ -167,6 +188,12 @@ func ValidateAPIResourceVersion(version *apisv1alpha1.APIResourceVersion, fldPat
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("schema"), string(version.Schema.Raw), fmt.Sprintf("invalid schema: %v", err)))
 		} else {
 			allErrs = append(allErrs, crdvalidation.ValidateCustomResourceDefinitionValidation(&crdSchemaInternal, statusEnabled, defaultValidationOpts, fldPath.Child("schema"))...)
+
+			// bound CRDs drop validation rules when the feature gate is off. As schemas are immutable,
+			// reject them instead of silently not enforcing them in the consumer workspaces.
+			if !utilfeature.DefaultFeatureGate.Enabled(features.CustomResourceValidationExpressions) && hasXValidations(crdSchemaInternal.OpenAPIV3Schema) {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("schema"), fmt.Sprintf("x-kubernetes-validations requires the %s feature gate", features.CustomResourceValidationExpressions)))
+			}
 		}
 	}
 
@@ -191,6 +218,43 @@ func ValidateAPIResourceVersion(version *apisv1alpha1.APIResourceVersion, fldPat
 	return allErrs
 }
 
+// hasXValidations returns whether the schema or any of its sub-schemas has x-kubernetes-validations rules.
+func hasXValidations(s *apiextensionsinternal.JSONSchemaProps) bool {
+	if s == nil {
+		return false
+	}
+	if len(s.XValidations) > 0 {
+		return true
+	}
+	for name := range s.Properties {
+		prop := s.Properties[name]
+		if hasXValidations(&prop) {
+			return true
+		}
+	}
+	if s.AdditionalProperties != nil && hasXValidations(s.AdditionalProperties.Schema) {
+		return true
+	}
+	if s.Items != nil {
+		if hasXValidations(s.Items.Schema) {
+			return true
+		}
+		for i := range s.Items.JSONSchemas {
+			if hasXValidations(&s.Items.JSONSchemas[i]) {
+				return true
+			}
+		}
+	}
+	for _, schemas := range [][]apiextensionsinternal.JSONSchemaProps{s.AllOf, s.AnyOf, s.OneOf} {
+		for i := range schemas {
+			if hasXValidations(&schemas[i]) {
+				return true
+			}
+		}
+	}
+	return hasXValidations(s.Not)
+}
+
 // ValidateAPIResourceSchemaUpdate validates an APIResourceSchema on update.
 func ValidateAPIResourceSchemaUpdate(s, old *apisv1alpha1.APIResourceSchema) field.ErrorList {
 	allErrs := ValidateAPIResourceSchema(s)
@@ -19,6 +19,18 @@ package apiresourceschema
 import (
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/features"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+	"k8s.io/utils/pointer"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 )
 
 func TestValidationOptionDrift(t *testing.T) {
@@ -45,3 +57,108 @@ func TestValidationOptionDrift(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateAPIResourceVersionValidationRules(t *testing.T) {
+	tests := map[string]struct {
+		schema      string
+		gateEnabled bool
+		wantErrs    []string
+	}{
+		"no rules": {
+			schema: `{"type":"object","properties":{"spec":{"type":"object","properties":{"replicas":{"type":"integer","default":1}}}}}`,
+		},
+		"valid rule": {
+			schema:      `{"type":"object","properties":{"spec":{"type":"object","x-kubernetes-validations":[{"rule":"self.minReplicas <= self.maxReplicas"}],"properties":{"minReplicas":{"type":"integer"},"maxReplicas":{"type":"integer"}}}}}`,
+			gateEnabled: true,
+		},
+		"rule referencing an unknown field": {
+			schema:      `{"type":"object","properties":{"spec":{"type":"object","x-kubernetes-validations":[{"rule":"self.unknown > 0"}],"properties":{"replicas":{"type":"integer"}}}}}`,
+			gateEnabled: true,
+			wantErrs:    []string{"schema.openAPIV3Schema.properties[spec].x-kubernetes-validations[0].rule"},
+		},
+		"rule with feature gate disabled": {
+			schema:   `{"type":"object","properties":{"spec":{"type":"object","x-kubernetes-validations":[{"rule":"self.minReplicas <= self.maxReplicas"}],"properties":{"minReplicas":{"type":"integer"},"maxReplicas":{"type":"integer"}}}}}`,
+			wantErrs: []string{"schema"},
+		},
+		"nested rule with feature gate disabled": {
+			schema:   `{"type":"object","properties":{"spec":{"type":"object","properties":{"ports":{"type":"array","items":{"type":"integer","x-kubernetes-validations":[{"rule":"self > 0"}]}}}}}}`,
+			wantErrs: []string{"schema"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.CustomResourceValidationExpressions, tc.gateEnabled)()
+
+			errs := ValidateAPIResourceVersion(&apisv1alpha1.APIResourceVersion{
+				Name:    "v1",
+				Served:  true,
+				Storage: true,
+				Schema:  runtime.RawExtension{Raw: []byte(tc.schema)},
+			}, nil)
+
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			require.Equal(t, tc.wantErrs, fields, "errors: %v", errs)
+		})
+	}
+}
+
+func TestValidateAPIResourceConversion(t *testing.T) {
+	tests := map[string]struct {
+		conversion *apiextensionsv1.CustomResourceConversion
+		wantErrs   []string
+	}{
+		"none": {
+			conversion: &apiextensionsv1.CustomResourceConversion{Strategy: apiextensionsv1.NoneConverter},
+		},
+		"webhook url": {
+			conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ClientConfig:             &apiextensionsv1.WebhookClientConfig{URL: pointer.StringPtr("https://widgets.example.com/convert")},
+					ConversionReviewVersions: []string{"v1"},
+				},
+			},
+		},
+		"webhook service": {
+			conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ClientConfig:             &apiextensionsv1.WebhookClientConfig{Service: &apiextensionsv1.ServiceReference{Namespace: "widgets", Name: "converter", Port: pointer.Int32Ptr(443)}},
+					ConversionReviewVersions: []string{"v1"},
+				},
+			},
+			wantErrs: []string{"spec.conversion.webhook.clientConfig.service"},
+		},
+		"webhook without client config": {
+			conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook:  &apiextensionsv1.WebhookConversion{ConversionReviewVersions: []string{"v1"}},
+			},
+			wantErrs: []string{"spec.conversion.webhookClientConfig"},
+		},
+		"webhook with http url": {
+			conversion: &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.WebhookConverter,
+				Webhook: &apiextensionsv1.WebhookConversion{
+					ClientConfig:             &apiextensionsv1.WebhookClientConfig{URL: pointer.StringPtr("http://widgets.example.com/convert")},
+					ConversionReviewVersions: []string{"v1"},
+				},
+			},
+			wantErrs: []string{"spec.conversion.webhookClientConfig.url"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			errs := ValidateAPIResourceConversion(tc.conversion, field.NewPath("spec", "conversion"))
+
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			require.Equal(t, tc.wantErrs, fields, "errors: %v", errs)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacedryrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacescheduler"
+)
+
+// Report the effects of a ClusterWorkspace creation on a server-side dry-run:
+// - the shard picked by the workspace scheduler plugins is set in the
+//   tenancy.kcp.dev/dry-run-shard annotation. The dry-run is rejected if no shard fits.
+// - the initializers, set by the ClusterWorkspaceTypeExists plugin running before, are
+//   kept in the tenancy.kcp.dev/dry-run-initializers annotation.
+//
+// Both annotations are removed on creations which are not a dry-run.
+
+const (
+	PluginName = "tenancy.kcp.dev/ClusterWorkspaceDryRun"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &clusterWorkspaceDryRun{
+				Handler: admission.NewHandler(admission.Create),
+			}, nil
+		})
+}
+
+type clusterWorkspaceDryRun struct {
+	*admission.Handler
+
+	workspaceLister tenancylister.ClusterWorkspaceLister
+	shardLister     tenancylister.ClusterWorkspaceShardLister
+	schedulerNames  []string
+	scheduler       *workspacescheduler.Scheduler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.MutationInterface(&clusterWorkspaceDryRun{})
+var _ = admission.InitializationValidator(&clusterWorkspaceDryRun{})
+var _ = kcpinitializers.WantsKcpInformers(&clusterWorkspaceDryRun{})
+var _ = kcpinitializers.WantsRootKcpInformers(&clusterWorkspaceDryRun{})
+var _ = kcpinitializers.WantsWorkspaceSchedulerPlugins(&clusterWorkspaceDryRun{})
+var _ = workspacescheduler.Handle(&clusterWorkspaceDryRun{})
+
+// Admit schedules the workspace on a dry-run create and records the shard in an annotation.
+func (o *clusterWorkspaceDryRun) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaces") {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+
+	if !a.IsDryRun() {
+		annotations := u.GetAnnotations()
+		_, foundShard := annotations[tenancyv1alpha1.DryRunShardAnnotationKey]
+		_, foundInitializers := annotations[tenancyv1alpha1.DryRunInitializersAnnotationKey]
+		if foundShard || foundInitializers {
+			delete(annotations, tenancyv1alpha1.DryRunShardAnnotationKey)
+			delete(annotations, tenancyv1alpha1.DryRunInitializersAnnotationKey)
+			u.SetAnnotations(annotations)
+		}
+		return nil
+	}
+
+	cw := &tenancyv1alpha1.ClusterWorkspace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, cw); err != nil {
+		return fmt.Errorf("failed to convert unstructured to ClusterWorkspace: %w", err)
+	}
+
+	if !o.WaitForReady() {
+		return admission.NewForbidden(a, fmt.Errorf("not yet ready to handle request"))
+	}
+
+	shards, err := o.shardLister.List(labels.Everything())
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	shard, rejected, err := o.scheduler.Schedule(ctx, cw, shards)
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	if shard == nil {
+		return admission.NewForbidden(a, fmt.Errorf("no available shards to schedule the workspace%s", formatRejected(rejected)))
+	}
+
+	annotations := u.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[tenancyv1alpha1.DryRunShardAnnotationKey] = shard.Name
+	u.SetAnnotations(annotations)
+
+	return nil
+}
+
+// formatRejected returns the reasons of the scheduler plugins for the rejected shards, sorted by shard name.
+func formatRejected(rejected map[string]*workspacescheduler.Status) string {
+	if len(rejected) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(rejected))
+	for name := range rejected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reasons := make([]string, 0, len(names))
+	for _, name := range names {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", name, rejected[name].Message))
+	}
+	return ": " + strings.Join(reasons, ", ")
+}
+
+func (o *clusterWorkspaceDryRun) ValidateInitialization() error {
+	if o.workspaceLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspace lister")
+	}
+	if o.shardLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspaceShard lister")
+	}
+	scheduler, err := workspacescheduler.New(o, o.schedulerNames)
+	if err != nil {
+		return fmt.Errorf(PluginName+" plugin failed to create the workspace scheduler: %w", err)
+	}
+	o.scheduler = scheduler
+	return nil
+}
+
+func (o *clusterWorkspaceDryRun) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	o.workspaceLister = informers.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+}
+
+func (o *clusterWorkspaceDryRun) SetRootKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	o.SetReadyFunc(informers.Tenancy().V1alpha1().ClusterWorkspaceShards().Informer().HasSynced)
+	o.shardLister = informers.Tenancy().V1alpha1().ClusterWorkspaceShards().Lister()
+}
+
+func (o *clusterWorkspaceDryRun) SetWorkspaceSchedulerPlugins(names []string) {
+	o.schedulerNames = names
+}
+
+func (o *clusterWorkspaceDryRun) ClusterWorkspaceLister() tenancylister.ClusterWorkspaceLister {
+	return o.workspaceLister
+}
+
+func (o *clusterWorkspaceDryRun) ClusterWorkspaceShardLister() tenancylister.ClusterWorkspaceShardLister {
+	return o.shardLister
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacedryrun
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacescheduler"
+)
+
+func createAttr(obj *tenancyv1alpha1.ClusterWorkspace, dryRun bool) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		nil,
+		tenancyv1alpha1.Kind("ClusterWorkspace").WithVersion("v1alpha1"),
+		"",
+		"test",
+		tenancyv1alpha1.Resource("clusterworkspaces").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		dryRun,
+		&user.DefaultInfo{},
+	)
+}
+
+func newShard(name string, unschedulable bool) *tenancyv1alpha1.ClusterWorkspaceShard {
+	return &tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: "root"},
+		Spec:       tenancyv1alpha1.ClusterWorkspaceShardSpec{Unschedulable: unschedulable},
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	tests := []struct {
+		name            string
+		shards          []*tenancyv1alpha1.ClusterWorkspaceShard
+		annotations     map[string]string
+		dryRun          bool
+		wantErr         string
+		wantAnnotations map[string]string
+	}{
+		{
+			name:            "dry-run reports the shard passing the filters",
+			shards:          []*tenancyv1alpha1.ClusterWorkspaceShard{newShard("cordoned", true), newShard("open", false)},
+			annotations:     map[string]string{tenancyv1alpha1.DryRunInitializersAnnotationKey: "a,b"},
+			dryRun:          true,
+			wantAnnotations: map[string]string{tenancyv1alpha1.DryRunInitializersAnnotationKey: "a,b", tenancyv1alpha1.DryRunShardAnnotationKey: "open"},
+		},
+		{
+			name:    "dry-run fails without a fitting shard",
+			shards:  []*tenancyv1alpha1.ClusterWorkspaceShard{newShard("cordoned", true)},
+			dryRun:  true,
+			wantErr: `no available shards to schedule the workspace: cordoned: The shard is cordoned.`,
+		},
+		{
+			name:    "dry-run fails without shards",
+			dryRun:  true,
+			wantErr: `no available shards to schedule the workspace`,
+		},
+		{
+			name:            "creation drops the dry-run annotations",
+			shards:          []*tenancyv1alpha1.ClusterWorkspaceShard{newShard("open", false)},
+			annotations:     map[string]string{tenancyv1alpha1.DryRunShardAnnotationKey: "open", tenancyv1alpha1.DryRunInitializersAnnotationKey: "a", "other": "kept"},
+			wantAnnotations: map[string]string{"other": "kept"},
+		},
+		{
+			name:   "creation does not schedule",
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{newShard("open", false)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shardIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, shard := range tt.shards {
+				require.NoError(t, shardIndexer.Add(shard))
+			}
+			o := &clusterWorkspaceDryRun{
+				Handler:         admission.NewHandler(admission.Create),
+				workspaceLister: tenancyv1alpha1lister.NewClusterWorkspaceLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+				shardLister:     tenancyv1alpha1lister.NewClusterWorkspaceShardLister(shardIndexer),
+				schedulerNames:  []string{workspacescheduler.UnschedulablePluginName},
+			}
+			require.NoError(t, o.ValidateInitialization())
+
+			a := createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Annotations: tt.annotations},
+			}, tt.dryRun)
+			err := o.Admit(context.Background(), a, nil)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			u, ok := a.GetObject().(*unstructured.Unstructured)
+			require.True(t, ok, "expected unstructured, got %T", a.GetObject())
+			require.Equal(t, tt.wantAnnotations, u.GetAnnotations())
+		})
+	}
+}
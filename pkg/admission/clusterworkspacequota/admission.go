@@ -0,0 +1,166 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clusterworkspacequota
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/quota/v1/generic"
+	"k8s.io/client-go/util/retry"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	tenancyv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+// Charge the creation of namespaced objects against the ClusterWorkspaceQuotas of all
+// ancestor workspaces, and reject it if any of them would exceed its limit.
+//
+// Usage is charged optimistically before the object is persisted. If creation fails
+// afterwards, the quota controller releases the usage on its next recalculation.
+
+const (
+	PluginName = "tenancy.kcp.dev/ClusterWorkspaceQuota"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &clusterWorkspaceQuota{
+				Handler: admission.NewHandler(admission.Create),
+			}, nil
+		})
+}
+
+type clusterWorkspaceQuota struct {
+	*admission.Handler
+
+	quotaLister       tenancyv1alpha1lister.ClusterWorkspaceQuotaLister
+	getQuota          func(ctx context.Context, clusterName logicalcluster.LogicalCluster, name string) (*tenancyv1alpha1.ClusterWorkspaceQuota, error)
+	updateQuotaStatus func(ctx context.Context, quota *tenancyv1alpha1.ClusterWorkspaceQuota) error
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&clusterWorkspaceQuota{})
+var _ = admission.InitializationValidator(&clusterWorkspaceQuota{})
+var _ = kcpinitializers.WantsKcpInformers(&clusterWorkspaceQuota{})
+var _ = kcpinitializers.WantsKcpClusterClient(&clusterWorkspaceQuota{})
+
+// Validate charges the created object against the quotas of the ancestor workspaces.
+func (o *clusterWorkspaceQuota) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetSubresource() != "" || a.GetNamespace() == "" {
+		return nil
+	}
+
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error determining workspace: %w", err))
+	}
+
+	quotas, err := o.quotaLister.List(labels.Everything())
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	name := generic.ObjectCountQuotaResourceNameFor(a.GetResource().GroupResource())
+	for _, quota := range quotas {
+		if !helper.IsInWorkspaceSubtree(logicalcluster.From(quota), cluster.Name) {
+			continue
+		}
+		if _, found := quota.Spec.Hard[name]; !found {
+			continue
+		}
+		if err := o.charge(ctx, quota, name, a.IsDryRun()); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+	}
+
+	return nil
+}
+
+// charge increments the usage of the quota by one, retrying with the current quota on conflicts.
+func (o *clusterWorkspaceQuota) charge(ctx context.Context, quota *tenancyv1alpha1.ClusterWorkspaceQuota, name corev1.ResourceName, dryRun bool) error {
+	clusterName := logicalcluster.From(quota)
+	first := true
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if !first {
+			current, err := o.getQuota(ctx, clusterName, quota.Name)
+			if err != nil {
+				return err
+			}
+			quota = current
+		}
+		first = false
+
+		hard, found := quota.Status.Hard[name]
+		if !found {
+			return fmt.Errorf("status unknown for quota %s|%s, resource %s", clusterName, quota.Name, name)
+		}
+		used := quota.Status.Used[name]
+		used.Add(resource.MustParse("1"))
+		if used.Cmp(hard) > 0 {
+			return fmt.Errorf("exceeded quota %s|%s: requested %s=1, used %s=%s, limited %s=%s",
+				clusterName, quota.Name, name, name, quota.Status.Used.Name(name, resource.DecimalSI), name, hard.String())
+		}
+		if dryRun {
+			return nil
+		}
+
+		quota = quota.DeepCopy()
+		if quota.Status.Used == nil {
+			quota.Status.Used = corev1.ResourceList{}
+		}
+		quota.Status.Used[name] = used
+		return o.updateQuotaStatus(ctx, quota)
+	})
+}
+
+func (o *clusterWorkspaceQuota) ValidateInitialization() error {
+	if o.quotaLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspaceQuota lister")
+	}
+	if o.updateQuotaStatus == nil {
+		return fmt.Errorf(PluginName + " plugin needs a kcp cluster client")
+	}
+	return nil
+}
+
+func (o *clusterWorkspaceQuota) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	o.SetReadyFunc(informers.Tenancy().V1alpha1().ClusterWorkspaceQuotas().Informer().HasSynced)
+	o.quotaLister = informers.Tenancy().V1alpha1().ClusterWorkspaceQuotas().Lister()
+}
+
+func (o *clusterWorkspaceQuota) SetKcpClusterClient(kcpClusterClient *kcpclient.Cluster) {
+	o.getQuota = func(ctx context.Context, clusterName logicalcluster.LogicalCluster, name string) (*tenancyv1alpha1.ClusterWorkspaceQuota, error) {
+		return kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().ClusterWorkspaceQuotas().Get(ctx, name, metav1.GetOptions{})
+	}
+	o.updateQuotaStatus = func(ctx context.Context, quota *tenancyv1alpha1.ClusterWorkspaceQuota) error {
+		_, err := kcpClusterClient.Cluster(logicalcluster.From(quota)).TenancyV1alpha1().ClusterWorkspaceQuotas().UpdateStatus(ctx, quota, metav1.UpdateOptions{})
+		return err
+	}
+}
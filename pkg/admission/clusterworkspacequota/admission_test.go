@@ -0,0 +1,226 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clusterworkspacequota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func createAttr(namespace string, resource schema.GroupVersionResource, subresource string, dryRun bool) admission.Attributes {
+	return admission.NewAttributesRecord(
+		nil,
+		nil,
+		schema.GroupVersionKind{},
+		namespace,
+		"test",
+		resource,
+		subresource,
+		admission.Create,
+		&metav1.CreateOptions{},
+		dryRun,
+		&user.DefaultInfo{},
+	)
+}
+
+func newQuota(clusterName string, hard, statusHard, used corev1.ResourceList) *tenancyv1alpha1.ClusterWorkspaceQuota {
+	return &tenancyv1alpha1.ClusterWorkspaceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", ClusterName: clusterName, ResourceVersion: "1"},
+		Spec:       tenancyv1alpha1.ClusterWorkspaceQuotaSpec{Hard: hard},
+		Status:     tenancyv1alpha1.ClusterWorkspaceQuotaStatus{Hard: statusHard, Used: used},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	limit := func(n string) corev1.ResourceList {
+		return corev1.ResourceList{"count/configmaps": resource.MustParse(n)}
+	}
+
+	tests := []struct {
+		name        string
+		quotas      []*tenancyv1alpha1.ClusterWorkspaceQuota
+		attr        admission.Attributes
+		conflicts   int
+		wantErr     bool
+		wantCharged map[string]string
+	}{
+		{
+			name:   "no quotas",
+			attr:   createAttr("default", configMaps, "", false),
+			quotas: nil,
+		},
+		{
+			name:        "below the limit of the parent",
+			attr:        createAttr("default", configMaps, "", false),
+			quotas:      []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org", limit("2"), limit("2"), limit("1"))},
+			wantCharged: map[string]string{"root:org": "2"},
+		},
+		{
+			name:    "at the limit of the parent",
+			attr:    createAttr("default", configMaps, "", false),
+			quotas:  []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org", limit("2"), limit("2"), limit("2"))},
+			wantErr: true,
+		},
+		{
+			name:    "at the limit of the grandparent",
+			attr:    createAttr("default", configMaps, "", false),
+			quotas:  []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root", limit("5"), limit("5"), limit("5"))},
+			wantErr: true,
+		},
+		{
+			name:   "quota of an unrelated workspace",
+			attr:   createAttr("default", configMaps, "", false),
+			quotas: []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:other", limit("0"), limit("0"), nil)},
+		},
+		{
+			name:   "quota of the own workspace",
+			attr:   createAttr("default", configMaps, "", false),
+			quotas: []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org:ws", limit("0"), limit("0"), nil)},
+		},
+		{
+			name:   "other resource",
+			attr:   createAttr("default", schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, "", false),
+			quotas: []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org", limit("0"), limit("0"), nil)},
+		},
+		{
+			name:   "cluster-scoped resource",
+			attr:   createAttr("", configMaps, "", false),
+			quotas: []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org", limit("0"), limit("0"), nil)},
+		},
+		{
+			name:   "subresource",
+			attr:   createAttr("default", configMaps, "status", false),
+			quotas: []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org", limit("0"), limit("0"), nil)},
+		},
+		{
+			name:    "status not yet calculated",
+			attr:    createAttr("default", configMaps, "", false),
+			quotas:  []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org", limit("2"), nil, nil)},
+			wantErr: true,
+		},
+		{
+			name:   "dry run is checked but not charged",
+			attr:   createAttr("default", configMaps, "", true),
+			quotas: []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org", limit("2"), limit("2"), nil)},
+		},
+		{
+			name: "all ancestors are charged",
+			attr: createAttr("default", configMaps, "", false),
+			quotas: []*tenancyv1alpha1.ClusterWorkspaceQuota{
+				newQuota("root:org", limit("2"), limit("2"), nil),
+				newQuota("root", limit("10"), limit("10"), limit("4")),
+			},
+			wantCharged: map[string]string{"root:org": "1", "root": "5"},
+		},
+		{
+			name:        "conflict is retried",
+			attr:        createAttr("default", configMaps, "", false),
+			quotas:      []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org", limit("2"), limit("2"), limit("0"))},
+			conflicts:   1,
+			wantCharged: map[string]string{"root:org": "2"},
+		},
+		{
+			name:      "conflict is retried and then exceeds",
+			attr:      createAttr("default", configMaps, "", false),
+			quotas:    []*tenancyv1alpha1.ClusterWorkspaceQuota{newQuota("root:org", limit("2"), limit("2"), limit("1"))},
+			conflicts: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charged := map[string]string{}
+			conflicts := tt.conflicts
+			o := &clusterWorkspaceQuota{
+				Handler:     admission.NewHandler(admission.Create),
+				quotaLister: fakeClusterWorkspaceQuotaLister(tt.quotas),
+				getQuota: func(ctx context.Context, clusterName logicalcluster.LogicalCluster, name string) (*tenancyv1alpha1.ClusterWorkspaceQuota, error) {
+					// somebody else charged in the meantime
+					for _, quota := range tt.quotas {
+						if logicalcluster.From(quota) == clusterName {
+							quota = quota.DeepCopy()
+							used := quota.Status.Used[corev1.ResourceName("count/configmaps")]
+							used.Add(resource.MustParse("1"))
+							quota.Status.Used["count/configmaps"] = used
+							return quota, nil
+						}
+					}
+					return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspacequotas"), name)
+				},
+				updateQuotaStatus: func(ctx context.Context, quota *tenancyv1alpha1.ClusterWorkspaceQuota) error {
+					if conflicts > 0 {
+						conflicts--
+						return apierrors.NewConflict(tenancyv1alpha1.Resource("clusterworkspacequotas"), quota.Name, nil)
+					}
+					used := quota.Status.Used["count/configmaps"]
+					charged[logicalcluster.From(quota).String()] = used.String()
+					return nil
+				},
+			}
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org:ws")})
+			err := o.Validate(ctx, tt.attr, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantCharged == nil {
+				tt.wantCharged = map[string]string{}
+			}
+			require.Equal(t, tt.wantCharged, charged)
+		})
+	}
+}
+
+type fakeClusterWorkspaceQuotaLister []*tenancyv1alpha1.ClusterWorkspaceQuota
+
+func (l fakeClusterWorkspaceQuotaLister) List(selector labels.Selector) (ret []*tenancyv1alpha1.ClusterWorkspaceQuota, err error) {
+	return l.ListWithContext(context.Background(), selector)
+}
+
+func (l fakeClusterWorkspaceQuotaLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyv1alpha1.ClusterWorkspaceQuota, err error) {
+	return l, nil
+}
+
+func (l fakeClusterWorkspaceQuotaLister) Get(name string) (*tenancyv1alpha1.ClusterWorkspaceQuota, error) {
+	return l.GetWithContext(context.Background(), name)
+}
+
+func (l fakeClusterWorkspaceQuotaLister) GetWithContext(ctx context.Context, name string) (*tenancyv1alpha1.ClusterWorkspaceQuota, error) {
+	for _, quota := range l {
+		if quota.Name == name {
+			return quota, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspacequotas"), name)
+}
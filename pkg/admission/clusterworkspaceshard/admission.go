@@ -21,10 +21,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
 
 	"github.com/kcp-dev/kcp/pkg/admission/initializers"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -63,6 +66,8 @@ var _ = initializers.WantsExternalAddressProvider(&clusterWorkspaceShard{})
 // Validate ensures that
 // - baseURL is set
 // - externalURL is set
+// - shard credentials only update the shard they registered
+// - only privileged users issue fencing tokens
 func (o *clusterWorkspaceShard) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaceshards") {
 		return nil
@@ -84,10 +89,52 @@ func (o *clusterWorkspaceShard) Validate(ctx context.Context, a admission.Attrib
 		return admission.NewForbidden(a, errors.New("spec.externalURL must be set"))
 	}
 
+	if a.GetOperation() == admission.Update {
+		oldU, ok := a.GetOldObject().(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected type %T", a.GetOldObject())
+		}
+		old := &tenancyv1alpha1.ClusterWorkspaceShard{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(oldU.Object, old); err != nil {
+			return fmt.Errorf("failed to convert unstructured to ClusterWorkspaceShard: %w", err)
+		}
+
+		privileged := isPrivileged(a.GetUserInfo())
+		owner := old.Annotations[tenancyv1alpha1.ClusterWorkspaceShardOwnerAnnotationKey]
+		if isShardUser(a.GetUserInfo()) && !privileged && a.GetUserInfo().GetName() != owner {
+			return admission.NewForbidden(a, errors.New("shard credentials can only update the shard they registered"))
+		}
+		if cws.Status.FencingToken != old.Status.FencingToken && !privileged {
+			return admission.NewForbidden(a, errors.New("status.fencingToken can only be issued by the root shard"))
+		}
+	}
+
 	return nil
 }
 
-// Admit defaults the baseURL and externalURL to the shards external hostname.
+func isPrivileged(userInfo user.Info) bool {
+	return hasGroup(userInfo, user.SystemPrivilegedGroup)
+}
+
+func isShardUser(userInfo user.Info) bool {
+	return hasGroup(userInfo, tenancyv1alpha1.ClusterWorkspaceShardsGroup)
+}
+
+func hasGroup(userInfo user.Info, group string) bool {
+	if userInfo == nil {
+		return false
+	}
+	for _, g := range userInfo.GetGroups() {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Admit defaults the baseURL and externalURL to the shards external hostname, records
+// shard credentials creating the shard as its owner, and records the previous URLs of the shard
+// when they change.
 func (o *clusterWorkspaceShard) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaceshards") {
 		return nil
@@ -116,6 +163,45 @@ func (o *clusterWorkspaceShard) Admit(ctx context.Context, a admission.Attribute
 		cws.Spec.ExternalURL = cws.Spec.BaseURL
 	}
 
+	switch a.GetOperation() {
+	case admission.Create:
+		// others, e.g. admins registering a shard by hand, can name the shard credentials as owner
+		if userInfo := a.GetUserInfo(); isShardUser(userInfo) && userInfo.GetName() != "" {
+			if cws.Annotations == nil {
+				cws.Annotations = map[string]string{}
+			}
+			cws.Annotations[tenancyv1alpha1.ClusterWorkspaceShardOwnerAnnotationKey] = userInfo.GetName()
+		}
+	case admission.Update:
+		// the owner is immutable
+		oldU, ok := a.GetOldObject().(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected type %T", a.GetOldObject())
+		}
+		old := &tenancyv1alpha1.ClusterWorkspaceShard{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(oldU.Object, old); err != nil {
+			return fmt.Errorf("failed to convert unstructured to ClusterWorkspaceShard: %w", err)
+		}
+		if owner, found := old.Annotations[tenancyv1alpha1.ClusterWorkspaceShardOwnerAnnotationKey]; found {
+			if cws.Annotations == nil {
+				cws.Annotations = map[string]string{}
+			}
+			cws.Annotations[tenancyv1alpha1.ClusterWorkspaceShardOwnerAnnotationKey] = owner
+		} else {
+			delete(cws.Annotations, tenancyv1alpha1.ClusterWorkspaceShardOwnerAnnotationKey)
+		}
+
+		// the previous URLs are maintained here, such that clients of the old URLs can be pointed to the new ones
+		if previous := previousURLs(old, cws); len(previous) > 0 {
+			if cws.Annotations == nil {
+				cws.Annotations = map[string]string{}
+			}
+			cws.Annotations[tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey] = strings.Join(previous, ",")
+		} else {
+			delete(cws.Annotations, tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey)
+		}
+	}
+
 	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cws)
 	if err != nil {
 		return err
@@ -125,6 +211,32 @@ func (o *clusterWorkspaceShard) Admit(ctx context.Context, a admission.Attribute
 	return nil
 }
 
+// maxPreviousURLs bounds the number of previous URLs recorded on a shard.
+const maxPreviousURLs = 5
+
+// previousURLs returns the URLs the shard was reachable at before the update from old to cws,
+// most recent first and without the current URLs.
+func previousURLs(old, cws *tenancyv1alpha1.ClusterWorkspaceShard) []string {
+	candidates := []string{old.Spec.BaseURL, old.Spec.ExternalURL}
+	if recorded := old.Annotations[tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey]; recorded != "" {
+		candidates = append(candidates, strings.Split(recorded, ",")...)
+	}
+
+	seen := sets.NewString(cws.Spec.BaseURL, cws.Spec.ExternalURL, "")
+	var previous []string
+	for _, u := range candidates {
+		if seen.Has(u) {
+			continue
+		}
+		seen.Insert(u)
+		previous = append(previous, u)
+		if len(previous) == maxPreviousURLs {
+			break
+		}
+	}
+	return previous
+}
+
 func (o *clusterWorkspaceShard) SetExternalAddressProvider(externalAddressProvider func() string) {
 	o.externalAddressProvider = externalAddressProvider
 }
@@ -37,6 +37,10 @@ import (
 )
 
 func createAttr(ws *tenancyv1alpha1.ClusterWorkspaceShard) admission.Attributes {
+	return createAttrAs(ws, &user.DefaultInfo{})
+}
+
+func createAttrAs(ws *tenancyv1alpha1.ClusterWorkspaceShard, userInfo user.Info) admission.Attributes {
 	return admission.NewAttributesRecord(
 		helpers.ToUnstructuredOrDie(ws),
 		nil,
@@ -48,11 +52,15 @@ func createAttr(ws *tenancyv1alpha1.ClusterWorkspaceShard) admission.Attributes
 		admission.Create,
 		&metav1.CreateOptions{},
 		false,
-		&user.DefaultInfo{},
+		userInfo,
 	)
 }
 
 func updateAttr(ws, old *tenancyv1alpha1.ClusterWorkspaceShard) admission.Attributes {
+	return updateAttrAs(ws, old, &user.DefaultInfo{})
+}
+
+func updateAttrAs(ws, old *tenancyv1alpha1.ClusterWorkspaceShard, userInfo user.Info) admission.Attributes {
 	return admission.NewAttributesRecord(
 		helpers.ToUnstructuredOrDie(ws),
 		helpers.ToUnstructuredOrDie(old),
@@ -64,10 +72,28 @@ func updateAttr(ws, old *tenancyv1alpha1.ClusterWorkspaceShard) admission.Attrib
 		admission.Update,
 		&metav1.CreateOptions{},
 		false,
-		&user.DefaultInfo{},
+		userInfo,
 	)
 }
 
+var shardAdmin = &user.DefaultInfo{Name: "shard-admin", Groups: []string{tenancyv1alpha1.ClusterWorkspaceShardsGroup}}
+
+func ownedShard(owner string, token string) *tenancyv1alpha1.ClusterWorkspaceShard {
+	return &tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardOwnerAnnotationKey: owner},
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+			BaseURL:     "https://kcp",
+			ExternalURL: "https://kcp",
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceShardStatus{
+			FencingToken: token,
+		},
+	}
+}
+
 func TestAdmit(t *testing.T) {
 	tests := []struct {
 		name                      string
@@ -78,7 +104,7 @@ func TestAdmit(t *testing.T) {
 		wantErr                   bool
 	}{
 		{
-			name: "does nothing on update when baseURL and externalURL are set",
+			name: "records the previous URLs on update when baseURL and externalURL change",
 			a: updateAttr(&tenancyv1alpha1.ClusterWorkspaceShard{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "test",
@@ -99,7 +125,8 @@ func TestAdmit(t *testing.T) {
 				}),
 			expectedObj: &tenancyv1alpha1.ClusterWorkspaceShard{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
+					Name:        "test",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey: "https://boston.kcp.dev,https://kcp.dev"},
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
 					BaseURL:     "https://boston2.kcp.dev",
@@ -149,7 +176,8 @@ func TestAdmit(t *testing.T) {
 				}),
 			expectedObj: &tenancyv1alpha1.ClusterWorkspaceShard{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
+					Name:        "test",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey: "https://kcp.dev"},
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
 					BaseURL:     "https://boston2.kcp.dev",
@@ -198,7 +226,8 @@ func TestAdmit(t *testing.T) {
 				}),
 			expectedObj: &tenancyv1alpha1.ClusterWorkspaceShard{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
+					Name:        "test",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey: "https://boston.kcp.dev"},
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
 					BaseURL:     "https://external.kcp.dev",
@@ -245,7 +274,8 @@ func TestAdmit(t *testing.T) {
 				}),
 			expectedObj: &tenancyv1alpha1.ClusterWorkspaceShard{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
+					Name:        "test",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey: "https://boston.kcp.dev,https://kcp.dev"},
 				},
 				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
 					BaseURL:     "https://external.kcp.dev",
@@ -328,6 +358,65 @@ func TestAdmit(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "records shard credentials as owner on create",
+			a: createAttrAs(&tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardOwnerAnnotationKey: "someone-else"},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+					BaseURL:     "https://kcp",
+					ExternalURL: "https://kcp",
+				},
+			}, shardAdmin),
+			expectedObj: ownedShard("shard-admin", ""),
+		},
+		{
+			name: "keeps the owner named by an admin on create",
+			a: createAttrAs(ownedShard("shard-admin", ""),
+				&user.DefaultInfo{Name: "root-admin"}),
+			expectedObj: ownedShard("shard-admin", ""),
+		},
+		{
+			name: "keeps the owner on update",
+			a: updateAttrAs(ownedShard("someone-else", ""),
+				ownedShard("shard-admin", ""),
+				shardAdmin),
+			expectedObj: ownedShard("shard-admin", ""),
+		},
+		{
+			name: "keeps the previous URLs on update, most recent first and bounded",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+					BaseURL:     "https://boston.kcp.dev",
+					ExternalURL: "https://boston.kcp.dev",
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspaceShard{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "test",
+						Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey: "https://a.kcp.dev,https://boston.kcp.dev,https://b.kcp.dev,https://c.kcp.dev,https://d.kcp.dev"},
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+						BaseURL:     "https://boston2.kcp.dev",
+						ExternalURL: "https://boston2.kcp.dev",
+					},
+				}),
+			expectedObj: &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey: "https://boston2.kcp.dev,https://a.kcp.dev,https://b.kcp.dev,https://c.kcp.dev,https://d.kcp.dev"},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+					BaseURL:     "https://boston.kcp.dev",
+					ExternalURL: "https://boston.kcp.dev",
+				},
+			},
+		},
 		{
 			name: "fails on create when baseURL is not set and external address provider is nil",
 			a: createAttr(&tenancyv1alpha1.ClusterWorkspaceShard{
@@ -518,6 +607,44 @@ func TestValidate(t *testing.T) {
 			}),
 			wantErr: true,
 		},
+		{
+			name: "accept update by the owner",
+			a: updateAttrAs(ownedShard("shard-admin", ""),
+				ownedShard("shard-admin", ""),
+				shardAdmin),
+		},
+		{
+			name: "reject update by credentials of another shard",
+			a: updateAttrAs(ownedShard("shard-admin", ""),
+				ownedShard("shard-admin", ""),
+				&user.DefaultInfo{Name: "intruder", Groups: []string{tenancyv1alpha1.ClusterWorkspaceShardsGroup}}),
+			wantErr: true,
+		},
+		{
+			name: "accept update by an admin",
+			a: updateAttrAs(ownedShard("shard-admin", ""),
+				ownedShard("shard-admin", ""),
+				&user.DefaultInfo{Name: "root-admin"}),
+		},
+		{
+			name: "accept update by a privileged user",
+			a: updateAttrAs(ownedShard("shard-admin", ""),
+				ownedShard("shard-admin", ""),
+				&user.DefaultInfo{Name: "root-shard", Groups: []string{user.SystemPrivilegedGroup}}),
+		},
+		{
+			name: "accept fencing token issued by a privileged user",
+			a: updateAttrAs(ownedShard("shard-admin", "2-abc"),
+				ownedShard("shard-admin", "1-abc"),
+				&user.DefaultInfo{Name: "root-shard", Groups: []string{user.SystemPrivilegedGroup}}),
+		},
+		{
+			name: "reject fencing token issued by the owner",
+			a: updateAttrAs(ownedShard("shard-admin", "2-abc"),
+				ownedShard("shard-admin", "1-abc"),
+				shardAdmin),
+			wantErr: true,
+		},
 		{
 			name: "ignores different resources",
 			a: admission.NewAttributesRecord(
@@ -59,7 +59,8 @@ func Register(plugins *admission.Plugins) {
 // clusterWorkspaceTypeExists  does the following
 // - it checks existence of ClusterWorkspaceType in the same workspace,
 // - it applies the ClusterWorkspaceType initializers to the ClusterWorkspace when it
-//   transitions to the Initializing state.
+//   transitions to the Initializing state, and the initializer binding the defaultAPIBindings
+//   of the type if it has any.
 type clusterWorkspaceTypeExists struct {
 	*admission.Handler
 	typeLister        tenancyv1alpha1lister.ClusterWorkspaceTypeLister
@@ -75,7 +76,8 @@ var _ = admission.InitializationValidator(&clusterWorkspaceTypeExists{})
 var _ = kcpinitializers.WantsKcpInformers(&clusterWorkspaceTypeExists{})
 var _ = kcpinitializers.WantsKubeClusterClient(&clusterWorkspaceTypeExists{})
 
-// Admit adds type initializer on transition to initializing phase.
+// Admit adds type initializer on transition to initializing phase. On a dry-run create,
+// it reports the initializers the workspace would get in an annotation.
 func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaces") {
 		return nil
@@ -114,6 +116,10 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 
 	if a.GetOperation() == admission.Create {
 		addAdditionalWorkspaceLabels(cwt, cw)
+		addDefaultExpiration(cwt, cw)
+		if a.IsDryRun() {
+			addDryRunInitializers(cwt, cw)
+		}
 
 		return updateUnstructured(u, cw)
 	}
@@ -147,7 +153,7 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 	for _, i := range cw.Status.Initializers {
 		existing.Insert(string(i))
 	}
-	for _, i := range cwt.Spec.Initializers {
+	for _, i := range initializers(cwt) {
 		if !existing.Has(string(i)) {
 			cw.Status.Initializers = append(cw.Status.Initializers, i)
 		}
@@ -156,6 +162,17 @@ func (o *clusterWorkspaceTypeExists) Admit(ctx context.Context, a admission.Attr
 	return updateUnstructured(u, cw)
 }
 
+// initializers returns the initializers of the ClusterWorkspaces of the given type,
+// including the one binding its defaultAPIBindings.
+func initializers(cwt *tenancyv1alpha1.ClusterWorkspaceType) []tenancyv1alpha1.ClusterWorkspaceInitializer {
+	if len(cwt.Spec.DefaultAPIBindings) == 0 {
+		return cwt.Spec.Initializers
+	}
+	ret := make([]tenancyv1alpha1.ClusterWorkspaceInitializer, 0, len(cwt.Spec.Initializers)+1)
+	ret = append(ret, cwt.Spec.Initializers...)
+	return append(ret, tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer)
+}
+
 // Validate ensures that
 // - has a valid type
 // - has valid initializers when transitioning to initializing
@@ -238,7 +255,7 @@ func (o *clusterWorkspaceTypeExists) Validate(ctx context.Context, a admission.A
 		for _, initializer := range cw.Status.Initializers {
 			existing.Insert(string(initializer))
 		}
-		for _, initializer := range cwt.Spec.Initializers {
+		for _, initializer := range initializers(cwt) {
 			if !existing.Has(string(initializer)) {
 				return admission.NewForbidden(a, fmt.Errorf("spec.initializers %q does not exist", initializer))
 			}
@@ -316,3 +333,32 @@ func addAdditionalWorkspaceLabels(
 		}
 	}
 }
+
+// addDefaultExpiration sets the default expiration of the workspace
+// type on the workspace if it has no expiration.
+func addDefaultExpiration(
+	cwt *tenancyv1alpha1.ClusterWorkspaceType,
+	cw *tenancyv1alpha1.ClusterWorkspace,
+) {
+	if cwt.Spec.DefaultExpiration == nil || cw.Spec.Expiration != nil {
+		return
+	}
+	cw.Spec.Expiration = cwt.Spec.DefaultExpiration.DeepCopy()
+	cw.Spec.Expiration.ExpiryTime = nil
+}
+
+// addDryRunInitializers records the initializers the workspace would get
+// on transition to initializing phase in an annotation.
+func addDryRunInitializers(
+	cwt *tenancyv1alpha1.ClusterWorkspaceType,
+	cw *tenancyv1alpha1.ClusterWorkspace,
+) {
+	names := make([]string, 0, len(cwt.Spec.Initializers)+1)
+	for _, i := range initializers(cwt) {
+		names = append(names, string(i))
+	}
+	if cw.Annotations == nil {
+		cw.Annotations = map[string]string{}
+	}
+	cw.Annotations[tenancyv1alpha1.DryRunInitializersAnnotationKey] = strings.Join(names, ",")
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 	"github.com/stretchr/testify/require"
@@ -38,10 +39,15 @@ import (
 	"k8s.io/utils/diff"
 
 	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 )
 
 func createAttr(obj *tenancyv1alpha1.ClusterWorkspace) admission.Attributes {
+	return createAttrWithDryRun(obj, false)
+}
+
+func createAttrWithDryRun(obj *tenancyv1alpha1.ClusterWorkspace, dryRun bool) admission.Attributes {
 	return admission.NewAttributesRecord(
 		helpers.ToUnstructuredOrDie(obj),
 		nil,
@@ -52,7 +58,7 @@ func createAttr(obj *tenancyv1alpha1.ClusterWorkspace) admission.Attributes {
 		"",
 		admission.Create,
 		&metav1.CreateOptions{},
-		false,
+		dryRun,
 		&user.DefaultInfo{},
 	)
 }
@@ -134,6 +140,56 @@ func TestAdmit(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "adds apibindings initializer during transition to initializing if type has default APIBindings",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "root:org#$#foo",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+						Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"a"},
+						DefaultAPIBindings: []apisv1alpha1.ExportReference{
+							{Workspace: &apisv1alpha1.WorkspaceExportReference{WorkspaceName: "compute", ExportName: "kubernetes"}},
+						},
+					},
+				},
+			},
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+						Type: "Foo",
+					},
+					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+						Phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+					},
+				}),
+			expectedObj: &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:        tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+					Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"a", tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer},
+				},
+			},
+		},
 		{
 			name: "does not add initializers during transition not to initializing",
 			types: []*tenancyv1alpha1.ClusterWorkspaceType{
@@ -305,6 +361,115 @@ func TestAdmit(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "adds default expiration without expiry time",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "root:org#$#foo",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+						DefaultExpiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+							TTL:        &metav1.Duration{Duration: 24 * time.Hour},
+							ExpiryTime: &metav1.Time{Time: time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)},
+							Action:     tenancyv1alpha1.ClusterWorkspaceExpirationActionHibernate,
+						},
+					},
+				},
+			},
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+				},
+			}),
+			expectedObj: &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+					Expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+						TTL:    &metav1.Duration{Duration: 24 * time.Hour},
+						Action: tenancyv1alpha1.ClusterWorkspaceExpirationActionHibernate,
+					},
+				},
+			},
+		},
+		{
+			name: "reports the initializers on dry-run",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "root:org#$#foo",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+						Initializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"a", "b"},
+						DefaultAPIBindings: []apisv1alpha1.ExportReference{
+							{Workspace: &apisv1alpha1.WorkspaceExportReference{WorkspaceName: "compute", ExportName: "kubernetes"}},
+						},
+					},
+				},
+			},
+			a: createAttrWithDryRun(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+				},
+			}, true),
+			expectedObj: &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						tenancyv1alpha1.DryRunInitializersAnnotationKey: "a,b,initializers.tenancy.kcp.dev/apibindings",
+					},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+				},
+			},
+		},
+		{
+			name: "keeps expiration of the workspace",
+			types: []*tenancyv1alpha1.ClusterWorkspaceType{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "root:org#$#foo",
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+						DefaultExpiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+							TTL: &metav1.Duration{Duration: 24 * time.Hour},
+						},
+					},
+				},
+			},
+			a: createAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+					Expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+						TTL: &metav1.Duration{Duration: time.Hour},
+					},
+				},
+			}),
+			expectedObj: &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: "Foo",
+					Expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+						TTL: &metav1.Duration{Duration: time.Hour},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -18,6 +18,8 @@ package initializers
 
 import (
 	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
@@ -84,6 +86,26 @@ func (i *kcpClusterClientInitializer) Initialize(plugin admission.Interface) {
 	}
 }
 
+// NewRootKcpClientInitializer returns an admission plugin initializer that injects
+// a kcp client of the root workspace into admission plugins.
+func NewRootKcpClientInitializer(
+	rootKcpClient kcpclientset.Interface,
+) *rootKcpClientInitializer {
+	return &rootKcpClientInitializer{
+		rootKcpClient: rootKcpClient,
+	}
+}
+
+type rootKcpClientInitializer struct {
+	rootKcpClient kcpclientset.Interface
+}
+
+func (i *rootKcpClientInitializer) Initialize(plugin admission.Interface) {
+	if wants, ok := plugin.(WantsRootKcpClient); ok {
+		wants.SetRootKcpClient(i.rootKcpClient)
+	}
+}
+
 // NewExternalAddressInitializer returns an admission plugin initializer that injects
 // an external address provider into the admission plugin.
 func NewExternalAddressInitializer(
@@ -103,3 +125,83 @@ func (i *externalAddressInitializer) Initialize(plugin admission.Interface) {
 		wants.SetExternalAddressProvider(i.externalAddressProvider)
 	}
 }
+
+// NewAPIAudiencesInitializer returns an admission plugin initializer that injects
+// the API audiences of the server into admission plugins.
+func NewAPIAudiencesInitializer(
+	apiAudiences authenticator.Audiences,
+) *apiAudiencesInitializer {
+	return &apiAudiencesInitializer{
+		apiAudiences: apiAudiences,
+	}
+}
+
+type apiAudiencesInitializer struct {
+	apiAudiences authenticator.Audiences
+}
+
+func (i *apiAudiencesInitializer) Initialize(plugin admission.Interface) {
+	if wants, ok := plugin.(WantsAPIAudiences); ok {
+		wants.SetAPIAudiences(i.apiAudiences)
+	}
+}
+
+// NewDynamicClusterClientInitializer returns an admission plugin initializer that injects
+// a dynamic cluster client into admission plugins.
+func NewDynamicClusterClientInitializer(
+	dynamicClusterClient *dynamic.Cluster,
+) *dynamicClusterClientInitializer {
+	return &dynamicClusterClientInitializer{
+		dynamicClusterClient: dynamicClusterClient,
+	}
+}
+
+type dynamicClusterClientInitializer struct {
+	dynamicClusterClient *dynamic.Cluster
+}
+
+func (i *dynamicClusterClientInitializer) Initialize(plugin admission.Interface) {
+	if wants, ok := plugin.(WantsDynamicClusterClient); ok {
+		wants.SetDynamicClusterClient(i.dynamicClusterClient)
+	}
+}
+
+// NewRootKcpInformersInitializer returns an admission plugin initializer that injects
+// the kcp shared informer factory of the root workspace into admission plugins.
+func NewRootKcpInformersInitializer(
+	rootKcpInformers kcpinformers.SharedInformerFactory,
+) *rootKcpInformersInitializer {
+	return &rootKcpInformersInitializer{
+		rootKcpInformers: rootKcpInformers,
+	}
+}
+
+type rootKcpInformersInitializer struct {
+	rootKcpInformers kcpinformers.SharedInformerFactory
+}
+
+func (i *rootKcpInformersInitializer) Initialize(plugin admission.Interface) {
+	if wants, ok := plugin.(WantsRootKcpInformers); ok {
+		wants.SetRootKcpInformers(i.rootKcpInformers)
+	}
+}
+
+// NewWorkspaceSchedulerPluginsInitializer returns an admission plugin initializer that injects
+// the names of the enabled workspace scheduler plugins into admission plugins.
+func NewWorkspaceSchedulerPluginsInitializer(
+	workspaceSchedulerPlugins []string,
+) *workspaceSchedulerPluginsInitializer {
+	return &workspaceSchedulerPluginsInitializer{
+		workspaceSchedulerPlugins: workspaceSchedulerPlugins,
+	}
+}
+
+type workspaceSchedulerPluginsInitializer struct {
+	workspaceSchedulerPlugins []string
+}
+
+func (i *workspaceSchedulerPluginsInitializer) Initialize(plugin admission.Interface) {
+	if wants, ok := plugin.(WantsWorkspaceSchedulerPlugins); ok {
+		wants.SetWorkspaceSchedulerPlugins(i.workspaceSchedulerPlugins)
+	}
+}
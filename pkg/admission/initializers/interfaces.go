@@ -17,6 +17,8 @@ limitations under the License.
 package initializers
 
 import (
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
@@ -41,8 +43,39 @@ type WantsKcpClusterClient interface {
 	SetKcpClusterClient(kubeClusterClient *kcpclientset.Cluster)
 }
 
+// WantsRootKcpClient interface should be implemented by admission plugins
+// that want to have a kcp client of the root workspace injected. On non-root
+// shards, the client talks to the root shard.
+type WantsRootKcpClient interface {
+	SetRootKcpClient(rootKcpClient kcpclientset.Interface)
+}
+
 // WantsExternalAddressProvider interface should be implemented by admission plugins
 // that want to have an external address provider injected.
 type WantsExternalAddressProvider interface {
 	SetExternalAddressProvider(externalAddressProvider func() string)
 }
+
+// WantsAPIAudiences interface should be implemented by admission plugins
+// that want to have the API audiences of the server injected.
+type WantsAPIAudiences interface {
+	SetAPIAudiences(apiAudiences authenticator.Audiences)
+}
+
+// WantsDynamicClusterClient interface should be implemented by admission plugins
+// that want to have a dynamic cluster client injected.
+type WantsDynamicClusterClient interface {
+	SetDynamicClusterClient(dynamicClusterClient *dynamic.Cluster)
+}
+
+// WantsRootKcpInformers interface should be implemented by admission plugins
+// that want to have the kcp informer factory of the root workspace injected.
+type WantsRootKcpInformers interface {
+	SetRootKcpInformers(informers kcpinformers.SharedInformerFactory)
+}
+
+// WantsWorkspaceSchedulerPlugins interface should be implemented by admission plugins
+// that want to have the names of the enabled workspace scheduler plugins injected.
+type WantsWorkspaceSchedulerPlugins interface {
+	SetWorkspaceSchedulerPlugins(names []string)
+}
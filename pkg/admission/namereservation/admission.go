@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namereservation
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/reservation"
+)
+
+// Reserve the names that must be unique across workspaces of all shards through
+// NameReservations in the root workspace, before the objects holding them are persisted:
+// - the names of ClusterWorkspaceTypes within the workspace tree of their organization, but for
+//   the types bootstrapped in every organization
+// - the current and previous identity hashes of APIExports across all workspaces, when set in
+//   their status.
+//
+// The reservations of an object are released when it is deleted. Dry-run requests neither
+// reserve nor release. Reservations of objects which were never persisted, e.g. because a later
+// admission plugin rejected them, are cleaned up by the name reservation controller.
+
+const (
+	PluginName = "tenancy.kcp.dev/NameReservation"
+)
+
+// bootstrappedClusterWorkspaceTypes exist in many workspaces and are not reserved.
+var bootstrappedClusterWorkspaceTypes = sets.NewString("organization", "team", "universal")
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &nameReservation{
+				Handler: admission.NewHandler(admission.Create, admission.Update, admission.Delete),
+			}, nil
+		})
+}
+
+type nameReservation struct {
+	*admission.Handler
+
+	reserver *reservation.Reserver
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&nameReservation{})
+var _ = admission.InitializationValidator(&nameReservation{})
+var _ = kcpinitializers.WantsRootKcpClient(&nameReservation{})
+
+// Validate reserves the names of created objects, and releases them on deletion.
+func (o *nameReservation) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	switch a.GetResource().GroupResource() {
+	case tenancyv1alpha1.Resource("clusterworkspacetypes"):
+		return o.validateClusterWorkspaceType(ctx, a)
+	case apisv1alpha1.Resource("apiexports"):
+		return o.validateAPIExport(ctx, a)
+	}
+	return nil
+}
+
+func (o *nameReservation) validateClusterWorkspaceType(ctx context.Context, a admission.Attributes) error {
+	if a.GetSubresource() != "" || a.IsDryRun() || bootstrappedClusterWorkspaceTypes.Has(a.GetName()) {
+		return nil
+	}
+
+	owner, err := ownerOf(ctx, a)
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+
+	switch a.GetOperation() {
+	case admission.Create:
+		name := reservation.ClusterWorkspaceTypeName(logicalcluster.New(owner.Cluster), a.GetName())
+		if err := o.reserver.Reserve(ctx, reservation.ClusterWorkspaceTypesResource, name, owner); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+	case admission.Delete:
+		if err := o.reserver.Release(ctx, reservation.ClusterWorkspaceTypesResource, owner); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+	}
+	return nil
+}
+
+func (o *nameReservation) validateAPIExport(ctx context.Context, a admission.Attributes) error {
+	if a.IsDryRun() {
+		return nil
+	}
+
+	owner, err := ownerOf(ctx, a)
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+
+	switch {
+	case a.GetOperation() == admission.Update && a.GetSubresource() == "status":
+		apiExport, err := toAPIExport(a.GetObject())
+		if err != nil {
+			return err
+		}
+		oldAPIExport, err := toAPIExport(a.GetOldObject())
+		if err != nil {
+			return err
+		}
+		reserved := identityHashes(oldAPIExport)
+		for _, identityHash := range identityHashes(apiExport).List() {
+			if reserved.Has(identityHash) {
+				continue
+			}
+			if err := o.reserver.Reserve(ctx, reservation.APIExportIdentitiesResource, identityHash, owner); err != nil {
+				return admission.NewForbidden(a, err)
+			}
+		}
+	case a.GetOperation() == admission.Delete && a.GetSubresource() == "":
+		if err := o.reserver.Release(ctx, reservation.APIExportIdentitiesResource, owner); err != nil {
+			return admission.NewForbidden(a, err)
+		}
+	}
+	return nil
+}
+
+// identityHashes returns the current and previous identity hashes of the APIExport.
+func identityHashes(apiExport *apisv1alpha1.APIExport) sets.String {
+	hashes := sets.NewString()
+	if apiExport.Status.IdentityHash != "" {
+		hashes.Insert(apiExport.Status.IdentityHash)
+	}
+	for _, previous := range apiExport.Status.PreviousIdentityHashes {
+		hashes.Insert(previous.IdentityHash)
+	}
+	return hashes
+}
+
+func ownerOf(ctx context.Context, a admission.Attributes) (tenancyv1alpha1.NameReservationOwner, error) {
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return tenancyv1alpha1.NameReservationOwner{}, fmt.Errorf("error determining workspace: %w", err)
+	}
+	return tenancyv1alpha1.NameReservationOwner{Cluster: cluster.Name.String(), Name: a.GetName()}, nil
+}
+
+func toAPIExport(obj runtime.Object) (*apisv1alpha1.APIExport, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	apiExport := &apisv1alpha1.APIExport{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, apiExport); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to APIExport: %w", err)
+	}
+	return apiExport, nil
+}
+
+func (o *nameReservation) ValidateInitialization() error {
+	if o.reserver == nil {
+		return fmt.Errorf(PluginName + " plugin needs a root kcp client")
+	}
+	return nil
+}
+
+func (o *nameReservation) SetRootKcpClient(rootKcpClient kcpclient.Interface) {
+	o.reserver = reservation.NewReserver(rootKcpClient.TenancyV1alpha1().NameReservations())
+}
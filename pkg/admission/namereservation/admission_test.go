@@ -0,0 +1,196 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namereservation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	"github.com/kcp-dev/kcp/pkg/reservation"
+)
+
+func newAttr(obj, old runtime.Object, resource, name, subresource string, op admission.Operation) admission.Attributes {
+	return newDryRunAttr(obj, old, resource, name, subresource, op, false)
+}
+
+func newDryRunAttr(obj, old runtime.Object, resource, name, subresource string, op admission.Operation, dryRun bool) admission.Attributes {
+	gvr := tenancyv1alpha1.SchemeGroupVersion.WithResource(resource)
+	if resource == "apiexports" {
+		gvr = apisv1alpha1.SchemeGroupVersion.WithResource(resource)
+	}
+	if obj != nil {
+		obj = helpers.ToUnstructuredOrDie(obj)
+	}
+	if old != nil {
+		old = helpers.ToUnstructuredOrDie(old)
+	}
+	return admission.NewAttributesRecord(obj, old, schema.GroupVersionKind{}, "", name, gvr, subresource, op, nil, dryRun, &user.DefaultInfo{})
+}
+
+func newReservation(resource, name, cluster, owner string) *tenancyv1alpha1.NameReservation {
+	return reservation.NewReservation(resource, name, tenancyv1alpha1.NameReservationOwner{Cluster: cluster, Name: owner})
+}
+
+func apiExport(name, identityHash string, previousIdentityHashes ...string) *apisv1alpha1.APIExport {
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     apisv1alpha1.APIExportStatus{IdentityHash: identityHash},
+	}
+	for _, previous := range previousIdentityHashes {
+		export.Status.PreviousIdentityHashes = append(export.Status.PreviousIdentityHashes, apisv1alpha1.APIExportPreviousIdentity{IdentityHash: previous})
+	}
+	return export
+}
+
+func TestValidate(t *testing.T) {
+	cwt := &tenancyv1alpha1.ClusterWorkspaceType{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	bootstrapped := &tenancyv1alpha1.ClusterWorkspaceType{ObjectMeta: metav1.ObjectMeta{Name: "universal"}}
+
+	tests := []struct {
+		name             string
+		existing         []runtime.Object
+		attr             admission.Attributes
+		wantErr          bool
+		wantReservations []string
+	}{
+		{
+			name:             "created ClusterWorkspaceType is reserved",
+			attr:             newAttr(cwt, nil, "clusterworkspacetypes", "foo", "", admission.Create),
+			wantReservations: []string{reservation.ReservationName(reservation.ClusterWorkspaceTypesResource, "foo.root-org")},
+		},
+		{
+			name:             "ClusterWorkspaceType reserved in another workspace of the organization is rejected",
+			existing:         []runtime.Object{newReservation(reservation.ClusterWorkspaceTypesResource, "foo.root-org", "root:org:other", "foo")},
+			attr:             newAttr(cwt, nil, "clusterworkspacetypes", "foo", "", admission.Create),
+			wantErr:          true,
+			wantReservations: []string{reservation.ReservationName(reservation.ClusterWorkspaceTypesResource, "foo.root-org")},
+		},
+		{
+			name:     "ClusterWorkspaceType reserved in another organization is reserved",
+			existing: []runtime.Object{newReservation(reservation.ClusterWorkspaceTypesResource, "foo.root-other", "root:other", "foo")},
+			attr:     newAttr(cwt, nil, "clusterworkspacetypes", "foo", "", admission.Create),
+			wantReservations: []string{
+				reservation.ReservationName(reservation.ClusterWorkspaceTypesResource, "foo.root-other"),
+				reservation.ReservationName(reservation.ClusterWorkspaceTypesResource, "foo.root-org"),
+			},
+		},
+		{
+			name: "dry-run creation of a ClusterWorkspaceType is not reserved",
+			attr: newDryRunAttr(cwt, nil, "clusterworkspacetypes", "foo", "", admission.Create, true),
+		},
+		{
+			name:             "dry-run deletion of a ClusterWorkspaceType is not released",
+			existing:         []runtime.Object{newReservation(reservation.ClusterWorkspaceTypesResource, "foo.root-org", "root:org:ws", "foo")},
+			attr:             newDryRunAttr(nil, nil, "clusterworkspacetypes", "foo", "", admission.Delete, true),
+			wantReservations: []string{reservation.ReservationName(reservation.ClusterWorkspaceTypesResource, "foo.root-org")},
+		},
+		{
+			name: "bootstrapped ClusterWorkspaceType is not reserved",
+			attr: newAttr(bootstrapped, nil, "clusterworkspacetypes", "universal", "", admission.Create),
+		},
+		{
+			name:     "deleted ClusterWorkspaceType is released",
+			existing: []runtime.Object{newReservation(reservation.ClusterWorkspaceTypesResource, "foo.root-org", "root:org:ws", "foo")},
+			attr:     newAttr(nil, nil, "clusterworkspacetypes", "foo", "", admission.Delete),
+		},
+		{
+			name:             "new identity of an APIExport is reserved",
+			attr:             newAttr(apiExport("foo", "abc"), apiExport("foo", ""), "apiexports", "foo", "status", admission.Update),
+			wantReservations: []string{reservation.ReservationName(reservation.APIExportIdentitiesResource, "abc")},
+		},
+		{
+			name: "previous identities of an APIExport are reserved",
+			existing: []runtime.Object{
+				newReservation(reservation.APIExportIdentitiesResource, "abc", "root:org:ws", "foo"),
+			},
+			attr: newAttr(apiExport("foo", "def", "abc", "xyz"), apiExport("foo", "abc"), "apiexports", "foo", "status", admission.Update),
+			wantReservations: []string{
+				reservation.ReservationName(reservation.APIExportIdentitiesResource, "abc"),
+				reservation.ReservationName(reservation.APIExportIdentitiesResource, "def"),
+				reservation.ReservationName(reservation.APIExportIdentitiesResource, "xyz"),
+			},
+		},
+		{
+			name:             "previous identity of another APIExport is rejected",
+			existing:         []runtime.Object{newReservation(reservation.APIExportIdentitiesResource, "xyz", "root:org:other", "bar")},
+			attr:             newAttr(apiExport("foo", "abc", "xyz"), apiExport("foo", ""), "apiexports", "foo", "status", admission.Update),
+			wantErr:          true,
+			wantReservations: []string{reservation.ReservationName(reservation.APIExportIdentitiesResource, "abc"), reservation.ReservationName(reservation.APIExportIdentitiesResource, "xyz")},
+		},
+		{
+			name: "dry-run identity of an APIExport is not reserved",
+			attr: newDryRunAttr(apiExport("foo", "abc"), apiExport("foo", ""), "apiexports", "foo", "status", admission.Update, true),
+		},
+		{
+			name:             "identity of another APIExport is rejected",
+			existing:         []runtime.Object{newReservation(reservation.APIExportIdentitiesResource, "abc", "root:org:other", "bar")},
+			attr:             newAttr(apiExport("foo", "abc"), apiExport("foo", ""), "apiexports", "foo", "status", admission.Update),
+			wantErr:          true,
+			wantReservations: []string{reservation.ReservationName(reservation.APIExportIdentitiesResource, "abc")},
+		},
+		{
+			name:             "unchanged identity of an APIExport is not reserved again",
+			existing:         []runtime.Object{newReservation(reservation.APIExportIdentitiesResource, "abc", "root:org:other", "foo")},
+			attr:             newAttr(apiExport("foo", "abc"), apiExport("foo", "abc"), "apiexports", "foo", "status", admission.Update),
+			wantReservations: []string{reservation.ReservationName(reservation.APIExportIdentitiesResource, "abc")},
+		},
+		{
+			name:     "deleted APIExport is released",
+			existing: []runtime.Object{newReservation(reservation.APIExportIdentitiesResource, "abc", "root:org:ws", "foo")},
+			attr:     newAttr(nil, nil, "apiexports", "foo", "", admission.Delete),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := kcpfakeclient.NewSimpleClientset(tt.existing...)
+			o := &nameReservation{
+				Handler:  admission.NewHandler(admission.Create, admission.Update, admission.Delete),
+				reserver: reservation.NewReserver(client.TenancyV1alpha1().NameReservations()),
+			}
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org:ws")})
+			err := o.Validate(ctx, tt.attr, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			reservations, err := client.TenancyV1alpha1().NameReservations().List(ctx, metav1.ListOptions{})
+			require.NoError(t, err)
+			var got []string
+			for _, r := range reservations.Items {
+				got = append(got, r.Name)
+			}
+			require.ElementsMatch(t, tt.wantReservations, got)
+		})
+	}
+}
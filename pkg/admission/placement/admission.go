@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/admission"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// Validate Placement creation and updates for
+// - a set namespace selector
+// - valid namespace and location label selectors
+// - a valid pool name.
+
+const (
+	PluginName = "workload.kcp.dev/Placement"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &placement{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+type placement struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&placement{})
+
+// Validate ensures that the label selectors of the placement are valid.
+func (o *placement) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
+	if a.GetResource().GroupResource() != workloadv1alpha1.Resource("placements") {
+		return nil
+	}
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	placement := &workloadv1alpha1.Placement{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, placement); err != nil {
+		return fmt.Errorf("failed to convert unstructured to Placement: %w", err)
+	}
+
+	if errs := validatePlacementSpec(&placement.Spec, field.NewPath("spec")); len(errs) > 0 {
+		return admission.NewForbidden(a, errs.ToAggregate())
+	}
+
+	return nil
+}
+
+// validatePlacementSpec validates the label selectors and the pool of a placement.
+func validatePlacementSpec(spec *workloadv1alpha1.PlacementSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if spec.NamespaceSelector == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("namespaceSelector"), "an empty selector selects all namespaces"))
+	} else {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(spec.NamespaceSelector, fldPath.Child("namespaceSelector"))...)
+	}
+	if spec.LocationSelector != nil {
+		allErrs = append(allErrs, metav1validation.ValidateLabelSelector(spec.LocationSelector, fldPath.Child("locationSelector"))...)
+	}
+	if spec.Pool != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(spec.Pool) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("pool"), spec.Pool, msg))
+		}
+	}
+
+	return allErrs
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func createAttr(p *workloadv1alpha1.Placement) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(p),
+		nil,
+		workloadv1alpha1.Kind("Placement").WithVersion("v1alpha1"),
+		"",
+		p.Name,
+		workloadv1alpha1.Resource("placements").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    workloadv1alpha1.PlacementSpec
+		wantErr bool
+	}{
+		{
+			name: "empty namespace selector selects all namespaces",
+			spec: workloadv1alpha1.PlacementSpec{
+				NamespaceSelector: &metav1.LabelSelector{},
+			},
+		},
+		{
+			name: "namespace and location selectors",
+			spec: workloadv1alpha1.PlacementSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+				LocationSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"east", "west"}},
+					},
+				},
+			},
+		},
+		{
+			name: "pool",
+			spec: workloadv1alpha1.PlacementSpec{
+				NamespaceSelector: &metav1.LabelSelector{},
+				Pool:              "east-failover",
+			},
+		},
+		{
+			name: "invalid pool name",
+			spec: workloadv1alpha1.PlacementSpec{
+				NamespaceSelector: &metav1.LabelSelector{},
+				Pool:              "East_Failover",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing namespace selector",
+			spec:    workloadv1alpha1.PlacementSpec{},
+			wantErr: true,
+		},
+		{
+			name: "invalid namespace selector label",
+			spec: workloadv1alpha1.PlacementSpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "not valid"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid location selector operator",
+			spec: workloadv1alpha1.PlacementSpec{
+				NamespaceSelector: &metav1.LabelSelector{},
+				LocationSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "region", Operator: "Near", Values: []string{"east"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "values with exists operator",
+			spec: workloadv1alpha1.PlacementSpec{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "team", Operator: metav1.LabelSelectorOpExists, Values: []string{"a"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &placement{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}
+			a := createAttr(&workloadv1alpha1.Placement{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: tt.spec,
+			})
+			if err := o.Validate(context.Background(), a, nil); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
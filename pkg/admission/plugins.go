@@ -39,12 +39,25 @@ import (
 	"k8s.io/kubernetes/plugin/pkg/admission/storage/storageclass/setdefault"
 	"k8s.io/kubernetes/plugin/pkg/admission/storage/storageobjectinuseprotection"
 
+	"github.com/kcp-dev/kcp/pkg/admission/accessrequest"
 	"github.com/kcp-dev/kcp/pkg/admission/apibinding"
+	"github.com/kcp-dev/kcp/pkg/admission/apiexport"
+	"github.com/kcp-dev/kcp/pkg/admission/apiidentity"
 	"github.com/kcp-dev/kcp/pkg/admission/apiresourceschema"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspace"
+	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacedryrun"
+	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacequota"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspaceshard"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacetype"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacetypeexists"
+	"github.com/kcp-dev/kcp/pkg/admission/namereservation"
+	"github.com/kcp-dev/kcp/pkg/admission/placement"
+	"github.com/kcp-dev/kcp/pkg/admission/systemmetadata"
+	"github.com/kcp-dev/kcp/pkg/admission/workspaceapigroups"
+	"github.com/kcp-dev/kcp/pkg/admission/workspaceclone"
+	"github.com/kcp-dev/kcp/pkg/admission/workspacelimits"
+	"github.com/kcp-dev/kcp/pkg/admission/workspacerequestset"
+	"github.com/kcp-dev/kcp/pkg/admission/workspacetokenaudience"
 )
 
 // AllOrderedPlugins is the list of all the plugins in order.
@@ -54,7 +67,20 @@ var AllOrderedPlugins = beforeWebhooks(kubeapiserveroptions.AllOrderedPlugins,
 	clusterworkspaceshard.PluginName,
 	clusterworkspacetype.PluginName,
 	clusterworkspacetypeexists.PluginName,
+	clusterworkspacedryrun.PluginName,
 	apibinding.PluginName,
+	apiexport.PluginName,
+	placement.PluginName,
+	clusterworkspacequota.PluginName,
+	apiidentity.PluginName,
+	accessrequest.PluginName,
+	workspacerequestset.PluginName,
+	workspaceclone.PluginName,
+	workspacetokenaudience.PluginName,
+	workspacelimits.PluginName,
+	workspaceapigroups.PluginName,
+	namereservation.PluginName,
+	systemmetadata.PluginName,
 )
 
 func beforeWebhooks(recommended []string, plugins ...string) []string {
@@ -76,8 +102,21 @@ func RegisterAllKcpAdmissionPlugins(plugins *admission.Plugins) {
 	clusterworkspaceshard.Register(plugins)
 	clusterworkspacetype.Register(plugins)
 	clusterworkspacetypeexists.Register(plugins)
+	clusterworkspacedryrun.Register(plugins)
 	apiresourceschema.Register(plugins)
 	apibinding.Register(plugins)
+	apiexport.Register(plugins)
+	placement.Register(plugins)
+	clusterworkspacequota.Register(plugins)
+	apiidentity.Register(plugins)
+	accessrequest.Register(plugins)
+	workspacerequestset.Register(plugins)
+	workspaceclone.Register(plugins)
+	workspacetokenaudience.Register(plugins)
+	workspacelimits.Register(plugins)
+	workspaceapigroups.Register(plugins)
+	namereservation.Register(plugins)
+	systemmetadata.Register(plugins)
 }
 
 var defaultOnPluginsInKcp = sets.NewString(
@@ -94,8 +133,21 @@ var defaultOnPluginsInKcp = sets.NewString(
 	clusterworkspaceshard.PluginName,
 	clusterworkspacetype.PluginName,
 	clusterworkspacetypeexists.PluginName,
+	clusterworkspacedryrun.PluginName,
 	apiresourceschema.PluginName,
 	apibinding.PluginName,
+	apiexport.PluginName,
+	placement.PluginName,
+	clusterworkspacequota.PluginName,
+	apiidentity.PluginName,
+	accessrequest.PluginName,
+	workspacerequestset.PluginName,
+	workspaceclone.PluginName,
+	workspacetokenaudience.PluginName,
+	workspacelimits.PluginName,
+	workspaceapigroups.PluginName,
+	namereservation.PluginName,
+	systemmetadata.PluginName,
 )
 
 // defaultOnKubePluginsInKube is a copy of kubeapiserveroptions.defaultOnKubePlugins.
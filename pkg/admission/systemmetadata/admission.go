@@ -0,0 +1,214 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemmetadata
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/initialization"
+	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
+)
+
+// Protect the labels and annotations maintained by kcp from being set or changed by users outside
+// of system:masters, in all workspaces. The controllers owning them would overwrite them anyway.
+// The syncer, which sets the upsynced-from label on the objects it reflects into its workspace,
+// is allowed through the service account recorded on a WorkloadCluster of that workspace.
+//
+// The cluster label of namespaces is only protected while the built-in scheduler owns it,
+// i.e. unless scheduling is disabled or handed off to an external scheduler.
+
+const (
+	PluginName = "kcp.dev/SystemMetadata"
+)
+
+var (
+	// systemLabels are the labels maintained by kcp on objects of any resource.
+	systemLabels = sets.NewString(
+		workloadv1alpha1.UpsyncedFromLabel,
+		initialization.InitializerLabelKey,
+	)
+	// systemAnnotations are the annotations maintained by kcp on objects of any resource.
+	systemAnnotations = sets.NewString(
+		workloadv1alpha1.ReplicasManagedDownstreamAnnotation,
+	)
+	// creatableLabels are the system labels users may set on creation: the initializer locks
+	// are created by initialization controllers, which may run with the credentials of users.
+	creatableLabels = sets.NewString(
+		initialization.InitializerLabelKey,
+	)
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &systemMetadata{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+type systemMetadata struct {
+	*admission.Handler
+
+	workloadClusterLister workloadlisters.WorkloadClusterLister
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&systemMetadata{})
+var _ = admission.InitializationValidator(&systemMetadata{})
+var _ = kcpinitializers.WantsKcpInformers(&systemMetadata{})
+
+// Validate ensures that only privileged users and the syncer add, change or remove the labels and
+// annotations maintained by kcp.
+func (o *systemMetadata) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetSubresource() != "" || isPrivileged(a.GetUserInfo()) {
+		return nil
+	}
+
+	obj, err := meta.Accessor(a.GetObject())
+	if err != nil {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	protectedLabels := systemLabels
+	var old metav1.Object = &metav1.ObjectMeta{}
+	if a.GetOperation() == admission.Create {
+		protectedLabels = protectedLabels.Difference(creatableLabels)
+	} else {
+		old, err = meta.Accessor(a.GetOldObject())
+		if err != nil {
+			return fmt.Errorf("unexpected type %T", a.GetOldObject())
+		}
+	}
+
+	if a.GetResource().GroupResource() == corev1.Resource("namespaces") && isScheduledByKcp(obj) {
+		protectedLabels = protectedLabels.Union(sets.NewString(nscontroller.ClusterLabel))
+	}
+
+	var changeErr error
+	if key, changed := changedKey(protectedLabels, old.GetLabels(), obj.GetLabels()); changed {
+		changeErr = fmt.Errorf("label %q is managed by kcp and cannot be set or changed", key)
+	} else if key, changed := changedKey(systemAnnotations, old.GetAnnotations(), obj.GetAnnotations()); changed {
+		changeErr = fmt.Errorf("annotation %q is managed by kcp and cannot be set or changed", key)
+	}
+	if changeErr == nil {
+		return nil
+	}
+
+	isSyncer, err := o.isSyncer(ctx, a.GetUserInfo())
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	if !isSyncer {
+		return admission.NewForbidden(a, changeErr)
+	}
+	return nil
+}
+
+// isScheduledByKcp returns whether the built-in scheduler maintains the cluster label of the namespace.
+func isScheduledByKcp(ns metav1.Object) bool {
+	if _, disabled := ns.GetLabels()[nscontroller.SchedulingDisabledLabel]; disabled {
+		return false
+	}
+	return ns.GetAnnotations()[nscontroller.SchedulerAnnotation] == ""
+}
+
+// changedKey returns the first of the given keys, in order, that is added, changed or removed.
+func changedKey(keys sets.String, old, new map[string]string) (string, bool) {
+	for _, key := range keys.List() {
+		oldValue, oldFound := old[key]
+		newValue, newFound := new[key]
+		if oldFound != newFound || oldValue != newValue {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func isPrivileged(userInfo user.Info) bool {
+	if userInfo == nil {
+		return false
+	}
+	for _, group := range userInfo.GetGroups() {
+		if group == user.SystemPrivilegedGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// isSyncer returns whether the user is the service account of the workspace of the request that
+// is recorded on one of its WorkloadClusters as the service account of their syncer.
+func (o *systemMetadata) isSyncer(ctx context.Context, userInfo user.Info) (bool, error) {
+	if userInfo == nil {
+		return false, nil
+	}
+	namespace, name, err := serviceaccount.SplitUsername(userInfo.GetName())
+	if err != nil {
+		return false, nil
+	}
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error determining workspace: %w", err)
+	}
+	if !sets.NewString(userInfo.GetExtra()[serviceaccount.ClusterNameKey]...).Has(cluster.Name.String()) {
+		return false, nil
+	}
+
+	if !o.WaitForReady() {
+		return false, fmt.Errorf("not yet ready to handle request")
+	}
+	workloadClusters, err := o.workloadClusterLister.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, workloadCluster := range workloadClusters {
+		if logicalcluster.From(workloadCluster) == cluster.Name && workloadCluster.Annotations[workloadv1alpha1.SyncerServiceAccountAnnotation] == namespace+"/"+name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (o *systemMetadata) ValidateInitialization() error {
+	if o.workloadClusterLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a WorkloadCluster lister")
+	}
+	return nil
+}
+
+func (o *systemMetadata) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	o.SetReadyFunc(informers.Workload().V1alpha1().WorkloadClusters().Informer().HasSynced)
+	o.workloadClusterLister = informers.Workload().V1alpha1().WorkloadClusters().Lister()
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package systemmetadata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/initialization"
+	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
+)
+
+func newAttr(obj, old runtime.Object, gvr schema.GroupVersionResource, subresource string, userInfo user.Info) admission.Attributes {
+	return admission.NewAttributesRecord(obj, old, schema.GroupVersionKind{}, "", "foo", gvr, subresource, admission.Update, nil, false, userInfo)
+}
+
+func newCreateAttr(obj runtime.Object, gvr schema.GroupVersionResource, userInfo user.Info) admission.Attributes {
+	return admission.NewAttributesRecord(obj, nil, schema.GroupVersionKind{}, "", "foo", gvr, "", admission.Create, nil, false, userInfo)
+}
+
+func namespace(labels, annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: labels, Annotations: annotations}}
+}
+
+func TestValidate(t *testing.T) {
+	namespaces := corev1.SchemeGroupVersion.WithResource("namespaces")
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	deployment := func(labels, annotations map[string]string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("apps/v1")
+		u.SetKind("Deployment")
+		u.SetName("foo")
+		u.SetLabels(labels)
+		u.SetAnnotations(annotations)
+		return u
+	}
+	unprivileged := &user.DefaultInfo{Name: "user", Groups: []string{"system:authenticated"}}
+	admin := &user.DefaultInfo{Name: "admin", Groups: []string{"system:masters"}}
+	serviceAccount := func(cluster, namespace, name string) user.Info {
+		return &user.DefaultInfo{
+			Name:   serviceaccount.MakeUsername(namespace, name),
+			Groups: []string{"system:serviceaccounts"},
+			Extra:  map[string][]string{serviceaccount.ClusterNameKey: {cluster}},
+		}
+	}
+	syncer := serviceAccount("root:org:ws", "default", "syncer")
+	leases := coordinationv1.SchemeGroupVersion.WithResource("leases")
+
+	tests := []struct {
+		name    string
+		attr    admission.Attributes
+		wantErr bool
+	}{
+		{
+			name: "user changes other labels",
+			attr: newAttr(
+				namespace(map[string]string{nscontroller.ClusterLabel: "east", "app": "bar"}, nil),
+				namespace(map[string]string{nscontroller.ClusterLabel: "east", "app": "foo"}, nil),
+				namespaces, "", unprivileged),
+		},
+		{
+			name: "user changes the cluster of a scheduled namespace",
+			attr: newAttr(
+				namespace(map[string]string{nscontroller.ClusterLabel: "west"}, nil),
+				namespace(map[string]string{nscontroller.ClusterLabel: "east"}, nil),
+				namespaces, "", unprivileged),
+			wantErr: true,
+		},
+		{
+			name: "user removes the cluster of a scheduled namespace",
+			attr: newAttr(
+				namespace(nil, nil),
+				namespace(map[string]string{nscontroller.ClusterLabel: "east"}, nil),
+				namespaces, "", unprivileged),
+			wantErr: true,
+		},
+		{
+			name: "admin changes the cluster of a scheduled namespace",
+			attr: newAttr(
+				namespace(map[string]string{nscontroller.ClusterLabel: "west"}, nil),
+				namespace(map[string]string{nscontroller.ClusterLabel: "east"}, nil),
+				namespaces, "", admin),
+		},
+		{
+			name: "user changes the cluster of a namespace with scheduling disabled",
+			attr: newAttr(
+				namespace(map[string]string{nscontroller.ClusterLabel: "west", nscontroller.SchedulingDisabledLabel: ""}, nil),
+				namespace(map[string]string{nscontroller.ClusterLabel: "east"}, nil),
+				namespaces, "", unprivileged),
+		},
+		{
+			name: "user changes the cluster of an externally scheduled namespace",
+			attr: newAttr(
+				namespace(map[string]string{nscontroller.ClusterLabel: "west"}, map[string]string{nscontroller.SchedulerAnnotation: "mine"}),
+				namespace(map[string]string{nscontroller.ClusterLabel: "east"}, map[string]string{nscontroller.SchedulerAnnotation: "mine"}),
+				namespaces, "", unprivileged),
+		},
+		{
+			name: "user changes the cluster of a deployment",
+			attr: newAttr(
+				deployment(map[string]string{nscontroller.ClusterLabel: "west"}, nil),
+				deployment(map[string]string{nscontroller.ClusterLabel: "east"}, nil),
+				deployments, "", unprivileged),
+		},
+		{
+			name: "user adds the upsynced-from label",
+			attr: newAttr(
+				deployment(map[string]string{workloadv1alpha1.UpsyncedFromLabel: "east"}, nil),
+				deployment(nil, nil),
+				deployments, "", unprivileged),
+			wantErr: true,
+		},
+		{
+			name: "user removes the replicas-managed-downstream annotation",
+			attr: newAttr(
+				deployment(nil, nil),
+				deployment(nil, map[string]string{workloadv1alpha1.ReplicasManagedDownstreamAnnotation: "foo"}),
+				deployments, "", unprivileged),
+			wantErr: true,
+		},
+		{
+			name: "admin removes the replicas-managed-downstream annotation",
+			attr: newAttr(
+				deployment(nil, nil),
+				deployment(nil, map[string]string{workloadv1alpha1.ReplicasManagedDownstreamAnnotation: "foo"}),
+				deployments, "", admin),
+		},
+		{
+			name: "syncer adds the upsynced-from label",
+			attr: newAttr(
+				deployment(map[string]string{workloadv1alpha1.UpsyncedFromLabel: "east"}, nil),
+				deployment(nil, nil),
+				deployments, "", syncer),
+		},
+		{
+			name: "service account named syncer not recorded on a WorkloadCluster adds the upsynced-from label",
+			attr: newAttr(
+				deployment(map[string]string{workloadv1alpha1.UpsyncedFromLabel: "east"}, nil),
+				deployment(nil, nil),
+				deployments, "", serviceAccount("root:org:ws", "other", "syncer")),
+			wantErr: true,
+		},
+		{
+			name: "syncer service account of another workspace adds the upsynced-from label",
+			attr: newAttr(
+				deployment(map[string]string{workloadv1alpha1.UpsyncedFromLabel: "east"}, nil),
+				deployment(nil, nil),
+				deployments, "", serviceAccount("root:org:other", "default", "syncer")),
+			wantErr: true,
+		},
+		{
+			name:    "user creates a deployment with the upsynced-from label",
+			attr:    newCreateAttr(deployment(map[string]string{workloadv1alpha1.UpsyncedFromLabel: "east"}, nil), deployments, unprivileged),
+			wantErr: true,
+		},
+		{
+			name: "syncer creates a deployment with the upsynced-from label",
+			attr: newCreateAttr(deployment(map[string]string{workloadv1alpha1.UpsyncedFromLabel: "east"}, nil), deployments, syncer),
+		},
+		{
+			name:    "user creates a deployment with the replicas-managed-downstream annotation",
+			attr:    newCreateAttr(deployment(nil, map[string]string{workloadv1alpha1.ReplicasManagedDownstreamAnnotation: "foo"}), deployments, unprivileged),
+			wantErr: true,
+		},
+		{
+			name:    "user creates a scheduled namespace with a cluster",
+			attr:    newCreateAttr(namespace(map[string]string{nscontroller.ClusterLabel: "east"}, nil), namespaces, unprivileged),
+			wantErr: true,
+		},
+		{
+			name: "user creates a namespace with scheduling disabled with a cluster",
+			attr: newCreateAttr(namespace(map[string]string{nscontroller.ClusterLabel: "east", nscontroller.SchedulingDisabledLabel: ""}, nil), namespaces, unprivileged),
+		},
+		{
+			name: "user creates a deployment with other labels",
+			attr: newCreateAttr(deployment(map[string]string{"app": "foo"}, nil), deployments, unprivileged),
+		},
+		{
+			name: "initialization controller creates an initializer lock",
+			attr: newCreateAttr(&coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{initialization.InitializerLabelKey: "foo"}},
+			}, leases, unprivileged),
+		},
+		{
+			name: "status updates are ignored",
+			attr: newAttr(
+				deployment(nil, nil),
+				deployment(nil, map[string]string{workloadv1alpha1.ReplicasManagedDownstreamAnnotation: "foo"}),
+				deployments, "status", unprivileged),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, indexer.Add(&workloadv1alpha1.WorkloadCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "east",
+					ClusterName: "root:org:ws",
+					Annotations: map[string]string{workloadv1alpha1.SyncerServiceAccountAnnotation: "default/syncer"},
+				},
+			}))
+			require.NoError(t, indexer.Add(&workloadv1alpha1.WorkloadCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "west", ClusterName: "root:org:ws"},
+			}))
+			o := &systemMetadata{
+				Handler:               admission.NewHandler(admission.Create, admission.Update),
+				workloadClusterLister: workloadlisters.NewWorkloadClusterLister(indexer),
+			}
+			ctx := request.WithCluster(context.TODO(), request.Cluster{Name: logicalcluster.New("root:org:ws")})
+			err := o.Validate(ctx, tt.attr, nil)
+			require.Equal(t, tt.wantErr, err != nil, "unexpected error: %v", err)
+		})
+	}
+}
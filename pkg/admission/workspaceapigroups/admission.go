@@ -0,0 +1,257 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceapigroups
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/clusters"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	apisv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	tenancyv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+// Restrict the API groups added to a workspace to the allowedAPIGroups of its ClusterWorkspaceType:
+// - CustomResourceDefinitions are rejected on creation if their group is not allowed.
+// - APIBindings are rejected on creation, and on updates changing the reference, if a group of the
+//   latest resource schemas of the referenced APIExport is not allowed.
+//
+// Schemas added to an APIExport later are bound to existing APIBindings by the APIBinding controller,
+// which refuses to bind them if their group is not allowed.
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspaceAPIGroups"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &workspaceAPIGroups{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+type workspaceAPIGroups struct {
+	*admission.Handler
+
+	workspaceLister tenancyv1alpha1lister.ClusterWorkspaceLister
+	typeLister      tenancyv1alpha1lister.ClusterWorkspaceTypeLister
+	exportLister    apisv1alpha1lister.APIExportLister
+	schemaLister    apisv1alpha1lister.APIResourceSchemaLister
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&workspaceAPIGroups{})
+var _ = admission.InitializationValidator(&workspaceAPIGroups{})
+var _ = kcpinitializers.WantsKcpInformers(&workspaceAPIGroups{})
+
+// Validate rejects CustomResourceDefinitions and APIBindings adding API groups which the type of the
+// workspace does not allow.
+func (o *workspaceAPIGroups) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetSubresource() != "" {
+		return nil
+	}
+
+	var groups func(clusterName logicalcluster.LogicalCluster) ([]string, error)
+	switch a.GetResource().GroupResource() {
+	case apiextensions.Resource("customresourcedefinitions"):
+		if a.GetOperation() != admission.Create {
+			return nil // the group is immutable
+		}
+		groups = func(logicalcluster.LogicalCluster) ([]string, error) {
+			group, err := crdGroup(a.GetObject())
+			if err != nil {
+				return nil, err
+			}
+			return []string{group}, nil
+		}
+	case apisv1alpha1.Resource("apibindings"):
+		apiBinding, err := toAPIBinding(a.GetObject())
+		if err != nil {
+			return err
+		}
+		if a.GetOperation() == admission.Update {
+			old, err := toAPIBinding(a.GetOldObject())
+			if err != nil {
+				return err
+			}
+			if equalReferences(old.Spec.Reference, apiBinding.Spec.Reference) {
+				return nil
+			}
+		}
+		groups = func(clusterName logicalcluster.LogicalCluster) ([]string, error) {
+			return o.exportGroups(clusterName, apiBinding.Spec.Reference)
+		}
+	default:
+		return nil
+	}
+
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error determining workspace: %w", err))
+	}
+
+	cwt, err := o.workspaceType(cluster.Name)
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	if cwt == nil || len(cwt.Spec.AllowedAPIGroups) == 0 {
+		return nil
+	}
+
+	requested, err := groups(cluster.Name)
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	if denied := sets.NewString(requested...).Difference(sets.NewString(cwt.Spec.AllowedAPIGroups...)); denied.Len() > 0 {
+		return admission.NewForbidden(a, fmt.Errorf("API groups %s are not allowed in workspaces of type %q", strings.Join(quoted(denied.List()), ", "), cwt.Name))
+	}
+	return nil
+}
+
+// workspaceType returns the ClusterWorkspaceType of the given workspace, or nil if the workspace
+// is not known or its type does not exist.
+func (o *workspaceAPIGroups) workspaceType(clusterName logicalcluster.LogicalCluster) (*tenancyv1alpha1.ClusterWorkspaceType, error) {
+	parent, name := clusterName.Split()
+	if parent.Empty() {
+		return nil, nil
+	}
+	cw, err := o.workspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cwt, err := o.typeLister.Get(clusters.ToClusterAwareKey(parent, strings.ToLower(cw.Spec.Type)))
+	if apierrors.IsNotFound(err) {
+		return nil, nil // e.g. Universal
+	} else if err != nil {
+		return nil, err
+	}
+	return cwt, nil
+}
+
+// exportGroups returns the API groups of the latest resource schemas of the referenced APIExport.
+func (o *workspaceAPIGroups) exportGroups(clusterName logicalcluster.LogicalCluster, reference apisv1alpha1.ExportReference) ([]string, error) {
+	if reference.Workspace == nil {
+		return nil, nil
+	}
+	org, hasParent := clusterName.Parent()
+	if !hasParent {
+		return nil, fmt.Errorf("%q is not a valid workspace name", clusterName)
+	}
+	exportClusterName := org.Join(reference.Workspace.WorkspaceName)
+
+	export, err := o.exportLister.Get(clusters.ToClusterAwareKey(exportClusterName, reference.Workspace.ExportName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine the API groups of APIExport %s|%s: %w", exportClusterName, reference.Workspace.ExportName, err)
+	}
+	var groups []string
+	for _, schemaName := range export.Spec.LatestResourceSchemas {
+		schema, err := o.schemaLister.Get(clusters.ToClusterAwareKey(exportClusterName, schemaName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine the API groups of APIExport %s|%s: %w", exportClusterName, reference.Workspace.ExportName, err)
+		}
+		groups = append(groups, schema.Spec.Group)
+	}
+	return groups, nil
+}
+
+func equalReferences(a, b apisv1alpha1.ExportReference) bool {
+	if a.Workspace == nil || b.Workspace == nil {
+		return a.Workspace == nil && b.Workspace == nil
+	}
+	return *a.Workspace == *b.Workspace
+}
+
+func crdGroup(obj runtime.Object) (string, error) {
+	switch crd := obj.(type) {
+	case *apiextensions.CustomResourceDefinition:
+		return crd.Spec.Group, nil
+	case *unstructured.Unstructured:
+		group, _, err := unstructured.NestedString(crd.Object, "spec", "group")
+		return group, err
+	}
+	return "", fmt.Errorf("unexpected type %T", obj)
+}
+
+func toAPIBinding(obj runtime.Object) (*apisv1alpha1.APIBinding, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	apiBinding := &apisv1alpha1.APIBinding{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, apiBinding); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to APIBinding: %w", err)
+	}
+	return apiBinding, nil
+}
+
+func quoted(ss []string) []string {
+	ret := make([]string, 0, len(ss))
+	for _, s := range ss {
+		ret = append(ret, fmt.Sprintf("%q", s))
+	}
+	return ret
+}
+
+func (o *workspaceAPIGroups) ValidateInitialization() error {
+	if o.workspaceLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspace lister")
+	}
+	if o.typeLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspaceType lister")
+	}
+	if o.exportLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs an APIExport lister")
+	}
+	if o.schemaLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs an APIResourceSchema lister")
+	}
+	return nil
+}
+
+func (o *workspaceAPIGroups) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	workspacesReady := informers.Tenancy().V1alpha1().ClusterWorkspaces().Informer().HasSynced
+	typesReady := informers.Tenancy().V1alpha1().ClusterWorkspaceTypes().Informer().HasSynced
+	exportsReady := informers.Apis().V1alpha1().APIExports().Informer().HasSynced
+	schemasReady := informers.Apis().V1alpha1().APIResourceSchemas().Informer().HasSynced
+	o.SetReadyFunc(func() bool {
+		return workspacesReady() && typesReady() && exportsReady() && schemasReady()
+	})
+	o.workspaceLister = informers.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+	o.typeLister = informers.Tenancy().V1alpha1().ClusterWorkspaceTypes().Lister()
+	o.exportLister = informers.Apis().V1alpha1().APIExports().Lister()
+	o.schemaLister = informers.Apis().V1alpha1().APIResourceSchemas().Lister()
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceapigroups
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	apisv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	tenancyv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func createCRDAttr(group string) admission.Attributes {
+	return admission.NewAttributesRecord(
+		&apiextensions.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "widgets." + group},
+			Spec:       apiextensions.CustomResourceDefinitionSpec{Group: group},
+		},
+		nil,
+		apiextensions.Kind("CustomResourceDefinition").WithVersion("v1"),
+		"",
+		"widgets."+group,
+		apiextensions.Resource("customresourcedefinitions").WithVersion("v1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func newBinding(exportName string) *apisv1alpha1.APIBinding {
+	return &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "binding"},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference: apisv1alpha1.ExportReference{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{WorkspaceName: "exports", ExportName: exportName},
+			},
+		},
+	}
+}
+
+func bindingAttr(binding, old *apisv1alpha1.APIBinding) admission.Attributes {
+	op := admission.Create
+	var oldObj runtime.Object
+	if old != nil {
+		op = admission.Update
+		oldObj = helpers.ToUnstructuredOrDie(old)
+	}
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(binding),
+		oldObj,
+		apisv1alpha1.Kind("APIBinding").WithVersion("v1alpha1"),
+		"",
+		binding.Name,
+		apisv1alpha1.Resource("apibindings").WithVersion("v1alpha1"),
+		"",
+		op,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{},
+	)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		noType  bool
+		attr    admission.Attributes
+		wantErr string
+	}{
+		{
+			name:   "type does not exist",
+			noType: true,
+			attr:   createCRDAttr("example.io"),
+		},
+		{
+			name: "type without allowed groups",
+			attr: createCRDAttr("example.io"),
+		},
+		{
+			name:    "allowed CRD group",
+			allowed: []string{"example.io"},
+			attr:    createCRDAttr("example.io"),
+		},
+		{
+			name:    "CRD group not allowed",
+			allowed: []string{"example.io"},
+			attr:    createCRDAttr("other.io"),
+			wantErr: `API groups "other.io" are not allowed in workspaces of type "restricted"`,
+		},
+		{
+			name:    "binding to an export with allowed groups",
+			allowed: []string{"example.io", "other.io"},
+			attr:    bindingAttr(newBinding("both"), nil),
+		},
+		{
+			name:    "binding to an export with a group not allowed",
+			allowed: []string{"example.io"},
+			attr:    bindingAttr(newBinding("both"), nil),
+			wantErr: `API groups "other.io" are not allowed in workspaces of type "restricted"`,
+		},
+		{
+			name:    "binding to an unknown export",
+			allowed: []string{"example.io"},
+			attr:    bindingAttr(newBinding("unknown"), nil),
+			wantErr: `failed to determine the API groups of APIExport root:org:exports|unknown`,
+		},
+		{
+			name:    "update changing the reference",
+			allowed: []string{"example.io"},
+			attr:    bindingAttr(newBinding("both"), newBinding("example")),
+			wantErr: `API groups "other.io" are not allowed in workspaces of type "restricted"`,
+		},
+		{
+			name:    "update keeping the reference",
+			allowed: []string{"example.io"},
+			attr:    bindingAttr(newBinding("both"), newBinding("both")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, workspaces.Add(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "ws", ClusterName: "root:org"},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Restricted"},
+			}))
+			types := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if !tt.noType {
+				require.NoError(t, types.Add(&tenancyv1alpha1.ClusterWorkspaceType{
+					ObjectMeta: metav1.ObjectMeta{Name: "restricted", ClusterName: "root:org"},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceTypeSpec{AllowedAPIGroups: tt.allowed},
+				}))
+			}
+			exports := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, exports.Add(&apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", ClusterName: "root:org:exports"},
+				Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.io"}},
+			}))
+			require.NoError(t, exports.Add(&apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{Name: "both", ClusterName: "root:org:exports"},
+				Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v1.widgets.example.io", "v1.gadgets.other.io"}},
+			}))
+			schemas := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, schemas.Add(&apisv1alpha1.APIResourceSchema{
+				ObjectMeta: metav1.ObjectMeta{Name: "v1.widgets.example.io", ClusterName: "root:org:exports"},
+				Spec:       apisv1alpha1.APIResourceSchemaSpec{Group: "example.io"},
+			}))
+			require.NoError(t, schemas.Add(&apisv1alpha1.APIResourceSchema{
+				ObjectMeta: metav1.ObjectMeta{Name: "v1.gadgets.other.io", ClusterName: "root:org:exports"},
+				Spec:       apisv1alpha1.APIResourceSchemaSpec{Group: "other.io"},
+			}))
+
+			o := &workspaceAPIGroups{
+				Handler:         admission.NewHandler(admission.Create, admission.Update),
+				workspaceLister: tenancyv1alpha1lister.NewClusterWorkspaceLister(workspaces),
+				typeLister:      tenancyv1alpha1lister.NewClusterWorkspaceTypeLister(types),
+				exportLister:    apisv1alpha1lister.NewAPIExportLister(exports),
+				schemaLister:    apisv1alpha1lister.NewAPIResourceSchemaLister(schemas),
+			}
+			require.NoError(t, o.ValidateInitialization())
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org:ws")})
+			err := o.Validate(ctx, tt.attr, nil)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceclone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspaceClone"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &workspaceClone{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+// workspaceClone records the creating user of WorkspaceClones. The workspace is cloned on behalf
+// of that user, i.e. subject to their permissions.
+type workspaceClone struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&workspaceClone{})
+var _ = admission.MutationInterface(&workspaceClone{})
+
+// Admit sets spec.user and spec.groups to the creating user on creation.
+func (o *workspaceClone) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("workspaceclones") {
+		return nil
+	}
+	if a.GetOperation() != admission.Create {
+		return nil
+	}
+
+	u, clone, err := workspaceCloneFrom(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	clone.Spec.User = a.GetUserInfo().GetName()
+	clone.Spec.Groups = a.GetUserInfo().GetGroups()
+	clone.Status = tenancyv1alpha1.WorkspaceCloneStatus{}
+
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(clone)
+	if err != nil {
+		return err
+	}
+	u.Object = raw
+
+	return nil
+}
+
+// Validate ensures that spec.user and spec.groups are the creating user, that the source and the
+// target differ, and that the spec is immutable.
+func (o *workspaceClone) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("workspaceclones") {
+		return nil
+	}
+
+	_, clone, err := workspaceCloneFrom(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	if a.GetOperation() == admission.Create {
+		if clone.Spec.User != a.GetUserInfo().GetName() || !equality.Semantic.DeepEqual(clone.Spec.Groups, a.GetUserInfo().GetGroups()) {
+			return admission.NewForbidden(a, errors.New("spec.user and spec.groups must be the creating user"))
+		}
+		if clone.Spec.Source == clone.Spec.Target {
+			return admission.NewForbidden(a, errors.New("spec.source and spec.target must differ"))
+		}
+		return nil
+	}
+
+	_, old, err := workspaceCloneFrom(a.GetOldObject())
+	if err != nil {
+		return err
+	}
+	if !equality.Semantic.DeepEqual(clone.Spec, old.Spec) {
+		return admission.NewForbidden(a, errors.New("spec is immutable"))
+	}
+
+	return nil
+}
+
+func workspaceCloneFrom(obj runtime.Object) (*unstructured.Unstructured, *tenancyv1alpha1.WorkspaceClone, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	clone := &tenancyv1alpha1.WorkspaceClone{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, clone); err != nil {
+		return nil, nil, fmt.Errorf("failed to convert unstructured to WorkspaceClone: %w", err)
+	}
+	return u, clone, nil
+}
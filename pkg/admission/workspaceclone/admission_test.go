@@ -0,0 +1,152 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceclone
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func createAttr(obj *tenancyv1alpha1.WorkspaceClone, u user.Info) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		nil,
+		tenancyv1alpha1.Kind("WorkspaceClone").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("workspaceclones").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		u,
+	)
+}
+
+func updateAttr(obj, old *tenancyv1alpha1.WorkspaceClone, u user.Info) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		helpers.ToUnstructuredOrDie(old),
+		tenancyv1alpha1.Kind("WorkspaceClone").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("workspaceclones").WithVersion("v1alpha1"),
+		"",
+		admission.Update,
+		&metav1.UpdateOptions{},
+		false,
+		u,
+	)
+}
+
+var (
+	alice = &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}}
+	bob   = &user.DefaultInfo{Name: "bob", Groups: []string{"system:authenticated"}}
+)
+
+func newClone(u user.Info) *tenancyv1alpha1.WorkspaceClone {
+	return &tenancyv1alpha1.WorkspaceClone{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging"},
+		Spec: tenancyv1alpha1.WorkspaceCloneSpec{
+			Source: "production",
+			Target: "staging",
+			User:   u.GetName(),
+			Groups: u.GetGroups(),
+		},
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	clone := newClone(bob)
+	clone.Status.Phase = tenancyv1alpha1.WorkspaceClonePhaseCompleted
+
+	a := createAttr(clone, alice)
+	o := &workspaceClone{Handler: admission.NewHandler(admission.Create, admission.Update)}
+	require.NoError(t, o.Admit(context.Background(), a, nil))
+
+	got := &tenancyv1alpha1.WorkspaceClone{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(a.GetObject().(*unstructured.Unstructured).Object, got)
+	require.NoError(t, err)
+	require.Equal(t, newClone(alice).Spec, got.Spec)
+	require.Equal(t, tenancyv1alpha1.WorkspaceCloneStatus{}, got.Status)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       admission.Attributes
+		wantErr bool
+	}{
+		{
+			name: "creates a clone for the creating user",
+			a:    createAttr(newClone(alice), alice),
+		},
+		{
+			name:    "rejects a clone for another user",
+			a:       createAttr(newClone(bob), alice),
+			wantErr: true,
+		},
+		{
+			name: "rejects a clone into the source",
+			a: createAttr(func() *tenancyv1alpha1.WorkspaceClone {
+				c := newClone(alice)
+				c.Spec.Target = c.Spec.Source
+				return c
+			}(), alice),
+			wantErr: true,
+		},
+		{
+			name: "allows metadata changes",
+			a: updateAttr(func() *tenancyv1alpha1.WorkspaceClone {
+				c := newClone(alice)
+				c.Labels = map[string]string{"env": "staging"}
+				return c
+			}(), newClone(alice), bob),
+		},
+		{
+			name: "rejects spec changes",
+			a: updateAttr(func() *tenancyv1alpha1.WorkspaceClone {
+				c := newClone(alice)
+				c.Spec.Target = "test"
+				return c
+			}(), newClone(alice), alice),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &workspaceClone{Handler: admission.NewHandler(admission.Create, admission.Update)}
+			err := o.Validate(context.Background(), tt.a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
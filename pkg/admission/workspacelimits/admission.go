@@ -0,0 +1,299 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacelimits
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clusters"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	tenancyv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+// Enforce the limits of the ClusterWorkspaceType of a workspace on the objects written to it:
+// objects larger than the maximum object size are rejected on create and update, and creates
+// are rejected when the workspace already holds the maximum number of objects of the resource.
+//
+// Only persisted resources are counted: resources that cannot be listed, like tokenreviews or
+// subjectaccessreviews, are not limited in number.
+//
+// Object counts are local to the shard. They are cached for a short time and incremented on
+// every admitted create, so a burst of creates can overshoot the limit by the objects deleted
+// or created concurrently within the cache period.
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspaceLimits"
+
+	// countTTL is how long an object count is used before it is listed again.
+	countTTL = 10 * time.Second
+
+	// countPageSize is the number of objects listed per request when counting.
+	countPageSize = 500
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &workspaceLimits{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				counts:  newCountCache(countTTL),
+			}, nil
+		})
+}
+
+type workspaceLimits struct {
+	*admission.Handler
+
+	workspaceLister tenancyv1alpha1lister.ClusterWorkspaceLister
+	typeLister      tenancyv1alpha1lister.ClusterWorkspaceTypeLister
+
+	counts *countCache
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&workspaceLimits{})
+var _ = admission.InitializationValidator(&workspaceLimits{})
+var _ = kcpinitializers.WantsKcpInformers(&workspaceLimits{})
+var _ = kcpinitializers.WantsDynamicClusterClient(&workspaceLimits{})
+
+// Validate rejects objects beyond the limits of the type of the workspace.
+func (o *workspaceLimits) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetObject() == nil {
+		return nil
+	}
+	if a.GetSubresource() != "" && a.GetSubresource() != "status" {
+		return nil
+	}
+
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error determining workspace: %w", err))
+	}
+
+	cwt, err := o.workspaceType(cluster.Name)
+	if err != nil {
+		return admission.NewForbidden(a, err)
+	}
+	if cwt == nil || cwt.Spec.Limits == nil {
+		return nil
+	}
+	limits := cwt.Spec.Limits
+
+	if limits.MaxObjectSize != nil {
+		bs, err := json.Marshal(a.GetObject())
+		if err != nil {
+			return admission.NewForbidden(a, fmt.Errorf("failed to determine the object size: %w", err))
+		}
+		if max := limits.MaxObjectSize.Value(); int64(len(bs)) > max {
+			return apierrors.NewRequestEntityTooLargeError(fmt.Sprintf("object of %d bytes exceeds the maximum object size of %s of workspace type %q", len(bs), limits.MaxObjectSize.String(), cwt.Name))
+		}
+	}
+
+	if a.GetOperation() != admission.Create || a.GetSubresource() != "" {
+		return nil
+	}
+	max, found := maxObjects(limits, a.GetResource().GroupResource())
+	if !found {
+		return nil
+	}
+	gvr := a.GetResource()
+	count, countable, err := o.counts.get(ctx, cluster.Name, gvr)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("failed to count %s: %w", gvr.GroupResource(), err))
+	}
+	if !countable {
+		return nil
+	}
+	if count >= max {
+		return admission.NewForbidden(a, fmt.Errorf("workspace already has the maximum of %d %s allowed by workspace type %q", max, gvr.GroupResource(), cwt.Name))
+	}
+	if !a.IsDryRun() {
+		o.counts.add(cluster.Name, gvr)
+	}
+
+	return nil
+}
+
+// workspaceType returns the ClusterWorkspaceType of the given workspace, or nil if the workspace
+// is not known or its type does not exist.
+func (o *workspaceLimits) workspaceType(clusterName logicalcluster.LogicalCluster) (*tenancyv1alpha1.ClusterWorkspaceType, error) {
+	parent, name := clusterName.Split()
+	if parent.Empty() {
+		return nil, nil
+	}
+	cw, err := o.workspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cwt, err := o.typeLister.Get(clusters.ToClusterAwareKey(parent, strings.ToLower(cw.Spec.Type)))
+	if apierrors.IsNotFound(err) {
+		return nil, nil // e.g. Universal
+	} else if err != nil {
+		return nil, err
+	}
+	return cwt, nil
+}
+
+// maxObjects returns the maximum number of objects of the given resource, preferring
+// a limit for the resource over the default for all resources.
+func maxObjects(limits *tenancyv1alpha1.ClusterWorkspaceLimits, gr schema.GroupResource) (int64, bool) {
+	for _, l := range limits.Resources {
+		if l.Group == gr.Group && l.Resource == gr.Resource {
+			return l.MaxObjects, true
+		}
+	}
+	if limits.MaxObjectsPerResource != nil {
+		return *limits.MaxObjectsPerResource, true
+	}
+	return 0, false
+}
+
+func (o *workspaceLimits) ValidateInitialization() error {
+	if o.workspaceLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspace lister")
+	}
+	if o.typeLister == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspaceType lister")
+	}
+	if o.counts == nil || o.counts.list == nil {
+		return fmt.Errorf(PluginName + " plugin needs a dynamic cluster client")
+	}
+	return nil
+}
+
+func (o *workspaceLimits) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	workspacesReady := informers.Tenancy().V1alpha1().ClusterWorkspaces().Informer().HasSynced
+	typesReady := informers.Tenancy().V1alpha1().ClusterWorkspaceTypes().Informer().HasSynced
+	o.SetReadyFunc(func() bool {
+		return workspacesReady() && typesReady()
+	})
+	o.workspaceLister = informers.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+	o.typeLister = informers.Tenancy().V1alpha1().ClusterWorkspaceTypes().Lister()
+}
+
+func (o *workspaceLimits) SetDynamicClusterClient(dynamicClusterClient *dynamic.Cluster) {
+	o.counts.list = func(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource) (int64, error) {
+		// the remaining item count is not reliably set, e.g. not when served from the watch cache,
+		// hence the objects are counted page by page.
+		var count int64
+		opts := metav1.ListOptions{Limit: countPageSize}
+		for {
+			list, err := dynamicClusterClient.Cluster(clusterName).Resource(gvr).List(ctx, opts)
+			if err != nil {
+				return 0, err
+			}
+			count += int64(len(list.Items))
+			if list.GetContinue() == "" {
+				return count, nil
+			}
+			opts.Continue = list.GetContinue()
+		}
+	}
+}
+
+type countKey struct {
+	clusterName logicalcluster.LogicalCluster
+	gvr         schema.GroupVersionResource
+}
+
+type countEntry struct {
+	count     int64
+	countable bool
+	expires   time.Time
+}
+
+// countCache caches the number of objects of a resource in a workspace, listing them
+// again when the cached count is older than the ttl. Resources that cannot be listed
+// are cached as not countable.
+type countCache struct {
+	lock    sync.Mutex
+	entries map[countKey]countEntry
+
+	ttl  time.Duration
+	now  func() time.Time
+	list func(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource) (int64, error)
+}
+
+func newCountCache(ttl time.Duration) *countCache {
+	return &countCache{
+		entries: map[countKey]countEntry{},
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// get returns the number of objects of the resource in the workspace, and false if the
+// resource is not persisted and cannot be counted.
+func (c *countCache) get(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource) (int64, bool, error) {
+	key := countKey{clusterName: clusterName, gvr: gvr}
+
+	c.lock.Lock()
+	entry, found := c.entries[key]
+	c.lock.Unlock()
+	if found && c.now().Before(entry.expires) {
+		return entry.count, entry.countable, nil
+	}
+
+	countable := true
+	count, err := c.list(ctx, clusterName, gvr)
+	if apierrors.IsMethodNotSupported(err) || apierrors.IsNotFound(err) {
+		countable = false
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = countEntry{count: count, countable: countable, expires: now.Add(c.ttl)}
+	return count, countable, nil
+}
+
+func (c *countCache) add(clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource) {
+	key := countKey{clusterName: clusterName, gvr: gvr}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if entry, found := c.entries[key]; found && entry.countable {
+		entry.count++
+		c.entries[key] = entry
+	}
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacelimits
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1alpha1lister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+var configMaps = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func createAttr(size int, op admission.Operation, subresource string, dryRun bool) admission.Attributes {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "test", "namespace": "default"},
+		"data":       map[string]interface{}{"key": strings.Repeat("x", size)},
+	}}
+	return admission.NewAttributesRecord(
+		obj,
+		nil,
+		schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		"default",
+		"test",
+		configMaps,
+		subresource,
+		op,
+		nil,
+		dryRun,
+		&user.DefaultInfo{},
+	)
+}
+
+func newType(limits *tenancyv1alpha1.ClusterWorkspaceLimits) *tenancyv1alpha1.ClusterWorkspaceType {
+	return &tenancyv1alpha1.ClusterWorkspaceType{
+		ObjectMeta: metav1.ObjectMeta{Name: "limited", ClusterName: "root:org"},
+		Spec:       tenancyv1alpha1.ClusterWorkspaceTypeSpec{Limits: limits},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	quantity := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+	maxObjects := func(n int64) *int64 { return &n }
+
+	tests := []struct {
+		name          string
+		cwt           *tenancyv1alpha1.ClusterWorkspaceType
+		attr          admission.Attributes
+		existing      int64
+		listErr       error
+		wantErr       bool
+		wantTooLarge  bool
+		wantListCalls int
+	}{
+		{
+			name: "type does not exist",
+			attr: createAttr(10, admission.Create, "", false),
+		},
+		{
+			name: "type without limits",
+			cwt:  newType(nil),
+			attr: createAttr(10, admission.Create, "", false),
+		},
+		{
+			name: "below the maximum object size",
+			cwt:  newType(&tenancyv1alpha1.ClusterWorkspaceLimits{MaxObjectSize: quantity("1Ki")}),
+			attr: createAttr(10, admission.Create, "", false),
+		},
+		{
+			name:         "create beyond the maximum object size",
+			cwt:          newType(&tenancyv1alpha1.ClusterWorkspaceLimits{MaxObjectSize: quantity("1Ki")}),
+			attr:         createAttr(2000, admission.Create, "", false),
+			wantErr:      true,
+			wantTooLarge: true,
+		},
+		{
+			name:         "update beyond the maximum object size",
+			cwt:          newType(&tenancyv1alpha1.ClusterWorkspaceLimits{MaxObjectSize: quantity("1Ki")}),
+			attr:         createAttr(2000, admission.Update, "", false),
+			wantErr:      true,
+			wantTooLarge: true,
+		},
+		{
+			name:         "status update beyond the maximum object size",
+			cwt:          newType(&tenancyv1alpha1.ClusterWorkspaceLimits{MaxObjectSize: quantity("1Ki")}),
+			attr:         createAttr(2000, admission.Update, "status", false),
+			wantErr:      true,
+			wantTooLarge: true,
+		},
+		{
+			name:          "below the maximum objects per resource",
+			cwt:           newType(&tenancyv1alpha1.ClusterWorkspaceLimits{MaxObjectsPerResource: maxObjects(3)}),
+			attr:          createAttr(10, admission.Create, "", false),
+			existing:      2,
+			wantListCalls: 1,
+		},
+		{
+			name:          "at the maximum objects per resource",
+			cwt:           newType(&tenancyv1alpha1.ClusterWorkspaceLimits{MaxObjectsPerResource: maxObjects(3)}),
+			attr:          createAttr(10, admission.Create, "", false),
+			existing:      3,
+			wantErr:       true,
+			wantListCalls: 1,
+		},
+		{
+			name: "resource limit overrides the maximum objects per resource",
+			cwt: newType(&tenancyv1alpha1.ClusterWorkspaceLimits{
+				MaxObjectsPerResource: maxObjects(3),
+				Resources:             []tenancyv1alpha1.ClusterWorkspaceResourceLimit{{Resource: "configmaps", MaxObjects: 10}},
+			}),
+			attr:          createAttr(10, admission.Create, "", false),
+			existing:      3,
+			wantListCalls: 1,
+		},
+		{
+			name: "limit of another resource",
+			cwt: newType(&tenancyv1alpha1.ClusterWorkspaceLimits{
+				Resources: []tenancyv1alpha1.ClusterWorkspaceResourceLimit{{Resource: "secrets", MaxObjects: 0}},
+			}),
+			attr:     createAttr(10, admission.Create, "", false),
+			existing: 3,
+		},
+		{
+			name:          "resource that cannot be listed",
+			cwt:           newType(&tenancyv1alpha1.ClusterWorkspaceLimits{MaxObjectsPerResource: maxObjects(0)}),
+			attr:          createAttr(10, admission.Create, "", false),
+			listErr:       apierrors.NewMethodNotSupported(configMaps.GroupResource(), "list"),
+			wantListCalls: 1,
+		},
+		{
+			name:          "failure to count",
+			cwt:           newType(&tenancyv1alpha1.ClusterWorkspaceLimits{MaxObjectsPerResource: maxObjects(3)}),
+			attr:          createAttr(10, admission.Create, "", false),
+			listErr:       apierrors.NewServiceUnavailable("etcd is down"),
+			wantErr:       true,
+			wantListCalls: 1,
+		},
+		{
+			name:     "updates are not counted",
+			cwt:      newType(&tenancyv1alpha1.ClusterWorkspaceLimits{MaxObjectsPerResource: maxObjects(3)}),
+			attr:     createAttr(10, admission.Update, "", false),
+			existing: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, workspaces.Add(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "ws", ClusterName: "root:org"},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Limited"},
+			}))
+			types := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tt.cwt != nil {
+				require.NoError(t, types.Add(tt.cwt))
+			}
+
+			listCalls := 0
+			o := &workspaceLimits{
+				Handler:         admission.NewHandler(admission.Create, admission.Update),
+				workspaceLister: tenancyv1alpha1lister.NewClusterWorkspaceLister(workspaces),
+				typeLister:      tenancyv1alpha1lister.NewClusterWorkspaceTypeLister(types),
+				counts:          newCountCache(time.Minute),
+			}
+			o.counts.list = func(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource) (int64, error) {
+				require.Equal(t, "root:org:ws", clusterName.String())
+				require.Equal(t, configMaps, gvr)
+				listCalls++
+				return tt.existing, tt.listErr
+			}
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org:ws")})
+			err := o.Validate(ctx, tt.attr, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Equal(t, tt.wantTooLarge, apierrors.IsRequestEntityTooLargeError(err))
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantListCalls, listCalls)
+		})
+	}
+}
+
+func TestCountCache(t *testing.T) {
+	now := time.Now()
+	listed := int64(1)
+	c := newCountCache(10 * time.Second)
+	c.now = func() time.Time { return now }
+	c.list = func(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource) (int64, error) {
+		return listed, nil
+	}
+	clusterName := logicalcluster.New("root:org:ws")
+
+	count, countable, err := c.get(context.Background(), clusterName, configMaps)
+	require.NoError(t, err)
+	require.True(t, countable)
+	require.Equal(t, int64(1), count)
+
+	// creates are counted until the entry expires
+	c.add(clusterName, configMaps)
+	listed = 5
+	count, _, err = c.get(context.Background(), clusterName, configMaps)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	now = now.Add(10 * time.Second)
+	count, _, err = c.get(context.Background(), clusterName, configMaps)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), count)
+}
+
+func TestCountCacheNotCountable(t *testing.T) {
+	listCalls := 0
+	c := newCountCache(10 * time.Second)
+	c.list = func(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource) (int64, error) {
+		listCalls++
+		return 0, apierrors.NewMethodNotSupported(gvr.GroupResource(), "list")
+	}
+	clusterName := logicalcluster.New("root:org:ws")
+	tokenReviews := schema.GroupVersionResource{Group: "authentication.k8s.io", Version: "v1", Resource: "tokenreviews"}
+
+	for i := 0; i < 2; i++ {
+		c.add(clusterName, tokenReviews)
+		_, countable, err := c.get(context.Background(), clusterName, tokenReviews)
+		require.NoError(t, err)
+		require.False(t, countable)
+	}
+	require.Equal(t, 1, listCalls, "expected the result to be cached")
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacerequestset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspaceRequestSet"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &workspaceRequestSet{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+			}, nil
+		})
+}
+
+// workspaceRequestSet records the creating user of WorkspaceRequestSets. The workspaces of
+// the set are created on behalf of that user, i.e. subject to their permissions.
+type workspaceRequestSet struct {
+	*admission.Handler
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.ValidationInterface(&workspaceRequestSet{})
+var _ = admission.MutationInterface(&workspaceRequestSet{})
+
+// Admit sets spec.user and spec.groups to the creating user on creation.
+func (o *workspaceRequestSet) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("workspacerequestsets") {
+		return nil
+	}
+	if a.GetOperation() != admission.Create {
+		return nil
+	}
+
+	u, set, err := workspaceRequestSetFrom(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	set.Spec.User = a.GetUserInfo().GetName()
+	set.Spec.Groups = a.GetUserInfo().GetGroups()
+	set.Status = tenancyv1alpha1.WorkspaceRequestSetStatus{}
+
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(set)
+	if err != nil {
+		return err
+	}
+	u.Object = raw
+
+	return nil
+}
+
+// Validate ensures that spec.user and spec.groups are the creating user, and are immutable.
+// As the workspaces are created on behalf of that user, only they may change the spec.
+func (o *workspaceRequestSet) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("workspacerequestsets") {
+		return nil
+	}
+
+	_, set, err := workspaceRequestSetFrom(a.GetObject())
+	if err != nil {
+		return err
+	}
+
+	if a.GetOperation() == admission.Create {
+		if set.Spec.User != a.GetUserInfo().GetName() || !equality.Semantic.DeepEqual(set.Spec.Groups, a.GetUserInfo().GetGroups()) {
+			return admission.NewForbidden(a, errors.New("spec.user and spec.groups must be the creating user"))
+		}
+		return nil
+	}
+
+	_, old, err := workspaceRequestSetFrom(a.GetOldObject())
+	if err != nil {
+		return err
+	}
+	if set.Spec.User != old.Spec.User || !equality.Semantic.DeepEqual(set.Spec.Groups, old.Spec.Groups) {
+		return admission.NewForbidden(a, errors.New("spec.user and spec.groups are immutable"))
+	}
+	if !equality.Semantic.DeepEqual(set.Spec, old.Spec) && a.GetUserInfo().GetName() != old.Spec.User {
+		return admission.NewForbidden(a, fmt.Errorf("spec can only be changed by %q", old.Spec.User))
+	}
+
+	return nil
+}
+
+func workspaceRequestSetFrom(obj runtime.Object) (*unstructured.Unstructured, *tenancyv1alpha1.WorkspaceRequestSet, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected type %T", obj)
+	}
+	set := &tenancyv1alpha1.WorkspaceRequestSet{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, set); err != nil {
+		return nil, nil, fmt.Errorf("failed to convert unstructured to WorkspaceRequestSet: %w", err)
+	}
+	return u, set, nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacerequestset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func createAttr(obj *tenancyv1alpha1.WorkspaceRequestSet, u user.Info) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		nil,
+		tenancyv1alpha1.Kind("WorkspaceRequestSet").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("workspacerequestsets").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		u,
+	)
+}
+
+func updateAttr(obj, old *tenancyv1alpha1.WorkspaceRequestSet, u user.Info) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(obj),
+		helpers.ToUnstructuredOrDie(old),
+		tenancyv1alpha1.Kind("WorkspaceRequestSet").WithVersion("v1alpha1"),
+		"",
+		obj.Name,
+		tenancyv1alpha1.Resource("workspacerequestsets").WithVersion("v1alpha1"),
+		"",
+		admission.Update,
+		&metav1.UpdateOptions{},
+		false,
+		u,
+	)
+}
+
+var (
+	alice = &user.DefaultInfo{Name: "alice", Groups: []string{"teachers", "system:authenticated"}}
+	bob   = &user.DefaultInfo{Name: "bob", Groups: []string{"system:authenticated"}}
+)
+
+func newSet(u user.Info) *tenancyv1alpha1.WorkspaceRequestSet {
+	return &tenancyv1alpha1.WorkspaceRequestSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "class"},
+		Spec: tenancyv1alpha1.WorkspaceRequestSetSpec{
+			Count:       30,
+			NamePattern: "student-{index}",
+			User:        u.GetName(),
+			Groups:      u.GetGroups(),
+		},
+	}
+}
+
+func TestAdmit(t *testing.T) {
+	set := newSet(bob)
+	set.Status.Phase = tenancyv1alpha1.WorkspaceRequestSetPhaseReady
+
+	a := createAttr(set, alice)
+	o := &workspaceRequestSet{Handler: admission.NewHandler(admission.Create, admission.Update)}
+	require.NoError(t, o.Admit(context.Background(), a, nil))
+
+	got := &tenancyv1alpha1.WorkspaceRequestSet{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(a.GetObject().(*unstructured.Unstructured).Object, got)
+	require.NoError(t, err)
+	require.Equal(t, newSet(alice).Spec, got.Spec)
+	require.Equal(t, tenancyv1alpha1.WorkspaceRequestSetStatus{}, got.Status)
+}
+
+func TestValidate(t *testing.T) {
+	scaled := newSet(alice)
+	scaled.Spec.Count = 40
+
+	tests := []struct {
+		name    string
+		a       admission.Attributes
+		wantErr bool
+	}{
+		{
+			name: "creates a set for the creating user",
+			a:    createAttr(newSet(alice), alice),
+		},
+		{
+			name:    "rejects a set for another user",
+			a:       createAttr(newSet(bob), alice),
+			wantErr: true,
+		},
+		{
+			name: "rejects a set for other groups",
+			a: createAttr(func() *tenancyv1alpha1.WorkspaceRequestSet {
+				s := newSet(alice)
+				s.Spec.Groups = append(s.Spec.Groups, "system:masters")
+				return s
+			}(), alice),
+			wantErr: true,
+		},
+		{
+			name: "allows changes of the count",
+			a:    updateAttr(scaled, newSet(alice), alice),
+		},
+		{
+			name:    "rejects changes of the count by others",
+			a:       updateAttr(scaled, newSet(alice), bob),
+			wantErr: true,
+		},
+		{
+			name: "allows metadata changes by others",
+			a: updateAttr(func() *tenancyv1alpha1.WorkspaceRequestSet {
+				s := newSet(alice)
+				s.Labels = map[string]string{"class": "101"}
+				return s
+			}(), newSet(alice), bob),
+		},
+		{
+			name:    "rejects changes of the user",
+			a:       updateAttr(newSet(bob), newSet(alice), bob),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &workspaceRequestSet{Handler: admission.NewHandler(admission.Create, admission.Update)}
+			err := o.Validate(context.Background(), tt.a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacetokenaudience
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	authenticationapi "k8s.io/kubernetes/pkg/apis/authentication"
+	api "k8s.io/kubernetes/pkg/apis/core"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	"github.com/kcp-dev/kcp/pkg/authentication"
+)
+
+// Bind service account tokens minted through serviceaccounts/token to the workspace they are
+// minted in by adding the workspace audience. Tokens requested without audiences get the API
+// audiences of the server, as they would without this plugin, plus the workspace audience.
+// Downstream services can verify the workspace audience to make sure a token was intended
+// for their workspace. Audiences of other workspaces cannot be requested.
+
+const (
+	PluginName = "tenancy.kcp.dev/WorkspaceTokenAudience"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &workspaceTokenAudience{
+				Handler: admission.NewHandler(admission.Create),
+			}, nil
+		})
+}
+
+type workspaceTokenAudience struct {
+	*admission.Handler
+
+	apiAudiences authenticator.Audiences
+}
+
+// Ensure that the required admission interfaces are implemented.
+var _ = admission.MutationInterface(&workspaceTokenAudience{})
+var _ = kcpinitializers.WantsAPIAudiences(&workspaceTokenAudience{})
+
+// Admit adds the workspace audience to token requests, and rejects requests for the audience of
+// another workspace.
+func (o *workspaceTokenAudience) Admit(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != api.Resource("serviceaccounts") || a.GetSubresource() != "token" {
+		return nil
+	}
+
+	tokenRequest, ok := a.GetObject().(*authenticationapi.TokenRequest)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("error determining workspace: %w", err))
+	}
+
+	if len(tokenRequest.Spec.Audiences) == 0 {
+		tokenRequest.Spec.Audiences = append([]string{}, o.apiAudiences...)
+	}
+	audience := authentication.WorkspaceAudience(cluster.Name)
+	found := false
+	for _, aud := range tokenRequest.Spec.Audiences {
+		if aud == audience {
+			found = true
+		} else if strings.HasPrefix(aud, authentication.WorkspaceAudiencePrefix) {
+			return admission.NewForbidden(a, fmt.Errorf("audience %q is not the audience of workspace %s", aud, cluster.Name))
+		}
+	}
+	if !found {
+		tokenRequest.Spec.Audiences = append(tokenRequest.Spec.Audiences, audience)
+	}
+
+	return nil
+}
+
+func (o *workspaceTokenAudience) SetAPIAudiences(apiAudiences authenticator.Audiences) {
+	o.apiAudiences = apiAudiences
+}
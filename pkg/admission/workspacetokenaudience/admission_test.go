@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacetokenaudience
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	authenticationapi "k8s.io/kubernetes/pkg/apis/authentication"
+	api "k8s.io/kubernetes/pkg/apis/core"
+)
+
+func createAttr(obj runtime.Object, resource, subresource string) admission.Attributes {
+	return admission.NewAttributesRecord(
+		obj,
+		nil,
+		authenticationapi.Kind("TokenRequest").WithVersion("v1"),
+		"default",
+		"default",
+		api.Resource(resource).WithVersion("v1"),
+		subresource,
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		nil,
+	)
+}
+
+func TestAdmit(t *testing.T) {
+	tests := []struct {
+		name          string
+		a             admission.Attributes
+		wantAudiences []string
+		wantErr       bool
+	}{
+		{
+			name:          "defaults to the api audiences and the workspace audience",
+			a:             createAttr(&authenticationapi.TokenRequest{}, "serviceaccounts", "token"),
+			wantAudiences: []string{"https://kcp.default.svc", "kcp.dev/workspace/root:org:team"},
+		},
+		{
+			name: "adds the workspace audience to requested audiences",
+			a: createAttr(&authenticationapi.TokenRequest{
+				Spec: authenticationapi.TokenRequestSpec{Audiences: []string{"https://vault.example.com"}},
+			}, "serviceaccounts", "token"),
+			wantAudiences: []string{"https://vault.example.com", "kcp.dev/workspace/root:org:team"},
+		},
+		{
+			name: "keeps an already requested workspace audience",
+			a: createAttr(&authenticationapi.TokenRequest{
+				Spec: authenticationapi.TokenRequestSpec{Audiences: []string{"kcp.dev/workspace/root:org:team"}},
+			}, "serviceaccounts", "token"),
+			wantAudiences: []string{"kcp.dev/workspace/root:org:team"},
+		},
+		{
+			name: "rejects the audience of another workspace",
+			a: createAttr(&authenticationapi.TokenRequest{
+				Spec: authenticationapi.TokenRequestSpec{Audiences: []string{"kcp.dev/workspace/root:org:other"}},
+			}, "serviceaccounts", "token"),
+			wantAudiences: []string{"kcp.dev/workspace/root:org:other"},
+			wantErr:       true,
+		},
+		{
+			name:          "ignores other subresources",
+			a:             createAttr(&authenticationapi.TokenRequest{}, "serviceaccounts", ""),
+			wantAudiences: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &workspaceTokenAudience{
+				Handler:      admission.NewHandler(admission.Create),
+				apiAudiences: authenticator.Audiences{"https://kcp.default.svc"},
+			}
+			ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:org:team")})
+			err := o.Admit(ctx, tt.a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.wantAudiences, tt.a.GetObject().(*authenticationapi.TokenRequest).Spec.Audiences)
+		})
+	}
+}
@@ -26,6 +26,12 @@ import (
 
 const APIVersionAnnotation = "apiresource.kcp.dev/apiVersion"
 
+// OrphanedSinceAnnotation is set on a NegotiatedAPIResource by the apiresource controller when no
+// existing WorkloadCluster imports its GVR anymore. The value is an RFC3339 timestamp. The
+// NegotiatedAPIResource (and the CRD published from it) is deleted once the retention period
+// has elapsed since then.
+const OrphanedSinceAnnotation = "apiresource.kcp.dev/orphaned-since"
+
 type ColumnDefinition struct {
 	metav1.TableColumnDefinition `json:",inline"`
 
@@ -0,0 +1,64 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package helper
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// IdentityHash returns the identity of an APIExport. Without an identity secret it is derived from
+// the UID of the APIExport, such that a deleted and recreated APIExport gets a new identity. The hash
+// is a valid label value.
+func IdentityHash(apiExport *apisv1alpha1.APIExport) string {
+	if apiExport.Status.IdentityHash != "" {
+		return apiExport.Status.IdentityHash
+	}
+	return fmt.Sprintf("%x", sha256.Sum224([]byte(strings.Join([]string{logicalcluster.From(apiExport).String(), apiExport.Name, string(apiExport.UID)}, "/"))))
+}
+
+// IdentityHashFromKey returns the identity hash for the key of an identity secret.
+func IdentityHashFromKey(key []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum224(key))
+}
+
+// ValidIdentityHashes returns the current identity of an APIExport, followed by the previous
+// identities whose grace period is not over at the given time.
+func ValidIdentityHashes(apiExport *apisv1alpha1.APIExport, now time.Time) []string {
+	hashes := []string{IdentityHash(apiExport)}
+	for _, previous := range apiExport.Status.PreviousIdentityHashes {
+		if now.Before(previous.ValidUntil.Time) {
+			hashes = append(hashes, previous.IdentityHash)
+		}
+	}
+	return hashes
+}
+
+// SplitResourceIdentity splits a resource of the form <resource>:<identityHash> into the resource
+// and the identity hash. The identity hash is empty if the resource has none.
+func SplitResourceIdentity(resource string) (string, string) {
+	parts := strings.SplitN(resource, ":", 2)
+	if len(parts) == 1 {
+		return resource, ""
+	}
+	return parts[0], parts[1]
+}
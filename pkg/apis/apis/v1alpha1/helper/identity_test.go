@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestIdentityHash(t *testing.T) {
+	export := func(clusterName, name, uid string) *apisv1alpha1.APIExport {
+		return &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{ClusterName: clusterName, Name: name, UID: types.UID(uid)}}
+	}
+
+	hash := IdentityHash(export("root:org:ws", "today", "uid"))
+	require.Empty(t, validation.IsValidLabelValue(hash))
+	require.Equal(t, hash, IdentityHash(export("root:org:ws", "today", "uid")))
+	require.NotEqual(t, hash, IdentityHash(export("root:org:ws", "today", "other-uid")))
+	require.NotEqual(t, hash, IdentityHash(export("root:org:other", "today", "uid")))
+	require.NotEqual(t, hash, IdentityHash(export("root:org:ws", "tomorrow", "uid")))
+
+	withSecret := export("root:org:ws", "today", "uid")
+	withSecret.Status.IdentityHash = IdentityHashFromKey([]byte("key"))
+	require.Empty(t, validation.IsValidLabelValue(withSecret.Status.IdentityHash))
+	require.Equal(t, withSecret.Status.IdentityHash, IdentityHash(withSecret))
+	require.NotEqual(t, hash, IdentityHash(withSecret))
+}
+
+func TestValidIdentityHashes(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	apiExport := &apisv1alpha1.APIExport{
+		Status: apisv1alpha1.APIExportStatus{
+			IdentityHash: "current",
+			PreviousIdentityHashes: []apisv1alpha1.APIExportPreviousIdentity{
+				{IdentityHash: "expired", ValidUntil: metav1.NewTime(now.Add(-time.Minute))},
+				{IdentityHash: "previous", ValidUntil: metav1.NewTime(now.Add(time.Minute))},
+			},
+		},
+	}
+	require.Equal(t, []string{"current", "previous"}, ValidIdentityHashes(apiExport, now))
+}
+
+func TestSplitResourceIdentity(t *testing.T) {
+	tests := []struct {
+		resource     string
+		wantResource string
+		wantIdentity string
+	}{
+		{"widgets", "widgets", ""},
+		{"widgets:abc", "widgets", "abc"},
+		{"widgets:", "widgets", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			resource, identity := SplitResourceIdentity(tt.resource)
+			require.Equal(t, tt.wantResource, resource)
+			require.Equal(t, tt.wantIdentity, identity)
+		})
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -65,8 +66,43 @@ type APIBindingSpec struct {
 	// +required
 	// +kubebuilder:validation:Required
 	Reference ExportReference `json:"reference"`
+
+	// conflictPolicy defines how a bound resource conflicting with a CRD of the workspace or with
+	// a resource bound by another APIBinding is handled:
+	// - Fail: the APIBinding is not bound and the ConflictsResolved condition is false.
+	// - Adopt: the APIBinding takes over the resource of a conflicting CRD. Its existing objects
+	//   are adopted into the identity of the APIExport. Conflicts with other APIBindings fail.
+	// - RenameWithPrefix: the conflicting resource is bound with its names prefixed by resourcePrefix.
+	//
+	// A resource keeps its name once it is bound.
+	//
+	// +optional
+	// +kubebuilder:default:=Fail
+	ConflictPolicy APIBindingConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// resourcePrefix is prepended to the names of the conflicting resources with the
+	// RenameWithPrefix conflict policy. It is required for that policy.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern:="^[a-z][a-z0-9]*$"
+	// +kubebuilder:validation:MaxLength=20
+	ResourcePrefix string `json:"resourcePrefix,omitempty"`
 }
 
+// APIBindingConflictPolicy defines how conflicts of bound resources are handled.
+//
+// +kubebuilder:validation:Enum=Fail;Adopt;RenameWithPrefix
+type APIBindingConflictPolicy string
+
+const (
+	// APIBindingConflictPolicyFail fails binding conflicting resources.
+	APIBindingConflictPolicyFail APIBindingConflictPolicy = "Fail"
+	// APIBindingConflictPolicyAdopt takes over the resources of conflicting CRDs of the workspace.
+	APIBindingConflictPolicyAdopt APIBindingConflictPolicy = "Adopt"
+	// APIBindingConflictPolicyRenameWithPrefix binds conflicting resources under prefixed names.
+	APIBindingConflictPolicyRenameWithPrefix APIBindingConflictPolicy = "RenameWithPrefix"
+)
+
 // ExportReference describes a reference to an APIExport. Exactly one of the
 // fields must be set.
 type ExportReference struct {
@@ -160,6 +196,9 @@ const (
 	APIExportInvalidReferenceReason = "APIExportInvalidReference"
 	// APIExportNotFoundReason is a reason for APIExportValid condition that the referenced APIExport is not found.
 	APIExportNotFoundReason = "APIExportNotFound"
+	// APIGroupsNotAllowedReason is a reason for APIExportValid condition that the referenced APIExport exports
+	// API groups which the type of the workspace of the APIBinding does not allow.
+	APIGroupsNotAllowedReason = "APIGroupsNotAllowed"
 
 	// CRDReady is a condition for APIBinding that reflects that the referenced CRDs are ready.
 	CRDReady conditionsv1alpha1.ConditionType = "CRDReady"
@@ -174,6 +213,18 @@ const (
 	UpdateErrorReason = "UpdateError"
 	// WaitingForEstablishedReason is a reason for CRDReady condition that the referenced CRDs are not ready.
 	WaitingForEstablishedReason = "WaitingForEstablished"
+
+	// ConflictsResolved is a condition for APIBinding that reflects that the bound resources don't
+	// conflict with CRDs of the workspace or the resources of other APIBindings, or that the conflicts
+	// are resolved by the conflict policy.
+	ConflictsResolved conditionsv1alpha1.ConditionType = "ConflictsResolved"
+
+	// NamingConflictsReason is a reason for ConflictsResolved condition that resources conflict
+	// and the conflict policy cannot resolve them.
+	NamingConflictsReason = "NamingConflicts"
+	// AdoptionFailedReason is a reason for ConflictsResolved condition that the objects of an
+	// adopted CRD cannot be adopted into the identity of the APIExport.
+	AdoptionFailedReason = "AdoptionFailed"
 )
 
 // BoundAPIResource describes a bound GroupVersionResource through an APIResourceSchema of an APIExport..
@@ -194,6 +245,20 @@ type BoundAPIResource struct {
 	// +required
 	Schema BoundAPIResourceSchema `json:"schema"`
 
+	// identityHash is the identity of the APIExport the resource is bound from. Objects of the
+	// resource carry it in the apis.kcp.dev/identity label, such that wildcard requests across
+	// workspaces for <resource>:<identityHash> only see objects of this APIExport, not those of
+	// other APIExports or CRDs with the same group and resource.
+	//
+	// +optional
+	IdentityHash string `json:"identityHash,omitempty"`
+
+	// renamedFrom is the resource name of the APIResourceSchema if the resource is bound under
+	// a name prefixed with the resourcePrefix of the RenameWithPrefix conflict policy.
+	//
+	// +optional
+	RenamedFrom string `json:"renamedFrom,omitempty"`
+
 	// storageVersions lists all versions of a resource that were ever persisted. Tracking these
 	// versions allows a migration path for stored versions in etcd. The field is mutable
 	// so a migration controller can finish a migration to another version (ensuring
@@ -207,6 +272,12 @@ type BoundAPIResource struct {
 	StorageVersions []string `json:"storageVersions,omitempty"`
 }
 
+const (
+	// IdentityHashLabelKey is set by admission on objects of resources bound through an APIBinding
+	// to the identity hash of the bound APIExport.
+	IdentityHashLabelKey = "apis.kcp.dev/identity"
+)
+
 // BoundAPIResourceSchema is a reference to an APIResourceSchema.
 type BoundAPIResourceSchema struct {
 	// name is the bound APIResourceSchema name.
@@ -259,6 +330,14 @@ type APIExport struct {
 	Status APIExportStatus `json:"status,omitempty"`
 }
 
+func (in *APIExport) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+func (in *APIExport) SetConditions(conditions conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
 // APIExportSpec defines the desired state of APIExport.
 type APIExportSpec struct {
 	// latestResourceSchemas records the latest APIResourceSchemas that are exposed
@@ -273,10 +352,170 @@ type APIExportSpec struct {
 	// +optional
 	// +listType=set
 	LatestResourceSchemas []string `json:"latestResourceSchemas,omitempty"`
+
+	// sunset announces that the APIExport is going away. Requests to the resources
+	// bound from it get a warning.
+	//
+	// +optional
+	Sunset *APIExportSunset `json:"sunset,omitempty"`
+
+	// identity configures a secret the identity hash of the APIExport is derived from, instead
+	// of the UID of the APIExport. The secret can be rotated, either periodically by the
+	// controller or by changing its key. Consumers are moved to the new identity, while the
+	// previous identity stays valid for the grace period.
+	//
+	// +optional
+	Identity *APIExportIdentity `json:"identity,omitempty"`
+
+	// maintenanceActions are housekeeping actions of the provider, e.g. cleanups or report
+	// generation, that consumers run on a schedule through MaintenanceTasks in their workspaces.
+	// kcp calls the webhook of the action for every run, so the provider needs no compute
+	// per workspace.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	MaintenanceActions []MaintenanceAction `json:"maintenanceActions,omitempty"`
+}
+
+// MaintenanceAction is an HTTPS endpoint a MaintenanceTaskRun is POSTed to for every run of
+// a MaintenanceTask referencing it. The endpoint must respond with a 2xx status code.
+type MaintenanceAction struct {
+	// name identifies the action among the actions of the APIExport.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern:="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	Name string `json:"name"`
+
+	// url is the HTTPS URL of the action.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:="^https://"
+	URL string `json:"url"`
+
+	// caBundle is a PEM encoded CA bundle to verify the serving certificate of the
+	// action. If unspecified, the system trust roots are used.
+	//
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// timeoutSeconds is the timeout of a single call of the action.
+	//
+	// +optional
+	// +kubebuilder:default:=10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// APIExportIdentity configures the identity secret of an APIExport.
+type APIExportIdentity struct {
+	// secretRef references the secret in the workspace of the APIExport holding the identity
+	// key in the "key" data field. The secret is created with a random key if it does not exist.
+	//
+	// +required
+	SecretRef corev1.SecretReference `json:"secretRef"`
+
+	// rotationPeriod is the age after which the identity key is replaced by a new random key.
+	// The key is not rotated periodically if unset.
+	//
+	// +optional
+	RotationPeriod *metav1.Duration `json:"rotationPeriod,omitempty"`
+
+	// gracePeriod is how long a previous identity hash stays valid after a rotation.
+	//
+	// +optional
+	// +kubebuilder:default:="24h"
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+const (
+	// APIExportIdentityKey is the data field of the identity secret of an APIExport holding the key.
+	APIExportIdentityKey = "key"
+)
+
+// APIExportSunset announces the end of an APIExport.
+type APIExportSunset struct {
+	// time is when the APIExport stops being served.
+	//
+	// +optional
+	Time *metav1.Time `json:"time,omitempty"`
+
+	// message is added to the warnings, e.g. to point to the replacement of the APIExport.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=256
+	Message string `json:"message,omitempty"`
 }
 
 // APIExportStatus defines the observed state of APIExport.
 type APIExportStatus struct {
+	// identityHash is the current identity of the APIExport, derived from the key of the
+	// identity secret. It is empty if no identity secret is configured.
+	//
+	// +optional
+	IdentityHash string `json:"identityHash,omitempty"`
+
+	// previousIdentityHashes are the identities before the last rotations, which stay valid
+	// until the grace period is over.
+	//
+	// +optional
+	PreviousIdentityHashes []APIExportPreviousIdentity `json:"previousIdentityHashes,omitempty"`
+
+	// lastRotationTime is the time the identity key was last created or rotated by the controller.
+	//
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+
+	// conditions is a list of conditions that apply to the APIExport.
+	//
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+// These are valid conditions of APIExport.
+const (
+	// APIResourceSchemasCompatible is a condition for APIExport that reflects whether the latest
+	// APIResourceSchemas are backward-compatible with the previous generation of the same resource,
+	// i.e. the latest created before it in the workspace with the same group and resource.
+	APIResourceSchemasCompatible conditionsv1alpha1.ConditionType = "APIResourceSchemasCompatible"
+
+	// BreakingChangesReason is a reason for APIResourceSchemasCompatible condition that a latest
+	// APIResourceSchema removes fields or versions, changes types or tightens validation.
+	BreakingChangesReason = "BreakingChanges"
+
+	// IdentityValid is a condition for APIExport that reflects whether the identity secret
+	// exists and holds a key.
+	IdentityValid conditionsv1alpha1.ConditionType = "IdentityValid"
+
+	// IdentitySecretInvalidReason is a reason for IdentityValid condition that the identity
+	// secret cannot be created or has no key.
+	IdentitySecretInvalidReason = "IdentitySecretInvalid"
+
+	// IdentityRotated is a condition for APIExport that reflects whether all APIBindings use the
+	// current identity after a rotation.
+	IdentityRotated conditionsv1alpha1.ConditionType = "IdentityRotated"
+
+	// RotationInProgressReason is a reason for IdentityRotated condition that APIBindings still
+	// use a previous identity.
+	RotationInProgressReason = "RotationInProgress"
+)
+
+// APIExportPreviousIdentity is an identity hash of an APIExport before a rotation.
+type APIExportPreviousIdentity struct {
+	// identityHash is the previous identity hash.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	IdentityHash string `json:"identityHash"`
+
+	// validUntil is when the previous identity hash stops being valid.
+	//
+	// +required
+	ValidUntil metav1.Time `json:"validUntil"`
 }
 
 // APIExportList is a list of APIExport resources
@@ -331,14 +570,21 @@ type APIResourceSchemaSpec struct {
 
 	// versions is the API version of the defined custom resource.
 	//
-	// Note: the OpenAPI v3 schemas must be equal for all versions until CEL
-	//       version migration is supported.
+	// Note: the OpenAPI v3 schemas must be equal for all versions unless a
+	//       conversion webhook is configured, until CEL version migration is supported.
 	//
 	// +required
 	// +listType=map
 	// +listMapKey=name
 	// +kubebuilder:validation:MinItems=1
 	Versions []APIResourceVersion `json:"versions"`
+
+	// conversion defines conversion settings for the defined custom resource.
+	// The conversion webhook is called by the bound CRDs of all the workspaces
+	// binding the resource. It must be given by url, services are not supported.
+	//
+	// +optional
+	Conversion *apiextensionsv1.CustomResourceConversion `json:"conversion,omitempty"`
 }
 
 // APIResourceVersion describes one API version of a resource.
@@ -403,3 +649,140 @@ type APIResourceSchemaList struct {
 
 	Items []APIResourceSchema `json:"items"`
 }
+
+// MaintenanceTask runs a maintenance action of a bound APIExport periodically for the
+// workspace it lives in.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Binding",type=string,JSONPath=`.spec.apiBinding`,description="The APIBinding of the APIExport providing the action"
+// +kubebuilder:printcolumn:name="Action",type=string,JSONPath=`.spec.action`,description="The maintenance action"
+// +kubebuilder:printcolumn:name="Period",type=string,JSONPath=`.spec.period`,description="The period of the runs"
+// +kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=`.status.lastScheduleTime`,description="The time of the last run"
+type MaintenanceTask struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state.
+	//
+	// +optional
+	Spec MaintenanceTaskSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state.
+	//
+	// +optional
+	Status MaintenanceTaskStatus `json:"status,omitempty"`
+}
+
+func (in *MaintenanceTask) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+func (in *MaintenanceTask) SetConditions(conditions conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
+// MaintenanceTaskSpec defines the desired state of MaintenanceTask.
+type MaintenanceTaskSpec struct {
+	// apiBinding is the name of the APIBinding in this workspace bound to the APIExport
+	// providing the action.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	APIBinding string `json:"apiBinding"`
+
+	// action is the name of the maintenance action of the APIExport.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Action string `json:"action"`
+
+	// period is the time between two runs. The first run happens one period after the
+	// creation of the task. It must be at least one minute.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Period metav1.Duration `json:"period"`
+
+	// suspend stops further runs until it is unset.
+	//
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// MaintenanceTaskStatus defines the observed state of MaintenanceTask.
+type MaintenanceTaskStatus struct {
+	// lastScheduleTime is the time of the last run.
+	//
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// lastSuccessfulTime is the time of the last run the action succeeded.
+	//
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// conditions is a list of conditions that apply to the MaintenanceTask.
+	//
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+// These are valid conditions of MaintenanceTask.
+const (
+	// MaintenanceActionValid is a condition for MaintenanceTask that reflects whether the
+	// action exists in the APIExport bound by the APIBinding, and the period is valid.
+	MaintenanceActionValid conditionsv1alpha1.ConditionType = "MaintenanceActionValid"
+
+	// APIBindingNotBoundReason is a reason for MaintenanceActionValid condition that the
+	// APIBinding does not exist or is not bound.
+	APIBindingNotBoundReason = "APIBindingNotBound"
+	// MaintenanceActionNotFoundReason is a reason for MaintenanceActionValid condition that
+	// the bound APIExport has no action of the name.
+	MaintenanceActionNotFoundReason = "MaintenanceActionNotFound"
+	// InvalidPeriodReason is a reason for MaintenanceActionValid condition that the period
+	// is shorter than the minimum.
+	InvalidPeriodReason = "InvalidPeriod"
+
+	// MaintenanceTaskSucceeded is a condition for MaintenanceTask that reflects whether the
+	// last run of the action succeeded.
+	MaintenanceTaskSucceeded conditionsv1alpha1.ConditionType = "Succeeded"
+
+	// MaintenanceActionFailedReason is a reason for Succeeded condition that the action
+	// failed or did not respond with a 2xx status code.
+	MaintenanceActionFailedReason = "MaintenanceActionFailed"
+)
+
+// MaintenanceTaskRun is the JSON body POSTed to a maintenance action for a run of a
+// MaintenanceTask.
+type MaintenanceTaskRun struct {
+	// clusterName is the logical cluster of the workspace the MaintenanceTask lives in.
+	ClusterName string `json:"clusterName"`
+	// name is the name of the MaintenanceTask.
+	Name string `json:"name"`
+	// uid is the UID of the MaintenanceTask.
+	UID string `json:"uid"`
+	// apiExport is the bound APIExport as <workspace>:<name>.
+	APIExport string `json:"apiExport"`
+	// action is the name of the maintenance action.
+	Action string `json:"action"`
+	// scheduledTime is the time the run was due.
+	ScheduledTime metav1.Time `json:"scheduledTime"`
+}
+
+// MaintenanceTaskList is a list of MaintenanceTask resources
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type MaintenanceTaskList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MaintenanceTask `json:"items"`
+}
@@ -22,7 +22,8 @@ limitations under the License.
 package v1alpha1
 
 import (
-	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
@@ -152,7 +153,7 @@ func (in *APIExport) DeepCopyInto(out *APIExport) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -174,6 +175,33 @@ func (in *APIExport) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIExportIdentity) DeepCopyInto(out *APIExportIdentity) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+	if in.RotationPeriod != nil {
+		in, out := &in.RotationPeriod, &out.RotationPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIExportIdentity.
+func (in *APIExportIdentity) DeepCopy() *APIExportIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(APIExportIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *APIExportList) DeepCopyInto(out *APIExportList) {
 	*out = *in
@@ -207,6 +235,23 @@ func (in *APIExportList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIExportPreviousIdentity) DeepCopyInto(out *APIExportPreviousIdentity) {
+	*out = *in
+	in.ValidUntil.DeepCopyInto(&out.ValidUntil)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIExportPreviousIdentity.
+func (in *APIExportPreviousIdentity) DeepCopy() *APIExportPreviousIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(APIExportPreviousIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *APIExportSpec) DeepCopyInto(out *APIExportSpec) {
 	*out = *in
@@ -215,6 +260,23 @@ func (in *APIExportSpec) DeepCopyInto(out *APIExportSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Sunset != nil {
+		in, out := &in.Sunset, &out.Sunset
+		*out = new(APIExportSunset)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Identity != nil {
+		in, out := &in.Identity, &out.Identity
+		*out = new(APIExportIdentity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceActions != nil {
+		in, out := &in.MaintenanceActions, &out.MaintenanceActions
+		*out = make([]MaintenanceAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -231,6 +293,24 @@ func (in *APIExportSpec) DeepCopy() *APIExportSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *APIExportStatus) DeepCopyInto(out *APIExportStatus) {
 	*out = *in
+	if in.PreviousIdentityHashes != nil {
+		in, out := &in.PreviousIdentityHashes, &out.PreviousIdentityHashes
+		*out = make([]APIExportPreviousIdentity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(conditionsv1alpha1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -244,6 +324,26 @@ func (in *APIExportStatus) DeepCopy() *APIExportStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIExportSunset) DeepCopyInto(out *APIExportSunset) {
+	*out = *in
+	if in.Time != nil {
+		in, out := &in.Time, &out.Time
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIExportSunset.
+func (in *APIExportSunset) DeepCopy() *APIExportSunset {
+	if in == nil {
+		return nil
+	}
+	out := new(APIExportSunset)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *APIResourceSchema) DeepCopyInto(out *APIResourceSchema) {
 	*out = *in
@@ -315,6 +415,11 @@ func (in *APIResourceSchemaSpec) DeepCopyInto(out *APIResourceSchemaSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Conversion != nil {
+		in, out := &in.Conversion, &out.Conversion
+		*out = new(apiextensionsv1.CustomResourceConversion)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -339,12 +444,12 @@ func (in *APIResourceVersion) DeepCopyInto(out *APIResourceVersion) {
 	in.Schema.DeepCopyInto(&out.Schema)
 	if in.Subresources != nil {
 		in, out := &in.Subresources, &out.Subresources
-		*out = new(v1.CustomResourceSubresources)
+		*out = new(apiextensionsv1.CustomResourceSubresources)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.AdditionalPrinterColumns != nil {
 		in, out := &in.AdditionalPrinterColumns, &out.AdditionalPrinterColumns
-		*out = make([]v1.CustomResourceColumnDefinition, len(*in))
+		*out = make([]apiextensionsv1.CustomResourceColumnDefinition, len(*in))
 		copy(*out, *in)
 	}
 	return
@@ -419,6 +524,153 @@ func (in *ExportReference) DeepCopy() *ExportReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceAction) DeepCopyInto(out *MaintenanceAction) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceAction.
+func (in *MaintenanceAction) DeepCopy() *MaintenanceAction {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceAction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTask) DeepCopyInto(out *MaintenanceTask) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTask.
+func (in *MaintenanceTask) DeepCopy() *MaintenanceTask {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTask)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MaintenanceTask) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTaskList) DeepCopyInto(out *MaintenanceTaskList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MaintenanceTask, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTaskList.
+func (in *MaintenanceTaskList) DeepCopy() *MaintenanceTaskList {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTaskList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MaintenanceTaskList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTaskRun) DeepCopyInto(out *MaintenanceTaskRun) {
+	*out = *in
+	in.ScheduledTime.DeepCopyInto(&out.ScheduledTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTaskRun.
+func (in *MaintenanceTaskRun) DeepCopy() *MaintenanceTaskRun {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTaskRun)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTaskSpec) DeepCopyInto(out *MaintenanceTaskSpec) {
+	*out = *in
+	out.Period = in.Period
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTaskSpec.
+func (in *MaintenanceTaskSpec) DeepCopy() *MaintenanceTaskSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTaskSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceTaskStatus) DeepCopyInto(out *MaintenanceTaskStatus) {
+	*out = *in
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(conditionsv1alpha1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceTaskStatus.
+func (in *MaintenanceTaskStatus) DeepCopy() *MaintenanceTaskStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceTaskStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceExportReference) DeepCopyInto(out *WorkspaceExportReference) {
 	*out = *in
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// NewFencingToken returns a fencing token for the given shard generation, with the given
+// random part.
+func NewFencingToken(generation int64, random string) string {
+	return fmt.Sprintf("%d-%s", generation, random)
+}
+
+// IsFencingTokenCurrent returns true if the shard has a fencing token that has been issued
+// for the current generation of the shard.
+func IsFencingTokenCurrent(shard *tenancyv1alpha1.ClusterWorkspaceShard) bool {
+	token := shard.Status.FencingToken
+	parts := strings.SplitN(token, "-", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return false
+	}
+	generation, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return false
+	}
+	return generation == shard.Generation
+}
+
+// IsValidFencingToken returns true if the given token is the current fencing token of the shard.
+func IsValidFencingToken(shard *tenancyv1alpha1.ClusterWorkspaceShard, token string) bool {
+	if token == "" || !IsFencingTokenCurrent(shard) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(shard.Status.FencingToken)) == 1
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestIsValidFencingToken(t *testing.T) {
+	tests := map[string]struct {
+		generation  int64
+		issued      string
+		token       string
+		wantCurrent bool
+		wantValid   bool
+	}{
+		"no token issued": {
+			generation: 1,
+			token:      "1-abc",
+		},
+		"matching token": {
+			generation:  2,
+			issued:      "2-abc",
+			token:       "2-abc",
+			wantCurrent: true,
+			wantValid:   true,
+		},
+		"wrong token": {
+			generation:  2,
+			issued:      "2-abc",
+			token:       "2-abd",
+			wantCurrent: true,
+		},
+		"empty token": {
+			generation:  2,
+			issued:      "2-abc",
+			wantCurrent: true,
+		},
+		"token of an older generation": {
+			generation: 3,
+			issued:     "2-abc",
+			token:      "2-abc",
+		},
+		"malformed token": {
+			generation: 2,
+			issued:     "abc",
+			token:      "abc",
+		},
+		"token without random part": {
+			generation: 2,
+			issued:     "2-",
+			token:      "2-",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{Name: "shard-1", Generation: tc.generation},
+				Status:     tenancyv1alpha1.ClusterWorkspaceShardStatus{FencingToken: tc.issued},
+			}
+			require.Equal(t, tc.wantCurrent, IsFencingTokenCurrent(shard))
+			require.Equal(t, tc.wantValid, IsValidFencingToken(shard, tc.token))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package helper
+
+import (
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const objectCountPrefix = "count/"
+
+// IsInWorkspaceSubtree returns true if the logical cluster is a descendant of root, i.e.
+// it lives in the subtree constrained by a ClusterWorkspaceQuota of root. root itself is not
+// part of its subtree.
+func IsInWorkspaceSubtree(root, clusterName logicalcluster.LogicalCluster) bool {
+	return strings.HasPrefix(clusterName.String(), root.String()+":")
+}
+
+// ObjectCountGroupResource returns the group resource of an object count quota resource name
+// of the form count/<resource>.<group>, and false if the name is not an object count.
+func ObjectCountGroupResource(name corev1.ResourceName) (schema.GroupResource, bool) {
+	if !strings.HasPrefix(string(name), objectCountPrefix) {
+		return schema.GroupResource{}, false
+	}
+	gr := schema.ParseGroupResource(strings.TrimPrefix(string(name), objectCountPrefix))
+	if gr.Resource == "" {
+		return schema.GroupResource{}, false
+	}
+	return gr, true
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package helper
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsInWorkspaceSubtree(t *testing.T) {
+	tests := []struct {
+		root, clusterName string
+		want              bool
+	}{
+		{"root:org", "root:org:team", true},
+		{"root:org", "root:org:team:ws", true},
+		{"root", "root:org", true},
+		{"root:org", "root:org", false},
+		{"root:org", "root:organization", false},
+		{"root:org", "root", false},
+		{"root:org", "root:other:ws", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.root+" "+tt.clusterName, func(t *testing.T) {
+			require.Equal(t, tt.want, IsInWorkspaceSubtree(logicalcluster.New(tt.root), logicalcluster.New(tt.clusterName)))
+		})
+	}
+}
+
+func TestObjectCountGroupResource(t *testing.T) {
+	tests := []struct {
+		name   corev1.ResourceName
+		want   schema.GroupResource
+		wantOk bool
+	}{
+		{"count/configmaps", schema.GroupResource{Resource: "configmaps"}, true},
+		{"count/deployments.apps", schema.GroupResource{Group: "apps", Resource: "deployments"}, true},
+		{"count/widgets.example.dev", schema.GroupResource{Group: "example.dev", Resource: "widgets"}, true},
+		{"count/", schema.GroupResource{}, false},
+		{"cpu", schema.GroupResource{}, false},
+		{"requests.memory", schema.GroupResource{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.name), func(t *testing.T) {
+			got, ok := ObjectCountGroupResource(tt.name)
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
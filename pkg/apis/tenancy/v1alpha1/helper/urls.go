@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// Audience is who a URL emitted by kcp is meant for. In split-horizon deployments a shard is
+// reachable under different addresses from inside and from outside the deployment network.
+type Audience string
+
+const (
+	// ExternalAudience are users and components outside the deployment network, e.g. clients
+	// of workspaces and syncers on physical clusters.
+	ExternalAudience Audience = "External"
+	// InternalAudience are components inside the deployment network, e.g. the front-proxy
+	// and other shards.
+	InternalAudience Audience = "Internal"
+)
+
+// ShardURL returns the URL of the shard for the given audience. The base URL is the internal
+// one, the external URL the external one. Each falls back to the other if not set.
+func ShardURL(spec tenancyv1alpha1.ClusterWorkspaceShardSpec, audience Audience) string {
+	internal, external := spec.BaseURL, spec.ExternalURL
+	if internal == "" {
+		internal = external
+	}
+	if external == "" {
+		external = internal
+	}
+	if audience == InternalAudience {
+		return internal
+	}
+	return external
+}
+
+// ShardVirtualWorkspaceURL returns the URL of the virtual workspaces of the shard, without the
+// /services path, for the given audience. Virtual workspaces served in-process by the shard,
+// i.e. without a URL of their own, are reachable under the URL of the shard.
+func ShardVirtualWorkspaceURL(spec tenancyv1alpha1.ClusterWorkspaceShardSpec, audience Audience) string {
+	if audience == ExternalAudience && spec.ExternalVirtualWorkspaceURL != "" {
+		return spec.ExternalVirtualWorkspaceURL
+	}
+	if spec.VirtualWorkspaceURL != "" {
+		return spec.VirtualWorkspaceURL
+	}
+	return ShardURL(spec, audience)
+}
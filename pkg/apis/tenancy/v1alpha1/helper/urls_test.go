@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestShardURLs(t *testing.T) {
+	tests := map[string]struct {
+		spec                   tenancyv1alpha1.ClusterWorkspaceShardSpec
+		wantInternal           string
+		wantExternal           string
+		wantInternalVirtualURL string
+		wantExternalVirtualURL string
+	}{
+		"single URL": {
+			spec:                   tenancyv1alpha1.ClusterWorkspaceShardSpec{ExternalURL: "https://kcp.example.com"},
+			wantInternal:           "https://kcp.example.com",
+			wantExternal:           "https://kcp.example.com",
+			wantInternalVirtualURL: "https://kcp.example.com",
+			wantExternalVirtualURL: "https://kcp.example.com",
+		},
+		"split horizon with in-process virtual workspaces": {
+			spec:                   tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1.kcp.svc:6443", ExternalURL: "https://kcp.example.com"},
+			wantInternal:           "https://shard-1.kcp.svc:6443",
+			wantExternal:           "https://kcp.example.com",
+			wantInternalVirtualURL: "https://shard-1.kcp.svc:6443",
+			wantExternalVirtualURL: "https://kcp.example.com",
+		},
+		"stand-alone virtual workspaces": {
+			spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+				BaseURL:             "https://shard-1.kcp.svc:6443",
+				ExternalURL:         "https://kcp.example.com",
+				VirtualWorkspaceURL: "https://vw-1.kcp.svc:6443",
+			},
+			wantInternal:           "https://shard-1.kcp.svc:6443",
+			wantExternal:           "https://kcp.example.com",
+			wantInternalVirtualURL: "https://vw-1.kcp.svc:6443",
+			wantExternalVirtualURL: "https://vw-1.kcp.svc:6443",
+		},
+		"split horizon with stand-alone virtual workspaces": {
+			spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+				BaseURL:                     "https://shard-1.kcp.svc:6443",
+				ExternalURL:                 "https://kcp.example.com",
+				VirtualWorkspaceURL:         "https://vw-1.kcp.svc:6443",
+				ExternalVirtualWorkspaceURL: "https://vw.kcp.example.com",
+			},
+			wantInternal:           "https://shard-1.kcp.svc:6443",
+			wantExternal:           "https://kcp.example.com",
+			wantInternalVirtualURL: "https://vw-1.kcp.svc:6443",
+			wantExternalVirtualURL: "https://vw.kcp.example.com",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.wantInternal, ShardURL(tt.spec, InternalAudience))
+			require.Equal(t, tt.wantExternal, ShardURL(tt.spec, ExternalAudience))
+			require.Equal(t, tt.wantInternalVirtualURL, ShardVirtualWorkspaceURL(tt.spec, InternalAudience))
+			require.Equal(t, tt.wantExternalVirtualURL, ShardVirtualWorkspaceURL(tt.spec, ExternalAudience))
+		})
+	}
+}
@@ -51,6 +51,20 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ClusterWorkspaceTypeList{},
 		&ClusterWorkspaceShard{},
 		&ClusterWorkspaceShardList{},
+		&ClusterWorkspaceQuota{},
+		&ClusterWorkspaceQuotaList{},
+		&AccessRequest{},
+		&AccessRequestList{},
+		&WorkspaceRequestSet{},
+		&WorkspaceRequestSetList{},
+		&WorkspaceClone{},
+		&WorkspaceCloneList{},
+		&WorkspaceMigration{},
+		&WorkspaceMigrationList{},
+		&ShardUpgradePlan{},
+		&ShardUpgradePlanList{},
+		&NameReservation{},
+		&NameReservationList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
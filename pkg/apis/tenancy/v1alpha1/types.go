@@ -20,8 +20,10 @@ import (
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
@@ -29,6 +31,18 @@ import (
 // RootCluster is the root of ClusterWorkspace based logical clusters.
 var RootCluster = logicalcluster.New("root")
 
+// RootShard is the name of the ClusterWorkspaceShard of the shard hosting the root logical cluster.
+const RootShard = "root"
+
+// OwnerClusterAnnotationKey on an object names the logical cluster of an ancestor workspace in which
+// the owner references of the object are resolved, instead of in the object's own logical cluster.
+// The object is garbage collected when none of its owners exists anymore in that workspace.
+const OwnerClusterAnnotationKey = "tenancy.kcp.dev/owner-cluster"
+
+// ExpirationTimeAnnotationKey on a ClusterRoleBinding in a workspace holds an RFC3339 timestamp after
+// which the binding is deleted, for just-in-time access.
+const ExpirationTimeAnnotationKey = "experimental.tenancy.kcp.dev/expiration-time"
+
 // ClusterWorkspace defines a Kubernetes-cluster-like endpoint that holds a default set
 // of resources and exhibits standard Kubernetes API semantics of CRUD operations. It represents
 // the full life-cycle of the persisted data in this workspace in a KCP installation.
@@ -41,6 +55,8 @@ var RootCluster = logicalcluster.New("root")
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:storageversion
+// +kubebuilder:deprecatedversion:warning="tenancy.kcp.dev/v1alpha1 ClusterWorkspace is deprecated, use tenancy.kcp.dev/v1beta1"
 // +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`,description="Type of the workspace"
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The current phase (e.g. Scheduling, Initializing, Ready)"
 // +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.baseURL`,description="URL to access the workspace"
@@ -85,6 +101,107 @@ type ClusterWorkspaceSpec struct {
 	// +optional
 	// +kubebuilder:default:="Universal"
 	Type string `json:"type,omitempty"`
+
+	// impersonation restricts who may impersonate which users and groups inside
+	// the workspace. It is enforced on top of the RBAC permissions of the workspace,
+	// and can only be changed by those who can update the ClusterWorkspace in its
+	// parent. If unset, impersonation is only subject to RBAC.
+	//
+	// +optional
+	Impersonation *ImpersonationConstraints `json:"impersonation,omitempty"`
+
+	// expiration makes the workspace ephemeral. Once it expires, the workspace
+	// is deleted or hibernated. If unset on creation, the default expiration of
+	// the type is applied.
+	//
+	// +optional
+	Expiration *ClusterWorkspaceExpiration `json:"expiration,omitempty"`
+
+	// serviceAccountAudiences are additional audiences accepted for service
+	// account tokens used against the workspace, on top of the API audiences of
+	// the server and the audience of the workspace itself, which is the
+	// workspace path prefixed with "kcp.dev/workspace/".
+	//
+	// +optional
+	// +listType=set
+	ServiceAccountAudiences []string `json:"serviceAccountAudiences,omitempty"`
+}
+
+// ClusterWorkspaceExpiration defines when a workspace expires and what happens then.
+// The expiration can be changed at any time, e.g. to extend the lifetime of a workspace.
+type ClusterWorkspaceExpiration struct {
+	// ttl is the lifetime of the workspace, counted from its creation.
+	//
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// expiryTime is the point in time the workspace expires. It takes precedence
+	// over ttl. It is ignored in the default expiration of a ClusterWorkspaceType.
+	//
+	// +optional
+	ExpiryTime *metav1.Time `json:"expiryTime,omitempty"`
+
+	// warningPeriod is how long before the expiry the workspace gets the
+	// Expiring condition and an event is recorded.
+	//
+	// +optional
+	// +kubebuilder:default:="1h"
+	WarningPeriod *metav1.Duration `json:"warningPeriod,omitempty"`
+
+	// action is what happens to the workspace when it expires. Delete deletes
+	// the workspace, Hibernate marks it as hibernated.
+	//
+	// +optional
+	// +kubebuilder:default:=Delete
+	Action ClusterWorkspaceExpirationAction `json:"action,omitempty"`
+}
+
+// ClusterWorkspaceExpirationAction is what happens to a ClusterWorkspace when it expires.
+//
+// +kubebuilder:validation:Enum=Delete;Hibernate
+type ClusterWorkspaceExpirationAction string
+
+const (
+	// ClusterWorkspaceExpirationActionDelete deletes the expired workspace.
+	ClusterWorkspaceExpirationActionDelete ClusterWorkspaceExpirationAction = "Delete"
+	// ClusterWorkspaceExpirationActionHibernate marks the expired workspace as hibernated.
+	ClusterWorkspaceExpirationActionHibernate ClusterWorkspaceExpirationAction = "Hibernate"
+)
+
+// ImpersonationConstraints restricts impersonation inside a workspace.
+type ImpersonationConstraints struct {
+	// rules lists the allowed impersonations. An impersonation is allowed if
+	// one rule matches both the impersonating user and the impersonated user or
+	// group. No rules forbid impersonation inside the workspace.
+	//
+	// +optional
+	Rules []ImpersonationRule `json:"rules,omitempty"`
+}
+
+// ImpersonationRule allows the matching users and members of the matching groups
+// to impersonate the matching users and groups. "*" matches every name.
+type ImpersonationRule struct {
+	// users are the names of the users allowed to impersonate.
+	//
+	// +optional
+	Users []string `json:"users,omitempty"`
+
+	// groups are the groups whose members are allowed to impersonate.
+	//
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// impersonatedUsers are the names of the users that can be impersonated.
+	// Service accounts are matched by their user name, i.e.
+	// system:serviceaccount:<namespace>:<name>.
+	//
+	// +optional
+	ImpersonatedUsers []string `json:"impersonatedUsers,omitempty"`
+
+	// impersonatedGroups are the groups that can be impersonated.
+	//
+	// +optional
+	ImpersonatedGroups []string `json:"impersonatedGroups,omitempty"`
 }
 
 // ClusterWorkspaceType specifies behaviour of workspaces of this type.
@@ -116,6 +233,208 @@ type ClusterWorkspaceTypeSpec struct {
 	//
 	// +optional
 	AdditionalWorkspaceLabels map[string]string `json:"additionalWorkspaceLabels,omitempty"`
+
+	// lifecycleHooks are called when a ClusterWorkspace of this type is created,
+	// becomes ready or is deleted, such that external systems are notified of
+	// the life-cycle of the workspaces without polling.
+	//
+	// +optional
+	LifecycleHooks []ClusterWorkspaceLifecycleHook `json:"lifecycleHooks,omitempty"`
+
+	// deprecated indicates that the type is deprecated. Requests creating or
+	// accessing ClusterWorkspaces of the type, or the type itself, get a warning.
+	//
+	// +optional
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// deprecationWarning overrides the default warning returned to API clients.
+	// May only be set when `deprecated` is true.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=256
+	DeprecationWarning *string `json:"deprecationWarning,omitempty"`
+
+	// defaultExpiration is set on ClusterWorkspaces of this type that are
+	// created without an expiration. Its expiryTime is ignored.
+	//
+	// +optional
+	DefaultExpiration *ClusterWorkspaceExpiration `json:"defaultExpiration,omitempty"`
+
+	// limits protect the storage of the shard from ClusterWorkspaces of this type
+	// by limiting the size of objects and the number of objects per resource.
+	//
+	// +optional
+	Limits *ClusterWorkspaceLimits `json:"limits,omitempty"`
+
+	// defaultAPIBindings are the APIExports bound in every ClusterWorkspace of
+	// this type when it is initialized, e.g. the kubernetes APIExport of a compute
+	// workspace, such that their APIs are available when the workspace is ready.
+	// The APIBindings are named after the APIExports.
+	//
+	// +optional
+	DefaultAPIBindings []apisv1alpha1.ExportReference `json:"defaultAPIBindings,omitempty"`
+
+	// allowedAPIGroups restricts the API groups that can be added to ClusterWorkspaces of
+	// this type through CustomResourceDefinitions and APIBindings. The groups of an APIBinding
+	// are those of the latest resource schemas of the bound APIExport. If empty, all API groups
+	// are allowed. The built-in APIs of kcp are not restricted.
+	//
+	// +optional
+	// +listType=set
+	AllowedAPIGroups []string `json:"allowedAPIGroups,omitempty"`
+}
+
+// ClusterWorkspaceAPIBindingsInitializer is the initializer of the ClusterWorkspaces whose type has
+// defaultAPIBindings. It is removed once the APIBindings are bound.
+const ClusterWorkspaceAPIBindingsInitializer ClusterWorkspaceInitializer = "initializers.tenancy.kcp.dev/apibindings"
+
+// ClusterWorkspaceLimits limits the objects stored in a workspace. Writes beyond
+// the limits are rejected at admission.
+type ClusterWorkspaceLimits struct {
+	// maxObjectSize is the maximum size of an object, serialized as JSON.
+	// Larger objects are rejected on create and update.
+	//
+	// +optional
+	MaxObjectSize *resource.Quantity `json:"maxObjectSize,omitempty"`
+
+	// maxObjectsPerResource is the maximum number of objects of each resource
+	// in the workspace. Creating more objects is rejected.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxObjectsPerResource *int64 `json:"maxObjectsPerResource,omitempty"`
+
+	// resources overrides maxObjectsPerResource for individual resources.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=group
+	// +listMapKey=resource
+	Resources []ClusterWorkspaceResourceLimit `json:"resources,omitempty"`
+}
+
+// ClusterWorkspaceResourceLimit limits the number of objects of one resource.
+type ClusterWorkspaceResourceLimit struct {
+	// group is the API group of the resource, empty for the core group.
+	//
+	// +required
+	Group string `json:"group"`
+
+	// resource is the lower-case plural name of the resource.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Resource string `json:"resource"`
+
+	// maxObjects is the maximum number of objects of the resource in the workspace.
+	//
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	MaxObjects int64 `json:"maxObjects"`
+}
+
+// ClusterWorkspaceLifecycleHook is an HTTPS endpoint a ClusterWorkspaceLifecycleNotification
+// is POSTed to on the given events of the ClusterWorkspaces of a type. The hook must respond
+// with a 2xx status code.
+type ClusterWorkspaceLifecycleHook struct {
+	// name identifies the hook among the hooks of the type.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern:="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	Name string `json:"name"`
+
+	// url is the HTTPS URL of the hook.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:="^https://"
+	URL string `json:"url"`
+
+	// caBundle is a PEM encoded CA bundle to verify the serving certificate of the
+	// hook. If unspecified, the system trust roots are used.
+	//
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// events are the life-cycle events the hook is called on.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Events []ClusterWorkspaceLifecycleEvent `json:"events"`
+
+	// failurePolicy defines how a hook failing after retries is handled. Ignore
+	// gives up on the event, Fail retries with backoff until the hook succeeds, and
+	// blocks the deletion of the workspace on Deleted events.
+	//
+	// +optional
+	// +kubebuilder:default:=Ignore
+	FailurePolicy ClusterWorkspaceLifecycleHookFailurePolicy `json:"failurePolicy,omitempty"`
+
+	// timeoutSeconds is the timeout of a single call of the hook.
+	//
+	// +optional
+	// +kubebuilder:default:=10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ClusterWorkspaceLifecycleEvent is an event in the life-cycle of a ClusterWorkspace.
+//
+// +kubebuilder:validation:Enum=Created;Ready;Deleted
+type ClusterWorkspaceLifecycleEvent string
+
+const (
+	// ClusterWorkspaceCreated is sent once for a new ClusterWorkspace, and for the existing
+	// ClusterWorkspaces of the type when a hook is added.
+	ClusterWorkspaceCreated ClusterWorkspaceLifecycleEvent = "Created"
+	// ClusterWorkspaceReady is sent once a ClusterWorkspace reached the Ready phase.
+	ClusterWorkspaceReady ClusterWorkspaceLifecycleEvent = "Ready"
+	// ClusterWorkspaceDeleted is sent when a ClusterWorkspace is being deleted.
+	ClusterWorkspaceDeleted ClusterWorkspaceLifecycleEvent = "Deleted"
+)
+
+// ClusterWorkspaceLifecycleHookFailurePolicy defines how failures of a hook are handled.
+//
+// +kubebuilder:validation:Enum=Ignore;Fail
+type ClusterWorkspaceLifecycleHookFailurePolicy string
+
+const (
+	// LifecycleHookFailurePolicyIgnore gives up on an event after a number of retries.
+	LifecycleHookFailurePolicyIgnore ClusterWorkspaceLifecycleHookFailurePolicy = "Ignore"
+	// LifecycleHookFailurePolicyFail retries an event until the hook succeeds.
+	LifecycleHookFailurePolicyFail ClusterWorkspaceLifecycleHookFailurePolicy = "Fail"
+)
+
+const (
+	// LifecycleHooksFinalizer is set on the ClusterWorkspaces whose type has hooks for the
+	// Deleted event. It is removed once the hooks have been called.
+	LifecycleHooksFinalizer = "tenancy.kcp.dev/lifecycle-hooks"
+
+	// LifecycleHooksNotifiedAnnotationKey on a ClusterWorkspace records the hooks and events that
+	// have been delivered, as comma-separated <hook>/<event> pairs.
+	LifecycleHooksNotifiedAnnotationKey = "tenancy.kcp.dev/lifecycle-hooks-notified"
+)
+
+// ClusterWorkspaceLifecycleNotification is the JSON body POSTed to a lifecycle hook.
+type ClusterWorkspaceLifecycleNotification struct {
+	// event is the life-cycle event.
+	Event ClusterWorkspaceLifecycleEvent `json:"event"`
+	// clusterName is the logical cluster of the parent the ClusterWorkspace lives in.
+	ClusterName string `json:"clusterName"`
+	// name is the name of the ClusterWorkspace.
+	Name string `json:"name"`
+	// uid is the UID of the ClusterWorkspace.
+	UID string `json:"uid"`
+	// type is the type of the ClusterWorkspace.
+	Type string `json:"type"`
+	// phase is the phase of the ClusterWorkspace.
+	Phase ClusterWorkspacePhaseType `json:"phase,omitempty"`
+	// baseURL is the URL of the ClusterWorkspace.
+	BaseURL string `json:"baseURL,omitempty"`
 }
 
 // ClusterWorkspaceTypeList is a list of cluster workspace types
@@ -141,6 +460,17 @@ const (
 	ClusterWorkspacePhaseReady        ClusterWorkspacePhaseType = "Ready"
 )
 
+const (
+	// DryRunShardAnnotationKey is set on the ClusterWorkspace returned by a server-side dry-run
+	// create to the name of the ClusterWorkspaceShard the workspace would be scheduled to. The
+	// status is not returned on create, hence the annotation.
+	DryRunShardAnnotationKey = "tenancy.kcp.dev/dry-run-shard"
+
+	// DryRunInitializersAnnotationKey is set on the ClusterWorkspace returned by a server-side
+	// dry-run create to the comma-separated initializers the workspace would get from its type.
+	DryRunInitializersAnnotationKey = "tenancy.kcp.dev/dry-run-initializers"
+)
+
 // ClusterWorkspaceStatus communicates the observed state of the ClusterWorkspace.
 type ClusterWorkspaceStatus struct {
 	// Phase of the workspace  (Scheduling / Initializing / Ready)
@@ -158,6 +488,14 @@ type ClusterWorkspaceStatus struct {
 	// +optional
 	BaseURL string `json:"baseURL,omitempty"`
 
+	// internalBaseURL is where this ClusterWorkspace can be targeted from inside the deployment
+	// network, i.e. through the base URL of its shard instead of its external URL. It is only
+	// different from baseURL in split-horizon deployments.
+	//
+	// +kubebuilder:validation:Pattern:https://[^/].*
+	// +optional
+	InternalBaseURL string `json:"internalBaseURL,omitempty"`
+
 	// Contains workspace placement information.
 	//
 	// +optional
@@ -172,6 +510,11 @@ type ClusterWorkspaceStatus struct {
 	//
 	// +optional
 	Initializers []ClusterWorkspaceInitializer `json:"initializers,omitempty"`
+
+	// expiryTime is the point in time the workspace expires, if it has an expiration.
+	//
+	// +optional
+	ExpiryTime *metav1.Time `json:"expiryTime,omitempty"`
 }
 
 // These are valid conditions of workspace.
@@ -190,6 +533,18 @@ const (
 	// WorkspaceShardValidReasonShardNotFound reason in WorkspaceShardValid condition means that the
 	// referenced ClusterWorkspaceShard object got deleted.
 	WorkspaceShardValidReasonShardNotFound = "ShardNotFound"
+
+	// WorkspaceHibernated is true when the workspace has not received requests of users for longer
+	// than the idle timeout of the shard. It turns false again on the next request.
+	WorkspaceHibernated conditionsv1alpha1.ConditionType = "Hibernated"
+	// WorkspaceHibernatedReasonWokenUp reason in WorkspaceHibernated condition means that the
+	// hibernated workspace received a request again.
+	WorkspaceHibernatedReasonWokenUp = "WokenUp"
+
+	// WorkspaceExpiring is true when the workspace expires within the warning period of its expiration.
+	WorkspaceExpiring conditionsv1alpha1.ConditionType = "Expiring"
+	// WorkspaceExpired is true when the workspace expired and has been hibernated.
+	WorkspaceExpired conditionsv1alpha1.ConditionType = "Expired"
 )
 
 // ClusterWorkspaceLocation specifies workspace placement information, including current, desired (target), and
@@ -226,8 +581,12 @@ type ClusterWorkspaceList struct {
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:storageversion
+// +kubebuilder:deprecatedversion:warning="tenancy.kcp.dev/v1alpha1 ClusterWorkspaceShard is deprecated, use tenancy.kcp.dev/v1beta1"
 // +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.baseURL`,description="Type URL to directly connect to the shard"
 // +kubebuilder:printcolumn:name="External URL",type=string,JSONPath=`.spec.externalURL`,description="The URL exposed in workspaces created on that shard"
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`,description="The version of kcp the shard runs"
+// +kubebuilder:printcolumn:name="Workspaces",type=integer,JSONPath=`.status.workspaceCount`,description="The number of logical clusters with objects on the shard"
 type ClusterWorkspaceShard struct {
 	metav1.TypeMeta `json:",inline"`
 	// +optional
@@ -283,6 +642,36 @@ type ClusterWorkspaceShardSpec struct {
 	// +kubebuilder:Required
 	// +required
 	ExternalURL string `json:"externalURL"`
+
+	// virtualWorkspaceURL is the address of the virtual workspace apiserver of the shard,
+	// without the /services path. If empty, virtual workspaces are served at the baseURL.
+	//
+	// +kubebuilder:validation:Format=uri
+	// +optional
+	VirtualWorkspaceURL string `json:"virtualWorkspaceURL,omitempty"`
+
+	// externalVirtualWorkspaceURL is the externally visible address of the virtual workspaces
+	// of the shard, without the /services path, presented to users. It defaults to the
+	// virtualWorkspaceURL, or to the externalURL if the shard serves virtual workspaces itself.
+	//
+	// +kubebuilder:validation:Format=uri
+	// +optional
+	ExternalVirtualWorkspaceURL string `json:"externalVirtualWorkspaceURL,omitempty"`
+
+	// replicaURL is the address of a read-only replica of the shard, reachable by other shards
+	// with the same credentials and serving CA as the shard. Cross-shard controllers read from
+	// it when the shard is briefly unavailable, e.g. while restarting. These reads may be stale.
+	//
+	// +kubebuilder:validation:Format=uri
+	// +optional
+	ReplicaURL string `json:"replicaURL,omitempty"`
+
+	// unschedulable cordons the shard: no new ClusterWorkspaces are scheduled to it, and
+	// WorkspaceMigrations to it wait until it is schedulable again. The workspaces on the
+	// shard are not affected.
+	//
+	// +optional
+	Unschedulable bool `json:"unschedulable,omitempty"`
 }
 
 // ClusterWorkspaceShardStatus communicates the observed state of the ClusterWorkspaceShard.
@@ -291,11 +680,70 @@ type ClusterWorkspaceShardStatus struct {
 	// +optional
 	Capacity corev1.ResourceList `json:"capacity,omitempty"`
 
+	// servingCABundle is the PEM encoded CA bundle that validates the serving certificate
+	// of the shard at spec.baseURL. It is published by the shard itself and kept up-to-date
+	// on rotation, such that the front-proxy and other shards can connect to it without
+	// distributing the CA out-of-band.
+	//
+	// +optional
+	ServingCABundle []byte `json:"servingCABundle,omitempty"`
+
+	// fencingToken is issued by the root shard when the join handshake of the shard succeeded,
+	// i.e. when its identity has been verified. The shard presents it on requests to the root
+	// shard. It is reissued whenever the spec of the shard changes, such that a shard acting
+	// on stale configuration is fenced off.
+	//
+	// +optional
+	FencingToken string `json:"fencingToken,omitempty"`
+
+	// version is the version of kcp the shard runs. It is published by the shard itself.
+	//
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// workspaceCount is the number of logical clusters with objects on the shard. It is
+	// published by the shard itself and refreshed periodically.
+	//
+	// +optional
+	WorkspaceCount *int32 `json:"workspaceCount,omitempty"`
+
+	// apiGroups are the sorted API groups of the CustomResourceDefinitions served by the shard,
+	// including those bound through APIBindings. They are published by the shard itself and
+	// refreshed periodically.
+	//
+	// +optional
+	APIGroups []string `json:"apiGroups,omitempty"`
+
 	// Current processing state of the ClusterWorkspaceShard.
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
 }
 
+const (
+	// ClusterWorkspaceShardsGroup is the group of the credentials shards use towards the root shard.
+	// Requests with these credentials are fenced, i.e. have to present the fencing token of their shard.
+	ClusterWorkspaceShardsGroup = "system:kcp:shards"
+
+	// ClusterWorkspaceShardOwnerAnnotationKey records the shard credentials, i.e. the user in the
+	// ClusterWorkspaceShardsGroup, that registered the ClusterWorkspaceShard. No other shard
+	// credentials can update or act as the shard afterwards.
+	ClusterWorkspaceShardOwnerAnnotationKey = "tenancy.kcp.dev/shard-owner"
+
+	// ClusterWorkspaceShardPreviousURLsAnnotationKey records the comma separated URLs the
+	// ClusterWorkspaceShard was reachable at before its baseURL or externalURL changed, most
+	// recent first. Requests arriving at these URLs are warned or redirected to the current one.
+	ClusterWorkspaceShardPreviousURLsAnnotationKey = "tenancy.kcp.dev/previous-urls"
+)
+
+// These are valid conditions of workspace shards.
+const (
+	// WorkspaceShardJoined represents the status of the join handshake of the shard with the root shard.
+	WorkspaceShardJoined conditionsv1alpha1.ConditionType = "Joined"
+	// WorkspaceShardJoinedReasonIdentityNotVerified reason in WorkspaceShardJoined condition means that
+	// the serving certificate of the shard could not be verified.
+	WorkspaceShardJoinedReasonIdentityNotVerified = "IdentityNotVerified"
+)
+
 // ClusterWorkspaceShardList is a list of workspace shards
 //
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -305,3 +753,984 @@ type ClusterWorkspaceShardList struct {
 
 	Items []ClusterWorkspaceShard `json:"items"`
 }
+
+// ClusterWorkspaceQuota constrains the aggregated usage of all workspaces below the workspace
+// it lives in, i.e. of the whole workspace subtree, not only of the direct children.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+type ClusterWorkspaceQuota struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec ClusterWorkspaceQuotaSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status ClusterWorkspaceQuotaStatus `json:"status,omitempty"`
+}
+
+// ClusterWorkspaceQuotaSpec holds the desired hard limits of the workspace subtree.
+type ClusterWorkspaceQuotaSpec struct {
+	// hard is the set of limits for the sum of all descendant workspaces. Object counts of
+	// namespaced resources are supported, in the same format as for ResourceQuotas, i.e.
+	// count/<resource>.<group> or count/<resource> for the core group.
+	//
+	// +optional
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+}
+
+// ClusterWorkspaceQuotaStatus communicates the observed usage of the workspace subtree.
+type ClusterWorkspaceQuotaStatus struct {
+	// hard is the set of enforced hard limits.
+	//
+	// +optional
+	Hard corev1.ResourceList `json:"hard,omitempty"`
+
+	// used is the current observed total usage of the resources in all descendant workspaces.
+	// Admission charges new objects here before they are created, and the quota controller
+	// recalculates it periodically.
+	//
+	// +optional
+	Used corev1.ResourceList `json:"used,omitempty"`
+}
+
+// ClusterWorkspaceQuotaList is a list of ClusterWorkspaceQuotas
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ClusterWorkspaceQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ClusterWorkspaceQuota `json:"items"`
+}
+
+// AccessRequest is a request of a user for access to a ClusterWorkspace. It lives next to the
+// ClusterWorkspace, i.e. in its parent workspace. Owners of the workspace approve or deny it
+// in the status, and the granted access is revoked after an optional TTL.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Workspace",type=string,JSONPath=`.spec.workspace`,description="The workspace access is requested to"
+// +kubebuilder:printcolumn:name="Role",type=string,JSONPath=`.spec.role`,description="The requested role"
+// +kubebuilder:printcolumn:name="User",type=string,JSONPath=`.spec.user`,description="The requesting user"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The phase of the request"
+type AccessRequest struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec AccessRequestSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status AccessRequestStatus `json:"status,omitempty"`
+}
+
+// AccessRequestRole is the access to a workspace, i.e. the verb on clusterworkspaces/content.
+//
+// +kubebuilder:validation:Enum=access;admin
+type AccessRequestRole string
+
+const (
+	// AccessRequestRoleAccess grants access to the content of the workspace.
+	AccessRequestRoleAccess AccessRequestRole = "access"
+	// AccessRequestRoleAdmin grants admin access to the content of the workspace.
+	AccessRequestRoleAdmin AccessRequestRole = "admin"
+)
+
+// AccessRequestSpec holds the requested access.
+type AccessRequestSpec struct {
+	// workspace is the name of the ClusterWorkspace access is requested to. It must be a
+	// child of the workspace the request lives in.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	// +kubebuilder:validation:Required
+	Workspace string `json:"workspace"`
+
+	// role is the requested access to the workspace.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Role AccessRequestRole `json:"role"`
+
+	// justification explains to the owners of the workspace why access is needed.
+	//
+	// +optional
+	Justification string `json:"justification,omitempty"`
+
+	// ttl is how long the access is granted after approval. If unset, the access is granted
+	// until the request is deleted.
+	//
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// user is the user requesting access. It is set on creation to the creating user.
+	//
+	// +optional
+	User string `json:"user,omitempty"`
+}
+
+// AccessRequestDecision is the decision of an owner of the workspace about an AccessRequest.
+//
+// +kubebuilder:validation:Enum=Approved;Denied
+type AccessRequestDecision string
+
+const (
+	AccessRequestApproved AccessRequestDecision = "Approved"
+	AccessRequestDenied   AccessRequestDecision = "Denied"
+)
+
+// AccessRequestPhase is the phase of an AccessRequest.
+//
+// +kubebuilder:validation:Enum=Pending;Granted;Denied;Expired
+type AccessRequestPhase string
+
+const (
+	// AccessRequestPhasePending means no decision has been taken yet.
+	AccessRequestPhasePending AccessRequestPhase = "Pending"
+	// AccessRequestPhaseGranted means the request was approved and the access is granted.
+	AccessRequestPhaseGranted AccessRequestPhase = "Granted"
+	// AccessRequestPhaseDenied means the request was denied.
+	AccessRequestPhaseDenied AccessRequestPhase = "Denied"
+	// AccessRequestPhaseExpired means the granted access has been revoked after the TTL.
+	AccessRequestPhaseExpired AccessRequestPhase = "Expired"
+)
+
+// AccessRequestStatus communicates the decision about the AccessRequest and the state of the access.
+type AccessRequestStatus struct {
+	// decision is set once by an owner of the workspace, i.e. a user with admin access to it,
+	// other than the requesting user.
+	//
+	// +optional
+	Decision AccessRequestDecision `json:"decision,omitempty"`
+
+	// decidedBy is the user that set the decision. It is set on decision.
+	//
+	// +optional
+	DecidedBy string `json:"decidedBy,omitempty"`
+
+	// phase is the state of the access.
+	//
+	// +optional
+	Phase AccessRequestPhase `json:"phase,omitempty"`
+
+	// expirationTime is when the granted access is revoked. It is set when access is granted
+	// with a TTL.
+	//
+	// +optional
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+}
+
+// AccessRequestList is a list of AccessRequests
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type AccessRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AccessRequest `json:"items"`
+}
+
+// WorkspaceRequestSet creates a number of ClusterWorkspaces from a template, e.g. for
+// classes or CI runs needing many identical workspaces. It lives next to the created
+// ClusterWorkspaces, i.e. in their parent workspace. The ClusterWorkspaces are created
+// on behalf of the user that created the set, and are deleted with it.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Count",type=integer,JSONPath=`.spec.count`,description="The number of requested workspaces"
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.ready`,description="The number of ready workspaces"
+// +kubebuilder:printcolumn:name="Failed",type=integer,JSONPath=`.status.failed`,description="The number of workspaces that could not be created"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The phase of the set"
+type WorkspaceRequestSet struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec WorkspaceRequestSetSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status WorkspaceRequestSetStatus `json:"status,omitempty"`
+}
+
+// WorkspaceRequestSetIndexPlaceholder is replaced by the index of the workspace in the name
+// pattern of a WorkspaceRequestSet.
+const WorkspaceRequestSetIndexPlaceholder = "{index}"
+
+// WorkspaceRequestSetLabel is set on the ClusterWorkspaces created by a WorkspaceRequestSet
+// to the name of the set.
+const WorkspaceRequestSetLabel = "tenancy.kcp.dev/workspace-request-set"
+
+// WorkspaceRequestSetSpec holds the workspaces to create.
+type WorkspaceRequestSetSpec struct {
+	// count is the number of workspaces to create. Lowering it deletes the workspaces
+	// with the highest indexes.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	// +required
+	// +kubebuilder:validation:Required
+	Count int32 `json:"count"`
+
+	// namePattern is the name of the workspaces, with "{index}" replaced by the index of
+	// the workspace, e.g. "student-{index}".
+	//
+	// +kubebuilder:validation:Pattern=`\{index\}`
+	// +required
+	// +kubebuilder:validation:Required
+	NamePattern string `json:"namePattern"`
+
+	// startIndex is the index of the first workspace.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StartIndex int32 `json:"startIndex,omitempty"`
+
+	// template is the ClusterWorkspace created for every index. Changes only apply to
+	// workspaces created afterwards.
+	//
+	// +optional
+	Template WorkspaceRequestSetTemplate `json:"template,omitempty"`
+
+	// user is the user on whose behalf the workspaces are created. It is set on creation to
+	// the creating user.
+	//
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// groups are the groups of the user. They are set on creation to the groups of the
+	// creating user.
+	//
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+}
+
+// WorkspaceRequestSetTemplate describes the ClusterWorkspaces created by a WorkspaceRequestSet.
+type WorkspaceRequestSetTemplate struct {
+	// labels are set on the created ClusterWorkspaces.
+	//
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// annotations are set on the created ClusterWorkspaces.
+	//
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// spec is the spec of the created ClusterWorkspaces.
+	//
+	// +optional
+	Spec ClusterWorkspaceSpec `json:"spec,omitempty"`
+}
+
+// WorkspaceRequestSetPhase is the phase of a WorkspaceRequestSet.
+//
+// +kubebuilder:validation:Enum=Creating;Ready;Failed
+type WorkspaceRequestSetPhase string
+
+const (
+	// WorkspaceRequestSetPhaseCreating means that some workspaces are not ready yet.
+	WorkspaceRequestSetPhaseCreating WorkspaceRequestSetPhase = "Creating"
+	// WorkspaceRequestSetPhaseReady means that all workspaces are ready.
+	WorkspaceRequestSetPhaseReady WorkspaceRequestSetPhase = "Ready"
+	// WorkspaceRequestSetPhaseFailed means that all workspaces are created or failed,
+	// and at least one failed.
+	WorkspaceRequestSetPhaseFailed WorkspaceRequestSetPhase = "Failed"
+)
+
+// WorkspaceRequestPhase is the phase of a single workspace of a WorkspaceRequestSet.
+//
+// +kubebuilder:validation:Enum=Pending;Creating;Ready;Failed
+type WorkspaceRequestPhase string
+
+const (
+	// WorkspaceRequestPhasePending means that the workspace has not been created yet.
+	WorkspaceRequestPhasePending WorkspaceRequestPhase = "Pending"
+	// WorkspaceRequestPhaseCreating means that the workspace is created, but not ready yet.
+	WorkspaceRequestPhaseCreating WorkspaceRequestPhase = "Creating"
+	// WorkspaceRequestPhaseReady means that the workspace is ready.
+	WorkspaceRequestPhaseReady WorkspaceRequestPhase = "Ready"
+	// WorkspaceRequestPhaseFailed means that the workspace could not be created, e.g.
+	// because it is forbidden or the name is taken.
+	WorkspaceRequestPhaseFailed WorkspaceRequestPhase = "Failed"
+)
+
+// WorkspaceRequestStatus is the state of a single workspace of a WorkspaceRequestSet.
+type WorkspaceRequestStatus struct {
+	// name is the name of the ClusterWorkspace.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// phase is the state of the workspace.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Phase WorkspaceRequestPhase `json:"phase"`
+
+	// message is a human readable reason why the workspace failed.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// WorkspaceRequestSetStatus communicates the state of the workspaces of a WorkspaceRequestSet.
+type WorkspaceRequestSetStatus struct {
+	// phase is the state of the set.
+	//
+	// +optional
+	Phase WorkspaceRequestSetPhase `json:"phase,omitempty"`
+
+	// ready is the number of ready workspaces.
+	//
+	// +optional
+	Ready int32 `json:"ready,omitempty"`
+
+	// failed is the number of workspaces that could not be created.
+	//
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+
+	// workspaces is the state of every workspace, in the order of their indexes.
+	//
+	// +optional
+	Workspaces []WorkspaceRequestStatus `json:"workspaces,omitempty"`
+}
+
+// WorkspaceRequestSetList is a list of WorkspaceRequestSets
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceRequestSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkspaceRequestSet `json:"items"`
+}
+
+// WorkspaceClone duplicates a ClusterWorkspace into a new sibling ClusterWorkspace of the same
+// type: its APIBindings, its RBAC and optionally its other contents. It lives next to both
+// ClusterWorkspaces, i.e. in their parent workspace. The clone is done on behalf of the user
+// that created the WorkspaceClone, i.e. only what the user can read is cloned.
+//
+// The clone is a snapshot. Later changes of the source workspace are not cloned, and deleting
+// the WorkspaceClone keeps the cloned workspace.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source`,description="The cloned workspace"
+// +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.target`,description="The new workspace"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The progress of the clone"
+type WorkspaceClone struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec WorkspaceCloneSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status WorkspaceCloneStatus `json:"status,omitempty"`
+}
+
+func (in *WorkspaceClone) SetConditions(c conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = c
+}
+
+func (in *WorkspaceClone) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+var _ conditions.Getter = &WorkspaceClone{}
+var _ conditions.Setter = &WorkspaceClone{}
+
+// WorkspaceCloneSpec holds the workspace to clone, and what to clone of it.
+type WorkspaceCloneSpec struct {
+	// source is the name of the ClusterWorkspace to clone.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	// +kubebuilder:validation:Required
+	Source string `json:"source"`
+
+	// target is the name of the ClusterWorkspace to create. It must not exist yet.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	// +kubebuilder:validation:Required
+	Target string `json:"target"`
+
+	// contents selects the resources cloned in addition to the APIBindings and the RBAC. If
+	// unset, no other resources are cloned.
+	//
+	// +optional
+	Contents *WorkspaceCloneContents `json:"contents,omitempty"`
+
+	// user is the user on whose behalf the workspace is cloned. It is set on creation to the
+	// creating user.
+	//
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// groups are the groups of the user. They are set on creation to the groups of the
+	// creating user.
+	//
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+}
+
+// WorkspaceCloneContents selects the resources to clone. Objects are cloned without their status.
+type WorkspaceCloneContents struct {
+	// include lists the resources to clone. If empty, all resources are cloned that are not
+	// excluded.
+	//
+	// +optional
+	Include []WorkspaceCloneResource `json:"include,omitempty"`
+
+	// exclude lists the resources not to clone.
+	//
+	// +optional
+	Exclude []WorkspaceCloneResource `json:"exclude,omitempty"`
+}
+
+// WorkspaceCloneResource is a resource of a workspace.
+type WorkspaceCloneResource struct {
+	// group is the API group of the resource, empty for the core group.
+	//
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// resource is the plural name of the resource, or "*" for all resources of the group.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	// +kubebuilder:validation:Required
+	Resource string `json:"resource"`
+}
+
+// WorkspaceClonePhase is the phase of a WorkspaceClone.
+//
+// +kubebuilder:validation:Enum=Pending;Creating;Cloning;Completed;Failed
+type WorkspaceClonePhase string
+
+const (
+	// WorkspaceClonePhasePending means the source workspace is not ready yet.
+	WorkspaceClonePhasePending WorkspaceClonePhase = "Pending"
+	// WorkspaceClonePhaseCreating means the target workspace is created and initialized.
+	WorkspaceClonePhaseCreating WorkspaceClonePhase = "Creating"
+	// WorkspaceClonePhaseCloning means the objects of the source workspace are cloned.
+	WorkspaceClonePhaseCloning WorkspaceClonePhase = "Cloning"
+	// WorkspaceClonePhaseCompleted means the workspace has been cloned.
+	WorkspaceClonePhaseCompleted WorkspaceClonePhase = "Completed"
+	// WorkspaceClonePhaseFailed means the target workspace could not be created.
+	WorkspaceClonePhaseFailed WorkspaceClonePhase = "Failed"
+)
+
+// WorkspaceCloneStatus communicates the progress of the WorkspaceClone.
+type WorkspaceCloneStatus struct {
+	// phase is the progress of the clone.
+	//
+	// +optional
+	Phase WorkspaceClonePhase `json:"phase,omitempty"`
+
+	// clonedObjects is the number of objects cloned into the target workspace.
+	//
+	// +optional
+	ClonedObjects int64 `json:"clonedObjects,omitempty"`
+
+	// Current processing state of the WorkspaceClone.
+	//
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+const (
+	// WorkspaceCloneWorkspaceCreated represents whether the target workspace has been created
+	// and is ready.
+	WorkspaceCloneWorkspaceCreated conditionsv1alpha1.ConditionType = "WorkspaceCreated"
+	// WorkspaceCloneReasonSourceNotFound reason in WorkspaceCloneWorkspaceCreated condition means
+	// that the source ClusterWorkspace does not exist.
+	WorkspaceCloneReasonSourceNotFound = "SourceNotFound"
+	// WorkspaceCloneReasonSourceNotReady reason in WorkspaceCloneWorkspaceCreated condition means
+	// that the source ClusterWorkspace is not ready yet.
+	WorkspaceCloneReasonSourceNotReady = "SourceNotReady"
+	// WorkspaceCloneReasonTargetExists reason in WorkspaceCloneWorkspaceCreated condition means
+	// that the target ClusterWorkspace exists, but has not been created by the WorkspaceClone.
+	WorkspaceCloneReasonTargetExists = "TargetExists"
+	// WorkspaceCloneReasonCreationFailed reason in WorkspaceCloneWorkspaceCreated condition means
+	// that the target ClusterWorkspace could not be created.
+	WorkspaceCloneReasonCreationFailed = "CreationFailed"
+	// WorkspaceCloneReasonTargetNotReady reason in WorkspaceCloneWorkspaceCreated condition means
+	// that the target ClusterWorkspace is not ready yet.
+	WorkspaceCloneReasonTargetNotReady = "TargetNotReady"
+
+	// WorkspaceCloneAPIBindingsCloned represents whether the APIBindings have been cloned.
+	WorkspaceCloneAPIBindingsCloned conditionsv1alpha1.ConditionType = "APIBindingsCloned"
+	// WorkspaceCloneRBACCloned represents whether the roles and role bindings have been cloned.
+	WorkspaceCloneRBACCloned conditionsv1alpha1.ConditionType = "RBACCloned"
+	// WorkspaceCloneContentsCloned represents whether the selected contents have been cloned.
+	WorkspaceCloneContentsCloned conditionsv1alpha1.ConditionType = "ContentsCloned"
+	// WorkspaceCloneReasonCloneFailed reason in the APIBindingsCloned, RBACCloned and
+	// ContentsCloned conditions means that some objects could not be cloned yet. Cloning is
+	// retried.
+	WorkspaceCloneReasonCloneFailed = "CloneFailed"
+
+	// WorkspaceCloneAnnotationKey is set on a ClusterWorkspace created by a WorkspaceClone to the
+	// name of the WorkspaceClone.
+	WorkspaceCloneAnnotationKey = "tenancy.kcp.dev/workspace-clone"
+)
+
+// WorkspaceCloneList is a list of WorkspaceClones
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceCloneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkspaceClone `json:"items"`
+}
+
+// WorkspaceMigration moves a ClusterWorkspace with its content to another ClusterWorkspaceShard.
+// It lives in the root workspace, next to the ClusterWorkspaceShards.
+//
+// Writes to the workspace are fenced on its current shard while its objects are copied to the
+// target shard. The workspace is then moved to the target shard, the fence is lifted, and the
+// objects are deleted from the source shard.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Workspace",type=string,JSONPath=`.spec.workspace`,description="The migrated workspace"
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.status.sourceShard`,description="The shard the workspace is migrated from"
+// +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.targetShard`,description="The shard the workspace is migrated to"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The phase of the migration"
+type WorkspaceMigration struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec WorkspaceMigrationSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status WorkspaceMigrationStatus `json:"status,omitempty"`
+}
+
+func (in *WorkspaceMigration) SetConditions(c conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = c
+}
+
+func (in *WorkspaceMigration) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+var _ conditions.Getter = &WorkspaceMigration{}
+var _ conditions.Setter = &WorkspaceMigration{}
+
+// WorkspaceMigrationSpec holds the workspace to migrate and where to.
+type WorkspaceMigrationSpec struct {
+	// workspace is the logical cluster name of the migrated workspace, e.g. root:org:team.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	// +kubebuilder:validation:Required
+	Workspace string `json:"workspace"`
+
+	// targetShard is the name of the ClusterWorkspaceShard the workspace is migrated to.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	// +kubebuilder:validation:Required
+	TargetShard string `json:"targetShard"`
+}
+
+// WorkspaceMigrationPhase is the phase of a WorkspaceMigration.
+//
+// +kubebuilder:validation:Enum=Pending;Copying;CuttingOver;CleaningUp;Completed
+type WorkspaceMigrationPhase string
+
+const (
+	// WorkspaceMigrationPhasePending means the migration has not started, e.g. because the
+	// workspace is not ready yet, or another migration of the workspace is in progress.
+	WorkspaceMigrationPhasePending WorkspaceMigrationPhase = "Pending"
+	// WorkspaceMigrationPhaseCopying means writes to the workspace are fenced, and its objects
+	// are copied to the target shard.
+	WorkspaceMigrationPhaseCopying WorkspaceMigrationPhase = "Copying"
+	// WorkspaceMigrationPhaseCuttingOver means the workspace is being moved to the target shard.
+	WorkspaceMigrationPhaseCuttingOver WorkspaceMigrationPhase = "CuttingOver"
+	// WorkspaceMigrationPhaseCleaningUp means the workspace is served by the target shard, and
+	// its objects are deleted from the source shard.
+	WorkspaceMigrationPhaseCleaningUp WorkspaceMigrationPhase = "CleaningUp"
+	// WorkspaceMigrationPhaseCompleted means the workspace has been migrated.
+	WorkspaceMigrationPhaseCompleted WorkspaceMigrationPhase = "Completed"
+)
+
+// WorkspaceMigrationStatus communicates the progress of the WorkspaceMigration.
+type WorkspaceMigrationStatus struct {
+	// phase is the progress of the migration.
+	//
+	// +optional
+	Phase WorkspaceMigrationPhase `json:"phase,omitempty"`
+
+	// sourceShard is the ClusterWorkspaceShard the workspace is migrated from. It is set when
+	// the migration starts.
+	//
+	// +optional
+	SourceShard string `json:"sourceShard,omitempty"`
+
+	// copiedObjects is the number of objects copied to the target shard.
+	//
+	// +optional
+	CopiedObjects int64 `json:"copiedObjects,omitempty"`
+
+	// Current processing state of the WorkspaceMigration.
+	//
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+const (
+	// WorkspaceMigrationValid represents whether the migration can proceed.
+	WorkspaceMigrationValid conditionsv1alpha1.ConditionType = "Valid"
+	// WorkspaceMigrationReasonWorkspaceNotFound reason in WorkspaceMigrationValid condition means
+	// that the migrated ClusterWorkspace does not exist.
+	WorkspaceMigrationReasonWorkspaceNotFound = "WorkspaceNotFound"
+	// WorkspaceMigrationReasonWorkspaceNotReady reason in WorkspaceMigrationValid condition means
+	// that the migrated ClusterWorkspace is not ready yet.
+	WorkspaceMigrationReasonWorkspaceNotReady = "WorkspaceNotReady"
+	// WorkspaceMigrationReasonShardNotFound reason in WorkspaceMigrationValid condition means
+	// that the source or the target ClusterWorkspaceShard does not exist.
+	WorkspaceMigrationReasonShardNotFound = "ShardNotFound"
+	// WorkspaceMigrationReasonMigrationInProgress reason in WorkspaceMigrationValid condition
+	// means that another migration of the workspace is in progress.
+	WorkspaceMigrationReasonMigrationInProgress = "MigrationInProgress"
+	// WorkspaceMigrationReasonHasChildWorkspaces reason in WorkspaceMigrationValid condition means
+	// that the workspace has ClusterWorkspaces, which cannot be migrated with it.
+	WorkspaceMigrationReasonHasChildWorkspaces = "HasChildWorkspaces"
+	// WorkspaceMigrationReasonShardUnschedulable reason in WorkspaceMigrationValid condition means
+	// that the target ClusterWorkspaceShard is cordoned.
+	WorkspaceMigrationReasonShardUnschedulable = "ShardUnschedulable"
+
+	// WorkspaceMigrationFinalizer is set on WorkspaceMigrations while they fence their workspace,
+	// such that the fence is lifted when the migration is deleted.
+	WorkspaceMigrationFinalizer = "tenancy.kcp.dev/workspace-migration"
+
+	// WorkspaceMigrationFenceAnnotationKey is set on a ClusterWorkspace to the name of the
+	// WorkspaceMigration migrating it. Writes to the workspace are rejected on its current shard
+	// while it is set.
+	WorkspaceMigrationFenceAnnotationKey = "tenancy.kcp.dev/migration-fence"
+)
+
+// WorkspaceMigrationList is a list of WorkspaceMigrations
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkspaceMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkspaceMigration `json:"items"`
+}
+
+// ShardUpgradePlan sequences the upgrade of ClusterWorkspaceShards to a version of kcp. It lives
+// in the root workspace, next to the ClusterWorkspaceShards.
+//
+// The shards are upgraded one after the other: a shard is cordoned, in-flight WorkspaceMigrations
+// from and to it are waited for, and the hook is notified that the shard can be upgraded. Once the
+// shard reports the version in its status, it is uncordoned and the next shard is upgraded.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.version`,description="The version the shards are upgraded to"
+// +kubebuilder:printcolumn:name="Shard",type=string,JSONPath=`.status.currentShard`,description="The shard being upgraded"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The phase of the upgrade"
+type ShardUpgradePlan struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec ShardUpgradePlanSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status ShardUpgradePlanStatus `json:"status,omitempty"`
+}
+
+func (in *ShardUpgradePlan) SetConditions(c conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = c
+}
+
+func (in *ShardUpgradePlan) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+var _ conditions.Getter = &ShardUpgradePlan{}
+var _ conditions.Setter = &ShardUpgradePlan{}
+
+// ShardUpgradePlanSpec holds the version to upgrade to and the shards to upgrade.
+type ShardUpgradePlanSpec struct {
+	// version is the version of kcp the shards are upgraded to. A shard is upgraded once its
+	// ClusterWorkspaceShard reports this version in status.version.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	// +kubebuilder:validation:Required
+	Version string `json:"version"`
+
+	// shards are the names of the ClusterWorkspaceShards to upgrade, in order. If empty, all
+	// shards are upgraded in the order of their names.
+	//
+	// +optional
+	Shards []string `json:"shards,omitempty"`
+
+	// hook is notified when a shard is ready to be upgraded. Without a hook, the shards are
+	// upgraded out-of-band, e.g. by an operator watching status.currentShard.
+	//
+	// +optional
+	Hook *ShardUpgradeHook `json:"hook,omitempty"`
+
+	// paused stops the plan from cordoning further shards. A shard already being upgraded
+	// is finished.
+	//
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ShardUpgradeHook is an HTTPS endpoint a ShardUpgradeNotification is POSTed to when a shard
+// is ready to be upgraded. The hook must respond with a 2xx status code.
+type ShardUpgradeHook struct {
+	// url is the HTTPS URL of the hook.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:="^https://"
+	URL string `json:"url"`
+
+	// caBundle is a PEM encoded CA bundle to verify the serving certificate of the
+	// hook. If unspecified, the system trust roots are used.
+	//
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// timeoutSeconds is the timeout of a single call of the hook.
+	//
+	// +optional
+	// +kubebuilder:default:=10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ShardUpgradeNotification is the JSON body POSTed to the hook of a ShardUpgradePlan.
+type ShardUpgradeNotification struct {
+	// plan is the name of the ShardUpgradePlan.
+	Plan string `json:"plan"`
+	// shard is the name of the ClusterWorkspaceShard to upgrade.
+	Shard string `json:"shard"`
+	// version is the version of kcp to upgrade the shard to.
+	Version string `json:"version"`
+	// baseURL is the base URL of the shard.
+	BaseURL string `json:"baseURL,omitempty"`
+}
+
+// ShardUpgradePlanPhase is the phase of a ShardUpgradePlan.
+//
+// +kubebuilder:validation:Enum=Pending;Upgrading;Completed
+type ShardUpgradePlanPhase string
+
+const (
+	// ShardUpgradePlanPhasePending means no shard has been cordoned yet, e.g. because the plan
+	// is not valid, paused, or another plan is in progress.
+	ShardUpgradePlanPhasePending ShardUpgradePlanPhase = "Pending"
+	// ShardUpgradePlanPhaseUpgrading means the shards are being upgraded.
+	ShardUpgradePlanPhaseUpgrading ShardUpgradePlanPhase = "Upgrading"
+	// ShardUpgradePlanPhaseCompleted means all shards have been upgraded.
+	ShardUpgradePlanPhaseCompleted ShardUpgradePlanPhase = "Completed"
+)
+
+// ShardUpgradePhase is the phase of the upgrade of a single shard.
+//
+// +kubebuilder:validation:Enum=Pending;Draining;Upgrading;Completed
+type ShardUpgradePhase string
+
+const (
+	// ShardUpgradePhasePending means the shard has not been cordoned yet.
+	ShardUpgradePhasePending ShardUpgradePhase = "Pending"
+	// ShardUpgradePhaseDraining means the shard is cordoned, and in-flight WorkspaceMigrations
+	// from and to it are waited for.
+	ShardUpgradePhaseDraining ShardUpgradePhase = "Draining"
+	// ShardUpgradePhaseUpgrading means the hook has been notified, and the shard reporting the
+	// version is waited for.
+	ShardUpgradePhaseUpgrading ShardUpgradePhase = "Upgrading"
+	// ShardUpgradePhaseCompleted means the shard runs the version and is uncordoned.
+	ShardUpgradePhaseCompleted ShardUpgradePhase = "Completed"
+)
+
+// ShardUpgradeStatus is the progress of the upgrade of a single shard.
+type ShardUpgradeStatus struct {
+	// name is the name of the ClusterWorkspaceShard.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// phase is the progress of the upgrade of the shard.
+	//
+	// +optional
+	Phase ShardUpgradePhase `json:"phase,omitempty"`
+}
+
+// ShardUpgradePlanStatus communicates the progress of the ShardUpgradePlan.
+type ShardUpgradePlanStatus struct {
+	// phase is the progress of the plan.
+	//
+	// +optional
+	Phase ShardUpgradePlanPhase `json:"phase,omitempty"`
+
+	// currentShard is the name of the ClusterWorkspaceShard being upgraded.
+	//
+	// +optional
+	CurrentShard string `json:"currentShard,omitempty"`
+
+	// shards is the progress of the upgrade of every shard of the plan, in order.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Shards []ShardUpgradeStatus `json:"shards,omitempty"`
+
+	// Current processing state of the ShardUpgradePlan.
+	//
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+const (
+	// ShardUpgradePlanValid represents whether the plan can proceed.
+	ShardUpgradePlanValid conditionsv1alpha1.ConditionType = "Valid"
+	// ShardUpgradePlanReasonShardNotFound reason in ShardUpgradePlanValid condition means that
+	// a ClusterWorkspaceShard of the plan does not exist.
+	ShardUpgradePlanReasonShardNotFound = "ShardNotFound"
+	// ShardUpgradePlanReasonPlanInProgress reason in ShardUpgradePlanValid condition means that
+	// another ShardUpgradePlan is upgrading shards.
+	ShardUpgradePlanReasonPlanInProgress = "PlanInProgress"
+
+	// ShardUpgradeHookNotified represents whether the hook has been notified for the current shard.
+	ShardUpgradeHookNotified conditionsv1alpha1.ConditionType = "HookNotified"
+	// ShardUpgradeReasonHookFailed reason in ShardUpgradeHookNotified condition means that the
+	// hook could not be notified. It is retried with backoff.
+	ShardUpgradeReasonHookFailed = "HookFailed"
+
+	// ShardUpgradePlanFinalizer is set on ShardUpgradePlans while they cordon a shard, such that
+	// the shard is uncordoned when the plan is deleted.
+	ShardUpgradePlanFinalizer = "tenancy.kcp.dev/shard-upgrade"
+
+	// ShardUpgradeCordonAnnotationKey is set on a ClusterWorkspaceShard to the name of the
+	// ShardUpgradePlan that cordoned it. Shards cordoned by someone else are left cordoned.
+	ShardUpgradeCordonAnnotationKey = "tenancy.kcp.dev/upgrade-cordon"
+)
+
+// ShardUpgradePlanList is a list of ShardUpgradePlans
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ShardUpgradePlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ShardUpgradePlan `json:"items"`
+}
+
+// NameReservation reserves a name that must be unique across all workspaces of all shards, like
+// the name of a ClusterWorkspaceType or the identity of an APIExport. It lives in the root
+// workspace, and is created by admission before the object holding the name is persisted.
+// As the name of the NameReservation is derived from the reserved name, a concurrent request
+// for the same name on another shard fails to create it and is rejected.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Resource",type=string,JSONPath=`.spec.resource`,description="The resource the name is reserved for"
+// +kubebuilder:printcolumn:name="Name",type=string,JSONPath=`.spec.name`,description="The reserved name"
+// +kubebuilder:printcolumn:name="Owner Workspace",type=string,JSONPath=`.spec.owner.cluster`,description="The workspace of the object holding the name"
+type NameReservation struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec NameReservationSpec `json:"spec,omitempty"`
+}
+
+// NameReservationSpec describes the reserved name and its owner.
+type NameReservationSpec struct {
+	// resource is the group resource the name is reserved for, e.g.
+	// clusterworkspacetypes.tenancy.kcp.dev.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Resource string `json:"resource"`
+
+	// name is the reserved name.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// owner is the object holding the name.
+	//
+	// +required
+	Owner NameReservationOwner `json:"owner"`
+}
+
+// NameReservationOwner references the object holding a reserved name.
+type NameReservationOwner struct {
+	// cluster is the logical cluster of the object.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Cluster string `json:"cluster"`
+
+	// name is the name of the object.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// NameReservationList is a list of NameReservations
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type NameReservationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NameReservation `json:"items"`
+}
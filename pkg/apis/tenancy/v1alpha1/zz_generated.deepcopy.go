@@ -22,18 +22,122 @@ limitations under the License.
 package v1alpha1
 
 import (
-	v1 "k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRequest) DeepCopyInto(out *AccessRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRequest.
+func (in *AccessRequest) DeepCopy() *AccessRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRequestList) DeepCopyInto(out *AccessRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AccessRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRequestList.
+func (in *AccessRequestList) DeepCopy() *AccessRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AccessRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRequestSpec) DeepCopyInto(out *AccessRequestSpec) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRequestSpec.
+func (in *AccessRequestSpec) DeepCopy() *AccessRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessRequestStatus) DeepCopyInto(out *AccessRequestStatus) {
+	*out = *in
+	if in.ExpirationTime != nil {
+		in, out := &in.ExpirationTime, &out.ExpirationTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessRequestStatus.
+func (in *AccessRequestStatus) DeepCopy() *AccessRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterWorkspace) DeepCopyInto(out *ClusterWorkspace) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 	return
 }
@@ -56,6 +160,109 @@ func (in *ClusterWorkspace) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceExpiration) DeepCopyInto(out *ClusterWorkspaceExpiration) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ExpiryTime != nil {
+		in, out := &in.ExpiryTime, &out.ExpiryTime
+		*out = (*in).DeepCopy()
+	}
+	if in.WarningPeriod != nil {
+		in, out := &in.WarningPeriod, &out.WarningPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceExpiration.
+func (in *ClusterWorkspaceExpiration) DeepCopy() *ClusterWorkspaceExpiration {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceExpiration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceLifecycleHook) DeepCopyInto(out *ClusterWorkspaceLifecycleHook) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]ClusterWorkspaceLifecycleEvent, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceLifecycleHook.
+func (in *ClusterWorkspaceLifecycleHook) DeepCopy() *ClusterWorkspaceLifecycleHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceLifecycleHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceLifecycleNotification) DeepCopyInto(out *ClusterWorkspaceLifecycleNotification) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceLifecycleNotification.
+func (in *ClusterWorkspaceLifecycleNotification) DeepCopy() *ClusterWorkspaceLifecycleNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceLifecycleNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceLimits) DeepCopyInto(out *ClusterWorkspaceLimits) {
+	*out = *in
+	if in.MaxObjectSize != nil {
+		in, out := &in.MaxObjectSize, &out.MaxObjectSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxObjectsPerResource != nil {
+		in, out := &in.MaxObjectsPerResource, &out.MaxObjectsPerResource
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ClusterWorkspaceResourceLimit, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceLimits.
+func (in *ClusterWorkspaceLimits) DeepCopy() *ClusterWorkspaceLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterWorkspaceList) DeepCopyInto(out *ClusterWorkspaceList) {
 	*out = *in
@@ -105,6 +312,136 @@ func (in *ClusterWorkspaceLocation) DeepCopy() *ClusterWorkspaceLocation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceQuota) DeepCopyInto(out *ClusterWorkspaceQuota) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceQuota.
+func (in *ClusterWorkspaceQuota) DeepCopy() *ClusterWorkspaceQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWorkspaceQuota) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceQuotaList) DeepCopyInto(out *ClusterWorkspaceQuotaList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterWorkspaceQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceQuotaList.
+func (in *ClusterWorkspaceQuotaList) DeepCopy() *ClusterWorkspaceQuotaList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceQuotaList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWorkspaceQuotaList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceQuotaSpec) DeepCopyInto(out *ClusterWorkspaceQuotaSpec) {
+	*out = *in
+	if in.Hard != nil {
+		in, out := &in.Hard, &out.Hard
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceQuotaSpec.
+func (in *ClusterWorkspaceQuotaSpec) DeepCopy() *ClusterWorkspaceQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceQuotaStatus) DeepCopyInto(out *ClusterWorkspaceQuotaStatus) {
+	*out = *in
+	if in.Hard != nil {
+		in, out := &in.Hard, &out.Hard
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Used != nil {
+		in, out := &in.Used, &out.Used
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceQuotaStatus.
+func (in *ClusterWorkspaceQuotaStatus) DeepCopy() *ClusterWorkspaceQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceResourceLimit) DeepCopyInto(out *ClusterWorkspaceResourceLimit) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceResourceLimit.
+func (in *ClusterWorkspaceResourceLimit) DeepCopy() *ClusterWorkspaceResourceLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceResourceLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterWorkspaceShard) DeepCopyInto(out *ClusterWorkspaceShard) {
 	*out = *in
@@ -187,11 +524,26 @@ func (in *ClusterWorkspaceShardStatus) DeepCopyInto(out *ClusterWorkspaceShardSt
 	*out = *in
 	if in.Capacity != nil {
 		in, out := &in.Capacity, &out.Capacity
-		*out = make(v1.ResourceList, len(*in))
+		*out = make(corev1.ResourceList, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.ServingCABundle != nil {
+		in, out := &in.ServingCABundle, &out.ServingCABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.WorkspaceCount != nil {
+		in, out := &in.WorkspaceCount, &out.WorkspaceCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.APIGroups != nil {
+		in, out := &in.APIGroups, &out.APIGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(conditionsv1alpha1.Conditions, len(*in))
@@ -215,6 +567,21 @@ func (in *ClusterWorkspaceShardStatus) DeepCopy() *ClusterWorkspaceShardStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterWorkspaceSpec) DeepCopyInto(out *ClusterWorkspaceSpec) {
 	*out = *in
+	if in.Impersonation != nil {
+		in, out := &in.Impersonation, &out.Impersonation
+		*out = new(ImpersonationConstraints)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Expiration != nil {
+		in, out := &in.Expiration, &out.Expiration
+		*out = new(ClusterWorkspaceExpiration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountAudiences != nil {
+		in, out := &in.ServiceAccountAudiences, &out.ServiceAccountAudiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -244,6 +611,10 @@ func (in *ClusterWorkspaceStatus) DeepCopyInto(out *ClusterWorkspaceStatus) {
 		*out = make([]ClusterWorkspaceInitializer, len(*in))
 		copy(*out, *in)
 	}
+	if in.ExpiryTime != nil {
+		in, out := &in.ExpiryTime, &out.ExpiryTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -332,15 +703,772 @@ func (in *ClusterWorkspaceTypeSpec) DeepCopyInto(out *ClusterWorkspaceTypeSpec)
 			(*out)[key] = val
 		}
 	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceTypeSpec.
-func (in *ClusterWorkspaceTypeSpec) DeepCopy() *ClusterWorkspaceTypeSpec {
-	if in == nil {
-		return nil
+	if in.LifecycleHooks != nil {
+		in, out := &in.LifecycleHooks, &out.LifecycleHooks
+		*out = make([]ClusterWorkspaceLifecycleHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	out := new(ClusterWorkspaceTypeSpec)
+	if in.DeprecationWarning != nil {
+		in, out := &in.DeprecationWarning, &out.DeprecationWarning
+		*out = new(string)
+		**out = **in
+	}
+	if in.DefaultExpiration != nil {
+		in, out := &in.DefaultExpiration, &out.DefaultExpiration
+		*out = new(ClusterWorkspaceExpiration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(ClusterWorkspaceLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DefaultAPIBindings != nil {
+		in, out := &in.DefaultAPIBindings, &out.DefaultAPIBindings
+		*out = make([]apisv1alpha1.ExportReference, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AllowedAPIGroups != nil {
+		in, out := &in.AllowedAPIGroups, &out.AllowedAPIGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceTypeSpec.
+func (in *ClusterWorkspaceTypeSpec) DeepCopy() *ClusterWorkspaceTypeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceTypeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImpersonationConstraints) DeepCopyInto(out *ImpersonationConstraints) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]ImpersonationRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImpersonationConstraints.
+func (in *ImpersonationConstraints) DeepCopy() *ImpersonationConstraints {
+	if in == nil {
+		return nil
+	}
+	out := new(ImpersonationConstraints)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImpersonationRule) DeepCopyInto(out *ImpersonationRule) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImpersonatedUsers != nil {
+		in, out := &in.ImpersonatedUsers, &out.ImpersonatedUsers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImpersonatedGroups != nil {
+		in, out := &in.ImpersonatedGroups, &out.ImpersonatedGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImpersonationRule.
+func (in *ImpersonationRule) DeepCopy() *ImpersonationRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ImpersonationRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NameReservation) DeepCopyInto(out *NameReservation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NameReservation.
+func (in *NameReservation) DeepCopy() *NameReservation {
+	if in == nil {
+		return nil
+	}
+	out := new(NameReservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NameReservation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NameReservationList) DeepCopyInto(out *NameReservationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NameReservation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NameReservationList.
+func (in *NameReservationList) DeepCopy() *NameReservationList {
+	if in == nil {
+		return nil
+	}
+	out := new(NameReservationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NameReservationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NameReservationOwner) DeepCopyInto(out *NameReservationOwner) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NameReservationOwner.
+func (in *NameReservationOwner) DeepCopy() *NameReservationOwner {
+	if in == nil {
+		return nil
+	}
+	out := new(NameReservationOwner)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NameReservationSpec) DeepCopyInto(out *NameReservationSpec) {
+	*out = *in
+	out.Owner = in.Owner
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NameReservationSpec.
+func (in *NameReservationSpec) DeepCopy() *NameReservationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NameReservationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardUpgradeHook) DeepCopyInto(out *ShardUpgradeHook) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardUpgradeHook.
+func (in *ShardUpgradeHook) DeepCopy() *ShardUpgradeHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardUpgradeHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardUpgradeNotification) DeepCopyInto(out *ShardUpgradeNotification) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardUpgradeNotification.
+func (in *ShardUpgradeNotification) DeepCopy() *ShardUpgradeNotification {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardUpgradeNotification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardUpgradePlan) DeepCopyInto(out *ShardUpgradePlan) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardUpgradePlan.
+func (in *ShardUpgradePlan) DeepCopy() *ShardUpgradePlan {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardUpgradePlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ShardUpgradePlan) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardUpgradePlanList) DeepCopyInto(out *ShardUpgradePlanList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ShardUpgradePlan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardUpgradePlanList.
+func (in *ShardUpgradePlanList) DeepCopy() *ShardUpgradePlanList {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardUpgradePlanList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ShardUpgradePlanList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardUpgradePlanSpec) DeepCopyInto(out *ShardUpgradePlanSpec) {
+	*out = *in
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hook != nil {
+		in, out := &in.Hook, &out.Hook
+		*out = new(ShardUpgradeHook)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardUpgradePlanSpec.
+func (in *ShardUpgradePlanSpec) DeepCopy() *ShardUpgradePlanSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardUpgradePlanSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardUpgradePlanStatus) DeepCopyInto(out *ShardUpgradePlanStatus) {
+	*out = *in
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make([]ShardUpgradeStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(conditionsv1alpha1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardUpgradePlanStatus.
+func (in *ShardUpgradePlanStatus) DeepCopy() *ShardUpgradePlanStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardUpgradePlanStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardUpgradeStatus) DeepCopyInto(out *ShardUpgradeStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardUpgradeStatus.
+func (in *ShardUpgradeStatus) DeepCopy() *ShardUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceClone) DeepCopyInto(out *WorkspaceClone) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceClone.
+func (in *WorkspaceClone) DeepCopy() *WorkspaceClone {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceClone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkspaceClone) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceCloneContents) DeepCopyInto(out *WorkspaceCloneContents) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]WorkspaceCloneResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]WorkspaceCloneResource, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceCloneContents.
+func (in *WorkspaceCloneContents) DeepCopy() *WorkspaceCloneContents {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceCloneContents)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceCloneList) DeepCopyInto(out *WorkspaceCloneList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkspaceClone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceCloneList.
+func (in *WorkspaceCloneList) DeepCopy() *WorkspaceCloneList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceCloneList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkspaceCloneList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceCloneResource) DeepCopyInto(out *WorkspaceCloneResource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceCloneResource.
+func (in *WorkspaceCloneResource) DeepCopy() *WorkspaceCloneResource {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceCloneResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceCloneSpec) DeepCopyInto(out *WorkspaceCloneSpec) {
+	*out = *in
+	if in.Contents != nil {
+		in, out := &in.Contents, &out.Contents
+		*out = new(WorkspaceCloneContents)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceCloneSpec.
+func (in *WorkspaceCloneSpec) DeepCopy() *WorkspaceCloneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceCloneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceCloneStatus) DeepCopyInto(out *WorkspaceCloneStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(conditionsv1alpha1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceCloneStatus.
+func (in *WorkspaceCloneStatus) DeepCopy() *WorkspaceCloneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceCloneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceMigration) DeepCopyInto(out *WorkspaceMigration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceMigration.
+func (in *WorkspaceMigration) DeepCopy() *WorkspaceMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkspaceMigration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceMigrationList) DeepCopyInto(out *WorkspaceMigrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkspaceMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceMigrationList.
+func (in *WorkspaceMigrationList) DeepCopy() *WorkspaceMigrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceMigrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkspaceMigrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceMigrationSpec) DeepCopyInto(out *WorkspaceMigrationSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceMigrationSpec.
+func (in *WorkspaceMigrationSpec) DeepCopy() *WorkspaceMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceMigrationStatus) DeepCopyInto(out *WorkspaceMigrationStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(conditionsv1alpha1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceMigrationStatus.
+func (in *WorkspaceMigrationStatus) DeepCopy() *WorkspaceMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceRequestSet) DeepCopyInto(out *WorkspaceRequestSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceRequestSet.
+func (in *WorkspaceRequestSet) DeepCopy() *WorkspaceRequestSet {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceRequestSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkspaceRequestSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceRequestSetList) DeepCopyInto(out *WorkspaceRequestSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkspaceRequestSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceRequestSetList.
+func (in *WorkspaceRequestSetList) DeepCopy() *WorkspaceRequestSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceRequestSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkspaceRequestSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceRequestSetSpec) DeepCopyInto(out *WorkspaceRequestSetSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceRequestSetSpec.
+func (in *WorkspaceRequestSetSpec) DeepCopy() *WorkspaceRequestSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceRequestSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceRequestSetStatus) DeepCopyInto(out *WorkspaceRequestSetStatus) {
+	*out = *in
+	if in.Workspaces != nil {
+		in, out := &in.Workspaces, &out.Workspaces
+		*out = make([]WorkspaceRequestStatus, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceRequestSetStatus.
+func (in *WorkspaceRequestSetStatus) DeepCopy() *WorkspaceRequestSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceRequestSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceRequestSetTemplate) DeepCopyInto(out *WorkspaceRequestSetTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceRequestSetTemplate.
+func (in *WorkspaceRequestSetTemplate) DeepCopy() *WorkspaceRequestSetTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceRequestSetTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceRequestStatus) DeepCopyInto(out *WorkspaceRequestStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceRequestStatus.
+func (in *WorkspaceRequestStatus) DeepCopy() *WorkspaceRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceRequestStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,85 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// addConversionFuncs registers the conversions between the deprecated v1alpha1 types and
+// their v1beta1 successors. Both versions share the same schema, hence the conversions only
+// copy the fields over, and the compiler catches if the versions diverge. The TypeMeta is
+// left to the scheme.
+func addConversionFuncs(scheme *runtime.Scheme) error {
+	if err := scheme.AddConversionFunc((*v1alpha1.ClusterWorkspace)(nil), (*ClusterWorkspace)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ClusterWorkspace_To_v1beta1_ClusterWorkspace(a.(*v1alpha1.ClusterWorkspace), b.(*ClusterWorkspace), scope)
+	}); err != nil {
+		return err
+	}
+	if err := scheme.AddConversionFunc((*ClusterWorkspace)(nil), (*v1alpha1.ClusterWorkspace)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ClusterWorkspace_To_v1alpha1_ClusterWorkspace(a.(*ClusterWorkspace), b.(*v1alpha1.ClusterWorkspace), scope)
+	}); err != nil {
+		return err
+	}
+	if err := scheme.AddConversionFunc((*v1alpha1.ClusterWorkspaceShard)(nil), (*ClusterWorkspaceShard)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ClusterWorkspaceShard_To_v1beta1_ClusterWorkspaceShard(a.(*v1alpha1.ClusterWorkspaceShard), b.(*ClusterWorkspaceShard), scope)
+	}); err != nil {
+		return err
+	}
+	return scheme.AddConversionFunc((*ClusterWorkspaceShard)(nil), (*v1alpha1.ClusterWorkspaceShard)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ClusterWorkspaceShard_To_v1alpha1_ClusterWorkspaceShard(a.(*ClusterWorkspaceShard), b.(*v1alpha1.ClusterWorkspaceShard), scope)
+	})
+}
+
+// Convert_v1alpha1_ClusterWorkspace_To_v1beta1_ClusterWorkspace converts a v1alpha1 ClusterWorkspace to v1beta1.
+func Convert_v1alpha1_ClusterWorkspace_To_v1beta1_ClusterWorkspace(in *v1alpha1.ClusterWorkspace, out *ClusterWorkspace, s conversion.Scope) error {
+	in = in.DeepCopy()
+	out.ObjectMeta = in.ObjectMeta
+	out.Spec = ClusterWorkspaceSpec(in.Spec)
+	out.Status = ClusterWorkspaceStatus(in.Status)
+	return nil
+}
+
+// Convert_v1beta1_ClusterWorkspace_To_v1alpha1_ClusterWorkspace converts a v1beta1 ClusterWorkspace to v1alpha1.
+func Convert_v1beta1_ClusterWorkspace_To_v1alpha1_ClusterWorkspace(in *ClusterWorkspace, out *v1alpha1.ClusterWorkspace, s conversion.Scope) error {
+	in = in.DeepCopy()
+	out.ObjectMeta = in.ObjectMeta
+	out.Spec = v1alpha1.ClusterWorkspaceSpec(in.Spec)
+	out.Status = v1alpha1.ClusterWorkspaceStatus(in.Status)
+	return nil
+}
+
+// Convert_v1alpha1_ClusterWorkspaceShard_To_v1beta1_ClusterWorkspaceShard converts a v1alpha1 ClusterWorkspaceShard to v1beta1.
+func Convert_v1alpha1_ClusterWorkspaceShard_To_v1beta1_ClusterWorkspaceShard(in *v1alpha1.ClusterWorkspaceShard, out *ClusterWorkspaceShard, s conversion.Scope) error {
+	in = in.DeepCopy()
+	out.ObjectMeta = in.ObjectMeta
+	out.Spec = ClusterWorkspaceShardSpec(in.Spec)
+	out.Status = ClusterWorkspaceShardStatus(in.Status)
+	return nil
+}
+
+// Convert_v1beta1_ClusterWorkspaceShard_To_v1alpha1_ClusterWorkspaceShard converts a v1beta1 ClusterWorkspaceShard to v1alpha1.
+func Convert_v1beta1_ClusterWorkspaceShard_To_v1alpha1_ClusterWorkspaceShard(in *ClusterWorkspaceShard, out *v1alpha1.ClusterWorkspaceShard, s conversion.Scope) error {
+	in = in.DeepCopy()
+	out.ObjectMeta = in.ObjectMeta
+	out.Spec = v1alpha1.ClusterWorkspaceShardSpec(in.Spec)
+	out.Status = v1alpha1.ClusterWorkspaceShardStatus(in.Status)
+	return nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestRoundTrip(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	require.NoError(t, AddToScheme(scheme))
+	f := fuzzer.FuzzerFor(metafuzzer.Funcs, rand.NewSource(0), serializer.NewCodecFactory(scheme))
+
+	tests := []struct {
+		name  string
+		alpha func() runtime.Object
+		beta  func() runtime.Object
+	}{
+		{
+			name:  "ClusterWorkspace",
+			alpha: func() runtime.Object { return &v1alpha1.ClusterWorkspace{} },
+			beta:  func() runtime.Object { return &ClusterWorkspace{} },
+		},
+		{
+			name:  "ClusterWorkspaceShard",
+			alpha: func() runtime.Object { return &v1alpha1.ClusterWorkspaceShard{} },
+			beta:  func() runtime.Object { return &ClusterWorkspaceShard{} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				original := tt.alpha()
+				f.Fuzz(original)
+				original.GetObjectKind().SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind(tt.name))
+
+				beta := tt.beta()
+				require.NoError(t, scheme.Convert(original, beta, nil))
+				roundTripped := tt.alpha()
+				require.NoError(t, scheme.Convert(beta, roundTripped, nil))
+				roundTripped.GetObjectKind().SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind(tt.name))
+				require.True(t, equality.Semantic.DeepEqual(original, roundTripped), "round trip through v1beta1 changed the object:\n%#v\n%#v", original, roundTripped)
+
+				// both versions are served from the same storage without conversion, hence must serialize the same
+				beta.GetObjectKind().SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind(tt.name))
+				alphaJSON, err := json.Marshal(original)
+				require.NoError(t, err)
+				betaJSON, err := json.Marshal(beta)
+				require.NoError(t, err)
+				require.JSONEq(t, string(alphaJSON), string(betaJSON))
+			}
+		})
+	}
+}
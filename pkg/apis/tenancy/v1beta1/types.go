@@ -17,9 +17,12 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
 
 // Workspace defines a generic Kubernetes-cluster-like endpoint, with standard Kubernetes
@@ -90,3 +93,294 @@ type WorkspaceList struct {
 
 	Items []Workspace `json:"items"`
 }
+
+// ClusterWorkspace is the v1beta1 version of the v1alpha1 ClusterWorkspace, which is deprecated.
+// Both versions have the same schema and are served from the same storage. Clients can migrate
+// one by one using the conversion functions of this package.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`,description="Type of the workspace"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The current phase (e.g. Scheduling, Initializing, Ready)"
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.status.baseURL`,description="URL to access the workspace"
+type ClusterWorkspace struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec ClusterWorkspaceSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status ClusterWorkspaceStatus `json:"status,omitempty"`
+}
+
+func (in *ClusterWorkspace) SetConditions(c conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = c
+}
+
+func (in *ClusterWorkspace) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+var _ conditions.Getter = &ClusterWorkspace{}
+var _ conditions.Setter = &ClusterWorkspace{}
+
+// ClusterWorkspaceSpec holds the desired state of the ClusterWorkspace.
+type ClusterWorkspaceSpec struct {
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// type defines properties of the workspace both on creation (e.g. initial
+	// resources and initially installed APIs) and during runtime (e.g. permissions).
+	//
+	// The type is a reference to a ClusterWorkspaceType in the same workspace
+	// with the same name, but lower-cased. The ClusterWorkspaceType existence is
+	// validated at admission during creation, with the exception of the
+	// "Universal" type whose existence is not required but respected if it exists.
+	// The type is immutable after creation. The use of a type is gated via
+	// the RBAC clusterworkspacetypes/use resource permission.
+	//
+	// +optional
+	// +kubebuilder:default:="Universal"
+	Type string `json:"type,omitempty"`
+
+	// impersonation restricts who may impersonate which users and groups inside
+	// the workspace. It is enforced on top of the RBAC permissions of the workspace,
+	// and can only be changed by those who can update the ClusterWorkspace in its
+	// parent. If unset, impersonation is only subject to RBAC.
+	//
+	// +optional
+	Impersonation *v1alpha1.ImpersonationConstraints `json:"impersonation,omitempty"`
+
+	// expiration makes the workspace ephemeral. Once it expires, the workspace
+	// is deleted or hibernated. If unset on creation, the default expiration of
+	// the type is applied.
+	//
+	// +optional
+	Expiration *v1alpha1.ClusterWorkspaceExpiration `json:"expiration,omitempty"`
+
+	// serviceAccountAudiences are additional audiences accepted for service
+	// account tokens used against the workspace, on top of the API audiences of
+	// the server and the audience of the workspace itself, which is the
+	// workspace path prefixed with "kcp.dev/workspace/".
+	//
+	// +optional
+	// +listType=set
+	ServiceAccountAudiences []string `json:"serviceAccountAudiences,omitempty"`
+}
+
+// ClusterWorkspaceStatus communicates the observed state of the ClusterWorkspace.
+type ClusterWorkspaceStatus struct {
+	// Phase of the workspace  (Scheduling / Initializing / Ready)
+	Phase v1alpha1.ClusterWorkspacePhaseType `json:"phase,omitempty"`
+
+	// Current processing state of the ClusterWorkspace.
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+
+	// Base URL where this ClusterWorkspace can be targeted.
+	// This will generally be of the form: https://<workspace shard server>/cluster/<workspace name>.
+	// But a workspace could also be targetable by a unique hostname in the future.
+	//
+	// +kubebuilder:validation:Pattern:https://[^/].*
+	// +optional
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// internalBaseURL is where this ClusterWorkspace can be targeted from inside the deployment
+	// network, i.e. through the base URL of its shard instead of its external URL. It is only
+	// different from baseURL in split-horizon deployments.
+	//
+	// +kubebuilder:validation:Pattern:https://[^/].*
+	// +optional
+	InternalBaseURL string `json:"internalBaseURL,omitempty"`
+
+	// Contains workspace placement information.
+	//
+	// +optional
+	Location v1alpha1.ClusterWorkspaceLocation `json:"location,omitempty"`
+
+	// initializers are set on creation by the system and must be cleared
+	// by a controller before the workspace can be used. The workspace will
+	// stay in the phase "Initializing" state until all initializers are cleared.
+	//
+	// A cluster workspace in "Initializing" state are gated via the RBAC
+	// clusterworkspaces/initialize resource permission.
+	//
+	// +optional
+	Initializers []v1alpha1.ClusterWorkspaceInitializer `json:"initializers,omitempty"`
+
+	// expiryTime is the point in time the workspace expires, if it has an expiration.
+	//
+	// +optional
+	ExpiryTime *metav1.Time `json:"expiryTime,omitempty"`
+}
+
+// ClusterWorkspaceList is a list of ClusterWorkspace resources
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ClusterWorkspaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ClusterWorkspace `json:"items"`
+}
+
+// ClusterWorkspaceShard is the v1beta1 version of the v1alpha1 ClusterWorkspaceShard, which is
+// deprecated. Both versions have the same schema and are served from the same storage.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.baseURL`,description="Type URL to directly connect to the shard"
+// +kubebuilder:printcolumn:name="External URL",type=string,JSONPath=`.spec.externalURL`,description="The URL exposed in workspaces created on that shard"
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`,description="The version of kcp the shard runs"
+// +kubebuilder:printcolumn:name="Workspaces",type=integer,JSONPath=`.status.workspaceCount`,description="The number of logical clusters with objects on the shard"
+type ClusterWorkspaceShard struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +optional
+	Spec ClusterWorkspaceShardSpec `json:"spec,omitempty"`
+
+	// +optional
+	Status ClusterWorkspaceShardStatus `json:"status,omitempty"`
+}
+
+func (in *ClusterWorkspaceShard) SetConditions(c conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = c
+}
+
+func (in *ClusterWorkspaceShard) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+var _ conditions.Getter = &ClusterWorkspaceShard{}
+var _ conditions.Setter = &ClusterWorkspaceShard{}
+
+// ClusterWorkspaceShardSpec holds the desired state of the ClusterWorkspaceShard.
+type ClusterWorkspaceShardSpec struct {
+	// baseURL is the address of the KCP shard for direct connections, e.g. by some
+	// front-proxy doing the fan-out to the shards.
+	//
+	// This will be defaulted to the shard's external address if not specified. Note that this
+	// is only sensible in single-shards setups.
+	//
+	// +kubebuilder:validation:Format=uri
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	BaseURL string `json:"baseURL"`
+
+	// ExternalURL is the externally visible address presented to users in Workspace URLs.
+	// Changing this will break all existing workspaces on that shard, i.e. existing
+	// kubeconfigs of clients will be invalid. Hence, when changing this value, the old
+	// URL used by clients must keep working.
+	//
+	// The external address will not be unique if a front-proxy does a fan-out to
+	// shards, but all workspace client will talk to the front-proxy. In that case,
+	// put the address of the front-proxy here.
+	//
+	// Note that movement of shards is only possible (in the future) between shards
+	// that share a common external URL.
+	//
+	// This will be defaulted to the value of the baseURL.
+	//
+	// +kubebuilder:validation:Format=uri
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:Required
+	// +required
+	ExternalURL string `json:"externalURL"`
+
+	// virtualWorkspaceURL is the address of the virtual workspace apiserver of the shard,
+	// without the /services path. If empty, virtual workspaces are served at the baseURL.
+	//
+	// +kubebuilder:validation:Format=uri
+	// +optional
+	VirtualWorkspaceURL string `json:"virtualWorkspaceURL,omitempty"`
+
+	// externalVirtualWorkspaceURL is the externally visible address of the virtual workspaces
+	// of the shard, without the /services path, presented to users. It defaults to the
+	// virtualWorkspaceURL, or to the externalURL if the shard serves virtual workspaces itself.
+	//
+	// +kubebuilder:validation:Format=uri
+	// +optional
+	ExternalVirtualWorkspaceURL string `json:"externalVirtualWorkspaceURL,omitempty"`
+
+	// replicaURL is the address of a read-only replica of the shard, reachable by other shards
+	// with the same credentials and serving CA as the shard. Cross-shard controllers read from
+	// it when the shard is briefly unavailable, e.g. while restarting. These reads may be stale.
+	//
+	// +kubebuilder:validation:Format=uri
+	// +optional
+	ReplicaURL string `json:"replicaURL,omitempty"`
+
+	// unschedulable cordons the shard: no new ClusterWorkspaces are scheduled to it, and
+	// WorkspaceMigrations to it wait until it is schedulable again. The workspaces on the
+	// shard are not affected.
+	//
+	// +optional
+	Unschedulable bool `json:"unschedulable,omitempty"`
+}
+
+// ClusterWorkspaceShardStatus communicates the observed state of the ClusterWorkspaceShard.
+type ClusterWorkspaceShardStatus struct {
+	// Set of integer resources that workspaces can be scheduled into
+	// +optional
+	Capacity corev1.ResourceList `json:"capacity,omitempty"`
+
+	// servingCABundle is the PEM encoded CA bundle that validates the serving certificate
+	// of the shard at spec.baseURL. It is published by the shard itself and kept up-to-date
+	// on rotation, such that the front-proxy and other shards can connect to it without
+	// distributing the CA out-of-band.
+	//
+	// +optional
+	ServingCABundle []byte `json:"servingCABundle,omitempty"`
+
+	// fencingToken is issued by the root shard when the join handshake of the shard succeeded,
+	// i.e. when its identity has been verified. The shard presents it on requests to the root
+	// shard. It is reissued whenever the spec of the shard changes, such that a shard acting
+	// on stale configuration is fenced off.
+	//
+	// +optional
+	FencingToken string `json:"fencingToken,omitempty"`
+
+	// version is the version of kcp the shard runs. It is published by the shard itself.
+	//
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// workspaceCount is the number of logical clusters with objects on the shard. It is
+	// published by the shard itself and refreshed periodically.
+	//
+	// +optional
+	WorkspaceCount *int32 `json:"workspaceCount,omitempty"`
+
+	// apiGroups are the sorted API groups of the CustomResourceDefinitions served by the shard,
+	// including those bound through APIBindings. They are published by the shard itself and
+	// refreshed periodically.
+	//
+	// +optional
+	APIGroups []string `json:"apiGroups,omitempty"`
+
+	// Current processing state of the ClusterWorkspaceShard.
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+// ClusterWorkspaceShardList is a list of workspace shards
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ClusterWorkspaceShardList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ClusterWorkspaceShard `json:"items"`
+}
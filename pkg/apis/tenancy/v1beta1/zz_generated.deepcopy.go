@@ -22,9 +22,259 @@ limitations under the License.
 package v1beta1
 
 import (
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	v1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	v1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspace) DeepCopyInto(out *ClusterWorkspace) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspace.
+func (in *ClusterWorkspace) DeepCopy() *ClusterWorkspace {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWorkspace) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceList) DeepCopyInto(out *ClusterWorkspaceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterWorkspace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceList.
+func (in *ClusterWorkspaceList) DeepCopy() *ClusterWorkspaceList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWorkspaceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceShard) DeepCopyInto(out *ClusterWorkspaceShard) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceShard.
+func (in *ClusterWorkspaceShard) DeepCopy() *ClusterWorkspaceShard {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceShard)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWorkspaceShard) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceShardList) DeepCopyInto(out *ClusterWorkspaceShardList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterWorkspaceShard, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceShardList.
+func (in *ClusterWorkspaceShardList) DeepCopy() *ClusterWorkspaceShardList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceShardList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWorkspaceShardList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceShardSpec) DeepCopyInto(out *ClusterWorkspaceShardSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceShardSpec.
+func (in *ClusterWorkspaceShardSpec) DeepCopy() *ClusterWorkspaceShardSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceShardSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceShardStatus) DeepCopyInto(out *ClusterWorkspaceShardStatus) {
+	*out = *in
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.ServingCABundle != nil {
+		in, out := &in.ServingCABundle, &out.ServingCABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.WorkspaceCount != nil {
+		in, out := &in.WorkspaceCount, &out.WorkspaceCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.APIGroups != nil {
+		in, out := &in.APIGroups, &out.APIGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1alpha1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceShardStatus.
+func (in *ClusterWorkspaceShardStatus) DeepCopy() *ClusterWorkspaceShardStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceShardStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceSpec) DeepCopyInto(out *ClusterWorkspaceSpec) {
+	*out = *in
+	if in.Impersonation != nil {
+		in, out := &in.Impersonation, &out.Impersonation
+		*out = new(tenancyv1alpha1.ImpersonationConstraints)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Expiration != nil {
+		in, out := &in.Expiration, &out.Expiration
+		*out = new(tenancyv1alpha1.ClusterWorkspaceExpiration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccountAudiences != nil {
+		in, out := &in.ServiceAccountAudiences, &out.ServiceAccountAudiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceSpec.
+func (in *ClusterWorkspaceSpec) DeepCopy() *ClusterWorkspaceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWorkspaceStatus) DeepCopyInto(out *ClusterWorkspaceStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(v1alpha1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.Location = in.Location
+	if in.Initializers != nil {
+		in, out := &in.Initializers, &out.Initializers
+		*out = make([]tenancyv1alpha1.ClusterWorkspaceInitializer, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExpiryTime != nil {
+		in, out := &in.ExpiryTime, &out.ExpiryTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWorkspaceStatus.
+func (in *ClusterWorkspaceStatus) DeepCopy() *ClusterWorkspaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWorkspaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Workspace) DeepCopyInto(out *Workspace) {
 	*out = *in
@@ -47,6 +47,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&WorkloadCluster{},
 		&WorkloadClusterList{},
+		&Placement{},
+		&PlacementList{},
+		&WorkloadClusterPool{},
+		&WorkloadClusterPoolList{},
+		&PlacementDecision{},
+		&PlacementDecisionList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -68,6 +68,58 @@ type WorkloadClusterSpec struct {
 	// will be unassigned from the cluster.
 	// By default, workloads scheduled to the cluster are not evicted.
 	EvictAfter *metav1.Time `json:"evictAfter,omitempty"`
+
+	// PriorityClassMappings translates the PriorityClasses referenced by the workloads of the
+	// workspace to the PriorityClasses of the physical cluster. PriorityClasses without mapping
+	// are referenced by the same name on the physical cluster.
+	// +optional
+	PriorityClassMappings []ClassMapping `json:"priorityClassMappings,omitempty"`
+
+	// RuntimeClassMappings translates the RuntimeClasses referenced by the workloads of the
+	// workspace to the RuntimeClasses of the physical cluster. RuntimeClasses without mapping
+	// are referenced by the same name on the physical cluster.
+	// +optional
+	RuntimeClassMappings []ClassMapping `json:"runtimeClassMappings,omitempty"`
+
+	// SharedCluster declares the physical cluster as shared by the WorkloadClusters of several
+	// workspaces. The namespaces of the workspaces are scheduled onto it according to their fair
+	// share of its capacity, instead of first-come-first-served.
+	// +optional
+	SharedCluster *SharedCluster `json:"sharedCluster,omitempty"`
+}
+
+// SharedCluster describes the share of a workspace of a physical cluster shared with other workspaces.
+type SharedCluster struct {
+	// Name identifies the physical cluster. The WorkloadClusters with the same name, in any
+	// workspace, share its capacity.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Capacity is the number of namespaces of all workspaces the physical cluster holds. The
+	// smallest capacity of the WorkloadClusters sharing the physical cluster applies. Without
+	// capacity, the number of namespaces is not limited.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Capacity int32 `json:"capacity,omitempty"`
+
+	// Weight is the share of the workspace of the capacity, relative to the weights of the
+	// other WorkloadClusters sharing the physical cluster.
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// ClassMapping maps the name of a class in the workspace to the name of a class of the physical cluster.
+type ClassMapping struct {
+	// Name is the name of the class in the workspace.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// ClusterName is the name of the class on the physical cluster. If empty, the reference
+	// to the class is removed, and the default class of the physical cluster applies.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
 }
 
 // WorkloadClusterStatus communicates the observed state of the WorkloadCluster (from the controller).
@@ -103,6 +155,76 @@ type WorkloadClusterList struct {
 	Items []WorkloadCluster `json:"items"`
 }
 
+const (
+	// WorkloadClusterDrainFinalizer is set on WorkloadClusters to drain the namespaces
+	// and the downstream resources before the WorkloadCluster is removed.
+	WorkloadClusterDrainFinalizer = "workload.kcp.dev/drain"
+
+	// ForceDeleteAnnotation on a deleted WorkloadCluster skips waiting for the drain
+	// to complete. It is also set by kcp when the drain timed out.
+	ForceDeleteAnnotation = "workload.kcp.dev/force-delete"
+
+	// ApplyConflictsAnnotationPrefix is the prefix of the annotation the syncer of a WorkloadCluster
+	// sets on an upstream resource when the server-side apply to the WorkloadCluster conflicted with
+	// another field manager. The name of the WorkloadCluster is appended to the prefix, and the value
+	// is the JSON list of the conflicting fields.
+	ApplyConflictsAnnotationPrefix = "apply-conflicts.workload.kcp.dev/"
+
+	// DownstreamFieldsAnnotationPrefix is the prefix of the annotation the syncer of a WorkloadCluster
+	// sets on an upstream resource to expose the fields populated on the WorkloadCluster, like node ports,
+	// load balancer ingress points or the volume bound to a claim. The name of the WorkloadCluster is
+	// appended to the prefix, such that the values of several WorkloadClusters don't overwrite each other.
+	DownstreamFieldsAnnotationPrefix = "downstream-fields.workload.kcp.dev/"
+
+	// EndpointsAnnotationPrefix is the prefix of the annotation the syncer of a WorkloadCluster sets on
+	// an upstream Service to expose the ready endpoints of the Service on the WorkloadCluster. The name
+	// of the WorkloadCluster is appended to the prefix. The endpoints of all the WorkloadClusters are
+	// aggregated into EndpointSlices next to the Service.
+	EndpointsAnnotationPrefix = "endpoints.workload.kcp.dev/"
+
+	// OwnerWorkspaceAnnotation is set by the syncer on the resources it creates on a WorkloadCluster,
+	// to the logical cluster of the upstream resource.
+	OwnerWorkspaceAnnotation = "workload.kcp.dev/owner-workspace"
+
+	// OwnerWorkloadClusterAnnotation is set by the syncer on the resources it creates on a WorkloadCluster,
+	// to the name of the WorkloadCluster.
+	OwnerWorkloadClusterAnnotation = "workload.kcp.dev/owner-workload-cluster"
+
+	// OwnerUIDAnnotation is set by the syncer on the resources it creates on a WorkloadCluster, to the
+	// UID of the upstream resource.
+	OwnerUIDAnnotation = "workload.kcp.dev/owner-uid"
+
+	// ForceAdoptAnnotation with the value "true" on a resource on a WorkloadCluster allows the syncer
+	// to take over the resource although it was not created by the syncer. Without it, the syncer does
+	// neither update nor delete resources it does not own.
+	ForceAdoptAnnotation = "workload.kcp.dev/force-adopt"
+
+	// NotOwnedCauseType is the type of the cause reported in the apply conflicts annotation when the
+	// resource exists on the WorkloadCluster, but is not owned by the syncer.
+	NotOwnedCauseType metav1.CauseType = "NotOwned"
+
+	// UpstreamNamespaceAnnotation is set by the syncer on the pods of the workloads it syncs to a
+	// WorkloadCluster, to the namespace of the workload in kcp. It is projected into the service
+	// account volume of the pods, so that in-cluster clients use their namespace in kcp.
+	UpstreamNamespaceAnnotation = "workload.kcp.dev/upstream-namespace"
+
+	// UpsyncedFromLabel is set by the syncer on the read-only pods and events it reflects from a
+	// WorkloadCluster into kcp, to the name of the WorkloadCluster. The labelled objects are not
+	// synced down.
+	UpsyncedFromLabel = "workload.kcp.dev/upsynced-from"
+
+	// SyncerServiceAccountAnnotation is set on a WorkloadCluster to the <namespace>/<name> of the
+	// service account its syncer authenticates with to the workspace of the WorkloadCluster. Only
+	// this service account may set the labels and annotations maintained by the syncer, e.g. the
+	// UpsyncedFromLabel.
+	SyncerServiceAccountAnnotation = "workload.kcp.dev/syncer-service-account"
+
+	// ReplicasManagedDownstreamAnnotation is set on a Deployment to the name of the HorizontalPodAutoscaler
+	// scaling it on its WorkloadCluster. The syncer then leaves the replicas of the Deployment on the
+	// WorkloadCluster to the autoscaler.
+	ReplicasManagedDownstreamAnnotation = "workload.kcp.dev/replicas-managed-downstream"
+)
+
 // Conditions and ConditionReasons for the kcp WorkloadCluster object.
 const (
 	// SyncerReady means the syncer is ready to transfer resources between KCP and the WorkloadCluster.
@@ -114,6 +236,9 @@ const (
 	// HeartbeatHealthy means the HeartbeatManager has seen a heartbeat for the WorkloadCluster within the expected interval.
 	HeartbeatHealthy conditionsv1alpha1.ConditionType = "HeartbeatHealthy"
 
+	// DownstreamDrained means the syncer has removed the resources from the WorkloadCluster after it was deleted.
+	DownstreamDrained conditionsv1alpha1.ConditionType = "DownstreamDrained"
+
 	// WorkloadClusterUnknownReason documents a WorkloadCluster which readiness is unknown.
 	WorkloadClusterUnknownReason = "WorkloadClusterStatusUnknown"
 
@@ -152,3 +277,259 @@ func (in *WorkloadCluster) SetConditions(conditions conditionsv1alpha1.Condition
 func (in *WorkloadCluster) GetConditions() conditionsv1alpha1.Conditions {
 	return in.Status.Conditions
 }
+
+// Placement selects the namespaces of a workspace by label and restricts the
+// WorkloadClusters they can be scheduled to. It lets a workspace split its
+// namespaces across different locations.
+//
+// A namespace matched by several Placements uses the first one by name. When
+// Placements exist in a workspace, namespaces not matched by any of them are
+// not scheduled.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+type Placement struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state.
+	// +optional
+	Spec PlacementSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state.
+	// +optional
+	Status PlacementStatus `json:"status,omitempty"`
+}
+
+var _ conditions.Getter = &Placement{}
+var _ conditions.Setter = &Placement{}
+
+// PlacementSpec holds the desired state of the Placement.
+type PlacementSpec struct {
+	// namespaceSelector selects the namespaces of the workspace this placement
+	// applies to. An empty selector selects all namespaces.
+	//
+	// +required
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector"`
+
+	// locationSelector restricts the WorkloadClusters the selected namespaces
+	// can be scheduled to by label. If unset, all WorkloadClusters are candidates.
+	//
+	// +optional
+	LocationSelector *metav1.LabelSelector `json:"locationSelector,omitempty"`
+
+	// pool names a WorkloadClusterPool in the workspace of this placement. When
+	// set, the selected namespaces are only scheduled to members of the pool
+	// that also match the locationSelector, and fail over within the pool. A
+	// pool that does not exist has no members.
+	//
+	// +optional
+	Pool string `json:"pool,omitempty"`
+}
+
+// PlacementStatus communicates the observed state of the Placement.
+type PlacementStatus struct {
+	// Current processing state of the Placement.
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+func (in *Placement) SetConditions(conditions conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
+func (in *Placement) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+// PlacementList is a list of Placement resources
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type PlacementList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []Placement `json:"items"`
+}
+
+// WorkloadClusterPool groups WorkloadClusters into a failover group. Placements
+// referencing the pool schedule their namespaces to members of the pool, and the
+// namespace scheduler moves the namespaces of a member losing its syncer heartbeat
+// to another healthy member according to the failover policy. Members can be
+// added and removed without changing the Placements.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="Policy",type="string",JSONPath=`.spec.failoverPolicy`,priority=1
+type WorkloadClusterPool struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state.
+	// +optional
+	Spec WorkloadClusterPoolSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state.
+	// +optional
+	Status WorkloadClusterPoolStatus `json:"status,omitempty"`
+}
+
+var _ conditions.Getter = &WorkloadClusterPool{}
+var _ conditions.Setter = &WorkloadClusterPool{}
+
+// FailoverPolicy determines the member of a WorkloadClusterPool a namespace is
+// assigned to.
+//
+// +kubebuilder:validation:Enum=Priority;Random
+type FailoverPolicy string
+
+const (
+	// FailoverPolicyPriority assigns namespaces to the first healthy member in
+	// the order of the members list. Namespaces don't move back when a member
+	// with a higher priority becomes healthy again.
+	FailoverPolicyPriority FailoverPolicy = "Priority"
+	// FailoverPolicyRandom assigns namespaces to a random healthy member.
+	FailoverPolicyRandom FailoverPolicy = "Random"
+)
+
+// WorkloadClusterPoolSpec holds the desired state of the WorkloadClusterPool.
+type WorkloadClusterPoolSpec struct {
+	// members are the names of the WorkloadClusters of the pool, in the workspace
+	// of the pool, by decreasing priority.
+	//
+	// +optional
+	Members []string `json:"members,omitempty"`
+
+	// failoverPolicy determines the member namespaces are assigned to, initially
+	// and when their member becomes unhealthy.
+	//
+	// +optional
+	// +kubebuilder:default=Priority
+	FailoverPolicy FailoverPolicy `json:"failoverPolicy,omitempty"`
+}
+
+const (
+	// GeneratedFromLabelAnnotation is set by kcp on the WorkloadClusterPools it generates from
+	// the value of a label of the WorkloadClusters, to the key of the label. The members of these
+	// pools are maintained by kcp.
+	GeneratedFromLabelAnnotation = "workload.kcp.dev/generated-from-label"
+)
+
+// WorkloadClusterPoolStatus communicates the observed state of the WorkloadClusterPool.
+type WorkloadClusterPoolStatus struct {
+	// Current processing state of the WorkloadClusterPool.
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+}
+
+func (in *WorkloadClusterPool) SetConditions(conditions conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
+func (in *WorkloadClusterPool) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
+// WorkloadClusterPoolList is a list of WorkloadClusterPool resources
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type WorkloadClusterPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []WorkloadClusterPool `json:"items"`
+}
+
+// PlacementDecision records the last scheduling decisions of the built-in scheduler
+// for the namespace with the same name, in the same workspace. It lets users find
+// out why a namespace was assigned to a WorkloadCluster, or why it wasn't.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+type PlacementDecision struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// decisions are the last scheduling decisions for the namespace, oldest first.
+	//
+	// +optional
+	Decisions []SchedulingDecision `json:"decisions,omitempty"`
+}
+
+// SchedulingDecision is a scheduling decision for a namespace.
+type SchedulingDecision struct {
+	// time is when the decision was taken.
+	//
+	// +required
+	Time metav1.Time `json:"time"`
+
+	// placement is the name of the Placement selecting the namespace, if any.
+	//
+	// +optional
+	Placement string `json:"placement,omitempty"`
+
+	// pool is the name of the WorkloadClusterPool of the placement, if any.
+	//
+	// +optional
+	Pool string `json:"pool,omitempty"`
+
+	// previousCluster is the WorkloadCluster the namespace was assigned to before
+	// the decision, if any.
+	//
+	// +optional
+	PreviousCluster string `json:"previousCluster,omitempty"`
+
+	// cluster is the WorkloadCluster the namespace is assigned to by the decision.
+	// It is empty if no WorkloadCluster is suitable.
+	//
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// message explains the decision.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// rejectedClusters are the WorkloadClusters of the workspace that were not
+	// candidates for the namespace, with the reason.
+	//
+	// +optional
+	RejectedClusters []RejectedCluster `json:"rejectedClusters,omitempty"`
+}
+
+// RejectedCluster is a WorkloadCluster that was not a candidate for a scheduling decision.
+type RejectedCluster struct {
+	// name is the name of the WorkloadCluster.
+	//
+	// +required
+	Name string `json:"name"`
+
+	// reason explains why the WorkloadCluster was not a candidate.
+	//
+	// +required
+	Reason string `json:"reason"`
+}
+
+// PlacementDecisionList is a list of PlacementDecision resources
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type PlacementDecisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PlacementDecision `json:"items"`
+}
@@ -22,13 +22,260 @@ limitations under the License.
 package v1alpha1
 
 import (
-	v1 "k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClassMapping) DeepCopyInto(out *ClassMapping) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClassMapping.
+func (in *ClassMapping) DeepCopy() *ClassMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(ClassMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Placement) DeepCopyInto(out *Placement) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Placement.
+func (in *Placement) DeepCopy() *Placement {
+	if in == nil {
+		return nil
+	}
+	out := new(Placement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Placement) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementDecision) DeepCopyInto(out *PlacementDecision) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Decisions != nil {
+		in, out := &in.Decisions, &out.Decisions
+		*out = make([]SchedulingDecision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementDecision.
+func (in *PlacementDecision) DeepCopy() *PlacementDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlacementDecision) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementDecisionList) DeepCopyInto(out *PlacementDecisionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PlacementDecision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementDecisionList.
+func (in *PlacementDecisionList) DeepCopy() *PlacementDecisionList {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementDecisionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlacementDecisionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementList) DeepCopyInto(out *PlacementList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Placement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementList.
+func (in *PlacementList) DeepCopy() *PlacementList {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlacementList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LocationSelector != nil {
+		in, out := &in.LocationSelector, &out.LocationSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementSpec.
+func (in *PlacementSpec) DeepCopy() *PlacementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementStatus) DeepCopyInto(out *PlacementStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(conditionsv1alpha1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementStatus.
+func (in *PlacementStatus) DeepCopy() *PlacementStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RejectedCluster) DeepCopyInto(out *RejectedCluster) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RejectedCluster.
+func (in *RejectedCluster) DeepCopy() *RejectedCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(RejectedCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchedulingDecision) DeepCopyInto(out *SchedulingDecision) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.RejectedClusters != nil {
+		in, out := &in.RejectedClusters, &out.RejectedClusters
+		*out = make([]RejectedCluster, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchedulingDecision.
+func (in *SchedulingDecision) DeepCopy() *SchedulingDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(SchedulingDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedCluster) DeepCopyInto(out *SharedCluster) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedCluster.
+func (in *SharedCluster) DeepCopy() *SharedCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkloadCluster) DeepCopyInto(out *WorkloadCluster) {
 	*out = *in
@@ -90,6 +337,111 @@ func (in *WorkloadClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadClusterPool) DeepCopyInto(out *WorkloadClusterPool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadClusterPool.
+func (in *WorkloadClusterPool) DeepCopy() *WorkloadClusterPool {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadClusterPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkloadClusterPool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadClusterPoolList) DeepCopyInto(out *WorkloadClusterPoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkloadClusterPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadClusterPoolList.
+func (in *WorkloadClusterPoolList) DeepCopy() *WorkloadClusterPoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadClusterPoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkloadClusterPoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadClusterPoolSpec) DeepCopyInto(out *WorkloadClusterPoolSpec) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadClusterPoolSpec.
+func (in *WorkloadClusterPoolSpec) DeepCopy() *WorkloadClusterPoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadClusterPoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadClusterPoolStatus) DeepCopyInto(out *WorkloadClusterPoolStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(conditionsv1alpha1.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadClusterPoolStatus.
+func (in *WorkloadClusterPoolStatus) DeepCopy() *WorkloadClusterPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadClusterPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkloadClusterSpec) DeepCopyInto(out *WorkloadClusterSpec) {
 	*out = *in
@@ -97,6 +449,21 @@ func (in *WorkloadClusterSpec) DeepCopyInto(out *WorkloadClusterSpec) {
 		in, out := &in.EvictAfter, &out.EvictAfter
 		*out = (*in).DeepCopy()
 	}
+	if in.PriorityClassMappings != nil {
+		in, out := &in.PriorityClassMappings, &out.PriorityClassMappings
+		*out = make([]ClassMapping, len(*in))
+		copy(*out, *in)
+	}
+	if in.RuntimeClassMappings != nil {
+		in, out := &in.RuntimeClassMappings, &out.RuntimeClassMappings
+		*out = make([]ClassMapping, len(*in))
+		copy(*out, *in)
+	}
+	if in.SharedCluster != nil {
+		in, out := &in.SharedCluster, &out.SharedCluster
+		*out = new(SharedCluster)
+		**out = **in
+	}
 	return
 }
 
@@ -115,10 +482,10 @@ func (in *WorkloadClusterStatus) DeepCopyInto(out *WorkloadClusterStatus) {
 	*out = *in
 	if in.Allocatable != nil {
 		in, out := &in.Allocatable, &out.Allocatable
-		*out = new(v1.ResourceList)
+		*out = new(corev1.ResourceList)
 		if **in != nil {
 			in, out := *in, *out
-			*out = make(map[v1.ResourceName]resource.Quantity, len(*in))
+			*out = make(map[corev1.ResourceName]resource.Quantity, len(*in))
 			for key, val := range *in {
 				(*out)[key] = val.DeepCopy()
 			}
@@ -126,10 +493,10 @@ func (in *WorkloadClusterStatus) DeepCopyInto(out *WorkloadClusterStatus) {
 	}
 	if in.Capacity != nil {
 		in, out := &in.Capacity, &out.Capacity
-		*out = new(v1.ResourceList)
+		*out = new(corev1.ResourceList)
 		if **in != nil {
 			in, out := *in, *out
-			*out = make(map[v1.ResourceName]resource.Quantity, len(*in))
+			*out = make(map[corev1.ResourceName]resource.Quantity, len(*in))
 			for key, val := range *in {
 				(*out)[key] = val.DeepCopy()
 			}
@@ -0,0 +1,180 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/tools/cache"
+)
+
+// TokenCache caches the authentication of requests carrying only a bearer token, keyed by
+// the token and the audiences accepted for the request. Successful authentications are kept
+// for the success TTL, rejected tokens for the failure TTL. Errors are not cached.
+//
+// Requests with a client certificate are always passed through, as they might be
+// authenticated by the certificate instead of the token.
+//
+// The authentications of a service account are dropped when it or one of its token secrets
+// is deleted, see InvalidationHandler.
+type TokenCache struct {
+	delegate   authenticator.Request
+	cache      *utilcache.LRUExpireCache
+	successTTL time.Duration
+	failureTTL time.Duration
+}
+
+type cachedResponse struct {
+	response *authenticator.Response
+	ok       bool
+	// serviceAccount is the key of the authenticated service account, if any.
+	serviceAccount string
+}
+
+// NewTokenCache wraps the given authenticator with a cache of at most size entries.
+func NewTokenCache(delegate authenticator.Request, size int, successTTL, failureTTL time.Duration) *TokenCache {
+	return newTokenCache(delegate, utilcache.NewLRUExpireCache(size), successTTL, failureTTL)
+}
+
+func newTokenCache(delegate authenticator.Request, cache *utilcache.LRUExpireCache, successTTL, failureTTL time.Duration) *TokenCache {
+	return &TokenCache{
+		delegate:   delegate,
+		cache:      cache,
+		successTTL: successTTL,
+		failureTTL: failureTTL,
+	}
+}
+
+func (c *TokenCache) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	token, found := bearerToken(req)
+	if !found || (req.TLS != nil && len(req.TLS.PeerCertificates) > 0) {
+		return c.delegate.AuthenticateRequest(req)
+	}
+
+	audiences, _ := authenticator.AudiencesFrom(req.Context())
+	key := cacheKey(token, audiences)
+	if cached, found := c.cache.Get(key); found {
+		cached := cached.(*cachedResponse)
+		if !cached.ok {
+			return nil, false, nil
+		}
+		// like the bearer token authenticator, do not pass the token on after successful authentication
+		req.Header.Del("Authorization")
+		// callers may replace fields of the response
+		resp := *cached.response
+		return &resp, true, nil
+	}
+
+	resp, ok, err := c.delegate.AuthenticateRequest(req)
+	if err != nil {
+		return resp, ok, err
+	}
+	switch {
+	case ok && c.successTTL > 0:
+		copied := *resp
+		c.cache.Add(key, &cachedResponse{response: &copied, ok: true, serviceAccount: serviceAccountKeyOf(resp.User)}, c.successTTL)
+	case !ok && c.failureTTL > 0:
+		c.cache.Add(key, &cachedResponse{}, c.failureTTL)
+	}
+	return resp, ok, nil
+}
+
+// InvalidateServiceAccount drops the cached authentications of the given service account.
+func (c *TokenCache) InvalidateServiceAccount(clusterName logicalcluster.LogicalCluster, namespace, name string) {
+	saKey := serviceAccountKey(clusterName.String(), namespace, name)
+	// keys are returned from the least to the most recently used, getting them in this
+	// order keeps the order of the entries.
+	for _, key := range c.cache.Keys() {
+		if cached, found := c.cache.Get(key); found && cached.(*cachedResponse).serviceAccount == saKey {
+			c.cache.Remove(key)
+		}
+	}
+}
+
+// InvalidationHandler returns the event handler for ServiceAccounts and Secrets, dropping
+// the cached authentications of deleted service accounts and of deleted token secrets.
+func (c *TokenCache) InvalidationHandler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			switch obj := obj.(type) {
+			case *corev1.ServiceAccount:
+				c.InvalidateServiceAccount(logicalcluster.From(obj), obj.Namespace, obj.Name)
+			case *corev1.Secret:
+				if name := obj.Annotations[corev1.ServiceAccountNameKey]; obj.Type == corev1.SecretTypeServiceAccountToken && name != "" {
+					c.InvalidateServiceAccount(logicalcluster.From(obj), obj.Namespace, name)
+				}
+			}
+		},
+	}
+}
+
+// serviceAccountKeyOf returns the key of the service account of the user, or an empty string
+// if it is not a service account.
+func serviceAccountKeyOf(u user.Info) string {
+	namespace, name, err := serviceaccount.SplitUsername(u.GetName())
+	if err != nil {
+		return ""
+	}
+	var clusterName string
+	if values := u.GetExtra()[serviceaccount.ClusterNameKey]; len(values) > 0 {
+		clusterName = values[0]
+	}
+	return serviceAccountKey(clusterName, namespace, name)
+}
+
+func serviceAccountKey(clusterName, namespace, name string) string {
+	return clusterName + "|" + namespace + "/" + name
+}
+
+// bearerToken returns the token of the Authorization header of the request.
+func bearerToken(req *http.Request) (string, bool) {
+	parts := strings.SplitN(strings.TrimSpace(req.Header.Get("Authorization")), " ", 2)
+	if len(parts) < 2 || strings.ToLower(parts[0]) != "bearer" {
+		return "", false
+	}
+	token := strings.TrimSpace(parts[1])
+	return token, len(token) > 0
+}
+
+// cacheKey hashes the token, so that the cache does not hold credentials, together with the audiences.
+func cacheKey(token string, audiences authenticator.Audiences) string {
+	sorted := append([]string(nil), audiences...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	h.Write([]byte(token))
+	for _, audience := range sorted {
+		h.Write([]byte{0})
+		h.Write([]byte(audience))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
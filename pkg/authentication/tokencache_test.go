@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/tools/cache"
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+type countingAuthenticator struct {
+	calls int
+	users map[string]string
+	err   error
+}
+
+func (a *countingAuthenticator) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	a.calls++
+	if a.err != nil {
+		return nil, false, a.err
+	}
+	token, _ := bearerToken(req)
+	name, found := a.users[token]
+	if !found {
+		return nil, false, nil
+	}
+	req.Header.Del("Authorization")
+	audiences, _ := authenticator.AudiencesFrom(req.Context())
+	return &authenticator.Response{User: &user.DefaultInfo{Name: name}, Audiences: audiences}, true, nil
+}
+
+func newRequest(token string, audiences ...string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req.WithContext(authenticator.WithAudiences(context.Background(), audiences))
+}
+
+func TestTokenCache(t *testing.T) {
+	clock := testingclock.NewFakeClock(time.Now())
+	delegate := &countingAuthenticator{users: map[string]string{"good": "alice"}}
+	c := newTokenCache(delegate, utilcache.NewLRUExpireCacheWithClock(10, clock), time.Minute, 10*time.Second)
+
+	authenticate := func(req *http.Request) (string, bool) {
+		t.Helper()
+		resp, ok, err := c.AuthenticateRequest(req)
+		require.NoError(t, err)
+		if !ok {
+			return "", false
+		}
+		return resp.User.GetName(), true
+	}
+
+	// successful authentications are cached for the success TTL
+	req := newRequest("good", "kcp")
+	name, ok := authenticate(req)
+	require.True(t, ok)
+	require.Equal(t, "alice", name)
+	req = newRequest("good", "kcp")
+	name, ok = authenticate(req)
+	require.True(t, ok)
+	require.Equal(t, "alice", name)
+	require.Empty(t, req.Header.Get("Authorization"), "token should be removed after authentication from the cache")
+	require.Equal(t, 1, delegate.calls)
+
+	// responses are copied, callers may modify them
+	resp, _, _ := c.AuthenticateRequest(newRequest("good", "kcp"))
+	resp.Audiences = authenticator.Audiences{"other"}
+	resp, _, _ = c.AuthenticateRequest(newRequest("good", "kcp"))
+	require.Equal(t, authenticator.Audiences{"kcp"}, resp.Audiences)
+	require.Equal(t, 1, delegate.calls)
+
+	// other audiences are authenticated separately
+	_, ok = authenticate(newRequest("good", "kcp.dev/workspace/root:org"))
+	require.True(t, ok)
+	require.Equal(t, 2, delegate.calls)
+
+	// rejected tokens are cached for the failure TTL
+	_, ok = authenticate(newRequest("bad", "kcp"))
+	require.False(t, ok)
+	_, ok = authenticate(newRequest("bad", "kcp"))
+	require.False(t, ok)
+	require.Equal(t, 3, delegate.calls)
+	clock.Step(11 * time.Second)
+	_, ok = authenticate(newRequest("bad", "kcp"))
+	require.False(t, ok)
+	require.Equal(t, 4, delegate.calls)
+
+	// successful authentications expire after the success TTL
+	clock.Step(time.Minute)
+	_, ok = authenticate(newRequest("good", "kcp"))
+	require.True(t, ok)
+	require.Equal(t, 5, delegate.calls)
+
+	// requests without token or with a client certificate are passed through
+	_, ok = authenticate(newRequest("", "kcp"))
+	require.False(t, ok)
+	_, ok = authenticate(newRequest("", "kcp"))
+	require.False(t, ok)
+	require.Equal(t, 7, delegate.calls)
+	req = newRequest("bad", "kcp")
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+	_, ok = authenticate(req)
+	require.False(t, ok)
+	require.Equal(t, 8, delegate.calls)
+
+	// errors are not cached
+	delegate.err = errors.New("webhook unavailable")
+	_, _, err := c.AuthenticateRequest(newRequest("other", "kcp"))
+	require.Error(t, err)
+	_, _, err = c.AuthenticateRequest(newRequest("other", "kcp"))
+	require.Error(t, err)
+	require.Equal(t, 10, delegate.calls)
+}
+
+func TestTokenCacheInvalidation(t *testing.T) {
+	calls := map[string]int{}
+	users := map[string]user.Info{
+		"builder":  serviceaccount.UserInfo(logicalcluster.New("root:org:ws"), "default", "builder", "uid-1"),
+		"other":    serviceaccount.UserInfo(logicalcluster.New("root:org:other"), "default", "builder", "uid-2"),
+		"deployer": serviceaccount.UserInfo(logicalcluster.New("root:org:ws"), "default", "deployer", "uid-3"),
+		"alice":    &user.DefaultInfo{Name: "alice"},
+	}
+	delegate := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+		token, _ := bearerToken(req)
+		calls[token]++
+		return &authenticator.Response{User: users[token]}, true, nil
+	})
+	c := NewTokenCache(delegate, 10, time.Minute, 10*time.Second)
+	authenticateAll := func() {
+		t.Helper()
+		for token := range users {
+			_, ok, err := c.AuthenticateRequest(newRequest(token, "kcp"))
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+	}
+	authenticateAll()
+
+	handler := c.InvalidationHandler()
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "builder", Namespace: "default", ClusterName: "root:org:ws"}}
+	handler.OnDelete(serviceAccount)
+	authenticateAll()
+	require.Equal(t, map[string]int{"builder": 2, "other": 1, "deployer": 1, "alice": 1}, calls, "only the deleted service account should be authenticated again")
+
+	// secrets other than service account tokens are ignored
+	handler.OnDelete(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployer", Namespace: "default", ClusterName: "root:org:ws", Annotations: map[string]string{corev1.ServiceAccountNameKey: "deployer"}},
+		Type:       corev1.SecretTypeOpaque,
+	})
+	authenticateAll()
+	require.Equal(t, map[string]int{"builder": 2, "other": 1, "deployer": 1, "alice": 1}, calls)
+
+	handler.OnDelete(cache.DeletedFinalStateUnknown{Obj: &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployer-token-abc", Namespace: "default", ClusterName: "root:org:ws", Annotations: map[string]string{corev1.ServiceAccountNameKey: "deployer"}},
+		Type:       corev1.SecretTypeServiceAccountToken,
+	}})
+	authenticateAll()
+	require.Equal(t, map[string]int{"builder": 2, "other": 1, "deployer": 2, "alice": 1}, calls)
+}
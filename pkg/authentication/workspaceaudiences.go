@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+// WorkspaceAudiencePrefix prefixes the workspace path to form the audience of a workspace.
+const WorkspaceAudiencePrefix = "kcp.dev/workspace/"
+
+// WorkspaceAudience returns the audience service account tokens are bound to in order
+// to be only valid for the given workspace.
+func WorkspaceAudience(cluster logicalcluster.LogicalCluster) string {
+	return WorkspaceAudiencePrefix + cluster.String()
+}
+
+// WithWorkspaceAudiences wraps the given authenticator to accept tokens for the audience of
+// the workspace of the request and the additional audiences configured in its ClusterWorkspace
+// spec, on top of the API audiences of the server.
+//
+// Only service account tokens are accepted through these audiences. A token of another kind, e.g.
+// one reviewed by a webhook, that is accepted for a workspace audience only is rejected. A service
+// account token accepted only through a workspace audience is reported with the API audiences, such
+// that the authentication filter accepts it.
+func WithWorkspaceAudiences(delegate authenticator.Request, apiAudiences authenticator.Audiences, clusterWorkspaceLister tenancylisters.ClusterWorkspaceLister) authenticator.Request {
+	return &workspaceAudiences{
+		delegate:     delegate,
+		apiAudiences: apiAudiences,
+		getAudiences: func(cluster logicalcluster.LogicalCluster) ([]string, error) {
+			parent, name := cluster.Split()
+			if parent.Empty() {
+				return nil, nil
+			}
+			workspace, err := clusterWorkspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+			if errors.IsNotFound(err) {
+				return nil, nil
+			} else if err != nil {
+				return nil, err
+			}
+			return workspace.Spec.ServiceAccountAudiences, nil
+		},
+	}
+}
+
+type workspaceAudiences struct {
+	delegate     authenticator.Request
+	apiAudiences authenticator.Audiences
+	getAudiences func(cluster logicalcluster.LogicalCluster) ([]string, error)
+}
+
+func (a *workspaceAudiences) AuthenticateRequest(req *http.Request) (*authenticator.Response, bool, error) {
+	cluster := genericapirequest.ClusterFrom(req.Context())
+	if cluster == nil || cluster.Name.Empty() || cluster.Wildcard {
+		return a.delegate.AuthenticateRequest(req)
+	}
+
+	extra, err := a.getAudiences(cluster.Name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	accepted := make(authenticator.Audiences, 0, len(a.apiAudiences)+1+len(extra))
+	accepted = append(accepted, a.apiAudiences...)
+	accepted = append(accepted, WorkspaceAudience(cluster.Name))
+	accepted = append(accepted, extra...)
+
+	resp, ok, err := a.delegate.AuthenticateRequest(req.WithContext(authenticator.WithAudiences(req.Context(), accepted)))
+	if err != nil || !ok {
+		return resp, ok, err
+	}
+
+	if len(a.apiAudiences) > 0 && len(resp.Audiences) > 0 && len(a.apiAudiences.Intersect(resp.Audiences)) == 0 {
+		if resp.User == nil || !strings.HasPrefix(resp.User.GetName(), serviceaccount.ServiceAccountUsernamePrefix) {
+			return nil, false, nil
+		}
+		resp.Audiences = a.apiAudiences
+	}
+
+	return resp, true, nil
+}
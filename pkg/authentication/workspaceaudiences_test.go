@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func TestWithWorkspaceAudiences(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"},
+		Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{ServiceAccountAudiences: []string{"https://vault.example.com"}},
+	}))
+
+	tests := map[string]struct {
+		cluster        request.Cluster
+		tokenAudiences []string
+		userName       string
+		wantOK         bool
+		wantAudiences  authenticator.Audiences
+	}{
+		"api audience": {
+			cluster:        request.Cluster{Name: logicalcluster.New("root:org:team")},
+			tokenAudiences: []string{"https://kcp.default.svc"},
+			wantOK:         true,
+			wantAudiences:  authenticator.Audiences{"https://kcp.default.svc"},
+		},
+		"workspace audience": {
+			cluster:        request.Cluster{Name: logicalcluster.New("root:org:team")},
+			tokenAudiences: []string{"kcp.dev/workspace/root:org:team"},
+			wantOK:         true,
+			wantAudiences:  authenticator.Audiences{"https://kcp.default.svc"},
+		},
+		"audience of another workspace": {
+			cluster:        request.Cluster{Name: logicalcluster.New("root:org:team")},
+			tokenAudiences: []string{"kcp.dev/workspace/root:org:other"},
+		},
+		"configured workspace audience": {
+			cluster:        request.Cluster{Name: logicalcluster.New("root:org:team")},
+			tokenAudiences: []string{"https://vault.example.com"},
+			wantOK:         true,
+			wantAudiences:  authenticator.Audiences{"https://kcp.default.svc"},
+		},
+		"configured audience of another workspace": {
+			cluster:        request.Cluster{Name: logicalcluster.New("root:org:other")},
+			tokenAudiences: []string{"https://vault.example.com"},
+		},
+		"workspace audience in root": {
+			cluster:        request.Cluster{Name: logicalcluster.New("root")},
+			tokenAudiences: []string{"kcp.dev/workspace/root"},
+			wantOK:         true,
+			wantAudiences:  authenticator.Audiences{"https://kcp.default.svc"},
+		},
+		"workspace audience of a token of another authenticator": {
+			cluster:        request.Cluster{Name: logicalcluster.New("root:org:team")},
+			tokenAudiences: []string{"kcp.dev/workspace/root:org:team"},
+			userName:       "webhook-user",
+		},
+		"api audience of a token of another authenticator": {
+			cluster:        request.Cluster{Name: logicalcluster.New("root:org:team")},
+			tokenAudiences: []string{"https://kcp.default.svc", "kcp.dev/workspace/root:org:team"},
+			userName:       "webhook-user",
+			wantOK:         true,
+			wantAudiences:  authenticator.Audiences{"https://kcp.default.svc", "kcp.dev/workspace/root:org:team"},
+		},
+		"workspace audience on wildcard request": {
+			cluster:        request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			tokenAudiences: []string{"kcp.dev/workspace/root:org:team"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// mimic the JWT authenticator, intersecting the token audiences with the requested ones
+			delegate := authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+				requested, ok := authenticator.AudiencesFrom(req.Context())
+				if !ok {
+					requested = authenticator.Audiences{"https://kcp.default.svc"}
+				}
+				auds := requested.Intersect(tc.tokenAudiences)
+				if len(auds) == 0 {
+					return nil, false, nil
+				}
+				userName := tc.userName
+				if userName == "" {
+					userName = "system:serviceaccount:default:default"
+				}
+				return &authenticator.Response{User: &user.DefaultInfo{Name: userName}, Audiences: auds}, true, nil
+			})
+			auth := WithWorkspaceAudiences(delegate, authenticator.Audiences{"https://kcp.default.svc"}, tenancylisters.NewClusterWorkspaceLister(indexer))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/configmaps", nil)
+			req = req.WithContext(request.WithCluster(req.Context(), tc.cluster))
+			resp, ok, err := auth.AuthenticateRequest(req)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantOK, ok)
+			if tc.wantOK {
+				require.Equal(t, tc.wantAudiences, resp.Audiences)
+			}
+		})
+	}
+}
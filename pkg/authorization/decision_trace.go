@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+type decisionTraceKeyType int
+
+const decisionTraceKey decisionTraceKeyType = iota
+
+// AuthorizerDecision is the decision of one authorizer of the chain. Depth is the
+// nesting of the authorizer, i.e. authorizers delegating to others have a lower
+// depth than their delegates.
+type AuthorizerDecision struct {
+	Authorizer string `json:"authorizer"`
+	Depth      int    `json:"depth"`
+	Decision   string `json:"decision"`
+	Reason     string `json:"reason,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DecisionTrace records the decisions of the traced authorizers for one request,
+// in the order the authorizers are called.
+type DecisionTrace struct {
+	lock      sync.Mutex
+	depth     int
+	decisions []AuthorizerDecision
+}
+
+// WithDecisionTrace returns a context in which traced authorizers record their
+// decisions into the returned trace.
+func WithDecisionTrace(ctx context.Context) (context.Context, *DecisionTrace) {
+	trace := &DecisionTrace{}
+	return context.WithValue(ctx, decisionTraceKey, trace), trace
+}
+
+// Decisions returns the recorded decisions.
+func (t *DecisionTrace) Decisions() []AuthorizerDecision {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append([]AuthorizerDecision(nil), t.decisions...)
+}
+
+func (t *DecisionTrace) enter(name string) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.decisions = append(t.decisions, AuthorizerDecision{Authorizer: name, Depth: t.depth})
+	t.depth++
+	return len(t.decisions) - 1
+}
+
+func (t *DecisionTrace) leave(i int, decision authorizer.Decision, reason string, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.depth--
+	t.decisions[i].Decision = DecisionString(decision)
+	t.decisions[i].Reason = reason
+	if err != nil {
+		t.decisions[i].Error = err.Error()
+	}
+}
+
+// DecisionString returns a human readable form of the decision.
+func DecisionString(decision authorizer.Decision) string {
+	switch decision {
+	case authorizer.DecisionAllow:
+		return "Allow"
+	case authorizer.DecisionDeny:
+		return "Deny"
+	default:
+		return "NoOpinion"
+	}
+}
+
+// NewTracingAuthorizer returns an authorizer recording the decisions of the given
+// authorizer under the given name, if the context carries a decision trace.
+func NewTracingAuthorizer(name string, delegate authorizer.Authorizer) authorizer.Authorizer {
+	return &tracingAuthorizer{
+		name:     name,
+		delegate: delegate,
+	}
+}
+
+type tracingAuthorizer struct {
+	name     string
+	delegate authorizer.Authorizer
+}
+
+func (a *tracingAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	trace, ok := ctx.Value(decisionTraceKey).(*DecisionTrace)
+	if !ok {
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	i := trace.enter(a.name)
+	decision, reason, err := a.delegate.Authorize(ctx, attr)
+	trace.leave(i, decision, reason, err)
+	return decision, reason, err
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// AuthorizationExplanation explains the authorization decision for a hypothetical request
+// in a logical cluster, with the decisions of the traced authorizers of the chain.
+type AuthorizationExplanation struct {
+	Cluster  string               `json:"cluster"`
+	Decision string               `json:"decision"`
+	Reason   string               `json:"reason,omitempty"`
+	Error    string               `json:"error,omitempty"`
+	Chain    []AuthorizerDecision `json:"chain"`
+}
+
+// NewExplainHandler returns a handler explaining the decisions of the given authorizer chain
+// for the request described by a POSTed SubjectAccessReview in the logical cluster given by
+// the "cluster" query parameter. Only members of the system:masters group may use it.
+func NewExplainHandler(delegate authorizer.Authorizer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requester, ok := genericapirequest.UserFrom(req.Context())
+		if !ok || !sets.NewString(requester.GetGroups()...).Has(user.SystemPrivilegedGroup) {
+			http.Error(w, "authorization explanations are only available to "+user.SystemPrivilegedGroup, http.StatusForbidden)
+			return
+		}
+		if req.Method != http.MethodPost {
+			http.Error(w, "only POST of a SubjectAccessReview is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		cluster := req.URL.Query().Get("cluster")
+		if cluster == "" {
+			http.Error(w, "the cluster query parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		var review authorizationv1.SubjectAccessReview
+		if err := json.NewDecoder(req.Body).Decode(&review); err != nil {
+			http.Error(w, fmt.Sprintf("failed to decode SubjectAccessReview: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		ctx := genericapirequest.WithCluster(req.Context(), genericapirequest.Cluster{Name: logicalcluster.New(cluster)})
+		ctx, trace := WithDecisionTrace(ctx)
+		decision, reason, err := delegate.Authorize(ctx, attributesFrom(review.Spec))
+
+		explanation := AuthorizationExplanation{
+			Cluster:  cluster,
+			Decision: DecisionString(decision),
+			Reason:   reason,
+			Chain:    trace.Decisions(),
+		}
+		if err != nil {
+			explanation.Error = err.Error()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(explanation); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func attributesFrom(spec authorizationv1.SubjectAccessReviewSpec) authorizer.AttributesRecord {
+	extra := map[string][]string{}
+	for k, v := range spec.Extra {
+		extra[k] = v
+	}
+	attrs := authorizer.AttributesRecord{
+		User: &user.DefaultInfo{
+			Name:   spec.User,
+			UID:    spec.UID,
+			Groups: spec.Groups,
+			Extra:  extra,
+		},
+	}
+
+	if spec.ResourceAttributes != nil {
+		attrs.Verb = spec.ResourceAttributes.Verb
+		attrs.Namespace = spec.ResourceAttributes.Namespace
+		attrs.APIGroup = spec.ResourceAttributes.Group
+		attrs.APIVersion = spec.ResourceAttributes.Version
+		attrs.Resource = spec.ResourceAttributes.Resource
+		attrs.Subresource = spec.ResourceAttributes.Subresource
+		attrs.Name = spec.ResourceAttributes.Name
+		attrs.ResourceRequest = true
+	}
+	if spec.NonResourceAttributes != nil {
+		attrs.Verb = spec.NonResourceAttributes.Verb
+		attrs.Path = spec.NonResourceAttributes.Path
+	}
+
+	return attrs
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/authorization/union"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestExplainHandler(t *testing.T) {
+	denyAll := authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+		return authorizer.DecisionNoOpinion, "no binding", nil
+	})
+	allowAlice := authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+		cluster := genericapirequest.ClusterFrom(ctx)
+		if attr.GetUser().GetName() == "alice" && cluster != nil && cluster.Name.String() == "root:org" {
+			return authorizer.DecisionAllow, "bound to admin", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+	content := authorizer.AuthorizerFunc(func(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+		return union.New(NewTracingAuthorizer("Bootstrap", denyAll), NewTracingAuthorizer("Local", allowAlice)).Authorize(ctx, attr)
+	})
+	chain := union.New(NewTracingAuthorizer("AlwaysAllowGroups", denyAll), NewTracingAuthorizer("WorkspaceContent", content))
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               "alice",
+			ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Resource: "configmaps", Namespace: "default"},
+		},
+	}
+
+	tests := map[string]struct {
+		requester       user.Info
+		method          string
+		cluster         string
+		wantStatus      int
+		wantExplanation *AuthorizationExplanation
+	}{
+		"explains an allowed request": {
+			requester:  &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}},
+			method:     http.MethodPost,
+			cluster:    "root:org",
+			wantStatus: http.StatusOK,
+			wantExplanation: &AuthorizationExplanation{
+				Cluster:  "root:org",
+				Decision: "Allow",
+				Reason:   "bound to admin",
+				Chain: []AuthorizerDecision{
+					{Authorizer: "AlwaysAllowGroups", Depth: 0, Decision: "NoOpinion", Reason: "no binding"},
+					{Authorizer: "WorkspaceContent", Depth: 0, Decision: "Allow", Reason: "bound to admin"},
+					{Authorizer: "Bootstrap", Depth: 1, Decision: "NoOpinion", Reason: "no binding"},
+					{Authorizer: "Local", Depth: 1, Decision: "Allow", Reason: "bound to admin"},
+				},
+			},
+		},
+		"explains a request without opinion": {
+			requester:  &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}},
+			method:     http.MethodPost,
+			cluster:    "root:other",
+			wantStatus: http.StatusOK,
+			wantExplanation: &AuthorizationExplanation{
+				Cluster:  "root:other",
+				Decision: "NoOpinion",
+				Reason:   "no binding\nno binding",
+				Chain: []AuthorizerDecision{
+					{Authorizer: "AlwaysAllowGroups", Depth: 0, Decision: "NoOpinion", Reason: "no binding"},
+					{Authorizer: "WorkspaceContent", Depth: 0, Decision: "NoOpinion", Reason: "no binding"},
+					{Authorizer: "Bootstrap", Depth: 1, Decision: "NoOpinion", Reason: "no binding"},
+					{Authorizer: "Local", Depth: 1, Decision: "NoOpinion"},
+				},
+			},
+		},
+		"forbidden for non-admins": {
+			requester:  &user.DefaultInfo{Name: "alice", Groups: []string{"system:authenticated"}},
+			method:     http.MethodPost,
+			cluster:    "root:org",
+			wantStatus: http.StatusForbidden,
+		},
+		"cluster is required": {
+			requester:  &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}},
+			method:     http.MethodPost,
+			wantStatus: http.StatusBadRequest,
+		},
+		"only POST": {
+			requester:  &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}},
+			method:     http.MethodGet,
+			cluster:    "root:org",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			body, err := json.Marshal(review)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(tc.method, "/services/debug/authorization?cluster="+tc.cluster, bytes.NewReader(body))
+			req = req.WithContext(genericapirequest.WithUser(req.Context(), tc.requester))
+			rw := httptest.NewRecorder()
+			NewExplainHandler(chain).ServeHTTP(rw, req)
+
+			require.Equal(t, tc.wantStatus, rw.Code, rw.Body.String())
+			if tc.wantExplanation == nil {
+				return
+			}
+			var got AuthorizationExplanation
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &got))
+			require.Equal(t, *tc.wantExplanation, got)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package authorization
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/clusters"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1 "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+// NewImpersonationConstraintAuthorizer returns an authorizer that denies impersonation inside a
+// workspace which is not allowed by the impersonation constraints of its ClusterWorkspace, and
+// delegates everything else. The constraints live in the parent workspace, so they cannot be
+// bypassed by RBAC inside the workspace.
+func NewImpersonationConstraintAuthorizer(clusterWorkspaceLister tenancyv1.ClusterWorkspaceLister, delegate authorizer.Authorizer) authorizer.Authorizer {
+	return &impersonationConstraintAuthorizer{
+		clusterWorkspaceLister: clusterWorkspaceLister,
+		delegate:               delegate,
+	}
+}
+
+type impersonationConstraintAuthorizer struct {
+	clusterWorkspaceLister tenancyv1.ClusterWorkspaceLister
+
+	delegate authorizer.Authorizer
+}
+
+func (a *impersonationConstraintAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorized authorizer.Decision, reason string, err error) {
+	if attr.GetVerb() != "impersonate" || !attr.IsResourceRequest() || a.clusterWorkspaceLister == nil {
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return authorizer.DecisionNoOpinion, "", err
+	}
+	if cluster == nil || cluster.Name.Empty() {
+		return a.delegate.Authorize(ctx, attr)
+	}
+	parent, hasParent := cluster.Name.Parent()
+	if !hasParent {
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	ws, err := a.clusterWorkspaceLister.Get(clusters.ToClusterAwareKey(parent, cluster.Name.Base()))
+	if errors.IsNotFound(err) {
+		return a.delegate.Authorize(ctx, attr)
+	} else if err != nil {
+		return authorizer.DecisionNoOpinion, "", err
+	}
+	if ws.Spec.Impersonation == nil {
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	if !impersonationAllowed(ws.Spec.Impersonation.Rules, attr) {
+		return authorizer.DecisionDeny, fmt.Sprintf("impersonation of %s %q not permitted by the constraints of workspace %q", attr.GetResource(), attr.GetName(), cluster.Name), nil
+	}
+	return a.delegate.Authorize(ctx, attr)
+}
+
+// impersonationAllowed returns whether one of the rules matches the user of the attributes and the
+// impersonated user, service account or group. User extras and UIDs only qualify an impersonated
+// user, hence they are allowed to anybody matched as impersonating user by a rule.
+func impersonationAllowed(rules []v1alpha1.ImpersonationRule, attr authorizer.Attributes) bool {
+	for _, rule := range rules {
+		if !impersonatorMatches(rule, attr.GetUser()) {
+			continue
+		}
+		switch {
+		case attr.GetAPIGroup() == "" && attr.GetResource() == "users":
+			if matches(rule.ImpersonatedUsers, attr.GetName()) {
+				return true
+			}
+		case attr.GetAPIGroup() == "" && attr.GetResource() == "serviceaccounts":
+			if matches(rule.ImpersonatedUsers, authserviceaccount.MakeUsername(attr.GetNamespace(), attr.GetName())) {
+				return true
+			}
+		case attr.GetAPIGroup() == "" && attr.GetResource() == "groups":
+			if matches(rule.ImpersonatedGroups, attr.GetName()) {
+				return true
+			}
+		case attr.GetAPIGroup() == "authentication.k8s.io" && (attr.GetResource() == "userextras" || attr.GetResource() == "uids"):
+			return true
+		}
+	}
+	return false
+}
+
+func impersonatorMatches(rule v1alpha1.ImpersonationRule, u user.Info) bool {
+	if matches(rule.Users, u.GetName()) {
+		return true
+	}
+	for _, group := range u.GetGroups() {
+		if matches(rule.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(names []string, name string) bool {
+	allowed := sets.NewString(names...)
+	return allowed.Has("*") || allowed.Has(name)
+}
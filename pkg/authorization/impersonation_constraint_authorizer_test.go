@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+type allowAllAuthorizer struct{}
+
+func (allowAllAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	return authorizer.DecisionAllow, "", nil
+}
+
+func TestImpersonationConstraintAuthorizer(t *testing.T) {
+	ciRule := v1alpha1.ImpersonationRule{
+		Groups:             []string{"ci"},
+		ImpersonatedUsers:  []string{"system:serviceaccount:default:deployer"},
+		ImpersonatedGroups: []string{"deployers"},
+	}
+	adminRule := v1alpha1.ImpersonationRule{
+		Users:             []string{"alice"},
+		ImpersonatedUsers: []string{"*"},
+	}
+	ciUser := &user.DefaultInfo{Name: "bot", Groups: []string{"ci", "system:authenticated"}}
+
+	tests := map[string]struct {
+		constraints *v1alpha1.ImpersonationConstraints
+		user        user.Info
+		attr        authorizer.AttributesRecord
+		want        authorizer.Decision
+	}{
+		"no constraints": {
+			user: ciUser,
+			attr: authorizer.AttributesRecord{Verb: "impersonate", Resource: "users", Name: "bob", ResourceRequest: true},
+			want: authorizer.DecisionAllow,
+		},
+		"no rules": {
+			constraints: &v1alpha1.ImpersonationConstraints{},
+			user:        ciUser,
+			attr:        authorizer.AttributesRecord{Verb: "impersonate", Resource: "users", Name: "bob", ResourceRequest: true},
+			want:        authorizer.DecisionDeny,
+		},
+		"other verbs are not constrained": {
+			constraints: &v1alpha1.ImpersonationConstraints{},
+			user:        ciUser,
+			attr:        authorizer.AttributesRecord{Verb: "get", Resource: "users", Name: "bob", ResourceRequest: true},
+			want:        authorizer.DecisionAllow,
+		},
+		"service account allowed to a group": {
+			constraints: &v1alpha1.ImpersonationConstraints{Rules: []v1alpha1.ImpersonationRule{ciRule}},
+			user:        ciUser,
+			attr:        authorizer.AttributesRecord{Verb: "impersonate", Resource: "serviceaccounts", Namespace: "default", Name: "deployer", ResourceRequest: true},
+			want:        authorizer.DecisionAllow,
+		},
+		"other service account": {
+			constraints: &v1alpha1.ImpersonationConstraints{Rules: []v1alpha1.ImpersonationRule{ciRule}},
+			user:        ciUser,
+			attr:        authorizer.AttributesRecord{Verb: "impersonate", Resource: "serviceaccounts", Namespace: "kube-system", Name: "deployer", ResourceRequest: true},
+			want:        authorizer.DecisionDeny,
+		},
+		"group allowed to a group": {
+			constraints: &v1alpha1.ImpersonationConstraints{Rules: []v1alpha1.ImpersonationRule{ciRule}},
+			user:        ciUser,
+			attr:        authorizer.AttributesRecord{Verb: "impersonate", Resource: "groups", Name: "deployers", ResourceRequest: true},
+			want:        authorizer.DecisionAllow,
+		},
+		"other group": {
+			constraints: &v1alpha1.ImpersonationConstraints{Rules: []v1alpha1.ImpersonationRule{ciRule}},
+			user:        ciUser,
+			attr:        authorizer.AttributesRecord{Verb: "impersonate", Resource: "groups", Name: "system:masters", ResourceRequest: true},
+			want:        authorizer.DecisionDeny,
+		},
+		"user extras of a matching impersonator": {
+			constraints: &v1alpha1.ImpersonationConstraints{Rules: []v1alpha1.ImpersonationRule{ciRule}},
+			user:        ciUser,
+			attr:        authorizer.AttributesRecord{Verb: "impersonate", APIGroup: "authentication.k8s.io", Resource: "userextras", Subresource: "scopes", Name: "view", ResourceRequest: true},
+			want:        authorizer.DecisionAllow,
+		},
+		"user extras of another user": {
+			constraints: &v1alpha1.ImpersonationConstraints{Rules: []v1alpha1.ImpersonationRule{ciRule}},
+			user:        &user.DefaultInfo{Name: "bob"},
+			attr:        authorizer.AttributesRecord{Verb: "impersonate", APIGroup: "authentication.k8s.io", Resource: "userextras", Subresource: "scopes", Name: "view", ResourceRequest: true},
+			want:        authorizer.DecisionDeny,
+		},
+		"wildcard": {
+			constraints: &v1alpha1.ImpersonationConstraints{Rules: []v1alpha1.ImpersonationRule{ciRule, adminRule}},
+			user:        &user.DefaultInfo{Name: "alice"},
+			attr:        authorizer.AttributesRecord{Verb: "impersonate", Resource: "users", Name: "bob", ResourceRequest: true},
+			want:        authorizer.DecisionAllow,
+		},
+		"wildcard on users only": {
+			constraints: &v1alpha1.ImpersonationConstraints{Rules: []v1alpha1.ImpersonationRule{ciRule, adminRule}},
+			user:        &user.DefaultInfo{Name: "alice"},
+			attr:        authorizer.AttributesRecord{Verb: "impersonate", Resource: "groups", Name: "deployers", ResourceRequest: true},
+			want:        authorizer.DecisionDeny,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			factory := kcpinformers.NewSharedInformerFactory(kcpfake.NewSimpleClientset(), 0)
+			require.NoError(t, factory.Tenancy().V1alpha1().ClusterWorkspaces().Informer().GetIndexer().Add(&v1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"},
+				Spec:       v1alpha1.ClusterWorkspaceSpec{Impersonation: tt.constraints},
+			}))
+
+			attr := tt.attr
+			attr.User = tt.user
+			ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New("root:org:team")})
+			dec, _, err := NewImpersonationConstraintAuthorizer(factory.Tenancy().V1alpha1().ClusterWorkspaces().Lister(), allowAllAuthorizer{}).Authorize(ctx, attr)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, dec)
+		})
+	}
+}
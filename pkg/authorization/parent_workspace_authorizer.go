@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	clientgoinformers "k8s.io/client-go/informers"
+	"k8s.io/kubernetes/pkg/genericcontrolplane"
+	"k8s.io/kubernetes/plugin/pkg/auth/authorizer/rbac"
+
+	rbacwrapper "github.com/kcp-dev/kcp/pkg/virtual/framework/wrappers/rbac"
+)
+
+// WorkspacePathGroupPrefix is the prefix of the group that service accounts of a workspace are
+// projected into when authorized against the RBAC of its parent workspace.
+const WorkspacePathGroupPrefix = "system:workspace-path:"
+
+// WorkspacePathGroup returns the group that service accounts of the child workspaces of the
+// given workspace are members of in that workspace.
+func WorkspacePathGroup(clusterName logicalcluster.LogicalCluster) string {
+	return WorkspacePathGroupPrefix + clusterName.String()
+}
+
+// NewParentWorkspaceAuthorizer returns an authorizer that delegates the authorization of service
+// accounts to the parent of their workspace: a service account acting in its own workspace gets
+// the permissions bound in the parent workspace to the group system:workspace-path:<parent>.
+// This allows to grant permissions to the service accounts of all child workspaces, e.g. to bots
+// of an organization, with a single binding.
+func NewParentWorkspaceAuthorizer(versionedInformers clientgoinformers.SharedInformerFactory) authorizer.Authorizer {
+	return &parentWorkspaceAuthorizer{
+		versionedInformers: versionedInformers,
+	}
+}
+
+type parentWorkspaceAuthorizer struct {
+	// TODO: this will go away when scoping lands.
+	versionedInformers clientgoinformers.SharedInformerFactory
+}
+
+func (a *parentWorkspaceAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorized authorizer.Decision, reason string, err error) {
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil {
+		return authorizer.DecisionNoOpinion, "", err
+	}
+	if cluster == nil || cluster.Name.Empty() {
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+
+	// only service accounts acting in their own workspace are delegated
+	inOwnWorkspace := false
+	for _, sc := range attr.GetUser().GetExtra()[authserviceaccount.ClusterNameKey] {
+		if logicalcluster.New(sc) == cluster.Name {
+			inOwnWorkspace = true
+			break
+		}
+	}
+	if !inOwnWorkspace {
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+
+	parentClusterName, hasParent := cluster.Name.Parent()
+	if !hasParent {
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+
+	parentInformer := rbacwrapper.FilterInformers(parentClusterName, a.versionedInformers.Rbac().V1())
+	bootstrapInformer := rbacwrapper.FilterInformers(genericcontrolplane.LocalAdminCluster, a.versionedInformers.Rbac().V1())
+
+	mergedClusterRoleInformer := rbacwrapper.MergedClusterRoleInformer(parentInformer.ClusterRoles(), bootstrapInformer.ClusterRoles())
+
+	parentAuth := rbac.New(
+		&rbac.RoleGetter{Lister: parentInformer.Roles().Lister()},
+		&rbac.RoleBindingLister{Lister: parentInformer.RoleBindings().Lister()},
+		&rbac.ClusterRoleGetter{Lister: mergedClusterRoleInformer.Lister()},
+		&rbac.ClusterRoleBindingLister{Lister: parentInformer.ClusterRoleBindings().Lister()},
+	)
+
+	// The service account is only known in the parent by the projected group. Its name might
+	// match a service account of the parent, hence it is dropped. Namespaces of the parent are
+	// unrelated to those of the child, hence the namespace is dropped too, such that only
+	// cluster role bindings apply.
+	dec, reason, err := parentAuth.Authorize(ctx, authorizer.AttributesRecord{
+		User: &user.DefaultInfo{
+			Groups: []string{WorkspacePathGroup(parentClusterName)},
+		},
+		Verb:            attr.GetVerb(),
+		APIGroup:        attr.GetAPIGroup(),
+		APIVersion:      attr.GetAPIVersion(),
+		Resource:        attr.GetResource(),
+		Subresource:     attr.GetSubresource(),
+		Name:            attr.GetName(),
+		ResourceRequest: attr.IsResourceRequest(),
+		Path:            attr.GetPath(),
+	})
+	if err != nil {
+		return authorizer.DecisionNoOpinion, reason, err
+	}
+	if dec == authorizer.DecisionAllow {
+		return authorizer.DecisionAllow, fmt.Sprintf("allowed by parent workspace %q: %s", parentClusterName, reason), nil
+	}
+	return authorizer.DecisionNoOpinion, reason, nil
+}
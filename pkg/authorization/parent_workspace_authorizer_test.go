@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParentWorkspaceAuthorizer(t *testing.T) {
+	reader := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "reader", ClusterName: "root:org"},
+		Rules: []rbacv1.PolicyRule{
+			{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+		},
+	}
+	bindingTo := func(subject rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+		return &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "bots", ClusterName: "root:org"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "reader"},
+			Subjects:   []rbacv1.Subject{subject},
+		}
+	}
+	groupBinding := bindingTo(rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: "system:workspace-path:root:org"})
+	serviceAccountBinding := bindingTo(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "default", Name: "bot"})
+
+	serviceAccount := func(cluster string) user.Info {
+		return &user.DefaultInfo{
+			Name:   "system:serviceaccount:default:bot",
+			Groups: []string{"system:serviceaccounts", "system:authenticated"},
+			Extra:  map[string][]string{authserviceaccount.ClusterNameKey: {cluster}},
+		}
+	}
+
+	tests := map[string]struct {
+		cluster  string
+		user     user.Info
+		verb     string
+		bindings []*rbacv1.ClusterRoleBinding
+		want     authorizer.Decision
+	}{
+		"service account in its workspace gets the permissions bound to the group in the parent": {
+			cluster:  "root:org:team",
+			user:     serviceAccount("root:org:team"),
+			verb:     "get",
+			bindings: []*rbacv1.ClusterRoleBinding{groupBinding},
+			want:     authorizer.DecisionAllow,
+		},
+		"permissions not bound in the parent": {
+			cluster:  "root:org:team",
+			user:     serviceAccount("root:org:team"),
+			verb:     "delete",
+			bindings: []*rbacv1.ClusterRoleBinding{groupBinding},
+			want:     authorizer.DecisionNoOpinion,
+		},
+		"no binding in the parent": {
+			cluster: "root:org:team",
+			user:    serviceAccount("root:org:team"),
+			verb:    "get",
+			want:    authorizer.DecisionNoOpinion,
+		},
+		"service account in another workspace": {
+			cluster:  "root:org:team",
+			user:     serviceAccount("root:org:other"),
+			verb:     "get",
+			bindings: []*rbacv1.ClusterRoleBinding{groupBinding},
+			want:     authorizer.DecisionNoOpinion,
+		},
+		"binding to a service account of the same name in the parent": {
+			cluster:  "root:org:team",
+			user:     serviceAccount("root:org:team"),
+			verb:     "get",
+			bindings: []*rbacv1.ClusterRoleBinding{serviceAccountBinding},
+			want:     authorizer.DecisionNoOpinion,
+		},
+		"users are not delegated": {
+			cluster:  "root:org:team",
+			user:     &user.DefaultInfo{Name: "user", Groups: []string{"system:workspace-path:root:org"}},
+			verb:     "get",
+			bindings: []*rbacv1.ClusterRoleBinding{groupBinding},
+			want:     authorizer.DecisionNoOpinion,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+			require.NoError(t, factory.Rbac().V1().ClusterRoles().Informer().GetIndexer().Add(reader))
+			for _, binding := range tt.bindings {
+				require.NoError(t, factory.Rbac().V1().ClusterRoleBindings().Informer().GetIndexer().Add(binding))
+			}
+
+			ctx := genericapirequest.WithCluster(context.Background(), genericapirequest.Cluster{Name: logicalcluster.New(tt.cluster)})
+			dec, _, err := NewParentWorkspaceAuthorizer(factory).Authorize(ctx, authorizer.AttributesRecord{
+				User:            tt.user,
+				Verb:            tt.verb,
+				Namespace:       "default",
+				Resource:        "configmaps",
+				Name:            "config",
+				ResourceRequest: true,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, dec)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+)
+
+// EmergencyAccessAuditAnnotationKey is the audit annotation holding the emergency access group
+// through which a request to a system workspace was allowed.
+const EmergencyAccessAuditAnnotationKey = "authorization.kcp.dev/emergency-access-group"
+
+var systemCluster = logicalcluster.New("system")
+
+// NewSystemWorkspaceAuthorizer returns an authorizer that denies requests to system: workspaces,
+// which only system:masters (excluded from authorization) may access. Members of the emergency
+// access groups are allowed in the given known system workspaces, which is logged and recorded in
+// the audit events of the requests. Requests to other workspaces are delegated.
+func NewSystemWorkspaceAuthorizer(systemWorkspaces []logicalcluster.LogicalCluster, emergencyAccessGroups []string, delegate authorizer.Authorizer) authorizer.Authorizer {
+	known := sets.NewString()
+	for _, clusterName := range systemWorkspaces {
+		known.Insert(clusterName.String())
+	}
+	return &systemWorkspaceAuthorizer{
+		systemWorkspaces:      known,
+		emergencyAccessGroups: sets.NewString(emergencyAccessGroups...),
+		delegate:              delegate,
+	}
+}
+
+type systemWorkspaceAuthorizer struct {
+	systemWorkspaces      sets.String
+	emergencyAccessGroups sets.String
+
+	delegate authorizer.Authorizer
+}
+
+func (a *systemWorkspaceAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorized authorizer.Decision, reason string, err error) {
+	cluster, err := genericapirequest.ValidClusterFrom(ctx)
+	if err != nil || cluster == nil || cluster.Name.Empty() || cluster.Wildcard || !cluster.Name.HasPrefix(systemCluster) {
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	if !a.systemWorkspaces.Has(cluster.Name.String()) {
+		return authorizer.DecisionDeny, fmt.Sprintf("%q is not a known system workspace", cluster.Name), nil
+	}
+
+	groups := a.emergencyAccessGroups.Intersection(sets.NewString(attr.GetUser().GetGroups()...))
+	if groups.Len() == 0 {
+		return authorizer.DecisionDeny, fmt.Sprintf("%q system workspace access not permitted", cluster.Name), nil
+	}
+
+	group := groups.List()[0]
+	klog.Warningf("Emergency access of user %q through group %q to system workspace %q: %s", attr.GetUser().GetName(), group, cluster.Name, describeAttributes(attr))
+	audit.AddAuditAnnotation(ctx, EmergencyAccessAuditAnnotationKey, group)
+	return authorizer.DecisionAllow, fmt.Sprintf("emergency access through group %q", group), nil
+}
+
+func describeAttributes(attr authorizer.Attributes) string {
+	if !attr.IsResourceRequest() {
+		return fmt.Sprintf("%s %s", attr.GetVerb(), attr.GetPath())
+	}
+	resource := attr.GetResource()
+	if attr.GetAPIGroup() != "" {
+		resource += "." + attr.GetAPIGroup()
+	}
+	if attr.GetSubresource() != "" {
+		resource += "/" + attr.GetSubresource()
+	}
+	return fmt.Sprintf("%s %s %q in namespace %q", attr.GetVerb(), resource, attr.GetName(), attr.GetNamespace())
+}
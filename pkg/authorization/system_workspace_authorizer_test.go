@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestSystemWorkspaceAuthorizer(t *testing.T) {
+	tests := map[string]struct {
+		cluster        genericapirequest.Cluster
+		groups         []string
+		want           authorizer.Decision
+		wantAnnotation string
+	}{
+		"other workspaces are delegated": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.New("root:org")},
+			want:    authorizer.DecisionAllow,
+		},
+		"wildcard requests are delegated": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			want:    authorizer.DecisionAllow,
+		},
+		"known system workspace": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.New("system:bound-crds")},
+			groups:  []string{"system:authenticated"},
+			want:    authorizer.DecisionDeny,
+		},
+		"unknown system workspace": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.New("system:foo")},
+			groups:  []string{"system:authenticated"},
+			want:    authorizer.DecisionDeny,
+		},
+		"emergency access to a known system workspace": {
+			cluster:        genericapirequest.Cluster{Name: logicalcluster.New("system:bound-crds")},
+			groups:         []string{"system:authenticated", "break-glass"},
+			want:           authorizer.DecisionAllow,
+			wantAnnotation: "break-glass",
+		},
+		"emergency access to an unknown system workspace": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.New("system:foo")},
+			groups:  []string{"break-glass"},
+			want:    authorizer.DecisionDeny,
+		},
+		"emergency access group outside of system workspaces": {
+			cluster: genericapirequest.Cluster{Name: logicalcluster.New("root:org")},
+			groups:  []string{"break-glass"},
+			want:    authorizer.DecisionAllow,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ev := &auditinternal.Event{Level: auditinternal.LevelMetadata}
+			ctx := audit.WithAuditContext(context.Background(), &audit.AuditContext{Event: ev})
+			ctx = genericapirequest.WithCluster(ctx, tt.cluster)
+			attr := authorizer.AttributesRecord{
+				User:            &user.DefaultInfo{Name: "alice", Groups: tt.groups},
+				Verb:            "get",
+				Resource:        "configmaps",
+				Namespace:       "default",
+				Name:            "foo",
+				ResourceRequest: true,
+			}
+
+			a := NewSystemWorkspaceAuthorizer([]logicalcluster.LogicalCluster{logicalcluster.New("system:bound-crds")}, []string{"break-glass"}, allowAllAuthorizer{})
+			dec, _, err := a.Authorize(ctx, attr)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, dec)
+			require.Equal(t, tt.wantAnnotation, ev.Annotations[EmergencyAccessAuditAnnotationKey])
+		})
+	}
+}
@@ -34,6 +34,7 @@ type ApisV1alpha1Interface interface {
 	APIBindingsGetter
 	APIExportsGetter
 	APIResourceSchemasGetter
+	MaintenanceTasksGetter
 }
 
 // ApisV1alpha1Client is used to interact with features provided by the apis.kcp.dev group.
@@ -54,6 +55,10 @@ func (c *ApisV1alpha1Client) APIResourceSchemas() APIResourceSchemaInterface {
 	return newAPIResourceSchemas(c)
 }
 
+func (c *ApisV1alpha1Client) MaintenanceTasks() MaintenanceTaskInterface {
+	return newMaintenanceTasks(c)
+}
+
 // NewForConfig creates a new ApisV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
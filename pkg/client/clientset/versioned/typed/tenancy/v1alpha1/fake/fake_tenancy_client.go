@@ -29,10 +29,18 @@ type FakeTenancyV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeTenancyV1alpha1) AccessRequests() v1alpha1.AccessRequestInterface {
+	return &FakeAccessRequests{c}
+}
+
 func (c *FakeTenancyV1alpha1) ClusterWorkspaces() v1alpha1.ClusterWorkspaceInterface {
 	return &FakeClusterWorkspaces{c}
 }
 
+func (c *FakeTenancyV1alpha1) ClusterWorkspaceQuotas() v1alpha1.ClusterWorkspaceQuotaInterface {
+	return &FakeClusterWorkspaceQuotas{c}
+}
+
 func (c *FakeTenancyV1alpha1) ClusterWorkspaceShards() v1alpha1.ClusterWorkspaceShardInterface {
 	return &FakeClusterWorkspaceShards{c}
 }
@@ -41,6 +49,26 @@ func (c *FakeTenancyV1alpha1) ClusterWorkspaceTypes() v1alpha1.ClusterWorkspaceT
 	return &FakeClusterWorkspaceTypes{c}
 }
 
+func (c *FakeTenancyV1alpha1) NameReservations() v1alpha1.NameReservationInterface {
+	return &FakeNameReservations{c}
+}
+
+func (c *FakeTenancyV1alpha1) ShardUpgradePlans() v1alpha1.ShardUpgradePlanInterface {
+	return &FakeShardUpgradePlans{c}
+}
+
+func (c *FakeTenancyV1alpha1) WorkspaceClones() v1alpha1.WorkspaceCloneInterface {
+	return &FakeWorkspaceClones{c}
+}
+
+func (c *FakeTenancyV1alpha1) WorkspaceMigrations() v1alpha1.WorkspaceMigrationInterface {
+	return &FakeWorkspaceMigrations{c}
+}
+
+func (c *FakeTenancyV1alpha1) WorkspaceRequestSets() v1alpha1.WorkspaceRequestSetInterface {
+	return &FakeWorkspaceRequestSets{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeTenancyV1alpha1) RESTClient() rest.Interface {
@@ -18,8 +18,22 @@ limitations under the License.
 
 package v1alpha1
 
+type AccessRequestExpansion interface{}
+
 type ClusterWorkspaceExpansion interface{}
 
+type ClusterWorkspaceQuotaExpansion interface{}
+
 type ClusterWorkspaceShardExpansion interface{}
 
 type ClusterWorkspaceTypeExpansion interface{}
+
+type NameReservationExpansion interface{}
+
+type ShardUpgradePlanExpansion interface{}
+
+type WorkspaceCloneExpansion interface{}
+
+type WorkspaceMigrationExpansion interface{}
+
+type WorkspaceRequestSetExpansion interface{}
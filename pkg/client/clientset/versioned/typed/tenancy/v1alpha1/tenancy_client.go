@@ -31,9 +31,16 @@ import (
 
 type TenancyV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	AccessRequestsGetter
 	ClusterWorkspacesGetter
+	ClusterWorkspaceQuotasGetter
 	ClusterWorkspaceShardsGetter
 	ClusterWorkspaceTypesGetter
+	NameReservationsGetter
+	ShardUpgradePlansGetter
+	WorkspaceClonesGetter
+	WorkspaceMigrationsGetter
+	WorkspaceRequestSetsGetter
 }
 
 // TenancyV1alpha1Client is used to interact with features provided by the tenancy.kcp.dev group.
@@ -42,10 +49,18 @@ type TenancyV1alpha1Client struct {
 	cluster    logicalcluster.LogicalCluster
 }
 
+func (c *TenancyV1alpha1Client) AccessRequests() AccessRequestInterface {
+	return newAccessRequests(c)
+}
+
 func (c *TenancyV1alpha1Client) ClusterWorkspaces() ClusterWorkspaceInterface {
 	return newClusterWorkspaces(c)
 }
 
+func (c *TenancyV1alpha1Client) ClusterWorkspaceQuotas() ClusterWorkspaceQuotaInterface {
+	return newClusterWorkspaceQuotas(c)
+}
+
 func (c *TenancyV1alpha1Client) ClusterWorkspaceShards() ClusterWorkspaceShardInterface {
 	return newClusterWorkspaceShards(c)
 }
@@ -54,6 +69,26 @@ func (c *TenancyV1alpha1Client) ClusterWorkspaceTypes() ClusterWorkspaceTypeInte
 	return newClusterWorkspaceTypes(c)
 }
 
+func (c *TenancyV1alpha1Client) NameReservations() NameReservationInterface {
+	return newNameReservations(c)
+}
+
+func (c *TenancyV1alpha1Client) ShardUpgradePlans() ShardUpgradePlanInterface {
+	return newShardUpgradePlans(c)
+}
+
+func (c *TenancyV1alpha1Client) WorkspaceClones() WorkspaceCloneInterface {
+	return newWorkspaceClones(c)
+}
+
+func (c *TenancyV1alpha1Client) WorkspaceMigrations() WorkspaceMigrationInterface {
+	return newWorkspaceMigrations(c)
+}
+
+func (c *TenancyV1alpha1Client) WorkspaceRequestSets() WorkspaceRequestSetInterface {
+	return newWorkspaceRequestSets(c)
+}
+
 // NewForConfig creates a new TenancyV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
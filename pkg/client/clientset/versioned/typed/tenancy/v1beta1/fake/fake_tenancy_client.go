@@ -29,6 +29,14 @@ type FakeTenancyV1beta1 struct {
 	*testing.Fake
 }
 
+func (c *FakeTenancyV1beta1) ClusterWorkspaces() v1beta1.ClusterWorkspaceInterface {
+	return &FakeClusterWorkspaces{c}
+}
+
+func (c *FakeTenancyV1beta1) ClusterWorkspaceShards() v1beta1.ClusterWorkspaceShardInterface {
+	return &FakeClusterWorkspaceShards{c}
+}
+
 func (c *FakeTenancyV1beta1) Workspaces() v1beta1.WorkspaceInterface {
 	return &FakeWorkspaces{c}
 }
@@ -18,4 +18,8 @@ limitations under the License.
 
 package v1beta1
 
+type ClusterWorkspaceExpansion interface{}
+
+type ClusterWorkspaceShardExpansion interface{}
+
 type WorkspaceExpansion interface{}
@@ -31,6 +31,8 @@ import (
 
 type TenancyV1beta1Interface interface {
 	RESTClient() rest.Interface
+	ClusterWorkspacesGetter
+	ClusterWorkspaceShardsGetter
 	WorkspacesGetter
 }
 
@@ -40,6 +42,14 @@ type TenancyV1beta1Client struct {
 	cluster    logicalcluster.LogicalCluster
 }
 
+func (c *TenancyV1beta1Client) ClusterWorkspaces() ClusterWorkspaceInterface {
+	return newClusterWorkspaces(c)
+}
+
+func (c *TenancyV1beta1Client) ClusterWorkspaceShards() ClusterWorkspaceShardInterface {
+	return newClusterWorkspaceShards(c)
+}
+
 func (c *TenancyV1beta1Client) Workspaces() WorkspaceInterface {
 	return newWorkspaces(c)
 }
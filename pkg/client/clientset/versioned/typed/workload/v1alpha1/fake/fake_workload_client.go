@@ -29,10 +29,22 @@ type FakeWorkloadV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeWorkloadV1alpha1) Placements() v1alpha1.PlacementInterface {
+	return &FakePlacements{c}
+}
+
+func (c *FakeWorkloadV1alpha1) PlacementDecisions() v1alpha1.PlacementDecisionInterface {
+	return &FakePlacementDecisions{c}
+}
+
 func (c *FakeWorkloadV1alpha1) WorkloadClusters() v1alpha1.WorkloadClusterInterface {
 	return &FakeWorkloadClusters{c}
 }
 
+func (c *FakeWorkloadV1alpha1) WorkloadClusterPools() v1alpha1.WorkloadClusterPoolInterface {
+	return &FakeWorkloadClusterPools{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeWorkloadV1alpha1) RESTClient() rest.Interface {
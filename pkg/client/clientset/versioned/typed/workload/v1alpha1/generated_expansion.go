@@ -18,4 +18,10 @@ limitations under the License.
 
 package v1alpha1
 
+type PlacementExpansion interface{}
+
+type PlacementDecisionExpansion interface{}
+
 type WorkloadClusterExpansion interface{}
+
+type WorkloadClusterPoolExpansion interface{}
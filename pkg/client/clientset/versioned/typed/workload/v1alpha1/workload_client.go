@@ -31,7 +31,10 @@ import (
 
 type WorkloadV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	PlacementsGetter
+	PlacementDecisionsGetter
 	WorkloadClustersGetter
+	WorkloadClusterPoolsGetter
 }
 
 // WorkloadV1alpha1Client is used to interact with features provided by the workload.kcp.dev group.
@@ -40,10 +43,22 @@ type WorkloadV1alpha1Client struct {
 	cluster    logicalcluster.LogicalCluster
 }
 
+func (c *WorkloadV1alpha1Client) Placements() PlacementInterface {
+	return newPlacements(c)
+}
+
+func (c *WorkloadV1alpha1Client) PlacementDecisions() PlacementDecisionInterface {
+	return newPlacementDecisions(c)
+}
+
 func (c *WorkloadV1alpha1Client) WorkloadClusters() WorkloadClusterInterface {
 	return newWorkloadClusters(c)
 }
 
+func (c *WorkloadV1alpha1Client) WorkloadClusterPools() WorkloadClusterPoolInterface {
+	return newWorkloadClusterPools(c)
+}
+
 // NewForConfig creates a new WorkloadV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
@@ -30,6 +30,8 @@ type Interface interface {
 	APIExports() APIExportInformer
 	// APIResourceSchemas returns a APIResourceSchemaInformer.
 	APIResourceSchemas() APIResourceSchemaInformer
+	// MaintenanceTasks returns a MaintenanceTaskInformer.
+	MaintenanceTasks() MaintenanceTaskInformer
 }
 
 type version struct {
@@ -57,3 +59,8 @@ func (v *version) APIExports() APIExportInformer {
 func (v *version) APIResourceSchemas() APIResourceSchemaInformer {
 	return &aPIResourceSchemaInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// MaintenanceTasks returns a MaintenanceTaskInformer.
+func (v *version) MaintenanceTasks() MaintenanceTaskInformer {
+	return &maintenanceTaskInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
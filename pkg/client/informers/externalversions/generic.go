@@ -70,22 +70,48 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apis().V1alpha1().APIExports().Informer()}, nil
 	case apisv1alpha1.SchemeGroupVersion.WithResource("apiresourceschemas"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apis().V1alpha1().APIResourceSchemas().Informer()}, nil
+	case apisv1alpha1.SchemeGroupVersion.WithResource("maintenancetasks"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Apis().V1alpha1().MaintenanceTasks().Informer()}, nil
 
 		// Group=tenancy.kcp.dev, Version=v1alpha1
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("accessrequests"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().AccessRequests().Informer()}, nil
 	case tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ClusterWorkspaces().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspacequotas"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ClusterWorkspaceQuotas().Informer()}, nil
 	case tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaceshards"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ClusterWorkspaceShards().Informer()}, nil
 	case tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspacetypes"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ClusterWorkspaceTypes().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("namereservations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().NameReservations().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("shardupgradeplans"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().ShardUpgradePlans().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("workspaceclones"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().WorkspaceClones().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("workspacemigrations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().WorkspaceMigrations().Informer()}, nil
+	case tenancyv1alpha1.SchemeGroupVersion.WithResource("workspacerequestsets"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1alpha1().WorkspaceRequestSets().Informer()}, nil
 
 		// Group=tenancy.kcp.dev, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("clusterworkspaces"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1beta1().ClusterWorkspaces().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("clusterworkspaceshards"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1beta1().ClusterWorkspaceShards().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("workspaces"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Tenancy().V1beta1().Workspaces().Informer()}, nil
 
 		// Group=workload.kcp.dev, Version=v1alpha1
+	case workloadv1alpha1.SchemeGroupVersion.WithResource("placements"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Workload().V1alpha1().Placements().Informer()}, nil
+	case workloadv1alpha1.SchemeGroupVersion.WithResource("placementdecisions"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Workload().V1alpha1().PlacementDecisions().Informer()}, nil
 	case workloadv1alpha1.SchemeGroupVersion.WithResource("workloadclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Workload().V1alpha1().WorkloadClusters().Informer()}, nil
+	case workloadv1alpha1.SchemeGroupVersion.WithResource("workloadclusterpools"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Workload().V1alpha1().WorkloadClusterPools().Informer()}, nil
 
 	}
 
@@ -24,12 +24,26 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// AccessRequests returns a AccessRequestInformer.
+	AccessRequests() AccessRequestInformer
 	// ClusterWorkspaces returns a ClusterWorkspaceInformer.
 	ClusterWorkspaces() ClusterWorkspaceInformer
+	// ClusterWorkspaceQuotas returns a ClusterWorkspaceQuotaInformer.
+	ClusterWorkspaceQuotas() ClusterWorkspaceQuotaInformer
 	// ClusterWorkspaceShards returns a ClusterWorkspaceShardInformer.
 	ClusterWorkspaceShards() ClusterWorkspaceShardInformer
 	// ClusterWorkspaceTypes returns a ClusterWorkspaceTypeInformer.
 	ClusterWorkspaceTypes() ClusterWorkspaceTypeInformer
+	// NameReservations returns a NameReservationInformer.
+	NameReservations() NameReservationInformer
+	// ShardUpgradePlans returns a ShardUpgradePlanInformer.
+	ShardUpgradePlans() ShardUpgradePlanInformer
+	// WorkspaceClones returns a WorkspaceCloneInformer.
+	WorkspaceClones() WorkspaceCloneInformer
+	// WorkspaceMigrations returns a WorkspaceMigrationInformer.
+	WorkspaceMigrations() WorkspaceMigrationInformer
+	// WorkspaceRequestSets returns a WorkspaceRequestSetInformer.
+	WorkspaceRequestSets() WorkspaceRequestSetInformer
 }
 
 type version struct {
@@ -43,11 +57,21 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// AccessRequests returns a AccessRequestInformer.
+func (v *version) AccessRequests() AccessRequestInformer {
+	return &accessRequestInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // ClusterWorkspaces returns a ClusterWorkspaceInformer.
 func (v *version) ClusterWorkspaces() ClusterWorkspaceInformer {
 	return &clusterWorkspaceInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// ClusterWorkspaceQuotas returns a ClusterWorkspaceQuotaInformer.
+func (v *version) ClusterWorkspaceQuotas() ClusterWorkspaceQuotaInformer {
+	return &clusterWorkspaceQuotaInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // ClusterWorkspaceShards returns a ClusterWorkspaceShardInformer.
 func (v *version) ClusterWorkspaceShards() ClusterWorkspaceShardInformer {
 	return &clusterWorkspaceShardInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -57,3 +81,28 @@ func (v *version) ClusterWorkspaceShards() ClusterWorkspaceShardInformer {
 func (v *version) ClusterWorkspaceTypes() ClusterWorkspaceTypeInformer {
 	return &clusterWorkspaceTypeInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// NameReservations returns a NameReservationInformer.
+func (v *version) NameReservations() NameReservationInformer {
+	return &nameReservationInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// ShardUpgradePlans returns a ShardUpgradePlanInformer.
+func (v *version) ShardUpgradePlans() ShardUpgradePlanInformer {
+	return &shardUpgradePlanInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// WorkspaceClones returns a WorkspaceCloneInformer.
+func (v *version) WorkspaceClones() WorkspaceCloneInformer {
+	return &workspaceCloneInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// WorkspaceMigrations returns a WorkspaceMigrationInformer.
+func (v *version) WorkspaceMigrations() WorkspaceMigrationInformer {
+	return &workspaceMigrationInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// WorkspaceRequestSets returns a WorkspaceRequestSetInformer.
+func (v *version) WorkspaceRequestSets() WorkspaceRequestSetInformer {
+	return &workspaceRequestSetInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
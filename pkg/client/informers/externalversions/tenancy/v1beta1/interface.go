@@ -24,6 +24,10 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ClusterWorkspaces returns a ClusterWorkspaceInformer.
+	ClusterWorkspaces() ClusterWorkspaceInformer
+	// ClusterWorkspaceShards returns a ClusterWorkspaceShardInformer.
+	ClusterWorkspaceShards() ClusterWorkspaceShardInformer
 	// Workspaces returns a WorkspaceInformer.
 	Workspaces() WorkspaceInformer
 }
@@ -39,6 +43,16 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ClusterWorkspaces returns a ClusterWorkspaceInformer.
+func (v *version) ClusterWorkspaces() ClusterWorkspaceInformer {
+	return &clusterWorkspaceInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// ClusterWorkspaceShards returns a ClusterWorkspaceShardInformer.
+func (v *version) ClusterWorkspaceShards() ClusterWorkspaceShardInformer {
+	return &clusterWorkspaceShardInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // Workspaces returns a WorkspaceInformer.
 func (v *version) Workspaces() WorkspaceInformer {
 	return &workspaceInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -24,8 +24,14 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// Placements returns a PlacementInformer.
+	Placements() PlacementInformer
+	// PlacementDecisions returns a PlacementDecisionInformer.
+	PlacementDecisions() PlacementDecisionInformer
 	// WorkloadClusters returns a WorkloadClusterInformer.
 	WorkloadClusters() WorkloadClusterInformer
+	// WorkloadClusterPools returns a WorkloadClusterPoolInformer.
+	WorkloadClusterPools() WorkloadClusterPoolInformer
 }
 
 type version struct {
@@ -39,7 +45,22 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// Placements returns a PlacementInformer.
+func (v *version) Placements() PlacementInformer {
+	return &placementInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// PlacementDecisions returns a PlacementDecisionInformer.
+func (v *version) PlacementDecisions() PlacementDecisionInformer {
+	return &placementDecisionInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // WorkloadClusters returns a WorkloadClusterInformer.
 func (v *version) WorkloadClusters() WorkloadClusterInformer {
 	return &workloadClusterInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// WorkloadClusterPools returns a WorkloadClusterPoolInformer.
+func (v *version) WorkloadClusterPools() WorkloadClusterPoolInformer {
+	return &workloadClusterPoolInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -18,10 +18,18 @@ limitations under the License.
 
 package v1alpha1
 
+// AccessRequestListerExpansion allows custom methods to be added to
+// AccessRequestLister.
+type AccessRequestListerExpansion interface{}
+
 // ClusterWorkspaceListerExpansion allows custom methods to be added to
 // ClusterWorkspaceLister.
 type ClusterWorkspaceListerExpansion interface{}
 
+// ClusterWorkspaceQuotaListerExpansion allows custom methods to be added to
+// ClusterWorkspaceQuotaLister.
+type ClusterWorkspaceQuotaListerExpansion interface{}
+
 // ClusterWorkspaceShardListerExpansion allows custom methods to be added to
 // ClusterWorkspaceShardLister.
 type ClusterWorkspaceShardListerExpansion interface{}
@@ -29,3 +37,23 @@ type ClusterWorkspaceShardListerExpansion interface{}
 // ClusterWorkspaceTypeListerExpansion allows custom methods to be added to
 // ClusterWorkspaceTypeLister.
 type ClusterWorkspaceTypeListerExpansion interface{}
+
+// NameReservationListerExpansion allows custom methods to be added to
+// NameReservationLister.
+type NameReservationListerExpansion interface{}
+
+// ShardUpgradePlanListerExpansion allows custom methods to be added to
+// ShardUpgradePlanLister.
+type ShardUpgradePlanListerExpansion interface{}
+
+// WorkspaceCloneListerExpansion allows custom methods to be added to
+// WorkspaceCloneLister.
+type WorkspaceCloneListerExpansion interface{}
+
+// WorkspaceMigrationListerExpansion allows custom methods to be added to
+// WorkspaceMigrationLister.
+type WorkspaceMigrationListerExpansion interface{}
+
+// WorkspaceRequestSetListerExpansion allows custom methods to be added to
+// WorkspaceRequestSetLister.
+type WorkspaceRequestSetListerExpansion interface{}
@@ -18,6 +18,14 @@ limitations under the License.
 
 package v1beta1
 
+// ClusterWorkspaceListerExpansion allows custom methods to be added to
+// ClusterWorkspaceLister.
+type ClusterWorkspaceListerExpansion interface{}
+
+// ClusterWorkspaceShardListerExpansion allows custom methods to be added to
+// ClusterWorkspaceShardLister.
+type ClusterWorkspaceShardListerExpansion interface{}
+
 // WorkspaceListerExpansion allows custom methods to be added to
 // WorkspaceLister.
 type WorkspaceListerExpansion interface{}
@@ -18,6 +18,18 @@ limitations under the License.
 
 package v1alpha1
 
+// PlacementListerExpansion allows custom methods to be added to
+// PlacementLister.
+type PlacementListerExpansion interface{}
+
+// PlacementDecisionListerExpansion allows custom methods to be added to
+// PlacementDecisionLister.
+type PlacementDecisionListerExpansion interface{}
+
 // WorkloadClusterListerExpansion allows custom methods to be added to
 // WorkloadClusterLister.
 type WorkloadClusterListerExpansion interface{}
+
+// WorkloadClusterPoolListerExpansion allows custom methods to be added to
+// WorkloadClusterPoolLister.
+type WorkloadClusterPoolListerExpansion interface{}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/access/plugin"
+)
+
+var (
+	accessReportExample = `
+	# report who can do what in the current workspace
+	%[1]s access report
+
+	# report who can do what in the child workspace my-team of the current workspace
+	%[1]s access report my-team
+
+	# report who can do what in an absolute workspace
+	%[1]s access report root:my-org:my-team
+`
+)
+
+// NewCmdAccess provides a cobra command wrapping the access sub-commands.
+func NewCmdAccess(streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "access",
+		Short: "Reviews the access to workspaces",
+	}
+	cmd.AddCommand(newCmdAccessReport(streams))
+	return cmd
+}
+
+func newCmdAccessReport(streams genericclioptions.IOStreams) *cobra.Command {
+	configFlags := genericclioptions.NewConfigFlags(false)
+
+	cmd := &cobra.Command{
+		Use:          "report [<workspace>]",
+		Short:        "Prints who can do what in a workspace, for security reviews",
+		Example:      fmt.Sprintf(accessReportExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return c.Help()
+			}
+			config, err := configFlags.ToRESTConfig()
+			if err != nil {
+				return err
+			}
+			server, workspace, err := plugin.SplitClusterURL(config.Host)
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				if args[0] == "root" || strings.HasPrefix(args[0], "root:") || strings.HasPrefix(args[0], "system:") {
+					workspace = logicalcluster.New(args[0])
+				} else {
+					workspace = workspace.Join(args[0])
+				}
+			}
+
+			config = rest.CopyConfig(config)
+			config.Host = server.String()
+			client, err := kubernetes.NewClusterForConfig(config)
+			if err != nil {
+				return err
+			}
+			return plugin.AccessReport(c.Context(), streams.Out, client, workspace)
+		},
+	}
+	configFlags.AddFlags(cmd.Flags())
+
+	return cmd
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubernetes/pkg/genericcontrolplane"
+
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/authorization"
+)
+
+// grant is a subject bound to a role, in a namespace or cluster-wide.
+type grant struct {
+	subject string
+	scope   string
+	via     string
+	rules   []rbacv1.PolicyRule
+}
+
+// AccessReport prints who can do what in the given workspace: who has access to it through the
+// clusterworkspaces/content subresource in the parent workspace, the permissions granted by the
+// RBAC of the workspace itself, those delegated to its service accounts by the parent workspace,
+// and the bootstrap policy applying to all workspaces, if it is readable.
+func AccessReport(ctx context.Context, out io.Writer, client kubernetes.ClusterInterface, workspace logicalcluster.LogicalCluster) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Workspace %s\n", workspace)
+
+	parent, name := workspace.Split()
+	if parent.Empty() {
+		fmt.Fprintf(&b, "\nAccess to the workspace:\n  Everybody authenticated\n")
+	} else {
+		parentGrants, err := grants(ctx, client.Cluster(parent))
+		if err != nil {
+			return fmt.Errorf("failed to read the RBAC of %s: %w", parent, err)
+		}
+
+		fmt.Fprintf(&b, "\nAccess to the workspace, granted in %s:\n", parent)
+		var lines []string
+		for _, g := range parentGrants {
+			if verbs := contentVerbs(g.rules, name); len(verbs) > 0 {
+				lines = append(lines, fmt.Sprintf("  %s: %s (%s)\n", g.subject, strings.Join(verbs, ","), g.via))
+			}
+		}
+		writeLines(&b, lines)
+		if org, ok := topLevelOrg(workspace); ok && org != workspace {
+			fmt.Fprintf(&b, "  Users other than service accounts also need access to %s.\n", org)
+		}
+
+		fmt.Fprintf(&b, "\nDelegated by %s to the service accounts of the workspace (with --authorization-service-account-parent-delegation):\n", parent)
+		lines = nil
+		for _, g := range parentGrants {
+			if g.subject == "Group "+authorization.WorkspacePathGroupPrefix+parent.String() && g.scope == "cluster-wide" {
+				lines = append(lines, permissionLines(g)...)
+			}
+		}
+		writeLines(&b, lines)
+	}
+
+	workspaceGrants, err := grants(ctx, client.Cluster(workspace))
+	if err != nil {
+		return fmt.Errorf("failed to read the RBAC of %s: %w", workspace, err)
+	}
+	fmt.Fprintf(&b, "\nPermissions in the workspace:\n")
+	var lines []string
+	for _, g := range workspaceGrants {
+		lines = append(lines, permissionLines(g)...)
+	}
+	writeLines(&b, lines)
+
+	fmt.Fprintf(&b, "\nBootstrap policy in %s, applying to all workspaces:\n", genericcontrolplane.LocalAdminCluster)
+	bootstrapGrants, err := grants(ctx, client.Cluster(genericcontrolplane.LocalAdminCluster))
+	if apierrors.IsForbidden(err) {
+		fmt.Fprintf(&b, "  Not readable: %v\n", err)
+	} else if err != nil {
+		return fmt.Errorf("failed to read the RBAC of %s: %w", genericcontrolplane.LocalAdminCluster, err)
+	} else {
+		lines = nil
+		for _, g := range bootstrapGrants {
+			if g.scope == "cluster-wide" {
+				lines = append(lines, permissionLines(g)...)
+			}
+		}
+		writeLines(&b, lines)
+	}
+
+	_, err = io.WriteString(out, b.String())
+	return err
+}
+
+// grants resolves the bindings of a workspace to the rules of their roles.
+func grants(ctx context.Context, client kubernetes.Interface) ([]grant, error) {
+	clusterRoles, err := client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	roles, err := client.RbacV1().Roles(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	clusterRoleBindings, err := client.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	roleBindings, err := client.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	clusterRoleRules := map[string][]rbacv1.PolicyRule{}
+	for _, r := range clusterRoles.Items {
+		clusterRoleRules[r.Name] = r.Rules
+	}
+	roleRules := map[string][]rbacv1.PolicyRule{}
+	for _, r := range roles.Items {
+		roleRules[r.Namespace+"/"+r.Name] = r.Rules
+	}
+	roleRefRules := func(namespace string, ref rbacv1.RoleRef) []rbacv1.PolicyRule {
+		if ref.Kind == "Role" {
+			return roleRules[namespace+"/"+ref.Name]
+		}
+		return clusterRoleRules[ref.Name]
+	}
+
+	var ret []grant
+	for _, binding := range clusterRoleBindings.Items {
+		for _, subject := range binding.Subjects {
+			ret = append(ret, grant{
+				subject: subjectString(subject, ""),
+				scope:   "cluster-wide",
+				via:     fmt.Sprintf("ClusterRoleBinding %s -> %s %s", binding.Name, binding.RoleRef.Kind, binding.RoleRef.Name),
+				rules:   roleRefRules("", binding.RoleRef),
+			})
+		}
+	}
+	for _, binding := range roleBindings.Items {
+		for _, subject := range binding.Subjects {
+			ret = append(ret, grant{
+				subject: subjectString(subject, binding.Namespace),
+				scope:   "namespace " + binding.Namespace,
+				via:     fmt.Sprintf("RoleBinding %s/%s -> %s %s", binding.Namespace, binding.Name, binding.RoleRef.Kind, binding.RoleRef.Name),
+				rules:   roleRefRules(binding.Namespace, binding.RoleRef),
+			})
+		}
+	}
+	return ret, nil
+}
+
+// contentVerbs returns the verbs on clusterworkspaces/content of the named workspace granted by the rules.
+func contentVerbs(rules []rbacv1.PolicyRule, name string) []string {
+	verbs := sets.NewString()
+	for _, rule := range rules {
+		if !matches(rule.APIGroups, v1alpha1.SchemeGroupVersion.Group) ||
+			!matches(rule.Resources, "clusterworkspaces/content") && !matches(rule.Resources, "*/content") ||
+			len(rule.ResourceNames) > 0 && !sets.NewString(rule.ResourceNames...).Has(name) {
+			continue
+		}
+		for _, verb := range rule.Verbs {
+			switch verb {
+			case "access", "member", "admin", rbacv1.VerbAll:
+				verbs.Insert(verb)
+			}
+		}
+	}
+	return verbs.List()
+}
+
+func matches(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == rbacv1.ResourceAll {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionLines(g grant) []string {
+	header := fmt.Sprintf("  %s, %s (%s):\n", g.subject, g.scope, g.via)
+	if g.rules == nil {
+		return []string{header + "    role not found\n"}
+	}
+	var b strings.Builder
+	b.WriteString(header)
+	for _, rule := range g.rules {
+		fmt.Fprintf(&b, "    %s\n", ruleString(rule))
+	}
+	return []string{b.String()}
+}
+
+func ruleString(rule rbacv1.PolicyRule) string {
+	verbs := strings.Join(rule.Verbs, ",")
+	if len(rule.NonResourceURLs) > 0 {
+		return fmt.Sprintf("%s on %s", verbs, strings.Join(rule.NonResourceURLs, ", "))
+	}
+	var resources []string
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			if group != "" {
+				resource += "." + group
+			}
+			resources = append(resources, resource)
+		}
+	}
+	s := fmt.Sprintf("%s on %s", verbs, strings.Join(resources, ", "))
+	if len(rule.ResourceNames) > 0 {
+		s += " named " + strings.Join(rule.ResourceNames, ", ")
+	}
+	return s
+}
+
+func subjectString(subject rbacv1.Subject, bindingNamespace string) string {
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		namespace := subject.Namespace
+		if namespace == "" {
+			namespace = bindingNamespace
+		}
+		return fmt.Sprintf("ServiceAccount %s/%s", namespace, subject.Name)
+	}
+	return subject.Kind + " " + subject.Name
+}
+
+func writeLines(b *strings.Builder, lines []string) {
+	if len(lines) == 0 {
+		b.WriteString("  None\n")
+		return
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+}
+
+func topLevelOrg(workspace logicalcluster.LogicalCluster) (logicalcluster.LogicalCluster, bool) {
+	for {
+		parent, hasParent := workspace.Parent()
+		if !hasParent {
+			return logicalcluster.LogicalCluster{}, false
+		}
+		if parent == v1alpha1.RootCluster {
+			return workspace, true
+		}
+		workspace = parent
+	}
+}
+
+// SplitClusterURL returns the server URL without the /clusters/<workspace> path of the given
+// host, and the workspace.
+func SplitClusterURL(host string) (*url.URL, logicalcluster.LogicalCluster, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, logicalcluster.LogicalCluster{}, err
+	}
+	i := strings.Index(u.Path, "/clusters/")
+	if i < 0 {
+		return nil, logicalcluster.LogicalCluster{}, fmt.Errorf("current cluster URL %s is not pointing to a workspace", u)
+	}
+	workspace := logicalcluster.New(strings.SplitN(u.Path[i+len("/clusters/"):], "/", 2)[0])
+	u.Path = u.Path[:i]
+	return u, workspace, nil
+}
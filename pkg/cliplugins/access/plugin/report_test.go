@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+type fakeClusterClient map[logicalcluster.LogicalCluster]*fake.Clientset
+
+func (c fakeClusterClient) Cluster(name logicalcluster.LogicalCluster) kubernetes.Interface {
+	if client, ok := c[name]; ok {
+		return client
+	}
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: action.GetResource().Resource}, "",
+			errors.New(`User "bob" cannot list resource "`+action.GetResource().Resource+`" in API group "rbac.authorization.k8s.io" at the cluster scope`))
+	})
+	return client
+}
+
+func TestAccessReport(t *testing.T) {
+	parent := fake.NewSimpleClientset(
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-access"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"clusterworkspaces/content"}, ResourceNames: []string{"team"}, Verbs: []string{"access", "member"}},
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-access"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"clusterworkspaces/content"}, ResourceNames: []string{"other"}, Verbs: []string{"access"}},
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "view"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-access"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "team-access"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "devs"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-access"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "other-access"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "mallory"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "bots"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:workspace-path:root:org"}},
+		},
+	)
+	workspace := fake.NewSimpleClientset(
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+				{NonResourceURLs: []string{"*"}, Verbs: []string{"*"}},
+			},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "deployer"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"web"}, Verbs: []string{"update", "patch"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice"}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "deployer"},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "deployer"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "ci"}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "dangling"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "missing"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "bob"}},
+		},
+	)
+	client := fakeClusterClient{
+		logicalcluster.New("root:org"):      parent,
+		logicalcluster.New("root:org:team"): workspace,
+	}
+
+	out := &bytes.Buffer{}
+	err := AccessReport(context.Background(), out, client, logicalcluster.New("root:org:team"))
+	require.NoError(t, err)
+	require.Equal(t, `Workspace root:org:team
+
+Access to the workspace, granted in root:org:
+  Group devs: access,member (ClusterRoleBinding team-access -> ClusterRole team-access)
+  Users other than service accounts also need access to root:org.
+
+Delegated by root:org to the service accounts of the workspace (with --authorization-service-account-parent-delegation):
+  Group system:workspace-path:root:org, cluster-wide (ClusterRoleBinding bots -> ClusterRole view):
+    get,list on configmaps
+
+Permissions in the workspace:
+  ServiceAccount default/ci, namespace default (RoleBinding default/deployer -> Role deployer):
+    update,patch on deployments.apps named web
+  User alice, cluster-wide (ClusterRoleBinding admins -> ClusterRole cluster-admin):
+    * on *.*
+    * on *
+  User bob, namespace default (RoleBinding default/dangling -> ClusterRole missing):
+    role not found
+
+Bootstrap policy in system:admin, applying to all workspaces:
+  Not readable: clusterroles.rbac.authorization.k8s.io is forbidden: User "bob" cannot list resource "clusterroles" in API group "rbac.authorization.k8s.io" at the cluster scope
+`, out.String())
+}
+
+func TestAccessReportRoot(t *testing.T) {
+	client := fakeClusterClient{
+		logicalcluster.New("root"):         fake.NewSimpleClientset(),
+		logicalcluster.New("system:admin"): fake.NewSimpleClientset(),
+	}
+
+	out := &bytes.Buffer{}
+	err := AccessReport(context.Background(), out, client, logicalcluster.New("root"))
+	require.NoError(t, err)
+	require.Equal(t, `Workspace root
+
+Access to the workspace:
+  Everybody authenticated
+
+Permissions in the workspace:
+  None
+
+Bootstrap policy in system:admin, applying to all workspaces:
+  None
+`, out.String())
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/crd/plugin"
+)
+
+var (
+	verifyCompatExample = `
+	# verify that a new version of a CRD doesn't break the clients and objects of the existing one
+	%[1]s crd verify-compat existing-crd.yaml new-crd.yaml
+
+	# verify that a new APIResourceSchema generation is compatible with the previous one
+	%[1]s crd verify-compat v1.widgets.example.com.yaml v2.widgets.example.com.yaml
+`
+)
+
+// NewCmdCRD provides a cobra command for CustomResourceDefinitions and APIResourceSchemas.
+func NewCmdCRD(streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "crd",
+		Short:        "Operations on CustomResourceDefinitions and APIResourceSchemas",
+		SilenceUsage: true,
+	}
+
+	verifyCompatCmd := &cobra.Command{
+		Use:          "verify-compat <existing-file> <new-file>",
+		Short:        "Reports the breaking changes of a new CustomResourceDefinition or APIResourceSchema compared to an existing one",
+		Example:      fmt.Sprintf(verifyCompatExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return c.Help()
+			}
+			return plugin.VerifyCompatibility(streams.Out, args[0], args[1])
+		},
+	}
+
+	cmd.AddCommand(verifyCompatCmd)
+	return cmd
+}
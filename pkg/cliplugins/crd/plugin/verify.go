@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"go.uber.org/multierr"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/schemacompat"
+)
+
+// VerifyCompatibility compares the new CustomResourceDefinition or APIResourceSchema in newFile with the existing
+// one in existingFile, prints the changes breaking the clients or the objects of the existing one, and fails
+// if there are any.
+func VerifyCompatibility(out io.Writer, existingFile, newFile string) error {
+	existing, err := readAPIResourceSchemaSpec(existingFile)
+	if err != nil {
+		return err
+	}
+	new, err := readAPIResourceSchemaSpec(newFile)
+	if err != nil {
+		return err
+	}
+
+	breakingChanges := multierr.Errors(schemacompat.EnsureAPIResourceSchemaCompatibility(field.NewPath("spec"), existing, new))
+	if len(breakingChanges) == 0 {
+		_, err := fmt.Fprintf(out, "No breaking changes found in %s\n", newFile)
+		return err
+	}
+	for _, change := range breakingChanges {
+		if _, err := fmt.Fprintf(out, "%v\n", change); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("found %d breaking changes in %s", len(breakingChanges), newFile)
+}
+
+// readAPIResourceSchemaSpec reads the spec of an APIResourceSchema, or of a CustomResourceDefinition
+// converted to an APIResourceSchema, from a YAML or JSON file.
+func readAPIResourceSchemaSpec(file string) (*apisv1alpha1.APIResourceSchemaSpec, error) {
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var typeMeta metav1.TypeMeta
+	if err := yaml.Unmarshal(bs, &typeMeta); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	switch typeMeta.Kind {
+	case "APIResourceSchema":
+		var schema apisv1alpha1.APIResourceSchema
+		if err := yaml.Unmarshal(bs, &schema); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		return &schema.Spec, nil
+	case "CustomResourceDefinition":
+		var crd apiextensionsv1.CustomResourceDefinition
+		if err := yaml.Unmarshal(bs, &crd); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		return apiResourceSchemaSpecFromCRD(&crd)
+	default:
+		return nil, fmt.Errorf("%s must contain an APIResourceSchema or a CustomResourceDefinition, not %q", file, typeMeta.Kind)
+	}
+}
+
+func apiResourceSchemaSpecFromCRD(crd *apiextensionsv1.CustomResourceDefinition) (*apisv1alpha1.APIResourceSchemaSpec, error) {
+	spec := &apisv1alpha1.APIResourceSchemaSpec{
+		Group:      crd.Spec.Group,
+		Names:      crd.Spec.Names,
+		Scope:      crd.Spec.Scope,
+		Conversion: crd.Spec.Conversion,
+	}
+	for _, version := range crd.Spec.Versions {
+		var schema runtime.RawExtension
+		if version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+			raw, err := json.Marshal(version.Schema.OpenAPIV3Schema)
+			if err != nil {
+				return nil, err
+			}
+			schema.Raw = raw
+		} else {
+			schema.Raw = []byte("{}")
+		}
+		spec.Versions = append(spec.Versions, apisv1alpha1.APIResourceVersion{
+			Name:                     version.Name,
+			Served:                   version.Served,
+			Storage:                  version.Storage,
+			Deprecated:               version.Deprecated,
+			DeprecationWarning:       version.DeprecationWarning,
+			Schema:                   schema,
+			Subresources:             version.Subresources,
+			AdditionalPrinterColumns: version.AdditionalPrinterColumns,
+		})
+	}
+	return spec, nil
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package plugin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const existingCRD = `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    plural: widgets
+    kind: Widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          size:
+            type: integer
+`
+
+func TestVerifyCompatibility(t *testing.T) {
+	tests := map[string]struct {
+		new string
+
+		wantOut string
+		wantErr bool
+	}{
+		"compatible APIResourceSchema": {
+			new: `
+apiVersion: apis.kcp.dev/v1alpha1
+kind: APIResourceSchema
+metadata:
+  name: v2.widgets.example.com
+spec:
+  group: example.com
+  names:
+    plural: widgets
+    kind: Widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      type: object
+      properties:
+        size:
+          type: integer
+        color:
+          type: string
+`,
+			wantOut: "No breaking changes found",
+		},
+		"breaking CRD": {
+			new: `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    plural: widgets
+    kind: Widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          size:
+            type: string
+`,
+			wantOut: "spec.versions[v1].schema.properties[size].type",
+			wantErr: true,
+		},
+		"unsupported kind": {
+			new: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: widgets
+`,
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			existingFile := filepath.Join(dir, "existing.yaml")
+			newFile := filepath.Join(dir, "new.yaml")
+			require.NoError(t, ioutil.WriteFile(existingFile, []byte(existingCRD), 0600))
+			require.NoError(t, ioutil.WriteFile(newFile, []byte(tc.new), 0600))
+
+			var out bytes.Buffer
+			err := VerifyCompatibility(&out, existingFile, newFile)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Contains(t, out.String(), tc.wantOut)
+		})
+	}
+}
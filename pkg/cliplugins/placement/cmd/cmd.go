@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/placement/plugin"
+)
+
+var (
+	explainPlacementExample = `
+	# explain why the namespace my-namespace of the current workspace landed on its cluster
+	%[1]s explain-placement my-namespace
+`
+)
+
+// NewCmdExplainPlacement provides a cobra command explaining the scheduling decisions of a namespace.
+func NewCmdExplainPlacement(streams genericclioptions.IOStreams) *cobra.Command {
+	configFlags := genericclioptions.NewConfigFlags(false)
+	// the namespace is the argument of the command
+	configFlags.Namespace = nil
+
+	cmd := &cobra.Command{
+		Use:          "explain-placement <namespace>",
+		Short:        "Shows the last scheduling decisions for a namespace of the current workspace, with the rejected clusters",
+		Example:      fmt.Sprintf(explainPlacementExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+			config, err := configFlags.ToRESTConfig()
+			if err != nil {
+				return err
+			}
+			client, err := kcpclient.NewForConfig(config)
+			if err != nil {
+				return err
+			}
+			return plugin.ExplainPlacement(c.Context(), streams.Out, client.WorkloadV1alpha1().PlacementDecisions(), args[0])
+		},
+	}
+	configFlags.AddFlags(cmd.Flags())
+
+	return cmd
+}
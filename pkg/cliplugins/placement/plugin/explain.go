@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/workload/v1alpha1"
+)
+
+// ExplainPlacement prints the last scheduling decisions recorded for the given namespace of
+// the current workspace, oldest first, with the clusters rejected by each decision.
+func ExplainPlacement(ctx context.Context, out io.Writer, client workloadclient.PlacementDecisionInterface, namespace string) error {
+	placementDecision, err := client.Get(ctx, namespace, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err := fmt.Fprintf(out, "No scheduling decision recorded for namespace %s.\n", namespace)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, decision := range placementDecision.Decisions {
+		fmt.Fprintf(&b, "%s  %s", decision.Time.UTC().Format(time.RFC3339), summary(decision))
+		if decision.Placement != "" {
+			fmt.Fprintf(&b, " (placement %s", decision.Placement)
+			if decision.Pool != "" {
+				fmt.Fprintf(&b, ", pool %s", decision.Pool)
+			}
+			b.WriteString(")")
+		}
+		b.WriteString("\n")
+		if decision.Message != "" {
+			fmt.Fprintf(&b, "    %s\n", decision.Message)
+		}
+		for _, rejected := range decision.RejectedClusters {
+			fmt.Fprintf(&b, "    Rejected %s: %s\n", rejected.Name, rejected.Reason)
+		}
+	}
+	_, err = io.WriteString(out, b.String())
+	return err
+}
+
+func summary(decision workloadv1alpha1.SchedulingDecision) string {
+	switch {
+	case decision.Cluster == "" && decision.PreviousCluster == "":
+		return "Not assigned to any cluster"
+	case decision.Cluster == "":
+		return fmt.Sprintf("Unassigned from cluster %s", decision.PreviousCluster)
+	case decision.PreviousCluster == "":
+		return fmt.Sprintf("Assigned to cluster %s", decision.Cluster)
+	case decision.Cluster == decision.PreviousCluster:
+		return fmt.Sprintf("Kept on cluster %s", decision.Cluster)
+	default:
+		return fmt.Sprintf("Moved from cluster %s to cluster %s", decision.PreviousCluster, decision.Cluster)
+	}
+}
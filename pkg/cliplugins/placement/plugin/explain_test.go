@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestExplainPlacement(t *testing.T) {
+	at := func(minute int) metav1.Time {
+		return metav1.NewTime(time.Date(2022, 5, 1, 10, minute, 0, 0, time.UTC))
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		want     string
+	}{
+		{
+			name: "no decision",
+			want: "No scheduling decision recorded for namespace default.\n",
+		},
+		{
+			name: "decisions",
+			existing: []runtime.Object{&workloadv1alpha1.PlacementDecision{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Decisions: []workloadv1alpha1.SchedulingDecision{
+					{
+						Time: at(0),
+						RejectedClusters: []workloadv1alpha1.RejectedCluster{
+							{Name: "east-1", Reason: "is not reporting ready"},
+						},
+					},
+					{
+						Time:      at(1),
+						Placement: "failover",
+						Pool:      "east",
+						Cluster:   "east-1",
+					},
+					{
+						Time:            at(5),
+						Placement:       "failover",
+						Pool:            "east",
+						PreviousCluster: "east-1",
+						Cluster:         "east-2",
+						Message:         "Cluster east-1 lost the heartbeat of its syncer",
+						RejectedClusters: []workloadv1alpha1.RejectedCluster{
+							{Name: "east-1", Reason: "lost the heartbeat of its syncer"},
+							{Name: "west-1", Reason: "is not a member of pool east"},
+						},
+					},
+				},
+			}},
+			want: `2022-05-01T10:00:00Z  Not assigned to any cluster
+    Rejected east-1: is not reporting ready
+2022-05-01T10:01:00Z  Assigned to cluster east-1 (placement failover, pool east)
+2022-05-01T10:05:00Z  Moved from cluster east-1 to cluster east-2 (placement failover, pool east)
+    Cluster east-1 lost the heartbeat of its syncer
+    Rejected east-1: lost the heartbeat of its syncer
+    Rejected west-1: is not a member of pool east
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := kcpfakeclient.NewSimpleClientset(tt.existing...)
+			out := &bytes.Buffer{}
+			err := ExplainPlacement(context.Background(), out, client.WorkloadV1alpha1().PlacementDecisions(), "default")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/scaffold/plugin"
+)
+
+var (
+	initProviderExample = `
+	# scaffold a provider of the namespaced Widget kind in the widgets.example.com group
+	%[1]s init provider ./widgets --group widgets.example.com --kind Widget
+
+	# scaffold a provider of a cluster-scoped resource with an irregular plural
+	%[1]s init provider ./gadgets --group gadgets.example.com --kind Gadget --plural gadgetry --scope Cluster
+`
+)
+
+// NewCmdInit provides a cobra command scaffolding new projects.
+func NewCmdInit(streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "init",
+		Short:        "Scaffolds new projects",
+		SilenceUsage: true,
+	}
+
+	opts := plugin.ProviderOptions{}
+	providerCmd := &cobra.Command{
+		Use:          "provider <directory>",
+		Short:        "Scaffolds an API provider: an APIResourceSchema, an APIExport and a minimal controller",
+		Example:      fmt.Sprintf(initProviderExample, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return c.Help()
+			}
+			return plugin.ScaffoldProvider(streams.Out, args[0], opts)
+		},
+	}
+	providerCmd.Flags().StringVar(&opts.Group, "group", opts.Group, "API group of the resource")
+	providerCmd.Flags().StringVar(&opts.Kind, "kind", opts.Kind, "Kind of the resource")
+	providerCmd.Flags().StringVar(&opts.Plural, "plural", opts.Plural, "Plural name of the resource, defaults to the lower-case kind with an s suffix")
+	providerCmd.Flags().StringVar(&opts.Version, "version", opts.Version, "API version of the resource, defaults to v1alpha1")
+	providerCmd.Flags().StringVar(&opts.Scope, "scope", opts.Scope, "Scope of the resource, Namespaced or Cluster, defaults to Namespaced")
+
+	cmd.AddCommand(providerCmd)
+	return cmd
+}
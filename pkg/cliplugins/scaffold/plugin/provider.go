@@ -0,0 +1,305 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// ProviderOptions describe the API of a scaffolded provider.
+type ProviderOptions struct {
+	// Group is the API group of the resource.
+	Group string
+	// Kind is the kind of the resource.
+	Kind string
+	// Plural is the plural resource name. It defaults to the lower-case kind with an "s" suffix.
+	Plural string
+	// Version is the API version of the resource.
+	Version string
+	// Scope is either Namespaced or Cluster.
+	Scope string
+}
+
+// Validate validates the options, and completes the defaults.
+func (o *ProviderOptions) Validate() error {
+	if o.Plural == "" {
+		o.Plural = strings.ToLower(o.Kind) + "s"
+	}
+	if o.Version == "" {
+		o.Version = "v1alpha1"
+	}
+	if o.Scope == "" {
+		o.Scope = string(apiextensionsv1.NamespaceScoped)
+	}
+
+	if errs := validation.IsDNS1123Subdomain(o.Group); len(errs) > 0 || !strings.Contains(o.Group, ".") {
+		return fmt.Errorf("group %q must be a DNS subdomain with at least one dot", o.Group)
+	}
+	if o.Kind == "" || strings.ToUpper(o.Kind[:1]) != o.Kind[:1] {
+		return fmt.Errorf("kind %q must start with an upper-case letter", o.Kind)
+	}
+	if errs := validation.IsDNS1035Label(o.Plural); len(errs) > 0 {
+		return fmt.Errorf("plural %q must be a lower-case DNS label: %s", o.Plural, strings.Join(errs, ", "))
+	}
+	if errs := validation.IsDNS1035Label(o.Version); len(errs) > 0 {
+		return fmt.Errorf("version %q must be a lower-case DNS label: %s", o.Version, strings.Join(errs, ", "))
+	}
+	if o.Scope != string(apiextensionsv1.NamespaceScoped) && o.Scope != string(apiextensionsv1.ClusterScoped) {
+		return fmt.Errorf("scope %q must be %s or %s", o.Scope, apiextensionsv1.NamespaceScoped, apiextensionsv1.ClusterScoped)
+	}
+	return nil
+}
+
+// ScaffoldProvider writes an APIResourceSchema, an APIExport exporting it and a minimal controller
+// reconciling the objects of the resource in all workspaces into dir. Existing files are not
+// overwritten.
+func ScaffoldProvider(out io.Writer, dir string, opts ProviderOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	schema := apiResourceSchema(opts)
+	schemaYAML, err := yaml.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	exportYAML, err := yaml.Marshal(apiExport(opts, schema.Name))
+	if err != nil {
+		return err
+	}
+	var controller bytes.Buffer
+	if err := controllerTemplate.Execute(&controller, opts); err != nil {
+		return err
+	}
+
+	files := []struct {
+		path    string
+		content []byte
+	}{
+		{filepath.Join(dir, "config", "apiresourceschema.yaml"), schemaYAML},
+		{filepath.Join(dir, "config", "apiexport.yaml"), exportYAML},
+		{filepath.Join(dir, "main.go"), controller.Bytes()},
+	}
+	for _, f := range files {
+		if _, err := os.Stat(f.path); err == nil {
+			return fmt.Errorf("%s already exists", f.path)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.path, f.content, 0644); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(out, "Created %s\n", f.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func apiResourceSchema(opts ProviderOptions) *apisv1alpha1.APIResourceSchema {
+	return &apisv1alpha1.APIResourceSchema{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apisv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "APIResourceSchema",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			// the prefix allows for new generations of the schema under a new name
+			Name: fmt.Sprintf("v1.%s.%s", opts.Plural, opts.Group),
+		},
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Group: opts.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   opts.Plural,
+				Singular: strings.ToLower(opts.Kind),
+				Kind:     opts.Kind,
+				ListKind: opts.Kind + "List",
+			},
+			Scope: apiextensionsv1.ResourceScope(opts.Scope),
+			Versions: []apisv1alpha1.APIResourceVersion{{
+				Name:    opts.Version,
+				Served:  true,
+				Storage: true,
+				Schema:  runtime.RawExtension{Raw: []byte(openAPISchema)},
+				Subresources: &apiextensionsv1.CustomResourceSubresources{
+					Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+				},
+			}},
+		},
+	}
+}
+
+func apiExport(opts ProviderOptions, schemaName string) *apisv1alpha1.APIExport {
+	return &apisv1alpha1.APIExport{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apisv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "APIExport",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: opts.Group,
+		},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{schemaName},
+		},
+	}
+}
+
+const openAPISchema = `{
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "x-kubernetes-preserve-unknown-fields": true
+    },
+    "status": {
+      "type": "object",
+      "properties": {
+        "observedGeneration": {
+          "type": "integer",
+          "format": "int64"
+        }
+      }
+    }
+  }
+}`
+
+var controllerTemplate = template.Must(template.New("controller").Parse(`package main
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/clustercontext"
+)
+
+var gvr = schema.GroupVersionResource{Group: "{{.Group}}", Version: "{{.Version}}", Resource: "{{.Plural}}"}
+
+// main runs a controller reconciling the {{.Plural}} of all workspaces that bound the APIExport.
+func main() {
+	kubeconfig := flag.String("kubeconfig", "", "Path to a kubeconfig for kcp")
+	flag.Parse()
+
+	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	// the workspace of the kubeconfig is replaced by the workspace of every request
+	config.Host = strings.SplitN(config.Host, "/clusters/", 2)[0]
+
+	wildcardClient, err := dynamic.NewForConfig(clustercontext.NewWildcardConfig(config))
+	if err != nil {
+		klog.Fatal(err)
+	}
+	clusterConfig, err := clustercontext.NewConfig(config)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	client, err := dynamic.NewForConfig(clusterConfig)
+	if err != nil {
+		klog.Fatal(err)
+	}
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), gvr.Resource)
+	informer := dynamicinformer.NewDynamicSharedInformerFactory(wildcardClient, 10*time.Hour).ForResource(gvr)
+	enqueue := func(obj interface{}) {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			klog.Error(err)
+			return
+		}
+		queue.Add(key)
+	}
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+	})
+
+	ctx := context.Background()
+	go informer.Informer().Run(ctx.Done())
+	cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced)
+
+	wait.Until(func() {
+		for {
+			key, quit := queue.Get()
+			if quit {
+				return
+			}
+			if err := reconcile(ctx, client, informer.Lister(), key.(string)); err != nil {
+				klog.Errorf("Failed to reconcile %s: %v", key, err)
+				queue.AddRateLimited(key)
+			} else {
+				queue.Forget(key)
+			}
+			queue.Done(key)
+		}
+	}, time.Second, ctx.Done())
+}
+
+// reconcile records the observed generation of the object. Replace it with the logic of your API.
+func reconcile(ctx context.Context, client dynamic.Interface, lister cache.GenericLister, key string) error {
+	obj, err := lister.Get(key)
+	if err != nil {
+		return nil // the object is gone
+	}
+	u := obj.(*unstructured.Unstructured).DeepCopy()
+
+	observed, _, _ := unstructured.NestedInt64(u.Object, "status", "observedGeneration")
+	if observed == u.GetGeneration() {
+		return nil
+	}
+	if err := unstructured.SetNestedField(u.Object, u.GetGeneration(), "status", "observedGeneration"); err != nil {
+		return err
+	}
+
+	cluster, _, _, err := clustercontext.SplitKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = client.Resource(gvr).Namespace(u.GetNamespace()).UpdateStatus(clustercontext.WithCluster(ctx, cluster), u, metav1.UpdateOptions{})
+	return err
+}
+`))
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package plugin
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kcp-dev/kcp/pkg/admission/apiresourceschema"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestScaffoldProvider(t *testing.T) {
+	tests := map[string]struct {
+		opts    ProviderOptions
+		wantErr string
+	}{
+		"namespaced": {
+			opts: ProviderOptions{Group: "widgets.example.com", Kind: "Widget"},
+		},
+		"cluster-scoped": {
+			opts: ProviderOptions{Group: "widgets.example.com", Kind: "Gadget", Plural: "gadgetry", Version: "v1", Scope: "Cluster"},
+		},
+		"group without dot": {
+			opts:    ProviderOptions{Group: "widgets", Kind: "Widget"},
+			wantErr: "must be a DNS subdomain with at least one dot",
+		},
+		"lower-case kind": {
+			opts:    ProviderOptions{Group: "widgets.example.com", Kind: "widget"},
+			wantErr: "must start with an upper-case letter",
+		},
+		"invalid scope": {
+			opts:    ProviderOptions{Group: "widgets.example.com", Kind: "Widget", Scope: "Workspace"},
+			wantErr: "must be Namespaced or Cluster",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			var out bytes.Buffer
+			err := ScaffoldProvider(&out, dir, tc.opts)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			bs, err := os.ReadFile(filepath.Join(dir, "config", "apiresourceschema.yaml"))
+			require.NoError(t, err)
+			var schema apisv1alpha1.APIResourceSchema
+			require.NoError(t, yaml.Unmarshal(bs, &schema))
+			require.Empty(t, apiresourceschema.ValidateAPIResourceSchema(&schema))
+
+			bs, err = os.ReadFile(filepath.Join(dir, "config", "apiexport.yaml"))
+			require.NoError(t, err)
+			var export apisv1alpha1.APIExport
+			require.NoError(t, yaml.Unmarshal(bs, &export))
+			require.Equal(t, []string{schema.Name}, export.Spec.LatestResourceSchemas)
+
+			bs, err = os.ReadFile(filepath.Join(dir, "main.go"))
+			require.NoError(t, err)
+			formatted, err := format.Source(bs)
+			require.NoError(t, err)
+			require.Equal(t, string(formatted), string(bs), "main.go is not gofmt'ed")
+
+			require.Error(t, ScaffoldProvider(&out, dir, tc.opts), "existing files must not be overwritten")
+		})
+	}
+}
@@ -47,6 +47,10 @@ var (
 	# enter the previous workspace
 	%[1]s workspace -
 
+	# define the alias prod for a workspace, and enter it through the alias
+	%[1]s workspace alias prod root:my-org:production
+	%[1]s workspace use @prod
+
 	# create a workspace and immediately enter it
 	%[1]s workspace create my-workspace --use
 
@@ -55,6 +59,12 @@ var (
 
 	# create a context with the current workspace, named context-name
 	%[1]s workspace create-context context-name
+
+	# clone the workspace production with its APIBindings, RBAC and config maps into the new workspace staging
+	%[1]s workspace clone production staging --include configmaps
+
+	# check the shards recorded for all workspaces against the contents of the shards, and repair them
+	%[1]s workspace check-index --repair
 `
 )
 
@@ -81,20 +91,36 @@ func NewCmdWorkspace(streams genericclioptions.IOStreams) (*cobra.Command, error
 		}
 		return kubeconfig.UseWorkspace(cmd.Context(), arg)
 	}
+	completeWorkspace := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		kubeconfig, err := plugin.NewKubeConfig(opts)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		completions, err := kubeconfig.CompleteWorkspace(cmd.Context(), toComplete)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
 	cmd := &cobra.Command{
 		Aliases:          []string{"ws", "workspaces"},
-		Use:              "workspace [list|create|create-context|<workspace>|..|-|<root:absolute:workspace>]",
+		Use:              "workspace [list|create|create-context|alias|<workspace>|..|-|<root:absolute:workspace>|@<alias>]",
 		Short:            "Manages KCP workspaces",
 		Example:          fmt.Sprintf(workspaceExample, "kubectl kcp"),
 		SilenceUsage:     true,
 		TraverseChildren: true,
 		RunE:             useRunE,
+
+		ValidArgsFunction: completeWorkspace,
 	}
 	opts.BindFlags(cmd)
 
 	useCmd := &cobra.Command{
-		Use:          "use <workspace>|..|-|<root:absolute:workspace>",
-		Short:        "Uses the given workspace as the current workspace. Using - means previous workspace, .. means parent workspace",
+		Use:          "use <workspace>|..|-|<root:absolute:workspace>|@<alias>",
+		Short:        "Uses the given workspace as the current workspace. Using - means previous workspace, .. means parent workspace, @<alias> means the workspace of an alias",
 		SilenceUsage: true,
 		RunE: func(c *cobra.Command, args []string) error {
 			if len(args) == 0 {
@@ -102,7 +128,45 @@ func NewCmdWorkspace(streams genericclioptions.IOStreams) (*cobra.Command, error
 			}
 			return useRunE(c, args)
 		},
+		ValidArgsFunction: completeWorkspace,
+	}
+
+	var deleteAlias bool
+	aliasCmd := &cobra.Command{
+		Use:          "alias [<alias> [<workspace>|<root:absolute:workspace>|@<alias>]] [--delete]",
+		Short:        "Lists, defines or deletes aliases of workspaces, stored in the kubeconfig. Without workspace, the alias points to the current workspace",
+		Example:      "kcp workspace alias prod root:my-org:production",
+		SilenceUsage: true,
+		Args:         cobra.MaximumNArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			switch {
+			case len(args) == 0 && !deleteAlias:
+				return kubeconfig.ListAliases()
+			case len(args) == 1 && deleteAlias:
+				return kubeconfig.DeleteAlias(args[0])
+			case deleteAlias:
+				return c.Help()
+			case len(args) == 1:
+				return kubeconfig.SetAlias(c.Context(), args[0], "")
+			default:
+				return kubeconfig.SetAlias(c.Context(), args[0], args[1])
+			}
+		},
+		ValidArgsFunction: func(c *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeWorkspace(c, nil, toComplete)
+		},
 	}
+	aliasCmd.Flags().BoolVar(&deleteAlias, "delete", deleteAlias, "Delete the given alias")
 
 	var shortWorkspaceOutput bool
 	currentCmd := &cobra.Command{
@@ -204,6 +268,51 @@ func NewCmdWorkspace(streams genericclioptions.IOStreams) (*cobra.Command, error
 	}
 	createContextCmd.Flags().BoolVar(&overwriteContext, "overwrite", overwriteContext, "Overwrite the context if it already exists")
 
+	var cloneContents bool
+	var cloneInclude, cloneExclude []string
+	cloneCmd := &cobra.Command{
+		Use:          "clone <source> <target> [--contents] [--include=<resource>.<group>] [--exclude=<resource>.<group>]",
+		Short:        "Clones a workspace of the current workspace into a new workspace",
+		Example:      "kcp workspace clone production staging --contents --exclude secrets",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(2),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			return kubeconfig.CloneWorkspace(c.Context(), args[0], args[1], cloneContents, cloneInclude, cloneExclude)
+		},
+	}
+	cloneCmd.Flags().BoolVar(&cloneContents, "contents", cloneContents, "Clone the contents of the workspace in addition to its APIBindings and RBAC")
+	cloneCmd.Flags().StringSliceVar(&cloneInclude, "include", cloneInclude, "Clone only these resources of the contents, e.g. deployments.apps or *.apps. Implies --contents")
+	cloneCmd.Flags().StringSliceVar(&cloneExclude, "exclude", cloneExclude, "Do not clone these resources of the contents, e.g. secrets. Implies --contents")
+
+	var repairIndex bool
+	checkIndexCmd := &cobra.Command{
+		Use:          "check-index [--repair]",
+		Short:        "Check the shards recorded in the ClusterWorkspaces against the contents of the shards",
+		Example:      "kcp workspace check-index --repair",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			kubeconfig, err := plugin.NewKubeConfig(opts)
+			if err != nil {
+				return err
+			}
+			if len(args) != 0 {
+				return cmd.Help()
+			}
+			return kubeconfig.CheckIndex(c.Context(), repairIndex)
+		},
+	}
+	checkIndexCmd.Flags().BoolVar(&repairIndex, "repair", repairIndex, "Point ClusterWorkspaces to the shard holding their objects where this is unambiguous")
+
 	deleteCmd := &cobra.Command{
 		Use:          "delete",
 		Short:        "Replaced with \"kubectl delete workspace <workspace-name>\"",
@@ -216,10 +325,13 @@ func NewCmdWorkspace(streams genericclioptions.IOStreams) (*cobra.Command, error
 	}
 
 	cmd.AddCommand(useCmd)
+	cmd.AddCommand(aliasCmd)
 	cmd.AddCommand(currentCmd)
 	cmd.AddCommand(listCmd)
 	cmd.AddCommand(createCmd)
 	cmd.AddCommand(createContextCmd)
+	cmd.AddCommand(cloneCmd)
+	cmd.AddCommand(checkIndexCmd)
 	cmd.AddCommand(deleteCmd)
 	return cmd, nil
 }
@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// kcpWorkspaceAliasesExtensionKey is the kubeconfig preferences extension holding the
+	// workspace aliases of the user, as a JSON object mapping alias names to workspace paths.
+	kcpWorkspaceAliasesExtensionKey string = "workspace.kcp.dev/aliases"
+
+	// aliasPrefix marks an alias as argument of workspace use, e.g. @prod.
+	aliasPrefix = "@"
+)
+
+// workspaceAliases returns the workspace aliases stored in the kubeconfig.
+func workspaceAliases(config *clientcmdapi.Config) (map[string]string, error) {
+	aliases := map[string]string{}
+	ext, found := config.Preferences.Extensions[kcpWorkspaceAliasesExtensionKey]
+	if !found || ext == nil {
+		return aliases, nil
+	}
+	unknown, ok := ext.(*runtime.Unknown)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T of kubeconfig extension %q", ext, kcpWorkspaceAliasesExtensionKey)
+	}
+	if err := json.Unmarshal(unknown.Raw, &aliases); err != nil {
+		return nil, fmt.Errorf("failed to decode kubeconfig extension %q: %w", kcpWorkspaceAliasesExtensionKey, err)
+	}
+	return aliases, nil
+}
+
+// setWorkspaceAliases stores the workspace aliases in the kubeconfig, removing the extension
+// if there are none.
+func setWorkspaceAliases(config *clientcmdapi.Config, aliases map[string]string) error {
+	if len(aliases) == 0 {
+		delete(config.Preferences.Extensions, kcpWorkspaceAliasesExtensionKey)
+		return nil
+	}
+	raw, err := json.Marshal(aliases)
+	if err != nil {
+		return err
+	}
+	if config.Preferences.Extensions == nil {
+		config.Preferences.Extensions = map[string]runtime.Object{}
+	}
+	config.Preferences.Extensions[kcpWorkspaceAliasesExtensionKey] = &runtime.Unknown{Raw: raw, ContentType: runtime.ContentTypeJSON}
+	return nil
+}
+
+// resolveAlias returns the workspace path of the given alias, without the @ prefix.
+func (kc *KubeConfig) resolveAlias(alias string) (string, error) {
+	aliases, err := workspaceAliases(kc.startingConfig)
+	if err != nil {
+		return "", err
+	}
+	path, found := aliases[alias]
+	if !found {
+		return "", fmt.Errorf("workspace alias %q not found", alias)
+	}
+	return path, nil
+}
+
+// SetAlias stores an alias for the given workspace, or the current workspace if empty.
+// Relative workspace names are resolved against the current workspace.
+func (kc *KubeConfig) SetAlias(ctx context.Context, alias, workspace string) error {
+	if alias == "" || strings.ContainsAny(alias, aliasPrefix+":/ ") {
+		return fmt.Errorf("invalid workspace alias %q: must not be empty or contain %q, \":\", \"/\" or spaces", alias, aliasPrefix)
+	}
+
+	config, err := clientcmd.NewDefaultClientConfig(*kc.startingConfig, kc.overrides).ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := parseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	var path logicalcluster.LogicalCluster
+	switch {
+	case workspace == "":
+		path = currentClusterName
+	case strings.HasPrefix(workspace, aliasPrefix):
+		resolved, err := kc.resolveAlias(strings.TrimPrefix(workspace, aliasPrefix))
+		if err != nil {
+			return err
+		}
+		path = logicalcluster.New(resolved)
+	case strings.Contains(workspace, ":") || workspace == tenancyv1alpha1.RootCluster.String():
+		path = logicalcluster.New(workspace)
+	default:
+		path = currentClusterName.Join(workspace)
+	}
+
+	newKubeConfig := kc.startingConfig.DeepCopy()
+	aliases, err := workspaceAliases(newKubeConfig)
+	if err != nil {
+		return err
+	}
+	aliases[alias] = path.String()
+	if err := setWorkspaceAliases(newKubeConfig, aliases); err != nil {
+		return err
+	}
+	if err := kc.modifyConfig(newKubeConfig); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(kc.Out, "Workspace alias %q points to %q.\n", aliasPrefix+alias, path)
+	return err
+}
+
+// DeleteAlias removes the given workspace alias.
+func (kc *KubeConfig) DeleteAlias(alias string) error {
+	newKubeConfig := kc.startingConfig.DeepCopy()
+	aliases, err := workspaceAliases(newKubeConfig)
+	if err != nil {
+		return err
+	}
+	if _, found := aliases[alias]; !found {
+		return fmt.Errorf("workspace alias %q not found", alias)
+	}
+	delete(aliases, alias)
+	if err := setWorkspaceAliases(newKubeConfig, aliases); err != nil {
+		return err
+	}
+	if err := kc.modifyConfig(newKubeConfig); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(kc.Out, "Workspace alias %q deleted.\n", aliasPrefix+alias)
+	return err
+}
+
+// ListAliases outputs the workspace aliases.
+func (kc *KubeConfig) ListAliases() error {
+	aliases, err := workspaceAliases(kc.startingConfig)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := fmt.Fprintf(kc.Out, "%s\t%s\n", aliasPrefix+name, aliases[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompleteWorkspace returns the completions of a workspace argument: aliases, the workspaces in
+// the current workspace, and for absolute paths the workspaces in the parent of the path.
+func (kc *KubeConfig) CompleteWorkspace(ctx context.Context, toComplete string) ([]string, error) {
+	var completions []string
+
+	if toComplete == "" || strings.HasPrefix(toComplete, aliasPrefix) {
+		aliases, err := workspaceAliases(kc.startingConfig)
+		if err != nil {
+			return nil, err
+		}
+		for name := range aliases {
+			completions = append(completions, aliasPrefix+name)
+		}
+		if toComplete != "" {
+			sort.Strings(completions)
+			return filterPrefix(completions, toComplete), nil
+		}
+	}
+
+	config, err := clientcmd.NewDefaultClientConfig(*kc.startingConfig, kc.overrides).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	_, currentClusterName, err := parseClusterURL(config.Host)
+	if err != nil {
+		sort.Strings(completions)
+		return completions, nil
+	}
+
+	parent, prefix := currentClusterName, ""
+	if i := strings.LastIndex(toComplete, ":"); i >= 0 {
+		parent, prefix = logicalcluster.New(toComplete[:i]), toComplete[:i+1]
+	}
+	workspaces, err := kc.personalClient.Cluster(parent).TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ws := range workspaces.Items {
+		completions = append(completions, prefix+ws.Name)
+	}
+
+	sort.Strings(completions)
+	return filterPrefix(completions, toComplete), nil
+}
+
+func filterPrefix(candidates []string, prefix string) []string {
+	var ret []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
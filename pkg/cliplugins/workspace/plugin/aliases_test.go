@@ -0,0 +1,197 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func aliasConfig(aliases string) *clientcmdapi.Config {
+	config := &clientcmdapi.Config{CurrentContext: "workspace.kcp.dev/current",
+		Contexts:  map[string]*clientcmdapi.Context{"workspace.kcp.dev/current": {Cluster: "workspace.kcp.dev/current", AuthInfo: "test"}},
+		Clusters:  map[string]*clientcmdapi.Cluster{"workspace.kcp.dev/current": {Server: "https://test/clusters/root:foo"}},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+	}
+	if aliases != "" {
+		config.Preferences.Extensions = map[string]runtime.Object{kcpWorkspaceAliasesExtensionKey: &runtime.Unknown{Raw: []byte(aliases)}}
+	}
+	return config
+}
+
+func TestSetAlias(t *testing.T) {
+	tests := []struct {
+		name      string
+		aliases   string
+		alias     string
+		workspace string
+		delete    bool
+
+		wantAliases map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "current workspace",
+			alias:       "foo",
+			wantAliases: map[string]string{"foo": "root:foo"},
+		},
+		{
+			name:        "relative workspace",
+			aliases:     `{"other":"root:other"}`,
+			alias:       "bar",
+			workspace:   "bar",
+			wantAliases: map[string]string{"other": "root:other", "bar": "root:foo:bar"},
+		},
+		{
+			name:        "absolute workspace overriding an alias",
+			aliases:     `{"prod":"root:other"}`,
+			alias:       "prod",
+			workspace:   "root:acme:production",
+			wantAliases: map[string]string{"prod": "root:acme:production"},
+		},
+		{
+			name:        "alias of an alias",
+			aliases:     `{"prod":"root:acme:production"}`,
+			alias:       "p",
+			workspace:   "@prod",
+			wantAliases: map[string]string{"prod": "root:acme:production", "p": "root:acme:production"},
+		},
+		{
+			name:    "invalid alias",
+			alias:   "root:foo",
+			wantErr: true,
+		},
+		{
+			name:        "delete",
+			aliases:     `{"prod":"root:acme:production","dev":"root:acme:dev"}`,
+			alias:       "prod",
+			delete:      true,
+			wantAliases: map[string]string{"dev": "root:acme:dev"},
+		},
+		{
+			name:        "delete last",
+			aliases:     `{"prod":"root:acme:production"}`,
+			alias:       "prod",
+			delete:      true,
+			wantAliases: map[string]string{},
+		},
+		{
+			name:    "delete unknown",
+			alias:   "prod",
+			delete:  true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *clientcmdapi.Config
+			streams, _, _, _ := genericclioptions.NewTestIOStreams()
+			kc := &KubeConfig{
+				startingConfig: aliasConfig(tt.aliases),
+				currentContext: "workspace.kcp.dev/current",
+				modifyConfig: func(config *clientcmdapi.Config) error {
+					got = config
+					return nil
+				},
+				IOStreams: streams,
+			}
+
+			var err error
+			if tt.delete {
+				err = kc.DeleteAlias(tt.alias)
+			} else {
+				err = kc.SetAlias(context.Background(), tt.alias, tt.workspace)
+			}
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Nil(t, got)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, got)
+
+			aliases, err := workspaceAliases(got)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAliases, aliases)
+		})
+	}
+}
+
+func TestCompleteWorkspace(t *testing.T) {
+	workspaces := func(names ...string) *tenancyfake.Clientset {
+		objs := []runtime.Object{}
+		for _, name := range names {
+			objs = append(objs, &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		}
+		return tenancyfake.NewSimpleClientset(objs...)
+	}
+
+	tests := []struct {
+		name       string
+		toComplete string
+		want       []string
+	}{
+		{
+			name: "empty",
+			want: []string{"@dev", "@prod", "bar", "baz"},
+		},
+		{
+			name:       "alias",
+			toComplete: "@p",
+			want:       []string{"@prod"},
+		},
+		{
+			name:       "relative",
+			toComplete: "ba",
+			want:       []string{"bar", "baz"},
+		},
+		{
+			name:       "absolute",
+			toComplete: "root:acme:p",
+			want:       []string{"root:acme:production"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kc := &KubeConfig{
+				startingConfig: aliasConfig(`{"prod":"root:acme:production","dev":"root:acme:dev"}`),
+				currentContext: "workspace.kcp.dev/current",
+				personalClient: fakeTenancyClient{
+					t: t,
+					clients: map[logicalcluster.LogicalCluster]*tenancyfake.Clientset{
+						logicalcluster.New("root:foo"):  workspaces("bar", "baz"),
+						logicalcluster.New("root:acme"): workspaces("production", "dev"),
+					},
+				},
+			}
+			got, err := kc.CompleteWorkspace(context.Background(), tt.toComplete)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
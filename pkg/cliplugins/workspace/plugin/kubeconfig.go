@@ -31,6 +31,8 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/printers"
@@ -39,8 +41,10 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspaceindex"
 )
 
 const (
@@ -56,6 +60,7 @@ type KubeConfig struct {
 	overrides      *clientcmd.ConfigOverrides
 	currentContext string // including override
 
+	clusterConfig  *rest.Config
 	clusterClient  tenancyclient.ClusterInterface
 	personalClient tenancyclient.ClusterInterface
 	modifyConfig   func(newConfig *clientcmdapi.Config) error
@@ -100,6 +105,7 @@ func NewKubeConfig(opts *Options) (*KubeConfig, error) {
 		overrides:      opts.KubectlOverrides,
 		currentContext: currentContext,
 
+		clusterConfig:  clusterConfig,
 		clusterClient:  clusterClient,
 		personalClient: &personalClusterClient{clusterConfig},
 		modifyConfig: func(newConfig *clientcmdapi.Config) error {
@@ -122,6 +128,15 @@ func (kc *KubeConfig) UseWorkspace(ctx context.Context, name string) error {
 		return fmt.Errorf("current %q context not found", kc.currentContext)
 	}
 
+	// aliases resolve to absolute workspace paths
+	if strings.HasPrefix(name, aliasPrefix) {
+		resolved, err := kc.resolveAlias(strings.TrimPrefix(name, aliasPrefix))
+		if err != nil {
+			return err
+		}
+		name = resolved
+	}
+
 	var newServerHost, workspaceType string
 	switch name {
 	case "-":
@@ -467,3 +482,105 @@ func (kc *KubeConfig) CreateContext(ctx context.Context, name string, overwrite
 
 	return nil
 }
+
+// CloneWorkspace creates a WorkspaceClone in the current workspace, cloning the source workspace
+// into the new target workspace. With contents, or if resources are included or excluded, the
+// contents are cloned in addition to the APIBindings and the RBAC. Resources are given as
+// <resource>.<group>, e.g. deployments.apps, and "*" as resource matches all of the group.
+func (kc *KubeConfig) CloneWorkspace(ctx context.Context, source, target string, contents bool, include, exclude []string) error {
+	config, err := clientcmd.NewDefaultClientConfig(*kc.startingConfig, kc.overrides).ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := parseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	clone := &tenancyv1alpha1.WorkspaceClone{
+		ObjectMeta: metav1.ObjectMeta{Name: target},
+		Spec: tenancyv1alpha1.WorkspaceCloneSpec{
+			Source: source,
+			Target: target,
+		},
+	}
+	if contents || len(include) > 0 || len(exclude) > 0 {
+		clone.Spec.Contents = &tenancyv1alpha1.WorkspaceCloneContents{
+			Include: cloneResources(include),
+			Exclude: cloneResources(exclude),
+		}
+	}
+	if _, err := kc.clusterClient.Cluster(currentClusterName).TenancyV1alpha1().WorkspaceClones().Create(ctx, clone, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(kc.Out, "Cloning workspace %q into %q. Follow the progress with \"kubectl get workspaceclones %s\".\n", source, target, target) // nolint: errcheck
+	return nil
+}
+
+func cloneResources(resources []string) []tenancyv1alpha1.WorkspaceCloneResource {
+	var ret []tenancyv1alpha1.WorkspaceCloneResource
+	for _, r := range resources {
+		gr := schema.ParseGroupResource(r)
+		ret = append(ret, tenancyv1alpha1.WorkspaceCloneResource{Group: gr.Group, Resource: gr.Resource})
+	}
+	return ret
+}
+
+// CheckIndex checks the shards recorded in the ClusterWorkspaces of all workspaces against
+// the contents of the shards, reachable under their external URL with the credentials of
+// the current context, and outputs the inconsistencies. With repair, the ClusterWorkspaces
+// are pointed to the shard holding their objects where this is unambiguous.
+func (kc *KubeConfig) CheckIndex(ctx context.Context, repair bool) error {
+	workspaces, err := kc.clusterClient.Cluster(logicalcluster.Wildcard).TenancyV1alpha1().ClusterWorkspaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	shards, err := kc.clusterClient.Cluster(tenancyv1alpha1.RootCluster).TenancyV1alpha1().ClusterWorkspaceShards().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	checker := workspaceindex.NewChecker(func(shard *tenancyv1alpha1.ClusterWorkspaceShard) *rest.Config {
+		shardConfig := rest.CopyConfig(kc.clusterConfig)
+		shardConfig.Host = tenancyhelper.ShardURL(shard.Spec, tenancyhelper.ExternalAudience)
+		return shardConfig
+	}, kc.clusterClient)
+
+	workspacePointers := make([]*tenancyv1alpha1.ClusterWorkspace, 0, len(workspaces.Items))
+	for i := range workspaces.Items {
+		workspacePointers = append(workspacePointers, &workspaces.Items[i])
+	}
+	shardPointers := make([]*tenancyv1alpha1.ClusterWorkspaceShard, 0, len(shards.Items))
+	for i := range shards.Items {
+		shardPointers = append(shardPointers, &shards.Items[i])
+	}
+
+	problems, checkErr := checker.Check(ctx, workspacePointers, shardPointers)
+	if len(problems) == 0 {
+		if checkErr == nil {
+			fmt.Fprintf(kc.Out, "Checked %d workspaces on %d shards, no inconsistencies found.\n", len(workspaces.Items), len(shards.Items)) // nolint: errcheck
+		}
+		return checkErr
+	}
+
+	w := printers.GetNewTabWriter(kc.Out)
+	fmt.Fprintln(w, "WORKSPACE\tREASON\tSHARD\tMESSAGE") // nolint: errcheck
+	var repairErrs []error
+	for _, problem := range problems {
+		shard := problem.Shard
+		if repair && shard != "" {
+			if err := checker.Repair(ctx, problem); err != nil {
+				repairErrs = append(repairErrs, err)
+			} else {
+				shard += " (repaired)"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", problem.Workspace, problem.Reason, shard, problem.Message) // nolint: errcheck
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return utilerrors.NewAggregate(append([]error{checkErr}, repairErrs...))
+}
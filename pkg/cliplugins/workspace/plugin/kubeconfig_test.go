@@ -361,6 +361,43 @@ func TestUse(t *testing.T) {
 			param:   "root:bar",
 			wantErr: true,
 		},
+		{
+			name: "alias",
+			config: clientcmdapi.Config{CurrentContext: "workspace.kcp.dev/current",
+				Contexts:    map[string]*clientcmdapi.Context{"workspace.kcp.dev/current": {Cluster: "workspace.kcp.dev/current", AuthInfo: "test"}},
+				Clusters:    map[string]*clientcmdapi.Cluster{"workspace.kcp.dev/current": {Server: "https://test/clusters/root:foo"}},
+				AuthInfos:   map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+				Preferences: clientcmdapi.Preferences{Extensions: map[string]runtime.Object{"workspace.kcp.dev/aliases": &runtime.Unknown{Raw: []byte(`{"prod":"root:foo:bar"}`)}}},
+			},
+			existingObjects: map[logicalcluster.LogicalCluster][]string{
+				logicalcluster.New("root:foo"): {"bar"},
+			},
+			param: "@prod",
+			expected: &clientcmdapi.Config{CurrentContext: "workspace.kcp.dev/current",
+				Contexts: map[string]*clientcmdapi.Context{
+					"workspace.kcp.dev/current":  {Cluster: "workspace.kcp.dev/current", AuthInfo: "test"},
+					"workspace.kcp.dev/previous": {Cluster: "workspace.kcp.dev/previous", AuthInfo: "test"},
+				},
+				Clusters: map[string]*clientcmdapi.Cluster{
+					"workspace.kcp.dev/current":  {Server: "https://test/clusters/root:foo:bar"},
+					"workspace.kcp.dev/previous": {Server: "https://test/clusters/root:foo"},
+				},
+				AuthInfos:   map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+				Preferences: clientcmdapi.Preferences{Extensions: map[string]runtime.Object{"workspace.kcp.dev/aliases": &runtime.Unknown{Raw: []byte(`{"prod":"root:foo:bar"}`)}}},
+			},
+			wantStdout: []string{"Current workspace is \"root:foo:bar\""},
+		},
+		{
+			name: "unknown alias",
+			config: clientcmdapi.Config{CurrentContext: "workspace.kcp.dev/current",
+				Contexts:  map[string]*clientcmdapi.Context{"workspace.kcp.dev/current": {Cluster: "workspace.kcp.dev/current", AuthInfo: "test"}},
+				Clusters:  map[string]*clientcmdapi.Cluster{"workspace.kcp.dev/current": {Server: "https://test/clusters/root:foo"}},
+				AuthInfos: map[string]*clientcmdapi.AuthInfo{"test": {Token: "test"}},
+			},
+			param:      "@prod",
+			wantErr:    true,
+			wantErrors: []string{"workspace alias \"prod\" not found"},
+		},
 		{
 			name: "system:admin",
 			config: clientcmdapi.Config{CurrentContext: "workspace.kcp.dev/current",
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package clustercontext lets clients which are not cluster-aware, e.g. the clients and caches
+// of controller-runtime based operators, talk to the logical clusters of kcp or of a kcp virtual
+// workspace. The logical cluster of a request is carried in its context.
+package clustercontext
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+)
+
+type key int
+
+const clusterKey key = iota
+
+// WithCluster returns a context sending the requests of clients built from a config returned
+// by NewConfig to the given logical cluster.
+func WithCluster(ctx context.Context, cluster logicalcluster.LogicalCluster) context.Context {
+	return context.WithValue(ctx, clusterKey, cluster)
+}
+
+// ClusterFrom returns the logical cluster of the context, if any.
+func ClusterFrom(ctx context.Context) (logicalcluster.LogicalCluster, bool) {
+	cluster, ok := ctx.Value(clusterKey).(logicalcluster.LogicalCluster)
+	return cluster, ok && !cluster.Empty()
+}
+
+// NewConfig returns a copy of the config whose requests go to the logical cluster of their
+// context, under the path of the config host. Requests without a logical cluster in their
+// context, or with an explicit cluster in their path, are sent unchanged.
+func NewConfig(config *rest.Config) (*rest.Config, error) {
+	host, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host %q: %w", config.Host, err)
+	}
+
+	clusterConfig := rest.CopyConfig(config)
+	clusterConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &clusterTransport{RoundTripper: rt, prefix: strings.TrimSuffix(host.Path, "/")}
+	})
+	return clusterConfig, nil
+}
+
+// NewWildcardConfig returns a copy of the config whose requests go to all logical clusters. It
+// is meant for caches and informers watching the objects of every workspace.
+func NewWildcardConfig(config *rest.Config) *rest.Config {
+	wildcardConfig := rest.CopyConfig(config)
+	wildcardConfig.Host = strings.TrimSuffix(config.Host, "/") + "/clusters/" + logicalcluster.Wildcard.String()
+	return wildcardConfig
+}
+
+// SplitKey splits the key of an object in a cache of a wildcard informer into its logical
+// cluster, namespace and name.
+func SplitKey(key string) (cluster logicalcluster.LogicalCluster, namespace, name string, err error) {
+	namespace, clusterAndName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return logicalcluster.LogicalCluster{}, "", "", err
+	}
+	cluster, name = clusters.SplitClusterAwareKey(clusterAndName)
+	return cluster, namespace, name, nil
+}
+
+// clusterTransport inserts the logical cluster of the request context into the request path.
+type clusterTransport struct {
+	http.RoundTripper
+	prefix string
+}
+
+func (t *clusterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cluster, ok := ClusterFrom(req.Context())
+	if !ok || !strings.HasPrefix(req.URL.Path, t.prefix+"/") {
+		return t.RoundTripper.RoundTrip(req)
+	}
+	path := strings.TrimPrefix(req.URL.Path, t.prefix)
+	if strings.HasPrefix(path, "/clusters/") {
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	// the request must not be mutated by a RoundTripper
+	req = req.Clone(req.Context())
+	req.URL.Path = t.prefix + "/clusters/" + cluster.String() + path
+	req.URL.RawPath = ""
+	return t.RoundTripper.RoundTrip(req)
+}
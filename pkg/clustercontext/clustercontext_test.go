@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clustercontext
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestNewConfig(t *testing.T) {
+	tests := map[string]struct {
+		hostPath string
+		ctx      context.Context
+		wantPath string
+	}{
+		"no cluster": {
+			ctx:      context.Background(),
+			wantPath: "/api/v1/namespaces/default/configmaps/foo",
+		},
+		"cluster": {
+			ctx:      WithCluster(context.Background(), logicalcluster.New("root:org:team")),
+			wantPath: "/clusters/root:org:team/api/v1/namespaces/default/configmaps/foo",
+		},
+		"virtual workspace": {
+			hostPath: "/services/workspaces/root/personal",
+			ctx:      WithCluster(context.Background(), logicalcluster.New("root:org:team")),
+			wantPath: "/services/workspaces/root/personal/clusters/root:org:team/api/v1/namespaces/default/configmaps/foo",
+		},
+		"empty cluster": {
+			ctx:      WithCluster(context.Background(), logicalcluster.LogicalCluster{}),
+			wantPath: "/api/v1/namespaces/default/configmaps/foo",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				gotPath = req.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"foo"}}`)) //nolint:errcheck
+			}))
+			defer server.Close()
+
+			config, err := NewConfig(&rest.Config{Host: server.URL + tc.hostPath})
+			require.NoError(t, err)
+			client, err := kubernetes.NewForConfig(config)
+			require.NoError(t, err)
+
+			_, err = client.CoreV1().ConfigMaps("default").Get(tc.ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tc.wantPath, gotPath)
+		})
+	}
+}
+
+func TestNewWildcardConfig(t *testing.T) {
+	require.Equal(t, "https://kcp:6443/clusters/*", NewWildcardConfig(&rest.Config{Host: "https://kcp:6443/"}).Host)
+}
+
+func TestSplitKey(t *testing.T) {
+	cluster, namespace, name, err := SplitKey("default/root:org#$#foo")
+	require.NoError(t, err)
+	require.Equal(t, logicalcluster.New("root:org"), cluster)
+	require.Equal(t, "default", namespace)
+	require.Equal(t, "foo", name)
+
+	cluster, namespace, name, err = SplitKey("root:org#$#foo")
+	require.NoError(t, err)
+	require.Equal(t, logicalcluster.New("root:org"), cluster)
+	require.Equal(t, "", namespace)
+	require.Equal(t, "foo", name)
+}
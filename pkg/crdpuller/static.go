@@ -0,0 +1,95 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crdpuller
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+type staticSchemaPuller struct {
+	crds []*apiextensionsv1.CustomResourceDefinition
+}
+
+var _ SchemaPuller = &staticSchemaPuller{}
+
+// NewStaticSchemaPuller returns a SchemaPuller serving the CRDs of the YAML files of the
+// file system, e.g. to import APIs without a physical cluster to pull them from.
+func NewStaticSchemaPuller(fsys fs.FS) (SchemaPuller, error) {
+	puller := &staticSchemaPuller{}
+	err := fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(filePath) != ".yaml" {
+			return nil
+		}
+		raw, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := yaml.Unmarshal(raw, crd); err != nil {
+			return fmt.Errorf("invalid CRD %s: %w", filePath, err)
+		}
+		if len(crd.Spec.Versions) == 0 {
+			return fmt.Errorf("invalid CRD %s: no versions", filePath)
+		}
+		puller.crds = append(puller.crds, crd)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return puller, nil
+}
+
+// PullCRDs returns the CRDs of the resources named by their plural names, optionally qualified by
+// their group. Resources without a CRD are skipped. If the list of resources is empty, all the
+// CRDs are returned.
+func (sp *staticSchemaPuller) PullCRDs(_ context.Context, resourceNames ...string) (map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition, error) {
+	crds := map[schema.GroupResource]*apiextensionsv1.CustomResourceDefinition{}
+	if len(resourceNames) == 0 {
+		for _, crd := range sp.crds {
+			crds[schema.GroupResource{Group: crd.Spec.Group, Resource: crd.Spec.Names.Plural}] = crd.DeepCopy()
+		}
+		return crds, nil
+	}
+
+	for _, resourceName := range resourceNames {
+		requested := schema.ParseGroupResource(resourceName)
+		found := false
+		for _, crd := range sp.crds {
+			groupResource := schema.GroupResource{Group: crd.Spec.Group, Resource: crd.Spec.Names.Plural}
+			if requested.Resource == groupResource.Resource && (requested.Group == "" || requested.Group == groupResource.Group) {
+				crds[groupResource] = crd.DeepCopy()
+				found = true
+			}
+		}
+		if !found {
+			klog.Infof("ignoring resource %q which has no CRD", resourceName)
+		}
+	}
+	return crds, nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package features
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/component-base/featuregate"
+)
+
+// Status is the state of a feature gate served by the debug handler.
+type Status struct {
+	Enabled       bool   `json:"enabled"`
+	PreRelease    string `json:"preRelease,omitempty"`
+	LockToDefault bool   `json:"lockToDefault,omitempty"`
+}
+
+// Handler serves the state of all the known feature gates as a JSON object by feature name,
+// such that the features enabled in a running process can be inspected.
+func Handler(gate featuregate.MutableFeatureGate) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		status := map[featuregate.Feature]Status{}
+		for feature, spec := range gate.GetAll() {
+			status[feature] = Status{
+				Enabled:       gate.Enabled(feature),
+				PreRelease:    string(spec.PreRelease),
+				LockToDefault: spec.LockToDefault,
+			}
+		}
+
+		bs, err := json.Marshal(status)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(bs) //nolint:errcheck
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package features
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/component-base/featuregate"
+)
+
+func TestHandler(t *testing.T) {
+	gate := featuregate.NewFeatureGate()
+	require.NoError(t, gate.Add(map[featuregate.Feature]featuregate.FeatureSpec{
+		"Alpha":  {Default: false, PreRelease: featuregate.Alpha},
+		"Beta":   {Default: true, PreRelease: featuregate.Beta},
+		"Locked": {Default: true, PreRelease: featuregate.GA, LockToDefault: true},
+	}))
+	require.NoError(t, gate.Set("Alpha=true,Beta=false"))
+
+	rec := httptest.NewRecorder()
+	Handler(gate).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/featuregates", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var status map[string]Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	require.Equal(t, Status{Enabled: true, PreRelease: "ALPHA"}, status["Alpha"])
+	require.Equal(t, Status{Enabled: false, PreRelease: "BETA"}, status["Beta"])
+	require.Equal(t, Status{Enabled: true, LockToDefault: true}, status["Locked"])
+}
+
+func TestDefaultFeatureGates(t *testing.T) {
+	for _, feature := range []featuregate.Feature{AdvancedPlacement, Upsync, IngressSplitting} {
+		require.Contains(t, defaultGenericControlPlaneFeatureGates, feature)
+		require.True(t, defaultGenericControlPlaneFeatureGates[feature].Default, "%s must be enabled by default to keep the existing behaviour", feature)
+	}
+}
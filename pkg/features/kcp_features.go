@@ -24,11 +24,44 @@ import (
 )
 
 const (
-// Every feature gate should add method here following this template:
-//
-// // owner: @username
-// // alpha: v1.4
-// MyFeature() bool
+	// Every feature gate should add method here following this template:
+	//
+	// // owner: @username
+	// // alpha: v1.4
+	// MyFeature() bool
+
+	// beta: v0.4
+	//
+	// AdvancedPlacement enables the Placements selecting the WorkloadClusters namespaces are
+	// scheduled to. When disabled, namespaces are scheduled to any WorkloadCluster of their
+	// workspace.
+	AdvancedPlacement featuregate.Feature = "AdvancedPlacement"
+
+	// beta: v0.4
+	//
+	// Upsync enables the syncers to expose data populated on their WorkloadCluster on the
+	// upstream resources, beyond their status: node ports, load balancer ingress points,
+	// bound volumes and ready endpoints, and to upsync the events of the synced namespaces.
+	Upsync featuregate.Feature = "Upsync"
+
+	// beta: v0.4
+	//
+	// IngressSplitting enables the splitting of Ingresses, and Gateway API HTTPRoutes, into
+	// one leaf per WorkloadCluster of their backends by the ingress controller.
+	IngressSplitting featuregate.Feature = "IngressSplitting"
+
+	// alpha: v0.4
+	//
+	// SkipResyncUpdates makes the controllers drop update events of objects whose resourceVersion
+	// did not change, as they are sent for every object when an informer relists after its watch
+	// was reconnected, e.g. after a shard restart. This also drops the periodic resyncs.
+	SkipResyncUpdates featuregate.Feature = "SkipResyncUpdates"
+
+	// alpha: v0.4
+	//
+	// WorkspaceMetrics enables the /workspace-metrics endpoint of every workspace, serving the
+	// request rate, error rate and most requested resources of the workspace over the last minutes.
+	WorkspaceMetrics featuregate.Feature = "WorkspaceMetrics"
 )
 
 func init() {
@@ -50,4 +83,15 @@ var defaultGenericControlPlaneFeatureGates = map[featuregate.Feature]featuregate
 	genericfeatures.ServerSideApply:         {Default: true, PreRelease: featuregate.GA},
 	genericfeatures.APIPriorityAndFairness:  {Default: true, PreRelease: featuregate.Beta},
 	genericfeatures.WarningHeaders:          {Default: true, PreRelease: featuregate.GA, LockToDefault: true}, // remove in 1.24
+
+	// transparent multi-cluster features:
+	AdvancedPlacement: {Default: true, PreRelease: featuregate.Beta},
+	Upsync:            {Default: true, PreRelease: featuregate.Beta},
+	IngressSplitting:  {Default: true, PreRelease: featuregate.Beta},
+
+	// controller features:
+	SkipResyncUpdates: {Default: false, PreRelease: featuregate.Alpha},
+
+	// tenancy features:
+	WorkspaceMetrics: {Default: false, PreRelease: featuregate.Alpha},
 }
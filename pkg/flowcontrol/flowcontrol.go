@@ -0,0 +1,308 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package flowcontrol
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	flowcontrolv1beta2 "k8s.io/api/flowcontrol/v1beta2"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
+	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
+	fcrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	flowcontrolv1beta2listers "k8s.io/client-go/listers/flowcontrol/v1beta2"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const controllerName = "kcp-workspace-priority-and-fairness"
+
+// workspaceSeatsDivisor divides the concurrency limit of the shard into the limit of the config of
+// a workspace, such that one workspace cannot take all the seats of the shard.
+const workspaceSeatsDivisor = 4
+
+// NewWorkspaceAwareFlowControl returns an API priority and fairness implementation that handles the requests
+// of a workspace with its own FlowSchemas and PriorityLevelConfigurations, if it has any, merged with
+// the ones of the defaults cluster. Requests to all other workspaces are handled by the shard's config.
+//
+// Every workspace with its own configuration gets a share of the shard's concurrency limit. Its requests
+// are dispatched by its own config and then occupy a seat of the shard's config, i.e. workspaces do not
+// add seats to the shard. A noisy tenant is hence throttled by its own priority levels, without using up
+// the seats of the other workspaces.
+func NewWorkspaceAwareFlowControl(
+	shard utilflowcontrol.Interface,
+	defaultsCluster logicalcluster.LogicalCluster,
+	wildcardInformerFactory informers.SharedInformerFactory,
+	kubeClusterClient kubernetes.ClusterInterface,
+	serverConcurrencyLimit int,
+	requestWaitLimit time.Duration,
+) utilflowcontrol.Interface {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	fc := &workspaceAwareFlowControl{
+		Interface:       shard,
+		defaultsCluster: defaultsCluster,
+		queue:           queue,
+
+		flowSchemaLister:                 wildcardInformerFactory.Flowcontrol().V1beta2().FlowSchemas().Lister(),
+		priorityLevelConfigurationLister: wildcardInformerFactory.Flowcontrol().V1beta2().PriorityLevelConfigurations().Lister(),
+		syncedFuncs: []cache.InformerSynced{
+			wildcardInformerFactory.Flowcontrol().V1beta2().FlowSchemas().Informer().HasSynced,
+			wildcardInformerFactory.Flowcontrol().V1beta2().PriorityLevelConfigurations().Informer().HasSynced,
+		},
+
+		workspaces: map[logicalcluster.LogicalCluster]*workspaceFlowControl{},
+	}
+	workspaceConcurrencyLimit := serverConcurrencyLimit / workspaceSeatsDivisor
+	if workspaceConcurrencyLimit < 1 {
+		workspaceConcurrencyLimit = 1
+	}
+	fc.newWorkspaceFlowControl = func(clusterName logicalcluster.LogicalCluster) (utilflowcontrol.Interface, *workspaceInformers) {
+		workspaceInformers := newWorkspaceInformers(clusterName, defaultsCluster, wildcardInformerFactory)
+		return utilflowcontrol.New(
+			workspaceInformers,
+			kubeClusterClient.Cluster(clusterName).FlowcontrolV1beta2(),
+			workspaceConcurrencyLimit,
+			requestWaitLimit,
+		), workspaceInformers
+	}
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { fc.forward(obj, func(h cache.ResourceEventHandler) { h.OnAdd(obj) }) },
+		UpdateFunc: func(oldObj, newObj interface{}) { fc.forward(newObj, func(h cache.ResourceEventHandler) { h.OnUpdate(oldObj, newObj) }) },
+		DeleteFunc: func(obj interface{}) { fc.forward(obj, func(h cache.ResourceEventHandler) { h.OnDelete(obj) }) },
+	}
+	wildcardInformerFactory.Flowcontrol().V1beta2().FlowSchemas().Informer().AddEventHandler(handler)
+	wildcardInformerFactory.Flowcontrol().V1beta2().PriorityLevelConfigurations().Informer().AddEventHandler(handler)
+
+	return fc
+}
+
+// workspaceAwareFlowControl dispatches requests to the config of their workspace. It embeds the shard's
+// config, which serves the debugging endpoints and tracks the watches used to estimate the work of
+// mutating requests.
+type workspaceAwareFlowControl struct {
+	utilflowcontrol.Interface
+
+	defaultsCluster logicalcluster.LogicalCluster
+	queue           workqueue.RateLimitingInterface
+
+	flowSchemaLister                 flowcontrolv1beta2listers.FlowSchemaLister
+	priorityLevelConfigurationLister flowcontrolv1beta2listers.PriorityLevelConfigurationLister
+	syncedFuncs                      []cache.InformerSynced
+
+	newWorkspaceFlowControl func(clusterName logicalcluster.LogicalCluster) (utilflowcontrol.Interface, *workspaceInformers)
+
+	lock       sync.RWMutex
+	stopCh     <-chan struct{}
+	workspaces map[logicalcluster.LogicalCluster]*workspaceFlowControl
+}
+
+// workspaceFlowControl is the running config of one workspace.
+type workspaceFlowControl struct {
+	utilflowcontrol.Interface
+	informers *workspaceInformers
+	cancel    context.CancelFunc
+}
+
+var _ utilflowcontrol.Interface = (*workspaceAwareFlowControl)(nil)
+
+// forward enqueues the workspace of the object, and passes the event on to the workspace configs that
+// see the object.
+func (fc *workspaceAwareFlowControl) forward(obj interface{}, f func(handler cache.ResourceEventHandler)) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	var clusterName logicalcluster.LogicalCluster
+	switch obj := obj.(type) {
+	case *flowcontrolv1beta2.FlowSchema:
+		clusterName = logicalcluster.From(obj)
+	case *flowcontrolv1beta2.PriorityLevelConfiguration:
+		clusterName = logicalcluster.From(obj)
+	default:
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+	if clusterName != fc.defaultsCluster {
+		fc.queue.Add(clusterName.String())
+	}
+
+	fc.lock.RLock()
+	defer fc.lock.RUnlock()
+	for _, workspace := range fc.workspaces {
+		if workspace.informers.relevant(clusterName) {
+			f(workspace.informers)
+		}
+	}
+}
+
+// Handle dispatches the request to the config of its workspace and then to the shard's config, or only
+// to the shard's config if the workspace has none. The workspace's classification of the request is the
+// one noted.
+func (fc *workspaceAwareFlowControl) Handle(ctx context.Context,
+	requestDigest utilflowcontrol.RequestDigest,
+	noteFn func(fs *flowcontrolv1beta2.FlowSchema, pl *flowcontrolv1beta2.PriorityLevelConfiguration, flowDistinguisher string),
+	workEstimator func() fcrequest.WorkEstimate,
+	queueNoteFn fq.QueueNoteFn,
+	execFn func(),
+) {
+	workspace := fc.forRequest(ctx)
+	if workspace == nil {
+		fc.Interface.Handle(ctx, requestDigest, noteFn, workEstimator, queueNoteFn, execFn)
+		return
+	}
+	workspace.Handle(ctx, requestDigest, noteFn, workEstimator, queueNoteFn, func() {
+		fc.Interface.Handle(ctx, requestDigest, func(*flowcontrolv1beta2.FlowSchema, *flowcontrolv1beta2.PriorityLevelConfiguration, string) {}, workEstimator, queueNoteFn, execFn)
+	})
+}
+
+// forRequest returns the config of the workspace of the request, or nil if it has none.
+func (fc *workspaceAwareFlowControl) forRequest(ctx context.Context) utilflowcontrol.Interface {
+	cluster := request.ClusterFrom(ctx)
+	if cluster == nil || cluster.Wildcard {
+		return nil
+	}
+
+	fc.lock.RLock()
+	defer fc.lock.RUnlock()
+	if workspace, ok := fc.workspaces[cluster.Name]; ok {
+		return workspace
+	}
+	return nil
+}
+
+// Run runs the shard's config, and starts and stops the configs of the workspaces as their
+// FlowSchemas and PriorityLevelConfigurations come and go.
+func (fc *workspaceAwareFlowControl) Run(stopCh <-chan struct{}) error {
+	defer runtime.HandleCrash()
+	defer fc.queue.ShutDown()
+
+	fc.lock.Lock()
+	fc.stopCh = stopCh
+	fc.lock.Unlock()
+
+	go func() {
+		if !cache.WaitForNamedCacheSync(controllerName, stopCh, fc.syncedFuncs...) {
+			return
+		}
+		go wait.Until(fc.startWorker, time.Second, stopCh)
+	}()
+
+	return fc.Interface.Run(stopCh)
+}
+
+func (fc *workspaceAwareFlowControl) startWorker() {
+	for fc.processNextWorkItem() {
+	}
+}
+
+func (fc *workspaceAwareFlowControl) processNextWorkItem() bool {
+	// Wait until there is a new item in the working queue
+	k, quit := fc.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer fc.queue.Done(key)
+
+	if err := fc.sync(logicalcluster.New(key)); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		fc.queue.AddRateLimited(key)
+		return true
+	}
+	fc.queue.Forget(key)
+	return true
+}
+
+// sync starts the config of the workspace if it has FlowSchemas or PriorityLevelConfigurations, and
+// stops it otherwise.
+func (fc *workspaceAwareFlowControl) sync(clusterName logicalcluster.LogicalCluster) error {
+	configured, err := fc.hasConfig(clusterName)
+	if err != nil {
+		return err
+	}
+
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	workspace, running := fc.workspaces[clusterName]
+	switch {
+	case configured && !running:
+		klog.Infof("Starting API priority and fairness config of workspace %s", clusterName)
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-fc.stopCh:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		workspaceInterface, workspaceInformers := fc.newWorkspaceFlowControl(clusterName)
+		fc.workspaces[clusterName] = &workspaceFlowControl{
+			Interface: workspaceInterface,
+			informers: workspaceInformers,
+			cancel:    cancel,
+		}
+		go workspaceInterface.MaintainObservations(ctx.Done())
+		go func() {
+			if err := workspaceInterface.Run(ctx.Done()); err != nil {
+				runtime.HandleError(fmt.Errorf("API priority and fairness config of workspace %s failed: %w", clusterName, err))
+			}
+		}()
+	case !configured && running:
+		klog.Infof("Stopping API priority and fairness config of workspace %s", clusterName)
+		workspace.cancel()
+		delete(fc.workspaces, clusterName)
+	}
+	return nil
+}
+
+func (fc *workspaceAwareFlowControl) hasConfig(clusterName logicalcluster.LogicalCluster) (bool, error) {
+	flowSchemas, err := fc.flowSchemaLister.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, flowSchema := range flowSchemas {
+		if logicalcluster.From(flowSchema) == clusterName {
+			return true, nil
+		}
+	}
+
+	priorityLevelConfigurations, err := fc.priorityLevelConfigurationLister.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, priorityLevelConfiguration := range priorityLevelConfigurations {
+		if logicalcluster.From(priorityLevelConfiguration) == clusterName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
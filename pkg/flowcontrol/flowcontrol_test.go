@@ -0,0 +1,194 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package flowcontrol
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	flowcontrolv1beta2 "k8s.io/api/flowcontrol/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
+	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
+	fcrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+var (
+	defaultsCluster = logicalcluster.New("system:admin")
+	workspace       = logicalcluster.New("root:org:ws")
+)
+
+func flowSchema(clusterName logicalcluster.LogicalCluster, name, priorityLevel string) *flowcontrolv1beta2.FlowSchema {
+	return &flowcontrolv1beta2.FlowSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: clusterName.String()},
+		Spec: flowcontrolv1beta2.FlowSchemaSpec{
+			PriorityLevelConfiguration: flowcontrolv1beta2.PriorityLevelConfigurationReference{Name: priorityLevel},
+		},
+	}
+}
+
+func priorityLevelConfiguration(clusterName logicalcluster.LogicalCluster, name string) *flowcontrolv1beta2.PriorityLevelConfiguration {
+	return &flowcontrolv1beta2.PriorityLevelConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: clusterName.String()},
+	}
+}
+
+func newInformerFactory(t *testing.T, objs ...interface{}) informers.SharedInformerFactory {
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	for _, obj := range objs {
+		var indexer cache.Indexer
+		switch obj.(type) {
+		case *flowcontrolv1beta2.FlowSchema:
+			indexer = factory.Flowcontrol().V1beta2().FlowSchemas().Informer().GetIndexer()
+		case *flowcontrolv1beta2.PriorityLevelConfiguration:
+			indexer = factory.Flowcontrol().V1beta2().PriorityLevelConfigurations().Informer().GetIndexer()
+		}
+		require.NoError(t, indexer.Add(obj))
+	}
+	return factory
+}
+
+func TestMergedFlowSchemaLister(t *testing.T) {
+	factory := newInformerFactory(t,
+		flowSchema(defaultsCluster, "global-default", "global-default"),
+		flowSchema(defaultsCluster, "workload-low", "workload-low"),
+		flowSchema(workspace, "workload-low", "tenant-low"),
+		flowSchema(workspace, "tenant-controllers", "tenant-low"),
+		flowSchema(logicalcluster.New("root:org:other"), "other", "other"),
+	)
+	lister := newWorkspaceInformers(workspace, defaultsCluster, factory).Flowcontrol().V1beta2().FlowSchemas().Lister()
+
+	flowSchemas, err := lister.List(labels.Everything())
+	require.NoError(t, err)
+	got := map[string]string{}
+	for _, flowSchema := range flowSchemas {
+		got[flowSchema.Name] = logicalcluster.From(flowSchema).String()
+	}
+	require.Equal(t, map[string]string{
+		"global-default":     defaultsCluster.String(),
+		"workload-low":       workspace.String(),
+		"tenant-controllers": workspace.String(),
+	}, got)
+
+	flowSchema, err := lister.Get("workload-low")
+	require.NoError(t, err)
+	require.Equal(t, "tenant-low", flowSchema.Spec.PriorityLevelConfiguration.Name, "workspace should override the shard default")
+
+	flowSchema, err = lister.Get("global-default")
+	require.NoError(t, err)
+	require.Equal(t, defaultsCluster, logicalcluster.From(flowSchema))
+
+	_, err = lister.Get("other")
+	require.Error(t, err)
+}
+
+type fakeFlowControl struct {
+	utilflowcontrol.Interface
+	name    string
+	handled *[]string
+}
+
+func (f *fakeFlowControl) Handle(ctx context.Context, requestDigest utilflowcontrol.RequestDigest, noteFn func(fs *flowcontrolv1beta2.FlowSchema, pl *flowcontrolv1beta2.PriorityLevelConfiguration, flowDistinguisher string), workEstimator func() fcrequest.WorkEstimate, queueNoteFn fq.QueueNoteFn, execFn func()) {
+	*f.handled = append(*f.handled, f.name)
+	execFn()
+}
+
+func (f *fakeFlowControl) Run(stopCh <-chan struct{}) error {
+	<-stopCh
+	return nil
+}
+
+func (f *fakeFlowControl) MaintainObservations(stopCh <-chan struct{}) {
+	<-stopCh
+}
+
+func TestWorkspaceAwareFlowControl(t *testing.T) {
+	var handled []string
+	factory := newInformerFactory(t,
+		flowSchema(defaultsCluster, "global-default", "global-default"),
+		priorityLevelConfiguration(workspace, "tenant-low"),
+	)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	fc := &workspaceAwareFlowControl{
+		Interface:                        &fakeFlowControl{name: "shard", handled: &handled},
+		defaultsCluster:                  defaultsCluster,
+		queue:                            workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		flowSchemaLister:                 factory.Flowcontrol().V1beta2().FlowSchemas().Lister(),
+		priorityLevelConfigurationLister: factory.Flowcontrol().V1beta2().PriorityLevelConfigurations().Lister(),
+		stopCh:                           stopCh,
+		workspaces:                       map[logicalcluster.LogicalCluster]*workspaceFlowControl{},
+	}
+	fc.newWorkspaceFlowControl = func(clusterName logicalcluster.LogicalCluster) (utilflowcontrol.Interface, *workspaceInformers) {
+		return &fakeFlowControl{name: clusterName.String(), handled: &handled}, newWorkspaceInformers(clusterName, defaultsCluster, factory)
+	}
+
+	handle := func(cluster *request.Cluster) {
+		ctx := context.Background()
+		if cluster != nil {
+			ctx = request.WithCluster(ctx, *cluster)
+		}
+		fc.Handle(ctx, utilflowcontrol.RequestDigest{}, nil, nil, nil, func() {})
+	}
+
+	require.NoError(t, fc.sync(workspace))
+	require.NoError(t, fc.sync(logicalcluster.New("root:org:other")))
+	require.Len(t, fc.workspaces, 1, "only the workspace with a config should get its own")
+
+	handle(&request.Cluster{Name: workspace})
+	handle(&request.Cluster{Name: logicalcluster.New("root:org:other")})
+	handle(&request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true})
+	handle(nil)
+	require.Equal(t, []string{workspace.String(), "shard", "shard", "shard", "shard"}, handled, "requests of a workspace with a config should occupy a seat of the shard too")
+
+	// events of the workspace and the defaults reach the workspace config, others don't
+	var events []string
+	fc.workspaces[workspace].informers.addEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			events = append(events, obj.(*flowcontrolv1beta2.FlowSchema).Name)
+		},
+	})
+	add := func(obj *flowcontrolv1beta2.FlowSchema) {
+		fc.forward(obj, func(h cache.ResourceEventHandler) { h.OnAdd(obj) })
+	}
+	add(flowSchema(workspace, "a", "tenant-low"))
+	add(flowSchema(defaultsCluster, "b", "global-default"))
+	add(flowSchema(logicalcluster.New("root:org:other"), "c", "other"))
+	sort.Strings(events)
+	require.Equal(t, []string{"a", "b"}, events)
+	require.Equal(t, 2, fc.queue.Len(), "events of workspaces other than the defaults cluster should be enqueued")
+
+	// removing the config of the workspace stops its config
+	indexer := factory.Flowcontrol().V1beta2().PriorityLevelConfigurations().Informer().GetIndexer()
+	require.NoError(t, indexer.Delete(priorityLevelConfiguration(workspace, "tenant-low")))
+	require.NoError(t, fc.sync(workspace))
+	require.Empty(t, fc.workspaces)
+
+	handled = nil
+	handle(&request.Cluster{Name: workspace})
+	require.Equal(t, []string{"shard"}, handled)
+}
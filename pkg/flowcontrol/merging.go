@@ -0,0 +1,264 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package flowcontrol
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	flowcontrolv1beta2 "k8s.io/api/flowcontrol/v1beta2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	flowcontrolinformers "k8s.io/client-go/informers/flowcontrol"
+	flowcontrolv1beta2informers "k8s.io/client-go/informers/flowcontrol/v1beta2"
+	flowcontrolv1beta2listers "k8s.io/client-go/listers/flowcontrol/v1beta2"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+)
+
+// workspaceInformers presents the FlowSchemas and PriorityLevelConfigurations of a workspace, merged
+// with those of the shard defaults cluster, to the upstream API priority and fairness config controller.
+// Objects of the workspace take precedence over shard defaults of the same name.
+//
+// Event handlers added by the config controller are not registered with the wildcard informers, but
+// are notified by the owner of the workspaceInformers. This way they go away with the workspace.
+type workspaceInformers struct {
+	informers.SharedInformerFactory
+
+	clusterName     logicalcluster.LogicalCluster
+	defaultsCluster logicalcluster.LogicalCluster
+	wildcard        flowcontrolv1beta2informers.Interface
+
+	lock     sync.RWMutex
+	handlers []cache.ResourceEventHandler
+}
+
+var _ informers.SharedInformerFactory = (*workspaceInformers)(nil)
+var _ flowcontrolv1beta2informers.Interface = (*workspaceInformers)(nil)
+
+func newWorkspaceInformers(clusterName, defaultsCluster logicalcluster.LogicalCluster, factory informers.SharedInformerFactory) *workspaceInformers {
+	return &workspaceInformers{
+		SharedInformerFactory: factory,
+		clusterName:           clusterName,
+		defaultsCluster:       defaultsCluster,
+		wildcard:              factory.Flowcontrol().V1beta2(),
+	}
+}
+
+func (i *workspaceInformers) Flowcontrol() flowcontrolinformers.Interface {
+	return &flowcontrolGroup{
+		Interface: i.SharedInformerFactory.Flowcontrol(),
+		v1beta2:   i,
+	}
+}
+
+// flowcontrolGroup serves the merged v1beta2 informers. These are the ones the config controller uses.
+type flowcontrolGroup struct {
+	flowcontrolinformers.Interface
+	v1beta2 *workspaceInformers
+}
+
+func (g *flowcontrolGroup) V1beta2() flowcontrolv1beta2informers.Interface {
+	return g.v1beta2
+}
+
+func (i *workspaceInformers) FlowSchemas() flowcontrolv1beta2informers.FlowSchemaInformer {
+	return &mergedFlowSchemaInformer{workspaceInformers: i}
+}
+
+func (i *workspaceInformers) PriorityLevelConfigurations() flowcontrolv1beta2informers.PriorityLevelConfigurationInformer {
+	return &mergedPriorityLevelConfigurationInformer{workspaceInformers: i}
+}
+
+// relevant returns true if changes to objects of the given logical cluster affect the merged view.
+func (i *workspaceInformers) relevant(clusterName logicalcluster.LogicalCluster) bool {
+	return clusterName == i.clusterName || clusterName == i.defaultsCluster
+}
+
+func (i *workspaceInformers) addEventHandler(handler cache.ResourceEventHandler) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.handlers = append(i.handlers, handler)
+}
+
+func (i *workspaceInformers) notify(f func(handler cache.ResourceEventHandler)) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	for _, handler := range i.handlers {
+		f(handler)
+	}
+}
+
+func (i *workspaceInformers) OnAdd(obj interface{}) {
+	i.notify(func(handler cache.ResourceEventHandler) { handler.OnAdd(obj) })
+}
+
+func (i *workspaceInformers) OnUpdate(oldObj, newObj interface{}) {
+	i.notify(func(handler cache.ResourceEventHandler) { handler.OnUpdate(oldObj, newObj) })
+}
+
+func (i *workspaceInformers) OnDelete(obj interface{}) {
+	i.notify(func(handler cache.ResourceEventHandler) { handler.OnDelete(obj) })
+}
+
+// handlerForwardingInformer is the wildcard informer, with event handlers registered with the
+// workspaceInformers instead.
+type handlerForwardingInformer struct {
+	cache.SharedIndexInformer
+	workspaceInformers *workspaceInformers
+}
+
+func (i *handlerForwardingInformer) AddEventHandler(handler cache.ResourceEventHandler) {
+	i.workspaceInformers.addEventHandler(handler)
+}
+
+func (i *handlerForwardingInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, _ time.Duration) {
+	i.workspaceInformers.addEventHandler(handler)
+}
+
+type mergedFlowSchemaInformer struct {
+	*workspaceInformers
+}
+
+func (i *mergedFlowSchemaInformer) Informer() cache.SharedIndexInformer {
+	return &handlerForwardingInformer{
+		SharedIndexInformer: i.wildcard.FlowSchemas().Informer(),
+		workspaceInformers:  i.workspaceInformers,
+	}
+}
+
+func (i *mergedFlowSchemaInformer) Lister() flowcontrolv1beta2listers.FlowSchemaLister {
+	return &mergedFlowSchemaLister{
+		clusterName:     i.clusterName,
+		defaultsCluster: i.defaultsCluster,
+		lister:          i.wildcard.FlowSchemas().Lister(),
+	}
+}
+
+type mergedFlowSchemaLister struct {
+	clusterName     logicalcluster.LogicalCluster
+	defaultsCluster logicalcluster.LogicalCluster
+	lister          flowcontrolv1beta2listers.FlowSchemaLister
+}
+
+func (l *mergedFlowSchemaLister) List(selector labels.Selector) ([]*flowcontrolv1beta2.FlowSchema, error) {
+	return l.ListWithContext(context.TODO(), selector)
+}
+
+func (l *mergedFlowSchemaLister) ListWithContext(ctx context.Context, selector labels.Selector) ([]*flowcontrolv1beta2.FlowSchema, error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	var ret, defaults []*flowcontrolv1beta2.FlowSchema
+	names := map[string]bool{}
+	for _, item := range items {
+		switch logicalcluster.From(item) {
+		case l.clusterName:
+			ret = append(ret, item)
+			names[item.Name] = true
+		case l.defaultsCluster:
+			defaults = append(defaults, item)
+		}
+	}
+	for _, item := range defaults {
+		if !names[item.Name] {
+			ret = append(ret, item)
+		}
+	}
+	return ret, nil
+}
+
+func (l *mergedFlowSchemaLister) Get(name string) (*flowcontrolv1beta2.FlowSchema, error) {
+	return l.GetWithContext(context.TODO(), name)
+}
+
+func (l *mergedFlowSchemaLister) GetWithContext(ctx context.Context, name string) (*flowcontrolv1beta2.FlowSchema, error) {
+	item, err := l.lister.GetWithContext(ctx, clusters.ToClusterAwareKey(l.clusterName, name))
+	if apierrors.IsNotFound(err) {
+		return l.lister.GetWithContext(ctx, clusters.ToClusterAwareKey(l.defaultsCluster, name))
+	}
+	return item, err
+}
+
+type mergedPriorityLevelConfigurationInformer struct {
+	*workspaceInformers
+}
+
+func (i *mergedPriorityLevelConfigurationInformer) Informer() cache.SharedIndexInformer {
+	return &handlerForwardingInformer{
+		SharedIndexInformer: i.wildcard.PriorityLevelConfigurations().Informer(),
+		workspaceInformers:  i.workspaceInformers,
+	}
+}
+
+func (i *mergedPriorityLevelConfigurationInformer) Lister() flowcontrolv1beta2listers.PriorityLevelConfigurationLister {
+	return &mergedPriorityLevelConfigurationLister{
+		clusterName:     i.clusterName,
+		defaultsCluster: i.defaultsCluster,
+		lister:          i.wildcard.PriorityLevelConfigurations().Lister(),
+	}
+}
+
+type mergedPriorityLevelConfigurationLister struct {
+	clusterName     logicalcluster.LogicalCluster
+	defaultsCluster logicalcluster.LogicalCluster
+	lister          flowcontrolv1beta2listers.PriorityLevelConfigurationLister
+}
+
+func (l *mergedPriorityLevelConfigurationLister) List(selector labels.Selector) ([]*flowcontrolv1beta2.PriorityLevelConfiguration, error) {
+	return l.ListWithContext(context.TODO(), selector)
+}
+
+func (l *mergedPriorityLevelConfigurationLister) ListWithContext(ctx context.Context, selector labels.Selector) ([]*flowcontrolv1beta2.PriorityLevelConfiguration, error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	var ret, defaults []*flowcontrolv1beta2.PriorityLevelConfiguration
+	names := map[string]bool{}
+	for _, item := range items {
+		switch logicalcluster.From(item) {
+		case l.clusterName:
+			ret = append(ret, item)
+			names[item.Name] = true
+		case l.defaultsCluster:
+			defaults = append(defaults, item)
+		}
+	}
+	for _, item := range defaults {
+		if !names[item.Name] {
+			ret = append(ret, item)
+		}
+	}
+	return ret, nil
+}
+
+func (l *mergedPriorityLevelConfigurationLister) Get(name string) (*flowcontrolv1beta2.PriorityLevelConfiguration, error) {
+	return l.GetWithContext(context.TODO(), name)
+}
+
+func (l *mergedPriorityLevelConfigurationLister) GetWithContext(ctx context.Context, name string) (*flowcontrolv1beta2.PriorityLevelConfiguration, error) {
+	item, err := l.lister.GetWithContext(ctx, clusters.ToClusterAwareKey(l.clusterName, name))
+	if apierrors.IsNotFound(err) {
+		return l.lister.GetWithContext(ctx, clusters.ToClusterAwareKey(l.defaultsCluster, name))
+	}
+	return item, err
+}
@@ -21,17 +21,22 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 	"go.uber.org/multierr"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
@@ -48,21 +53,46 @@ type clusterDiscovery interface {
 
 // DynamicDiscoverySharedInformerFactory is a SharedInformerFactory that
 // dynamically discovers new types and begins informing on them.
+//
+// Informers are started lazily: a discovered type is only informed on once
+// objects of it exist in some workspace, and its informer is stopped again
+// when the type is not discovered anymore or when its last object is gone.
+// Both are checked every poll interval.
 type DynamicDiscoverySharedInformerFactory struct {
 	workspaceLister tenancylisters.ClusterWorkspaceLister
 	disco           clusterDiscovery
-	dsif            dynamicinformer.DynamicSharedInformerFactory
 	handler         GVREventHandler
 	filterFunc      func(interface{}) bool
 	pollInterval    time.Duration
 
-	mu   sync.RWMutex // guards gvrs
-	gvrs sets.String
+	// newInformer returns a new, not started informer for the given type.
+	newInformer func(gvr schema.GroupVersionResource) informers.GenericInformer
+	// hasObjects returns whether objects of the given type exist in any workspace.
+	hasObjects func(ctx context.Context, gvr schema.GroupVersionResource) (bool, error)
+
+	mu        sync.RWMutex // guards informers
+	informers map[schema.GroupVersionResource]*gvrInformer
+}
+
+// gvrInformer is a started informer of one type.
+type gvrInformer struct {
+	informers.GenericInformer
+	stop context.CancelFunc
+
+	// objects counts the objects in the store of the informer.
+	objects int64
 }
 
-// IndexerFor returns the indexer for the given type GVR.
+// IndexerFor returns the indexer for the given type GVR, which is empty if the
+// type is not informed on.
 func (d *DynamicDiscoverySharedInformerFactory) IndexerFor(gvr schema.GroupVersionResource) cache.Indexer {
-	return d.dsif.ForResource(gvr).Informer().GetIndexer()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if inf, found := d.informers[gvr]; found {
+		return inf.Informer().GetIndexer()
+	}
+	return cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
 }
 
 // Listers returns a map of per-resource-type listers for all types that are
@@ -76,19 +106,13 @@ func (d *DynamicDiscoverySharedInformerFactory) Listers() (listers map[schema.Gr
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
-	for _, gvrstr := range d.gvrs.UnsortedList() {
-		gvr, _ := schema.ParseResourceArg(gvrstr)
-		if gvr == nil {
-			klog.Errorf("parsing GVR string %q", gvrstr)
-			continue
-		}
-
-		if !d.dsif.ForResource(*gvr).Informer().HasSynced() {
-			notSynced = append(notSynced, *gvr)
+	for gvr, inf := range d.informers {
+		if !inf.Informer().HasSynced() {
+			notSynced = append(notSynced, gvr)
 			continue
 		}
 
-		listers[*gvr] = d.dsif.ForResource(*gvr).Lister()
+		listers[gvr] = inf.Lister()
 	}
 	return listers, notSynced
 }
@@ -104,15 +128,62 @@ func NewDynamicDiscoverySharedInformerFactory(
 	handler GVREventHandler,
 	pollInterval time.Duration,
 ) DynamicDiscoverySharedInformerFactory {
-	dsif := dynamicinformer.NewDynamicSharedInformerFactory(dynClient, resyncPeriod)
+	newInformer := func(gvr schema.GroupVersionResource) informers.GenericInformer {
+		return dynamicinformer.NewFilteredDynamicInformer(dynClient, gvr, metav1.NamespaceAll, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil)
+	}
+	hasObjects := func(ctx context.Context, gvr schema.GroupVersionResource) (bool, error) {
+		list, err := dynClient.Resource(gvr).List(ctx, metav1.ListOptions{Limit: 1})
+		if err != nil {
+			return false, err
+		}
+		return len(list.Items) > 0, nil
+	}
+	return newDiscoverySharedInformerFactory(workspaceLister, disco, newInformer, hasObjects, filterFunc, handler, pollInterval)
+}
+
+// NewMetadataDiscoverySharedInformerFactory returns a factory like NewDynamicDiscoverySharedInformerFactory,
+// but with informers that only store the metadata of the resources, as *metav1.PartialObjectMetadata.
+// Controllers that don't need more than the metadata of the objects should use it, as typed metadata takes
+// a fraction of the memory of the unstructured objects.
+func NewMetadataDiscoverySharedInformerFactory(
+	workspaceLister tenancylisters.ClusterWorkspaceLister,
+	disco clusterDiscovery,
+	metadataClient metadata.Interface,
+	filterFunc func(obj interface{}) bool,
+	handler GVREventHandler,
+	pollInterval time.Duration,
+) DynamicDiscoverySharedInformerFactory {
+	newInformer := func(gvr schema.GroupVersionResource) informers.GenericInformer {
+		return metadatainformer.NewFilteredMetadataInformer(metadataClient, gvr, metav1.NamespaceAll, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil)
+	}
+	hasObjects := func(ctx context.Context, gvr schema.GroupVersionResource) (bool, error) {
+		list, err := metadataClient.Resource(gvr).List(ctx, metav1.ListOptions{Limit: 1})
+		if err != nil {
+			return false, err
+		}
+		return len(list.Items) > 0, nil
+	}
+	return newDiscoverySharedInformerFactory(workspaceLister, disco, newInformer, hasObjects, filterFunc, handler, pollInterval)
+}
+
+func newDiscoverySharedInformerFactory(
+	workspaceLister tenancylisters.ClusterWorkspaceLister,
+	disco clusterDiscovery,
+	newInformer func(gvr schema.GroupVersionResource) informers.GenericInformer,
+	hasObjects func(ctx context.Context, gvr schema.GroupVersionResource) (bool, error),
+	filterFunc func(obj interface{}) bool,
+	handler GVREventHandler,
+	pollInterval time.Duration,
+) DynamicDiscoverySharedInformerFactory {
 	return DynamicDiscoverySharedInformerFactory{
 		workspaceLister: workspaceLister,
 		disco:           disco,
-		dsif:            dsif,
 		handler:         handler,
 		filterFunc:      filterFunc,
-		gvrs:            sets.NewString(),
 		pollInterval:    pollInterval,
+		newInformer:     newInformer,
+		hasObjects:      hasObjects,
+		informers:       map[schema.GroupVersionResource]*gvrInformer{},
 	}
 }
 
@@ -217,37 +288,82 @@ func (d *DynamicDiscoverySharedInformerFactory) discoverTypes(ctx context.Contex
 		}
 	}
 
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
-	// Set up informers for any new types that have been discovered.
-	// TODO: Stop informers for types we no longer have.
-	newGVRs := latest.Difference(d.gvrs)
 	var merr error
-	for _, gvrstr := range newGVRs.UnsortedList() {
-		klog.Infof("Adding informer for %q in %s", gvrstr, logicalClusterNames)
+	discovered := map[schema.GroupVersionResource]bool{}
+	for _, gvrstr := range latest.UnsortedList() {
 		gvr, _ := schema.ParseResourceArg(gvrstr)
 		if gvr == nil {
 			// TODO(ncdc): consider tracking where each gvrstr came from (which workspace) so we can include that in the error.
 			multierr.AppendInto(&merr, fmt.Errorf("unable to parse %q to a GroupVersionResource", gvrstr))
 			continue
 		}
+		discovered[*gvr] = true
+	}
 
-		inf := d.dsif.ForResource(*gvr).Informer()
-		inf.AddEventHandler(cache.FilteringResourceEventHandler{
-			FilterFunc: d.filterFunc,
-			Handler: cache.ResourceEventHandlerFuncs{
-				AddFunc:    func(obj interface{}) { d.handler.OnAdd(*gvr, obj) },
-				UpdateFunc: func(oldObj, newObj interface{}) { d.handler.OnUpdate(*gvr, oldObj, newObj) },
-				DeleteFunc: func(obj interface{}) { d.handler.OnDelete(*gvr, obj) },
-			},
-		})
-		go inf.Run(ctx.Done())
+	// Stop informers of types that are gone, or that have no objects anymore.
+	d.mu.Lock()
+	for gvr, inf := range d.informers {
+		if discovered[gvr] && (!inf.Informer().HasSynced() || atomic.LoadInt64(&inf.objects) > 0) {
+			continue
+		}
+		klog.Infof("Stopping informer for %q", gvr)
+		inf.stop()
+		delete(d.informers, gvr)
+	}
+	var candidates []schema.GroupVersionResource
+	for gvr := range discovered {
+		if _, found := d.informers[gvr]; !found {
+			candidates = append(candidates, gvr)
+		}
 	}
-	if merr != nil {
-		return merr
+	d.mu.Unlock()
+
+	// Start informers for types with objects. The lists are done without holding the lock.
+	var withObjects []schema.GroupVersionResource
+	for _, gvr := range candidates {
+		found, err := d.hasObjects(ctx, gvr)
+		if err != nil {
+			multierr.AppendInto(&merr, fmt.Errorf("failed to list %q: %w", gvr, err))
+			continue
+		}
+		if found {
+			withObjects = append(withObjects, gvr)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, gvr := range withObjects {
+		if _, found := d.informers[gvr]; found {
+			continue
+		}
+		klog.Infof("Adding informer for %q in %s", gvr, logicalClusterNames)
+		d.informers[gvr] = d.startInformer(ctx, gvr)
+	}
+
+	return merr
+}
+
+func (d *DynamicDiscoverySharedInformerFactory) startInformer(ctx context.Context, gvr schema.GroupVersionResource) *gvrInformer {
+	ctx, cancel := context.WithCancel(ctx)
+	inf := &gvrInformer{
+		GenericInformer: d.newInformer(gvr),
+		stop:            cancel,
 	}
 
-	d.gvrs = latest
-	return nil
+	inf.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { atomic.AddInt64(&inf.objects, 1) },
+		DeleteFunc: func(obj interface{}) { atomic.AddInt64(&inf.objects, -1) },
+	})
+	inf.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: d.filterFunc,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { d.handler.OnAdd(gvr, obj) },
+			UpdateFunc: func(oldObj, newObj interface{}) { d.handler.OnUpdate(gvr, oldObj, newObj) },
+			DeleteFunc: func(obj interface{}) { d.handler.OnDelete(gvr, obj) },
+		},
+	})
+	go inf.Informer().Run(ctx.Done())
+
+	return inf
 }
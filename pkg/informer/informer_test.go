@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+type fakeDiscovery struct {
+	discovery.DiscoveryInterface
+	resources []*metav1.APIResourceList
+}
+
+func (d fakeDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return d.resources, nil
+}
+
+type fakeClusterDiscovery struct {
+	*fakeDiscovery
+}
+
+func (d fakeClusterDiscovery) WithCluster(logicalcluster.LogicalCluster) discovery.DiscoveryInterface {
+	return d.fakeDiscovery
+}
+
+func TestLazyInformers(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	widgets := schema.GroupVersionResource{Group: "example.dev", Version: "v1", Resource: "widgets"}
+	verbs := metav1.Verbs{"list", "watch"}
+
+	workspaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, workspaces.Add(&tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "ws", ClusterName: "root:org"}}))
+
+	disco := &fakeDiscovery{resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "configmaps", Namespaced: true, Verbs: verbs}}},
+		{GroupVersion: "example.dev/v1", APIResources: []metav1.APIResource{{Name: "widgets", Namespaced: true, Verbs: verbs}}},
+	}}
+
+	configMap := &unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	configMap.SetNamespace("default")
+	configMap.SetName("cm")
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMaps: "ConfigMapList",
+		widgets:    "WidgetList",
+	}, configMap)
+
+	var adds int64
+	d := NewDynamicDiscoverySharedInformerFactory(tenancylisters.NewClusterWorkspaceLister(workspaces), fakeClusterDiscovery{disco}, client,
+		func(obj interface{}) bool { return true },
+		GVREventHandlerFuncs{AddFunc: func(gvr schema.GroupVersionResource, obj interface{}) { atomic.AddInt64(&adds, 1) }},
+		time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	informed := func() []schema.GroupVersionResource {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+		var gvrs []schema.GroupVersionResource
+		for gvr := range d.informers {
+			gvrs = append(gvrs, gvr)
+		}
+		return gvrs
+	}
+
+	t.Log("Only types with objects are informed on")
+	require.NoError(t, d.discoverTypes(ctx))
+	require.Equal(t, []schema.GroupVersionResource{configMaps}, informed())
+	require.Eventually(t, func() bool {
+		_, notSynced := d.Listers()
+		return len(notSynced) == 0 && atomic.LoadInt64(&adds) == 1
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+	_, exists, err := d.IndexerFor(configMaps).GetByKey("default/cm")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	t.Log("The informer is started with the first object")
+	widget := &unstructured.Unstructured{}
+	widget.SetAPIVersion("example.dev/v1")
+	widget.SetKind("Widget")
+	widget.SetNamespace("default")
+	widget.SetName("w")
+	_, err = client.Resource(widgets).Namespace("default").Create(ctx, widget, metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, d.discoverTypes(ctx))
+	require.ElementsMatch(t, []schema.GroupVersionResource{configMaps, widgets}, informed())
+
+	t.Log("The informer is stopped when the last object is gone")
+	require.NoError(t, client.Resource(configMaps).Namespace("default").Delete(ctx, "cm", metav1.DeleteOptions{}))
+	require.Eventually(t, func() bool {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+		return atomic.LoadInt64(&d.informers[configMaps].objects) == 0
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+	require.NoError(t, d.discoverTypes(ctx))
+	require.Equal(t, []schema.GroupVersionResource{widgets}, informed())
+	_, exists, err = d.IndexerFor(configMaps).GetByKey("default/cm")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	t.Log("The informer is stopped when the type is gone")
+	disco.resources = disco.resources[:1]
+	require.NoError(t, d.discoverTypes(ctx))
+	require.Empty(t, informed())
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package informer
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kcp-dev/kcp/pkg/features"
+)
+
+// IsResync returns whether an update event carries an unchanged object, i.e. the resourceVersion
+// is the same before and after. These are sent by the informers for every object when relisting
+// after the watch was reconnected, and on periodic resyncs.
+func IsResync(oldObj, newObj interface{}) bool {
+	oldMeta, err := meta.Accessor(oldObj)
+	if err != nil {
+		return false
+	}
+	newMeta, err := meta.Accessor(newObj)
+	if err != nil {
+		return false
+	}
+	return oldMeta.GetResourceVersion() != "" && oldMeta.GetResourceVersion() == newMeta.GetResourceVersion()
+}
+
+// SkipResyncs returns a handler passing the events on to the given handler. If the SkipResyncUpdates
+// feature gate is enabled, the updates of unchanged objects are dropped, such that an informer
+// reconnecting to a restarted shard doesn't enqueue every object again.
+func SkipResyncs(handler cache.ResourceEventHandler) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: handler.OnAdd,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if utilfeature.DefaultFeatureGate.Enabled(features.SkipResyncUpdates) && IsResync(oldObj, newObj) {
+				return
+			}
+			handler.OnUpdate(oldObj, newObj)
+		},
+		DeleteFunc: handler.OnDelete,
+	}
+}
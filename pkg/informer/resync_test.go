@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package informer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/tools/cache"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+
+	"github.com/kcp-dev/kcp/pkg/features"
+)
+
+func TestSkipResyncs(t *testing.T) {
+	configMap := func(resourceVersion string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", ResourceVersion: resourceVersion}}
+	}
+
+	tests := map[string]struct {
+		gateEnabled bool
+		oldObj      interface{}
+		newObj      interface{}
+		wantUpdate  bool
+	}{
+		"changed": {
+			gateEnabled: true,
+			oldObj:      configMap("1"),
+			newObj:      configMap("2"),
+			wantUpdate:  true,
+		},
+		"resync": {
+			gateEnabled: true,
+			oldObj:      configMap("1"),
+			newObj:      configMap("1"),
+		},
+		"resync with gate disabled": {
+			oldObj:     configMap("1"),
+			newObj:     configMap("1"),
+			wantUpdate: true,
+		},
+		"no resourceVersion": {
+			gateEnabled: true,
+			oldObj:      configMap(""),
+			newObj:      configMap(""),
+			wantUpdate:  true,
+		},
+		"no object": {
+			gateEnabled: true,
+			oldObj:      "foo",
+			newObj:      "foo",
+			wantUpdate:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.SkipResyncUpdates, tc.gateEnabled)()
+
+			var adds, updates, deletes int
+			handler := SkipResyncs(cache.ResourceEventHandlerFuncs{
+				AddFunc:    func(obj interface{}) { adds++ },
+				UpdateFunc: func(oldObj, newObj interface{}) { updates++ },
+				DeleteFunc: func(obj interface{}) { deletes++ },
+			})
+
+			handler.OnAdd(tc.newObj)
+			handler.OnUpdate(tc.oldObj, tc.newObj)
+			handler.OnDelete(tc.newObj)
+
+			require.Equal(t, 1, adds, "adds")
+			require.Equal(t, tc.wantUpdate, updates == 1, "updates")
+			require.Equal(t, 1, deletes, "deletes")
+		})
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package initialization
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// InitializerLabelKey is set on the Leases of the initializer locks, to the initializer they
+	// coordinate.
+	InitializerLabelKey = "tenancy.kcp.dev/initializer"
+)
+
+// Lock coordinates the replicas of an initialization controller, such that only one of them
+// initializes a given ClusterWorkspace at a time. It is a coordination.k8s.io Lease in the default
+// namespace of the parent workspace, named after the initializer and the workspace.
+//
+// A replica initializes the workspace only after TryAcquire succeeded, and releases the lock after
+// it removed its initializer from the workspace. A lock of a replica that crashed expires after
+// the lease duration, and is then taken over by another replica.
+type Lock struct {
+	client      coordinationv1client.LeasesGetter
+	initializer tenancyv1alpha1.ClusterWorkspaceInitializer
+	name        string
+	identity    string
+	duration    time.Duration
+
+	now func() time.Time
+}
+
+// NewLock returns the lock of the initializer on the given workspace. The client must target the
+// parent workspace, and identity must be unique per replica, e.g. the pod name.
+func NewLock(client coordinationv1client.LeasesGetter, initializer tenancyv1alpha1.ClusterWorkspaceInitializer, workspace, identity string, duration time.Duration) *Lock {
+	return &Lock{
+		client:      client,
+		initializer: initializer,
+		name:        LeaseName(initializer, workspace),
+		identity:    identity,
+		duration:    duration,
+		now:         time.Now,
+	}
+}
+
+// LeaseName returns the name of the Lease of the lock of the initializer on the given workspace.
+func LeaseName(initializer tenancyv1alpha1.ClusterWorkspaceInitializer, workspace string) string {
+	// initializers are free-form strings, the lease name must be a DNS subdomain
+	name := strings.NewReplacer(":", "-", "/", "-", "_", "-").Replace(strings.ToLower(string(initializer)))
+	return fmt.Sprintf("initializer-%s-%s", name, workspace)
+}
+
+// TryAcquire acquires or renews the lock. It returns false without error if another replica holds
+// an unexpired lock, or has acquired it concurrently.
+func (l *Lock) TryAcquire(ctx context.Context) (bool, error) {
+	leases := l.client.Leases(metav1.NamespaceDefault)
+	now := metav1.NewMicroTime(l.now())
+	durationSeconds := int32(l.duration / time.Second)
+
+	existing, err := leases.Get(ctx, l.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		lease := &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   l.name,
+				Labels: map[string]string{InitializerLabelKey: labelValue(l.initializer)},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &l.identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if _, err := leases.Create(ctx, lease, metav1.CreateOptions{}); errors.IsAlreadyExists(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	lease := existing.DeepCopy()
+	if !heldBy(existing, l.identity) {
+		if !l.expired(existing) {
+			return false, nil
+		}
+		lease.Spec.HolderIdentity = &l.identity
+		lease.Spec.AcquireTime = &now
+		transitions := int32(0)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions
+		}
+		transitions++
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.RenewTime = &now
+
+	// the resourceVersion of the existing lease makes concurrent acquisitions conflict
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); errors.IsConflict(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release deletes the lock if it is held by this replica.
+func (l *Lock) Release(ctx context.Context) error {
+	leases := l.client.Leases(metav1.NamespaceDefault)
+	existing, err := leases.Get(ctx, l.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !heldBy(existing, l.identity) {
+		return nil
+	}
+
+	err = leases.Delete(ctx, l.name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &existing.UID, ResourceVersion: &existing.ResourceVersion},
+	})
+	if errors.IsNotFound(err) || errors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
+func heldBy(lease *coordinationv1.Lease, identity string) bool {
+	return lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == identity
+}
+
+func (l *Lock) expired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return true
+	}
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return !l.now().Before(expiry)
+}
+
+// labelValue returns the initializer as label value, which must not contain colons or slashes.
+func labelValue(initializer tenancyv1alpha1.ClusterWorkspaceInitializer) string {
+	value := strings.NewReplacer(":", ".", "/", ".").Replace(string(initializer))
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.Trim(value, "._-")
+}
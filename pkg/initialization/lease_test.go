@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package initialization
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/pointer"
+)
+
+func TestLeaseName(t *testing.T) {
+	require.Equal(t, "initializer-system-apibindings-team", LeaseName("system:apibindings", "team"))
+	require.Equal(t, "initializer-example.com-setup-team", LeaseName("example.com/Setup", "team"))
+}
+
+func TestLock(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		existing *coordinationv1.Lease
+		identity string
+
+		wantAcquired bool
+		wantHolder   string
+		wantReleased bool
+	}{
+		"no lease": {
+			identity:     "a",
+			wantAcquired: true,
+			wantHolder:   "a",
+			wantReleased: true,
+		},
+		"held by another replica": {
+			existing:   lease("b", now.Add(-10*time.Second)),
+			identity:   "a",
+			wantHolder: "b",
+		},
+		"renewed": {
+			existing:     lease("a", now.Add(-10*time.Second)),
+			identity:     "a",
+			wantAcquired: true,
+			wantHolder:   "a",
+			wantReleased: true,
+		},
+		"expired": {
+			existing:     lease("b", now.Add(-time.Minute)),
+			identity:     "a",
+			wantAcquired: true,
+			wantHolder:   "a",
+			wantReleased: true,
+		},
+		"released": {
+			existing:     &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "initializer-example-team", Namespace: metav1.NamespaceDefault}},
+			identity:     "a",
+			wantAcquired: true,
+			wantHolder:   "a",
+			wantReleased: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.NewSimpleClientset()
+			if tc.existing != nil {
+				client = fake.NewSimpleClientset(tc.existing)
+			}
+
+			lock := NewLock(client.CoordinationV1(), "example", "team", tc.identity, 30*time.Second)
+			lock.now = func() time.Time { return now }
+
+			acquired, err := lock.TryAcquire(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantAcquired, acquired, "acquired")
+
+			got, err := client.CoordinationV1().Leases(metav1.NamespaceDefault).Get(ctx, "initializer-example-team", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tc.wantHolder, *got.Spec.HolderIdentity)
+			if tc.wantAcquired {
+				require.Equal(t, now, got.Spec.RenewTime.Time)
+				require.Equal(t, int32(30), *got.Spec.LeaseDurationSeconds)
+			}
+
+			require.NoError(t, lock.Release(ctx))
+			_, err = client.CoordinationV1().Leases(metav1.NamespaceDefault).Get(ctx, "initializer-example-team", metav1.GetOptions{})
+			require.Equal(t, tc.wantReleased, errors.IsNotFound(err), "released")
+		})
+	}
+}
+
+func lease(holder string, renewTime time.Time) *coordinationv1.Lease {
+	renew := metav1.NewMicroTime(renewTime)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "initializer-example-team", Namespace: metav1.NamespaceDefault},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       pointer.StringPtr(holder),
+			LeaseDurationSeconds: pointer.Int32Ptr(30),
+			RenewTime:            &renew,
+		},
+	}
+}
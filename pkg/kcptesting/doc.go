@@ -0,0 +1,29 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kcptesting provides the helpers the kcp e2e tests are built on, for projects writing
+// integration tests against kcp: starting kcp servers in-process, creating organization and
+// workspace fixtures, running a syncer against a fake workload cluster, and waiting for
+// expectations on workspaces.
+//
+// A test typically starts with
+//
+//	server := kcptesting.PrivateServer(t)
+//	org := kcptesting.NewOrganizationFixture(t, server)
+//	ws := kcptesting.NewWorkspaceFixture(t, server, org, "Universal")
+//
+// Everything a helper creates is cleaned up when the test ends.
+package kcptesting
@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package framework
+package kcptesting
 
 import (
 	"testing"
@@ -24,6 +24,7 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+// RunningServer is a kcp server started by a test, or one the test is pointed at.
 type RunningServer interface {
 	Name() string
 	KubeconfigPath() string
@@ -32,13 +33,15 @@ type RunningServer interface {
 	Artifact(t *testing.T, producer func() (runtime.Object, error))
 }
 
-// kcpConfig qualify a kcp server to start
-//
-// Deprecated for use outside this package. Prefer PrivateKcpServer().
-type kcpConfig struct {
+// ServerConfig qualifies a kcp server to start.
+type ServerConfig struct {
 	Name string
 	Args []string
 
+	// Command is the command line starting kcp, e.g. "kcp start", without the args. If empty,
+	// the server runs in-process.
+	Command []string
+
 	LogToConsole bool
 	RunInProcess bool
 }
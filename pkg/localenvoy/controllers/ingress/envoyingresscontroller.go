@@ -149,24 +149,25 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if requeue, err := c.process(ctx, key); err != nil {
+	requeueAfter, err := c.process(ctx, key)
+	if err != nil {
 		runtime.HandleError(fmt.Errorf("failed to sync %q: %w", key, err))
 		c.queue.AddRateLimited(key)
 		return true
-	} else if requeue {
-		c.queue.AddAfter(key, time.Minute)
-		return true
 	}
 
 	c.queue.Forget(key)
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
 	return true
 }
 
-func (c *Controller) process(ctx context.Context, key string) (requeue bool, err error) {
+func (c *Controller) process(ctx context.Context, key string) (requeueAfter time.Duration, err error) {
 	obj, exists, err := c.ingressIndexer.GetByKey(key)
 	if err != nil {
 		klog.Errorf("Failed to get Ingress with key %q because: %v", key, err)
-		return true, nil
+		return time.Minute, nil
 	}
 
 	if !exists {
@@ -174,17 +175,17 @@ func (c *Controller) process(ctx context.Context, key string) (requeue bool, err
 
 		if err := c.ecp.UpdateEnvoyConfig(ctx); err != nil {
 			klog.Errorf("Error setting Envoy snapshot: %v", err)
-			return true, nil
+			return time.Minute, nil
 		}
 
-		return false, nil
+		return 0, nil
 	}
 
 	current := obj.(*networkingv1.Ingress)
 	previous := current.DeepCopy()
 
 	if err := c.reconcile(ctx, current); err != nil {
-		return false, err
+		return 0, err
 	}
 	if !equality.Semantic.DeepEqual(previous, current) {
 		if current.Labels[envoycontrolplane.ToEnvoyLabel] == "" {
@@ -192,24 +193,24 @@ func (c *Controller) process(ctx context.Context, key string) (requeue bool, err
 			// TODO(jmprusi): Move to patch instead of Update.
 			_, err := c.client.Cluster(logicalcluster.From(current)).NetworkingV1().Ingresses(current.Namespace).UpdateStatus(ctx, current, metav1.UpdateOptions{})
 			if err != nil {
-				return false, err
+				return 0, err
 			}
 		} else {
 			// If it's a leaf, we need to patch only non-status (to set labels)
 			// TODO(jmprusi): Move to patch instead of Update.
 			_, err := c.client.Cluster(logicalcluster.From(current)).NetworkingV1().Ingresses(current.Namespace).Update(ctx, current, metav1.UpdateOptions{})
 			if err != nil {
-				return false, err
+				return 0, err
 			}
 		}
 	}
 
 	if err = c.ecp.UpdateEnvoyConfig(ctx); err != nil {
 		klog.Errorf("failed setting Envoy snapshot: %w", err)
-		return true, nil
+		return time.Minute, nil
 	}
 
-	return false, nil
+	return 0, nil
 }
 
 func rootIngressKeyFor(ingress metav1.Object) string {
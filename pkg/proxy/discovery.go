@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+)
+
+const maxDiscoveryBodyBytes = 4 << 20
+
+// DiscoveryCache caches the discovery responses of the shards per workspace and user, until
+// the TTL expires or the workspace is invalidated.
+type DiscoveryCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	lock      sync.Mutex
+	entries   map[logicalcluster.LogicalCluster]map[string]*discoveryResponse
+	lastPrune time.Time
+}
+
+type discoveryResponse struct {
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+func NewDiscoveryCache(ttl time.Duration) *DiscoveryCache {
+	return &DiscoveryCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[logicalcluster.LogicalCluster]map[string]*discoveryResponse{},
+	}
+}
+
+// Invalidate drops the cached responses of the workspace.
+func (c *DiscoveryCache) Invalidate(cluster logicalcluster.LogicalCluster) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.entries, cluster)
+}
+
+func (c *DiscoveryCache) get(cluster logicalcluster.LogicalCluster, key string) (*discoveryResponse, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	resp, found := c.entries[cluster][key]
+	if !found || !c.now().Before(resp.expires) {
+		return nil, false
+	}
+	return resp, true
+}
+
+func (c *DiscoveryCache) set(cluster logicalcluster.LogicalCluster, key string, resp *discoveryResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastPrune) > c.ttl {
+		for cluster, responses := range c.entries {
+			for key, resp := range responses {
+				if !now.Before(resp.expires) {
+					delete(responses, key)
+				}
+			}
+			if len(responses) == 0 {
+				delete(c.entries, cluster)
+			}
+		}
+		c.lastPrune = now
+	}
+
+	resp.expires = now.Add(c.ttl)
+	if c.entries[cluster] == nil {
+		c.entries[cluster] = map[string]*discoveryResponse{}
+	}
+	c.entries[cluster][key] = resp
+}
+
+// WithShardDiscovery serves the discovery requests to workspaces, i.e. /clusters/<workspace>/api
+// and /clusters/<workspace>/apis with their groups and versions, from the shard the workspace
+// lives on, impersonating the user authenticated by client certificate. Successful responses are
+// cached. Other requests, and those to workspaces not found in the index, are served by handler.
+func WithShardDiscovery(handler http.Handler, index *ShardIndex, discoveryCache *DiscoveryCache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cluster, ok := discoveryCluster(req)
+		if !ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		u, ok := request.UserFrom(req.Context())
+		if !ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		backend, ok := index.lookup(cluster)
+		if !ok {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		groups := append([]string(nil), u.GetGroups()...)
+		sort.Strings(groups)
+		accept := req.Header.Get("Accept")
+		key := strings.Join(append([]string{req.URL.Path, accept, u.GetName()}, groups...), "\x00")
+		if resp, found := discoveryCache.get(cluster, key); found {
+			writeDiscoveryResponse(w, http.StatusOK, resp)
+			return
+		}
+
+		shardReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, backend.host+req.URL.Path, nil)
+		if err != nil {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		if accept != "" {
+			shardReq.Header.Set("Accept", accept)
+		}
+		shardReq.Header.Set("Impersonate-User", u.GetName())
+		for _, group := range u.GetGroups() {
+			shardReq.Header.Add("Impersonate-Group", group)
+		}
+
+		shardResp, err := backend.client.Do(shardReq)
+		if err != nil {
+			klog.V(2).Infof("Failed to get discovery %s from %s: %v", req.URL.Path, backend.host, err)
+			http.Error(w, "failed to get discovery from the shard of the workspace", http.StatusBadGateway)
+			return
+		}
+		defer shardResp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(shardResp.Body, maxDiscoveryBodyBytes))
+		if err != nil {
+			klog.V(2).Infof("Failed to read discovery %s from %s: %v", req.URL.Path, backend.host, err)
+			http.Error(w, "failed to get discovery from the shard of the workspace", http.StatusBadGateway)
+			return
+		}
+
+		resp := &discoveryResponse{contentType: shardResp.Header.Get("Content-Type"), body: body}
+		if shardResp.StatusCode == http.StatusOK {
+			discoveryCache.set(cluster, key, resp)
+		}
+		writeDiscoveryResponse(w, shardResp.StatusCode, resp)
+	})
+}
+
+// discoveryCluster returns the workspace of a discovery request.
+func discoveryCluster(req *http.Request) (logicalcluster.LogicalCluster, bool) {
+	if req.Method != http.MethodGet || !strings.HasPrefix(req.URL.Path, "/clusters/") {
+		return logicalcluster.LogicalCluster{}, false
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/clusters/"), "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[0] == logicalcluster.Wildcard.String() {
+		return logicalcluster.LogicalCluster{}, false
+	}
+	switch {
+	case parts[1] == "api" && len(parts) <= 3:
+	case parts[1] == "apis" && len(parts) <= 4:
+	default:
+		return logicalcluster.LogicalCluster{}, false
+	}
+	return logicalcluster.New(parts[0]), true
+}
+
+func writeDiscoveryResponse(w http.ResponseWriter, code int, resp *discoveryResponse) {
+	if resp.contentType != "" {
+		w.Header().Set("Content-Type", resp.contentType)
+	}
+	w.WriteHeader(code)
+	w.Write(resp.body) // nolint:errcheck
+}
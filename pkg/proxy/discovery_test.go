@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func TestDiscoveryCluster(t *testing.T) {
+	tests := map[string]struct {
+		method      string
+		path        string
+		wantCluster string
+	}{
+		"core":                 {path: "/clusters/root:org/api", wantCluster: "root:org"},
+		"core version":         {path: "/clusters/root:org/api/v1", wantCluster: "root:org"},
+		"groups":               {path: "/clusters/root:org/apis", wantCluster: "root:org"},
+		"group":                {path: "/clusters/root:org/apis/apps", wantCluster: "root:org"},
+		"group version":        {path: "/clusters/root:org/apis/apps/v1", wantCluster: "root:org"},
+		"resource":             {path: "/clusters/root:org/apis/apps/v1/deployments"},
+		"core resource":        {path: "/clusters/root:org/api/v1/configmaps"},
+		"wildcard":             {path: "/clusters/*/apis"},
+		"without cluster":      {path: "/apis"},
+		"non-discovery":        {path: "/clusters/root:org/version"},
+		"non-get":              {method: http.MethodPost, path: "/clusters/root:org/apis"},
+		"virtual workspaces":   {path: "/services/workspaces/root/personal/apis"},
+		"trailing slash group": {path: "/clusters/root:org/apis/apps/", wantCluster: "root:org"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			cluster, ok := discoveryCluster(httptest.NewRequest(method, tc.path, nil))
+			require.Equal(t, tc.wantCluster != "", ok)
+			if ok {
+				require.Equal(t, logicalcluster.New(tc.wantCluster), cluster)
+			}
+		})
+	}
+}
+
+func TestWithShardDiscovery(t *testing.T) {
+	var shardRequests int
+	shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		shardRequests++
+		require.Equal(t, "/clusters/root:org:team/apis", req.URL.Path)
+		require.Equal(t, "alice", req.Header.Get("Impersonate-User"))
+		require.Equal(t, []string{"team"}, req.Header.Values("Impersonate-Group"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind":"APIGroupList"}`)) // nolint:errcheck
+	}))
+	defer shard.Close()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"},
+		Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard-2"}},
+	}))
+	emptyLister := tenancylisters.NewClusterWorkspaceLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
+	index := &ShardIndex{
+		shards: map[string]*shardBackend{
+			"root":    {host: "https://root.invalid", workspaceLister: emptyLister},
+			"shard-1": {host: "https://shard-1.invalid", workspaceLister: tenancylisters.NewClusterWorkspaceLister(indexer)},
+			"shard-2": {host: shard.URL, client: shard.Client(), workspaceLister: emptyLister},
+		},
+	}
+	discoveryCache := NewDiscoveryCache(time.Minute)
+
+	var fallbackRequests int
+	handler := WithShardDiscovery(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fallbackRequests++
+	}), index, discoveryCache)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "alice", Groups: []string{"team"}}))
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+		return rw
+	}
+
+	rw := get("/clusters/root:org:team/apis")
+	require.Equal(t, http.StatusOK, rw.Code)
+	body, err := io.ReadAll(rw.Body)
+	require.NoError(t, err)
+	require.Equal(t, `{"kind":"APIGroupList"}`, string(body))
+	require.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+	require.Equal(t, 1, shardRequests)
+
+	get("/clusters/root:org:team/apis")
+	require.Equal(t, 1, shardRequests, "expected the discovery to be cached")
+
+	discoveryCache.Invalidate(logicalcluster.New("root:org:team"))
+	get("/clusters/root:org:team/apis")
+	require.Equal(t, 2, shardRequests, "expected the discovery to be requested again after invalidation")
+
+	get("/clusters/root:org:unknown/apis")
+	get("/clusters/root:org:team/apis/apps/v1/deployments")
+	require.Equal(t, 2, fallbackRequests)
+	require.Equal(t, 2, shardRequests)
+}
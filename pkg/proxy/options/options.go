@@ -18,21 +18,28 @@ package options
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/pflag"
 )
 
 type Options struct {
-	MappingFile string
+	MappingFile       string
+	ShardsKubeconfig  string
+	DiscoveryCacheTTL time.Duration
 }
 
 func NewOptions() *Options {
-	o := &Options{}
+	o := &Options{
+		DiscoveryCacheTTL: time.Minute,
+	}
 	return o
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.MappingFile, "mapping-file", o.MappingFile, "Config file mapping paths to backends")
+	fs.StringVar(&o.ShardsKubeconfig, "shards-kubeconfig", o.ShardsKubeconfig, "Kubeconfig of the root shard with credentials valid on all shards and allowed to impersonate users. If set, discovery requests to workspaces are served from the shard of the workspace")
+	fs.DurationVar(&o.DiscoveryCacheTTL, "discovery-cache-ttl", o.DiscoveryCacheTTL, "Amount of time discovery responses of the shards are cached, unless the APIBindings of the workspace change before")
 }
 
 func (o *Options) Complete() error {
@@ -45,6 +52,9 @@ func (o *Options) Validate() []error {
 	if o.MappingFile == "" {
 		errs = append(errs, fmt.Errorf("--mapping-file is required"))
 	}
+	if o.DiscoveryCacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("--discovery-cache-ttl must be >=0 (%s)", o.DiscoveryCacheTTL))
+	}
 
 	return errs
 }
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/sharding"
+)
+
+const shardIndexResyncPeriod = 10 * time.Hour
+
+// ShardIndex resolves the shard workspaces live on from the ClusterWorkspaces of all shards,
+// and reports changes of the APIBindings of workspaces.
+type ShardIndex struct {
+	config             *rest.Config
+	onAPIBindingChange func(cluster logicalcluster.LogicalCluster)
+
+	shardLister tenancylisters.ClusterWorkspaceShardLister
+
+	lock   sync.RWMutex
+	shards map[string]*shardBackend
+}
+
+// shardBackend is a shard with the informers on its ClusterWorkspaces and APIBindings.
+type shardBackend struct {
+	host            string
+	client          *http.Client
+	workspaceLister tenancylisters.ClusterWorkspaceLister
+	stop            context.CancelFunc
+}
+
+// NewShardIndex returns a ShardIndex for the shards registered in the root shard of the config,
+// which must hold credentials valid on all shards. onAPIBindingChange is called with the
+// workspace of every added, updated or deleted APIBinding.
+func NewShardIndex(config *rest.Config, onAPIBindingChange func(cluster logicalcluster.LogicalCluster)) *ShardIndex {
+	return &ShardIndex{
+		config:             config,
+		onAPIBindingChange: onAPIBindingChange,
+		shards:             map[string]*shardBackend{},
+	}
+}
+
+// Start watches the shards of the root shard and the ClusterWorkspaces and APIBindings on each
+// of them until the context is done.
+func (s *ShardIndex) Start(ctx context.Context) error {
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(s.config)
+	if err != nil {
+		return err
+	}
+	factory := kcpinformers.NewSharedInformerFactoryWithOptions(kcpClusterClient.Cluster(tenancyv1alpha1.RootCluster), shardIndexResyncPeriod)
+	shardInformer := factory.Tenancy().V1alpha1().ClusterWorkspaceShards()
+	s.shardLister = shardInformer.Lister()
+	shardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { s.syncShards(ctx) },
+		UpdateFunc: func(_, obj interface{}) { s.syncShards(ctx) },
+		DeleteFunc: func(obj interface{}) { s.syncShards(ctx) },
+	})
+	factory.Start(ctx.Done())
+
+	<-ctx.Done()
+	return nil
+}
+
+// syncShards starts the informers of new shards and of shards with a changed URL, and stops
+// those of removed shards.
+func (s *ShardIndex) syncShards(ctx context.Context) {
+	shards, err := s.shardLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Failed to list ClusterWorkspaceShards: %v", err)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	existing := map[string]bool{}
+	for _, shard := range shards {
+		existing[shard.Name] = true
+		config := sharding.ShardConfig(shard, s.config)
+		if backend, found := s.shards[shard.Name]; found {
+			if backend.host == strings.TrimSuffix(config.Host, "/") {
+				continue
+			}
+			backend.stop()
+		}
+		backend, err := s.startShard(ctx, config)
+		if err != nil {
+			klog.Errorf("Failed to watch ClusterWorkspaceShard %q: %v", shard.Name, err)
+			delete(s.shards, shard.Name)
+			continue
+		}
+		klog.V(2).Infof("Watching ClusterWorkspaceShard %q at %s", shard.Name, backend.host)
+		s.shards[shard.Name] = backend
+	}
+	for name, backend := range s.shards {
+		if !existing[name] {
+			klog.V(2).Infof("Stopped watching ClusterWorkspaceShard %q", name)
+			backend.stop()
+			delete(s.shards, name)
+		}
+	}
+}
+
+func (s *ShardIndex) startShard(ctx context.Context, config *rest.Config) (*shardBackend, error) {
+	client, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	factory := kcpinformers.NewSharedInformerFactoryWithOptions(kcpClusterClient.Cluster(logicalcluster.Wildcard), shardIndexResyncPeriod)
+	workspaceInformer := factory.Tenancy().V1alpha1().ClusterWorkspaces()
+	workspaceInformer.Informer()
+	factory.Apis().V1alpha1().APIBindings().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    s.apiBindingChanged,
+		UpdateFunc: func(_, obj interface{}) { s.apiBindingChanged(obj) },
+		DeleteFunc: s.apiBindingChanged,
+	})
+	factory.Start(ctx.Done())
+
+	return &shardBackend{
+		host:            strings.TrimSuffix(config.Host, "/"),
+		client:          client,
+		workspaceLister: workspaceInformer.Lister(),
+		stop:            cancel,
+	}, nil
+}
+
+func (s *ShardIndex) apiBindingChanged(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	binding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return
+	}
+	s.onAPIBindingChange(logicalcluster.From(binding))
+}
+
+// lookup returns the shard the workspace lives on. The root workspace lives on the root shard.
+func (s *ShardIndex) lookup(cluster logicalcluster.LogicalCluster) (*shardBackend, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if cluster == tenancyv1alpha1.RootCluster {
+		backend, found := s.shards[tenancyv1alpha1.RootShard]
+		return backend, found
+	}
+
+	parent, name := cluster.Split()
+	if parent.Empty() {
+		return nil, false
+	}
+	key := clusters.ToClusterAwareKey(parent, name)
+	for _, backend := range s.shards {
+		workspace, err := backend.workspaceLister.Get(key)
+		if err != nil || workspace.Status.Location.Current == "" {
+			continue
+		}
+		current, found := s.shards[workspace.Status.Location.Current]
+		return current, found
+	}
+	return nil, false
+}
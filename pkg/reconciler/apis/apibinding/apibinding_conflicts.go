@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apibinding
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// BoundCRDName returns the name of the CRD in the ShadowWorkspaceName serving the bound resource.
+// Resources renamed with the RenameWithPrefix conflict policy are served by their own CRD.
+func BoundCRDName(boundResource apisv1alpha1.BoundAPIResource) string {
+	if boundResource.RenamedFrom == "" {
+		return boundResource.Schema.UID
+	}
+	return boundResource.Resource + "." + boundResource.Schema.UID
+}
+
+// conflictResolution describes how the resource of an APIResourceSchema is bound.
+type conflictResolution struct {
+	// prefix is prepended to the names of the resource, if it is renamed.
+	prefix string
+	// adopt is true if the resource of a CRD of the workspace is taken over.
+	adopt bool
+	// conflict describes the conflict if it cannot be resolved.
+	conflict string
+}
+
+// resolveConflicts determines how the resource of the schema is bound. Resources that are already
+// bound keep their names, the others are checked for conflicts with the CRDs of the workspace and
+// the resources bound by other APIBindings, and the conflict policy is applied.
+func (r *workspaceAPIExportReferenceReconciler) resolveConflicts(apiBinding *apisv1alpha1.APIBinding, apiResourceSchema *apisv1alpha1.APIResourceSchema) (conflictResolution, error) {
+	group, resource := apiResourceSchema.Spec.Group, apiResourceSchema.Spec.Names.Plural
+	adopt := apiBinding.Spec.ConflictPolicy == apisv1alpha1.APIBindingConflictPolicyAdopt
+
+	for _, boundResource := range apiBinding.Status.BoundResources {
+		if boundResource.Group != group {
+			continue
+		}
+		if boundResource.RenamedFrom == resource {
+			return conflictResolution{prefix: strings.TrimSuffix(boundResource.Resource, resource)}, nil
+		}
+		if boundResource.RenamedFrom == "" && boundResource.Resource == resource {
+			if !adopt {
+				return conflictResolution{}, nil
+			}
+			// keep adopting objects created through the CRD before it was shadowed
+			crd, err := r.getLocalCRD(logicalcluster.From(apiBinding), group, resource)
+			return conflictResolution{adopt: crd != nil}, err
+		}
+	}
+
+	conflict, boundByOther, err := r.findConflict(apiBinding, group, resource)
+	if err != nil || conflict == "" {
+		return conflictResolution{}, err
+	}
+
+	switch {
+	case apiBinding.Spec.ConflictPolicy == apisv1alpha1.APIBindingConflictPolicyRenameWithPrefix && apiBinding.Spec.ResourcePrefix != "":
+		prefix := apiBinding.Spec.ResourcePrefix
+		conflict, _, err := r.findConflict(apiBinding, group, prefix+resource)
+		if err != nil {
+			return conflictResolution{}, err
+		}
+		return conflictResolution{prefix: prefix, conflict: conflict}, nil
+	case adopt && !boundByOther:
+		return conflictResolution{adopt: true}, nil
+	}
+	return conflictResolution{conflict: conflict}, nil
+}
+
+// findConflict returns a description of what the resource conflicts with in the workspace of the
+// APIBinding, if anything, and whether it is bound by another APIBinding.
+func (r *workspaceAPIExportReferenceReconciler) findConflict(apiBinding *apisv1alpha1.APIBinding, group, resource string) (string, bool, error) {
+	clusterName := logicalcluster.From(apiBinding)
+	gr := resource
+	if group != "" {
+		gr = resource + "." + group
+	}
+
+	apiBindings, err := r.listAPIBindings(clusterName)
+	if err != nil {
+		return "", false, err
+	}
+	for _, other := range apiBindings {
+		if other.Name == apiBinding.Name {
+			continue
+		}
+		for _, boundResource := range other.Status.BoundResources {
+			if boundResource.Group == group && boundResource.Resource == resource {
+				return fmt.Sprintf("resource %s is bound by APIBinding %s", gr, other.Name), true, nil
+			}
+		}
+	}
+
+	crd, err := r.getLocalCRD(clusterName, group, resource)
+	if err != nil {
+		return "", false, err
+	}
+	if crd != nil {
+		return fmt.Sprintf("resource %s is defined by CustomResourceDefinition %s", gr, crd.Name), false, nil
+	}
+	return "", false, nil
+}
+
+// getLocalCRD returns the CRD of the resource in the given workspace, or nil if there is none.
+func (r *workspaceAPIExportReferenceReconciler) getLocalCRD(clusterName logicalcluster.LogicalCluster, group, resource string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	if group == "" {
+		return nil, nil // the core group is not served by CRDs
+	}
+	crd, err := r.getCRD(clusterName, resource+"."+group)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return crd, err
+}
+
+// storageVersionResource returns the resource of the schema in its storage version.
+func storageVersionResource(apiResourceSchema *apisv1alpha1.APIResourceSchema) schema.GroupVersionResource {
+	gvr := schema.GroupVersionResource{Group: apiResourceSchema.Spec.Group, Resource: apiResourceSchema.Spec.Names.Plural}
+	for _, version := range apiResourceSchema.Spec.Versions {
+		if version.Storage {
+			gvr.Version = version.Name
+		}
+	}
+	return gvr
+}
+
+// renameCRD prepends the prefix to the names of the CRD, and names the CRD as expected by BoundCRDName.
+// The short names are dropped as they would conflict as well.
+func renameCRD(crd *apiextensionsv1.CustomResourceDefinition, prefix string) {
+	kindPrefix := strings.ToUpper(prefix[:1]) + prefix[1:]
+
+	names := &crd.Spec.Names
+	names.Plural = prefix + names.Plural
+	if names.Singular != "" {
+		names.Singular = prefix + names.Singular
+	}
+	names.Kind = kindPrefix + names.Kind
+	if names.ListKind != "" {
+		names.ListKind = kindPrefix + names.ListKind
+	}
+	names.ShortNames = nil
+
+	crd.Name = names.Plural + "." + crd.Name
+}
@@ -36,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/client-go/util/workqueue"
@@ -44,12 +45,18 @@ import (
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/priorityqueue"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
 
 const (
 	controllerName                     = "kcp-apibinding"
 	indexAPIBindingsByWorkspaceExport  = "apiBindingsByWorkspaceExport"
+	indexAPIBindingsByWorkspace        = "apiBindingsByWorkspace"
 	indexAPIExportsByAPIResourceSchema = "apiExportsByAPIResourceSchema"
 )
 
@@ -61,18 +68,23 @@ var (
 func NewController(
 	crdClusterClient apiextensionclientset.ClusterInterface,
 	kcpClusterClient kcpclient.ClusterInterface,
+	dynamicClusterClient dynamic.ClusterInterface,
 	apiBindingInformer apisinformers.APIBindingInformer,
 	apiExportInformer apisinformers.APIExportInformer,
 	apiResourceSchemaInformer apisinformers.APIResourceSchemaInformer,
 	crdInformer apiextensionsinformers.CustomResourceDefinitionInformer,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	workspaceTypeInformer tenancyinformers.ClusterWorkspaceTypeInformer,
 ) (*controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	// objects of the root and system workspaces are reconciled first, e.g. when the queue fills up after a restart
+	queue := priorityqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName, priorityqueue.SystemWorkspaceKey)
 
 	c := &controller{
 		queue:                    queue,
 		enqueueAfter:             func(binding *apisv1alpha1.APIBinding, duration time.Duration) { queue.AddAfter(binding, duration) },
 		crdClusterClient:         crdClusterClient,
 		kcpClusterClient:         kcpClusterClient,
+		dynamicClusterClient:     dynamicClusterClient,
 		apiBindingsLister:        apiBindingInformer.Lister(),
 		apiBindingsIndexer:       apiBindingInformer.Informer().GetIndexer(),
 		apiExportsLister:         apiExportInformer.Lister(),
@@ -81,22 +93,31 @@ func NewController(
 		apiResourceSchemaIndexer: apiResourceSchemaInformer.Informer().GetIndexer(),
 		crdLister:                crdInformer.Lister(),
 		crdIndexer:               crdInformer.Informer().GetIndexer(),
+		workspaceLister:          workspaceInformer.Lister(),
+		workspaceTypeLister:      workspaceTypeInformer.Lister(),
 		deletedCRDTracker:        newLockedStringSet(),
 	}
 
-	apiBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueAPIBinding(obj) },
-		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIBinding(obj) },
-		DeleteFunc: func(obj interface{}) { c.enqueueAPIBinding(obj) },
-	})
+	apiBindingInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { c.enqueueAPIBinding(obj) },
+		UpdateFunc: func(_, obj interface{}) {
+			c.enqueueAPIBinding(obj)
+			c.enqueueConflictingAPIBindings(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueAPIBinding(obj)
+			c.enqueueConflictingAPIBindings(obj)
+		},
+	}))
 
 	if err := apiBindingInformer.Informer().AddIndexers(cache.Indexers{
 		indexAPIBindingsByWorkspaceExport: indexAPIBindingByWorkspaceExport,
+		indexAPIBindingsByWorkspace:       indexAPIBindingByWorkspace,
 	}); err != nil {
 		return nil, err
 	}
 
-	crdInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+	crdInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.FilteringResourceEventHandler{
 		FilterFunc: func(obj interface{}) bool {
 			crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
 			if !ok {
@@ -123,19 +144,36 @@ func NewController(
 				c.enqueueCRD(obj)
 			},
 		},
-	})
+	}))
+
+	// CRDs of workspaces can conflict with the resources of APIBindings
+	crdInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			crd, ok := obj.(*apiextensionsv1.CustomResourceDefinition)
+			if !ok {
+				return true // tombstones are filtered in enqueueConflictingAPIBindings
+			}
+
+			return logicalcluster.From(crd) != ShadowWorkspaceName
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueueConflictingAPIBindings(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueueConflictingAPIBindings(obj) },
+			DeleteFunc: func(obj interface{}) { c.enqueueConflictingAPIBindings(obj) },
+		},
+	}))
 
-	apiResourceSchemaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	apiResourceSchemaInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { c.enqueueAPIResourceSchema(obj) },
 		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIResourceSchema(obj) },
 		DeleteFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj) },
-	})
+	}))
 
-	apiExportInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	apiExportInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { c.enqueueAPIExport(obj) },
 		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIExport(obj) },
 		DeleteFunc: func(obj interface{}) { c.enqueueAPIExport(obj) },
-	})
+	}))
 
 	if err := c.apiExportsIndexer.AddIndexers(cache.Indexers{
 		indexAPIExportsByAPIResourceSchema: indexAPIExportByAPIResourceSchemas,
@@ -153,8 +191,9 @@ type controller struct {
 	queue        workqueue.RateLimitingInterface
 	enqueueAfter func(*apisv1alpha1.APIBinding, time.Duration)
 
-	crdClusterClient apiextensionclientset.ClusterInterface
-	kcpClusterClient kcpclient.ClusterInterface
+	crdClusterClient     apiextensionclientset.ClusterInterface
+	kcpClusterClient     kcpclient.ClusterInterface
+	dynamicClusterClient dynamic.ClusterInterface
 
 	apiBindingsLister        apislisters.APIBindingLister
 	apiBindingsIndexer       cache.Indexer
@@ -164,6 +203,8 @@ type controller struct {
 	apiResourceSchemaIndexer cache.Indexer
 	crdLister                apiextensionslisters.CustomResourceDefinitionLister
 	crdIndexer               cache.Indexer
+	workspaceLister          tenancylisters.ClusterWorkspaceLister
+	workspaceTypeLister      tenancylisters.ClusterWorkspaceTypeLister
 
 	deletedCRDTracker *lockedStringSet
 }
@@ -180,6 +221,34 @@ func (c *controller) enqueueAPIBinding(obj interface{}) {
 	c.queue.Add(key)
 }
 
+// enqueueConflictingAPIBindings enqueues the APIBindings with unresolved conflicts in the workspace of
+// the given CRD or APIBinding, as its change might resolve them.
+func (c *controller) enqueueConflictingAPIBindings(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	meta, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName := logicalcluster.From(meta)
+	if clusterName == ShadowWorkspaceName {
+		return
+	}
+
+	apiBindings, err := c.listAPIBindings(clusterName)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, apiBinding := range apiBindings {
+		if apiBinding.Name != meta.GetName() && conditions.IsFalse(apiBinding, apisv1alpha1.ConflictsResolved) {
+			c.enqueueAPIBinding(apiBinding)
+		}
+	}
+}
+
 // enqueueAPIExport enqueues maps an APIExport to APIBindings for enqueuing.
 func (c *controller) enqueueAPIExport(obj interface{}) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
@@ -47,6 +47,16 @@ func indexAPIBindingByWorkspaceExport(obj interface{}) ([]string, error) {
 	return []string{}, nil
 }
 
+// indexAPIBindingByWorkspace is an index function that maps an APIBinding to its logical cluster.
+func indexAPIBindingByWorkspace(obj interface{}) ([]string, error) {
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be an APIBinding, but is %T", obj)
+	}
+
+	return []string{logicalcluster.From(apiBinding).String()}, nil
+}
+
 // indexAPIExportByAPIResourceSchemas is an index function that maps an APIExport to its spec.latestResourceSchemas.
 func indexAPIExportByAPIResourceSchemas(obj interface{}) ([]string, error) {
 	apiExport, ok := obj.(*apisv1alpha1.APIExport)
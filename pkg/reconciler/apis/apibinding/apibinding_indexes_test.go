@@ -120,3 +120,22 @@ func TestIndexAPIExportByAPIResourceSchemas(t *testing.T) {
 		})
 	}
 }
+
+func TestIndexAPIBindingByWorkspace(t *testing.T) {
+	got, err := indexAPIBindingByWorkspace(&apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			ClusterName: "root:default",
+			Name:        "foo",
+		},
+	})
+	if err != nil {
+		t.Fatalf("indexAPIBindingByWorkspace() error = %v", err)
+	}
+	if want := []string{"root:default"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("indexAPIBindingByWorkspace() got = %v, want %v", got, want)
+	}
+
+	if _, err := indexAPIBindingByWorkspace("not an APIBinding"); err == nil {
+		t.Errorf("indexAPIBindingByWorkspace() expected an error for a non-APIBinding")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,12 +32,15 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apishelper "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/helper"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
@@ -195,6 +199,10 @@ type workspaceAPIExportReferenceReconciler struct {
 	getCRD               func(clusterName logicalcluster.LogicalCluster, name string) (*apiextensionsv1.CustomResourceDefinition, error)
 	createCRD            func(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, error)
 	updateCRD            func(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, error)
+	listAPIBindings      func(clusterName logicalcluster.LogicalCluster) ([]*apisv1alpha1.APIBinding, error)
+	getAllowedAPIGroups  func(clusterName logicalcluster.LogicalCluster) (sets.String, error)
+	adoptObjects         func(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource, fromIdentityHash, identityHash string) error
+	enqueueAfter         func(*apisv1alpha1.APIBinding, time.Duration)
 
 	deletedCRDTracker *lockedStringSet
 }
@@ -242,8 +250,28 @@ func (r *workspaceAPIExportReferenceReconciler) reconcile(ctx context.Context, a
 		return reconcileStatusStop, err // temporary error, retry
 	}
 
+	// the APIExport might have gained schemas since the APIBinding was admitted
+	if denied, err := r.deniedAPIGroups(apiBinding, apiExportClusterName, apiExport); err != nil {
+		return reconcileStatusStop, err // temporary error, retry
+	} else if len(denied) > 0 {
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.APIExportValid,
+			apisv1alpha1.APIGroupsNotAllowedReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"API groups %s of APIExport %s|%s are not allowed in this workspace",
+			strings.Join(denied, ", "),
+			apiExportClusterName,
+			workspaceRef.ExportName,
+		)
+		return reconcileStatusStop, nil // don't retry, only when the APIExport changes
+	}
+
 	var boundResources []apisv1alpha1.BoundAPIResource
+	var conflicts []string
+	var adopted, bound []schema.GroupVersionResource
 	needToWaitForRequeue := false
+	wasBound := apiBinding.Status.Phase == apisv1alpha1.APIBindingPhaseBound
 
 	for _, schemaName := range apiExport.Spec.LatestResourceSchemas {
 		schema, err := r.getAPIResourceSchema(apiExportClusterName, schemaName)
@@ -265,6 +293,15 @@ func (r *workspaceAPIExportReferenceReconciler) reconcile(ctx context.Context, a
 			return reconcileStatusStop, err // temporary error, retry
 		}
 
+		resolution, err := r.resolveConflicts(apiBinding, schema)
+		if err != nil {
+			return reconcileStatusStop, err // temporary error, retry
+		}
+		if resolution.conflict != "" {
+			conflicts = append(conflicts, resolution.conflict)
+			continue
+		}
+
 		crd, err := crdFromAPIResourceSchema(schema)
 		if err != nil {
 			// TODO(ncdc): not sure if we should expose this level of detail to the binding user?
@@ -281,6 +318,9 @@ func (r *workspaceAPIExportReferenceReconciler) reconcile(ctx context.Context, a
 
 			return reconcileStatusStop, nil // don't retry
 		}
+		if resolution.prefix != "" {
+			renameCRD(crd, resolution.prefix)
+		}
 
 		existingCRD, err := r.getCRD(ShadowWorkspaceName, crd.Name)
 		if err != nil && !apierrors.IsNotFound(err) {
@@ -330,9 +370,11 @@ func (r *workspaceAPIExportReferenceReconciler) reconcile(ctx context.Context, a
 
 			needToWaitForRequeue = true
 		} else {
-			// We never set Conversion, so nil it out before comparing
-			existingCRD = existingCRD.DeepCopy()
-			existingCRD.Spec.Conversion = nil
+			// Without conversion in the schema, the CRD is defaulted to no conversion, so nil it out before comparing
+			if crd.Spec.Conversion == nil {
+				existingCRD = existingCRD.DeepCopy()
+				existingCRD.Spec.Conversion = nil
+			}
 
 			if !reflect.DeepEqual(existingCRD.Spec, crd.Spec) {
 				klog.Infof("CRD diff: %s", cmp.Diff(existingCRD.Spec, crd.Spec))
@@ -364,19 +406,92 @@ func (r *workspaceAPIExportReferenceReconciler) reconcile(ctx context.Context, a
 			}
 		}
 
-		boundResources = append(boundResources, apisv1alpha1.BoundAPIResource{
+		boundResource := apisv1alpha1.BoundAPIResource{
 			Group:    schema.Spec.Group,
-			Resource: schema.Spec.Names.Plural,
+			Resource: crd.Spec.Names.Plural,
 			Schema: apisv1alpha1.BoundAPIResourceSchema{
 				Name: schema.Name,
 				UID:  string(schema.UID),
 			},
+			IdentityHash:    apishelper.IdentityHash(apiExport),
 			StorageVersions: crd.Status.StoredVersions,
-		})
+		}
+		if resolution.prefix != "" {
+			boundResource.RenamedFrom = schema.Spec.Names.Plural
+		}
+		boundResources = append(boundResources, boundResource)
+
+		bound = append(bound, storageVersionResource(schema))
+		if resolution.adopt {
+			adopted = append(adopted, storageVersionResource(schema))
+		}
 	}
 
 	conditions.MarkTrue(apiBinding, apisv1alpha1.APIExportValid)
 
+	if len(conflicts) > 0 {
+		conditions.MarkFalse(
+			apiBinding,
+			apisv1alpha1.ConflictsResolved,
+			apisv1alpha1.NamingConflictsReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"%s",
+			strings.Join(conflicts, "; "),
+		)
+		return reconcileStatusStop, nil // retried when the CRDs or APIBindings of the workspace change
+	}
+
+	// objects of adopted CRDs are only served through the bound CRD once the APIBinding is bound
+	if wasBound {
+		for _, gvr := range adopted {
+			if err := r.adoptObjects(ctx, logicalcluster.From(apiBinding), gvr, "", apishelper.IdentityHash(apiExport)); err != nil {
+				conditions.MarkFalse(
+					apiBinding,
+					apisv1alpha1.ConflictsResolved,
+					apisv1alpha1.AdoptionFailedReason,
+					conditionsv1alpha1.ConditionSeverityWarning,
+					"failed to adopt objects of %s: %v",
+					gvr.GroupResource(),
+					err,
+				)
+				r.enqueueAfter(apiBinding, time.Minute)
+				return reconcileStatusStop, nil
+			}
+		}
+	}
+	conditions.MarkTrue(apiBinding, apisv1alpha1.ConflictsResolved)
+
+	// move the objects labelled with an identity of the APIExport before a rotation to the current identity
+	if wasBound {
+		for _, previous := range apiExport.Status.PreviousIdentityHashes {
+			for _, gvr := range bound {
+				if err := r.adoptObjects(ctx, logicalcluster.From(apiBinding), gvr, previous.IdentityHash, apishelper.IdentityHash(apiExport)); err != nil {
+					return reconcileStatusStop, err // retry
+				}
+			}
+		}
+	}
+
+	// label the objects created before the bound resource recorded its identity, i.e. before objects got labelled
+	if wasBound {
+		unlabelled := sets.NewString()
+		for _, existing := range apiBinding.Status.BoundResources {
+			if existing.IdentityHash == "" {
+				unlabelled.Insert(existing.Schema.UID)
+			}
+		}
+		for i, boundResource := range boundResources {
+			if !unlabelled.Has(boundResource.Schema.UID) {
+				continue
+			}
+			gvr := bound[i]
+			gvr.Resource = boundResource.Resource
+			if err := r.adoptObjects(ctx, logicalcluster.From(apiBinding), gvr, "", boundResource.IdentityHash); err != nil {
+				return reconcileStatusStop, err // retry
+			}
+		}
+	}
+
 	if needToWaitForRequeue {
 		return reconcileStatusStop, nil
 	}
@@ -390,6 +505,28 @@ func (r *workspaceAPIExportReferenceReconciler) reconcile(ctx context.Context, a
 	return reconcileStatusContinue, nil
 }
 
+// deniedAPIGroups returns the groups of the latest resource schemas of the APIExport which the type of the
+// workspace of the APIBinding does not allow. Missing schemas are reported when binding them.
+func (r *workspaceAPIExportReferenceReconciler) deniedAPIGroups(apiBinding *apisv1alpha1.APIBinding, apiExportClusterName logicalcluster.LogicalCluster, apiExport *apisv1alpha1.APIExport) ([]string, error) {
+	allowed, err := r.getAllowedAPIGroups(logicalcluster.From(apiBinding))
+	if err != nil || allowed.Len() == 0 {
+		return nil, err
+	}
+	denied := sets.NewString()
+	for _, schemaName := range apiExport.Spec.LatestResourceSchemas {
+		schema, err := r.getAPIResourceSchema(apiExportClusterName, schemaName)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if !allowed.Has(schema.Spec.Group) {
+			denied.Insert(schema.Spec.Group)
+		}
+	}
+	return denied.List(), nil
+}
+
 func (c *controller) reconcile(ctx context.Context, apiBinding *apisv1alpha1.APIBinding) error {
 	reconcilers := []reconciler{
 		&phaseReconciler{
@@ -403,6 +540,10 @@ func (c *controller) reconcile(ctx context.Context, apiBinding *apisv1alpha1.API
 			getCRD:               c.getCRD,
 			createCRD:            c.createCRD,
 			updateCRD:            c.updateCRD,
+			listAPIBindings:      c.listAPIBindings,
+			getAllowedAPIGroups:  c.getAllowedAPIGroups,
+			adoptObjects:         c.adoptObjects,
+			enqueueAfter:         c.enqueueAfter,
 			deletedCRDTracker:    c.deletedCRDTracker,
 		},
 	}
@@ -434,6 +575,71 @@ func (c *controller) getCRD(clusterName logicalcluster.LogicalCluster, name stri
 	return c.crdLister.Get(clusters.ToClusterAwareKey(clusterName, name))
 }
 
+// getAllowedAPIGroups returns the allowedAPIGroups of the ClusterWorkspaceType of the given workspace,
+// empty if all groups are allowed, like for workspaces whose type does not exist.
+func (c *controller) getAllowedAPIGroups(clusterName logicalcluster.LogicalCluster) (sets.String, error) {
+	parent, name := clusterName.Split()
+	if parent.Empty() {
+		return nil, nil
+	}
+	workspace, err := c.workspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	cwt, err := c.workspaceTypeLister.Get(clusters.ToClusterAwareKey(parent, strings.ToLower(workspace.Spec.Type)))
+	if apierrors.IsNotFound(err) {
+		return nil, nil // e.g. Universal
+	} else if err != nil {
+		return nil, err
+	}
+	return sets.NewString(cwt.Spec.AllowedAPIGroups...), nil
+}
+
+func (c *controller) listAPIBindings(clusterName logicalcluster.LogicalCluster) ([]*apisv1alpha1.APIBinding, error) {
+	objs, err := c.apiBindingsIndexer.ByIndex(indexAPIBindingsByWorkspace, clusterName.String())
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*apisv1alpha1.APIBinding, 0, len(objs))
+	for _, obj := range objs {
+		ret = append(ret, obj.(*apisv1alpha1.APIBinding))
+	}
+	return ret, nil
+}
+
+// adoptObjects labels the objects of an adopted CRD with the identity of the APIExport. The objects
+// share the storage of the bound resource, only the identity label is missing. With a non-empty
+// fromIdentityHash, the objects labelled with that previous identity of the APIExport are relabelled.
+func (c *controller) adoptObjects(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource, fromIdentityHash, identityHash string) error {
+	selector := "!" + apisv1alpha1.IdentityHashLabelKey
+	if fromIdentityHash != "" {
+		selector = apisv1alpha1.IdentityHashLabelKey + "=" + fromIdentityHash
+	}
+
+	client := c.dynamicClusterClient.Cluster(clusterName).Resource(gvr)
+	objs, err := client.List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+
+	if len(objs.Items) > 0 {
+		klog.Infof("Adopting %d objects of %s in %s with selector %q into APIExport identity %s", len(objs.Items), gvr.GroupResource(), clusterName, selector, identityHash)
+	}
+
+	patch := fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, apisv1alpha1.IdentityHashLabelKey, identityHash)
+	var errs []error
+	for _, obj := range objs.Items {
+		_, err := client.Namespace(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.NewAggregate(errs)
+}
+
 func (c *controller) createCRD(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, error) {
 	return c.crdClusterClient.Cluster(logicalcluster.From(crd)).ApiextensionsV1().CustomResourceDefinitions().Create(ctx, crd, metav1.CreateOptions{})
 }
@@ -459,6 +665,9 @@ func crdFromAPIResourceSchema(schema *apisv1alpha1.APIResourceSchema) (*apiexten
 			Scope: schema.Spec.Scope,
 		},
 	}
+	if schema.Spec.Conversion != nil {
+		crd.Spec.Conversion = schema.Spec.Conversion.DeepCopy()
+	}
 
 	for _, version := range schema.Spec.Versions {
 		crdVersion := apiextensionsv1.CustomResourceDefinitionVersion{
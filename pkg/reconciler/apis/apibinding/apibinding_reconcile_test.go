@@ -26,15 +26,23 @@ import (
 	"github.com/stretchr/testify/require"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	celvalidation "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/cel"
+	structuraldefaulting "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	structuralpruning "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/pruning"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/pointer"
 
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apishelper "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/helper"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
@@ -387,6 +395,58 @@ func TestCRDFromAPIResourceSchema(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		"conversion webhook": {
+			schema: &apisv1alpha1.APIResourceSchema{
+				ObjectMeta: metav1.ObjectMeta{
+					ClusterName: "my-cluster",
+					Name:        "my-name",
+					UID:         types.UID("my-uuid"),
+				},
+				Spec: apisv1alpha1.APIResourceSchemaSpec{
+					Group: "my-group",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Singular: "widget", Kind: "Widget", ListKind: "WidgetList"},
+					Scope: "Namespaced",
+					Versions: []apisv1alpha1.APIResourceVersion{
+						{Name: "v1", Served: true, Storage: true, Schema: runtime.RawExtension{Raw: []byte(`{"type":"object"}`)}},
+						{Name: "v2", Served: true, Schema: runtime.RawExtension{Raw: []byte(`{"type":"object"}`)}},
+					},
+					Conversion: &apiextensionsv1.CustomResourceConversion{
+						Strategy: apiextensionsv1.WebhookConverter,
+						Webhook: &apiextensionsv1.WebhookConversion{
+							ClientConfig:             &apiextensionsv1.WebhookClientConfig{URL: pointer.StringPtr("https://widgets.my-group/convert")},
+							ConversionReviewVersions: []string{"v1"},
+						},
+					},
+				},
+			},
+			want: &apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					ClusterName: ShadowWorkspaceName.String(),
+					Name:        "my-uuid",
+					Annotations: map[string]string{
+						annotationBoundCRDKey:      "",
+						annotationSchemaClusterKey: "my-cluster",
+						annotationSchemaNameKey:    "my-name",
+					},
+				},
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Group: "my-group",
+					Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Singular: "widget", Kind: "Widget", ListKind: "WidgetList"},
+					Scope: "Namespaced",
+					Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+						{Name: "v1", Served: true, Storage: true, Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Type: "object"}}},
+						{Name: "v2", Served: true, Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Type: "object"}}},
+					},
+					Conversion: &apiextensionsv1.CustomResourceConversion{
+						Strategy: apiextensionsv1.WebhookConverter,
+						Webhook: &apiextensionsv1.WebhookConversion{
+							ClientConfig:             &apiextensionsv1.WebhookClientConfig{URL: pointer.StringPtr("https://widgets.my-group/convert")},
+							ConversionReviewVersions: []string{"v1"},
+						},
+					},
+				},
+			},
+		},
 		"error when schema is invalid": {
 			schema: &apisv1alpha1.APIResourceSchema{
 				Spec: apisv1alpha1.APIResourceSchemaSpec{
@@ -418,6 +478,58 @@ func TestCRDFromAPIResourceSchema(t *testing.T) {
 	}
 }
 
+// TestCRDFromAPIResourceSchemaStructuralSemantics checks that objects of a bound CRD are defaulted,
+// pruned and validated by CEL rules as declared in the APIResourceSchema, like those of a CRD.
+func TestCRDFromAPIResourceSchemaStructuralSemantics(t *testing.T) {
+	crd, err := crdFromAPIResourceSchema(&apisv1alpha1.APIResourceSchema{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: "my-cluster", Name: "today.widgets.my-group", UID: "my-uuid"},
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Group: "my-group",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Singular: "widget", Kind: "Widget", ListKind: "WidgetList"},
+			Scope: apiextensionsv1.NamespaceScoped,
+			Versions: []apisv1alpha1.APIResourceVersion{{
+				Name:    "v1",
+				Served:  true,
+				Storage: true,
+				Schema: runtime.RawExtension{Raw: []byte(`{
+	"type": "object",
+	"properties": {
+		"spec": {
+			"type": "object",
+			"x-kubernetes-validations": [{"rule": "self.minReplicas <= self.replicas", "message": "replicas must not be below minReplicas"}],
+			"properties": {
+				"replicas": {"type": "integer", "default": 1},
+				"minReplicas": {"type": "integer", "default": 2}
+			}
+		}
+	}
+}`)},
+			}},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, crd.Spec.PreserveUnknownFields)
+
+	var internal apiextensions.CustomResourceValidation
+	require.NoError(t, apiextensionsv1.Convert_v1_CustomResourceValidation_To_apiextensions_CustomResourceValidation(crd.Spec.Versions[0].Schema, &internal, nil))
+	ss, err := structuralschema.NewStructural(internal.OpenAPIV3Schema)
+	require.NoError(t, err)
+
+	obj := map[string]interface{}{
+		"apiVersion": "my-group/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "foo"},
+		"spec":       map[string]interface{}{"unknown": "field"},
+	}
+	structuraldefaulting.Default(obj, ss)
+	structuralpruning.Prune(obj, ss, true)
+	require.Equal(t, map[string]interface{}{"replicas": int64(1), "minReplicas": int64(2)}, obj["spec"])
+
+	errs := celvalidation.NewValidator(ss).Validate(field.NewPath("root"), ss, obj)
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].Error(), "replicas must not be below minReplicas")
+}
+
 func TestWorkspaceAPIExportReferenceReconciler(t *testing.T) {
 	tests := map[string]struct {
 		apiBinding                *apisv1alpha1.APIBinding
@@ -432,9 +544,17 @@ func TestWorkspaceAPIExportReferenceReconciler(t *testing.T) {
 		wantUpdateCRD             bool
 		updateCRDError            error
 		deletedCRDs               []string
+		localCRDs                 map[string]*apiextensionsv1.CustomResourceDefinition
+		otherAPIBindings          []*apisv1alpha1.APIBinding
+		allowedAPIGroups          []string
+		adoptError                error
 		wantReconcileStatus       reconcileStatus
 		wantError                 bool
 		wantConditions            []wantCondition
+		wantBoundResources        []apisv1alpha1.BoundAPIResource
+		wantAdopted               []schema.GroupVersionResource
+		wantAdoptedFrom           string
+		wantRequeue               bool
 	}{
 		"Bound updated with nil workspace ref reports invalid APIExport": {
 			apiBinding:          bound.DeepCopy().WithoutWorkspaceReference().Build(),
@@ -617,11 +737,312 @@ func TestWorkspaceAPIExportReferenceReconciler(t *testing.T) {
 				},
 			},
 		},
+		"schemas of groups not allowed in the workspace are not bound": {
+			apiBinding: unbound.DeepCopy().WithClusterName("org:some-workspace").Build(),
+			apiExport:  conflictingExport,
+			apiResourceSchemas: map[string]*apisv1alpha1.APIResourceSchema{
+				"schema1": {Spec: apisv1alpha1.APIResourceSchemaSpec{Group: "group"}},
+			},
+			allowedAPIGroups:    []string{"other"},
+			wantReconcileStatus: reconcileStatusStop,
+			wantConditions: []wantCondition{
+				{
+					Type:     apisv1alpha1.APIExportValid,
+					Status:   corev1.ConditionFalse,
+					Reason:   apisv1alpha1.APIGroupsNotAllowedReason,
+					Severity: conditionsv1alpha1.ConditionSeverityError,
+				},
+			},
+		},
+		"schemas of groups allowed in the workspace are bound": {
+			apiBinding: unbound.DeepCopy().WithClusterName("org:some-workspace").Build(),
+			apiExport:  conflictingExport,
+			apiResourceSchemas: map[string]*apisv1alpha1.APIResourceSchema{
+				"schema1": {Spec: apisv1alpha1.APIResourceSchemaSpec{Group: "group"}},
+			},
+			allowedAPIGroups:    []string{"group", "other"},
+			getCRDError:         apierrors.NewNotFound(schema.GroupResource{}, ""),
+			wantCreateCRD:       true,
+			wantReconcileStatus: reconcileStatusStop,
+			wantConditions: []wantCondition{
+				{
+					Type:   apisv1alpha1.APIExportValid,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+		"established CRD binds with the identity of the APIExport": {
+			apiBinding: new(bindingBuilder).
+				WithClusterName("org:some-workspace").
+				WithName("binding").
+				WithWorkspaceReference("some-workspace", "some-export").
+				Build(),
+			apiExport: &apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{
+					ClusterName: "org:some-workspace",
+					Name:        "some-export",
+					UID:         "export-uid",
+				},
+				Spec: apisv1alpha1.APIExportSpec{
+					LatestResourceSchemas: []string{"schema1"},
+				},
+			},
+			apiResourceSchemas: map[string]*apisv1alpha1.APIResourceSchema{
+				"schema1": {
+					ObjectMeta: metav1.ObjectMeta{Name: "schema1", UID: "uid1"},
+					Spec: apisv1alpha1.APIResourceSchemaSpec{
+						Group: "group",
+						Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "resources"},
+					},
+				},
+			},
+			crds: map[string]*apiextensionsv1.CustomResourceDefinition{
+				"uid1": {
+					Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+						Group: "group",
+						Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "resources"},
+					},
+					Status: apiextensionsv1.CustomResourceDefinitionStatus{
+						Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+							{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+						},
+					},
+				},
+			},
+			wantReconcileStatus: reconcileStatusContinue,
+			wantConditions: []wantCondition{
+				{
+					Type:   apisv1alpha1.CRDReady,
+					Status: corev1.ConditionTrue,
+				},
+			},
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:        "group",
+					Resource:     "resources",
+					Schema:       apisv1alpha1.BoundAPIResourceSchema{Name: "schema1", UID: "uid1"},
+					IdentityHash: apishelper.IdentityHash(&apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{ClusterName: "org:some-workspace", Name: "some-export", UID: "export-uid"}}),
+				},
+			},
+		},
+		"conflict with a CRD of the workspace fails": {
+			apiBinding:         unbound.DeepCopy().WithClusterName("org:some-workspace").Build(),
+			apiExport:          conflictingExport,
+			apiResourceSchemas: conflictingSchemas,
+			localCRDs: map[string]*apiextensionsv1.CustomResourceDefinition{
+				"resources.group": {ObjectMeta: metav1.ObjectMeta{Name: "resources.group"}},
+			},
+			wantReconcileStatus: reconcileStatusStop,
+			wantConditions: []wantCondition{
+				{
+					Type:     apisv1alpha1.ConflictsResolved,
+					Status:   corev1.ConditionFalse,
+					Reason:   apisv1alpha1.NamingConflictsReason,
+					Severity: conditionsv1alpha1.ConditionSeverityError,
+				},
+			},
+		},
+		"conflict with another APIBinding fails with the adopt policy": {
+			apiBinding:         unbound.DeepCopy().WithClusterName("org:some-workspace").WithConflictPolicy(apisv1alpha1.APIBindingConflictPolicyAdopt, "").Build(),
+			apiExport:          conflictingExport,
+			apiResourceSchemas: conflictingSchemas,
+			otherAPIBindings: []*apisv1alpha1.APIBinding{
+				new(bindingBuilder).WithName("other").WithBoundResources(
+					new(boundAPIResourceBuilder).WithGroupResource("group", "resources").WithSchema("other", "uid2").BoundAPIResource,
+				).Build(),
+			},
+			wantReconcileStatus: reconcileStatusStop,
+			wantConditions: []wantCondition{
+				{
+					Type:     apisv1alpha1.ConflictsResolved,
+					Status:   corev1.ConditionFalse,
+					Reason:   apisv1alpha1.NamingConflictsReason,
+					Severity: conditionsv1alpha1.ConditionSeverityError,
+				},
+			},
+		},
+		"conflict with a CRD of the workspace is adopted": {
+			apiBinding:         unbound.DeepCopy().WithClusterName("org:some-workspace").WithConflictPolicy(apisv1alpha1.APIBindingConflictPolicyAdopt, "").Build(),
+			apiExport:          conflictingExport,
+			apiResourceSchemas: conflictingSchemas,
+			crds:               map[string]*apiextensionsv1.CustomResourceDefinition{"uid1": establishedCRD("resources")},
+			localCRDs: map[string]*apiextensionsv1.CustomResourceDefinition{
+				"resources.group": {ObjectMeta: metav1.ObjectMeta{Name: "resources.group"}},
+			},
+			wantReconcileStatus: reconcileStatusContinue,
+			wantConditions: []wantCondition{
+				{
+					Type:   apisv1alpha1.ConflictsResolved,
+					Status: corev1.ConditionTrue,
+				},
+			},
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:        "group",
+					Resource:     "resources",
+					Schema:       apisv1alpha1.BoundAPIResourceSchema{Name: "schema1", UID: "uid1"},
+					IdentityHash: apishelper.IdentityHash(conflictingExport),
+				},
+			},
+		},
+		"objects of an adopted CRD are adopted once bound": {
+			apiBinding: unbound.DeepCopy().WithClusterName("org:some-workspace").WithConflictPolicy(apisv1alpha1.APIBindingConflictPolicyAdopt, "").
+				WithPhase(apisv1alpha1.APIBindingPhaseBound).
+				WithBoundResources(new(boundAPIResourceBuilder).WithGroupResource("group", "resources").WithSchema("schema1", "uid1").WithIdentityHash(apishelper.IdentityHash(conflictingExport)).BoundAPIResource).
+				Build(),
+			apiExport:          conflictingExport,
+			apiResourceSchemas: conflictingSchemas,
+			crds:               map[string]*apiextensionsv1.CustomResourceDefinition{"uid1": establishedCRD("resources")},
+			localCRDs: map[string]*apiextensionsv1.CustomResourceDefinition{
+				"resources.group": {ObjectMeta: metav1.ObjectMeta{Name: "resources.group"}},
+			},
+			wantReconcileStatus: reconcileStatusContinue,
+			wantConditions: []wantCondition{
+				{
+					Type:   apisv1alpha1.ConflictsResolved,
+					Status: corev1.ConditionTrue,
+				},
+			},
+			wantAdopted: []schema.GroupVersionResource{{Group: "group", Version: "v1", Resource: "resources"}},
+		},
+		"failed adoption is retried": {
+			apiBinding: unbound.DeepCopy().WithClusterName("org:some-workspace").WithConflictPolicy(apisv1alpha1.APIBindingConflictPolicyAdopt, "").
+				WithPhase(apisv1alpha1.APIBindingPhaseBound).
+				WithBoundResources(new(boundAPIResourceBuilder).WithGroupResource("group", "resources").WithSchema("schema1", "uid1").BoundAPIResource).
+				Build(),
+			apiExport:          conflictingExport,
+			apiResourceSchemas: conflictingSchemas,
+			crds:               map[string]*apiextensionsv1.CustomResourceDefinition{"uid1": establishedCRD("resources")},
+			localCRDs: map[string]*apiextensionsv1.CustomResourceDefinition{
+				"resources.group": {ObjectMeta: metav1.ObjectMeta{Name: "resources.group"}},
+			},
+			adoptError:          errors.New("boom"),
+			wantReconcileStatus: reconcileStatusStop,
+			wantConditions: []wantCondition{
+				{
+					Type:     apisv1alpha1.ConflictsResolved,
+					Status:   corev1.ConditionFalse,
+					Reason:   apisv1alpha1.AdoptionFailedReason,
+					Severity: conditionsv1alpha1.ConditionSeverityWarning,
+				},
+			},
+			wantAdopted: []schema.GroupVersionResource{{Group: "group", Version: "v1", Resource: "resources"}},
+			wantRequeue: true,
+		},
+		"objects are relabelled after an identity rotation": {
+			apiBinding: unbound.DeepCopy().WithClusterName("org:some-workspace").
+				WithPhase(apisv1alpha1.APIBindingPhaseBound).
+				WithBoundResources(new(boundAPIResourceBuilder).WithGroupResource("group", "resources").WithSchema("schema1", "uid1").WithIdentityHash("previous").BoundAPIResource).
+				Build(),
+			apiExport:           rotatedExport,
+			apiResourceSchemas:  conflictingSchemas,
+			crds:                map[string]*apiextensionsv1.CustomResourceDefinition{"uid1": establishedCRD("resources")},
+			wantReconcileStatus: reconcileStatusContinue,
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:        "group",
+					Resource:     "resources",
+					Schema:       apisv1alpha1.BoundAPIResourceSchema{Name: "schema1", UID: "uid1"},
+					IdentityHash: "current",
+				},
+			},
+			wantAdopted:     []schema.GroupVersionResource{{Group: "group", Version: "v1", Resource: "resources"}},
+			wantAdoptedFrom: "previous",
+		},
+		"objects bound before the identity was recorded are labelled": {
+			apiBinding: unbound.DeepCopy().WithClusterName("org:some-workspace").
+				WithPhase(apisv1alpha1.APIBindingPhaseBound).
+				WithBoundResources(new(boundAPIResourceBuilder).WithGroupResource("group", "resources").WithSchema("schema1", "uid1").BoundAPIResource).
+				Build(),
+			apiExport:           conflictingExport,
+			apiResourceSchemas:  conflictingSchemas,
+			crds:                map[string]*apiextensionsv1.CustomResourceDefinition{"uid1": establishedCRD("resources")},
+			wantReconcileStatus: reconcileStatusContinue,
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:        "group",
+					Resource:     "resources",
+					Schema:       apisv1alpha1.BoundAPIResourceSchema{Name: "schema1", UID: "uid1"},
+					IdentityHash: apishelper.IdentityHash(conflictingExport),
+				},
+			},
+			wantAdopted: []schema.GroupVersionResource{{Group: "group", Version: "v1", Resource: "resources"}},
+		},
+		"conflict is renamed with the prefix": {
+			apiBinding:         unbound.DeepCopy().WithClusterName("org:some-workspace").WithConflictPolicy(apisv1alpha1.APIBindingConflictPolicyRenameWithPrefix, "acme").Build(),
+			apiExport:          conflictingExport,
+			apiResourceSchemas: conflictingSchemas,
+			crds:               map[string]*apiextensionsv1.CustomResourceDefinition{"acmeresources.uid1": establishedCRD("acmeresources")},
+			localCRDs: map[string]*apiextensionsv1.CustomResourceDefinition{
+				"resources.group": {ObjectMeta: metav1.ObjectMeta{Name: "resources.group"}},
+			},
+			wantReconcileStatus: reconcileStatusContinue,
+			wantConditions: []wantCondition{
+				{
+					Type:   apisv1alpha1.ConflictsResolved,
+					Status: corev1.ConditionTrue,
+				},
+			},
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:        "group",
+					Resource:     "acmeresources",
+					Schema:       apisv1alpha1.BoundAPIResourceSchema{Name: "schema1", UID: "uid1"},
+					IdentityHash: apishelper.IdentityHash(conflictingExport),
+					RenamedFrom:  "resources",
+				},
+			},
+		},
+		"renamed resource keeps its name": {
+			apiBinding: unbound.DeepCopy().WithClusterName("org:some-workspace").
+				WithPhase(apisv1alpha1.APIBindingPhaseBound).
+				WithBoundResources(apisv1alpha1.BoundAPIResource{
+					Group:        "group",
+					Resource:     "acmeresources",
+					Schema:       apisv1alpha1.BoundAPIResourceSchema{Name: "schema1", UID: "uid1"},
+					IdentityHash: apishelper.IdentityHash(conflictingExport),
+					RenamedFrom:  "resources",
+				}).
+				Build(),
+			apiExport:           conflictingExport,
+			apiResourceSchemas:  conflictingSchemas,
+			crds:                map[string]*apiextensionsv1.CustomResourceDefinition{"acmeresources.uid1": establishedCRD("acmeresources")},
+			wantReconcileStatus: reconcileStatusContinue,
+			wantBoundResources: []apisv1alpha1.BoundAPIResource{
+				{
+					Group:        "group",
+					Resource:     "acmeresources",
+					Schema:       apisv1alpha1.BoundAPIResourceSchema{Name: "schema1", UID: "uid1"},
+					IdentityHash: apishelper.IdentityHash(conflictingExport),
+					RenamedFrom:  "resources",
+				},
+			},
+		},
+		"renamed resource conflicting as well fails": {
+			apiBinding:         unbound.DeepCopy().WithClusterName("org:some-workspace").WithConflictPolicy(apisv1alpha1.APIBindingConflictPolicyRenameWithPrefix, "acme").Build(),
+			apiExport:          conflictingExport,
+			apiResourceSchemas: conflictingSchemas,
+			localCRDs: map[string]*apiextensionsv1.CustomResourceDefinition{
+				"resources.group":     {ObjectMeta: metav1.ObjectMeta{Name: "resources.group"}},
+				"acmeresources.group": {ObjectMeta: metav1.ObjectMeta{Name: "acmeresources.group"}},
+			},
+			wantReconcileStatus: reconcileStatusStop,
+			wantConditions: []wantCondition{
+				{
+					Type:     apisv1alpha1.ConflictsResolved,
+					Status:   corev1.ConditionFalse,
+					Reason:   apisv1alpha1.NamingConflictsReason,
+					Severity: conditionsv1alpha1.ConditionSeverityError,
+				},
+			},
+		},
 	}
 
 	for testName, tc := range tests {
 		t.Run(testName, func(t *testing.T) {
 			createCRDCalled := false
+			var adopted []schema.GroupVersionResource
+			requeued := false
 
 			r := &workspaceAPIExportReferenceReconciler{
 				getAPIExport: func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIExport, error) {
@@ -634,7 +1055,10 @@ func TestWorkspaceAPIExportReferenceReconciler(t *testing.T) {
 					return tc.apiResourceSchemas[name], tc.getAPIResourceSchemaError
 				},
 				getCRD: func(clusterName logicalcluster.LogicalCluster, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
-					require.Equal(t, ShadowWorkspaceName, clusterName)
+					if clusterName != ShadowWorkspaceName {
+						require.Equal(t, "org:some-workspace", clusterName.String())
+						return tc.localCRDs[name], nil
+					}
 					return tc.crds[name], tc.getCRDError
 				},
 				createCRD: func(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, error) {
@@ -645,6 +1069,24 @@ func TestWorkspaceAPIExportReferenceReconciler(t *testing.T) {
 				updateCRD: func(ctx context.Context, crd *apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, error) {
 					return nil, nil
 				},
+				listAPIBindings: func(clusterName logicalcluster.LogicalCluster) ([]*apisv1alpha1.APIBinding, error) {
+					require.Equal(t, "org:some-workspace", clusterName.String())
+					return append(tc.otherAPIBindings, tc.apiBinding), nil
+				},
+				getAllowedAPIGroups: func(clusterName logicalcluster.LogicalCluster) (sets.String, error) {
+					require.Equal(t, logicalcluster.From(tc.apiBinding), clusterName)
+					return sets.NewString(tc.allowedAPIGroups...), nil
+				},
+				adoptObjects: func(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr schema.GroupVersionResource, fromIdentityHash, identityHash string) error {
+					require.Equal(t, "org:some-workspace", clusterName.String())
+					require.Equal(t, tc.wantAdoptedFrom, fromIdentityHash)
+					require.Equal(t, apishelper.IdentityHash(tc.apiExport), identityHash)
+					adopted = append(adopted, gvr)
+					return tc.adoptError
+				},
+				enqueueAfter: func(*apisv1alpha1.APIBinding, time.Duration) {
+					requeued = true
+				},
 				deletedCRDTracker: &lockedStringSet{},
 			}
 
@@ -662,10 +1104,75 @@ func TestWorkspaceAPIExportReferenceReconciler(t *testing.T) {
 			for _, wantCondition := range tc.wantConditions {
 				requireConditionMatches(t, tc.apiBinding, wantCondition)
 			}
+			if tc.wantBoundResources != nil {
+				require.Equal(t, tc.wantBoundResources, tc.apiBinding.Status.BoundResources)
+			}
+			require.Equal(t, tc.wantAdopted, adopted, "adopted resources")
+			require.Equal(t, tc.wantRequeue, requeued, "requeued")
 		})
 	}
 }
 
+var (
+	conflictingExport = &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			ClusterName: "org:some-workspace",
+			Name:        "some-export",
+			UID:         "export-uid",
+		},
+		Spec: apisv1alpha1.APIExportSpec{
+			LatestResourceSchemas: []string{"schema1"},
+		},
+	}
+
+	rotatedExport = &apisv1alpha1.APIExport{
+		ObjectMeta: conflictingExport.ObjectMeta,
+		Spec:       conflictingExport.Spec,
+		Status: apisv1alpha1.APIExportStatus{
+			IdentityHash: "current",
+			PreviousIdentityHashes: []apisv1alpha1.APIExportPreviousIdentity{
+				{IdentityHash: "previous"},
+			},
+		},
+	}
+
+	conflictingSchemas = map[string]*apisv1alpha1.APIResourceSchema{
+		"schema1": {
+			ObjectMeta: metav1.ObjectMeta{Name: "schema1", UID: "uid1"},
+			Spec: apisv1alpha1.APIResourceSchemaSpec{
+				Group: "group",
+				Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "resources", Kind: "Resource"},
+				Versions: []apisv1alpha1.APIResourceVersion{
+					{Name: "v1", Served: true, Storage: true, Schema: runtime.RawExtension{Raw: []byte(`{"type":"object"}`)}},
+				},
+			},
+		},
+	}
+)
+
+func establishedCRD(resource string) *apiextensionsv1.CustomResourceDefinition {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+			},
+		},
+	}
+	schema := conflictingSchemas["schema1"]
+	crd.Spec = apiextensionsv1.CustomResourceDefinitionSpec{
+		Group: schema.Spec.Group,
+		Names: schema.Spec.Names,
+		Scope: schema.Spec.Scope,
+		Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+			{Name: "v1", Served: true, Storage: true, Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Type: "object"}}},
+		},
+	}
+	if resource != schema.Spec.Names.Plural {
+		renameCRD(crd, resource[:len(resource)-len(schema.Spec.Names.Plural)])
+	}
+	return crd
+}
+
 // TODO(ncdc): this is a modified copy from apibinding admission. Unify these into a reusable package.
 type bindingBuilder struct {
 	apisv1alpha1.APIBinding
@@ -704,6 +1211,12 @@ func (b *bindingBuilder) WithWorkspaceReference(workspaceName, exportName string
 	return b
 }
 
+func (b *bindingBuilder) WithConflictPolicy(policy apisv1alpha1.APIBindingConflictPolicy, prefix string) *bindingBuilder {
+	b.Spec.ConflictPolicy = policy
+	b.Spec.ResourcePrefix = prefix
+	return b
+}
+
 func (b *bindingBuilder) WithPhase(phase apisv1alpha1.APIBindingPhaseType) *bindingBuilder {
 	b.Status.Phase = phase
 	return b
@@ -741,3 +1254,8 @@ func (b *boundAPIResourceBuilder) WithSchema(name, uid string) *boundAPIResource
 	}
 	return b
 }
+
+func (b *boundAPIResourceBuilder) WithIdentityHash(identityHash string) *boundAPIResourceBuilder {
+	b.IdentityHash = identityHash
+	return b
+}
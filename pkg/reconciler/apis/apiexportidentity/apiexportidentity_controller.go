@@ -0,0 +1,308 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportidentity
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName                    = "kcp-apiexport-identity"
+	indexAPIExportsByIdentitySecret   = "apiExportIdentity-apiExportsByIdentitySecret"
+	indexAPIExportsByPreviousIdentity = "apiExportIdentity-apiExportsByPreviousIdentity"
+)
+
+// NewController returns a controller maintaining the identity secrets of APIExports. It creates
+// missing secrets, rotates the keys periodically, keeps the previous identity hashes of the
+// APIExports valid for the grace period, and tracks the APIBindings still using them.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	kubeClusterClient kubernetes.ClusterInterface,
+	apiExportInformer apisinformers.APIExportInformer,
+	apiBindingInformer apisinformers.APIBindingInformer,
+	secretInformer coreinformers.SecretInformer,
+) (*controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:             queue,
+		kcpClusterClient:  kcpClusterClient,
+		kubeClusterClient: kubeClusterClient,
+		apiExportsLister:  apiExportInformer.Lister(),
+		apiExportsIndexer: apiExportInformer.Informer().GetIndexer(),
+		apiBindingsLister: apiBindingInformer.Lister(),
+		secretLister:      secretInformer.Lister(),
+		now:               time.Now,
+		newKey:            newRandomKey,
+	}
+	c.getSecret = func(clusterName logicalcluster.LogicalCluster, namespace, name string) (*corev1.Secret, error) {
+		return c.secretLister.Secrets(namespace).Get(clusters.ToClusterAwareKey(clusterName, name))
+	}
+	c.createSecret = func(ctx context.Context, clusterName logicalcluster.LogicalCluster, secret *corev1.Secret) (*corev1.Secret, error) {
+		return c.kubeClusterClient.Cluster(clusterName).CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+	}
+	c.updateSecret = func(ctx context.Context, clusterName logicalcluster.LogicalCluster, secret *corev1.Secret) (*corev1.Secret, error) {
+		return c.kubeClusterClient.Cluster(clusterName).CoreV1().Secrets(secret.Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	c.listAPIBindings = func() ([]*apisv1alpha1.APIBinding, error) {
+		return c.apiBindingsLister.List(labels.Everything())
+	}
+	c.enqueueAfter = func(apiExport *apisv1alpha1.APIExport, duration time.Duration) {
+		key, err := cache.MetaNamespaceKeyFunc(apiExport)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		c.queue.AddAfter(key, duration)
+	}
+
+	if err := c.apiExportsIndexer.AddIndexers(cache.Indexers{
+		indexAPIExportsByIdentitySecret:   indexAPIExportByIdentitySecret,
+		indexAPIExportsByPreviousIdentity: indexAPIExportByPreviousIdentity,
+	}); err != nil {
+		return nil, fmt.Errorf("error adding APIExport indexes: %w", err)
+	}
+
+	apiExportInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			apiExport, ok := obj.(*apisv1alpha1.APIExport)
+			return ok && (apiExport.Spec.Identity != nil || len(apiExport.Status.PreviousIdentityHashes) > 0)
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueueAPIExport(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueueAPIExport(obj) },
+		},
+	}))
+
+	// a changed key of an identity secret is a rotation
+	secretInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueSecret(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueSecret(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueSecret(obj) },
+	}))
+
+	// APIBindings moving to the current identity progress the rotation
+	apiBindingInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, _ interface{}) { c.enqueueAPIBinding(old) },
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIBinding(obj) },
+	}))
+
+	return c, nil
+}
+
+// controller maintains the identity secrets and identity status of APIExports.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient  kcpclient.ClusterInterface
+	kubeClusterClient kubernetes.ClusterInterface
+
+	apiExportsLister  apislisters.APIExportLister
+	apiExportsIndexer cache.Indexer
+	apiBindingsLister apislisters.APIBindingLister
+	secretLister      corelisters.SecretLister
+
+	now             func() time.Time
+	newKey          func() ([]byte, error)
+	getSecret       func(clusterName logicalcluster.LogicalCluster, namespace, name string) (*corev1.Secret, error)
+	createSecret    func(ctx context.Context, clusterName logicalcluster.LogicalCluster, secret *corev1.Secret) (*corev1.Secret, error)
+	updateSecret    func(ctx context.Context, clusterName logicalcluster.LogicalCluster, secret *corev1.Secret) (*corev1.Secret, error)
+	listAPIBindings func() ([]*apisv1alpha1.APIBinding, error)
+	enqueueAfter    func(apiExport *apisv1alpha1.APIExport, duration time.Duration)
+}
+
+func (c *controller) enqueueAPIExport(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueSecret enqueues the APIExports referencing a secret as identity secret.
+func (c *controller) enqueueSecret(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a Secret, but is %T", obj))
+		return
+	}
+
+	apiExports, err := c.apiExportsIndexer.ByIndex(indexAPIExportsByIdentitySecret, identitySecretKey(logicalcluster.From(secret), secret.Namespace, secret.Name))
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, apiExport := range apiExports {
+		c.enqueueAPIExport(apiExport)
+	}
+}
+
+// enqueueAPIBinding enqueues the APIExports one of the identity hashes of an APIBinding is a
+// previous identity of.
+func (c *controller) enqueueAPIBinding(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIBinding, but is %T", obj))
+		return
+	}
+
+	for _, boundResource := range apiBinding.Status.BoundResources {
+		if boundResource.IdentityHash == "" {
+			continue
+		}
+		apiExports, err := c.apiExportsIndexer.ByIndex(indexAPIExportsByPreviousIdentity, boundResource.IdentityHash)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		for _, apiExport := range apiExports {
+			c.enqueueAPIExport(apiExport)
+		}
+	}
+}
+
+// newRandomKey returns a new random identity key.
+func newRandomKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.apiExportsLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	old := obj
+	obj = obj.DeepCopy()
+
+	if err := c.reconcile(ctx, obj); err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(apisv1alpha1.APIExport{
+		Status: old.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for apiexport %s|%s: %w", clusterName, obj.Name, err)
+	}
+
+	newData, err := json.Marshal(apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for apiexport %s|%s: %w", clusterName, obj.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for apiexport %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).ApisV1alpha1().APIExports().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
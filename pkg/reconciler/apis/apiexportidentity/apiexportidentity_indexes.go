@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportidentity
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/client-go/tools/clusters"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// indexAPIExportByIdentitySecret is an index function that maps an APIExport to its identity secret.
+func indexAPIExportByIdentitySecret(obj interface{}) ([]string, error) {
+	apiExport, ok := obj.(*apisv1alpha1.APIExport)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj)
+	}
+
+	if apiExport.Spec.Identity == nil {
+		return []string{}, nil
+	}
+	ref := apiExport.Spec.Identity.SecretRef
+	return []string{identitySecretKey(logicalcluster.From(apiExport), ref.Namespace, ref.Name)}, nil
+}
+
+// indexAPIExportByPreviousIdentity is an index function that maps an APIExport to its previous
+// identity hashes.
+func indexAPIExportByPreviousIdentity(obj interface{}) ([]string, error) {
+	apiExport, ok := obj.(*apisv1alpha1.APIExport)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj)
+	}
+
+	hashes := make([]string, 0, len(apiExport.Status.PreviousIdentityHashes))
+	for _, previous := range apiExport.Status.PreviousIdentityHashes {
+		hashes = append(hashes, previous.IdentityHash)
+	}
+	return hashes, nil
+}
+
+func identitySecretKey(clusterName logicalcluster.LogicalCluster, namespace, name string) string {
+	return namespace + "/" + clusters.ToClusterAwareKey(clusterName, name)
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportidentity
+
+import (
+	"context"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apishelper "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/helper"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// defaultGracePeriod is how long a previous identity stays valid if the APIExport does not say otherwise.
+const defaultGracePeriod = 24 * time.Hour
+
+func (c *controller) reconcile(ctx context.Context, apiExport *apisv1alpha1.APIExport) error {
+	now := c.now()
+	c.pruneExpiredIdentities(apiExport, now)
+
+	identity := apiExport.Spec.Identity
+	if identity == nil {
+		// the current identity is kept, only the previous ones expire
+		return c.updateRotationProgress(apiExport, now)
+	}
+
+	clusterName := logicalcluster.From(apiExport)
+	ref := identity.SecretRef
+	secret, err := c.getSecret(clusterName, ref.Namespace, ref.Name)
+	if errors.IsNotFound(err) {
+		key, err := c.newKey()
+		if err != nil {
+			return err
+		}
+		secret, err = c.createSecret(ctx, clusterName, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ref.Namespace,
+				Name:      ref.Name,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{apisv1alpha1.APIExportIdentityKey: key},
+		})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			conditions.MarkFalse(
+				apiExport,
+				apisv1alpha1.IdentityValid,
+				apisv1alpha1.IdentitySecretInvalidReason,
+				conditionsv1alpha1.ConditionSeverityError,
+				"failed to create identity secret %s/%s: %v",
+				ref.Namespace,
+				ref.Name,
+				err,
+			)
+			c.enqueueAfter(apiExport, time.Minute)
+			return nil
+		} else if err != nil {
+			return err // retry with the existing secret in the informer
+		}
+		klog.Infof("Created identity secret %s/%s for APIExport %s|%s", ref.Namespace, ref.Name, clusterName, apiExport.Name)
+		apiExport.Status.LastRotationTime = &metav1.Time{Time: now}
+	} else if err != nil {
+		return err
+	}
+
+	key := secret.Data[apisv1alpha1.APIExportIdentityKey]
+	if len(key) == 0 {
+		conditions.MarkFalse(
+			apiExport,
+			apisv1alpha1.IdentityValid,
+			apisv1alpha1.IdentitySecretInvalidReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"identity secret %s/%s has no %q key",
+			ref.Namespace,
+			ref.Name,
+			apisv1alpha1.APIExportIdentityKey,
+		)
+		return nil // retried when the secret changes
+	}
+	conditions.MarkTrue(apiExport, apisv1alpha1.IdentityValid)
+
+	if apiExport.Status.LastRotationTime == nil {
+		// a secret created by the user starts the rotation period when it is first seen
+		apiExport.Status.LastRotationTime = &metav1.Time{Time: now}
+	}
+	if identity.RotationPeriod != nil && !now.Before(apiExport.Status.LastRotationTime.Add(identity.RotationPeriod.Duration)) {
+		newKey, err := c.newKey()
+		if err != nil {
+			return err
+		}
+		secret = secret.DeepCopy()
+		secret.Data[apisv1alpha1.APIExportIdentityKey] = newKey
+		if _, err := c.updateSecret(ctx, clusterName, secret); err != nil {
+			return err
+		}
+		klog.Infof("Rotated identity secret %s/%s of APIExport %s|%s", ref.Namespace, ref.Name, clusterName, apiExport.Name)
+		key = newKey
+		apiExport.Status.LastRotationTime = &metav1.Time{Time: now}
+	}
+
+	// Switching from the UID based identity to the secret is a rotation as well.
+	if hash, current := apishelper.IdentityHashFromKey(key), apishelper.IdentityHash(apiExport); hash != current {
+		gracePeriod := defaultGracePeriod
+		if identity.GracePeriod != nil {
+			gracePeriod = identity.GracePeriod.Duration
+		}
+		apiExport.Status.PreviousIdentityHashes = append(apiExport.Status.PreviousIdentityHashes, apisv1alpha1.APIExportPreviousIdentity{
+			IdentityHash: current,
+			ValidUntil:   metav1.Time{Time: now.Add(gracePeriod)},
+		})
+		apiExport.Status.IdentityHash = hash
+	}
+
+	if identity.RotationPeriod != nil {
+		c.enqueueAfter(apiExport, apiExport.Status.LastRotationTime.Add(identity.RotationPeriod.Duration).Sub(now))
+	}
+
+	return c.updateRotationProgress(apiExport, now)
+}
+
+// pruneExpiredIdentities drops the previous identities whose grace period is over.
+func (c *controller) pruneExpiredIdentities(apiExport *apisv1alpha1.APIExport, now time.Time) {
+	var valid []apisv1alpha1.APIExportPreviousIdentity
+	for _, previous := range apiExport.Status.PreviousIdentityHashes {
+		if now.Before(previous.ValidUntil.Time) {
+			valid = append(valid, previous)
+		}
+	}
+	apiExport.Status.PreviousIdentityHashes = valid
+}
+
+// updateRotationProgress sets the IdentityRotated condition from the APIBindings still using a
+// previous identity, and requeues the APIExport when the next previous identity expires.
+func (c *controller) updateRotationProgress(apiExport *apisv1alpha1.APIExport, now time.Time) error {
+	if len(apiExport.Status.PreviousIdentityHashes) == 0 {
+		if conditions.Has(apiExport, apisv1alpha1.IdentityRotated) {
+			conditions.MarkTrue(apiExport, apisv1alpha1.IdentityRotated)
+		}
+		return nil
+	}
+
+	previous := sets.NewString()
+	nextExpiry := apiExport.Status.PreviousIdentityHashes[0].ValidUntil.Time
+	for _, identity := range apiExport.Status.PreviousIdentityHashes {
+		previous.Insert(identity.IdentityHash)
+		if identity.ValidUntil.Before(&metav1.Time{Time: nextExpiry}) {
+			nextExpiry = identity.ValidUntil.Time
+		}
+	}
+	c.enqueueAfter(apiExport, nextExpiry.Sub(now))
+
+	apiBindings, err := c.listAPIBindings()
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, apiBinding := range apiBindings {
+		for _, boundResource := range apiBinding.Status.BoundResources {
+			if previous.Has(boundResource.IdentityHash) {
+				pending++
+				break
+			}
+		}
+	}
+
+	if pending > 0 {
+		conditions.MarkFalse(
+			apiExport,
+			apisv1alpha1.IdentityRotated,
+			apisv1alpha1.RotationInProgressReason,
+			conditionsv1alpha1.ConditionSeverityInfo,
+			"%d APIBindings still use a previous identity, valid until %s",
+			pending,
+			nextExpiry.UTC().Format(time.RFC3339),
+		)
+		return nil
+	}
+	conditions.MarkTrue(apiExport, apisv1alpha1.IdentityRotated)
+
+	return nil
+}
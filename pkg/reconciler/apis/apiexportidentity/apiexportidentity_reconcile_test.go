@@ -0,0 +1,287 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexportidentity
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apishelper "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/helper"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	uidExport := &apisv1alpha1.APIExport{ObjectMeta: metav1.ObjectMeta{ClusterName: "root:org:ws", Name: "today", UID: "uid"}}
+	uidHash := apishelper.IdentityHash(uidExport)
+	oldHash := apishelper.IdentityHashFromKey([]byte("old"))
+	newHash := apishelper.IdentityHashFromKey([]byte("new"))
+	secret := func(key string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kcp-system", Name: "identity"},
+			Data:       map[string][]byte{apisv1alpha1.APIExportIdentityKey: []byte(key)},
+		}
+	}
+	bindingWithIdentity := func(identityHash string) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{
+			Status: apisv1alpha1.APIBindingStatus{
+				BoundResources: []apisv1alpha1.BoundAPIResource{{Group: "group", Resource: "resources", IdentityHash: identityHash}},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		identity    *apisv1alpha1.APIExportIdentity
+		status      apisv1alpha1.APIExportStatus
+		secret      *corev1.Secret
+		createErr   error
+		apiBindings []*apisv1alpha1.APIBinding
+
+		wantCreatedKey    string
+		wantUpdatedKey    string
+		wantIdentityHash  string
+		wantPrevious      []apisv1alpha1.APIExportPreviousIdentity
+		wantLastRotation  *time.Time
+		wantValid         corev1.ConditionStatus
+		wantRotated       corev1.ConditionStatus
+		wantRotatedReason string
+		wantRequeueAfter  time.Duration
+	}{
+		"no identity": {},
+		"secret is created": {
+			identity:         &apisv1alpha1.APIExportIdentity{SecretRef: corev1.SecretReference{Namespace: "kcp-system", Name: "identity"}},
+			wantCreatedKey:   "new",
+			wantIdentityHash: newHash,
+			wantPrevious: []apisv1alpha1.APIExportPreviousIdentity{
+				{IdentityHash: uidHash, ValidUntil: metav1.NewTime(now.Add(defaultGracePeriod))},
+			},
+			wantLastRotation: &now,
+			wantValid:        corev1.ConditionTrue,
+			wantRotated:      corev1.ConditionTrue,
+			wantRequeueAfter: defaultGracePeriod,
+		},
+		"secret cannot be created": {
+			identity:         &apisv1alpha1.APIExportIdentity{SecretRef: corev1.SecretReference{Namespace: "missing", Name: "identity"}},
+			createErr:        apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "missing"),
+			wantValid:        corev1.ConditionFalse,
+			wantRequeueAfter: time.Minute,
+		},
+		"secret without key": {
+			identity:  &apisv1alpha1.APIExportIdentity{SecretRef: corev1.SecretReference{Namespace: "kcp-system", Name: "identity"}},
+			secret:    secret(""),
+			wantValid: corev1.ConditionFalse,
+		},
+		"APIBindings on the UID identity are tracked": {
+			identity: &apisv1alpha1.APIExportIdentity{
+				SecretRef:   corev1.SecretReference{Namespace: "kcp-system", Name: "identity"},
+				GracePeriod: &metav1.Duration{Duration: time.Hour},
+			},
+			secret:           secret("old"),
+			apiBindings:      []*apisv1alpha1.APIBinding{bindingWithIdentity(uidHash), bindingWithIdentity(oldHash)},
+			wantIdentityHash: oldHash,
+			wantPrevious: []apisv1alpha1.APIExportPreviousIdentity{
+				{IdentityHash: uidHash, ValidUntil: metav1.NewTime(now.Add(time.Hour))},
+			},
+			wantLastRotation:  &now,
+			wantValid:         corev1.ConditionTrue,
+			wantRotated:       corev1.ConditionFalse,
+			wantRotatedReason: apisv1alpha1.RotationInProgressReason,
+			wantRequeueAfter:  time.Hour,
+		},
+		"rotation not due": {
+			identity: &apisv1alpha1.APIExportIdentity{
+				SecretRef:      corev1.SecretReference{Namespace: "kcp-system", Name: "identity"},
+				RotationPeriod: &metav1.Duration{Duration: 30 * 24 * time.Hour},
+			},
+			status: apisv1alpha1.APIExportStatus{
+				IdentityHash:     oldHash,
+				LastRotationTime: &metav1.Time{Time: now.Add(-24 * time.Hour)},
+			},
+			secret:           secret("old"),
+			wantIdentityHash: oldHash,
+			wantLastRotation: timePtr(now.Add(-24 * time.Hour)),
+			wantValid:        corev1.ConditionTrue,
+			wantRequeueAfter: 29 * 24 * time.Hour,
+		},
+		"rotation due": {
+			identity: &apisv1alpha1.APIExportIdentity{
+				SecretRef:      corev1.SecretReference{Namespace: "kcp-system", Name: "identity"},
+				RotationPeriod: &metav1.Duration{Duration: 30 * 24 * time.Hour},
+				GracePeriod:    &metav1.Duration{Duration: time.Hour},
+			},
+			status: apisv1alpha1.APIExportStatus{
+				IdentityHash:     oldHash,
+				LastRotationTime: &metav1.Time{Time: now.Add(-30 * 24 * time.Hour)},
+			},
+			secret:           secret("old"),
+			apiBindings:      []*apisv1alpha1.APIBinding{bindingWithIdentity(newHash)},
+			wantUpdatedKey:   "new",
+			wantIdentityHash: newHash,
+			wantPrevious: []apisv1alpha1.APIExportPreviousIdentity{
+				{IdentityHash: oldHash, ValidUntil: metav1.NewTime(now.Add(time.Hour))},
+			},
+			wantLastRotation: &now,
+			wantValid:        corev1.ConditionTrue,
+			wantRotated:      corev1.ConditionTrue,
+			wantRequeueAfter: time.Hour,
+		},
+		"key changed in the secret": {
+			identity: &apisv1alpha1.APIExportIdentity{SecretRef: corev1.SecretReference{Namespace: "kcp-system", Name: "identity"}},
+			status: apisv1alpha1.APIExportStatus{
+				IdentityHash:     oldHash,
+				LastRotationTime: &metav1.Time{Time: now.Add(-time.Hour)},
+			},
+			secret:           secret("new"),
+			apiBindings:      []*apisv1alpha1.APIBinding{bindingWithIdentity(oldHash)},
+			wantIdentityHash: newHash,
+			wantPrevious: []apisv1alpha1.APIExportPreviousIdentity{
+				{IdentityHash: oldHash, ValidUntil: metav1.NewTime(now.Add(defaultGracePeriod))},
+			},
+			wantLastRotation:  timePtr(now.Add(-time.Hour)),
+			wantValid:         corev1.ConditionTrue,
+			wantRotated:       corev1.ConditionFalse,
+			wantRotatedReason: apisv1alpha1.RotationInProgressReason,
+			wantRequeueAfter:  defaultGracePeriod,
+		},
+		"expired previous identity is dropped": {
+			identity: &apisv1alpha1.APIExportIdentity{SecretRef: corev1.SecretReference{Namespace: "kcp-system", Name: "identity"}},
+			status: apisv1alpha1.APIExportStatus{
+				IdentityHash:     newHash,
+				LastRotationTime: &metav1.Time{Time: now.Add(-48 * time.Hour)},
+				PreviousIdentityHashes: []apisv1alpha1.APIExportPreviousIdentity{
+					{IdentityHash: oldHash, ValidUntil: metav1.NewTime(now.Add(-time.Minute))},
+				},
+			},
+			secret:           secret("new"),
+			apiBindings:      []*apisv1alpha1.APIBinding{bindingWithIdentity(oldHash)},
+			wantIdentityHash: newHash,
+			wantLastRotation: timePtr(now.Add(-48 * time.Hour)),
+			wantValid:        corev1.ConditionTrue,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var createdKey, updatedKey string
+			var requeueAfter time.Duration
+			c := &controller{
+				now: func() time.Time { return now },
+				newKey: func() ([]byte, error) {
+					return []byte("new"), nil
+				},
+				getSecret: func(clusterName logicalcluster.LogicalCluster, namespace, name string) (*corev1.Secret, error) {
+					require.Equal(t, "root:org:ws", clusterName.String())
+					if tc.secret == nil || tc.secret.Namespace != namespace || tc.secret.Name != name {
+						return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+					}
+					return tc.secret, nil
+				},
+				createSecret: func(ctx context.Context, clusterName logicalcluster.LogicalCluster, secret *corev1.Secret) (*corev1.Secret, error) {
+					if tc.createErr != nil {
+						return nil, tc.createErr
+					}
+					createdKey = string(secret.Data[apisv1alpha1.APIExportIdentityKey])
+					return secret, nil
+				},
+				updateSecret: func(ctx context.Context, clusterName logicalcluster.LogicalCluster, secret *corev1.Secret) (*corev1.Secret, error) {
+					updatedKey = string(secret.Data[apisv1alpha1.APIExportIdentityKey])
+					return secret, nil
+				},
+				listAPIBindings: func() ([]*apisv1alpha1.APIBinding, error) {
+					return tc.apiBindings, nil
+				},
+				enqueueAfter: func(_ *apisv1alpha1.APIExport, duration time.Duration) {
+					if requeueAfter == 0 || duration < requeueAfter {
+						requeueAfter = duration
+					}
+				},
+			}
+
+			apiExport := uidExport.DeepCopy()
+			apiExport.Spec.Identity = tc.identity
+			apiExport.Status = tc.status
+
+			err := c.reconcile(context.Background(), apiExport)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.wantCreatedKey, createdKey, "created key")
+			require.Equal(t, tc.wantUpdatedKey, updatedKey, "updated key")
+			require.Equal(t, tc.wantIdentityHash, apiExport.Status.IdentityHash)
+			require.Equal(t, tc.wantPrevious, apiExport.Status.PreviousIdentityHashes)
+			if tc.wantLastRotation == nil {
+				require.Nil(t, apiExport.Status.LastRotationTime)
+			} else {
+				require.NotNil(t, apiExport.Status.LastRotationTime)
+				require.Equal(t, *tc.wantLastRotation, apiExport.Status.LastRotationTime.Time)
+			}
+			validReason := ""
+			if tc.wantValid == corev1.ConditionFalse {
+				validReason = apisv1alpha1.IdentitySecretInvalidReason
+			}
+			requireCondition(t, apiExport, apisv1alpha1.IdentityValid, tc.wantValid, validReason)
+			requireCondition(t, apiExport, apisv1alpha1.IdentityRotated, tc.wantRotated, tc.wantRotatedReason)
+			require.Equal(t, tc.wantRequeueAfter, requeueAfter, "requeue after")
+		})
+	}
+}
+
+func TestReconcileCreateError(t *testing.T) {
+	c := &controller{
+		now: time.Now,
+		newKey: func() ([]byte, error) {
+			return nil, errors.New("no entropy")
+		},
+		getSecret: func(clusterName logicalcluster.LogicalCluster, namespace, name string) (*corev1.Secret, error) {
+			return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+		},
+	}
+	apiExport := &apisv1alpha1.APIExport{
+		Spec: apisv1alpha1.APIExportSpec{
+			Identity: &apisv1alpha1.APIExportIdentity{SecretRef: corev1.SecretReference{Namespace: "kcp-system", Name: "identity"}},
+		},
+	}
+	require.Error(t, c.reconcile(context.Background(), apiExport))
+}
+
+func requireCondition(t *testing.T, apiExport *apisv1alpha1.APIExport, conditionType conditionsv1alpha1.ConditionType, status corev1.ConditionStatus, reason string) {
+	t.Helper()
+
+	if status == "" {
+		require.False(t, conditions.Has(apiExport, conditionType), "unexpected condition %s", conditionType)
+		return
+	}
+	c := conditions.Get(apiExport, conditionType)
+	require.NotNil(t, c, "missing condition %s", conditionType)
+	require.Equal(t, status, c.Status, "condition %s", conditionType)
+	require.Equal(t, reason, c.Reason, "condition %s", conditionType)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
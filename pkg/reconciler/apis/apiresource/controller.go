@@ -38,7 +38,9 @@ import (
 	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	apiresourceinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apiresource/v1alpha1"
+	workloadinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	apiresourcelister "github.com/kcp-dev/kcp/pkg/client/listers/apiresource/v1alpha1"
+	workloadlister "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
 )
 
 const clusterNameAndGVRIndexName = "clusterNameAndGVR"
@@ -52,9 +54,11 @@ func NewController(
 	crdClusterClient *apiextensionsclientset.Cluster,
 	kcpClusterClient *kcpclient.Cluster,
 	autoPublishNegotiatedAPIResource bool,
+	orphanRetention time.Duration,
 	negotiatedAPIResourceInformer apiresourceinformer.NegotiatedAPIResourceInformer,
 	apiResourceImportInformer apiresourceinformer.APIResourceImportInformer,
 	crdInformer crdinfomer.CustomResourceDefinitionInformer,
+	workloadClusterInformer workloadinformer.WorkloadClusterInformer,
 ) (*Controller, error) {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-apiresource")
 
@@ -63,12 +67,14 @@ func NewController(
 		crdClusterClient:                 crdClusterClient,
 		kcpClusterClient:                 kcpClusterClient,
 		AutoPublishNegotiatedAPIResource: autoPublishNegotiatedAPIResource,
+		orphanRetention:                  orphanRetention,
 		negotiatedApiResourceIndexer:     negotiatedAPIResourceInformer.Informer().GetIndexer(),
 		negotiatedApiResourceLister:      negotiatedAPIResourceInformer.Lister(),
 		apiResourceImportIndexer:         apiResourceImportInformer.Informer().GetIndexer(),
 		apiResourceImportLister:          apiResourceImportInformer.Lister(),
 		crdIndexer:                       crdInformer.Informer().GetIndexer(),
 		crdLister:                        crdInformer.Lister(),
+		workloadClusterLister:            workloadClusterInformer.Lister(),
 	}
 
 	negotiatedAPIResourceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -139,7 +145,12 @@ type Controller struct {
 	crdIndexer cache.Indexer
 	crdLister  crdlister.CustomResourceDefinitionLister
 
+	workloadClusterLister workloadlister.WorkloadClusterLister
+
 	AutoPublishNegotiatedAPIResource bool
+
+	// orphanRetention is the time an unused NegotiatedAPIResource is kept before it is garbage collected.
+	orphanRetention time.Duration
 }
 
 type queueElementType string
@@ -300,6 +311,8 @@ func (c *Controller) Start(ctx context.Context, numThreads int) {
 		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
 	}
 
+	go wait.UntilWithContext(ctx, c.collectOrphanedNegotiatedAPIResources, orphanCheckInterval(c.orphanRetention))
+
 	<-ctx.Done()
 }
 
@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiresource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+)
+
+// orphanAction is the garbage collection decision taken for a NegotiatedAPIResource.
+type orphanAction string
+
+const (
+	orphanActionNone   orphanAction = "None"
+	orphanActionMark   orphanAction = "Mark"
+	orphanActionUnmark orphanAction = "Unmark"
+	orphanActionDelete orphanAction = "Delete"
+)
+
+// minOrphanCheckInterval is the shortest period between two checks for orphaned NegotiatedAPIResources.
+const minOrphanCheckInterval = 10 * time.Second
+
+// orphanCheckInterval returns how often the NegotiatedAPIResources are checked for being orphaned.
+func orphanCheckInterval(retention time.Duration) time.Duration {
+	if retention < minOrphanCheckInterval {
+		return minOrphanCheckInterval
+	}
+	if retention < time.Minute {
+		return retention
+	}
+	return time.Minute
+}
+
+// decideOrphanAction decides what to do with a NegotiatedAPIResource given the number of APIResourceImports
+// of existing WorkloadClusters for its GVR.
+func decideOrphanAction(negotiatedAPIResource *apiresourcev1alpha1.NegotiatedAPIResource, liveImports int, retention time.Duration, now time.Time) orphanAction {
+	// manually applied CRDs are owned by the user, not by the negotiation.
+	if negotiatedAPIResource.IsConditionTrue(apiresourcev1alpha1.Enforced) {
+		return orphanActionNone
+	}
+
+	value, marked := negotiatedAPIResource.Annotations[apiresourcev1alpha1.OrphanedSinceAnnotation]
+	if liveImports > 0 {
+		if marked {
+			return orphanActionUnmark
+		}
+		return orphanActionNone
+	}
+	if retention <= 0 {
+		return orphanActionDelete
+	}
+	if !marked {
+		return orphanActionMark
+	}
+
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// reset an unparseable value instead of deleting right away
+		return orphanActionMark
+	}
+	if now.Sub(since) >= retention {
+		return orphanActionDelete
+	}
+	return orphanActionNone
+}
+
+// liveAPIResourceImports returns the APIResourceImports for the GVR whose WorkloadCluster still exists,
+// and the ones that are left over from deleted WorkloadClusters.
+func (c *Controller) liveAPIResourceImports(clusterName logicalcluster.LogicalCluster, gvr metav1.GroupVersionResource) (live, stale []*apiresourcev1alpha1.APIResourceImport, err error) {
+	objs, err := c.apiResourceImportIndexer.ByIndex(clusterNameAndGVRIndexName, GetClusterNameAndGVRIndexKey(clusterName, gvr))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, obj := range objs {
+		apiResourceImport := obj.(*apiresourcev1alpha1.APIResourceImport)
+		_, err := c.workloadClusterLister.Get(clusters.ToClusterAwareKey(clusterName, apiResourceImport.Spec.Location))
+		if k8serrors.IsNotFound(err) {
+			stale = append(stale, apiResourceImport)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		live = append(live, apiResourceImport)
+	}
+	return live, stale, nil
+}
+
+// markNegotiatedAPIResourceOrphaned sets the orphaned-since annotation on the NegotiatedAPIResource of the GVR,
+// if not already set.
+func (c *Controller) markNegotiatedAPIResourceOrphaned(ctx context.Context, clusterName logicalcluster.LogicalCluster, gvr metav1.GroupVersionResource) error {
+	objs, err := c.negotiatedApiResourceIndexer.ByIndex(clusterNameAndGVRIndexName, GetClusterNameAndGVRIndexKey(clusterName, gvr))
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		negotiatedAPIResource := obj.(*apiresourcev1alpha1.NegotiatedAPIResource)
+		if _, marked := negotiatedAPIResource.Annotations[apiresourcev1alpha1.OrphanedSinceAnnotation]; marked {
+			continue
+		}
+		if err := c.patchOrphanedSince(ctx, negotiatedAPIResource, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchOrphanedSince sets the orphaned-since annotation to the given value, or removes it if the value is empty.
+func (c *Controller) patchOrphanedSince(ctx context.Context, negotiatedAPIResource *apiresourcev1alpha1.NegotiatedAPIResource, value string) error {
+	var annotationValue interface{}
+	if value != "" {
+		annotationValue = value
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				apiresourcev1alpha1.OrphanedSinceAnnotation: annotationValue,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kcpClusterClient.Cluster(logicalcluster.From(negotiatedAPIResource)).ApiresourceV1alpha1().NegotiatedAPIResources().Patch(ctx, negotiatedAPIResource.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// collectOrphanedNegotiatedAPIResources deletes the NegotiatedAPIResources that are not backed by an
+// APIResourceImport of an existing WorkloadCluster for longer than the retention period. The deletion
+// of the NegotiatedAPIResource in turn removes the CRD version that was published from it.
+func (c *Controller) collectOrphanedNegotiatedAPIResources(ctx context.Context) {
+	negotiatedAPIResources, err := c.negotiatedApiResourceLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	now := time.Now()
+	for _, negotiatedAPIResource := range negotiatedAPIResources {
+		if err := c.collectOrphanedNegotiatedAPIResource(ctx, negotiatedAPIResource, now); err != nil {
+			runtime.HandleError(fmt.Errorf("%q controller failed to garbage collect NegotiatedAPIResource %s|%s: %w", controllerName, logicalcluster.From(negotiatedAPIResource), negotiatedAPIResource.Name, err))
+		}
+	}
+}
+
+func (c *Controller) collectOrphanedNegotiatedAPIResource(ctx context.Context, negotiatedAPIResource *apiresourcev1alpha1.NegotiatedAPIResource, now time.Time) error {
+	clusterName := logicalcluster.From(negotiatedAPIResource)
+	gvr := negotiatedAPIResource.GVR()
+
+	live, stale, err := c.liveAPIResourceImports(clusterName, gvr)
+	if err != nil {
+		return err
+	}
+
+	switch decideOrphanAction(negotiatedAPIResource, len(live), c.orphanRetention, now) {
+	case orphanActionMark:
+		return c.patchOrphanedSince(ctx, negotiatedAPIResource, now.UTC().Format(time.RFC3339))
+	case orphanActionUnmark:
+		return c.patchOrphanedSince(ctx, negotiatedAPIResource, "")
+	case orphanActionDelete:
+		klog.Infof("Deleting NegotiatedAPIResource %s|%s for %s: no WorkloadCluster imported it for %s", clusterName, negotiatedAPIResource.Name, gvr.String(), c.orphanRetention)
+		for _, apiResourceImport := range stale {
+			if err := c.kcpClusterClient.Cluster(clusterName).ApiresourceV1alpha1().APIResourceImports().Delete(ctx, apiResourceImport.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+		err := c.kcpClusterClient.Cluster(clusterName).ApiresourceV1alpha1().NegotiatedAPIResources().Delete(ctx, negotiatedAPIResource.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &negotiatedAPIResource.UID},
+		})
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
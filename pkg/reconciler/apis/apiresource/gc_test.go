@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiresource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiresourcev1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apiresource/v1alpha1"
+)
+
+func TestDecideOrphanAction(t *testing.T) {
+	now := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	retention := 10 * time.Minute
+
+	orphanedSince := func(d time.Duration) map[string]string {
+		return map[string]string{apiresourcev1alpha1.OrphanedSinceAnnotation: now.Add(-d).Format(time.RFC3339)}
+	}
+
+	tests := map[string]struct {
+		annotations map[string]string
+		enforced    bool
+		liveImports int
+		noRetention bool
+		want        orphanAction
+	}{
+		"imported, not marked": {
+			liveImports: 1,
+			want:        orphanActionNone,
+		},
+		"imported again after being marked": {
+			annotations: orphanedSince(time.Minute),
+			liveImports: 2,
+			want:        orphanActionUnmark,
+		},
+		"not imported, not marked": {
+			want: orphanActionMark,
+		},
+		"not imported, within retention": {
+			annotations: orphanedSince(time.Minute),
+			want:        orphanActionNone,
+		},
+		"not imported, retention elapsed": {
+			annotations: orphanedSince(retention),
+			want:        orphanActionDelete,
+		},
+		"invalid timestamp": {
+			annotations: map[string]string{apiresourcev1alpha1.OrphanedSinceAnnotation: "yesterday"},
+			want:        orphanActionMark,
+		},
+		"not imported, no retention": {
+			noRetention: true,
+			want:        orphanActionDelete,
+		},
+		"imported, no retention": {
+			liveImports: 1,
+			noRetention: true,
+			want:        orphanActionNone,
+		},
+		"enforced by a manually applied CRD": {
+			annotations: orphanedSince(time.Hour),
+			enforced:    true,
+			want:        orphanActionNone,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			negotiatedAPIResource := &apiresourcev1alpha1.NegotiatedAPIResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "deployments.v1.apps",
+					Annotations: tc.annotations,
+				},
+			}
+			if tc.enforced {
+				negotiatedAPIResource.SetCondition(apiresourcev1alpha1.NegotiatedAPIResourceCondition{
+					Type:   apiresourcev1alpha1.Enforced,
+					Status: metav1.ConditionTrue,
+				})
+			}
+			retention := retention
+			if tc.noRetention {
+				retention = 0
+			}
+			got := decideOrphanAction(negotiatedAPIResource, tc.liveImports, retention, now)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -115,6 +115,10 @@ func (c *Controller) process(ctx context.Context, key queueElement) error {
 				return err
 			}
 			if isOrphan {
+				if c.orphanRetention > 0 {
+					// The periodic garbage collection deletes the NegotiatedAPIResource once the retention period is over.
+					return c.markNegotiatedAPIResourceOrphaned(ctx, logicalcluster.From(apiResourceImport), key.gvr)
+				}
 				return c.deleteNegotiatedAPIResource(ctx, logicalcluster.From(apiResourceImport), key.gvr, nil)
 			}
 
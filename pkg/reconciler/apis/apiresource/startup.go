@@ -17,7 +17,9 @@ limitations under the License.
 package apiresource
 
 import (
+	"fmt"
 	"runtime"
+	"time"
 
 	"github.com/spf13/pflag"
 )
@@ -27,7 +29,8 @@ func DefaultOptions() *Options {
 	return &Options{
 		AutoPublishAPIs: false,
 		// Consumed by server instantiation
-		NumThreads: runtime.NumCPU(),
+		NumThreads:      runtime.NumCPU(),
+		OrphanRetention: 10 * time.Minute,
 	}
 }
 
@@ -35,6 +38,7 @@ func DefaultOptions() *Options {
 func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
 	fs.BoolVar(&o.AutoPublishAPIs, "auto-publish-apis", o.AutoPublishAPIs, "If true, the APIs imported from physical clusters will be published automatically as CRDs")
 	fs.IntVar(&o.NumThreads, "apiresource-controller-threads", o.NumThreads, "Number of threads to use for the cluster controller.")
+	fs.DurationVar(&o.OrphanRetention, "apiresource-orphan-retention", o.OrphanRetention, "Amount of time a NegotiatedAPIResource and its published CRD are kept after no WorkloadCluster imports the API anymore. 0 deletes them immediately.")
 	return o
 }
 
@@ -42,8 +46,12 @@ func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
 type Options struct {
 	AutoPublishAPIs bool
 	NumThreads      int
+	OrphanRetention time.Duration
 }
 
 func (o *Options) Validate() error {
+	if o.OrphanRetention < 0 {
+		return fmt.Errorf("--apiresource-orphan-retention must be >=0 (%s)", o.OrphanRetention)
+	}
 	return nil
 }
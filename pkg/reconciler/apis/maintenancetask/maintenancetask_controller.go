@@ -0,0 +1,306 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancetask
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-maintenancetask"
+
+	// defaultTimeout is the timeout of an action call without timeoutSeconds.
+	defaultTimeout = 10 * time.Second
+)
+
+// NewController returns a controller running the maintenance actions of bound APIExports
+// periodically for the MaintenanceTasks of the workspaces, by calling the webhooks of the
+// actions.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	maintenanceTaskInformer apisinformers.MaintenanceTaskInformer,
+	apiBindingInformer apisinformers.APIBindingInformer,
+	apiExportInformer apisinformers.APIExportInformer,
+) *controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:                 queue,
+		kcpClusterClient:      kcpClusterClient,
+		maintenanceTaskLister: maintenanceTaskInformer.Lister(),
+		apiBindingLister:      apiBindingInformer.Lister(),
+		apiExportLister:       apiExportInformer.Lister(),
+		now:                   time.Now,
+		callAction:            postRun,
+	}
+	c.getAPIBinding = func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIBinding, error) {
+		return c.apiBindingLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+	}
+	c.getAPIExport = func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIExport, error) {
+		return c.apiExportLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+	}
+
+	maintenanceTaskInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueMaintenanceTask(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueMaintenanceTask(obj) },
+	}))
+	apiBindingInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueAPIBinding(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIBinding(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIBinding(obj) },
+	}))
+	apiExportInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueAPIExport(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIExport(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIExport(obj) },
+	}))
+
+	return c
+}
+
+// controller runs MaintenanceTasks, and records their runs in their status.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient      kcpclient.ClusterInterface
+	maintenanceTaskLister apislisters.MaintenanceTaskLister
+	apiBindingLister      apislisters.APIBindingLister
+	apiExportLister       apislisters.APIExportLister
+
+	now           func() time.Time
+	getAPIBinding func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIBinding, error)
+	getAPIExport  func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIExport, error)
+	callAction    func(ctx context.Context, action apisv1alpha1.MaintenanceAction, run *apisv1alpha1.MaintenanceTaskRun) error
+}
+
+func (c *controller) enqueueMaintenanceTask(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueAPIBinding enqueues the MaintenanceTasks referencing an APIBinding.
+func (c *controller) enqueueAPIBinding(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIBinding, but is %T", obj))
+		return
+	}
+
+	tasks, err := c.maintenanceTaskLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName := logicalcluster.From(apiBinding)
+	for _, task := range tasks {
+		if logicalcluster.From(task) == clusterName && task.Spec.APIBinding == apiBinding.Name {
+			c.enqueueMaintenanceTask(task)
+		}
+	}
+}
+
+// enqueueAPIExport enqueues the APIBindings bound to an APIExport, i.e. their MaintenanceTasks.
+func (c *controller) enqueueAPIExport(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	apiExport, ok := obj.(*apisv1alpha1.APIExport)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj))
+		return
+	}
+
+	apiBindings, err := c.apiBindingLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, apiBinding := range apiBindings {
+		if exportClusterName, exportName, ok := boundAPIExport(apiBinding); ok && exportClusterName == logicalcluster.From(apiExport) && exportName == apiExport.Name {
+			c.enqueueAPIBinding(apiBinding)
+		}
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.maintenanceTaskLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	old := obj
+	obj = obj.DeepCopy()
+
+	requeueAfter := c.reconcile(ctx, obj)
+
+	if !equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		if err := c.patchStatus(ctx, old, obj); err != nil {
+			return err
+		}
+	}
+
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return nil
+}
+
+func (c *controller) patchStatus(ctx context.Context, old, obj *apisv1alpha1.MaintenanceTask) error {
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(apisv1alpha1.MaintenanceTask{
+		Status: old.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for maintenancetask %s|%s: %w", clusterName, obj.Name, err)
+	}
+
+	newData, err := json.Marshal(apisv1alpha1.MaintenanceTask{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for maintenancetask %s|%s: %w", clusterName, obj.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for maintenancetask %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).ApisV1alpha1().MaintenanceTasks().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// postRun POSTs the run to the action, and fails unless it responds with a 2xx status code.
+func postRun(ctx context.Context, action apisv1alpha1.MaintenanceAction, run *apisv1alpha1.MaintenanceTaskRun) error {
+	body, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	timeout := defaultTimeout
+	if action.TimeoutSeconds > 0 {
+		timeout = time.Duration(action.TimeoutSeconds) * time.Second
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(action.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(action.CABundle) {
+			return fmt.Errorf("invalid caBundle of action %s", action.Name)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, action.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("action %s responded with %s", action.Name, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancetask
+
+import (
+	"context"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// minPeriod protects the providers from tasks calling their actions too often.
+const minPeriod = time.Minute
+
+// reconcile runs the action of the task if it is due, and returns when the task
+// must be looked at again. Failures of the action are recorded in the status, and
+// retried with the next run only.
+func (c *controller) reconcile(ctx context.Context, task *apisv1alpha1.MaintenanceTask) time.Duration {
+	if task.DeletionTimestamp != nil {
+		return 0
+	}
+
+	clusterName := logicalcluster.From(task)
+	apiBinding, err := c.getAPIBinding(clusterName, task.Spec.APIBinding)
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Failed to get APIBinding %s|%s: %v", clusterName, task.Spec.APIBinding, err)
+		return time.Second
+	}
+	var exportClusterName logicalcluster.LogicalCluster
+	var exportName string
+	bound := false
+	if err == nil {
+		exportClusterName, exportName, bound = boundAPIExport(apiBinding)
+	}
+	if !bound {
+		conditions.MarkFalse(
+			task,
+			apisv1alpha1.MaintenanceActionValid,
+			apisv1alpha1.APIBindingNotBoundReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"APIBinding %s is not bound",
+			task.Spec.APIBinding,
+		)
+		return 0
+	}
+
+	var action *apisv1alpha1.MaintenanceAction
+	apiExport, err := c.getAPIExport(exportClusterName, exportName)
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Failed to get APIExport %s|%s: %v", exportClusterName, exportName, err)
+		return time.Second
+	}
+	if err == nil {
+		for i := range apiExport.Spec.MaintenanceActions {
+			if apiExport.Spec.MaintenanceActions[i].Name == task.Spec.Action {
+				action = &apiExport.Spec.MaintenanceActions[i]
+				break
+			}
+		}
+	}
+	if action == nil {
+		conditions.MarkFalse(
+			task,
+			apisv1alpha1.MaintenanceActionValid,
+			apisv1alpha1.MaintenanceActionNotFoundReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"APIExport %s|%s has no maintenance action %s",
+			exportClusterName, exportName, task.Spec.Action,
+		)
+		return 0
+	}
+
+	period := task.Spec.Period.Duration
+	if period < minPeriod {
+		conditions.MarkFalse(
+			task,
+			apisv1alpha1.MaintenanceActionValid,
+			apisv1alpha1.InvalidPeriodReason,
+			conditionsv1alpha1.ConditionSeverityError,
+			"period %s is shorter than %s",
+			period, minPeriod,
+		)
+		return 0
+	}
+	conditions.MarkTrue(task, apisv1alpha1.MaintenanceActionValid)
+
+	if task.Spec.Suspend {
+		return 0
+	}
+
+	// runs missed while the controller was down are not caught up, only the last one is done
+	last := task.CreationTimestamp.Time
+	if task.Status.LastScheduleTime != nil {
+		last = task.Status.LastScheduleTime.Time
+	}
+	scheduled := last.Add(period)
+	now := c.now()
+	if now.Before(scheduled) {
+		return scheduled.Sub(now)
+	}
+
+	run := &apisv1alpha1.MaintenanceTaskRun{
+		ClusterName:   clusterName.String(),
+		Name:          task.Name,
+		UID:           string(task.UID),
+		APIExport:     exportClusterName.Join(exportName).String(),
+		Action:        action.Name,
+		ScheduledTime: metav1.NewTime(scheduled),
+	}
+	task.Status.LastScheduleTime = &metav1.Time{Time: now}
+	if err := c.callAction(ctx, *action, run); err != nil {
+		klog.V(2).Infof("Maintenance action %s of MaintenanceTask %s|%s failed: %v", action.Name, clusterName, task.Name, err)
+		conditions.MarkFalse(
+			task,
+			apisv1alpha1.MaintenanceTaskSucceeded,
+			apisv1alpha1.MaintenanceActionFailedReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"%v",
+			err,
+		)
+		return period
+	}
+	task.Status.LastSuccessfulTime = &metav1.Time{Time: now}
+	conditions.MarkTrue(task, apisv1alpha1.MaintenanceTaskSucceeded)
+	return period
+}
+
+// boundAPIExport returns the logical cluster and the name of the APIExport an APIBinding is bound to.
+func boundAPIExport(apiBinding *apisv1alpha1.APIBinding) (logicalcluster.LogicalCluster, string, bool) {
+	if apiBinding.Status.BoundAPIExport == nil || apiBinding.Status.BoundAPIExport.Workspace == nil {
+		return logicalcluster.LogicalCluster{}, "", false
+	}
+	parent, hasParent := logicalcluster.From(apiBinding).Parent()
+	if !hasParent {
+		return logicalcluster.LogicalCluster{}, "", false
+	}
+	ref := apiBinding.Status.BoundAPIExport.Workspace
+	return parent.Join(ref.WorkspaceName), ref.ExportName, true
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenancetask
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	created := now.Add(-90 * time.Minute)
+	boundBinding := &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: "root:org:consumer", Name: "widgets"},
+		Status: apisv1alpha1.APIBindingStatus{
+			BoundAPIExport: &apisv1alpha1.ExportReference{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{WorkspaceName: "provider", ExportName: "widgets"},
+			},
+		},
+	}
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: "root:org:provider", Name: "widgets"},
+		Spec: apisv1alpha1.APIExportSpec{
+			MaintenanceActions: []apisv1alpha1.MaintenanceAction{{Name: "cleanup", URL: "https://provider.example.com/cleanup"}},
+		},
+	}
+
+	tests := map[string]struct {
+		action           string
+		period           time.Duration
+		suspend          bool
+		lastSchedule     *time.Time
+		apiBinding       *apisv1alpha1.APIBinding
+		apiExport        *apisv1alpha1.APIExport
+		actionErr        error
+		wantRun          *apisv1alpha1.MaintenanceTaskRun
+		wantValid        corev1.ConditionStatus
+		wantValidReason  string
+		wantSucceeded    corev1.ConditionStatus
+		wantLastSchedule *time.Time
+		wantLastSuccess  *time.Time
+		wantRequeueAfter time.Duration
+	}{
+		"APIBinding not found": {
+			action:          "cleanup",
+			period:          time.Hour,
+			wantValid:       corev1.ConditionFalse,
+			wantValidReason: apisv1alpha1.APIBindingNotBoundReason,
+		},
+		"APIBinding not bound": {
+			action:          "cleanup",
+			period:          time.Hour,
+			apiBinding:      &apisv1alpha1.APIBinding{ObjectMeta: metav1.ObjectMeta{ClusterName: "root:org:consumer", Name: "widgets"}},
+			wantValid:       corev1.ConditionFalse,
+			wantValidReason: apisv1alpha1.APIBindingNotBoundReason,
+		},
+		"APIExport not found": {
+			action:          "cleanup",
+			period:          time.Hour,
+			apiBinding:      boundBinding,
+			wantValid:       corev1.ConditionFalse,
+			wantValidReason: apisv1alpha1.MaintenanceActionNotFoundReason,
+		},
+		"unknown action": {
+			action:          "report",
+			period:          time.Hour,
+			apiBinding:      boundBinding,
+			apiExport:       export,
+			wantValid:       corev1.ConditionFalse,
+			wantValidReason: apisv1alpha1.MaintenanceActionNotFoundReason,
+		},
+		"period too short": {
+			action:          "cleanup",
+			period:          time.Second,
+			apiBinding:      boundBinding,
+			apiExport:       export,
+			wantValid:       corev1.ConditionFalse,
+			wantValidReason: apisv1alpha1.InvalidPeriodReason,
+		},
+		"suspended": {
+			action:     "cleanup",
+			period:     time.Hour,
+			suspend:    true,
+			apiBinding: boundBinding,
+			apiExport:  export,
+			wantValid:  corev1.ConditionTrue,
+		},
+		"first run not due": {
+			action:           "cleanup",
+			period:           2 * time.Hour,
+			apiBinding:       boundBinding,
+			apiExport:        export,
+			wantValid:        corev1.ConditionTrue,
+			wantRequeueAfter: 30 * time.Minute,
+		},
+		"first run": {
+			action:     "cleanup",
+			period:     time.Hour,
+			apiBinding: boundBinding,
+			apiExport:  export,
+			wantRun: &apisv1alpha1.MaintenanceTaskRun{
+				ClusterName:   "root:org:consumer",
+				Name:          "task",
+				UID:           "uid",
+				APIExport:     "root:org:provider:widgets",
+				Action:        "cleanup",
+				ScheduledTime: metav1.NewTime(created.Add(time.Hour)),
+			},
+			wantValid:        corev1.ConditionTrue,
+			wantSucceeded:    corev1.ConditionTrue,
+			wantLastSchedule: &now,
+			wantLastSuccess:  &now,
+			wantRequeueAfter: time.Hour,
+		},
+		"next run not due": {
+			action:           "cleanup",
+			period:           time.Hour,
+			lastSchedule:     timePtr(now.Add(-10 * time.Minute)),
+			apiBinding:       boundBinding,
+			apiExport:        export,
+			wantValid:        corev1.ConditionTrue,
+			wantLastSchedule: timePtr(now.Add(-10 * time.Minute)),
+			wantRequeueAfter: 50 * time.Minute,
+		},
+		"missed runs are run once": {
+			action:       "cleanup",
+			period:       time.Minute,
+			lastSchedule: timePtr(now.Add(-10 * time.Minute)),
+			apiBinding:   boundBinding,
+			apiExport:    export,
+			wantRun: &apisv1alpha1.MaintenanceTaskRun{
+				ClusterName:   "root:org:consumer",
+				Name:          "task",
+				UID:           "uid",
+				APIExport:     "root:org:provider:widgets",
+				Action:        "cleanup",
+				ScheduledTime: metav1.NewTime(now.Add(-9 * time.Minute)),
+			},
+			wantValid:        corev1.ConditionTrue,
+			wantSucceeded:    corev1.ConditionTrue,
+			wantLastSchedule: &now,
+			wantLastSuccess:  &now,
+			wantRequeueAfter: time.Minute,
+		},
+		"failed run": {
+			action:     "cleanup",
+			period:     time.Hour,
+			apiBinding: boundBinding,
+			apiExport:  export,
+			actionErr:  errors.New("action cleanup responded with 500 Internal Server Error"),
+			wantRun: &apisv1alpha1.MaintenanceTaskRun{
+				ClusterName:   "root:org:consumer",
+				Name:          "task",
+				UID:           "uid",
+				APIExport:     "root:org:provider:widgets",
+				Action:        "cleanup",
+				ScheduledTime: metav1.NewTime(created.Add(time.Hour)),
+			},
+			wantValid:        corev1.ConditionTrue,
+			wantSucceeded:    corev1.ConditionFalse,
+			wantLastSchedule: &now,
+			wantRequeueAfter: time.Hour,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotRun *apisv1alpha1.MaintenanceTaskRun
+			c := &controller{
+				now: func() time.Time { return now },
+				getAPIBinding: func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIBinding, error) {
+					if tt.apiBinding == nil || logicalcluster.From(tt.apiBinding) != clusterName || tt.apiBinding.Name != name {
+						return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apibindings"), name)
+					}
+					return tt.apiBinding, nil
+				},
+				getAPIExport: func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIExport, error) {
+					if tt.apiExport == nil || logicalcluster.From(tt.apiExport) != clusterName || tt.apiExport.Name != name {
+						return nil, apierrors.NewNotFound(apisv1alpha1.Resource("apiexports"), name)
+					}
+					return tt.apiExport, nil
+				},
+				callAction: func(ctx context.Context, action apisv1alpha1.MaintenanceAction, run *apisv1alpha1.MaintenanceTaskRun) error {
+					gotRun = run
+					return tt.actionErr
+				},
+			}
+
+			task := &apisv1alpha1.MaintenanceTask{
+				ObjectMeta: metav1.ObjectMeta{
+					ClusterName:       "root:org:consumer",
+					Name:              "task",
+					UID:               "uid",
+					CreationTimestamp: metav1.NewTime(created),
+				},
+				Spec: apisv1alpha1.MaintenanceTaskSpec{
+					APIBinding: "widgets",
+					Action:     tt.action,
+					Period:     metav1.Duration{Duration: tt.period},
+					Suspend:    tt.suspend,
+				},
+			}
+			if tt.lastSchedule != nil {
+				task.Status.LastScheduleTime = &metav1.Time{Time: *tt.lastSchedule}
+			}
+
+			requeueAfter := c.reconcile(context.Background(), task)
+
+			require.Equal(t, tt.wantRequeueAfter, requeueAfter)
+			require.Equal(t, tt.wantRun, gotRun)
+			requireCondition(t, task, apisv1alpha1.MaintenanceActionValid, tt.wantValid, tt.wantValidReason)
+			wantSucceededReason := ""
+			if tt.wantSucceeded == corev1.ConditionFalse {
+				wantSucceededReason = apisv1alpha1.MaintenanceActionFailedReason
+			}
+			requireCondition(t, task, apisv1alpha1.MaintenanceTaskSucceeded, tt.wantSucceeded, wantSucceededReason)
+			requireTime(t, tt.wantLastSchedule, task.Status.LastScheduleTime)
+			requireTime(t, tt.wantLastSuccess, task.Status.LastSuccessfulTime)
+		})
+	}
+}
+
+func requireCondition(t *testing.T, task *apisv1alpha1.MaintenanceTask, conditionType conditionsv1alpha1.ConditionType, status corev1.ConditionStatus, reason string) {
+	t.Helper()
+
+	if status == "" {
+		require.False(t, conditions.Has(task, conditionType), "unexpected condition %s", conditionType)
+		return
+	}
+	c := conditions.Get(task, conditionType)
+	require.NotNil(t, c, "missing condition %s", conditionType)
+	require.Equal(t, status, c.Status, "condition %s", conditionType)
+	require.Equal(t, reason, c.Reason, "condition %s", conditionType)
+}
+
+func requireTime(t *testing.T, want *time.Time, got *metav1.Time) {
+	t.Helper()
+
+	if want == nil {
+		require.Nil(t, got)
+		return
+	}
+	require.NotNil(t, got)
+	require.True(t, want.Equal(got.Time), "expected %s, got %s", want, got.Time)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schemaevolution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName                         = "kcp-apiresourceschema-evolution"
+	indexAPIExportsByWorkspace             = "schemaEvolution-apiExportsByWorkspace"
+	indexAPIResourceSchemasByGroupResource = "schemaEvolution-apiResourceSchemasByGroupResource"
+)
+
+// NewController returns a controller comparing the latest APIResourceSchemas of APIExports with the
+// previous generations of the same resources, and reporting the breaking changes in the
+// APIResourceSchemasCompatible condition of the APIExports.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	apiExportInformer apisinformers.APIExportInformer,
+	apiResourceSchemaInformer apisinformers.APIResourceSchemaInformer,
+) (*controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:                    queue,
+		kcpClusterClient:         kcpClusterClient,
+		apiExportsLister:         apiExportInformer.Lister(),
+		apiExportsIndexer:        apiExportInformer.Informer().GetIndexer(),
+		apiResourceSchemaLister:  apiResourceSchemaInformer.Lister(),
+		apiResourceSchemaIndexer: apiResourceSchemaInformer.Informer().GetIndexer(),
+	}
+	c.getAPIResourceSchema = func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIResourceSchema, error) {
+		return c.apiResourceSchemaLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+	}
+	c.listAPIResourceSchemas = c.listAPIResourceSchemasByGroupResource
+
+	if err := c.apiExportsIndexer.AddIndexers(cache.Indexers{
+		indexAPIExportsByWorkspace: indexAPIExportByWorkspace,
+	}); err != nil {
+		return nil, fmt.Errorf("error adding APIExport indexes: %w", err)
+	}
+	if err := c.apiResourceSchemaIndexer.AddIndexers(cache.Indexers{
+		indexAPIResourceSchemasByGroupResource: indexAPIResourceSchemaByGroupResource,
+	}); err != nil {
+		return nil, fmt.Errorf("error adding APIResourceSchema indexes: %w", err)
+	}
+
+	apiExportInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueAPIExport(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueAPIExport(obj) },
+	}))
+
+	// a new or deleted generation can change the previous generation of the latest schemas of every export of the workspace
+	apiResourceSchemaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueAPIResourceSchema(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueAPIResourceSchema(obj) },
+	})
+
+	return c, nil
+}
+
+// controller sets the APIResourceSchemasCompatible condition of APIExports.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.ClusterInterface
+
+	apiExportsLister         apislisters.APIExportLister
+	apiExportsIndexer        cache.Indexer
+	apiResourceSchemaLister  apislisters.APIResourceSchemaLister
+	apiResourceSchemaIndexer cache.Indexer
+
+	getAPIResourceSchema   func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIResourceSchema, error)
+	listAPIResourceSchemas func(clusterName logicalcluster.LogicalCluster, group, resource string) ([]*apisv1alpha1.APIResourceSchema, error)
+}
+
+func (c *controller) enqueueAPIExport(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueAPIResourceSchema enqueues the APIExports of the workspace of an APIResourceSchema.
+func (c *controller) enqueueAPIResourceSchema(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	schema, ok := obj.(*apisv1alpha1.APIResourceSchema)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be an APIResourceSchema, but is %T", obj))
+		return
+	}
+
+	apiExports, err := c.apiExportsIndexer.ByIndex(indexAPIExportsByWorkspace, logicalcluster.From(schema).String())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, apiExport := range apiExports {
+		c.enqueueAPIExport(apiExport)
+	}
+}
+
+func (c *controller) listAPIResourceSchemasByGroupResource(clusterName logicalcluster.LogicalCluster, group, resource string) ([]*apisv1alpha1.APIResourceSchema, error) {
+	objs, err := c.apiResourceSchemaIndexer.ByIndex(indexAPIResourceSchemasByGroupResource, groupResourceKey(clusterName, group, resource))
+	if err != nil {
+		return nil, err
+	}
+	schemas := make([]*apisv1alpha1.APIResourceSchema, 0, len(objs))
+	for _, obj := range objs {
+		schemas = append(schemas, obj.(*apisv1alpha1.APIResourceSchema))
+	}
+	return schemas, nil
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.apiExportsLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	old := obj
+	obj = obj.DeepCopy()
+
+	if err := c.reconcile(ctx, obj); err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(apisv1alpha1.APIExport{
+		Status: old.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for apiexport %s|%s: %w", clusterName, obj.Name, err)
+	}
+
+	newData, err := json.Marshal(apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             old.UID,
+			ResourceVersion: old.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for apiexport %s|%s: %w", clusterName, obj.Name, err)
+	}
+
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for apiexport %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).ApisV1alpha1().APIExports().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schemaevolution
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/client-go/tools/clusters"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// indexAPIExportByWorkspace is an index function that maps an APIExport to its logical cluster.
+func indexAPIExportByWorkspace(obj interface{}) ([]string, error) {
+	apiExport, ok := obj.(*apisv1alpha1.APIExport)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj)
+	}
+
+	return []string{logicalcluster.From(apiExport).String()}, nil
+}
+
+// indexAPIResourceSchemaByGroupResource is an index function that maps an APIResourceSchema to its logical
+// cluster, spec.group and spec.names.plural.
+func indexAPIResourceSchemaByGroupResource(obj interface{}) ([]string, error) {
+	schema, ok := obj.(*apisv1alpha1.APIResourceSchema)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be an APIResourceSchema, but is %T", obj)
+	}
+
+	return []string{groupResourceKey(logicalcluster.From(schema), schema.Spec.Group, schema.Spec.Names.Plural)}, nil
+}
+
+func groupResourceKey(clusterName logicalcluster.LogicalCluster, group, resource string) string {
+	return clusters.ToClusterAwareKey(clusterName, resource+"."+group)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schemaevolution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"go.uber.org/multierr"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/schemacompat"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// reconcile compares every latest APIResourceSchema of the export with the previous generation of the
+// same resource in the workspace, and sets the APIResourceSchemasCompatible condition accordingly.
+func (c *controller) reconcile(ctx context.Context, apiExport *apisv1alpha1.APIExport) error {
+	clusterName := logicalcluster.From(apiExport)
+
+	var breakingChanges []string
+	for _, schemaName := range apiExport.Spec.LatestResourceSchemas {
+		schema, err := c.getAPIResourceSchema(clusterName, schemaName)
+		if errors.IsNotFound(err) {
+			continue // reported by the APIBindings of the export
+		} else if err != nil {
+			return err
+		}
+
+		previous, err := c.previousGeneration(schema)
+		if err != nil {
+			return err
+		}
+		if previous == nil {
+			continue
+		}
+
+		compatErr := schemacompat.EnsureAPIResourceSchemaCompatibility(field.NewPath("spec"), &previous.Spec, &schema.Spec)
+		for _, err := range multierr.Errors(compatErr) {
+			breakingChanges = append(breakingChanges, fmt.Sprintf("%s (previous %s): %v", schema.Name, previous.Name, err))
+		}
+	}
+
+	if len(breakingChanges) > 0 {
+		conditions.MarkFalse(
+			apiExport,
+			apisv1alpha1.APIResourceSchemasCompatible,
+			apisv1alpha1.BreakingChangesReason,
+			conditionsv1alpha1.ConditionSeverityWarning,
+			"Breaking changes: %s",
+			strings.Join(breakingChanges, "; "),
+		)
+		return nil
+	}
+
+	conditions.MarkTrue(apiExport, apisv1alpha1.APIResourceSchemasCompatible)
+	return nil
+}
+
+// previousGeneration returns the newest APIResourceSchema of the same group and resource in the workspace
+// created before the given one, or nil if there is none.
+func (c *controller) previousGeneration(schema *apisv1alpha1.APIResourceSchema) (*apisv1alpha1.APIResourceSchema, error) {
+	candidates, err := c.listAPIResourceSchemas(logicalcluster.From(schema), schema.Spec.Group, schema.Spec.Names.Plural)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *apisv1alpha1.APIResourceSchema
+	for _, candidate := range candidates {
+		if candidate.Name == schema.Name || !olderThan(candidate, schema) {
+			continue
+		}
+		if previous == nil || olderThan(previous, candidate) {
+			previous = candidate
+		}
+	}
+	return previous, nil
+}
+
+// olderThan orders APIResourceSchemas by creation, and by name when created in the same second.
+func olderThan(a, b *apisv1alpha1.APIResourceSchema) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
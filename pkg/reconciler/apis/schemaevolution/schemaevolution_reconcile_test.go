@@ -0,0 +1,150 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schemaevolution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func newSchema(name string, created time.Time, schema string) *apisv1alpha1.APIResourceSchema {
+	return &apisv1alpha1.APIResourceSchema{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			ClusterName:       "root:org:ws",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Group: "example.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Plural:   "widgets",
+				Singular: "widget",
+				Kind:     "Widget",
+				ListKind: "WidgetList",
+			},
+			Scope: apiextensionsv1.NamespaceScoped,
+			Versions: []apisv1alpha1.APIResourceVersion{{
+				Name:    "v1",
+				Served:  true,
+				Storage: true,
+				Schema:  runtime.RawExtension{Raw: []byte(schema)},
+			}},
+		},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	t0 := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	v1 := newSchema("v1.widgets.example.com", t0, `{"type":"object","properties":{"spec":{"type":"object","properties":{"size":{"type":"integer"},"color":{"type":"string"}}}}}`)
+	v2Compatible := newSchema("v2.widgets.example.com", t0.Add(time.Hour), `{"type":"object","properties":{"spec":{"type":"object","properties":{"size":{"type":"integer"},"color":{"type":"string"},"shape":{"type":"string"}}}}}`)
+	v2Breaking := newSchema("v2.widgets.example.com", t0.Add(time.Hour), `{"type":"object","properties":{"spec":{"type":"object","properties":{"size":{"type":"string"}}}}}`)
+	v0Breaking := newSchema("v0.widgets.example.com", t0.Add(-time.Hour), `{"type":"object","properties":{"spec":{"type":"object","properties":{"size":{"type":"string"}}}}}`)
+
+	tests := map[string]struct {
+		latest  string
+		schemas []*apisv1alpha1.APIResourceSchema
+
+		wantStatus   metav1.ConditionStatus
+		wantMessages []string
+	}{
+		"missing schema": {
+			latest:     "v1.widgets.example.com",
+			wantStatus: metav1.ConditionTrue,
+		},
+		"first generation": {
+			latest:     "v1.widgets.example.com",
+			schemas:    []*apisv1alpha1.APIResourceSchema{v1},
+			wantStatus: metav1.ConditionTrue,
+		},
+		"compatible generation": {
+			latest:     "v2.widgets.example.com",
+			schemas:    []*apisv1alpha1.APIResourceSchema{v1, v2Compatible},
+			wantStatus: metav1.ConditionTrue,
+		},
+		"breaking generation": {
+			latest:     "v2.widgets.example.com",
+			schemas:    []*apisv1alpha1.APIResourceSchema{v1, v2Breaking},
+			wantStatus: metav1.ConditionFalse,
+			wantMessages: []string{
+				"v2.widgets.example.com (previous v1.widgets.example.com)",
+				"spec.versions[v1].schema.properties[spec].properties[size].type",
+				"properties have been removed in an incompatible way",
+			},
+		},
+		"compared with the newest older generation only": {
+			latest:     "v2.widgets.example.com",
+			schemas:    []*apisv1alpha1.APIResourceSchema{v0Breaking, v1, v2Compatible},
+			wantStatus: metav1.ConditionTrue,
+		},
+		"newer generations are ignored": {
+			latest:     "v1.widgets.example.com",
+			schemas:    []*apisv1alpha1.APIResourceSchema{v1, v2Breaking},
+			wantStatus: metav1.ConditionTrue,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &controller{
+				getAPIResourceSchema: func(clusterName logicalcluster.LogicalCluster, name string) (*apisv1alpha1.APIResourceSchema, error) {
+					for _, schema := range tc.schemas {
+						if schema.Name == name {
+							return schema, nil
+						}
+					}
+					return nil, errors.NewNotFound(apisv1alpha1.Resource("apiresourceschemas"), name)
+				},
+				listAPIResourceSchemas: func(clusterName logicalcluster.LogicalCluster, group, resource string) ([]*apisv1alpha1.APIResourceSchema, error) {
+					require.Equal(t, "root:org:ws", clusterName.String())
+					require.Equal(t, "example.com", group)
+					require.Equal(t, "widgets", resource)
+					return tc.schemas, nil
+				},
+			}
+			apiExport := &apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{Name: "widgets", ClusterName: "root:org:ws"},
+				Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{tc.latest}},
+			}
+
+			err := c.reconcile(context.Background(), apiExport)
+			require.NoError(t, err)
+
+			condition := conditions.Get(apiExport, apisv1alpha1.APIResourceSchemasCompatible)
+			require.NotNil(t, condition)
+			require.Equal(t, string(tc.wantStatus), string(condition.Status))
+			if tc.wantStatus == metav1.ConditionTrue {
+				return
+			}
+			require.Equal(t, apisv1alpha1.BreakingChangesReason, condition.Reason)
+			require.Equal(t, conditionsv1alpha1.ConditionSeverityWarning, condition.Severity)
+			for _, message := range tc.wantMessages {
+				require.Contains(t, condition.Message, message)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Committer persists the changes a Reconciler made to an object.
+type Committer interface {
+	Commit(ctx context.Context, old, obj runtime.Object) error
+}
+
+// CommitterFunc is a function implementing Committer.
+type CommitterFunc func(ctx context.Context, old, obj runtime.Object) error
+
+// Commit calls f.
+func (f CommitterFunc) Commit(ctx context.Context, old, obj runtime.Object) error {
+	return f(ctx, old, obj)
+}
+
+// PatchFunc applies a merge patch to the status subresource of the object.
+type PatchFunc func(ctx context.Context, obj runtime.Object, patch []byte) error
+
+// NewStatusCommitter returns a Committer which patches the status of the object if it changed. The
+// uid and resource version of the old object are preconditions of the patch, such that a reconcile
+// based on a stale object fails with a conflict and is retried.
+func NewStatusCommitter(patch PatchFunc) Committer {
+	return CommitterFunc(func(ctx context.Context, old, obj runtime.Object) error {
+		oldStatus, err := status(old)
+		if err != nil {
+			return err
+		}
+		newStatus, err := status(obj)
+		if err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(oldStatus, newStatus) {
+			return nil
+		}
+
+		patchBytes, err := statusPatch(old, oldStatus, newStatus)
+		if err != nil {
+			return err
+		}
+		LoggerFrom(ctx).V(2).Info("patching status", "patch", string(patchBytes))
+		return patch(ctx, obj, patchBytes)
+	})
+}
+
+func status(obj runtime.Object) (interface{}, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %T: %w", obj, err)
+	}
+	return u["status"], nil
+}
+
+func statusPatch(old runtime.Object, oldStatus, newStatus interface{}) ([]byte, error) {
+	accessor, err := meta.Accessor(old)
+	if err != nil {
+		return nil, err
+	}
+	oldData, err := json.Marshal(map[string]interface{}{
+		"status": oldStatus,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal old data for %s: %w", accessor.GetName(), err)
+	}
+	newData, err := json.Marshal(map[string]interface{}{
+		// to ensure they appear in the patch as preconditions
+		"metadata": map[string]interface{}{
+			"uid":             accessor.GetUID(),
+			"resourceVersion": accessor.GetResourceVersion(),
+		},
+		"status": newStatus,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal new data for %s: %w", accessor.GetName(), err)
+	}
+	patch, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create patch for %s: %w", accessor.GetName(), err)
+	}
+	return patch, nil
+}
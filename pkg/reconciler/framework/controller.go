@@ -0,0 +1,275 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package framework contains the scaffolding shared by the controllers reconciling a single kind of
+// object: the work queue, the workers, a logger per object, the commit of the changes made by the
+// reconciler and the reconcile metrics.
+//
+// A key failing to reconcile is retried with backoff up to a retry budget. It is quarantined
+// afterwards, and only reconciled again when its object changes, such that a corrupt object cannot
+// keep a worker busy forever. The quarantined keys are listed by QuarantineHandler, the state of all
+// controllers is dumped by DumpHandler.
+//
+// On shutdown, a Controller stops taking keys off its queue and lets the in-flight reconciles complete
+// for up to ShutdownTimeout, such that patches are not interrupted midway.
+//
+// A controller only provides the reconcile logic, converting the cached object to its type:
+//
+//	c.controller = framework.New("kcp-workspaceshard", informer.Informer().GetIndexer(),
+//		framework.ReconcilerFunc(func(ctx context.Context, obj runtime.Object) (time.Duration, error) {
+//			return c.reconcile(ctx, obj.(*tenancyv1alpha1.ClusterWorkspaceShard))
+//		}),
+//		framework.NewStatusCommitter(patchStatus),
+//	)
+//	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+//		AddFunc:    c.controller.Enqueue,
+//		UpdateFunc: func(_, obj interface{}) { c.controller.Enqueue(obj) },
+//	})
+package framework
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"k8s.io/klog/v2/klogr"
+)
+
+// ShutdownTimeout is how long the in-flight reconciles of a Controller may take to complete on
+// shutdown, before their context is cancelled.
+const ShutdownTimeout = 30 * time.Second
+
+// Reconciler brings the world in line with an object.
+type Reconciler interface {
+	// Reconcile is called with a deep copy of the cached object, whose changes are committed
+	// afterwards. If requeueAfter is positive and no error is returned, the object is reconciled
+	// again after that duration, even without any change to it.
+	Reconcile(ctx context.Context, obj runtime.Object) (requeueAfter time.Duration, err error)
+}
+
+// ReconcilerFunc is a function implementing Reconciler.
+type ReconcilerFunc func(ctx context.Context, obj runtime.Object) (time.Duration, error)
+
+// Reconcile calls f.
+func (f ReconcilerFunc) Reconcile(ctx context.Context, obj runtime.Object) (time.Duration, error) {
+	return f(ctx, obj)
+}
+
+// Controller processes the keys of the objects of an informer with a Reconciler.
+type Controller struct {
+	name       string
+	queue      *trackingQueue
+	indexer    cache.Indexer
+	reconciler Reconciler
+	committer  Committer
+
+	maxRetries int
+	quarantine *quarantine
+
+	shutdownTimeout time.Duration
+}
+
+// New returns a controller reconciling the objects in the indexer, and committing the changes with
+// the committer, if not nil. The objects have to be added to the queue with Enqueue, typically
+// from the event handlers of the informer of the indexer.
+func New(name string, indexer cache.Indexer, reconciler Reconciler, committer Committer) *Controller {
+	registerMetrics()
+
+	c := &Controller{
+		name:       name,
+		queue:      newTrackingQueue(workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name)),
+		indexer:    indexer,
+		reconciler: reconciler,
+		committer:  committer,
+
+		maxRetries: DefaultMaxRetries,
+		quarantine: newQuarantine(name),
+
+		shutdownTimeout: ShutdownTimeout,
+	}
+	register(c)
+	return c
+}
+
+// SetMaxRetries sets how often a failing key is retried before it is quarantined. Zero or less
+// retries a key forever.
+func (c *Controller) SetMaxRetries(maxRetries int) {
+	c.maxRetries = maxRetries
+}
+
+// Enqueue adds the key of the object to the queue. A quarantined key is released, as its object
+// changed.
+func (c *Controller) Enqueue(obj interface{}) {
+	c.EnqueueAfter(obj, 0)
+}
+
+// EnqueueAfter adds the key of the object to the queue after the given duration. A quarantined key
+// is released, as its object changed.
+func (c *Controller) EnqueueAfter(obj interface{}, duration time.Duration) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	klog.V(4).Infof("%s: queueing %q", c.name, key)
+	c.quarantine.remove(key)
+	c.queue.AddAfter(key, duration)
+}
+
+// Start runs numThreads workers until the context is done. It then shuts the controller down,
+// and returns when the in-flight reconciles have completed, or were cancelled after the
+// shutdown timeout.
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer utilruntime.HandleCrash()
+
+	klog.Infof("Starting %s controller", c.name)
+	defer klog.Infof("Shut down %s controller", c.name)
+
+	// The workers outlive ctx, to complete the in-flight reconciles on shutdown.
+	workerCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var workers sync.WaitGroup
+	for i := 0; i < numThreads; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wait.UntilWithContext(workerCtx, c.startWorker, time.Second)
+		}()
+	}
+
+	<-ctx.Done()
+	c.shutDown(cancel, &workers)
+}
+
+// shutDown stops the queue from accepting keys, waits for the in-flight reconciles for up to the
+// shutdown timeout, and cancels them afterwards. The keys still queued are dropped, and reconciled
+// by the next instance of the controller.
+func (c *Controller) shutDown(cancel context.CancelFunc, workers *sync.WaitGroup) {
+	dropped := c.queue.Len()
+	klog.Infof("Shutting down %s controller, dropping %d queued keys", c.name, dropped)
+	shutdownDroppedKeys.WithLabelValues(c.name).Set(float64(dropped))
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		c.queue.ShutDownWithDrain()
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(c.shutdownTimeout):
+		klog.Warningf("%s: in-flight reconciles did not complete within %s, cancelling them", c.name, c.shutdownTimeout)
+		c.queue.ShutDown()
+	}
+	cancel()
+	workers.Wait()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	// Keys taken off the queue after the shutdown started are dropped, only in-flight reconciles
+	// are completed.
+	if c.queue.ShuttingDown() {
+		return false
+	}
+
+	logger := klogr.New().WithValues("controller", c.name, "key", key)
+	ctx = logr.NewContext(ctx, logger)
+
+	start := time.Now()
+	requeueAfter, err := c.process(ctx, key)
+	observeReconcile(c.name, start, err)
+	if err != nil {
+		if retries := c.queue.NumRequeues(key); c.maxRetries > 0 && retries >= c.maxRetries {
+			utilruntime.HandleError(fmt.Errorf("%s: failed to sync %q %d times, quarantining it, err: %w", c.name, key, retries+1, err))
+			c.queue.Forget(key)
+			c.quarantine.add(key, err, retries)
+			return true
+		}
+		utilruntime.HandleError(fmt.Errorf("%s: failed to sync %q, err: %w", c.name, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	c.quarantine.remove(key)
+	if requeueAfter > 0 {
+		logger.V(4).Info("requeueing", "after", requeueAfter)
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) (time.Duration, error) {
+	obj, exists, err := c.indexer.GetByKey(key)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		LoggerFrom(ctx).V(2).Info("object was deleted")
+		return 0, nil
+	}
+	previous, ok := obj.(runtime.Object)
+	if !ok {
+		return 0, fmt.Errorf("unexpected object type %T", obj)
+	}
+	current := previous.DeepCopyObject()
+
+	requeueAfter, err := c.reconciler.Reconcile(ctx, current)
+	if err != nil {
+		return 0, err
+	}
+
+	if c.committer != nil {
+		if err := c.committer.Commit(ctx, previous, current); err != nil {
+			return 0, err
+		}
+	}
+
+	return requeueAfter, nil
+}
+
+// LoggerFrom returns the logger of the object being reconciled, carrying the name of the controller
+// and the key of the object.
+func LoggerFrom(ctx context.Context) logr.Logger {
+	if logger, err := logr.FromContext(ctx); err == nil {
+		return logger
+	}
+	return klogr.New()
+}
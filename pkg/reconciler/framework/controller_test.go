@@ -0,0 +1,262 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestProcessNextWorkItem(t *testing.T) {
+	tests := map[string]struct {
+		phase        corev1.PodPhase
+		requeueAfter time.Duration
+		reconcileErr error
+		wantPatch    string
+		wantRequeue  bool
+	}{
+		"unchanged status is not committed": {
+			phase: corev1.PodPending,
+		},
+		"changed status is patched with preconditions": {
+			phase:     corev1.PodRunning,
+			wantPatch: `{"metadata":{"resourceVersion":"42","uid":"uid-1"},"status":{"phase":"Running"}}`,
+		},
+		"requeue after a duration": {
+			phase:        corev1.PodPending,
+			requeueAfter: 10 * time.Millisecond,
+			wantRequeue:  true,
+		},
+		"failed reconcile is retried and not committed": {
+			phase:        corev1.PodRunning,
+			reconcileErr: errors.New("boom"),
+			wantRequeue:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			cached := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod", UID: "uid-1", ResourceVersion: "42"},
+				Status:     corev1.PodStatus{Phase: corev1.PodPending},
+			}
+			require.NoError(t, indexer.Add(cached))
+
+			var patch string
+			c := New("test", indexer,
+				ReconcilerFunc(func(ctx context.Context, obj runtime.Object) (time.Duration, error) {
+					obj.(*corev1.Pod).Status.Phase = tc.phase
+					return tc.requeueAfter, tc.reconcileErr
+				}),
+				NewStatusCommitter(func(ctx context.Context, obj runtime.Object, p []byte) error {
+					patch = string(p)
+					return nil
+				}),
+			)
+			defer c.queue.ShutDown()
+
+			c.Enqueue(cached)
+			require.True(t, c.processNextWorkItem(context.Background()))
+
+			require.Equal(t, tc.wantPatch, patch)
+			require.Equal(t, corev1.PodPending, cached.Status.Phase, "the cached object must not be mutated")
+			if tc.wantRequeue {
+				require.Eventually(t, func() bool { return c.queue.Len() == 1 }, time.Second, time.Millisecond)
+			} else {
+				require.Equal(t, 0, c.queue.Len())
+			}
+		})
+	}
+}
+
+func TestProcessDeleted(t *testing.T) {
+	reconciled := false
+	c := New("test", cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		ReconcilerFunc(func(ctx context.Context, obj runtime.Object) (time.Duration, error) {
+			reconciled = true
+			return 0, nil
+		}),
+		nil,
+	)
+	defer c.queue.ShutDown()
+
+	c.Enqueue(cache.DeletedFinalStateUnknown{Key: "default/pod"})
+	require.True(t, c.processNextWorkItem(context.Background()))
+	require.False(t, reconciled)
+}
+
+func TestShutdown(t *testing.T) {
+	tests := map[string]struct {
+		reconcileDuration time.Duration
+		wantCancelled     bool
+	}{
+		"in-flight reconcile completes": {
+			reconcileDuration: 50 * time.Millisecond,
+		},
+		"slow reconcile is cancelled after the timeout": {
+			reconcileDuration: time.Hour,
+			wantCancelled:     true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, name := range []string{"a", "b", "c"} {
+				require.NoError(t, indexer.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}))
+			}
+
+			started := make(chan struct{}, 3)
+			var reconciled []string
+			var cancelled bool
+			c := New("test", indexer,
+				ReconcilerFunc(func(ctx context.Context, obj runtime.Object) (time.Duration, error) {
+					started <- struct{}{}
+					select {
+					case <-time.After(tc.reconcileDuration):
+						reconciled = append(reconciled, obj.(*corev1.Pod).Name)
+					case <-ctx.Done():
+						cancelled = true
+					}
+					return 0, nil
+				}),
+				nil,
+			)
+			c.shutdownTimeout = 200 * time.Millisecond
+			c.Enqueue(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"}})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				c.Start(ctx, 1)
+			}()
+
+			<-started
+			c.Enqueue(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b"}})
+			cancel()
+			// keys added after the shutdown started are ignored
+			c.Enqueue(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "c"}})
+
+			select {
+			case <-stopped:
+			case <-time.After(10 * time.Second):
+				t.Fatal("controller did not shut down")
+			}
+
+			require.Equal(t, tc.wantCancelled, cancelled)
+			if tc.wantCancelled {
+				require.Empty(t, reconciled)
+			} else {
+				require.Equal(t, []string{"a"}, reconciled, "only the in-flight key should be reconciled")
+			}
+			require.Len(t, started, 0, "no reconcile should start after the shutdown")
+		})
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "corrupt"}}
+	require.NoError(t, indexer.Add(pod))
+
+	reconciles := 0
+	c := New("test-quarantine", indexer,
+		ReconcilerFunc(func(ctx context.Context, obj runtime.Object) (time.Duration, error) {
+			reconciles++
+			return 0, errors.New("corrupt object")
+		}),
+		nil,
+	)
+	c.SetMaxRetries(2)
+	defer c.queue.ShutDown()
+
+	c.Enqueue(pod)
+	for i := 0; i < 3; i++ {
+		require.True(t, c.processNextWorkItem(context.Background()))
+	}
+	require.Equal(t, 3, reconciles)
+
+	// the key is not retried anymore
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, 0, c.queue.Len())
+	quarantined := c.quarantine.list()
+	require.Len(t, quarantined, 1)
+	require.Equal(t, "default/corrupt", quarantined[0].Key)
+	require.Equal(t, "corrupt object", quarantined[0].Error)
+	require.Equal(t, 2, quarantined[0].Retries)
+
+	rec := httptest.NewRecorder()
+	QuarantineHandler()(rec, httptest.NewRequest(http.MethodGet, "/debug/controllers/quarantine", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"test-quarantine":[{"key":"default/corrupt","error":"corrupt object","retries":2`)
+
+	// a change of the object releases the key with a fresh retry budget
+	c.Enqueue(pod)
+	require.Empty(t, c.quarantine.list())
+	require.Equal(t, 0, c.queue.NumRequeues("default/corrupt"))
+	require.True(t, c.processNextWorkItem(context.Background()))
+	require.Equal(t, 4, reconciles)
+}
+
+func TestDump(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, name := range []string{"a", "b"} {
+		require.NoError(t, indexer.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}))
+	}
+	c := New("test-dump", indexer,
+		ReconcilerFunc(func(ctx context.Context, obj runtime.Object) (time.Duration, error) {
+			return 0, errors.New("failed")
+		}),
+		nil,
+	)
+	defer c.queue.ShutDown()
+
+	c.Enqueue(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "a"}})
+	require.True(t, c.processNextWorkItem(context.Background()))
+	c.Enqueue(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "b"}})
+
+	dump := c.Dump()
+	require.Equal(t, "test-dump", dump.Name)
+	require.Equal(t, 2, dump.CacheSize)
+	require.Len(t, dump.Queue, 2)
+	require.Equal(t, "default/a", dump.Queue[0].Key)
+	require.Equal(t, 1, dump.Queue[0].Retries)
+	require.Equal(t, "default/b", dump.Queue[1].Key)
+	require.Equal(t, 0, dump.Queue[1].Retries)
+
+	// b is processed while a waits for its backoff, and is retried as well
+	require.True(t, c.processNextWorkItem(context.Background()))
+	dump = c.Dump()
+	require.Len(t, dump.Queue, 2)
+	require.Equal(t, 1, dump.Queue[1].Retries)
+
+	rec := httptest.NewRecorder()
+	DumpHandler()(rec, httptest.NewRequest(http.MethodGet, "/debug/controllers", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `{"name":"test-dump","cacheSize":2,"queue":[{"key":"default/`)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// QueuedKey is a key waiting in the queue of a controller.
+type QueuedKey struct {
+	Key     string    `json:"key"`
+	Retries int       `json:"retries"`
+	Since   time.Time `json:"since"`
+}
+
+// ControllerDump is the state of a controller, for debugging.
+type ControllerDump struct {
+	Name string `json:"name"`
+	// CacheSize is the number of objects in the informer cache of the controller.
+	CacheSize   int              `json:"cacheSize"`
+	Queue       []QueuedKey      `json:"queue"`
+	Quarantined []QuarantinedKey `json:"quarantined"`
+}
+
+// Dump returns the state of the controller.
+func (c *Controller) Dump() ControllerDump {
+	return ControllerDump{
+		Name:        c.name,
+		CacheSize:   len(c.indexer.ListKeys()),
+		Queue:       c.queue.queued(),
+		Quarantined: c.quarantine.list(),
+	}
+}
+
+var (
+	controllersLock sync.Mutex
+	// controllers holds all controllers by name, for the debug handlers.
+	controllers = map[string]*Controller{}
+)
+
+func register(c *Controller) {
+	controllersLock.Lock()
+	defer controllersLock.Unlock()
+	controllers[c.name] = c
+}
+
+func listControllers() []*Controller {
+	controllersLock.Lock()
+	defer controllersLock.Unlock()
+	list := make([]*Controller, 0, len(controllers))
+	for _, c := range controllers {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list
+}
+
+// DumpHandler returns a handler dumping the state of all controllers: their queued keys with their
+// retries, their quarantined keys and the size of their informer cache.
+func DumpHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		dumps := []ControllerDump{}
+		for _, c := range listControllers() {
+			dumps = append(dumps, c.Dump())
+		}
+		writeJSON(w, dumps)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bs) //nolint:errcheck
+}
+
+// trackingQueue records the keys waiting in a rate limiting queue, and since when.
+type trackingQueue struct {
+	workqueue.RateLimitingInterface
+
+	lock  sync.Mutex
+	since map[interface{}]time.Time
+}
+
+func newTrackingQueue(queue workqueue.RateLimitingInterface) *trackingQueue {
+	return &trackingQueue{
+		RateLimitingInterface: queue,
+		since:                 map[interface{}]time.Time{},
+	}
+}
+
+func (q *trackingQueue) Add(item interface{}) {
+	q.track(item)
+	q.RateLimitingInterface.Add(item)
+}
+
+func (q *trackingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.track(item)
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
+
+func (q *trackingQueue) AddRateLimited(item interface{}) {
+	q.track(item)
+	q.RateLimitingInterface.AddRateLimited(item)
+}
+
+func (q *trackingQueue) Get() (interface{}, bool) {
+	item, shutdown := q.RateLimitingInterface.Get()
+	if !shutdown {
+		q.lock.Lock()
+		delete(q.since, item)
+		q.lock.Unlock()
+	}
+	return item, shutdown
+}
+
+// track records the item as waiting. An item which is already waiting keeps the time it started waiting.
+func (q *trackingQueue) track(item interface{}) {
+	if q.ShuttingDown() {
+		return
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if _, found := q.since[item]; !found {
+		q.since[item] = time.Now()
+	}
+}
+
+// queued returns the keys waiting in the queue, the longest waiting first.
+func (q *trackingQueue) queued() []QueuedKey {
+	q.lock.Lock()
+	keys := make([]QueuedKey, 0, len(q.since))
+	for item, since := range q.since {
+		if key, ok := item.(string); ok {
+			keys = append(keys, QueuedKey{Key: key, Since: since})
+		}
+	}
+	q.lock.Unlock()
+
+	for i := range keys {
+		keys[i].Retries = q.NumRequeues(keys[i].Key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].Since.Equal(keys[j].Since) {
+			return keys[i].Since.Before(keys[j].Since)
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	return keys
+}
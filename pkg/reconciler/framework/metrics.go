@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var (
+	// reconcileTotal counts the reconciles, by controller and result.
+	reconcileTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kcp",
+			Subsystem:      "controller",
+			Name:           "reconcile_total",
+			Help:           "Number of reconciles, by controller and result (success or error).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"controller", "result"},
+	)
+
+	// reconcileDuration observes the duration of the reconciles, including the commit.
+	reconcileDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      "kcp",
+			Subsystem:      "controller",
+			Name:           "reconcile_duration_seconds",
+			Help:           "Duration of the reconciles, by controller.",
+			Buckets:        []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"controller"},
+	)
+
+	// shutdownDroppedKeys is the number of keys still queued when a controller shut down.
+	shutdownDroppedKeys = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "kcp",
+			Subsystem:      "controller",
+			Name:           "shutdown_dropped_keys",
+			Help:           "Number of keys still queued when the controller shut down, by controller.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"controller"},
+	)
+
+	// quarantinedKeys is the number of keys which exhausted their retry budget.
+	quarantinedKeys = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "kcp",
+			Subsystem:      "controller",
+			Name:           "quarantined_keys",
+			Help:           "Number of keys not retried anymore after failing to reconcile too often, by controller.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"controller"},
+	)
+
+	registerMetricsOnce sync.Once
+)
+
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(reconcileTotal)
+		legacyregistry.MustRegister(reconcileDuration)
+		legacyregistry.MustRegister(shutdownDroppedKeys)
+		legacyregistry.MustRegister(quarantinedKeys)
+	})
+}
+
+func observeReconcile(controller string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	reconcileTotal.WithLabelValues(controller, result).Inc()
+	reconcileDuration.WithLabelValues(controller).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxRetries is how often a key is retried before it is quarantined. With the default
+// rate limiter of the queue, the retries span about five minutes.
+const DefaultMaxRetries = 15
+
+// QuarantinedKey is a key which failed to reconcile more often than the retry budget allows.
+type QuarantinedKey struct {
+	Key     string    `json:"key"`
+	Error   string    `json:"error"`
+	Retries int       `json:"retries"`
+	Since   time.Time `json:"since"`
+}
+
+// quarantine holds the keys of a controller which are not retried anymore, until their object
+// changes.
+type quarantine struct {
+	controller string
+
+	lock sync.Mutex
+	keys map[string]QuarantinedKey
+}
+
+func newQuarantine(controller string) *quarantine {
+	return &quarantine{
+		controller: controller,
+		keys:       map[string]QuarantinedKey{},
+	}
+}
+
+func (q *quarantine) add(key string, err error, retries int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.keys[key] = QuarantinedKey{Key: key, Error: err.Error(), Retries: retries, Since: time.Now()}
+	quarantinedKeys.WithLabelValues(q.controller).Set(float64(len(q.keys)))
+}
+
+func (q *quarantine) remove(key string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if _, found := q.keys[key]; !found {
+		return
+	}
+	delete(q.keys, key)
+	quarantinedKeys.WithLabelValues(q.controller).Set(float64(len(q.keys)))
+}
+
+func (q *quarantine) list() []QuarantinedKey {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	keys := make([]QuarantinedKey, 0, len(q.keys))
+	for _, key := range q.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+// QuarantineHandler returns a handler listing the quarantined keys of all controllers, by controller.
+func QuarantineHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		keys := map[string][]QuarantinedKey{}
+		for _, c := range listControllers() {
+			keys[c.name] = c.quarantine.list()
+		}
+		writeJSON(w, keys)
+	}
+}
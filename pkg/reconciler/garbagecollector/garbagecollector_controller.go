@@ -0,0 +1,272 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package garbagecollector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-cross-workspace-garbage-collector"
+
+	// dependentResyncPeriod is how often all known dependents are checked again. Owners that are
+	// cluster-scoped are not informed about, hence their deletion is only noticed by this resync.
+	dependentResyncPeriod = 5 * time.Minute
+)
+
+type clusterDiscovery interface {
+	WithCluster(name logicalcluster.LogicalCluster) discovery.DiscoveryInterface
+}
+
+// NewController returns a new controller that deletes objects whose owners, living in an ancestor
+// workspace named by the tenancy.kcp.dev/owner-cluster annotation, are all gone.
+func NewController(
+	dynamicMetadataClusterClient dynamic.ClusterInterface,
+	metadataClusterClient metadata.ClusterInterface,
+	clusterDiscoveryClient clusterDiscovery,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	pollInterval time.Duration,
+) *controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:                queue,
+		metadataClient:       dynamicMetadataClusterClient,
+		dependentsByOwnerUID: map[types.UID]sets.String{},
+		ownerUIDsByDependent: map[string][]types.UID{},
+		discoveryFor:         clusterDiscoveryClient.WithCluster,
+	}
+	c.getOwnerUID = c.getOwnerUIDFromServer
+
+	c.ddsif = informer.NewMetadataDiscoverySharedInformerFactory(workspaceInformer.Lister(), clusterDiscoveryClient, metadataClusterClient.Cluster(logicalcluster.Wildcard.String()),
+		func(obj interface{}) bool { return true },
+		informer.GVREventHandlerFuncs{
+			AddFunc:    func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueDependent(gvr, obj) },
+			UpdateFunc: func(gvr schema.GroupVersionResource, _, obj interface{}) { c.enqueueDependent(gvr, obj) },
+			DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) { c.handleDeletion(gvr, obj) },
+		}, pollInterval)
+
+	return c
+}
+
+// controller watches all namespaced resources for dependents of owners in ancestor workspaces, and
+// deletes them when their owners are gone.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	metadataClient dynamic.ClusterInterface
+	ddsif          informer.DynamicDiscoverySharedInformerFactory
+
+	// dependentsByOwnerUID maps owner UIDs to the queue keys of the dependents referencing them.
+	// UIDs are unique across logical clusters.
+	dependentsLock       sync.Mutex
+	dependentsByOwnerUID map[types.UID]sets.String
+	ownerUIDsByDependent map[string][]types.UID
+
+	discoveryFor func(clusterName logicalcluster.LogicalCluster) discovery.DiscoveryInterface
+
+	// getOwnerUID returns the UID of the owner in the given logical cluster, looked up in the given
+	// namespace if the owner is namespaced, or an empty UID if it does not exist.
+	getOwnerUID func(ctx context.Context, clusterName logicalcluster.LogicalCluster, namespace string, ref metav1.OwnerReference) (types.UID, error)
+}
+
+func dependentKey(gvr schema.GroupVersionResource, obj interface{}) (string, error) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return "", err
+	}
+	gvrstr := strings.Join([]string{gvr.Resource, gvr.Version, gvr.Group}, ".")
+	return gvrstr + "::" + key, nil
+}
+
+func (c *controller) enqueueDependent(gvr schema.GroupVersionResource, obj interface{}) {
+	u, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	key, err := dependentKey(gvr, obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	var ownerUIDs []types.UID
+	if _, found := u.GetAnnotations()[tenancyv1alpha1.OwnerClusterAnnotationKey]; found {
+		for _, ref := range u.GetOwnerReferences() {
+			ownerUIDs = append(ownerUIDs, ref.UID)
+		}
+	}
+	c.setOwnerUIDs(key, ownerUIDs)
+
+	if len(ownerUIDs) > 0 {
+		c.queue.Add(key)
+	}
+}
+
+// handleDeletion forgets a deleted dependent, and enqueues the dependents of a deleted owner.
+func (c *controller) handleDeletion(gvr schema.GroupVersionResource, obj interface{}) {
+	key, err := dependentKey(gvr, obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.setOwnerUIDs(key, nil)
+
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	c.dependentsLock.Lock()
+	defer c.dependentsLock.Unlock()
+	for _, dependent := range c.dependentsByOwnerUID[u.GetUID()].UnsortedList() {
+		klog.V(2).Infof("Owner %s|%s/%s is gone, queueing dependent %s", logicalcluster.From(u), u.GetNamespace(), u.GetName(), dependent)
+		c.queue.Add(dependent)
+	}
+}
+
+func (c *controller) setOwnerUIDs(key string, ownerUIDs []types.UID) {
+	c.dependentsLock.Lock()
+	defer c.dependentsLock.Unlock()
+
+	for _, uid := range c.ownerUIDsByDependent[key] {
+		c.dependentsByOwnerUID[uid].Delete(key)
+		if c.dependentsByOwnerUID[uid].Len() == 0 {
+			delete(c.dependentsByOwnerUID, uid)
+		}
+	}
+	delete(c.ownerUIDsByDependent, key)
+
+	if len(ownerUIDs) == 0 {
+		return
+	}
+	c.ownerUIDsByDependent[key] = ownerUIDs
+	for _, uid := range ownerUIDs {
+		if _, found := c.dependentsByOwnerUID[uid]; !found {
+			c.dependentsByOwnerUID[uid] = sets.NewString()
+		}
+		c.dependentsByOwnerUID[uid].Insert(key)
+	}
+}
+
+func (c *controller) enqueueAllDependents() {
+	c.dependentsLock.Lock()
+	defer c.dependentsLock.Unlock()
+	for key := range c.ownerUIDsByDependent {
+		c.queue.Add(key)
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	c.ddsif.Start(ctx)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+	go wait.Until(c.enqueueAllDependents, dependentResyncPeriod, ctx.Done())
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	parts := strings.SplitN(key, "::", 2)
+	if len(parts) != 2 {
+		klog.Errorf("Error parsing key %q; dropping", key)
+		return nil
+	}
+	gvr, _ := schema.ParseResourceArg(parts[0])
+	if gvr == nil {
+		klog.Errorf("Error parsing GVR %q; dropping", parts[0])
+		return nil
+	}
+
+	obj, exists, err := c.ddsif.IndexerFor(*gvr).GetByKey(parts[1])
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	u, err := meta.Accessor(obj)
+	if err != nil {
+		klog.Errorf("object has no metadata, dropping: %T", obj)
+		return nil
+	}
+
+	return c.reconcile(ctx, *gvr, u)
+}
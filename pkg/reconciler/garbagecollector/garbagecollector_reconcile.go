@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package garbagecollector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// ownerCluster returns the logical cluster in which the owner references of the object are resolved,
+// or false if the object does not opt into cross-workspace owner references. Only ancestors of the
+// object's own workspace are accepted, so that nobody can tie the lifecycle of an object to a
+// workspace unrelated to it.
+func ownerCluster(obj metav1.Object) (logicalcluster.LogicalCluster, bool) {
+	value, found := obj.GetAnnotations()[tenancyv1alpha1.OwnerClusterAnnotationKey]
+	if !found || value == "" || len(obj.GetOwnerReferences()) == 0 {
+		return logicalcluster.LogicalCluster{}, false
+	}
+	if !strings.HasPrefix(logicalcluster.From(obj).String(), value+":") {
+		return logicalcluster.LogicalCluster{}, false
+	}
+	return logicalcluster.New(value), true
+}
+
+func (c *controller) reconcile(ctx context.Context, gvr schema.GroupVersionResource, dependent metav1.Object) error {
+	if dependent.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	owners, ok := ownerCluster(dependent)
+	if !ok {
+		return nil
+	}
+
+	for _, ref := range dependent.GetOwnerReferences() {
+		uid, err := c.getOwnerUID(ctx, owners, dependent.GetNamespace(), ref)
+		if err != nil {
+			return err
+		}
+		if uid == ref.UID {
+			// at least one owner is still around
+			return nil
+		}
+	}
+
+	clusterName := logicalcluster.From(dependent)
+	klog.Infof("Deleting %s %s|%s/%s: all of its owners in %s are gone", gvr, clusterName, dependent.GetNamespace(), dependent.GetName(), owners)
+
+	uid := dependent.GetUID()
+	propagation := metav1.DeletePropagationBackground
+	err := c.metadataClient.Cluster(clusterName).Resource(gvr).Namespace(dependent.GetNamespace()).Delete(ctx, dependent.GetName(), metav1.DeleteOptions{
+		Preconditions:     &metav1.Preconditions{UID: &uid},
+		PropagationPolicy: &propagation,
+	})
+	if k8serrors.IsNotFound(err) || k8serrors.IsConflict(err) {
+		// gone already, or replaced by a new object with the same name
+		return nil
+	}
+	return err
+}
+
+// getOwnerUIDFromServer resolves the kind of the owner reference through discovery of the owner's
+// logical cluster, and returns the UID of the owner, or an empty UID if it does not exist.
+func (c *controller) getOwnerUIDFromServer(ctx context.Context, clusterName logicalcluster.LogicalCluster, namespace string, ref metav1.OwnerReference) (types.UID, error) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return "", fmt.Errorf("invalid owner reference apiVersion %q: %w", ref.APIVersion, err)
+	}
+	resources, err := c.discoveryFor(clusterName).ServerResourcesForGroupVersion(ref.APIVersion)
+	if k8serrors.IsNotFound(err) {
+		// the API is gone, hence the owner as well
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Kind != ref.Kind || strings.Contains(resource.Name, "/") {
+			continue
+		}
+		client := c.metadataClient.Cluster(clusterName).Resource(gv.WithResource(resource.Name))
+		var owner *unstructured.Unstructured
+		if resource.Namespaced {
+			owner, err = client.Namespace(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		} else {
+			owner, err = client.Get(ctx, ref.Name, metav1.GetOptions{})
+		}
+		if k8serrors.IsNotFound(err) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return owner.GetUID(), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package garbagecollector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clientgotesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/workqueue"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+type fakeClusterClient struct {
+	*dynamicfake.FakeDynamicClient
+}
+
+func (c fakeClusterClient) Cluster(logicalcluster.LogicalCluster) dynamic.Interface {
+	return c.FakeDynamicClient
+}
+
+func TestReconcile(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	dependent := func(cluster, ownerCluster string, owners ...types.UID) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace("default")
+		u.SetName("dependent")
+		u.SetUID("dependent-uid")
+		u.SetClusterName(cluster)
+		if ownerCluster != "" {
+			u.SetAnnotations(map[string]string{tenancyv1alpha1.OwnerClusterAnnotationKey: ownerCluster})
+		}
+		var refs []metav1.OwnerReference
+		for _, uid := range owners {
+			refs = append(refs, metav1.OwnerReference{APIVersion: "example.dev/v1", Kind: "Widget", Name: "owner-" + string(uid), UID: uid})
+		}
+		u.SetOwnerReferences(refs)
+		return u
+	}
+
+	tests := map[string]struct {
+		dependent     *unstructured.Unstructured
+		existing      map[string]types.UID
+		wantDeleted   bool
+		wantOwnerGets int
+	}{
+		"no annotation": {
+			dependent: dependent("root:org:ws", "", "a"),
+		},
+		"owner cluster is not an ancestor": {
+			dependent: dependent("root:org:ws", "root:other", "a"),
+		},
+		"owner cluster is the object's own cluster": {
+			dependent: dependent("root:org:ws", "root:org:ws", "a"),
+		},
+		"owner exists": {
+			dependent:     dependent("root:org:ws", "root:org", "a"),
+			existing:      map[string]types.UID{"owner-a": "a"},
+			wantOwnerGets: 1,
+		},
+		"owner is gone": {
+			dependent:     dependent("root:org:ws", "root:org", "a"),
+			wantDeleted:   true,
+			wantOwnerGets: 1,
+		},
+		"owner was recreated with another uid": {
+			dependent:     dependent("root:org:ws", "root:org", "a"),
+			existing:      map[string]types.UID{"owner-a": "other"},
+			wantDeleted:   true,
+			wantOwnerGets: 1,
+		},
+		"one of two owners exists": {
+			dependent:     dependent("root:org:ws", "root", "a", "b"),
+			existing:      map[string]types.UID{"owner-b": "b"},
+			wantOwnerGets: 2,
+		},
+		"all owners are gone": {
+			dependent:     dependent("root:org:ws", "root", "a", "b"),
+			wantDeleted:   true,
+			wantOwnerGets: 2,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), tc.dependent.DeepCopy())
+			ownerGets := 0
+			c := &controller{
+				metadataClient: fakeClusterClient{client},
+				getOwnerUID: func(ctx context.Context, clusterName logicalcluster.LogicalCluster, namespace string, ref metav1.OwnerReference) (types.UID, error) {
+					ownerGets++
+					require.Equal(t, tc.dependent.GetAnnotations()[tenancyv1alpha1.OwnerClusterAnnotationKey], clusterName.String())
+					require.Equal(t, "default", namespace)
+					return tc.existing[ref.Name], nil
+				},
+			}
+
+			err := c.reconcile(context.Background(), gvr, tc.dependent)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantOwnerGets, ownerGets)
+
+			deleted := false
+			for _, action := range client.Actions() {
+				if action.Matches("delete", "configmaps") {
+					deleted = true
+					require.Equal(t, "dependent", action.(clientgotesting.DeleteAction).GetName())
+				}
+			}
+			require.Equal(t, tc.wantDeleted, deleted)
+		})
+	}
+}
+
+func TestHandleDeletion(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	c := &controller{
+		queue:                workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		dependentsByOwnerUID: map[types.UID]sets.String{},
+		ownerUIDsByDependent: map[string][]types.UID{},
+	}
+	defer c.queue.ShutDown()
+
+	// the metadata informers deliver PartialObjectMetadata
+	dependent := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "dependent",
+			ClusterName:     "root:org:ws",
+			Annotations:     map[string]string{tenancyv1alpha1.OwnerClusterAnnotationKey: "root:org"},
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "example.dev/v1", Kind: "Widget", Name: "owner", UID: "owner-uid"}},
+		},
+	}
+
+	c.enqueueDependent(gvr, dependent)
+	require.Equal(t, 1, c.queue.Len())
+	key, _ := c.queue.Get()
+	c.queue.Done(key)
+	c.queue.Forget(key)
+
+	owner := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{Name: "owner", UID: "owner-uid", ClusterName: "root:org"},
+	}
+	c.handleDeletion(schema.GroupVersionResource{Group: "example.dev", Version: "v1", Resource: "widgets"}, owner)
+	require.Equal(t, 1, c.queue.Len())
+	requeued, _ := c.queue.Get()
+	require.Equal(t, key, requeued)
+	c.queue.Done(requeued)
+
+	c.handleDeletion(gvr, dependent)
+	require.Empty(t, c.dependentsByOwnerUID)
+	require.Empty(t, c.ownerUIDsByDependent)
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Package priorityqueue provides a workqueue with two tiers, processing the items of
+// system workspaces ahead of those of user workspaces.
+package priorityqueue
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// NewNamedRateLimitingQueue returns a rate limiting queue like workqueue.NewNamedRateLimitingQueue,
+// but which hands out the items for which isPriority returns true before all others. Within each
+// tier, items are processed in FIFO order.
+//
+// During recovery storms, e.g. after a restart, this keeps platform-level objects from starving
+// behind the churn of tenant objects.
+func NewNamedRateLimitingQueue(rateLimiter workqueue.RateLimiter, name string, isPriority func(item interface{}) bool) workqueue.RateLimitingInterface {
+	return &rateLimitingQueue{
+		DelayingInterface: workqueue.NewDelayingQueueWithCustomQueue(newQueue(isPriority), name),
+		rateLimiter:       rateLimiter,
+	}
+}
+
+// SystemWorkspaceKey returns true for the keys of objects in the root workspace or in a
+// system:* logical cluster, as returned by cache.MetaNamespaceKeyFunc for cluster-aware objects.
+func SystemWorkspaceKey(item interface{}) bool {
+	key, ok := item.(string)
+	if !ok {
+		return false
+	}
+	_, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return false
+	}
+	clusterName, _ := clusters.SplitClusterAwareKey(clusterAwareName)
+	return IsSystemWorkspace(clusterName)
+}
+
+// IsSystemWorkspace returns true for the root workspace and the system:* logical clusters.
+func IsSystemWorkspace(clusterName logicalcluster.LogicalCluster) bool {
+	return clusterName == logicalcluster.New("root") || strings.HasPrefix(clusterName.String(), "system:")
+}
+
+// rateLimitingQueue adds rate limiting to a delaying queue, like the rate limiting queue of client-go.
+type rateLimitingQueue struct {
+	workqueue.DelayingInterface
+
+	rateLimiter workqueue.RateLimiter
+}
+
+// AddRateLimited adds the item to the queue once the rate limiter says it's ok.
+func (q *rateLimitingQueue) AddRateLimited(item interface{}) {
+	q.DelayingInterface.AddAfter(item, q.rateLimiter.When(item))
+}
+
+func (q *rateLimitingQueue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
+
+func (q *rateLimitingQueue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+// queue is a workqueue.Interface with the semantics of workqueue.Type: an item is never processed
+// concurrently, and an item added while it is processed is processed again afterwards. It keeps
+// two FIFO tiers, and Get returns the priority tier first.
+type queue struct {
+	isPriority func(item interface{}) bool
+
+	cond *sync.Cond
+
+	priority []interface{}
+	normal   []interface{}
+
+	// dirty holds the items that need to be processed, processing those that are being processed.
+	dirty      map[interface{}]struct{}
+	processing map[interface{}]struct{}
+
+	shuttingDown bool
+	drain        bool
+}
+
+var _ workqueue.Interface = &queue{}
+
+func newQueue(isPriority func(item interface{}) bool) *queue {
+	return &queue{
+		isPriority: isPriority,
+		cond:       sync.NewCond(&sync.Mutex{}),
+		dirty:      map[interface{}]struct{}{},
+		processing: map[interface{}]struct{}{},
+	}
+}
+
+func (q *queue) Add(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	if q.shuttingDown {
+		return
+	}
+	if _, found := q.dirty[item]; found {
+		return
+	}
+	q.dirty[item] = struct{}{}
+	if _, found := q.processing[item]; found {
+		// queued again when done
+		return
+	}
+	q.push(item)
+	q.cond.Signal()
+}
+
+func (q *queue) push(item interface{}) {
+	if q.isPriority(item) {
+		q.priority = append(q.priority, item)
+	} else {
+		q.normal = append(q.normal, item)
+	}
+}
+
+func (q *queue) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return len(q.priority) + len(q.normal)
+}
+
+func (q *queue) Get() (interface{}, bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	for len(q.priority) == 0 && len(q.normal) == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	var item interface{}
+	switch {
+	case len(q.priority) > 0:
+		item, q.priority[0] = q.priority[0], nil
+		q.priority = q.priority[1:]
+	case len(q.normal) > 0:
+		item, q.normal[0] = q.normal[0], nil
+		q.normal = q.normal[1:]
+	default:
+		// shutting down and empty
+		return nil, true
+	}
+
+	q.processing[item] = struct{}{}
+	delete(q.dirty, item)
+	return item, false
+}
+
+func (q *queue) Done(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	delete(q.processing, item)
+	if _, found := q.dirty[item]; found {
+		q.push(item)
+		q.cond.Signal()
+	} else if len(q.processing) == 0 {
+		// wakes up ShutDownWithDrain
+		q.cond.Broadcast()
+	}
+}
+
+func (q *queue) ShutDown() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.drain = false
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+// ShutDownWithDrain shuts down the queue, and waits until all items being processed are done.
+func (q *queue) ShutDownWithDrain() {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.drain = true
+	q.shuttingDown = true
+	q.cond.Broadcast()
+
+	for len(q.processing) != 0 && q.drain {
+		q.cond.Wait()
+	}
+}
+
+func (q *queue) ShuttingDown() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return q.shuttingDown
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package priorityqueue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func key(namespace, clusterName, name string) string {
+	key := clusters.ToClusterAwareKey(logicalcluster.New(clusterName), name)
+	if namespace != "" {
+		key = namespace + "/" + key
+	}
+	return key
+}
+
+func TestSystemWorkspaceKey(t *testing.T) {
+	tests := map[string]bool{
+		key("", "root", "org"):                   true,
+		key("ns", "root", "cm"):                  true,
+		key("", "system:bound-crds", "uid"):      true,
+		key("ns", "system:admin", "cm"):          true,
+		key("", "root:org", "ws"):                false,
+		key("ns", "root:org:ws", "cm"):           false,
+		key("ns", "root:system:something", "cm"): false,
+		"invalid/key/with/many/slashes":          false,
+	}
+	for key, want := range tests {
+		t.Run(key, func(t *testing.T) {
+			require.Equal(t, want, SystemWorkspaceKey(key))
+		})
+	}
+}
+
+func TestQueueOrder(t *testing.T) {
+	q := NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test", SystemWorkspaceKey)
+	defer q.ShutDown()
+
+	q.Add(key("", "root:org:a", "one"))
+	q.Add(key("", "root:org:b", "two"))
+	q.Add(key("", "root", "org"))
+	q.Add(key("", "root:org:a", "one")) // deduplicated
+	q.Add(key("", "system:bound-crds", "crd"))
+	require.Equal(t, 4, q.Len())
+
+	var got []string
+	for q.Len() > 0 {
+		item, shutdown := q.Get()
+		require.False(t, shutdown)
+		got = append(got, item.(string))
+		q.Done(item)
+	}
+	require.Equal(t, []string{key("", "root", "org"), key("", "system:bound-crds", "crd"), key("", "root:org:a", "one"), key("", "root:org:b", "two")}, got)
+}
+
+func TestQueueProcessing(t *testing.T) {
+	q := NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test", SystemWorkspaceKey)
+
+	q.Add(key("", "root:org", "ws"))
+	item, _ := q.Get()
+
+	// an item added while being processed is handed out again only when done
+	q.Add(key("", "root:org", "ws"))
+	require.Equal(t, 0, q.Len())
+	q.Done(item)
+	require.Equal(t, 1, q.Len())
+	item, _ = q.Get()
+
+	// shutting down with drain waits for the items being processed
+	drained := make(chan struct{})
+	go func() {
+		q.ShutDownWithDrain()
+		close(drained)
+	}()
+	require.Eventually(t, q.ShuttingDown, time.Second, time.Millisecond)
+	select {
+	case <-drained:
+		t.Fatal("queue drained while an item is processed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	q.Done(item)
+	<-drained
+
+	_, shutdown := q.Get()
+	require.True(t, shutdown)
+}
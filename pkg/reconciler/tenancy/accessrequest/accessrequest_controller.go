@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessrequest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "accessrequest"
+)
+
+// NewController returns a controller that grants the access of approved AccessRequests and
+// revokes it when their TTL is over or they are deleted.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	kubeClusterClient kubernetes.ClusterInterface,
+	accessRequestInformer tenancyinformer.AccessRequestInformer,
+) (*Controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-accessrequest")
+
+	c := &Controller{
+		queue:               queue,
+		kcpClusterClient:    kcpClusterClient,
+		kubeClusterClient:   kubeClusterClient,
+		accessRequestLister: accessRequestInformer.Lister(),
+		now:                 time.Now,
+	}
+	c.grantAccess = c.ensureBinding
+	c.revokeAccess = c.deleteBinding
+
+	accessRequestInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueue(obj) },
+	}))
+
+	return c, nil
+}
+
+// Controller materializes the access of approved AccessRequests as a ClusterRole and
+// ClusterRoleBinding in the workspace of the request, and removes them again on expiry.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient    kcpclient.ClusterInterface
+	kubeClusterClient   kubernetes.ClusterInterface
+	accessRequestLister tenancylister.AccessRequestLister
+
+	now          func() time.Time
+	grantAccess  func(ctx context.Context, request *tenancyv1alpha1.AccessRequest) error
+	revokeAccess func(ctx context.Context, request *tenancyv1alpha1.AccessRequest) error
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting AccessRequest controller")
+	defer klog.Info("Shutting down AccessRequest controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, err := c.accessRequestLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// revoke the access of the deleted request
+			clusterName, name := clusters.SplitClusterAwareKey(key)
+			return c.revokeAccess(ctx, &tenancyv1alpha1.AccessRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: clusterName.String()},
+			})
+		}
+		return err
+	}
+	previous := obj
+	obj = obj.DeepCopy()
+
+	requeueAfter, err := c.reconcile(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
+		if err := c.patchStatus(ctx, previous, obj); err != nil {
+			return err
+		}
+	}
+
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return nil
+}
+
+func (c *Controller) patchStatus(ctx context.Context, previous, obj *tenancyv1alpha1.AccessRequest) error {
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(tenancyv1alpha1.AccessRequest{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for AccessRequest %s|%s: %w", clusterName, obj.Name, err)
+	}
+	newData, err := json.Marshal(tenancyv1alpha1.AccessRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for AccessRequest %s|%s: %w", clusterName, obj.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for AccessRequest %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().AccessRequests().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
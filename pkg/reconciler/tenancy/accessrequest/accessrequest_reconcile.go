@@ -0,0 +1,207 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessrequest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func (c *Controller) reconcile(ctx context.Context, request *tenancyv1alpha1.AccessRequest) (time.Duration, error) {
+	previousPhase := request.Status.Phase
+
+	switch request.Status.Decision {
+	case tenancyv1alpha1.AccessRequestApproved:
+	case tenancyv1alpha1.AccessRequestDenied:
+		request.Status.Phase = tenancyv1alpha1.AccessRequestPhaseDenied
+		return 0, nil
+	default:
+		request.Status.Phase = tenancyv1alpha1.AccessRequestPhasePending
+		return 0, nil
+	}
+
+	if previousPhase == tenancyv1alpha1.AccessRequestPhaseExpired {
+		return 0, nil
+	}
+
+	now := c.now()
+	if request.Spec.TTL != nil && request.Status.ExpirationTime == nil {
+		expiration := metav1.NewTime(now.Add(request.Spec.TTL.Duration))
+		request.Status.ExpirationTime = &expiration
+	}
+
+	if request.Status.ExpirationTime != nil && !now.Before(request.Status.ExpirationTime.Time) {
+		if err := c.revokeAccess(ctx, request); err != nil {
+			return 0, err
+		}
+		request.Status.Phase = tenancyv1alpha1.AccessRequestPhaseExpired
+		return 0, nil
+	}
+
+	if err := c.grantAccess(ctx, request); err != nil {
+		return 0, err
+	}
+	request.Status.Phase = tenancyv1alpha1.AccessRequestPhaseGranted
+
+	if request.Status.ExpirationTime == nil {
+		return 0, nil
+	}
+	return request.Status.ExpirationTime.Sub(now), nil
+}
+
+// bindingName is the name of the ClusterRole and ClusterRoleBinding granting the access of the request.
+func bindingName(request *tenancyv1alpha1.AccessRequest) string {
+	return "accessrequest-" + request.Name
+}
+
+// desiredBinding returns the ClusterRole and ClusterRoleBinding granting the requested verb on the
+// content of the workspace to the requesting user. Both are owned by the request.
+func desiredBinding(request *tenancyv1alpha1.AccessRequest) (*rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding) {
+	verbs := []string{string(tenancyv1alpha1.AccessRequestRoleAccess)}
+	if request.Spec.Role == tenancyv1alpha1.AccessRequestRoleAdmin {
+		verbs = append([]string{string(tenancyv1alpha1.AccessRequestRoleAdmin)}, verbs...)
+	}
+
+	objectMeta := metav1.ObjectMeta{
+		Name: bindingName(request),
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: tenancyv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "AccessRequest",
+			Name:       request.Name,
+			UID:        request.UID,
+		}},
+	}
+
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: objectMeta,
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{tenancyv1alpha1.SchemeGroupVersion.Group},
+			Resources:     []string{"clusterworkspaces/content"},
+			ResourceNames: []string{request.Spec.Workspace},
+			Verbs:         verbs,
+		}},
+	}
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: *objectMeta.DeepCopy(),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     role.Name,
+		},
+		Subjects: []rbacv1.Subject{{
+			APIGroup: rbacv1.GroupName,
+			Kind:     rbacv1.UserKind,
+			Name:     request.Spec.User,
+		}},
+	}
+	return role, binding
+}
+
+// isOwnedBy returns whether the object is owned by the request. Without a UID of the request, i.e.
+// when the request has been deleted, any AccessRequest of the same name is accepted as owner.
+func isOwnedBy(obj metav1.Object, request *tenancyv1alpha1.AccessRequest) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.APIVersion != tenancyv1alpha1.SchemeGroupVersion.String() || ref.Kind != "AccessRequest" || ref.Name != request.Name {
+			continue
+		}
+		if request.UID == "" || ref.UID == request.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureBinding creates or updates the ClusterRole and ClusterRoleBinding of the request. Existing
+// objects of the same name that are not owned by the request are not adopted.
+func (c *Controller) ensureBinding(ctx context.Context, request *tenancyv1alpha1.AccessRequest) error {
+	clusterName := logicalcluster.From(request)
+	rbacClient := c.kubeClusterClient.Cluster(clusterName).RbacV1()
+	role, binding := desiredBinding(request)
+
+	existingRole, err := rbacClient.ClusterRoles().Get(ctx, role.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := rbacClient.ClusterRoles().Create(ctx, role, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if !isOwnedBy(existingRole, request) {
+		return fmt.Errorf("ClusterRole %q exists and is not owned by AccessRequest %q", role.Name, request.Name)
+	} else if !equality.Semantic.DeepEqual(existingRole.Rules, role.Rules) {
+		existingRole = existingRole.DeepCopy()
+		existingRole.Rules = role.Rules
+		if _, err := rbacClient.ClusterRoles().Update(ctx, existingRole, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	existingBinding, err := rbacClient.ClusterRoleBindings().Get(ctx, binding.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = rbacClient.ClusterRoleBindings().Create(ctx, binding, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if !isOwnedBy(existingBinding, request) {
+		return fmt.Errorf("ClusterRoleBinding %q exists and is not owned by AccessRequest %q", binding.Name, request.Name)
+	}
+	if equality.Semantic.DeepEqual(existingBinding.Subjects, binding.Subjects) {
+		return nil
+	}
+	existingBinding = existingBinding.DeepCopy()
+	existingBinding.Subjects = binding.Subjects
+	_, err = rbacClient.ClusterRoleBindings().Update(ctx, existingBinding, metav1.UpdateOptions{})
+	return err
+}
+
+// deleteBinding deletes the ClusterRoleBinding and ClusterRole of the request, if they are owned by it.
+func (c *Controller) deleteBinding(ctx context.Context, request *tenancyv1alpha1.AccessRequest) error {
+	clusterName := logicalcluster.From(request)
+	rbacClient := c.kubeClusterClient.Cluster(clusterName).RbacV1()
+	name := bindingName(request)
+
+	binding, err := rbacClient.ClusterRoleBindings().Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && isOwnedBy(binding, request) {
+		if err := rbacClient.ClusterRoleBindings().Delete(ctx, name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &binding.UID}}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	role, err := rbacClient.ClusterRoles().Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && isOwnedBy(role, request) {
+		if err := rbacClient.ClusterRoles().Delete(ctx, name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &role.UID}}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessrequest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *metav1.Time {
+		t := metav1.NewTime(now.Add(d))
+		return &t
+	}
+
+	tests := map[string]struct {
+		ttl    *metav1.Duration
+		status tenancyv1alpha1.AccessRequestStatus
+
+		wantStatus       tenancyv1alpha1.AccessRequestStatus
+		wantGranted      bool
+		wantRevoked      bool
+		wantRequeueAfter time.Duration
+	}{
+		"undecided": {
+			wantStatus: tenancyv1alpha1.AccessRequestStatus{Phase: tenancyv1alpha1.AccessRequestPhasePending},
+		},
+		"denied": {
+			status: tenancyv1alpha1.AccessRequestStatus{Decision: tenancyv1alpha1.AccessRequestDenied},
+			wantStatus: tenancyv1alpha1.AccessRequestStatus{
+				Decision: tenancyv1alpha1.AccessRequestDenied,
+				Phase:    tenancyv1alpha1.AccessRequestPhaseDenied,
+			},
+		},
+		"approved without ttl": {
+			status: tenancyv1alpha1.AccessRequestStatus{Decision: tenancyv1alpha1.AccessRequestApproved},
+			wantStatus: tenancyv1alpha1.AccessRequestStatus{
+				Decision: tenancyv1alpha1.AccessRequestApproved,
+				Phase:    tenancyv1alpha1.AccessRequestPhaseGranted,
+			},
+			wantGranted: true,
+		},
+		"approved with ttl": {
+			ttl:    &metav1.Duration{Duration: time.Hour},
+			status: tenancyv1alpha1.AccessRequestStatus{Decision: tenancyv1alpha1.AccessRequestApproved},
+			wantStatus: tenancyv1alpha1.AccessRequestStatus{
+				Decision:       tenancyv1alpha1.AccessRequestApproved,
+				Phase:          tenancyv1alpha1.AccessRequestPhaseGranted,
+				ExpirationTime: at(time.Hour),
+			},
+			wantGranted:      true,
+			wantRequeueAfter: time.Hour,
+		},
+		"granted before expiration": {
+			ttl: &metav1.Duration{Duration: time.Hour},
+			status: tenancyv1alpha1.AccessRequestStatus{
+				Decision:       tenancyv1alpha1.AccessRequestApproved,
+				Phase:          tenancyv1alpha1.AccessRequestPhaseGranted,
+				ExpirationTime: at(10 * time.Minute),
+			},
+			wantStatus: tenancyv1alpha1.AccessRequestStatus{
+				Decision:       tenancyv1alpha1.AccessRequestApproved,
+				Phase:          tenancyv1alpha1.AccessRequestPhaseGranted,
+				ExpirationTime: at(10 * time.Minute),
+			},
+			wantGranted:      true,
+			wantRequeueAfter: 10 * time.Minute,
+		},
+		"granted after expiration": {
+			ttl: &metav1.Duration{Duration: time.Hour},
+			status: tenancyv1alpha1.AccessRequestStatus{
+				Decision:       tenancyv1alpha1.AccessRequestApproved,
+				Phase:          tenancyv1alpha1.AccessRequestPhaseGranted,
+				ExpirationTime: at(-time.Second),
+			},
+			wantStatus: tenancyv1alpha1.AccessRequestStatus{
+				Decision:       tenancyv1alpha1.AccessRequestApproved,
+				Phase:          tenancyv1alpha1.AccessRequestPhaseExpired,
+				ExpirationTime: at(-time.Second),
+			},
+			wantRevoked: true,
+		},
+		"expired": {
+			ttl: &metav1.Duration{Duration: time.Hour},
+			status: tenancyv1alpha1.AccessRequestStatus{
+				Decision:       tenancyv1alpha1.AccessRequestApproved,
+				Phase:          tenancyv1alpha1.AccessRequestPhaseExpired,
+				ExpirationTime: at(-time.Hour),
+			},
+			wantStatus: tenancyv1alpha1.AccessRequestStatus{
+				Decision:       tenancyv1alpha1.AccessRequestApproved,
+				Phase:          tenancyv1alpha1.AccessRequestPhaseExpired,
+				ExpirationTime: at(-time.Hour),
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var granted, revoked bool
+			c := &Controller{
+				now: func() time.Time { return now },
+				grantAccess: func(ctx context.Context, request *tenancyv1alpha1.AccessRequest) error {
+					granted = true
+					return nil
+				},
+				revokeAccess: func(ctx context.Context, request *tenancyv1alpha1.AccessRequest) error {
+					revoked = true
+					return nil
+				},
+			}
+			request := &tenancyv1alpha1.AccessRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "request", ClusterName: "root:org"},
+				Spec: tenancyv1alpha1.AccessRequestSpec{
+					Workspace: "team",
+					Role:      tenancyv1alpha1.AccessRequestRoleAccess,
+					User:      "alice",
+					TTL:       tc.ttl,
+				},
+				Status: tc.status,
+			}
+
+			requeueAfter, err := c.reconcile(context.Background(), request)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantStatus, request.Status)
+			require.Equal(t, tc.wantGranted, granted, "granted")
+			require.Equal(t, tc.wantRevoked, revoked, "revoked")
+			require.Equal(t, tc.wantRequeueAfter, requeueAfter)
+		})
+	}
+}
+
+func TestDesiredBinding(t *testing.T) {
+	tests := map[string]struct {
+		role      tenancyv1alpha1.AccessRequestRole
+		wantVerbs []string
+	}{
+		"access": {
+			role:      tenancyv1alpha1.AccessRequestRoleAccess,
+			wantVerbs: []string{"access"},
+		},
+		"admin": {
+			role:      tenancyv1alpha1.AccessRequestRoleAdmin,
+			wantVerbs: []string{"admin", "access"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			request := &tenancyv1alpha1.AccessRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "request", ClusterName: "root:org", UID: "uid"},
+				Spec: tenancyv1alpha1.AccessRequestSpec{
+					Workspace: "team",
+					Role:      tc.role,
+					User:      "alice",
+				},
+			}
+
+			role, binding := desiredBinding(request)
+			require.Equal(t, "accessrequest-request", role.Name)
+			require.Equal(t, []rbacv1.PolicyRule{{
+				APIGroups:     []string{"tenancy.kcp.dev"},
+				Resources:     []string{"clusterworkspaces/content"},
+				ResourceNames: []string{"team"},
+				Verbs:         tc.wantVerbs,
+			}}, role.Rules)
+			require.Equal(t, role.Name, binding.RoleRef.Name)
+			require.Equal(t, []rbacv1.Subject{{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: "alice"}}, binding.Subjects)
+			require.Len(t, binding.OwnerReferences, 1)
+			require.Equal(t, "request", binding.OwnerReferences[0].Name)
+		})
+	}
+}
+
+type fakeClusterClient struct {
+	*fake.Clientset
+}
+
+func (c fakeClusterClient) Cluster(logicalcluster.LogicalCluster) kubernetes.Interface {
+	return c.Clientset
+}
+
+func TestBindingOwnership(t *testing.T) {
+	request := &tenancyv1alpha1.AccessRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "request", ClusterName: "root:org", UID: "uid"},
+		Spec: tenancyv1alpha1.AccessRequestSpec{
+			Workspace: "team",
+			Role:      tenancyv1alpha1.AccessRequestRoleAccess,
+			User:      "alice",
+		},
+	}
+	ownedRole, ownedBinding := desiredBinding(request)
+	foreignRole, foreignBinding := ownedRole.DeepCopy(), ownedBinding.DeepCopy()
+	foreignRole.OwnerReferences, foreignBinding.OwnerReferences = nil, nil
+	previous := request.DeepCopy()
+	previous.UID = "previous"
+	previousRole, previousBinding := desiredBinding(previous)
+
+	tests := map[string]struct {
+		objects []runtime.Object
+		request *tenancyv1alpha1.AccessRequest
+		revoke  bool
+
+		wantErr     bool
+		wantDeleted bool
+	}{
+		"grants with owned objects": {
+			objects: []runtime.Object{ownedRole, ownedBinding},
+			request: request,
+		},
+		"does not adopt foreign objects": {
+			objects: []runtime.Object{foreignRole, foreignBinding},
+			request: request,
+			wantErr: true,
+		},
+		"does not adopt objects of a previous request of the same name": {
+			objects: []runtime.Object{previousRole, previousBinding},
+			request: request,
+			wantErr: true,
+		},
+		"revokes owned objects": {
+			objects:     []runtime.Object{ownedRole, ownedBinding},
+			request:     request,
+			revoke:      true,
+			wantDeleted: true,
+		},
+		"revokes owned objects of a deleted request": {
+			objects:     []runtime.Object{ownedRole, ownedBinding},
+			request:     &tenancyv1alpha1.AccessRequest{ObjectMeta: metav1.ObjectMeta{Name: "request", ClusterName: "root:org"}},
+			revoke:      true,
+			wantDeleted: true,
+		},
+		"does not delete foreign objects": {
+			objects: []runtime.Object{foreignRole, foreignBinding},
+			request: &tenancyv1alpha1.AccessRequest{ObjectMeta: metav1.ObjectMeta{Name: "request", ClusterName: "root:org"}},
+			revoke:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var objects []runtime.Object
+			for _, obj := range tc.objects {
+				objects = append(objects, obj.DeepCopyObject())
+			}
+			client := fake.NewSimpleClientset(objects...)
+			c := &Controller{kubeClusterClient: fakeClusterClient{client}}
+
+			var err error
+			if tc.revoke {
+				err = c.deleteBinding(context.Background(), tc.request)
+			} else {
+				err = c.ensureBinding(context.Background(), tc.request)
+			}
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			_, roleErr := client.RbacV1().ClusterRoles().Get(context.Background(), "accessrequest-request", metav1.GetOptions{})
+			_, bindingErr := client.RbacV1().ClusterRoleBindings().Get(context.Background(), "accessrequest-request", metav1.GetOptions{})
+			if tc.wantDeleted {
+				require.True(t, errors.IsNotFound(roleErr), "expected the ClusterRole to be deleted")
+				require.True(t, errors.IsNotFound(bindingErr), "expected the ClusterRoleBinding to be deleted")
+			} else {
+				require.NoError(t, roleErr)
+				require.NoError(t, bindingErr)
+			}
+		})
+	}
+}
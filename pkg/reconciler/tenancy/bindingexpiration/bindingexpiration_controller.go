@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindingexpiration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-binding-expiration"
+)
+
+// NewController returns a controller that deletes ClusterRoleBindings in workspaces once the time
+// of their expiration annotation has passed.
+func NewController(
+	kubeClusterClient kubernetes.ClusterInterface,
+	clusterRoleBindingInformer rbacinformers.ClusterRoleBindingInformer,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue:                    queue,
+		kubeClusterClient:        kubeClusterClient,
+		clusterRoleBindingLister: clusterRoleBindingInformer.Lister(),
+		now:                      time.Now,
+	}
+	c.deleteBinding = c.deleteClusterRoleBinding
+	c.recordEvent = c.createEvent
+
+	clusterRoleBindingInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			binding, ok := obj.(*rbacv1.ClusterRoleBinding)
+			if !ok {
+				return false
+			}
+			_, found := binding.Annotations[tenancyv1alpha1.ExpirationTimeAnnotationKey]
+			return found
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		},
+	}))
+
+	return c
+}
+
+// Controller deletes expired ClusterRoleBindings and records an event for every deletion.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kubeClusterClient        kubernetes.ClusterInterface
+	clusterRoleBindingLister rbaclisters.ClusterRoleBindingLister
+
+	now           func() time.Time
+	deleteBinding func(ctx context.Context, binding *rbacv1.ClusterRoleBinding) error
+	recordEvent   func(ctx context.Context, event *corev1.Event)
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting binding expiration controller")
+	defer klog.Info("Shutting down binding expiration controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	binding, err := c.clusterRoleBindingLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	requeueAfter, err := c.reconcile(ctx, binding)
+	if err != nil {
+		return err
+	}
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return nil
+}
+
+func (c *Controller) deleteClusterRoleBinding(ctx context.Context, binding *rbacv1.ClusterRoleBinding) error {
+	// the UID precondition protects a binding recreated under the same name
+	err := c.kubeClusterClient.Cluster(logicalcluster.From(binding)).RbacV1().ClusterRoleBindings().Delete(ctx, binding.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &binding.UID},
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// createEvent records the event in the default namespace of the workspace of the binding, as is
+// usual for cluster-scoped objects. Failing to record the event doesn't fail the reconciliation.
+func (c *Controller) createEvent(ctx context.Context, event *corev1.Event) {
+	if _, err := c.kubeClusterClient.Cluster(logicalcluster.From(event)).CoreV1().Events(event.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		klog.Errorf("Failed to record event %s for ClusterRoleBinding %s|%s: %v", event.Reason, event.ClusterName, event.InvolvedObject.Name, err)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindingexpiration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// BindingExpiredReason is the reason of the event recorded when an expired binding is deleted.
+	BindingExpiredReason = "Expired"
+)
+
+func (c *Controller) reconcile(ctx context.Context, binding *rbacv1.ClusterRoleBinding) (time.Duration, error) {
+	value, found := binding.Annotations[tenancyv1alpha1.ExpirationTimeAnnotationKey]
+	if !found || binding.DeletionTimestamp != nil {
+		return 0, nil
+	}
+	expiration, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		// retrying doesn't help, the binding is reconciled again when the annotation is fixed
+		klog.Errorf("Invalid %s annotation on ClusterRoleBinding %s|%s: %v", tenancyv1alpha1.ExpirationTimeAnnotationKey, binding.ClusterName, binding.Name, err)
+		return 0, nil
+	}
+
+	now := c.now()
+	if now.Before(expiration) {
+		return expiration.Sub(now), nil
+	}
+
+	if err := c.deleteBinding(ctx, binding); err != nil {
+		return 0, err
+	}
+	c.recordEvent(ctx, expiredEvent(binding, value, metav1.NewTime(now)))
+	return 0, nil
+}
+
+func expiredEvent(binding *rbacv1.ClusterRoleBinding, expiration string, now metav1.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: binding.Name + ".",
+			Namespace:    metav1.NamespaceDefault,
+			ClusterName:  binding.ClusterName,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: rbacv1.SchemeGroupVersion.String(),
+			Kind:       "ClusterRoleBinding",
+			Name:       binding.Name,
+			UID:        binding.UID,
+		},
+		Reason:              BindingExpiredReason,
+		Message:             fmt.Sprintf("Deleted ClusterRoleBinding %s of ClusterRole %s, expired at %s", binding.Name, binding.RoleRef.Name, expiration),
+		Type:                corev1.EventTypeNormal,
+		Source:              corev1.EventSource{Component: controllerName},
+		ReportingController: controllerName,
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bindingexpiration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		annotations map[string]string
+		deleteErr   error
+
+		wantDeleted      bool
+		wantEvent        bool
+		wantRequeueAfter time.Duration
+		wantErr          bool
+	}{
+		"no expiration": {},
+		"invalid expiration": {
+			annotations: map[string]string{tenancyv1alpha1.ExpirationTimeAnnotationKey: "tomorrow"},
+		},
+		"not expired yet": {
+			annotations:      map[string]string{tenancyv1alpha1.ExpirationTimeAnnotationKey: "2022-03-01T13:00:00Z"},
+			wantRequeueAfter: time.Hour,
+		},
+		"expired": {
+			annotations: map[string]string{tenancyv1alpha1.ExpirationTimeAnnotationKey: "2022-03-01T12:00:00Z"},
+			wantDeleted: true,
+			wantEvent:   true,
+		},
+		"expired with time zone": {
+			annotations: map[string]string{tenancyv1alpha1.ExpirationTimeAnnotationKey: "2022-03-01T12:30:00+01:00"},
+			wantDeleted: true,
+			wantEvent:   true,
+		},
+		"failed deletion": {
+			annotations: map[string]string{tenancyv1alpha1.ExpirationTimeAnnotationKey: "2022-03-01T11:00:00Z"},
+			deleteErr:   errors.New("boom"),
+			wantDeleted: true,
+			wantErr:     true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var deleted bool
+			var events []*corev1.Event
+			c := &Controller{
+				now: func() time.Time { return now },
+				deleteBinding: func(ctx context.Context, binding *rbacv1.ClusterRoleBinding) error {
+					deleted = true
+					return tc.deleteErr
+				},
+				recordEvent: func(ctx context.Context, event *corev1.Event) {
+					events = append(events, event)
+				},
+			}
+			binding := &rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "oncall",
+					ClusterName: "root:org:team",
+					UID:         "uid",
+					Annotations: tc.annotations,
+				},
+				RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "admin"},
+			}
+
+			requeueAfter, err := c.reconcile(context.Background(), binding)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantDeleted, deleted, "deleted")
+			require.Equal(t, tc.wantRequeueAfter, requeueAfter)
+			if !tc.wantEvent {
+				require.Empty(t, events)
+				return
+			}
+			require.Len(t, events, 1)
+			require.Equal(t, BindingExpiredReason, events[0].Reason)
+			require.Equal(t, "default", events[0].Namespace)
+			require.Equal(t, "root:org:team", events[0].ClusterName)
+			require.Equal(t, corev1.ObjectReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding", Name: "oncall", UID: "uid"}, events[0].InvolvedObject)
+		})
+	}
+}
@@ -33,7 +33,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -42,9 +41,13 @@ import (
 	"k8s.io/klog/v2"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/priorityqueue"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacescheduler"
 	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
@@ -59,8 +62,10 @@ func NewController(
 	kcpClient kcpclient.ClusterInterface,
 	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
 	rootWorkspaceShardInformer tenancyinformer.ClusterWorkspaceShardInformer,
+	schedulerPlugins []string,
 ) (*Controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	// objects of the root and system workspaces are reconciled first, e.g. when the queue fills up after a restart
+	queue := priorityqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName, priorityqueue.SystemWorkspaceKey)
 
 	c := &Controller{
 		queue:                     queue,
@@ -71,10 +76,16 @@ func NewController(
 		rootWorkspaceShardLister:  rootWorkspaceShardInformer.Lister(),
 	}
 
-	workspaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+	scheduler, err := workspacescheduler.New(c, schedulerPlugins)
+	if err != nil {
+		return nil, err
+	}
+	c.scheduler = scheduler
+
+	workspaceInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
 		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
-	})
+	}))
 	if err := c.workspaceIndexer.AddIndexers(map[string]cache.IndexFunc{
 		currentShardIndex: func(obj interface{}) ([]string, error) {
 			if workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace); ok {
@@ -94,11 +105,14 @@ func NewController(
 		return nil, fmt.Errorf("failed to add indexer for ClusterWorkspace: %w", err)
 	}
 
-	rootWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueueUpsertedShard(obj, "add") },
-		UpdateFunc: func(obj, _ interface{}) { c.enqueueUpsertedShard(obj, "update") },
+	rootWorkspaceShardInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { c.enqueueUpsertedShard(obj, "add") },
+		UpdateFunc: func(oldObj, obj interface{}) {
+			c.enqueueUpsertedShard(oldObj, "update")
+			c.enqueueMovedShard(oldObj, obj)
+		},
 		DeleteFunc: func(obj interface{}) { c.enqueueDeletedShard(obj) },
-	})
+	}))
 
 	return c, nil
 }
@@ -114,6 +128,18 @@ type Controller struct {
 
 	rootWorkspaceShardIndexer cache.Indexer
 	rootWorkspaceShardLister  tenancylister.ClusterWorkspaceShardLister
+
+	scheduler *workspacescheduler.Scheduler
+}
+
+var _ workspacescheduler.Handle = &Controller{}
+
+func (c *Controller) ClusterWorkspaceLister() tenancylister.ClusterWorkspaceLister {
+	return c.workspaceLister
+}
+
+func (c *Controller) ClusterWorkspaceShardLister() tenancylister.ClusterWorkspaceShardLister {
+	return c.rootWorkspaceShardLister
 }
 
 func (c *Controller) enqueue(obj interface{}) {
@@ -149,6 +175,38 @@ func (c *Controller) enqueueUpsertedShard(obj interface{}, verb string) {
 	}
 }
 
+// enqueueMovedShard queues the workspaces of a shard whose URLs changed, such that their base
+// URLs follow the shard.
+func (c *Controller) enqueueMovedShard(oldObj, obj interface{}) {
+	oldShard, ok := oldObj.(*tenancyv1alpha1.ClusterWorkspaceShard)
+	if !ok {
+		return
+	}
+	shard, ok := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("got %T when handling updated ClusterWorkspaceShard", obj))
+		return
+	}
+	if oldShard.Spec.BaseURL == shard.Spec.BaseURL && oldShard.Spec.ExternalURL == shard.Spec.ExternalURL {
+		return
+	}
+	klog.Infof("Handling changed URLs of shard %q", shard.Name)
+	workspaces, err := c.workspaceIndexer.ByIndex(currentShardIndex, shard.Name)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, workspace := range workspaces {
+		key, err := cache.MetaNamespaceKeyFunc(workspace)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		klog.Infof("Queuing workspace %q of moved shard", key)
+		c.queue.Add(key)
+	}
+}
+
 func (c *Controller) enqueueDeletedShard(obj interface{}) {
 	shard, ok := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
 	if !ok {
@@ -285,52 +343,58 @@ func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 				klog.Infof("De-scheduling workspace %s|%s from nonexistent shard %q", tenancyv1alpha1.RootCluster, workspace.Name, current)
 				workspace.Status.Location.Current = ""
 				workspace.Status.BaseURL = ""
+				workspace.Status.InternalBaseURL = ""
 			} else if err != nil {
 				return err
 			} else if valid, _, _ := isValidShard(shard); !valid {
 				klog.Infof("De-scheduling workspace %s|%s from invalid shard %q", tenancyv1alpha1.RootCluster, workspace.Name, current)
 				workspace.Status.Location.Current = ""
 				workspace.Status.BaseURL = ""
+				workspace.Status.InternalBaseURL = ""
 			}
 		}
 
 		if workspace.Status.Location.Current == "" {
-			// find a shard for this workspace, randomly
+			// find a shard for this workspace through the scheduler plugins
 			shards, err := c.rootWorkspaceShardLister.List(labels.Everything())
 			if err != nil {
 				return err
 			}
 
 			validShards := make([]*tenancyv1alpha1.ClusterWorkspaceShard, 0, len(shards))
-			invalidShards := map[string]struct {
-				reason, message string
-			}{}
+			invalidShards := map[string]*workspacescheduler.Status{}
 			for _, shard := range shards {
 				if valid, reason, message := isValidShard(shard); valid {
 					validShards = append(validShards, shard)
 				} else {
-					invalidShards[shard.Name] = struct {
-						reason, message string
-					}{
-						reason:  reason,
-						message: message,
-					}
+					invalidShards[shard.Name] = &workspacescheduler.Status{Reason: reason, Message: message}
 				}
 			}
 
-			if len(validShards) > 0 {
-				targetShard := validShards[rand.Intn(len(validShards))]
+			targetShard, unschedulableShards, err := c.scheduler.Schedule(ctx, workspace, validShards)
+			if err != nil {
+				return err
+			}
+			for name, status := range unschedulableShards {
+				invalidShards[name] = status
+			}
+
+			if targetShard != nil {
 
-				u, err := url.Parse(targetShard.Spec.ExternalURL)
+				baseURL, err := workspaceURL(workspace, tenancyhelper.ShardURL(targetShard.Spec, tenancyhelper.ExternalAudience))
 				if err != nil {
 					// shouldn't happen since we just checked in isValidShard
 					conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonReasonUnknown, conditionsv1alpha1.ConditionSeverityError, "Invalid connection information on target ClusterWorkspaceShard: %v.", err)
 					return err // requeue
 				}
-				logicalCluster := logicalcluster.From(workspace)
-				u.Path = path.Join(u.Path, logicalCluster.Join(workspace.Name).Path())
+				internalBaseURL, err := workspaceURL(workspace, tenancyhelper.ShardURL(targetShard.Spec, tenancyhelper.InternalAudience))
+				if err != nil {
+					conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonReasonUnknown, conditionsv1alpha1.ConditionSeverityError, "Invalid connection information on target ClusterWorkspaceShard: %v.", err)
+					return err // requeue
+				}
 
-				workspace.Status.BaseURL = u.String()
+				workspace.Status.BaseURL = baseURL
+				workspace.Status.InternalBaseURL = internalBaseURL
 				workspace.Status.Location.Current = targetShard.Name
 
 				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
@@ -339,7 +403,7 @@ func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "No available shards to schedule the workspace.")
 				failures := make([]string, 0, len(invalidShards))
 				for name, x := range invalidShards {
-					failures = append(failures, fmt.Sprintf("  %s: reason %q, message %q", name, x.reason, x.message))
+					failures = append(failures, fmt.Sprintf("  %s: reason %q, message %q", name, x.Reason, x.Message))
 				}
 				klog.Infof("No valid shards found for workspace %s|%s, skipped:\n%s", workspace.ClusterName, workspace.Name, strings.Join(failures, "\n"))
 			}
@@ -348,6 +412,9 @@ func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 	case tenancyv1alpha1.ClusterWorkspacePhaseInitializing, tenancyv1alpha1.ClusterWorkspacePhaseReady:
 		// movement can only happen after scheduling
 		if workspace.Status.Location.Target == "" {
+			if err := c.followShardURLs(workspace); err != nil {
+				return err
+			}
 			break
 		}
 
@@ -357,14 +424,26 @@ func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 			break
 		}
 
-		_, err := c.rootWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, target))
+		targetShard, err := c.rootWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, target))
 		if errors.IsNotFound(err) {
-			klog.Infof("Cannot move to nonexistent shard %q", tenancyv1alpha1.RootCluster, workspace.Name, target)
+			klog.Infof("Cannot move workspace %s|%s to nonexistent shard %q", workspace.ClusterName, workspace.Name, target)
+			break
 		} else if err != nil {
 			return err
 		}
 
+		baseURL, err := workspaceURL(workspace, tenancyhelper.ShardURL(targetShard.Spec, tenancyhelper.ExternalAudience))
+		if err != nil {
+			return err
+		}
+		internalBaseURL, err := workspaceURL(workspace, tenancyhelper.ShardURL(targetShard.Spec, tenancyhelper.InternalAudience))
+		if err != nil {
+			return err
+		}
+
 		klog.Infof("Moving workspace %q to %q", workspace.Name, workspace.Status.Location.Target)
+		workspace.Status.BaseURL = baseURL
+		workspace.Status.InternalBaseURL = internalBaseURL
 		workspace.Status.Location.Current = workspace.Status.Location.Target
 		workspace.Status.Location.Target = ""
 	}
@@ -396,6 +475,7 @@ func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 				// reschedule
 				workspace.Status.Location.Current = ""
 				workspace.Status.BaseURL = ""
+				workspace.Status.InternalBaseURL = ""
 				return nil // nolint:nilerr
 			}
 
@@ -410,6 +490,44 @@ func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.C
 	return nil
 }
 
+// followShardURLs updates the base URLs of a workspace after the URLs of its current shard changed.
+func (c *Controller) followShardURLs(workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	if workspace.Status.Location.Current == "" {
+		return nil
+	}
+	shard, err := c.rootWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, workspace.Status.Location.Current))
+	if errors.IsNotFound(err) {
+		return nil // reported by the WorkspaceShardValid condition
+	} else if err != nil {
+		return err
+	}
+
+	baseURL, err := workspaceURL(workspace, tenancyhelper.ShardURL(shard.Spec, tenancyhelper.ExternalAudience))
+	if err != nil {
+		return err
+	}
+	internalBaseURL, err := workspaceURL(workspace, tenancyhelper.ShardURL(shard.Spec, tenancyhelper.InternalAudience))
+	if err != nil {
+		return err
+	}
+	if workspace.Status.BaseURL != baseURL || workspace.Status.InternalBaseURL != internalBaseURL {
+		klog.Infof("Updating base URL of workspace %s|%s from %q to %q of shard %q", workspace.ClusterName, workspace.Name, workspace.Status.BaseURL, baseURL, shard.Name)
+		workspace.Status.BaseURL = baseURL
+		workspace.Status.InternalBaseURL = internalBaseURL
+	}
+	return nil
+}
+
+// workspaceURL returns the URL of the workspace on a shard reachable at shardURL.
+func workspaceURL(workspace *tenancyv1alpha1.ClusterWorkspace, shardURL string) (string, error) {
+	u, err := url.Parse(shardURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, logicalcluster.From(workspace).Join(workspace.Name).Path())
+	return u.String(), nil
+}
+
 func isValidShard(shard *tenancyv1alpha1.ClusterWorkspaceShard) (valid bool, reason, message string) {
 	return true, "", ""
 }
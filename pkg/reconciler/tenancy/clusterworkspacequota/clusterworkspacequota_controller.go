@@ -0,0 +1,243 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clusterworkspacequota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "clusterworkspacequota"
+
+	// usageResyncPeriod is how often the usage of all quotas is recalculated from scratch. This
+	// releases usage charged by admission for objects whose creation failed afterwards.
+	usageResyncPeriod = 5 * time.Minute
+)
+
+type clusterDiscovery interface {
+	WithCluster(name logicalcluster.LogicalCluster) discovery.DiscoveryInterface
+}
+
+// NewController returns a controller that calculates the aggregated usage of the workspace subtree
+// of every ClusterWorkspaceQuota.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	metadataClusterClient metadata.ClusterInterface,
+	clusterDiscoveryClient clusterDiscovery,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+	quotaInformer tenancyinformer.ClusterWorkspaceQuotaInformer,
+	pollInterval time.Duration,
+) (*Controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-clusterworkspacequota")
+
+	c := &Controller{
+		queue:            queue,
+		kcpClusterClient: kcpClusterClient,
+		quotaLister:      quotaInformer.Lister(),
+	}
+	c.ddsif = informer.NewMetadataDiscoverySharedInformerFactory(workspaceInformer.Lister(), clusterDiscoveryClient, metadataClusterClient.Cluster(logicalcluster.Wildcard.String()),
+		func(obj interface{}) bool { return true },
+		informer.GVREventHandlerFuncs{
+			AddFunc:    func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueQuotasFor(gvr, obj) },
+			DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueQuotasFor(gvr, obj) },
+		}, pollInterval)
+	c.countObjects = c.countObjectsInInformers
+
+	quotaInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	}))
+
+	return c, nil
+}
+
+// Controller keeps status.used of ClusterWorkspaceQuotas up-to-date with the number of objects in
+// the workspace subtree, for every object count in spec.hard.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.ClusterInterface
+	quotaLister      tenancylister.ClusterWorkspaceQuotaLister
+	ddsif            informer.DynamicDiscoverySharedInformerFactory
+
+	// countObjects returns the number of objects of the given resource in the workspace subtree of root.
+	countObjects func(gr schema.GroupResource, root logicalcluster.LogicalCluster) (int64, error)
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueQuotasFor enqueues the quotas of all ancestor workspaces of the object that count its resource.
+func (c *Controller) enqueueQuotasFor(gvr schema.GroupVersionResource, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName := logicalcluster.From(metaObj)
+
+	quotas, err := c.quotaLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, quota := range quotas {
+		if !helper.IsInWorkspaceSubtree(logicalcluster.From(quota), clusterName) {
+			continue
+		}
+		for name := range quota.Spec.Hard {
+			if gr, ok := helper.ObjectCountGroupResource(name); ok && gr == gvr.GroupResource() {
+				c.enqueue(quota)
+				break
+			}
+		}
+	}
+}
+
+func (c *Controller) enqueueAll() {
+	quotas, err := c.quotaLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, quota := range quotas {
+		c.enqueue(quota)
+	}
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting ClusterWorkspaceQuota controller")
+	defer klog.Info("Shutting down ClusterWorkspaceQuota controller")
+
+	c.ddsif.Start(ctx)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+	go wait.Until(c.enqueueAll, usageResyncPeriod, ctx.Done())
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, err := c.quotaLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	previous := obj
+	obj = obj.DeepCopy()
+
+	if err := c.reconcile(obj); err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(previous.Status, obj.Status) {
+		return nil
+	}
+
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspaceQuota{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspaceQuota %s|%s: %w", clusterName, obj.Name, err)
+	}
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspaceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions, racing with admission charging usage
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspaceQuota %s|%s: %w", clusterName, obj.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspaceQuota %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().ClusterWorkspaceQuotas().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
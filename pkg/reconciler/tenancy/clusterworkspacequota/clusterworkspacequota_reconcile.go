@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clusterworkspacequota
+
+import (
+	"fmt"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+)
+
+func (c *Controller) reconcile(quota *tenancyv1alpha1.ClusterWorkspaceQuota) error {
+	root := logicalcluster.From(quota)
+
+	used := corev1.ResourceList{}
+	for name := range quota.Spec.Hard {
+		gr, ok := helper.ObjectCountGroupResource(name)
+		if !ok {
+			// only object counts are aggregated across workspaces
+			continue
+		}
+		count, err := c.countObjects(gr, root)
+		if err != nil {
+			return err
+		}
+		used[name] = *resource.NewQuantity(count, resource.DecimalSI)
+	}
+
+	quota.Status.Hard = quota.Spec.Hard.DeepCopy()
+	quota.Status.Used = used
+	return nil
+}
+
+// countObjectsInInformers counts the objects of the given resource in the workspace subtree of root,
+// using the first discovered version of the resource. Other versions serve the same objects.
+func (c *Controller) countObjectsInInformers(gr schema.GroupResource, root logicalcluster.LogicalCluster) (int64, error) {
+	listers, notSynced := c.ddsif.Listers()
+	for _, gvr := range notSynced {
+		if gvr.GroupResource() == gr {
+			return 0, fmt.Errorf("informer for %s is not synced yet", gvr)
+		}
+	}
+
+	for gvr, lister := range listers {
+		if gvr.GroupResource() != gr {
+			continue
+		}
+		objs, err := lister.List(labels.Everything())
+		if err != nil {
+			return 0, err
+		}
+		var count int64
+		for _, obj := range objs {
+			metaObj, err := meta.Accessor(obj)
+			if err != nil {
+				return 0, err
+			}
+			if helper.IsInWorkspaceSubtree(root, logicalcluster.From(metaObj)) {
+				count++
+			}
+		}
+		return count, nil
+	}
+
+	// not served in any workspace
+	return 0, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clusterworkspacequota
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	tests := map[string]struct {
+		hard     corev1.ResourceList
+		counts   map[schema.GroupResource]int64
+		wantUsed corev1.ResourceList
+	}{
+		"no limits": {
+			wantUsed: corev1.ResourceList{},
+		},
+		"object counts": {
+			hard: corev1.ResourceList{
+				"count/configmaps":       resource.MustParse("10"),
+				"count/deployments.apps": resource.MustParse("5"),
+			},
+			counts: map[schema.GroupResource]int64{
+				{Resource: "configmaps"}:                 7,
+				{Group: "apps", Resource: "deployments"}: 0,
+			},
+			wantUsed: corev1.ResourceList{
+				"count/configmaps":       resource.MustParse("7"),
+				"count/deployments.apps": resource.MustParse("0"),
+			},
+		},
+		"usage above the limit is reported": {
+			hard:     corev1.ResourceList{"count/configmaps": resource.MustParse("1")},
+			counts:   map[schema.GroupResource]int64{{Resource: "configmaps"}: 3},
+			wantUsed: corev1.ResourceList{"count/configmaps": resource.MustParse("3")},
+		},
+		"compute resources are not aggregated": {
+			hard:     corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			wantUsed: corev1.ResourceList{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{
+				countObjects: func(gr schema.GroupResource, root logicalcluster.LogicalCluster) (int64, error) {
+					require.Equal(t, "root:org", root.String())
+					count, found := tc.counts[gr]
+					require.True(t, found, "unexpected count of %s", gr)
+					return count, nil
+				},
+			}
+			quota := &tenancyv1alpha1.ClusterWorkspaceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "quota", ClusterName: "root:org"},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceQuotaSpec{Hard: tc.hard},
+			}
+
+			err := c.reconcile(quota)
+			require.NoError(t, err)
+			require.Equal(t, tc.hard, quota.Status.Hard)
+			require.Equal(t, len(tc.wantUsed), len(quota.Status.Used))
+			for name, want := range tc.wantUsed {
+				got := quota.Status.Used[name]
+				require.Zero(t, want.Cmp(got), "%s: want %s, got %s", name, want.String(), got.String())
+			}
+		})
+	}
+}
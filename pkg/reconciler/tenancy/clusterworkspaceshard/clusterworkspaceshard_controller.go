@@ -18,172 +18,174 @@ package clusterworkspaceshard
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
-	jsonpatch "github.com/evanphx/json-patch"
-
-	"k8s.io/apimachinery/pkg/api/equality"
-	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/util/workqueue"
-	"k8s.io/klog/v2"
+	componentbaseversion "k8s.io/component-base/version"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
-	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/framework"
 )
 
 const (
-	controllerName = "clusterworkspaceshard"
+	controllerName = "kcp-workspaceshard"
+
+	// ownShardResyncPeriod is how often the serving CA bundle and the inventory of the own
+	// shard are compared with the published ones, in order to pick up certificate rotation
+	// and changed workspaces and APIs.
+	ownShardResyncPeriod = time.Minute
 )
 
+// NewController returns a controller that publishes the serving CA bundle, the version and the inventory
+// of the shard named shardName into its ClusterWorkspaceShard. servingCABundle returns the current PEM
+// encoded CA bundle, workspaceCount the number of logical clusters with objects on the shard, and
+// apiGroups the API groups of the CRDs served by the shard. trustedCABundle returns the PEM encoded
+// CAs the serving certificates of joining shards are verified against.
 func NewController(
 	rootKcpClient kcpclient.Interface,
 	rootWorkspaceShardInformer tenancyinformer.ClusterWorkspaceShardInformer,
+	shardName string,
+	servingCABundle func() ([]byte, error),
+	workspaceCount func() (int, error),
+	apiGroups func() ([]string, error),
+	trustedCABundle func() ([]byte, error),
 ) (*Controller, error) {
-	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-workspaceshard")
-
 	c := &Controller{
-		queue:                     queue,
-		kcpClient:                 rootKcpClient,
-		rootWorkspaceShardIndexer: rootWorkspaceShardInformer.Informer().GetIndexer(),
-		rootWorkspaceShardLister:  rootWorkspaceShardInformer.Lister(),
+		kcpClient:       rootKcpClient,
+		shardName:       shardName,
+		servingCABundle: servingCABundle,
+		workspaceCount:  workspaceCount,
+		apiGroups:       apiGroups,
+		version:         componentbaseversion.Get().GitVersion,
+		verifyShardIdentity: func(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) error {
+			caBundle, err := trustedCABundle()
+			if err != nil {
+				return err
+			}
+			return verifyShardIdentity(ctx, workspaceShard, caBundle)
+		},
 	}
-
-	rootWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
-		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
-	})
+	c.controller = framework.New(
+		controllerName,
+		rootWorkspaceShardInformer.Informer().GetIndexer(),
+		framework.ReconcilerFunc(func(ctx context.Context, obj runtime.Object) (time.Duration, error) {
+			return c.reconcile(ctx, obj.(*tenancyv1alpha1.ClusterWorkspaceShard))
+		}),
+		framework.NewStatusCommitter(func(ctx context.Context, obj runtime.Object, patch []byte) error {
+			workspaceShard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
+			_, err := c.kcpClient.TenancyV1alpha1().ClusterWorkspaceShards().Patch(ctx, workspaceShard.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+			return err
+		}),
+	)
+
+	rootWorkspaceShardInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.controller.Enqueue,
+		UpdateFunc: func(_, obj interface{}) { c.controller.Enqueue(obj) },
+	}))
 
 	return c, nil
 }
 
 // Controller watches WorkspaceShards and Secrets in order to make sure every ClusterWorkspaceShard
-// has its URL exposed when a valid kubeconfig is connected to it.
+// has its URL exposed when a valid kubeconfig is connected to it. For the shard it runs on, it keeps
+// status.servingCABundle in sync with the serving CA of the shard and publishes status.version,
+// status.workspaceCount and status.apiGroups. Shards joining are verified and get a fencing token issued.
 type Controller struct {
-	queue workqueue.RateLimitingInterface
+	controller *framework.Controller
 
 	kcpClient kcpclient.Interface
 
-	rootWorkspaceShardIndexer cache.Indexer
-	rootWorkspaceShardLister  tenancylister.ClusterWorkspaceShardLister
-}
+	shardName       string
+	servingCABundle func() ([]byte, error)
+	workspaceCount  func() (int, error)
+	apiGroups       func() ([]string, error)
+	version         string
 
-func (c *Controller) enqueue(obj interface{}) {
-	key, err := cache.MetaNamespaceKeyFunc(obj)
-	if err != nil {
-		runtime.HandleError(err)
-		return
-	}
-	klog.Infof("queueing workspace shard %q", key)
-	c.queue.Add(key)
+	verifyShardIdentity func(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) error
 }
 
 func (c *Controller) Start(ctx context.Context, numThreads int) {
-	defer runtime.HandleCrash()
-	defer c.queue.ShutDown()
-
-	klog.Info("Starting ClusterWorkspaceShard controller")
-	defer klog.Info("Shutting down ClusterWorkspaceShard controller")
-
-	for i := 0; i < numThreads; i++ {
-		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
-	}
-
-	<-ctx.Done()
+	c.controller.Start(ctx, numThreads)
 }
 
-func (c *Controller) startWorker(ctx context.Context) {
-	for c.processNextWorkItem(ctx) {
+func (c *Controller) reconcile(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) (time.Duration, error) {
+	if err := c.reconcileServingCABundle(workspaceShard); err != nil {
+		return 0, err
 	}
-}
-
-func (c *Controller) processNextWorkItem(ctx context.Context) bool {
-	// Wait until there is a new item in the working queue
-	k, quit := c.queue.Get()
-	if quit {
-		return false
+	c.reconcileVersion(workspaceShard)
+	if err := c.reconcileInventory(workspaceShard); err != nil {
+		return 0, err
+	}
+	requeueAfter, err := c.reconcileHandshake(ctx, workspaceShard)
+	if err != nil {
+		return 0, err
 	}
-	key := k.(string)
-
-	klog.Infof("processing key %q", key)
-
-	// No matter what, tell the queue we're done with this key, to unblock
-	// other workers.
-	defer c.queue.Done(key)
 
-	if err := c.process(ctx, key); err != nil {
-		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
-		c.queue.AddRateLimited(key)
-		return true
+	// serving certificates rotate and the inventory changes without the ClusterWorkspaceShard changing
+	if workspaceShard.Name == c.shardName && (requeueAfter == 0 || ownShardResyncPeriod < requeueAfter) {
+		requeueAfter = ownShardResyncPeriod
 	}
-	c.queue.Forget(key)
-	return true
+	return requeueAfter, nil
 }
 
-func (c *Controller) process(ctx context.Context, key string) error {
-	namespace, name, err := cache.SplitMetaNamespaceKey(key)
-	if err != nil {
-		klog.Errorf("invalid key: %q: %v", key, err)
+func (c *Controller) reconcileServingCABundle(workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) error {
+	// every shard only publishes its own CA bundle
+	if workspaceShard.Name != c.shardName || c.servingCABundle == nil {
 		return nil
 	}
-	if namespace != "" {
-		klog.Errorf("namespace %q found in key for cluster-wide ClusterWorkspaceShard object", namespace)
+
+	caBundle, err := c.servingCABundle()
+	if err != nil {
+		return fmt.Errorf("failed to get serving CA bundle of shard %q: %w", c.shardName, err)
+	}
+	if len(caBundle) == 0 {
 		return nil
 	}
+	workspaceShard.Status.ServingCABundle = caBundle
 
-	obj, err := c.rootWorkspaceShardLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
-	if err != nil {
-		if errors.IsNotFound(err) {
-			return nil // object deleted before we handled it
-		}
-		return err
+	return nil
+}
+
+func (c *Controller) reconcileVersion(workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) {
+	// every shard only publishes its own version
+	if workspaceShard.Name != c.shardName || c.version == "" {
+		return
 	}
-	previous := obj
-	obj = obj.DeepCopy()
+	workspaceShard.Status.Version = c.version
+}
 
-	if err := c.reconcile(ctx, obj); err != nil {
-		return err
+func (c *Controller) reconcileInventory(workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) error {
+	// every shard only publishes its own inventory
+	if workspaceShard.Name != c.shardName {
+		return nil
 	}
 
-	// If the object being reconciled changed as a result, update it.
-	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
-		oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspaceShard{
-			Status: previous.Status,
-		})
+	if c.workspaceCount != nil {
+		count, err := c.workspaceCount()
 		if err != nil {
-			return fmt.Errorf("failed to Marshal old data for workspace shard %s|%s/%s: %w", tenancyv1alpha1.RootCluster, namespace, name, err)
+			return fmt.Errorf("failed to count the workspaces of shard %q: %w", c.shardName, err)
 		}
-
-		newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspaceShard{
-			ObjectMeta: metav1.ObjectMeta{
-				UID:             previous.UID,
-				ResourceVersion: previous.ResourceVersion,
-			}, // to ensure they appear in the patch as preconditions
-			Status: obj.Status,
-		})
+		n := int32(count)
+		workspaceShard.Status.WorkspaceCount = &n
+	}
+	if c.apiGroups != nil {
+		groups, err := c.apiGroups()
 		if err != nil {
-			return fmt.Errorf("failed to Marshal new data for workspace shard %s|%s/%s: %w", tenancyv1alpha1.RootCluster, namespace, name, err)
+			return fmt.Errorf("failed to get the API groups of shard %q: %w", c.shardName, err)
 		}
-
-		patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
-		if err != nil {
-			return fmt.Errorf("failed to create patch for workspace shard %s|%s/%s: %w", tenancyv1alpha1.RootCluster, namespace, name, err)
+		workspaceShard.Status.APIGroups = nil
+		if len(groups) > 0 {
+			workspaceShard.Status.APIGroups = sets.NewString(groups...).List()
 		}
-		_, uerr := c.kcpClient.TenancyV1alpha1().ClusterWorkspaceShards().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
-		return uerr
 	}
 
 	return nil
 }
-
-func (c *Controller) reconcile(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) error {
-	return nil
-}
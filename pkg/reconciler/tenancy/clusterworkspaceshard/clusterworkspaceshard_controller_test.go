@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clusterworkspaceshard
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReconcileServingCABundle(t *testing.T) {
+	tests := map[string]struct {
+		shardName       string
+		current         []byte
+		servingCABundle func() ([]byte, error)
+		want            []byte
+		wantErr         bool
+	}{
+		"own shard gets the CA bundle": {
+			shardName:       "root",
+			servingCABundle: func() ([]byte, error) { return []byte("new-ca"), nil },
+			want:            []byte("new-ca"),
+		},
+		"own shard gets a rotated CA bundle": {
+			shardName:       "root",
+			current:         []byte("old-ca"),
+			servingCABundle: func() ([]byte, error) { return []byte("new-ca"), nil },
+			want:            []byte("new-ca"),
+		},
+		"other shards are left alone": {
+			shardName:       "other",
+			current:         []byte("other-ca"),
+			servingCABundle: func() ([]byte, error) { return []byte("new-ca"), nil },
+			want:            []byte("other-ca"),
+		},
+		"empty CA bundle does not clear the published one": {
+			shardName:       "root",
+			current:         []byte("old-ca"),
+			servingCABundle: func() ([]byte, error) { return nil, nil },
+			want:            []byte("old-ca"),
+		},
+		"error getting the CA bundle": {
+			shardName:       "root",
+			current:         []byte("old-ca"),
+			servingCABundle: func() ([]byte, error) { return nil, errors.New("no such file") },
+			want:            []byte("old-ca"),
+			wantErr:         true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{
+				shardName:       "root",
+				servingCABundle: tc.servingCABundle,
+			}
+			shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.shardName},
+				Status:     tenancyv1alpha1.ClusterWorkspaceShardStatus{ServingCABundle: tc.current},
+			}
+			err := c.reconcileServingCABundle(shard)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.want, shard.Status.ServingCABundle)
+		})
+	}
+}
+
+func TestReconcileVersion(t *testing.T) {
+	tests := map[string]struct {
+		shardName string
+		current   string
+		want      string
+	}{
+		"own shard publishes its version": {
+			shardName: "root",
+			current:   "v0.4.0",
+			want:      "v0.5.0",
+		},
+		"other shards are left alone": {
+			shardName: "other",
+			current:   "v0.4.0",
+			want:      "v0.4.0",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{
+				shardName: "root",
+				version:   "v0.5.0",
+			}
+			shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.shardName},
+				Status:     tenancyv1alpha1.ClusterWorkspaceShardStatus{Version: tc.current},
+			}
+			c.reconcileVersion(shard)
+			require.Equal(t, tc.want, shard.Status.Version)
+		})
+	}
+}
+
+func TestReconcileInventory(t *testing.T) {
+	three := int32(3)
+	tests := map[string]struct {
+		shardName      string
+		current        tenancyv1alpha1.ClusterWorkspaceShardStatus
+		workspaceCount func() (int, error)
+		apiGroups      func() ([]string, error)
+		want           tenancyv1alpha1.ClusterWorkspaceShardStatus
+		wantErr        bool
+	}{
+		"own shard publishes its inventory": {
+			shardName:      "root",
+			workspaceCount: func() (int, error) { return 3, nil },
+			apiGroups:      func() ([]string, error) { return []string{"wildwest.dev", "apps.example.com", "wildwest.dev"}, nil },
+			want: tenancyv1alpha1.ClusterWorkspaceShardStatus{
+				WorkspaceCount: &three,
+				APIGroups:      []string{"apps.example.com", "wildwest.dev"},
+			},
+		},
+		"removed API groups are dropped": {
+			shardName:      "root",
+			current:        tenancyv1alpha1.ClusterWorkspaceShardStatus{WorkspaceCount: &three, APIGroups: []string{"wildwest.dev"}},
+			workspaceCount: func() (int, error) { return 3, nil },
+			apiGroups:      func() ([]string, error) { return nil, nil },
+			want:           tenancyv1alpha1.ClusterWorkspaceShardStatus{WorkspaceCount: &three},
+		},
+		"other shards are left alone": {
+			shardName:      "other",
+			current:        tenancyv1alpha1.ClusterWorkspaceShardStatus{APIGroups: []string{"wildwest.dev"}},
+			workspaceCount: func() (int, error) { return 3, nil },
+			apiGroups:      func() ([]string, error) { return nil, nil },
+			want:           tenancyv1alpha1.ClusterWorkspaceShardStatus{APIGroups: []string{"wildwest.dev"}},
+		},
+		"error counting the workspaces": {
+			shardName:      "root",
+			current:        tenancyv1alpha1.ClusterWorkspaceShardStatus{WorkspaceCount: &three},
+			workspaceCount: func() (int, error) { return 0, errors.New("informer not synced") },
+			apiGroups:      func() ([]string, error) { return nil, nil },
+			want:           tenancyv1alpha1.ClusterWorkspaceShardStatus{WorkspaceCount: &three},
+			wantErr:        true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Controller{
+				shardName:      "root",
+				workspaceCount: tc.workspaceCount,
+				apiGroups:      tc.apiGroups,
+			}
+			shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.shardName},
+				Status:     tc.current,
+			}
+			err := c.reconcileInventory(shard)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.want, shard.Status)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceshard
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+const (
+	// handshakeRetryPeriod is how long to wait before verifying a shard again whose identity
+	// could not be verified.
+	handshakeRetryPeriod = 30 * time.Second
+
+	handshakeDialTimeout = 10 * time.Second
+)
+
+// reconcileHandshake verifies the identity of a joining shard and issues a fencing token for it.
+// A new token is issued whenever the spec of the shard changes, which invalidates the old one. A shard
+// whose identity cannot be verified is verified again after handshakeRetryPeriod.
+func (c *Controller) reconcileHandshake(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) (time.Duration, error) {
+	if tenancyhelper.IsFencingTokenCurrent(workspaceShard) {
+		return 0, nil
+	}
+
+	if err := c.verifyShardIdentity(ctx, workspaceShard); err != nil {
+		klog.Infof("Failed to verify identity of ClusterWorkspaceShard %s: %v", workspaceShard.Name, err)
+		workspaceShard.Status.FencingToken = ""
+		conditions.MarkFalse(workspaceShard, tenancyv1alpha1.WorkspaceShardJoined, tenancyv1alpha1.WorkspaceShardJoinedReasonIdentityNotVerified, conditionsv1alpha1.ConditionSeverityError, "Failed to verify shard identity: %v.", err)
+		return handshakeRetryPeriod, nil
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return 0, fmt.Errorf("failed to generate fencing token: %w", err)
+	}
+	workspaceShard.Status.FencingToken = tenancyhelper.NewFencingToken(workspaceShard.Generation, hex.EncodeToString(random))
+	conditions.MarkTrue(workspaceShard, tenancyv1alpha1.WorkspaceShardJoined)
+
+	return 0, nil
+}
+
+// verifyShardIdentity verifies that the shard serves a certificate at its base URL that is valid for
+// the host name of the base URL and signed by one of the trusted CAs of the root shard. The serving CA
+// bundle the shard published itself is not trusted, as the shard writes it.
+func verifyShardIdentity(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard, trustedCABundle []byte) error {
+	u, err := url.Parse(workspaceShard.Spec.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL %q: %w", workspaceShard.Spec.BaseURL, err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("base URL %q is not https", workspaceShard.Spec.BaseURL)
+	}
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "443")
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(trustedCABundle) {
+		return errors.New("trusted CA bundle contains no valid certificate")
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: handshakeDialTimeout},
+		Config: &tls.Config{
+			RootCAs:    roots,
+			ServerName: u.Hostname(),
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to verify serving certificate at %q: %w", workspaceShard.Spec.BaseURL, err)
+	}
+	return conn.Close()
+}
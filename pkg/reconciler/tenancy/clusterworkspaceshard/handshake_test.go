@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package clusterworkspaceshard
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certutil "k8s.io/client-go/util/cert"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcileHandshake(t *testing.T) {
+	tests := map[string]struct {
+		generation int64
+		token      string
+		verifyErr  error
+		wantJoined bool
+		wantVerify bool
+		wantToken  bool
+		wantRetry  bool
+	}{
+		"new shard gets verified and a token issued": {
+			generation: 1,
+			wantJoined: true,
+			wantVerify: true,
+			wantToken:  true,
+		},
+		"current token is kept without verification": {
+			generation: 1,
+			token:      "1-abc",
+			wantToken:  true,
+		},
+		"spec change reissues the token": {
+			generation: 2,
+			token:      "1-abc",
+			wantJoined: true,
+			wantVerify: true,
+			wantToken:  true,
+		},
+		"failed verification fences the shard": {
+			generation: 2,
+			token:      "1-abc",
+			verifyErr:  errors.New("x509: certificate signed by unknown authority"),
+			wantVerify: true,
+			wantRetry:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			verified := false
+			c := &Controller{
+				verifyShardIdentity: func(ctx context.Context, workspaceShard *tenancyv1alpha1.ClusterWorkspaceShard) error {
+					verified = true
+					return tc.verifyErr
+				},
+			}
+
+			shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{Name: "shard-1", ClusterName: "root", Generation: tc.generation},
+				Status:     tenancyv1alpha1.ClusterWorkspaceShardStatus{FencingToken: tc.token},
+			}
+			requeueAfter, err := c.reconcileHandshake(context.Background(), shard)
+			require.NoError(t, err)
+			if tc.wantRetry {
+				require.Equal(t, handshakeRetryPeriod, requeueAfter)
+			} else {
+				require.Zero(t, requeueAfter)
+			}
+
+			require.Equal(t, tc.wantVerify, verified)
+			require.Equal(t, tc.wantToken, shard.Status.FencingToken != "")
+			if tc.wantToken {
+				require.True(t, tenancyhelper.IsFencingTokenCurrent(shard))
+			}
+			if tc.wantVerify {
+				require.Equal(t, tc.wantJoined, conditions.IsTrue(shard, tenancyv1alpha1.WorkspaceShardJoined))
+			}
+			if tc.token != "" && tc.wantJoined {
+				require.NotEqual(t, tc.token, shard.Status.FencingToken)
+			}
+		})
+	}
+}
+
+func TestVerifyShardIdentity(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	otherCABundle, _, err := certutil.GenerateSelfSignedCertKey("127.0.0.1", nil, nil)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		baseURL           string
+		caBundle          []byte
+		publishedCABundle []byte
+		wantErr           string
+	}{
+		"valid serving certificate": {
+			baseURL:  server.URL,
+			caBundle: caBundle,
+		},
+		"no trusted CA bundle": {
+			baseURL: server.URL,
+			wantErr: "contains no valid certificate",
+		},
+		"invalid CA bundle": {
+			baseURL:  server.URL,
+			caBundle: []byte("not a certificate"),
+			wantErr:  "contains no valid certificate",
+		},
+		"CA published by the shard is not trusted": {
+			baseURL:           server.URL,
+			caBundle:          otherCABundle,
+			publishedCABundle: caBundle,
+			wantErr:           "failed to verify serving certificate",
+		},
+		"plain http": {
+			baseURL:  strings.Replace(server.URL, "https://", "http://", 1),
+			caBundle: caBundle,
+			wantErr:  "is not https",
+		},
+		"certificate signed by another CA": {
+			baseURL:  server.URL,
+			caBundle: otherCABundle,
+			wantErr:  "failed to verify serving certificate",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{Name: "shard-1"},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: tc.baseURL},
+				Status:     tenancyv1alpha1.ClusterWorkspaceShardStatus{ServingCABundle: tc.publishedCABundle},
+			}
+			err := verifyShardIdentity(context.Background(), shard, tc.caBundle)
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
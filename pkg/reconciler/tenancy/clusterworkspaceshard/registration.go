@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceshard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/sharding"
+)
+
+const (
+	registrarName = "clusterworkspaceshard-registrar"
+
+	// registrationResyncPeriod is how often the registration of the own shard is compared with
+	// the desired one, in order to undo changes by others and to pick up changed configuration.
+	registrationResyncPeriod = time.Minute
+
+	// fencingTokenPollPeriod is how often the registration is read while the root shard has not
+	// issued a fencing token for the current spec yet.
+	fencingTokenPollPeriod = 5 * time.Second
+)
+
+// NewRegistrar returns a Registrar that registers the shard named shardName as ClusterWorkspaceShard
+// in the root shard. desiredSpec returns the spec the shard should be registered with. The fencing
+// token the root shard issues for the registration is stored in fencingToken.
+func NewRegistrar(
+	rootKcpClient kcpclient.Interface,
+	shardName string,
+	desiredSpec func() (tenancyv1alpha1.ClusterWorkspaceShardSpec, error),
+	fencingToken *sharding.FencingToken,
+) *Registrar {
+	return &Registrar{
+		kcpClient:    rootKcpClient,
+		shardName:    shardName,
+		desiredSpec:  desiredSpec,
+		fencingToken: fencingToken,
+	}
+}
+
+// Registrar creates the ClusterWorkspaceShard of a non-root shard in the root shard, and keeps its
+// spec up-to-date with the configuration of the shard. This replaces registering the shard manually.
+type Registrar struct {
+	kcpClient kcpclient.Interface
+
+	shardName    string
+	desiredSpec  func() (tenancyv1alpha1.ClusterWorkspaceShardSpec, error)
+	fencingToken *sharding.FencingToken
+}
+
+func (r *Registrar) Start(ctx context.Context) {
+	defer runtime.HandleCrash()
+
+	klog.Infof("Starting ClusterWorkspaceShard registrar for shard %q", r.shardName)
+	defer klog.Infof("Shutting down ClusterWorkspaceShard registrar for shard %q", r.shardName)
+
+	for {
+		joined, err := r.register(ctx)
+		if err != nil {
+			runtime.HandleError(fmt.Errorf("%q failed to register shard %q, err: %w", registrarName, r.shardName, err))
+		}
+		period := registrationResyncPeriod
+		if !joined {
+			period = fencingTokenPollPeriod
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(period):
+		}
+	}
+}
+
+// register creates or updates the registration of the shard, and stores its fencing token. It
+// returns whether the fencing token is current, i.e. the shard has joined with its current spec.
+func (r *Registrar) register(ctx context.Context) (bool, error) {
+	spec, err := r.desiredSpec()
+	if err != nil {
+		return false, err
+	}
+
+	existing, err := r.kcpClient.TenancyV1alpha1().ClusterWorkspaceShards().Get(ctx, r.shardName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+			ObjectMeta: metav1.ObjectMeta{Name: r.shardName},
+			Spec:       spec,
+		}
+		if _, err := r.kcpClient.TenancyV1alpha1().ClusterWorkspaceShards().Create(ctx, shard, metav1.CreateOptions{}); err != nil {
+			return false, err
+		}
+		klog.Infof("Registered ClusterWorkspaceShard %q in the root shard", r.shardName)
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	r.fencingToken.Set(existing.Status.FencingToken)
+
+	updated, changed := updatedRegistration(existing, spec)
+	if !changed {
+		return tenancyhelper.IsFencingTokenCurrent(existing), nil
+	}
+	if _, err := r.kcpClient.TenancyV1alpha1().ClusterWorkspaceShards().Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return false, err
+	}
+	klog.Infof("Updated registration of ClusterWorkspaceShard %q in the root shard", r.shardName)
+	// the root shard issues a new fencing token for the changed spec
+	return false, nil
+}
+
+// updatedRegistration returns a copy of the shard with the URLs of the desired spec, and whether
+// any of them changed. An empty desired URL leaves the registered one alone, such that defaults
+// applied on registration are not fought over.
+func updatedRegistration(shard *tenancyv1alpha1.ClusterWorkspaceShard, desired tenancyv1alpha1.ClusterWorkspaceShardSpec) (*tenancyv1alpha1.ClusterWorkspaceShard, bool) {
+	updated := shard.DeepCopy()
+	if desired.BaseURL != "" {
+		updated.Spec.BaseURL = desired.BaseURL
+	}
+	if desired.ExternalURL != "" {
+		updated.Spec.ExternalURL = desired.ExternalURL
+	}
+	if desired.VirtualWorkspaceURL != "" {
+		updated.Spec.VirtualWorkspaceURL = desired.VirtualWorkspaceURL
+	}
+	return updated, updated.Spec != shard.Spec
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceshard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	"github.com/kcp-dev/kcp/pkg/sharding"
+)
+
+func TestUpdatedRegistration(t *testing.T) {
+	tests := map[string]struct {
+		current     tenancyv1alpha1.ClusterWorkspaceShardSpec
+		desired     tenancyv1alpha1.ClusterWorkspaceShardSpec
+		want        tenancyv1alpha1.ClusterWorkspaceShardSpec
+		wantChanged bool
+	}{
+		"unchanged": {
+			current: tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://kcp.example.com"},
+			desired: tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://kcp.example.com"},
+			want:    tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://kcp.example.com"},
+		},
+		"changed base URL": {
+			current:     tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://kcp.example.com"},
+			desired:     tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:7443", ExternalURL: "https://kcp.example.com"},
+			want:        tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:7443", ExternalURL: "https://kcp.example.com"},
+			wantChanged: true,
+		},
+		"new virtual workspace URL": {
+			current:     tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://kcp.example.com"},
+			desired:     tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://kcp.example.com", VirtualWorkspaceURL: "https://vw-1:6443"},
+			want:        tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://kcp.example.com", VirtualWorkspaceURL: "https://vw-1:6443"},
+			wantChanged: true,
+		},
+		"empty desired URLs keep the registered ones": {
+			current: tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://kcp.example.com", VirtualWorkspaceURL: "https://vw-1:6443"},
+			desired: tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443"},
+			want:    tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://kcp.example.com", VirtualWorkspaceURL: "https://vw-1:6443"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+				ObjectMeta: metav1.ObjectMeta{Name: "shard-1"},
+				Spec:       tc.current,
+			}
+			got, changed := updatedRegistration(shard, tc.desired)
+			require.Equal(t, tc.wantChanged, changed)
+			require.Equal(t, tc.want, got.Spec)
+			require.Equal(t, tc.current, shard.Spec, "input must not be mutated")
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	spec := tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443", ExternalURL: "https://shard-1:6443"}
+
+	client := kcpfake.NewSimpleClientset()
+	fencingToken := &sharding.FencingToken{}
+	r := NewRegistrar(client, "shard-1", func() (tenancyv1alpha1.ClusterWorkspaceShardSpec, error) { return spec, nil }, fencingToken)
+
+	joined, err := r.register(ctx)
+	require.NoError(t, err)
+	require.False(t, joined, "expected no fencing token before the handshake")
+	shard, err := client.TenancyV1alpha1().ClusterWorkspaceShards().Get(ctx, "shard-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, spec, shard.Spec)
+
+	// the root shard completes the handshake
+	shard.Status.FencingToken = tenancyhelper.NewFencingToken(shard.Generation, "abc")
+	_, err = client.TenancyV1alpha1().ClusterWorkspaceShards().UpdateStatus(ctx, shard, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	joined, err = r.register(ctx)
+	require.NoError(t, err)
+	require.True(t, joined)
+	require.Equal(t, shard.Status.FencingToken, fencingToken.Get())
+
+	spec.ExternalURL = "https://kcp.example.com"
+	joined, err = r.register(ctx)
+	require.NoError(t, err)
+	require.False(t, joined, "expected a new fencing token for the changed spec")
+	shard, err = client.TenancyV1alpha1().ClusterWorkspaceShards().Get(ctx, "shard-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, spec, shard.Spec)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultapibindings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/reconciler/framework"
+)
+
+const (
+	controllerName = "kcp-clusterworkspace-default-apibindings"
+)
+
+// NewController returns a controller that initializes the ClusterWorkspaces whose type has
+// defaultAPIBindings: it creates the APIBindings in the workspaces, and removes the
+// ClusterWorkspaceAPIBindingsInitializer once they are bound.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+	workspaceTypeInformer tenancyinformer.ClusterWorkspaceTypeInformer,
+	apiBindingInformer apisinformers.APIBindingInformer,
+) *Controller {
+	c := &Controller{
+		workspaceLister:     workspaceInformer.Lister(),
+		workspaceTypeLister: workspaceTypeInformer.Lister(),
+		apiBindingLister:    apiBindingInformer.Lister(),
+		createAPIBinding: func(ctx context.Context, clusterName logicalcluster.LogicalCluster, binding *apisv1alpha1.APIBinding) error {
+			_, err := kcpClusterClient.Cluster(clusterName).ApisV1alpha1().APIBindings().Create(ctx, binding, metav1.CreateOptions{})
+			return err
+		},
+	}
+	c.controller = framework.New(
+		controllerName,
+		workspaceInformer.Informer().GetIndexer(),
+		framework.ReconcilerFunc(func(ctx context.Context, obj runtime.Object) (time.Duration, error) {
+			return c.reconcile(ctx, obj.(*tenancyv1alpha1.ClusterWorkspace))
+		}),
+		framework.NewStatusCommitter(func(ctx context.Context, obj runtime.Object, patch []byte) error {
+			workspace := obj.(*tenancyv1alpha1.ClusterWorkspace)
+			_, err := kcpClusterClient.Cluster(logicalcluster.From(workspace)).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, workspace.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+			return err
+		}),
+	)
+
+	workspaceInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.controller.Enqueue,
+		UpdateFunc: func(_, obj interface{}) { c.controller.Enqueue(obj) },
+	}))
+	apiBindingInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueWorkspaceOfAPIBinding(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspaceOfAPIBinding(obj) },
+	}))
+
+	return c
+}
+
+// Controller binds the defaultAPIBindings of the ClusterWorkspaceTypes in their ClusterWorkspaces.
+type Controller struct {
+	controller *framework.Controller
+
+	workspaceLister     tenancylister.ClusterWorkspaceLister
+	workspaceTypeLister tenancylister.ClusterWorkspaceTypeLister
+	apiBindingLister    apislisters.APIBindingLister
+
+	createAPIBinding func(ctx context.Context, clusterName logicalcluster.LogicalCluster, binding *apisv1alpha1.APIBinding) error
+}
+
+// enqueueWorkspaceOfAPIBinding enqueues the ClusterWorkspace whose logical cluster holds the
+// APIBinding, such that the initializer is removed as soon as the bindings are bound.
+func (c *Controller) enqueueWorkspaceOfAPIBinding(obj interface{}) {
+	binding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+	parent, name := logicalcluster.From(binding).Split()
+	if parent.Empty() {
+		return
+	}
+	workspace, err := c.workspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+	if err != nil {
+		return // not a ClusterWorkspace, or not yet known
+	}
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing {
+		return
+	}
+	c.controller.Enqueue(workspace)
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	c.controller.Start(ctx, numThreads)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultapibindings
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing {
+		return 0, nil
+	}
+	if !hasInitializer(workspace) {
+		return 0, nil
+	}
+
+	// a type deleted in the meantime has nothing to bind anymore
+	var references []apisv1alpha1.ExportReference
+	workspaceType, err := c.workspaceTypeLister.Get(clusters.ToClusterAwareKey(logicalcluster.From(workspace), strings.ToLower(workspace.Spec.Type)))
+	if err == nil {
+		references = workspaceType.Spec.DefaultAPIBindings
+	} else if !errors.IsNotFound(err) {
+		return 0, err
+	}
+
+	clusterName := logicalcluster.From(workspace).Join(workspace.Name)
+	bound := true
+	for _, reference := range references {
+		if reference.Workspace == nil {
+			continue
+		}
+		name := reference.Workspace.ExportName
+		binding, err := c.apiBindingLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+		if errors.IsNotFound(err) {
+			klog.Infof("Creating APIBinding %s|%s for export %s|%s", clusterName, name, reference.Workspace.WorkspaceName, name)
+			binding = &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec:       apisv1alpha1.APIBindingSpec{Reference: reference},
+			}
+			if err := c.createAPIBinding(ctx, clusterName, binding); err != nil && !errors.IsAlreadyExists(err) {
+				return 0, err
+			}
+			bound = false
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		if binding.Status.Phase != apisv1alpha1.APIBindingPhaseBound {
+			bound = false
+		}
+	}
+	if !bound {
+		return 0, nil // the updates of the APIBindings requeue the workspace
+	}
+
+	// we are done. remove our initializer
+	initializers := make([]tenancyv1alpha1.ClusterWorkspaceInitializer, 0, len(workspace.Status.Initializers))
+	for _, i := range workspace.Status.Initializers {
+		if i != tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer {
+			initializers = append(initializers, i)
+		}
+	}
+	workspace.Status.Initializers = initializers
+
+	return 0, nil
+}
+
+func hasInitializer(workspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	for _, i := range workspace.Status.Initializers {
+		if i == tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer {
+			return true
+		}
+	}
+	return false
+}
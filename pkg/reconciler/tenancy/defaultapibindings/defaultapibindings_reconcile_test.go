@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package defaultapibindings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	kubernetes := apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{WorkspaceName: "compute", ExportName: "kubernetes"}}
+	certificates := apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{WorkspaceName: "pki", ExportName: "certificates"}}
+	binding := func(name string, phase apisv1alpha1.APIBindingPhaseType) *apisv1alpha1.APIBinding {
+		return &apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: "root:org:ws"},
+			Status:     apisv1alpha1.APIBindingStatus{Phase: phase},
+		}
+	}
+
+	tests := map[string]struct {
+		phase            tenancyv1alpha1.ClusterWorkspacePhaseType
+		initializers     []tenancyv1alpha1.ClusterWorkspaceInitializer
+		references       []apisv1alpha1.ExportReference
+		noType           bool
+		bindings         []*apisv1alpha1.APIBinding
+		wantCreated      []string
+		wantInitializers []tenancyv1alpha1.ClusterWorkspaceInitializer
+	}{
+		"creates the bindings": {
+			phase:            tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			initializers:     []tenancyv1alpha1.ClusterWorkspaceInitializer{"other", tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer},
+			references:       []apisv1alpha1.ExportReference{kubernetes, certificates},
+			wantCreated:      []string{"root:org:ws|kubernetes->compute", "root:org:ws|certificates->pki"},
+			wantInitializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"other", tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer},
+		},
+		"waits for the bindings to be bound": {
+			phase:            tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			initializers:     []tenancyv1alpha1.ClusterWorkspaceInitializer{tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer},
+			references:       []apisv1alpha1.ExportReference{kubernetes, certificates},
+			bindings:         []*apisv1alpha1.APIBinding{binding("kubernetes", apisv1alpha1.APIBindingPhaseBound), binding("certificates", apisv1alpha1.APIBindingPhaseBinding)},
+			wantInitializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer},
+		},
+		"removes the initializer when bound": {
+			phase:            tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			initializers:     []tenancyv1alpha1.ClusterWorkspaceInitializer{"other", tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer},
+			references:       []apisv1alpha1.ExportReference{kubernetes, certificates},
+			bindings:         []*apisv1alpha1.APIBinding{binding("kubernetes", apisv1alpha1.APIBindingPhaseBound), binding("certificates", apisv1alpha1.APIBindingPhaseBound)},
+			wantInitializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"other"},
+		},
+		"removes the initializer when the type is gone": {
+			phase:            tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			initializers:     []tenancyv1alpha1.ClusterWorkspaceInitializer{tenancyv1alpha1.ClusterWorkspaceAPIBindingsInitializer},
+			noType:           true,
+			wantInitializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{},
+		},
+		"ignores workspaces without the initializer": {
+			phase:            tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			initializers:     []tenancyv1alpha1.ClusterWorkspaceInitializer{"other"},
+			references:       []apisv1alpha1.ExportReference{kubernetes},
+			wantInitializers: []tenancyv1alpha1.ClusterWorkspaceInitializer{"other"},
+		},
+		"ignores ready workspaces": {
+			phase:            tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			references:       []apisv1alpha1.ExportReference{kubernetes},
+			wantInitializers: nil,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			typeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if !tc.noType {
+				require.NoError(t, typeIndexer.Add(&tenancyv1alpha1.ClusterWorkspaceType{
+					ObjectMeta: metav1.ObjectMeta{Name: "compute", ClusterName: "root:org"},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceTypeSpec{DefaultAPIBindings: tc.references},
+				}))
+			}
+			bindingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, b := range tc.bindings {
+				require.NoError(t, bindingIndexer.Add(b))
+			}
+
+			var created []string
+			c := &Controller{
+				workspaceTypeLister: tenancylister.NewClusterWorkspaceTypeLister(typeIndexer),
+				apiBindingLister:    apislisters.NewAPIBindingLister(bindingIndexer),
+				createAPIBinding: func(ctx context.Context, clusterName logicalcluster.LogicalCluster, binding *apisv1alpha1.APIBinding) error {
+					created = append(created, clusterName.String()+"|"+binding.Name+"->"+binding.Spec.Reference.Workspace.WorkspaceName)
+					return nil
+				},
+			}
+
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "ws", ClusterName: "root:org"},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Compute"},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:        tc.phase,
+					Initializers: tc.initializers,
+				},
+			}
+			_, err := c.reconcile(context.Background(), workspace)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantCreated, created)
+			require.Equal(t, tc.wantInitializers, workspace.Status.Initializers)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package groupmapping
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	"k8s.io/client-go/kubernetes"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-workspace-group-mapping"
+
+	// workspaceLabelKey is set on the ClusterRoles and ClusterRoleBindings managed by the controller,
+	// in the parent of the workspace, to the name of the workspace they grant access to.
+	workspaceLabelKey = "tenancy.kcp.dev/group-mapping-workspace"
+)
+
+// NewController returns a controller that grants the groups of the given mappings access to their
+// workspaces, by ClusterRoles and ClusterRoleBindings in the parent workspaces.
+func NewController(
+	mappings []Mapping,
+	kubeClusterClient kubernetes.ClusterInterface,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+	clusterRoleInformer rbacinformers.ClusterRoleInformer,
+	clusterRoleBindingInformer rbacinformers.ClusterRoleBindingInformer,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue:                    queue,
+		groups:                   groupsByRole(mappings),
+		kubeClusterClient:        kubeClusterClient,
+		workspaceLister:          workspaceInformer.Lister(),
+		clusterRoleLister:        clusterRoleInformer.Lister(),
+		clusterRoleBindingLister: clusterRoleBindingInformer.Lister(),
+	}
+
+	workspaceInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+			if !ok {
+				return false
+			}
+			_, found := c.groups[logicalcluster.From(workspace).Join(workspace.Name)]
+			return found
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueueWorkspace(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspace(obj) },
+			DeleteFunc: func(obj interface{}) { c.enqueueWorkspace(obj) },
+		},
+	}))
+
+	// changes to the managed objects are reverted, and they are deleted when their mapping is gone
+	clusterRoleBindingInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueManaged(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueManaged(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueManaged(obj) },
+	}))
+	clusterRoleInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, obj interface{}) { c.enqueueManaged(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueManaged(obj) },
+	}))
+
+	return c
+}
+
+// Controller keeps a ClusterRole and ClusterRoleBinding per mapped workspace and role in the parent
+// of the workspace, binding the mapped groups to the role on the content of the workspace.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	// groups holds the mapped groups per role of every mapped workspace path.
+	groups map[logicalcluster.LogicalCluster]map[string]sets.String
+
+	kubeClusterClient        kubernetes.ClusterInterface
+	workspaceLister          tenancylister.ClusterWorkspaceLister
+	clusterRoleLister        rbaclisters.ClusterRoleLister
+	clusterRoleBindingLister rbaclisters.ClusterRoleBindingLister
+}
+
+func (c *Controller) enqueueWorkspace(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueManaged enqueues the workspace a managed ClusterRole or ClusterRoleBinding grants access to.
+func (c *Controller) enqueueManaged(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	var clusterName logicalcluster.LogicalCluster
+	var labels map[string]string
+	switch obj := obj.(type) {
+	case *rbacv1.ClusterRole:
+		clusterName, labels = logicalcluster.From(obj), obj.Labels
+	case *rbacv1.ClusterRoleBinding:
+		clusterName, labels = logicalcluster.From(obj), obj.Labels
+	default:
+		return
+	}
+	workspace, found := labels[workspaceLabelKey]
+	if !found {
+		return
+	}
+	c.queue.Add(clusters.ToClusterAwareKey(clusterName, workspace))
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting workspace group mapping controller")
+	defer klog.Info("Shutting down workspace group mapping controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.reconcile(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
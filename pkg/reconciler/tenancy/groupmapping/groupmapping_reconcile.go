@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package groupmapping
+
+import (
+	"context"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// reconcile creates or updates the ClusterRoles and ClusterRoleBindings of the mapped roles of the
+// workspace, and deletes the managed ones of roles that are not mapped anymore, or of deleted workspaces.
+func (c *Controller) reconcile(ctx context.Context, key string) error {
+	parent, workspace := clusters.SplitClusterAwareKey(key)
+
+	var roles map[string]sets.String
+	if _, err := c.workspaceLister.Get(key); err == nil {
+		roles = c.groups[parent.Join(workspace)]
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	desired := sets.NewString()
+	for role, groups := range roles {
+		clusterRole, binding := desiredObjects(workspace, role, groups)
+		if err := c.ensureClusterRole(ctx, parent, clusterRole); err != nil {
+			return err
+		}
+		if err := c.ensureClusterRoleBinding(ctx, parent, binding); err != nil {
+			return err
+		}
+		desired.Insert(clusterRole.Name)
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{workspaceLabelKey: workspace})
+	bindings, err := c.clusterRoleBindingLister.List(selector)
+	if err != nil {
+		return err
+	}
+	clusterRoles, err := c.clusterRoleLister.List(selector)
+	if err != nil {
+		return err
+	}
+	rbacClient := c.kubeClusterClient.Cluster(parent).RbacV1()
+	for _, binding := range bindings {
+		if logicalcluster.From(binding) != parent || desired.Has(binding.Name) {
+			continue
+		}
+		if err := rbacClient.ClusterRoleBindings().Delete(ctx, binding.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	for _, clusterRole := range clusterRoles {
+		if logicalcluster.From(clusterRole) != parent || desired.Has(clusterRole.Name) {
+			continue
+		}
+		if err := rbacClient.ClusterRoles().Delete(ctx, clusterRole.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Controller) ensureClusterRole(ctx context.Context, parent logicalcluster.LogicalCluster, clusterRole *rbacv1.ClusterRole) error {
+	rbacClient := c.kubeClusterClient.Cluster(parent).RbacV1()
+	existing, err := c.clusterRoleLister.Get(clusters.ToClusterAwareKey(parent, clusterRole.Name))
+	if errors.IsNotFound(err) {
+		_, err = rbacClient.ClusterRoles().Create(ctx, clusterRole, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(existing.Rules, clusterRole.Rules) && equality.Semantic.DeepEqual(existing.Labels, clusterRole.Labels) {
+		return nil
+	}
+	existing = existing.DeepCopy()
+	existing.Rules = clusterRole.Rules
+	existing.Labels = clusterRole.Labels
+	_, err = rbacClient.ClusterRoles().Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *Controller) ensureClusterRoleBinding(ctx context.Context, parent logicalcluster.LogicalCluster, binding *rbacv1.ClusterRoleBinding) error {
+	rbacClient := c.kubeClusterClient.Cluster(parent).RbacV1()
+	existing, err := c.clusterRoleBindingLister.Get(clusters.ToClusterAwareKey(parent, binding.Name))
+	if errors.IsNotFound(err) {
+		_, err = rbacClient.ClusterRoleBindings().Create(ctx, binding, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if existing.RoleRef != binding.RoleRef {
+		// the role reference is immutable
+		if err := rbacClient.ClusterRoleBindings().Delete(ctx, existing.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = rbacClient.ClusterRoleBindings().Create(ctx, binding, metav1.CreateOptions{})
+		return err
+	}
+	if equality.Semantic.DeepEqual(existing.Subjects, binding.Subjects) && equality.Semantic.DeepEqual(existing.Labels, binding.Labels) {
+		return nil
+	}
+	existing = existing.DeepCopy()
+	existing.Subjects = binding.Subjects
+	existing.Labels = binding.Labels
+	_, err = rbacClient.ClusterRoleBindings().Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+// desiredObjects returns the ClusterRole granting the role on the content of the workspace, and the
+// ClusterRoleBinding binding the groups to it. Both are named after workspace and role.
+func desiredObjects(workspace, role string, groups sets.String) (*rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding) {
+	verbs := []string{RoleAccess}
+	if role == RoleAdmin {
+		verbs = []string{RoleAdmin, RoleAccess}
+	}
+
+	objectMeta := metav1.ObjectMeta{
+		Name:   "system:kcp:group-mapping:" + workspace + ":" + role,
+		Labels: map[string]string{workspaceLabelKey: workspace},
+	}
+
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: objectMeta,
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{tenancyv1alpha1.SchemeGroupVersion.Group},
+			Resources:     []string{"clusterworkspaces/content"},
+			ResourceNames: []string{workspace},
+			Verbs:         verbs,
+		}},
+	}
+
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: *objectMeta.DeepCopy(),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterRole.Name,
+		},
+	}
+	for _, group := range groups.List() {
+		binding.Subjects = append(binding.Subjects, rbacv1.Subject{
+			APIGroup: rbacv1.GroupName,
+			Kind:     rbacv1.GroupKind,
+			Name:     group,
+		})
+	}
+
+	return clusterRole, binding
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package groupmapping
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+type fakeClusterClient struct {
+	*fake.Clientset
+}
+
+func (c fakeClusterClient) Cluster(logicalcluster.LogicalCluster) kubernetes.Interface {
+	return c.Clientset
+}
+
+func TestReconcile(t *testing.T) {
+	workspace := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"},
+	}
+	withCluster := func(clusterName string, obj metav1.Object) metav1.Object {
+		obj.SetClusterName(clusterName)
+		return obj
+	}
+	adminRole, adminBinding := desiredObjects("team", "admin", sets.NewString("oidc:platform"))
+	staleRole, staleBinding := desiredObjects("team", "access", sets.NewString("oidc:old"))
+	outdatedBinding := adminBinding.DeepCopy()
+	outdatedBinding.Subjects = outdatedBinding.Subjects[:0]
+
+	type action struct {
+		verb, resource, name string
+	}
+
+	tests := map[string]struct {
+		mappings     []Mapping
+		workspaces   []*tenancyv1alpha1.ClusterWorkspace
+		clusterRoles []*rbacv1.ClusterRole
+		bindings     []*rbacv1.ClusterRoleBinding
+		wantActions  []action
+	}{
+		"creates the objects of a mapped workspace": {
+			mappings:   []Mapping{{Group: "oidc:platform", Workspace: "root:org:team", Role: "admin"}},
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{workspace},
+			wantActions: []action{
+				{"create", "clusterroles", adminRole.Name},
+				{"create", "clusterrolebindings", adminBinding.Name},
+			},
+		},
+		"nothing to do when in sync": {
+			mappings:     []Mapping{{Group: "oidc:platform", Workspace: "root:org:team", Role: "admin"}},
+			workspaces:   []*tenancyv1alpha1.ClusterWorkspace{workspace},
+			clusterRoles: []*rbacv1.ClusterRole{withCluster("root:org", adminRole.DeepCopy()).(*rbacv1.ClusterRole)},
+			bindings:     []*rbacv1.ClusterRoleBinding{withCluster("root:org", adminBinding.DeepCopy()).(*rbacv1.ClusterRoleBinding)},
+		},
+		"updates the subjects": {
+			mappings:     []Mapping{{Group: "oidc:platform", Workspace: "root:org:team", Role: "admin"}},
+			workspaces:   []*tenancyv1alpha1.ClusterWorkspace{workspace},
+			clusterRoles: []*rbacv1.ClusterRole{withCluster("root:org", adminRole.DeepCopy()).(*rbacv1.ClusterRole)},
+			bindings:     []*rbacv1.ClusterRoleBinding{withCluster("root:org", outdatedBinding).(*rbacv1.ClusterRoleBinding)},
+			wantActions: []action{
+				{"update", "clusterrolebindings", adminBinding.Name},
+			},
+		},
+		"deletes the objects of unmapped roles": {
+			mappings:     []Mapping{{Group: "oidc:platform", Workspace: "root:org:team", Role: "admin"}},
+			workspaces:   []*tenancyv1alpha1.ClusterWorkspace{workspace},
+			clusterRoles: []*rbacv1.ClusterRole{withCluster("root:org", adminRole.DeepCopy()).(*rbacv1.ClusterRole), withCluster("root:org", staleRole.DeepCopy()).(*rbacv1.ClusterRole)},
+			bindings:     []*rbacv1.ClusterRoleBinding{withCluster("root:org", adminBinding.DeepCopy()).(*rbacv1.ClusterRoleBinding), withCluster("root:org", staleBinding.DeepCopy()).(*rbacv1.ClusterRoleBinding)},
+			wantActions: []action{
+				{"delete", "clusterrolebindings", staleBinding.Name},
+				{"delete", "clusterroles", staleRole.Name},
+			},
+		},
+		"deletes the objects of deleted workspaces": {
+			mappings:     []Mapping{{Group: "oidc:platform", Workspace: "root:org:team", Role: "admin"}},
+			clusterRoles: []*rbacv1.ClusterRole{withCluster("root:org", adminRole.DeepCopy()).(*rbacv1.ClusterRole)},
+			bindings:     []*rbacv1.ClusterRoleBinding{withCluster("root:org", adminBinding.DeepCopy()).(*rbacv1.ClusterRoleBinding)},
+			wantActions: []action{
+				{"delete", "clusterrolebindings", adminBinding.Name},
+				{"delete", "clusterroles", adminRole.Name},
+			},
+		},
+		"ignores objects of a workspace with the same name elsewhere": {
+			workspaces:   []*tenancyv1alpha1.ClusterWorkspace{workspace},
+			clusterRoles: []*rbacv1.ClusterRole{withCluster("root:other", adminRole.DeepCopy()).(*rbacv1.ClusterRole)},
+			bindings:     []*rbacv1.ClusterRoleBinding{withCluster("root:other", adminBinding.DeepCopy()).(*rbacv1.ClusterRoleBinding)},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kcpInformers := kcpinformers.NewSharedInformerFactory(kcpfake.NewSimpleClientset(), 0)
+			for _, ws := range tc.workspaces {
+				require.NoError(t, kcpInformers.Tenancy().V1alpha1().ClusterWorkspaces().Informer().GetIndexer().Add(ws))
+			}
+			var objects []runtime.Object
+			for _, clusterRole := range tc.clusterRoles {
+				objects = append(objects, clusterRole)
+			}
+			for _, binding := range tc.bindings {
+				objects = append(objects, binding)
+			}
+			kubeClient := fake.NewSimpleClientset(objects...)
+			kubeInformers := informers.NewSharedInformerFactory(kubeClient, 0)
+			for _, clusterRole := range tc.clusterRoles {
+				require.NoError(t, kubeInformers.Rbac().V1().ClusterRoles().Informer().GetIndexer().Add(clusterRole))
+			}
+			for _, binding := range tc.bindings {
+				require.NoError(t, kubeInformers.Rbac().V1().ClusterRoleBindings().Informer().GetIndexer().Add(binding))
+			}
+
+			c := NewController(tc.mappings, fakeClusterClient{kubeClient},
+				kcpInformers.Tenancy().V1alpha1().ClusterWorkspaces(),
+				kubeInformers.Rbac().V1().ClusterRoles(),
+				kubeInformers.Rbac().V1().ClusterRoleBindings(),
+			)
+			err := c.reconcile(context.Background(), "root:org#$#team")
+			require.NoError(t, err)
+
+			var got []action
+			for _, a := range kubeClient.Actions() {
+				var name string
+				switch a := a.(type) {
+				case clientgotesting.CreateAction:
+					name = a.GetObject().(metav1.Object).GetName()
+				case clientgotesting.UpdateAction:
+					name = a.GetObject().(metav1.Object).GetName()
+				case clientgotesting.DeleteAction:
+					name = a.GetName()
+				}
+				got = append(got, action{a.GetVerb(), a.GetResource().Resource, name})
+			}
+			require.Equal(t, tc.wantActions, got)
+		})
+	}
+}
+
+func TestDesiredObjects(t *testing.T) {
+	clusterRole, binding := desiredObjects("team", "admin", sets.NewString("oidc:b", "oidc:a"))
+	require.Equal(t, "system:kcp:group-mapping:team:admin", clusterRole.Name)
+	require.Equal(t, []rbacv1.PolicyRule{{
+		APIGroups:     []string{"tenancy.kcp.dev"},
+		Resources:     []string{"clusterworkspaces/content"},
+		ResourceNames: []string{"team"},
+		Verbs:         []string{"admin", "access"},
+	}}, clusterRole.Rules)
+	require.Equal(t, clusterRole.Name, binding.Name)
+	require.Equal(t, clusterRole.Name, binding.RoleRef.Name)
+	require.Equal(t, []rbacv1.Subject{
+		{APIGroup: rbacv1.GroupName, Kind: rbacv1.GroupKind, Name: "oidc:a"},
+		{APIGroup: rbacv1.GroupName, Kind: rbacv1.GroupKind, Name: "oidc:b"},
+	}, binding.Subjects)
+
+	clusterRole, _ = desiredObjects("team", "access", sets.NewString("oidc:a"))
+	require.Equal(t, []string{"access"}, clusterRole.Rules[0].Verbs)
+}
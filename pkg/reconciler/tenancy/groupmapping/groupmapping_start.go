@@ -0,0 +1,42 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package groupmapping
+
+import (
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringVar(&o.MappingFile, "workspace-group-mapping-file", o.MappingFile, "Path to a YAML file with a list of group, workspace and role (access or admin) mappings. Members of each group are granted the role on the workspace with the given path. If empty, no groups are mapped")
+	return o
+}
+
+type Options struct {
+	MappingFile string
+}
+
+func (o *Options) Validate() error {
+	if o.MappingFile == "" {
+		return nil
+	}
+	_, err := LoadMappings(o.MappingFile)
+	return err
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package groupmapping
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RoleAccess grants access to the content of the workspace.
+	RoleAccess = "access"
+	// RoleAdmin grants admin access to the content of the workspace.
+	RoleAdmin = "admin"
+)
+
+// Mapping grants the members of a group of the identity provider a role on a workspace.
+type Mapping struct {
+	// Group is the name of the group, as presented by the authenticator, e.g. with the prefix
+	// of --oidc-groups-prefix.
+	Group string `json:"group"`
+	// Workspace is the path of the workspace, e.g. root:my-org:my-team.
+	Workspace string `json:"workspace"`
+	// Role is either access or admin.
+	Role string `json:"role"`
+}
+
+// LoadMappings reads and validates a YAML list of mappings.
+func LoadMappings(file string) ([]Mapping, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace group mapping file %q: %w", file, err)
+	}
+
+	var mappings []Mapping
+	if err := yaml.Unmarshal(data, &mappings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workspace group mapping file %q: %w", file, err)
+	}
+	for i, m := range mappings {
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("invalid mapping %d in workspace group mapping file %q: %w", i, file, err)
+		}
+	}
+	return mappings, nil
+}
+
+func (m Mapping) validate() error {
+	if m.Group == "" {
+		return errors.New("group must not be empty")
+	}
+	if m.Role != RoleAccess && m.Role != RoleAdmin {
+		return fmt.Errorf("role must be %q or %q, got %q", RoleAccess, RoleAdmin, m.Role)
+	}
+	for _, segment := range strings.Split(m.Workspace, ":") {
+		if segment == "" {
+			return fmt.Errorf("invalid workspace path %q", m.Workspace)
+		}
+	}
+	if _, hasParent := logicalcluster.New(m.Workspace).Parent(); !hasParent {
+		return fmt.Errorf("workspace path %q has no parent", m.Workspace)
+	}
+	return nil
+}
+
+// groupsByRole returns the groups per role of every workspace path of the mappings.
+func groupsByRole(mappings []Mapping) map[logicalcluster.LogicalCluster]map[string]sets.String {
+	ret := map[logicalcluster.LogicalCluster]map[string]sets.String{}
+	for _, m := range mappings {
+		workspace := logicalcluster.New(m.Workspace)
+		if ret[workspace] == nil {
+			ret[workspace] = map[string]sets.String{}
+		}
+		if ret[workspace][m.Role] == nil {
+			ret[workspace][m.Role] = sets.NewString()
+		}
+		ret[workspace][m.Role].Insert(m.Group)
+	}
+	return ret
+}
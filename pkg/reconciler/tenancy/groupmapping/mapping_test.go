@@ -0,0 +1,105 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package groupmapping
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestLoadMappings(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    []Mapping
+		wantErr bool
+	}{
+		"valid": {
+			content: `
+- group: oidc:platform
+  workspace: root:org
+  role: admin
+- group: oidc:developers
+  workspace: root:org:team
+  role: access
+`,
+			want: []Mapping{
+				{Group: "oidc:platform", Workspace: "root:org", Role: "admin"},
+				{Group: "oidc:developers", Workspace: "root:org:team", Role: "access"},
+			},
+		},
+		"empty": {},
+		"missing group": {
+			content: "- workspace: root:org\n  role: admin\n",
+			wantErr: true,
+		},
+		"unknown role": {
+			content: "- group: oidc:platform\n  workspace: root:org\n  role: owner\n",
+			wantErr: true,
+		},
+		"root workspace": {
+			content: "- group: oidc:platform\n  workspace: root\n  role: admin\n",
+			wantErr: true,
+		},
+		"invalid path": {
+			content: "- group: oidc:platform\n  workspace: root::team\n  role: admin\n",
+			wantErr: true,
+		},
+		"not a list": {
+			content: "group: oidc:platform\n",
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "mappings.yaml")
+			require.NoError(t, ioutil.WriteFile(file, []byte(tc.content), 0600))
+
+			got, err := LoadMappings(file)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestGroupsByRole(t *testing.T) {
+	got := groupsByRole([]Mapping{
+		{Group: "b", Workspace: "root:org", Role: "admin"},
+		{Group: "a", Workspace: "root:org", Role: "admin"},
+		{Group: "a", Workspace: "root:org", Role: "admin"},
+		{Group: "c", Workspace: "root:org", Role: "access"},
+		{Group: "c", Workspace: "root:org:team", Role: "access"},
+	})
+	require.Equal(t, map[logicalcluster.LogicalCluster]map[string]sets.String{
+		logicalcluster.New("root:org"): {
+			"admin":  sets.NewString("a", "b"),
+			"access": sets.NewString("c"),
+		},
+		logicalcluster.New("root:org:team"): {
+			"access": sets.NewString("c"),
+		},
+	}, got)
+}
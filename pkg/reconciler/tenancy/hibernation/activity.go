@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package hibernation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+)
+
+// wakeUpAfter is the idle time after which a request to a logical cluster is passed on to the
+// wake-up handlers. Requests in between only update the time of the last activity.
+const wakeUpAfter = time.Minute
+
+// ActivityTracker records the time of the last request of a user per logical cluster. It is
+// in-memory and local to the shard.
+type ActivityTracker struct {
+	lock     sync.RWMutex
+	last     map[logicalcluster.LogicalCluster]time.Time
+	handlers []func(cluster logicalcluster.LogicalCluster)
+
+	now func() time.Time
+}
+
+// NewActivityTracker returns an empty ActivityTracker.
+func NewActivityTracker() *ActivityTracker {
+	return &ActivityTracker{
+		last: map[logicalcluster.LogicalCluster]time.Time{},
+		now:  time.Now,
+	}
+}
+
+// AddWakeUpHandler adds a handler that is called with the logical cluster of the first request
+// after an idle period.
+func (t *ActivityTracker) AddWakeUpHandler(handler func(cluster logicalcluster.LogicalCluster)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.handlers = append(t.handlers, handler)
+}
+
+// Touch records a request to the logical cluster.
+func (t *ActivityTracker) Touch(cluster logicalcluster.LogicalCluster) {
+	now := t.now()
+
+	t.lock.RLock()
+	last, found := t.last[cluster]
+	t.lock.RUnlock()
+	if found && now.Sub(last) < time.Second {
+		// avoid the write lock on bursts of requests
+		return
+	}
+
+	t.lock.Lock()
+	t.last[cluster] = now
+	handlers := t.handlers
+	t.lock.Unlock()
+
+	if !found || now.Sub(last) >= wakeUpAfter {
+		for _, handler := range handlers {
+			handler(cluster)
+		}
+	}
+}
+
+// LastActivity returns the time of the last request to the logical cluster, if there was any
+// since the start of the shard.
+func (t *ActivityTracker) LastActivity(cluster logicalcluster.LogicalCluster) (time.Time, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	last, found := t.last[cluster]
+	return last, found
+}
+
+// Forget drops the activity of a logical cluster, e.g. of a deleted workspace.
+func (t *ActivityTracker) Forget(cluster logicalcluster.LogicalCluster) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.last, cluster)
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package hibernation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-clusterworkspace-hibernation"
+)
+
+// NewController returns a controller that marks the ClusterWorkspaces without requests of users
+// for the idle timeout as hibernated, and wakes them up on the next request recorded by the tracker.
+// Only the workspaces located on the given shard are handled, as the tracker only sees the requests
+// served by this shard.
+func NewController(
+	shardName string,
+	kcpClusterClient kcpclient.ClusterInterface,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+	tracker *ActivityTracker,
+	idleTimeout time.Duration,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue:            queue,
+		shardName:        shardName,
+		kcpClusterClient: kcpClusterClient,
+		workspaceLister:  workspaceInformer.Lister(),
+		tracker:          tracker,
+		idleTimeout:      idleTimeout,
+		now:              time.Now,
+		startTime:        time.Now(),
+	}
+
+	workspaceInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: func(obj interface{}) { c.forget(obj) },
+	}))
+
+	tracker.AddWakeUpHandler(func(cluster logicalcluster.LogicalCluster) {
+		parent, name := cluster.Split()
+		if parent.Empty() {
+			return // root is not a ClusterWorkspace
+		}
+		key := clusters.ToClusterAwareKey(parent, name)
+		if _, err := c.workspaceLister.Get(key); err != nil {
+			return // not a ClusterWorkspace known to this shard
+		}
+		queue.Add(key)
+	})
+
+	return c
+}
+
+// Controller maintains the Hibernated condition of ClusterWorkspaces.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	shardName        string
+	kcpClusterClient kcpclient.ClusterInterface
+	workspaceLister  tenancylister.ClusterWorkspaceLister
+
+	tracker     *ActivityTracker
+	idleTimeout time.Duration
+	now         func() time.Time
+	startTime   time.Time
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *Controller) forget(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		return
+	}
+	c.tracker.Forget(logicalcluster.From(workspace).Join(workspace.Name))
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting ClusterWorkspace hibernation controller")
+	defer klog.Info("Shutting down ClusterWorkspace hibernation controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, err := c.workspaceLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	previous := obj
+	obj = obj.DeepCopy()
+
+	requeueAfter := c.reconcile(obj)
+
+	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
+		if err := c.patchStatus(ctx, previous, obj); err != nil {
+			return err
+		}
+	}
+
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return nil
+}
+
+func (c *Controller) patchStatus(ctx context.Context, previous, obj *tenancyv1alpha1.ClusterWorkspace) error {
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+	}
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package hibernation
+
+import (
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// reconcile marks a ready workspace as hibernated when it has been idle for the idle timeout, and
+// wakes it up when it received a request since. It returns the duration after which the workspace
+// must be checked again. Workspaces located on other shards are left alone, their activity is unknown here.
+func (c *Controller) reconcile(workspace *tenancyv1alpha1.ClusterWorkspace) time.Duration {
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady || workspace.DeletionTimestamp != nil {
+		return 0
+	}
+	if workspace.Status.Location.Current != c.shardName {
+		return 0
+	}
+
+	lastActivity, found := c.tracker.LastActivity(logicalcluster.From(workspace).Join(workspace.Name))
+	if conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceHibernated) {
+		hibernatedAt := conditions.Get(workspace, tenancyv1alpha1.WorkspaceHibernated).LastTransitionTime
+		if !found || !lastActivity.After(hibernatedAt.Time) {
+			return 0 // the tracker enqueues the workspace on the next request
+		}
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceHibernated, tenancyv1alpha1.WorkspaceHibernatedReasonWokenUp, conditionsv1alpha1.ConditionSeverityNone, "Received a request at %s", lastActivity.UTC().Format(time.RFC3339))
+	}
+
+	// the requests before the start of the shard are unknown
+	if !found || lastActivity.Before(c.startTime) {
+		lastActivity = c.startTime
+	}
+	idle := c.now().Sub(lastActivity)
+	if idle >= c.idleTimeout {
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceHibernated)
+		return 0
+	}
+	return c.idleTimeout - idle
+}
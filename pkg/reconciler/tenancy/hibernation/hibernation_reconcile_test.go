@@ -0,0 +1,173 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package hibernation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	startTime := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := startTime.Add(2 * time.Hour)
+
+	tests := map[string]struct {
+		phase        tenancyv1alpha1.ClusterWorkspacePhaseType
+		shard        string
+		hibernatedAt *time.Time
+		lastActivity *time.Time
+
+		wantHibernated   *bool
+		wantRequeueAfter time.Duration
+	}{
+		"not ready": {
+			phase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+		},
+		"active": {
+			phase:            tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			lastActivity:     timePtr(now.Add(-10 * time.Minute)),
+			wantRequeueAfter: 50 * time.Minute,
+		},
+		"idle": {
+			phase:          tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			lastActivity:   timePtr(now.Add(-time.Hour)),
+			wantHibernated: boolPtr(true),
+		},
+		"idle on another shard": {
+			phase:        tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			shard:        "other",
+			lastActivity: timePtr(now.Add(-time.Hour)),
+		},
+		"no activity since the start": {
+			phase:          tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			wantHibernated: boolPtr(true),
+		},
+		"hibernated without activity": {
+			phase:          tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			hibernatedAt:   timePtr(now.Add(-time.Hour)),
+			lastActivity:   timePtr(now.Add(-90 * time.Minute)),
+			wantHibernated: boolPtr(true),
+		},
+		"hibernated before the start": {
+			phase:          tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			hibernatedAt:   timePtr(startTime.Add(-time.Hour)),
+			wantHibernated: boolPtr(true),
+		},
+		"woken up": {
+			phase:            tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			hibernatedAt:     timePtr(now.Add(-time.Hour)),
+			lastActivity:     timePtr(now.Add(-time.Minute)),
+			wantHibernated:   boolPtr(false),
+			wantRequeueAfter: 59 * time.Minute,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := NewActivityTracker()
+			if tc.lastActivity != nil {
+				tracker.now = func() time.Time { return *tc.lastActivity }
+				tracker.Touch(logicalcluster.New("root:org:team"))
+			}
+			c := &Controller{
+				shardName:   "shard",
+				tracker:     tracker,
+				idleTimeout: time.Hour,
+				now:         func() time.Time { return now },
+				startTime:   startTime,
+			}
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tc.phase,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard"},
+				},
+			}
+			if tc.shard != "" {
+				workspace.Status.Location.Current = tc.shard
+			}
+			if tc.hibernatedAt != nil {
+				workspace.Status.Conditions = conditionsv1alpha1.Conditions{{
+					Type:               tenancyv1alpha1.WorkspaceHibernated,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(*tc.hibernatedAt),
+				}}
+			}
+
+			requeueAfter := c.reconcile(workspace)
+			require.Equal(t, tc.wantRequeueAfter, requeueAfter)
+			condition := conditions.Get(workspace, tenancyv1alpha1.WorkspaceHibernated)
+			if tc.wantHibernated == nil {
+				require.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			require.Equal(t, *tc.wantHibernated, condition.Status == corev1.ConditionTrue)
+			if !*tc.wantHibernated {
+				require.Equal(t, tenancyv1alpha1.WorkspaceHibernatedReasonWokenUp, condition.Reason)
+			}
+		})
+	}
+}
+
+func TestActivityTracker(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewActivityTracker()
+	tracker.now = func() time.Time { return now }
+	var wokenUp []string
+	tracker.AddWakeUpHandler(func(cluster logicalcluster.LogicalCluster) { wokenUp = append(wokenUp, cluster.String()) })
+
+	team := logicalcluster.New("root:org:team")
+	_, found := tracker.LastActivity(team)
+	require.False(t, found)
+
+	tracker.Touch(team)
+	last, found := tracker.LastActivity(team)
+	require.True(t, found)
+	require.Equal(t, now, last)
+	require.Equal(t, []string{"root:org:team"}, wokenUp, "first request")
+
+	now = now.Add(30 * time.Second)
+	tracker.Touch(team)
+	last, _ = tracker.LastActivity(team)
+	require.Equal(t, now, last)
+	require.Len(t, wokenUp, 1, "request within the wake-up period")
+
+	now = now.Add(wakeUpAfter)
+	tracker.Touch(team)
+	require.Len(t, wokenUp, 2, "request after an idle period")
+
+	tracker.Forget(team)
+	_, found = tracker.LastActivity(team)
+	require.False(t, found)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
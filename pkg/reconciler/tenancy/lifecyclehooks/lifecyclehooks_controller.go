@@ -0,0 +1,268 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package lifecyclehooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-clusterworkspace-lifecycle-hooks"
+
+	// defaultTimeout is the timeout of a hook call without timeoutSeconds.
+	defaultTimeout = 10 * time.Second
+)
+
+// NewController returns a controller that calls the lifecycle hooks of the ClusterWorkspaceTypes
+// on the creation, readiness and deletion of their ClusterWorkspaces.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+	workspaceTypeInformer tenancyinformer.ClusterWorkspaceTypeInformer,
+) *Controller {
+	// hooks are external systems, give them time to recover between retries
+	rateLimiter := workqueue.NewItemExponentialFailureRateLimiter(time.Second, 5*time.Minute)
+	queue := workqueue.NewNamedRateLimitingQueue(rateLimiter, controllerName)
+
+	c := &Controller{
+		queue:               queue,
+		kcpClusterClient:    kcpClusterClient,
+		workspaceLister:     workspaceInformer.Lister(),
+		workspaceTypeLister: workspaceTypeInformer.Lister(),
+	}
+	c.callHook = postNotification
+
+	workspaceInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueWorkspace(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspace(obj) },
+	}))
+	workspaceTypeInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueWorkspacesOfType(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspacesOfType(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueWorkspacesOfType(obj) },
+	}))
+
+	return c
+}
+
+// Controller calls lifecycle hooks, and records the delivered events on the ClusterWorkspaces.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient    kcpclient.ClusterInterface
+	workspaceLister     tenancylister.ClusterWorkspaceLister
+	workspaceTypeLister tenancylister.ClusterWorkspaceTypeLister
+
+	callHook func(ctx context.Context, hook tenancyv1alpha1.ClusterWorkspaceLifecycleHook, notification *tenancyv1alpha1.ClusterWorkspaceLifecycleNotification) error
+}
+
+func (c *Controller) enqueueWorkspace(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueWorkspacesOfType enqueues the ClusterWorkspaces of the type in its logical cluster.
+func (c *Controller) enqueueWorkspacesOfType(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	workspaceType, ok := obj.(*tenancyv1alpha1.ClusterWorkspaceType)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+	workspaces, err := c.workspaceLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName := logicalcluster.From(workspaceType)
+	for _, workspace := range workspaces {
+		if logicalcluster.From(workspace) == clusterName && strings.ToLower(workspace.Spec.Type) == workspaceType.Name {
+			c.enqueueWorkspace(workspace)
+		}
+	}
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting ClusterWorkspace lifecycle hooks controller")
+	defer klog.Info("Shutting down ClusterWorkspace lifecycle hooks controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	workspace, err := c.workspaceLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	var hooks []tenancyv1alpha1.ClusterWorkspaceLifecycleHook
+	workspaceType, err := c.workspaceTypeLister.Get(clusters.ToClusterAwareKey(logicalcluster.From(workspace), strings.ToLower(workspace.Spec.Type)))
+	if err == nil {
+		hooks = workspaceType.Spec.LifecycleHooks
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	previous := workspace
+	workspace = workspace.DeepCopy()
+
+	// the delivered events are recorded even if other hooks failed
+	reconcileErr := c.reconcile(ctx, workspace, hooks, c.queue.NumRequeues(key))
+
+	if !equality.Semantic.DeepEqual(previous.Finalizers, workspace.Finalizers) || !equality.Semantic.DeepEqual(previous.Annotations, workspace.Annotations) {
+		if err := c.patchMetadata(ctx, previous, workspace); err != nil {
+			return err
+		}
+	}
+	return reconcileErr
+}
+
+// patchMetadata writes the finalizers and the notified annotation, guarded by the resourceVersion.
+func (c *Controller) patchMetadata(ctx context.Context, previous, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	finalizers := workspace.Finalizers
+	if finalizers == nil {
+		finalizers = []string{}
+	}
+	var notified interface{}
+	if value, found := workspace.Annotations[tenancyv1alpha1.LifecycleHooksNotifiedAnnotationKey]; found {
+		notified = value
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": previous.ResourceVersion,
+			"finalizers":      finalizers,
+			"annotations": map[string]interface{}{
+				tenancyv1alpha1.LifecycleHooksNotifiedAnnotationKey: notified,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kcpClusterClient.Cluster(logicalcluster.From(workspace)).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, workspace.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// postNotification POSTs the notification to the hook, and fails unless it responds with a 2xx
+// status code.
+func postNotification(ctx context.Context, hook tenancyv1alpha1.ClusterWorkspaceLifecycleHook, notification *tenancyv1alpha1.ClusterWorkspaceLifecycleNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	timeout := defaultTimeout
+	if hook.TimeoutSeconds > 0 {
+		timeout = time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(hook.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(hook.CABundle) {
+			return fmt.Errorf("invalid caBundle of hook %s", hook.Name)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("hook %s responded with %s", hook.Name, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package lifecyclehooks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// maxAttempts is the number of calls of a hook with the Ignore failure policy before the event is
+// given up on.
+const maxAttempts = 5
+
+// reconcile calls the hooks for the due events that were not delivered yet, records the delivered
+// ones in the notified annotation of the workspace, and adds or removes the finalizer. attempts is
+// the number of previous failed reconciliations.
+func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, hooks []tenancyv1alpha1.ClusterWorkspaceLifecycleHook, attempts int) error {
+	notified := sets.NewString()
+	if value := workspace.Annotations[tenancyv1alpha1.LifecycleHooksNotifiedAnnotationKey]; value != "" {
+		notified.Insert(strings.Split(value, ",")...)
+	}
+	deleting := workspace.DeletionTimestamp != nil
+
+	var errs []error
+	hasDeletedHooks, deletedDelivered := false, true
+	for _, hook := range hooks {
+		for _, event := range hook.Events {
+			if event == tenancyv1alpha1.ClusterWorkspaceDeleted {
+				hasDeletedHooks = true
+			}
+			key := hook.Name + "/" + string(event)
+			if !isDue(workspace, event) || notified.Has(key) {
+				continue
+			}
+			if err := c.callHook(ctx, hook, notification(workspace, event)); err != nil {
+				if hook.FailurePolicy == tenancyv1alpha1.LifecycleHookFailurePolicyFail || attempts+1 < maxAttempts {
+					errs = append(errs, fmt.Errorf("hook %s failed on event %s: %w", hook.Name, event, err))
+					if event == tenancyv1alpha1.ClusterWorkspaceDeleted {
+						deletedDelivered = false
+					}
+					continue
+				}
+				klog.Errorf("Giving up on event %s of ClusterWorkspace %s|%s for hook %s after %d attempts: %v", event, logicalcluster.From(workspace), workspace.Name, hook.Name, attempts+1, err)
+			}
+			notified.Insert(key)
+		}
+	}
+
+	if notified.Len() > 0 {
+		if workspace.Annotations == nil {
+			workspace.Annotations = map[string]string{}
+		}
+		workspace.Annotations[tenancyv1alpha1.LifecycleHooksNotifiedAnnotationKey] = strings.Join(notified.List(), ",")
+	}
+
+	hasFinalizer := sets.NewString(workspace.Finalizers...).Has(tenancyv1alpha1.LifecycleHooksFinalizer)
+	switch {
+	case !deleting && hasDeletedHooks && !hasFinalizer:
+		workspace.Finalizers = append(workspace.Finalizers, tenancyv1alpha1.LifecycleHooksFinalizer)
+	case hasFinalizer && (!hasDeletedHooks || deleting && deletedDelivered):
+		workspace.Finalizers = withoutFinalizer(workspace.Finalizers)
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// isDue returns whether the event has happened to the workspace.
+func isDue(workspace *tenancyv1alpha1.ClusterWorkspace, event tenancyv1alpha1.ClusterWorkspaceLifecycleEvent) bool {
+	deleting := workspace.DeletionTimestamp != nil
+	switch event {
+	case tenancyv1alpha1.ClusterWorkspaceCreated:
+		return !deleting
+	case tenancyv1alpha1.ClusterWorkspaceReady:
+		return !deleting && workspace.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady
+	case tenancyv1alpha1.ClusterWorkspaceDeleted:
+		return deleting
+	}
+	return false
+}
+
+func notification(workspace *tenancyv1alpha1.ClusterWorkspace, event tenancyv1alpha1.ClusterWorkspaceLifecycleEvent) *tenancyv1alpha1.ClusterWorkspaceLifecycleNotification {
+	return &tenancyv1alpha1.ClusterWorkspaceLifecycleNotification{
+		Event:       event,
+		ClusterName: logicalcluster.From(workspace).String(),
+		Name:        workspace.Name,
+		UID:         string(workspace.UID),
+		Type:        workspace.Spec.Type,
+		Phase:       workspace.Status.Phase,
+		BaseURL:     workspace.Status.BaseURL,
+	}
+}
+
+func withoutFinalizer(finalizers []string) []string {
+	var ret []string
+	for _, f := range finalizers {
+		if f != tenancyv1alpha1.LifecycleHooksFinalizer {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package lifecyclehooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	billing := tenancyv1alpha1.ClusterWorkspaceLifecycleHook{
+		Name:   "billing",
+		URL:    "https://billing.example.com",
+		Events: []tenancyv1alpha1.ClusterWorkspaceLifecycleEvent{tenancyv1alpha1.ClusterWorkspaceCreated, tenancyv1alpha1.ClusterWorkspaceDeleted},
+	}
+	cmdb := tenancyv1alpha1.ClusterWorkspaceLifecycleHook{
+		Name:          "cmdb",
+		URL:           "https://cmdb.example.com",
+		Events:        []tenancyv1alpha1.ClusterWorkspaceLifecycleEvent{tenancyv1alpha1.ClusterWorkspaceReady},
+		FailurePolicy: tenancyv1alpha1.LifecycleHookFailurePolicyFail,
+	}
+	now := metav1.Now()
+
+	tests := map[string]struct {
+		hooks      []tenancyv1alpha1.ClusterWorkspaceLifecycleHook
+		annotation string
+		finalizers []string
+		phase      tenancyv1alpha1.ClusterWorkspacePhaseType
+		deleting   bool
+		failing    map[string]bool
+		attempts   int
+		wantCalls  []string
+		wantNotify string
+		wantFinal  []string
+		wantErr    bool
+	}{
+		"no hooks": {},
+		"created": {
+			hooks:      []tenancyv1alpha1.ClusterWorkspaceLifecycleHook{billing, cmdb},
+			phase:      tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			wantCalls:  []string{"billing/Created"},
+			wantNotify: "billing/Created",
+			wantFinal:  []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+		},
+		"ready": {
+			hooks:      []tenancyv1alpha1.ClusterWorkspaceLifecycleHook{billing, cmdb},
+			annotation: "billing/Created",
+			finalizers: []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+			phase:      tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			wantCalls:  []string{"cmdb/Ready"},
+			wantNotify: "billing/Created,cmdb/Ready",
+			wantFinal:  []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+		},
+		"already notified": {
+			hooks:      []tenancyv1alpha1.ClusterWorkspaceLifecycleHook{billing, cmdb},
+			annotation: "billing/Created,cmdb/Ready",
+			finalizers: []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+			phase:      tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			wantNotify: "billing/Created,cmdb/Ready",
+			wantFinal:  []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+		},
+		"deleted": {
+			hooks:      []tenancyv1alpha1.ClusterWorkspaceLifecycleHook{billing, cmdb},
+			annotation: "billing/Created,cmdb/Ready",
+			finalizers: []string{"other", tenancyv1alpha1.LifecycleHooksFinalizer},
+			phase:      tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			deleting:   true,
+			wantCalls:  []string{"billing/Deleted"},
+			wantNotify: "billing/Created,billing/Deleted,cmdb/Ready",
+			wantFinal:  []string{"other"},
+		},
+		"failed deletion hook keeps the finalizer": {
+			hooks:      []tenancyv1alpha1.ClusterWorkspaceLifecycleHook{billing},
+			annotation: "billing/Created",
+			finalizers: []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+			deleting:   true,
+			failing:    map[string]bool{"billing": true},
+			wantCalls:  []string{"billing/Deleted"},
+			wantNotify: "billing/Created",
+			wantFinal:  []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+			wantErr:    true,
+		},
+		"ignored failure is given up on": {
+			hooks:      []tenancyv1alpha1.ClusterWorkspaceLifecycleHook{billing},
+			annotation: "billing/Created",
+			finalizers: []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+			deleting:   true,
+			failing:    map[string]bool{"billing": true},
+			attempts:   maxAttempts - 1,
+			wantCalls:  []string{"billing/Deleted"},
+			wantNotify: "billing/Created,billing/Deleted",
+		},
+		"failure policy Fail retries forever": {
+			hooks:     []tenancyv1alpha1.ClusterWorkspaceLifecycleHook{cmdb},
+			phase:     tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			failing:   map[string]bool{"cmdb": true},
+			attempts:  100,
+			wantCalls: []string{"cmdb/Ready"},
+			wantErr:   true,
+		},
+		"other hooks are delivered despite a failure": {
+			hooks:      []tenancyv1alpha1.ClusterWorkspaceLifecycleHook{billing, cmdb},
+			phase:      tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			failing:    map[string]bool{"cmdb": true},
+			wantCalls:  []string{"billing/Created", "cmdb/Ready"},
+			wantNotify: "billing/Created",
+			wantFinal:  []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+			wantErr:    true,
+		},
+		"hook removed from the type": {
+			annotation: "billing/Created",
+			finalizers: []string{tenancyv1alpha1.LifecycleHooksFinalizer},
+			phase:      tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			wantNotify: "billing/Created",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+			c := &Controller{
+				callHook: func(ctx context.Context, hook tenancyv1alpha1.ClusterWorkspaceLifecycleHook, notification *tenancyv1alpha1.ClusterWorkspaceLifecycleNotification) error {
+					require.Equal(t, "root:org", notification.ClusterName)
+					require.Equal(t, "team", notification.Name)
+					calls = append(calls, hook.Name+"/"+string(notification.Event))
+					if tc.failing[hook.Name] {
+						return errors.New("boom")
+					}
+					return nil
+				},
+			}
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "team",
+					ClusterName: "root:org",
+					Finalizers:  tc.finalizers,
+				},
+				Spec:   tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Team"},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tc.phase},
+			}
+			if tc.annotation != "" {
+				workspace.Annotations = map[string]string{tenancyv1alpha1.LifecycleHooksNotifiedAnnotationKey: tc.annotation}
+			}
+			if tc.deleting {
+				workspace.DeletionTimestamp = &now
+			}
+
+			err := c.reconcile(context.Background(), workspace, tc.hooks, tc.attempts)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantCalls, calls)
+			require.Equal(t, tc.wantNotify, workspace.Annotations[tenancyv1alpha1.LifecycleHooksNotifiedAnnotationKey])
+			require.Equal(t, tc.wantFinal, workspace.Finalizers)
+		})
+	}
+}
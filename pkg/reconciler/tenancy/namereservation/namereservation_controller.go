@@ -0,0 +1,175 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namereservation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	apisinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apis/v1alpha1"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	apislister "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reservation"
+)
+
+const (
+	controllerName = "kcp-name-reservation-cleanup"
+
+	// cleanupPeriod is how often the NameReservations are checked for owners that do not exist.
+	cleanupPeriod = 10 * time.Minute
+
+	// gracePeriod is how long a NameReservation is kept regardless of its owner, as it is created
+	// by admission before the owner is persisted.
+	gracePeriod = 5 * time.Minute
+)
+
+// NewController returns a controller that periodically deletes the NameReservations in the root
+// workspace whose owner does not exist, e.g. because the request creating it was rejected after
+// the name was reserved. Every shard cleans up the reservations of the workspaces it hosts.
+func NewController(
+	shardName string,
+	rootKcpClient kcpclient.Interface,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+	typeInformer tenancyinformer.ClusterWorkspaceTypeInformer,
+	apiExportInformer apisinformer.APIExportInformer,
+) *Controller {
+	return &Controller{
+		shardName:       shardName,
+		kcpClient:       rootKcpClient,
+		workspaceLister: workspaceInformer.Lister(),
+		typeLister:      typeInformer.Lister(),
+		apiExportLister: apiExportInformer.Lister(),
+		now:             time.Now,
+	}
+}
+
+type Controller struct {
+	shardName       string
+	kcpClient       kcpclient.Interface
+	workspaceLister tenancylister.ClusterWorkspaceLister
+	typeLister      tenancylister.ClusterWorkspaceTypeLister
+	apiExportLister apislister.APIExportLister
+
+	now func() time.Time
+}
+
+func (c *Controller) Start(ctx context.Context) {
+	defer runtime.HandleCrash()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	wait.UntilWithContext(ctx, c.cleanup, cleanupPeriod)
+}
+
+func (c *Controller) cleanup(ctx context.Context) {
+	reservations, err := c.kcpClient.TenancyV1alpha1().NameReservations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to list NameReservations: %w", controllerName, err))
+		return
+	}
+	for i := range reservations.Items {
+		r := &reservations.Items[i]
+		orphaned, err := c.isOrphaned(r)
+		if err != nil {
+			runtime.HandleError(fmt.Errorf("%q controller failed to check NameReservation %q: %w", controllerName, r.Name, err))
+			continue
+		}
+		if !orphaned {
+			continue
+		}
+		// the preconditions protect a reservation taken again by an owner meanwhile
+		err = c.kcpClient.TenancyV1alpha1().NameReservations().Delete(ctx, r.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &r.UID, ResourceVersion: &r.ResourceVersion},
+		})
+		if errors.IsNotFound(err) || errors.IsConflict(err) {
+			continue
+		} else if err != nil {
+			runtime.HandleError(fmt.Errorf("%q controller failed to delete NameReservation %q: %w", controllerName, r.Name, err))
+			continue
+		}
+		klog.Infof("Deleted NameReservation %q of %s %q, its owner %s|%s does not exist", r.Name, r.Spec.Resource, r.Spec.Name, r.Spec.Owner.Cluster, r.Spec.Owner.Name)
+	}
+}
+
+// isOrphaned returns whether the owner of the reservation is in a workspace hosted by this
+// shard, but does not exist or does not hold the reserved name.
+func (c *Controller) isOrphaned(r *tenancyv1alpha1.NameReservation) (bool, error) {
+	if c.now().Sub(r.CreationTimestamp.Time) < gracePeriod {
+		return false, nil
+	}
+	clusterName := logicalcluster.New(r.Spec.Owner.Cluster)
+	if hosted, err := c.hosts(clusterName); err != nil || !hosted {
+		return false, err
+	}
+	key := clusters.ToClusterAwareKey(clusterName, r.Spec.Owner.Name)
+
+	switch r.Spec.Resource {
+	case reservation.ClusterWorkspaceTypesResource:
+		_, err := c.typeLister.Get(key)
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	case reservation.APIExportIdentitiesResource:
+		apiExport, err := c.apiExportLister.Get(key)
+		if errors.IsNotFound(err) {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		if apiExport.Status.IdentityHash == r.Spec.Name {
+			return false, nil
+		}
+		for _, previous := range apiExport.Status.PreviousIdentityHashes {
+			if previous.IdentityHash == r.Spec.Name {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// hosts returns whether the given workspace is hosted by this shard. Workspaces whose
+// ClusterWorkspace is not known to this shard are not.
+func (c *Controller) hosts(clusterName logicalcluster.LogicalCluster) (bool, error) {
+	parent, name := clusterName.Split()
+	if parent.Empty() {
+		return clusterName == tenancyv1alpha1.RootCluster && c.shardName == tenancyv1alpha1.RootShard, nil
+	}
+	workspace, err := c.workspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return workspace.Status.Location.Current == c.shardName, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namereservation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	apislister "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reservation"
+)
+
+func TestCleanup(t *testing.T) {
+	now := time.Now()
+	old := metav1.NewTime(now.Add(-time.Hour))
+
+	newReservation := func(resource, name, cluster, owner string, created metav1.Time) *tenancyv1alpha1.NameReservation {
+		r := reservation.NewReservation(resource, name, tenancyv1alpha1.NameReservationOwner{Cluster: cluster, Name: owner})
+		r.CreationTimestamp = created
+		return r
+	}
+
+	workspaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ws := range []struct{ name, shard string }{{"here", "shard-1"}, {"elsewhere", "shard-2"}} {
+		require.NoError(t, workspaces.Add(&tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{Name: ws.name, ClusterName: "root:org"},
+			Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+				Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: ws.shard},
+			},
+		}))
+	}
+	types := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, types.Add(&tenancyv1alpha1.ClusterWorkspaceType{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", ClusterName: "root:org:here"},
+	}))
+	apiExports := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, apiExports.Add(&apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "export", ClusterName: "root:org:here"},
+		Status: apisv1alpha1.APIExportStatus{
+			IdentityHash:           "current",
+			PreviousIdentityHashes: []apisv1alpha1.APIExportPreviousIdentity{{IdentityHash: "previous"}},
+		},
+	}))
+
+	client := kcpfakeclient.NewSimpleClientset([]runtime.Object{
+		newReservation(reservation.ClusterWorkspaceTypesResource, "existing", "root:org:here", "existing", old),
+		newReservation(reservation.ClusterWorkspaceTypesResource, "missing", "root:org:here", "missing", old),
+		newReservation(reservation.ClusterWorkspaceTypesResource, "recent", "root:org:here", "recent", metav1.NewTime(now)),
+		newReservation(reservation.ClusterWorkspaceTypesResource, "remote", "root:org:elsewhere", "remote", old),
+		newReservation(reservation.ClusterWorkspaceTypesResource, "unknown", "root:org:unknown", "unknown", old),
+		newReservation(reservation.APIExportIdentitiesResource, "current", "root:org:here", "export", old),
+		newReservation(reservation.APIExportIdentitiesResource, "previous", "root:org:here", "export", old),
+		newReservation(reservation.APIExportIdentitiesResource, "expired", "root:org:here", "export", old),
+		newReservation(reservation.APIExportIdentitiesResource, "gone", "root:org:here", "gone", old),
+	}...)
+
+	c := &Controller{
+		shardName:       "shard-1",
+		kcpClient:       client,
+		workspaceLister: tenancylister.NewClusterWorkspaceLister(workspaces),
+		typeLister:      tenancylister.NewClusterWorkspaceTypeLister(types),
+		apiExportLister: apislister.NewAPIExportLister(apiExports),
+		now:             func() time.Time { return now },
+	}
+	c.cleanup(context.Background())
+
+	reservations, err := client.TenancyV1alpha1().NameReservations().List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	var got []string
+	for _, r := range reservations.Items {
+		got = append(got, r.Name)
+	}
+	require.ElementsMatch(t, []string{
+		reservation.ReservationName(reservation.ClusterWorkspaceTypesResource, "existing"),
+		reservation.ReservationName(reservation.ClusterWorkspaceTypesResource, "recent"),
+		reservation.ReservationName(reservation.ClusterWorkspaceTypesResource, "remote"),
+		reservation.ReservationName(reservation.ClusterWorkspaceTypesResource, "unknown"),
+		reservation.ReservationName(reservation.APIExportIdentitiesResource, "current"),
+		reservation.ReservationName(reservation.APIExportIdentitiesResource, "previous"),
+	}, got)
+}
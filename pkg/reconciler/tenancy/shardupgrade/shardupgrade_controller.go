@@ -0,0 +1,314 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shardupgrade
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-shard-upgrade"
+
+	// defaultTimeout is the timeout of a hook call if the hook does not specify one.
+	defaultTimeout = 10 * time.Second
+)
+
+// NewController returns a controller that upgrades ClusterWorkspaceShards one after the other as
+// requested by ShardUpgradePlans.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	planInformer tenancyinformer.ShardUpgradePlanInformer,
+	rootShardInformer tenancyinformer.ClusterWorkspaceShardInformer,
+	migrationInformer tenancyinformer.WorkspaceMigrationInformer,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue:            queue,
+		kcpClusterClient: kcpClusterClient,
+		planLister:       planInformer.Lister(),
+		shardLister:      rootShardInformer.Lister(),
+		migrationLister:  migrationInformer.Lister(),
+		notifyHook:       postNotification,
+	}
+	c.setCordon = c.patchCordon
+
+	planInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	}))
+
+	// plans wait for shards to report their version, and for migrations to finish
+	rootShardInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, _ interface{}) { c.enqueueAll() },
+	}))
+	migrationInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, _ interface{}) { c.enqueueAll() },
+		DeleteFunc: func(_ interface{}) { c.enqueueAll() },
+	}))
+
+	return c
+}
+
+// Controller cordons the shards of a ShardUpgradePlan one after the other, waits for the in-flight
+// WorkspaceMigrations from and to the shard, notifies the hook of the plan that the shard can be
+// upgraded, and uncordons the shard once it reports the version of the plan.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.ClusterInterface
+	planLister       tenancylister.ShardUpgradePlanLister
+	shardLister      tenancylister.ClusterWorkspaceShardLister
+	migrationLister  tenancylister.WorkspaceMigrationLister
+
+	setCordon  func(ctx context.Context, shard *tenancyv1alpha1.ClusterWorkspaceShard, plan string) error
+	notifyHook func(ctx context.Context, hook *tenancyv1alpha1.ShardUpgradeHook, notification *tenancyv1alpha1.ShardUpgradeNotification) error
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueAll enqueues the ShardUpgradePlans that are not completed.
+func (c *Controller) enqueueAll() {
+	plans, err := c.planLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, plan := range plans {
+		if plan.Status.Phase != tenancyv1alpha1.ShardUpgradePlanPhaseCompleted {
+			c.enqueue(plan)
+		}
+	}
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting ShardUpgradePlan controller")
+	defer klog.Info("Shutting down ShardUpgradePlan controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, err := c.planLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	previous := obj
+	obj = obj.DeepCopy()
+
+	// the progress is recorded even if the current step failed
+	reconcileErr := c.reconcile(ctx, obj)
+
+	if !equality.Semantic.DeepEqual(previous.Finalizers, obj.Finalizers) {
+		if err := c.patchFinalizers(ctx, previous, obj); err != nil {
+			return err
+		}
+		// the status patch must not fail on the resourceVersion precondition
+		previous = previous.DeepCopy()
+		previous.ResourceVersion = ""
+	}
+	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
+		if err := c.patchStatus(ctx, previous, obj); err != nil {
+			return err
+		}
+	}
+	return reconcileErr
+}
+
+func (c *Controller) patchStatus(ctx context.Context, previous, obj *tenancyv1alpha1.ShardUpgradePlan) error {
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(tenancyv1alpha1.ShardUpgradePlan{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ShardUpgradePlan %s|%s: %w", clusterName, obj.Name, err)
+	}
+	newData, err := json.Marshal(tenancyv1alpha1.ShardUpgradePlan{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ShardUpgradePlan %s|%s: %w", clusterName, obj.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ShardUpgradePlan %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().ShardUpgradePlans().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// patchFinalizers writes the finalizers, guarded by the resourceVersion.
+func (c *Controller) patchFinalizers(ctx context.Context, previous, obj *tenancyv1alpha1.ShardUpgradePlan) error {
+	finalizers := obj.Finalizers
+	if finalizers == nil {
+		finalizers = []string{}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": previous.ResourceVersion,
+			"finalizers":      finalizers,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kcpClusterClient.Cluster(logicalcluster.From(obj)).TenancyV1alpha1().ShardUpgradePlans().Patch(ctx, obj.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// patchCordon cordons the shard on behalf of the plan, or uncordons it if the plan is empty.
+func (c *Controller) patchCordon(ctx context.Context, shard *tenancyv1alpha1.ClusterWorkspaceShard, plan string) error {
+	var value interface{}
+	if plan != "" {
+		value = plan
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				tenancyv1alpha1.ShardUpgradeCordonAnnotationKey: value,
+			},
+		},
+		"spec": map[string]interface{}{
+			"unschedulable": plan != "",
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kcpClusterClient.Cluster(tenancyv1alpha1.RootCluster).TenancyV1alpha1().ClusterWorkspaceShards().Patch(ctx, shard.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+func (c *Controller) getShard(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+	return c.shardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, name))
+}
+
+// postNotification POSTs the notification to the hook, and fails unless it responds with a 2xx
+// status code.
+func postNotification(ctx context.Context, hook *tenancyv1alpha1.ShardUpgradeHook, notification *tenancyv1alpha1.ShardUpgradeNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	timeout := defaultTimeout
+	if hook.TimeoutSeconds > 0 {
+		timeout = time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(hook.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(hook.CABundle) {
+			return fmt.Errorf("invalid caBundle of hook")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("hook responded with %s", resp.Status)
+	}
+	return nil
+}
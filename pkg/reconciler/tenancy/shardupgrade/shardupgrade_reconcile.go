@@ -0,0 +1,237 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shardupgrade
+
+import (
+	"context"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func (c *Controller) reconcile(ctx context.Context, plan *tenancyv1alpha1.ShardUpgradePlan) error {
+	if plan.DeletionTimestamp != nil {
+		return c.abort(ctx, plan)
+	}
+	if plan.Status.Phase == tenancyv1alpha1.ShardUpgradePlanPhaseCompleted {
+		return nil
+	}
+	if plan.Status.Phase == "" {
+		plan.Status.Phase = tenancyv1alpha1.ShardUpgradePlanPhasePending
+	}
+
+	names, err := c.shardNames(plan)
+	if err != nil {
+		return err
+	}
+	plan.Status.Shards = shardStatuses(plan.Status.Shards, names)
+
+	for i := range plan.Status.Shards {
+		status := &plan.Status.Shards[i]
+		if status.Phase == tenancyv1alpha1.ShardUpgradePhaseCompleted {
+			continue
+		}
+
+		shard, err := c.getShard(status.Name)
+		if errors.IsNotFound(err) {
+			conditions.MarkFalse(plan, tenancyv1alpha1.ShardUpgradePlanValid, tenancyv1alpha1.ShardUpgradePlanReasonShardNotFound, conditionsv1alpha1.ConditionSeverityError, "ClusterWorkspaceShard %q does not exist.", status.Name)
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if status.Phase == tenancyv1alpha1.ShardUpgradePhasePending {
+			if other, err := c.planInProgress(plan); err != nil {
+				return err
+			} else if other != "" {
+				conditions.MarkFalse(plan, tenancyv1alpha1.ShardUpgradePlanValid, tenancyv1alpha1.ShardUpgradePlanReasonPlanInProgress, conditionsv1alpha1.ConditionSeverityInfo, "ShardUpgradePlan %s is upgrading shards.", other)
+				return nil
+			}
+			conditions.MarkTrue(plan, tenancyv1alpha1.ShardUpgradePlanValid)
+
+			if shard.Status.Version == plan.Spec.Version {
+				status.Phase = tenancyv1alpha1.ShardUpgradePhaseCompleted
+				continue
+			}
+			if plan.Spec.Paused {
+				plan.Status.CurrentShard = ""
+				return nil
+			}
+
+			// the finalizer makes sure the shard is uncordoned if the plan is deleted before it is done
+			plan.Finalizers = sets.NewString(plan.Finalizers...).Insert(tenancyv1alpha1.ShardUpgradePlanFinalizer).List()
+			if err := c.cordon(ctx, shard, plan.Name); err != nil {
+				return err
+			}
+			klog.Infof("Upgrading ClusterWorkspaceShard %q to version %q", shard.Name, plan.Spec.Version)
+			plan.Status.Phase = tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading
+			plan.Status.CurrentShard = shard.Name
+			status.Phase = tenancyv1alpha1.ShardUpgradePhaseDraining
+			return nil // wait for the cordon to be observed
+		}
+		conditions.MarkTrue(plan, tenancyv1alpha1.ShardUpgradePlanValid)
+		plan.Status.CurrentShard = shard.Name
+
+		if status.Phase == tenancyv1alpha1.ShardUpgradePhaseDraining {
+			if err := c.cordon(ctx, shard, plan.Name); err != nil {
+				return err
+			}
+			if inFlight, err := c.hasMigrationsInFlight(shard.Name); err != nil {
+				return err
+			} else if inFlight {
+				return nil // wait for the migrations to finish
+			}
+
+			if plan.Spec.Hook != nil {
+				notification := &tenancyv1alpha1.ShardUpgradeNotification{
+					Plan:    plan.Name,
+					Shard:   shard.Name,
+					Version: plan.Spec.Version,
+					BaseURL: shard.Spec.BaseURL,
+				}
+				if err := c.notifyHook(ctx, plan.Spec.Hook, notification); err != nil {
+					conditions.MarkFalse(plan, tenancyv1alpha1.ShardUpgradeHookNotified, tenancyv1alpha1.ShardUpgradeReasonHookFailed, conditionsv1alpha1.ConditionSeverityWarning, "Failed to notify the hook for shard %q: %v.", shard.Name, err)
+					return err // retry with backoff
+				}
+				conditions.MarkTrue(plan, tenancyv1alpha1.ShardUpgradeHookNotified)
+			}
+			status.Phase = tenancyv1alpha1.ShardUpgradePhaseUpgrading
+		}
+
+		if status.Phase == tenancyv1alpha1.ShardUpgradePhaseUpgrading {
+			if shard.Status.Version != plan.Spec.Version {
+				return nil // wait for the shard to be upgraded
+			}
+			if shard.Annotations[tenancyv1alpha1.ShardUpgradeCordonAnnotationKey] == plan.Name {
+				if err := c.setCordon(ctx, shard, ""); err != nil {
+					return err
+				}
+			}
+			klog.Infof("Upgraded ClusterWorkspaceShard %q to version %q", shard.Name, plan.Spec.Version)
+			status.Phase = tenancyv1alpha1.ShardUpgradePhaseCompleted
+		}
+	}
+
+	plan.Finalizers = sets.NewString(plan.Finalizers...).Delete(tenancyv1alpha1.ShardUpgradePlanFinalizer).List()
+	plan.Status.Phase = tenancyv1alpha1.ShardUpgradePlanPhaseCompleted
+	plan.Status.CurrentShard = ""
+	return nil
+}
+
+// abort uncordons the shards cordoned by a deleted plan.
+func (c *Controller) abort(ctx context.Context, plan *tenancyv1alpha1.ShardUpgradePlan) error {
+	if !sets.NewString(plan.Finalizers...).Has(tenancyv1alpha1.ShardUpgradePlanFinalizer) {
+		return nil
+	}
+
+	shards, err := c.shardLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, shard := range shards {
+		if shard.Annotations[tenancyv1alpha1.ShardUpgradeCordonAnnotationKey] != plan.Name {
+			continue
+		}
+		if err := c.setCordon(ctx, shard, ""); err != nil {
+			return err
+		}
+	}
+
+	plan.Finalizers = sets.NewString(plan.Finalizers...).Delete(tenancyv1alpha1.ShardUpgradePlanFinalizer).List()
+	return nil
+}
+
+// cordon cordons the shard on behalf of the plan, unless it is cordoned already. Shards cordoned
+// by someone else are left cordoned when the plan is done.
+func (c *Controller) cordon(ctx context.Context, shard *tenancyv1alpha1.ClusterWorkspaceShard, plan string) error {
+	if shard.Spec.Unschedulable {
+		return nil
+	}
+	return c.setCordon(ctx, shard, plan)
+}
+
+// shardNames returns the names of the shards of the plan, in order.
+func (c *Controller) shardNames(plan *tenancyv1alpha1.ShardUpgradePlan) ([]string, error) {
+	if len(plan.Spec.Shards) > 0 {
+		return plan.Spec.Shards, nil
+	}
+	shards, err := c.shardLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(shards))
+	for _, shard := range shards {
+		names = append(names, shard.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// shardStatuses returns the statuses of the named shards, keeping the phases of the existing ones.
+func shardStatuses(existing []tenancyv1alpha1.ShardUpgradeStatus, names []string) []tenancyv1alpha1.ShardUpgradeStatus {
+	phases := make(map[string]tenancyv1alpha1.ShardUpgradePhase, len(existing))
+	for _, status := range existing {
+		phases[status.Name] = status.Phase
+	}
+	statuses := make([]tenancyv1alpha1.ShardUpgradeStatus, 0, len(names))
+	for _, name := range names {
+		phase, found := phases[name]
+		if !found || phase == "" {
+			phase = tenancyv1alpha1.ShardUpgradePhasePending
+		}
+		statuses = append(statuses, tenancyv1alpha1.ShardUpgradeStatus{Name: name, Phase: phase})
+	}
+	return statuses
+}
+
+// planInProgress returns the name of another plan that is upgrading shards, if any.
+func (c *Controller) planInProgress(plan *tenancyv1alpha1.ShardUpgradePlan) (string, error) {
+	plans, err := c.planLister.List(labels.Everything())
+	if err != nil {
+		return "", err
+	}
+	for _, other := range plans {
+		if other.Name != plan.Name && other.Status.Phase == tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading {
+			return other.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// hasMigrationsInFlight returns whether WorkspaceMigrations from or to the shard are in progress.
+func (c *Controller) hasMigrationsInFlight(shard string) (bool, error) {
+	migrations, err := c.migrationLister.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, migration := range migrations {
+		switch migration.Status.Phase {
+		case tenancyv1alpha1.WorkspaceMigrationPhaseCopying, tenancyv1alpha1.WorkspaceMigrationPhaseCuttingOver, tenancyv1alpha1.WorkspaceMigrationPhaseCleaningUp:
+			if migration.Status.SourceShard == shard || migration.Spec.TargetShard == shard {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
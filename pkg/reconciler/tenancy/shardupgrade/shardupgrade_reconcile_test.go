@@ -0,0 +1,302 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shardupgrade
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	now := metav1.Now()
+
+	type shard struct {
+		version  string
+		cordon   string // the plan that cordoned the shard, "-" for someone else
+		notFound bool
+	}
+	tests := map[string]struct {
+		shards     []string
+		phases     []tenancyv1alpha1.ShardUpgradePhase
+		finalizers []string
+		deleting   bool
+		paused     bool
+		hook       bool
+		hookErr    error
+		state      map[string]shard
+		otherPlan  bool
+		migration  *tenancyv1alpha1.WorkspaceMigration
+
+		wantPhase      tenancyv1alpha1.ShardUpgradePlanPhase
+		wantShard      string
+		wantPhases     []tenancyv1alpha1.ShardUpgradePhase
+		wantReason     string
+		wantFinalizers []string
+		wantCordon     map[string]string
+		wantNotified   bool
+		wantErr        bool
+	}{
+		"all shards in the order of their names": {
+			state:          map[string]shard{"shard-b": {version: "v1"}, "shard-a": {version: "v1"}},
+			wantPhase:      tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading,
+			wantShard:      "shard-a",
+			wantPhases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining, tenancyv1alpha1.ShardUpgradePhasePending},
+			wantFinalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantCordon:     map[string]string{"shard-a": "upgrade"},
+		},
+		"shards in the given order": {
+			shards:         []string{"shard-b", "shard-a"},
+			state:          map[string]shard{"shard-b": {version: "v1"}, "shard-a": {version: "v1"}},
+			wantPhase:      tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading,
+			wantShard:      "shard-b",
+			wantPhases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining, tenancyv1alpha1.ShardUpgradePhasePending},
+			wantFinalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantCordon:     map[string]string{"shard-b": "upgrade"},
+		},
+		"shards already on the version are skipped": {
+			state:      map[string]shard{"shard-a": {version: "v2"}, "shard-b": {version: "v2"}},
+			wantPhase:  tenancyv1alpha1.ShardUpgradePlanPhaseCompleted,
+			wantPhases: []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseCompleted, tenancyv1alpha1.ShardUpgradePhaseCompleted},
+		},
+		"shard not found": {
+			shards:     []string{"shard-a"},
+			state:      map[string]shard{"shard-a": {notFound: true}},
+			wantPhase:  tenancyv1alpha1.ShardUpgradePlanPhasePending,
+			wantPhases: []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhasePending},
+			wantReason: tenancyv1alpha1.ShardUpgradePlanReasonShardNotFound,
+		},
+		"other plan in progress": {
+			state:      map[string]shard{"shard-a": {version: "v1"}},
+			otherPlan:  true,
+			wantPhase:  tenancyv1alpha1.ShardUpgradePlanPhasePending,
+			wantPhases: []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhasePending},
+			wantReason: tenancyv1alpha1.ShardUpgradePlanReasonPlanInProgress,
+		},
+		"paused": {
+			state:      map[string]shard{"shard-a": {version: "v1"}},
+			paused:     true,
+			wantPhase:  tenancyv1alpha1.ShardUpgradePlanPhasePending,
+			wantPhases: []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhasePending},
+		},
+		"waiting for in-flight migrations": {
+			state:          map[string]shard{"shard-a": {version: "v1", cordon: "upgrade"}},
+			phases:         []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining},
+			finalizers:     []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			hook:           true,
+			migration:      newMigration(tenancyv1alpha1.WorkspaceMigrationPhaseCopying, "shard-b", "shard-a"),
+			wantPhase:      tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading,
+			wantShard:      "shard-a",
+			wantPhases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining},
+			wantFinalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+		},
+		"completed migrations are not waited for": {
+			state:          map[string]shard{"shard-a": {version: "v1", cordon: "upgrade"}},
+			phases:         []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining},
+			finalizers:     []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			hook:           true,
+			migration:      newMigration(tenancyv1alpha1.WorkspaceMigrationPhaseCompleted, "shard-a", "shard-b"),
+			wantPhase:      tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading,
+			wantShard:      "shard-a",
+			wantPhases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseUpgrading},
+			wantFinalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantNotified:   true,
+		},
+		"hook fails": {
+			state:          map[string]shard{"shard-a": {version: "v1", cordon: "upgrade"}},
+			phases:         []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining},
+			finalizers:     []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			hook:           true,
+			hookErr:        errors.New("boom"),
+			wantPhase:      tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading,
+			wantShard:      "shard-a",
+			wantPhases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining},
+			wantFinalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantNotified:   true,
+			wantErr:        true,
+		},
+		"without hook": {
+			state:          map[string]shard{"shard-a": {version: "v1", cordon: "upgrade"}},
+			phases:         []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining},
+			finalizers:     []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantPhase:      tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading,
+			wantShard:      "shard-a",
+			wantPhases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseUpgrading},
+			wantFinalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+		},
+		"upgraded shard is uncordoned and the next one cordoned": {
+			state:          map[string]shard{"shard-a": {version: "v2", cordon: "upgrade"}, "shard-b": {version: "v1"}},
+			phases:         []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseUpgrading, tenancyv1alpha1.ShardUpgradePhasePending},
+			finalizers:     []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantPhase:      tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading,
+			wantShard:      "shard-b",
+			wantPhases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseCompleted, tenancyv1alpha1.ShardUpgradePhaseDraining},
+			wantFinalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantCordon:     map[string]string{"shard-a": "", "shard-b": "upgrade"},
+		},
+		"last shard upgraded": {
+			state:      map[string]shard{"shard-a": {version: "v2", cordon: "upgrade"}},
+			phases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseUpgrading},
+			finalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantPhase:  tenancyv1alpha1.ShardUpgradePlanPhaseCompleted,
+			wantPhases: []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseCompleted},
+			wantCordon: map[string]string{"shard-a": ""},
+		},
+		"shard cordoned by someone else stays cordoned": {
+			state:      map[string]shard{"shard-a": {version: "v2", cordon: "-"}},
+			phases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseUpgrading},
+			finalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantPhase:  tenancyv1alpha1.ShardUpgradePlanPhaseCompleted,
+			wantPhases: []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseCompleted},
+		},
+		"waiting for the version": {
+			state:          map[string]shard{"shard-a": {version: "v1", cordon: "upgrade"}},
+			phases:         []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseUpgrading},
+			finalizers:     []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			wantPhase:      tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading,
+			wantShard:      "shard-a",
+			wantPhases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseUpgrading},
+			wantFinalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+		},
+		"deleted while draining": {
+			state:      map[string]shard{"shard-a": {version: "v1", cordon: "upgrade"}, "shard-b": {version: "v1", cordon: "-"}},
+			phases:     []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining, tenancyv1alpha1.ShardUpgradePhasePending},
+			finalizers: []string{tenancyv1alpha1.ShardUpgradePlanFinalizer},
+			deleting:   true,
+			wantPhase:  tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading,
+			wantPhases: []tenancyv1alpha1.ShardUpgradePhase{tenancyv1alpha1.ShardUpgradePhaseDraining, tenancyv1alpha1.ShardUpgradePhasePending},
+			wantShard:  "shard-a",
+			wantCordon: map[string]string{"shard-a": ""},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			shardIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for name, state := range tc.state {
+				if state.notFound {
+					continue
+				}
+				shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+					ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: "root"},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://" + name, Unschedulable: state.cordon != ""},
+					Status:     tenancyv1alpha1.ClusterWorkspaceShardStatus{Version: state.version},
+				}
+				if state.cordon != "" && state.cordon != "-" {
+					shard.Annotations = map[string]string{tenancyv1alpha1.ShardUpgradeCordonAnnotationKey: state.cordon}
+				}
+				require.NoError(t, shardIndexer.Add(shard))
+			}
+			planIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.otherPlan {
+				require.NoError(t, planIndexer.Add(&tenancyv1alpha1.ShardUpgradePlan{
+					ObjectMeta: metav1.ObjectMeta{Name: "other", ClusterName: "root"},
+					Status:     tenancyv1alpha1.ShardUpgradePlanStatus{Phase: tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading},
+				}))
+			}
+			migrationIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tc.migration != nil {
+				require.NoError(t, migrationIndexer.Add(tc.migration))
+			}
+
+			cordons := map[string]string{}
+			notified := false
+			c := &Controller{
+				planLister:      tenancylister.NewShardUpgradePlanLister(planIndexer),
+				shardLister:     tenancylister.NewClusterWorkspaceShardLister(shardIndexer),
+				migrationLister: tenancylister.NewWorkspaceMigrationLister(migrationIndexer),
+				setCordon: func(ctx context.Context, shard *tenancyv1alpha1.ClusterWorkspaceShard, plan string) error {
+					cordons[shard.Name] = plan
+					return nil
+				},
+				notifyHook: func(ctx context.Context, hook *tenancyv1alpha1.ShardUpgradeHook, notification *tenancyv1alpha1.ShardUpgradeNotification) error {
+					require.Equal(t, &tenancyv1alpha1.ShardUpgradeNotification{Plan: "upgrade", Shard: "shard-a", Version: "v2", BaseURL: "https://shard-a"}, notification)
+					notified = true
+					return tc.hookErr
+				},
+			}
+
+			plan := &tenancyv1alpha1.ShardUpgradePlan{
+				ObjectMeta: metav1.ObjectMeta{Name: "upgrade", ClusterName: "root", Finalizers: tc.finalizers},
+				Spec:       tenancyv1alpha1.ShardUpgradePlanSpec{Version: "v2", Shards: tc.shards, Paused: tc.paused},
+			}
+			if tc.hook {
+				plan.Spec.Hook = &tenancyv1alpha1.ShardUpgradeHook{URL: "https://hook"}
+			}
+			if len(tc.phases) > 0 {
+				plan.Status.Phase = tenancyv1alpha1.ShardUpgradePlanPhaseUpgrading
+				plan.Status.CurrentShard = "shard-a"
+				names := tc.shards
+				if len(names) == 0 {
+					names = []string{"shard-a", "shard-b"}[:len(tc.phases)]
+				}
+				for i, phase := range tc.phases {
+					plan.Status.Shards = append(plan.Status.Shards, tenancyv1alpha1.ShardUpgradeStatus{Name: names[i], Phase: phase})
+				}
+			}
+			if tc.deleting {
+				plan.DeletionTimestamp = &now
+			}
+
+			err := c.reconcile(context.Background(), plan)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.wantPhase, plan.Status.Phase, "phase")
+			require.Equal(t, tc.wantShard, plan.Status.CurrentShard, "current shard")
+			var phases []tenancyv1alpha1.ShardUpgradePhase
+			for _, status := range plan.Status.Shards {
+				phases = append(phases, status.Phase)
+			}
+			require.Equal(t, tc.wantPhases, phases, "shard phases")
+			if tc.wantReason != "" {
+				require.Equal(t, corev1.ConditionFalse, conditions.Get(plan, tenancyv1alpha1.ShardUpgradePlanValid).Status)
+				require.Equal(t, tc.wantReason, conditions.GetReason(plan, tenancyv1alpha1.ShardUpgradePlanValid))
+			}
+			if len(tc.wantFinalizers) == 0 {
+				require.Empty(t, plan.Finalizers, "finalizers")
+			} else {
+				require.Equal(t, tc.wantFinalizers, plan.Finalizers, "finalizers")
+			}
+			if tc.wantCordon == nil {
+				tc.wantCordon = map[string]string{}
+			}
+			require.Equal(t, tc.wantCordon, cordons, "cordons")
+			require.Equal(t, tc.wantNotified, notified, "notified")
+		})
+	}
+}
+
+func newMigration(phase tenancyv1alpha1.WorkspaceMigrationPhase, source, target string) *tenancyv1alpha1.WorkspaceMigration {
+	return &tenancyv1alpha1.WorkspaceMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: "move", ClusterName: "root"},
+		Spec:       tenancyv1alpha1.WorkspaceMigrationSpec{Workspace: "root:org:team", TargetShard: target},
+		Status:     tenancyv1alpha1.WorkspaceMigrationStatus{Phase: phase, SourceShard: source},
+	}
+}
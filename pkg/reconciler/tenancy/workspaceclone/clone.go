@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceclone
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+var (
+	// cloneFirst are the resources which other objects depend on, cloned in this order before all others.
+	cloneFirst = []schema.GroupResource{
+		{Resource: "namespaces"},
+		{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"},
+		{Group: "apis.kcp.dev", Resource: "apiresourceschemas"},
+		{Group: "apis.kcp.dev", Resource: "apiexports"},
+	}
+
+	// skipped are the resources which are never cloned: events are ephemeral, and the others
+	// are about child workspaces, which are not cloned.
+	skipped = sets.NewString(
+		schema.GroupResource{Resource: "events"}.String(),
+		schema.GroupResource{Group: "events.k8s.io", Resource: "events"}.String(),
+		tenancyv1alpha1.Resource("workspaces").String(),
+		tenancyv1alpha1.Resource("clusterworkspaces").String(),
+		tenancyv1alpha1.Resource("workspaceclones").String(),
+		tenancyv1alpha1.Resource("workspacerequestsets").String(),
+		tenancyv1alpha1.Resource("accessrequests").String(),
+	)
+
+	namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+)
+
+// cloneResourcesAsUser creates the objects of the selected resources of the source workspace in
+// the target workspace, as the user of the clone. Objects existing in the target workspace are
+// skipped, such that cloning can be retried, e.g. until cloned APIBindings are bound. It returns
+// the number of created objects.
+func (c *Controller) cloneResourcesAsUser(ctx context.Context, clone *tenancyv1alpha1.WorkspaceClone, selected resourceSelector) (int64, error) {
+	parent := logicalcluster.From(clone)
+	source, err := c.userConfig(clone, parent.Join(clone.Spec.Source))
+	if err != nil {
+		return 0, err
+	}
+	target, err := c.userConfig(clone, parent.Join(clone.Spec.Target))
+	if err != nil {
+		return 0, err
+	}
+	sourceDiscovery, err := discovery.NewDiscoveryClientForConfig(source)
+	if err != nil {
+		return 0, err
+	}
+	sourceClient, err := dynamic.NewForConfig(source)
+	if err != nil {
+		return 0, err
+	}
+	targetClient, err := dynamic.NewForConfig(target)
+	if err != nil {
+		return 0, err
+	}
+
+	resources, err := discoverResources(sourceDiscovery, selected)
+	if err != nil {
+		return 0, err
+	}
+
+	var cloned int64
+	for _, gvr := range resources {
+		list, err := sourceClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return cloned, fmt.Errorf("failed to list %s: %w", gvr, err)
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if isSystemObject(gvr.GroupResource(), obj) {
+				continue
+			}
+			created, err := cloneObject(ctx, targetClient, gvr, obj)
+			if err != nil {
+				return cloned, fmt.Errorf("failed to clone %s %s/%s: %w", gvr, obj.GetNamespace(), obj.GetName(), err)
+			}
+			if created {
+				cloned++
+			}
+		}
+	}
+	return cloned, nil
+}
+
+// discoverResources returns the selected resources of the workspace which can be listed and
+// created, with the ones of cloneFirst first.
+func discoverResources(client discovery.DiscoveryInterface, selected resourceSelector) ([]schema.GroupVersionResource, error) {
+	lists, err := discovery.ServerPreferredResources(client)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []schema.GroupVersionResource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range list.APIResources {
+			gvr := gv.WithResource(resource.Name)
+			if strings.Contains(resource.Name, "/") || !sets.NewString(resource.Verbs...).HasAll("list", "create") {
+				continue
+			}
+			if skipped.Has(gvr.GroupResource().String()) || !selected(gvr.GroupResource()) {
+				continue
+			}
+			resources = append(resources, gvr)
+		}
+	}
+
+	order := func(gr schema.GroupResource) int {
+		for i, first := range cloneFirst {
+			if first == gr {
+				return i
+			}
+		}
+		return len(cloneFirst)
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return order(resources[i].GroupResource()) < order(resources[j].GroupResource())
+	})
+	return resources, nil
+}
+
+// isSystemObject returns whether the object is created by kcp in every workspace, or must not be
+// cloned because it belongs to the source workspace, like service account tokens.
+func isSystemObject(gr schema.GroupResource, obj *unstructured.Unstructured) bool {
+	switch {
+	case gr.Group == "rbac.authorization.k8s.io":
+		return strings.HasPrefix(obj.GetName(), "system:")
+	case gr == schema.GroupResource{Resource: "secrets"}:
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return secretType == string(corev1.SecretTypeServiceAccountToken)
+	}
+	return false
+}
+
+// cloneObject creates the object without its status, the fields owned by the storage and its owner
+// references. Missing namespaces are created on the way. It returns whether the object was created.
+func cloneObject(ctx context.Context, client dynamic.Interface, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (bool, error) {
+	cloned := obj.DeepCopy()
+	for _, field := range []string{"resourceVersion", "uid", "selfLink", "creationTimestamp", "generation", "managedFields", "ownerReferences", "clusterName", "deletionTimestamp", "deletionGracePeriodSeconds"} {
+		unstructured.RemoveNestedField(cloned.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(cloned.Object, "status")
+
+	_, err := client.Resource(gvr).Namespace(obj.GetNamespace()).Create(ctx, cloned, metav1.CreateOptions{})
+	if errors.IsNotFound(err) && obj.GetNamespace() != "" {
+		namespace := &unstructured.Unstructured{}
+		namespace.SetAPIVersion("v1")
+		namespace.SetKind("Namespace")
+		namespace.SetName(obj.GetNamespace())
+		if _, err := client.Resource(namespacesGVR).Create(ctx, namespace, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return false, err
+		}
+		_, err = client.Resource(gvr).Namespace(obj.GetNamespace()).Create(ctx, cloned, metav1.CreateOptions{})
+	}
+	if errors.IsAlreadyExists(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
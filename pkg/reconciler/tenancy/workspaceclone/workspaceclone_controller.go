@@ -0,0 +1,244 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceclone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-workspace-clone"
+)
+
+// NewController returns a controller that clones ClusterWorkspaces as requested by WorkspaceClones.
+// The workspaces are accessed with the given config, impersonating the user of the clone.
+func NewController(
+	config *rest.Config,
+	kcpClusterClient kcpclient.ClusterInterface,
+	cloneInformer tenancyinformer.WorkspaceCloneInformer,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue:            queue,
+		config:           config,
+		kcpClusterClient: kcpClusterClient,
+		cloneLister:      cloneInformer.Lister(),
+		workspaceLister:  workspaceInformer.Lister(),
+	}
+	c.createWorkspace = c.createWorkspaceAsUser
+	c.cloneResources = c.cloneResourcesAsUser
+
+	cloneInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	}))
+
+	// clones wait for their source and target workspaces to be ready
+	workspaceInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueClonesOf(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueClonesOf(obj) },
+	}))
+
+	return c
+}
+
+// Controller creates the target workspace of a WorkspaceClone, and clones the APIBindings, the RBAC
+// and the selected contents of the source workspace into it, on behalf of the user of the clone.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	config           *rest.Config
+	kcpClusterClient kcpclient.ClusterInterface
+	cloneLister      tenancylister.WorkspaceCloneLister
+	workspaceLister  tenancylister.ClusterWorkspaceLister
+
+	createWorkspace func(ctx context.Context, clone *tenancyv1alpha1.WorkspaceClone, workspace *tenancyv1alpha1.ClusterWorkspace) error
+	cloneResources  func(ctx context.Context, clone *tenancyv1alpha1.WorkspaceClone, selected resourceSelector) (int64, error)
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueClonesOf enqueues the WorkspaceClones with the ClusterWorkspace as source or target.
+func (c *Controller) enqueueClonesOf(obj interface{}) {
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+	clones, err := c.cloneLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	clusterName := logicalcluster.From(workspace)
+	for _, clone := range clones {
+		if logicalcluster.From(clone) == clusterName && (clone.Spec.Source == workspace.Name || clone.Spec.Target == workspace.Name) {
+			c.enqueue(clone)
+		}
+	}
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting WorkspaceClone controller")
+	defer klog.Info("Shutting down WorkspaceClone controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, err := c.cloneLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	previous := obj
+	obj = obj.DeepCopy()
+
+	// the progress is recorded even if the current step failed
+	reconcileErr := c.reconcile(ctx, obj)
+
+	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
+		if err := c.patchStatus(ctx, previous, obj); err != nil {
+			return err
+		}
+	}
+	return reconcileErr
+}
+
+// userConfig returns a copy of the config pointing to the logical cluster, impersonating the user
+// of the clone.
+func (c *Controller) userConfig(clone *tenancyv1alpha1.WorkspaceClone, cluster logicalcluster.LogicalCluster) (*rest.Config, error) {
+	if clone.Spec.User == "" {
+		return nil, fmt.Errorf("WorkspaceClone %s|%s has no user", logicalcluster.From(clone), clone.Name)
+	}
+	config := rest.CopyConfig(c.config)
+	config.Host = strings.TrimSuffix(config.Host, "/") + cluster.Path()
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: clone.Spec.User,
+		Groups:   clone.Spec.Groups,
+	}
+	return config, nil
+}
+
+// createWorkspaceAsUser creates the target ClusterWorkspace next to the clone as the user of the clone.
+func (c *Controller) createWorkspaceAsUser(ctx context.Context, clone *tenancyv1alpha1.WorkspaceClone, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	config, err := c.userConfig(clone, logicalcluster.From(clone))
+	if err != nil {
+		return err
+	}
+	client, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	_, err = client.TenancyV1alpha1().ClusterWorkspaces().Create(ctx, workspace, metav1.CreateOptions{})
+	return err
+}
+
+func (c *Controller) patchStatus(ctx context.Context, previous, obj *tenancyv1alpha1.WorkspaceClone) error {
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(tenancyv1alpha1.WorkspaceClone{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for WorkspaceClone %s|%s: %w", clusterName, obj.Name, err)
+	}
+	newData, err := json.Marshal(tenancyv1alpha1.WorkspaceClone{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for WorkspaceClone %s|%s: %w", clusterName, obj.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for WorkspaceClone %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().WorkspaceClones().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
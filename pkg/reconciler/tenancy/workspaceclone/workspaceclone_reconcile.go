@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceclone
+
+import (
+	"context"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+// cloneStep clones the selected resources, recording the progress in the condition.
+type cloneStep struct {
+	condition conditionsv1alpha1.ConditionType
+	selected  resourceSelector
+}
+
+func (c *Controller) reconcile(ctx context.Context, clone *tenancyv1alpha1.WorkspaceClone) error {
+	switch clone.Status.Phase {
+	case tenancyv1alpha1.WorkspaceClonePhaseCompleted, tenancyv1alpha1.WorkspaceClonePhaseFailed:
+		return nil
+	}
+
+	clusterName := logicalcluster.From(clone)
+	source, err := c.workspaceLister.Get(clusters.ToClusterAwareKey(clusterName, clone.Spec.Source))
+	if errors.IsNotFound(err) {
+		clone.Status.Phase = tenancyv1alpha1.WorkspaceClonePhasePending
+		conditions.MarkFalse(clone, tenancyv1alpha1.WorkspaceCloneWorkspaceCreated, tenancyv1alpha1.WorkspaceCloneReasonSourceNotFound, conditionsv1alpha1.ConditionSeverityError, "ClusterWorkspace %s does not exist.", clone.Spec.Source)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if source.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		clone.Status.Phase = tenancyv1alpha1.WorkspaceClonePhasePending
+		conditions.MarkFalse(clone, tenancyv1alpha1.WorkspaceCloneWorkspaceCreated, tenancyv1alpha1.WorkspaceCloneReasonSourceNotReady, conditionsv1alpha1.ConditionSeverityInfo, "ClusterWorkspace %s is not ready.", clone.Spec.Source)
+		return nil
+	}
+
+	target, err := c.workspaceLister.Get(clusters.ToClusterAwareKey(clusterName, clone.Spec.Target))
+	if errors.IsNotFound(err) {
+		err := c.createWorkspace(ctx, clone, desiredWorkspace(clone, source))
+		if errors.IsForbidden(err) || errors.IsInvalid(err) || errors.IsAlreadyExists(err) {
+			clone.Status.Phase = tenancyv1alpha1.WorkspaceClonePhaseFailed
+			conditions.MarkFalse(clone, tenancyv1alpha1.WorkspaceCloneWorkspaceCreated, tenancyv1alpha1.WorkspaceCloneReasonCreationFailed, conditionsv1alpha1.ConditionSeverityError, "Failed to create ClusterWorkspace %s: %v", clone.Spec.Target, err)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		clone.Status.Phase = tenancyv1alpha1.WorkspaceClonePhaseCreating
+		conditions.MarkFalse(clone, tenancyv1alpha1.WorkspaceCloneWorkspaceCreated, tenancyv1alpha1.WorkspaceCloneReasonTargetNotReady, conditionsv1alpha1.ConditionSeverityInfo, "ClusterWorkspace %s is not ready.", clone.Spec.Target)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if target.Annotations[tenancyv1alpha1.WorkspaceCloneAnnotationKey] != clone.Name {
+		clone.Status.Phase = tenancyv1alpha1.WorkspaceClonePhaseFailed
+		conditions.MarkFalse(clone, tenancyv1alpha1.WorkspaceCloneWorkspaceCreated, tenancyv1alpha1.WorkspaceCloneReasonTargetExists, conditionsv1alpha1.ConditionSeverityError, "ClusterWorkspace %s exists already.", clone.Spec.Target)
+		return nil
+	}
+	if target.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		clone.Status.Phase = tenancyv1alpha1.WorkspaceClonePhaseCreating
+		conditions.MarkFalse(clone, tenancyv1alpha1.WorkspaceCloneWorkspaceCreated, tenancyv1alpha1.WorkspaceCloneReasonTargetNotReady, conditionsv1alpha1.ConditionSeverityInfo, "ClusterWorkspace %s is not ready.", clone.Spec.Target)
+		return nil
+	}
+	conditions.MarkTrue(clone, tenancyv1alpha1.WorkspaceCloneWorkspaceCreated)
+	clone.Status.Phase = tenancyv1alpha1.WorkspaceClonePhaseCloning
+
+	// the APIs first, such that the bound resources exist for the contents
+	steps := []cloneStep{
+		{condition: tenancyv1alpha1.WorkspaceCloneAPIBindingsCloned, selected: isAPIBinding},
+		{condition: tenancyv1alpha1.WorkspaceCloneRBACCloned, selected: isRBAC},
+	}
+	if clone.Spec.Contents != nil {
+		steps = append(steps, cloneStep{condition: tenancyv1alpha1.WorkspaceCloneContentsCloned, selected: contentsSelector(clone.Spec.Contents)})
+	}
+	for _, step := range steps {
+		if conditions.IsTrue(clone, step.condition) {
+			continue
+		}
+		cloned, err := c.cloneResources(ctx, clone, step.selected)
+		clone.Status.ClonedObjects += cloned
+		if err != nil {
+			conditions.MarkFalse(clone, step.condition, tenancyv1alpha1.WorkspaceCloneReasonCloneFailed, conditionsv1alpha1.ConditionSeverityWarning, "%v", err)
+			return err
+		}
+		conditions.MarkTrue(clone, step.condition)
+	}
+
+	clone.Status.Phase = tenancyv1alpha1.WorkspaceClonePhaseCompleted
+	return nil
+}
+
+// desiredWorkspace returns the target ClusterWorkspace of the clone, with the spec of the source.
+func desiredWorkspace(clone *tenancyv1alpha1.WorkspaceClone, source *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	return &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: clone.Spec.Target,
+			Annotations: map[string]string{
+				tenancyv1alpha1.WorkspaceCloneAnnotationKey: clone.Name,
+			},
+		},
+		Spec: *source.Spec.DeepCopy(),
+	}
+}
+
+// resourceSelector selects the resources cloned in a step.
+type resourceSelector func(gr schema.GroupResource) bool
+
+func isAPIBinding(gr schema.GroupResource) bool {
+	return gr == schema.GroupResource{Group: "apis.kcp.dev", Resource: "apibindings"}
+}
+
+func isRBAC(gr schema.GroupResource) bool {
+	return gr.Group == "rbac.authorization.k8s.io"
+}
+
+// contentsSelector selects the included and not excluded resources, apart from the APIBindings
+// and the RBAC which are cloned before.
+func contentsSelector(contents *tenancyv1alpha1.WorkspaceCloneContents) resourceSelector {
+	matches := func(resources []tenancyv1alpha1.WorkspaceCloneResource, gr schema.GroupResource) bool {
+		for _, r := range resources {
+			if r.Group == gr.Group && (r.Resource == "*" || r.Resource == gr.Resource) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(gr schema.GroupResource) bool {
+		if isAPIBinding(gr) || isRBAC(gr) {
+			return false
+		}
+		if len(contents.Include) > 0 && !matches(contents.Include, gr) {
+			return false
+		}
+		return !matches(contents.Exclude, gr)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceclone
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	workspace := func(name, clone string, phase tenancyv1alpha1.ClusterWorkspacePhaseType) *tenancyv1alpha1.ClusterWorkspace {
+		ws := &tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: "root:org"},
+			Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Team"},
+			Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: phase},
+		}
+		if clone != "" {
+			ws.Annotations = map[string]string{tenancyv1alpha1.WorkspaceCloneAnnotationKey: clone}
+		}
+		return ws
+	}
+	ready := tenancyv1alpha1.ClusterWorkspacePhaseReady
+
+	tests := map[string]struct {
+		workspaces []*tenancyv1alpha1.ClusterWorkspace
+		contents   *tenancyv1alpha1.WorkspaceCloneContents
+		status     tenancyv1alpha1.WorkspaceCloneStatus
+		createErr  error
+		cloneErr   error
+
+		wantPhase      tenancyv1alpha1.WorkspaceClonePhase
+		wantConditions map[conditionsv1alpha1.ConditionType]string // reason, or "" for true
+		wantCreated    bool
+		wantCloned     []conditionsv1alpha1.ConditionType
+		wantObjects    int64
+		wantErr        bool
+	}{
+		"missing source": {
+			wantPhase:      tenancyv1alpha1.WorkspaceClonePhasePending,
+			wantConditions: map[conditionsv1alpha1.ConditionType]string{tenancyv1alpha1.WorkspaceCloneWorkspaceCreated: tenancyv1alpha1.WorkspaceCloneReasonSourceNotFound},
+		},
+		"source not ready": {
+			workspaces:     []*tenancyv1alpha1.ClusterWorkspace{workspace("production", "", tenancyv1alpha1.ClusterWorkspacePhaseInitializing)},
+			wantPhase:      tenancyv1alpha1.WorkspaceClonePhasePending,
+			wantConditions: map[conditionsv1alpha1.ConditionType]string{tenancyv1alpha1.WorkspaceCloneWorkspaceCreated: tenancyv1alpha1.WorkspaceCloneReasonSourceNotReady},
+		},
+		"creates the target": {
+			workspaces:     []*tenancyv1alpha1.ClusterWorkspace{workspace("production", "", ready)},
+			wantPhase:      tenancyv1alpha1.WorkspaceClonePhaseCreating,
+			wantConditions: map[conditionsv1alpha1.ConditionType]string{tenancyv1alpha1.WorkspaceCloneWorkspaceCreated: tenancyv1alpha1.WorkspaceCloneReasonTargetNotReady},
+			wantCreated:    true,
+		},
+		"forbidden target": {
+			workspaces:     []*tenancyv1alpha1.ClusterWorkspace{workspace("production", "", ready)},
+			createErr:      apierrors.NewForbidden(tenancyv1alpha1.Resource("clusterworkspaces"), "staging", errors.New("no use permission")),
+			wantPhase:      tenancyv1alpha1.WorkspaceClonePhaseFailed,
+			wantConditions: map[conditionsv1alpha1.ConditionType]string{tenancyv1alpha1.WorkspaceCloneWorkspaceCreated: tenancyv1alpha1.WorkspaceCloneReasonCreationFailed},
+			wantCreated:    true,
+		},
+		"foreign target": {
+			workspaces:     []*tenancyv1alpha1.ClusterWorkspace{workspace("production", "", ready), workspace("staging", "", ready)},
+			wantPhase:      tenancyv1alpha1.WorkspaceClonePhaseFailed,
+			wantConditions: map[conditionsv1alpha1.ConditionType]string{tenancyv1alpha1.WorkspaceCloneWorkspaceCreated: tenancyv1alpha1.WorkspaceCloneReasonTargetExists},
+		},
+		"target not ready": {
+			workspaces:     []*tenancyv1alpha1.ClusterWorkspace{workspace("production", "", ready), workspace("staging", "staging", tenancyv1alpha1.ClusterWorkspacePhaseInitializing)},
+			wantPhase:      tenancyv1alpha1.WorkspaceClonePhaseCreating,
+			wantConditions: map[conditionsv1alpha1.ConditionType]string{tenancyv1alpha1.WorkspaceCloneWorkspaceCreated: tenancyv1alpha1.WorkspaceCloneReasonTargetNotReady},
+		},
+		"clones bindings and rbac": {
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{workspace("production", "", ready), workspace("staging", "staging", ready)},
+			wantPhase:  tenancyv1alpha1.WorkspaceClonePhaseCompleted,
+			wantConditions: map[conditionsv1alpha1.ConditionType]string{
+				tenancyv1alpha1.WorkspaceCloneWorkspaceCreated:  "",
+				tenancyv1alpha1.WorkspaceCloneAPIBindingsCloned: "",
+				tenancyv1alpha1.WorkspaceCloneRBACCloned:        "",
+			},
+			wantCloned:  []conditionsv1alpha1.ConditionType{tenancyv1alpha1.WorkspaceCloneAPIBindingsCloned, tenancyv1alpha1.WorkspaceCloneRBACCloned},
+			wantObjects: 2,
+		},
+		"clones contents": {
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{workspace("production", "", ready), workspace("staging", "staging", ready)},
+			contents:   &tenancyv1alpha1.WorkspaceCloneContents{},
+			wantPhase:  tenancyv1alpha1.WorkspaceClonePhaseCompleted,
+			wantConditions: map[conditionsv1alpha1.ConditionType]string{
+				tenancyv1alpha1.WorkspaceCloneWorkspaceCreated:  "",
+				tenancyv1alpha1.WorkspaceCloneAPIBindingsCloned: "",
+				tenancyv1alpha1.WorkspaceCloneRBACCloned:        "",
+				tenancyv1alpha1.WorkspaceCloneContentsCloned:    "",
+			},
+			wantCloned:  []conditionsv1alpha1.ConditionType{tenancyv1alpha1.WorkspaceCloneAPIBindingsCloned, tenancyv1alpha1.WorkspaceCloneRBACCloned, tenancyv1alpha1.WorkspaceCloneContentsCloned},
+			wantObjects: 3,
+		},
+		"retries failed contents": {
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{workspace("production", "", ready), workspace("staging", "staging", ready)},
+			contents:   &tenancyv1alpha1.WorkspaceCloneContents{},
+			status: tenancyv1alpha1.WorkspaceCloneStatus{
+				ClonedObjects: 5,
+				Conditions: conditionsv1alpha1.Conditions{
+					*conditions.TrueCondition(tenancyv1alpha1.WorkspaceCloneAPIBindingsCloned),
+					*conditions.TrueCondition(tenancyv1alpha1.WorkspaceCloneRBACCloned),
+				},
+			},
+			cloneErr:  errors.New("the server could not find the requested resource"),
+			wantPhase: tenancyv1alpha1.WorkspaceClonePhaseCloning,
+			wantConditions: map[conditionsv1alpha1.ConditionType]string{
+				tenancyv1alpha1.WorkspaceCloneWorkspaceCreated:  "",
+				tenancyv1alpha1.WorkspaceCloneAPIBindingsCloned: "",
+				tenancyv1alpha1.WorkspaceCloneRBACCloned:        "",
+				tenancyv1alpha1.WorkspaceCloneContentsCloned:    tenancyv1alpha1.WorkspaceCloneReasonCloneFailed,
+			},
+			wantCloned:  []conditionsv1alpha1.ConditionType{tenancyv1alpha1.WorkspaceCloneContentsCloned},
+			wantObjects: 6,
+			wantErr:     true,
+		},
+		"completed": {
+			status:    tenancyv1alpha1.WorkspaceCloneStatus{Phase: tenancyv1alpha1.WorkspaceClonePhaseCompleted},
+			wantPhase: tenancyv1alpha1.WorkspaceClonePhaseCompleted,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, ws := range tc.workspaces {
+				require.NoError(t, indexer.Add(ws))
+			}
+
+			clone := &tenancyv1alpha1.WorkspaceClone{
+				ObjectMeta: metav1.ObjectMeta{Name: "staging", ClusterName: "root:org"},
+				Spec: tenancyv1alpha1.WorkspaceCloneSpec{
+					Source:   "production",
+					Target:   "staging",
+					Contents: tc.contents,
+					User:     "alice",
+				},
+				Status: tc.status,
+			}
+
+			var created *tenancyv1alpha1.ClusterWorkspace
+			var cloned []conditionsv1alpha1.ConditionType
+			c := &Controller{
+				workspaceLister: tenancylister.NewClusterWorkspaceLister(indexer),
+				createWorkspace: func(ctx context.Context, clone *tenancyv1alpha1.WorkspaceClone, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+					created = workspace
+					return tc.createErr
+				},
+				cloneResources: func(ctx context.Context, clone *tenancyv1alpha1.WorkspaceClone, selected resourceSelector) (int64, error) {
+					switch {
+					case selected(schema.GroupResource{Group: "apis.kcp.dev", Resource: "apibindings"}):
+						cloned = append(cloned, tenancyv1alpha1.WorkspaceCloneAPIBindingsCloned)
+					case selected(schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "roles"}):
+						cloned = append(cloned, tenancyv1alpha1.WorkspaceCloneRBACCloned)
+					default:
+						cloned = append(cloned, tenancyv1alpha1.WorkspaceCloneContentsCloned)
+					}
+					return 1, tc.cloneErr
+				},
+			}
+
+			err := c.reconcile(context.Background(), clone)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.wantPhase, clone.Status.Phase)
+			require.Len(t, clone.Status.Conditions, len(tc.wantConditions))
+			for conditionType, reason := range tc.wantConditions {
+				if reason == "" {
+					require.True(t, conditions.IsTrue(clone, conditionType), "%s should be true", conditionType)
+				} else {
+					require.Equal(t, reason, conditions.GetReason(clone, conditionType), "%s has wrong reason", conditionType)
+				}
+			}
+			if tc.wantCreated {
+				require.NotNil(t, created)
+				require.Equal(t, "staging", created.Name)
+				require.Equal(t, "staging", created.Annotations[tenancyv1alpha1.WorkspaceCloneAnnotationKey])
+				require.Equal(t, "Team", created.Spec.Type)
+			} else {
+				require.Nil(t, created)
+			}
+			require.Equal(t, tc.wantCloned, cloned)
+			require.Equal(t, tc.wantObjects, clone.Status.ClonedObjects)
+		})
+	}
+}
+
+func TestContentsSelector(t *testing.T) {
+	configMaps := schema.GroupResource{Resource: "configmaps"}
+	secrets := schema.GroupResource{Resource: "secrets"}
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	roles := schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "roles"}
+
+	tests := map[string]struct {
+		contents tenancyv1alpha1.WorkspaceCloneContents
+		want     map[schema.GroupResource]bool
+	}{
+		"everything": {
+			want: map[schema.GroupResource]bool{configMaps: true, secrets: true, deployments: true, roles: false},
+		},
+		"included resources": {
+			contents: tenancyv1alpha1.WorkspaceCloneContents{Include: []tenancyv1alpha1.WorkspaceCloneResource{{Resource: "configmaps"}, {Group: "apps", Resource: "*"}}},
+			want:     map[schema.GroupResource]bool{configMaps: true, secrets: false, deployments: true, roles: false},
+		},
+		"excluded resources": {
+			contents: tenancyv1alpha1.WorkspaceCloneContents{Exclude: []tenancyv1alpha1.WorkspaceCloneResource{{Resource: "secrets"}}},
+			want:     map[schema.GroupResource]bool{configMaps: true, secrets: false, deployments: true, roles: false},
+		},
+		"included and excluded resources": {
+			contents: tenancyv1alpha1.WorkspaceCloneContents{
+				Include: []tenancyv1alpha1.WorkspaceCloneResource{{Resource: "*"}},
+				Exclude: []tenancyv1alpha1.WorkspaceCloneResource{{Resource: "secrets"}},
+			},
+			want: map[schema.GroupResource]bool{configMaps: true, secrets: false, deployments: false, roles: false},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			selected := contentsSelector(&tc.contents)
+			for gr, want := range tc.want {
+				require.Equal(t, want, selected(gr), gr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceexpiration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-clusterworkspace-expiration"
+)
+
+// NewController returns a controller that warns about ClusterWorkspaces expiring soon, and deletes
+// or hibernates them once their expiration has passed.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	kubeClusterClient kubernetes.ClusterInterface,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue:             queue,
+		kcpClusterClient:  kcpClusterClient,
+		kubeClusterClient: kubeClusterClient,
+		workspaceLister:   workspaceInformer.Lister(),
+		now:               time.Now,
+	}
+	c.deleteWorkspace = c.deleteClusterWorkspace
+	c.recordEvent = c.createEvent
+
+	workspaceInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+			if !ok {
+				return false
+			}
+			// workspaces without expiration are enqueued to clean up the status once it is removed
+			return workspace.Spec.Expiration != nil || workspace.Status.ExpiryTime != nil
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		},
+	}))
+
+	return c
+}
+
+// Controller maintains the expiry time and the Expiring and Expired conditions of ClusterWorkspaces,
+// and deletes or hibernates the expired ones.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient  kcpclient.ClusterInterface
+	kubeClusterClient kubernetes.ClusterInterface
+	workspaceLister   tenancylister.ClusterWorkspaceLister
+
+	now             func() time.Time
+	deleteWorkspace func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error
+	recordEvent     func(ctx context.Context, event *corev1.Event)
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting ClusterWorkspace expiration controller")
+	defer klog.Info("Shutting down ClusterWorkspace expiration controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, err := c.workspaceLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	previous := obj
+	obj = obj.DeepCopy()
+
+	requeueAfter, err := c.reconcile(ctx, obj)
+	if err != nil {
+		return err
+	}
+
+	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
+		if err := c.patchStatus(ctx, previous, obj); err != nil {
+			return err
+		}
+	}
+
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return nil
+}
+
+func (c *Controller) patchStatus(ctx context.Context, previous, obj *tenancyv1alpha1.ClusterWorkspace) error {
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+	}
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
+
+func (c *Controller) deleteClusterWorkspace(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	// the UID precondition protects a workspace recreated under the same name, the resource version
+	// one a workspace whose expiration changed since it was checked. Conflicts are retried.
+	err := c.kcpClusterClient.Cluster(logicalcluster.From(workspace)).TenancyV1alpha1().ClusterWorkspaces().Delete(ctx, workspace.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &workspace.UID, ResourceVersion: &workspace.ResourceVersion},
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// createEvent records the event in the default namespace of the parent workspace. Failing to record
+// the event doesn't fail the reconciliation.
+func (c *Controller) createEvent(ctx context.Context, event *corev1.Event) {
+	if _, err := c.kubeClusterClient.Cluster(logicalcluster.From(event)).CoreV1().Events(event.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		klog.Errorf("Failed to record event %s for ClusterWorkspace %s|%s: %v", event.Reason, event.ClusterName, event.InvolvedObject.Name, err)
+	}
+}
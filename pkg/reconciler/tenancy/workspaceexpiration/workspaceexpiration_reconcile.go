@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceexpiration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+const (
+	// WorkspaceExpiringReason is the reason of the event recorded when a workspace enters the
+	// warning period of its expiration.
+	WorkspaceExpiringReason = "Expiring"
+	// WorkspaceExpiredReason is the reason of the event recorded when an expired workspace is
+	// deleted or hibernated.
+	WorkspaceExpiredReason = "Expired"
+)
+
+// reconcile updates the expiry time and the Expiring condition of the workspace, and deletes or
+// hibernates it once it expired. It returns the duration after which the workspace must be checked
+// again.
+func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	if workspace.DeletionTimestamp != nil {
+		return 0, nil
+	}
+
+	expiration := workspace.Spec.Expiration
+	expiryTime := expiryTime(workspace)
+	if expiryTime == nil {
+		workspace.Status.ExpiryTime = nil
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceExpiring)
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceExpired)
+		return 0, nil
+	}
+	expiry := expiryTime.UTC().Format(time.RFC3339)
+
+	now := c.now()
+	if !now.Before(expiryTime.Time) && expiration.Action != tenancyv1alpha1.ClusterWorkspaceExpirationActionHibernate {
+		if err := c.deleteWorkspace(ctx, workspace); err != nil {
+			return 0, err
+		}
+		c.recordEvent(ctx, newEvent(workspace, metav1.NewTime(now), corev1.EventTypeNormal, WorkspaceExpiredReason, "Deleted ClusterWorkspace %s, expired at %s", workspace.Name, expiry))
+		return 0, nil
+	}
+
+	workspace.Status.ExpiryTime = expiryTime
+
+	if !now.Before(expiryTime.Time) {
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceExpiring)
+		if conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceExpired) {
+			return 0, nil // hibernated once, it may be woken up again by requests
+		}
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceExpired)
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceHibernated)
+		c.recordEvent(ctx, newEvent(workspace, metav1.NewTime(now), corev1.EventTypeNormal, WorkspaceExpiredReason, "Hibernated ClusterWorkspace %s, expired at %s", workspace.Name, expiry))
+		return 0, nil
+	}
+
+	// the expiration has been extended
+	conditions.Delete(workspace, tenancyv1alpha1.WorkspaceExpired)
+
+	var warningPeriod time.Duration
+	if expiration.WarningPeriod != nil {
+		warningPeriod = expiration.WarningPeriod.Duration
+	}
+	warningTime := expiryTime.Add(-warningPeriod)
+	if now.Before(warningTime) {
+		conditions.Delete(workspace, tenancyv1alpha1.WorkspaceExpiring)
+		return warningTime.Sub(now), nil
+	}
+
+	if !conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceExpiring) {
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceExpiring)
+		action := "deleted"
+		if expiration.Action == tenancyv1alpha1.ClusterWorkspaceExpirationActionHibernate {
+			action = "hibernated"
+		}
+		c.recordEvent(ctx, newEvent(workspace, metav1.NewTime(now), corev1.EventTypeWarning, WorkspaceExpiringReason, "ClusterWorkspace %s expires at %s and will be %s", workspace.Name, expiry, action))
+	}
+	return expiryTime.Sub(now), nil
+}
+
+// expiryTime returns the expiry time of the workspace, or nil if it doesn't expire.
+func expiryTime(workspace *tenancyv1alpha1.ClusterWorkspace) *metav1.Time {
+	expiration := workspace.Spec.Expiration
+	switch {
+	case expiration == nil:
+		return nil
+	case expiration.ExpiryTime != nil:
+		return &metav1.Time{Time: expiration.ExpiryTime.Time}
+	case expiration.TTL != nil:
+		return &metav1.Time{Time: workspace.CreationTimestamp.Add(expiration.TTL.Duration)}
+	}
+	return nil
+}
+
+func newEvent(workspace *tenancyv1alpha1.ClusterWorkspace, now metav1.Time, eventType, reason, messageFormat string, messageArgs ...interface{}) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: workspace.Name + ".",
+			Namespace:    metav1.NamespaceDefault,
+			ClusterName:  workspace.ClusterName,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: tenancyv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "ClusterWorkspace",
+			Name:       workspace.Name,
+			UID:        workspace.UID,
+		},
+		Reason:              reason,
+		Message:             fmt.Sprintf(messageFormat, messageArgs...),
+		Type:                eventType,
+		Source:              corev1.EventSource{Component: controllerName},
+		ReportingController: controllerName,
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+	}
+}
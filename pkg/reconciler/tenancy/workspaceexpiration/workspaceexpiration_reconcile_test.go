@@ -0,0 +1,214 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspaceexpiration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := created.Add(2 * time.Hour)
+
+	tests := map[string]struct {
+		expiration *tenancyv1alpha1.ClusterWorkspaceExpiration
+		conditions conditionsv1alpha1.Conditions
+		deleteErr  error
+
+		wantExpiryTime   *time.Time
+		wantDeleted      bool
+		wantExpiring     bool
+		wantExpired      bool
+		wantHibernated   bool
+		wantEventReason  string
+		wantRequeueAfter time.Duration
+		wantErr          bool
+	}{
+		"no expiration": {},
+		"expiration removed": {
+			conditions: conditionsv1alpha1.Conditions{
+				{Type: tenancyv1alpha1.WorkspaceExpiring, Status: corev1.ConditionTrue},
+			},
+		},
+		"before warning period": {
+			expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+				TTL:           &metav1.Duration{Duration: 4 * time.Hour},
+				WarningPeriod: &metav1.Duration{Duration: time.Hour},
+			},
+			wantExpiryTime:   timePtr(created.Add(4 * time.Hour)),
+			wantRequeueAfter: time.Hour,
+		},
+		"in warning period": {
+			expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+				TTL:           &metav1.Duration{Duration: 150 * time.Minute},
+				WarningPeriod: &metav1.Duration{Duration: time.Hour},
+			},
+			wantExpiryTime:   timePtr(created.Add(150 * time.Minute)),
+			wantExpiring:     true,
+			wantEventReason:  WorkspaceExpiringReason,
+			wantRequeueAfter: 30 * time.Minute,
+		},
+		"already warned": {
+			expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+				TTL:           &metav1.Duration{Duration: 150 * time.Minute},
+				WarningPeriod: &metav1.Duration{Duration: time.Hour},
+			},
+			conditions: conditionsv1alpha1.Conditions{
+				{Type: tenancyv1alpha1.WorkspaceExpiring, Status: corev1.ConditionTrue},
+			},
+			wantExpiryTime:   timePtr(created.Add(150 * time.Minute)),
+			wantExpiring:     true,
+			wantRequeueAfter: 30 * time.Minute,
+		},
+		"expiry time takes precedence": {
+			expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+				TTL:        &metav1.Duration{Duration: time.Hour},
+				ExpiryTime: &metav1.Time{Time: now.Add(time.Hour)},
+			},
+			wantExpiryTime:   timePtr(now.Add(time.Hour)),
+			wantRequeueAfter: time.Hour,
+		},
+		"expired": {
+			expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+				TTL:    &metav1.Duration{Duration: time.Hour},
+				Action: tenancyv1alpha1.ClusterWorkspaceExpirationActionDelete,
+			},
+			wantDeleted:     true,
+			wantEventReason: WorkspaceExpiredReason,
+		},
+		"failed deletion": {
+			expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+				TTL: &metav1.Duration{Duration: time.Hour},
+			},
+			deleteErr:   errors.New("boom"),
+			wantDeleted: true,
+			wantErr:     true,
+		},
+		"expired and hibernated": {
+			expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+				TTL:    &metav1.Duration{Duration: time.Hour},
+				Action: tenancyv1alpha1.ClusterWorkspaceExpirationActionHibernate,
+			},
+			conditions: conditionsv1alpha1.Conditions{
+				{Type: tenancyv1alpha1.WorkspaceExpiring, Status: corev1.ConditionTrue},
+			},
+			wantExpiryTime:  timePtr(created.Add(time.Hour)),
+			wantExpired:     true,
+			wantHibernated:  true,
+			wantEventReason: WorkspaceExpiredReason,
+		},
+		"expired and woken up": {
+			expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+				TTL:    &metav1.Duration{Duration: time.Hour},
+				Action: tenancyv1alpha1.ClusterWorkspaceExpirationActionHibernate,
+			},
+			conditions: conditionsv1alpha1.Conditions{
+				{Type: tenancyv1alpha1.WorkspaceExpired, Status: corev1.ConditionTrue},
+				{Type: tenancyv1alpha1.WorkspaceHibernated, Status: corev1.ConditionFalse, Reason: tenancyv1alpha1.WorkspaceHibernatedReasonWokenUp},
+			},
+			wantExpiryTime: timePtr(created.Add(time.Hour)),
+			wantExpired:    true,
+		},
+		"expiration extended": {
+			expiration: &tenancyv1alpha1.ClusterWorkspaceExpiration{
+				TTL:    &metav1.Duration{Duration: 4 * time.Hour},
+				Action: tenancyv1alpha1.ClusterWorkspaceExpirationActionHibernate,
+			},
+			conditions: conditionsv1alpha1.Conditions{
+				{Type: tenancyv1alpha1.WorkspaceExpired, Status: corev1.ConditionTrue},
+				{Type: tenancyv1alpha1.WorkspaceHibernated, Status: corev1.ConditionTrue},
+			},
+			wantExpiryTime:   timePtr(created.Add(4 * time.Hour)),
+			wantHibernated:   true,
+			wantRequeueAfter: 2 * time.Hour,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var deleted bool
+			var events []*corev1.Event
+			c := &Controller{
+				now: func() time.Time { return now },
+				deleteWorkspace: func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+					deleted = true
+					return tc.deleteErr
+				},
+				recordEvent: func(ctx context.Context, event *corev1.Event) {
+					events = append(events, event)
+				},
+			}
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "dev",
+					ClusterName:       "root:org",
+					UID:               "uid",
+					CreationTimestamp: metav1.NewTime(created),
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Expiration: tc.expiration,
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:      tenancyv1alpha1.ClusterWorkspacePhaseReady,
+					Conditions: tc.conditions,
+				},
+			}
+
+			requeueAfter, err := c.reconcile(context.Background(), workspace)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantDeleted, deleted, "deleted")
+			require.Equal(t, tc.wantRequeueAfter, requeueAfter)
+			if tc.wantExpiryTime == nil {
+				require.Nil(t, workspace.Status.ExpiryTime)
+			} else {
+				require.NotNil(t, workspace.Status.ExpiryTime)
+				require.True(t, tc.wantExpiryTime.Equal(workspace.Status.ExpiryTime.Time), "expected expiry time %s, got %s", tc.wantExpiryTime, workspace.Status.ExpiryTime)
+			}
+			require.Equal(t, tc.wantExpiring, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceExpiring), "expiring")
+			require.Equal(t, tc.wantExpired, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceExpired), "expired")
+			require.Equal(t, tc.wantHibernated, conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceHibernated), "hibernated")
+			if tc.wantEventReason == "" {
+				require.Empty(t, events)
+				return
+			}
+			require.Len(t, events, 1)
+			require.Equal(t, tc.wantEventReason, events[0].Reason)
+			require.Equal(t, "default", events[0].Namespace)
+			require.Equal(t, "root:org", events[0].ClusterName)
+			require.Equal(t, corev1.ObjectReference{APIVersion: "tenancy.kcp.dev/v1alpha1", Kind: "ClusterWorkspace", Name: "dev", UID: "uid"}, events[0].InvolvedObject)
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
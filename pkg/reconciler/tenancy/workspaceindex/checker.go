@@ -0,0 +1,307 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspaceindex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+)
+
+// Reason describes why the location of a workspace is inconsistent.
+type Reason string
+
+const (
+	// ReasonNotScheduled means that a ready workspace has no shard in its status.
+	ReasonNotScheduled Reason = "NotScheduled"
+	// ReasonShardNotFound means that the shard of a workspace does not exist.
+	ReasonShardNotFound Reason = "ShardNotFound"
+	// ReasonContentNotFound means that a workspace has no objects on its shard, but on another one.
+	ReasonContentNotFound Reason = "ContentNotFound"
+	// ReasonStaleURL means that the base URLs of a workspace don't point to its shard.
+	ReasonStaleURL Reason = "StaleURL"
+)
+
+// Problem is an inconsistency between the location of a workspace recorded in the
+// status of its ClusterWorkspace and the contents of the shards.
+type Problem struct {
+	Workspace logicalcluster.LogicalCluster
+	Reason    Reason
+	Message   string
+
+	// Shard is the shard the workspace is located on by Repair. It is empty if the
+	// problem can't be repaired automatically.
+	Shard string
+
+	workspace *tenancyv1alpha1.ClusterWorkspace
+	shard     *tenancyv1alpha1.ClusterWorkspaceShard
+}
+
+// Checker cross-checks the location of workspaces, i.e. status.location.current and the
+// base URLs of their ClusterWorkspaces, which clients and the front-proxy route by,
+// against the contents of the shards.
+type Checker struct {
+	// hasContent returns whether the logical cluster has objects on the shard.
+	hasContent  func(ctx context.Context, shard *tenancyv1alpha1.ClusterWorkspaceShard, cluster logicalcluster.LogicalCluster) (bool, error)
+	patchStatus func(ctx context.Context, previous, obj *tenancyv1alpha1.ClusterWorkspace) error
+}
+
+// NewChecker returns a Checker looking into the shards with the configs returned by
+// shardConfig, and repairing ClusterWorkspaces through kcpClusterClient.
+func NewChecker(shardConfig func(shard *tenancyv1alpha1.ClusterWorkspaceShard) *rest.Config, kcpClusterClient kcpclient.ClusterInterface) *Checker {
+	return &Checker{
+		hasContent: func(ctx context.Context, shard *tenancyv1alpha1.ClusterWorkspaceShard, cluster logicalcluster.LogicalCluster) (bool, error) {
+			client, err := kubernetes.NewClusterForConfig(shardConfig(shard))
+			if err != nil {
+				return false, err
+			}
+			return hasContent(ctx, client.Cluster(cluster))
+		},
+		patchStatus: func(ctx context.Context, previous, obj *tenancyv1alpha1.ClusterWorkspace) error {
+			return patchStatus(ctx, kcpClusterClient, previous, obj)
+		},
+	}
+}
+
+// Check returns the problems of the given ClusterWorkspaces. Workspaces which are not
+// initializing or ready, being deleted or being moved are skipped.
+func (c *Checker) Check(ctx context.Context, workspaces []*tenancyv1alpha1.ClusterWorkspace, shards []*tenancyv1alpha1.ClusterWorkspaceShard) ([]Problem, error) {
+	shardsByName := make(map[string]*tenancyv1alpha1.ClusterWorkspaceShard, len(shards))
+	for _, shard := range shards {
+		shardsByName[shard.Name] = shard
+	}
+	shards = append([]*tenancyv1alpha1.ClusterWorkspaceShard(nil), shards...)
+	sort.Slice(shards, func(i, j int) bool { return shards[i].Name < shards[j].Name })
+
+	var problems []Problem
+	var errs []error
+	for _, workspace := range workspaces {
+		if workspace.DeletionTimestamp != nil {
+			continue
+		}
+		if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing && workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+			continue
+		}
+		if _, migrating := workspace.Annotations[tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey]; migrating || workspace.Status.Location.Target != "" {
+			continue
+		}
+
+		problem, err := c.checkWorkspace(ctx, workspace, shardsByName, shards)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to check workspace %s: %w", logicalcluster.From(workspace).Join(workspace.Name), err))
+			continue
+		}
+		if problem != nil {
+			problems = append(problems, *problem)
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Workspace.String() < problems[j].Workspace.String() })
+	return problems, utilerrors.NewAggregate(errs)
+}
+
+func (c *Checker) checkWorkspace(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, shardsByName map[string]*tenancyv1alpha1.ClusterWorkspaceShard, shards []*tenancyv1alpha1.ClusterWorkspaceShard) (*Problem, error) {
+	cluster := logicalcluster.From(workspace).Join(workspace.Name)
+	current := workspace.Status.Location.Current
+
+	if current == "" {
+		found, err := c.locate(ctx, cluster, shards)
+		if err != nil {
+			return nil, err
+		}
+		return newProblem(workspace, ReasonNotScheduled, "workspace has no shard", found), nil
+	}
+	shard, ok := shardsByName[current]
+	if !ok {
+		found, err := c.locate(ctx, cluster, shards)
+		if err != nil {
+			return nil, err
+		}
+		return newProblem(workspace, ReasonShardNotFound, fmt.Sprintf("shard %q does not exist", current), found), nil
+	}
+
+	ok, err := c.hasContent(ctx, shard, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		others := make([]*tenancyv1alpha1.ClusterWorkspaceShard, 0, len(shards))
+		for _, s := range shards {
+			if s.Name != current {
+				others = append(others, s)
+			}
+		}
+		found, err := c.locate(ctx, cluster, others)
+		if err != nil {
+			return nil, err
+		}
+		// an empty workspace cannot be told apart from a missing one, hence this is
+		// only a problem if the objects are somewhere else.
+		if len(found) == 0 {
+			return nil, nil
+		}
+		return newProblem(workspace, ReasonContentNotFound, fmt.Sprintf("workspace has no objects on shard %q", current), found), nil
+	}
+
+	baseURL, internalBaseURL, err := workspaceURLs(workspace, shard)
+	if err != nil {
+		return nil, err
+	}
+	if workspace.Status.BaseURL != baseURL || workspace.Status.InternalBaseURL != internalBaseURL {
+		return &Problem{
+			Workspace: cluster,
+			Reason:    ReasonStaleURL,
+			Message:   fmt.Sprintf("base URL %q does not point to shard %q", workspace.Status.BaseURL, current),
+			Shard:     current,
+			workspace: workspace,
+			shard:     shard,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// locate returns the shards holding objects of the logical cluster.
+func (c *Checker) locate(ctx context.Context, cluster logicalcluster.LogicalCluster, shards []*tenancyv1alpha1.ClusterWorkspaceShard) ([]*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+	var found []*tenancyv1alpha1.ClusterWorkspaceShard
+	for _, shard := range shards {
+		ok, err := c.hasContent(ctx, shard, cluster)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = append(found, shard)
+		}
+	}
+	return found, nil
+}
+
+// newProblem returns a problem of the workspace, which can be repaired if its objects
+// were found on exactly one shard.
+func newProblem(workspace *tenancyv1alpha1.ClusterWorkspace, reason Reason, message string, found []*tenancyv1alpha1.ClusterWorkspaceShard) *Problem {
+	problem := &Problem{
+		Workspace: logicalcluster.From(workspace).Join(workspace.Name),
+		Reason:    reason,
+		workspace: workspace,
+	}
+	switch len(found) {
+	case 0:
+		problem.Message = message + ", objects not found on any shard"
+	case 1:
+		problem.Message = fmt.Sprintf("%s, objects found on shard %q", message, found[0].Name)
+		problem.Shard = found[0].Name
+		problem.shard = found[0]
+	default:
+		names := make([]string, 0, len(found))
+		for _, shard := range found {
+			names = append(names, shard.Name)
+		}
+		problem.Message = fmt.Sprintf("%s, objects found on shards %s", message, strings.Join(names, ", "))
+	}
+	return problem
+}
+
+// Repair points the ClusterWorkspace of the problem to the shard holding its objects.
+func (c *Checker) Repair(ctx context.Context, problem Problem) error {
+	if problem.shard == nil {
+		return fmt.Errorf("workspace %s cannot be repaired automatically: %s", problem.Workspace, problem.Message)
+	}
+
+	baseURL, internalBaseURL, err := workspaceURLs(problem.workspace, problem.shard)
+	if err != nil {
+		return err
+	}
+	workspace := problem.workspace.DeepCopy()
+	workspace.Status.Location.Current = problem.shard.Name
+	workspace.Status.BaseURL = baseURL
+	workspace.Status.InternalBaseURL = internalBaseURL
+	return c.patchStatus(ctx, problem.workspace, workspace)
+}
+
+// hasContent returns whether the logical cluster of the client has namespaces or
+// cluster role bindings, of which every initialized workspace has some.
+func hasContent(ctx context.Context, client kubernetes.Interface) (bool, error) {
+	namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	if len(namespaces.Items) > 0 {
+		return true, nil
+	}
+	bindings, err := client.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return false, err
+	}
+	return len(bindings.Items) > 0, nil
+}
+
+// workspaceURLs returns the external and internal URL of the workspace on the shard.
+func workspaceURLs(workspace *tenancyv1alpha1.ClusterWorkspace, shard *tenancyv1alpha1.ClusterWorkspaceShard) (string, string, error) {
+	cluster := logicalcluster.From(workspace).Join(workspace.Name)
+	var urls []string
+	for _, audience := range []tenancyhelper.Audience{tenancyhelper.ExternalAudience, tenancyhelper.InternalAudience} {
+		u, err := url.Parse(tenancyhelper.ShardURL(shard.Spec, audience))
+		if err != nil {
+			return "", "", err
+		}
+		u.Path = path.Join(u.Path, cluster.Path())
+		urls = append(urls, u.String())
+	}
+	return urls[0], urls[1], nil
+}
+
+func patchStatus(ctx context.Context, kcpClusterClient kcpclient.ClusterInterface, previous, obj *tenancyv1alpha1.ClusterWorkspace) error {
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+	}
+	newData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
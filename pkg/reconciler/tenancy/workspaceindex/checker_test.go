@@ -0,0 +1,225 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspaceindex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestCheck(t *testing.T) {
+	now := metav1.Now()
+
+	tests := map[string]struct {
+		phase       tenancyv1alpha1.ClusterWorkspacePhaseType
+		deleting    bool
+		fenced      bool
+		current     string
+		target      string
+		baseURL     string
+		contentOn   []string
+		wantProblem *Problem
+		wantRepair  *tenancyv1alpha1.ClusterWorkspaceStatus
+	}{
+		"consistent": {
+			current:   "shard-1",
+			baseURL:   "https://shard-1.external/clusters/root:org:team",
+			contentOn: []string{"shard-1"},
+		},
+		"empty workspace": {
+			current: "shard-1",
+			baseURL: "https://shard-1.external/clusters/root:org:team",
+		},
+		"not ready yet": {
+			phase: tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+		},
+		"being deleted": {
+			deleting: true,
+		},
+		"being migrated": {
+			current:   "shard-1",
+			fenced:    true,
+			contentOn: []string{"shard-2"},
+		},
+		"being moved": {
+			current:   "shard-1",
+			target:    "shard-2",
+			contentOn: []string{"shard-2"},
+		},
+		"stale base URL": {
+			current:   "shard-1",
+			baseURL:   "https://old.external/clusters/root:org:team",
+			contentOn: []string{"shard-1"},
+			wantProblem: &Problem{
+				Workspace: logicalcluster.New("root:org:team"),
+				Reason:    ReasonStaleURL,
+				Message:   `base URL "https://old.external/clusters/root:org:team" does not point to shard "shard-1"`,
+				Shard:     "shard-1",
+			},
+			wantRepair: &tenancyv1alpha1.ClusterWorkspaceStatus{
+				Location:        tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard-1"},
+				BaseURL:         "https://shard-1.external/clusters/root:org:team",
+				InternalBaseURL: "https://shard-1.internal/clusters/root:org:team",
+			},
+		},
+		"no shard": {
+			contentOn: []string{"shard-2"},
+			wantProblem: &Problem{
+				Workspace: logicalcluster.New("root:org:team"),
+				Reason:    ReasonNotScheduled,
+				Message:   `workspace has no shard, objects found on shard "shard-2"`,
+				Shard:     "shard-2",
+			},
+			wantRepair: &tenancyv1alpha1.ClusterWorkspaceStatus{
+				Location:        tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard-2"},
+				BaseURL:         "https://shard-2.external/clusters/root:org:team",
+				InternalBaseURL: "https://shard-2.internal/clusters/root:org:team",
+			},
+		},
+		"shard deleted": {
+			current:   "gone",
+			baseURL:   "https://gone.external/clusters/root:org:team",
+			contentOn: []string{"shard-1"},
+			wantProblem: &Problem{
+				Workspace: logicalcluster.New("root:org:team"),
+				Reason:    ReasonShardNotFound,
+				Message:   `shard "gone" does not exist, objects found on shard "shard-1"`,
+				Shard:     "shard-1",
+			},
+			wantRepair: &tenancyv1alpha1.ClusterWorkspaceStatus{
+				Location:        tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard-1"},
+				BaseURL:         "https://shard-1.external/clusters/root:org:team",
+				InternalBaseURL: "https://shard-1.internal/clusters/root:org:team",
+			},
+		},
+		"shard deleted, objects not found": {
+			current: "gone",
+			wantProblem: &Problem{
+				Workspace: logicalcluster.New("root:org:team"),
+				Reason:    ReasonShardNotFound,
+				Message:   `shard "gone" does not exist, objects not found on any shard`,
+			},
+		},
+		"objects on another shard": {
+			current:   "shard-1",
+			baseURL:   "https://shard-1.external/clusters/root:org:team",
+			contentOn: []string{"shard-2"},
+			wantProblem: &Problem{
+				Workspace: logicalcluster.New("root:org:team"),
+				Reason:    ReasonContentNotFound,
+				Message:   `workspace has no objects on shard "shard-1", objects found on shard "shard-2"`,
+				Shard:     "shard-2",
+			},
+			wantRepair: &tenancyv1alpha1.ClusterWorkspaceStatus{
+				Location:        tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard-2"},
+				BaseURL:         "https://shard-2.external/clusters/root:org:team",
+				InternalBaseURL: "https://shard-2.internal/clusters/root:org:team",
+			},
+		},
+		"objects on several shards": {
+			contentOn: []string{"shard-1", "shard-2"},
+			wantProblem: &Problem{
+				Workspace: logicalcluster.New("root:org:team"),
+				Reason:    ReasonNotScheduled,
+				Message:   `workspace has no shard, objects found on shards shard-1, shard-2`,
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			phase := tc.phase
+			if phase == "" {
+				phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+			}
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    phase,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: tc.current, Target: tc.target},
+					BaseURL:  tc.baseURL,
+				},
+			}
+			if tc.baseURL != "" {
+				workspace.Status.InternalBaseURL = "https://" + tc.current + ".internal/clusters/root:org:team"
+			}
+			if tc.deleting {
+				workspace.DeletionTimestamp = &now
+			}
+			if tc.fenced {
+				workspace.Annotations = map[string]string{tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey: "move"}
+			}
+
+			var shards []*tenancyv1alpha1.ClusterWorkspaceShard
+			for _, name := range []string{"shard-2", "shard-1"} {
+				shards = append(shards, &tenancyv1alpha1.ClusterWorkspaceShard{
+					ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: "root"},
+					Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+						BaseURL:     "https://" + name + ".internal",
+						ExternalURL: "https://" + name + ".external",
+					},
+				})
+			}
+
+			var repaired *tenancyv1alpha1.ClusterWorkspaceStatus
+			c := &Checker{
+				hasContent: func(ctx context.Context, shard *tenancyv1alpha1.ClusterWorkspaceShard, cluster logicalcluster.LogicalCluster) (bool, error) {
+					require.Equal(t, logicalcluster.New("root:org:team"), cluster)
+					for _, name := range tc.contentOn {
+						if name == shard.Name {
+							return true, nil
+						}
+					}
+					return false, nil
+				},
+				patchStatus: func(ctx context.Context, previous, obj *tenancyv1alpha1.ClusterWorkspace) error {
+					require.Equal(t, workspace, previous)
+					repaired = &obj.Status
+					return nil
+				},
+			}
+
+			problems, err := c.Check(context.Background(), []*tenancyv1alpha1.ClusterWorkspace{workspace}, shards)
+			require.NoError(t, err)
+			if tc.wantProblem == nil {
+				require.Empty(t, problems)
+				return
+			}
+			require.Len(t, problems, 1)
+			problem := problems[0]
+			require.Equal(t, tc.wantProblem.Workspace, problem.Workspace)
+			require.Equal(t, tc.wantProblem.Reason, problem.Reason)
+			require.Equal(t, tc.wantProblem.Message, problem.Message)
+			require.Equal(t, tc.wantProblem.Shard, problem.Shard)
+
+			err = c.Repair(context.Background(), problem)
+			if tc.wantRepair == nil {
+				require.Error(t, err)
+				require.Nil(t, repaired)
+				return
+			}
+			require.NoError(t, err)
+			tc.wantRepair.Phase = phase
+			require.Equal(t, tc.wantRepair, repaired)
+		})
+	}
+}
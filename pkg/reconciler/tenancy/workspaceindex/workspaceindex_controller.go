@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspaceindex
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+const (
+	controllerName = "kcp-workspace-index-check"
+)
+
+// NewController returns a controller that periodically checks the shards recorded in the
+// ClusterWorkspaces against the contents of the shards, and repairs them if enabled.
+func NewController(
+	checker *Checker,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+	rootShardInformer tenancyinformer.ClusterWorkspaceShardInformer,
+	period time.Duration,
+	repair bool,
+) *Controller {
+	return &Controller{
+		checker:         checker,
+		workspaceLister: workspaceInformer.Lister(),
+		shardLister:     rootShardInformer.Lister(),
+		period:          period,
+		repair:          repair,
+	}
+}
+
+type Controller struct {
+	checker         *Checker
+	workspaceLister tenancylister.ClusterWorkspaceLister
+	shardLister     tenancylister.ClusterWorkspaceShardLister
+	period          time.Duration
+	repair          bool
+}
+
+func (c *Controller) Start(ctx context.Context) {
+	defer runtime.HandleCrash()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	wait.UntilWithContext(ctx, c.check, c.period)
+}
+
+func (c *Controller) check(ctx context.Context) {
+	workspaces, err := c.workspaceLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	shards, err := c.shardLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	problems, err := c.checker.Check(ctx, workspaces, shards)
+	if err != nil {
+		runtime.HandleError(err)
+	}
+	for _, problem := range problems {
+		klog.Warningf("Workspace %s is inconsistent (%s): %s", problem.Workspace, problem.Reason, problem.Message)
+		if !c.repair || problem.Shard == "" {
+			continue
+		}
+		if err := c.checker.Repair(ctx, problem); err != nil {
+			runtime.HandleError(err)
+			continue
+		}
+		klog.Infof("Repaired workspace %s to be located on shard %q", problem.Workspace, problem.Shard)
+	}
+}
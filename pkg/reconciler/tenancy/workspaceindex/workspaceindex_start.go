@@ -0,0 +1,45 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspaceindex
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.DurationVar(&o.CheckPeriod, "workspace-index-check-period", o.CheckPeriod, "Period in which the shards of the ClusterWorkspaces are checked against the contents of the shards. If 0, they are not checked")
+	fs.BoolVar(&o.Repair, "workspace-index-repair", o.Repair, "Point ClusterWorkspaces to the shard holding their objects when the check finds them on another shard")
+	return o
+}
+
+type Options struct {
+	CheckPeriod time.Duration
+	Repair      bool
+}
+
+func (o *Options) Validate() error {
+	if o.CheckPeriod < 0 {
+		return fmt.Errorf("--workspace-index-check-period must be >=0 (%s)", o.CheckPeriod)
+	}
+	return nil
+}
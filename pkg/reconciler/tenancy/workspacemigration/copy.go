@@ -0,0 +1,327 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspacemigration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+var (
+	// copyFirst are the resources which other objects depend on, copied in this order before all others.
+	copyFirst = []schema.GroupResource{
+		{Resource: "namespaces"},
+		{Resource: "serviceaccounts"},
+		{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"},
+		{Group: "apis.kcp.dev", Resource: "apiresourceschemas"},
+		{Group: "apis.kcp.dev", Resource: "apiexports"},
+		{Group: "apis.kcp.dev", Resource: "apibindings"},
+	}
+
+	// skipped are the resources which are not migrated: events are ephemeral, and workspaces are
+	// a projection of clusterworkspaces, which cannot be migrated with their parent.
+	skipped = sets.NewString(
+		schema.GroupResource{Resource: "events"}.String(),
+		schema.GroupResource{Group: "events.k8s.io", Resource: "events"}.String(),
+		schema.GroupResource{Group: tenancyv1alpha1.SchemeGroupVersion.Group, Resource: "workspaces"}.String(),
+		schema.GroupResource{Group: tenancyv1alpha1.SchemeGroupVersion.Group, Resource: "clusterworkspaces"}.String(),
+	)
+)
+
+// migratedResource is a resource of a logical cluster which is migrated.
+type migratedResource struct {
+	gvr       schema.GroupVersionResource
+	hasStatus bool
+}
+
+// clusterConfig returns a copy of the shard config pointing to the logical cluster.
+func clusterConfig(config *rest.Config, cluster logicalcluster.LogicalCluster) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Host = strings.TrimSuffix(config.Host, "/") + cluster.Path()
+	return config
+}
+
+// discoverResources returns the resources of the logical cluster supporting the given verbs, with
+// the ones of copyFirst first.
+func discoverResources(client discovery.DiscoveryInterface, verbs ...string) ([]migratedResource, error) {
+	lists, err := discovery.ServerPreferredResources(client)
+	if err != nil {
+		return nil, err
+	}
+
+	subresources := sets.NewString()
+	for _, list := range lists {
+		for _, resource := range list.APIResources {
+			subresources.Insert(list.GroupVersion + "/" + resource.Name)
+		}
+	}
+
+	var resources []migratedResource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") || !sets.NewString(resource.Verbs...).HasAll(verbs...) {
+				continue
+			}
+			if skipped.Has(gv.WithResource(resource.Name).GroupResource().String()) {
+				continue
+			}
+			resources = append(resources, migratedResource{
+				gvr:       gv.WithResource(resource.Name),
+				hasStatus: subresources.Has(list.GroupVersion + "/" + resource.Name + "/status"),
+			})
+		}
+	}
+
+	order := func(gr schema.GroupResource) int {
+		for i, first := range copyFirst {
+			if first == gr {
+				return i
+			}
+		}
+		return len(copyFirst)
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return order(resources[i].gvr.GroupResource()) < order(resources[j].gvr.GroupResource())
+	})
+	return resources, nil
+}
+
+// copyLogicalCluster creates the objects of the logical cluster on the source shard on the target
+// shard, with their status, and then points their owner references to the copied owners. Objects
+// existing on the target shard are skipped, such that the copy can be retried, e.g. until the
+// copied CustomResourceDefinitions are established. It returns the number of objects on the
+// target shard.
+func (c *Controller) copyLogicalCluster(ctx context.Context, source, target *rest.Config, cluster logicalcluster.LogicalCluster) (int64, error) {
+	source, target = clusterConfig(source, cluster), clusterConfig(target, cluster)
+	sourceDiscovery, err := discovery.NewDiscoveryClientForConfig(source)
+	if err != nil {
+		return 0, err
+	}
+	sourceClient, err := dynamic.NewForConfig(source)
+	if err != nil {
+		return 0, err
+	}
+	targetClient, err := dynamic.NewForConfig(target)
+	if err != nil {
+		return 0, err
+	}
+
+	resources, err := discoverResources(sourceDiscovery, "list", "create")
+	if err != nil {
+		return 0, err
+	}
+
+	var copied int64
+	var owned []unstructured.Unstructured
+	for _, resource := range resources {
+		list, err := sourceClient.Resource(resource.gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return copied, fmt.Errorf("failed to list %s: %w", resource.gvr, err)
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if resource.gvr.GroupResource() == (schema.GroupResource{Resource: "secrets"}) {
+				if err := reissueServiceAccountToken(ctx, targetClient, obj); err != nil {
+					return copied, fmt.Errorf("failed to re-issue the token of %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+				}
+			}
+			if err := copyObject(ctx, targetClient.Resource(resource.gvr).Namespace(obj.GetNamespace()), obj, resource.hasStatus); err != nil {
+				return copied, fmt.Errorf("failed to copy %s %s/%s: %w", resource.gvr, obj.GetNamespace(), obj.GetName(), err)
+			}
+			copied++
+			if len(obj.GetOwnerReferences()) > 0 {
+				owned = append(owned, *obj)
+			}
+		}
+	}
+
+	if len(owned) == 0 {
+		return copied, nil
+	}
+	groupResources, err := restmapper.GetAPIGroupResources(sourceDiscovery)
+	if err != nil {
+		return copied, err
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groupResources)
+	for i := range owned {
+		if err := copyOwnerReferences(ctx, targetClient, mapper, &owned[i]); err != nil {
+			return copied, fmt.Errorf("failed to copy the owner references of %s %s/%s: %w", owned[i].GroupVersionKind(), owned[i].GetNamespace(), owned[i].GetName(), err)
+		}
+	}
+	return copied, nil
+}
+
+// copyObject creates the object without the fields owned by the storage of the source shard, and
+// its owner references, whose UIDs change.
+func copyObject(ctx context.Context, client dynamic.ResourceInterface, obj *unstructured.Unstructured, hasStatus bool) error {
+	copied := obj.DeepCopy()
+	for _, field := range []string{"resourceVersion", "uid", "selfLink", "creationTimestamp", "generation", "managedFields", "ownerReferences", "clusterName", "deletionTimestamp", "deletionGracePeriodSeconds"} {
+		unstructured.RemoveNestedField(copied.Object, "metadata", field)
+	}
+	status, hadStatus := copied.Object["status"]
+
+	created, err := client.Create(ctx, copied, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !hasStatus || !hadStatus {
+		return nil
+	}
+	created.Object["status"] = status
+	_, err = client.UpdateStatus(ctx, created, metav1.UpdateOptions{})
+	return err
+}
+
+// reissueServiceAccountToken points a token secret of a ServiceAccount to the copy of the
+// ServiceAccount on the target shard, and drops the token for the tokens controller of the target
+// shard to issue a new one. The copied ServiceAccount has a new UID, i.e. the token of the source
+// shard would not authenticate, and the secret would be deleted by the tokens controller.
+func reissueServiceAccountToken(ctx context.Context, client dynamic.Interface, secret *unstructured.Unstructured) error {
+	if secretType, _, _ := unstructured.NestedString(secret.Object, "type"); secretType != string(corev1.SecretTypeServiceAccountToken) {
+		return nil
+	}
+	annotations := secret.GetAnnotations()
+	name := annotations[corev1.ServiceAccountNameKey]
+	if name == "" {
+		return nil
+	}
+	serviceAccount, err := client.Resource(corev1.SchemeGroupVersion.WithResource("serviceaccounts")).Namespace(secret.GetNamespace()).Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil // the tokens controller deletes the secret
+	} else if err != nil {
+		return err
+	}
+	annotations[corev1.ServiceAccountUIDKey] = string(serviceAccount.GetUID())
+	secret.SetAnnotations(annotations)
+	unstructured.RemoveNestedField(secret.Object, "data", corev1.ServiceAccountTokenKey)
+	return nil
+}
+
+// copyOwnerReferences sets the owner references of the copy of the object to the copied owners.
+// References to owners which were not copied are dropped.
+func copyOwnerReferences(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, obj *unstructured.Unstructured) error {
+	mapping, err := mapper.RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
+	if err != nil {
+		return err
+	}
+	copied, err := client.Resource(mapping.Resource).Namespace(obj.GetNamespace()).Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if len(copied.GetOwnerReferences()) > 0 {
+		return nil // done before
+	}
+
+	var refs []metav1.OwnerReference
+	for _, ref := range obj.GetOwnerReferences() {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return err
+		}
+		ownerMapping, err := mapper.RESTMapping(gv.WithKind(ref.Kind).GroupKind(), gv.Version)
+		if err != nil {
+			return err
+		}
+		namespace := ""
+		if ownerMapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			namespace = obj.GetNamespace()
+		}
+		owner, err := client.Resource(ownerMapping.Resource).Namespace(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		ref.UID = owner.GetUID()
+		refs = append(refs, ref)
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": copied.GetResourceVersion(),
+			"ownerReferences": refs,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.Resource(mapping.Resource).Namespace(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// deleteLogicalCluster deletes the objects of the logical cluster on the source shard. Objects
+// which cannot be deleted, like the default namespace, are left behind.
+func (c *Controller) deleteLogicalCluster(ctx context.Context, source *rest.Config, cluster logicalcluster.LogicalCluster) error {
+	source = clusterConfig(source, cluster)
+	sourceDiscovery, err := discovery.NewDiscoveryClientForConfig(source)
+	if err != nil {
+		return err
+	}
+	sourceClient, err := dynamic.NewForConfig(source)
+	if err != nil {
+		return err
+	}
+
+	resources, err := discoverResources(sourceDiscovery, "list", "delete")
+	if err != nil {
+		return err
+	}
+
+	// dependents first, the resources of copyFirst last
+	for i := len(resources) - 1; i >= 0; i-- {
+		resource := resources[i]
+		list, err := sourceClient.Resource(resource.gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", resource.gvr, err)
+		}
+		for _, obj := range list.Items {
+			err := sourceClient.Resource(resource.gvr).Namespace(obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) && !errors.IsForbidden(err) && !errors.IsMethodNotSupported(err) {
+				return fmt.Errorf("failed to delete %s %s/%s: %w", resource.gvr, obj.GetNamespace(), obj.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacemigration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestReissueServiceAccountToken(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := dynamicfake.NewSimpleDynamicClient(scheme, &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "builder", Namespace: "default", UID: "target-uid"},
+	})
+
+	tests := map[string]struct {
+		secret          *corev1.Secret
+		wantUID         string
+		wantTokenIssued bool
+	}{
+		"token of a copied service account": {
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "builder-token", Namespace: "default", Annotations: map[string]string{
+					corev1.ServiceAccountNameKey: "builder",
+					corev1.ServiceAccountUIDKey:  "source-uid",
+				}},
+				Type: corev1.SecretTypeServiceAccountToken,
+				Data: map[string][]byte{corev1.ServiceAccountTokenKey: []byte("token"), corev1.ServiceAccountNamespaceKey: []byte("default")},
+			},
+			wantUID: "target-uid",
+		},
+		"token of a missing service account": {
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "gone-token", Namespace: "default", Annotations: map[string]string{
+					corev1.ServiceAccountNameKey: "gone",
+					corev1.ServiceAccountUIDKey:  "source-uid",
+				}},
+				Type: corev1.SecretTypeServiceAccountToken,
+				Data: map[string][]byte{corev1.ServiceAccountTokenKey: []byte("token")},
+			},
+			wantUID:         "source-uid",
+			wantTokenIssued: true,
+		},
+		"other secret": {
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "default"},
+				Type:       corev1.SecretTypeOpaque,
+				Data:       map[string][]byte{corev1.ServiceAccountTokenKey: []byte("token")},
+			},
+			wantTokenIssued: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(tc.secret)
+			require.NoError(t, err)
+			secret := &unstructured.Unstructured{Object: raw}
+
+			require.NoError(t, reissueServiceAccountToken(context.Background(), client, secret))
+			require.Equal(t, tc.wantUID, secret.GetAnnotations()[corev1.ServiceAccountUIDKey])
+			_, found, err := unstructured.NestedFieldNoCopy(secret.Object, "data", corev1.ServiceAccountTokenKey)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantTokenIssued, found, "token")
+		})
+	}
+}
@@ -0,0 +1,366 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspacemigration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/sharding"
+)
+
+const (
+	controllerName = "kcp-workspace-migration"
+)
+
+// NewController returns a controller that migrates ClusterWorkspaces between shards as requested
+// by WorkspaceMigrations. The shards are accessed with the credentials of the given config.
+func NewController(
+	config *rest.Config,
+	kcpClusterClient kcpclient.ClusterInterface,
+	migrationInformer tenancyinformer.WorkspaceMigrationInformer,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+	rootShardInformer tenancyinformer.ClusterWorkspaceShardInformer,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue:            queue,
+		config:           config,
+		kcpClusterClient: kcpClusterClient,
+		migrationLister:  migrationInformer.Lister(),
+		workspaceLister:  workspaceInformer.Lister(),
+		shardLister:      rootShardInformer.Lister(),
+	}
+	c.setFence = c.patchFence
+	c.moveWorkspace = c.patchLocation
+	c.copyObjects = c.copyLogicalCluster
+	c.deleteObjects = c.deleteLogicalCluster
+
+	migrationInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	}))
+
+	// migrations wait for their workspace to be ready, and to be moved on cut over
+	workspaceInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueMigrationsOf(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueMigrationsOf(obj) },
+	}))
+
+	// migrations to a cordoned shard wait for it to be uncordoned
+	rootShardInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, obj interface{}) { c.enqueueMigrationsTo(obj) },
+	}))
+
+	return c
+}
+
+// Controller copies the objects of the workspace of a WorkspaceMigration to the target shard while
+// writes are fenced, moves the workspace to the target shard and deletes the objects from the
+// source shard.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	config           *rest.Config
+	kcpClusterClient kcpclient.ClusterInterface
+	migrationLister  tenancylister.WorkspaceMigrationLister
+	workspaceLister  tenancylister.ClusterWorkspaceLister
+	shardLister      tenancylister.ClusterWorkspaceShardLister
+
+	setFence      func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, migration string) error
+	moveWorkspace func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, target *tenancyv1alpha1.ClusterWorkspaceShard) error
+	copyObjects   func(ctx context.Context, source, target *rest.Config, cluster logicalcluster.LogicalCluster) (int64, error)
+	deleteObjects func(ctx context.Context, source *rest.Config, cluster logicalcluster.LogicalCluster) error
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueMigrationsOf enqueues the WorkspaceMigrations of the ClusterWorkspace.
+func (c *Controller) enqueueMigrationsOf(obj interface{}) {
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+	migrations, err := c.migrationLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	cluster := logicalcluster.From(workspace).Join(workspace.Name).String()
+	for _, migration := range migrations {
+		if migration.Spec.Workspace == cluster {
+			c.enqueue(migration)
+		}
+	}
+}
+
+// enqueueMigrationsTo enqueues the pending WorkspaceMigrations to the ClusterWorkspaceShard.
+func (c *Controller) enqueueMigrationsTo(obj interface{}) {
+	shard, ok := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+	migrations, err := c.migrationLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, migration := range migrations {
+		if migration.Spec.TargetShard == shard.Name && (migration.Status.Phase == "" || migration.Status.Phase == tenancyv1alpha1.WorkspaceMigrationPhasePending) {
+			c.enqueue(migration)
+		}
+	}
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting WorkspaceMigration controller")
+	defer klog.Info("Shutting down WorkspaceMigration controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, err := c.migrationLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	previous := obj
+	obj = obj.DeepCopy()
+
+	// the progress is recorded even if the current phase failed
+	reconcileErr := c.reconcile(ctx, obj)
+
+	if !equality.Semantic.DeepEqual(previous.Finalizers, obj.Finalizers) {
+		if err := c.patchFinalizers(ctx, previous, obj); err != nil {
+			return err
+		}
+		// the status patch must not fail on the resourceVersion precondition
+		previous = previous.DeepCopy()
+		previous.ResourceVersion = ""
+	}
+	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
+		if err := c.patchStatus(ctx, previous, obj); err != nil {
+			return err
+		}
+	}
+	return reconcileErr
+}
+
+func (c *Controller) patchStatus(ctx context.Context, previous, obj *tenancyv1alpha1.WorkspaceMigration) error {
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(tenancyv1alpha1.WorkspaceMigration{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for WorkspaceMigration %s|%s: %w", clusterName, obj.Name, err)
+	}
+	newData, err := json.Marshal(tenancyv1alpha1.WorkspaceMigration{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for WorkspaceMigration %s|%s: %w", clusterName, obj.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for WorkspaceMigration %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().WorkspaceMigrations().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// patchFinalizers writes the finalizers, guarded by the resourceVersion.
+func (c *Controller) patchFinalizers(ctx context.Context, previous, obj *tenancyv1alpha1.WorkspaceMigration) error {
+	finalizers := obj.Finalizers
+	if finalizers == nil {
+		finalizers = []string{}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": previous.ResourceVersion,
+			"finalizers":      finalizers,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kcpClusterClient.Cluster(logicalcluster.From(obj)).TenancyV1alpha1().WorkspaceMigrations().Patch(ctx, obj.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// patchFence sets the fence annotation of the workspace to the name of the migration, or removes
+// it if the name is empty.
+func (c *Controller) patchFence(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, migration string) error {
+	var value interface{}
+	if migration != "" {
+		value = migration
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kcpClusterClient.Cluster(logicalcluster.From(workspace)).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, workspace.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// patchLocation moves the workspace to the target shard by setting its current location and its base
+// URLs at once. status.location.target is not used, it is reserved for a scheduler of moves.
+func (c *Controller) patchLocation(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, target *tenancyv1alpha1.ClusterWorkspaceShard) error {
+	baseURL, err := workspaceURL(workspace, tenancyhelper.ShardURL(target.Spec, tenancyhelper.ExternalAudience))
+	if err != nil {
+		return err
+	}
+	internalBaseURL, err := workspaceURL(workspace, tenancyhelper.ShardURL(target.Spec, tenancyhelper.InternalAudience))
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": workspace.ResourceVersion,
+		},
+		"status": map[string]interface{}{
+			"baseURL":         baseURL,
+			"internalBaseURL": internalBaseURL,
+			"location": map[string]interface{}{
+				"current": target.Name,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.kcpClusterClient.Cluster(logicalcluster.From(workspace)).TenancyV1alpha1().ClusterWorkspaces().Patch(ctx, workspace.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	return err
+}
+
+// workspaceURL returns the URL of the workspace on the shard with the given URL.
+func workspaceURL(workspace *tenancyv1alpha1.ClusterWorkspace, shardURL string) (string, error) {
+	u, err := url.Parse(shardURL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, logicalcluster.From(workspace).Join(workspace.Name).Path())
+	return u.String(), nil
+}
+
+// shardConfig returns the config to access the logical cluster on the shard.
+func (c *Controller) shardConfig(shard *tenancyv1alpha1.ClusterWorkspaceShard) *rest.Config {
+	return sharding.ShardConfig(shard, c.config)
+}
+
+func (c *Controller) getWorkspace(cluster logicalcluster.LogicalCluster) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	parent, name := cluster.Split()
+	return c.workspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+}
+
+func (c *Controller) getShard(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+	return c.shardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, name))
+}
+
+// hasChildWorkspaces returns whether ClusterWorkspaces exist in the logical cluster.
+func (c *Controller) hasChildWorkspaces(cluster logicalcluster.LogicalCluster) (bool, error) {
+	workspaces, err := c.workspaceLister.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, workspace := range workspaces {
+		if logicalcluster.From(workspace) == cluster {
+			return true, nil
+		}
+	}
+	return false, nil
+}
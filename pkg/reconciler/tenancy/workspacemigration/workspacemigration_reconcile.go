@@ -0,0 +1,209 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspacemigration
+
+import (
+	"context"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func (c *Controller) reconcile(ctx context.Context, migration *tenancyv1alpha1.WorkspaceMigration) error {
+	cluster := logicalcluster.New(migration.Spec.Workspace)
+
+	if migration.DeletionTimestamp != nil {
+		return c.abort(ctx, migration, cluster)
+	}
+
+	switch migration.Status.Phase {
+	case "", tenancyv1alpha1.WorkspaceMigrationPhasePending:
+		return c.start(ctx, migration, cluster)
+	case tenancyv1alpha1.WorkspaceMigrationPhaseCopying:
+		return c.copy(ctx, migration, cluster)
+	case tenancyv1alpha1.WorkspaceMigrationPhaseCuttingOver:
+		return c.cutOver(ctx, migration, cluster)
+	case tenancyv1alpha1.WorkspaceMigrationPhaseCleaningUp:
+		return c.cleanUp(ctx, migration, cluster)
+	}
+	return nil
+}
+
+// start validates the migration, and fences writes to the workspace on its current shard.
+func (c *Controller) start(ctx context.Context, migration *tenancyv1alpha1.WorkspaceMigration, cluster logicalcluster.LogicalCluster) error {
+	migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhasePending
+
+	workspace, err := c.getWorkspace(cluster)
+	if errors.IsNotFound(err) {
+		conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationValid, tenancyv1alpha1.WorkspaceMigrationReasonWorkspaceNotFound, conditionsv1alpha1.ConditionSeverityError, "ClusterWorkspace %s does not exist.", cluster)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationValid, tenancyv1alpha1.WorkspaceMigrationReasonWorkspaceNotReady, conditionsv1alpha1.ConditionSeverityInfo, "ClusterWorkspace %s is not ready.", cluster)
+		return nil
+	}
+	if fence := workspace.Annotations[tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey]; fence != "" && fence != migration.Name {
+		conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationValid, tenancyv1alpha1.WorkspaceMigrationReasonMigrationInProgress, conditionsv1alpha1.ConditionSeverityInfo, "ClusterWorkspace %s is being migrated by WorkspaceMigration %s.", cluster, fence)
+		return nil
+	}
+	if hasChildren, err := c.hasChildWorkspaces(cluster); err != nil {
+		return err
+	} else if hasChildren {
+		conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationValid, tenancyv1alpha1.WorkspaceMigrationReasonHasChildWorkspaces, conditionsv1alpha1.ConditionSeverityError, "ClusterWorkspace %s has ClusterWorkspaces, which cannot be migrated.", cluster)
+		return nil
+	}
+
+	source := workspace.Status.Location.Current
+	if source == migration.Spec.TargetShard {
+		conditions.MarkTrue(migration, tenancyv1alpha1.WorkspaceMigrationValid)
+		migration.Status.SourceShard = source
+		migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseCompleted
+		return nil
+	}
+	for _, name := range []string{source, migration.Spec.TargetShard} {
+		if _, err := c.getShard(name); errors.IsNotFound(err) {
+			conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationValid, tenancyv1alpha1.WorkspaceMigrationReasonShardNotFound, conditionsv1alpha1.ConditionSeverityError, "ClusterWorkspaceShard %q does not exist.", name)
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	if target, err := c.getShard(migration.Spec.TargetShard); err != nil {
+		return err
+	} else if target.Spec.Unschedulable {
+		conditions.MarkFalse(migration, tenancyv1alpha1.WorkspaceMigrationValid, tenancyv1alpha1.WorkspaceMigrationReasonShardUnschedulable, conditionsv1alpha1.ConditionSeverityInfo, "ClusterWorkspaceShard %q is cordoned.", migration.Spec.TargetShard)
+		return nil
+	}
+
+	// the finalizer makes sure the fence is lifted if the migration is deleted before it is done
+	migration.Finalizers = sets.NewString(migration.Finalizers...).Insert(tenancyv1alpha1.WorkspaceMigrationFinalizer).List()
+	if err := c.setFence(ctx, workspace, migration.Name); err != nil {
+		return err
+	}
+
+	klog.Infof("Migrating ClusterWorkspace %s from shard %q to %q", cluster, source, migration.Spec.TargetShard)
+	conditions.MarkTrue(migration, tenancyv1alpha1.WorkspaceMigrationValid)
+	migration.Status.SourceShard = source
+	migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseCopying
+	return nil
+}
+
+// copy copies the objects of the fenced workspace to the target shard.
+func (c *Controller) copy(ctx context.Context, migration *tenancyv1alpha1.WorkspaceMigration, cluster logicalcluster.LogicalCluster) error {
+	workspace, err := c.getWorkspace(cluster)
+	if err != nil {
+		return err
+	}
+	if workspace.Annotations[tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey] != migration.Name {
+		// writes must not happen while copying
+		return c.setFence(ctx, workspace, migration.Name)
+	}
+
+	source, err := c.getShard(migration.Status.SourceShard)
+	if err != nil {
+		return err
+	}
+	target, err := c.getShard(migration.Spec.TargetShard)
+	if err != nil {
+		return err
+	}
+
+	copied, err := c.copyObjects(ctx, c.shardConfig(source), c.shardConfig(target), cluster)
+	migration.Status.CopiedObjects = copied
+	if err != nil {
+		return err
+	}
+
+	migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseCuttingOver
+	return nil
+}
+
+// cutOver moves the workspace to the target shard, and lifts the fence once it is moved. The move
+// is a single write of the location and the base URLs, i.e. a migration deleted meanwhile leaves the
+// workspace either on the source or on the target shard.
+func (c *Controller) cutOver(ctx context.Context, migration *tenancyv1alpha1.WorkspaceMigration, cluster logicalcluster.LogicalCluster) error {
+	workspace, err := c.getWorkspace(cluster)
+	if err != nil {
+		return err
+	}
+
+	if workspace.Status.Location.Current != migration.Spec.TargetShard {
+		target, err := c.getShard(migration.Spec.TargetShard)
+		if err != nil {
+			return err
+		}
+		return c.moveWorkspace(ctx, workspace, target)
+	}
+
+	if _, found := workspace.Annotations[tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey]; found {
+		if err := c.setFence(ctx, workspace, ""); err != nil {
+			return err
+		}
+	}
+	migration.Finalizers = sets.NewString(migration.Finalizers...).Delete(tenancyv1alpha1.WorkspaceMigrationFinalizer).List()
+	migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseCleaningUp
+	return nil
+}
+
+// cleanUp deletes the objects of the workspace from the source shard.
+func (c *Controller) cleanUp(ctx context.Context, migration *tenancyv1alpha1.WorkspaceMigration, cluster logicalcluster.LogicalCluster) error {
+	source, err := c.getShard(migration.Status.SourceShard)
+	if errors.IsNotFound(err) {
+		// nothing to clean up on a removed shard
+		migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseCompleted
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := c.deleteObjects(ctx, c.shardConfig(source), cluster); err != nil {
+		return err
+	}
+
+	klog.Infof("Migrated ClusterWorkspace %s from shard %q to %q", cluster, migration.Status.SourceShard, migration.Spec.TargetShard)
+	migration.Status.Phase = tenancyv1alpha1.WorkspaceMigrationPhaseCompleted
+	return nil
+}
+
+// abort lifts the fence of a deleted migration. Objects already copied to the target shard are
+// left behind.
+func (c *Controller) abort(ctx context.Context, migration *tenancyv1alpha1.WorkspaceMigration, cluster logicalcluster.LogicalCluster) error {
+	if !sets.NewString(migration.Finalizers...).Has(tenancyv1alpha1.WorkspaceMigrationFinalizer) {
+		return nil
+	}
+
+	workspace, err := c.getWorkspace(cluster)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && workspace.Annotations[tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey] == migration.Name {
+		if err := c.setFence(ctx, workspace, ""); err != nil {
+			return err
+		}
+	}
+
+	migration.Finalizers = sets.NewString(migration.Finalizers...).Delete(tenancyv1alpha1.WorkspaceMigrationFinalizer).List()
+	return nil
+}
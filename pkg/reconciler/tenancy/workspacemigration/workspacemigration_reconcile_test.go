@@ -0,0 +1,276 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspacemigration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestReconcile(t *testing.T) {
+	now := metav1.Now()
+
+	tests := map[string]struct {
+		phase       tenancyv1alpha1.WorkspaceMigrationPhase
+		finalizers  []string
+		deleting    bool
+		noWorkspace bool
+		wsPhase     tenancyv1alpha1.ClusterWorkspacePhaseType
+		current     string
+		fence       string
+		child       bool
+		cordoned    bool
+		copyErr     error
+
+		wantPhase      tenancyv1alpha1.WorkspaceMigrationPhase
+		wantReason     string
+		wantFinalizers []string
+		wantFence      *string
+		wantMove       string
+		wantCopied     bool
+		wantDeleted    bool
+		wantErr        bool
+	}{
+		"workspace not found": {
+			noWorkspace: true,
+			wantPhase:   tenancyv1alpha1.WorkspaceMigrationPhasePending,
+			wantReason:  tenancyv1alpha1.WorkspaceMigrationReasonWorkspaceNotFound,
+		},
+		"workspace not ready": {
+			wsPhase:    tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+			current:    "shard-1",
+			wantPhase:  tenancyv1alpha1.WorkspaceMigrationPhasePending,
+			wantReason: tenancyv1alpha1.WorkspaceMigrationReasonWorkspaceNotReady,
+		},
+		"other migration in progress": {
+			current:    "shard-1",
+			fence:      "other",
+			wantPhase:  tenancyv1alpha1.WorkspaceMigrationPhasePending,
+			wantReason: tenancyv1alpha1.WorkspaceMigrationReasonMigrationInProgress,
+		},
+		"workspace with children": {
+			current:    "shard-1",
+			child:      true,
+			wantPhase:  tenancyv1alpha1.WorkspaceMigrationPhasePending,
+			wantReason: tenancyv1alpha1.WorkspaceMigrationReasonHasChildWorkspaces,
+		},
+		"source shard not found": {
+			current:    "gone",
+			wantPhase:  tenancyv1alpha1.WorkspaceMigrationPhasePending,
+			wantReason: tenancyv1alpha1.WorkspaceMigrationReasonShardNotFound,
+		},
+		"target shard cordoned": {
+			current:    "shard-1",
+			cordoned:   true,
+			wantPhase:  tenancyv1alpha1.WorkspaceMigrationPhasePending,
+			wantReason: tenancyv1alpha1.WorkspaceMigrationReasonShardUnschedulable,
+		},
+		"already on target shard": {
+			current:   "shard-2",
+			wantPhase: tenancyv1alpha1.WorkspaceMigrationPhaseCompleted,
+		},
+		"start": {
+			current:        "shard-1",
+			wantPhase:      tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			wantFinalizers: []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			wantFence:      stringPtr("move"),
+		},
+		"copy": {
+			phase:          tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			finalizers:     []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			current:        "shard-1",
+			fence:          "move",
+			wantPhase:      tenancyv1alpha1.WorkspaceMigrationPhaseCuttingOver,
+			wantFinalizers: []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			wantCopied:     true,
+		},
+		"copy without fence": {
+			phase:          tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			finalizers:     []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			current:        "shard-1",
+			wantPhase:      tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			wantFinalizers: []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			wantFence:      stringPtr("move"),
+		},
+		"failed copy": {
+			phase:          tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			finalizers:     []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			current:        "shard-1",
+			fence:          "move",
+			copyErr:        errors.New("boom"),
+			wantPhase:      tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			wantFinalizers: []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			wantCopied:     true,
+			wantErr:        true,
+		},
+		"cut over": {
+			phase:          tenancyv1alpha1.WorkspaceMigrationPhaseCuttingOver,
+			finalizers:     []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			current:        "shard-1",
+			fence:          "move",
+			wantPhase:      tenancyv1alpha1.WorkspaceMigrationPhaseCuttingOver,
+			wantFinalizers: []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			wantMove:       "shard-2",
+		},
+		"moved": {
+			phase:      tenancyv1alpha1.WorkspaceMigrationPhaseCuttingOver,
+			finalizers: []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			current:    "shard-2",
+			fence:      "move",
+			wantPhase:  tenancyv1alpha1.WorkspaceMigrationPhaseCleaningUp,
+			wantFence:  stringPtr(""),
+		},
+		"clean up": {
+			phase:       tenancyv1alpha1.WorkspaceMigrationPhaseCleaningUp,
+			current:     "shard-2",
+			wantPhase:   tenancyv1alpha1.WorkspaceMigrationPhaseCompleted,
+			wantDeleted: true,
+		},
+		"deleted while cutting over": {
+			phase:      tenancyv1alpha1.WorkspaceMigrationPhaseCuttingOver,
+			finalizers: []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			deleting:   true,
+			current:    "shard-2",
+			fence:      "move",
+			wantPhase:  tenancyv1alpha1.WorkspaceMigrationPhaseCuttingOver,
+			wantFence:  stringPtr(""),
+		},
+		"deleted while copying": {
+			phase:      tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			finalizers: []string{tenancyv1alpha1.WorkspaceMigrationFinalizer},
+			deleting:   true,
+			current:    "shard-1",
+			fence:      "move",
+			wantPhase:  tenancyv1alpha1.WorkspaceMigrationPhaseCopying,
+			wantFence:  stringPtr(""),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			workspaceIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if !tc.noWorkspace {
+				wsPhase := tc.wsPhase
+				if wsPhase == "" {
+					wsPhase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+				}
+				workspace := &tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"},
+					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+						Phase:    wsPhase,
+						Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: tc.current},
+					},
+				}
+				if tc.fence != "" {
+					workspace.Annotations = map[string]string{tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey: tc.fence}
+				}
+				require.NoError(t, workspaceIndexer.Add(workspace))
+			}
+			if tc.child {
+				require.NoError(t, workspaceIndexer.Add(&tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "child", ClusterName: "root:org:team"}}))
+			}
+			shardIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, name := range []string{"shard-1", "shard-2"} {
+				require.NoError(t, shardIndexer.Add(&tenancyv1alpha1.ClusterWorkspaceShard{
+					ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: "root"},
+					Spec:       tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://" + name, Unschedulable: tc.cordoned && name == "shard-2"},
+				}))
+			}
+
+			var fence *string
+			var moved string
+			var copied, deleted bool
+			c := &Controller{
+				config:          &rest.Config{},
+				workspaceLister: tenancylister.NewClusterWorkspaceLister(workspaceIndexer),
+				shardLister:     tenancylister.NewClusterWorkspaceShardLister(shardIndexer),
+				setFence: func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, migration string) error {
+					fence = &migration
+					return nil
+				},
+				moveWorkspace: func(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, target *tenancyv1alpha1.ClusterWorkspaceShard) error {
+					moved = target.Name
+					return nil
+				},
+				copyObjects: func(ctx context.Context, source, target *rest.Config, cluster logicalcluster.LogicalCluster) (int64, error) {
+					require.Equal(t, "https://shard-1", source.Host)
+					require.Equal(t, "https://shard-2", target.Host)
+					require.Equal(t, logicalcluster.New("root:org:team"), cluster)
+					copied = true
+					return 3, tc.copyErr
+				},
+				deleteObjects: func(ctx context.Context, source *rest.Config, cluster logicalcluster.LogicalCluster) error {
+					require.Equal(t, "https://shard-1", source.Host)
+					deleted = true
+					return nil
+				},
+			}
+
+			migration := &tenancyv1alpha1.WorkspaceMigration{
+				ObjectMeta: metav1.ObjectMeta{Name: "move", ClusterName: "root", Finalizers: tc.finalizers},
+				Spec:       tenancyv1alpha1.WorkspaceMigrationSpec{Workspace: "root:org:team", TargetShard: "shard-2"},
+				Status:     tenancyv1alpha1.WorkspaceMigrationStatus{Phase: tc.phase},
+			}
+			if tc.phase != "" {
+				migration.Status.SourceShard = "shard-1"
+			}
+			if tc.deleting {
+				migration.DeletionTimestamp = &now
+			}
+
+			err := c.reconcile(context.Background(), migration)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.wantPhase, migration.Status.Phase)
+			if tc.wantReason != "" {
+				require.Equal(t, corev1.ConditionFalse, conditions.Get(migration, tenancyv1alpha1.WorkspaceMigrationValid).Status)
+				require.Equal(t, tc.wantReason, conditions.GetReason(migration, tenancyv1alpha1.WorkspaceMigrationValid))
+			}
+			if len(tc.wantFinalizers) == 0 {
+				require.Empty(t, migration.Finalizers, "finalizers")
+			} else {
+				require.Equal(t, tc.wantFinalizers, migration.Finalizers, "finalizers")
+			}
+			require.Equal(t, tc.wantFence, fence, "fence")
+			require.Equal(t, tc.wantMove, moved, "moved")
+			require.Equal(t, tc.wantCopied, copied, "copied")
+			if tc.wantCopied {
+				require.Equal(t, int64(3), migration.Status.CopiedObjects)
+			}
+			require.Equal(t, tc.wantDeleted, deleted, "deleted")
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
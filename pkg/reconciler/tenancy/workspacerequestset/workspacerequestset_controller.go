@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacerequestset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/tenancy/v1alpha1"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-workspace-request-set"
+)
+
+// NewController returns a controller that creates the ClusterWorkspaces of WorkspaceRequestSets.
+// The workspaces are created with the given config, impersonating the user of the set.
+func NewController(
+	config *rest.Config,
+	kcpClusterClient kcpclient.ClusterInterface,
+	setInformer tenancyinformer.WorkspaceRequestSetInformer,
+	workspaceInformer tenancyinformer.ClusterWorkspaceInformer,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue:            queue,
+		config:           config,
+		kcpClusterClient: kcpClusterClient,
+		setLister:        setInformer.Lister(),
+		workspaceLister:  workspaceInformer.Lister(),
+	}
+	c.workspaceClientFor = c.impersonatingWorkspaceClient
+
+	setInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	}))
+
+	// the status of a set follows the phases of its workspaces
+	workspaceInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueSetOf(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueSetOf(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueSetOf(obj) },
+	}))
+
+	return c
+}
+
+// Controller creates the ClusterWorkspaces of a WorkspaceRequestSet on behalf of the user of the
+// set, deletes those beyond the requested count, and reports the state of every workspace.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	config           *rest.Config
+	kcpClusterClient kcpclient.ClusterInterface
+	setLister        tenancylister.WorkspaceRequestSetLister
+	workspaceLister  tenancylister.ClusterWorkspaceLister
+
+	workspaceClientFor func(set *tenancyv1alpha1.WorkspaceRequestSet) (tenancyclient.ClusterWorkspaceInterface, error)
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueSetOf enqueues the WorkspaceRequestSet that created the ClusterWorkspace, if any.
+func (c *Controller) enqueueSetOf(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	workspace, ok := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type %T", obj))
+		return
+	}
+	if set := workspace.Labels[tenancyv1alpha1.WorkspaceRequestSetLabel]; set != "" {
+		c.queue.Add(clusters.ToClusterAwareKey(logicalcluster.From(workspace), set))
+	}
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Info("Starting WorkspaceRequestSet controller")
+	defer klog.Info("Shutting down WorkspaceRequestSet controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, err := c.setLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // the workspaces are garbage collected with the set
+		}
+		return err
+	}
+	if obj.DeletionTimestamp != nil {
+		return nil
+	}
+	previous := obj
+	obj = obj.DeepCopy()
+
+	// the created workspaces are recorded even if others failed
+	reconcileErr := c.reconcile(ctx, obj)
+
+	if !equality.Semantic.DeepEqual(previous.Status, obj.Status) {
+		if err := c.patchStatus(ctx, previous, obj); err != nil {
+			return err
+		}
+	}
+	return reconcileErr
+}
+
+// impersonatingWorkspaceClient returns a client for the ClusterWorkspaces next to the set,
+// acting as the user of the set.
+func (c *Controller) impersonatingWorkspaceClient(set *tenancyv1alpha1.WorkspaceRequestSet) (tenancyclient.ClusterWorkspaceInterface, error) {
+	if set.Spec.User == "" {
+		return nil, fmt.Errorf("WorkspaceRequestSet %s|%s has no user", logicalcluster.From(set), set.Name)
+	}
+	config := rest.CopyConfig(c.config)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: set.Spec.User,
+		Groups:   set.Spec.Groups,
+	}
+	client, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return client.Cluster(logicalcluster.From(set)).TenancyV1alpha1().ClusterWorkspaces(), nil
+}
+
+func (c *Controller) patchStatus(ctx context.Context, previous, obj *tenancyv1alpha1.WorkspaceRequestSet) error {
+	clusterName := logicalcluster.From(obj)
+	oldData, err := json.Marshal(tenancyv1alpha1.WorkspaceRequestSet{
+		Status: previous.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal old data for WorkspaceRequestSet %s|%s: %w", clusterName, obj.Name, err)
+	}
+	newData, err := json.Marshal(tenancyv1alpha1.WorkspaceRequestSet{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             previous.UID,
+			ResourceVersion: previous.ResourceVersion,
+		}, // to ensure they appear in the patch as preconditions
+		Status: obj.Status,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to Marshal new data for WorkspaceRequestSet %s|%s: %w", clusterName, obj.Name, err)
+	}
+	patchBytes, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return fmt.Errorf("failed to create patch for WorkspaceRequestSet %s|%s: %w", clusterName, obj.Name, err)
+	}
+	_, err = c.kcpClusterClient.Cluster(clusterName).TenancyV1alpha1().WorkspaceRequestSets().Patch(ctx, obj.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	return err
+}
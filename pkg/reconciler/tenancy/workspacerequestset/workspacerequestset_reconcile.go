@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacerequestset
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// createBurst is the number of workspaces of a set created in parallel.
+const createBurst = 10
+
+func (c *Controller) reconcile(ctx context.Context, set *tenancyv1alpha1.WorkspaceRequestSet) error {
+	clusterName := logicalcluster.From(set)
+
+	client, err := c.workspaceClientFor(set)
+	if err != nil {
+		return err
+	}
+
+	names := workspaceNames(set)
+	statuses := make([]tenancyv1alpha1.WorkspaceRequestStatus, len(names))
+	var missing []int
+	for i, name := range names {
+		statuses[i].Name = name
+		workspace, err := c.workspaceLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+		if errors.IsNotFound(err) {
+			statuses[i].Phase = tenancyv1alpha1.WorkspaceRequestPhasePending
+			missing = append(missing, i)
+			continue
+		} else if err != nil {
+			return err
+		}
+		statuses[i].Phase, statuses[i].Message = workspacePhase(set, workspace)
+	}
+
+	// create the missing workspaces, a burst at a time
+	var errs []error
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	burst := make(chan struct{}, createBurst)
+	for _, i := range missing {
+		i := i
+		wg.Add(1)
+		burst <- struct{}{}
+		go func() {
+			defer func() { <-burst; wg.Done() }()
+			_, err := client.Create(ctx, desiredWorkspace(set, names[i]), metav1.CreateOptions{})
+			switch {
+			case err == nil:
+				statuses[i].Phase = tenancyv1alpha1.WorkspaceRequestPhaseCreating
+			case errors.IsForbidden(err) || errors.IsInvalid(err) || errors.IsAlreadyExists(err):
+				statuses[i].Phase = tenancyv1alpha1.WorkspaceRequestPhaseFailed
+				statuses[i].Message = err.Error()
+			default:
+				lock.Lock()
+				errs = append(errs, err)
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// delete the workspaces beyond the count
+	selector := labels.SelectorFromSet(labels.Set{tenancyv1alpha1.WorkspaceRequestSetLabel: set.Name})
+	workspaces, err := c.workspaceLister.List(selector)
+	if err != nil {
+		return err
+	}
+	desired := sets.NewString(names...)
+	for _, workspace := range workspaces {
+		if logicalcluster.From(workspace) != clusterName || desired.Has(workspace.Name) || !isOwnedBy(workspace, set) || workspace.DeletionTimestamp != nil {
+			continue
+		}
+		if err := client.Delete(ctx, workspace.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	set.Status = setStatus(statuses)
+	return utilerrors.NewAggregate(errs)
+}
+
+// workspaceNames returns the names of the workspaces of the set, in the order of their indexes.
+func workspaceNames(set *tenancyv1alpha1.WorkspaceRequestSet) []string {
+	names := make([]string, 0, set.Spec.Count)
+	for i := set.Spec.StartIndex; i < set.Spec.StartIndex+set.Spec.Count; i++ {
+		names = append(names, strings.ReplaceAll(set.Spec.NamePattern, tenancyv1alpha1.WorkspaceRequestSetIndexPlaceholder, strconv.Itoa(int(i))))
+	}
+	return names
+}
+
+// desiredWorkspace returns the ClusterWorkspace with the given name from the template of the set,
+// owned by the set.
+func desiredWorkspace(set *tenancyv1alpha1.WorkspaceRequestSet, name string) *tenancyv1alpha1.ClusterWorkspace {
+	workspace := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{},
+			Annotations: set.Spec.Template.Annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: tenancyv1alpha1.SchemeGroupVersion.String(),
+				Kind:       "WorkspaceRequestSet",
+				Name:       set.Name,
+				UID:        set.UID,
+			}},
+		},
+		Spec: *set.Spec.Template.Spec.DeepCopy(),
+	}
+	for k, v := range set.Spec.Template.Labels {
+		workspace.Labels[k] = v
+	}
+	workspace.Labels[tenancyv1alpha1.WorkspaceRequestSetLabel] = set.Name
+	return workspace
+}
+
+func isOwnedBy(workspace *tenancyv1alpha1.ClusterWorkspace, set *tenancyv1alpha1.WorkspaceRequestSet) bool {
+	for _, ref := range workspace.OwnerReferences {
+		if ref.UID == set.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// workspacePhase returns the phase of an existing workspace of the set.
+func workspacePhase(set *tenancyv1alpha1.WorkspaceRequestSet, workspace *tenancyv1alpha1.ClusterWorkspace) (tenancyv1alpha1.WorkspaceRequestPhase, string) {
+	if !isOwnedBy(workspace, set) {
+		return tenancyv1alpha1.WorkspaceRequestPhaseFailed, fmt.Sprintf("ClusterWorkspace %s exists already and does not belong to the set", workspace.Name)
+	}
+	if workspace.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		return tenancyv1alpha1.WorkspaceRequestPhaseReady, ""
+	}
+	return tenancyv1alpha1.WorkspaceRequestPhaseCreating, ""
+}
+
+// setStatus sums up the state of the workspaces of a set.
+func setStatus(statuses []tenancyv1alpha1.WorkspaceRequestStatus) tenancyv1alpha1.WorkspaceRequestSetStatus {
+	status := tenancyv1alpha1.WorkspaceRequestSetStatus{Workspaces: statuses}
+	inProgress := false
+	for _, s := range statuses {
+		switch s.Phase {
+		case tenancyv1alpha1.WorkspaceRequestPhaseReady:
+			status.Ready++
+		case tenancyv1alpha1.WorkspaceRequestPhaseFailed:
+			status.Failed++
+		default:
+			inProgress = true
+		}
+	}
+	switch {
+	case inProgress:
+		status.Phase = tenancyv1alpha1.WorkspaceRequestSetPhaseCreating
+	case status.Failed > 0:
+		status.Phase = tenancyv1alpha1.WorkspaceRequestSetPhaseFailed
+	default:
+		status.Phase = tenancyv1alpha1.WorkspaceRequestSetPhaseReady
+	}
+	return status
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacerequestset
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	set := &tenancyv1alpha1.WorkspaceRequestSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "class", ClusterName: "root:org", UID: "set-uid"},
+		Spec: tenancyv1alpha1.WorkspaceRequestSetSpec{
+			Count:       2,
+			NamePattern: "student-{index}",
+			StartIndex:  1,
+			Template: tenancyv1alpha1.WorkspaceRequestSetTemplate{
+				Labels: map[string]string{"class": "101"},
+				Spec:   tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Universal"},
+			},
+			User: "alice",
+		},
+	}
+	workspace := func(name string, owned bool, phase tenancyv1alpha1.ClusterWorkspacePhaseType) *tenancyv1alpha1.ClusterWorkspace {
+		ws := desiredWorkspace(set, name)
+		ws.ClusterName = "root:org"
+		if !owned {
+			ws.Labels = nil
+			ws.OwnerReferences = nil
+		}
+		ws.Status.Phase = phase
+		return ws
+	}
+	status := func(name string, phase tenancyv1alpha1.WorkspaceRequestPhase, message string) tenancyv1alpha1.WorkspaceRequestStatus {
+		return tenancyv1alpha1.WorkspaceRequestStatus{Name: name, Phase: phase, Message: message}
+	}
+
+	tests := map[string]struct {
+		workspaces []*tenancyv1alpha1.ClusterWorkspace
+		createErr  error
+
+		wantStatus  tenancyv1alpha1.WorkspaceRequestSetStatus
+		wantCreated []string
+		wantDeleted []string
+		wantErr     bool
+	}{
+		"creates the workspaces": {
+			wantStatus: tenancyv1alpha1.WorkspaceRequestSetStatus{
+				Phase: tenancyv1alpha1.WorkspaceRequestSetPhaseCreating,
+				Workspaces: []tenancyv1alpha1.WorkspaceRequestStatus{
+					status("student-1", tenancyv1alpha1.WorkspaceRequestPhaseCreating, ""),
+					status("student-2", tenancyv1alpha1.WorkspaceRequestPhaseCreating, ""),
+				},
+			},
+			wantCreated: []string{"student-1", "student-2"},
+		},
+		"reports ready workspaces": {
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				workspace("student-1", true, tenancyv1alpha1.ClusterWorkspacePhaseReady),
+				workspace("student-2", true, tenancyv1alpha1.ClusterWorkspacePhaseReady),
+			},
+			wantStatus: tenancyv1alpha1.WorkspaceRequestSetStatus{
+				Phase: tenancyv1alpha1.WorkspaceRequestSetPhaseReady,
+				Ready: 2,
+				Workspaces: []tenancyv1alpha1.WorkspaceRequestStatus{
+					status("student-1", tenancyv1alpha1.WorkspaceRequestPhaseReady, ""),
+					status("student-2", tenancyv1alpha1.WorkspaceRequestPhaseReady, ""),
+				},
+			},
+		},
+		"fails on foreign workspaces": {
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				workspace("student-1", false, tenancyv1alpha1.ClusterWorkspacePhaseReady),
+				workspace("student-2", true, tenancyv1alpha1.ClusterWorkspacePhaseReady),
+			},
+			wantStatus: tenancyv1alpha1.WorkspaceRequestSetStatus{
+				Phase:  tenancyv1alpha1.WorkspaceRequestSetPhaseFailed,
+				Ready:  1,
+				Failed: 1,
+				Workspaces: []tenancyv1alpha1.WorkspaceRequestStatus{
+					status("student-1", tenancyv1alpha1.WorkspaceRequestPhaseFailed, "ClusterWorkspace student-1 exists already and does not belong to the set"),
+					status("student-2", tenancyv1alpha1.WorkspaceRequestPhaseReady, ""),
+				},
+			},
+		},
+		"fails on forbidden creation": {
+			createErr: apierrors.NewForbidden(tenancyv1alpha1.Resource("clusterworkspaces"), "", errors.New("no use permission")),
+			wantStatus: tenancyv1alpha1.WorkspaceRequestSetStatus{
+				Phase:  tenancyv1alpha1.WorkspaceRequestSetPhaseFailed,
+				Failed: 2,
+				Workspaces: []tenancyv1alpha1.WorkspaceRequestStatus{
+					status("student-1", tenancyv1alpha1.WorkspaceRequestPhaseFailed, `clusterworkspaces.tenancy.kcp.dev is forbidden: no use permission`),
+					status("student-2", tenancyv1alpha1.WorkspaceRequestPhaseFailed, `clusterworkspaces.tenancy.kcp.dev is forbidden: no use permission`),
+				},
+			},
+		},
+		"retries other errors": {
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				workspace("student-1", true, tenancyv1alpha1.ClusterWorkspacePhaseInitializing),
+			},
+			createErr: errors.New("connection refused"),
+			wantStatus: tenancyv1alpha1.WorkspaceRequestSetStatus{
+				Phase: tenancyv1alpha1.WorkspaceRequestSetPhaseCreating,
+				Workspaces: []tenancyv1alpha1.WorkspaceRequestStatus{
+					status("student-1", tenancyv1alpha1.WorkspaceRequestPhaseCreating, ""),
+					status("student-2", tenancyv1alpha1.WorkspaceRequestPhasePending, ""),
+				},
+			},
+			wantErr: true,
+		},
+		"deletes workspaces beyond the count": {
+			workspaces: []*tenancyv1alpha1.ClusterWorkspace{
+				workspace("student-1", true, tenancyv1alpha1.ClusterWorkspacePhaseReady),
+				workspace("student-2", true, tenancyv1alpha1.ClusterWorkspacePhaseReady),
+				workspace("student-3", true, tenancyv1alpha1.ClusterWorkspacePhaseReady),
+				workspace("student-4", false, tenancyv1alpha1.ClusterWorkspacePhaseReady),
+			},
+			wantStatus: tenancyv1alpha1.WorkspaceRequestSetStatus{
+				Phase: tenancyv1alpha1.WorkspaceRequestSetPhaseReady,
+				Ready: 2,
+				Workspaces: []tenancyv1alpha1.WorkspaceRequestStatus{
+					status("student-1", tenancyv1alpha1.WorkspaceRequestPhaseReady, ""),
+					status("student-2", tenancyv1alpha1.WorkspaceRequestPhaseReady, ""),
+				},
+			},
+			wantDeleted: []string{"student-3"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			var objects []runtime.Object
+			for _, ws := range tc.workspaces {
+				require.NoError(t, indexer.Add(ws))
+				objects = append(objects, ws)
+			}
+			client := fake.NewSimpleClientset(objects...)
+			if tc.createErr != nil {
+				client.PrependReactor("create", "clusterworkspaces", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tc.createErr
+				})
+			}
+
+			c := &Controller{
+				workspaceLister: tenancylister.NewClusterWorkspaceLister(indexer),
+				workspaceClientFor: func(*tenancyv1alpha1.WorkspaceRequestSet) (tenancyclient.ClusterWorkspaceInterface, error) {
+					return client.TenancyV1alpha1().ClusterWorkspaces(), nil
+				},
+			}
+
+			obj := set.DeepCopy()
+			err := c.reconcile(context.Background(), obj)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.wantStatus, obj.Status)
+
+			var created, deleted []string
+			for _, action := range client.Actions() {
+				switch action := action.(type) {
+				case clienttesting.CreateAction:
+					if tc.createErr != nil {
+						continue
+					}
+					ws := action.GetObject().(*tenancyv1alpha1.ClusterWorkspace)
+					require.Equal(t, map[string]string{"class": "101", tenancyv1alpha1.WorkspaceRequestSetLabel: "class"}, ws.Labels)
+					require.Equal(t, set.UID, ws.OwnerReferences[0].UID)
+					created = append(created, ws.Name)
+				case clienttesting.DeleteAction:
+					deleted = append(deleted, action.GetName())
+				}
+			}
+			sort.Strings(created)
+			require.Equal(t, tc.wantCreated, created)
+			require.Equal(t, tc.wantDeleted, deleted)
+		})
+	}
+}
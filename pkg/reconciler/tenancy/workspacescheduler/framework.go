@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacescheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylister "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+// Plugin is an extension of the workspace scheduler, identified by its name.
+type Plugin interface {
+	Name() string
+}
+
+// FilterPlugin decides whether a workspace can be scheduled to a shard.
+type FilterPlugin interface {
+	Plugin
+
+	// Filter returns nil if the workspace can be scheduled to the shard, and the reason
+	// why not otherwise.
+	Filter(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, shard *tenancyv1alpha1.ClusterWorkspaceShard) *Status
+}
+
+// ScorePlugin ranks the shards that passed all filters for a workspace.
+type ScorePlugin interface {
+	Plugin
+
+	// Score returns the score of the shard for the workspace. The scores of all plugins are
+	// summed up, and the workspace is scheduled to one of the shards with the highest sum.
+	Score(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, shard *tenancyv1alpha1.ClusterWorkspaceShard) (int64, error)
+}
+
+// Status is the reason why a workspace can't be scheduled to a shard.
+type Status struct {
+	Reason  string
+	Message string
+}
+
+// Handle gives plugins access to the state of the scheduler.
+type Handle interface {
+	ClusterWorkspaceLister() tenancylister.ClusterWorkspaceLister
+	ClusterWorkspaceShardLister() tenancylister.ClusterWorkspaceShardLister
+}
+
+// Factory creates a plugin.
+type Factory func(handle Handle) (Plugin, error)
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]Factory{}
+)
+
+// Register makes a plugin available under the given name, to be enabled with
+// --workspace-scheduler-plugins. It is meant to be called from init functions of
+// packages with custom plugins, and panics if the name is taken.
+func Register(name string, factory Factory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, found := registry[name]; found {
+		panic(fmt.Sprintf("workspace scheduler plugin %q is registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Registered returns the sorted names of the registered plugins.
+func Registered() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Scheduler picks a shard for a workspace by running the filter and score plugins.
+type Scheduler struct {
+	filters []FilterPlugin
+	scorers []ScorePlugin
+
+	randIntn func(n int) int
+}
+
+// New returns a Scheduler running the given registered plugins, in order.
+func New(handle Handle, names []string) (*Scheduler, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	s := &Scheduler{randIntn: rand.Intn}
+	for _, name := range names {
+		factory, found := registry[name]
+		if !found {
+			return nil, fmt.Errorf("unknown workspace scheduler plugin %q", name)
+		}
+		plugin, err := factory(handle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create workspace scheduler plugin %q: %w", name, err)
+		}
+
+		filter, isFilter := plugin.(FilterPlugin)
+		if isFilter {
+			s.filters = append(s.filters, filter)
+		}
+		scorer, isScorer := plugin.(ScorePlugin)
+		if isScorer {
+			s.scorers = append(s.scorers, scorer)
+		}
+		if !isFilter && !isScorer {
+			return nil, fmt.Errorf("workspace scheduler plugin %q is neither a filter nor a score plugin", name)
+		}
+	}
+	return s, nil
+}
+
+// Schedule returns the shard to schedule the workspace to, picked randomly among the shards
+// with the highest score that passed all filters. If no shard passed, it returns nil and the
+// reasons by shard name.
+func (s *Scheduler) Schedule(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, shards []*tenancyv1alpha1.ClusterWorkspaceShard) (*tenancyv1alpha1.ClusterWorkspaceShard, map[string]*Status, error) {
+	feasible := make([]*tenancyv1alpha1.ClusterWorkspaceShard, 0, len(shards))
+	rejected := map[string]*Status{}
+	for _, shard := range shards {
+		if status := s.filter(ctx, workspace, shard); status != nil {
+			rejected[shard.Name] = status
+			continue
+		}
+		feasible = append(feasible, shard)
+	}
+	if len(feasible) == 0 {
+		return nil, rejected, nil
+	}
+
+	var best []*tenancyv1alpha1.ClusterWorkspaceShard
+	var bestScore int64
+	for _, shard := range feasible {
+		var score int64
+		for _, scorer := range s.scorers {
+			x, err := scorer.Score(ctx, workspace, shard)
+			if err != nil {
+				return nil, nil, fmt.Errorf("workspace scheduler plugin %q failed to score shard %q: %w", scorer.Name(), shard.Name, err)
+			}
+			score += x
+		}
+		switch {
+		case len(best) == 0 || score > bestScore:
+			best, bestScore = []*tenancyv1alpha1.ClusterWorkspaceShard{shard}, score
+		case score == bestScore:
+			best = append(best, shard)
+		}
+	}
+
+	return best[s.randIntn(len(best))], rejected, nil
+}
+
+func (s *Scheduler) filter(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, shard *tenancyv1alpha1.ClusterWorkspaceShard) *Status {
+	for _, filter := range s.filters {
+		if status := filter.Filter(ctx, workspace, shard); status != nil {
+			return status
+		}
+	}
+	return nil
+}
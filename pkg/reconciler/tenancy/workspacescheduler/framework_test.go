@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacescheduler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// dataResidency is an example of a custom plugin, keeping workspaces with a region label on
+// shards of that region.
+type dataResidency struct{}
+
+func (dataResidency) Name() string { return "DataResidency" }
+
+func (dataResidency) Filter(_ context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, shard *tenancyv1alpha1.ClusterWorkspaceShard) *Status {
+	if region, ok := workspace.Labels["region"]; ok && shard.Labels["region"] != region {
+		return &Status{Reason: "WrongRegion", Message: "The shard is not in region " + region + "."}
+	}
+	return nil
+}
+
+func init() {
+	Register("DataResidency", func(_ Handle) (Plugin, error) { return dataResidency{}, nil })
+}
+
+func shard(name, region string, workspaces int32, unschedulable bool) *tenancyv1alpha1.ClusterWorkspaceShard {
+	return &tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"region": region}},
+		Spec:       tenancyv1alpha1.ClusterWorkspaceShardSpec{Unschedulable: unschedulable},
+		Status:     tenancyv1alpha1.ClusterWorkspaceShardStatus{WorkspaceCount: &workspaces},
+	}
+}
+
+func reasons(rejected map[string]*Status) map[string]string {
+	reasons := map[string]string{}
+	for name, status := range rejected {
+		reasons[name] = status.Reason
+	}
+	return reasons
+}
+
+func TestSchedule(t *testing.T) {
+	shards := []*tenancyv1alpha1.ClusterWorkspaceShard{
+		shard("eu-1", "eu", 10, false),
+		shard("eu-2", "eu", 5, false),
+		shard("us-1", "us", 1, false),
+		shard("us-2", "us", 0, true),
+	}
+
+	tests := map[string]struct {
+		plugins      []string
+		labels       map[string]string
+		wantShards   []string
+		wantRejected map[string]string
+		wantErr      bool
+	}{
+		"cordoned shards are filtered": {
+			plugins:      []string{UnschedulablePluginName},
+			wantShards:   []string{"eu-1", "eu-2", "us-1"},
+			wantRejected: map[string]string{"us-2": tenancyv1alpha1.WorkspaceReasonUnschedulable},
+		},
+		"least workspaces wins": {
+			plugins:      []string{UnschedulablePluginName, LeastWorkspacesPluginName},
+			wantShards:   []string{"us-1"},
+			wantRejected: map[string]string{"us-2": tenancyv1alpha1.WorkspaceReasonUnschedulable},
+		},
+		"custom filter plugin": {
+			plugins:      []string{UnschedulablePluginName, "DataResidency", LeastWorkspacesPluginName},
+			labels:       map[string]string{"region": "eu"},
+			wantShards:   []string{"eu-2"},
+			wantRejected: map[string]string{"us-1": "WrongRegion", "us-2": tenancyv1alpha1.WorkspaceReasonUnschedulable},
+		},
+		"no feasible shard": {
+			plugins:      []string{UnschedulablePluginName, "DataResidency"},
+			labels:       map[string]string{"region": "ap"},
+			wantRejected: map[string]string{"eu-1": "WrongRegion", "eu-2": "WrongRegion", "us-1": "WrongRegion", "us-2": tenancyv1alpha1.WorkspaceReasonUnschedulable},
+		},
+		"unknown plugin": {
+			plugins: []string{"NoSuchPlugin"},
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := New(nil, tc.plugins)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			workspace := &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: tc.labels}}
+			// pick every candidate once to see all shards with the highest score
+			for i := range tc.wantShards {
+				s.randIntn = func(n int) int {
+					require.Equal(t, len(tc.wantShards), n, "unexpected number of candidates")
+					return i
+				}
+				got, rejected, err := s.Schedule(context.Background(), workspace, shards)
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				require.Equal(t, tc.wantShards[i], got.Name)
+
+				require.Equal(t, tc.wantRejected, reasons(rejected))
+			}
+			if len(tc.wantShards) == 0 {
+				got, rejected, err := s.Schedule(context.Background(), workspace, shards)
+				require.NoError(t, err)
+				require.Nil(t, got)
+				require.Equal(t, tc.wantRejected, reasons(rejected))
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, DefaultOptions().Validate())
+	err := (&Options{Plugins: []string{UnschedulablePluginName, "NoSuchPlugin"}}).Validate()
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "NoSuchPlugin"), err.Error())
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacescheduler
+
+import (
+	"context"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// UnschedulablePluginName filters out cordoned shards.
+	UnschedulablePluginName = "Unschedulable"
+
+	// LeastWorkspacesPluginName prefers the shards with the fewest workspaces.
+	LeastWorkspacesPluginName = "LeastWorkspaces"
+)
+
+func init() {
+	Register(UnschedulablePluginName, func(_ Handle) (Plugin, error) { return unschedulable{}, nil })
+	Register(LeastWorkspacesPluginName, func(_ Handle) (Plugin, error) { return leastWorkspaces{}, nil })
+}
+
+// unschedulable rejects shards with spec.unschedulable set. Unlike an invalid shard, a cordoned
+// shard keeps serving the workspaces scheduled to it.
+type unschedulable struct{}
+
+var _ FilterPlugin = unschedulable{}
+
+func (unschedulable) Name() string {
+	return UnschedulablePluginName
+}
+
+func (unschedulable) Filter(_ context.Context, _ *tenancyv1alpha1.ClusterWorkspace, shard *tenancyv1alpha1.ClusterWorkspaceShard) *Status {
+	if shard.Spec.Unschedulable {
+		return &Status{Reason: tenancyv1alpha1.WorkspaceReasonUnschedulable, Message: "The shard is cordoned."}
+	}
+	return nil
+}
+
+// leastWorkspaces scores shards by the negated number of workspaces they publish in
+// status.workspaceCount. Shards without a published count score like empty ones.
+type leastWorkspaces struct{}
+
+var _ ScorePlugin = leastWorkspaces{}
+
+func (leastWorkspaces) Name() string {
+	return LeastWorkspacesPluginName
+}
+
+func (leastWorkspaces) Score(_ context.Context, _ *tenancyv1alpha1.ClusterWorkspace, shard *tenancyv1alpha1.ClusterWorkspaceShard) (int64, error) {
+	if shard.Status.WorkspaceCount == nil {
+		return 0, nil
+	}
+	return -int64(*shard.Status.WorkspaceCount), nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacescheduler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func DefaultOptions() *Options {
+	return &Options{
+		Plugins: []string{UnschedulablePluginName},
+	}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringSliceVar(&o.Plugins, "workspace-scheduler-plugins", o.Plugins, fmt.Sprintf("Filter and score plugins run, in order, to schedule workspaces to shards. Available plugins: %s", strings.Join(Registered(), ", ")))
+	return o
+}
+
+type Options struct {
+	Plugins []string
+}
+
+func (o *Options) Validate() error {
+	registered := sets.NewString(Registered()...)
+	for _, name := range o.Plugins {
+		if !registered.Has(name) {
+			return fmt.Errorf("--workspace-scheduler-plugins contains unknown plugin %q, available are: %s", name, strings.Join(registered.List(), ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacesigner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	certificatesinformers "k8s.io/client-go/informers/certificates/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	certificateslisters "k8s.io/client-go/listers/certificates/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/informer"
+)
+
+const (
+	controllerName = "kcp-workspace-signer"
+
+	// ServingSignerName is the signer name of CertificateSigningRequests for serving certificates
+	// signed by the CA of the workspace.
+	ServingSignerName = "kcp.dev/workspace-serving"
+	// ClientSignerName is the signer name of CertificateSigningRequests for client certificates
+	// signed by the CA of the workspace.
+	ClientSignerName = "kcp.dev/workspace-client"
+
+	// CAConfigMapName is the name of the ConfigMap in the default namespace of a workspace
+	// publishing the CA certificate of the workspace in the ca.crt key.
+	CAConfigMapName = "kcp-workspace-ca.crt"
+)
+
+// SignerWorkspaceName is the system logical cluster holding the CA keys of the workspaces. The
+// keys are kept out of the workspaces, such that users of a workspace cannot sign certificates
+// without an approved CertificateSigningRequest.
+var SignerWorkspaceName = logicalcluster.New("system:workspace-signers")
+
+// NewController returns a controller signing approved CertificateSigningRequests for the
+// kcp.dev/workspace-serving and kcp.dev/workspace-client signers with a CA per workspace. The CA
+// is created on the first request of a workspace.
+func NewController(
+	kubeClusterClient kubernetes.ClusterInterface,
+	csrInformer certificatesinformers.CertificateSigningRequestInformer,
+	secretInformer coreinformers.SecretInformer,
+	certificateDuration time.Duration,
+) *controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:               queue,
+		kubeClusterClient:   kubeClusterClient,
+		csrLister:           csrInformer.Lister(),
+		secretLister:        secretInformer.Lister(),
+		certificateDuration: certificateDuration,
+		now:                 time.Now,
+	}
+	c.getCASecret = func(name string) (*corev1.Secret, error) {
+		return c.secretLister.Secrets(metav1.NamespaceDefault).Get(clusters.ToClusterAwareKey(SignerWorkspaceName, name))
+	}
+	c.createCASecret = c.createSecretInSignerWorkspace
+	c.publishCA = c.publishCAConfigMap
+
+	csrInformer.Informer().AddEventHandler(informer.SkipResyncs(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			csr, ok := obj.(*certificatesv1.CertificateSigningRequest)
+			return ok && isWorkspaceSigner(csr.Spec.SignerName)
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		},
+	}))
+
+	return c
+}
+
+// controller signs CertificateSigningRequests with the CAs of the workspaces.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kubeClusterClient kubernetes.ClusterInterface
+	csrLister         certificateslisters.CertificateSigningRequestLister
+	secretLister      corelisters.SecretLister
+
+	certificateDuration time.Duration
+
+	now            func() time.Time
+	getCASecret    func(name string) (*corev1.Secret, error)
+	createCASecret func(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error)
+	publishCA      func(ctx context.Context, clusterName logicalcluster.LogicalCluster, caPEM []byte) error
+}
+
+func isWorkspaceSigner(signerName string) bool {
+	return signerName == ServingSignerName || signerName == ClientSignerName
+}
+
+func (c *controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// createSecretInSignerWorkspace creates a CA secret in the default namespace of the signer
+// workspace, creating the namespace if necessary.
+func (c *controller) createSecretInSignerWorkspace(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error) {
+	client := c.kubeClusterClient.Cluster(SignerWorkspaceName).CoreV1()
+	if _, err := client.Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: secret.Namespace}}, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	return client.Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+}
+
+// publishCAConfigMap creates or updates the ConfigMap with the CA certificate in the default
+// namespace of the workspace.
+func (c *controller) publishCAConfigMap(ctx context.Context, clusterName logicalcluster.LogicalCluster, caPEM []byte) error {
+	client := c.kubeClusterClient.Cluster(clusterName).CoreV1().ConfigMaps(metav1.NamespaceDefault)
+	data := map[string]string{"ca.crt": string(caPEM)}
+
+	cm, err := client.Get(ctx, CAConfigMapName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err := client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: CAConfigMapName},
+			Data:       data,
+		}, metav1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(cm.Data, data) {
+		return nil
+	}
+	cm = cm.DeepCopy()
+	cm.Data = data
+	_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	obj, err := c.csrLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+	old := obj
+	obj = obj.DeepCopy()
+
+	if err := c.reconcile(ctx, obj); err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(old.Status, obj.Status) {
+		return nil
+	}
+
+	_, err = c.kubeClusterClient.Cluster(logicalcluster.From(obj)).CertificatesV1().CertificateSigningRequests().UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
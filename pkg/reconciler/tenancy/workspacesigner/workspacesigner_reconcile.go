@@ -0,0 +1,225 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacesigner
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/certificate/csr"
+	"k8s.io/client-go/util/keyutil"
+	"k8s.io/klog/v2"
+	capihelper "k8s.io/kubernetes/pkg/apis/certificates"
+	"k8s.io/kubernetes/pkg/controller/certificates"
+	"k8s.io/kubernetes/pkg/controller/certificates/authority"
+)
+
+const (
+	// workspaceAnnotationKey is set on the CA secrets to the logical cluster name of the workspace.
+	workspaceAnnotationKey = "workspacesigner.kcp.dev/workspace"
+
+	// minCertificateDuration is the shortest duration of signed certificates. It must be longer
+	// than the backdating of the signing policy.
+	minCertificateDuration = 10 * time.Minute
+)
+
+var (
+	servingUsages = sets.NewString(string(certificatesv1.UsageDigitalSignature), string(certificatesv1.UsageKeyEncipherment), string(certificatesv1.UsageServerAuth))
+	clientUsages  = sets.NewString(string(certificatesv1.UsageDigitalSignature), string(certificatesv1.UsageKeyEncipherment), string(certificatesv1.UsageClientAuth))
+)
+
+func (c *controller) reconcile(ctx context.Context, request *certificatesv1.CertificateSigningRequest) error {
+	if !isWorkspaceSigner(request.Spec.SignerName) {
+		return nil
+	}
+	if !certificates.IsCertificateRequestApproved(request) || certificates.HasTrueCondition(request, certificatesv1.CertificateFailed) || len(request.Status.Certificate) > 0 {
+		return nil
+	}
+
+	x509cr, err := capihelper.ParseCSR(request.Spec.Request)
+	if err != nil {
+		c.markFailed(request, fmt.Sprintf("unable to parse the request: %v", err))
+		return nil
+	}
+	if err := validateUsages(request.Spec.SignerName, request.Spec.Usages); err != nil {
+		c.markFailed(request, err.Error())
+		return nil
+	}
+
+	clusterName := logicalcluster.From(request)
+	ca, err := c.getCA(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	der, err := ca.Sign(x509cr.Raw, authority.PermissiveSigningPolicy{
+		TTL:      c.duration(request.Spec.ExpirationSeconds),
+		Usages:   request.Spec.Usages,
+		Backdate: 5 * time.Minute, // must be less than minCertificateDuration
+		Short:    8 * time.Hour,
+		Now:      c.now,
+	})
+	if err != nil {
+		c.markFailed(request, fmt.Sprintf("unable to sign the request: %v", err))
+		return nil
+	}
+	request.Status.Certificate = pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: der})
+
+	return nil
+}
+
+func (c *controller) markFailed(request *certificatesv1.CertificateSigningRequest, message string) {
+	request.Status.Conditions = append(request.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:           certificatesv1.CertificateFailed,
+		Status:         corev1.ConditionTrue,
+		Reason:         "SignerValidationFailure",
+		Message:        message,
+		LastUpdateTime: metav1.NewTime(c.now()),
+	})
+}
+
+// validateUsages checks that serving certificates are requested with server auth and client
+// certificates with client auth, plus the usages of the key only.
+func validateUsages(signerName string, usages []certificatesv1.KeyUsage) error {
+	required, allowed := certificatesv1.UsageServerAuth, servingUsages
+	if signerName == ClientSignerName {
+		required, allowed = certificatesv1.UsageClientAuth, clientUsages
+	}
+
+	requested := sets.NewString()
+	for _, usage := range usages {
+		requested.Insert(string(usage))
+	}
+	if !requested.Has(string(required)) {
+		return fmt.Errorf("usage %q is required by signer %s", required, signerName)
+	}
+	if invalid := requested.Difference(allowed); invalid.Len() > 0 {
+		return fmt.Errorf("usages %v are not allowed by signer %s", invalid.List(), signerName)
+	}
+	return nil
+}
+
+// duration returns the certificate duration for the requested expiration, bounded by the
+// configured certificate duration.
+func (c *controller) duration(expirationSeconds *int32) time.Duration {
+	if expirationSeconds == nil {
+		return c.certificateDuration
+	}
+
+	switch requested := csr.ExpirationSecondsToDuration(*expirationSeconds); {
+	case requested > c.certificateDuration:
+		return c.certificateDuration
+	case requested < minCertificateDuration:
+		return minCertificateDuration
+	default:
+		return requested
+	}
+}
+
+// getCA returns the CA of the workspace, creating it if it does not exist yet, and publishes its
+// certificate in the workspace.
+func (c *controller) getCA(ctx context.Context, clusterName logicalcluster.LogicalCluster) (*authority.CertificateAuthority, error) {
+	name := caSecretName(clusterName)
+	secret, err := c.getCASecret(name)
+	if errors.IsNotFound(err) {
+		secret, err = newCASecret(clusterName, name)
+		if err != nil {
+			return nil, err
+		}
+		// an AlreadyExists error is retried once the informer has seen the secret
+		if secret, err = c.createCASecret(ctx, secret); err != nil {
+			return nil, err
+		}
+		klog.Infof("Created CA of workspace %s in %s|%s/%s", clusterName, SignerWorkspaceName, secret.Namespace, secret.Name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	caPEM, keyPEM := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+	certs, err := cert.ParseCertsPEM(caPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA certificate of workspace %s: %w", clusterName, err)
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CA key of workspace %s: %w", clusterName, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("invalid CA key of workspace %s: %T is no signer", clusterName, key)
+	}
+
+	if err := c.publishCA(ctx, clusterName, caPEM); err != nil {
+		return nil, err
+	}
+
+	return &authority.CertificateAuthority{
+		RawCert:     caPEM,
+		RawKey:      keyPEM,
+		Certificate: certs[0],
+		PrivateKey:  signer,
+	}, nil
+}
+
+// caSecretName returns the name of the CA secret of a workspace in the signer workspace.
+func caSecretName(clusterName logicalcluster.LogicalCluster) string {
+	return fmt.Sprintf("ca-%x", sha256.Sum224([]byte(clusterName.String())))
+}
+
+// newCASecret generates a self-signed CA for the workspace.
+func newCASecret(clusterName logicalcluster.LogicalCluster, name string) (*corev1.Secret, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := cert.NewSelfSignedCACert(cert.Config{CommonName: "kcp-workspace-ca:" + clusterName.String()}, key)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := keyutil.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   metav1.NamespaceDefault,
+			Name:        name,
+			Annotations: map[string]string{workspaceAnnotationKey: clusterName.String()},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: cert.CertificateBlockType, Bytes: caCert.Raw}),
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}, nil
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacesigner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/kubernetes/pkg/controller/certificates"
+)
+
+func TestReconcile(t *testing.T) {
+	tests := map[string]struct {
+		signerName        string
+		usages            []certificatesv1.KeyUsage
+		dnsNames          []string
+		notApproved       bool
+		expirationSeconds *int32
+
+		wantSigned   bool
+		wantFailed   bool
+		wantDuration time.Duration
+	}{
+		"serving certificate": {
+			signerName:   ServingSignerName,
+			usages:       []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageKeyEncipherment, certificatesv1.UsageServerAuth},
+			dnsNames:     []string{"webhook.default.svc"},
+			wantSigned:   true,
+			wantDuration: 24 * time.Hour,
+		},
+		"client certificate with shorter expiration": {
+			signerName:        ClientSignerName,
+			usages:            []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+			expirationSeconds: int32Ptr(3600),
+			wantSigned:        true,
+			wantDuration:      time.Hour,
+		},
+		"longer expiration is capped": {
+			signerName:        ClientSignerName,
+			usages:            []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+			expirationSeconds: int32Ptr(7 * 24 * 3600),
+			wantSigned:        true,
+			wantDuration:      24 * time.Hour,
+		},
+		"not approved": {
+			signerName:  ServingSignerName,
+			usages:      []certificatesv1.KeyUsage{certificatesv1.UsageServerAuth},
+			notApproved: true,
+		},
+		"other signer": {
+			signerName: certificatesv1.KubeAPIServerClientSignerName,
+			usages:     []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+		},
+		"client signer with server auth": {
+			signerName: ClientSignerName,
+			usages:     []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth, certificatesv1.UsageServerAuth},
+			wantFailed: true,
+		},
+		"serving signer without server auth": {
+			signerName: ServingSignerName,
+			usages:     []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature},
+			wantFailed: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var caSecrets []*corev1.Secret
+			var published []byte
+			c := &controller{
+				certificateDuration: 24 * time.Hour,
+				now:                 time.Now,
+				getCASecret: func(name string) (*corev1.Secret, error) {
+					for _, secret := range caSecrets {
+						if secret.Name == name {
+							return secret, nil
+						}
+					}
+					return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+				},
+				createCASecret: func(ctx context.Context, secret *corev1.Secret) (*corev1.Secret, error) {
+					caSecrets = append(caSecrets, secret)
+					return secret, nil
+				},
+				publishCA: func(ctx context.Context, clusterName logicalcluster.LogicalCluster, caPEM []byte) error {
+					require.Equal(t, "root:org:ws", clusterName.String())
+					published = caPEM
+					return nil
+				},
+			}
+
+			request := newRequest(t, tc.signerName, tc.usages, tc.dnsNames)
+			request.Spec.ExpirationSeconds = tc.expirationSeconds
+			if !tc.notApproved {
+				request.Status.Conditions = append(request.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+					Type:   certificatesv1.CertificateApproved,
+					Status: corev1.ConditionTrue,
+				})
+			}
+
+			err := c.reconcile(context.Background(), request)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.wantFailed, certificates.HasTrueCondition(request, certificatesv1.CertificateFailed), "failed condition")
+			if !tc.wantSigned {
+				require.Empty(t, request.Status.Certificate)
+				require.Empty(t, caSecrets)
+				return
+			}
+
+			require.Len(t, caSecrets, 1)
+			require.Equal(t, "root:org:ws", caSecrets[0].Annotations[workspaceAnnotationKey])
+			require.Equal(t, caSecrets[0].Data[corev1.TLSCertKey], published)
+
+			certs, err := cert.ParseCertsPEM(request.Status.Certificate)
+			require.NoError(t, err)
+			roots := x509.NewCertPool()
+			require.True(t, roots.AppendCertsFromPEM(published))
+			_, err = certs[0].Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+			require.NoError(t, err)
+			require.Equal(t, tc.dnsNames, certs[0].DNSNames)
+			require.InDelta(t, tc.wantDuration.Seconds(), certs[0].NotAfter.Sub(certs[0].NotBefore).Seconds(), (5 * time.Minute).Seconds())
+
+			// the CA is reused for the next request of the workspace
+			next := newRequest(t, tc.signerName, tc.usages, tc.dnsNames)
+			next.Status.Conditions = request.Status.Conditions
+			require.NoError(t, c.reconcile(context.Background(), next))
+			require.Len(t, caSecrets, 1)
+			require.NotEmpty(t, next.Status.Certificate)
+		})
+	}
+}
+
+func newRequest(t *testing.T, signerName string, usages []certificatesv1.KeyUsage, dnsNames []string) *certificatesv1.CertificateSigningRequest {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "webhook"},
+		DNSNames: dnsNames,
+	}, key)
+	require.NoError(t, err)
+
+	return &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: "root:org:ws", Name: "webhook"},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:    pem.EncodeToMemory(&pem.Block{Type: cert.CertificateRequestBlockType, Bytes: der}),
+			SignerName: signerName,
+			Usages:     usages,
+		},
+	}
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workspacesigner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{
+		CertificateDuration: 365 * 24 * time.Hour,
+	}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.DurationVar(&o.CertificateDuration, "workspace-signer-certificate-duration", o.CertificateDuration, "The maximum duration of certificates signed by the CAs of the workspaces. CertificateSigningRequests can ask for shorter certificates through spec.expirationSeconds")
+	return o
+}
+
+type Options struct {
+	CertificateDuration time.Duration
+}
+
+func (o *Options) Validate() error {
+	if o.CertificateDuration < minCertificateDuration {
+		return fmt.Errorf("--workspace-signer-certificate-duration must be >=%s (%s)", minCertificateDuration, o.CertificateDuration)
+	}
+	return nil
+}
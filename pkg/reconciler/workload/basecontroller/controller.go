@@ -54,7 +54,9 @@ func GetLocationInLogicalClusterIndexKey(location string, clusterName logicalclu
 // ClusterReconcileImpl defines the methods that ClusterReconciler
 // will call in response to changes to Cluster resources.
 type ClusterReconcileImpl interface {
-	Reconcile(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster) error
+	// Reconcile reconciles the cluster. If requeueAfter is positive and no error is returned,
+	// the cluster is reconciled again after that duration, even without any change to it.
+	Reconcile(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster) (requeueAfter time.Duration, err error)
 	Cleanup(ctx context.Context, deletedCluster *workloadv1alpha1.WorkloadCluster)
 }
 
@@ -205,39 +207,45 @@ func (c *ClusterReconciler) processNextWorkItem(ctx context.Context) bool {
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, key); err != nil {
+	requeueAfter, err := c.process(ctx, key)
+	if err != nil {
 		runtime.HandleError(fmt.Errorf("%s: failed to sync %q, err: %w", c.name, key, err))
 		c.queue.AddRateLimited(key)
 		return true
 	}
 	c.queue.Forget(key)
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
 	return true
 }
 
-func (c *ClusterReconciler) process(ctx context.Context, key string) error {
+func (c *ClusterReconciler) process(ctx context.Context, key string) (time.Duration, error) {
 	obj, exists, err := c.clusterIndexer.GetByKey(key)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if !exists {
 		klog.Errorf("%s: Object with key %q was deleted", c.name, key)
-		return nil
+		return 0, nil
 	}
 	current := obj.(*workloadv1alpha1.WorkloadCluster).DeepCopy()
 	previous := current.DeepCopy()
 
-	if err := c.reconciler.Reconcile(ctx, current); err != nil {
-		return err
+	requeueAfter, err := c.reconciler.Reconcile(ctx, current)
+	if err != nil {
+		return 0, err
 	}
 
 	// If the object being reconciled changed as a result, update it.
 	if !equality.Semantic.DeepEqual(previous.Status, current.Status) {
-		_, uerr := c.kcpClusterClient.Cluster(logicalcluster.From(current)).WorkloadV1alpha1().WorkloadClusters().UpdateStatus(ctx, current, metav1.UpdateOptions{})
-		return uerr
+		if _, err := c.kcpClusterClient.Cluster(logicalcluster.From(current)).WorkloadV1alpha1().WorkloadClusters().UpdateStatus(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return 0, err
+		}
 	}
 
-	return nil
+	return requeueAfter, nil
 }
 
 func (c *ClusterReconciler) deletedCluster(obj interface{}) {
@@ -33,7 +33,9 @@ import (
 
 const (
 	clusterLabel = nscontroller.ClusterLabel
-	ownedByLabel = "kcp.dev/owned-by"
+
+	// OwnedByLabel is set on the leaf Deployments to the name of their root Deployment.
+	OwnedByLabel = "kcp.dev/owned-by"
 )
 
 func (c *Controller) reconcile(ctx context.Context, deployment *appsv1.Deployment) error {
@@ -41,7 +43,7 @@ func (c *Controller) reconcile(ctx context.Context, deployment *appsv1.Deploymen
 
 	if deployment.Labels == nil || deployment.Labels[clusterLabel] == "" {
 		// This is a root deployment; get its leafs.
-		sel, err := labels.Parse(fmt.Sprintf("%s=%s", ownedByLabel, deployment.Name))
+		sel, err := labels.Parse(fmt.Sprintf("%s=%s", OwnedByLabel, deployment.Name))
 		if err != nil {
 			return err
 		}
@@ -56,10 +58,10 @@ func (c *Controller) reconcile(ctx context.Context, deployment *appsv1.Deploymen
 			}
 		}
 
-	} else if deployment.Labels[ownedByLabel] != "" {
-		rootDeploymentName := deployment.Labels[ownedByLabel]
+	} else if deployment.Labels[OwnedByLabel] != "" {
+		rootDeploymentName := deployment.Labels[OwnedByLabel]
 		// A leaf deployment was updated; get others and aggregate status.
-		sel, err := labels.Parse(fmt.Sprintf("%s=%s", ownedByLabel, rootDeploymentName))
+		sel, err := labels.Parse(fmt.Sprintf("%s=%s", OwnedByLabel, rootDeploymentName))
 		if err != nil {
 			return err
 		}
@@ -154,7 +156,7 @@ func (c *Controller) createLeafs(ctx context.Context, root *appsv1.Deployment) e
 			vd.Labels = map[string]string{}
 		}
 		vd.Labels[clusterLabel] = cl.Name
-		vd.Labels[ownedByLabel] = root.Name
+		vd.Labels[OwnedByLabel] = root.Name
 
 		replicasToSet := replicasEach
 		if index == 0 {
@@ -0,0 +1,170 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package drain
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	workloadinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	kcpnamespace "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
+)
+
+const controllerName = "kcp-workloadcluster-drain"
+
+// NewController returns a new controller which drains deleted WorkloadClusters.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	workloadClusterInformer workloadinformer.WorkloadClusterInformer,
+	namespaceInformer coreinformers.NamespaceInformer,
+	drainTimeout time.Duration,
+) *controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:                 queue,
+		kcpClusterClient:      kcpClusterClient,
+		workloadClusterLister: workloadClusterInformer.Lister(),
+		namespaceLister:       namespaceInformer.Lister(),
+		drainTimeout:          drainTimeout,
+	}
+
+	workloadClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueWorkloadCluster(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorkloadCluster(obj) },
+	})
+
+	// Namespaces leaving a cluster can complete its drain.
+	namespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, _ interface{}) { c.enqueueNamespaceCluster(oldObj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueNamespaceCluster(obj) },
+	})
+
+	return c
+}
+
+// controller adds a finalizer to WorkloadClusters. When a WorkloadCluster is deleted, the finalizer
+// is removed once no namespace is scheduled to the cluster anymore and the syncer reported that the
+// downstream resources are gone, or when the drain timed out.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.ClusterInterface
+
+	workloadClusterLister workloadlisters.WorkloadClusterLister
+	namespaceLister       corelisters.NamespaceLister
+
+	drainTimeout time.Duration
+}
+
+func (c *controller) enqueueWorkloadCluster(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *controller) enqueueNamespaceCluster(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	ns, ok := obj.(*corev1.Namespace)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("obj is supposed to be a Namespace, but is %T", obj))
+		return
+	}
+	if clusterName := ns.Labels[kcpnamespace.ClusterLabel]; clusterName != "" {
+		c.queue.Add(clusters.ToClusterAwareKey(logicalcluster.From(ns), clusterName))
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	workloadCluster, err := c.workloadClusterLister.Get(key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	requeueAfter, err := c.reconcile(ctx, workloadCluster.DeepCopy())
+	if err != nil {
+		return err
+	}
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return nil
+}
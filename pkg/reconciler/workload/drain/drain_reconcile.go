@@ -0,0 +1,143 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package drain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpnamespace "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+type drainAction int
+
+const (
+	drainActionNone drainAction = iota
+	drainActionAddFinalizer
+	drainActionWait
+	drainActionRemoveFinalizer
+	drainActionForce
+)
+
+// decideDrainAction decides what to do with a WorkloadCluster given the number of namespaces still
+// scheduled to it. For drainActionWait, the time until the drain times out is returned.
+func decideDrainAction(workloadCluster *workloadv1alpha1.WorkloadCluster, scheduledNamespaces int, drainTimeout time.Duration, now time.Time) (drainAction, time.Duration) {
+	hasFinalizer := sets.NewString(workloadCluster.Finalizers...).Has(workloadv1alpha1.WorkloadClusterDrainFinalizer)
+	if workloadCluster.DeletionTimestamp == nil {
+		if hasFinalizer {
+			return drainActionNone, 0
+		}
+		return drainActionAddFinalizer, 0
+	}
+	if !hasFinalizer {
+		return drainActionNone, 0
+	}
+
+	if _, forced := workloadCluster.Annotations[workloadv1alpha1.ForceDeleteAnnotation]; forced {
+		return drainActionRemoveFinalizer, 0
+	}
+
+	// Without a syncer heartbeat, nothing has ever been synced downstream.
+	syncerSeen := workloadCluster.Status.LastSyncerHeartbeatTime != nil
+	if scheduledNamespaces == 0 && (!syncerSeen || conditions.IsTrue(workloadCluster, workloadv1alpha1.DownstreamDrained)) {
+		return drainActionRemoveFinalizer, 0
+	}
+
+	remaining := workloadCluster.DeletionTimestamp.Add(drainTimeout).Sub(now)
+	if remaining <= 0 {
+		return drainActionForce, 0
+	}
+	return drainActionWait, remaining
+}
+
+// reconcile adds or removes the drain finalizer, and returns the duration after which the
+// WorkloadCluster must be checked again for a timed out drain.
+func (c *controller) reconcile(ctx context.Context, workloadCluster *workloadv1alpha1.WorkloadCluster) (time.Duration, error) {
+	clusterName := logicalcluster.From(workloadCluster)
+
+	scheduledNamespaces := 0
+	if workloadCluster.DeletionTimestamp != nil {
+		namespaces, err := c.namespaceLister.List(labels.SelectorFromSet(labels.Set{kcpnamespace.ClusterLabel: workloadCluster.Name}))
+		if err != nil {
+			return 0, err
+		}
+		for _, ns := range namespaces {
+			if logicalcluster.From(ns) == clusterName {
+				scheduledNamespaces++
+			}
+		}
+	}
+
+	action, remaining := decideDrainAction(workloadCluster, scheduledNamespaces, c.drainTimeout, time.Now())
+	switch action {
+	case drainActionAddFinalizer:
+		return 0, c.patchFinalizers(ctx, workloadCluster, append(workloadCluster.Finalizers, workloadv1alpha1.WorkloadClusterDrainFinalizer), nil)
+	case drainActionRemoveFinalizer:
+		klog.Infof("WorkloadCluster %s|%s is drained, removing the finalizer", clusterName, workloadCluster.Name)
+		return 0, c.patchFinalizers(ctx, workloadCluster, withoutDrainFinalizer(workloadCluster.Finalizers), nil)
+	case drainActionForce:
+		klog.Infof("Draining WorkloadCluster %s|%s timed out after %s with %d namespaces still scheduled, forcing the deletion", clusterName, workloadCluster.Name, c.drainTimeout, scheduledNamespaces)
+		return 0, c.patchFinalizers(ctx, workloadCluster, withoutDrainFinalizer(workloadCluster.Finalizers), map[string]interface{}{
+			workloadv1alpha1.ForceDeleteAnnotation: "timeout",
+		})
+	case drainActionWait:
+		klog.V(2).Infof("Waiting for WorkloadCluster %s|%s to be drained, %d namespaces still scheduled", clusterName, workloadCluster.Name, scheduledNamespaces)
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+func withoutDrainFinalizer(finalizers []string) []string {
+	var ret []string
+	for _, f := range finalizers {
+		if f != workloadv1alpha1.WorkloadClusterDrainFinalizer {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+// patchFinalizers replaces the finalizers of the WorkloadCluster, guarded by its resourceVersion,
+// and optionally sets annotations.
+func (c *controller) patchFinalizers(ctx context.Context, workloadCluster *workloadv1alpha1.WorkloadCluster, finalizers []string, annotations map[string]interface{}) error {
+	if finalizers == nil {
+		finalizers = []string{}
+	}
+	metadata := map[string]interface{}{
+		"resourceVersion": workloadCluster.ResourceVersion,
+		"finalizers":      finalizers,
+	}
+	if len(annotations) > 0 {
+		metadata["annotations"] = annotations
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return err
+	}
+	_, err = c.kcpClusterClient.Cluster(logicalcluster.From(workloadCluster)).WorkloadV1alpha1().WorkloadClusters().Patch(ctx, workloadCluster.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
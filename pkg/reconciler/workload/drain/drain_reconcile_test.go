@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package drain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestDecideDrainAction(t *testing.T) {
+	now := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	timeout := 5 * time.Minute
+
+	tests := map[string]struct {
+		deletedAgo          *time.Duration
+		noFinalizer         bool
+		forced              bool
+		syncerSeen          bool
+		downstreamDrained   bool
+		scheduledNamespaces int
+		wantAction          drainAction
+		wantRemaining       time.Duration
+	}{
+		"not deleted, finalizer missing": {
+			noFinalizer: true,
+			wantAction:  drainActionAddFinalizer,
+		},
+		"not deleted, finalizer set": {
+			wantAction: drainActionNone,
+		},
+		"deleted, finalizer already removed": {
+			deletedAgo:  durationPtr(time.Minute),
+			noFinalizer: true,
+			wantAction:  drainActionNone,
+		},
+		"deleted, never synced": {
+			deletedAgo: durationPtr(time.Minute),
+			wantAction: drainActionRemoveFinalizer,
+		},
+		"deleted, namespaces still scheduled": {
+			deletedAgo:          durationPtr(time.Minute),
+			syncerSeen:          true,
+			scheduledNamespaces: 2,
+			wantAction:          drainActionWait,
+			wantRemaining:       4 * time.Minute,
+		},
+		"deleted, namespaces gone, downstream not drained": {
+			deletedAgo:    durationPtr(time.Minute),
+			syncerSeen:    true,
+			wantAction:    drainActionWait,
+			wantRemaining: 4 * time.Minute,
+		},
+		"deleted, namespaces gone, downstream drained": {
+			deletedAgo:        durationPtr(time.Minute),
+			syncerSeen:        true,
+			downstreamDrained: true,
+			wantAction:        drainActionRemoveFinalizer,
+		},
+		"deleted, force-delete annotation": {
+			deletedAgo:          durationPtr(time.Minute),
+			syncerSeen:          true,
+			forced:              true,
+			scheduledNamespaces: 2,
+			wantAction:          drainActionRemoveFinalizer,
+		},
+		"deleted, timed out": {
+			deletedAgo:          durationPtr(timeout),
+			syncerSeen:          true,
+			scheduledNamespaces: 1,
+			wantAction:          drainActionForce,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			workloadCluster := &workloadv1alpha1.WorkloadCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster",
+				},
+			}
+			if !tc.noFinalizer {
+				workloadCluster.Finalizers = []string{workloadv1alpha1.WorkloadClusterDrainFinalizer}
+			}
+			if tc.deletedAgo != nil {
+				deletionTimestamp := metav1.NewTime(now.Add(-*tc.deletedAgo))
+				workloadCluster.DeletionTimestamp = &deletionTimestamp
+			}
+			if tc.forced {
+				workloadCluster.Annotations = map[string]string{workloadv1alpha1.ForceDeleteAnnotation: ""}
+			}
+			if tc.syncerSeen {
+				heartbeat := metav1.NewTime(now)
+				workloadCluster.Status.LastSyncerHeartbeatTime = &heartbeat
+			}
+			if tc.downstreamDrained {
+				conditions.MarkTrue(workloadCluster, workloadv1alpha1.DownstreamDrained)
+			}
+
+			action, remaining := decideDrainAction(workloadCluster, tc.scheduledNamespaces, timeout, now)
+			require.Equal(t, tc.wantAction, action)
+			require.Equal(t, tc.wantRemaining, remaining)
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package drain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{
+		DrainTimeout: 5 * time.Minute,
+	}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.DurationVar(&o.DrainTimeout, "workload-cluster-drain-timeout", o.DrainTimeout, "Amount of time to wait for the namespaces and the downstream resources of a deleted cluster to be drained before forcing its deletion")
+	return o
+}
+
+type Options struct {
+	DrainTimeout time.Duration
+}
+
+func (o *Options) Validate() error {
+	if o.DrainTimeout <= 0 {
+		return fmt.Errorf("--workload-cluster-drain-timeout must be >0 (%s)", o.DrainTimeout)
+	}
+	return nil
+}
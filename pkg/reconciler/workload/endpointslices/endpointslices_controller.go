@@ -0,0 +1,152 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package endpointslices
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+const controllerName = "kcp-endpointslice-aggregator"
+
+// NewController returns a new Controller which aggregates the ready endpoints the syncers expose
+// on the Services, for every WorkloadCluster the Services are placed on, into EndpointSlices
+// next to the Services.
+func NewController(kubeClusterClient kubernetes.ClusterInterface, serviceInformer coreinformers.ServiceInformer) *Controller {
+	c := &Controller{
+		queue:             workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		kubeClusterClient: kubeClusterClient,
+		serviceIndexer:    serviceInformer.Informer().GetIndexer(),
+	}
+
+	serviceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: hasEndpointsAnnotation,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+			DeleteFunc: func(obj interface{}) { c.enqueue(obj) },
+		},
+	})
+
+	return c
+}
+
+// Controller aggregates the endpoints of Services across WorkloadClusters.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kubeClusterClient kubernetes.ClusterInterface
+
+	serviceIndexer cache.Indexer
+}
+
+// hasEndpointsAnnotation returns true if a syncer exposed endpoints on the Service. When the last
+// annotation is removed, the filter reports the update as a deletion, which still enqueues the
+// Service to delete its aggregated EndpointSlices.
+func hasEndpointsAnnotation(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	service, ok := obj.(interface{ GetAnnotations() map[string]string })
+	if !ok {
+		return false
+	}
+	for key := range service.GetAnnotations() {
+		if strings.HasPrefix(key, workloadv1alpha1.EndpointsAnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// Start starts the controller workers.
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.InfoS("Starting workers", "controller", controllerName)
+	defer klog.InfoS("Stopping workers", "controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, exists, err := c.serviceIndexer.GetByKey(key)
+	if err != nil {
+		klog.Errorf("Failed to get Service with key %q because: %v", key, err)
+		return nil
+	}
+	if !exists {
+		// The aggregated EndpointSlices are owned by the Service, and garbage collected with it.
+		klog.Infof("Service with key %q was deleted", key)
+		return nil
+	}
+
+	return c.reconcile(ctx, obj.(*corev1.Service))
+}
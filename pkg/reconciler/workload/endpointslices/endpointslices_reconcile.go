@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package endpointslices
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+const (
+	// ManagedBy is the value of the managed-by label of the aggregated EndpointSlices.
+	ManagedBy = "endpointslice-aggregator.workload.kcp.dev"
+
+	// WorkloadClusterLabel is the label of an aggregated EndpointSlice holding the name of the
+	// WorkloadCluster the endpoints are running on.
+	WorkloadClusterLabel = "endpoints.workload.kcp.dev/workload-cluster"
+)
+
+// ClusterEndpoints are ready endpoints of a Service on a WorkloadCluster, along with their ports.
+// The syncer of the WorkloadCluster sets a list of them, one per EndpointSlice of the physical
+// cluster, as JSON in the workloadv1alpha1.EndpointsAnnotationPrefix annotation of the Service.
+type ClusterEndpoints struct {
+	AddressType discoveryv1.AddressType    `json:"addressType"`
+	Ports       []discoveryv1.EndpointPort `json:"ports,omitempty"`
+	Endpoints   []discoveryv1.Endpoint     `json:"endpoints"`
+}
+
+func (c *Controller) reconcile(ctx context.Context, service *corev1.Service) error {
+	klog.V(4).Infof("Reconciling EndpointSlices of Service %s|%s/%s", logicalcluster.From(service), service.Namespace, service.Name)
+
+	client := c.kubeClusterClient.Cluster(logicalcluster.From(service)).DiscoveryV1().EndpointSlices(service.Namespace)
+
+	desired, err := desiredEndpointSlices(service)
+	if err != nil {
+		// The annotation is broken, retrying won't help.
+		klog.Errorf("Failed to aggregate the endpoints of Service %s|%s/%s: %v", logicalcluster.From(service), service.Namespace, service.Name, err)
+		return nil
+	}
+
+	existing, err := client.List(ctx, metav1.ListOptions{LabelSelector: ownedBySelector(service.Name).String()})
+	if err != nil {
+		return err
+	}
+	existingByName := map[string]discoveryv1.EndpointSlice{}
+	for _, slice := range existing.Items {
+		existingByName[slice.Name] = slice
+	}
+
+	for _, slice := range desired {
+		current, found := existingByName[slice.Name]
+		delete(existingByName, slice.Name)
+		if !found {
+			if _, err := client.Create(ctx, slice, metav1.CreateOptions{}); err != nil {
+				return err
+			}
+			klog.Infof("Created EndpointSlice %s|%s/%s", logicalcluster.From(service), service.Namespace, slice.Name)
+			continue
+		}
+		if equality.Semantic.DeepEqual(current.Labels, slice.Labels) &&
+			equality.Semantic.DeepEqual(current.Ports, slice.Ports) &&
+			equality.Semantic.DeepEqual(current.Endpoints, slice.Endpoints) &&
+			current.AddressType == slice.AddressType {
+			continue
+		}
+		slice.ResourceVersion = current.ResourceVersion
+		if _, err := client.Update(ctx, slice, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		klog.Infof("Updated EndpointSlice %s|%s/%s", logicalcluster.From(service), service.Namespace, slice.Name)
+	}
+
+	for name := range existingByName {
+		if err := client.Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+		klog.Infof("Deleted EndpointSlice %s|%s/%s", logicalcluster.From(service), service.Namespace, name)
+	}
+
+	return nil
+}
+
+// desiredEndpointSlices returns the EndpointSlices aggregating the endpoints of the Service
+// exposed by the syncers, one per WorkloadCluster and EndpointSlice of the WorkloadCluster.
+func desiredEndpointSlices(service *corev1.Service) ([]*discoveryv1.EndpointSlice, error) {
+	var workloadClusters []string
+	for key := range service.Annotations {
+		if strings.HasPrefix(key, workloadv1alpha1.EndpointsAnnotationPrefix) {
+			workloadClusters = append(workloadClusters, strings.TrimPrefix(key, workloadv1alpha1.EndpointsAnnotationPrefix))
+		}
+	}
+	sort.Strings(workloadClusters)
+
+	var slices []*discoveryv1.EndpointSlice
+	for _, workloadCluster := range workloadClusters {
+		var clusterEndpoints []ClusterEndpoints
+		if err := json.Unmarshal([]byte(service.Annotations[workloadv1alpha1.EndpointsAnnotationPrefix+workloadCluster]), &clusterEndpoints); err != nil {
+			return nil, fmt.Errorf("failed to decode the endpoints of WorkloadCluster %q: %w", workloadCluster, err)
+		}
+		for i, endpoints := range clusterEndpoints {
+			slices = append(slices, &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-%s-%d", service.Name, workloadCluster, i),
+					Namespace: service.Namespace,
+					Labels: map[string]string{
+						discoveryv1.LabelServiceName: service.Name,
+						discoveryv1.LabelManagedBy:   ManagedBy,
+						WorkloadClusterLabel:         workloadCluster,
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: "v1",
+							Kind:       "Service",
+							Name:       service.Name,
+							UID:        service.UID,
+						},
+					},
+				},
+				AddressType: endpoints.AddressType,
+				Ports:       endpoints.Ports,
+				Endpoints:   endpoints.Endpoints,
+			})
+		}
+	}
+	return slices, nil
+}
+
+func ownedBySelector(serviceName string) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{
+		discoveryv1.LabelServiceName: serviceName,
+		discoveryv1.LabelManagedBy:   ManagedBy,
+	})
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package endpointslices
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/pointer"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestDesiredEndpointSlices(t *testing.T) {
+	service := func(annotations map[string]string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				ClusterName: "root:org:ws",
+				Namespace:   "default",
+				Name:        "web",
+				UID:         "uid",
+				Annotations: annotations,
+			},
+		}
+	}
+
+	t.Run("no endpoints", func(t *testing.T) {
+		slices, err := desiredEndpointSlices(service(map[string]string{"other": "annotation"}))
+		require.NoError(t, err)
+		require.Empty(t, slices)
+	})
+
+	t.Run("invalid annotation", func(t *testing.T) {
+		_, err := desiredEndpointSlices(service(map[string]string{workloadv1alpha1.EndpointsAnnotationPrefix + "us-east1": "{"}))
+		require.Error(t, err)
+	})
+
+	t.Run("endpoints of several clusters", func(t *testing.T) {
+		slices, err := desiredEndpointSlices(service(map[string]string{
+			workloadv1alpha1.EndpointsAnnotationPrefix + "us-west1": `[{"addressType":"IPv4","ports":[{"port":8080}],"endpoints":[{"addresses":["10.1.0.1"]}]}]`,
+			workloadv1alpha1.EndpointsAnnotationPrefix + "us-east1": `[{"addressType":"IPv4","endpoints":[{"addresses":["10.0.0.1"]}]},{"addressType":"IPv6","endpoints":[{"addresses":["fd00::1"]}]}]`,
+		}))
+		require.NoError(t, err)
+
+		var names []string
+		for _, slice := range slices {
+			names = append(names, slice.Name)
+			require.Equal(t, "default", slice.Namespace)
+			require.Equal(t, "web", slice.Labels[discoveryv1.LabelServiceName])
+			require.Equal(t, ManagedBy, slice.Labels[discoveryv1.LabelManagedBy])
+			require.True(t, ownedBySelector("web").Matches(labels.Set(slice.Labels)))
+			require.Equal(t, []metav1.OwnerReference{{APIVersion: "v1", Kind: "Service", Name: "web", UID: "uid"}}, slice.OwnerReferences)
+		}
+		require.Equal(t, []string{"web-us-east1-0", "web-us-east1-1", "web-us-west1-0"}, names)
+
+		require.Equal(t, "us-east1", slices[1].Labels[WorkloadClusterLabel])
+		require.Equal(t, discoveryv1.AddressTypeIPv6, slices[1].AddressType)
+		require.Equal(t, []discoveryv1.Endpoint{{Addresses: []string{"fd00::1"}}}, slices[1].Endpoints)
+
+		require.Equal(t, "us-west1", slices[2].Labels[WorkloadClusterLabel])
+		require.Equal(t, []discoveryv1.EndpointPort{{Port: pointer.Int32(8080)}}, slices[2].Ports)
+	})
+}
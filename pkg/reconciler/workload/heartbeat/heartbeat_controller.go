@@ -29,13 +29,13 @@ func NewController(
 	kcpClusterClient *kcpclient.Cluster,
 	clusterInformer workloadinformer.WorkloadClusterInformer,
 	apiResourceImportInformer apiresourceinformer.APIResourceImportInformer,
-	heartbeatThreshold time.Duration,
+	heartbeatThreshold func() time.Duration,
 ) (*basecontroller.ClusterReconciler, error) {
 	cm := &clusterManager{
 		heartbeatThreshold: heartbeatThreshold,
 	}
 
-	r, queue, err := basecontroller.NewClusterReconciler(
+	r, _, err := basecontroller.NewClusterReconciler(
 		"kcp-cluster-heartbeat-manager",
 		cm,
 		kcpClusterClient,
@@ -45,6 +45,5 @@ func NewController(
 	if err != nil {
 		return nil, err
 	}
-	cm.enqueueClusterAfter = queue.EnqueueAfter
 	return r, nil
 }
@@ -31,11 +31,11 @@ import (
 var _ basecontroller.ClusterReconcileImpl = (*clusterManager)(nil)
 
 type clusterManager struct {
-	heartbeatThreshold  time.Duration
-	enqueueClusterAfter func(*workloadv1alpha1.WorkloadCluster, time.Duration)
+	// heartbeatThreshold returns the current threshold, which can change while the server runs.
+	heartbeatThreshold func() time.Duration
 }
 
-func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster) error {
+func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster) (time.Duration, error) {
 	defer conditions.SetSummary(
 		cluster,
 		conditions.WithConditions(
@@ -45,6 +45,7 @@ func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha
 		),
 	)
 
+	heartbeatThreshold := c.heartbeatThreshold()
 	latestHeartbeat := time.Time{}
 	if cluster.Status.LastSyncerHeartbeatTime != nil {
 		latestHeartbeat = cluster.Status.LastSyncerHeartbeatTime.Time
@@ -56,7 +57,7 @@ func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha
 			workloadv1alpha1.ErrorHeartbeatMissedReason,
 			conditionsapi.ConditionSeverityWarning,
 			"No heartbeat yet seen")
-	} else if time.Since(latestHeartbeat) > c.heartbeatThreshold {
+	} else if time.Since(latestHeartbeat) > heartbeatThreshold {
 		klog.V(5).Infof("Marking HeartbeatHealthy false for WorkloadCluster %s|%s due to a stale heartbeat", cluster.ClusterName, cluster.Name)
 		conditions.MarkFalse(cluster,
 			workloadv1alpha1.HeartbeatHealthy,
@@ -67,12 +68,11 @@ func (c *clusterManager) Reconcile(ctx context.Context, cluster *workloadv1alpha
 		klog.V(5).Infof("Marking Heartbeat healthy true for WorkloadCluster %s|%s", cluster.ClusterName, cluster.Name)
 		conditions.MarkTrue(cluster, workloadv1alpha1.HeartbeatHealthy)
 
-		// Enqueue another check after which the heartbeat should have been updated again.
-		dur := time.Until(latestHeartbeat.Add(c.heartbeatThreshold))
-		c.enqueueClusterAfter(cluster, dur)
+		// Check again when the heartbeat should have been updated again.
+		return time.Until(latestHeartbeat.Add(heartbeatThreshold)), nil
 	}
 
-	return nil
+	return 0, nil
 }
 
 func (c *clusterManager) Cleanup(ctx context.Context, deletedCluster *workloadv1alpha1.WorkloadCluster) {
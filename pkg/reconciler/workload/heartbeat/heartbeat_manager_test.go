@@ -48,13 +48,8 @@ func TestManager(t *testing.T) {
 		wantReady:         false,
 	}} {
 		t.Run(c.desc, func(t *testing.T) {
-			var enqueued time.Duration
-			enqueueFunc := func(_ *workloadv1alpha1.WorkloadCluster, dur time.Duration) {
-				enqueued = dur
-			}
 			mgr := clusterManager{
-				heartbeatThreshold:  time.Minute,
-				enqueueClusterAfter: enqueueFunc,
+				heartbeatThreshold: func() time.Duration { return time.Minute },
 			}
 			ctx := context.Background()
 			heartbeat := metav1.NewTime(c.lastHeartbeatTime)
@@ -67,7 +62,8 @@ func TestManager(t *testing.T) {
 					LastSyncerHeartbeatTime: &heartbeat,
 				},
 			}
-			if err := mgr.Reconcile(ctx, cl); err != nil {
+			enqueued, err := mgr.Reconcile(ctx, cl)
+			if err != nil {
 				t.Fatalf("Reconcile: %v", err)
 			}
 
@@ -0,0 +1,220 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hpasplitter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/deploymentsplitter"
+)
+
+const controllerName = "kcp-hpa-splitter"
+
+// HorizontalPodAutoscalersGVR is the resource of the HorizontalPodAutoscalers split by the controller.
+var HorizontalPodAutoscalersGVR = schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+
+// NewController returns a new Controller which splits the HorizontalPodAutoscalers of split
+// Deployments into one leaf HorizontalPodAutoscaler per leaf Deployment, dividing the minimum and
+// maximum replicas between the clusters, and aggregates the status of the leaves into the root
+// HorizontalPodAutoscaler.
+//
+// HorizontalPodAutoscalers are handled as unstructured objects, as their API is imported from the
+// physical clusters.
+func NewController(
+	dynamicClient dynamic.Interface,
+	kubeClient kubernetes.Interface,
+	hpaInformer informers.GenericInformer,
+	deploymentInformer appsinformers.DeploymentInformer,
+) *Controller {
+	c := &Controller{
+		queue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		client:     dynamicClient,
+		kubeClient: kubeClient,
+
+		hpaIndexer: hpaInformer.Informer().GetIndexer(),
+		hpaLister:  hpaInformer.Lister(),
+
+		deploymentLister: deploymentInformer.Lister(),
+	}
+
+	hpaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			hpa, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				runtime.HandleError(fmt.Errorf("unexpected object type: %T", obj))
+				return
+			}
+
+			// If it's a deleted leaf, enqueue the root
+			if root := hpa.GetLabels()[deploymentsplitter.OwnedByLabel]; root != "" {
+				c.enqueue(&metav1.ObjectMeta{ClusterName: hpa.GetClusterName(), Namespace: hpa.GetNamespace(), Name: root})
+				return
+			}
+			c.enqueue(hpa)
+		},
+	})
+
+	deploymentInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueHPAsForDeployment(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueHPAsForDeployment(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueHPAsForDeployment(obj) },
+	})
+
+	return c
+}
+
+// Controller splits HorizontalPodAutoscalers by the clusters of the Deployment they scale.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	client     dynamic.Interface
+	kubeClient kubernetes.Interface
+
+	hpaIndexer cache.Indexer
+	hpaLister  cache.GenericLister
+
+	deploymentLister appslisters.DeploymentLister
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueHPAsForDeployment enqueues the root HorizontalPodAutoscalers in the namespace of a leaf
+// Deployment, which scale its root Deployment.
+func (c *Controller) enqueueHPAsForDeployment(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type: %T", obj))
+		return
+	}
+	root := deployment.Labels[deploymentsplitter.OwnedByLabel]
+	if root == "" {
+		return
+	}
+
+	hpas, err := c.hpaIndexer.ByIndex(cache.NamespaceIndex, deployment.Namespace)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, obj := range hpas {
+		hpa := obj.(*unstructured.Unstructured)
+		if logicalcluster.From(hpa) != logicalcluster.From(deployment) || isLeaf(hpa) || scaledDeployment(hpa) != root {
+			continue
+		}
+		klog.Infof("Deployment %s/%s triggered HorizontalPodAutoscaler %q reconciliation", deployment.Namespace, deployment.Name, hpa.GetName())
+		c.enqueue(hpa)
+	}
+}
+
+// Start starts the controller workers.
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.InfoS("Starting workers", "controller", controllerName)
+	defer klog.InfoS("Stopping workers", "controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, exists, err := c.hpaIndexer.GetByKey(key)
+	if err != nil {
+		klog.Errorf("Failed to get HorizontalPodAutoscaler with key %q because: %v", key, err)
+		return nil
+	}
+	if !exists {
+		klog.Infof("HorizontalPodAutoscaler with key %q was deleted", key)
+
+		namespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			runtime.HandleError(err)
+			return nil
+		}
+		clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
+		// the leaves are garbage collected, but the Deployments have to be handed back to the syncer
+		return c.releaseDeployments(ctx, clusterName, namespace, name, nil)
+	}
+
+	return c.reconcile(ctx, obj.(*unstructured.Unstructured))
+}
@@ -0,0 +1,341 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hpasplitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/deploymentsplitter"
+	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
+)
+
+const clusterLabel = nscontroller.ClusterLabel
+
+// reconcile is triggered on every change to a HorizontalPodAutoscaler, or to the leaf Deployments in its namespace.
+func (c *Controller) reconcile(ctx context.Context, hpa *unstructured.Unstructured) error {
+	klog.InfoS("reconciling HorizontalPodAutoscaler", "ClusterName", hpa.GetClusterName(), "Namespace", hpa.GetNamespace(), "Name", hpa.GetName())
+
+	if isLeaf(hpa) {
+		// we have a leaf HorizontalPodAutoscaler here and have to reconcile the root status
+		return c.reconcileRootStatusFromLeaves(ctx, hpa)
+	}
+	if hpa.GetLabels()[clusterLabel] != "" {
+		// the HorizontalPodAutoscaler is synced as is to a single cluster
+		return nil
+	}
+	return c.reconcileLeaves(ctx, hpa)
+}
+
+func (c *Controller) reconcileLeaves(ctx context.Context, root *unstructured.Unstructured) error {
+	clusterName := logicalcluster.From(root)
+	client := c.client.Resource(HorizontalPodAutoscalersGVR).Namespace(root.GetNamespace())
+
+	var deployments []*appsv1.Deployment
+	if target := scaledDeployment(root); target != "" {
+		var err error
+		if deployments, err = c.leafDeploymentsOf(clusterName, root.GetNamespace(), target); err != nil {
+			return err
+		}
+	}
+
+	currentLeaves, err := c.leavesOf(clusterName, root.GetNamespace(), root.GetName())
+	if err != nil {
+		return err
+	}
+	current := map[string]*unstructured.Unstructured{}
+	for _, leaf := range currentLeaves {
+		current[leaf.GetName()] = leaf
+	}
+
+	for _, leaf := range desiredLeaves(root, deployments) {
+		existing, found := current[leaf.GetName()]
+		delete(current, leaf.GetName())
+		if !found {
+			klog.InfoS("Creating leaf", "ClusterName", clusterName, "Namespace", leaf.GetNamespace(), "Name", leaf.GetName())
+			if _, err := client.Create(ctx, leaf, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create leaf: %w", err)
+			}
+			continue
+		}
+		if equality.Semantic.DeepEqual(existing.Object["spec"], leaf.Object["spec"]) {
+			continue
+		}
+		klog.InfoS("Updating leaf", "ClusterName", clusterName, "Namespace", leaf.GetNamespace(), "Name", leaf.GetName())
+		updated := existing.DeepCopy()
+		updated.Object["spec"] = leaf.Object["spec"]
+		if _, err := client.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update leaf: %w", err)
+		}
+	}
+
+	for _, leaf := range current {
+		klog.InfoS("Deleting leaf", "ClusterName", clusterName, "Namespace", leaf.GetNamespace(), "Name", leaf.GetName())
+		if err := client.Delete(ctx, leaf.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete leaf: %w", err)
+		}
+	}
+
+	return c.releaseDeployments(ctx, clusterName, root.GetNamespace(), root.GetName(), deployments)
+}
+
+// releaseDeployments marks the leaf Deployments scaled by the leaves of the root HorizontalPodAutoscaler,
+// such that the syncer leaves their replicas to the autoscalers on the clusters, and unmarks the others.
+func (c *Controller) releaseDeployments(ctx context.Context, clusterName logicalcluster.LogicalCluster, namespace, hpaName string, scaled []*appsv1.Deployment) error {
+	scaledNames := map[string]bool{}
+	for _, deployment := range scaled {
+		scaledNames[deployment.Name] = true
+	}
+	deployments, err := c.deploymentLister.Deployments(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, deployment := range deployments {
+		if logicalcluster.From(deployment) != clusterName {
+			continue
+		}
+		value, found := deployment.Annotations[workloadv1alpha1.ReplicasManagedDownstreamAnnotation]
+		var desired interface{}
+		switch {
+		case scaledNames[deployment.Name] && value != hpaName:
+			desired = hpaName
+		case !scaledNames[deployment.Name] && found && value == hpaName:
+			desired = nil
+		default:
+			continue
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{
+					workloadv1alpha1.ReplicasManagedDownstreamAnnotation: desired,
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		klog.InfoS("Patching autoscaled Deployment", "ClusterName", clusterName, "Namespace", namespace, "Name", deployment.Name, "HorizontalPodAutoscaler", desired)
+		if _, err := c.kubeClient.AppsV1().Deployments(namespace).Patch(ctx, deployment.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) reconcileRootStatusFromLeaves(ctx context.Context, leaf *unstructured.Unstructured) error {
+	root := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	root.SetClusterName(leaf.GetClusterName())
+	root.SetNamespace(leaf.GetNamespace())
+	root.SetName(leaf.GetLabels()[deploymentsplitter.OwnedByLabel])
+	obj, exists, err := c.hpaIndexer.Get(root)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		klog.Warningf("root HorizontalPodAutoscaler not found %s|%s/%s", root.GetClusterName(), root.GetNamespace(), root.GetName())
+		return nil
+	}
+	root = obj.(*unstructured.Unstructured)
+	clusterName := logicalcluster.From(root)
+
+	leaves, err := c.leavesOf(clusterName, root.GetNamespace(), root.GetName())
+	if err != nil {
+		return err
+	}
+
+	status := aggregateStatus(leaves)
+	if equality.Semantic.DeepEqual(root.Object["status"], status) {
+		return nil
+	}
+
+	updated := root.DeepCopy()
+	updated.Object["status"] = status
+	if _, err := c.client.Resource(HorizontalPodAutoscalersGVR).Namespace(root.GetNamespace()).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update root HorizontalPodAutoscaler status: %w", err)
+	}
+	return nil
+}
+
+// leafDeploymentsOf returns the leaf Deployments of the root Deployment, sorted by name.
+func (c *Controller) leafDeploymentsOf(clusterName logicalcluster.LogicalCluster, namespace, name string) ([]*appsv1.Deployment, error) {
+	selector := labels.SelectorFromSet(labels.Set{deploymentsplitter.OwnedByLabel: name})
+	objs, err := c.deploymentLister.Deployments(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	deployments := make([]*appsv1.Deployment, 0, len(objs))
+	for _, deployment := range objs {
+		if logicalcluster.From(deployment) == clusterName && deployment.Labels[clusterLabel] != "" {
+			deployments = append(deployments, deployment)
+		}
+	}
+	sort.Slice(deployments, func(i, j int) bool { return deployments[i].Name < deployments[j].Name })
+	return deployments, nil
+}
+
+func (c *Controller) leavesOf(clusterName logicalcluster.LogicalCluster, namespace, name string) ([]*unstructured.Unstructured, error) {
+	selector := labels.SelectorFromSet(labels.Set{deploymentsplitter.OwnedByLabel: name})
+	objs, err := c.hpaLister.ByNamespace(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([]*unstructured.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		leaf := obj.(*unstructured.Unstructured)
+		if logicalcluster.From(leaf) == clusterName && isLeaf(leaf) {
+			leaves = append(leaves, leaf)
+		}
+	}
+	return leaves, nil
+}
+
+func isLeaf(hpa *unstructured.Unstructured) bool {
+	return hpa.GetLabels()[clusterLabel] != "" && hpa.GetLabels()[deploymentsplitter.OwnedByLabel] != ""
+}
+
+// scaledDeployment returns the name of the Deployment scaled by the HorizontalPodAutoscaler, or an
+// empty string if it scales something else.
+func scaledDeployment(hpa *unstructured.Unstructured) string {
+	apiVersion, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "apiVersion")
+	kind, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "kind")
+	name, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "name")
+	if kind != "Deployment" || (apiVersion != "" && apiVersion != appsv1.SchemeGroupVersion.String()) {
+		return ""
+	}
+	return name
+}
+
+// splitReplicas divides the replicas between n clusters, the first ones getting the remainder.
+func splitReplicas(replicas int64, n int) []int64 {
+	split := make([]int64, n)
+	for i := range split {
+		split[i] = replicas / int64(n)
+		if int64(i) < replicas%int64(n) {
+			split[i]++
+		}
+	}
+	return split
+}
+
+// desiredLeaves returns one leaf HorizontalPodAutoscaler per leaf Deployment, with the minimum and
+// maximum replicas divided between them. Every leaf has at least one replica, and a maximum no less
+// than its minimum.
+func desiredLeaves(root *unstructured.Unstructured, deployments []*appsv1.Deployment) []*unstructured.Unstructured {
+	if len(deployments) == 0 {
+		return nil
+	}
+	minReplicas, found, _ := unstructured.NestedInt64(root.Object, "spec", "minReplicas")
+	if !found {
+		minReplicas = 1
+	}
+	maxReplicas, _, _ := unstructured.NestedInt64(root.Object, "spec", "maxReplicas")
+	mins := splitReplicas(minReplicas, len(deployments))
+	maxs := splitReplicas(maxReplicas, len(deployments))
+
+	leaves := make([]*unstructured.Unstructured, 0, len(deployments))
+	for i, deployment := range deployments {
+		if mins[i] < 1 {
+			mins[i] = 1
+		}
+		if maxs[i] < mins[i] {
+			maxs[i] = mins[i]
+		}
+		spec, _ := runtime.DeepCopyJSONValue(root.Object["spec"]).(map[string]interface{})
+		if spec == nil {
+			spec = map[string]interface{}{}
+		}
+		leaf := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": root.GetAPIVersion(),
+			"kind":       root.GetKind(),
+			"spec":       spec,
+		}}
+		_ = unstructured.SetNestedField(leaf.Object, deployment.Name, "spec", "scaleTargetRef", "name")
+		_ = unstructured.SetNestedField(leaf.Object, mins[i], "spec", "minReplicas")
+		_ = unstructured.SetNestedField(leaf.Object, maxs[i], "spec", "maxReplicas")
+
+		cluster := deployment.Labels[clusterLabel]
+		leaf.SetName(fmt.Sprintf("%s--%s", root.GetName(), cluster))
+		leaf.SetNamespace(root.GetNamespace())
+		leaf.SetLabels(map[string]string{
+			clusterLabel:                    cluster,
+			deploymentsplitter.OwnedByLabel: root.GetName(),
+		})
+		// Set OwnerReference so deleting the HorizontalPodAutoscaler deletes all its leaves.
+		leaf.SetOwnerReferences([]metav1.OwnerReference{{
+			APIVersion: root.GetAPIVersion(),
+			Kind:       root.GetKind(),
+			UID:        root.GetUID(),
+			Name:       root.GetName(),
+		}})
+		leaves = append(leaves, leaf)
+	}
+	return leaves
+}
+
+// aggregateStatus returns the status of the root HorizontalPodAutoscaler: the current and desired
+// replicas are the sums of those of the leaves, and the last scale time the latest one. The metrics
+// and conditions are those of the first leaf by name.
+// TODO: aggregate the metrics and conditions.
+func aggregateStatus(leaves []*unstructured.Unstructured) map[string]interface{} {
+	sorted := make([]*unstructured.Unstructured, len(leaves))
+	copy(sorted, leaves)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].GetName() < sorted[j].GetName() })
+
+	var currentReplicas, desiredReplicas int64
+	var lastScaleTime string
+	for _, leaf := range sorted {
+		current, _, _ := unstructured.NestedInt64(leaf.Object, "status", "currentReplicas")
+		desired, _, _ := unstructured.NestedInt64(leaf.Object, "status", "desiredReplicas")
+		currentReplicas += current
+		desiredReplicas += desired
+		// RFC 3339 timestamps sort lexicographically
+		if scaleTime, _, _ := unstructured.NestedString(leaf.Object, "status", "lastScaleTime"); scaleTime > lastScaleTime {
+			lastScaleTime = scaleTime
+		}
+	}
+
+	status := map[string]interface{}{
+		"currentReplicas": currentReplicas,
+		"desiredReplicas": desiredReplicas,
+	}
+	if lastScaleTime != "" {
+		status["lastScaleTime"] = lastScaleTime
+	}
+	if len(sorted) > 0 {
+		for _, field := range []string{"currentMetrics", "conditions"} {
+			if value, found, _ := unstructured.NestedFieldCopy(sorted[0].Object, "status", field); found {
+				status[field] = value
+			}
+		}
+	}
+	return status
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hpasplitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/deploymentsplitter"
+)
+
+func newHPA(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	hpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling/v2",
+		"kind":       "HorizontalPodAutoscaler",
+		"spec":       spec,
+	}}
+	hpa.SetClusterName("root:org:ws")
+	hpa.SetNamespace("default")
+	hpa.SetName(name)
+	return hpa
+}
+
+func newLeafDeployment(name, cluster string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "--" + cluster,
+			Namespace: "default",
+			Labels: map[string]string{
+				clusterLabel:                    cluster,
+				deploymentsplitter.OwnedByLabel: name,
+			},
+		},
+	}
+}
+
+func TestSplitReplicas(t *testing.T) {
+	for _, tc := range []struct {
+		replicas int64
+		n        int
+		want     []int64
+	}{
+		{replicas: 10, n: 1, want: []int64{10}},
+		{replicas: 10, n: 2, want: []int64{5, 5}},
+		{replicas: 10, n: 3, want: []int64{4, 3, 3}},
+		{replicas: 5, n: 3, want: []int64{2, 2, 1}},
+		{replicas: 1, n: 3, want: []int64{1, 0, 0}},
+	} {
+		require.Equal(t, tc.want, splitReplicas(tc.replicas, tc.n))
+	}
+}
+
+func TestScaledDeployment(t *testing.T) {
+	for _, tc := range []struct {
+		desc           string
+		scaleTargetRef map[string]interface{}
+		want           string
+	}{{
+		desc:           "deployment",
+		scaleTargetRef: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web"},
+		want:           "web",
+	}, {
+		desc:           "statefulset",
+		scaleTargetRef: map[string]interface{}{"apiVersion": "apps/v1", "kind": "StatefulSet", "name": "db"},
+	}, {
+		desc:           "custom deployment",
+		scaleTargetRef: map[string]interface{}{"apiVersion": "example.com/v1", "kind": "Deployment", "name": "web"},
+	}} {
+		t.Run(tc.desc, func(t *testing.T) {
+			hpa := newHPA("web", map[string]interface{}{"scaleTargetRef": tc.scaleTargetRef})
+			require.Equal(t, tc.want, scaledDeployment(hpa))
+		})
+	}
+}
+
+func TestDesiredLeaves(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		min, max interface{}
+		clusters []string
+		wantMin  []int64
+		wantMax  []int64
+	}{{
+		desc:     "even split",
+		min:      int64(2),
+		max:      int64(10),
+		clusters: []string{"east", "west"},
+		wantMin:  []int64{1, 1},
+		wantMax:  []int64{5, 5},
+	}, {
+		desc:     "remainder goes to the first leaf",
+		min:      int64(3),
+		max:      int64(7),
+		clusters: []string{"east", "west"},
+		wantMin:  []int64{2, 1},
+		wantMax:  []int64{4, 3},
+	}, {
+		desc:     "at least one replica per leaf",
+		max:      int64(2),
+		clusters: []string{"east", "north", "west"},
+		wantMin:  []int64{1, 1, 1},
+		wantMax:  []int64{1, 1, 1},
+	}} {
+		t.Run(tc.desc, func(t *testing.T) {
+			spec := map[string]interface{}{
+				"scaleTargetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web"},
+				"maxReplicas":    tc.max,
+			}
+			if tc.min != nil {
+				spec["minReplicas"] = tc.min
+			}
+			root := newHPA("web", spec)
+			var deployments []*appsv1.Deployment
+			for _, cluster := range tc.clusters {
+				deployments = append(deployments, newLeafDeployment("web", cluster))
+			}
+
+			leaves := desiredLeaves(root, deployments)
+			require.Len(t, leaves, len(tc.clusters))
+			for i, leaf := range leaves {
+				require.Equal(t, "web--"+tc.clusters[i], leaf.GetName())
+				require.Equal(t, "default", leaf.GetNamespace())
+				require.Equal(t, tc.clusters[i], leaf.GetLabels()[clusterLabel])
+				require.True(t, isLeaf(leaf))
+				require.Equal(t, "web--"+tc.clusters[i], scaledDeployment(leaf))
+				min, _, _ := unstructured.NestedInt64(leaf.Object, "spec", "minReplicas")
+				max, _, _ := unstructured.NestedInt64(leaf.Object, "spec", "maxReplicas")
+				require.Equal(t, tc.wantMin[i], min)
+				require.Equal(t, tc.wantMax[i], max)
+			}
+
+			// the spec of the leaves is independent of the root
+			require.Equal(t, "web", scaledDeployment(root))
+		})
+	}
+
+	require.Empty(t, desiredLeaves(newHPA("web", map[string]interface{}{}), nil))
+}
+
+func TestAggregateStatus(t *testing.T) {
+	leaf := func(name string, current, desired int64, lastScaleTime string) *unstructured.Unstructured {
+		hpa := newHPA(name, map[string]interface{}{})
+		hpa.Object["status"] = map[string]interface{}{
+			"currentReplicas": current,
+			"desiredReplicas": desired,
+			"lastScaleTime":   lastScaleTime,
+			"conditions":      []interface{}{map[string]interface{}{"type": "AbleToScale", "status": "True", "reason": name}},
+		}
+		return hpa
+	}
+
+	status := aggregateStatus([]*unstructured.Unstructured{
+		leaf("web--west", 3, 4, "2022-05-02T10:00:00Z"),
+		leaf("web--east", 2, 2, "2022-05-03T10:00:00Z"),
+	})
+	require.Equal(t, map[string]interface{}{
+		"currentReplicas": int64(5),
+		"desiredReplicas": int64(6),
+		"lastScaleTime":   "2022-05-03T10:00:00Z",
+		"conditions":      []interface{}{map[string]interface{}{"type": "AbleToScale", "status": "True", "reason": "web--east"}},
+	}, status)
+
+	require.Equal(t, map[string]interface{}{
+		"currentReplicas": int64(0),
+		"desiredReplicas": int64(0),
+	}, aggregateStatus(nil))
+}
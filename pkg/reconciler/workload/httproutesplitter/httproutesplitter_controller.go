@@ -0,0 +1,208 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package httproutesplitter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+const controllerName = "kcp-httproute-splitter"
+
+// HTTPRoutesGVR is the resource of the Gateway API HTTPRoutes split by the controller.
+var HTTPRoutesGVR = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "httproutes"}
+
+// NewController returns a new Controller which splits HTTPRoutes into one leaf HTTPRoute per
+// cluster the backend services are placed on, and aggregates the status of the leaves into the
+// root HTTPRoute.
+//
+// HTTPRoutes are handled as unstructured objects, such that the Gateway API types are not needed.
+func NewController(
+	dynamicClusterClient dynamic.ClusterInterface,
+	httpRouteInformer informers.GenericInformer,
+	serviceInformer coreinformers.ServiceInformer,
+) *Controller {
+	c := &Controller{
+		queue:  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		client: dynamicClusterClient,
+
+		httpRouteIndexer: httpRouteInformer.Informer().GetIndexer(),
+		httpRouteLister:  httpRouteInformer.Lister(),
+
+		serviceLister: serviceInformer.Lister(),
+	}
+
+	httpRouteInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			route, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				runtime.HandleError(fmt.Errorf("unexpected object type: %T", obj))
+				return
+			}
+
+			// If it's a deleted leaf, enqueue the root
+			if rootKey := rootHTTPRouteKeyFor(route); rootKey != "" {
+				c.queue.Add(rootKey)
+				return
+			}
+			c.enqueue(route)
+		},
+	})
+
+	serviceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueRoutesForService(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueRoutesForService(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueRoutesForService(obj) },
+	})
+
+	return c
+}
+
+// Controller splits HTTPRoutes by the clusters of their backends.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	client dynamic.ClusterInterface
+
+	httpRouteIndexer cache.Indexer
+	httpRouteLister  cache.GenericLister
+
+	serviceLister corelisters.ServiceLister
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// enqueueRoutesForService enqueues the root HTTPRoutes in the namespace of the service, as one of them
+// might have the service as a backend.
+func (c *Controller) enqueueRoutesForService(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	service, ok := obj.(*corev1.Service)
+	if !ok {
+		runtime.HandleError(fmt.Errorf("unexpected object type: %T", obj))
+		return
+	}
+
+	routes, err := c.httpRouteIndexer.ByIndex(cache.NamespaceIndex, service.Namespace)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, obj := range routes {
+		route := obj.(*unstructured.Unstructured)
+		if logicalcluster.From(route) != logicalcluster.From(service) || route.GetLabels()[clusterLabel] != "" {
+			continue
+		}
+		if !hasBackendService(route, service.Name) {
+			continue
+		}
+		klog.Infof("Service %s|%s/%s triggered HTTPRoute %q reconciliation", logicalcluster.From(service), service.Namespace, service.Name, route.GetName())
+		c.enqueue(route)
+	}
+}
+
+// Start starts the controller workers.
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.InfoS("Starting workers", "controller", controllerName)
+	defer klog.InfoS("Stopping workers", "controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	obj, exists, err := c.httpRouteIndexer.GetByKey(key)
+	if err != nil {
+		klog.Errorf("Failed to get HTTPRoute with key %q because: %v", key, err)
+		return nil
+	}
+	if !exists {
+		klog.Infof("HTTPRoute with key %q was deleted", key)
+
+		namespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			runtime.HandleError(err)
+			return nil
+		}
+		clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
+		return c.deleteLeaves(ctx, clusterName, namespace, name)
+	}
+
+	return c.reconcile(ctx, obj.(*unstructured.Unstructured))
+}
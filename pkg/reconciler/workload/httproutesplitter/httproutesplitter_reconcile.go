@@ -0,0 +1,282 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package httproutesplitter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/ingresssplitter"
+	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
+)
+
+const (
+	clusterLabel     = nscontroller.ClusterLabel
+	OwnedByCluster   = "gateway.kcp.dev/owned-by-cluster"
+	OwnedByHTTPRoute = "gateway.kcp.dev/owned-by-httproute"
+	OwnedByNamespace = "gateway.kcp.dev/owned-by-namespace"
+)
+
+// reconcile is triggered on every change to an HTTPRoute, or to the services in its namespace.
+func (c *Controller) reconcile(ctx context.Context, route *unstructured.Unstructured) error {
+	klog.InfoS("reconciling HTTPRoute", "ClusterName", route.GetClusterName(), "Namespace", route.GetNamespace(), "Name", route.GetName())
+
+	if route.GetLabels()[clusterLabel] == "" {
+		// we have a root route here
+		return c.reconcileLeaves(ctx, route)
+	}
+	// we have a leaf route here and have to reconcile the root status
+	return c.reconcileRootStatusFromLeaves(ctx, route)
+}
+
+func (c *Controller) reconcileLeaves(ctx context.Context, root *unstructured.Unstructured) error {
+	clusterName := logicalcluster.From(root)
+	client := c.client.Cluster(clusterName).Resource(HTTPRoutesGVR).Namespace(root.GetNamespace())
+
+	currentLeaves, err := c.leavesOf(clusterName, root.GetNamespace(), root.GetName())
+	if err != nil {
+		return err
+	}
+
+	var backendClusters []string
+	for _, name := range backendServiceNames(root) {
+		service, err := c.serviceLister.Services(root.GetNamespace()).Get(clusters.ToClusterAwareKey(clusterName, name))
+		if apierrors.IsNotFound(err) {
+			klog.Infof("Skipping missing backend service %q of HTTPRoute %s|%s/%s", name, clusterName, root.GetNamespace(), root.GetName())
+			continue
+		} else if err != nil {
+			return err
+		}
+		if cluster := service.Labels[clusterLabel]; cluster != "" {
+			backendClusters = append(backendClusters, cluster)
+		} else {
+			klog.Infof("Skipping service %q because it is not assigned to any cluster", service.Name)
+		}
+	}
+
+	desired := desiredLeaves(root, backendClusters)
+	current := map[string]*unstructured.Unstructured{}
+	for _, leaf := range currentLeaves {
+		current[leaf.GetName()] = leaf
+	}
+
+	for _, leaf := range desired {
+		existing, found := current[leaf.GetName()]
+		delete(current, leaf.GetName())
+		if !found {
+			klog.InfoS("Creating leaf", "ClusterName", clusterName, "Namespace", leaf.GetNamespace(), "Name", leaf.GetName())
+			if _, err := client.Create(ctx, leaf, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create leaf: %w", err)
+			}
+			continue
+		}
+		if equality.Semantic.DeepEqual(existing.Object["spec"], leaf.Object["spec"]) {
+			continue
+		}
+		klog.InfoS("Updating leaf", "ClusterName", clusterName, "Namespace", leaf.GetNamespace(), "Name", leaf.GetName())
+		updated := existing.DeepCopy()
+		updated.Object["spec"] = leaf.Object["spec"]
+		if _, err := client.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update leaf: %w", err)
+		}
+	}
+
+	for _, leaf := range current {
+		klog.InfoS("Deleting leaf", "ClusterName", clusterName, "Namespace", leaf.GetNamespace(), "Name", leaf.GetName())
+		if err := client.Delete(ctx, leaf.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete leaf: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// deleteLeaves deletes the leaves of a deleted root HTTPRoute.
+func (c *Controller) deleteLeaves(ctx context.Context, clusterName logicalcluster.LogicalCluster, namespace, name string) error {
+	leaves, err := c.leavesOf(clusterName, namespace, name)
+	if err != nil {
+		return err
+	}
+	for _, leaf := range leaves {
+		klog.InfoS("Deleting leaf of deleted root", "ClusterName", clusterName, "Namespace", leaf.GetNamespace(), "Name", leaf.GetName())
+		if err := c.client.Cluster(clusterName).Resource(HTTPRoutesGVR).Namespace(namespace).Delete(ctx, leaf.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete leaf: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) reconcileRootStatusFromLeaves(ctx context.Context, leaf *unstructured.Unstructured) error {
+	rootKey := rootHTTPRouteKeyFor(leaf)
+	if rootKey == "" {
+		return nil
+	}
+	obj, exists, err := c.httpRouteIndexer.GetByKey(rootKey)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		klog.Warningf("root HTTPRoute not found %s", rootKey)
+		return nil
+	}
+	root := obj.(*unstructured.Unstructured)
+	clusterName := logicalcluster.From(root)
+
+	leaves, err := c.leavesOf(clusterName, root.GetNamespace(), root.GetName())
+	if err != nil {
+		return err
+	}
+
+	parents := aggregateParents(leaves)
+	current, _, _ := unstructured.NestedSlice(root.Object, "status", "parents")
+	if equality.Semantic.DeepEqual(current, parents) {
+		return nil
+	}
+
+	updated := root.DeepCopy()
+	if err := unstructured.SetNestedSlice(updated.Object, parents, "status", "parents"); err != nil {
+		return err
+	}
+	if _, err := c.client.Cluster(clusterName).Resource(HTTPRoutesGVR).Namespace(root.GetNamespace()).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update root HTTPRoute status: %w", err)
+	}
+	return nil
+}
+
+func (c *Controller) leavesOf(clusterName logicalcluster.LogicalCluster, namespace, name string) ([]*unstructured.Unstructured, error) {
+	selector, err := createOwnedBySelector(clusterName, name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := c.httpRouteLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([]*unstructured.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		leaves = append(leaves, obj.(*unstructured.Unstructured))
+	}
+	return leaves, nil
+}
+
+// backendServiceNames returns the names of the services referenced as backends by the rules of the
+// HTTPRoute, in its namespace.
+func backendServiceNames(route *unstructured.Unstructured) []string {
+	names := sets.NewString()
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	for _, rule := range rules {
+		rule, ok := rule.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		for _, backendRef := range backendRefs {
+			backendRef, ok := backendRef.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			group, _, _ := unstructured.NestedString(backendRef, "group")
+			kind, _, _ := unstructured.NestedString(backendRef, "kind")
+			namespace, _, _ := unstructured.NestedString(backendRef, "namespace")
+			name, _, _ := unstructured.NestedString(backendRef, "name")
+			if group != "" || (kind != "" && kind != "Service") || (namespace != "" && namespace != route.GetNamespace()) || name == "" {
+				continue
+			}
+			names.Insert(name)
+		}
+	}
+	return names.List()
+}
+
+func hasBackendService(route *unstructured.Unstructured, name string) bool {
+	return sets.NewString(backendServiceNames(route)...).Has(name)
+}
+
+// desiredLeaves returns one leaf HTTPRoute per cluster.
+func desiredLeaves(root *unstructured.Unstructured, backendClusters []string) []*unstructured.Unstructured {
+	clusterNames := sets.NewString(backendClusters...).List()
+	leaves := make([]*unstructured.Unstructured, 0, len(clusterNames))
+	for _, cluster := range clusterNames {
+		leaf := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": root.GetAPIVersion(),
+			"kind":       root.GetKind(),
+			"spec":       runtime.DeepCopyJSONValue(root.Object["spec"]),
+		}}
+		leaf.SetName(root.GetName() + "-" + cluster)
+		leaf.SetNamespace(root.GetNamespace())
+		leaf.SetLabels(map[string]string{
+			clusterLabel: cluster,
+
+			// Label the leaf with the root information, so we can construct the root key from it.
+			OwnedByCluster:   ingresssplitter.LabelEscapeClusterName(logicalcluster.From(root)),
+			OwnedByHTTPRoute: root.GetName(),
+			OwnedByNamespace: root.GetNamespace(),
+		})
+		leaves = append(leaves, leaf)
+	}
+	return leaves
+}
+
+// aggregateParents returns the status parents of all the leaves, sorted by the name of the leaves.
+func aggregateParents(leaves []*unstructured.Unstructured) []interface{} {
+	sorted := make([]*unstructured.Unstructured, len(leaves))
+	copy(sorted, leaves)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].GetName() < sorted[j].GetName() })
+
+	parents := []interface{}{}
+	for _, leaf := range sorted {
+		leafParents, _, _ := unstructured.NestedSlice(leaf.Object, "status", "parents")
+		parents = append(parents, leafParents...)
+	}
+	return parents
+}
+
+func createOwnedBySelector(clusterName logicalcluster.LogicalCluster, name, namespace string) (labels.Selector, error) {
+	ownedClusterReq, err := labels.NewRequirement(OwnedByCluster, selection.Equals, []string{ingresssplitter.LabelEscapeClusterName(clusterName)})
+	if err != nil {
+		return nil, err
+	}
+	ownedRouteReq, err := labels.NewRequirement(OwnedByHTTPRoute, selection.Equals, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	ownedNamespaceReq, err := labels.NewRequirement(OwnedByNamespace, selection.Equals, []string{namespace})
+	if err != nil {
+		return nil, err
+	}
+	return labels.NewSelector().Add(*ownedClusterReq, *ownedRouteReq, *ownedNamespaceReq), nil
+}
+
+func rootHTTPRouteKeyFor(route metav1.Object) string {
+	if route.GetLabels()[OwnedByCluster] != "" && route.GetLabels()[OwnedByNamespace] != "" && route.GetLabels()[OwnedByHTTPRoute] != "" {
+		return route.GetLabels()[OwnedByNamespace] + "/" + clusters.ToClusterAwareKey(ingresssplitter.UnescapeClusterNameLabel(route.GetLabels()[OwnedByCluster]), route.GetLabels()[OwnedByHTTPRoute])
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package httproutesplitter
+
+import (
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/clusters"
+
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/ingresssplitter"
+)
+
+func newHTTPRoute(name string, rules ...interface{}) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1alpha2",
+		"kind":       "HTTPRoute",
+		"spec": map[string]interface{}{
+			"hostnames": []interface{}{"example.com"},
+			"rules":     rules,
+		},
+	}}
+	route.SetClusterName("root:org:ws")
+	route.SetNamespace("default")
+	route.SetName(name)
+	return route
+}
+
+func backendRef(fields map[string]interface{}) interface{} {
+	return map[string]interface{}{"backendRefs": []interface{}{fields}}
+}
+
+func TestBackendServiceNames(t *testing.T) {
+	route := newHTTPRoute("route",
+		backendRef(map[string]interface{}{"name": "web", "port": int64(80)}),
+		backendRef(map[string]interface{}{"name": "api", "kind": "Service", "port": int64(80)}),
+		backendRef(map[string]interface{}{"name": "web", "port": int64(8080)}),
+		backendRef(map[string]interface{}{"name": "other-namespace", "namespace": "other"}),
+		backendRef(map[string]interface{}{"name": "bucket", "group": "example.com", "kind": "Bucket"}),
+	)
+	require.Equal(t, []string{"api", "web"}, backendServiceNames(route))
+	require.True(t, hasBackendService(route, "web"))
+	require.False(t, hasBackendService(route, "other-namespace"))
+}
+
+func TestDesiredLeaves(t *testing.T) {
+	root := newHTTPRoute("route", backendRef(map[string]interface{}{"name": "web"}))
+	root.SetLabels(map[string]string{"app": "web"})
+
+	leaves := desiredLeaves(root, []string{"us-west1", "us-east1", "us-west1"})
+	require.Len(t, leaves, 2)
+
+	require.Equal(t, "route-us-east1", leaves[0].GetName())
+	require.Equal(t, "route-us-west1", leaves[1].GetName())
+	for _, leaf := range leaves {
+		require.Equal(t, "default", leaf.GetNamespace())
+		require.Equal(t, root.Object["spec"], leaf.Object["spec"])
+		require.Equal(t, "root:org:ws", rootClusterOf(t, leaf))
+		require.Equal(t, "default/"+clusters.ToClusterAwareKey(logicalcluster.New("root:org:ws"), "route"), rootHTTPRouteKeyFor(leaf))
+	}
+	require.Equal(t, "us-east1", leaves[0].GetLabels()[clusterLabel])
+
+	// the spec of the leaves is independent of the root
+	unstructured.RemoveNestedField(leaves[0].Object, "spec", "hostnames")
+	hostnames, found, _ := unstructured.NestedStringSlice(root.Object, "spec", "hostnames")
+	require.True(t, found)
+	require.Equal(t, []string{"example.com"}, hostnames)
+}
+
+func rootClusterOf(t *testing.T, leaf *unstructured.Unstructured) string {
+	t.Helper()
+	selector, err := createOwnedBySelector(logicalcluster.New("root:org:ws"), "route", "default")
+	require.NoError(t, err)
+	require.True(t, selector.Matches(labels.Set(leaf.GetLabels())))
+	return ingresssplitter.UnescapeClusterNameLabel(leaf.GetLabels()[OwnedByCluster]).String()
+}
+
+func TestAggregateParents(t *testing.T) {
+	leaf := func(name, controller string) *unstructured.Unstructured {
+		l := newHTTPRoute(name)
+		require.NoError(t, unstructured.SetNestedSlice(l.Object, []interface{}{
+			map[string]interface{}{"controllerName": controller},
+		}, "status", "parents"))
+		return l
+	}
+
+	require.Equal(t, []interface{}{}, aggregateParents(nil))
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"controllerName": "east"},
+		map[string]interface{}{"controllerName": "west"},
+	}, aggregateParents([]*unstructured.Unstructured{
+		leaf("route-us-west1", "west"),
+		newHTTPRoute("route-pending"),
+		leaf("route-us-east1", "east"),
+	}))
+}
@@ -18,6 +18,7 @@ package ingresssplitter
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -30,6 +31,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 
 	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
@@ -40,6 +42,10 @@ const (
 	OwnedByCluster   = "ingress.kcp.dev/owned-by-cluster"
 	OwnedByIngress   = "ingress.kcp.dev/owned-by-ingress"
 	OwnedByNamespace = "ingress.kcp.dev/owned-by-namespace"
+
+	// EndpointsAnnotation is set on a root ingress to the load balancer ingress points of its leaves,
+	// in JSON, by the cluster they are placed on.
+	EndpointsAnnotation = "ingress.kcp.dev/endpoints"
 )
 
 // reconcile is triggered on every change to an ingress resource, or it's associated services (by tracker).
@@ -148,9 +154,51 @@ func (c *Controller) reconcileRootStatusFromLeaves(ctx context.Context, ingress
 		return fmt.Errorf("failed to update root ingress status: %w", err)
 	}
 
+	// Publish the merged endpoints view, which tells where the LB ingress points are placed.
+	endpoints, err := endpointsView(others)
+	if err != nil {
+		return err
+	}
+	if rootIngress.Annotations[EndpointsAnnotation] == endpoints {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				EndpointsAnnotation: endpoints,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Cluster(logicalcluster.From(rootIngress)).NetworkingV1().Ingresses(rootIngress.Namespace).Patch(ctx, rootIngress.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to update root ingress endpoints: %w", err)
+	}
+
 	return nil
 }
 
+// endpointsView returns the LB ingress points of the leaves by cluster, in JSON.
+func endpointsView(leaves []*networkingv1.Ingress) (string, error) {
+	endpoints := map[string][]corev1.LoadBalancerIngress{}
+	for _, leaf := range leaves {
+		cluster := leaf.Labels[clusterLabel]
+		if cluster == "" {
+			continue
+		}
+		if _, ok := endpoints[cluster]; !ok {
+			endpoints[cluster] = []corev1.LoadBalancerIngress{}
+		}
+		endpoints[cluster] = append(endpoints[cluster], leaf.Status.LoadBalancer.Ingress...)
+	}
+	bs, err := json.Marshal(endpoints)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
 func (c *Controller) updateLeafs(ctx context.Context, currentLeaves []*networkingv1.Ingress, desiredLeaves []*networkingv1.Ingress) ([]*networkingv1.Ingress, []*networkingv1.Ingress, error) {
 	var toDelete, toCreate []*networkingv1.Ingress
 
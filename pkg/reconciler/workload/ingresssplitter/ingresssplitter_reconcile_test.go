@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package ingresssplitter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEndpointsView(t *testing.T) {
+	leaf := func(cluster string, ips ...string) *networkingv1.Ingress {
+		ingress := &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}},
+		}
+		if cluster != "" {
+			ingress.Labels[clusterLabel] = cluster
+		}
+		for _, ip := range ips {
+			ingress.Status.LoadBalancer.Ingress = append(ingress.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{IP: ip})
+		}
+		return ingress
+	}
+
+	tests := []struct {
+		name   string
+		leaves []*networkingv1.Ingress
+		want   string
+	}{
+		{
+			name: "no leaves",
+			want: "{}",
+		},
+		{
+			name:   "leaf without load balancer yet",
+			leaves: []*networkingv1.Ingress{leaf("us-east1")},
+			want:   `{"us-east1":[]}`,
+		},
+		{
+			name: "multiple clusters",
+			leaves: []*networkingv1.Ingress{
+				leaf("us-west1", "10.0.0.2"),
+				leaf("us-east1", "10.0.0.1", "10.0.0.3"),
+				leaf("", "10.0.0.4"),
+			},
+			want: `{"us-east1":[{"ip":"10.0.0.1"},{"ip":"10.0.0.3"}],"us-west1":[{"ip":"10.0.0.2"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := endpointsView(tt.leaves)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
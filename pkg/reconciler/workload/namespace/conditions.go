@@ -36,6 +36,14 @@ const (
 	// means that the automated scheduling for this namespace is disabled, e.g., when it's
 	// labelled with ScheduleDisabledLabel.
 	NamespaceReasonSchedulingDisabled = "SchedulingDisabled"
+	// NamespaceReasonReschedulingBlocked reason in NamespaceScheduled Namespace Condition
+	// means that the namespace is kept on a cluster it would otherwise be moved away from,
+	// as persistent volume claims of the namespace are bound on that cluster.
+	NamespaceReasonReschedulingBlocked = "ReschedulingBlocked"
+	// NamespaceReasonExternallyScheduled reason in NamespaceScheduled Namespace Condition
+	// means that the namespace is waiting for the external scheduler named by the
+	// SchedulerAnnotation to assign a cluster.
+	NamespaceReasonExternallyScheduled = "ExternallyScheduled"
 )
 
 // NamespaceConditionsAdapter enables the use of the conditions helper
@@ -84,7 +92,7 @@ func IsScheduled(ns *corev1.Namespace) bool {
 	return false
 }
 
-func setScheduledCondition(ns *corev1.Namespace) *corev1.Namespace {
+func setScheduledCondition(ns *corev1.Namespace, reschedulingBlockedMsg string) *corev1.Namespace {
 	updatedNs := ns.DeepCopy()
 	conditionsAdapter := &NamespaceConditionsAdapter{updatedNs}
 
@@ -93,6 +101,16 @@ func setScheduledCondition(ns *corev1.Namespace) *corev1.Namespace {
 		conditions.MarkFalse(conditionsAdapter, NamespaceScheduled, NamespaceReasonSchedulingDisabled,
 			conditionsv1alpha1.ConditionSeverityNone, // NamespaceCondition doesn't support severity
 			"Automatic scheduling is deactivated and can be performed by setting the cluster label manually.")
+	} else if scheduler := ns.Annotations[SchedulerAnnotation]; scheduler != "" && ns.Labels[ClusterLabel] == "" {
+		// Waiting for the external scheduler
+		conditions.MarkFalse(conditionsAdapter, NamespaceScheduled, NamespaceReasonExternallyScheduled,
+			conditionsv1alpha1.ConditionSeverityNone, // NamespaceCondition doesn't support severity
+			"Waiting for scheduler %q to assign a cluster.", scheduler)
+	} else if reschedulingBlockedMsg != "" {
+		// Sticky to an invalid cluster
+		conditions.MarkFalse(conditionsAdapter, NamespaceScheduled, NamespaceReasonReschedulingBlocked,
+			conditionsv1alpha1.ConditionSeverityNone, // NamespaceCondition doesn't support severity
+			"%s", reschedulingBlockedMsg)
 	} else if ns.Labels[ClusterLabel] == "" {
 		// Unschedulable
 		conditions.MarkFalse(conditionsAdapter, NamespaceScheduled, NamespaceReasonUnschedulable,
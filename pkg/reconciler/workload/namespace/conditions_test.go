@@ -30,9 +30,11 @@ import (
 
 func TestSetScheduledCondition(t *testing.T) {
 	testCases := map[string]struct {
-		labels    map[string]string
-		scheduled bool
-		reason    conditionsapi.ConditionType
+		labels                 map[string]string
+		annotations            map[string]string
+		reschedulingBlockedMsg string
+		scheduled              bool
+		reason                 conditionsapi.ConditionType
 	}{
 		"disabled label present but empty": {
 			labels: map[string]string{
@@ -54,6 +56,28 @@ func TestSetScheduledCondition(t *testing.T) {
 			},
 			scheduled: true,
 		},
+		"rescheduling blocked": {
+			labels: map[string]string{
+				ClusterLabel: "foo",
+			},
+			reschedulingBlockedMsg: "Cluster foo is not reporting ready",
+			reason:                 NamespaceReasonReschedulingBlocked,
+		},
+		"waiting for external scheduler": {
+			annotations: map[string]string{
+				SchedulerAnnotation: "my-scheduler",
+			},
+			reason: NamespaceReasonExternallyScheduled,
+		},
+		"scheduled by external scheduler": {
+			labels: map[string]string{
+				ClusterLabel: "foo",
+			},
+			annotations: map[string]string{
+				SchedulerAnnotation: "my-scheduler",
+			},
+			scheduled: true,
+		},
 		"unscheduled with label": {
 			labels: map[string]string{
 				ClusterLabel: "",
@@ -68,10 +92,11 @@ func TestSetScheduledCondition(t *testing.T) {
 		t.Run(testName, func(t *testing.T) {
 			ns := &corev1.Namespace{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: testCase.labels,
+					Labels:      testCase.labels,
+					Annotations: testCase.annotations,
 				},
 			}
-			updatedNs := setScheduledCondition(ns)
+			updatedNs := setScheduledCondition(ns, testCase.reschedulingBlockedMsg)
 			condition := conditions.Get(&NamespaceConditionsAdapter{updatedNs}, NamespaceScheduled)
 			require.NotEmpty(t, condition, "condition missing")
 			scheduled := condition.Status == corev1.ConditionTrue
@@ -42,6 +42,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	workloadinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
@@ -61,9 +62,13 @@ func NewController(
 	dynamicMetadataClusterClient dynamic.ClusterInterface,
 	clusterDiscoveryClient clusterDiscovery,
 	kubeClusterClient kubernetes.ClusterInterface,
+	kcpClusterClient kcpclient.ClusterInterface,
 	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
 	clusterInformer workloadinformer.WorkloadClusterInformer,
 	clusterLister workloadlisters.WorkloadClusterLister,
+	placementInformer workloadinformer.PlacementInformer,
+	poolInformer workloadinformer.WorkloadClusterPoolInformer,
+	placementDecisionInformer workloadinformer.PlacementDecisionInformer,
 	namespaceInformer coreinformers.NamespaceInformer,
 	namespaceLister corelisters.NamespaceLister,
 	pollInterval time.Duration,
@@ -73,6 +78,7 @@ func NewController(
 	namespaceQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-namespace")
 	clusterQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-cluster")
 	workspaceQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-workspace")
+	placementQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-namespace-placement")
 
 	workspaceLister := workspaceInformer.Lister()
 
@@ -82,12 +88,18 @@ func NewController(
 		namespaceQueue: namespaceQueue,
 		clusterQueue:   clusterQueue,
 		workspaceQueue: workspaceQueue,
+		placementQueue: placementQueue,
 
 		dynClient:       dynamicClusterClient,
 		workspaceLister: workspaceLister,
 		clusterLister:   clusterLister,
+		placementLister: placementInformer.Lister(),
+		poolLister:      poolInformer.Lister(),
 		namespaceLister: namespaceLister,
 		kubeClient:      kubeClusterClient,
+		kcpClient:       kcpClusterClient,
+
+		placementDecisionLister: placementDecisionInformer.Lister(),
 
 		namespaceContentsEnqueuedForMap: map[string]string{},
 	}
@@ -96,6 +108,18 @@ func NewController(
 		UpdateFunc: func(_, obj interface{}) { c.enqueueCluster(obj) },
 		DeleteFunc: func(obj interface{}) { c.enqueueCluster(obj) },
 	})
+	placementInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueuePlacement(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueuePlacement(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueuePlacement(obj) },
+	})
+	// A pool change affects the namespaces of the placements referencing the pool,
+	// which live in the logical cluster of the pool, like for a placement change.
+	poolInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueuePlacement(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueuePlacement(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueuePlacement(obj) },
+	})
 	namespaceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: filterNamespace,
 		Handler: cache.ResourceEventHandlerFuncs{
@@ -137,14 +161,20 @@ type Controller struct {
 	namespaceQueue workqueue.RateLimitingInterface
 	clusterQueue   workqueue.RateLimitingInterface
 	workspaceQueue workqueue.RateLimitingInterface
+	placementQueue workqueue.RateLimitingInterface
 
 	dynClient       dynamic.ClusterInterface
 	clusterLister   workloadlisters.WorkloadClusterLister
+	placementLister workloadlisters.PlacementLister
+	poolLister      workloadlisters.WorkloadClusterPoolLister
 	namespaceLister corelisters.NamespaceLister
 	workspaceLister tenancylisters.ClusterWorkspaceLister
 	kubeClient      kubernetes.ClusterInterface
+	kcpClient       kcpclient.ClusterInterface
 	ddsif           informer.DynamicDiscoverySharedInformerFactory
 
+	placementDecisionLister workloadlisters.PlacementDecisionLister
+
 	// Mapping of namespace key to the last scheduling decision for
 	// which contained resources were enqueued for.
 	namespaceContentsEnqueuedForMap  map[string]string
@@ -235,6 +265,15 @@ func (c *Controller) enqueueWorkspace(obj interface{}) {
 	c.workspaceQueue.Add(key)
 }
 
+func (c *Controller) enqueuePlacement(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.placementQueue.Add(key)
+}
+
 func (c *Controller) Start(ctx context.Context, numThreads int) {
 	defer runtime.HandleCrash()
 	defer c.resourceQueue.ShutDown()
@@ -242,6 +281,7 @@ func (c *Controller) Start(ctx context.Context, numThreads int) {
 	defer c.namespaceQueue.ShutDown()
 	defer c.clusterQueue.ShutDown()
 	defer c.workspaceQueue.ShutDown()
+	defer c.placementQueue.ShutDown()
 
 	klog.Info("Starting Namespace scheduler")
 	defer klog.Info("Shutting down Namespace scheduler")
@@ -254,6 +294,7 @@ func (c *Controller) Start(ctx context.Context, numThreads int) {
 		go wait.Until(func() { c.startNamespaceWorker(ctx) }, time.Second, ctx.Done())
 		go wait.Until(func() { c.startClusterWorker(ctx) }, time.Second, ctx.Done())
 		go wait.Until(func() { c.startWorkspaceWorker(ctx) }, time.Second, ctx.Done())
+		go wait.Until(func() { c.startPlacementWorker(ctx) }, time.Second, ctx.Done())
 	}
 	<-ctx.Done()
 }
@@ -280,6 +321,11 @@ func (c *Controller) startWorkspaceWorker(ctx context.Context) {
 	}
 }
 
+func (c *Controller) startPlacementWorker(ctx context.Context) {
+	for processNext(ctx, c.placementQueue, c.processPlacement) {
+	}
+}
+
 func processNext(
 	ctx context.Context,
 	queue workqueue.RateLimitingInterface,
@@ -407,3 +453,24 @@ func (c *Controller) processWorkspace(ctx context.Context, key string) error {
 	// TODO(marun) Enqueue only the namespaces in the workspace.
 	return c.enqueueNamespaces(ctx, labels.Everything())
 }
+
+func (c *Controller) processPlacement(ctx context.Context, key string) error {
+	// Any placement or pool change, including a deletion, can change the
+	// scheduling of the namespaces of the placement's logical cluster.
+	lclusterName, _ := clusters.SplitClusterAwareKey(key)
+
+	namespaces, err := c.namespaceLister.ListWithContext(ctx, labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, namespace := range namespaces {
+		if logicalcluster.From(namespace) != lclusterName {
+			continue
+		}
+		if namespaceBlocklist.Has(namespace.Name) {
+			continue
+		}
+		c.enqueueNamespace(namespace)
+	}
+	return nil
+}
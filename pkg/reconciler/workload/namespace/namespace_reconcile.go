@@ -28,6 +28,8 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -52,8 +54,35 @@ const (
 	// enables scheduling for the contents of the workspace. It is applied by
 	// default to workspaces of type `Universal`.
 	WorkspaceSchedulableLabel = "workloads.kcp.dev/schedulable"
+
+	// AllowMigrationAnnotation set to "true" on a namespace allows the scheduler to assign
+	// the namespace to another cluster although persistent volume claims of the namespace
+	// are bound on its current cluster. The data of the volumes is not migrated.
+	AllowMigrationAnnotation = "experimental.workloads.kcp.dev/allow-migration"
+
+	// SchedulerAnnotation on a namespace hands the scheduling of the namespace off to the
+	// external scheduler named by the value. The built-in scheduler then leaves the cluster
+	// label of the namespace to the external scheduler, while the resources of the namespace
+	// keep following the cluster of the namespace.
+	SchedulerAnnotation = "experimental.workloads.kcp.dev/scheduler"
+
+	// SchedulingDecisionReasonScheduled is the reason of the event recorded on a namespace
+	// when it is assigned to a cluster for the first time.
+	SchedulingDecisionReasonScheduled = "Scheduled"
+	// SchedulingDecisionReasonRescheduled is the reason of the event recorded on a namespace
+	// when it is moved from one cluster to another.
+	SchedulingDecisionReasonRescheduled = "Rescheduled"
+	// SchedulingDecisionReasonUnscheduled is the reason of the event recorded on a namespace
+	// when its cluster assignment is removed.
+	SchedulingDecisionReasonUnscheduled = "Unscheduled"
+
+	// maxPlacementDecisions is the number of scheduling decisions kept in the
+	// PlacementDecision of a namespace.
+	maxPlacementDecisions = 10
 )
 
+var persistentVolumeClaimsGVR = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+
 var (
 	scheduleRequirement           labels.Requirement
 	scheduleEmptyLabelRequirement labels.Requirement
@@ -166,21 +195,27 @@ func (c *Controller) reconcileGVR(ctx context.Context, gvr schema.GroupVersionRe
 // ensureScheduled attempts to ensure the namespace is assigned to a viable cluster. This
 // will succeed without error if a cluster is assigned or if there are no viable clusters
 // to assign to. The condition of not being scheduled to a cluster will be reflected in
-// the namespace's status rather than by returning an error.
-func (c *Controller) ensureScheduled(ctx context.Context, ns *corev1.Namespace) error {
+// the namespace's status rather than by returning an error. The returned message explains
+// why the namespace was kept on a cluster that is not viable anymore, if so.
+func (c *Controller) ensureScheduled(ctx context.Context, ns *corev1.Namespace) (string, error) {
 	oldPClusterName := ns.Labels[ClusterLabel]
 
 	scheduler := namespaceScheduler{
-		getCluster:   c.clusterLister.Get,
-		listClusters: c.clusterLister.List,
-	}
-	newPClusterName, err := scheduler.AssignCluster(ns)
+		getCluster:     c.clusterLister.Get,
+		listClusters:   c.clusterLister.List,
+		listPlacements: c.placementLister.List,
+		getPool:        c.poolLister.Get,
+		hasBoundClaims: c.hasBoundPersistentVolumeClaims,
+		listNamespaces: c.namespaceLister.List,
+	}
+	newPClusterName, reschedulingBlockedMsg, err := scheduler.AssignCluster(ns)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if oldPClusterName == newPClusterName {
-		return nil
+		c.recordPlacementDecision(ctx, ns, scheduler.decision)
+		return reschedulingBlockedMsg, nil
 	}
 
 	klog.Infof("Patching to update cluster assignment for namespace %s|%s: %s -> %s",
@@ -188,18 +223,182 @@ func (c *Controller) ensureScheduled(ctx context.Context, ns *corev1.Namespace)
 	patchType, patchBytes := clusterLabelPatchBytes(newPClusterName)
 	patchedNamespace, err := c.kubeClient.Cluster(logicalcluster.From(ns)).CoreV1().Namespaces().
 		Patch(ctx, ns.Name, patchType, patchBytes, metav1.PatchOptions{})
-	if err == nil {
-		// Update the label to enable the caller to detect a scheduling change.
-		ns.Labels[ClusterLabel] = patchedNamespace.Labels[ClusterLabel]
+	if err != nil {
+		return "", err
+	}
+	// Update the label to enable the caller to detect a scheduling change.
+	ns.Labels[ClusterLabel] = patchedNamespace.Labels[ClusterLabel]
+
+	c.recordSchedulingDecision(ctx, ns, oldPClusterName, newPClusterName)
+	c.recordPlacementDecision(ctx, ns, scheduler.decision)
+
+	return "", nil
+}
+
+// recordPlacementDecision appends the given decision to the PlacementDecision of the
+// namespace, keeping the last maxPlacementDecisions. A decision identical to the last
+// one but for its time is not recorded again. Failing to record the decision doesn't
+// fail the scheduling.
+func (c *Controller) recordPlacementDecision(ctx context.Context, ns *corev1.Namespace, decision *workloadv1alpha1.SchedulingDecision) {
+	if decision == nil {
+		return
+	}
+	decision = decision.DeepCopy()
+	decision.Time = metav1.Now()
+
+	client := c.kcpClient.Cluster(logicalcluster.From(ns)).WorkloadV1alpha1().PlacementDecisions()
+	existing, err := c.placementDecisionLister.Get(clusters.ToClusterAwareKey(logicalcluster.From(ns), ns.Name))
+	if apierrors.IsNotFound(err) {
+		placementDecision := &workloadv1alpha1.PlacementDecision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ns.Name,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "v1",
+					Kind:       "Namespace",
+					Name:       ns.Name,
+					UID:        ns.UID,
+				}},
+			},
+			Decisions: []workloadv1alpha1.SchedulingDecision{*decision},
+		}
+		if _, err := client.Create(ctx, placementDecision, metav1.CreateOptions{}); err != nil {
+			klog.Errorf("Failed to record the placement decision for namespace %s|%s: %v", ns.ClusterName, ns.Name, err)
+		}
+		return
+	}
+	if err != nil {
+		klog.Errorf("Failed to get the placement decisions of namespace %s|%s: %v", ns.ClusterName, ns.Name, err)
+		return
+	}
+
+	placementDecision := existing.DeepCopy()
+	if !appendSchedulingDecision(placementDecision, *decision) {
+		return
+	}
+	if _, err := client.Update(ctx, placementDecision, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Failed to record the placement decision for namespace %s|%s: %v", ns.ClusterName, ns.Name, err)
+	}
+}
+
+// appendSchedulingDecision appends the decision to the given PlacementDecision, dropping
+// the oldest decisions beyond maxPlacementDecisions. It returns false if the decision is
+// identical to the last one but for its time, and leaves the PlacementDecision unchanged.
+func appendSchedulingDecision(placementDecision *workloadv1alpha1.PlacementDecision, decision workloadv1alpha1.SchedulingDecision) bool {
+	if n := len(placementDecision.Decisions); n > 0 {
+		last := placementDecision.Decisions[n-1]
+		last.Time = decision.Time
+		if equality.Semantic.DeepEqual(last, decision) {
+			return false
+		}
+	}
+	placementDecision.Decisions = append(placementDecision.Decisions, decision)
+	if n := len(placementDecision.Decisions); n > maxPlacementDecisions {
+		placementDecision.Decisions = placementDecision.Decisions[n-maxPlacementDecisions:]
+	}
+	return true
+}
+
+// recordSchedulingDecision records an event on the namespace for the change of its cluster
+// assignment, such that scheduling decisions can be watched and audited. Failing to record
+// the event doesn't fail the scheduling.
+func (c *Controller) recordSchedulingDecision(ctx context.Context, ns *corev1.Namespace, oldPClusterName, newPClusterName string) {
+	event := schedulingDecisionEvent(ns, oldPClusterName, newPClusterName, metav1.Now())
+	if _, err := c.kubeClient.Cluster(logicalcluster.From(ns)).CoreV1().Events(ns.Name).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		klog.Errorf("Failed to record the scheduling decision for namespace %s|%s: %v", ns.ClusterName, ns.Name, err)
+	}
+}
+
+func schedulingDecisionEvent(ns *corev1.Namespace, oldPClusterName, newPClusterName string, now metav1.Time) *corev1.Event {
+	var reason, message string
+	switch {
+	case oldPClusterName == "":
+		reason = SchedulingDecisionReasonScheduled
+		message = fmt.Sprintf("Assigned to cluster %s", newPClusterName)
+	case newPClusterName == "":
+		reason = SchedulingDecisionReasonUnscheduled
+		message = fmt.Sprintf("Unassigned from cluster %s", oldPClusterName)
+	default:
+		reason = SchedulingDecisionReasonRescheduled
+		message = fmt.Sprintf("Moved from cluster %s to cluster %s", oldPClusterName, newPClusterName)
 	}
 
-	return err
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: ns.Name + ".",
+			Namespace:    ns.Name,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+			Name:       ns.Name,
+			UID:        ns.UID,
+		},
+		Reason:              reason,
+		Message:             message,
+		Type:                corev1.EventTypeNormal,
+		Source:              corev1.EventSource{Component: controllerName},
+		ReportingController: controllerName,
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+	}
+}
+
+// hasBoundPersistentVolumeClaims returns true if a persistent volume claim of the namespace is
+// bound to a volume on the given cluster, as exposed by the syncer of the cluster.
+func (c *Controller) hasBoundPersistentVolumeClaims(ns *corev1.Namespace, clusterName string) (bool, error) {
+	listers, notSynced := c.ddsif.Listers()
+	lister, found := listers[persistentVolumeClaimsGVR]
+	if !found {
+		for _, gvr := range notSynced {
+			if gvr == persistentVolumeClaimsGVR {
+				return false, fmt.Errorf("informer for %s is not synced", gvr)
+			}
+		}
+		// persistent volume claims are not served
+		return false, nil
+	}
+
+	objs, err := lister.ByNamespace(ns.Name).List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, obj := range objs {
+		claim, err := meta.Accessor(obj)
+		if err != nil {
+			return false, err
+		}
+		if logicalcluster.From(claim) != logicalcluster.From(ns) {
+			continue
+		}
+		if isClaimBound(claim, clusterName) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isClaimBound returns true if the volume name of the persistent volume claim on the
+// given cluster is exposed in the downstream fields annotation of the syncer.
+func isClaimBound(claim metav1.Object, clusterName string) bool {
+	value := claim.GetAnnotations()[workloadv1alpha1.DownstreamFieldsAnnotationPrefix+clusterName]
+	if value == "" {
+		return false
+	}
+	var fields struct {
+		VolumeName string `json:"volumeName"`
+	}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		klog.Errorf("Invalid downstream fields of persistent volume claim %s|%s/%s: %v", claim.GetClusterName(), claim.GetNamespace(), claim.GetName(), err)
+		return false
+	}
+	return fields.VolumeName != ""
 }
 
 // ensureScheduledStatus ensures the status of the given namespace reflects the
 // namespace's scheduled state.
-func (c *Controller) ensureScheduledStatus(ctx context.Context, ns *corev1.Namespace) error {
-	updatedNs := setScheduledCondition(ns)
+func (c *Controller) ensureScheduledStatus(ctx context.Context, ns *corev1.Namespace, reschedulingBlockedMsg string) error {
+	updatedNs := setScheduledCondition(ns, reschedulingBlockedMsg)
 
 	if equality.Semantic.DeepEqual(ns.Status, updatedNs.Status) {
 		return nil
@@ -240,11 +439,12 @@ func (c *Controller) reconcileNamespace(ctx context.Context, lclusterName logica
 		ns.Labels = map[string]string{}
 	}
 
-	if err := c.ensureScheduled(ctx, ns); err != nil {
+	reschedulingBlockedMsg, err := c.ensureScheduled(ctx, ns)
+	if err != nil {
 		return err
 	}
 
-	if err := c.ensureScheduledStatus(ctx, ns); err != nil {
+	if err := c.ensureScheduledStatus(ctx, ns, reschedulingBlockedMsg); err != nil {
 		return err
 	}
 
@@ -404,10 +604,11 @@ const (
 func enqueueStrategyForCluster(cl *workloadv1alpha1.WorkloadCluster) (strategy clusterEnqueueStrategy, pendingCordon bool) {
 	ready := conditions.IsTrue(cl, conditionsapi.ReadyCondition)
 	cordoned := cl.Spec.EvictAfter != nil && cl.Spec.EvictAfter.Time.Before(time.Now())
-	if !ready || cordoned {
-		// An unready or cordoned cluster requires revisiting the scheduling
-		// for the namespaces currently scheduled to the cluster to ensure
-		// rescheduling is performed.
+	deleting := cl.DeletionTimestamp != nil
+	if !ready || cordoned || deleting {
+		// An unready, cordoned or deleting cluster requires revisiting the
+		// scheduling for the namespaces currently scheduled to the cluster
+		// to ensure rescheduling is performed.
 		return enqueueScheduled, false
 	}
 
@@ -458,7 +659,8 @@ func statusPatchBytes(old, new *corev1.Namespace) ([]byte, error) {
 type getWorkspaceFunc func(name string) (*tenancyv1alpha1.ClusterWorkspace, error)
 
 // isWorkspaceSchedulable indicates whether the contents of the workspace
-// identified by the logical cluster name are schedulable.
+// identified by the logical cluster name are schedulable. The scheduling of
+// hibernated workspaces is paused until they are woken up.
 func isWorkspaceSchedulable(getWorkspace getWorkspaceFunc, logicalClusterName logicalcluster.LogicalCluster) (bool, error) {
 	org, hasParent := logicalClusterName.Parent()
 	if !hasParent {
@@ -471,5 +673,9 @@ func isWorkspaceSchedulable(getWorkspace getWorkspaceFunc, logicalClusterName lo
 		return false, fmt.Errorf("failed to retrieve workspace with key %s", workspaceKey)
 	}
 
+	if conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceHibernated) {
+		return false, nil
+	}
+
 	return workspaceSchedulableRequirement.Matches(labels.Set(workspace.Labels)), nil
 }
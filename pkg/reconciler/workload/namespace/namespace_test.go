@@ -23,6 +23,7 @@ import (
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 	"github.com/stretchr/testify/require"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -39,6 +40,7 @@ func TestEnqueueStrategyForCluster(t *testing.T) {
 		ready         bool
 		unschedulable bool
 		evictAfter    *time.Time
+		deleting      bool
 		strategy      clusterEnqueueStrategy
 		pendingCordon bool
 	}{
@@ -57,6 +59,12 @@ func TestEnqueueStrategyForCluster(t *testing.T) {
 			evictAfter: &previousTime,
 			strategy:   enqueueScheduled,
 		},
+		// Existing assignments need to be drained
+		"ready, deleting -> enqueue scheduled": {
+			ready:    true,
+			deleting: true,
+			strategy: enqueueScheduled,
+		},
 		// Existing assignments are maintained, no new assignments possible
 		"ready, unschedulable -> enqueue nothing": {
 			ready:         true,
@@ -101,6 +109,10 @@ func TestEnqueueStrategyForCluster(t *testing.T) {
 				evictAfter := metav1.NewTime(*testCase.evictAfter)
 				cluster.Spec.EvictAfter = &evictAfter
 			}
+			if testCase.deleting {
+				now := metav1.Now()
+				cluster.DeletionTimestamp = &now
+			}
 			strategy, pendingCordon := enqueueStrategyForCluster(cluster)
 			require.Equal(t, testCase.strategy, strategy, "unexpected strategy")
 			require.Equal(t, testCase.pendingCordon, pendingCordon, "unexpected pendingCordon")
@@ -112,6 +124,7 @@ func TestIsWorkspaceSchedulable(t *testing.T) {
 	testCases := []struct {
 		testName    string
 		schedulable bool
+		hibernated  bool
 		expected    bool
 	}{{
 		testName:    "workspace schedulable",
@@ -119,6 +132,10 @@ func TestIsWorkspaceSchedulable(t *testing.T) {
 		expected:    true,
 	}, {
 		testName: "workspace unschedulable",
+	}, {
+		testName:    "workspace schedulable but hibernated",
+		schedulable: true,
+		hibernated:  true,
 	}}
 	for _, testCase := range testCases {
 		t.Run(testCase.testName, func(t *testing.T) {
@@ -126,13 +143,17 @@ func TestIsWorkspaceSchedulable(t *testing.T) {
 			if testCase.schedulable {
 				labels[WorkspaceSchedulableLabel] = "true"
 			}
+			workspace := &tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "ws",
+					Labels: labels,
+				},
+			}
+			if testCase.hibernated {
+				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceHibernated)
+			}
 			getWorkspace := func(key string) (*tenancyv1alpha1.ClusterWorkspace, error) {
-				return &tenancyv1alpha1.ClusterWorkspace{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:   "ws",
-						Labels: labels,
-					},
-				}, nil
+				return workspace, nil
 			}
 			actual, err := isWorkspaceSchedulable(getWorkspace, logicalcluster.New("org:ws"))
 			require.NoError(t, err)
@@ -140,3 +161,93 @@ func TestIsWorkspaceSchedulable(t *testing.T) {
 		})
 	}
 }
+
+func TestSchedulingDecisionEvent(t *testing.T) {
+	testCases := map[string]struct {
+		oldCluster, newCluster string
+		reason, message        string
+	}{
+		"scheduled": {
+			newCluster: "east",
+			reason:     SchedulingDecisionReasonScheduled,
+			message:    "Assigned to cluster east",
+		},
+		"rescheduled": {
+			oldCluster: "east",
+			newCluster: "west",
+			reason:     SchedulingDecisionReasonRescheduled,
+			message:    "Moved from cluster east to cluster west",
+		},
+		"unscheduled": {
+			oldCluster: "east",
+			reason:     SchedulingDecisionReasonUnscheduled,
+			message:    "Unassigned from cluster east",
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"}}
+			now := metav1.Now()
+			event := schedulingDecisionEvent(ns, testCase.oldCluster, testCase.newCluster, now)
+			require.Equal(t, "foo", event.Namespace)
+			require.Equal(t, corev1.ObjectReference{APIVersion: "v1", Kind: "Namespace", Name: "foo", UID: "uid"}, event.InvolvedObject)
+			require.Equal(t, testCase.reason, event.Reason)
+			require.Equal(t, testCase.message, event.Message)
+			require.Equal(t, controllerName, event.Source.Component)
+			require.Equal(t, now, event.LastTimestamp)
+		})
+	}
+}
+
+func TestAppendSchedulingDecision(t *testing.T) {
+	decision := func(cluster string, minute int) workloadv1alpha1.SchedulingDecision {
+		return workloadv1alpha1.SchedulingDecision{
+			Time:    metav1.NewTime(time.Date(2022, 5, 1, 10, minute, 0, 0, time.UTC)),
+			Cluster: cluster,
+		}
+	}
+
+	testCases := map[string]struct {
+		existing        []workloadv1alpha1.SchedulingDecision
+		decision        workloadv1alpha1.SchedulingDecision
+		expectedChanged bool
+		expected        []workloadv1alpha1.SchedulingDecision
+	}{
+		"first decision": {
+			decision:        decision("east", 0),
+			expectedChanged: true,
+			expected:        []workloadv1alpha1.SchedulingDecision{decision("east", 0)},
+		},
+		"same decision later -> not recorded": {
+			existing: []workloadv1alpha1.SchedulingDecision{decision("east", 0)},
+			decision: decision("east", 5),
+			expected: []workloadv1alpha1.SchedulingDecision{decision("east", 0)},
+		},
+		"different decision -> appended": {
+			existing:        []workloadv1alpha1.SchedulingDecision{decision("east", 0)},
+			decision:        decision("west", 5),
+			expectedChanged: true,
+			expected:        []workloadv1alpha1.SchedulingDecision{decision("east", 0), decision("west", 5)},
+		},
+		"full history -> oldest dropped": {
+			existing: []workloadv1alpha1.SchedulingDecision{
+				decision("a", 0), decision("b", 1), decision("c", 2), decision("d", 3), decision("e", 4),
+				decision("f", 5), decision("g", 6), decision("h", 7), decision("i", 8), decision("j", 9),
+			},
+			decision:        decision("k", 10),
+			expectedChanged: true,
+			expected: []workloadv1alpha1.SchedulingDecision{
+				decision("b", 1), decision("c", 2), decision("d", 3), decision("e", 4), decision("f", 5),
+				decision("g", 6), decision("h", 7), decision("i", 8), decision("j", 9), decision("k", 10),
+			},
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			placementDecision := &workloadv1alpha1.PlacementDecision{Decisions: testCase.existing}
+			changed := appendSchedulingDecision(placementDecision, testCase.decision)
+			require.Equal(t, testCase.expectedChanged, changed)
+			require.Equal(t, testCase.expected, placementDecision.Decisions)
+		})
+	}
+}
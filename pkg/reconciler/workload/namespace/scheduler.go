@@ -17,70 +17,246 @@ limitations under the License.
 package namespace
 
 import (
+	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/features"
 	conditionsapi "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
 
 type getClusterFunc func(name string) (*workloadv1alpha1.WorkloadCluster, error)
 type listClustersFunc func(selector labels.Selector) ([]*workloadv1alpha1.WorkloadCluster, error)
+type listPlacementsFunc func(selector labels.Selector) ([]*workloadv1alpha1.Placement, error)
+type getPoolFunc func(name string) (*workloadv1alpha1.WorkloadClusterPool, error)
+type hasBoundClaimsFunc func(ns *corev1.Namespace, clusterName string) (bool, error)
+type listNamespacesFunc func(selector labels.Selector) ([]*corev1.Namespace, error)
 
 type namespaceScheduler struct {
-	getCluster   getClusterFunc
-	listClusters listClustersFunc
+	getCluster     getClusterFunc
+	listClusters   listClustersFunc
+	listPlacements listPlacementsFunc
+	getPool        getPoolFunc
+	hasBoundClaims hasBoundClaimsFunc
+	listNamespaces listNamespacesFunc
+
+	// decision records the last decision of AssignCluster, without time. It is
+	// nil if the scheduler didn't have to decide, i.e. if the current assignment
+	// is valid or the namespace isn't scheduled by the built-in scheduler.
+	decision *workloadv1alpha1.SchedulingDecision
 }
 
 // AssignCluster returns the name of the cluster to assign to the provided
-// namespace. The current cluster assignment will be returned if it is valid or if
-// the automatic scheduling is disabled for the namespace. An new assignment will
-// be attempted if the current assignment is empty or invalid.
-func (s *namespaceScheduler) AssignCluster(ns *corev1.Namespace) (string, error) {
+// namespace. The current cluster assignment will be returned if it is valid, if
+// the automatic scheduling is disabled for the namespace, or if an external
+// scheduler claimed the namespace. An new assignment will be attempted if the
+// current assignment is empty or invalid.
+//
+// A namespace holding persistent volume claims bound on its current cluster is
+// sticky to that cluster, as a new assignment would leave the data behind, unless
+// the AllowMigrationAnnotation is set. The returned message explains why the
+// rescheduling was blocked, and is empty otherwise.
+//
+// When the placement of the namespace references a WorkloadClusterPool, only the
+// members of the pool are candidates, and a namespace whose cluster became invalid
+// fails over to another member according to the failover policy of the pool.
+//
+// A cluster sharing its physical cluster with other logical clusters is not a
+// candidate when the fair share of the logical cluster of the namespace is used up.
+//
+// The decision taken, if any, including the clusters rejected as candidates, is
+// recorded in the decision field of the scheduler.
+func (s *namespaceScheduler) AssignCluster(ns *corev1.Namespace) (string, string, error) {
 	assignedCluster := ns.Labels[ClusterLabel]
+	s.decision = nil
 
 	schedulingDisabled := !scheduleRequirement.Matches(labels.Set(ns.Labels))
 	if schedulingDisabled {
 		klog.Infof("Automatic scheduling is disabled for namespace %s|%s", ns.ClusterName, ns.Name)
-		return assignedCluster, nil
+		return assignedCluster, "", nil
+	}
+
+	if scheduler := ns.Annotations[SchedulerAnnotation]; scheduler != "" {
+		klog.V(5).Infof("Scheduling of namespace %s|%s is handed off to scheduler %q", ns.ClusterName, ns.Name, scheduler)
+		return assignedCluster, "", nil
+	}
+
+	locationSelector, placement, placed, err := s.locationSelectorFor(ns)
+	if err != nil {
+		return "", "", err
+	}
+	decision := &workloadv1alpha1.SchedulingDecision{PreviousCluster: assignedCluster}
+	var poolName string
+	if placement != nil {
+		decision.Placement = placement.Name
+		decision.Pool = placement.Spec.Pool
+		poolName = placement.Spec.Pool
+	}
+	pool, err := s.poolFor(logicalcluster.From(ns), poolName)
+	if err != nil {
+		return "", "", err
+	}
+	if !placed && assignedCluster == "" {
+		klog.V(5).Infof("No placement selects namespace %s|%s", ns.ClusterName, ns.Name)
+		decision.Message = "No placement selects the namespace"
+		s.decision = decision
+		return "", "", nil
 	}
 
 	if assignedCluster != "" {
-		isValid, invalidMsg, err := s.isValidCluster(logicalcluster.From(ns), assignedCluster)
-		if err != nil {
-			return "", err
+		isValid, invalidMsg := false, "is not selected by any placement of the namespace"
+		if placed {
+			isValid, invalidMsg, err = s.isValidCluster(logicalcluster.From(ns), assignedCluster, locationSelector, pool)
+			if err != nil {
+				return "", "", err
+			}
 		}
 		if isValid {
-			return assignedCluster, nil
+			return assignedCluster, "", nil
+		}
+		if ns.Annotations[AllowMigrationAnnotation] != "true" {
+			bound, err := s.hasBoundClaims(ns, assignedCluster)
+			if err != nil {
+				return "", "", err
+			}
+			if bound {
+				klog.Infof("Cluster %s|%s %s, but namespace %s holds bound persistent volume claims", ns.ClusterName, assignedCluster, invalidMsg, ns.Name)
+				reschedulingBlockedMsg := fmt.Sprintf("Cluster %s %s, but persistent volume claims of the namespace are bound on it. Set the %s annotation to \"true\" to allow the migration to another cluster.",
+					assignedCluster, invalidMsg, AllowMigrationAnnotation)
+				decision.Cluster = assignedCluster
+				decision.Message = reschedulingBlockedMsg
+				s.decision = decision
+				return assignedCluster, reschedulingBlockedMsg, nil
+			}
 		}
 		// A new cluster needs to be assigned
 		klog.V(5).Infof("Cluster %s|%s %s", ns.ClusterName, assignedCluster, invalidMsg)
+		decision.Message = fmt.Sprintf("Cluster %s %s", assignedCluster, invalidMsg)
 	}
 
+	if !placed {
+		klog.V(5).Infof("No placement selects namespace %s|%s", ns.ClusterName, ns.Name)
+		s.decision = decision
+		return "", "", nil
+	}
+
+	// All clusters are listed, such that the decision explains why the clusters
+	// not matching the location selector are not candidates.
 	allClusters, err := s.listClusters(labels.Everything())
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	fullClusters, err := s.fullSharedClusters(allClusters, logicalcluster.From(ns))
+	if err != nil {
+		return "", "", err
+	}
+	if pool != nil {
+		decision.Cluster, decision.RejectedClusters = pickPoolMember(allClusters, logicalcluster.From(ns), locationSelector, pool, fullClusters)
+	} else {
+		decision.Cluster, decision.RejectedClusters = pickCluster(allClusters, logicalcluster.From(ns), locationSelector, fullClusters)
+	}
+	s.decision = decision
+	return decision.Cluster, "", nil
+}
+
+// locationSelectorFor returns the selector for the clusters the given namespace
+// can be assigned to, and the placement selecting it, if any. Without any Placement in the logical cluster of the namespace,
+// all clusters are selected. Otherwise, the first Placement by name selecting the
+// namespace determines the clusters. If no Placement selects the namespace, false
+// is returned and the namespace must not be scheduled. Placements are ignored when
+// the AdvancedPlacement feature is disabled.
+func (s *namespaceScheduler) locationSelectorFor(ns *corev1.Namespace) (labels.Selector, *workloadv1alpha1.Placement, bool, error) {
+	if !utilfeature.DefaultFeatureGate.Enabled(features.AdvancedPlacement) {
+		return labels.Everything(), nil, true, nil
+	}
+
+	allPlacements, err := s.listPlacements(labels.Everything())
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var placements []*workloadv1alpha1.Placement
+	for _, placement := range allPlacements {
+		if logicalcluster.From(placement) == logicalcluster.From(ns) {
+			placements = append(placements, placement)
+		}
+	}
+	if len(placements) == 0 {
+		return labels.Everything(), nil, true, nil
+	}
+	sort.Slice(placements, func(i, j int) bool {
+		return placements[i].Name < placements[j].Name
+	})
+
+	for _, placement := range placements {
+		if placement.Spec.NamespaceSelector == nil {
+			continue
+		}
+		namespaceSelector, err := metav1.LabelSelectorAsSelector(placement.Spec.NamespaceSelector)
+		if err != nil {
+			// invalid selectors are rejected on admission, this is only defensive
+			klog.Errorf("Invalid namespace selector of placement %s|%s: %v", placement.ClusterName, placement.Name, err)
+			continue
+		}
+		if !namespaceSelector.Matches(labels.Set(ns.Labels)) {
+			continue
+		}
+		if placement.Spec.LocationSelector == nil {
+			return labels.Everything(), placement, true, nil
+		}
+		locationSelector, err := metav1.LabelSelectorAsSelector(placement.Spec.LocationSelector)
+		if err != nil {
+			klog.Errorf("Invalid location selector of placement %s|%s: %v", placement.ClusterName, placement.Name, err)
+			continue
+		}
+		return locationSelector, placement, true, nil
+	}
+	return nil, nil, false, nil
+}
+
+// poolFor returns the pool with the given name in the given logical cluster, or
+// nil if no pool is named. A pool that doesn't exist is returned without members,
+// such that no cluster is valid for the namespaces of its placements.
+func (s *namespaceScheduler) poolFor(lclusterName logicalcluster.LogicalCluster, poolName string) (*workloadv1alpha1.WorkloadClusterPool, error) {
+	if poolName == "" {
+		return nil, nil
+	}
+	pool, err := s.getPool(clusters.ToClusterAwareKey(lclusterName, poolName))
+	if apierrors.IsNotFound(err) {
+		klog.V(2).Infof("Pool %s|%s does not exist", lclusterName, poolName)
+		return &workloadv1alpha1.WorkloadClusterPool{ObjectMeta: metav1.ObjectMeta{Name: poolName, ClusterName: lclusterName.String()}}, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	return pickCluster(allClusters, logicalcluster.From(ns)), nil
+	return pool, nil
 }
 
 // isValidCluster checks whether the given cluster name exists and is valid for
 // the purposes of any namespace already scheduled to it (i.e., if it reports
-// as Ready, and any evictAfter value, if specified, has not yet passed).
+// as Ready, matches the location selector of the namespace's placement, is a
+// member of the pool of the placement if any, and any evictAfter value, if
+// specified, has not yet passed).
 //
 // It doesn't take into account Unschedulable, and should only be used when
 // determining if a cluster that a namespace has already been assigned to
 // should keep having that namespace.
-func (s *namespaceScheduler) isValidCluster(lclusterName logicalcluster.LogicalCluster, clusterName string) (
+func (s *namespaceScheduler) isValidCluster(lclusterName logicalcluster.LogicalCluster, clusterName string, locationSelector labels.Selector,
+	pool *workloadv1alpha1.WorkloadClusterPool) (
 	isValid bool, invalidMsg string, err error) {
 
 	cluster, err := s.getCluster(clusters.ToClusterAwareKey(lclusterName, clusterName))
@@ -90,6 +266,18 @@ func (s *namespaceScheduler) isValidCluster(lclusterName logicalcluster.LogicalC
 	if err != nil {
 		return false, "", err
 	}
+	if cluster.DeletionTimestamp != nil {
+		return false, "is being deleted", nil
+	}
+	if !locationSelector.Matches(labels.Set(cluster.Labels)) {
+		return false, "is not selected by the placement of the namespace", nil
+	}
+	if pool != nil && !isPoolMember(pool, clusterName) {
+		return false, fmt.Sprintf("is not a member of pool %s", pool.Name), nil
+	}
+	if conditions.IsFalse(cluster, workloadv1alpha1.HeartbeatHealthy) {
+		return false, "lost the heartbeat of its syncer", nil
+	}
 	// TODO(marun) Stop duplicating these checks here and in pickCluster
 	if ready := conditions.IsTrue(cluster, conditionsapi.ReadyCondition); !ready {
 		return false, "is not reporting ready", nil
@@ -100,12 +288,171 @@ func (s *namespaceScheduler) isValidCluster(lclusterName logicalcluster.LogicalC
 	return true, "", nil
 }
 
+// fullSharedClusters returns the clusters of the given logical cluster whose physical
+// cluster is shared with other logical clusters and has no fair share left for the
+// logical cluster, with the reason.
+//
+// The capacity of a shared physical cluster is split by the weights of the clusters
+// sharing it, and every cluster can take namespaces up to its share, such that
+// logical clusters coming late still get their share.
+func (s *namespaceScheduler) fullSharedClusters(allClusters []*workloadv1alpha1.WorkloadCluster, lclusterName logicalcluster.LogicalCluster) (map[string]string, error) {
+	shared := map[string][]*workloadv1alpha1.WorkloadCluster{}
+	for _, cluster := range allClusters {
+		if cluster.Spec.SharedCluster != nil {
+			shared[cluster.Spec.SharedCluster.Name] = append(shared[cluster.Spec.SharedCluster.Name], cluster)
+		}
+	}
+
+	var full map[string]string
+	var usage map[string]int
+	for name, members := range shared {
+		var capacity, totalWeight int32
+		owned := false
+		for _, member := range members {
+			if c := member.Spec.SharedCluster.Capacity; c > 0 && (capacity == 0 || c < capacity) {
+				capacity = c
+			}
+			totalWeight += sharedClusterWeight(member)
+			owned = owned || logicalcluster.From(member) == lclusterName
+		}
+		if !owned || capacity == 0 {
+			continue
+		}
+
+		if usage == nil {
+			var err error
+			if usage, err = s.namespacesPerCluster(); err != nil {
+				return nil, err
+			}
+		}
+		used := func(member *workloadv1alpha1.WorkloadCluster) int {
+			return usage[clusters.ToClusterAwareKey(logicalcluster.From(member), member.Name)]
+		}
+		total := 0
+		for _, member := range members {
+			total += used(member)
+		}
+
+		for _, cluster := range members {
+			if logicalcluster.From(cluster) != lclusterName {
+				continue
+			}
+			// the total is only above the capacity after the capacity or the weights changed
+			share := float64(capacity) * float64(sharedClusterWeight(cluster)) / float64(totalWeight)
+			if float64(used(cluster)+1) <= share && total < int(capacity) {
+				continue
+			}
+			if full == nil {
+				full = map[string]string{}
+			}
+			full[cluster.Name] = fmt.Sprintf("has used up its fair share of shared cluster %s", name)
+		}
+	}
+	return full, nil
+}
+
+// namespacesPerCluster returns the number of namespaces assigned to every cluster, by
+// cluster aware key.
+func (s *namespaceScheduler) namespacesPerCluster() (map[string]int, error) {
+	assigned, err := labels.NewRequirement(ClusterLabel, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.listNamespaces(labels.NewSelector().Add(*assigned))
+	if err != nil {
+		return nil, err
+	}
+	usage := map[string]int{}
+	for _, ns := range namespaces {
+		usage[clusters.ToClusterAwareKey(logicalcluster.From(ns), ns.Labels[ClusterLabel])]++
+	}
+	return usage, nil
+}
+
+func sharedClusterWeight(cluster *workloadv1alpha1.WorkloadCluster) int32 {
+	if cluster.Spec.SharedCluster.Weight < 1 {
+		return 1
+	}
+	return cluster.Spec.SharedCluster.Weight
+}
+
 // pickCluster attempts to choose a cluster in the given logical
 // cluster to assign to a namespace. If a suitable cluster is
 // identified, its name will be returned. Otherwise, an empty string
-// will be returned.
-func pickCluster(allClusters []*workloadv1alpha1.WorkloadCluster, lclusterName logicalcluster.LogicalCluster) string {
+// will be returned. The clusters that were not candidates are returned
+// with the reason.
+func pickCluster(allClusters []*workloadv1alpha1.WorkloadCluster, lclusterName logicalcluster.LogicalCluster, locationSelector labels.Selector,
+	fullClusters map[string]string) (string, []workloadv1alpha1.RejectedCluster) {
+	clusters, rejected := schedulableClusters(allClusters, lclusterName, locationSelector, fullClusters)
+
+	newClusterName := ""
+	if len(clusters) > 0 {
+		// Select a cluster at random.
+		cluster := clusters[rand.Intn(len(clusters))]
+		newClusterName = cluster.Name
+	}
+
+	return newClusterName, rejected
+}
+
+// pickPoolMember chooses a member of the given pool to assign a namespace to,
+// according to the failover policy of the pool. An empty string is returned if
+// no member is suitable. The clusters that were not candidates are returned
+// with the reason.
+func pickPoolMember(allClusters []*workloadv1alpha1.WorkloadCluster, lclusterName logicalcluster.LogicalCluster, locationSelector labels.Selector,
+	pool *workloadv1alpha1.WorkloadClusterPool, fullClusters map[string]string) (string, []workloadv1alpha1.RejectedCluster) {
+	clusters, rejected := schedulableClusters(allClusters, lclusterName, locationSelector, fullClusters)
+
+	schedulable := map[string]bool{}
+	for _, cluster := range clusters {
+		if !isPoolMember(pool, cluster.Name) {
+			rejected = append(rejected, workloadv1alpha1.RejectedCluster{Name: cluster.Name, Reason: fmt.Sprintf("is not a member of pool %s", pool.Name)})
+			continue
+		}
+		schedulable[cluster.Name] = true
+	}
+	sortRejectedClusters(rejected)
+
+	var members []string
+	for _, member := range pool.Spec.Members {
+		if schedulable[member] {
+			members = append(members, member)
+		}
+	}
+	if len(members) == 0 {
+		klog.V(2).InfoS("pickPoolMember: no schedulable member", "pool", pool.Name)
+		return "", rejected
+	}
+
+	if pool.Spec.FailoverPolicy == workloadv1alpha1.FailoverPolicyRandom {
+		return members[rand.Intn(len(members))], rejected
+	}
+	// The members are ordered by decreasing priority.
+	return members[0], rejected
+}
+
+func isPoolMember(pool *workloadv1alpha1.WorkloadClusterPool, clusterName string) bool {
+	for _, member := range pool.Spec.Members {
+		if member == clusterName {
+			return true
+		}
+	}
+	return false
+}
+
+// schedulableClusters returns the clusters of the given logical cluster that
+// new namespaces can be assigned to, and the other clusters of the logical
+// cluster with the reason they are rejected, sorted by name. The full clusters
+// are rejected with the given reason.
+func schedulableClusters(allClusters []*workloadv1alpha1.WorkloadCluster, lclusterName logicalcluster.LogicalCluster, locationSelector labels.Selector,
+	fullClusters map[string]string) (
+	[]*workloadv1alpha1.WorkloadCluster, []workloadv1alpha1.RejectedCluster) {
 	var clusters []*workloadv1alpha1.WorkloadCluster
+	var rejected []workloadv1alpha1.RejectedCluster
+	reject := func(cluster *workloadv1alpha1.WorkloadCluster, reason string) {
+		klog.V(2).InfoS("pickCluster: excluding cluster", "metadata.name", cluster.Name, "reason", reason)
+		rejected = append(rejected, workloadv1alpha1.RejectedCluster{Name: cluster.Name, Reason: reason})
+	}
 	for i := range allClusters {
 		// Only include Clusters that are in the logical cluster
 		if logicalcluster.From(allClusters[i]) != lclusterName {
@@ -113,30 +460,44 @@ func pickCluster(allClusters []*workloadv1alpha1.WorkloadCluster, lclusterName l
 				"ns.clusterName", lclusterName, "check", allClusters[i].ClusterName)
 			continue
 		}
+		if !locationSelector.Matches(labels.Set(allClusters[i].Labels)) {
+			reject(allClusters[i], "is not selected by the placement of the namespace")
+			continue
+		}
+		if allClusters[i].DeletionTimestamp != nil {
+			reject(allClusters[i], "is being deleted")
+			continue
+		}
 		if allClusters[i].Spec.Unschedulable {
-			klog.V(2).InfoS("pickCluster: excluding unschedulable cluster", "metadata.name", allClusters[i].Name)
+			reject(allClusters[i], "is unschedulable")
 			continue
 		}
 		if evictAfter := allClusters[i].Spec.EvictAfter; evictAfter != nil && evictAfter.Time.Before(time.Now()) {
-			klog.V(2).InfoS("pickCluster: excluding cluster with evictAfter value that has passed",
-				"metadata.name", allClusters[i].Name)
+			reject(allClusters[i], "is cordoned")
+			continue
+		}
+		if conditions.IsFalse(allClusters[i], workloadv1alpha1.HeartbeatHealthy) {
+			reject(allClusters[i], "lost the heartbeat of its syncer")
 			continue
 		}
 		if !conditions.IsTrue(allClusters[i], conditionsapi.ReadyCondition) {
-			klog.V(2).InfoS("pickCluster: excluding not-ready cluster", "metadata.name", allClusters[i].Name)
+			reject(allClusters[i], "is not reporting ready")
+			continue
+		}
+		if reason, full := fullClusters[allClusters[i].Name]; full {
+			reject(allClusters[i], reason)
 			continue
 		}
 
 		klog.V(2).InfoS("pickCluster: found a ready candidate", "metadata.name", allClusters[i].Name)
 		clusters = append(clusters, allClusters[i])
 	}
+	sortRejectedClusters(rejected)
+	return clusters, rejected
+}
 
-	newClusterName := ""
-	if len(clusters) > 0 {
-		// Select a cluster at random.
-		cluster := clusters[rand.Intn(len(clusters))]
-		newClusterName = cluster.Name
-	}
-
-	return newClusterName
+func sortRejectedClusters(rejected []workloadv1alpha1.RejectedCluster) {
+	sort.Slice(rejected, func(i, j int) bool {
+		return rejected[i].Name < rejected[j].Name
+	})
 }
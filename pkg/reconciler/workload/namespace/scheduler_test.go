@@ -17,6 +17,7 @@ limitations under the License.
 package namespace
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -27,9 +28,12 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	clustertools "k8s.io/client-go/tools/clusters"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/features"
 	conditionsapi "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
@@ -73,11 +77,28 @@ func (f *clusterFixture) withReady() *clusterFixture {
 	return f
 }
 
+func (f *clusterFixture) withHeartbeatMissed() *clusterFixture {
+	conditions.MarkFalse(f.cluster, workloadv1alpha1.HeartbeatHealthy, workloadv1alpha1.ErrorHeartbeatMissedReason, conditionsapi.ConditionSeverityError, "No heartbeat")
+	conditions.MarkFalse(f.cluster, conditionsapi.ReadyCondition, workloadv1alpha1.ErrorHeartbeatMissedReason, conditionsapi.ConditionSeverityError, "No heartbeat")
+	return f
+}
+
+func (f *clusterFixture) withLabels(lbls map[string]string) *clusterFixture {
+	f.cluster.Labels = lbls
+	return f
+}
+
 func (f *clusterFixture) withUnscheduable() *clusterFixture {
 	f.cluster.Spec.Unschedulable = true
 	return f
 }
 
+func (f *clusterFixture) withDeletionTimestamp() *clusterFixture {
+	now := metav1.Now()
+	f.cluster.DeletionTimestamp = &now
+	return f
+}
+
 func (f *clusterFixture) withFutureEvictionTime() *clusterFixture {
 	futureTime := metav1.NewTime(time.Now().Add(1 * time.Hour))
 	f.cluster.Spec.EvictAfter = &futureTime
@@ -90,7 +111,25 @@ func (f *clusterFixture) withPassedEvictionTime() *clusterFixture {
 	return f
 }
 
-func newTestScheduler(clusters []*workloadv1alpha1.WorkloadCluster) namespaceScheduler {
+func (f *clusterFixture) withSharedCluster(name string, capacity, weight int32) *clusterFixture {
+	f.cluster.Spec.SharedCluster = &workloadv1alpha1.SharedCluster{Name: name, Capacity: capacity, Weight: weight}
+	return f
+}
+
+func newPlacement(lclusterName logicalcluster.LogicalCluster, name string, namespaceSelector, locationSelector *metav1.LabelSelector) *workloadv1alpha1.Placement {
+	return &workloadv1alpha1.Placement{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			ClusterName: lclusterName.String(),
+		},
+		Spec: workloadv1alpha1.PlacementSpec{
+			NamespaceSelector: namespaceSelector,
+			LocationSelector:  locationSelector,
+		},
+	}
+}
+
+func newTestScheduler(clusters []*workloadv1alpha1.WorkloadCluster, placements []*workloadv1alpha1.Placement) namespaceScheduler {
 	return namespaceScheduler{
 		getCluster: func(name string) (*workloadv1alpha1.WorkloadCluster, error) {
 			for _, cluster := range clusters {
@@ -101,7 +140,25 @@ func newTestScheduler(clusters []*workloadv1alpha1.WorkloadCluster) namespaceSch
 			return nil, apierrors.NewNotFound(workloadv1alpha1.Resource("workloadcluster"), name)
 		},
 		listClusters: func(selector labels.Selector) ([]*workloadv1alpha1.WorkloadCluster, error) {
-			return clusters, nil
+			var selected []*workloadv1alpha1.WorkloadCluster
+			for _, cluster := range clusters {
+				if selector.Matches(labels.Set(cluster.Labels)) {
+					selected = append(selected, cluster)
+				}
+			}
+			return selected, nil
+		},
+		listPlacements: func(selector labels.Selector) ([]*workloadv1alpha1.Placement, error) {
+			return placements, nil
+		},
+		getPool: func(name string) (*workloadv1alpha1.WorkloadClusterPool, error) {
+			return nil, apierrors.NewNotFound(workloadv1alpha1.Resource("workloadclusterpool"), name)
+		},
+		hasBoundClaims: func(ns *corev1.Namespace, clusterName string) (bool, error) {
+			return false, nil
+		},
+		listNamespaces: func(selector labels.Selector) ([]*corev1.Namespace, error) {
+			return nil, nil
 		},
 	}
 }
@@ -111,6 +168,7 @@ func TestAssignCluster(t *testing.T) {
 
 	testCases := map[string]struct {
 		labels          map[string]string
+		annotations     map[string]string
 		expectedCluster string
 	}{
 		"scheduling disabled set to empty -> no change even for unknown cluster name": {
@@ -133,6 +191,21 @@ func TestAssignCluster(t *testing.T) {
 			},
 			expectedCluster: testClusterName,
 		},
+		"external scheduler -> no change even for unknown cluster name": {
+			labels: map[string]string{
+				ClusterLabel: unknownClusterName,
+			},
+			annotations: map[string]string{
+				SchedulerAnnotation: "my-scheduler",
+			},
+			expectedCluster: unknownClusterName,
+		},
+		"external scheduler -> no assignment": {
+			annotations: map[string]string{
+				SchedulerAnnotation: "my-scheduler",
+			},
+			expectedCluster: "",
+		},
 		"invalid assignment -> new assignment": {
 			labels: map[string]string{
 				ClusterLabel: unknownClusterName,
@@ -148,7 +221,103 @@ func TestAssignCluster(t *testing.T) {
 			clusters := []*workloadv1alpha1.WorkloadCluster{
 				defaultClusterFixture().withReady().cluster,
 			}
-			scheduler := newTestScheduler(clusters)
+			scheduler := newTestScheduler(clusters, nil)
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "default",
+					ClusterName: testLclusterName.String(),
+					Labels:      testCase.labels,
+					Annotations: testCase.annotations,
+				},
+			}
+			clusterName, reschedulingBlockedMsg, err := scheduler.AssignCluster(ns)
+			require.NoError(t, err)
+			require.Empty(t, reschedulingBlockedMsg)
+			require.Equal(t, testCase.expectedCluster, clusterName)
+		})
+	}
+}
+
+func TestAssignClusterWithPlacements(t *testing.T) {
+	east := &metav1.LabelSelector{MatchLabels: map[string]string{"region": "east"}}
+	west := &metav1.LabelSelector{MatchLabels: map[string]string{"region": "west"}}
+
+	testCases := map[string]struct {
+		labels                    map[string]string
+		placements                []*workloadv1alpha1.Placement
+		advancedPlacementDisabled bool
+		anyAssignment             bool
+		expectedCluster           string
+	}{
+		"placement in another logical cluster -> all clusters": {
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(otherTestLclusterName, "east", &metav1.LabelSelector{}, east),
+			},
+			anyAssignment: true,
+		},
+		"empty namespace selector -> location of the placement": {
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "west", &metav1.LabelSelector{}, west),
+			},
+			expectedCluster: otherTestClusterName,
+		},
+		"no location selector -> all clusters": {
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "all", &metav1.LabelSelector{}, nil),
+			},
+			anyAssignment: true,
+		},
+		"namespace selected by label -> location of the placement": {
+			labels: map[string]string{"team": "a"},
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "team-a", &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}, east),
+				newPlacement(testLclusterName, "team-b", &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}}, west),
+			},
+			expectedCluster: testClusterName,
+		},
+		"namespace not selected -> unassigned": {
+			labels: map[string]string{
+				"team":       "c",
+				ClusterLabel: testClusterName,
+			},
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "team-a", &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}, east),
+			},
+			expectedCluster: "",
+		},
+		"several placements select the namespace -> first by name": {
+			labels: map[string]string{"team": "a"},
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "b", &metav1.LabelSelector{}, east),
+				newPlacement(testLclusterName, "a", &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}, west),
+			},
+			expectedCluster: otherTestClusterName,
+		},
+		"advanced placement disabled -> all clusters": {
+			labels: map[string]string{"team": "c"},
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "team-a", &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}, east),
+			},
+			advancedPlacementDisabled: true,
+			anyAssignment:             true,
+		},
+		"assigned cluster not matching the location -> new assignment": {
+			labels: map[string]string{ClusterLabel: testClusterName},
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "west", &metav1.LabelSelector{}, west),
+			},
+			expectedCluster: otherTestClusterName,
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			clusters := []*workloadv1alpha1.WorkloadCluster{
+				defaultClusterFixture().withReady().withLabels(map[string]string{"region": "east"}).cluster,
+				otherClusterFixture().withReady().withLabels(map[string]string{"region": "west"}).cluster,
+			}
+			defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.AdvancedPlacement, !testCase.advancedPlacementDisabled)()
+
+			scheduler := newTestScheduler(clusters, testCase.placements)
 			ns := &corev1.Namespace{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        "default",
@@ -156,9 +325,91 @@ func TestAssignCluster(t *testing.T) {
 					Labels:      testCase.labels,
 				},
 			}
-			clusterName, err := scheduler.AssignCluster(ns)
+			clusterName, _, err := scheduler.AssignCluster(ns)
+			require.NoError(t, err)
+			if testCase.anyAssignment {
+				require.Contains(t, []string{testClusterName, otherTestClusterName}, clusterName)
+			} else {
+				require.Equal(t, testCase.expectedCluster, clusterName)
+			}
+		})
+	}
+}
+
+func TestAssignClusterWithBoundClaims(t *testing.T) {
+	testCases := map[string]struct {
+		cluster         *clusterFixture
+		annotations     map[string]string
+		expectedCluster string
+		blocked         bool
+	}{
+		"valid assignment -> no change": {
+			cluster:         defaultClusterFixture().withReady(),
+			expectedCluster: testClusterName,
+		},
+		"unready cluster -> rescheduling blocked": {
+			cluster:         defaultClusterFixture(),
+			expectedCluster: testClusterName,
+			blocked:         true,
+		},
+		"cordoned cluster -> rescheduling blocked": {
+			cluster:         defaultClusterFixture().withReady().withPassedEvictionTime(),
+			expectedCluster: testClusterName,
+			blocked:         true,
+		},
+		"unready cluster with migration allowed -> new assignment": {
+			cluster:         defaultClusterFixture(),
+			annotations:     map[string]string{AllowMigrationAnnotation: "true"},
+			expectedCluster: otherTestClusterName,
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			clusters := []*workloadv1alpha1.WorkloadCluster{
+				testCase.cluster.cluster,
+				otherClusterFixture().withReady().cluster,
+			}
+			scheduler := newTestScheduler(clusters, nil)
+			scheduler.hasBoundClaims = func(ns *corev1.Namespace, clusterName string) (bool, error) {
+				return clusterName == testClusterName, nil
+			}
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "default",
+					ClusterName: testLclusterName.String(),
+					Labels:      map[string]string{ClusterLabel: testClusterName},
+					Annotations: testCase.annotations,
+				},
+			}
+			clusterName, reschedulingBlockedMsg, err := scheduler.AssignCluster(ns)
 			require.NoError(t, err)
 			require.Equal(t, testCase.expectedCluster, clusterName)
+			require.Equal(t, testCase.blocked, reschedulingBlockedMsg != "", "unexpected rescheduling blocked message %q", reschedulingBlockedMsg)
+		})
+	}
+}
+
+func TestIsClaimBound(t *testing.T) {
+	testCases := map[string]struct {
+		annotations map[string]string
+		bound       bool
+	}{
+		"no annotation": {},
+		"bound on another cluster": {
+			annotations: map[string]string{workloadv1alpha1.DownstreamFieldsAnnotationPrefix + otherTestClusterName: `{"volumeName":"pv"}`},
+		},
+		"bound": {
+			annotations: map[string]string{workloadv1alpha1.DownstreamFieldsAnnotationPrefix + testClusterName: `{"volumeName":"pv"}`},
+			bound:       true,
+		},
+		"invalid annotation": {
+			annotations: map[string]string{workloadv1alpha1.DownstreamFieldsAnnotationPrefix + testClusterName: `{`},
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			claim := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Annotations: testCase.annotations}}
+			require.Equal(t, testCase.bound, isClaimBound(claim, testClusterName))
 		})
 	}
 }
@@ -179,6 +430,9 @@ func TestIsValidCluster(t *testing.T) {
 		"ready and passed eviction time -> false": {
 			cluster: defaultClusterFixture().withReady().withPassedEvictionTime(),
 		},
+		"ready and being deleted -> false": {
+			cluster: defaultClusterFixture().withReady().withDeletionTimestamp(),
+		},
 		"ready and future eviction time -> true": {
 			cluster: defaultClusterFixture().withReady().withFutureEvictionTime(),
 			isValid: true,
@@ -190,8 +444,8 @@ func TestIsValidCluster(t *testing.T) {
 			if testCase.cluster != nil {
 				clusters = append(clusters, testCase.cluster.cluster)
 			}
-			scheduler := newTestScheduler(clusters)
-			isValid, _, err := scheduler.isValidCluster(testLclusterName, testClusterName)
+			scheduler := newTestScheduler(clusters, nil)
+			isValid, _, err := scheduler.isValidCluster(testLclusterName, testClusterName, labels.Everything(), nil)
 			require.NoError(t, err)
 			require.Equal(t, testCase.isValid, isValid)
 		})
@@ -214,6 +468,11 @@ func TestPickCluster(t *testing.T) {
 				defaultClusterFixture().withUnscheduable(),
 			},
 		},
+		"ignore cluster being deleted": {
+			clusters: []*clusterFixture{
+				defaultClusterFixture().withReady().withDeletionTimestamp(),
+			},
+		},
 		"ignore cluster with eviction time in the past": {
 			clusters: []*clusterFixture{
 				defaultClusterFixture().withPassedEvictionTime(),
@@ -250,7 +509,7 @@ func TestPickCluster(t *testing.T) {
 			for _, fixture := range testCase.clusters {
 				clusters = append(clusters, fixture.cluster)
 			}
-			clusterName := pickCluster(clusters, testLclusterName)
+			clusterName, _ := pickCluster(clusters, testLclusterName, labels.Everything(), nil)
 			if testCase.anyAssignment {
 				found := false
 				for _, cluster := range clusters {
@@ -266,3 +525,309 @@ func TestPickCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestAssignClusterWithSharedCluster(t *testing.T) {
+	thirdLclusterName := logicalcluster.New("test:third-lcluster")
+
+	testCases := map[string]struct {
+		clusters         []*clusterFixture
+		usage            map[logicalcluster.LogicalCluster]int
+		expectedCluster  string
+		expectedRejected []workloadv1alpha1.RejectedCluster
+	}{
+		"not shared -> no limit": {
+			clusters: []*clusterFixture{
+				defaultClusterFixture().withReady(),
+			},
+			usage:           map[logicalcluster.LogicalCluster]int{testLclusterName: 100},
+			expectedCluster: testClusterName,
+		},
+		"without capacity -> no limit": {
+			clusters: []*clusterFixture{
+				defaultClusterFixture().withReady().withSharedCluster("shared", 0, 1),
+				newClusterFixture(otherTestLclusterName, testClusterName).withReady().withSharedCluster("shared", 0, 1),
+			},
+			usage:           map[logicalcluster.LogicalCluster]int{testLclusterName: 100},
+			expectedCluster: testClusterName,
+		},
+		"below the share -> assigned": {
+			clusters: []*clusterFixture{
+				defaultClusterFixture().withReady().withSharedCluster("shared", 10, 1),
+				newClusterFixture(otherTestLclusterName, testClusterName).withReady().withSharedCluster("shared", 10, 1),
+			},
+			usage:           map[logicalcluster.LogicalCluster]int{testLclusterName: 4, otherTestLclusterName: 5},
+			expectedCluster: testClusterName,
+		},
+		"share used up -> rejected although the other share is unused": {
+			clusters: []*clusterFixture{
+				defaultClusterFixture().withReady().withSharedCluster("shared", 10, 1),
+				newClusterFixture(otherTestLclusterName, testClusterName).withReady().withSharedCluster("shared", 10, 1),
+			},
+			usage: map[logicalcluster.LogicalCluster]int{testLclusterName: 5, otherTestLclusterName: 0},
+			expectedRejected: []workloadv1alpha1.RejectedCluster{
+				{Name: testClusterName, Reason: "has used up its fair share of shared cluster shared"},
+			},
+		},
+		"weights -> larger share": {
+			clusters: []*clusterFixture{
+				defaultClusterFixture().withReady().withSharedCluster("shared", 12, 3),
+				newClusterFixture(otherTestLclusterName, testClusterName).withReady().withSharedCluster("shared", 12, 1),
+			},
+			usage:           map[logicalcluster.LogicalCluster]int{testLclusterName: 8},
+			expectedCluster: testClusterName,
+		},
+		"smallest capacity applies": {
+			clusters: []*clusterFixture{
+				defaultClusterFixture().withReady().withSharedCluster("shared", 100, 1),
+				newClusterFixture(otherTestLclusterName, testClusterName).withReady().withSharedCluster("shared", 4, 1),
+			},
+			usage: map[logicalcluster.LogicalCluster]int{testLclusterName: 2},
+			expectedRejected: []workloadv1alpha1.RejectedCluster{
+				{Name: testClusterName, Reason: "has used up its fair share of shared cluster shared"},
+			},
+		},
+		"capacity lowered below the usage -> rejected below the share": {
+			clusters: []*clusterFixture{
+				defaultClusterFixture().withReady().withSharedCluster("shared", 4, 1),
+				newClusterFixture(otherTestLclusterName, testClusterName).withReady().withSharedCluster("shared", 4, 1),
+			},
+			usage: map[logicalcluster.LogicalCluster]int{otherTestLclusterName: 4},
+			expectedRejected: []workloadv1alpha1.RejectedCluster{
+				{Name: testClusterName, Reason: "has used up its fair share of shared cluster shared"},
+			},
+		},
+		"full shared cluster -> other cluster": {
+			clusters: []*clusterFixture{
+				defaultClusterFixture().withReady().withSharedCluster("shared", 9, 1),
+				otherClusterFixture().withReady(),
+				newClusterFixture(otherTestLclusterName, testClusterName).withReady().withSharedCluster("shared", 9, 1),
+				newClusterFixture(thirdLclusterName, testClusterName).withReady().withSharedCluster("shared", 9, 1),
+			},
+			usage:           map[logicalcluster.LogicalCluster]int{testLclusterName: 3, otherTestLclusterName: 3, thirdLclusterName: 3},
+			expectedCluster: otherTestClusterName,
+			expectedRejected: []workloadv1alpha1.RejectedCluster{
+				{Name: testClusterName, Reason: "has used up its fair share of shared cluster shared"},
+			},
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			clusters := []*workloadv1alpha1.WorkloadCluster{}
+			for _, fixture := range testCase.clusters {
+				clusters = append(clusters, fixture.cluster)
+			}
+			var namespaces []*corev1.Namespace
+			for lclusterName, count := range testCase.usage {
+				for i := 0; i < count; i++ {
+					namespaces = append(namespaces, &corev1.Namespace{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        fmt.Sprintf("ns-%d", i),
+							ClusterName: lclusterName.String(),
+							Labels:      map[string]string{ClusterLabel: testClusterName},
+						},
+					})
+				}
+			}
+			scheduler := newTestScheduler(clusters, nil)
+			scheduler.listNamespaces = func(selector labels.Selector) ([]*corev1.Namespace, error) {
+				return namespaces, nil
+			}
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "new",
+					ClusterName: testLclusterName.String(),
+				},
+			}
+			clusterName, _, err := scheduler.AssignCluster(ns)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedCluster, clusterName)
+			require.Equal(t, testCase.expectedRejected, scheduler.decision.RejectedClusters)
+		})
+	}
+}
+
+func TestAssignClusterWithPool(t *testing.T) {
+	thirdTestClusterName := "third-test-cluster"
+
+	testCases := map[string]struct {
+		labels          map[string]string
+		pool            *workloadv1alpha1.WorkloadClusterPool
+		heartbeatMissed bool
+		anyAssignment   []string
+		expectedCluster string
+	}{
+		"priority -> first member": {
+			pool:            newPool("pool", workloadv1alpha1.FailoverPolicyPriority, otherTestClusterName, thirdTestClusterName),
+			expectedCluster: otherTestClusterName,
+		},
+		"random -> any healthy member": {
+			pool:          newPool("pool", workloadv1alpha1.FailoverPolicyRandom, otherTestClusterName, thirdTestClusterName),
+			anyAssignment: []string{otherTestClusterName, thirdTestClusterName},
+		},
+		"member lost its heartbeat -> skipped": {
+			pool:            newPool("pool", workloadv1alpha1.FailoverPolicyPriority, testClusterName, otherTestClusterName),
+			heartbeatMissed: true,
+			expectedCluster: otherTestClusterName,
+		},
+		"assigned member lost its heartbeat -> failover to the next member": {
+			labels:          map[string]string{ClusterLabel: testClusterName},
+			pool:            newPool("pool", workloadv1alpha1.FailoverPolicyPriority, testClusterName, thirdTestClusterName),
+			heartbeatMissed: true,
+			expectedCluster: thirdTestClusterName,
+		},
+		"assigned to a lower priority member -> no change": {
+			labels:          map[string]string{ClusterLabel: thirdTestClusterName},
+			pool:            newPool("pool", workloadv1alpha1.FailoverPolicyPriority, otherTestClusterName, thirdTestClusterName),
+			expectedCluster: thirdTestClusterName,
+		},
+		"assigned cluster removed from the pool -> new assignment": {
+			labels:          map[string]string{ClusterLabel: otherTestClusterName},
+			pool:            newPool("pool", workloadv1alpha1.FailoverPolicyPriority, thirdTestClusterName),
+			expectedCluster: thirdTestClusterName,
+		},
+		"no healthy member -> unassigned": {
+			labels:          map[string]string{ClusterLabel: testClusterName},
+			pool:            newPool("pool", workloadv1alpha1.FailoverPolicyPriority, testClusterName),
+			heartbeatMissed: true,
+			expectedCluster: "",
+		},
+		"missing pool -> unassigned": {
+			labels:          map[string]string{ClusterLabel: otherTestClusterName},
+			expectedCluster: "",
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.AdvancedPlacement, true)()
+
+			first := defaultClusterFixture().withReady()
+			if testCase.heartbeatMissed {
+				first = first.withHeartbeatMissed()
+			}
+			clusters := []*workloadv1alpha1.WorkloadCluster{
+				first.cluster,
+				otherClusterFixture().withReady().cluster,
+				newClusterFixture(testLclusterName, thirdTestClusterName).withReady().cluster,
+			}
+			placements := []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "failover", &metav1.LabelSelector{}, nil),
+			}
+			placements[0].Spec.Pool = "pool"
+
+			scheduler := newTestScheduler(clusters, placements)
+			if testCase.pool != nil {
+				scheduler.getPool = func(name string) (*workloadv1alpha1.WorkloadClusterPool, error) {
+					require.Equal(t, clustertools.ToClusterAwareKey(testLclusterName, "pool"), name)
+					return testCase.pool, nil
+				}
+			}
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "default",
+					ClusterName: testLclusterName.String(),
+					Labels:      testCase.labels,
+				},
+			}
+			clusterName, _, err := scheduler.AssignCluster(ns)
+			require.NoError(t, err)
+			if len(testCase.anyAssignment) > 0 {
+				require.Contains(t, testCase.anyAssignment, clusterName)
+			} else {
+				require.Equal(t, testCase.expectedCluster, clusterName)
+			}
+		})
+	}
+}
+
+func newPool(name string, policy workloadv1alpha1.FailoverPolicy, members ...string) *workloadv1alpha1.WorkloadClusterPool {
+	return &workloadv1alpha1.WorkloadClusterPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			ClusterName: testLclusterName.String(),
+		},
+		Spec: workloadv1alpha1.WorkloadClusterPoolSpec{
+			Members:        members,
+			FailoverPolicy: policy,
+		},
+	}
+}
+
+func TestAssignClusterDecision(t *testing.T) {
+	east := &metav1.LabelSelector{MatchLabels: map[string]string{"region": "east"}}
+
+	testCases := map[string]struct {
+		labels           map[string]string
+		annotations      map[string]string
+		placements       []*workloadv1alpha1.Placement
+		expectedDecision *workloadv1alpha1.SchedulingDecision
+	}{
+		"valid assignment -> no decision": {
+			labels: map[string]string{ClusterLabel: testClusterName},
+		},
+		"external scheduler -> no decision": {
+			annotations: map[string]string{SchedulerAnnotation: "my-scheduler"},
+		},
+		"new assignment -> rejected clusters with reasons": {
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "east", &metav1.LabelSelector{}, east),
+			},
+			expectedDecision: &workloadv1alpha1.SchedulingDecision{
+				Placement: "east",
+				Cluster:   testClusterName,
+				RejectedClusters: []workloadv1alpha1.RejectedCluster{
+					{Name: otherTestClusterName, Reason: "is not selected by the placement of the namespace"},
+					{Name: "unready-cluster", Reason: "is not reporting ready"},
+				},
+			},
+		},
+		"invalid assignment -> previous cluster and reason": {
+			labels: map[string]string{ClusterLabel: otherTestClusterName},
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "east", &metav1.LabelSelector{}, east),
+			},
+			expectedDecision: &workloadv1alpha1.SchedulingDecision{
+				Placement:       "east",
+				PreviousCluster: otherTestClusterName,
+				Cluster:         testClusterName,
+				Message:         "Cluster other-test-cluster is not selected by the placement of the namespace",
+				RejectedClusters: []workloadv1alpha1.RejectedCluster{
+					{Name: otherTestClusterName, Reason: "is not selected by the placement of the namespace"},
+					{Name: "unready-cluster", Reason: "is not reporting ready"},
+				},
+			},
+		},
+		"not selected by any placement -> unassigned": {
+			labels: map[string]string{"team": "a"},
+			placements: []*workloadv1alpha1.Placement{
+				newPlacement(testLclusterName, "east", &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}}, east),
+			},
+			expectedDecision: &workloadv1alpha1.SchedulingDecision{
+				Message: "No placement selects the namespace",
+			},
+		},
+	}
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.AdvancedPlacement, true)()
+
+			clusters := []*workloadv1alpha1.WorkloadCluster{
+				defaultClusterFixture().withReady().withLabels(map[string]string{"region": "east"}).cluster,
+				otherClusterFixture().withReady().withLabels(map[string]string{"region": "west"}).cluster,
+				newClusterFixture(testLclusterName, "unready-cluster").withLabels(map[string]string{"region": "east"}).cluster,
+				newClusterFixture(otherTestLclusterName, "other-workspace-cluster").withReady().withLabels(map[string]string{"region": "east"}).cluster,
+			}
+			scheduler := newTestScheduler(clusters, testCase.placements)
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "default",
+					ClusterName: testLclusterName.String(),
+					Labels:      testCase.labels,
+					Annotations: testCase.annotations,
+				},
+			}
+			_, _, err := scheduler.AssignCluster(ns)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedDecision, scheduler.decision)
+		})
+	}
+}
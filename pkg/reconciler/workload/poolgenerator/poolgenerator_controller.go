@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolgenerator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	workloadinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+)
+
+const controllerName = "kcp-workloadclusterpool-generator"
+
+// NewController returns a new controller which generates a WorkloadClusterPool per value of the
+// grouping label of the WorkloadClusters of every workspace.
+func NewController(
+	kcpClusterClient kcpclient.ClusterInterface,
+	workloadClusterInformer workloadinformer.WorkloadClusterInformer,
+	workloadClusterPoolInformer workloadinformer.WorkloadClusterPoolInformer,
+	groupingLabel string,
+) *controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue:                     queue,
+		kcpClusterClient:          kcpClusterClient,
+		workloadClusterLister:     workloadClusterInformer.Lister(),
+		workloadClusterPoolLister: workloadClusterPoolInformer.Lister(),
+		groupingLabel:             groupingLabel,
+	}
+
+	workloadClusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueueWorkspace(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspace(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueueWorkspace(obj) },
+	})
+
+	// Generated pools changed or deleted by users are restored.
+	workloadClusterPoolInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: isGenerated,
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj interface{}) { c.enqueueWorkspace(obj) },
+			DeleteFunc: func(obj interface{}) { c.enqueueWorkspace(obj) },
+		},
+	})
+
+	return c
+}
+
+// controller maintains a WorkloadClusterPool per value of the grouping label in every workspace,
+// e.g. one per region, with the WorkloadClusters of the workspace having that value as members.
+// It only touches the pools it generated, marked by the GeneratedFromLabelAnnotation.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.ClusterInterface
+
+	workloadClusterLister     workloadlisters.WorkloadClusterLister
+	workloadClusterPoolLister workloadlisters.WorkloadClusterPoolLister
+
+	groupingLabel string
+}
+
+func isGenerated(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pool, ok := obj.(*workloadv1alpha1.WorkloadClusterPool)
+	if !ok {
+		return false
+	}
+	_, generated := pool.Annotations[workloadv1alpha1.GeneratedFromLabelAnnotation]
+	return generated
+}
+
+// enqueueWorkspace enqueues the logical cluster of the object.
+func (c *controller) enqueueWorkspace(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.Add(logicalcluster.From(metaObj).String())
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.Infof("Starting %s controller", controllerName)
+	defer klog.Infof("Shutting down %s controller", controllerName)
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.reconcile(ctx, logicalcluster.New(key)); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
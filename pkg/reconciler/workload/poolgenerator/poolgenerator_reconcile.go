@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolgenerator
+
+import (
+	"context"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// poolName returns the name of the pool generated for a value of the grouping label. Label
+// values are valid names once lowercased and with underscores replaced.
+func poolName(value string) string {
+	return strings.ReplaceAll(strings.ToLower(value), "_", "-")
+}
+
+// desiredPools returns the members, by name, of the pools to generate for the given WorkloadClusters,
+// by pool name. WorkloadClusters being deleted are left out.
+func desiredPools(workloadClusters []*workloadv1alpha1.WorkloadCluster, groupingLabel string) map[string][]string {
+	members := map[string]sets.String{}
+	for _, workloadCluster := range workloadClusters {
+		value := workloadCluster.Labels[groupingLabel]
+		if value == "" || workloadCluster.DeletionTimestamp != nil {
+			continue
+		}
+		name := poolName(value)
+		if members[name] == nil {
+			members[name] = sets.NewString()
+		}
+		members[name].Insert(workloadCluster.Name)
+	}
+
+	pools := make(map[string][]string, len(members))
+	for name, names := range members {
+		pools[name] = names.List()
+	}
+	return pools
+}
+
+func (c *controller) reconcile(ctx context.Context, clusterName logicalcluster.LogicalCluster) error {
+	allWorkloadClusters, err := c.workloadClusterLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var workloadClusters []*workloadv1alpha1.WorkloadCluster
+	for _, workloadCluster := range allWorkloadClusters {
+		if logicalcluster.From(workloadCluster) == clusterName {
+			workloadClusters = append(workloadClusters, workloadCluster)
+		}
+	}
+	desired := desiredPools(workloadClusters, c.groupingLabel)
+
+	allPools, err := c.workloadClusterPoolLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	existing := map[string]*workloadv1alpha1.WorkloadClusterPool{}
+	for _, pool := range allPools {
+		if logicalcluster.From(pool) == clusterName {
+			existing[pool.Name] = pool
+		}
+	}
+
+	client := c.kcpClusterClient.Cluster(clusterName).WorkloadV1alpha1().WorkloadClusterPools()
+
+	for name, pool := range existing {
+		if _, found := desired[name]; found || !isGenerated(pool) {
+			continue
+		}
+		klog.Infof("Deleting WorkloadClusterPool %s|%s: no WorkloadCluster has the %s label anymore", clusterName, name, c.groupingLabel)
+		if err := client.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	for name, members := range desired {
+		pool, found := existing[name]
+		if !found {
+			klog.Infof("Creating WorkloadClusterPool %s|%s with members %v", clusterName, name, members)
+			_, err := client.Create(ctx, &workloadv1alpha1.WorkloadClusterPool{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        name,
+					Annotations: map[string]string{workloadv1alpha1.GeneratedFromLabelAnnotation: c.groupingLabel},
+				},
+				Spec: workloadv1alpha1.WorkloadClusterPoolSpec{Members: members},
+			}, metav1.CreateOptions{})
+			if err != nil && !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+			continue
+		}
+		if !isGenerated(pool) {
+			klog.V(2).Infof("Not generating WorkloadClusterPool %s|%s: a pool with that name exists and was not generated", clusterName, name)
+			continue
+		}
+		if pool.Annotations[workloadv1alpha1.GeneratedFromLabelAnnotation] == c.groupingLabel && equality.Semantic.DeepEqual(pool.Spec.Members, members) {
+			continue
+		}
+
+		pool = pool.DeepCopy()
+		pool.Annotations[workloadv1alpha1.GeneratedFromLabelAnnotation] = c.groupingLabel
+		pool.Spec.Members = members
+		klog.Infof("Updating the members of WorkloadClusterPool %s|%s to %v", clusterName, name, members)
+		if _, err := client.Update(ctx, pool, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolgenerator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+)
+
+const regionLabel = "topology.kubernetes.io/region"
+
+type fakeClusterClient struct {
+	*kcpfakeclient.Clientset
+}
+
+func (c fakeClusterClient) Cluster(_ logicalcluster.LogicalCluster) kcpclient.Interface {
+	return c.Clientset
+}
+
+func workloadCluster(name, region string, deleted bool) *workloadv1alpha1.WorkloadCluster {
+	wc := &workloadv1alpha1.WorkloadCluster{ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: "root:org:ws"}}
+	if region != "" {
+		wc.Labels = map[string]string{regionLabel: region}
+	}
+	if deleted {
+		wc.DeletionTimestamp = &metav1.Time{}
+	}
+	return wc
+}
+
+func pool(name, generatedFrom string, members ...string) *workloadv1alpha1.WorkloadClusterPool {
+	p := &workloadv1alpha1.WorkloadClusterPool{
+		ObjectMeta: metav1.ObjectMeta{Name: name, ClusterName: "root:org:ws"},
+		Spec:       workloadv1alpha1.WorkloadClusterPoolSpec{Members: members},
+	}
+	if generatedFrom != "" {
+		p.Annotations = map[string]string{workloadv1alpha1.GeneratedFromLabelAnnotation: generatedFrom}
+	}
+	return p
+}
+
+func TestDesiredPools(t *testing.T) {
+	workloadClusters := []*workloadv1alpha1.WorkloadCluster{
+		workloadCluster("east-2", "US_East", false),
+		workloadCluster("east-1", "US_East", false),
+		workloadCluster("east-3", "US_East", true),
+		workloadCluster("west-1", "us-west", false),
+		workloadCluster("unlabeled", "", false),
+	}
+	require.Equal(t, map[string][]string{
+		"us-east": {"east-1", "east-2"},
+		"us-west": {"west-1"},
+	}, desiredPools(workloadClusters, regionLabel))
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		name             string
+		workloadClusters []*workloadv1alpha1.WorkloadCluster
+		pools            []*workloadv1alpha1.WorkloadClusterPool
+		wantPools        []*workloadv1alpha1.WorkloadClusterPool
+	}{
+		{
+			name: "pools are created",
+			workloadClusters: []*workloadv1alpha1.WorkloadCluster{
+				workloadCluster("east-1", "us-east", false),
+				workloadCluster("west-1", "us-west", false),
+			},
+			wantPools: []*workloadv1alpha1.WorkloadClusterPool{
+				pool("us-east", regionLabel, "east-1"),
+				pool("us-west", regionLabel, "west-1"),
+			},
+		},
+		{
+			name: "members are updated",
+			workloadClusters: []*workloadv1alpha1.WorkloadCluster{
+				workloadCluster("east-1", "us-east", false),
+				workloadCluster("east-2", "us-east", false),
+			},
+			pools: []*workloadv1alpha1.WorkloadClusterPool{
+				pool("us-east", regionLabel, "east-1", "west-1"),
+			},
+			wantPools: []*workloadv1alpha1.WorkloadClusterPool{
+				pool("us-east", regionLabel, "east-1", "east-2"),
+			},
+		},
+		{
+			name: "pools without members and of another label are deleted",
+			workloadClusters: []*workloadv1alpha1.WorkloadCluster{
+				workloadCluster("east-1", "", false),
+			},
+			pools: []*workloadv1alpha1.WorkloadClusterPool{
+				pool("us-east", regionLabel, "east-1"),
+				pool("zone-a", "topology.kubernetes.io/zone", "east-1"),
+			},
+		},
+		{
+			name: "pools not generated are left alone",
+			workloadClusters: []*workloadv1alpha1.WorkloadCluster{
+				workloadCluster("east-1", "us-east", false),
+			},
+			pools: []*workloadv1alpha1.WorkloadClusterPool{
+				pool("us-east", "", "west-1"),
+				pool("failover", "", "east-1"),
+			},
+			wantPools: []*workloadv1alpha1.WorkloadClusterPool{
+				pool("failover", "", "east-1"),
+				pool("us-east", "", "west-1"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, wc := range tt.workloadClusters {
+				require.NoError(t, clusterIndexer.Add(wc))
+			}
+			poolIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			var objects []runtime.Object
+			for _, p := range tt.pools {
+				require.NoError(t, poolIndexer.Add(p))
+				objects = append(objects, p)
+			}
+			client := kcpfakeclient.NewSimpleClientset(objects...)
+
+			c := &controller{
+				kcpClusterClient:          fakeClusterClient{client},
+				workloadClusterLister:     workloadlisters.NewWorkloadClusterLister(clusterIndexer),
+				workloadClusterPoolLister: workloadlisters.NewWorkloadClusterPoolLister(poolIndexer),
+				groupingLabel:             regionLabel,
+			}
+			err := c.reconcile(context.TODO(), logicalcluster.New("root:org:ws"))
+			require.NoError(t, err)
+
+			pools, err := client.WorkloadV1alpha1().WorkloadClusterPools().List(context.TODO(), metav1.ListOptions{})
+			require.NoError(t, err)
+			var got []*workloadv1alpha1.WorkloadClusterPool
+			for i := range pools.Items {
+				p := pools.Items[i]
+				got = append(got, pool(p.Name, p.Annotations[workloadv1alpha1.GeneratedFromLabelAnnotation], p.Spec.Members...))
+			}
+			require.Equal(t, tt.wantPools, got)
+		})
+	}
+}
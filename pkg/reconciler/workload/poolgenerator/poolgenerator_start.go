@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolgenerator
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringVar(&o.GroupingLabel, "workload-cluster-pool-grouping-label", o.GroupingLabel, "Key of the label of the WorkloadClusters, e.g. topology.kubernetes.io/region, whose values each get a WorkloadClusterPool with the WorkloadClusters of the workspace having that value. If empty, no pools are generated")
+	return o
+}
+
+type Options struct {
+	GroupingLabel string
+}
+
+func (o *Options) Validate() error {
+	if o.GroupingLabel == "" {
+		return nil
+	}
+	if errs := validation.IsQualifiedName(o.GroupingLabel); len(errs) > 0 {
+		return fmt.Errorf("--workload-cluster-pool-grouping-label must be a label key (%s): %v", o.GroupingLabel, errs)
+	}
+	return nil
+}
@@ -101,6 +101,14 @@ func installSyncer(ctx context.Context, client kubernetes.Interface, syncerImage
 			},
 		},
 	}
+	if resourcesWithStatus.Has("services") {
+		// The ready endpoints of the services are exposed upstream.
+		clusterRole.Rules = append(clusterRole.Rules, rbacv1.PolicyRule{
+			Verbs:     []string{"list", "watch"},
+			APIGroups: []string{"discovery.k8s.io"},
+			Resources: []string{"endpointslices"},
+		})
+	}
 	if _, err := client.RbacV1().ClusterRoles().Create(ctx, clusterRole, metav1.CreateOptions{}); err != nil {
 		if !k8serrors.IsAlreadyExists(err) {
 			return err
@@ -291,3 +299,13 @@ func isSyncerInstalledAndUpToDate(ctx context.Context, client kubernetes.Interfa
 	}
 	return pods.Items[0].Spec.Containers[0].Image == syncerImage, nil
 }
+
+func isSyncerKubeconfigUpToDate(ctx context.Context, client kubernetes.Interface, logicalCluster, kubeconfig string) (bool, error) {
+	configMap, err := client.CoreV1().ConfigMaps(syncerNS).Get(ctx, syncerConfigMapName(logicalCluster), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return configMap.Data["kubeconfig"] == kubeconfig, nil
+}
@@ -44,13 +44,24 @@ func (m *pullSyncerManager) name() string {
 	return "kcp-pull-syncer-manager"
 }
 
-func (m *pullSyncerManager) needsUpdate(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster, client *kubernetes.Clientset, groupResources sets.String) (bool, error) {
+func (m *pullSyncerManager) needsUpdate(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster, client *kubernetes.Clientset, groupResources sets.String, upstreamKubeConfig *clientcmdapi.Config) (bool, error) {
 	logicalCluster := cluster.GetClusterName()
 	upToDate, err := isSyncerInstalledAndUpToDate(ctx, client, logicalCluster, m.syncerImage)
 	if err != nil {
 		klog.Errorf("error checking if syncer needs to be installed: %v", err)
 		return false, err
 	}
+	if upToDate {
+		// the kubeconfig is rewritten when the URL of kcp changed
+		bytes, err := clientcmd.Write(*upstreamKubeConfig)
+		if err != nil {
+			return false, err
+		}
+		if upToDate, err = isSyncerKubeconfigUpToDate(ctx, client, logicalCluster, string(bytes)); err != nil {
+			klog.Errorf("error checking if syncer kubeconfig needs to be updated: %v", err)
+			return false, err
+		}
+	}
 	return !upToDate && groupResources.Len() > 0, nil
 }
 
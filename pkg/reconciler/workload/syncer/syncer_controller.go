@@ -20,11 +20,20 @@ import (
 	"context"
 
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog/v2"
 
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	apiresourceinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/apiresource/v1alpha1"
+	tenancyinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	workloadinformer "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
 	clusterctl "github.com/kcp-dev/kcp/pkg/reconciler/workload/basecontroller"
 )
 
@@ -33,6 +42,8 @@ type Controller struct {
 	crdClusterClient  *apiextensionsclient.Cluster
 	syncerManager     *syncerManager
 	clusterReconciler *clusterctl.ClusterReconciler
+	clusterQueue      clusterctl.ClusterQueue
+	clusterLister     workloadlisters.WorkloadClusterLister
 }
 
 func NewController(
@@ -52,7 +63,7 @@ func NewController(
 		apiresourceImportIndexer: apiResourceImportInformer.Informer().GetIndexer(),
 	}
 
-	cr, _, err := clusterctl.NewClusterReconciler(
+	cr, queue, err := clusterctl.NewClusterReconciler(
 		syncerManagerImpl.name(),
 		sm,
 		kcpClusterClient,
@@ -68,9 +79,50 @@ func NewController(
 		crdClusterClient:  crdClusterClient,
 		syncerManager:     sm,
 		clusterReconciler: cr,
+		clusterQueue:      queue,
+		clusterLister:     clusterInformer.Lister(),
 	}, nil
 }
 
+// FollowShard points the upstream kubeconfig of the syncers to the external URL of the given
+// ClusterWorkspaceShard, and updates the syncers when the URL of the shard changes. It must be
+// called before Start.
+func (c *Controller) FollowShard(shardInformer tenancyinformer.ClusterWorkspaceShardInformer, shardName string) {
+	shardLister := shardInformer.Lister()
+	c.syncerManager.upstreamServer = func() string {
+		shard, err := shardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, shardName))
+		if err != nil {
+			return ""
+		}
+		return tenancyhelper.ShardURL(shard.Spec, tenancyhelper.ExternalAudience)
+	}
+
+	shardInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			shard, ok := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
+			return ok && shard.Name == shardName
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, obj interface{}) {
+				oldShard := oldObj.(*tenancyv1alpha1.ClusterWorkspaceShard)
+				shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
+				if tenancyhelper.ShardURL(oldShard.Spec, tenancyhelper.ExternalAudience) == tenancyhelper.ShardURL(shard.Spec, tenancyhelper.ExternalAudience) {
+					return
+				}
+				workloadClusters, err := c.clusterLister.List(labels.Everything())
+				if err != nil {
+					runtime.HandleError(err)
+					return
+				}
+				klog.Infof("%s: URL of shard %q changed, updating the kubeconfig of %d syncers", c.name, shardName, len(workloadClusters))
+				for _, cluster := range workloadClusters {
+					c.clusterQueue.EnqueueAfter(cluster, 0)
+				}
+			},
+		},
+	})
+}
+
 // TODO(sttts): fix the many races due to unprotected field access and then increase worker count
 func (c *Controller) Start(ctx context.Context) {
 	c.clusterReconciler.Start(ctx)
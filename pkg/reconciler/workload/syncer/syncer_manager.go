@@ -19,6 +19,7 @@ package syncer
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 
@@ -39,7 +40,7 @@ import (
 
 type SyncerManager interface {
 	name() string
-	needsUpdate(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster, client *kubernetes.Clientset, groupResources sets.String) (bool, error)
+	needsUpdate(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster, client *kubernetes.Clientset, groupResources sets.String, kubeConfig *clientcmdapi.Config) (bool, error)
 	update(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster, client *kubernetes.Clientset, groupResources sets.String, kubeConfig *clientcmdapi.Config) (bool, error)
 	checkHealth(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster, client *kubernetes.Clientset) bool
 	cleanup(ctx context.Context, deletedCluster *workloadv1alpha1.WorkloadCluster)
@@ -49,12 +50,13 @@ type syncerManager struct {
 	name string
 
 	upstreamKubeconfig       *clientcmdapi.Config
+	upstreamServer           func() string
 	resourcesToSync          []string
 	syncerManagerImpl        SyncerManager
 	apiresourceImportIndexer cache.Indexer
 }
 
-func (m *syncerManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster) error {
+func (m *syncerManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1.WorkloadCluster) (time.Duration, error) {
 	klog.Infof("%s: reconciling cluster %s|%s", m.name, cluster.ClusterName, cluster.Name)
 
 	logicalCluster := logicalcluster.From(cluster)
@@ -67,7 +69,7 @@ func (m *syncerManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1
 	)
 	if err != nil {
 		klog.Errorf("%s: error in cluster reconcile: %v", m.name, err)
-		return err
+		return 0, err
 	}
 
 	for _, obj := range objs {
@@ -105,19 +107,20 @@ func (m *syncerManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1
 	if err != nil {
 		klog.Errorf("%s: invalid kubeconfig: %v", m.name, err)
 		conditions.MarkFalse(cluster, workloadv1alpha1.SyncerReady, workloadv1alpha1.InvalidKubeConfigReason, conditionsv1alpha1.ConditionSeverityError, "Error invalid kubeconfig: %v", err.Error())
-		return nil
+		return 0, nil
 	}
 
 	client, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		klog.Errorf("%s: error creating client: %v", m.name, err)
 		conditions.MarkFalse(cluster, workloadv1alpha1.SyncerReady, workloadv1alpha1.ErrorCreatingClientReason, conditionsv1alpha1.ConditionSeverityError, "Error creating client: %v", err.Error())
-		return nil
+		return 0, nil
 	}
 
-	needsUpdate, err := m.syncerManagerImpl.needsUpdate(ctx, cluster, client, groupResources)
+	upstreamKubeconfig := m.kubeconfig()
+	needsUpdate, err := m.syncerManagerImpl.needsUpdate(ctx, cluster, client, groupResources, upstreamKubeconfig)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if klog.V(2).Enabled() {
@@ -131,10 +134,10 @@ func (m *syncerManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1
 
 	if needsUpdate {
 		klog.V(2).Infof("%s: Need to create/update syncer", m.name)
-		if updateSucceeded, err := m.syncerManagerImpl.update(ctx, cluster, client, groupResources, m.upstreamKubeconfig); err != nil {
-			return err
+		if updateSucceeded, err := m.syncerManagerImpl.update(ctx, cluster, client, groupResources, upstreamKubeconfig); err != nil {
+			return 0, err
 		} else if !updateSucceeded {
-			return nil
+			return 0, nil
 		}
 
 		cluster.Status.SyncedResources = groupResources.List()
@@ -142,10 +145,26 @@ func (m *syncerManager) Reconcile(ctx context.Context, cluster *workloadv1alpha1
 
 	checkSucceeded := m.syncerManagerImpl.checkHealth(ctx, cluster, client)
 	if !checkSucceeded {
-		return nil
+		return 0, nil
 	}
 
-	return nil
+	return 0, nil
+}
+
+// kubeconfig returns the upstream kubeconfig, pointing to the current upstream server if known.
+func (m *syncerManager) kubeconfig() *clientcmdapi.Config {
+	if m.upstreamServer == nil {
+		return m.upstreamKubeconfig
+	}
+	server := m.upstreamServer()
+	if server == "" {
+		return m.upstreamKubeconfig
+	}
+	kubeconfig := m.upstreamKubeconfig.DeepCopy()
+	for _, cluster := range kubeconfig.Clusters {
+		cluster.Server = server
+	}
+	return kubeconfig
 }
 
 func (m *syncerManager) Cleanup(ctx context.Context, deletedCluster *workloadv1alpha1.WorkloadCluster) {
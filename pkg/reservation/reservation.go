@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reservation
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/tenancy/v1alpha1"
+)
+
+const (
+	// ClusterWorkspaceTypesResource is the resource of the reservations of ClusterWorkspaceType names.
+	ClusterWorkspaceTypesResource = "clusterworkspacetypes.tenancy.kcp.dev"
+	// APIExportIdentitiesResource is the resource of the reservations of APIExport identity hashes.
+	APIExportIdentitiesResource = "identities.apiexports.apis.kcp.dev"
+
+	// ResourceLabel is the label holding the resource of a NameReservation.
+	ResourceLabel = "reservation.tenancy.kcp.dev/resource"
+	// OwnerLabel is the label holding the hash of the owner of a NameReservation, as owners
+	// are not valid label values.
+	OwnerLabel = "reservation.tenancy.kcp.dev/owner"
+)
+
+// Reserver reserves names that must be unique across all workspaces of all shards, through
+// NameReservations in the root workspace. The name of a NameReservation is derived from the
+// resource and the reserved name, hence the apiserver of the root shard serializes concurrent
+// reservations of the same name, wherever they come from.
+type Reserver struct {
+	client tenancyclient.NameReservationInterface
+}
+
+// NewReserver returns a Reserver creating NameReservations with the given client of the root workspace.
+func NewReserver(client tenancyclient.NameReservationInterface) *Reserver {
+	return &Reserver{client: client}
+}
+
+// ReservationName returns the name of the NameReservation of the given name of a resource.
+func ReservationName(resource, name string) string {
+	return name + "." + resource
+}
+
+// ClusterWorkspaceTypeName returns the reserved name of the ClusterWorkspaceType with the given name
+// in the given logical cluster. ClusterWorkspaceType names are unique within the workspace tree of an
+// organization, not across organizations, hence the reserved name is qualified with the organization,
+// e.g. "database.root-org" for the type database in root:org:team, and "database.root" in root.
+func ClusterWorkspaceTypeName(clusterName logicalcluster.LogicalCluster, name string) string {
+	segments := strings.SplitN(clusterName.String(), ":", 3)
+	if len(segments) > 2 {
+		segments = segments[:2]
+	}
+	return name + "." + strings.Join(segments, "-")
+}
+
+// OwnerHash returns the value of the OwnerLabel of the given owner.
+func OwnerHash(owner tenancyv1alpha1.NameReservationOwner) string {
+	return fmt.Sprintf("%x", sha256.Sum224([]byte(owner.Cluster+"|"+owner.Name)))
+}
+
+// NewReservation returns the NameReservation of the name of the resource for the given owner.
+func NewReservation(resource, name string, owner tenancyv1alpha1.NameReservationOwner) *tenancyv1alpha1.NameReservation {
+	return &tenancyv1alpha1.NameReservation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ReservationName(resource, name),
+			Labels: map[string]string{
+				ResourceLabel: resource,
+				OwnerLabel:    OwnerHash(owner),
+			},
+		},
+		Spec: tenancyv1alpha1.NameReservationSpec{
+			Resource: resource,
+			Name:     name,
+			Owner:    owner,
+		},
+	}
+}
+
+// Reserve reserves the name of the resource for the given owner. It succeeds if the name
+// is already reserved for the same owner, and fails if another owner holds it.
+func (r *Reserver) Reserve(ctx context.Context, resource, name string, owner tenancyv1alpha1.NameReservationOwner) error {
+	reservation := NewReservation(resource, name, owner)
+	_, err := r.client.Create(ctx, reservation, metav1.CreateOptions{})
+	if err == nil {
+		klog.V(2).Infof("Reserved %s %q for %s|%s", resource, name, owner.Cluster, owner.Name)
+		return nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to reserve %s %q: %w", resource, name, err)
+	}
+
+	existing, err := r.client.Get(ctx, reservation.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the reservation of %s %q: %w", resource, name, err)
+	}
+	if existing.Spec.Owner == owner {
+		return nil
+	}
+	return fmt.Errorf("%s %q is already reserved by %q in workspace %q", resource, name, existing.Spec.Owner.Name, existing.Spec.Owner.Cluster)
+}
+
+// Release deletes the reservations of the resource held by the given owner.
+func (r *Reserver) Release(ctx context.Context, resource string, owner tenancyv1alpha1.NameReservationOwner) error {
+	selector := labels.SelectorFromSet(labels.Set{ResourceLabel: resource, OwnerLabel: OwnerHash(owner)})
+	reservations, err := r.client.List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("failed to list the reservations of %s: %w", resource, err)
+	}
+	for _, reservation := range reservations.Items {
+		if reservation.Spec.Resource != resource || reservation.Spec.Owner != owner {
+			continue
+		}
+		if err := r.client.Delete(ctx, reservation.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to release %s %q: %w", resource, reservation.Spec.Name, err)
+		}
+		klog.V(2).Infof("Released %s %q of %s|%s", resource, reservation.Spec.Name, owner.Cluster, owner.Name)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reservation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func newReservation(resource, name, cluster, owner string) *tenancyv1alpha1.NameReservation {
+	return NewReservation(resource, name, tenancyv1alpha1.NameReservationOwner{Cluster: cluster, Name: owner})
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		wantErr  bool
+	}{
+		{
+			name: "free name is reserved",
+		},
+		{
+			name:     "name reserved by the same owner",
+			existing: []runtime.Object{newReservation("widgets", "foo", "root:org:ws", "foo")},
+		},
+		{
+			name:     "name reserved in another workspace",
+			existing: []runtime.Object{newReservation("widgets", "foo", "root:org:other", "foo")},
+			wantErr:  true,
+		},
+		{
+			name:     "same name of another resource",
+			existing: []runtime.Object{newReservation("gadgets", "foo", "root:org:other", "foo")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := kcpfakeclient.NewSimpleClientset(tt.existing...).TenancyV1alpha1().NameReservations()
+			owner := tenancyv1alpha1.NameReservationOwner{Cluster: "root:org:ws", Name: "foo"}
+
+			err := NewReserver(client).Reserve(context.Background(), "widgets", "foo", owner)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			reservation, err := client.Get(context.Background(), ReservationName("widgets", "foo"), metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, owner, reservation.Spec.Owner)
+			require.Equal(t, OwnerHash(owner), reservation.Labels[OwnerLabel])
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	client := kcpfakeclient.NewSimpleClientset(
+		newReservation("widgets", "foo", "root:org:ws", "foo"),
+		newReservation("widgets", "bar", "root:org:other", "foo"),
+		newReservation("gadgets", "foo", "root:org:ws", "foo"),
+	).TenancyV1alpha1().NameReservations()
+	owner := tenancyv1alpha1.NameReservationOwner{Cluster: "root:org:ws", Name: "foo"}
+
+	err := NewReserver(client).Release(context.Background(), "widgets", owner)
+	require.NoError(t, err)
+
+	reservations, err := client.List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	var got []string
+	for _, reservation := range reservations.Items {
+		got = append(got, reservation.Name)
+	}
+	require.ElementsMatch(t, []string{ReservationName("widgets", "bar"), ReservationName("gadgets", "foo")}, got)
+}
+
+func TestClusterWorkspaceTypeName(t *testing.T) {
+	tests := map[string]string{
+		"root":              "foo.root",
+		"root:org":          "foo.root-org",
+		"root:org:team":     "foo.root-org",
+		"root:org:team:app": "foo.root-org",
+		"root:other":        "foo.root-other",
+	}
+	for cluster, want := range tests {
+		t.Run(cluster, func(t *testing.T) {
+			require.Equal(t, want, ClusterWorkspaceTypeName(logicalcluster.New(cluster), "foo"))
+		})
+	}
+}
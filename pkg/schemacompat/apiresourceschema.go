@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schemacompat
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/multierr"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+// EnsureAPIResourceSchemaCompatibility compares a new APIResourceSchema spec to an existing one of the same resource,
+// and reports every change breaking the clients or the objects of the existing one: a changed group, kind, resource
+// or scope, a removed or unserved version, and incompatible changes of the schemas of the versions served by both,
+// like removed fields, changed types or tightened validation.
+func EnsureAPIResourceSchemaCompatibility(fldPath *field.Path, existing, new *apisv1alpha1.APIResourceSchemaSpec) error {
+	var err error
+	if existing.Group != new.Group {
+		multierr.AppendInto(&err, field.Invalid(fldPath.Child("group"), new.Group, fmt.Sprintf("The group changed (was %q)", existing.Group)))
+	}
+	if existing.Names.Plural != new.Names.Plural {
+		multierr.AppendInto(&err, field.Invalid(fldPath.Child("names", "plural"), new.Names.Plural, fmt.Sprintf("The resource changed (was %q)", existing.Names.Plural)))
+	}
+	if existing.Names.Kind != new.Names.Kind {
+		multierr.AppendInto(&err, field.Invalid(fldPath.Child("names", "kind"), new.Names.Kind, fmt.Sprintf("The kind changed (was %q)", existing.Names.Kind)))
+	}
+	if existing.Scope != new.Scope {
+		multierr.AppendInto(&err, field.Invalid(fldPath.Child("scope"), new.Scope, fmt.Sprintf("The scope changed (was %q)", existing.Scope)))
+	}
+
+	newVersions := map[string]*apisv1alpha1.APIResourceVersion{}
+	for i := range new.Versions {
+		newVersions[new.Versions[i].Name] = &new.Versions[i]
+	}
+	for i := range existing.Versions {
+		existingVersion := &existing.Versions[i]
+		if !existingVersion.Served {
+			continue
+		}
+		versionPath := fldPath.Child("versions").Key(existingVersion.Name)
+		newVersion, found := newVersions[existingVersion.Name]
+		if !found || !newVersion.Served {
+			multierr.AppendInto(&err, field.NotFound(versionPath, "The version is not served anymore"))
+			continue
+		}
+
+		existingSchema, schemaErr := openAPISchema(existingVersion)
+		if schemaErr != nil {
+			multierr.AppendInto(&err, field.Invalid(versionPath.Child("schema"), nil, fmt.Sprintf("invalid existing schema: %v", schemaErr)))
+			continue
+		}
+		newSchema, schemaErr := openAPISchema(newVersion)
+		if schemaErr != nil {
+			multierr.AppendInto(&err, field.Invalid(versionPath.Child("schema"), nil, fmt.Sprintf("invalid new schema: %v", schemaErr)))
+			continue
+		}
+		_, compatErr := EnsureStructuralSchemaCompatibility(versionPath.Child("schema"), existingSchema, newSchema, false)
+		multierr.AppendInto(&err, compatErr)
+	}
+
+	return err
+}
+
+func openAPISchema(version *apisv1alpha1.APIResourceVersion) (*apiextensionsv1.JSONSchemaProps, error) {
+	var schema apiextensionsv1.JSONSchemaProps
+	if err := json.Unmarshal(version.Schema.Raw, &schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
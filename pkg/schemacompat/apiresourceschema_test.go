@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package schemacompat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestAPIResourceSchemaCompatibility(t *testing.T) {
+	spec := func(mutate func(spec *apisv1alpha1.APIResourceSchemaSpec)) *apisv1alpha1.APIResourceSchemaSpec {
+		spec := &apisv1alpha1.APIResourceSchemaSpec{
+			Group: "example.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Kind: "Widget"},
+			Scope: apiextensionsv1.NamespaceScoped,
+			Versions: []apisv1alpha1.APIResourceVersion{{
+				Name:   "v1",
+				Served: true,
+				Schema: runtime.RawExtension{Raw: []byte(`{"type":"object","properties":{"size":{"type":"integer"}}}`)},
+			}},
+		}
+		if mutate != nil {
+			mutate(spec)
+		}
+		return spec
+	}
+
+	tests := map[string]struct {
+		new      *apisv1alpha1.APIResourceSchemaSpec
+		wantErrs []string
+	}{
+		"unchanged": {
+			new: spec(nil),
+		},
+		"added field and version": {
+			new: spec(func(spec *apisv1alpha1.APIResourceSchemaSpec) {
+				spec.Versions[0].Schema.Raw = []byte(`{"type":"object","properties":{"size":{"type":"integer"},"color":{"type":"string"}}}`)
+				spec.Versions = append(spec.Versions, apisv1alpha1.APIResourceVersion{Name: "v2", Served: true, Schema: runtime.RawExtension{Raw: []byte("{}")}})
+			}),
+		},
+		"changed kind and scope": {
+			new: spec(func(spec *apisv1alpha1.APIResourceSchemaSpec) {
+				spec.Names.Kind = "Gadget"
+				spec.Scope = apiextensionsv1.ClusterScoped
+			}),
+			wantErrs: []string{
+				`spec.names.kind: Invalid value: "Gadget": The kind changed (was "Widget")`,
+				`spec.scope: Invalid value: "Cluster": The scope changed (was "Namespaced")`,
+			},
+		},
+		"removed version": {
+			new: spec(func(spec *apisv1alpha1.APIResourceSchemaSpec) {
+				spec.Versions[0].Name = "v2"
+			}),
+			wantErrs: []string{"spec.versions[v1]: Not found: \"The version is not served anymore\""},
+		},
+		"unserved version": {
+			new: spec(func(spec *apisv1alpha1.APIResourceSchemaSpec) {
+				spec.Versions[0].Served = false
+			}),
+			wantErrs: []string{"spec.versions[v1]: Not found: \"The version is not served anymore\""},
+		},
+		"changed type": {
+			new: spec(func(spec *apisv1alpha1.APIResourceSchemaSpec) {
+				spec.Versions[0].Schema.Raw = []byte(`{"type":"object","properties":{"size":{"type":"string"}}}`)
+			}),
+			wantErrs: []string{`spec.versions[v1].schema.properties[size].type: Invalid value: "string": The type changed (was "integer", now "string")`},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := EnsureAPIResourceSchemaCompatibility(field.NewPath("spec"), spec(nil), tc.new)
+			var errs []string
+			for _, err := range multierr.Errors(err) {
+				errs = append(errs, err.Error())
+			}
+			require.Equal(t, tc.wantErrs, errs)
+		})
+	}
+}
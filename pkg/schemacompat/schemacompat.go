@@ -157,29 +157,53 @@ func lcdForStructural(fldPath *field.Path, existing, new *schema.Structural, lcd
 		return field.Invalid(fldPath.Child("x-kubernetes-preserve-unknown-fields"), new.XPreserveUnknownFields, fmt.Sprintf("x-kubernetes-preserve-unknown-fields value changed (was %t, now %t)", was, now))
 	}
 
+	err := lcdForValidationRules(fldPath, existing, new, lcd, narrowExisting)
+
 	switch existing.Type {
 	case "number":
-		return lcdForNumber(fldPath, existing, new, lcd, narrowExisting)
+		return multierr.Combine(err, lcdForNumber(fldPath, existing, new, lcd, narrowExisting))
 	case "integer":
-		return lcdForInteger(fldPath, existing, new, lcd, narrowExisting)
+		return multierr.Combine(err, lcdForInteger(fldPath, existing, new, lcd, narrowExisting))
 	case "string":
-		return lcdForString(fldPath, existing, new, lcd, narrowExisting)
+		return multierr.Combine(err, lcdForString(fldPath, existing, new, lcd, narrowExisting))
 	case "boolean":
-		return lcdForBoolean(fldPath, existing, new, lcd, narrowExisting)
+		return multierr.Combine(err, lcdForBoolean(fldPath, existing, new, lcd, narrowExisting))
 	case "array":
-		return lcdForArray(fldPath, existing, new, lcd, narrowExisting)
+		return multierr.Combine(err, lcdForArray(fldPath, existing, new, lcd, narrowExisting))
 	case "object":
-		return lcdForObject(fldPath, existing, new, lcd, narrowExisting)
+		return multierr.Combine(err, lcdForObject(fldPath, existing, new, lcd, narrowExisting))
 	case "":
 		if existing.XIntOrString {
-			return lcdForIntOrString(fldPath, existing, new, lcd, narrowExisting)
+			return multierr.Combine(err, lcdForIntOrString(fldPath, existing, new, lcd, narrowExisting))
 		} else if existing.XPreserveUnknownFields {
-			return lcdForPreserveUnknownFields(fldPath, existing, new, lcd, narrowExisting)
+			return multierr.Combine(err, lcdForPreserveUnknownFields(fldPath, existing, new, lcd, narrowExisting))
 		}
 	}
 	return field.Invalid(field.NewPath(fldPath.String(), "type"), existing.Type, "Invalid type")
 }
 
+// lcdForValidationRules checks that the new schema has no x-kubernetes-validations rules that the existing
+// schema doesn't have, as their effect cannot be compared. Removed rules are compatible.
+func lcdForValidationRules(fldPath *field.Path, existing, new, lcd *schema.Structural, narrowExisting bool) error {
+	existingRules := sets.NewString()
+	for _, rule := range existing.XValidations {
+		existingRules.Insert(rule.Rule)
+	}
+	var added []string
+	for _, rule := range new.XValidations {
+		if !existingRules.Has(rule.Rule) {
+			added = append(added, rule.Rule)
+			if narrowExisting {
+				lcd.XValidations = append(lcd.XValidations, rule)
+			}
+		}
+	}
+	if len(added) > 0 && !narrowExisting {
+		return field.Invalid(fldPath.Child("x-kubernetes-validations"), added, "validation rules have been added in an incompatible way")
+	}
+	return nil
+}
+
 func lcdForIntegerValidation(fldPath *field.Path, existing, new *schema.ValueValidation, lcd *schema.ValueValidation, narrowExisting bool) error {
 	return checkUnsupportedValidationForNumerics(fldPath, existing, new, "integer")
 }
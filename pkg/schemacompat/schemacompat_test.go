@@ -440,6 +440,47 @@ func TestCompatibility(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		desc: "new has more validation rules",
+		existing: &apiextensionsv1.JSONSchemaProps{
+			Type:         "string",
+			XValidations: apiextensionsv1.ValidationRules{{Rule: "self != ''"}},
+		},
+		new: &apiextensionsv1.JSONSchemaProps{
+			Type:         "string",
+			XValidations: apiextensionsv1.ValidationRules{{Rule: "self != ''"}, {Rule: "self.size() < 10"}},
+		},
+		wantErr: field.Invalid(
+			field.NewPath("schema", "openAPISchema").Child("x-kubernetes-validations"),
+			[]string{"self.size() < 10"},
+			"validation rules have been added in an incompatible way"),
+	}, {
+		desc: "new has more validation rules, narrow existing",
+		existing: &apiextensionsv1.JSONSchemaProps{
+			Type: "string",
+		},
+		new: &apiextensionsv1.JSONSchemaProps{
+			Type:         "string",
+			XValidations: apiextensionsv1.ValidationRules{{Rule: "self.size() < 10"}},
+		},
+		narrowExisting: true,
+		wantLCD: &apiextensionsv1.JSONSchemaProps{
+			Type:         "string",
+			XValidations: apiextensionsv1.ValidationRules{{Rule: "self.size() < 10"}},
+		},
+	}, {
+		desc: "new has fewer validation rules",
+		existing: &apiextensionsv1.JSONSchemaProps{
+			Type:         "string",
+			XValidations: apiextensionsv1.ValidationRules{{Rule: "self != ''"}},
+		},
+		new: &apiextensionsv1.JSONSchemaProps{
+			Type: "string",
+		},
+		wantLCD: &apiextensionsv1.JSONSchemaProps{
+			Type:         "string",
+			XValidations: apiextensionsv1.ValidationRules{{Rule: "self != ''"}},
+		},
 	}} {
 		t.Run(c.desc, func(t *testing.T) {
 			gotLCD, err := EnsureStructuralSchemaCompatibility(field.NewPath("schema", "openAPISchema"), c.existing, c.new, c.narrowExisting)
@@ -74,7 +74,14 @@ func newSystemCRDProvider(
 		rootCRDs: sets.NewString(
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "clusterworkspaces.tenancy.kcp.dev"),
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "clusterworkspacetypes.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "clusterworkspacequotas.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "accessrequests.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "workspacerequestsets.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "workspaceclones.tenancy.kcp.dev"),
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "clusterworkspaceshards.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "workspacemigrations.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "shardupgradeplans.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "namereservations.tenancy.kcp.dev"),
 
 			// the following is installed to get discovery and OpenAPI right. But it is actually
 			// served by a native rest storage, projecting the clusterworkspaces.
@@ -83,6 +90,10 @@ func newSystemCRDProvider(
 		orgCRDs: sets.NewString(
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "clusterworkspaces.tenancy.kcp.dev"),
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "clusterworkspacetypes.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "clusterworkspacequotas.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "accessrequests.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "workspacerequestsets.tenancy.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "workspaceclones.tenancy.kcp.dev"),
 
 			// the following is installed to get discovery and OpenAPI right. But it is actually
 			// served by a native rest storage, projecting the clusterworkspaces.
@@ -92,9 +103,13 @@ func newSystemCRDProvider(
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "apiresourceimports.apiresource.kcp.dev"),
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "negotiatedapiresources.apiresource.kcp.dev"),
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "workloadclusters.workload.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "placements.workload.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "workloadclusterpools.workload.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "placementdecisions.workload.kcp.dev"),
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "apiexports.apis.kcp.dev"),
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "apibindings.apis.kcp.dev"),
 			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "apiresourceschemas.apis.kcp.dev"),
+			clusters.ToClusterAwareKey(SystemCRDLogicalCluster, "maintenancetasks.apis.kcp.dev"),
 		),
 		getClusterWorkspace: getClusterWorkspace,
 		getCRD:              getCRD,
@@ -203,7 +218,7 @@ func (c *apiBindingAwareCRDLister) ListWithContext(ctx context.Context, selector
 		}
 
 		for _, boundResource := range apiBinding.Status.BoundResources {
-			crdKey := clusters.ToClusterAwareKey(apibinding.ShadowWorkspaceName, boundResource.Schema.UID)
+			crdKey := clusters.ToClusterAwareKey(apibinding.ShadowWorkspaceName, apibinding.BoundCRDName(boundResource))
 			crd, err := c.crdLister.GetWithContext(ctx, crdKey)
 			if err != nil {
 				klog.Errorf("Error getting bound CRD %q: %v", crdKey, err)
@@ -269,6 +284,10 @@ func (c *apiBindingAwareCRDLister) GetWithContext(ctx context.Context, name stri
 	var crd *apiextensionsv1.CustomResourceDefinition
 
 	if cluster.Wildcard {
+		if identityHashes, ok := ctx.Value(identityHashContextKey).([]string); ok && len(identityHashes) > 0 {
+			return c.getBoundCRDByIdentity(name, identityHashes)
+		}
+
 		// HACK: Search for the right logical cluster hosting the given CRD when watching or listing with wildcards.
 		// This is a temporary fix for issue https://github.com/kcp-dev/kcp/issues/183: One cannot watch with wildcards
 		// (across logical clusters) if the CRD of the related API Resource hasn't been added in the root logical cluster first.
@@ -304,8 +323,21 @@ func (c *apiBindingAwareCRDLister) GetWithContext(ctx context.Context, name stri
 				return crd, nil
 			}
 		} else {
+			// bound CRDs of different APIExports may share group and resource. They are served by identity,
+			// such that objects of unrelated exports are not mixed with those of CRDs of the workspaces.
+			unbound := make([]*apiextensionsv1.CustomResourceDefinition, 0, len(crds))
+			for _, aCRD := range crds {
+				if logicalcluster.From(aCRD) != apibinding.ShadowWorkspaceName {
+					unbound = append(unbound, aCRD)
+				}
+			}
+
 			var equal bool // true if all the found CRDs have the same spec
-			crd, equal = findCRD(name, crds)
+			crd, equal = findCRD(name, unbound)
+			if crd == nil && equal {
+				// resources only served through APIBindings are served without identity as long as their schemas agree
+				crd, equal = findCRD(name, crds)
+			}
 			if !equal {
 				err = apierrors.NewInternalError(fmt.Errorf("error resolving resource: cannot watch across logical clusters for a resource type with several distinct schemas"))
 				return nil, err
@@ -362,7 +394,7 @@ func (c *apiBindingAwareCRDLister) GetWithContext(ctx context.Context, name stri
 
 		for _, boundResource := range apiBinding.Status.BoundResources {
 			if boundResource.Group == group && boundResource.Resource == resource {
-				crdKey := clusters.ToClusterAwareKey(apibinding.ShadowWorkspaceName, boundResource.Schema.UID)
+				crdKey := clusters.ToClusterAwareKey(apibinding.ShadowWorkspaceName, apibinding.BoundCRDName(boundResource))
 				crd, err = c.crdLister.Get(crdKey)
 				if err != nil && apierrors.IsNotFound(err) {
 					// If we got here, it means there is supposed to be a CRD coming from an APIBinding, but
@@ -392,6 +424,42 @@ func (c *apiBindingAwareCRDLister) GetWithContext(ctx context.Context, name stri
 	return nil, apierrors.NewNotFound(schema.GroupResource{Group: apiextensionsv1.SchemeGroupVersion.Group, Resource: "customresourcedefinitions"}, name)
 }
 
+// getBoundCRDByIdentity returns the bound CRD for name whose APIExport has one of the given identity
+// hashes, looking at the APIBindings of all logical clusters.
+func (c *apiBindingAwareCRDLister) getBoundCRDByIdentity(name string, identityHashes []string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Group: apiextensionsv1.SchemeGroupVersion.Group, Resource: "customresourcedefinitions"}, name)
+	}
+	resource, group := parts[0], parts[1]
+	if group == "core" {
+		group = ""
+	}
+
+	validHashes := sets.NewString(identityHashes...)
+	apiBindings, err := c.apiBindingLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, apiBinding := range apiBindings {
+		for _, boundResource := range apiBinding.Status.BoundResources {
+			if boundResource.Group != group || boundResource.Resource != resource || !validHashes.Has(boundResource.IdentityHash) {
+				continue
+			}
+			crd, err := c.crdLister.Get(clusters.ToClusterAwareKey(apibinding.ShadowWorkspaceName, apibinding.BoundCRDName(boundResource)))
+			if apierrors.IsNotFound(err) {
+				// another binding of the same export might have a synced CRD
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			return crd, nil
+		}
+	}
+
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: apiextensionsv1.SchemeGroupVersion.Group, Resource: "customresourcedefinitions"}, name)
+}
+
 // findCRD tries to locate a CRD named crdName in crds. It returns the located CRD, if any, and a bool
 // indicating that if there were multiple matches, they all have the same spec (true) or not (false).
 func findCRD(crdName string, crds []*apiextensionsv1.CustomResourceDefinition) (*apiextensionsv1.CustomResourceDefinition, bool) {
@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionslisters "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
+)
+
+func TestWildcardCRDLookup(t *testing.T) {
+	boundCRD := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: apibinding.ShadowWorkspaceName.String(), Name: "uid1"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "wildwest.dev",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "cowboys"},
+		},
+	}
+	crdIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, crdIndexer.Add(boundCRD))
+	bindingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, bindingIndexer.Add(&apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: "root:org:ws", Name: "cowboys"},
+		Status: apisv1alpha1.APIBindingStatus{
+			BoundResources: []apisv1alpha1.BoundAPIResource{
+				{Group: "wildwest.dev", Resource: "cowboys", Schema: apisv1alpha1.BoundAPIResourceSchema{UID: "uid1"}, IdentityHash: "abc"},
+			},
+		},
+	}))
+	lister := &apiBindingAwareCRDLister{
+		crdLister:        apiextensionslisters.NewCustomResourceDefinitionLister(crdIndexer),
+		apiBindingLister: apislisters.NewAPIBindingLister(bindingIndexer),
+	}
+
+	tests := map[string]struct {
+		name           string
+		identityHashes []string
+		wantNotFound   bool
+	}{
+		"bound resource by identity": {
+			name:           "cowboys.wildwest.dev",
+			identityHashes: []string{"abc"},
+		},
+		"bound resource without identity": {
+			name: "cowboys.wildwest.dev",
+		},
+		"bound resource with another identity": {
+			name:           "cowboys.wildwest.dev",
+			identityHashes: []string{"other"},
+			wantNotFound:   true,
+		},
+		"name without group with identity": {
+			name:           "cowboys",
+			identityHashes: []string{"abc"},
+			wantNotFound:   true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true})
+			ctx = context.WithValue(ctx, acceptHeaderContextKey, "")
+			if tc.identityHashes != nil {
+				ctx = context.WithValue(ctx, identityHashContextKey, tc.identityHashes)
+			}
+			crd, err := lister.GetWithContext(ctx, tc.name)
+			if tc.wantNotFound {
+				require.True(t, apierrors.IsNotFound(err), "expected NotFound, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, boundCRD.Name, crd.Name)
+		})
+	}
+}
@@ -17,12 +17,15 @@ limitations under the License.
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	_ "net/http/pprof"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
@@ -30,12 +33,16 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/keyutil"
@@ -53,13 +60,36 @@ import (
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	metadataclient "github.com/kcp-dev/kcp/pkg/metadata"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexportidentity"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiresource"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/maintenancetask"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/schemaevolution"
+	"github.com/kcp-dev/kcp/pkg/reconciler/framework"
+	"github.com/kcp-dev/kcp/pkg/reconciler/garbagecollector"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/accessrequest"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/bindingexpiration"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/bootstrap"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspace"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacequota"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspaceshard"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/defaultapibindings"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/groupmapping"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/hibernation"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/lifecyclehooks"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/namereservation"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/shardupgrade"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspaceclone"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspaceexpiration"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspaceindex"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacemigration"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacerequestset"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacesigner"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/drain"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/heartbeat"
 	kcpnamespace "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/poolgenerator"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/syncer"
+	"github.com/kcp-dev/kcp/pkg/sharding"
 )
 
 func (s *Server) installClusterRoleAggregationController(ctx context.Context, config *rest.Config) error {
@@ -279,45 +309,542 @@ func (s *Server) installWorkloadNamespaceScheduler(ctx context.Context, config *
 	if err != nil {
 		return err
 	}
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
 	dynamicClusterClient, err := dynamic.NewClusterForConfig(config)
 	if err != nil {
 		return err
 	}
 
-	// create special client that only gets PartialObjectMetadata objects. For these we can do
-	// wildcard requests with different schemas without risking data loss.
-	metadataClusterClient, err := metadataclient.NewDynamicMetadataClusterClientForConfig(config)
+	// create special client that only gets PartialObjectMetadata objects. For these we can do
+	// wildcard requests with different schemas without risking data loss.
+	metadataClusterClient, err := metadataclient.NewDynamicMetadataClusterClientForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	namespaceScheduler := kcpnamespace.NewController(
+		dynamicClusterClient,
+		metadataClusterClient,
+		kubeClient.DiscoveryClient,
+		kubeClient,
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusters(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusters().Lister(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().Placements(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusterPools(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().PlacementDecisions(),
+		s.kubeSharedInformerFactory.Core().V1().Namespaces(),
+		s.kubeSharedInformerFactory.Core().V1().Namespaces().Lister(),
+		s.options.Extra.DiscoveryPollInterval,
+	)
+
+	s.AddPostStartHook("kcp-install-namespace-scheduler", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-namespace-scheduler: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go namespaceScheduler.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installCrossWorkspaceGarbageCollector(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-cross-workspace-garbage-collector")
+	kubeClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+	dynamicMetadataClusterClient, err := metadataclient.NewDynamicMetadataClusterClientForConfig(config)
+	if err != nil {
+		return err
+	}
+	metadataClusterClient, err := metadata.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := garbagecollector.NewController(
+		dynamicMetadataClusterClient,
+		metadataClusterClient,
+		kubeClient.DiscoveryClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.options.Extra.DiscoveryPollInterval,
+	)
+
+	s.AddPostStartHook("kcp-install-cross-workspace-garbage-collector", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-cross-workspace-garbage-collector: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installClusterWorkspaceQuotaController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-clusterworkspacequota-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+	kubeClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+	metadataClusterClient, err := metadata.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := clusterworkspacequota.NewController(
+		kcpClusterClient,
+		metadataClusterClient,
+		kubeClient.DiscoveryClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceQuotas(),
+		s.options.Extra.DiscoveryPollInterval,
+	)
+	if err != nil {
+		return err
+	}
+
+	s.AddPostStartHook("kcp-install-clusterworkspacequota-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-clusterworkspacequota-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installAccessRequestController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-accessrequest-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := accessrequest.NewController(
+		kcpClusterClient,
+		kubeClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().AccessRequests(),
+	)
+	if err != nil {
+		return err
+	}
+
+	s.AddPostStartHook("kcp-install-accessrequest-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-accessrequest-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installBindingExpirationController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-binding-expiration-controller")
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := bindingexpiration.NewController(
+		kubeClusterClient,
+		s.kubeSharedInformerFactory.Rbac().V1().ClusterRoleBindings(),
+	)
+
+	s.AddPostStartHook("kcp-install-binding-expiration-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-binding-expiration-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installClusterWorkspaceExpirationController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-clusterworkspace-expiration-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := workspaceexpiration.NewController(
+		kcpClusterClient,
+		kubeClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+	)
+
+	s.AddPostStartHook("kcp-install-clusterworkspace-expiration-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-clusterworkspace-expiration-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installWorkspaceSignerController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workspace-signer-controller")
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := workspacesigner.NewController(
+		kubeClusterClient,
+		s.kubeSharedInformerFactory.Certificates().V1().CertificateSigningRequests(),
+		s.kubeSharedInformerFactory.Core().V1().Secrets(),
+		s.options.Controllers.WorkspaceSigner.CertificateDuration,
+	)
+
+	s.AddPostStartHook("kcp-install-workspace-signer-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-workspace-signer-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installClusterWorkspaceLifecycleHooksController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-clusterworkspace-lifecycle-hooks-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := lifecyclehooks.NewController(
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
+	)
+
+	s.AddPostStartHook("kcp-install-clusterworkspace-lifecycle-hooks-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-clusterworkspace-lifecycle-hooks-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installClusterWorkspaceDefaultAPIBindingsController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-clusterworkspace-default-apibindings-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := defaultapibindings.NewController(
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
+		s.kcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+	)
+
+	s.AddPostStartHook("kcp-install-clusterworkspace-default-apibindings-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-clusterworkspace-default-apibindings-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installClusterWorkspaceHibernationController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-clusterworkspace-hibernation-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := hibernation.NewController(
+		s.options.Extra.ShardName,
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.activityTracker,
+		s.options.Controllers.WorkspaceHibernation.IdleTimeout,
+	)
+
+	s.AddPostStartHook("kcp-install-clusterworkspace-hibernation-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-clusterworkspace-hibernation-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installWorkspaceMigrationController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workspace-migration-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	// the objects of the migrated workspaces are copied with the credentials for the peer shards, if any
+	shardConfig := config
+	if s.options.Extra.ShardKubeconfigFile != "" {
+		shardConfig, err = clientcmd.BuildConfigFromFlags("", s.options.Extra.ShardKubeconfigFile)
+		if err != nil {
+			return fmt.Errorf("failed to load shard kubeconfig %q: %w", s.options.Extra.ShardKubeconfigFile, err)
+		}
+		shardConfig = rest.AddUserAgent(shardConfig, "kcp-workspace-migration-controller")
+	}
+
+	c := workspacemigration.NewController(
+		shardConfig,
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceMigrations(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+	)
+
+	s.AddPostStartHook("kcp-install-workspace-migration-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-workspace-migration-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installShardUpgradeController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-shard-upgrade-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := shardupgrade.NewController(
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ShardUpgradePlans(),
+		s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceMigrations(),
+	)
+
+	s.AddPostStartHook("kcp-install-shard-upgrade-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-shard-upgrade-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		// a single worker, such that plans don't start upgrading concurrently
+		go c.Start(ctx, 1)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installWorkspaceRequestSetController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workspace-request-set-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := workspacerequestset.NewController(
+		config,
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceRequestSets(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+	)
+
+	s.AddPostStartHook("kcp-install-workspace-request-set-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-workspace-request-set-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installWorkspaceCloneController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workspace-clone-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := workspaceclone.NewController(
+		config,
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().WorkspaceClones(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+	)
+
+	s.AddPostStartHook("kcp-install-workspace-clone-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-workspace-clone-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installWorkspaceIndexCheckController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workspace-index-check-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
 	if err != nil {
 		return err
 	}
 
-	namespaceScheduler := kcpnamespace.NewController(
-		dynamicClusterClient,
-		metadataClusterClient,
-		kubeClient.DiscoveryClient,
-		kubeClient,
+	// the contents of the shards are looked into with the credentials for the peer shards, if any
+	shardConfig := config
+	if s.options.Extra.ShardKubeconfigFile != "" {
+		shardConfig, err = clientcmd.BuildConfigFromFlags("", s.options.Extra.ShardKubeconfigFile)
+		if err != nil {
+			return fmt.Errorf("failed to load shard kubeconfig %q: %w", s.options.Extra.ShardKubeconfigFile, err)
+		}
+		shardConfig = rest.AddUserAgent(shardConfig, "kcp-workspace-index-check-controller")
+	}
+
+	c := workspaceindex.NewController(
+		workspaceindex.NewChecker(func(shard *tenancyv1alpha1.ClusterWorkspaceShard) *rest.Config {
+			return sharding.ShardReadConfig(shard, shardConfig)
+		}, kcpClusterClient),
 		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
-		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusters(),
-		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusters().Lister(),
-		s.kubeSharedInformerFactory.Core().V1().Namespaces(),
-		s.kubeSharedInformerFactory.Core().V1().Namespaces().Lister(),
-		s.options.Extra.DiscoveryPollInterval,
+		s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		s.options.Controllers.WorkspaceIndex.CheckPeriod,
+		s.options.Controllers.WorkspaceIndex.Repair,
 	)
 
-	s.AddPostStartHook("kcp-install-namespace-scheduler", func(hookContext genericapiserver.PostStartHookContext) error {
+	s.AddPostStartHook("kcp-install-workspace-index-check-controller", func(hookContext genericapiserver.PostStartHookContext) error {
 		if err := s.waitForSync(hookContext.StopCh); err != nil {
-			klog.Errorf("failed to finish post-start-hook kcp-install-namespace-scheduler: %v", err)
+			klog.Errorf("failed to finish post-start-hook kcp-install-workspace-index-check-controller: %v", err)
 			// nolint:nilerr
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
 		}
 
-		go namespaceScheduler.Start(ctx, 2)
+		go c.Start(ctx)
+		return nil
+	})
+	return nil
+}
+
+// installNameReservationCleanupController deletes the NameReservations of the workspaces of this
+// shard whose owner does not exist, through the client of the root workspace used by admission.
+func (s *Server) installNameReservationCleanupController(ctx context.Context) error {
+	c := namereservation.NewController(
+		s.options.Extra.ShardName,
+		s.rootKcpClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
+		s.kcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+	)
+
+	s.AddPostStartHook("kcp-install-name-reservation-cleanup-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-name-reservation-cleanup-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx)
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installWorkspaceGroupMappingController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workspace-group-mapping-controller")
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	mappings, err := groupmapping.LoadMappings(s.options.Controllers.WorkspaceGroupMapping.MappingFile)
+	if err != nil {
+		return err
+	}
+
+	c := groupmapping.NewController(
+		mappings,
+		kubeClusterClient,
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.kubeSharedInformerFactory.Rbac().V1().ClusterRoles(),
+		s.kubeSharedInformerFactory.Rbac().V1().ClusterRoleBindings(),
+	)
+
+	s.AddPostStartHook("kcp-install-workspace-group-mapping-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-workspace-group-mapping-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
 		return nil
 	})
 	return nil
 }
 
-func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Config) error {
+func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Config, servingCert dynamiccertificates.CertKeyContentProvider) error {
 	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workspace-scheduler")
 	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
 	if err != nil {
@@ -338,14 +865,58 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Con
 		kcpClusterClient,
 		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
 		s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		s.options.Controllers.WorkspaceScheduler.Plugins,
 	)
 	if err != nil {
 		return err
 	}
 
+	namespaceInformer := s.kubeSharedInformerFactory.Core().V1().Namespaces().Informer()
+	clusterRoleBindingInformer := s.kubeSharedInformerFactory.Rbac().V1().ClusterRoleBindings().Informer()
+	crdLister := s.apiextensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Lister()
 	workspaceShardController, err := clusterworkspaceshard.NewController(
 		kcpClusterClient.Cluster(tenancyv1alpha1.RootCluster),
 		s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		s.options.Extra.ShardName,
+		func() ([]byte, error) {
+			return servingCABundle(servingCert)
+		},
+		func() (int, error) {
+			// like for the workspace index, namespaces or cluster role bindings mean objects of a workspace on this shard
+			clusterNames := sets.NewString()
+			for _, obj := range append(namespaceInformer.GetStore().List(), clusterRoleBindingInformer.GetStore().List()...) {
+				if o, ok := obj.(metav1.Object); ok && !strings.HasPrefix(logicalcluster.From(o).String(), "system:") {
+					clusterNames.Insert(logicalcluster.From(o).String())
+				}
+			}
+			return clusterNames.Len(), nil
+		},
+		func() ([]string, error) {
+			crds, err := crdLister.List(labels.Everything())
+			if err != nil {
+				return nil, err
+			}
+			groups := make([]string, 0, len(crds))
+			for _, crd := range crds {
+				groups = append(groups, crd.Spec.Group)
+			}
+			return groups, nil
+		},
+		func() ([]byte, error) {
+			// the root shard trusts itself, and the CAs of the shards configured to join
+			caBundle, err := servingCABundle(servingCert)
+			if err != nil {
+				return nil, err
+			}
+			if caFile := s.options.Extra.ShardServingCAFile; caFile != "" {
+				shardCABundle, err := os.ReadFile(caFile)
+				if err != nil {
+					return nil, err
+				}
+				caBundle = append(append(caBundle, '\n'), shardCABundle...)
+			}
+			return caBundle, nil
+		},
 	)
 	if err != nil {
 		return err
@@ -395,7 +966,7 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Con
 		}
 
 		go workspaceController.Start(ctx, 2)
-		go workspaceShardController.Start(ctx, 2)
+		s.startController(ctx, workspaceShardController.Start, 2)
 		go organizationController.Start(ctx, 2)
 		go teamController.Start(ctx, 2)
 		go universalController.Start(ctx, 2)
@@ -405,6 +976,78 @@ func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Con
 	return nil
 }
 
+// shardSpec returns the URLs of this shard as configured by the --shard-*-url flags, with
+// the unset ones defaulted from the external address of the server.
+func (s *Server) shardSpec(externalAddress string) tenancyv1alpha1.ClusterWorkspaceShardSpec {
+	spec := tenancyv1alpha1.ClusterWorkspaceShardSpec{
+		BaseURL:                     s.options.Extra.ShardBaseURL,
+		ExternalURL:                 s.options.Extra.ShardExternalURL,
+		VirtualWorkspaceURL:         s.options.Extra.ShardVirtualWorkspaceURL,
+		ExternalVirtualWorkspaceURL: s.options.Extra.ShardExternalVirtualWorkspaceURL,
+	}
+	if spec.BaseURL == "" {
+		spec.BaseURL = "https://" + externalAddress
+	}
+	if spec.ExternalURL == "" {
+		spec.ExternalURL = spec.BaseURL
+	}
+	if spec.VirtualWorkspaceURL == "" && !s.options.Virtual.Enabled {
+		spec.VirtualWorkspaceURL = s.options.Virtual.ExternalVirtualWorkspaceAddress
+	}
+	return spec
+}
+
+// servingCABundle returns the CA certificates of the serving certificate chain, i.e. the CA
+// clients of the shard have to trust. A self-signed serving certificate is its own CA, and the
+// certificate generated without --tls-cert-file is bundled with the CA which signed it.
+func servingCABundle(servingCert dynamiccertificates.CertKeyContentProvider) ([]byte, error) {
+	chain, _ := servingCert.CurrentCertKeyContent()
+	certs, err := certutil.ParseCertsPEM(chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the serving certificate: %w", err)
+	}
+	var cas []*x509.Certificate
+	for _, cert := range certs {
+		if cert.IsCA || bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			cas = append(cas, cert)
+		}
+	}
+	if len(cas) == 0 {
+		return nil, fmt.Errorf("the serving certificate is not bundled with the CA which signed it, append it to --tls-cert-file")
+	}
+	return certutil.EncodeCertificates(cas...)
+}
+
+// installShardRegistration registers a non-root shard as ClusterWorkspaceShard in the root shard,
+// using the credentials of --root-shard-kubeconfig-file.
+func (s *Server) installShardRegistration(ctx context.Context, externalAddress string) error {
+	config, err := clientcmd.BuildConfigFromFlags("", s.options.Extra.RootShardKubeconfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load root shard kubeconfig %q: %w", s.options.Extra.RootShardKubeconfigFile, err)
+	}
+	config = rest.AddUserAgent(sharding.WithShardIdentity(config, s.options.Extra.ShardName, &s.shardFencingToken), "kcp-clusterworkspaceshard-registrar")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	registrar := clusterworkspaceshard.NewRegistrar(
+		kcpClusterClient.Cluster(tenancyv1alpha1.RootCluster),
+		s.options.Extra.ShardName,
+		func() (tenancyv1alpha1.ClusterWorkspaceShardSpec, error) {
+			return s.shardSpec(externalAddress), nil
+		},
+		&s.shardFencingToken,
+	)
+
+	// the root shard verifies the identity of the shard by connecting to it, hence register once serving
+	s.AddPostStartHook("kcp-install-clusterworkspaceshard-registrar", func(hookContext genericapiserver.PostStartHookContext) error {
+		go registrar.Start(ctx)
+		return nil
+	})
+	return nil
+}
+
 func (s *Server) installApiResourceController(ctx context.Context, config *rest.Config) error {
 	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-api-resource-controller")
 	crdClusterClient, err := apiextensionsclient.NewClusterForConfig(config)
@@ -420,9 +1063,11 @@ func (s *Server) installApiResourceController(ctx context.Context, config *rest.
 		crdClusterClient,
 		kcpClusterClient,
 		s.options.Controllers.ApiResource.AutoPublishAPIs,
+		s.options.Controllers.ApiResource.OrphanRetention,
 		s.kcpSharedInformerFactory.Apiresource().V1alpha1().NegotiatedAPIResources(),
 		s.kcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
 		s.apiextensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusters(),
 	)
 	if err != nil {
 		return err
@@ -471,6 +1116,10 @@ func (s *Server) installWorkloadSyncerController(ctx context.Context, config *re
 	if err != nil {
 		return err
 	}
+	if s.options.Controllers.Syncer.PullMode {
+		// pull-mode syncers follow when the URL of this shard changes
+		c.FollowShard(s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(), s.options.Extra.ShardName)
+	}
 
 	s.AddPostStartHook("kcp-install-syncer-controller", func(hookContext genericapiserver.PostStartHookContext) error {
 		if err := s.waitForSync(hookContext.StopCh); err != nil {
@@ -493,11 +1142,16 @@ func (s *Server) installWorkloadClusterHeartbeatController(ctx context.Context,
 		return err
 	}
 
+	heartbeatThreshold := func() time.Duration { return s.options.Controllers.WorkloadClusterHeartbeat.HeartbeatThreshold }
+	if s.optionsReloader != nil {
+		heartbeatThreshold = s.optionsReloader.WorkloadClusterHeartbeatThreshold
+	}
+
 	c, err := heartbeat.NewController(
 		kcpClusterClient,
 		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusters(),
 		s.kcpSharedInformerFactory.Apiresource().V1alpha1().APIResourceImports(),
-		s.options.Controllers.WorkloadClusterHeartbeat.HeartbeatThreshold,
+		heartbeatThreshold,
 	)
 	if err != nil {
 		return err
@@ -518,6 +1172,62 @@ func (s *Server) installWorkloadClusterHeartbeatController(ctx context.Context,
 
 }
 
+func (s *Server) installWorkloadClusterDrainController(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workloadcluster-drain-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := drain.NewController(
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusters(),
+		s.kubeSharedInformerFactory.Core().V1().Namespaces(),
+		s.options.Controllers.WorkloadClusterDrain.DrainTimeout,
+	)
+
+	s.AddPostStartHook("kcp-install-workloadcluster-drain-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-workloadcluster-drain-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+
+		return nil
+	})
+	return nil
+}
+
+func (s *Server) installWorkloadClusterPoolGenerator(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-workloadclusterpool-generator")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := poolgenerator.NewController(
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusters(),
+		s.kcpSharedInformerFactory.Workload().V1alpha1().WorkloadClusterPools(),
+		s.options.Controllers.WorkloadClusterPools.GroupingLabel,
+	)
+
+	s.AddPostStartHook("kcp-install-workloadclusterpool-generator", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-workloadclusterpool-generator: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(ctx, 2)
+
+		return nil
+	})
+	return nil
+}
+
 func (s *Server) installAPIBindingController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
 	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-apibinding-controller")
 	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
@@ -530,13 +1240,21 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 		return err
 	}
 
+	dynamicClusterClient, err := dynamic.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
 	c, err := apibinding.NewController(
 		crdClusterClient,
 		kcpClusterClient,
+		dynamicClusterClient,
 		s.kcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
 		s.kcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
 		s.kcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas(),
 		s.apiextensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
 	)
 	if err != nil {
 		return err
@@ -559,6 +1277,109 @@ func (s *Server) installAPIBindingController(ctx context.Context, config *rest.C
 	return nil
 }
 
+func (s *Server) installAPIResourceSchemaEvolutionController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-apiresourceschema-evolution-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := schemaevolution.NewController(
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.kcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := server.AddPostStartHook("kcp-install-apiresourceschema-evolution-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-apiresourceschema-evolution-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), 2)
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) installAPIExportIdentityController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-apiexport-identity-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+	kubeClusterClient, err := kubernetes.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := apiexportidentity.NewController(
+		kcpClusterClient,
+		kubeClusterClient,
+		s.kcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+		s.kcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.kubeSharedInformerFactory.Core().V1().Secrets(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := server.AddPostStartHook("kcp-install-apiexport-identity-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-apiexport-identity-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), 2)
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) installMaintenanceTaskController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-maintenancetask-controller")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c := maintenancetask.NewController(
+		kcpClusterClient,
+		s.kcpSharedInformerFactory.Apis().V1alpha1().MaintenanceTasks(),
+		s.kcpSharedInformerFactory.Apis().V1alpha1().APIBindings(),
+		s.kcpSharedInformerFactory.Apis().V1alpha1().APIExports(),
+	)
+
+	if err := server.AddPostStartHook("kcp-install-maintenancetask-controller", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-maintenancetask-controller: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(goContext(hookContext), 2)
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Server) waitForSync(stop <-chan struct{}) error {
 	// Wait for shared informer factories to by synced.
 	// factory. Otherwise, informer list calls may go into backoff (before the CRDs are ready) and
@@ -570,3 +1391,33 @@ func (s *Server) waitForSync(stop <-chan struct{}) error {
 		return nil
 	}
 }
+
+// startController runs a controller built on the reconciler framework, and lets the server wait for
+// its in-flight reconciles to complete on shutdown.
+func (s *Server) startController(ctx context.Context, start func(ctx context.Context, numThreads int), numThreads int) {
+	if ctx.Err() != nil {
+		return
+	}
+	s.controllers.Add(1)
+	go func() {
+		defer s.controllers.Done()
+		start(ctx, numThreads)
+	}()
+}
+
+// waitForControllers waits for the controllers started with startController to shut down.
+func (s *Server) waitForControllers() error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.controllers.Wait()
+	}()
+
+	// the controllers cancel their in-flight reconciles after framework.ShutdownTimeout
+	select {
+	case <-done:
+		return nil
+	case <-time.After(framework.ShutdownTimeout + 5*time.Second):
+		return errors.New("timed out waiting for the controllers to shut down")
+	}
+}
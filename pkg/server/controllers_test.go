@@ -0,0 +1,50 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func TestServingCABundle(t *testing.T) {
+	// the generated serving certificate is bundled with the CA which signed it
+	chain, key, err := certutil.GenerateSelfSignedCertKey("localhost", nil, nil)
+	require.NoError(t, err)
+	certs, err := certutil.ParseCertsPEM(chain)
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	leaf, err := certutil.EncodeCertificates(certs[0])
+	require.NoError(t, err)
+	ca, err := certutil.EncodeCertificates(certs[1])
+	require.NoError(t, err)
+
+	provider, err := dynamiccertificates.NewStaticCertKeyContent("serving", chain, key)
+	require.NoError(t, err)
+	bundle, err := servingCABundle(provider)
+	require.NoError(t, err)
+	require.Equal(t, string(ca), string(bundle), "expected only the CA of the chain")
+
+	provider, err = dynamiccertificates.NewStaticCertKeyContent("serving", leaf, key)
+	require.NoError(t, err)
+	_, err = servingCABundle(provider)
+	require.Error(t, err, "expected an error for a chain without CA")
+}
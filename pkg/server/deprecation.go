@@ -0,0 +1,255 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/warning"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"sigs.k8s.io/yaml"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+const (
+	indexAPIBindingsByWorkspace = "deprecationWarnings-apiBindingsByWorkspace"
+
+	// maxWorkspaceTypeBodyBytes is the maximal size of ClusterWorkspace creation bodies inspected for the type.
+	maxWorkspaceTypeBodyBytes = 64 << 10
+)
+
+// DeprecationWarner finds the deprecations touched by requests: deprecated ClusterWorkspaceTypes,
+// sunsetting APIExports, and APIBindings whose schemas are older than the latest of their APIExport.
+type DeprecationWarner struct {
+	workspaceLister         tenancylisters.ClusterWorkspaceLister
+	workspaceTypeLister     tenancylisters.ClusterWorkspaceTypeLister
+	apiBindingIndexer       cache.Indexer
+	apiExportLister         apislisters.APIExportLister
+	apiResourceSchemaLister apislisters.APIResourceSchemaLister
+}
+
+func NewDeprecationWarner(kcpSharedInformerFactory kcpinformers.SharedInformerFactory) (*DeprecationWarner, error) {
+	apiBindingInformer := kcpSharedInformerFactory.Apis().V1alpha1().APIBindings().Informer()
+	if err := apiBindingInformer.AddIndexers(cache.Indexers{
+		indexAPIBindingsByWorkspace: indexAPIBindingByWorkspace,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &DeprecationWarner{
+		workspaceLister:         kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces().Lister(),
+		workspaceTypeLister:     kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes().Lister(),
+		apiBindingIndexer:       apiBindingInformer.GetIndexer(),
+		apiExportLister:         kcpSharedInformerFactory.Apis().V1alpha1().APIExports().Lister(),
+		apiResourceSchemaLister: kcpSharedInformerFactory.Apis().V1alpha1().APIResourceSchemas().Lister(),
+	}, nil
+}
+
+func indexAPIBindingByWorkspace(obj interface{}) ([]string, error) {
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be an APIBinding, but is %T", obj)
+	}
+	return []string{logicalcluster.From(apiBinding).String()}, nil
+}
+
+// WithDeprecationWarnings adds warning headers to the responses of requests touching deprecations,
+// such that users see them in the output of kubectl:
+// - requests to deprecated ClusterWorkspaceTypes, and to ClusterWorkspaces of such a type, including their creation,
+// - requests to resources bound from a sunsetting APIExport, and to their APIBindings,
+// - requests to resources bound with an older APIResourceSchema than the latest of their APIExport.
+func WithDeprecationWarnings(apiHandler http.Handler, warner *DeprecationWarner) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		info, ok := request.RequestInfoFrom(req.Context())
+		if !ok || !info.IsResourceRequest || cluster == nil || cluster.Wildcard || cluster.Name.Empty() {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		for _, msg := range warner.warnings(req, cluster.Name, info) {
+			warning.AddWarning(req.Context(), "", msg)
+		}
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
+func (d *DeprecationWarner) warnings(req *http.Request, cluster logicalcluster.LogicalCluster, info *request.RequestInfo) []string {
+	var warnings []string
+
+	if info.APIGroup == tenancyv1alpha1.SchemeGroupVersion.Group {
+		switch {
+		case info.Resource == "clusterworkspacetypes" && info.Name != "":
+			if msg, deprecated := d.workspaceTypeDeprecation(cluster, info.Name); deprecated {
+				warnings = append(warnings, msg)
+			}
+		case info.Resource == "clusterworkspaces" && info.Verb == "create" && info.Subresource == "":
+			if workspaceType, ok := workspaceTypeFromBody(req); ok {
+				if msg, deprecated := d.workspaceTypeDeprecation(cluster, workspaceType); deprecated {
+					warnings = append(warnings, msg)
+				}
+			}
+		case info.Resource == "clusterworkspaces" && info.Name != "":
+			if workspace, err := d.workspaceLister.Get(clusters.ToClusterAwareKey(cluster, info.Name)); err == nil {
+				if msg, deprecated := d.workspaceTypeDeprecation(cluster, workspace.Spec.Type); deprecated {
+					warnings = append(warnings, msg)
+				}
+			}
+		}
+	}
+
+	objs, err := d.apiBindingIndexer.ByIndex(indexAPIBindingsByWorkspace, cluster.String())
+	if err != nil {
+		return warnings
+	}
+	for _, obj := range objs {
+		binding := obj.(*apisv1alpha1.APIBinding)
+		isBinding := info.APIGroup == apisv1alpha1.SchemeGroupVersion.Group && info.Resource == "apibindings" && info.Name == binding.Name
+
+		var boundResource *apisv1alpha1.BoundAPIResource
+		for i := range binding.Status.BoundResources {
+			if br := &binding.Status.BoundResources[i]; br.Group == info.APIGroup && br.Resource == info.Resource {
+				boundResource = br
+				break
+			}
+		}
+		if !isBinding && boundResource == nil {
+			continue
+		}
+
+		exportCluster, exportName, ok := boundExport(binding)
+		if !ok {
+			continue
+		}
+		export, err := d.apiExportLister.Get(clusters.ToClusterAwareKey(exportCluster, exportName))
+		if err != nil {
+			continue
+		}
+		if export.Spec.Sunset != nil {
+			warnings = append(warnings, sunsetWarning(export))
+		}
+		if boundResource != nil {
+			if latest, outdated := d.latestSchema(export, boundResource); outdated {
+				warnings = append(warnings, fmt.Sprintf("%s is bound with APIResourceSchema %s, but APIExport %s|%s provides the newer %s",
+					schema.GroupResource{Group: boundResource.Group, Resource: boundResource.Resource}, boundResource.Schema.Name, exportCluster, exportName, latest))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// workspaceTypeDeprecation returns the warning for the ClusterWorkspaceType of the given name
+// in the cluster, if it is deprecated.
+func (d *DeprecationWarner) workspaceTypeDeprecation(cluster logicalcluster.LogicalCluster, name string) (string, bool) {
+	if name == "" {
+		name = "Universal"
+	}
+	workspaceType, err := d.workspaceTypeLister.Get(clusters.ToClusterAwareKey(cluster, strings.ToLower(name)))
+	if err != nil || !workspaceType.Spec.Deprecated {
+		return "", false
+	}
+	if workspaceType.Spec.DeprecationWarning != nil {
+		return *workspaceType.Spec.DeprecationWarning, true
+	}
+	return fmt.Sprintf("ClusterWorkspaceType %s|%s is deprecated", cluster, workspaceType.Name), true
+}
+
+// latestSchema returns the name of the latest APIResourceSchema of the export for the bound resource,
+// if it differs from the bound one.
+func (d *DeprecationWarner) latestSchema(export *apisv1alpha1.APIExport, boundResource *apisv1alpha1.BoundAPIResource) (string, bool) {
+	for _, name := range export.Spec.LatestResourceSchemas {
+		latest, err := d.apiResourceSchemaLister.Get(clusters.ToClusterAwareKey(logicalcluster.From(export), name))
+		if err != nil {
+			continue
+		}
+		if latest.Spec.Group == boundResource.Group && latest.Spec.Names.Plural == boundResource.Resource {
+			return name, name != boundResource.Schema.Name
+		}
+	}
+	return "", false
+}
+
+func sunsetWarning(export *apisv1alpha1.APIExport) string {
+	msg := fmt.Sprintf("APIExport %s|%s is sunsetting", logicalcluster.From(export), export.Name)
+	if export.Spec.Sunset.Time != nil {
+		msg += " on " + export.Spec.Sunset.Time.UTC().Format(time.RFC3339)
+	}
+	if export.Spec.Sunset.Message != "" {
+		msg += ": " + export.Spec.Sunset.Message
+	}
+	return msg
+}
+
+// boundExport returns the workspace and the name of the APIExport of the binding.
+func boundExport(binding *apisv1alpha1.APIBinding) (logicalcluster.LogicalCluster, string, bool) {
+	reference := binding.Spec.Reference
+	if binding.Status.BoundAPIExport != nil {
+		reference = *binding.Status.BoundAPIExport
+	}
+	if reference.Workspace == nil {
+		return logicalcluster.LogicalCluster{}, "", false
+	}
+	parent, hasParent := logicalcluster.From(binding).Parent()
+	if !hasParent {
+		return logicalcluster.LogicalCluster{}, "", false
+	}
+	return parent.Join(reference.Workspace.WorkspaceName), reference.Workspace.ExportName, true
+}
+
+// workspaceTypeFromBody returns the type of the ClusterWorkspace in the body of a create request,
+// leaving the body intact for the next handlers.
+func workspaceTypeFromBody(req *http.Request) (string, bool) {
+	if req.Body == nil {
+		return "", false
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxWorkspaceTypeBodyBytes))
+	req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), req.Body), Closer: req.Body}
+	if err != nil || len(body) == maxWorkspaceTypeBodyBytes {
+		return "", false
+	}
+
+	var workspace struct {
+		Spec struct {
+			Type string `json:"type"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal(body, &workspace); err != nil {
+		return "", false
+	}
+	return workspace.Spec.Type, true
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/warning"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	apislisters "github.com/kcp-dev/kcp/pkg/client/listers/apis/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+type warningRecorder []string
+
+func (r *warningRecorder) AddWarning(agent, text string) {
+	*r = append(*r, text)
+}
+
+func TestWithDeprecationWarnings(t *testing.T) {
+	customWarning := "use the Team type instead"
+	sunset := metav1.NewTime(time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	workspaceIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	typeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	bindingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{indexAPIBindingsByWorkspace: indexAPIBindingByWorkspace})
+	exportIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	schemaIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+	for _, obj := range []interface{}{
+		&tenancyv1alpha1.ClusterWorkspaceType{
+			ObjectMeta: metav1.ObjectMeta{Name: "legacy", ClusterName: "root:org"},
+			Spec:       tenancyv1alpha1.ClusterWorkspaceTypeSpec{Deprecated: true},
+		},
+		&tenancyv1alpha1.ClusterWorkspaceType{
+			ObjectMeta: metav1.ObjectMeta{Name: "old", ClusterName: "root:org"},
+			Spec:       tenancyv1alpha1.ClusterWorkspaceTypeSpec{Deprecated: true, DeprecationWarning: &customWarning},
+		},
+		&tenancyv1alpha1.ClusterWorkspaceType{
+			ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"},
+		},
+	} {
+		require.NoError(t, typeIndexer.Add(obj))
+	}
+	for _, obj := range []interface{}{
+		&tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "a", ClusterName: "root:org"}, Spec: tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Legacy"}},
+		&tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "b", ClusterName: "root:org"}, Spec: tenancyv1alpha1.ClusterWorkspaceSpec{Type: "Team"}},
+	} {
+		require.NoError(t, workspaceIndexer.Add(obj))
+	}
+	for _, obj := range []interface{}{
+		&apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "cowboys", ClusterName: "root:org:provider"},
+			Spec:       apisv1alpha1.APIExportSpec{LatestResourceSchemas: []string{"v2.cowboys.wildwest.dev"}},
+		},
+		&apisv1alpha1.APIExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "horses", ClusterName: "root:org:provider"},
+			Spec: apisv1alpha1.APIExportSpec{
+				LatestResourceSchemas: []string{"v1.horses.wildwest.dev"},
+				Sunset:                &apisv1alpha1.APIExportSunset{Time: &sunset, Message: "use cowboys instead"},
+			},
+		},
+	} {
+		require.NoError(t, exportIndexer.Add(obj))
+	}
+	for _, obj := range []interface{}{
+		&apisv1alpha1.APIResourceSchema{
+			ObjectMeta: metav1.ObjectMeta{Name: "v2.cowboys.wildwest.dev", ClusterName: "root:org:provider"},
+			Spec:       apisv1alpha1.APIResourceSchemaSpec{Group: "wildwest.dev", Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "cowboys"}},
+		},
+		&apisv1alpha1.APIResourceSchema{
+			ObjectMeta: metav1.ObjectMeta{Name: "v1.horses.wildwest.dev", ClusterName: "root:org:provider"},
+			Spec:       apisv1alpha1.APIResourceSchemaSpec{Group: "wildwest.dev", Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "horses"}},
+		},
+	} {
+		require.NoError(t, schemaIndexer.Add(obj))
+	}
+	for _, obj := range []interface{}{
+		&apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "cowboys", ClusterName: "root:org:consumer"},
+			Spec: apisv1alpha1.APIBindingSpec{Reference: apisv1alpha1.ExportReference{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{WorkspaceName: "provider", ExportName: "cowboys"},
+			}},
+			Status: apisv1alpha1.APIBindingStatus{BoundResources: []apisv1alpha1.BoundAPIResource{
+				{Group: "wildwest.dev", Resource: "cowboys", Schema: apisv1alpha1.BoundAPIResourceSchema{Name: "v1.cowboys.wildwest.dev"}},
+			}},
+		},
+		&apisv1alpha1.APIBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "horses", ClusterName: "root:org:consumer"},
+			Spec: apisv1alpha1.APIBindingSpec{Reference: apisv1alpha1.ExportReference{
+				Workspace: &apisv1alpha1.WorkspaceExportReference{WorkspaceName: "provider", ExportName: "horses"},
+			}},
+			Status: apisv1alpha1.APIBindingStatus{BoundResources: []apisv1alpha1.BoundAPIResource{
+				{Group: "wildwest.dev", Resource: "horses", Schema: apisv1alpha1.BoundAPIResourceSchema{Name: "v1.horses.wildwest.dev"}},
+			}},
+		},
+	} {
+		require.NoError(t, bindingIndexer.Add(obj))
+	}
+
+	warner := &DeprecationWarner{
+		workspaceLister:         tenancylisters.NewClusterWorkspaceLister(workspaceIndexer),
+		workspaceTypeLister:     tenancylisters.NewClusterWorkspaceTypeLister(typeIndexer),
+		apiBindingIndexer:       bindingIndexer,
+		apiExportLister:         apislisters.NewAPIExportLister(exportIndexer),
+		apiResourceSchemaLister: apislisters.NewAPIResourceSchemaLister(schemaIndexer),
+	}
+
+	tests := map[string]struct {
+		cluster      string
+		info         request.RequestInfo
+		body         string
+		wantWarnings []string
+	}{
+		"deprecated type": {
+			cluster:      "root:org",
+			info:         request.RequestInfo{Verb: "get", APIGroup: "tenancy.kcp.dev", Resource: "clusterworkspacetypes", Name: "legacy"},
+			wantWarnings: []string{"ClusterWorkspaceType root:org|legacy is deprecated"},
+		},
+		"deprecated type with custom warning": {
+			cluster:      "root:org",
+			info:         request.RequestInfo{Verb: "update", APIGroup: "tenancy.kcp.dev", Resource: "clusterworkspacetypes", Name: "old"},
+			wantWarnings: []string{customWarning},
+		},
+		"type": {
+			cluster: "root:org",
+			info:    request.RequestInfo{Verb: "get", APIGroup: "tenancy.kcp.dev", Resource: "clusterworkspacetypes", Name: "team"},
+		},
+		"workspace of deprecated type": {
+			cluster:      "root:org",
+			info:         request.RequestInfo{Verb: "get", APIGroup: "tenancy.kcp.dev", Resource: "clusterworkspaces", Name: "a"},
+			wantWarnings: []string{"ClusterWorkspaceType root:org|legacy is deprecated"},
+		},
+		"workspace": {
+			cluster: "root:org",
+			info:    request.RequestInfo{Verb: "get", APIGroup: "tenancy.kcp.dev", Resource: "clusterworkspaces", Name: "b"},
+		},
+		"creation of workspace of deprecated type": {
+			cluster:      "root:org",
+			info:         request.RequestInfo{Verb: "create", APIGroup: "tenancy.kcp.dev", Resource: "clusterworkspaces"},
+			body:         `{"apiVersion":"tenancy.kcp.dev/v1alpha1","kind":"ClusterWorkspace","metadata":{"name":"c"},"spec":{"type":"Old"}}`,
+			wantWarnings: []string{customWarning},
+		},
+		"creation of workspace": {
+			cluster: "root:org",
+			info:    request.RequestInfo{Verb: "create", APIGroup: "tenancy.kcp.dev", Resource: "clusterworkspaces"},
+			body:    `{"apiVersion":"tenancy.kcp.dev/v1alpha1","kind":"ClusterWorkspace","metadata":{"name":"c"},"spec":{"type":"Team"}}`,
+		},
+		"outdated schema": {
+			cluster:      "root:org:consumer",
+			info:         request.RequestInfo{Verb: "list", APIGroup: "wildwest.dev", Resource: "cowboys"},
+			wantWarnings: []string{"cowboys.wildwest.dev is bound with APIResourceSchema v1.cowboys.wildwest.dev, but APIExport root:org:provider|cowboys provides the newer v2.cowboys.wildwest.dev"},
+		},
+		"sunsetting export": {
+			cluster:      "root:org:consumer",
+			info:         request.RequestInfo{Verb: "get", APIGroup: "wildwest.dev", Resource: "horses", Name: "bella"},
+			wantWarnings: []string{"APIExport root:org:provider|horses is sunsetting on 2022-06-01T00:00:00Z: use cowboys instead"},
+		},
+		"binding of sunsetting export": {
+			cluster:      "root:org:consumer",
+			info:         request.RequestInfo{Verb: "get", APIGroup: "apis.kcp.dev", Resource: "apibindings", Name: "horses"},
+			wantWarnings: []string{"APIExport root:org:provider|horses is sunsetting on 2022-06-01T00:00:00Z: use cowboys instead"},
+		},
+		"unbound resource": {
+			cluster: "root:org:consumer",
+			info:    request.RequestInfo{Verb: "list", Resource: "configmaps"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var body string
+			handler := WithDeprecationWarnings(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				bs, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				body = string(bs)
+			}), warner)
+
+			req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader(tc.body))
+			recorder := new(warningRecorder)
+			tc.info.IsResourceRequest = true
+			ctx := request.WithCluster(req.Context(), request.Cluster{Name: logicalcluster.New(tc.cluster)})
+			ctx = request.WithRequestInfo(ctx, &tc.info)
+			ctx = warning.WithWarningRecorder(ctx, recorder)
+			handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+			require.Equal(t, tc.wantWarnings, []string(*recorder))
+			require.Equal(t, tc.body, body, "body must be passed on")
+		})
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	configdevconsumer "github.com/kcp-dev/kcp/config/dev/consumer"
+	configdevdemo "github.com/kcp-dev/kcp/config/dev/demo"
+	configdevprovider "github.com/kcp-dev/kcp/config/dev/provider"
+	configdevroot "github.com/kcp-dev/kcp/config/dev/root"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/syncer"
+)
+
+var (
+	// DevOrganization is the organization seeded by --dev.
+	DevOrganization = tenancyv1alpha1.RootCluster.Join("demo")
+	// DevProviderWorkspace is the workspace of --dev exporting the cowboys API.
+	DevProviderWorkspace = DevOrganization.Join("provider")
+	// DevConsumerWorkspace is the workspace of --dev binding the cowboys API, and with a
+	// WorkloadCluster synced to a simulated physical cluster.
+	DevConsumerWorkspace = DevOrganization.Join("consumer")
+)
+
+const (
+	// DevWorkloadCluster is the WorkloadCluster seeded by --dev in DevConsumerWorkspace.
+	DevWorkloadCluster = "simulated"
+)
+
+// installDevContent seeds the demo content of --dev once the server is up. The seeding does not
+// block the readiness of the server, as the workspaces have to be scheduled and initialized by
+// the controllers first.
+func (s *Server) installDevContent(ctx context.Context, config *rest.Config) error {
+	config = rest.AddUserAgent(rest.CopyConfig(config), "kcp-dev-content")
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+	dynamicClusterClient, err := dynamic.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	s.AddPostStartHook("kcp-install-dev-content", func(hookContext genericapiserver.PostStartHookContext) error {
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			klog.Errorf("failed to finish post-start-hook kcp-install-dev-content: %v", err)
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go func() {
+			if err := bootstrapDevContent(ctx, kcpClusterClient, dynamicClusterClient); err != nil {
+				klog.Errorf("failed to seed the content of --dev: %v", err)
+				return
+			}
+
+			// the WorkloadCluster of the consumer workspace is served by a syncer to a simulated
+			// physical cluster, i.e. workloads get a plausible status without a real cluster.
+			if err := syncer.StartSimulatedSyncers(ctx, []syncer.SyncTarget{{
+				Upstream:            config,
+				Resources:           sets.NewString("deployments.apps", "services"),
+				KCPClusterName:      DevConsumerWorkspace,
+				WorkloadClusterName: DevWorkloadCluster,
+			}}, 2, time.Minute); err != nil {
+				klog.Errorf("failed to start the simulated syncer of --dev: %v", err)
+				return
+			}
+
+			klog.Infof("Seeded the content of --dev in %s", DevOrganization)
+		}()
+
+		return nil
+	})
+	return nil
+}
+
+// bootstrapDevContent creates the demo content workspace by workspace, top-down, waiting for every
+// workspace to be ready before its content is created. This is blocking, i.e. it only returns
+// (with error) when the context is closed or with nil when the content is completely created.
+func bootstrapDevContent(ctx context.Context, kcpClusterClient kcpclient.ClusterInterface, dynamicClusterClient dynamic.ClusterInterface) error {
+	for _, step := range []struct {
+		clusterName logicalcluster.LogicalCluster
+		bootstrap   func(context.Context, discovery.DiscoveryInterface, dynamic.Interface) error
+	}{
+		{tenancyv1alpha1.RootCluster, configdevroot.Bootstrap},
+		{DevOrganization, configdevdemo.Bootstrap},
+		{DevProviderWorkspace, configdevprovider.Bootstrap},
+		{DevConsumerWorkspace, configdevconsumer.Bootstrap},
+	} {
+		if parent, hasParent := step.clusterName.Parent(); hasParent {
+			if err := waitForWorkspaceReady(ctx, kcpClusterClient.Cluster(parent), step.clusterName.Base()); err != nil {
+				return err
+			}
+		}
+		if err := step.bootstrap(ctx, kcpClusterClient.Cluster(step.clusterName).Discovery(), dynamicClusterClient.Cluster(step.clusterName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForWorkspaceReady waits for the ClusterWorkspace of the given name to be ready. This is
+// blocking, i.e. it only returns (with error) when the context is closed or with nil when the
+// workspace is ready.
+func waitForWorkspaceReady(ctx context.Context, kcpClient kcpclient.Interface, name string) error {
+	return wait.PollImmediateInfiniteWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
+		workspace, err := kcpClient.TenancyV1alpha1().ClusterWorkspaces().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			klog.V(2).Infof("Failed to get ClusterWorkspace %s, retrying: %v", name, err)
+			return false, nil
+		}
+		return workspace.Status.Phase == tenancyv1alpha1.ClusterWorkspacePhaseReady, nil
+	})
+}
@@ -26,7 +26,10 @@ import (
 	"path"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/emicklei/go-restful"
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
@@ -39,20 +42,31 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/selection"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/audit"
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	authserviceaccount "k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
 	apiserverdiscovery "k8s.io/apiserver/pkg/endpoints/discovery"
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/warning"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/genericcontrolplane"
 	"k8s.io/kubernetes/pkg/genericcontrolplane/aggregator"
 
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	apishelper "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1/helper"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/hibernation"
 )
 
 var (
@@ -87,6 +101,135 @@ func WithAcceptHeader(apiHandler http.Handler) http.Handler {
 	})
 }
 
+type identityHashContextKeyType int
+
+const (
+	// identityHashContextKey is the context key for the valid APIExport identity hashes of wildcard requests.
+	identityHashContextKey identityHashContextKeyType = iota
+)
+
+// APIExportsByIdentityIndex is the name of the index of APIExports by their current and previous identity hashes.
+const APIExportsByIdentityIndex = "wildcardIdentity-apiExportsByIdentity"
+
+// IndexAPIExportsByIdentity is an index function that maps an APIExport to its current and previous
+// identity hashes.
+func IndexAPIExportsByIdentity(obj interface{}) ([]string, error) {
+	apiExport, ok := obj.(*apisv1alpha1.APIExport)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be an APIExport, but is %T", obj)
+	}
+
+	hashes := []string{apishelper.IdentityHash(apiExport)}
+	for _, previous := range apiExport.Status.PreviousIdentityHashes {
+		hashes = append(hashes, previous.IdentityHash)
+	}
+	return hashes, nil
+}
+
+// WithWildcardIdentity strips the APIExport identity hash from the resource of wildcard requests of the
+// form /clusters/*/apis/<group>/<version>/<resource>:<identityHash>, and adds a label selector for
+// the identity label to the request. The selector is evaluated by the list and watch predicate of the
+// storage, such that only objects of resources bound from that APIExport are returned. During the
+// grace period after a rotation of the identity of the APIExport, the current and the previous
+// identity hashes are selected. The identity hashes are made available in the context for resolving
+// the bound CRD.
+func WithWildcardIdentity(apiHandler http.Handler, apiExportIndexer cache.Indexer) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		if cluster == nil || !cluster.Wildcard {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		segments := strings.Split(req.URL.Path, "/")
+		var i int
+		switch {
+		case len(segments) > 4 && segments[1] == "apis":
+			i = 4
+		case len(segments) > 3 && segments[1] == "api":
+			i = 3
+		default:
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		if segments[i] == "namespaces" && len(segments) > i+2 {
+			i += 2
+		}
+
+		resource, identityHash := apishelper.SplitResourceIdentity(segments[i])
+		if resource == segments[i] {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		if identityHash == "" {
+			responsewriters.ErrorNegotiated(
+				apierrors.NewBadRequest(fmt.Sprintf("invalid resource %q: empty identity", segments[i])),
+				errorCodecs, schema.GroupVersion{}, w, req,
+			)
+			return
+		}
+		identityHashes, err := validIdentityHashes(apiExportIndexer, identityHash, time.Now())
+		if err != nil {
+			responsewriters.InternalError(w, req, err)
+			return
+		}
+		selector, err := identitySelector(req.URL.Query().Get("labelSelector"), identityHashes)
+		if err != nil {
+			responsewriters.ErrorNegotiated(
+				apierrors.NewBadRequest(err.Error()),
+				errorCodecs, schema.GroupVersion{}, w, req,
+			)
+			return
+		}
+
+		segments[i] = resource
+		req.URL.Path = strings.Join(segments, "/")
+		req.URL.RawPath = ""
+		query := req.URL.Query()
+		query.Set("labelSelector", selector)
+		req.URL.RawQuery = query.Encode()
+
+		ctx := context.WithValue(req.Context(), identityHashContextKey, identityHashes)
+		apiHandler.ServeHTTP(w, req.WithContext(ctx))
+	}
+}
+
+// validIdentityHashes returns the identity hashes valid together with the given one, i.e. those of
+// the APIExport the identity hash is current or a previous identity of.
+func validIdentityHashes(apiExportIndexer cache.Indexer, identityHash string, now time.Time) ([]string, error) {
+	apiExports, err := apiExportIndexer.ByIndex(APIExportsByIdentityIndex, identityHash)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range apiExports {
+		hashes := apishelper.ValidIdentityHashes(obj.(*apisv1alpha1.APIExport), now)
+		for _, h := range hashes {
+			if h == identityHash {
+				return hashes, nil
+			}
+		}
+	}
+	return []string{identityHash}, nil
+}
+
+// identitySelector adds a requirement on the identity label to the given label selector.
+func identitySelector(selector string, identityHashes []string) (string, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return "", fmt.Errorf("invalid labelSelector: %w", err)
+	}
+	op := selection.Equals
+	if len(identityHashes) > 1 {
+		op = selection.In
+	}
+	requirement, err := labels.NewRequirement(apisv1alpha1.IdentityHashLabelKey, op, identityHashes)
+	if err != nil {
+		return "", fmt.Errorf("invalid identity: %w", err)
+	}
+	return parsed.Add(*requirement).String(), nil
+}
+
 func WithClusterScope(apiHandler http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		var clusterName logicalcluster.LogicalCluster
@@ -196,6 +339,219 @@ func WithWildcardListWatchGuard(apiHandler http.Handler) http.HandlerFunc {
 	}
 }
 
+// WildcardListLimits guard the shard against listing all objects of a resource in all workspaces at once,
+// e.g. by misconfigured controllers. Zero values disable the respective limit.
+type WildcardListLimits struct {
+	// RequireLimit rejects wildcard lists without a limit, i.e. requires pagination.
+	RequireLimit bool
+	// MaxLimit rejects wildcard lists with a larger limit, or without a limit.
+	MaxLimit int64
+	// MaxInflightPerUser rejects wildcard lists of a user while that many are served.
+	MaxInflightPerUser int
+}
+
+// WithWildcardListLimits enforces the given limits on list requests in the `*` logical cluster of users
+// other than system:masters, i.e. other than kcp itself. As the watch cache serves lists with
+// resourceVersion 0 in full, ignoring the limit, these are served from storage instead when a limit
+// is enforced. It must run after authentication, i.e. within the handler chain.
+func WithWildcardListLimits(apiHandler http.Handler, limits WildcardListLimits) http.HandlerFunc {
+	var lock sync.Mutex
+	inflight := map[string]int{}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		requestInfo, ok := request.RequestInfoFrom(req.Context())
+		if cluster == nil || !cluster.Wildcard || !ok || !requestInfo.IsResourceRequest || requestInfo.Verb != "list" {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		u, ok := request.UserFrom(req.Context())
+		if !ok || sets.NewString(u.GetGroups()...).Has(user.SystemPrivilegedGroup) {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		gv := schema.GroupVersion{Group: requestInfo.APIGroup, Version: requestInfo.APIVersion}
+
+		if limits.RequireLimit || limits.MaxLimit > 0 {
+			query := req.URL.Query()
+			limit, err := strconv.ParseInt(query.Get("limit"), 10, 64)
+			if err != nil || limit <= 0 {
+				limit = 0
+			}
+			switch {
+			case limit == 0 && limits.MaxLimit > 0:
+				responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("list requests in the `*` logical cluster must be paginated with a limit of at most %d", limits.MaxLimit)), errorCodecs, gv, w, req)
+				return
+			case limit == 0:
+				responsewriters.ErrorNegotiated(apierrors.NewBadRequest("list requests in the `*` logical cluster must be paginated with a limit"), errorCodecs, gv, w, req)
+				return
+			case limits.MaxLimit > 0 && limit > limits.MaxLimit:
+				responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("limit %d of the list request in the `*` logical cluster exceeds the maximum of %d", limit, limits.MaxLimit)), errorCodecs, gv, w, req)
+				return
+			}
+			if query.Get("resourceVersion") == "0" {
+				query.Del("resourceVersion")
+				query.Del("resourceVersionMatch")
+				req.URL.RawQuery = query.Encode()
+			}
+		}
+
+		if limits.MaxInflightPerUser > 0 {
+			lock.Lock()
+			if inflight[u.GetName()] >= limits.MaxInflightPerUser {
+				lock.Unlock()
+				responsewriters.ErrorNegotiated(apierrors.NewTooManyRequests(fmt.Sprintf("user %q already has %d list requests in flight in the `*` logical cluster", u.GetName(), limits.MaxInflightPerUser), 1), errorCodecs, gv, w, req)
+				return
+			}
+			inflight[u.GetName()]++
+			lock.Unlock()
+			defer func() {
+				lock.Lock()
+				defer lock.Unlock()
+				if inflight[u.GetName()]--; inflight[u.GetName()] == 0 {
+					delete(inflight, u.GetName())
+				}
+			}()
+		}
+
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
+const (
+	// ImpersonationWorkspaceAuditAnnotationKey is the audit annotation holding the logical cluster
+	// an impersonated request was served in.
+	ImpersonationWorkspaceAuditAnnotationKey = "impersonation.kcp.dev/workspace"
+	// ImpersonationChainAuditAnnotationKey is the audit annotation holding the impersonating and
+	// the impersonated user of a request, as "<user> -> <impersonated user>".
+	ImpersonationChainAuditAnnotationKey = "impersonation.kcp.dev/chain"
+)
+
+// WithImpersonationAuditAnnotations annotates the audit events of impersonated requests with the
+// impersonation chain and the workspace it happened in. It must run after the impersonation
+// filter, i.e. within the handler chain.
+func WithImpersonationAuditAnnotations(apiHandler http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if ae := audit.AuditEventFrom(req.Context()); ae != nil && ae.ImpersonatedUser != nil {
+			if cluster := request.ClusterFrom(req.Context()); cluster != nil && !cluster.Name.Empty() {
+				audit.LogAnnotation(ae, ImpersonationWorkspaceAuditAnnotationKey, cluster.Name.String())
+			}
+			audit.LogAnnotation(ae, ImpersonationChainAuditAnnotationKey, ae.User.Username+" -> "+ae.ImpersonatedUser.Username)
+		}
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
+// WithActivityTracking records the requests of users to logical clusters in the tracker. Requests
+// of privileged users, i.e. of kcp itself and its controllers, and wildcard requests are not
+// activity of a workspace. It must run after the authentication filter, i.e. within the handler chain.
+func WithActivityTracking(apiHandler http.Handler, tracker *hibernation.ActivityTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		if u, ok := request.UserFrom(req.Context()); ok && cluster != nil && !cluster.Name.Empty() && !cluster.Wildcard && !sets.NewString(u.GetGroups()...).Has(user.SystemPrivilegedGroup) {
+			tracker.Touch(cluster.Name)
+		}
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
+// WithPrivilegedUsers serves the handler only to privileged users, i.e. to members of
+// system:masters. Handlers exposing state across workspaces, e.g. the controller dumps, are
+// wrapped in it, as authorization of non-resource URLs is granted per workspace.
+func WithPrivilegedUsers(handler http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		u, ok := request.UserFrom(req.Context())
+		if !ok || !sets.NewString(u.GetGroups()...).Has(user.SystemPrivilegedGroup) {
+			http.Error(w, fmt.Sprintf("%s is only available to %s", req.URL.Path, user.SystemPrivilegedGroup), http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}
+}
+
+// migrationFenceRetryAfterSeconds is how long clients are told to wait before retrying a write
+// to a workspace being migrated.
+const migrationFenceRetryAfterSeconds = 5
+
+// WithMigrationFence rejects writes to workspaces on this shard while they are fenced by a
+// WorkspaceMigration, such that their objects can be copied consistently to another shard. The
+// ClusterWorkspace of the workspace must be known to this shard, i.e. live on it too. It must run
+// after the request info filter, i.e. within the handler chain.
+func WithMigrationFence(apiHandler http.Handler, workspaceLister tenancylisters.ClusterWorkspaceLister, shardName string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		info, ok := request.RequestInfoFrom(req.Context())
+		if !ok || !info.IsResourceRequest || cluster == nil || cluster.Wildcard || cluster.Name.Empty() {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		switch info.Verb {
+		case "create", "update", "patch", "delete", "deletecollection":
+		default:
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		parent, name := cluster.Name.Split()
+		if parent.Empty() {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		workspace, err := workspaceLister.Get(clusters.ToClusterAwareKey(parent, name))
+		if err != nil {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		if migration, fenced := workspace.Annotations[tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey]; fenced && workspace.Status.Location.Current == shardName {
+			responsewriters.ErrorNegotiated(
+				apierrors.NewTooManyRequests(fmt.Sprintf("workspace %s is being migrated by WorkspaceMigration %s, writes are not possible", cluster.Name, migration), migrationFenceRetryAfterSeconds),
+				errorCodecs, schema.GroupVersion{}, w, req,
+			)
+			return
+		}
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
+// WithShardURLRedirect points clients using a previous URL of this shard, as recorded on its
+// ClusterWorkspaceShard, to the current external URL of the shard. If redirect is true, these
+// requests are permanently redirected, otherwise they are served with a warning. It must run
+// before the handler chain, i.e. on the original request path.
+func WithShardURLRedirect(apiHandler http.Handler, shardLister tenancylisters.ClusterWorkspaceShardLister, shardName string, redirect bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		shard, err := shardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, shardName))
+		if err != nil || shard.Annotations[tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey] == "" {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		current, err := url.Parse(tenancyhelper.ShardURL(shard.Spec, tenancyhelper.ExternalAudience))
+		if err != nil || current.Host == req.Host {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		for _, previous := range strings.Split(shard.Annotations[tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey], ",") {
+			u, err := url.Parse(previous)
+			if err != nil || u.Host != req.Host || !strings.HasPrefix(req.URL.Path, u.Path) {
+				continue
+			}
+
+			target := *current
+			target.Path = strings.TrimSuffix(current.Path, "/") + "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, u.Path), "/")
+			target.RawQuery = req.URL.RawQuery
+			if redirect {
+				http.Redirect(w, req, target.String(), http.StatusPermanentRedirect)
+				return
+			}
+			if header, err := utilnet.NewWarningHeader(299, "-", fmt.Sprintf("shard %s moved from %s to %s, update the server of your kubeconfig", shardName, previous, current)); err == nil {
+				w.Header().Add("Warning", header)
+			}
+			break
+		}
+		apiHandler.ServeHTTP(w, req)
+	}
+}
+
 // WithInClusterServiceAccountRequestRewrite adds the /clusters/<clusterName> prefix to the request path if the request comes
 // from an InCluster service account requests (InCluster clients don't support prefixes).
 func WithInClusterServiceAccountRequestRewrite(handler http.Handler, unsafeServiceAccountPreAuth authenticator.Request) http.Handler {
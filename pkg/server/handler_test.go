@@ -19,15 +19,30 @@ package server
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 	"github.com/stretchr/testify/require"
 
+	authenticationv1 "k8s.io/api/authentication/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/cache"
 	"sigs.k8s.io/yaml"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
 )
 
 func TestClusterWorkspaceNamePattern(t *testing.T) {
@@ -37,14 +52,16 @@ func TestClusterWorkspaceNamePattern(t *testing.T) {
 	var crd apiextensionsv1.CustomResourceDefinition
 	err = yaml.Unmarshal(bs, &crd)
 	require.NoError(t, err)
-	require.Len(t, crd.Spec.Versions, 1, "crd should have exactly one version, update the test when this changes")
+	require.Len(t, crd.Spec.Versions, 2, "crd should have exactly two versions, update the test when this changes")
 
-	namePattern := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["metadata"].Properties["name"].Pattern
-	require.True(t, strings.HasPrefix(namePattern, "^"), "cluster name pattern should end with $")
-	require.True(t, strings.HasSuffix(namePattern, "$"), "cluster name pattern should start with ^")
+	for _, version := range crd.Spec.Versions {
+		namePattern := version.Schema.OpenAPIV3Schema.Properties["metadata"].Properties["name"].Pattern
+		require.True(t, strings.HasPrefix(namePattern, "^"), "cluster name pattern of %s should end with $", version.Name)
+		require.True(t, strings.HasSuffix(namePattern, "$"), "cluster name pattern of %s should start with ^", version.Name)
 
-	namePattern = strings.Trim(namePattern, "^$")
-	require.Equal(t, fmt.Sprintf("^(%s:)*%s$", namePattern, namePattern), reClusterName.String(), "logical cluster regex should match ClusterWorkspace name pattern")
+		namePattern = strings.Trim(namePattern, "^$")
+		require.Equal(t, fmt.Sprintf("^(%s:)*%s$", namePattern, namePattern), reClusterName.String(), "logical cluster regex should match ClusterWorkspace name pattern of %s", version.Name)
+	}
 }
 
 func TestReCluster(t *testing.T) {
@@ -92,3 +109,451 @@ func TestReCluster(t *testing.T) {
 		})
 	}
 }
+
+func TestWithWildcardIdentity(t *testing.T) {
+	tests := map[string]struct {
+		cluster        request.Cluster
+		path           string
+		query          string
+		wantPath       string
+		wantSelector   string
+		wantIdentities []string
+		wantCode       int
+	}{
+		"non-wildcard request is untouched": {
+			cluster:  request.Cluster{Name: logicalcluster.New("root:org")},
+			path:     "/apis/example.dev/v1/widgets:abc",
+			wantPath: "/apis/example.dev/v1/widgets:abc",
+		},
+		"wildcard request without identity is untouched": {
+			cluster:  request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			path:     "/apis/example.dev/v1/widgets",
+			wantPath: "/apis/example.dev/v1/widgets",
+		},
+		"wildcard list with identity": {
+			cluster:        request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			path:           "/apis/example.dev/v1/widgets:abc",
+			wantPath:       "/apis/example.dev/v1/widgets",
+			wantSelector:   "apis.kcp.dev/identity=abc",
+			wantIdentities: []string{"abc"},
+		},
+		"wildcard namespaced list with identity and selector": {
+			cluster:        request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			path:           "/apis/example.dev/v1/namespaces/default/widgets:abc",
+			query:          "labelSelector=app%3Dfoo&watch=true",
+			wantPath:       "/apis/example.dev/v1/namespaces/default/widgets",
+			wantSelector:   "apis.kcp.dev/identity=abc,app=foo",
+			wantIdentities: []string{"abc"},
+		},
+		"wildcard core list with identity": {
+			cluster:        request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			path:           "/api/v1/configmaps:abc",
+			wantPath:       "/api/v1/configmaps",
+			wantSelector:   "apis.kcp.dev/identity=abc",
+			wantIdentities: []string{"abc"},
+		},
+		"wildcard list with previous identity of a rotated APIExport": {
+			cluster:        request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			path:           "/apis/example.dev/v1/widgets:previous",
+			wantPath:       "/apis/example.dev/v1/widgets",
+			wantSelector:   "apis.kcp.dev/identity in (current,previous)",
+			wantIdentities: []string{"current", "previous"},
+		},
+		"wildcard list with expired identity of a rotated APIExport": {
+			cluster:        request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			path:           "/apis/example.dev/v1/widgets:expired",
+			wantPath:       "/apis/example.dev/v1/widgets",
+			wantSelector:   "apis.kcp.dev/identity=expired",
+			wantIdentities: []string{"expired"},
+		},
+		"empty identity": {
+			cluster:  request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			path:     "/apis/example.dev/v1/widgets:",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{APIExportsByIdentityIndex: IndexAPIExportsByIdentity})
+	require.NoError(t, indexer.Add(&apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: "root:org", Name: "rotated"},
+		Status: apisv1alpha1.APIExportStatus{
+			IdentityHash: "current",
+			PreviousIdentityHashes: []apisv1alpha1.APIExportPreviousIdentity{
+				{IdentityHash: "previous", ValidUntil: metav1.NewTime(time.Now().Add(time.Hour))},
+				{IdentityHash: "expired", ValidUntil: metav1.NewTime(time.Now().Add(-time.Hour))},
+			},
+		},
+	}))
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotReq *http.Request
+			handler := WithWildcardIdentity(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				gotReq = req
+			}), indexer)
+
+			req := httptest.NewRequest(http.MethodGet, tc.path+"?"+tc.query, nil)
+			req = req.WithContext(request.WithCluster(req.Context(), tc.cluster))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if tc.wantCode != 0 {
+				require.Equal(t, tc.wantCode, rec.Code)
+				require.Nil(t, gotReq)
+				return
+			}
+			require.NotNil(t, gotReq)
+			require.Equal(t, tc.wantPath, gotReq.URL.Path)
+			require.Equal(t, tc.wantSelector, gotReq.URL.Query().Get("labelSelector"))
+			identities, _ := gotReq.Context().Value(identityHashContextKey).([]string)
+			require.Equal(t, tc.wantIdentities, identities)
+		})
+	}
+}
+
+func TestWithWildcardListLimits(t *testing.T) {
+	tests := map[string]struct {
+		limits     WildcardListLimits
+		cluster    request.Cluster
+		verb       string
+		query      string
+		groups     []string
+		wantStatus int
+		wantQuery  string
+	}{
+		"no limits": {
+			cluster:    request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:       "list",
+			wantStatus: http.StatusOK,
+		},
+		"unpaginated list with required limit": {
+			limits:     WildcardListLimits{RequireLimit: true},
+			cluster:    request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:       "list",
+			wantStatus: http.StatusBadRequest,
+		},
+		"paginated list with required limit": {
+			limits:     WildcardListLimits{RequireLimit: true},
+			cluster:    request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:       "list",
+			query:      "limit=5000",
+			wantStatus: http.StatusOK,
+			wantQuery:  "limit=5000",
+		},
+		"unpaginated list with maximum limit": {
+			limits:     WildcardListLimits{MaxLimit: 500},
+			cluster:    request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:       "list",
+			wantStatus: http.StatusBadRequest,
+		},
+		"limit beyond the maximum": {
+			limits:     WildcardListLimits{MaxLimit: 500},
+			cluster:    request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:       "list",
+			query:      "limit=501",
+			wantStatus: http.StatusBadRequest,
+		},
+		"list from the watch cache is served from storage": {
+			limits:     WildcardListLimits{MaxLimit: 500},
+			cluster:    request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:       "list",
+			query:      "limit=500&resourceVersion=0&resourceVersionMatch=NotOlderThan",
+			wantStatus: http.StatusOK,
+			wantQuery:  "limit=500",
+		},
+		"list of system:masters": {
+			limits:     WildcardListLimits{MaxLimit: 500},
+			cluster:    request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:       "list",
+			groups:     []string{user.SystemPrivilegedGroup},
+			wantStatus: http.StatusOK,
+		},
+		"watch": {
+			limits:     WildcardListLimits{MaxLimit: 500},
+			cluster:    request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:       "watch",
+			wantStatus: http.StatusOK,
+		},
+		"list in a workspace": {
+			limits:     WildcardListLimits{MaxLimit: 500},
+			cluster:    request.Cluster{Name: logicalcluster.New("root:org")},
+			verb:       "list",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var query string
+			handler := WithWildcardListLimits(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				query = req.URL.RawQuery
+				w.WriteHeader(http.StatusOK)
+			}), tc.limits)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/configmaps?"+tc.query, nil)
+			ctx := request.WithCluster(req.Context(), tc.cluster)
+			ctx = request.WithRequestInfo(ctx, &request.RequestInfo{IsResourceRequest: true, Verb: tc.verb, APIVersion: "v1", Resource: "configmaps"})
+			ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "controller", Groups: tc.groups})
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req.WithContext(ctx))
+
+			require.Equal(t, tc.wantStatus, rw.Code, rw.Body.String())
+			if tc.wantQuery != "" {
+				require.Equal(t, tc.wantQuery, query)
+			}
+		})
+	}
+}
+
+func TestWithWildcardListLimitsInflight(t *testing.T) {
+	block := make(chan struct{})
+	served := make(chan struct{})
+	handler := WithWildcardListLimits(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("block") != "" {
+			served <- struct{}{}
+			<-block
+		}
+		w.WriteHeader(http.StatusOK)
+	}), WildcardListLimits{MaxInflightPerUser: 1})
+
+	list := func(userName, query string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/configmaps?"+query, nil)
+		ctx := request.WithCluster(req.Context(), request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true})
+		ctx = request.WithRequestInfo(ctx, &request.RequestInfo{IsResourceRequest: true, Verb: "list", APIVersion: "v1", Resource: "configmaps"})
+		ctx = request.WithUser(ctx, &user.DefaultInfo{Name: userName})
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req.WithContext(ctx))
+		return rw.Code
+	}
+
+	done := make(chan int)
+	go func() { done <- list("alice", "block=true") }()
+	<-served
+
+	require.Equal(t, http.StatusTooManyRequests, list("alice", ""), "second concurrent list of the same user should be rejected")
+	require.Equal(t, http.StatusOK, list("bob", ""), "lists of other users should be served")
+
+	close(block)
+	require.Equal(t, http.StatusOK, <-done)
+	require.Equal(t, http.StatusOK, list("alice", ""), "lists should be served again after the inflight one finished")
+}
+
+func TestWithImpersonationAuditAnnotations(t *testing.T) {
+	tests := map[string]struct {
+		cluster          request.Cluster
+		impersonatedUser *authenticationv1.UserInfo
+		wantAnnotations  map[string]string
+	}{
+		"not impersonated": {
+			cluster: request.Cluster{Name: logicalcluster.New("root:org:team")},
+		},
+		"impersonated": {
+			cluster:          request.Cluster{Name: logicalcluster.New("root:org:team")},
+			impersonatedUser: &authenticationv1.UserInfo{Username: "system:serviceaccount:default:deployer"},
+			wantAnnotations: map[string]string{
+				ImpersonationWorkspaceAuditAnnotationKey: "root:org:team",
+				ImpersonationChainAuditAnnotationKey:     "alice -> system:serviceaccount:default:deployer",
+			},
+		},
+		"impersonated without cluster": {
+			impersonatedUser: &authenticationv1.UserInfo{Username: "bob"},
+			wantAnnotations: map[string]string{
+				ImpersonationChainAuditAnnotationKey: "alice -> bob",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ae := &auditinternal.Event{
+				Level:            auditinternal.LevelMetadata,
+				User:             authenticationv1.UserInfo{Username: "alice"},
+				ImpersonatedUser: tc.impersonatedUser,
+			}
+			var served bool
+			handler := WithImpersonationAuditAnnotations(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				served = true
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/configmaps", nil)
+			ctx := audit.WithAuditContext(req.Context(), &audit.AuditContext{Event: ae})
+			if !tc.cluster.Name.Empty() {
+				ctx = request.WithCluster(ctx, tc.cluster)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+			require.True(t, served)
+			require.Equal(t, tc.wantAnnotations, ae.Annotations)
+		})
+	}
+}
+
+func TestWithPrivilegedUsers(t *testing.T) {
+	tests := map[string]struct {
+		user       user.Info
+		wantStatus int
+	}{
+		"system:masters": {
+			user:       &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}},
+			wantStatus: http.StatusOK,
+		},
+		"workspace admin": {
+			user:       &user.DefaultInfo{Name: "alice", Groups: []string{"system:kcp:clusterworkspace:admin"}},
+			wantStatus: http.StatusForbidden,
+		},
+		"no user": {
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := WithPrivilegedUsers(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/clusters/root:org/debug/controllers", nil)
+			if tc.user != nil {
+				req = req.WithContext(request.WithUser(req.Context(), tc.user))
+			}
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req)
+
+			require.Equal(t, tc.wantStatus, rw.Code, rw.Body.String())
+		})
+	}
+}
+
+func TestWithMigrationFence(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "fenced",
+			ClusterName: "root:org",
+			Annotations: map[string]string{tenancyv1alpha1.WorkspaceMigrationFenceAnnotationKey: "move"},
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard-1"}},
+	}))
+	require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", ClusterName: "root:org"},
+		Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard-1"}},
+	}))
+
+	tests := map[string]struct {
+		cluster    request.Cluster
+		verb       string
+		shard      string
+		wantStatus int
+	}{
+		"write to fenced workspace": {
+			cluster:    request.Cluster{Name: logicalcluster.New("root:org:fenced")},
+			verb:       "create",
+			shard:      "shard-1",
+			wantStatus: http.StatusTooManyRequests,
+		},
+		"read from fenced workspace": {
+			cluster:    request.Cluster{Name: logicalcluster.New("root:org:fenced")},
+			verb:       "list",
+			shard:      "shard-1",
+			wantStatus: http.StatusOK,
+		},
+		"write to fenced workspace on target shard": {
+			cluster:    request.Cluster{Name: logicalcluster.New("root:org:fenced")},
+			verb:       "update",
+			shard:      "shard-2",
+			wantStatus: http.StatusOK,
+		},
+		"write to unfenced workspace": {
+			cluster:    request.Cluster{Name: logicalcluster.New("root:org:team")},
+			verb:       "delete",
+			shard:      "shard-1",
+			wantStatus: http.StatusOK,
+		},
+		"wildcard write": {
+			cluster:    request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:       "deletecollection",
+			shard:      "shard-1",
+			wantStatus: http.StatusOK,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := WithMigrationFence(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), tenancylisters.NewClusterWorkspaceLister(indexer), tc.shard)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/configmaps", nil)
+			ctx := request.WithCluster(req.Context(), tc.cluster)
+			ctx = request.WithRequestInfo(ctx, &request.RequestInfo{IsResourceRequest: true, Verb: tc.verb, APIVersion: "v1", Resource: "configmaps"})
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req.WithContext(ctx))
+
+			require.Equal(t, tc.wantStatus, rw.Code)
+		})
+	}
+}
+
+func TestWithShardURLRedirect(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "shard-1",
+			ClusterName: "root",
+			Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardPreviousURLsAnnotationKey: "https://old.kcp.dev:6443,https://older.kcp.dev/kcp"},
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceShardSpec{
+			BaseURL:     "https://shard-1.internal:6443",
+			ExternalURL: "https://new.kcp.dev",
+		},
+	}))
+
+	tests := map[string]struct {
+		url          string
+		redirect     bool
+		wantStatus   int
+		wantLocation string
+		wantWarning  bool
+	}{
+		"current URL": {
+			url:        "https://new.kcp.dev/clusters/root:org/api/v1/configmaps",
+			wantStatus: http.StatusOK,
+		},
+		"internal URL": {
+			url:        "https://shard-1.internal:6443/clusters/root:org/api/v1/configmaps",
+			wantStatus: http.StatusOK,
+		},
+		"previous URL is warned": {
+			url:         "https://old.kcp.dev:6443/clusters/root:org/api/v1/configmaps",
+			wantStatus:  http.StatusOK,
+			wantWarning: true,
+		},
+		"previous URL is redirected": {
+			url:          "https://old.kcp.dev:6443/clusters/root:org/api/v1/configmaps?watch=true",
+			redirect:     true,
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "https://new.kcp.dev/clusters/root:org/api/v1/configmaps?watch=true",
+		},
+		"previous URL with path is redirected": {
+			url:          "https://older.kcp.dev/kcp/clusters/root:org/api",
+			redirect:     true,
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "https://new.kcp.dev/clusters/root:org/api",
+		},
+		"unknown URL": {
+			url:        "https://other.kcp.dev/clusters/root:org/api",
+			redirect:   true,
+			wantStatus: http.StatusOK,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := WithShardURLRedirect(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), tenancylisters.NewClusterWorkspaceShardLister(indexer), "shard-1", tc.redirect)
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, tc.url, nil))
+
+			require.Equal(t, tc.wantStatus, rw.Code)
+			require.Equal(t, tc.wantLocation, rw.Header().Get("Location"))
+			require.Equal(t, tc.wantWarning, rw.Header().Get("Warning") != "")
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/spf13/pflag"
@@ -75,6 +76,59 @@ func (s *AdminAuthentication) AddFlags(fs *pflag.FlagSet) {
 		"Path to which the administrative token hash should be written at startup. If this is relative, it is relative to --root-directory.")
 }
 
+// TokenCache configures the cache of the authentication of bearer tokens, in front of the
+// token authenticators like OIDC.
+type TokenCache struct {
+	Size       int
+	TTL        time.Duration
+	FailureTTL time.Duration
+}
+
+func NewTokenCache() *TokenCache {
+	return &TokenCache{
+		Size:       4096,
+		FailureTTL: 5 * time.Second,
+	}
+}
+
+func (s *TokenCache) Validate() []error {
+	if s == nil {
+		return nil
+	}
+
+	errs := []error{}
+
+	if s.Size <= 0 {
+		errs = append(errs, fmt.Errorf("--authentication-token-cache-size must be positive (%d)", s.Size))
+	}
+	if s.TTL < 0 {
+		errs = append(errs, fmt.Errorf("--authentication-token-cache-ttl must be >=0 (%s)", s.TTL))
+	}
+	if s.FailureTTL < 0 {
+		errs = append(errs, fmt.Errorf("--authentication-token-cache-failure-ttl must be >=0 (%s)", s.FailureTTL))
+	}
+
+	return errs
+}
+
+func (s *TokenCache) AddFlags(fs *pflag.FlagSet) {
+	if s == nil {
+		return
+	}
+
+	fs.IntVar(&s.Size, "authentication-token-cache-size", s.Size,
+		"Maximum number of cached bearer token authentications. A token is cached separately for each set of audiences it is used with.")
+	fs.DurationVar(&s.TTL, "authentication-token-cache-ttl", s.TTL,
+		"Duration to cache the successful authentication of bearer tokens. 0 disables the cache. Deleted service accounts and secrets drop the cache.")
+	fs.DurationVar(&s.FailureTTL, "authentication-token-cache-failure-ttl", s.FailureTTL,
+		"Duration to cache the rejection of bearer tokens, if the cache is enabled with --authentication-token-cache-ttl.")
+}
+
+// Enabled returns whether token authentications are cached.
+func (s *TokenCache) Enabled() bool {
+	return s.TTL > 0
+}
+
 func (s *AdminAuthentication) ApplyTo(config *genericapiserver.Config) (newTokenOrEmpty string, tokenHash []byte, err error) {
 	// try to load existing token to reuse
 	tokenHash, err = ioutil.ReadFile(s.TokenHashFilePath)
@@ -17,8 +17,12 @@ limitations under the License.
 package options
 
 import (
+	"fmt"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 	"github.com/spf13/pflag"
 
+	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/authorization/authorizerfactory"
 	"k8s.io/apiserver/pkg/authorization/path"
@@ -37,6 +41,16 @@ type Authorization struct {
 
 	// AlwaysAllowGroups are groups which are allowed to take any actions.  In kube, this is system:masters.
 	AlwaysAllowGroups []string
+
+	// ServiceAccountParentDelegation authorizes service accounts acting in their workspace
+	// against the cluster role bindings of the parent workspace to the group
+	// system:workspace-path:<parent>.
+	ServiceAccountParentDelegation bool
+
+	// EmergencyAccessGroups are groups whose members are allowed to access the known system:
+	// workspaces, e.g. to repair them without the system:masters credentials. Every such access
+	// is logged and annotated in the audit log.
+	EmergencyAccessGroups []string
 }
 
 func NewAuthorization() *Authorization {
@@ -67,6 +81,13 @@ func (s *Authorization) Validate() []error {
 
 	allErrors := []error{}
 
+	for _, group := range s.EmergencyAccessGroups {
+		switch group {
+		case "", user.AllAuthenticated, user.AllUnauthenticated:
+			allErrors = append(allErrors, fmt.Errorf("--emergency-access-group must not be %q", group))
+		}
+	}
+
 	return allErrors
 }
 
@@ -78,14 +99,22 @@ func (s *Authorization) AddFlags(fs *pflag.FlagSet) {
 	fs.StringSliceVar(&s.AlwaysAllowPaths, "authorization-always-allow-paths", s.AlwaysAllowPaths,
 		"A list of HTTP paths to skip during authorization, i.e. these are authorized without "+
 			"contacting the 'core' kubernetes server.")
+	fs.BoolVar(&s.ServiceAccountParentDelegation, "authorization-service-account-parent-delegation", s.ServiceAccountParentDelegation,
+		"Authorize service accounts acting in their workspace also against the cluster role bindings "+
+			"of the parent workspace to the group system:workspace-path:<parent>.")
+	fs.StringSliceVar(&s.EmergencyAccessGroups, "emergency-access-group", s.EmergencyAccessGroups,
+		"Groups whose members are allowed to access the system: workspaces of kcp in an emergency. "+
+			"Every such access is logged and annotated in the audit log.")
 }
 
-func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer coreexternalversions.SharedInformerFactory, workspaceLister v1alpha1.ClusterWorkspaceLister) error {
+// ApplyTo sets up the authorizer chain. Requests to system: workspaces other than the given
+// systemWorkspaces are denied.
+func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer coreexternalversions.SharedInformerFactory, workspaceLister v1alpha1.ClusterWorkspaceLister, systemWorkspaces []logicalcluster.LogicalCluster) error {
 	var authorizers []authorizer.Authorizer
 
 	// group authorizer
 	if len(s.AlwaysAllowGroups) > 0 {
-		authorizers = append(authorizers, authorizerfactory.NewPrivilegedGroups(s.AlwaysAllowGroups...))
+		authorizers = append(authorizers, authorization.NewTracingAuthorizer("AlwaysAllowGroups", authorizerfactory.NewPrivilegedGroups(s.AlwaysAllowGroups...)))
 	}
 
 	// path authorizer
@@ -94,18 +123,29 @@ func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer coreex
 		if err != nil {
 			return err
 		}
-		authorizers = append(authorizers, a)
+		authorizers = append(authorizers, authorization.NewTracingAuthorizer("AlwaysAllowPaths", a))
 	}
 
-	// kcp authorizers
+	// kcp authorizers, traced for authorization explanations
 	bootstrapAuth, bootstrapRules := authorization.NewBootstrapPolicyAuthorizer(informer)
 	localAuth, localResolver := authorization.NewLocalAuthorizer(informer)
+	workspaceAuthorizers := []authorizer.Authorizer{
+		authorization.NewTracingAuthorizer("BootstrapPolicy", bootstrapAuth),
+		authorization.NewTracingAuthorizer("Local", localAuth),
+	}
+	if s.ServiceAccountParentDelegation {
+		workspaceAuthorizers = append(workspaceAuthorizers, authorization.NewTracingAuthorizer("ParentWorkspace", authorization.NewParentWorkspaceAuthorizer(informer)))
+	}
 	authorizers = append(authorizers,
-		authorization.NewTopLevelOrganizationAccessAuthorizer(informer, workspaceLister,
-			authorization.NewWorkspaceContentAuthorizer(informer, workspaceLister,
-				union.New(bootstrapAuth, localAuth),
-			),
-		),
+		authorization.NewTracingAuthorizer("SystemWorkspace", authorization.NewSystemWorkspaceAuthorizer(systemWorkspaces, s.EmergencyAccessGroups,
+			authorization.NewTracingAuthorizer("TopLevelOrganizationAccess", authorization.NewTopLevelOrganizationAccessAuthorizer(informer, workspaceLister,
+				authorization.NewTracingAuthorizer("WorkspaceContent", authorization.NewWorkspaceContentAuthorizer(informer, workspaceLister,
+					authorization.NewTracingAuthorizer("ImpersonationConstraint", authorization.NewImpersonationConstraintAuthorizer(workspaceLister,
+						union.New(workspaceAuthorizers...),
+					)),
+				)),
+			)),
+		)),
 	)
 
 	config.RuleResolver = union.NewRuleResolvers(bootstrapRules, localResolver)
@@ -30,7 +30,14 @@ import (
 	kcmoptions "k8s.io/kubernetes/cmd/kube-controller-manager/app/options"
 
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiresource"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/groupmapping"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/hibernation"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspaceindex"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacescheduler"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacesigner"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/drain"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/heartbeat"
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/poolgenerator"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/syncer"
 )
 
@@ -40,12 +47,26 @@ type Controllers struct {
 	ApiResource              ApiResourceController
 	Syncer                   SyncerController
 	WorkloadClusterHeartbeat WorkloadClusterHeartbeatController
+	WorkloadClusterDrain     WorkloadClusterDrainController
+	WorkloadClusterPools     WorkloadClusterPoolGeneratorController
+	WorkspaceGroupMapping    WorkspaceGroupMappingController
+	WorkspaceHibernation     WorkspaceHibernationController
+	WorkspaceIndex           WorkspaceIndexController
+	WorkspaceScheduler       WorkspaceSchedulerController
+	WorkspaceSigner          WorkspaceSignerController
 	SAController             kcmoptions.SAControllerOptions
 }
 
 type ApiResourceController = apiresource.Options
 type SyncerController = syncer.Options
 type WorkloadClusterHeartbeatController = heartbeat.Options
+type WorkloadClusterDrainController = drain.Options
+type WorkloadClusterPoolGeneratorController = poolgenerator.Options
+type WorkspaceGroupMappingController = groupmapping.Options
+type WorkspaceHibernationController = hibernation.Options
+type WorkspaceIndexController = workspaceindex.Options
+type WorkspaceSchedulerController = workspacescheduler.Options
+type WorkspaceSignerController = workspacesigner.Options
 
 var kcmDefaults *kcmoptions.KubeControllerManagerOptions
 
@@ -65,7 +86,17 @@ func NewControllers() *Controllers {
 		ApiResource:              *apiresource.DefaultOptions(),
 		Syncer:                   *syncer.DefaultOptions(),
 		WorkloadClusterHeartbeat: *heartbeat.DefaultOptions(),
-		SAController:             *kcmDefaults.SAController,
+		WorkloadClusterDrain:     *drain.DefaultOptions(),
+		WorkloadClusterPools:     *poolgenerator.DefaultOptions(),
+		WorkspaceGroupMapping:    *groupmapping.DefaultOptions(),
+		WorkspaceHibernation:     *hibernation.DefaultOptions(),
+		WorkspaceIndex:           *workspaceindex.DefaultOptions(),
+		WorkspaceScheduler:       *workspacescheduler.DefaultOptions(),
+		WorkspaceSigner:          *workspacesigner.DefaultOptions(),
+		// the configuration is copied, it is shared through a pointer otherwise
+		SAController: kcmoptions.SAControllerOptions{
+			SAControllerConfiguration: kcmDefaults.SAController.SAControllerConfiguration.DeepCopy(),
+		},
 	}
 }
 
@@ -78,6 +109,13 @@ func (c *Controllers) AddFlags(fs *pflag.FlagSet) {
 	apiresource.BindOptions(&c.ApiResource, fs)
 	syncer.BindOptions(&c.Syncer, fs)
 	heartbeat.BindOptions(&c.WorkloadClusterHeartbeat, fs)
+	drain.BindOptions(&c.WorkloadClusterDrain, fs)
+	poolgenerator.BindOptions(&c.WorkloadClusterPools, fs)
+	groupmapping.BindOptions(&c.WorkspaceGroupMapping, fs)
+	hibernation.BindOptions(&c.WorkspaceHibernation, fs)
+	workspaceindex.BindOptions(&c.WorkspaceIndex, fs)
+	workspacescheduler.BindOptions(&c.WorkspaceScheduler, fs)
+	workspacesigner.BindOptions(&c.WorkspaceSigner, fs)
 
 	c.SAController.AddFlags(fs)
 }
@@ -120,6 +158,27 @@ func (c *Controllers) Validate() []error {
 	if err := c.WorkloadClusterHeartbeat.Validate(); err != nil {
 		errs = append(errs, err)
 	}
+	if err := c.WorkloadClusterDrain.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.WorkloadClusterPools.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.WorkspaceGroupMapping.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.WorkspaceHibernation.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.WorkspaceIndex.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.WorkspaceScheduler.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.WorkspaceSigner.Validate(); err != nil {
+		errs = append(errs, err)
+	}
 	if saErrs := c.SAController.Validate(); saErrs != nil {
 		errs = append(errs, saErrs...)
 	}
@@ -0,0 +1,34 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestControllersNotShared checks that the options of servers run in the same process, e.g. by
+// tests, do not leak into each other.
+func TestControllersNotShared(t *testing.T) {
+	first := NewControllers()
+	require.NoError(t, first.Complete(t.TempDir()))
+	require.NotEmpty(t, first.SAController.ServiceAccountKeyFile)
+
+	second := NewControllers()
+	require.Empty(t, second.SAController.ServiceAccountKeyFile)
+}
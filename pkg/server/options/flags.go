@@ -78,12 +78,27 @@ var (
 		"token-auth-file",                    // If set, the file that will be used to secure the secure port of the API server via token authentication.
 
 		// KCP Authorization flags
-		"authorization-always-allow-paths", // A list of HTTP paths to skip during authorization, i.e. these are authorized without contacting the 'core' kubernetes server.
+		"authorization-always-allow-paths",                // A list of HTTP paths to skip during authorization, i.e. these are authorized without contacting the 'core' kubernetes server.
+		"authorization-service-account-parent-delegation", // Authorize service accounts acting in their workspace also against the cluster role bindings of the parent workspace to the group system:workspace-path:<parent>.
+		"emergency-access-group",                          // Groups whose members are allowed to access the system: workspaces of kcp in an emergency. Every such access is logged and annotated in the audit log.
 
 		// KCP Admin Authentication flags
 		"authentication-admin-token-path", // Path to which the administrative token hash should be written at startup. If this is relative, it is relative to --root-directory.
 		"kubeconfig-path",                 // Path to which the administrative kubeconfig should be written at startup.
 
+		// KCP Authentication Token Cache flags
+		"authentication-token-cache-size",        // Maximum number of cached bearer token authentications. A token is cached separately for each set of audiences it is used with.
+		"authentication-token-cache-ttl",         // Duration to cache the successful authentication of bearer tokens. 0 disables the cache. Deleted service accounts and secrets drop the cache.
+		"authentication-token-cache-failure-ttl", // Duration to cache the rejection of bearer tokens, if the cache is enabled with --authentication-token-cache-ttl.
+
+		// KCP Wildcard Lists flags
+		"wildcard-list-require-limit",         // Reject list requests across all workspaces without a limit, i.e. require them to be paginated.
+		"wildcard-list-max-limit",             // Maximum limit of list requests across all workspaces. Requests without a limit are rejected too. 0 means no maximum.
+		"wildcard-list-max-inflight-per-user", // Maximum number of list requests across all workspaces served concurrently for a user. Further requests are rejected with 429. 0 means no maximum.
+
+		// KCP Reload flags
+		"reload-config-file", // File with options applied without restarting, i.e. verbosity and workloadClusterHeartbeatThreshold. It, and the file of --audit-policy-file, are reloaded on SIGHUP and when they change.
+
 		// Kubernetes ServiceAccount Token Controller
 		"concurrent-serviceaccount-token-syncs", // The number of service account token objects that are allowed to sync concurrently. Larger number = more responsive token generation, but more CPU (and network) load
 		"service-account-private-key-file",      // Filename containing a PEM-encoded private RSA or ECDSA key used to sign service account tokens.
@@ -108,12 +123,24 @@ var (
 		"tracing-config-file", // File with apiserver tracing configuration.
 
 		// KCP flags
-		"discovery-poll-interval",     // Polling interval for dynamic discovery informers.
-		"enable-sharding",             // Enable delegating to peer kcp shards.
-		"profiler-address",            // [Address]:port to bind the profiler to
-		"root-directory",              // Root directory.
-		"shard-kubeconfig-file",       // Kubeconfig holding admin(!) credentials to peer kcp shards.
-		"experimental-bind-free-port", // Bind to a free port. --secure-bind-port must be 0. Use the admin.kubeconfig to extract the chosen port.
+		"discovery-poll-interval",              // Polling interval for dynamic discovery informers.
+		"enable-sharding",                      // Enable delegating to peer kcp shards.
+		"profiler-address",                     // [Address]:port to bind the profiler to
+		"root-directory",                       // Root directory.
+		"shard-kubeconfig-file",                // Kubeconfig holding admin(!) credentials to peer kcp shards.
+		"shard-name",                           // Name of this shard. A shard other than the root shard registers itself as ClusterWorkspaceShard of this name in the root shard.
+		"root-shard-kubeconfig-file",           // Kubeconfig of the root shard, used to register this shard. Required unless this is the root shard.
+		"shard-base-url",                       // Base URL of this shard for direct connections, registered in its ClusterWorkspaceShard. Defaults to the external address of the shard.
+		"shard-external-url",                   // URL presented to users in the workspaces of this shard, registered in its ClusterWorkspaceShard. Defaults to the base URL.
+		"shard-virtual-workspace-url",          // URL of the virtual workspaces of this shard, registered in its ClusterWorkspaceShard.
+		"shard-external-virtual-workspace-url", // URL of the virtual workspaces of this shard presented to users, if different from the internal one in split-horizon deployments.
+		"shard-previous-url-redirect",          // Redirect requests to a previous URL of this shard, as recorded on its ClusterWorkspaceShard, to the current external URL.
+		"shard-serving-ca-file",                // CA bundle the serving certificates of shards joining the root shard are verified against.
+		"mirror-shard-kubeconfig-file",         // Kubeconfig of a shard to mirror read requests to, e.g. a copy of this shard on a new storage, to validate a migration. It must hold credentials allowed to impersonate users. Diverging responses are counted in the kcp_shard_mirror_requests_total metric.
+		"mirror-workspaces",                    // Logical clusters whose read requests are mirrored to the shard of --mirror-shard-kubeconfig-file.
+		"mirror-percentage",                    // Percentage of the read requests to --mirror-workspaces which are mirrored.
+		"dev",                                  // Seed demo content for a first tour of kcp.
+		"experimental-bind-free-port",          // Bind to a free port. --secure-bind-port must be 0. Use the admin.kubeconfig to extract the chosen port.
 
 		// secure serving flags
 		"bind-address",                     // The IP address on which to listen for the --secure-port port. The associated interface(s) must be reachable by the rest of the cluster, and by CLI/web clients. If blank or an unspecified address (0.0.0.0 or ::), all interfaces will be used.
@@ -137,6 +164,7 @@ var (
 		// KCP Controllers flags
 		"auto-publish-apis",                      // If true, the APIs imported from physical clusters will be published automatically as CRDs
 		"apiresource-controller-threads",         // Number of threads to use for the apiresource controller.
+		"apiresource-orphan-retention",           // Amount of time a NegotiatedAPIResource and its published CRD are kept after no WorkloadCluster imports the API anymore. 0 deletes them immediately.
 		"pull-mode",                              // Deploy the syncer in registered physical clusters in POD, and have it sync resources from KCP
 		"push-mode",                              // If true, run syncer for each cluster from inside cluster controller
 		"resources-to-sync",                      // Provides the list of resources that should be synced from KCP logical cluster to underlying physical clusters
@@ -144,7 +172,15 @@ var (
 		"run-virtual-workspaces",                 // Run the virtual workspaces apiservers in-process
 		"syncer-image",                           // Syncer image to install on clusters
 		"unsupported-run-individual-controllers", // Run individual controllers in-process. The controller names can change at any time.
+		"workload-cluster-drain-timeout",         // Amount of time to wait for the namespaces and the downstream resources of a deleted cluster to be drained before forcing its deletion
 		"workload-cluster-heartbeat-threshold",   // Amount of time to wait for a successful heartbeat before marking the cluster as not ready.
+		"workload-cluster-pool-grouping-label",   // Key of the label of the WorkloadClusters, e.g. topology.kubernetes.io/region, whose values each get a WorkloadClusterPool with the WorkloadClusters of the workspace having that value. If empty, no pools are generated
+		"workspace-hibernation-idle-timeout",     // Amount of time without requests of users after which a workspace is marked as Hibernated. It is woken up on the next request. If 0, workspaces are never hibernated
+		"workspace-group-mapping-file",           // Path to a YAML file with a list of group, workspace and role (access or admin) mappings. Members of each group are granted the role on the workspace with the given path. If empty, no groups are mapped
+		"workspace-index-check-period",           // Period in which the shards of the ClusterWorkspaces are checked against the contents of the shards. If 0, they are not checked
+		"workspace-index-repair",                 // Point ClusterWorkspaces to the shard holding their objects when the check finds them on another shard
+		"workspace-scheduler-plugins",            // Filter and score plugins run, in order, to schedule workspaces to shards.
+		"workspace-signer-certificate-duration",  // The maximum duration of certificates signed by the CAs of the workspaces. CertificateSigningRequests can ask for shorter certificates through spec.expirationSeconds
 
 		// generic flags
 		"cors-allowed-origins",                 // List of allowed origins for CORS, comma separated.  An allowed origin can be a regular expression to support subdomain matching. If this list is empty CORS will not be enabled.
@@ -191,7 +227,8 @@ var (
 		"proxy-client-key-file",                 // Private key for the client certificate used to prove the identity of the aggregator or kube-apiserver when it must call out during a request. This includes proxying requests to a user api-server and calling out to webhook admission plugins.
 
 		// KCP Virtual Workspaces flags
-		"virtual-workspace-address", // Address of a stand-alone virtual workspace apiserver.
+		"virtual-workspace-address",                // Address of a stand-alone virtual workspace apiserver.
+		"virtual-workspaces-authorization-explain", // Explain the authorization decisions of kcp for hypothetical requests to members of system:masters at /services/debug/authorization.
 	)
 
 	disallowedFlags = sets.NewString(
@@ -19,13 +19,16 @@ package options
 import (
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	genericapiserveroptions "k8s.io/apiserver/pkg/server/options"
 	cliflag "k8s.io/component-base/cli/flag"
 	_ "k8s.io/kubernetes/pkg/features"
@@ -33,6 +36,7 @@ import (
 	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
 
 	kcpadmission "github.com/kcp-dev/kcp/pkg/admission"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 )
 
 type Options struct {
@@ -41,6 +45,9 @@ type Options struct {
 	Controllers         Controllers
 	Authorization       Authorization
 	AdminAuthentication AdminAuthentication
+	TokenCache          TokenCache
+	WildcardLists       WildcardLists
+	Reload              Reload
 	Virtual             Virtual
 
 	Extra ExtraOptions
@@ -53,6 +60,21 @@ type ExtraOptions struct {
 	EnableSharding           bool
 	DiscoveryPollInterval    time.Duration
 	ExperimentalBindFreePort bool
+
+	ShardName                        string
+	RootShardKubeconfigFile          string
+	ShardBaseURL                     string
+	ShardExternalURL                 string
+	ShardVirtualWorkspaceURL         string
+	ShardExternalVirtualWorkspaceURL string
+	ShardPreviousURLRedirect         bool
+	ShardServingCAFile               string
+
+	MirrorShardKubeconfigFile string
+	MirrorWorkspaces          []string
+	MirrorPercentage          int
+
+	Dev bool
 }
 
 type completedOptions struct {
@@ -61,6 +83,9 @@ type completedOptions struct {
 	Controllers         Controllers
 	Authorization       Authorization
 	AdminAuthentication AdminAuthentication
+	TokenCache          TokenCache
+	WildcardLists       WildcardLists
+	Reload              Reload
 	Virtual             Virtual
 
 	Extra ExtraOptions
@@ -80,6 +105,9 @@ func NewOptions() *Options {
 		Controllers:         *NewControllers(),
 		Authorization:       *NewAuthorization(),
 		AdminAuthentication: *NewAdminAuthentication(),
+		TokenCache:          *NewTokenCache(),
+		WildcardLists:       *NewWildcardLists(),
+		Reload:              *NewReload(),
 		Virtual:             *NewVirtual(),
 
 		Extra: ExtraOptions{
@@ -89,6 +117,7 @@ func NewOptions() *Options {
 			EnableSharding:           false,
 			DiscoveryPollInterval:    60 * time.Second,
 			ExperimentalBindFreePort: false,
+			ShardName:                tenancyv1alpha1.RootShard,
 		},
 	}
 
@@ -128,6 +157,9 @@ func (o *Options) rawFlags() cliflag.NamedFlagSets {
 	o.Controllers.AddFlags(fss.FlagSet("KCP Controllers"))
 	o.Authorization.AddFlags(fss.FlagSet("KCP Authorization"))
 	o.AdminAuthentication.AddFlags(fss.FlagSet("KCP Authentication"))
+	o.TokenCache.AddFlags(fss.FlagSet("KCP Authentication"))
+	o.WildcardLists.AddFlags(fss.FlagSet("KCP Wildcard Lists"))
+	o.Reload.AddFlags(fss.FlagSet("KCP Reload"))
 	o.Virtual.AddFlags(fss.FlagSet("KCP Virtual Workspaces"))
 
 	fs := fss.FlagSet("KCP")
@@ -135,6 +167,18 @@ func (o *Options) rawFlags() cliflag.NamedFlagSets {
 	fs.StringVar(&o.Extra.ShardKubeconfigFile, "shard-kubeconfig-file", o.Extra.ShardKubeconfigFile, "Kubeconfig holding admin(!) credentials to peer kcp shards.")
 	fs.BoolVar(&o.Extra.EnableSharding, "enable-sharding", o.Extra.EnableSharding, "Enable delegating to peer kcp shards.")
 	fs.StringVar(&o.Extra.RootDirectory, "root-directory", o.Extra.RootDirectory, "Root directory.")
+	fs.StringVar(&o.Extra.ShardName, "shard-name", o.Extra.ShardName, "Name of this shard. A shard other than the root shard registers itself as ClusterWorkspaceShard of this name in the root shard.")
+	fs.StringVar(&o.Extra.RootShardKubeconfigFile, "root-shard-kubeconfig-file", o.Extra.RootShardKubeconfigFile, "Kubeconfig of the root shard, used to register this shard. Required unless this is the root shard.")
+	fs.StringVar(&o.Extra.ShardBaseURL, "shard-base-url", o.Extra.ShardBaseURL, "Base URL of this shard for direct connections, registered in its ClusterWorkspaceShard. Defaults to the external address of the shard.")
+	fs.StringVar(&o.Extra.ShardExternalURL, "shard-external-url", o.Extra.ShardExternalURL, "URL presented to users in the workspaces of this shard, registered in its ClusterWorkspaceShard. Defaults to the base URL.")
+	fs.StringVar(&o.Extra.ShardVirtualWorkspaceURL, "shard-virtual-workspace-url", o.Extra.ShardVirtualWorkspaceURL, "URL of the virtual workspaces of this shard, registered in its ClusterWorkspaceShard. Defaults to the base URL, or to --virtual-workspace-address if virtual workspaces run out-of-process.")
+	fs.StringVar(&o.Extra.ShardExternalVirtualWorkspaceURL, "shard-external-virtual-workspace-url", o.Extra.ShardExternalVirtualWorkspaceURL, "URL of the virtual workspaces of this shard presented to users, if different from the internal one in split-horizon deployments. Defaults to --shard-virtual-workspace-url, or to the external URL if virtual workspaces run in-process.")
+	fs.StringVar(&o.Extra.ShardServingCAFile, "shard-serving-ca-file", o.Extra.ShardServingCAFile, "CA bundle the serving certificates of shards joining the root shard are verified against. The serving CA of the root shard is always trusted.")
+	fs.BoolVar(&o.Extra.ShardPreviousURLRedirect, "shard-previous-url-redirect", o.Extra.ShardPreviousURLRedirect, "Redirect requests to a previous URL of this shard, as recorded on its ClusterWorkspaceShard, to the current external URL. Otherwise these requests are served with a warning.")
+	fs.StringVar(&o.Extra.MirrorShardKubeconfigFile, "mirror-shard-kubeconfig-file", o.Extra.MirrorShardKubeconfigFile, "Kubeconfig of a shard to mirror read requests to, e.g. a copy of this shard on a new storage, to validate a migration. It must hold credentials allowed to impersonate users. Diverging responses are counted in the kcp_shard_mirror_requests_total metric.")
+	fs.StringSliceVar(&o.Extra.MirrorWorkspaces, "mirror-workspaces", o.Extra.MirrorWorkspaces, "Logical clusters whose read requests are mirrored to the shard of --mirror-shard-kubeconfig-file.")
+	fs.IntVar(&o.Extra.MirrorPercentage, "mirror-percentage", o.Extra.MirrorPercentage, "Percentage of the read requests to --mirror-workspaces which are mirrored.")
+	fs.BoolVar(&o.Extra.Dev, "dev", o.Extra.Dev, "Seed demo content for a first tour of kcp: an organization root:demo with the workspaces provider and consumer, an APIExport of cowboys in the provider workspace bound in the consumer workspace, and a WorkloadCluster in the consumer workspace synced to a physical cluster simulated in-process. Implies --auto-publish-apis.")
 	fs.DurationVar(&o.Extra.DiscoveryPollInterval, "discovery-poll-interval", o.Extra.DiscoveryPollInterval, "Polling interval for dynamic discovery informers.")
 
 	fs.BoolVar(&o.Extra.ExperimentalBindFreePort, "experimental-bind-free-port", o.Extra.ExperimentalBindFreePort, "Bind to a free port. --secure-port must be 0. Use the admin.kubeconfig to extract the chosen port.")
@@ -157,12 +201,49 @@ func (o *CompletedOptions) Validate() []error {
 	errs = append(errs, o.EmbeddedEtcd.Validate()...)
 	errs = append(errs, o.Authorization.Validate()...)
 	errs = append(errs, o.AdminAuthentication.Validate()...)
+	errs = append(errs, o.TokenCache.Validate()...)
+	errs = append(errs, o.WildcardLists.Validate()...)
+	errs = append(errs, o.Reload.Validate()...)
 	errs = append(errs, o.Virtual.Validate()...)
 
 	if o.Extra.DiscoveryPollInterval == 0 {
 		errs = append(errs, fmt.Errorf("--discovery-poll-interval not set"))
 	}
 
+	if msgs := validation.IsDNS1123Subdomain(o.Extra.ShardName); len(msgs) > 0 {
+		errs = append(errs, fmt.Errorf("--shard-name %q is invalid: %s", o.Extra.ShardName, strings.Join(msgs, ", ")))
+	}
+	if o.Extra.ShardName != tenancyv1alpha1.RootShard && o.Extra.RootShardKubeconfigFile == "" {
+		errs = append(errs, fmt.Errorf("--root-shard-kubeconfig-file is required if --shard-name is not %q", tenancyv1alpha1.RootShard))
+	}
+	if o.Extra.MirrorShardKubeconfigFile != "" && len(o.Extra.MirrorWorkspaces) == 0 {
+		errs = append(errs, fmt.Errorf("--mirror-workspaces is required if --mirror-shard-kubeconfig-file is set"))
+	}
+	if o.Extra.MirrorPercentage < 0 || o.Extra.MirrorPercentage > 100 {
+		errs = append(errs, fmt.Errorf("--mirror-percentage must be between 0 and 100"))
+	}
+	if o.Extra.Dev && o.Extra.ShardName != tenancyv1alpha1.RootShard {
+		errs = append(errs, fmt.Errorf("--dev is only supported on the root shard"))
+	}
+	if o.Extra.Dev && !o.Controllers.EnableAll {
+		errs = append(errs, fmt.Errorf("--dev requires --run-controllers"))
+	}
+	for flag, value := range map[string]string{
+		"--shard-base-url":                       o.Extra.ShardBaseURL,
+		"--shard-external-url":                   o.Extra.ShardExternalURL,
+		"--shard-virtual-workspace-url":          o.Extra.ShardVirtualWorkspaceURL,
+		"--shard-external-virtual-workspace-url": o.Extra.ShardExternalVirtualWorkspaceURL,
+	} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s must be a valid URL: %w", flag, err))
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			errs = append(errs, fmt.Errorf("%s must be an http or https URL", flag))
+		}
+	}
+
 	return errs
 }
 
@@ -205,6 +286,11 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 		o.GenericControlPlane.SecureServing.Listener = listener
 	}
 
+	if o.Extra.Dev {
+		// the APIs of the simulated physical cluster are usable right away
+		o.Controllers.ApiResource.AutoPublishAPIs = true
+	}
+
 	if err := o.Controllers.Complete(o.Extra.RootDirectory); err != nil {
 		return nil, err
 	}
@@ -238,6 +324,9 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 			Controllers:         o.Controllers,
 			Authorization:       o.Authorization,
 			AdminAuthentication: o.AdminAuthentication,
+			TokenCache:          o.TokenCache,
+			WildcardLists:       o.WildcardLists,
+			Reload:              o.Reload,
 			Virtual:             o.Virtual,
 			Extra:               o.Extra,
 		},
@@ -0,0 +1,39 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteWildcardLists(t *testing.T) {
+	o := NewOptions()
+	o.Extra.RootDirectory = t.TempDir()
+	fss := o.rawFlags()
+	fs := fss.FlagSet("KCP Wildcard Lists")
+	require.NoError(t, fs.Parse([]string{
+		"--wildcard-list-require-limit",
+		"--wildcard-list-max-limit=500",
+		"--wildcard-list-max-inflight-per-user=3",
+	}))
+
+	completed, err := o.Complete()
+	require.NoError(t, err)
+	require.Equal(t, WildcardLists{RequireLimit: true, MaxLimit: 500, MaxInflightPerUser: 3}, completed.WildcardLists)
+}
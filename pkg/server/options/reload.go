@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Reload configures the reloading of selected options while the server runs, i.e. without
+// restarting the shard.
+type Reload struct {
+	// ConfigFile holds the ReloadableConfig. It, and the audit policy file, are reloaded on
+	// SIGHUP and when they change.
+	ConfigFile string
+}
+
+// ReloadableConfig are the options which can be changed while the server runs, read from
+// --reload-config-file.
+type ReloadableConfig struct {
+	// verbosity overrides -v, the log level verbosity.
+	Verbosity *int32 `json:"verbosity,omitempty"`
+
+	// workloadClusterHeartbeatThreshold overrides --workload-cluster-heartbeat-threshold.
+	WorkloadClusterHeartbeatThreshold *metav1.Duration `json:"workloadClusterHeartbeatThreshold,omitempty"`
+}
+
+func NewReload() *Reload {
+	return &Reload{}
+}
+
+func (s *Reload) Validate() []error {
+	if s == nil {
+		return nil
+	}
+
+	errs := []error{}
+
+	if s.ConfigFile != "" {
+		if _, err := LoadReloadableConfig(s.ConfigFile); err != nil {
+			errs = append(errs, fmt.Errorf("--reload-config-file: %w", err))
+		}
+	}
+
+	return errs
+}
+
+func (s *Reload) AddFlags(fs *pflag.FlagSet) {
+	if s == nil {
+		return
+	}
+
+	fs.StringVar(&s.ConfigFile, "reload-config-file", s.ConfigFile,
+		"File with options applied without restarting, i.e. verbosity and workloadClusterHeartbeatThreshold. "+
+			"It, and the file of --audit-policy-file, are reloaded on SIGHUP and when they change.")
+}
+
+// LoadReloadableConfig reads and validates the ReloadableConfig in the given file.
+func LoadReloadableConfig(path string) (*ReloadableConfig, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReloadableConfig(bs)
+}
+
+// ParseReloadableConfig parses and validates a ReloadableConfig.
+func ParseReloadableConfig(bs []byte) (*ReloadableConfig, error) {
+	config := &ReloadableConfig{}
+	if err := yaml.UnmarshalStrict(bs, config); err != nil {
+		return nil, err
+	}
+	if config.Verbosity != nil && *config.Verbosity < 0 {
+		return nil, fmt.Errorf("verbosity must be >=0 (%d)", *config.Verbosity)
+	}
+	if config.WorkloadClusterHeartbeatThreshold != nil && config.WorkloadClusterHeartbeatThreshold.Duration <= 0 {
+		return nil, fmt.Errorf("workloadClusterHeartbeatThreshold must be >0 (%s)", config.WorkloadClusterHeartbeatThreshold.Duration)
+	}
+	return config, nil
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/spf13/pflag"
 
+	eventsoptions "github.com/kcp-dev/kcp/pkg/virtual/events/options"
 	workspacesoptions "github.com/kcp-dev/kcp/pkg/virtual/workspaces/options"
 )
 
@@ -29,8 +30,13 @@ const virtualWorkspacesFlagPrefix = "virtual-workspaces-"
 
 type Virtual struct {
 	Workspaces workspacesoptions.Workspaces
+	Events     eventsoptions.Events
 	Enabled    bool
 
+	// AuthorizationExplain serves explanations of the authorization decisions of kcp for
+	// hypothetical requests to system:masters at /services/debug/authorization.
+	AuthorizationExplain bool
+
 	// ExternalVirtualWorkspaceAddress holds a URL to redirect to for stand-alone virtual workspaces.
 	ExternalVirtualWorkspaceAddress string
 }
@@ -38,6 +44,7 @@ type Virtual struct {
 func NewVirtual() *Virtual {
 	return &Virtual{
 		Workspaces: *workspacesoptions.NewWorkspaces(),
+		Events:     *eventsoptions.NewEvents(),
 
 		Enabled: true,
 	}
@@ -48,11 +55,15 @@ func (v *Virtual) Validate() []error {
 
 	if v.Enabled {
 		errs = append(errs, v.Workspaces.Validate(virtualWorkspacesFlagPrefix)...)
+		errs = append(errs, v.Events.Validate(virtualWorkspacesFlagPrefix)...)
 
 		if v.ExternalVirtualWorkspaceAddress != "" {
 			errs = append(errs, fmt.Errorf("--virtual-workspace-address must be empty if virtual workspaces run in-process"))
 		}
 	} else {
+		if v.AuthorizationExplain {
+			errs = append(errs, fmt.Errorf("--%sauthorization-explain requires the virtual workspaces to run in-process", virtualWorkspacesFlagPrefix))
+		}
 		if v.ExternalVirtualWorkspaceAddress == "" {
 			errs = append(errs, fmt.Errorf("--virtual-workspace-address is required if virtual workspaces run out-of-process"))
 		} else if u, err := url.Parse(v.ExternalVirtualWorkspaceAddress); err != nil {
@@ -67,7 +78,9 @@ func (v *Virtual) Validate() []error {
 
 func (v *Virtual) AddFlags(fs *pflag.FlagSet) {
 	v.Workspaces.AddFlags(fs, virtualWorkspacesFlagPrefix)
+	v.Events.AddFlags(fs, virtualWorkspacesFlagPrefix)
 
 	fs.BoolVar(&v.Enabled, "run-virtual-workspaces", v.Enabled, "Run the virtual workspace apiservers in-process")
+	fs.BoolVar(&v.AuthorizationExplain, virtualWorkspacesFlagPrefix+"authorization-explain", v.AuthorizationExplain, "Explain the authorization decisions of kcp for hypothetical requests, described by a SubjectAccessReview, to members of system:masters at /services/debug/authorization")
 	fs.StringVar(&v.ExternalVirtualWorkspaceAddress, "virtual-workspace-address", v.ExternalVirtualWorkspaceAddress, "Address of a stand-alone virtual workspace apiserver (without the /services path)")
 }
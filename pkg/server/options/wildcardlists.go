@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// WildcardLists configures the guards of list requests in the `*` logical cluster, i.e.
+// across all workspaces of the shard. kcp itself is not subject to them.
+type WildcardLists struct {
+	RequireLimit       bool
+	MaxLimit           int64
+	MaxInflightPerUser int
+}
+
+func NewWildcardLists() *WildcardLists {
+	return &WildcardLists{}
+}
+
+func (s *WildcardLists) Validate() []error {
+	if s == nil {
+		return nil
+	}
+
+	errs := []error{}
+
+	if s.MaxLimit < 0 {
+		errs = append(errs, fmt.Errorf("--wildcard-list-max-limit must be >=0 (%d)", s.MaxLimit))
+	}
+	if s.MaxInflightPerUser < 0 {
+		errs = append(errs, fmt.Errorf("--wildcard-list-max-inflight-per-user must be >=0 (%d)", s.MaxInflightPerUser))
+	}
+
+	return errs
+}
+
+func (s *WildcardLists) AddFlags(fs *pflag.FlagSet) {
+	if s == nil {
+		return
+	}
+
+	fs.BoolVar(&s.RequireLimit, "wildcard-list-require-limit", s.RequireLimit,
+		"Reject list requests across all workspaces without a limit, i.e. require them to be paginated.")
+	fs.Int64Var(&s.MaxLimit, "wildcard-list-max-limit", s.MaxLimit,
+		"Maximum limit of list requests across all workspaces. Requests without a limit are rejected too. 0 means no maximum.")
+	fs.IntVar(&s.MaxInflightPerUser, "wildcard-list-max-inflight-per-user", s.MaxInflightPerUser,
+		"Maximum number of list requests across all workspaces served concurrently for a user. Further requests are rejected with 429. 0 means no maximum.")
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/audit/policy"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/klog/v2"
+
+	kcpserveroptions "github.com/kcp-dev/kcp/pkg/server/options"
+)
+
+// reloadCheckPeriod is how often the reloaded files are checked for changes, e.g. when they are
+// mounted from a ConfigMap.
+const reloadCheckPeriod = 10 * time.Second
+
+// optionsReloader applies the options of --reload-config-file, and the audit policy of
+// --audit-policy-file, while the server runs. The files are read on SIGHUP and periodically, and
+// applied when their contents changed. Invalid contents are logged once and ignored, i.e. the
+// previous options stay in effect.
+type optionsReloader struct {
+	configFile      string
+	auditPolicyFile string
+
+	// defaultHeartbeatThreshold is the value of --workload-cluster-heartbeat-threshold.
+	defaultHeartbeatThreshold time.Duration
+	heartbeatThreshold        int64 // nanoseconds, accessed atomically
+
+	// auditPolicy is the evaluator of the audit policy used by the handler chain, if any.
+	auditPolicy *reloadablePolicyRuleEvaluator
+
+	// defaultVerbosity is the value of -v.
+	defaultVerbosity int32
+	verbosity        int32
+	setVerbosity     func(level int32) error
+
+	lock            sync.Mutex
+	lastConfig      []byte
+	lastAuditPolicy []byte
+}
+
+func newOptionsReloader(configFile, auditPolicyFile string, defaultHeartbeatThreshold time.Duration, defaultVerbosity int32) *optionsReloader {
+	return &optionsReloader{
+		configFile:                configFile,
+		auditPolicyFile:           auditPolicyFile,
+		defaultHeartbeatThreshold: defaultHeartbeatThreshold,
+		heartbeatThreshold:        int64(defaultHeartbeatThreshold),
+		defaultVerbosity:          defaultVerbosity,
+		verbosity:                 defaultVerbosity,
+		setVerbosity: func(level int32) error {
+			var l klog.Level
+			return l.Set(strconv.Itoa(int(level)))
+		},
+	}
+}
+
+// WorkloadClusterHeartbeatThreshold returns the current heartbeat threshold of workload clusters.
+func (r *optionsReloader) WorkloadClusterHeartbeatThreshold() time.Duration {
+	return time.Duration(atomic.LoadInt64(&r.heartbeatThreshold))
+}
+
+// WrapAuditPolicyRuleEvaluator returns an evaluator delegating to the one of the last audit
+// policy loaded from the audit policy file, starting with the given one.
+func (r *optionsReloader) WrapAuditPolicyRuleEvaluator(evaluator audit.PolicyRuleEvaluator) audit.PolicyRuleEvaluator {
+	if evaluator == nil || r.auditPolicyFile == "" {
+		return evaluator
+	}
+	r.auditPolicy = &reloadablePolicyRuleEvaluator{}
+	r.auditPolicy.evaluator.Store(evaluator)
+	return r.auditPolicy
+}
+
+// Run reloads the files on SIGHUP and periodically until the context is done.
+func (r *optionsReloader) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(reloadCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			klog.Infof("Reloading options on SIGHUP")
+		case <-ticker.C:
+		}
+		if err := r.reload(); err != nil {
+			klog.Errorf("Failed to reload options, keeping the previous ones: %v", err)
+		}
+	}
+}
+
+// reload reads the files and applies those whose contents changed since the last reload.
+func (r *optionsReloader) reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var errs []error
+	if bs, err := os.ReadFile(r.configFile); err != nil {
+		errs = append(errs, err)
+	} else if !bytes.Equal(bs, r.lastConfig) {
+		// invalid contents are reported once, not on every check
+		r.lastConfig = bs
+		if err := r.applyConfig(bs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if r.auditPolicy != nil {
+		if bs, err := os.ReadFile(r.auditPolicyFile); err != nil {
+			errs = append(errs, err)
+		} else if !bytes.Equal(bs, r.lastAuditPolicy) {
+			// the first load is the policy the server started with
+			initial := r.lastAuditPolicy == nil
+			r.lastAuditPolicy = bs
+			if p, err := policy.LoadPolicyFromBytes(bs); err != nil {
+				errs = append(errs, err)
+			} else {
+				if !initial {
+					klog.Infof("Applying the audit policy of %s", r.auditPolicyFile)
+				}
+				r.auditPolicy.evaluator.Store(policy.NewPolicyRuleEvaluator(p))
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (r *optionsReloader) applyConfig(bs []byte) error {
+	config, err := kcpserveroptions.ParseReloadableConfig(bs)
+	if err != nil {
+		return err
+	}
+
+	verbosity := r.defaultVerbosity
+	if config.Verbosity != nil {
+		verbosity = *config.Verbosity
+	}
+	if verbosity != r.verbosity {
+		if err := r.setVerbosity(verbosity); err != nil {
+			return err
+		}
+		r.verbosity = verbosity
+	}
+
+	heartbeatThreshold := r.defaultHeartbeatThreshold
+	if config.WorkloadClusterHeartbeatThreshold != nil {
+		heartbeatThreshold = config.WorkloadClusterHeartbeatThreshold.Duration
+	}
+	atomic.StoreInt64(&r.heartbeatThreshold, int64(heartbeatThreshold))
+
+	klog.Infof("Applied the options of %s", r.configFile)
+	return nil
+}
+
+// reloadablePolicyRuleEvaluator evaluates requests against the audit policy loaded last.
+type reloadablePolicyRuleEvaluator struct {
+	evaluator atomic.Value // audit.PolicyRuleEvaluator
+}
+
+func (e *reloadablePolicyRuleEvaluator) EvaluatePolicyRule(attrs authorizer.Attributes) audit.RequestAuditConfigWithLevel {
+	return e.evaluator.Load().(audit.PolicyRuleEvaluator).EvaluatePolicyRule(attrs)
+}
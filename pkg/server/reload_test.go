@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit/policy"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+const (
+	metadataPolicy = `apiVersion: audit.k8s.io/v1
+kind: Policy
+rules:
+- level: Metadata
+`
+	requestPolicy = `apiVersion: audit.k8s.io/v1
+kind: Policy
+rules:
+- level: Request
+`
+)
+
+func TestOptionsReloader(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	auditPolicyFile := filepath.Join(dir, "audit-policy.yaml")
+	write := func(path, contents string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	}
+	write(configFile, "")
+	write(auditPolicyFile, metadataPolicy)
+
+	r := newOptionsReloader(configFile, auditPolicyFile, time.Minute, 1)
+	var verbosity []int32
+	r.setVerbosity = func(level int32) error {
+		verbosity = append(verbosity, level)
+		return nil
+	}
+	p, err := policy.LoadPolicyFromFile(auditPolicyFile)
+	require.NoError(t, err)
+	evaluator := r.WrapAuditPolicyRuleEvaluator(policy.NewPolicyRuleEvaluator(p))
+	auditLevel := func() auditinternal.Level {
+		return evaluator.EvaluatePolicyRule(&authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "alice"}, Verb: "get", ResourceRequest: true}).Level
+	}
+
+	// the initial files
+	require.NoError(t, r.reload())
+	require.Equal(t, time.Minute, r.WorkloadClusterHeartbeatThreshold())
+	require.Equal(t, auditinternal.LevelMetadata, auditLevel())
+	require.Empty(t, verbosity)
+
+	// changed options are applied
+	write(configFile, "verbosity: 4\nworkloadClusterHeartbeatThreshold: 2m\n")
+	write(auditPolicyFile, requestPolicy)
+	require.NoError(t, r.reload())
+	require.Equal(t, 2*time.Minute, r.WorkloadClusterHeartbeatThreshold())
+	require.Equal(t, auditinternal.LevelRequest, auditLevel())
+	require.Equal(t, []int32{4}, verbosity)
+
+	// unchanged files are not applied again
+	require.NoError(t, r.reload())
+	require.Equal(t, []int32{4}, verbosity)
+
+	// invalid contents are reported once and ignored
+	write(configFile, "workloadClusterHeartbeatThreshold: -1s\n")
+	write(auditPolicyFile, "kind: Unknown\n")
+	require.Error(t, r.reload())
+	require.NoError(t, r.reload())
+	require.Equal(t, 2*time.Minute, r.WorkloadClusterHeartbeatThreshold())
+	require.Equal(t, auditinternal.LevelRequest, auditLevel())
+
+	// removed options fall back to the flags
+	write(configFile, "verbosity: 2\n")
+	require.NoError(t, r.reload())
+	require.Equal(t, time.Minute, r.WorkloadClusterHeartbeatThreshold())
+	require.Equal(t, []int32{4, 2}, verbosity)
+	write(configFile, "")
+	require.NoError(t, r.reload())
+	require.Equal(t, []int32{4, 2, 1}, verbosity)
+
+	// unknown options are rejected
+	write(configFile, "heartbeat: 1m\n")
+	require.Error(t, r.reload())
+}
@@ -22,6 +22,7 @@ import (
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
@@ -32,11 +33,14 @@ import (
 	"k8s.io/apiserver/pkg/admission"
 	"k8s.io/apiserver/pkg/endpoints/filters"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/apiserver/pkg/util/webhook"
 	"k8s.io/client-go/dynamic"
 	coreexternalversions "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/genericcontrolplane"
@@ -45,13 +49,21 @@ import (
 	"github.com/kcp-dev/kcp/config/system-crds"
 	kcpadmissioninitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 	"github.com/kcp-dev/kcp/pkg/authentication"
 	bootstrappolicy "github.com/kcp-dev/kcp/pkg/authorization/bootstrap"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpexternalversions "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/etcd"
+	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
+	kcpflowcontrol "github.com/kcp-dev/kcp/pkg/flowcontrol"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
+	"github.com/kcp-dev/kcp/pkg/reconciler/framework"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/hibernation"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/workspacesigner"
 	kcpserveroptions "github.com/kcp-dev/kcp/pkg/server/options"
 	"github.com/kcp-dev/kcp/pkg/sharding"
+	"github.com/kcp-dev/kcp/pkg/workspacemetrics"
 )
 
 const resyncPeriod = 10 * time.Hour
@@ -85,6 +97,22 @@ type Server struct {
 	// TODO(sttts): get rid of these. We have wildcard informers already.
 	rootKcpSharedInformerFactory  kcpexternalversions.SharedInformerFactory
 	rootKubeSharedInformerFactory coreexternalversions.SharedInformerFactory
+
+	// activityTracker records the requests of users per workspace, if hibernation is enabled.
+	activityTracker *hibernation.ActivityTracker
+
+	// optionsReloader applies selected options while the server runs, if --reload-config-file is set.
+	optionsReloader *optionsReloader
+
+	// controllers tracks the controllers which complete their in-flight reconciles on shutdown.
+	controllers sync.WaitGroup
+
+	// shardFencingToken is the fencing token the root shard issued to this shard, presented on
+	// requests to the root shard.
+	shardFencingToken sharding.FencingToken
+
+	// rootKcpClient reaches the root workspace, through the root shard on non-root shards.
+	rootKcpClient kcpclient.Interface
 }
 
 // NewServer creates a new instance of Server which manages the KCP api-server.
@@ -152,6 +180,15 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 
+	if s.options.Reload.ConfigFile != "" {
+		s.optionsReloader = newOptionsReloader(s.options.Reload.ConfigFile, s.options.GenericControlPlane.Audit.PolicyFile, s.options.Controllers.WorkloadClusterHeartbeat.HeartbeatThreshold, int32(s.options.GenericControlPlane.Logs.Config.Verbosity))
+		genericConfig.AuditPolicyRuleEvaluator = s.optionsReloader.WrapAuditPolicyRuleEvaluator(genericConfig.AuditPolicyRuleEvaluator)
+		if err := s.optionsReloader.reload(); err != nil {
+			return err
+		}
+		go s.optionsReloader.Run(ctx)
+	}
+
 	// Setup kcp * informers
 	kcpClusterClient, err := kcpclient.NewClusterForConfig(genericConfig.LoopbackClientConfig)
 	if err != nil {
@@ -168,6 +205,19 @@ func (s *Server) Run(ctx context.Context) error {
 	kubeClient := kubeClusterClient.Cluster(logicalcluster.Wildcard)
 	s.kubeSharedInformerFactory = coreexternalversions.NewSharedInformerFactoryWithOptions(kubeClient, resyncPeriod)
 
+	// Let workspaces bring their own API priority and fairness config
+	if genericConfig.FlowControl != nil {
+		runOptions := s.options.GenericControlPlane.GenericServerRunOptions
+		genericConfig.FlowControl = kcpflowcontrol.NewWorkspaceAwareFlowControl(
+			genericConfig.FlowControl,
+			genericcontrolplane.LocalAdminCluster,
+			s.kubeSharedInformerFactory,
+			kubeClusterClient,
+			runOptions.MaxRequestsInFlight+runOptions.MaxMutatingRequestsInFlight,
+			runOptions.RequestTimeout/4,
+		)
+	}
+
 	// Setup apiextensions * informers
 	apiextensionsClusterClient, err := apiextensionsclient.NewClusterForConfig(genericConfig.LoopbackClientConfig)
 	if err != nil {
@@ -180,13 +230,36 @@ func (s *Server) Run(ctx context.Context) error {
 	s.rootKcpSharedInformerFactory = kcpexternalversions.NewSharedInformerFactoryWithOptions(kcpClusterClient.Cluster(v1alpha1.RootCluster), resyncPeriod)
 	s.rootKubeSharedInformerFactory = coreexternalversions.NewSharedInformerFactoryWithOptions(kubeClusterClient.Cluster(v1alpha1.RootCluster), resyncPeriod)
 
+	// Names unique across shards are reserved in the root workspace, which non-root shards
+	// reach through the root shard.
+	rootKcpClient := kcpClusterClient.Cluster(v1alpha1.RootCluster)
+	if s.options.Extra.ShardName != v1alpha1.RootShard {
+		rootShardConfig, err := clientcmd.BuildConfigFromFlags("", s.options.Extra.RootShardKubeconfigFile)
+		if err != nil {
+			return fmt.Errorf("failed to load root shard kubeconfig %q: %w", s.options.Extra.RootShardKubeconfigFile, err)
+		}
+		rootShardConfig = sharding.WithShardIdentity(rootShardConfig, s.options.Extra.ShardName, &s.shardFencingToken)
+		rootShardKcpClusterClient, err := kcpclient.NewClusterForConfig(rest.AddUserAgent(rootShardConfig, "kcp-name-reservation"))
+		if err != nil {
+			return err
+		}
+		rootKcpClient = rootShardKcpClusterClient.Cluster(v1alpha1.RootCluster)
+	}
+	s.rootKcpClient = rootKcpClient
+
 	// Setup dynamic client
 	dynamicClusterClient, err := dynamic.NewClusterForConfig(genericConfig.LoopbackClientConfig)
 	if err != nil {
 		return err
 	}
 
-	if err := s.options.Authorization.ApplyTo(genericConfig, s.kubeSharedInformerFactory, s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces().Lister()); err != nil {
+	systemWorkspaces := []logicalcluster.LogicalCluster{
+		genericcontrolplane.LocalAdminCluster,
+		SystemCRDLogicalCluster,
+		apibinding.ShadowWorkspaceName,
+		workspacesigner.SignerWorkspaceName,
+	}
+	if err := s.options.Authorization.ApplyTo(genericConfig, s.kubeSharedInformerFactory, s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces().Lister(), systemWorkspaces); err != nil {
 		return err
 	}
 	newTokenOrEmpty, tokenHash, err := s.options.AdminAuthentication.ApplyTo(genericConfig)
@@ -202,6 +275,62 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 
+	// cache the authentication of bearer tokens, per audience, and drop the entries of service accounts
+	// whose tokens might be revoked
+	if genericConfig.Authentication.Authenticator != nil && s.options.TokenCache.Enabled() {
+		tokenCache := authentication.NewTokenCache(
+			genericConfig.Authentication.Authenticator,
+			s.options.TokenCache.Size,
+			s.options.TokenCache.TTL,
+			s.options.TokenCache.FailureTTL,
+		)
+		invalidate := tokenCache.InvalidationHandler()
+		s.kubeSharedInformerFactory.Core().V1().ServiceAccounts().Informer().AddEventHandler(invalidate)
+		s.kubeSharedInformerFactory.Core().V1().Secrets().Informer().AddEventHandler(invalidate)
+		genericConfig.Authentication.Authenticator = tokenCache
+	}
+
+	// accept service account tokens for the audience of the workspace and those configured in its spec
+	if genericConfig.Authentication.Authenticator != nil {
+		genericConfig.Authentication.Authenticator = authentication.WithWorkspaceAudiences(
+			genericConfig.Authentication.Authenticator,
+			genericConfig.Authentication.APIAudiences,
+			s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces().Lister(),
+		)
+	}
+
+	if s.options.Controllers.WorkspaceHibernation.IdleTimeout > 0 {
+		s.activityTracker = hibernation.NewActivityTracker()
+	}
+
+	var workspaceMetrics *workspacemetrics.Recorder
+	if utilfeature.DefaultFeatureGate.Enabled(kcpfeatures.WorkspaceMetrics) {
+		workspaceMetrics = workspacemetrics.NewRecorder()
+	}
+
+	var mirror *sharding.Mirror
+	if s.options.Extra.MirrorShardKubeconfigFile != "" && s.options.Extra.MirrorPercentage > 0 {
+		mirrorConfig, err := clientcmd.BuildConfigFromFlags("", s.options.Extra.MirrorShardKubeconfigFile)
+		if err != nil {
+			return fmt.Errorf("failed to load mirror shard kubeconfig %q: %w", s.options.Extra.MirrorShardKubeconfigFile, err)
+		}
+		mirror, err = sharding.NewMirror(mirrorConfig, s.options.Extra.MirrorWorkspaces, s.options.Extra.MirrorPercentage)
+		if err != nil {
+			return err
+		}
+	}
+
+	deprecationWarner, err := NewDeprecationWarner(s.kcpSharedInformerFactory)
+	if err != nil {
+		return err
+	}
+
+	shardLister := s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards().Lister()
+	apiExportInformer := s.kcpSharedInformerFactory.Apis().V1alpha1().APIExports().Informer()
+	if err := apiExportInformer.AddIndexers(cache.Indexers{APIExportsByIdentityIndex: IndexAPIExportsByIdentity}); err != nil {
+		return err
+	}
+
 	// preHandlerChainMux is called before the actual handler chain. Note that BuildHandlerChainFunc below
 	// is called multiple times, but only one of the handler chain will actually be used. Hence, we wrap it
 	// to give handlers below one mux.Handle func to call.
@@ -209,6 +338,7 @@ func (s *Server) Run(ctx context.Context) error {
 	genericConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, c *genericapiserver.Config) (secure http.Handler) {
 		// we want a request to hit the chain like:
 		// - lcluster handler (this package's ServeHTTP)
+		// - shard fencing (sharding.WithShardFencing)
 		// - shard proxy (sharding.ServeHTTP)
 		// - original handler chain
 		// the lcluster handler is a pass-through, not a delegate, so the wrapping looks weird
@@ -217,7 +347,25 @@ func (s *Server) Run(ctx context.Context) error {
 			clientLoader.Add(genericConfig.ExternalAddress, genericConfig.LoopbackClientConfig)
 			apiHandler = sharding.WithSharding(apiHandler, clientLoader)
 		}
+		if mirror != nil {
+			apiHandler = sharding.WithMirroring(apiHandler, mirror)
+		}
+		apiHandler = sharding.WithShardFencing(apiHandler, shardLister)
 		apiHandler = WithWildcardListWatchGuard(apiHandler)
+		apiHandler = WithWildcardListLimits(apiHandler, WildcardListLimits{
+			RequireLimit:       s.options.WildcardLists.RequireLimit,
+			MaxLimit:           s.options.WildcardLists.MaxLimit,
+			MaxInflightPerUser: s.options.WildcardLists.MaxInflightPerUser,
+		})
+		apiHandler = WithMigrationFence(apiHandler, s.kcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces().Lister(), s.options.Extra.ShardName)
+		apiHandler = WithDeprecationWarnings(apiHandler, deprecationWarner)
+		apiHandler = WithImpersonationAuditAnnotations(apiHandler)
+		if s.activityTracker != nil {
+			apiHandler = WithActivityTracking(apiHandler, s.activityTracker)
+		}
+		if workspaceMetrics != nil {
+			apiHandler = workspacemetrics.WithMetrics(apiHandler, workspaceMetrics)
+		}
 		apiHandler = genericapiserver.DefaultBuildHandlerChain(apiHandler, c)
 
 		// this will be replaced in DefaultBuildHandlerChain. So at worst we get twice as many warning.
@@ -230,21 +378,28 @@ func (s *Server) Run(ctx context.Context) error {
 		preHandlerChainMux = append(preHandlerChainMux, mux)
 		apiHandler = mux
 
+		apiHandler = WithWildcardIdentity(apiHandler, apiExportInformer.GetIndexer())
 		apiHandler = WithWorkspaceProjection(apiHandler)
 		apiHandler = WithClusterScope(apiHandler)
 		apiHandler = WithInClusterServiceAccountRequestRewrite(apiHandler, unsafeServiceAccountPreAuth)
 		apiHandler = WithAcceptHeader(apiHandler)
+		apiHandler = WithShardURLRedirect(apiHandler, s.rootKcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards().Lister(), s.options.Extra.ShardName, s.options.Extra.ShardPreviousURLRedirect)
 
 		return apiHandler
 	}
 
 	admissionPluginInitializers := []admission.PluginInitializer{
 		kcpadmissioninitializers.NewKcpInformersInitializer(s.kcpSharedInformerFactory),
+		kcpadmissioninitializers.NewRootKcpInformersInitializer(s.rootKcpSharedInformerFactory),
 		kcpadmissioninitializers.NewKubeClusterClientInitializer(kubeClusterClient),
 		kcpadmissioninitializers.NewKcpClusterClientInitializer(kcpClusterClient),
+		kcpadmissioninitializers.NewRootKcpClientInitializer(rootKcpClient),
+		kcpadmissioninitializers.NewDynamicClusterClientInitializer(dynamicClusterClient),
 		// The external address is provided as a function, as its value may be updated
 		// with the default secure port, when the config is later completed.
 		kcpadmissioninitializers.NewExternalAddressInitializer(func() string { return genericConfig.ExternalAddress }),
+		kcpadmissioninitializers.NewAPIAudiencesInitializer(genericConfig.Authentication.APIAudiences),
+		kcpadmissioninitializers.NewWorkspaceSchedulerPluginsInitializer(s.options.Controllers.WorkspaceScheduler.Plugins),
 	}
 
 	apisConfig, err := genericcontrolplane.CreateKubeAPIServerConfig(genericConfig, s.options.GenericControlPlane, s.kubeSharedInformerFactory, admissionPluginInitializers, storageFactory)
@@ -300,6 +455,9 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 	server := serverChain.MiniAggregator.GenericAPIServer
+	server.Handler.NonGoRestfulMux.Handle("/featuregates", kcpfeatures.Handler(utilfeature.DefaultMutableFeatureGate))
+	server.Handler.NonGoRestfulMux.Handle("/debug/controllers", WithPrivilegedUsers(framework.DumpHandler()))
+	server.Handler.NonGoRestfulMux.Handle("/debug/controllers/quarantine", WithPrivilegedUsers(framework.QuarantineHandler()))
 	serverChain.GenericControlPlane.GenericAPIServer.Handler.GoRestfulContainer.Filter(
 		mergeCRDsIntoCoreGroup(
 			serverChain.CustomResourceDefinitions.Informers.Apiextensions().V1().CustomResourceDefinitions().Lister(),
@@ -407,6 +565,12 @@ func (s *Server) Run(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		if s.options.Controllers.Syncer.PullMode {
+			// pull-mode syncers run on the physical clusters, outside of the deployment network
+			for _, cluster := range syncerConfig.Clusters {
+				cluster.Server = tenancyhelper.ShardURL(s.shardSpec(genericConfig.ExternalAddress), tenancyhelper.ExternalAudience)
+			}
+		}
 		if err := s.installWorkloadSyncerController(ctx, controllerConfig, syncerConfig); err != nil {
 			return err
 		}
@@ -416,10 +580,108 @@ func (s *Server) Run(ctx context.Context) error {
 		if err := s.installWorkloadClusterHeartbeatController(ctx, controllerConfig); err != nil {
 			return err
 		}
+		if err := s.installWorkloadClusterDrainController(ctx, controllerConfig); err != nil {
+			return err
+		}
+		if s.options.Controllers.WorkloadClusterPools.GroupingLabel != "" {
+			if err := s.installWorkloadClusterPoolGenerator(ctx, controllerConfig); err != nil {
+				return err
+			}
+		}
 	}
 
 	if s.options.Controllers.EnableAll || enabled.Has("workspace-scheduler") {
-		if err := s.installWorkspaceScheduler(ctx, controllerConfig); err != nil {
+		if err := s.installWorkspaceScheduler(ctx, controllerConfig, server.SecureServingInfo.Cert); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("workspace-quota") {
+		if err := s.installClusterWorkspaceQuotaController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("access-request") {
+		if err := s.installAccessRequestController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("binding-expiration") {
+		if err := s.installBindingExpirationController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("workspace-lifecycle-hooks") {
+		if err := s.installClusterWorkspaceLifecycleHooksController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("workspace-default-apibindings") {
+		if err := s.installClusterWorkspaceDefaultAPIBindingsController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if (s.options.Controllers.EnableAll || enabled.Has("workspace-hibernation")) && s.activityTracker != nil {
+		if err := s.installClusterWorkspaceHibernationController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("workspace-expiration") {
+		if err := s.installClusterWorkspaceExpirationController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("workspace-migration") {
+		if err := s.installWorkspaceMigrationController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("shard-upgrade") {
+		if err := s.installShardUpgradeController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("workspace-request-set") {
+		if err := s.installWorkspaceRequestSetController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("workspace-clone") {
+		if err := s.installWorkspaceCloneController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if (s.options.Controllers.EnableAll || enabled.Has("workspace-index-check")) && s.options.Controllers.WorkspaceIndex.CheckPeriod > 0 {
+		if err := s.installWorkspaceIndexCheckController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if (s.options.Controllers.EnableAll || enabled.Has("workspace-group-mapping")) && s.options.Controllers.WorkspaceGroupMapping.MappingFile != "" {
+		if err := s.installWorkspaceGroupMappingController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("workspace-signer") {
+		if err := s.installWorkspaceSignerController(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("name-reservation-cleanup") {
+		if err := s.installNameReservationCleanupController(ctx); err != nil {
 			return err
 		}
 	}
@@ -436,14 +698,53 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}
 
+	if s.options.Controllers.EnableAll || enabled.Has("apiresourceschema-evolution") {
+		if err := s.installAPIResourceSchemaEvolutionController(ctx, controllerConfig, server); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("apiexport-identity") {
+		if err := s.installAPIExportIdentityController(ctx, controllerConfig, server); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("maintenance-task") {
+		if err := s.installMaintenanceTaskController(ctx, controllerConfig, server); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Controllers.EnableAll || enabled.Has("cross-workspace-garbage-collector") {
+		if err := s.installCrossWorkspaceGarbageCollector(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Extra.Dev {
+		if err := s.installDevContent(ctx, controllerConfig); err != nil {
+			return err
+		}
+	}
+
+	if s.options.Extra.ShardName != v1alpha1.RootShard {
+		if err := s.installShardRegistration(ctx, genericConfig.ExternalAddress); err != nil {
+			return err
+		}
+	}
+
 	if s.options.Virtual.Enabled {
-		if err := s.installVirtualWorkspaces(ctx, kubeClusterClient, kcpClusterClient, genericConfig.Authentication, genericConfig.ExternalAddress, preHandlerChainMux); err != nil {
+		if err := s.installVirtualWorkspaces(ctx, kubeClusterClient, kcpClusterClient, genericConfig.Authentication, genericConfig.Authorization.Authorizer, genericConfig.ExternalAddress, preHandlerChainMux); err != nil {
 			return err
 		}
-	} else if err := s.installVirtualWorkspacesRedirect(ctx, preHandlerChainMux); err != nil {
+	} else if err := s.installVirtualWorkspacesRedirect(ctx, genericConfig.ExternalAddress, preHandlerChainMux); err != nil {
 		return err
 	}
 
+	// Controllers reconcile through the loopback client, keep serving until they shut down.
+	s.AddPreShutdownHook("kcp-shutdown-controllers", s.waitForControllers)
+
 	// Add our custom hooks to the underlying api server
 	for _, entry := range s.postStartHooks {
 		err := server.AddPostStartHook(entry.name, entry.hook)
@@ -26,11 +26,14 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	kubernetesclient "k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 
 	virtualcommandoptions "github.com/kcp-dev/kcp/cmd/virtual-workspaces/options"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/pkg/authorization"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	virtualrootapiserver "github.com/kcp-dev/kcp/pkg/virtual/framework/rootapiserver"
 )
@@ -39,7 +42,7 @@ type mux interface {
 	Handle(pattern string, handler http.Handler)
 }
 
-func (s *Server) installVirtualWorkspaces(ctx context.Context, kubeClusterClient kubernetesclient.ClusterInterface, kcpClusterClient kcpclient.ClusterInterface, auth genericapiserver.AuthenticationInfo, externalAddress string, preHandlerChainMux mux) error {
+func (s *Server) installVirtualWorkspaces(ctx context.Context, kubeClusterClient kubernetesclient.ClusterInterface, kcpClusterClient kcpclient.ClusterInterface, auth genericapiserver.AuthenticationInfo, authz authorizer.Authorizer, externalAddress string, preHandlerChainMux mux) error {
 	// create virtual workspaces
 	extraInformerStarts, virtualWorkspaces, err := s.options.Virtual.Workspaces.NewVirtualWorkspaces(
 		virtualcommandoptions.DefaultRootPathPrefix,
@@ -51,6 +54,19 @@ func (s *Server) installVirtualWorkspaces(ctx context.Context, kubeClusterClient
 	if err != nil {
 		return err
 	}
+	eventsInformerStarts, eventsVirtualWorkspaces, err := s.options.Virtual.Events.NewVirtualWorkspaces(
+		virtualcommandoptions.DefaultRootPathPrefix,
+		kubeClusterClient,
+		kcpClusterClient,
+		s.kubeSharedInformerFactory,
+		s.kcpSharedInformerFactory,
+	)
+	if err != nil {
+		return err
+	}
+	extraInformerStarts = append(extraInformerStarts, eventsInformerStarts...)
+	virtualWorkspaces = append(virtualWorkspaces, eventsVirtualWorkspaces...)
+
 	s.AddPostStartHook("kcp-start-virtual-workspace-extra-informers", func(ctx genericapiserver.PostStartHookContext) error {
 		for _, start := range extraInformerStarts {
 			start(ctx.StopCh)
@@ -77,6 +93,9 @@ func (s *Server) installVirtualWorkspaces(ctx context.Context, kubeClusterClient
 	if err != nil {
 		return err
 	}
+	if s.options.Virtual.AuthorizationExplain {
+		rootAPIServer.GenericAPIServer.Handler.NonGoRestfulMux.Handle(virtualcommandoptions.DefaultRootPathPrefix+"/debug/authorization", authorization.NewExplainHandler(authz))
+	}
 	preparedRootAPIServer := rootAPIServer.GenericAPIServer.PrepareRun()
 
 	// this **must** be done after PrepareRun() as it sets up the openapi endpoints
@@ -95,10 +114,11 @@ func (s *Server) installVirtualWorkspaces(ctx context.Context, kubeClusterClient
 	return nil
 }
 
-func (s *Server) installVirtualWorkspacesRedirect(ctx context.Context, preHandlerChainMux mux) error {
+func (s *Server) installVirtualWorkspacesRedirect(ctx context.Context, externalAddress string, preHandlerChainMux mux) error {
 	// TODO(sttts): protect redirect via authz?
 
-	externalBaseURL, err := url.Parse(s.options.Virtual.ExternalVirtualWorkspaceAddress)
+	// clients are redirected, hence they must be able to reach the target from where they are
+	externalBaseURL, err := url.Parse(tenancyhelper.ShardVirtualWorkspaceURL(s.shardSpec(externalAddress), tenancyhelper.ExternalAudience))
 	if err != nil {
 		return err // shouldn't happen due to options validation
 	}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
@@ -28,9 +29,11 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/klog/v2"
 )
 
 type shardedStorage struct {
@@ -48,12 +51,15 @@ func (s *shardedStorage) Watch(ctx context.Context, options *internalversion.Lis
 	}
 	state, err := NewResourceVersionState(options.ResourceVersion, shardIdentifiers, s.shardIdentifierResourceVersion)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse sharded resource version state: %w", err)
+		return nil, errors.NewBadRequest(fmt.Sprintf("failed to parse sharded resource version state: %v", err))
 	}
 
-	watchers := map[string]watch.Interface{}
-	for i := range state.ResourceVersions {
-		client, err := s.clientFor(s.shards[state.ResourceVersions[i].Identifier])
+	watchFor := func(identifier string, resourceVersion int64) (watch.Interface, error) {
+		config, ok := s.shards[identifier]
+		if !ok {
+			return nil, errors.NewBadRequest(fmt.Sprintf("unknown shard %q in resource version", identifier))
+		}
+		client, err := s.clientFor(config)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create sharded client: %w", err)
 		}
@@ -62,98 +68,239 @@ func (s *shardedStorage) Watch(ctx context.Context, options *internalversion.Lis
 			return nil, fmt.Errorf("failed to create sharded request: %w", err)
 		}
 		request.OverwriteParam("limit", "500")
-		request.OverwriteParam("resourceVersion", strconv.FormatInt(state.ResourceVersions[i].ResourceVersion, 10))
+		request.OverwriteParam("resourceVersion", strconv.FormatInt(resourceVersion, 10))
+		// bookmarks keep the resource versions of quiet shards recent, such that the watch can be
+		// resumed from them after a shard restart.
+		request.OverwriteParam("allowWatchBookmarks", "true")
 		request.SetHeader("X-Kubernetes-Cluster", "*")
-		watcher, err := request.Watch(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("error executing watch request: %w", err)
-		}
-		watchers[state.ResourceVersions[i].Identifier] = watcher
+		return request.Watch(ctx)
 	}
 
-	return NewAggregateWatcher(state, watchers), nil
+	return NewAggregateWatcher(state, watchFor, options.AllowWatchBookmarks)
 }
 
-type stopper interface {
-	Stop()
+// CauseTypeShardResourceVersionTooOld is the cause of a 410 Gone error of a sharded watch for every
+// shard which cannot serve the resource version requested from it anymore, e.g. because it has been
+// compacted. The field of the cause is the shard identifier, the message the compacted resource
+// version of the shard if it is known. A client has to list again.
+const CauseTypeShardResourceVersionTooOld metav1.CauseType = "ShardResourceVersionTooOld"
+
+// newShardExpiredError returns a 410 Gone error for the shard, with a hint at its compacted resource version.
+func newShardExpiredError(identifier string, resourceVersion int64, err error) *errors.StatusError {
+	cause := metav1.StatusCause{
+		Type:  CauseTypeShardResourceVersionTooOld,
+		Field: identifier,
+	}
+	var requested, compacted int64
+	if status, ok := err.(errors.APIStatus); ok {
+		if _, scanErr := fmt.Sscanf(status.Status().Message, "too old resource version: %d (%d)", &requested, &compacted); scanErr == nil {
+			cause.Message = strconv.FormatInt(compacted, 10)
+		}
+	}
+	expired := errors.NewResourceExpired(fmt.Sprintf("too old resource version %d for shard %q: %v", resourceVersion, identifier, err))
+	expired.ErrStatus.Details = &metav1.StatusDetails{Causes: []metav1.StatusCause{cause}}
+	return expired
+}
+
+// reconnectBackoff is the backoff of the attempts to resume the watch of a shard which has closed it.
+var reconnectBackoff = wait.Backoff{
+	Duration: 100 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    8,
+	Cap:      10 * time.Second,
 }
 
 type aggregateWatcher struct {
-	delegates []stopper
-	wg        *sync.WaitGroup
-	events    chan watch.Event
+	// watchFor opens a watch of the shard from the resource version.
+	watchFor  func(identifier string, resourceVersion int64) (watch.Interface, error)
+	bookmarks bool
 
-	state *ShardedResourceVersions
-	lock  *sync.Mutex
+	wg       *sync.WaitGroup
+	events   chan watch.Event
+	stopCh   chan struct{}
+	stopOnce sync.Once
+
+	// lock guards state and delegates.
+	lock      *sync.Mutex
+	state     *ShardedResourceVersions
+	delegates map[string]watch.Interface
 }
 
 func (a *aggregateWatcher) Stop() {
-	for i := range a.delegates {
-		a.delegates[i].Stop()
-	}
+	a.stop()
 	a.wg.Wait()
-	close(a.events)
+}
+
+func (a *aggregateWatcher) stop() {
+	a.stopOnce.Do(func() {
+		a.lock.Lock()
+		defer a.lock.Unlock()
+		close(a.stopCh)
+		for _, delegate := range a.delegates {
+			delegate.Stop()
+		}
+	})
 }
 
 func (a *aggregateWatcher) ResultChan() <-chan watch.Event {
 	return a.events
 }
 
-func (a *aggregateWatcher) process(identifier string, event watch.Event) {
+func (a *aggregateWatcher) send(event watch.Event) {
+	select {
+	case a.events <- event:
+	case <-a.stopCh:
+	}
+}
+
+// open opens the watch of the shard from the last resource version seen from it.
+func (a *aggregateWatcher) open(identifier string) (watch.Interface, int64, error) {
+	a.lock.Lock()
+	resourceVersion := a.state.ResourceVersionOf(identifier)
+	a.lock.Unlock()
+
+	delegate, err := a.watchFor(identifier, resourceVersion)
+	if err != nil {
+		return nil, resourceVersion, err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	select {
+	case <-a.stopCh:
+		delegate.Stop()
+	default:
+		a.delegates[identifier] = delegate
+	}
+	return delegate, resourceVersion, nil
+}
+
+// run forwards the events of the shard. When the shard closes the watch, e.g. because it restarted,
+// the watch is resumed from the last resource version seen from the shard.
+func (a *aggregateWatcher) run(identifier string, delegate watch.Interface) {
+	defer utilruntime.HandleCrash()
+	defer a.wg.Done()
+
+	backoff := reconnectBackoff
+	for {
+		for event := range delegate.ResultChan() {
+			if !a.process(identifier, event) {
+				a.stop()
+				return
+			}
+			backoff = reconnectBackoff
+		}
+
+		for {
+			select {
+			case <-a.stopCh:
+				return
+			case <-time.After(backoff.Step()):
+			}
+
+			var resourceVersion int64
+			var err error
+			delegate, resourceVersion, err = a.open(identifier)
+			if err == nil {
+				break
+			}
+			if errors.IsResourceExpired(err) || errors.IsGone(err) {
+				a.send(watch.Event{Type: watch.Error, Object: &newShardExpiredError(identifier, resourceVersion, err).ErrStatus})
+				a.stop()
+				return
+			}
+			klog.Infof("failed to resume watch of shard %q from resource version %d: %v", identifier, resourceVersion, err)
+		}
+	}
+}
+
+// process forwards the event of the shard with the updated resource version vector clock. It
+// returns false if the watch cannot continue.
+func (a *aggregateWatcher) process(identifier string, event watch.Event) bool {
+	if event.Type == watch.Error {
+		err := errors.FromObject(event.Object)
+		if errors.IsResourceExpired(err) || errors.IsGone(err) {
+			a.lock.Lock()
+			resourceVersion := a.state.ResourceVersionOf(identifier)
+			a.lock.Unlock()
+			a.send(watch.Event{Type: watch.Error, Object: &newShardExpiredError(identifier, resourceVersion, err).ErrStatus})
+			return false
+		}
+		// the shard closes the watch after the error, and it is resumed
+		klog.Infof("error watching shard %q: %v", identifier, err)
+		return true
+	}
+
 	obj, ok := event.Object.(metav1.Common)
 	if !ok {
-		a.events <- watch.Event{
+		a.send(watch.Event{
 			Type:   watch.Error,
 			Object: &errors.NewInternalError(fmt.Errorf("watch event contained a %T which could not cast to metav1.Common", event.Object)).ErrStatus,
-		}
-		return
+		})
+		return true
 	}
 	a.lock.Lock()
 	err := a.state.UpdateWith(identifier, obj)
+	var encoded string
+	if err == nil {
+		encoded, err = a.state.Encode()
+	}
 	a.lock.Unlock()
 	if err != nil {
-		a.events <- watch.Event{
+		a.send(watch.Event{
 			Type:   watch.Error,
 			Object: &errors.NewInternalError(fmt.Errorf("failed to update resource version vector clock: %w", err)).ErrStatus,
-		}
-		return
+		})
+		return true
 	}
-	encoded, err := a.state.Encode()
-	if err != nil {
-		a.events <- watch.Event{
-			Type:   watch.Error,
-			Object: &errors.NewInternalError(fmt.Errorf("failed to encode resource version vector clock: %w", err)).ErrStatus,
-		}
-		return
+	if event.Type == watch.Bookmark && !a.bookmarks {
+		return true
 	}
 	obj.SetResourceVersion(encoded)
-	a.events <- event
+	a.send(event)
+	return true
 }
 
-func NewAggregateWatcher(state *ShardedResourceVersions, delegates map[string]watch.Interface) watch.Interface {
+// NewAggregateWatcher opens the watches of all shards of the state, and merges their events. Resource
+// versions of the events are vector clocks of the resource versions of all shards.
+func NewAggregateWatcher(state *ShardedResourceVersions, watchFor func(identifier string, resourceVersion int64) (watch.Interface, error), bookmarks bool) (watch.Interface, error) {
 	w := &aggregateWatcher{
-		delegates: []stopper{},
+		watchFor:  watchFor,
+		bookmarks: bookmarks,
 		events:    make(chan watch.Event),
+		stopCh:    make(chan struct{}),
 		wg:        &sync.WaitGroup{},
 		state:     state,
 		lock:      &sync.Mutex{},
+		delegates: map[string]watch.Interface{},
 	}
-	for identifier := range delegates {
-		w.wg.Add(1)
-		go func(identifier string, events <-chan watch.Event) {
-			defer utilruntime.HandleCrash()
-			defer w.wg.Done()
-			for event := range events {
-				w.process(identifier, event)
+	delegates := map[string]watch.Interface{}
+	for _, shard := range state.ResourceVersions {
+		delegate, resourceVersion, err := w.open(shard.Identifier)
+		if err != nil {
+			w.stop()
+			if errors.IsResourceExpired(err) || errors.IsGone(err) {
+				return nil, newShardExpiredError(shard.Identifier, resourceVersion, err)
+			}
+			if _, ok := err.(errors.APIStatus); ok {
+				return nil, err
 			}
-		}(identifier, delegates[identifier].ResultChan())
-		w.delegates = append(w.delegates, delegates[identifier])
+			return nil, fmt.Errorf("error executing watch request: %w", err)
+		}
+		delegates[shard.Identifier] = delegate
 	}
-	return w
+	for identifier, delegate := range delegates {
+		w.wg.Add(1)
+		go w.run(identifier, delegate)
+	}
+	go func() {
+		w.wg.Wait()
+		close(w.events)
+	}()
+	return w, nil
 }
 
-var _ watch.Interface = &aggregateWatcher{}
-
 func (s *shardedStorage) List(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
 	if requestInfo, ok := request.RequestInfoFrom(ctx); ok && requestInfo.Namespace != "" {
 		return nil, fmt.Errorf("cross-cluster calls cannot specify namespace")
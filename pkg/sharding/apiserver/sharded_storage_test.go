@@ -0,0 +1,251 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+type fakeShards struct {
+	lock     sync.Mutex
+	watchers map[string]*watch.RaceFreeFakeWatcher
+	// opened records the resource versions the shards were watched from
+	opened map[string][]int64
+	// errs are returned by the next watch of the shard
+	errs map[string]error
+}
+
+func newFakeShards() *fakeShards {
+	return &fakeShards{
+		watchers: map[string]*watch.RaceFreeFakeWatcher{},
+		opened:   map[string][]int64{},
+		errs:     map[string]error{},
+	}
+}
+
+func (f *fakeShards) watchFor(identifier string, resourceVersion int64) (watch.Interface, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.opened[identifier] = append(f.opened[identifier], resourceVersion)
+	if err := f.errs[identifier]; err != nil {
+		return nil, err
+	}
+	f.watchers[identifier] = watch.NewRaceFreeFake()
+	return f.watchers[identifier], nil
+}
+
+func (f *fakeShards) watcher(identifier string) *watch.RaceFreeFakeWatcher {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.watchers[identifier]
+}
+
+func (f *fakeShards) openedFrom(identifier string) []int64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]int64(nil), f.opened[identifier]...)
+}
+
+func newObject(name, resourceVersion string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetName(name)
+	obj.SetResourceVersion(resourceVersion)
+	return obj
+}
+
+func receive(t *testing.T, w watch.Interface) watch.Event {
+	select {
+	case event, ok := <-w.ResultChan():
+		if !ok {
+			t.Fatalf("watch closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout")
+	}
+	return watch.Event{}
+}
+
+func decodeResourceVersion(t *testing.T, event watch.Event) *ShardedResourceVersions {
+	state := &ShardedResourceVersions{}
+	if err := state.Decode(event.Object.(metav1.Common).GetResourceVersion()); err != nil {
+		t.Fatalf("could not decode resource version: %v", err)
+	}
+	return state
+}
+
+func TestAggregateWatcherResumesShardWatch(t *testing.T) {
+	oldBackoff := reconnectBackoff
+	reconnectBackoff.Duration = time.Millisecond
+	defer func() { reconnectBackoff = oldBackoff }()
+
+	shards := newFakeShards()
+	state, err := NewResourceVersionState("", []string{"first", "second"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewAggregateWatcher(state, shards.watchFor, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	shards.watcher("first").Add(newObject("a", "10"))
+	if got := decodeResourceVersion(t, receive(t, w)).ResourceVersionOf("first"); got != 10 {
+		t.Fatalf("expected resource version 10 of the first shard, got %d", got)
+	}
+
+	// bookmarks move the resource version forward, but are not forwarded
+	shards.watcher("first").Action(watch.Bookmark, newObject("", "15"))
+
+	// the shard restarts
+	shards.watcher("first").Stop()
+	if err := waitFor(func() bool { return len(shards.openedFrom("first")) == 2 }); err != nil {
+		t.Fatalf("watch of the first shard was not resumed: %v", shards.openedFrom("first"))
+	}
+	if got := shards.openedFrom("first"); got[1] != 15 {
+		t.Fatalf("expected the watch to be resumed from 15, got %d", got[1])
+	}
+
+	shards.watcher("first").Add(newObject("b", "20"))
+	event := receive(t, w)
+	if event.Type != watch.Added || event.Object.(metav1.Object).GetName() != "b" {
+		t.Fatalf("unexpected event %#v", event)
+	}
+	if got := decodeResourceVersion(t, event).ResourceVersionOf("first"); got != 20 {
+		t.Fatalf("expected resource version 20 of the first shard, got %d", got)
+	}
+}
+
+func TestAggregateWatcherBookmarks(t *testing.T) {
+	shards := newFakeShards()
+	state, err := NewResourceVersionState("", []string{"first"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewAggregateWatcher(state, shards.watchFor, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	shards.watcher("first").Action(watch.Bookmark, newObject("", "15"))
+	event := receive(t, w)
+	if event.Type != watch.Bookmark {
+		t.Fatalf("expected bookmark, got %#v", event)
+	}
+	if got := decodeResourceVersion(t, event).ResourceVersionOf("first"); got != 15 {
+		t.Fatalf("expected resource version 15 of the first shard, got %d", got)
+	}
+}
+
+func TestAggregateWatcherTooOld(t *testing.T) {
+	oldBackoff := reconnectBackoff
+	reconnectBackoff.Duration = time.Millisecond
+	defer func() { reconnectBackoff = oldBackoff }()
+
+	tooOld := errors.NewResourceExpired("too old resource version: 10 (12)")
+
+	t.Run("when starting", func(t *testing.T) {
+		shards := newFakeShards()
+		shards.errs["second"] = tooOld
+		state, err := NewResourceVersionState("", []string{"first", "second"}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = NewAggregateWatcher(state, shards.watchFor, false)
+		if !errors.IsResourceExpired(err) {
+			t.Fatalf("expected expired error, got %v", err)
+		}
+		expectCompactedHint(t, &err.(*errors.StatusError).ErrStatus, "second", "12")
+	})
+
+	t.Run("while watching", func(t *testing.T) {
+		shards := newFakeShards()
+		state, err := NewResourceVersionState("", []string{"first"}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := NewAggregateWatcher(state, shards.watchFor, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Stop()
+
+		shards.watcher("first").Error(&tooOld.ErrStatus)
+		event := receive(t, w)
+		if event.Type != watch.Error {
+			t.Fatalf("expected error, got %#v", event)
+		}
+		expectCompactedHint(t, event.Object.(*metav1.Status), "first", "12")
+
+		if _, ok := <-w.ResultChan(); ok {
+			t.Fatalf("expected watch to be closed")
+		}
+	})
+
+	t.Run("when resuming", func(t *testing.T) {
+		shards := newFakeShards()
+		state, err := NewResourceVersionState("", []string{"first"}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := NewAggregateWatcher(state, shards.watchFor, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Stop()
+
+		shards.lock.Lock()
+		shards.errs["first"] = tooOld
+		shards.lock.Unlock()
+		shards.watcher("first").Stop()
+
+		event := receive(t, w)
+		if event.Type != watch.Error {
+			t.Fatalf("expected error, got %#v", event)
+		}
+		expectCompactedHint(t, event.Object.(*metav1.Status), "first", "12")
+	})
+}
+
+func expectCompactedHint(t *testing.T, status *metav1.Status, identifier, compacted string) {
+	if status.Code != 410 {
+		t.Fatalf("expected 410, got %d", status.Code)
+	}
+	if status.Details == nil || len(status.Details.Causes) != 1 {
+		t.Fatalf("expected one cause, got %#v", status.Details)
+	}
+	cause := status.Details.Causes[0]
+	if cause.Type != CauseTypeShardResourceVersionTooOld || cause.Field != identifier || cause.Message != compacted {
+		t.Fatalf("unexpected cause %#v", cause)
+	}
+}
+
+func waitFor(condition func() bool) error {
+	return wait.PollImmediate(time.Millisecond, 5*time.Second, func() (bool, error) {
+		return condition(), nil
+	})
+}
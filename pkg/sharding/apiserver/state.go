@@ -181,10 +181,18 @@ func (s *ShardedChunkedState) ContinueToken() (string, error) {
 	return etcd3.EncodeContinue(sillyPrefix+s.StartKey, sillyPrefix, s.ResourceVersion)
 }
 
+// shardedResourceVersionsScheme is the version of the encoding of ShardedResourceVersions. It is
+// incremented on incompatible changes, such that resource versions handed out by older servers
+// are rejected instead of being misinterpreted.
+const shardedResourceVersionsScheme = 1
+
 // ShardedResourceVersions are what a client passes to the resourceVersion
 // query parameter to initiate a LIST or WATCH across shards at a particular
 // point in time.
 type ShardedResourceVersions struct {
+	// Scheme is the version of the encoding. Resource versions without it predate
+	// the versioning and are compatible with the current scheme.
+	Scheme int `json:"v,omitempty"`
 	// ShardResourceVersion is the version at which the list of shards
 	// that needed to be queried was resolved.
 	ShardResourceVersion int64 `json:"srv"`
@@ -208,11 +216,15 @@ func (s *ShardedResourceVersions) Decode(encoded string) error {
 	if err := json.Unmarshal(raw, s); err != nil {
 		return fmt.Errorf("invalid sharded resource version serialization: %w", err)
 	}
+	if s.Scheme > shardedResourceVersionsScheme {
+		return fmt.Errorf("unsupported sharded resource version scheme %d", s.Scheme)
+	}
 	return nil
 }
 
 // Encode encodes into a resource version token
 func (s *ShardedResourceVersions) Encode() (string, error) {
+	s.Scheme = shardedResourceVersionsScheme
 	raw, err := json.Marshal(s)
 	if err != nil {
 		return "", err
@@ -241,6 +253,16 @@ func (s *ShardedResourceVersions) UpdateWith(identifier string, resp metav1.Comm
 	return nil
 }
 
+// ResourceVersionOf returns the resource version of the shard, or 0 if nothing has been seen from it.
+func (s *ShardedResourceVersions) ResourceVersionOf(identifier string) int64 {
+	for _, shard := range s.ResourceVersions {
+		if shard.Identifier == identifier {
+			return shard.ResourceVersion
+		}
+	}
+	return 0
+}
+
 func resourceVersionFor(identifier string, resp metav1.Common) (ShardedResourceVersion, error) {
 	resourceVersion := resp.GetResourceVersion()
 	if resourceVersion == "" {
@@ -286,9 +308,20 @@ func NewChunkedState(encodedContinueTokens string, identifiers []string, shardRe
 }
 
 // NewResourceVersionState parses state from a user query or initializes it if the client did not
-// request anything specific.
+// request anything specific. A plain resource version, e.g. one handed out by a shard before
+// sharding was enabled, is translated when there is a single shard it can belong to.
 func NewResourceVersionState(encodedResourceVersion string, identifiers []string, shardResourceVersion int64) (*ShardedResourceVersions, error) {
-	if encodedResourceVersion == "" {
+	if version, err := strconv.ParseInt(encodedResourceVersion, 10, 64); err == nil && version != 0 {
+		if len(identifiers) != 1 {
+			return nil, fmt.Errorf("plain resource version %q is ambiguous across %d shards", encodedResourceVersion, len(identifiers))
+		}
+		return &ShardedResourceVersions{
+			Scheme:               shardedResourceVersionsScheme,
+			ShardResourceVersion: shardResourceVersion,
+			ResourceVersions:     []ShardedResourceVersion{{Identifier: identifiers[0], ResourceVersion: version}},
+		}, nil
+	}
+	if encodedResourceVersion == "" || encodedResourceVersion == "0" {
 		var shards []ShardedResourceVersion
 		for _, identifier := range identifiers {
 			shards = append(shards, ShardedResourceVersion{Identifier: identifier})
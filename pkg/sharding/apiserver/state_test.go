@@ -17,6 +17,7 @@ limitations under the License.
 package apiserver
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -143,3 +144,99 @@ func TestShardedChunkedStates_UpdateWith(t *testing.T) {
 		}
 	}
 }
+
+func TestNewResourceVersionState(t *testing.T) {
+	encode := func(state *ShardedResourceVersions) string {
+		encoded, err := state.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encoded
+	}
+
+	for _, tc := range []struct {
+		name            string
+		resourceVersion string
+		identifiers     []string
+		expected        *ShardedResourceVersions
+		wantErr         bool
+	}{
+		{
+			name:        "empty",
+			identifiers: []string{"first"},
+			expected: &ShardedResourceVersions{
+				ShardResourceVersion: 1,
+				ResourceVersions:     []ShardedResourceVersion{{Identifier: "first"}},
+			},
+		},
+		{
+			name:            "any",
+			resourceVersion: "0",
+			identifiers:     []string{"first"},
+			expected: &ShardedResourceVersions{
+				ShardResourceVersion: 1,
+				ResourceVersions:     []ShardedResourceVersion{{Identifier: "first"}},
+			},
+		},
+		{
+			name:            "plain resource version of a single shard",
+			resourceVersion: "42",
+			identifiers:     []string{"first"},
+			expected: &ShardedResourceVersions{
+				Scheme:               shardedResourceVersionsScheme,
+				ShardResourceVersion: 1,
+				ResourceVersions:     []ShardedResourceVersion{{Identifier: "first", ResourceVersion: 42}},
+			},
+		},
+		{
+			name:            "plain resource version of multiple shards",
+			resourceVersion: "42",
+			identifiers:     []string{"first", "second"},
+			wantErr:         true,
+		},
+		{
+			name: "encoded",
+			resourceVersion: encode(&ShardedResourceVersions{
+				ShardResourceVersion: 1,
+				ResourceVersions:     []ShardedResourceVersion{{Identifier: "first", ResourceVersion: 42}, {Identifier: "second", ResourceVersion: 7}},
+			}),
+			identifiers: []string{"first", "second"},
+			expected: &ShardedResourceVersions{
+				Scheme:               shardedResourceVersionsScheme,
+				ShardResourceVersion: 1,
+				ResourceVersions:     []ShardedResourceVersion{{Identifier: "first", ResourceVersion: 42}, {Identifier: "second", ResourceVersion: 7}},
+			},
+		},
+		{
+			name:            "unsupported scheme",
+			resourceVersion: base64.RawURLEncoding.EncodeToString([]byte(`{"v":2,"srv":1,"rvs":[{"id":"first","rv":42}]}`)),
+			identifiers:     []string{"first"},
+			wantErr:         true,
+		},
+		{
+			name:            "without scheme",
+			resourceVersion: base64.RawURLEncoding.EncodeToString([]byte(`{"srv":1,"rvs":[{"id":"first","rv":42}]}`)),
+			identifiers:     []string{"first"},
+			expected: &ShardedResourceVersions{
+				ShardResourceVersion: 1,
+				ResourceVersions:     []ShardedResourceVersion{{Identifier: "first", ResourceVersion: 42}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state, err := NewResourceVersionState(tc.resourceVersion, tc.identifiers, 1)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got state %#v", state)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, state); diff != "" {
+				t.Fatalf("got incorrect state: %v", diff)
+			}
+		})
+	}
+}
@@ -22,6 +22,9 @@ import (
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
 )
 
 type ClientLoader struct {
@@ -75,3 +78,17 @@ func (c *ClientLoader) Clients() map[string]*rest.Config {
 
 	return out
 }
+
+// ShardConfig returns a copy of the given config pointing to the internal URL of the shard.
+// The TLS connection is validated with the serving CA bundle published in the shard's
+// status, if any. Otherwise, the CA of the given config is kept.
+func ShardConfig(shard *tenancyv1alpha1.ClusterWorkspaceShard, config *rest.Config) *rest.Config {
+	shardConfig := rest.CopyConfig(config)
+	shardConfig.Host = tenancyhelper.ShardURL(shard.Spec, tenancyhelper.InternalAudience)
+	if len(shard.Status.ServingCABundle) > 0 {
+		shardConfig.TLSClientConfig.CAFile = ""
+		shardConfig.TLSClientConfig.CAData = shard.Status.ServingCABundle
+		shardConfig.TLSClientConfig.Insecure = false
+	}
+	return shardConfig
+}
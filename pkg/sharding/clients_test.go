@@ -0,0 +1,66 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sharding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/client-go/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestShardConfig(t *testing.T) {
+	tests := map[string]struct {
+		caBundle   []byte
+		config     *rest.Config
+		wantCAData []byte
+		wantCAFile string
+	}{
+		"published CA bundle replaces the CA file": {
+			caBundle:   []byte("shard-ca"),
+			config:     &rest.Config{Host: "https://front-proxy", TLSClientConfig: rest.TLSClientConfig{CAFile: "/etc/ca.crt"}},
+			wantCAData: []byte("shard-ca"),
+		},
+		"published CA bundle replaces insecure": {
+			caBundle:   []byte("shard-ca"),
+			config:     &rest.Config{TLSClientConfig: rest.TLSClientConfig{Insecure: true}},
+			wantCAData: []byte("shard-ca"),
+		},
+		"no published CA bundle keeps the config CA": {
+			config:     &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAFile: "/etc/ca.crt"}},
+			wantCAFile: "/etc/ca.crt",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+				Spec:   tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: "https://shard-1:6443"},
+				Status: tenancyv1alpha1.ClusterWorkspaceShardStatus{ServingCABundle: tc.caBundle},
+			}
+			got := ShardConfig(shard, tc.config)
+			require.Equal(t, "https://shard-1:6443", got.Host)
+			require.Equal(t, tc.wantCAData, got.TLSClientConfig.CAData)
+			require.Equal(t, tc.wantCAFile, got.TLSClientConfig.CAFile)
+			if len(tc.caBundle) > 0 {
+				require.False(t, got.TLSClientConfig.Insecure)
+			}
+			require.NotSame(t, tc.config, got)
+		})
+	}
+}
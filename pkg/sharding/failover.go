@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	// StaleReadHeader is set on responses served by the replica of an unavailable shard, to the
+	// URL of the replica. These responses may be stale.
+	StaleReadHeader = "Kcp-Stale-Read"
+
+	// readFailoverRetries is the number of times a read is retried against an unavailable shard
+	// before the replica is asked.
+	readFailoverRetries = 3
+	// readFailoverInterval is the time between two attempts of a read.
+	readFailoverInterval = time.Second
+
+	readFailoverResultRetried = "retried"
+	readFailoverResultReplica = "replica"
+	readFailoverResultFailed  = "failed"
+)
+
+var (
+	// readFailovers counts the reads of cross-shard clients which did not succeed at the first
+	// attempt, by whether they succeeded after retrying, were served by the replica, or failed.
+	readFailovers = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kcp",
+			Subsystem:      "shard_read_failover",
+			Name:           "requests_total",
+			Help:           "Number of reads of cross-shard clients against unavailable shards, by result (retried, replica or failed).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	registerReadFailoverMetrics sync.Once
+)
+
+// ShardReadConfig returns a copy of the given config pointing to the internal URL of the shard,
+// like ShardConfig, whose idempotent reads are retried while the shard is briefly unavailable,
+// and served by the replica of the shard, if any, when it does not come back. Responses of the
+// replica carry the StaleReadHeader and a warning. It is meant for cross-shard controllers which
+// can live with stale reads, not for proxying user requests.
+func ShardReadConfig(shard *tenancyv1alpha1.ClusterWorkspaceShard, config *rest.Config) *rest.Config {
+	shardConfig := ShardConfig(shard, config)
+
+	var replica *url.URL
+	var replicaTransport http.RoundTripper
+	if shard.Spec.ReplicaURL != "" {
+		replicaConfig := rest.CopyConfig(shardConfig)
+		replicaConfig.Host = shard.Spec.ReplicaURL
+		u, err := url.Parse(shard.Spec.ReplicaURL)
+		if err == nil {
+			replicaTransport, err = rest.TransportFor(replicaConfig)
+		}
+		if err != nil {
+			klog.Errorf("Failed to set up the client of the replica %q of shard %s, reads will not fail over: %v", shard.Spec.ReplicaURL, shard.Name, err)
+		} else {
+			replica = u
+		}
+	}
+
+	registerReadFailoverMetrics.Do(func() {
+		legacyregistry.MustRegister(readFailovers)
+	})
+
+	shardConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &readFailoverRoundTripper{
+			delegate:         rt,
+			replica:          replica,
+			replicaTransport: replicaTransport,
+			retries:          readFailoverRetries,
+			interval:         readFailoverInterval,
+			record:           func(result string) { readFailovers.WithLabelValues(result).Inc() },
+		}
+	})
+	return shardConfig
+}
+
+// readFailoverRoundTripper retries GET requests failing because the shard is unavailable, and
+// finally sends them to the replica, if any. Watches are not sent to the replica, as its resource
+// versions differ from those of the shard.
+type readFailoverRoundTripper struct {
+	delegate         http.RoundTripper
+	replica          *url.URL
+	replicaTransport http.RoundTripper
+	retries          int
+	interval         time.Duration
+
+	record func(result string)
+}
+
+func (rt *readFailoverRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return rt.delegate.RoundTrip(req)
+	}
+
+	resp, err := rt.delegate.RoundTrip(req)
+	for attempt := 0; attempt < rt.retries && unavailable(resp, err); attempt++ {
+		drain(resp)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(rt.interval):
+		}
+		resp, err = rt.delegate.RoundTrip(req)
+		if !unavailable(resp, err) {
+			rt.record(readFailoverResultRetried)
+			return resp, err
+		}
+	}
+	if !unavailable(resp, err) {
+		return resp, err
+	}
+
+	if rt.replica == nil || req.URL.Query().Get("watch") == "true" {
+		rt.record(readFailoverResultFailed)
+		return resp, err
+	}
+	drain(resp)
+
+	replicaReq := req.Clone(req.Context())
+	replicaReq.URL.Scheme = rt.replica.Scheme
+	replicaReq.URL.Host = rt.replica.Host
+	replicaReq.Host = rt.replica.Host
+	replicaResp, replicaErr := rt.replicaTransport.RoundTrip(replicaReq)
+	if replicaErr != nil {
+		rt.record(readFailoverResultFailed)
+		return nil, fmt.Errorf("shard at %s unavailable, and replica at %s failed: %w", req.URL.Host, rt.replica.Host, replicaErr)
+	}
+	rt.record(readFailoverResultReplica)
+	klog.V(2).Infof("Served %s from the replica %s of the unavailable shard %s", req.URL.Path, rt.replica.Host, req.URL.Host)
+	replicaResp.Header.Set(StaleReadHeader, rt.replica.String())
+	replicaResp.Header.Add("Warning", fmt.Sprintf(`299 - "served by the replica %s of the unavailable shard %s, possibly stale"`, rt.replica.Host, req.URL.Host))
+	return replicaResp, nil
+}
+
+// unavailable returns whether the response or error of a request signals that the shard is not
+// available, e.g. because it is restarting.
+func unavailable(resp *http.Response, err error) bool {
+	if err != nil {
+		return utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || errors.Is(err, io.EOF) || utilnet.IsProbableEOF(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func drain(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+}
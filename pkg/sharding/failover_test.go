@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestReadFailoverRoundTripper(t *testing.T) {
+	tests := map[string]struct {
+		method        string
+		query         string
+		primaryCodes  []int
+		withReplica   bool
+		wantCode      int
+		wantBody      string
+		wantStale     bool
+		wantResult    string
+		wantPrimaries int
+	}{
+		"available shard": {
+			primaryCodes:  []int{http.StatusOK},
+			withReplica:   true,
+			wantCode:      http.StatusOK,
+			wantBody:      "primary",
+			wantPrimaries: 1,
+		},
+		"shard back after a retry": {
+			primaryCodes:  []int{http.StatusServiceUnavailable, http.StatusOK},
+			withReplica:   true,
+			wantCode:      http.StatusOK,
+			wantBody:      "primary",
+			wantResult:    readFailoverResultRetried,
+			wantPrimaries: 2,
+		},
+		"unavailable shard with replica": {
+			primaryCodes:  []int{http.StatusServiceUnavailable},
+			withReplica:   true,
+			wantCode:      http.StatusOK,
+			wantBody:      "replica",
+			wantStale:     true,
+			wantResult:    readFailoverResultReplica,
+			wantPrimaries: 3,
+		},
+		"unavailable shard without replica": {
+			primaryCodes:  []int{http.StatusServiceUnavailable},
+			wantCode:      http.StatusServiceUnavailable,
+			wantResult:    readFailoverResultFailed,
+			wantPrimaries: 3,
+		},
+		"watches are not sent to the replica": {
+			query:         "watch=true",
+			primaryCodes:  []int{http.StatusServiceUnavailable},
+			withReplica:   true,
+			wantCode:      http.StatusServiceUnavailable,
+			wantResult:    readFailoverResultFailed,
+			wantPrimaries: 3,
+		},
+		"other errors are not retried": {
+			primaryCodes:  []int{http.StatusNotFound},
+			withReplica:   true,
+			wantCode:      http.StatusNotFound,
+			wantPrimaries: 1,
+		},
+		"writes are not retried": {
+			method:        http.MethodPost,
+			primaryCodes:  []int{http.StatusServiceUnavailable},
+			withReplica:   true,
+			wantCode:      http.StatusServiceUnavailable,
+			wantPrimaries: 1,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			primaries := 0
+			primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				code := tt.primaryCodes[len(tt.primaryCodes)-1]
+				if primaries < len(tt.primaryCodes) {
+					code = tt.primaryCodes[primaries]
+				}
+				primaries++
+				w.WriteHeader(code)
+				_, _ = w.Write([]byte("primary"))
+			}))
+			defer primary.Close()
+			replicaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				require.Equal(t, "/clusters/root:org/api/v1/configmaps", req.URL.Path)
+				_, _ = w.Write([]byte("replica"))
+			}))
+			defer replicaServer.Close()
+
+			var results []string
+			rt := &readFailoverRoundTripper{
+				delegate:         http.DefaultTransport,
+				replicaTransport: http.DefaultTransport,
+				retries:          2,
+				record:           func(result string) { results = append(results, result) },
+			}
+			if tt.withReplica {
+				u, err := url.Parse(replicaServer.URL)
+				require.NoError(t, err)
+				rt.replica = u
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, primary.URL+"/clusters/root:org/api/v1/configmaps?"+tt.query, nil)
+			require.NoError(t, err)
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.wantCode, resp.StatusCode)
+			if tt.wantBody != "" {
+				require.Equal(t, tt.wantBody, string(body))
+			}
+			require.Equal(t, tt.wantStale, resp.Header.Get(StaleReadHeader) != "")
+			require.Equal(t, tt.wantPrimaries, primaries)
+			if tt.wantResult == "" {
+				require.Empty(t, results)
+			} else {
+				require.Equal(t, []string{tt.wantResult}, results)
+			}
+		})
+	}
+}
+
+func TestShardReadConfig(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"Namespace","apiVersion":"v1","metadata":{"name":"default"}}`))
+	}))
+	defer replica.Close()
+
+	shard := &tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{Name: "shard"},
+		Spec:       tenancyv1alpha1.ClusterWorkspaceShardSpec{BaseURL: primary.URL, ReplicaURL: replica.URL},
+	}
+	var warnings []string
+	config := ShardReadConfig(shard, &rest.Config{
+		WarningHandler: warningRecorder(func(message string) { warnings = append(warnings, message) }),
+	})
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		// skip the waiting between the attempts
+		rt.(*readFailoverRoundTripper).interval = 0
+		return rt
+	})
+	client, err := kubernetes.NewForConfig(config)
+	require.NoError(t, err)
+
+	ns, err := client.CoreV1().Namespaces().Get(context.Background(), "default", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "default", ns.Name)
+	require.Len(t, warnings, 1)
+	require.True(t, strings.HasPrefix(warnings[0], "served by the replica "), warnings[0])
+}
+
+type warningRecorder func(message string)
+
+func (r warningRecorder) HandleWarningHeader(code int, agent string, message string) {
+	r(message)
+}
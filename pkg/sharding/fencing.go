@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sharding
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clusters"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyhelper "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+const (
+	// ShardNameHeader identifies a request as coming from the named shard.
+	ShardNameHeader = "X-Kcp-Shard-Name"
+	// ShardFencingTokenHeader carries the fencing token the root shard issued to the shard.
+	ShardFencingTokenHeader = "X-Kcp-Shard-Fencing-Token"
+)
+
+// WithShardFencing rejects requests of shards that do not present the current fencing token
+// of their ClusterWorkspaceShard in the root workspace. A request comes from a shard if it names
+// one, or if its user is in the ClusterWorkspaceShardsGroup, i.e. uses the credentials of a shard.
+// Shard credentials can only act as the shard they registered. The only requests of shards passing
+// without token are those to ClusterWorkspaceShards in the root workspace, through which a shard
+// registers and learns its token.
+func WithShardFencing(apiHandler http.Handler, shardLister tenancylisters.ClusterWorkspaceShardLister) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		userInfo, _ := request.UserFrom(req.Context())
+		isShard := userInfo != nil && isShardUser(userInfo)
+		shardName := req.Header.Get(ShardNameHeader)
+		if shardName == "" {
+			if !isShard || isShardRegistration(req) {
+				apiHandler.ServeHTTP(w, req)
+				return
+			}
+			http.Error(w, fmt.Sprintf("user %q is a shard and has to present a fencing token", userInfo.GetName()), http.StatusForbidden)
+			return
+		}
+		if isShardRegistration(req) {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		shard, err := shardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, shardName))
+		if errors.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("unknown shard %q", shardName), http.StatusForbidden)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if owner := shard.Annotations[tenancyv1alpha1.ClusterWorkspaceShardOwnerAnnotationKey]; isShard && userInfo.GetName() != owner {
+			http.Error(w, fmt.Sprintf("shard %q is not owned by the requesting user", shardName), http.StatusForbidden)
+			return
+		}
+		if !tenancyhelper.IsValidFencingToken(shard, req.Header.Get(ShardFencingTokenHeader)) {
+			http.Error(w, fmt.Sprintf("shard %q has no valid fencing token", shardName), http.StatusForbidden)
+			return
+		}
+
+		apiHandler.ServeHTTP(w, req)
+	})
+}
+
+// isShardRegistration returns true for requests to ClusterWorkspaceShards in the root workspace.
+func isShardRegistration(req *http.Request) bool {
+	cluster := request.ClusterFrom(req.Context())
+	if cluster == nil || cluster.Name != tenancyv1alpha1.RootCluster {
+		return false
+	}
+	info, ok := request.RequestInfoFrom(req.Context())
+	return ok && info.IsResourceRequest && info.APIGroup == tenancyv1alpha1.SchemeGroupVersion.Group && info.Resource == "clusterworkspaceshards"
+}
+
+// isShardUser returns true for users with the credentials of a shard. Privileged users are not
+// fenced, even if they are in the ClusterWorkspaceShardsGroup.
+func isShardUser(userInfo user.Info) bool {
+	groups := sets.NewString(userInfo.GetGroups()...)
+	return groups.Has(tenancyv1alpha1.ClusterWorkspaceShardsGroup) && !groups.Has(user.SystemPrivilegedGroup)
+}
+
+// FencingToken holds the fencing token issued to a shard. It is safe for concurrent use.
+type FencingToken struct {
+	value atomic.Value
+}
+
+// Get returns the current fencing token, or the empty string if none has been issued.
+func (t *FencingToken) Get() string {
+	token, _ := t.value.Load().(string)
+	return token
+}
+
+// Set replaces the current fencing token.
+func (t *FencingToken) Set(token string) {
+	t.value.Store(token)
+}
+
+// WithShardIdentity returns a copy of the given config whose requests identify as coming from the
+// given shard, presenting its current fencing token.
+func WithShardIdentity(config *rest.Config, shardName string, fencingToken *FencingToken) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &shardIdentityRoundTripper{
+			delegate:     rt,
+			shardName:    shardName,
+			fencingToken: fencingToken,
+		}
+	})
+	return config
+}
+
+type shardIdentityRoundTripper struct {
+	delegate     http.RoundTripper
+	shardName    string
+	fencingToken *FencingToken
+}
+
+func (rt *shardIdentityRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(ShardNameHeader, rt.shardName)
+	if token := rt.fencingToken.Get(); token != "" {
+		req.Header.Set(ShardFencingTokenHeader, token)
+	}
+	return rt.delegate.RoundTrip(req)
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sharding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+)
+
+func TestWithShardFencing(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "shard-1",
+			ClusterName: "root",
+			Generation:  2,
+			Annotations: map[string]string{tenancyv1alpha1.ClusterWorkspaceShardOwnerAnnotationKey: "shard-1-admin"},
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceShardStatus{FencingToken: "2-abc"},
+	}))
+	handler := WithShardFencing(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), tenancylisters.NewClusterWorkspaceShardLister(indexer))
+
+	shardUser := &user.DefaultInfo{Name: "shard-1-admin", Groups: []string{tenancyv1alpha1.ClusterWorkspaceShardsGroup}}
+	tests := map[string]struct {
+		user         user.Info
+		cluster      logicalcluster.LogicalCluster
+		registration bool
+		shardName    string
+		token        string
+		wantStatus   int
+	}{
+		"request not coming from a shard": {
+			user:       &user.DefaultInfo{Name: "user-1"},
+			wantStatus: http.StatusOK,
+		},
+		"admin that registered a shard": {
+			user:       &user.DefaultInfo{Name: "shard-1-admin"},
+			wantStatus: http.StatusOK,
+		},
+		"privileged request": {
+			user:       &user.DefaultInfo{Name: "system:apiserver", Groups: []string{user.SystemPrivilegedGroup}},
+			wantStatus: http.StatusOK,
+		},
+		"shard with current token": {
+			user:       shardUser,
+			shardName:  "shard-1",
+			token:      "2-abc",
+			wantStatus: http.StatusOK,
+		},
+		"shard with stale token": {
+			user:       shardUser,
+			shardName:  "shard-1",
+			token:      "1-abc",
+			wantStatus: http.StatusForbidden,
+		},
+		"shard without token": {
+			user:       shardUser,
+			shardName:  "shard-1",
+			wantStatus: http.StatusForbidden,
+		},
+		"shard credentials without shard name": {
+			user:       shardUser,
+			wantStatus: http.StatusForbidden,
+		},
+		"shard credentials registering without shard name": {
+			user:         shardUser,
+			cluster:      tenancyv1alpha1.RootCluster,
+			registration: true,
+			wantStatus:   http.StatusOK,
+		},
+		"shard registering without token": {
+			user:         shardUser,
+			cluster:      tenancyv1alpha1.RootCluster,
+			registration: true,
+			shardName:    "shard-1",
+			wantStatus:   http.StatusOK,
+		},
+		"shard without token accessing shards outside of root": {
+			user:         shardUser,
+			cluster:      logicalcluster.New("root:org"),
+			registration: true,
+			shardName:    "shard-1",
+			wantStatus:   http.StatusForbidden,
+		},
+		"other credentials naming a shard with current token": {
+			user:       &user.DefaultInfo{Name: "user-1"},
+			shardName:  "shard-1",
+			token:      "2-abc",
+			wantStatus: http.StatusOK,
+		},
+		"other credentials naming a shard with stale token": {
+			user:       &user.DefaultInfo{Name: "user-1"},
+			shardName:  "shard-1",
+			token:      "1-abc",
+			wantStatus: http.StatusForbidden,
+		},
+		"credentials of another shard": {
+			user:       &user.DefaultInfo{Name: "shard-2-admin", Groups: []string{tenancyv1alpha1.ClusterWorkspaceShardsGroup}},
+			shardName:  "shard-1",
+			token:      "2-abc",
+			wantStatus: http.StatusForbidden,
+		},
+		"unknown shard": {
+			user:       shardUser,
+			shardName:  "shard-2",
+			token:      "2-abc",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/clusters/root/api/v1/namespaces", nil)
+			ctx := request.WithUser(req.Context(), tc.user)
+			if !tc.cluster.Empty() {
+				ctx = request.WithCluster(ctx, request.Cluster{Name: tc.cluster})
+			}
+			info := &request.RequestInfo{IsResourceRequest: true, APIVersion: "v1", Resource: "namespaces", Verb: "list"}
+			if tc.registration {
+				info = &request.RequestInfo{IsResourceRequest: true, APIGroup: tenancyv1alpha1.SchemeGroupVersion.Group, APIVersion: "v1alpha1", Resource: "clusterworkspaceshards", Name: "shard-1", Verb: "get"}
+			}
+			req = req.WithContext(request.WithRequestInfo(ctx, info))
+			if tc.shardName != "" {
+				req.Header.Set(ShardNameHeader, tc.shardName)
+			}
+			if tc.token != "" {
+				req.Header.Set(ShardFencingTokenHeader, tc.token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, tc.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestWithShardIdentity(t *testing.T) {
+	var gotName, gotToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotName = req.Header.Get(ShardNameHeader)
+		gotToken = req.Header.Get(ShardFencingTokenHeader)
+	}))
+	defer server.Close()
+
+	config := &rest.Config{Host: server.URL}
+	token := &FencingToken{}
+	token.Set("2-abc")
+	shardConfig := WithShardIdentity(config, "shard-1", token)
+	require.Nil(t, config.WrapTransport)
+
+	client, err := rest.HTTPClientFor(shardConfig)
+	require.NoError(t, err)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.Equal(t, "shard-1", gotName)
+	require.Equal(t, "2-abc", gotToken)
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sharding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/endpoints/responsewriter"
+	"k8s.io/client-go/rest"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maxMirroredBodyBytes is the size of the responses up to which bodies are compared. The
+	// status codes of bigger responses are compared only.
+	maxMirroredBodyBytes = 1 << 20
+	// maxMirroredInFlight is the number of mirrored requests in flight, beyond which requests
+	// are not mirrored anymore.
+	maxMirroredInFlight = 10
+	// mirrorTimeout is the time a mirrored request may take.
+	mirrorTimeout = 30 * time.Second
+
+	mirrorResultMatch    = "match"
+	mirrorResultDiverged = "diverged"
+	mirrorResultError    = "error"
+	mirrorResultDropped  = "dropped"
+)
+
+var (
+	// mirroredRequests counts the read requests replayed against the mirror shard, by whether
+	// the response of the mirror matched the one of this shard.
+	mirroredRequests = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kcp",
+			Subsystem:      "shard_mirror",
+			Name:           "requests_total",
+			Help:           "Number of read requests mirrored to another shard, by result (match, diverged, error or dropped).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	registerMirrorMetrics sync.Once
+)
+
+// Mirror replays a percentage of the read requests to selected workspaces against another shard,
+// e.g. a copy of this shard on a new storage, and counts the responses diverging from the ones of
+// this shard.
+type Mirror struct {
+	client     *http.Client
+	host       string
+	workspaces sets.String
+	inFlight   chan struct{}
+
+	sample func() bool
+	record func(result string)
+}
+
+// NewMirror returns a Mirror replaying the given percentage of the read requests to the given
+// workspaces against the shard of the config, which must hold credentials allowed to impersonate
+// the users of the requests.
+func NewMirror(config *rest.Config, workspaces []string, percentage int) (*Mirror, error) {
+	config = rest.CopyConfig(config)
+	config.Timeout = mirrorTimeout
+	client, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	registerMirrorMetrics.Do(func() {
+		legacyregistry.MustRegister(mirroredRequests)
+	})
+
+	return &Mirror{
+		client:     client,
+		host:       strings.TrimSuffix(config.Host, "/"),
+		workspaces: sets.NewString(workspaces...),
+		inFlight:   make(chan struct{}, maxMirroredInFlight),
+		sample:     func() bool { return rand.Intn(100) < percentage }, // nolint:gosec
+		record:     func(result string) { mirroredRequests.WithLabelValues(result).Inc() },
+	}, nil
+}
+
+// WithMirroring serves the requests with the apiHandler, and replays the sampled get and list
+// requests to the workspaces of the mirror asynchronously against the mirror shard, comparing
+// the responses. It must run after the request info and authentication filters, i.e. within the
+// handler chain.
+func WithMirroring(apiHandler http.Handler, mirror *Mirror) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		info, ok := request.RequestInfoFrom(req.Context())
+		if !ok || !info.IsResourceRequest || (info.Verb != "get" && info.Verb != "list") ||
+			cluster == nil || cluster.Wildcard || !mirror.workspaces.Has(cluster.Name.String()) || !mirror.sample() {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+		u, ok := request.UserFrom(req.Context())
+		if !ok {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		mirrored, err := mirror.newRequest(cluster.Name, req, u.GetName(), u.GetGroups())
+		if err != nil {
+			klog.Errorf("Failed to mirror request %s: %v", req.URL.Path, err)
+			mirror.record(mirrorResultError)
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		primary := &capturingResponseWriter{ResponseWriter: w, code: http.StatusOK}
+		apiHandler.ServeHTTP(responsewriter.WrapForHTTP1Or2(primary), req)
+		primary.contentType = primary.Header().Get("Content-Type")
+
+		select {
+		case mirror.inFlight <- struct{}{}:
+		default:
+			mirror.record(mirrorResultDropped)
+			return
+		}
+		go func() {
+			defer func() { <-mirror.inFlight }()
+			mirror.record(mirror.replay(mirrored, primary))
+		}()
+	})
+}
+
+// newRequest returns the request to the mirror shard equivalent to req to the given workspace,
+// impersonating the user of req.
+func (m *Mirror) newRequest(cluster logicalcluster.LogicalCluster, req *http.Request, user string, groups []string) (*http.Request, error) {
+	url := m.host + cluster.Path() + req.URL.Path
+	if req.URL.RawQuery != "" {
+		url += "?" + req.URL.RawQuery
+	}
+	mirrored, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	mirrored.Header.Set("Accept", "application/json")
+	mirrored.Header.Set("Impersonate-User", user)
+	for _, group := range groups {
+		mirrored.Header.Add("Impersonate-Group", group)
+	}
+	return mirrored, nil
+}
+
+// replay sends the request to the mirror shard and returns the result of the comparison of its
+// response with the primary response.
+func (m *Mirror) replay(req *http.Request, primary *capturingResponseWriter) string {
+	ctx, cancel := context.WithTimeout(context.Background(), mirrorTimeout)
+	defer cancel()
+
+	resp, err := m.client.Do(req.WithContext(ctx))
+	if err != nil {
+		klog.V(2).Infof("Failed to mirror request %s: %v", req.URL.Path, err)
+		return mirrorResultError
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMirroredBodyBytes+1))
+	if err != nil {
+		klog.V(2).Infof("Failed to read the mirrored response of %s: %v", req.URL.Path, err)
+		return mirrorResultError
+	}
+
+	compareBodies := !primary.truncated && len(body) <= maxMirroredBodyBytes &&
+		isJSON(primary.contentType) && isJSON(resp.Header.Get("Content-Type"))
+	if diff := divergence(primary.code, primary.body.Bytes(), resp.StatusCode, body, compareBodies); diff != "" {
+		klog.V(2).Infof("Mirrored request %s diverged: %s", req.URL.Path, diff)
+		return mirrorResultDiverged
+	}
+	return mirrorResultMatch
+}
+
+// divergence returns how the responses differ, or an empty string if they match. Bodies are
+// compared as JSON, ignoring the resource versions, which are specific to the storage of a shard.
+func divergence(primaryCode int, primaryBody []byte, mirroredCode int, mirroredBody []byte, compareBodies bool) string {
+	if primaryCode != mirroredCode {
+		return fmt.Sprintf("status code %d != %d", primaryCode, mirroredCode)
+	}
+	if !compareBodies {
+		return ""
+	}
+
+	var primary, mirrored interface{}
+	if err := json.Unmarshal(primaryBody, &primary); err != nil {
+		return fmt.Sprintf("invalid primary response: %v", err)
+	}
+	if err := json.Unmarshal(mirroredBody, &mirrored); err != nil {
+		return fmt.Sprintf("invalid mirrored response: %v", err)
+	}
+	if !reflect.DeepEqual(withoutResourceVersions(primary), withoutResourceVersions(mirrored)) {
+		return "bodies differ"
+	}
+	return ""
+}
+
+// withoutResourceVersions drops the resourceVersion and continue fields from the metadata of the
+// object, or of the list and its items.
+func withoutResourceVersions(obj interface{}) interface{} {
+	o, ok := obj.(map[string]interface{})
+	if !ok {
+		return obj
+	}
+	if metadata, ok := o["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "resourceVersion")
+		delete(metadata, "continue")
+	}
+	if items, ok := o["items"].([]interface{}); ok {
+		for _, item := range items {
+			withoutResourceVersions(item)
+		}
+	}
+	return o
+}
+
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json")
+}
+
+// capturingResponseWriter remembers the status code and the beginning of the body of the response.
+type capturingResponseWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+	body        bytes.Buffer
+	truncated   bool
+	contentType string
+}
+
+var _ responsewriter.UserProvidedDecorator = &capturingResponseWriter{}
+
+func (w *capturingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *capturingResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *capturingResponseWriter) Write(bs []byte) (int, error) {
+	w.wroteHeader = true
+	if !w.truncated {
+		if w.body.Len()+len(bs) > maxMirroredBodyBytes {
+			w.truncated = true
+			w.body.Reset()
+		} else {
+			w.body.Write(bs)
+		}
+	}
+	return w.ResponseWriter.Write(bs)
+}
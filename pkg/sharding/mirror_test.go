@@ -0,0 +1,198 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sharding
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/rest"
+)
+
+func TestWithMirroring(t *testing.T) {
+	const primaryBody = `{"kind":"ConfigMapList","metadata":{"resourceVersion":"10"},"items":[{"metadata":{"name":"foo","resourceVersion":"9"}}]}`
+
+	tests := map[string]struct {
+		cluster      request.Cluster
+		verb         string
+		mirrorCode   int
+		mirrorBody   string
+		mirrorBodyCT string
+
+		wantMirrored bool
+		wantResult   string
+	}{
+		"matching list": {
+			cluster:      request.Cluster{Name: logicalcluster.New("root:org:team")},
+			verb:         "list",
+			mirrorCode:   http.StatusOK,
+			mirrorBody:   `{"kind":"ConfigMapList","metadata":{"resourceVersion":"500"},"items":[{"metadata":{"name":"foo","resourceVersion":"499"}}]}`,
+			mirrorBodyCT: "application/json",
+			wantMirrored: true,
+			wantResult:   mirrorResultMatch,
+		},
+		"diverging list": {
+			cluster:      request.Cluster{Name: logicalcluster.New("root:org:team")},
+			verb:         "list",
+			mirrorCode:   http.StatusOK,
+			mirrorBody:   `{"kind":"ConfigMapList","metadata":{"resourceVersion":"500"},"items":[]}`,
+			mirrorBodyCT: "application/json",
+			wantMirrored: true,
+			wantResult:   mirrorResultDiverged,
+		},
+		"diverging status code": {
+			cluster:      request.Cluster{Name: logicalcluster.New("root:org:team")},
+			verb:         "get",
+			mirrorCode:   http.StatusNotFound,
+			mirrorBody:   `{"kind":"Status"}`,
+			mirrorBodyCT: "application/json",
+			wantMirrored: true,
+			wantResult:   mirrorResultDiverged,
+		},
+		"not a read request": {
+			cluster: request.Cluster{Name: logicalcluster.New("root:org:team")},
+			verb:    "create",
+		},
+		"watch": {
+			cluster: request.Cluster{Name: logicalcluster.New("root:org:team")},
+			verb:    "watch",
+		},
+		"other workspace": {
+			cluster: request.Cluster{Name: logicalcluster.New("root:org:other")},
+			verb:    "list",
+		},
+		"wildcard": {
+			cluster: request.Cluster{Name: logicalcluster.Wildcard, Wildcard: true},
+			verb:    "list",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mirrored := make(chan *http.Request, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mirrored <- req
+				w.Header().Set("Content-Type", tc.mirrorBodyCT)
+				w.WriteHeader(tc.mirrorCode)
+				w.Write([]byte(tc.mirrorBody)) //nolint:errcheck
+			}))
+			defer server.Close()
+
+			mirror, err := NewMirror(&rest.Config{Host: server.URL}, []string{"root:org:team"}, 100)
+			require.NoError(t, err)
+			results := make(chan string, 1)
+			mirror.record = func(result string) { results <- result }
+
+			handler := WithMirroring(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(primaryBody)) //nolint:errcheck
+			}), mirror)
+
+			ctx := request.WithCluster(context.Background(), tc.cluster)
+			ctx = request.WithRequestInfo(ctx, &request.RequestInfo{IsResourceRequest: true, Verb: tc.verb, Resource: "configmaps"})
+			ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "alice", Groups: []string{"team", "system:authenticated"}})
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/configmaps?limit=10", nil).WithContext(ctx)
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req)
+			require.Equal(t, http.StatusOK, rw.Code)
+			require.Equal(t, primaryBody, rw.Body.String())
+
+			if !tc.wantMirrored {
+				select {
+				case <-mirrored:
+					t.Fatal("unexpected mirrored request")
+				case <-time.After(100 * time.Millisecond):
+				}
+				return
+			}
+
+			select {
+			case req := <-mirrored:
+				require.Equal(t, "/clusters/root:org:team/api/v1/configmaps", req.URL.Path)
+				require.Equal(t, "limit=10", req.URL.RawQuery)
+				require.Equal(t, "alice", req.Header.Get("Impersonate-User"))
+				require.Equal(t, []string{"team", "system:authenticated"}, req.Header.Values("Impersonate-Group"))
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatal("request not mirrored")
+			}
+			select {
+			case result := <-results:
+				require.Equal(t, tc.wantResult, result)
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatal("no result recorded")
+			}
+		})
+	}
+}
+
+func TestDivergence(t *testing.T) {
+	tests := map[string]struct {
+		primaryCode, mirroredCode int
+		primaryBody, mirroredBody string
+		compareBodies             bool
+
+		wantDiverged bool
+	}{
+		"same status code, bodies not compared": {
+			primaryCode: 200, mirroredCode: 200,
+			primaryBody: "a", mirroredBody: "b",
+		},
+		"different status code": {
+			primaryCode: 200, mirroredCode: 500,
+			wantDiverged: true,
+		},
+		"only resource versions differ": {
+			primaryCode: 200, mirroredCode: 200,
+			primaryBody:   `{"metadata":{"name":"foo","resourceVersion":"1"}}`,
+			mirroredBody:  `{"metadata":{"resourceVersion":"2","name":"foo"}}`,
+			compareBodies: true,
+		},
+		"continue tokens differ": {
+			primaryCode: 200, mirroredCode: 200,
+			primaryBody:   `{"metadata":{"continue":"abc"},"items":[]}`,
+			mirroredBody:  `{"metadata":{"continue":"def"},"items":[]}`,
+			compareBodies: true,
+		},
+		"objects differ": {
+			primaryCode: 200, mirroredCode: 200,
+			primaryBody:   `{"metadata":{"name":"foo"},"data":{"a":"b"}}`,
+			mirroredBody:  `{"metadata":{"name":"foo"},"data":{"a":"c"}}`,
+			compareBodies: true,
+			wantDiverged:  true,
+		},
+		"invalid body": {
+			primaryCode: 200, mirroredCode: 200,
+			primaryBody:   `{}`,
+			mirroredBody:  `{`,
+			compareBodies: true,
+			wantDiverged:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff := divergence(tc.primaryCode, []byte(tc.primaryBody), tc.mirroredCode, []byte(tc.mirroredBody), tc.compareBodies)
+			require.Equal(t, tc.wantDiverged, diff != "", diff)
+		})
+	}
+}
@@ -60,9 +60,26 @@ func NewAPIImporter(
 	location string,
 ) (*APIImporter, error) {
 	agent := fmt.Sprintf("kcp-workload-api-importer-%s-%s", logicalClusterName, location)
-	upstreamConfig = rest.AddUserAgent(rest.CopyConfig(upstreamConfig), agent)
 	downstreamConfig = rest.AddUserAgent(rest.CopyConfig(downstreamConfig), agent)
 
+	schemaPuller, err := crdpuller.NewSchemaPuller(downstreamConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newAPIImporter(upstreamConfig, schemaPuller, resourcesToSync, logicalClusterName, location)
+}
+
+// newAPIImporter returns an APIImporter importing the schemas of the schema puller.
+func newAPIImporter(
+	upstreamConfig *rest.Config,
+	schemaPuller crdpuller.SchemaPuller,
+	resourcesToSync []string,
+	logicalClusterName logicalcluster.LogicalCluster,
+	location string,
+) (*APIImporter, error) {
+	agent := fmt.Sprintf("kcp-workload-api-importer-%s-%s", logicalClusterName, location)
+	upstreamConfig = rest.AddUserAgent(rest.CopyConfig(upstreamConfig), agent)
+
 	kcpClusterClient, err := kcpclient.NewClusterForConfig(upstreamConfig)
 	if err != nil {
 		return nil, err
@@ -98,11 +115,6 @@ func NewAPIImporter(
 		}
 	}
 
-	schemaPuller, err := crdpuller.NewSchemaPuller(downstreamConfig)
-	if err != nil {
-		return nil, err
-	}
-
 	return &APIImporter{
 		kcpInformerFactory:       kcpInformerFactory,
 		kcpClusterClient:         kcpClusterClient,
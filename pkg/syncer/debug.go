@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/kcp/pkg/reconciler/framework"
+)
+
+// controllerDump is the state of a syncer controller, for debugging.
+type controllerDump struct {
+	Name string `json:"name"`
+	// CacheSizes is the number of objects in the informer cache, by resource.
+	CacheSizes map[string]int        `json:"cacheSizes"`
+	Queue      []framework.QueuedKey `json:"queue"`
+}
+
+// targetDump is the state of the syncer controllers of a sync target, for debugging.
+type targetDump struct {
+	Name        string           `json:"name"`
+	Controllers []controllerDump `json:"controllers"`
+}
+
+func (c *Controller) dump() controllerDump {
+	dump := controllerDump{
+		Name:       c.name,
+		CacheSizes: map[string]int{},
+		Queue:      []framework.QueuedKey{},
+	}
+	if c.hasSynced() {
+		for _, gvr := range c.gvrs {
+			dump.CacheSizes[gvr.GroupResource().String()] = len(c.fromInformers.ForResource(gvr).Informer().GetStore().ListKeys())
+		}
+	}
+	for h, since := range c.pending.list() {
+		dump.Queue = append(dump.Queue, framework.QueuedKey{
+			Key:     h.gvr.GroupResource().String() + " " + informerKey(h),
+			Retries: c.queue.NumRequeues(h),
+			Since:   since,
+		})
+	}
+	sort.Slice(dump.Queue, func(i, j int) bool {
+		if !dump.Queue[i].Since.Equal(dump.Queue[j].Since) {
+			return dump.Queue[i].Since.Before(dump.Queue[j].Since)
+		}
+		return dump.Queue[i].Key < dump.Queue[j].Key
+	})
+	return dump
+}
+
+// dumpControllers dumps the queued objects with their retries, and the informer cache sizes of the
+// syncer controllers of all targets.
+func (h *health) dumpControllers(w http.ResponseWriter, _ *http.Request) {
+	dumps := []targetDump{}
+	for _, t := range h.listTargets() {
+		t.lock.Lock()
+		controllers := append([]*Controller(nil), t.controllers...)
+		t.lock.Unlock()
+
+		dump := targetDump{Name: t.name, Controllers: []controllerDump{}}
+		for _, c := range controllers {
+			dump.Controllers = append(dump.Controllers, c.dump())
+		}
+		dumps = append(dumps, dump)
+	}
+
+	bs, err := json.Marshal(dumps)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bs) //nolint:errcheck
+}
+
+// installDebugHandlers installs pprof and the controller dumps under /debug, for the requests
+// presenting the token as bearer token.
+func (h *health) installDebugHandlers(mux *http.ServeMux, token string) {
+	mux.Handle("/debug/controllers", withBearerToken(token, http.HandlerFunc(h.dumpControllers)))
+	mux.Handle("/debug/pprof/", withBearerToken(token, http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", withBearerToken(token, http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", withBearerToken(token, http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", withBearerToken(token, http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", withBearerToken(token, http.HandlerFunc(pprof.Trace)))
+}
+
+// validateLoopbackAddress fails unless the host of the address is a loopback address, such that
+// the debug endpoints, served without TLS, are only reachable from the pod, e.g. through
+// kubectl port-forward.
+func validateLoopbackAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid debug address %q: %w", address, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("debug address %q must be a loopback address", address)
+	}
+	return nil
+}
+
+func withBearerToken(token string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		presented := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
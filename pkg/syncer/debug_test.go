@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestDebugHandlers(t *testing.T) {
+	now := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+	c := &Controller{
+		name:    "Spec--root:org:ws--us-east1",
+		queue:   queue,
+		pending: newPendingObjects("root:org:ws|us-east1", SyncDown),
+	}
+	c.pending.nowFunc = func() time.Time { return now }
+	foo := holder{gvr: deployments, clusterName: logicalcluster.New("root:org:ws"), namespace: "ns", name: "foo"}
+	c.pending.add(foo)
+	queue.AddRateLimited(foo)
+
+	h := &health{now: time.Now}
+	h.addTarget("root:org:ws|us-east1").started(c)
+	mux := http.NewServeMux()
+	h.installDebugHandlers(mux, "secret")
+
+	for _, tc := range []struct {
+		name     string
+		path     string
+		token    string
+		wantCode int
+	}{
+		{name: "no token", path: "/debug/controllers", wantCode: http.StatusUnauthorized},
+		{name: "wrong token", path: "/debug/pprof/", token: "guess", wantCode: http.StatusUnauthorized},
+		{name: "pprof", path: "/debug/pprof/", token: "secret", wantCode: http.StatusOK},
+		{name: "controllers", path: "/debug/controllers", token: "secret", wantCode: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			require.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.path == "/debug/controllers" && tc.wantCode == http.StatusOK {
+				var dumps []targetDump
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &dumps))
+				require.Len(t, dumps, 1)
+				require.Equal(t, "root:org:ws|us-east1", dumps[0].Name)
+				require.Len(t, dumps[0].Controllers, 1)
+				dump := dumps[0].Controllers[0]
+				require.Equal(t, "Spec--root:org:ws--us-east1", dump.Name)
+				require.Empty(t, dump.CacheSizes, "the informers have not synced yet")
+				require.Len(t, dump.Queue, 1)
+				require.Equal(t, "deployments.apps ns/root:org:ws#$#foo", dump.Queue[0].Key)
+				require.Equal(t, 1, dump.Queue[0].Retries)
+				require.True(t, now.Equal(dump.Queue[0].Since))
+			}
+		})
+	}
+}
+
+func TestValidateLoopbackAddress(t *testing.T) {
+	for _, tc := range []struct {
+		address string
+		wantErr bool
+	}{
+		{address: "127.0.0.1:8081"},
+		{address: "[::1]:8081"},
+		{address: "localhost:8081"},
+		{address: ":8081", wantErr: true},
+		{address: "0.0.0.0:8081", wantErr: true},
+		{address: "10.0.0.1:8081", wantErr: true},
+		{address: "127.0.0.1", wantErr: true},
+	} {
+		t.Run(tc.address, func(t *testing.T) {
+			err := validateLoopbackAddress(tc.address)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
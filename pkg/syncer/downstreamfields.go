@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package syncer
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// downstreamFieldsExtractors extract the fields populated by the physical cluster, per resource.
+var downstreamFieldsExtractors = map[schema.GroupResource]func(obj *unstructured.Unstructured) map[string]interface{}{
+	{Resource: "services"}:               serviceDownstreamFields,
+	{Resource: "persistentvolumeclaims"}: persistentVolumeClaimDownstreamFields,
+}
+
+// downstreamFields returns the fields of the downstream object populated by the physical cluster, or nil if
+// there are none.
+func downstreamFields(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) map[string]interface{} {
+	extract, ok := downstreamFieldsExtractors[gvr.GroupResource()]
+	if !ok || obj == nil {
+		return nil
+	}
+	fields := extract(obj)
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+func deepEqualDownstreamFields(gvr schema.GroupVersionResource, oldObj, newObj interface{}) bool {
+	oldUnstrob, isOldObjUnstructured := oldObj.(*unstructured.Unstructured)
+	newUnstrob, isNewObjUnstructured := newObj.(*unstructured.Unstructured)
+	if !isOldObjUnstructured || !isNewObjUnstructured {
+		return false
+	}
+	return equality.Semantic.DeepEqual(downstreamFields(gvr, oldUnstrob), downstreamFields(gvr, newUnstrob))
+}
+
+// downstreamFieldsValue returns the downstream fields annotation value for the object, or an empty
+// string if there are none.
+func downstreamFieldsValue(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) (string, error) {
+	fields := downstreamFields(gvr, obj)
+	if fields == nil {
+		return "", nil
+	}
+	bs, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+func serviceDownstreamFields(obj *unstructured.Unstructured) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	ports, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+	var nodePorts []interface{}
+	for _, port := range ports {
+		port, ok := port.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, ok := port["nodePort"]; !ok {
+			continue
+		}
+		nodePort := map[string]interface{}{}
+		for _, key := range []string{"name", "protocol", "port", "nodePort"} {
+			if value, ok := port[key]; ok {
+				nodePort[key] = value
+			}
+		}
+		nodePorts = append(nodePorts, nodePort)
+	}
+	if len(nodePorts) > 0 {
+		fields["nodePorts"] = nodePorts
+	}
+
+	if ingress, _, _ := unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress"); len(ingress) > 0 {
+		fields["loadBalancerIngress"] = ingress
+	}
+
+	return fields
+}
+
+func persistentVolumeClaimDownstreamFields(obj *unstructured.Unstructured) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if volumeName, _, _ := unstructured.NestedString(obj.Object, "spec", "volumeName"); volumeName != "" {
+		fields["volumeName"] = volumeName
+	}
+	return fields
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDownstreamFieldsValue(t *testing.T) {
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	claims := schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	tests := map[string]struct {
+		gvr  schema.GroupVersionResource
+		obj  map[string]interface{}
+		want string
+	}{
+		"cluster IP service": {
+			gvr: services,
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"name": "http", "port": int64(80)},
+					},
+				},
+			},
+			want: "",
+		},
+		"node port service": {
+			gvr: services,
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"name": "http", "port": int64(80), "protocol": "TCP", "targetPort": int64(8080), "nodePort": int64(30080)},
+						map[string]interface{}{"name": "metrics", "port": int64(9090)},
+					},
+				},
+			},
+			want: `{"nodePorts":[{"name":"http","nodePort":30080,"port":80,"protocol":"TCP"}]}`,
+		},
+		"load balancer service": {
+			gvr: services,
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"port": int64(443), "nodePort": int64(30443)},
+					},
+				},
+				"status": map[string]interface{}{
+					"loadBalancer": map[string]interface{}{
+						"ingress": []interface{}{
+							map[string]interface{}{"ip": "10.0.0.1"},
+						},
+					},
+				},
+			},
+			want: `{"loadBalancerIngress":[{"ip":"10.0.0.1"}],"nodePorts":[{"nodePort":30443,"port":443}]}`,
+		},
+		"bound claim": {
+			gvr: claims,
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"volumeName": "pv-1",
+				},
+			},
+			want: `{"volumeName":"pv-1"}`,
+		},
+		"pending claim": {
+			gvr:  claims,
+			obj:  map[string]interface{}{"spec": map[string]interface{}{}},
+			want: "",
+		},
+		"other resources": {
+			gvr: deployments,
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"volumeName": "pv-1",
+				},
+			},
+			want: "",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := downstreamFieldsValue(tc.gvr, &unstructured.Unstructured{Object: tc.obj})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package syncer
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/endpointslices"
+)
+
+var (
+	servicesGVR       = schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	endpointSlicesGVR = discoveryv1.SchemeGroupVersion.WithResource("endpointslices")
+)
+
+// watchEndpointSlices enqueues the Services of the EndpointSlices of the physical cluster when
+// their endpoints change. The EndpointSlices inherit the labels of their Service, and are hence
+// selected by the informers of the syncer.
+func (c *Controller) watchEndpointSlices() {
+	enqueue := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		metaObj, err := meta.Accessor(obj)
+		if err != nil {
+			klog.Errorf("%s: error getting meta for %T", c.name, obj)
+			return
+		}
+		serviceName := metaObj.GetLabels()[discoveryv1.LabelServiceName]
+		if serviceName == "" {
+			return
+		}
+		c.queue.Add(holder{
+			gvr:         servicesGVR,
+			clusterName: logicalcluster.From(metaObj),
+			namespace:   metaObj.GetNamespace(),
+			name:        serviceName,
+		})
+	}
+
+	c.fromInformers.ForResource(endpointSlicesGVR).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, newObj interface{}) { enqueue(newObj) },
+		DeleteFunc: enqueue,
+	})
+	c.watchesEndpointSlices = true
+}
+
+// endpointsValue returns the endpoints annotation value for the downstream Service, or an empty
+// string if it has no ready endpoints.
+func (c *Controller) endpointsValue(service *unstructured.Unstructured) (string, error) {
+	selector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: service.GetName()})
+	objs, err := c.fromInformers.ForResource(endpointSlicesGVR).Lister().ByNamespace(service.GetNamespace()).List(selector)
+	if err != nil {
+		return "", err
+	}
+
+	var slices []*discoveryv1.EndpointSlice
+	for _, obj := range objs {
+		unstrob, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		var slice discoveryv1.EndpointSlice
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstrob.UnstructuredContent(), &slice); err != nil {
+			return "", err
+		}
+		slices = append(slices, &slice)
+	}
+
+	endpoints := readyEndpoints(slices)
+	if len(endpoints) == 0 {
+		return "", nil
+	}
+	bs, err := json.Marshal(endpoints)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+// readyEndpoints returns the ready endpoints of the EndpointSlices, ordered by EndpointSlice name.
+// References to the pods of the physical cluster are dropped, as they are meaningless upstream.
+func readyEndpoints(slices []*discoveryv1.EndpointSlice) []endpointslices.ClusterEndpoints {
+	sort.Slice(slices, func(i, j int) bool { return slices[i].Name < slices[j].Name })
+
+	var clusterEndpoints []endpointslices.ClusterEndpoints
+	for _, slice := range slices {
+		var ready []discoveryv1.Endpoint
+		for _, endpoint := range slice.Endpoints {
+			// A nil ready condition has to be interpreted as ready.
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			endpoint := *endpoint.DeepCopy()
+			endpoint.TargetRef = nil
+			ready = append(ready, endpoint)
+		}
+		if len(ready) == 0 {
+			continue
+		}
+		clusterEndpoints = append(clusterEndpoints, endpointslices.ClusterEndpoints{
+			AddressType: slice.AddressType,
+			Ports:       slice.Ports,
+			Endpoints:   ready,
+		})
+	}
+	return clusterEndpoints
+}
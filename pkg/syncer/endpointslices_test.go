@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/kcp-dev/kcp/pkg/reconciler/workload/endpointslices"
+)
+
+func TestReadyEndpoints(t *testing.T) {
+	port := discoveryv1.EndpointPort{Name: pointer.String("http"), Port: pointer.Int32(8080)}
+	endpoint := func(address string, ready *bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{address},
+			Conditions: discoveryv1.EndpointConditions{Ready: ready},
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "pod-" + address},
+		}
+	}
+	slice := func(name string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta:  metav1.ObjectMeta{Name: name},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Ports:       []discoveryv1.EndpointPort{port},
+			Endpoints:   endpoints,
+		}
+	}
+
+	tests := map[string]struct {
+		slices []*discoveryv1.EndpointSlice
+		want   []endpointslices.ClusterEndpoints
+	}{
+		"no slices": {},
+		"no ready endpoints": {
+			slices: []*discoveryv1.EndpointSlice{slice("web-abcde", endpoint("10.0.0.1", pointer.Bool(false)))},
+		},
+		"ready and unknown endpoints are kept, ordered by slice": {
+			slices: []*discoveryv1.EndpointSlice{
+				slice("web-zzzzz", endpoint("10.0.0.3", nil)),
+				slice("web-abcde", endpoint("10.0.0.1", pointer.Bool(true)), endpoint("10.0.0.2", pointer.Bool(false))),
+			},
+			want: []endpointslices.ClusterEndpoints{
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Ports:       []discoveryv1.EndpointPort{port},
+					Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true)}}},
+				},
+				{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Ports:       []discoveryv1.EndpointPort{port},
+					Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.3"}}},
+				},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.want, readyEndpoints(tt.slices))
+		})
+	}
+}
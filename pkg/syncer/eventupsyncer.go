@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+var eventsGVR = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+
+// NewEventUpsyncer returns an upsyncer of the events of the physical cluster in the synced namespaces,
+// e.g. about pods failing to be scheduled or to pull their image, so that the reasons of failures can
+// be seen in kcp without access to the physical cluster.
+func NewEventUpsyncer(from, to *rest.Config, kcpClusterName logicalcluster.LogicalCluster, pclusterID string) (*Upsyncer, error) {
+	fromClient, err := dynamic.NewForConfig(from)
+	if err != nil {
+		return nil, err
+	}
+	toClients, err := dynamic.NewClusterForConfig(to)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(to)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient.WithCluster(kcpClusterName)))
+	return newEventUpsyncer(kcpClusterName, pclusterID, fromClient, toClients.Cluster(kcpClusterName), mapper), nil
+}
+
+func newEventUpsyncer(kcpClusterName logicalcluster.LogicalCluster, pclusterID string, fromClient, toClient dynamic.Interface, mapper meta.RESTMapper) *Upsyncer {
+	u := newUpsyncer(eventsGVR, kcpClusterName, pclusterID, fromClient, toClient)
+	u.transform = func(ctx context.Context, upstreamObj *unstructured.Unstructured) error {
+		return involveUpstreamObject(ctx, toClient, mapper, upstreamObj)
+	}
+	return u
+}
+
+// involveUpstreamObject points the event to the object in kcp it is about, e.g. the Deployment of
+// the workspace, or its upsynced pod. The object is looked up by kind and name, as the UID of an
+// object differs between kcp and the physical cluster. If the object does not exist in kcp, the
+// event keeps referencing it by kind and name only.
+func involveUpstreamObject(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, event *unstructured.Unstructured) error {
+	// the related object is another object of the physical cluster
+	delete(event.Object, "related")
+
+	involved, found, err := unstructured.NestedMap(event.Object, "involvedObject")
+	if err != nil || !found {
+		return err
+	}
+	apiVersion, _, _ := unstructured.NestedString(involved, "apiVersion")
+	kind, _, _ := unstructured.NestedString(involved, "kind")
+	name, _, _ := unstructured.NestedString(involved, "name")
+	namespace, _, _ := unstructured.NestedString(involved, "namespace")
+	if namespace != "" {
+		namespace = event.GetNamespace()
+		involved["namespace"] = namespace
+	}
+	delete(involved, "resourceVersion")
+	delete(involved, "uid")
+	defer func() {
+		event.Object["involvedObject"] = involved
+	}()
+
+	gvk := schema.FromAPIVersionAndKind(apiVersion, kind)
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// the resource might have been added to kcp since the last discovery
+		meta.MaybeResetRESTMapper(mapper)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var obj *unstructured.Unstructured
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		obj, err = client.Resource(mapping.Resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	} else {
+		obj, err = client.Resource(mapping.Resource).Get(ctx, name, metav1.GetOptions{})
+	}
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	involved["uid"] = string(obj.GetUID())
+	return nil
+}
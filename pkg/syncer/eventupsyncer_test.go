@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestInvolveUpstreamObject(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment"}}
+	deployment.SetNamespace("ns")
+	deployment.SetName("foo")
+	deployment.SetUID("upstream-uid")
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), deployment)
+
+	tests := map[string]struct {
+		involvedObject map[string]interface{}
+		want           map[string]interface{}
+	}{
+		"object in kcp": {
+			involvedObject: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "namespace": "kcp1234", "name": "foo", "uid": "downstream-uid", "resourceVersion": "42"},
+			want:           map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "namespace": "ns", "name": "foo", "uid": "upstream-uid"},
+		},
+		"object not in kcp": {
+			involvedObject: map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "namespace": "kcp1234", "name": "bar", "uid": "downstream-uid"},
+			want:           map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "namespace": "ns", "name": "bar"},
+		},
+		"resource not in kcp": {
+			involvedObject: map[string]interface{}{"apiVersion": "apps/v1", "kind": "ReplicaSet", "namespace": "kcp1234", "name": "foo-1234", "uid": "downstream-uid", "fieldPath": "spec"},
+			want:           map[string]interface{}{"apiVersion": "apps/v1", "kind": "ReplicaSet", "namespace": "ns", "name": "foo-1234", "fieldPath": "spec"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			event := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion":     "v1",
+				"kind":           "Event",
+				"involvedObject": tc.involvedObject,
+				"related":        map[string]interface{}{"kind": "Node", "name": "node-1"},
+			}}
+			event.SetNamespace("ns")
+			require.NoError(t, involveUpstreamObject(context.Background(), client, mapper, event))
+			require.Equal(t, tc.want, event.Object["involvedObject"])
+			require.NotContains(t, event.Object, "related")
+		})
+	}
+}
+
+func TestEventUpsyncer(t *testing.T) {
+	upstreamClusterName := logicalcluster.New("root:org:ws")
+	downstreamNamespace, err := PhysicalClusterNamespaceName(NamespaceLocator{LogicalCluster: upstreamClusterName, Namespace: "ns"})
+	require.NoError(t, err)
+	locator, err := json.Marshal(NamespaceLocator{LogicalCluster: upstreamClusterName, Namespace: "ns"})
+	require.NoError(t, err)
+
+	namespace := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Namespace"}}
+	namespace.SetName(downstreamNamespace)
+	namespace.SetAnnotations(map[string]string{namespaceLocatorAnnotation: string(locator)})
+
+	event := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion":     "v1",
+		"kind":           "Event",
+		"reason":         "FailedScheduling",
+		"message":        "0/3 nodes are available: 3 Insufficient cpu.",
+		"involvedObject": map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "namespace": downstreamNamespace, "name": "foo-1234-abcde"},
+	}}
+	event.SetNamespace(downstreamNamespace)
+	event.SetName("foo-1234-abcde.16e1c3b2a4f0d3c1")
+
+	upstreamClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	u := newEventUpsyncer(upstreamClusterName, "us-east1", dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), upstreamClient, meta.NewDefaultRESTMapper(nil))
+	require.NoError(t, u.downstreamNamespaceInformers.ForResource(namespacesGVR).Informer().GetIndexer().Add(namespace))
+	require.NoError(t, u.downstreamInformers.ForResource(eventsGVR).Informer().GetIndexer().Add(event))
+
+	require.NoError(t, u.process(context.Background(), upsyncedObject{namespace: "ns", name: event.GetName()}))
+
+	got, err := upstreamClient.Resource(eventsGVR).Namespace("ns").Get(context.Background(), event.GetName(), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"workload.kcp.dev/upsynced-from": "us-east1"}, got.GetLabels())
+	require.Equal(t, "FailedScheduling", got.Object["reason"])
+	require.Equal(t, map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "namespace": "ns", "name": "foo-1234-abcde"}, got.Object["involvedObject"])
+}
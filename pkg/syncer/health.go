@@ -0,0 +1,195 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+// downstreamCheckTimeout is the timeout of the connectivity check of the physical cluster.
+const downstreamCheckTimeout = 5 * time.Second
+
+// health tracks the connectivity of the syncer to kcp and to the physical cluster.
+type health struct {
+	downstream discovery.DiscoveryInterface
+	now        func() time.Time
+
+	lock    sync.Mutex
+	targets []*targetHealth
+}
+
+// targetHealth tracks the connectivity to kcp and the informers of a sync target.
+type targetHealth struct {
+	name string
+	// lastHeartbeat is the time of the last successful heartbeat to kcp, in unix nanoseconds.
+	lastHeartbeat int64
+
+	lock        sync.Mutex
+	controllers []*Controller
+}
+
+func newHealth(downstream *rest.Config) (*health, error) {
+	downstream = rest.CopyConfig(downstream)
+	downstream.Timeout = downstreamCheckTimeout
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(downstream)
+	if err != nil {
+		return nil, err
+	}
+	return &health{
+		downstream: discoveryClient,
+		now:        time.Now,
+	}, nil
+}
+
+func (h *health) addTarget(name string) *targetHealth {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	t := &targetHealth{name: name}
+	h.targets = append(h.targets, t)
+	return t
+}
+
+func (h *health) listTargets() []*targetHealth {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]*targetHealth(nil), h.targets...)
+}
+
+func (t *targetHealth) heartbeat(at time.Time) {
+	atomic.StoreInt64(&t.lastHeartbeat, at.UnixNano())
+}
+
+// started records the syncer controllers of the target once they are created.
+func (t *targetHealth) started(controllers ...*Controller) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.controllers = controllers
+}
+
+func (t *targetHealth) hasSynced() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.controllers) == 0 {
+		return false
+	}
+	for _, c := range t.controllers {
+		if !c.hasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkUpstream fails when the syncer has not heartbeated to kcp recently for any of the targets.
+func (h *health) checkUpstream(_ *http.Request) error {
+	var failing []string
+	for _, t := range h.listTargets() {
+		last := atomic.LoadInt64(&t.lastHeartbeat)
+		if last == 0 {
+			failing = append(failing, fmt.Sprintf("%s: no successful heartbeat to kcp yet", t.name))
+		} else if since := h.now().Sub(time.Unix(0, last)); since > 3*heartbeatInterval {
+			failing = append(failing, fmt.Sprintf("%s: no successful heartbeat to kcp for %s", t.name, since.Round(time.Second)))
+		}
+	}
+	if len(failing) > 0 {
+		return errors.New(strings.Join(failing, ", "))
+	}
+	return nil
+}
+
+// checkDownstream fails when the physical cluster cannot be reached.
+func (h *health) checkDownstream(_ *http.Request) error {
+	if _, err := h.downstream.ServerVersion(); err != nil {
+		return fmt.Errorf("physical cluster cannot be reached: %w", err)
+	}
+	return nil
+}
+
+// checkSynced fails until the informers of the syncer controllers of all targets have synced.
+func (h *health) checkSynced(_ *http.Request) error {
+	var failing []string
+	for _, t := range h.listTargets() {
+		if !t.hasSynced() {
+			failing = append(failing, t.name)
+		}
+	}
+	if len(failing) > 0 {
+		return fmt.Errorf("informers of %s have not synced yet", strings.Join(failing, ", "))
+	}
+	return nil
+}
+
+// serve serves the metrics, and the /healthz and /readyz endpoints on the address until the context is done.
+// If debugToken is not empty, the debug endpoints are served to the requests presenting it.
+func (h *health) serve(ctx context.Context, address string) error {
+	connectivity := []healthz.HealthChecker{
+		healthz.NamedCheck("upstream", h.checkUpstream),
+		healthz.NamedCheck("downstream", h.checkDownstream),
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", legacyregistry.Handler())
+	healthz.InstallHandler(mux, connectivity...)
+	healthz.InstallReadyzHandler(mux, append(connectivity, healthz.NamedCheck("informer-sync", h.checkSynced))...)
+
+	return serveMux(ctx, address, mux, "syncer metrics and health")
+}
+
+// serveDebug serves the debug endpoints on the given loopback address, to the requests
+// presenting the token as bearer token.
+func (h *health) serveDebug(ctx context.Context, address, token string) error {
+	if err := validateLoopbackAddress(address); err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	h.installDebugHandlers(mux, token)
+
+	return serveMux(ctx, address, mux, "syncer debug endpoints")
+}
+
+func serveMux(ctx context.Context, address string, mux *http.ServeMux, what string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close() // nolint:errcheck
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			klog.Errorf("Failed to serve %s: %v", what, err)
+		}
+	}()
+	klog.Infof("Serving %s on %s", what, listener.Addr())
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestCheckUpstream(t *testing.T) {
+	now := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name          string
+		lastHeartbeat time.Time
+		wantErr       bool
+	}{
+		{name: "no heartbeat yet", wantErr: true},
+		{name: "recent heartbeat", lastHeartbeat: now.Add(-heartbeatInterval)},
+		{name: "stale heartbeat", lastHeartbeat: now.Add(-4 * heartbeatInterval), wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &health{now: func() time.Time { return now }}
+			synced := h.addTarget("root:org:ws|synced")
+			synced.heartbeat(now)
+			target := h.addTarget("root:org:ws|target")
+			if !tc.lastHeartbeat.IsZero() {
+				target.heartbeat(tc.lastHeartbeat)
+			}
+			err := h.checkUpstream(nil)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckSynced(t *testing.T) {
+	spec, status := &Controller{name: "spec"}, &Controller{name: "status"}
+	h := &health{}
+	target := h.addTarget("root:org:ws|target")
+	require.Error(t, h.checkSynced(nil), "controllers are not started yet")
+
+	target.started(spec, status)
+	require.Error(t, h.checkSynced(nil))
+
+	spec.synced = 1
+	require.Error(t, h.checkSynced(nil))
+
+	status.synced = 1
+	require.NoError(t, h.checkSynced(nil))
+}
+
+func TestPendingObjects(t *testing.T) {
+	RegisterMetrics()
+
+	now := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	p := newPendingObjects("root:org:ws|target", SyncUp)
+	p.nowFunc = func() time.Time { return now }
+
+	foo := holder{gvr: deployments, namespace: "ns", name: "foo"}
+	bar := holder{gvr: deployments, namespace: "ns", name: "bar"}
+	p.add(foo)
+	now = now.Add(time.Second)
+	p.add(foo)
+	p.add(bar)
+	depth, err := testutil.GetGaugeMetricValue(queueDepth.WithLabelValues("root:org:ws|target", "up", "deployments.apps"))
+	require.NoError(t, err)
+	require.Equal(t, float64(2), depth)
+
+	// a re-added object keeps waiting since it was first added
+	since := p.done(foo)
+	require.Equal(t, now.Add(-time.Second), since)
+	depth, err = testutil.GetGaugeMetricValue(queueDepth.WithLabelValues("root:org:ws|target", "up", "deployments.apps"))
+	require.NoError(t, err)
+	require.Equal(t, float64(1), depth)
+
+	before, err := testutil.GetCounterMetricValue(syncAttempts.WithLabelValues("root:org:ws|target", "up", "deployments.apps", syncResultConflict))
+	require.NoError(t, err)
+	p.observe(foo, since, k8serrors.NewConflict(deployments.GroupResource(), "foo", errors.New("conflict")))
+	after, err := testutil.GetCounterMetricValue(syncAttempts.WithLabelValues("root:org:ws|target", "up", "deployments.apps", syncResultConflict))
+	require.NoError(t, err)
+	require.Equal(t, before+1, after)
+}
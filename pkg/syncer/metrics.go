@@ -0,0 +1,208 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	syncResultSuccess  = "success"
+	syncResultConflict = "conflict"
+	syncResultError    = "error"
+)
+
+var (
+	// syncAttempts counts the attempts to sync an object, by result.
+	syncAttempts = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kcp",
+			Subsystem:      "syncer",
+			Name:           "sync_attempts_total",
+			Help:           "Number of attempts to sync an object, by sync target, direction, resource and result (success, conflict or error).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"sync_target", "direction", "resource", "result"},
+	)
+
+	// syncDuration observes the time from the change of an object to its successful sync,
+	// including retries. For the up direction, it is the upsync latency of the status.
+	syncDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Namespace:      "kcp",
+			Subsystem:      "syncer",
+			Name:           "sync_duration_seconds",
+			Help:           "Time from the change of an object to its successful sync, by sync target, direction and resource.",
+			Buckets:        []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"sync_target", "direction", "resource"},
+	)
+
+	// queueDepth is the number of objects waiting to be synced.
+	queueDepth = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Namespace:      "kcp",
+			Subsystem:      "syncer",
+			Name:           "queue_depth",
+			Help:           "Number of objects waiting to be synced, by sync target, direction and resource.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"sync_target", "direction", "resource"},
+	)
+
+	// downstreamAPIErrors counts the failed requests to the physical cluster. Not found and
+	// conflict responses are part of the normal operation of the syncer, and are not counted.
+	downstreamAPIErrors = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kcp",
+			Subsystem:      "syncer",
+			Name:           "downstream_api_errors_total",
+			Help:           "Number of failed requests to the physical cluster, by status code, or \"transport\" if no response was received.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"code"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the metrics of the syncer.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(syncAttempts)
+		legacyregistry.MustRegister(syncDuration)
+		legacyregistry.MustRegister(queueDepth)
+		legacyregistry.MustRegister(downstreamAPIErrors)
+	})
+}
+
+func syncResult(err error) string {
+	switch {
+	case err == nil:
+		return syncResultSuccess
+	case k8serrors.IsConflict(err):
+		return syncResultConflict
+	default:
+		return syncResultError
+	}
+}
+
+// pendingObjects tracks the objects waiting in the queue of a syncer controller, and since when
+// they are waiting, for the queue depth and the sync duration per resource.
+type pendingObjects struct {
+	// target is the WorkloadCluster of the syncer controller, as workspace|name.
+	target    string
+	direction SyncDirection
+
+	lock    sync.Mutex
+	since   map[holder]time.Time
+	byGVR   map[schema.GroupVersionResource]int
+	nowFunc func() time.Time
+}
+
+func newPendingObjects(target string, direction SyncDirection) *pendingObjects {
+	return &pendingObjects{
+		target:    target,
+		direction: direction,
+		since:     map[holder]time.Time{},
+		byGVR:     map[schema.GroupVersionResource]int{},
+		nowFunc:   time.Now,
+	}
+}
+
+// add records that the object is waiting to be synced. An object which is already waiting keeps
+// the time it started waiting.
+func (p *pendingObjects) add(h holder) {
+	p.addSince(h, p.nowFunc())
+}
+
+func (p *pendingObjects) addSince(h holder, since time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.since[h]; ok {
+		return
+	}
+	p.since[h] = since
+	p.byGVR[h.gvr]++
+	queueDepth.WithLabelValues(p.target, string(p.direction), h.gvr.GroupResource().String()).Set(float64(p.byGVR[h.gvr]))
+}
+
+// done records that the object is being synced, and returns since when it was waiting.
+func (p *pendingObjects) done(h holder) time.Time {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	since, ok := p.since[h]
+	if !ok {
+		return p.nowFunc()
+	}
+	delete(p.since, h)
+	p.byGVR[h.gvr]--
+	queueDepth.WithLabelValues(p.target, string(p.direction), h.gvr.GroupResource().String()).Set(float64(p.byGVR[h.gvr]))
+	return since
+}
+
+// list returns the objects waiting to be synced, and since when.
+func (p *pendingObjects) list() map[holder]time.Time {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	pending := make(map[holder]time.Time, len(p.since))
+	for h, since := range p.since {
+		pending[h] = since
+	}
+	return pending
+}
+
+// len returns the number of objects waiting to be synced.
+func (p *pendingObjects) len() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.since)
+}
+
+// observe records the result of the sync of the object which was waiting since the given time.
+func (p *pendingObjects) observe(h holder, since time.Time, err error) {
+	resource := h.gvr.GroupResource().String()
+	syncAttempts.WithLabelValues(p.target, string(p.direction), resource, syncResult(err)).Inc()
+	if err == nil {
+		syncDuration.WithLabelValues(p.target, string(p.direction), resource).Observe(p.nowFunc().Sub(since).Seconds())
+	}
+}
+
+// errorCountingRoundTripper counts the failed requests to the physical cluster.
+type errorCountingRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func (rt *errorCountingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.delegate.RoundTrip(req)
+	switch {
+	case err != nil:
+		downstreamAPIErrors.WithLabelValues("transport").Inc()
+	case resp.StatusCode >= 400 && resp.StatusCode != http.StatusNotFound && resp.StatusCode != http.StatusConflict:
+		downstreamAPIErrors.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+	}
+	return resp, err
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// ClassMutator translates the PriorityClass and RuntimeClass referenced by the pod specs of the
+// workspace to those of the physical cluster, following the mappings of the WorkloadCluster.
+//
+// The fields the physical cluster derives from the classes, i.e. the priority, the preemption
+// policy and the pod overhead, are removed, such that its admission computes them from its own
+// classes instead of rejecting values computed in kcp.
+type ClassMutator struct {
+	lock            sync.RWMutex
+	priorityClasses map[string]string
+	runtimeClasses  map[string]string
+}
+
+func NewClassMutator() *ClassMutator {
+	return &ClassMutator{}
+}
+
+// GVRs returns the resources holding a pod spec which are mutated.
+func (cm *ClassMutator) GVRs() []schema.GroupVersionResource {
+	gvrs := make([]schema.GroupVersionResource, 0, len(workloads))
+	for _, w := range workloads {
+		gvrs = append(gvrs, w.gvr)
+	}
+	return gvrs
+}
+
+// SetMappings replaces the mappings with those of the WorkloadCluster spec, and returns whether
+// they changed.
+func (cm *ClassMutator) SetMappings(spec workloadv1alpha1.WorkloadClusterSpec) bool {
+	priorityClasses := classMappings(spec.PriorityClassMappings)
+	runtimeClasses := classMappings(spec.RuntimeClassMappings)
+
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	if equality.Semantic.DeepEqual(priorityClasses, cm.priorityClasses) && equality.Semantic.DeepEqual(runtimeClasses, cm.runtimeClasses) {
+		return false
+	}
+	cm.priorityClasses, cm.runtimeClasses = priorityClasses, runtimeClasses
+	return true
+}
+
+func classMappings(mappings []workloadv1alpha1.ClassMapping) map[string]string {
+	if len(mappings) == 0 {
+		return nil
+	}
+	ret := make(map[string]string, len(mappings))
+	for _, m := range mappings {
+		ret[m.Name] = m.ClusterName
+	}
+	return ret
+}
+
+// Mutate applies the mutator changes to the object.
+func (cm *ClassMutator) Mutate(downstreamObj *unstructured.Unstructured, upstreamNamespace string) error {
+	podSpecPath := podSpecPathOf(downstreamObj)
+	if podSpecPath == nil {
+		return nil
+	}
+	podSpec, found, err := unstructured.NestedMap(downstreamObj.Object, podSpecPath...)
+	if err != nil || !found {
+		return err
+	}
+
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	if translateClass(podSpec, "priorityClassName", cm.priorityClasses) {
+		delete(podSpec, "priority")
+		delete(podSpec, "preemptionPolicy")
+	}
+	if translateClass(podSpec, "runtimeClassName", cm.runtimeClasses) {
+		delete(podSpec, "overhead")
+	}
+
+	return unstructured.SetNestedMap(downstreamObj.Object, podSpec, podSpecPath...)
+}
+
+// translateClass replaces the class referenced by the field of the pod spec by its mapping, removing
+// the field if it maps to the empty string. It returns whether the pod spec references a class.
+func translateClass(podSpec map[string]interface{}, field string, mappings map[string]string) bool {
+	name, _ := podSpec[field].(string)
+	if name == "" {
+		return false
+	}
+	if clusterName, found := mappings[name]; !found {
+		return true
+	} else if clusterName == "" {
+		delete(podSpec, field)
+	} else {
+		podSpec[field] = clusterName
+	}
+	return true
+}
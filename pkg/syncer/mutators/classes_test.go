@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestClassMutator(t *testing.T) {
+	deployment := func(podSpec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "foo"},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{"spec": podSpec},
+			},
+		}}
+	}
+	spec := workloadv1alpha1.WorkloadClusterSpec{
+		PriorityClassMappings: []workloadv1alpha1.ClassMapping{
+			{Name: "high", ClusterName: "tenant-high"},
+			{Name: "dropped"},
+		},
+		RuntimeClassMappings: []workloadv1alpha1.ClassMapping{
+			{Name: "sandboxed", ClusterName: "gvisor"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want *unstructured.Unstructured
+	}{
+		{
+			name: "no classes",
+			obj:  deployment(map[string]interface{}{"schedulerName": "default"}),
+			want: deployment(map[string]interface{}{"schedulerName": "default"}),
+		},
+		{
+			name: "mapped classes",
+			obj: deployment(map[string]interface{}{
+				"priorityClassName": "high",
+				"priority":          int64(1000),
+				"preemptionPolicy":  "Never",
+				"runtimeClassName":  "sandboxed",
+				"overhead":          map[string]interface{}{"cpu": "250m"},
+			}),
+			want: deployment(map[string]interface{}{
+				"priorityClassName": "tenant-high",
+				"runtimeClassName":  "gvisor",
+			}),
+		},
+		{
+			name: "unmapped classes keep their name",
+			obj: deployment(map[string]interface{}{
+				"priorityClassName": "system-cluster-critical",
+				"priority":          int64(2000000000),
+				"runtimeClassName":  "kata",
+			}),
+			want: deployment(map[string]interface{}{
+				"priorityClassName": "system-cluster-critical",
+				"runtimeClassName":  "kata",
+			}),
+		},
+		{
+			name: "class mapped to the default",
+			obj: deployment(map[string]interface{}{
+				"priorityClassName": "dropped",
+				"priority":          int64(10),
+			}),
+			want: deployment(map[string]interface{}{}),
+		},
+		{
+			name: "other kinds are not mutated",
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"spec":       map[string]interface{}{"priorityClassName": "high"},
+			}},
+			want: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"spec":       map[string]interface{}{"priorityClassName": "high"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := NewClassMutator()
+			require.True(t, cm.SetMappings(spec))
+			require.False(t, cm.SetMappings(spec))
+
+			err := cm.Mutate(tt.obj, "ns")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, tt.obj)
+		})
+	}
+}
@@ -17,68 +17,139 @@ limitations under the License.
 package mutators
 
 import (
+	"fmt"
 	"net/url"
+	"strings"
 
-	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	utilspointer "k8s.io/utils/pointer"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
-type DeploymentMutator struct {
+// workload is a resource which holds a pod spec.
+type workload struct {
+	gvr  schema.GroupVersionResource
+	kind string
+	// podSpecPath is the path of the pod spec in the object. The pod metadata is next to it.
+	podSpecPath []string
+}
+
+var workloads = []workload{
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, kind: "Pod", podSpecPath: []string{"spec"}},
+	{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, kind: "Deployment", podSpecPath: []string{"spec", "template", "spec"}},
+	{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, kind: "ReplicaSet", podSpecPath: []string{"spec", "template", "spec"}},
+	{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, kind: "StatefulSet", podSpecPath: []string{"spec", "template", "spec"}},
+	{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, kind: "DaemonSet", podSpecPath: []string{"spec", "template", "spec"}},
+	{gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, kind: "Job", podSpecPath: []string{"spec", "template", "spec"}},
+	{gvr: schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, kind: "CronJob", podSpecPath: []string{"spec", "jobTemplate", "spec", "template", "spec"}},
+}
+
+// podSpecPathOf returns the path of the pod spec in the object, or nil if it does not hold one.
+func podSpecPathOf(obj *unstructured.Unstructured) []string {
+	for _, w := range workloads {
+		if obj.GroupVersionKind() == w.gvr.GroupVersion().WithKind(w.kind) {
+			return w.podSpecPath
+		}
+	}
+	return nil
+}
+
+// ServiceAccountTokenSecretName returns the name of the secret holding the kcp token of the given
+// service account on the WorkloadCluster.
+func ServiceAccountTokenSecretName(serviceAccountName string) string {
+	return "kcp-" + serviceAccountName + "-token"
+}
+
+// PodSpecMutator makes the in-cluster clients of the synced pods talk to kcp, as if the pods were
+// running in their workspace: the API server is kcp, the token is the one of their service account
+// in kcp, which kcp uses to route the requests to the workspace, and the namespace is their
+// namespace in kcp.
+type PodSpecMutator struct {
 	fromConfig *rest.Config
 }
 
-func (dm *DeploymentMutator) GVR() schema.GroupVersionResource {
-	return schema.GroupVersionResource{
-		Group:    "apps",
-		Version:  "v1",
-		Resource: "deployments",
+// GVRs returns the resources holding a pod spec which are mutated.
+func (pm *PodSpecMutator) GVRs() []schema.GroupVersionResource {
+	gvrs := make([]schema.GroupVersionResource, 0, len(workloads))
+	for _, w := range workloads {
+		gvrs = append(gvrs, w.gvr)
 	}
+	return gvrs
 }
 
-func NewDeploymentMutator(fromConfig *rest.Config) *DeploymentMutator {
-	return &DeploymentMutator{
+func NewPodSpecMutator(fromConfig *rest.Config) *PodSpecMutator {
+	return &PodSpecMutator{
 		fromConfig: fromConfig,
 	}
 }
 
 // Mutate applies the mutator changes to the object.
-func (dm *DeploymentMutator) Mutate(downstreamObj *unstructured.Unstructured) error {
-	var deployment appsv1.Deployment
-	err := runtime.DefaultUnstructuredConverter.FromUnstructured(
-		downstreamObj.UnstructuredContent(),
-		&deployment)
-	if err != nil {
+func (pm *PodSpecMutator) Mutate(downstreamObj *unstructured.Unstructured, upstreamNamespace string) error {
+	podSpecPath := podSpecPathOf(downstreamObj)
+	if podSpecPath == nil {
+		return nil
+	}
+
+	podSpecContent, found, err := unstructured.NestedMap(downstreamObj.UnstructuredContent(), podSpecPath...)
+	if err != nil || !found {
 		return err
 	}
+	var templateSpec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podSpecContent, &templateSpec); err != nil {
+		return err
+	}
+
+	// Pods which explicitly opt out of the service account token do not talk to the API server.
+	if templateSpec.AutomountServiceAccountToken != nil && !*templateSpec.AutomountServiceAccountToken {
+		return nil
+	}
 
-	// If the deployment has no serviceaccount defined or it is "default", that means that it is using the default one
+	// If the pod has no serviceaccount defined or it is "default", that means that it is using the default one
 	// and we need will need to override it with our own, "kcp-default"
 	//
-	// If the deployment has a serviceaccount defined, that means we need to synchronize the created service account
+	// If the pod has a serviceaccount defined, that means we need to synchronize the created service account
 	// down to the workloadcluster, so we don't modify it, and expect the scheduler to do the job.
-	//
-	// Alias for the template.spec to improve readability.
-	templateSpec := &deployment.Spec.Template.Spec
-
-	if templateSpec.ServiceAccountName == "" || templateSpec.ServiceAccountName == "default" {
+	serviceAccountName := templateSpec.ServiceAccountName
+	if serviceAccountName == "" {
+		serviceAccountName = templateSpec.DeprecatedServiceAccount
+	}
+	if serviceAccountName == "" {
+		serviceAccountName = "default"
+	}
+	if serviceAccountName == "default" {
 		templateSpec.ServiceAccountName = "kcp-default"
+	} else {
+		templateSpec.ServiceAccountName = serviceAccountName
+	}
+	if templateSpec.DeprecatedServiceAccount != "" {
+		templateSpec.DeprecatedServiceAccount = templateSpec.ServiceAccountName
 	}
 
 	// Setting AutomountServiceAccountToken to false allow us to control the ServiceAccount
 	// VolumeMount and Volume definitions.
 	templateSpec.AutomountServiceAccountToken = utilspointer.BoolPtr(false)
 
-	url, err := url.Parse(dm.fromConfig.Host)
+	url, err := url.Parse(pm.fromConfig.Host)
 	if err != nil {
 		return err
 	}
 	kcpExternalHost := url.Hostname()
 	kcpExternalPort := url.Port()
+	if kcpExternalPort == "" {
+		switch strings.ToLower(url.Scheme) {
+		case "http":
+			kcpExternalPort = "80"
+		case "https", "":
+			kcpExternalPort = "443"
+		default:
+			return fmt.Errorf("unsupported scheme %q in kcp URL %q", url.Scheme, pm.fromConfig.Host)
+		}
+	}
 
 	overrideEnvs := []corev1.EnvVar{
 		{Name: "KUBERNETES_SERVICE_PORT", Value: kcpExternalPort},
@@ -86,16 +157,16 @@ func (dm *DeploymentMutator) Mutate(downstreamObj *unstructured.Unstructured) er
 		{Name: "KUBERNETES_SERVICE_HOST", Value: kcpExternalHost},
 	}
 
-	// This is the VolumeMount that we will append to all the containers of the deployment
+	// This is the VolumeMount that we will append to all the containers of the pod
 	serviceAccountMount := corev1.VolumeMount{
 		Name:      "kcp-api-access",
 		MountPath: "/var/run/secrets/kubernetes.io/serviceaccount",
 		ReadOnly:  true,
 	}
 
-	// This is the Volume that we will add to the Deployment in order to control
-	// the name of the ca.crt references (kcp-root-ca.crt vs kube-root-ca.crt)
-	// and the serviceaccount reference.
+	// This is the Volume that we will add to the pod in order to control
+	// the name of the ca.crt references (kcp-root-ca.crt vs kube-root-ca.crt),
+	// the serviceaccount reference and the namespace of the pod in kcp.
 	serviceAccountVolume := corev1.Volume{
 		Name: "kcp-api-access",
 		VolumeSource: corev1.VolumeSource{
@@ -109,7 +180,7 @@ func (dm *DeploymentMutator) Mutate(downstreamObj *unstructured.Unstructured) er
 						//                them non-valid for KCP. (Also it removes the ClusterName included in the JWT token)
 						Secret: &corev1.SecretProjection{
 							LocalObjectReference: corev1.LocalObjectReference{
-								Name: "kcp-default-token",
+								Name: ServiceAccountTokenSecretName(serviceAccountName),
 							},
 							Items: []corev1.KeyToPath{
 								{
@@ -139,7 +210,7 @@ func (dm *DeploymentMutator) Mutate(downstreamObj *unstructured.Unstructured) er
 									Path: "namespace",
 									FieldRef: &corev1.ObjectFieldSelector{
 										APIVersion: "v1",
-										FieldPath:  "metadata.namespace",
+										FieldPath:  "metadata.annotations['" + workloadv1alpha1.UpstreamNamespaceAnnotation + "']",
 									},
 								},
 							},
@@ -151,7 +222,7 @@ func (dm *DeploymentMutator) Mutate(downstreamObj *unstructured.Unstructured) er
 	}
 
 	// Override Envs and add the VolumeMount to all the containers
-	for i := range deployment.Spec.Template.Spec.Containers {
+	for i := range templateSpec.Containers {
 		for _, overrideEnv := range overrideEnvs {
 			templateSpec.Containers[i].Env = updateEnv(templateSpec.Containers[i].Env, overrideEnv)
 		}
@@ -175,7 +246,7 @@ func (dm *DeploymentMutator) Mutate(downstreamObj *unstructured.Unstructured) er
 	}
 
 	// Add the ServiceAccount volume with our overrides.
-	found := false
+	found = false
 	for i := range templateSpec.Volumes {
 		if templateSpec.Volumes[i].Name == "kcp-api-access" {
 			templateSpec.Volumes[i] = serviceAccountVolume
@@ -186,15 +257,17 @@ func (dm *DeploymentMutator) Mutate(downstreamObj *unstructured.Unstructured) er
 		templateSpec.Volumes = append(templateSpec.Volumes, serviceAccountVolume)
 	}
 
-	unstructured, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&deployment)
+	podSpecContent, err = runtime.DefaultUnstructuredConverter.ToUnstructured(&templateSpec)
 	if err != nil {
 		return err
 	}
 
 	// Set the changes back into the obj.
-	downstreamObj.SetUnstructuredContent(unstructured)
-
-	return nil
+	if err := unstructured.SetNestedMap(downstreamObj.UnstructuredContent(), podSpecContent, podSpecPath...); err != nil {
+		return err
+	}
+	annotationsPath := append(append([]string{}, podSpecPath[:len(podSpecPath)-1]...), "metadata", "annotations")
+	return unstructured.SetNestedField(downstreamObj.UnstructuredContent(), upstreamNamespace, append(annotationsPath, workloadv1alpha1.UpstreamNamespaceAnnotation)...)
 }
 
 // findEnv finds an env in a list of envs
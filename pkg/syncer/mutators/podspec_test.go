@@ -31,6 +31,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	utilspointer "k8s.io/utils/pointer"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 )
 
 var kcpApiAccessVolume = corev1.Volume{
@@ -72,7 +74,7 @@ var kcpApiAccessVolume = corev1.Volume{
 								Path: "namespace",
 								FieldRef: &corev1.ObjectFieldSelector{
 									APIVersion: "v1",
-									FieldPath:  "metadata.namespace",
+									FieldPath:  "metadata.annotations['workload.kcp.dev/upstream-namespace']",
 								},
 							},
 						},
@@ -129,6 +131,9 @@ func TestMutate(t *testing.T) {
 			Spec: appsv1.DeploymentSpec{
 				Replicas: new(int32),
 				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{workloadv1alpha1.UpstreamNamespaceAnnotation: "test-namespace"},
+					},
 					Spec: corev1.PodSpec{
 						AutomountServiceAccountToken: utilspointer.BoolPtr(false),
 						ServiceAccountName:           "kcp-default",
@@ -206,6 +211,9 @@ func TestMutate(t *testing.T) {
 			Spec: appsv1.DeploymentSpec{
 				Replicas: new(int32),
 				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{workloadv1alpha1.UpstreamNamespaceAnnotation: "test-namespace"},
+					},
 					Spec: corev1.PodSpec{
 						AutomountServiceAccountToken: utilspointer.BoolPtr(false),
 						ServiceAccountName:           "kcp-default",
@@ -287,6 +295,9 @@ func TestMutate(t *testing.T) {
 				Spec: appsv1.DeploymentSpec{
 					Replicas: new(int32),
 					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{workloadv1alpha1.UpstreamNamespaceAnnotation: "test-namespace"},
+						},
 						Spec: corev1.PodSpec{
 							AutomountServiceAccountToken: utilspointer.BoolPtr(false),
 							ServiceAccountName:           "kcp-default",
@@ -370,6 +381,9 @@ func TestMutate(t *testing.T) {
 				Spec: appsv1.DeploymentSpec{
 					Replicas: new(int32),
 					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{workloadv1alpha1.UpstreamNamespaceAnnotation: "test-namespace"},
+						},
 						Spec: corev1.PodSpec{
 							AutomountServiceAccountToken: utilspointer.BoolPtr(false),
 							ServiceAccountName:           "kcp-default",
@@ -464,6 +478,9 @@ func TestMutate(t *testing.T) {
 				Spec: appsv1.DeploymentSpec{
 					Replicas: new(int32),
 					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{workloadv1alpha1.UpstreamNamespaceAnnotation: "test-namespace"},
+						},
 						Spec: corev1.PodSpec{
 							AutomountServiceAccountToken: utilspointer.BoolPtr(false),
 							ServiceAccountName:           "kcp-default",
@@ -503,11 +520,11 @@ func TestMutate(t *testing.T) {
 	} {
 		{
 			t.Run(c.desc, func(t *testing.T) {
-				dm := NewDeploymentMutator(c.config)
+				dm := NewPodSpecMutator(c.config)
 				unstrOriginalDeployment, err := toUnstructured(c.originalDeployment)
 				require.NoError(t, err, "toRuntimeObject() = %v", err)
 
-				err = dm.Mutate(unstrOriginalDeployment)
+				err = dm.Mutate(unstrOriginalDeployment, "test-namespace")
 				require.NoError(t, err, "Mutate() = %v", err)
 
 				mutatedOriginalDeployment, err := toDeployment(unstrOriginalDeployment)
@@ -544,3 +561,108 @@ func toDeployment(obj *unstructured.Unstructured) (*appsv1.Deployment, error) {
 	}
 	return d, nil
 }
+
+func TestMutateWorkloads(t *testing.T) {
+	podSpec := func(serviceAccountName string, automount *bool) map[string]interface{} {
+		spec := map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "test-container", "image": "test-image"}},
+		}
+		if serviceAccountName != "" {
+			spec["serviceAccountName"] = serviceAccountName
+		}
+		if automount != nil {
+			spec["automountServiceAccountToken"] = *automount
+		}
+		return spec
+	}
+	tests := map[string]struct {
+		obj                    *unstructured.Unstructured
+		host                   string
+		podSpecPath            []string
+		wantServiceAccountName string
+		wantTokenSecret        string
+		wantPort               string
+		wantUnchanged          bool
+	}{
+		"pod": {
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1", "kind": "Pod",
+				"spec": podSpec("", nil),
+			}},
+			host:                   "https://kcp.example.com:6443",
+			podSpecPath:            []string{"spec"},
+			wantServiceAccountName: "kcp-default",
+			wantTokenSecret:        "kcp-default-token",
+			wantPort:               "6443",
+		},
+		"cronjob with a service account": {
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "batch/v1", "kind": "CronJob",
+				"spec": map[string]interface{}{"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{
+					"spec": podSpec("my-controller", nil),
+				}}}},
+			}},
+			host:                   "https://kcp.example.com:6443/clusters/root:org:ws",
+			podSpecPath:            []string{"spec", "jobTemplate", "spec", "template", "spec"},
+			wantServiceAccountName: "my-controller",
+			wantTokenSecret:        "kcp-my-controller-token",
+			wantPort:               "6443",
+		},
+		"statefulset without port in the kcp URL": {
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1", "kind": "StatefulSet",
+				"spec": map[string]interface{}{"template": map[string]interface{}{"spec": podSpec("default", nil)}},
+			}},
+			host:                   "https://kcp.example.com",
+			podSpecPath:            []string{"spec", "template", "spec"},
+			wantServiceAccountName: "kcp-default",
+			wantTokenSecret:        "kcp-default-token",
+			wantPort:               "443",
+		},
+		"job opting out of the service account token": {
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "batch/v1", "kind": "Job",
+				"spec": map[string]interface{}{"template": map[string]interface{}{"spec": podSpec("", utilspointer.BoolPtr(false))}},
+			}},
+			host:          "https://kcp.example.com:6443",
+			wantUnchanged: true,
+		},
+		"not a workload": {
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1", "kind": "ConfigMap",
+				"spec": podSpec("", nil),
+			}},
+			host:          "https://kcp.example.com:6443",
+			wantUnchanged: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			original := tc.obj.DeepCopy()
+			err := NewPodSpecMutator(&rest.Config{Host: tc.host}).Mutate(tc.obj, "test-namespace")
+			require.NoError(t, err)
+			if tc.wantUnchanged {
+				require.Equal(t, original, tc.obj)
+				return
+			}
+
+			content, found, err := unstructured.NestedMap(tc.obj.Object, tc.podSpecPath...)
+			require.NoError(t, err)
+			require.True(t, found)
+			var spec corev1.PodSpec
+			require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(content, &spec))
+
+			require.Equal(t, tc.wantServiceAccountName, spec.ServiceAccountName)
+			require.Equal(t, utilspointer.BoolPtr(false), spec.AutomountServiceAccountToken)
+			require.Contains(t, spec.Containers[0].Env, corev1.EnvVar{Name: "KUBERNETES_SERVICE_HOST", Value: "kcp.example.com"})
+			require.Contains(t, spec.Containers[0].Env, corev1.EnvVar{Name: "KUBERNETES_SERVICE_PORT", Value: tc.wantPort})
+			require.Len(t, spec.Volumes, 1)
+			require.Equal(t, tc.wantTokenSecret, spec.Volumes[0].Projected.Sources[0].Secret.Name)
+
+			annotationsPath := append(append([]string{}, tc.podSpecPath[:len(tc.podSpecPath)-1]...), "metadata", "annotations")
+			annotations, _, err := unstructured.NestedStringMap(tc.obj.Object, annotationsPath...)
+			require.NoError(t, err)
+			require.Equal(t, "test-namespace", annotations[workloadv1alpha1.UpstreamNamespaceAnnotation])
+		})
+	}
+}
@@ -39,7 +39,7 @@ func NewSecretMutator() *SecretMutator {
 }
 
 // Mutate applies the mutator changes to the object.
-func (sm *SecretMutator) Mutate(downstreamObj *unstructured.Unstructured) error {
+func (sm *SecretMutator) Mutate(downstreamObj *unstructured.Unstructured, upstreamNamespace string) error {
 	var secret corev1.Secret
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(
 		downstreamObj.UnstructuredContent(),
@@ -48,8 +48,8 @@ func (sm *SecretMutator) Mutate(downstreamObj *unstructured.Unstructured) error
 		return err
 	}
 
-	// We need to transform the default kcp token into an Opaque secret, in order to avoid the pcluster to rewrite it.
-	if secret.Name == "kcp-default-token" {
+	// We need to transform the kcp tokens into Opaque secrets, in order to avoid the pcluster to rewrite them.
+	if secret.Type == corev1.SecretTypeServiceAccountToken || secret.Name == ServiceAccountTokenSecretName("default") {
 		secret.Type = corev1.SecretTypeOpaque
 	}
 
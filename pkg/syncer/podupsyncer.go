@@ -0,0 +1,47 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+var podsGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+// NewPodUpsyncer returns an upsyncer of the pods running on the physical cluster, so that they can be
+// listed in kcp although they are not scheduled by kcp.
+func NewPodUpsyncer(from, to *rest.Config, kcpClusterName logicalcluster.LogicalCluster, pclusterID string) (*Upsyncer, error) {
+	fromClient, err := dynamic.NewForConfig(from)
+	if err != nil {
+		return nil, err
+	}
+	toClients, err := dynamic.NewClusterForConfig(to)
+	if err != nil {
+		return nil, err
+	}
+	return newPodUpsyncer(kcpClusterName, pclusterID, fromClient, toClients.Cluster(kcpClusterName)), nil
+}
+
+func newPodUpsyncer(kcpClusterName logicalcluster.LogicalCluster, pclusterID string, fromClient, toClient dynamic.Interface) *Upsyncer {
+	u := newUpsyncer(podsGVR, kcpClusterName, pclusterID, fromClient, toClient)
+	u.statusSubresource = true
+	return u
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestPodUpsyncer(t *testing.T) {
+	upstreamClusterName := logicalcluster.New("root:org:ws")
+	downstreamNamespace, err := PhysicalClusterNamespaceName(NamespaceLocator{LogicalCluster: upstreamClusterName, Namespace: "ns"})
+	require.NoError(t, err)
+	locator, err := json.Marshal(NamespaceLocator{LogicalCluster: upstreamClusterName, Namespace: "ns"})
+	require.NoError(t, err)
+
+	namespace := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Namespace"}}
+	namespace.SetName(downstreamNamespace)
+	namespace.SetAnnotations(map[string]string{namespaceLocatorAnnotation: string(locator)})
+
+	downstreamPod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"spec":       map[string]interface{}{"nodeName": "node-1"},
+		"status":     map[string]interface{}{"phase": "Running"},
+	}}
+	downstreamPod.SetNamespace(downstreamNamespace)
+	downstreamPod.SetName("foo")
+	downstreamPod.SetUID("downstream-uid")
+	downstreamPod.SetLabels(map[string]string{"app": "foo"})
+	downstreamPod.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "foo-1234", UID: "rs-uid"}})
+
+	upstreamPod := func(labels map[string]string, phase string) *unstructured.Unstructured {
+		pod := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"spec":       map[string]interface{}{"nodeName": "node-1"},
+			"status":     map[string]interface{}{"phase": phase},
+		}}
+		pod.SetClusterName(upstreamClusterName.String())
+		pod.SetNamespace("ns")
+		pod.SetName("foo")
+		pod.SetLabels(labels)
+		return pod
+	}
+
+	tests := map[string]struct {
+		downstream *unstructured.Unstructured
+		upstream   *unstructured.Unstructured
+		wantVerbs  []string
+		want       *unstructured.Unstructured
+	}{
+		"created": {
+			downstream: downstreamPod,
+			wantVerbs:  []string{"create", "update"},
+			want:       upstreamPod(map[string]string{"app": "foo", "workload.kcp.dev/upsynced-from": "us-east1"}, "Running"),
+		},
+		"up to date": {
+			downstream: downstreamPod,
+			upstream:   upstreamPod(map[string]string{"app": "foo", "workload.kcp.dev/upsynced-from": "us-east1", "workloads.kcp.dev/cluster": "us-east1"}, "Running"),
+			want:       upstreamPod(map[string]string{"app": "foo", "workload.kcp.dev/upsynced-from": "us-east1", "workloads.kcp.dev/cluster": "us-east1"}, "Running"),
+		},
+		"changes in kcp are reverted": {
+			downstream: downstreamPod,
+			upstream:   upstreamPod(map[string]string{"app": "bar", "workload.kcp.dev/upsynced-from": "us-east1", "workloads.kcp.dev/cluster": "us-east1"}, "Pending"),
+			wantVerbs:  []string{"update", "update"},
+			want:       upstreamPod(map[string]string{"app": "foo", "workload.kcp.dev/upsynced-from": "us-east1", "workloads.kcp.dev/cluster": "us-east1"}, "Running"),
+		},
+		"deleted": {
+			upstream:  upstreamPod(map[string]string{"app": "foo", "workload.kcp.dev/upsynced-from": "us-east1"}, "Running"),
+			wantVerbs: []string{"delete"},
+		},
+		"not upsynced": {},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var upstreamObjs []runtime.Object
+			if tc.upstream != nil {
+				upstreamObjs = append(upstreamObjs, tc.upstream.DeepCopy())
+			}
+			upstreamClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), upstreamObjs...)
+			downstreamClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			u := newPodUpsyncer(upstreamClusterName, "us-east1", downstreamClient, upstreamClient)
+
+			require.NoError(t, u.downstreamNamespaceInformers.ForResource(namespacesGVR).Informer().GetIndexer().Add(namespace))
+			if tc.downstream != nil {
+				require.NoError(t, u.downstreamInformers.ForResource(podsGVR).Informer().GetIndexer().Add(tc.downstream))
+				require.Equal(t, "ns", u.upstreamNamespace(logicalcluster.LogicalCluster{}, downstreamNamespace))
+			}
+			if tc.upstream != nil {
+				require.NoError(t, u.upstreamInformers.ForResource(podsGVR).Informer().GetIndexer().Add(tc.upstream))
+			}
+
+			require.NoError(t, u.process(context.Background(), upsyncedObject{namespace: "ns", name: "foo"}))
+
+			var verbs []string
+			for _, action := range upstreamClient.Actions() {
+				verbs = append(verbs, action.GetVerb())
+			}
+			require.Equal(t, tc.wantVerbs, verbs)
+
+			got, err := upstreamClient.Resource(podsGVR).Namespace("ns").Get(context.Background(), "foo", metav1.GetOptions{})
+			if tc.want == nil {
+				require.True(t, k8serrors.IsNotFound(err), "expected no upsynced pod, got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want.GetLabels(), got.GetLabels())
+			require.Empty(t, got.GetOwnerReferences())
+			require.Equal(t, tc.want.Object["spec"], got.Object["spec"])
+			require.Equal(t, tc.want.Object["status"], got.Object["status"])
+		})
+	}
+}
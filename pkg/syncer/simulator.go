@@ -0,0 +1,305 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	kcp "github.com/kcp-dev/kcp"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/crdpuller"
+)
+
+const simulatedSyncerAgent = "kcp#simulated-syncer/v0.0.0"
+
+// StartSimulatedSyncers syncs the targets to physical clusters simulated in memory, e.g. for
+// developers to test placement and status flows without a real cluster. The APIs of the legacy
+// schema resources of contrib/crds, e.g. Deployments and Pods, are imported for every target, and
+// the synced resources get a plausible status: Deployments have all their replicas ready, Pods are
+// running, and so on. Other resources are accepted as they are.
+func StartSimulatedSyncers(ctx context.Context, targets []SyncTarget, numSyncerThreads int, importPollInterval time.Duration) error {
+	if err := validateTargets(targets); err != nil {
+		return err
+	}
+
+	crds, err := fs.Sub(kcp.ContribCRDs, "contrib/crds")
+	if err != nil {
+		return err
+	}
+	schemaPuller, err := crdpuller.NewStaticSchemaPuller(crds)
+	if err != nil {
+		return err
+	}
+
+	return startTargets(targets, func(target SyncTarget) error {
+		return startSimulatedTarget(ctx, target, schemaPuller, numSyncerThreads, importPollInterval)
+	})
+}
+
+func startSimulatedTarget(ctx context.Context, target SyncTarget, schemaPuller crdpuller.SchemaPuller, numSyncerThreads int, importPollInterval time.Duration) error {
+	upstream, resources, kcpClusterName, pcluster := target.Upstream, target.Resources, target.KCPClusterName, target.WorkloadClusterName
+
+	apiImporter, err := newAPIImporter(upstream, schemaPuller, resources.List(), kcpClusterName, pcluster)
+	if err != nil {
+		return err
+	}
+	go apiImporter.Start(ctx, importPollInterval)
+
+	gvrs, err := discoverGVRs(target, resources)
+	if err != nil {
+		return err
+	}
+
+	upstream = rest.CopyConfig(upstream)
+	upstream.UserAgent = simulatedSyncerAgent
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(upstream)
+	if err != nil {
+		return err
+	}
+	fromClients, err := dynamic.NewClusterForConfig(upstream)
+	if err != nil {
+		return err
+	}
+	fromClient := fromClients.Cluster(kcpClusterName)
+
+	klog.Infof("Creating simulated spec syncer for clusterName %s to pcluster %s, resources %v", kcpClusterName, pcluster, resources.List())
+	specSyncer, err := New(kcpClusterName, pcluster, fromClient, nil, SyncDown, gvrs, pcluster, nil)
+	if err != nil {
+		return err
+	}
+	cluster := newSimulatedCluster(pcluster, fromClient)
+	specSyncer.upsertFn = cluster.upsert
+	specSyncer.deleteFn = cluster.delete
+	go specSyncer.Start(ctx, numSyncerThreads)
+
+	startHeartbeat(ctx, kcpClusterClient.Cluster(kcpClusterName).WorkloadV1alpha1().WorkloadClusters(), target, specSyncer, nil)
+	return nil
+}
+
+// simulatedCluster is a physical cluster kept in memory. It stores the resources synced to it, and
+// reports a plausible status for them upstream.
+type simulatedCluster struct {
+	name           string
+	upstreamClient dynamic.Interface
+
+	lock sync.Mutex
+	// objects are the synced objects by GVR and downstream namespace/name.
+	objects map[schema.GroupVersionResource]map[string]*unstructured.Unstructured
+}
+
+func newSimulatedCluster(name string, upstreamClient dynamic.Interface) *simulatedCluster {
+	return &simulatedCluster{
+		name:           name,
+		upstreamClient: upstreamClient,
+		objects:        map[schema.GroupVersionResource]map[string]*unstructured.Unstructured{},
+	}
+}
+
+func (s *simulatedCluster) upsert(ctx context.Context, gvr schema.GroupVersionResource, downstreamNamespace string, upstreamObj *unstructured.Unstructured) error {
+	if _, upsynced := upstreamObj.GetLabels()[workloadv1alpha1.UpsyncedFromLabel]; upsynced {
+		// the object is a read-only reflection of an object of a physical cluster
+		return nil
+	}
+
+	status := simulatedStatus(upstreamObj, s.name)
+
+	downstreamObj := upstreamObj.DeepCopy()
+	downstreamObj.SetUID("")
+	downstreamObj.SetResourceVersion("")
+	downstreamObj.SetNamespace(downstreamNamespace)
+	downstreamObj.SetManagedFields(nil)
+	downstreamObj.SetClusterName("")
+	if status != nil {
+		downstreamObj.Object["status"] = status
+	}
+	s.lock.Lock()
+	if s.objects[gvr] == nil {
+		s.objects[gvr] = map[string]*unstructured.Unstructured{}
+	}
+	s.objects[gvr][downstreamNamespace+"/"+downstreamObj.GetName()] = downstreamObj
+	s.lock.Unlock()
+	klog.Infof("Upserted %s %s/%s from upstream %s|%s/%s to simulated pcluster %s", gvr.Resource, downstreamNamespace, downstreamObj.GetName(), upstreamObj.GetClusterName(), upstreamObj.GetNamespace(), upstreamObj.GetName(), s.name)
+
+	if status == nil || equality.Semantic.DeepEqual(upstreamObj.Object["status"], status) {
+		return nil
+	}
+	updated := upstreamObj.DeepCopy()
+	updated.Object["status"] = status
+	if _, err := s.upstreamClient.Resource(gvr).Namespace(upstreamObj.GetNamespace()).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			// deleted in the meantime
+			return nil
+		}
+		return err
+	}
+	klog.Infof("Updated status of resource %s|%s/%s from simulated pcluster %s", upstreamObj.GetClusterName(), upstreamObj.GetNamespace(), upstreamObj.GetName(), s.name)
+	return nil
+}
+
+func (s *simulatedCluster) delete(_ context.Context, gvr schema.GroupVersionResource, downstreamNamespace, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.objects[gvr], downstreamNamespace+"/"+name)
+	return nil
+}
+
+// get returns the object synced to the simulated cluster, or nil.
+func (s *simulatedCluster) get(gvr schema.GroupVersionResource, downstreamNamespace, name string) *unstructured.Unstructured {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.objects[gvr][downstreamNamespace+"/"+name]
+}
+
+// simulatedStatus returns the status the resource would have on a healthy physical cluster, or nil
+// if the kind of the resource is not known to have one.
+func simulatedStatus(obj *unstructured.Unstructured, pcluster string) map[string]interface{} {
+	// timestamps are those of the creation, so that the status only changes with the spec
+	created := obj.GetCreationTimestamp().UTC().Format(time.RFC3339)
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	condition := func(conditionType, reason, message string) map[string]interface{} {
+		c := map[string]interface{}{
+			"type":               conditionType,
+			"status":             "True",
+			"lastTransitionTime": created,
+		}
+		if reason != "" {
+			c["reason"] = reason
+		}
+		if message != "" {
+			c["message"] = message
+		}
+		return c
+	}
+
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+		available := condition("Available", "MinimumReplicasAvailable", "Deployment has minimum availability.")
+		available["lastUpdateTime"] = created
+		progressing := condition("Progressing", "NewReplicaSetAvailable", fmt.Sprintf("ReplicaSet %q has successfully progressed.", obj.GetName()))
+		progressing["lastUpdateTime"] = created
+		return map[string]interface{}{
+			"observedGeneration": obj.GetGeneration(),
+			"replicas":           replicas,
+			"updatedReplicas":    replicas,
+			"readyReplicas":      replicas,
+			"availableReplicas":  replicas,
+			"conditions":         []interface{}{available, progressing},
+		}
+	case schema.GroupKind{Group: "apps", Kind: "ReplicaSet"}:
+		return map[string]interface{}{
+			"observedGeneration":   obj.GetGeneration(),
+			"replicas":             replicas,
+			"fullyLabeledReplicas": replicas,
+			"readyReplicas":        replicas,
+			"availableReplicas":    replicas,
+		}
+	case schema.GroupKind{Group: "apps", Kind: "StatefulSet"}:
+		return map[string]interface{}{
+			"observedGeneration": obj.GetGeneration(),
+			"replicas":           replicas,
+			"currentReplicas":    replicas,
+			"updatedReplicas":    replicas,
+			"readyReplicas":      replicas,
+			"availableReplicas":  replicas,
+		}
+	case schema.GroupKind{Group: "apps", Kind: "DaemonSet"}:
+		// the simulated cluster has a single node
+		return map[string]interface{}{
+			"observedGeneration":     obj.GetGeneration(),
+			"currentNumberScheduled": int64(1),
+			"desiredNumberScheduled": int64(1),
+			"numberMisscheduled":     int64(0),
+			"numberReady":            int64(1),
+			"numberAvailable":        int64(1),
+			"updatedNumberScheduled": int64(1),
+		}
+	case schema.GroupKind{Kind: "Pod"}:
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "containers")
+		var containerStatuses []interface{}
+		for _, container := range containers {
+			container, ok := container.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			containerStatuses = append(containerStatuses, map[string]interface{}{
+				"name":         container["name"],
+				"image":        container["image"],
+				"imageID":      "",
+				"ready":        true,
+				"started":      true,
+				"restartCount": int64(0),
+				"state": map[string]interface{}{
+					"running": map[string]interface{}{"startedAt": created},
+				},
+			})
+		}
+		status := map[string]interface{}{
+			"phase":     "Running",
+			"hostIP":    simulatedIP(pcluster),
+			"podIP":     simulatedIP(pcluster, obj.GetNamespace(), obj.GetName()),
+			"startTime": created,
+			"conditions": []interface{}{
+				condition("Initialized", "", ""),
+				condition("Ready", "", ""),
+				condition("ContainersReady", "", ""),
+				condition("PodScheduled", "", ""),
+			},
+		}
+		if len(containerStatuses) > 0 {
+			status["containerStatuses"] = containerStatuses
+		}
+		return status
+	case schema.GroupKind{Kind: "Service"}:
+		loadBalancer := map[string]interface{}{}
+		if serviceType, _, _ := unstructured.NestedString(obj.Object, "spec", "type"); serviceType == "LoadBalancer" {
+			loadBalancer["ingress"] = []interface{}{
+				map[string]interface{}{"ip": simulatedIP(pcluster, obj.GetNamespace(), obj.GetName())},
+			}
+		}
+		return map[string]interface{}{"loadBalancer": loadBalancer}
+	}
+	return nil
+}
+
+// simulatedIP returns an IP in 10.0.0.0/8 which is stable for the given names.
+func simulatedIP(names ...string) string {
+	h := fnv.New32a()
+	for _, name := range names {
+		h.Write([]byte(name)) // nolint:errcheck
+		h.Write([]byte{0})    // nolint:errcheck
+	}
+	sum := h.Sum32()
+	return fmt.Sprintf("10.%d.%d.%d", byte(sum>>16), byte(sum>>8), byte(sum)%254+1)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	kcp "github.com/kcp-dev/kcp"
+	"github.com/kcp-dev/kcp/pkg/crdpuller"
+)
+
+func TestSimulatedCluster(t *testing.T) {
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	configMapsGVR := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	created := metav1.NewTime(time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"spec":       map[string]interface{}{"replicas": int64(3)},
+	}}
+	deployment.SetClusterName("root:org:ws")
+	deployment.SetNamespace("ns")
+	deployment.SetName("foo")
+	deployment.SetGeneration(2)
+	deployment.SetCreationTimestamp(created)
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"data":       map[string]interface{}{"foo": "bar"},
+	}}
+	configMap.SetNamespace("ns")
+	configMap.SetName("foo")
+
+	upstreamClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		deploymentsGVR: "DeploymentList",
+	}, deployment.DeepCopy(), configMap.DeepCopy())
+	cluster := newSimulatedCluster("us-east1", upstreamClient)
+	ctx := context.Background()
+
+	// the status of the deployment is reported upstream
+	require.NoError(t, cluster.upsert(ctx, deploymentsGVR, "kcp-ns", deployment))
+	got, err := upstreamClient.Resource(deploymentsGVR).Namespace("ns").Get(ctx, "foo", metav1.GetOptions{})
+	require.NoError(t, err)
+	for _, field := range []string{"replicas", "updatedReplicas", "readyReplicas", "availableReplicas"} {
+		value, _, err := unstructured.NestedInt64(got.Object, "status", field)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), value, field)
+	}
+	observedGeneration, _, err := unstructured.NestedInt64(got.Object, "status", "observedGeneration")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), observedGeneration)
+	conditions, _, err := unstructured.NestedSlice(got.Object, "status", "conditions")
+	require.NoError(t, err)
+	require.Equal(t, "Available", conditions[0].(map[string]interface{})["type"])
+	require.Equal(t, "True", conditions[0].(map[string]interface{})["status"])
+
+	// the deployment is stored in the downstream namespace
+	downstream := cluster.get(deploymentsGVR, "kcp-ns", "foo")
+	require.NotNil(t, downstream)
+	require.Equal(t, got.Object["status"], downstream.Object["status"])
+	require.Empty(t, downstream.GetClusterName())
+
+	// an unchanged status is not updated again
+	upstreamClient.ClearActions()
+	require.NoError(t, cluster.upsert(ctx, deploymentsGVR, "kcp-ns", got))
+	require.Empty(t, upstreamClient.Actions())
+
+	// resources without status are only stored
+	require.NoError(t, cluster.upsert(ctx, configMapsGVR, "kcp-ns", configMap))
+	require.Empty(t, upstreamClient.Actions())
+	require.NotNil(t, cluster.get(configMapsGVR, "kcp-ns", "foo"))
+
+	// deleted resources are forgotten
+	require.NoError(t, cluster.delete(ctx, deploymentsGVR, "kcp-ns", "foo"))
+	require.Nil(t, cluster.get(deploymentsGVR, "kcp-ns", "foo"))
+}
+
+func TestSimulatedStatus(t *testing.T) {
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "app", "image": "nginx"}},
+		},
+	}}
+	pod.SetNamespace("ns")
+	pod.SetName("foo")
+	status := simulatedStatus(pod, "us-east1")
+	require.Equal(t, "Running", status["phase"])
+	require.Equal(t, status, simulatedStatus(pod, "us-east1"), "the status should be stable")
+	require.NotEqual(t, status["podIP"], simulatedStatus(pod, "us-west1")["podIP"])
+	require.Len(t, status["containerStatuses"], 1)
+
+	service := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"spec":       map[string]interface{}{"type": "LoadBalancer"},
+	}}
+	ingress, _, err := unstructured.NestedSlice(simulatedStatus(service, "us-east1"), "loadBalancer", "ingress")
+	require.NoError(t, err)
+	require.Len(t, ingress, 1)
+
+	require.Nil(t, simulatedStatus(&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Secret"}}, "us-east1"))
+}
+
+func TestSimulatedSchemas(t *testing.T) {
+	crds, err := fs.Sub(kcp.ContribCRDs, "contrib/crds")
+	require.NoError(t, err)
+	puller, err := crdpuller.NewStaticSchemaPuller(crds)
+	require.NoError(t, err)
+
+	pulled, err := puller.PullCRDs(context.Background(), "deployments.apps", "pods", "services", "ingresses.networking.k8s.io")
+	require.NoError(t, err)
+	require.Len(t, pulled, 3)
+	for _, gr := range []schema.GroupResource{{Group: "apps", Resource: "deployments"}, {Resource: "pods"}, {Resource: "services"}} {
+		crd := pulled[gr]
+		require.NotNil(t, crd, gr.String())
+		require.NotNil(t, crd.Spec.Versions[0].Schema.OpenAPIV3Schema, gr.String())
+		require.NotNil(t, crd.Spec.Versions[0].Subresources.Status, "%s should have a status subresource", gr)
+	}
+}
@@ -19,6 +19,8 @@ package syncer
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 
@@ -32,9 +34,10 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
-	"k8s.io/utils/pointer"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
+	"github.com/kcp-dev/kcp/pkg/syncer/mutators"
 )
 
 func deepEqualApartFromStatus(oldObj, newObj interface{}) bool {
@@ -65,7 +68,7 @@ func deepEqualApartFromStatus(oldObj, newObj interface{}) bool {
 
 const specSyncerAgent = "kcp#spec-syncer/v0.0.0"
 
-func NewSpecSyncer(from, to *rest.Config, gvrs []string, kcpClusterName logicalcluster.LogicalCluster, pclusterID string) (*Controller, error) {
+func NewSpecSyncer(from, to *rest.Config, gvrs []string, kcpClusterName logicalcluster.LogicalCluster, pclusterID string, classMutator *mutators.ClassMutator) (*Controller, error) {
 	from = rest.CopyConfig(from)
 	from.UserAgent = specSyncerAgent
 	to = rest.CopyConfig(to)
@@ -80,15 +83,67 @@ func NewSpecSyncer(from, to *rest.Config, gvrs []string, kcpClusterName logicalc
 
 	// Register the default mutators
 	mutatorsMap := getDefaultMutators(from)
+	// The classes are translated after the pod spec mutation, on the way down only.
+	for _, gvr := range classMutator.GVRs() {
+		mutatorsMap.chain(gvr, classMutator.Mutate)
+	}
 
 	return New(kcpClusterName, pclusterID, fromClient, toClient, SyncDown, gvrs, pclusterID, mutatorsMap)
 }
 
 func (c *Controller) deleteFromDownstream(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) error {
-	// TODO: get UID of just-deleted object and pass it as a precondition on this delete.
-	// This would avoid races where an object is deleted and another object with the same name is created immediately after.
+	existing, err := c.toClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !c.ownsDownstream(existing) {
+		klog.Infof("Not deleting %s %s/%s which is not owned by syncer %s", gvr.Resource, namespace, name, c.name)
+		return nil
+	}
 
-	return c.toClient.Resource(gvr).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	// the UID precondition avoids deleting an object created with the same name in the meantime
+	uid := existing.GetUID()
+	err = c.toClient.Resource(gvr).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// ownsDownstream returns true if the downstream object has been created by the syncer of this
+// WorkloadCluster for this workspace, or if the owner of the physical cluster allowed the syncer
+// to adopt it.
+func (c *Controller) ownsDownstream(obj *unstructured.Unstructured) bool {
+	annotations := obj.GetAnnotations()
+	if annotations[workloadv1alpha1.ForceAdoptAnnotation] == "true" {
+		return true
+	}
+	if workspace, ok := annotations[workloadv1alpha1.OwnerWorkspaceAnnotation]; ok {
+		return workspace == c.upstreamClusterName.String() && annotations[workloadv1alpha1.OwnerWorkloadClusterAnnotation] == c.pclusterID
+	}
+	// objects synced before the ownership annotations were set are recognized by the field manager of the syncer
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == syncerApplyManager {
+			return true
+		}
+	}
+	return false
+}
+
+// setOwnerAnnotations marks the downstream object as owned by this syncer.
+func (c *Controller) setOwnerAnnotations(downstreamObj, upstreamObj *unstructured.Unstructured) {
+	annotations := downstreamObj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[workloadv1alpha1.OwnerWorkspaceAnnotation] = logicalcluster.From(upstreamObj).String()
+	annotations[workloadv1alpha1.OwnerWorkloadClusterAnnotation] = c.pclusterID
+	if uid := upstreamObj.GetUID(); uid != "" {
+		annotations[workloadv1alpha1.OwnerUIDAnnotation] = string(uid)
+	}
+	downstreamObj.SetAnnotations(annotations)
 }
 
 const namespaceLocatorAnnotation = "kcp.dev/namespace-locator"
@@ -118,7 +173,9 @@ func (c *Controller) ensureDownstreamNamespaceExists(ctx context.Context, downst
 		return err
 	}
 	newNamespace.SetAnnotations(map[string]string{
-		namespaceLocatorAnnotation: string(b),
+		namespaceLocatorAnnotation:                      string(b),
+		workloadv1alpha1.OwnerWorkspaceAnnotation:       l.LogicalCluster.String(),
+		workloadv1alpha1.OwnerWorkloadClusterAnnotation: c.pclusterID,
 	})
 
 	if upstreamObj.GetLabels() != nil {
@@ -143,6 +200,11 @@ func (c *Controller) ensureDownstreamNamespaceExists(ctx context.Context, downst
 }
 
 func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVersionResource, downstreamNamespace string, upstreamObj *unstructured.Unstructured) error {
+	if _, upsynced := upstreamObj.GetLabels()[workloadv1alpha1.UpsyncedFromLabel]; upsynced {
+		// the object is a read-only reflection of an object of a physical cluster
+		return nil
+	}
+
 	if err := c.ensureDownstreamNamespaceExists(ctx, downstreamNamespace, upstreamObj); err != nil {
 		return err
 	}
@@ -161,13 +223,19 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 	downstreamObj.SetOwnerReferences(nil)
 	// Strip finalizers to avoid the deletion of the downstream resource from being blocked.
 	downstreamObj.SetFinalizers(nil)
+	// Conflicts and downstream fields are reported upstream only.
+	removeSyncerAnnotations(downstreamObj)
+	// The replicas are owned by an autoscaler on the WorkloadCluster, applying them would conflict.
+	if upstreamObj.GetAnnotations()[workloadv1alpha1.ReplicasManagedDownstreamAnnotation] != "" {
+		unstructured.RemoveNestedField(downstreamObj.Object, "spec", "replicas")
+	}
 
 	// Run name transformations on the downstreamObj.
 	transformName(downstreamObj, SyncDown)
 
 	// Run any transformations on the object before we apply it to the downstream cluster.
 	if mutator, ok := c.mutators[gvr]; ok {
-		if err := mutator(downstreamObj); err != nil {
+		if err := mutator(downstreamObj, upstreamObj.GetNamespace()); err != nil {
 			return err
 		}
 	}
@@ -175,17 +243,89 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 	// TODO: wipe things like finalizers, owner-refs and any other life-cycle fields. The life-cycle
 	//       should exclusively owned by the syncer. Let's not some Kubernetes magic interfere with it.
 
+	// Do not take over objects which exist downstream, but were not created by the syncer.
+	existing, err := c.toClient.Resource(gvr).Namespace(downstreamNamespace).Get(ctx, downstreamObj.GetName(), metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && !c.ownsDownstream(existing) {
+		klog.Infof("Not upserting %s %s/%s from upstream %s|%s/%s: the downstream object is not owned by the syncer", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), upstreamObj.GetClusterName(), upstreamObj.GetNamespace(), upstreamObj.GetName())
+		return c.reportApplyConflicts(ctx, gvr, upstreamObj, []metav1.StatusCause{{
+			Type:    workloadv1alpha1.NotOwnedCauseType,
+			Message: fmt.Sprintf("%s %s/%s exists on the WorkloadCluster, but was not created by the syncer. Annotate it with %s=true to let the syncer take it over.", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), workloadv1alpha1.ForceAdoptAnnotation),
+		}})
+	}
+	c.setOwnerAnnotations(downstreamObj, upstreamObj)
+
 	// Marshalling the unstructured object is good enough as SSA patch
 	data, err := json.Marshal(downstreamObj)
 	if err != nil {
 		return err
 	}
 
-	if _, err := c.toClient.Resource(gvr).Namespace(downstreamNamespace).Patch(ctx, downstreamObj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: syncerApplyManager, Force: pointer.Bool(true)}); err != nil {
+	// Do not force the apply: fields owned by other actors on the physical cluster are not overwritten,
+	// but the conflicts are reported on the upstream resource.
+	_, err = c.toClient.Resource(gvr).Namespace(downstreamNamespace).Patch(ctx, downstreamObj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: syncerApplyManager})
+	if k8serrors.IsConflict(err) {
+		klog.Infof("Conflicts upserting %s %s/%s from upstream %s|%s/%s: %v", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), upstreamObj.GetClusterName(), upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
+		return c.reportApplyConflicts(ctx, gvr, upstreamObj, applyConflicts(err))
+	}
+	if err != nil {
 		klog.Infof("Error upserting %s %s/%s from upstream %s|%s/%s: %v", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), upstreamObj.GetClusterName(), upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
 		return err
 	}
 	klog.Infof("Upserted %s %s/%s from upstream %s|%s/%s", gvr.Resource, downstreamObj.GetNamespace(), downstreamObj.GetName(), upstreamObj.GetClusterName(), upstreamObj.GetNamespace(), upstreamObj.GetName())
 
-	return nil
+	return c.reportApplyConflicts(ctx, gvr, upstreamObj, nil)
+}
+
+// applyConflicts returns the field manager conflicts of a failed server-side apply.
+func applyConflicts(err error) []metav1.StatusCause {
+	var conflicts []metav1.StatusCause
+	if status, ok := err.(k8serrors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if cause.Type == metav1.CauseTypeFieldManagerConflict {
+				conflicts = append(conflicts, cause)
+			}
+		}
+	}
+	if len(conflicts) == 0 {
+		conflicts = append(conflicts, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: err.Error(),
+		})
+	}
+	return conflicts
+}
+
+// reportApplyConflicts sets the apply conflicts annotation of this syncer on the upstream resource,
+// or removes it if there are no conflicts.
+func (c *Controller) reportApplyConflicts(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, conflicts []metav1.StatusCause) error {
+	var value string
+	if len(conflicts) > 0 {
+		bs, err := json.Marshal(conflicts)
+		if err != nil {
+			return err
+		}
+		value = string(bs)
+	}
+	return patchAnnotation(ctx, c.fromClient, gvr, upstreamObj, workloadv1alpha1.ApplyConflictsAnnotationPrefix+c.pclusterID, value)
+}
+
+// removeSyncerAnnotations removes the annotations the syncers of all WorkloadClusters set upstream.
+func removeSyncerAnnotations(obj *unstructured.Unstructured) {
+	annotations := obj.GetAnnotations()
+	for key := range annotations {
+		if key == workloadv1alpha1.ForceAdoptAnnotation {
+			// only the owner of the physical cluster decides on the adoption of its objects
+			delete(annotations, key)
+			continue
+		}
+		if strings.HasPrefix(key, workloadv1alpha1.ApplyConflictsAnnotationPrefix) ||
+			strings.HasPrefix(key, workloadv1alpha1.DownstreamFieldsAnnotationPrefix) ||
+			strings.HasPrefix(key, workloadv1alpha1.EndpointsAnnotationPrefix) {
+			delete(annotations, key)
+		}
+	}
+	obj.SetAnnotations(annotations)
 }
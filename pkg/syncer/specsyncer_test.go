@@ -0,0 +1,280 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+func TestApplyConflicts(t *testing.T) {
+	conflictErr := &k8serrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   409,
+		Reason: metav1.StatusReasonConflict,
+		Details: &metav1.StatusDetails{
+			Causes: []metav1.StatusCause{
+				{Type: metav1.CauseTypeFieldManagerConflict, Message: "conflict with \"hpa\"", Field: ".spec.replicas"},
+				{Type: metav1.CauseTypeFieldValueInvalid, Message: "unrelated", Field: ".spec.foo"},
+			},
+		},
+	}}
+
+	require.Equal(t, []metav1.StatusCause{
+		{Type: metav1.CauseTypeFieldManagerConflict, Message: "conflict with \"hpa\"", Field: ".spec.replicas"},
+	}, applyConflicts(conflictErr))
+
+	require.Equal(t, []metav1.StatusCause{
+		{Type: metav1.CauseTypeFieldManagerConflict, Message: "conflict"},
+	}, applyConflicts(errors.New("conflict")))
+}
+
+func TestReportApplyConflicts(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	conflicts := []metav1.StatusCause{{Type: metav1.CauseTypeFieldManagerConflict, Message: "conflict with \"hpa\"", Field: ".spec.replicas"}}
+	conflictsValue := `[{"reason":"FieldManagerConflict","message":"conflict with \"hpa\"","field":".spec.replicas"}]`
+
+	deployment := func(annotations map[string]interface{}) *unstructured.Unstructured {
+		metadata := map[string]interface{}{
+			"name":      "foo",
+			"namespace": "ns",
+		}
+		if annotations != nil {
+			metadata["annotations"] = annotations
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   metadata,
+		}}
+	}
+
+	tests := map[string]struct {
+		annotations     map[string]interface{}
+		conflicts       []metav1.StatusCause
+		wantPatched     bool
+		wantAnnotations map[string]string
+	}{
+		"no conflicts, nothing reported": {
+			wantPatched: false,
+		},
+		"conflicts are reported": {
+			conflicts:       conflicts,
+			wantPatched:     true,
+			wantAnnotations: map[string]string{"apply-conflicts.workload.kcp.dev/us-east1": conflictsValue},
+		},
+		"same conflicts are not reported again": {
+			annotations: map[string]interface{}{"apply-conflicts.workload.kcp.dev/us-east1": conflictsValue},
+			conflicts:   conflicts,
+			wantPatched: false,
+		},
+		"resolved conflicts are removed": {
+			annotations: map[string]interface{}{
+				"apply-conflicts.workload.kcp.dev/us-east1": conflictsValue,
+				"apply-conflicts.workload.kcp.dev/us-west1": conflictsValue,
+			},
+			wantPatched:     true,
+			wantAnnotations: map[string]string{"apply-conflicts.workload.kcp.dev/us-west1": conflictsValue},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			upstreamObj := deployment(tc.annotations)
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), upstreamObj.DeepCopy())
+			c := &Controller{
+				fromClient: client,
+				pclusterID: "us-east1",
+			}
+
+			err := c.reportApplyConflicts(context.Background(), gvr, upstreamObj, tc.conflicts)
+			require.NoError(t, err)
+
+			var patched bool
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "patch" {
+					patched = true
+				}
+			}
+			require.Equal(t, tc.wantPatched, patched)
+			if !tc.wantPatched {
+				return
+			}
+
+			got, err := client.Resource(gvr).Namespace("ns").Get(context.Background(), "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tc.wantAnnotations, got.GetAnnotations())
+		})
+	}
+}
+
+func TestRemoveSyncerAnnotations(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAnnotations(map[string]string{
+		"apply-conflicts.workload.kcp.dev/us-east1":   "[]",
+		"downstream-fields.workload.kcp.dev/us-east1": "{}",
+		"workload.kcp.dev/force-adopt":                "true",
+		"example.com/keep":                            "true",
+	})
+	removeSyncerAnnotations(obj)
+	require.Equal(t, map[string]string{"example.com/keep": "true"}, obj.GetAnnotations())
+}
+
+func TestOwnsDownstream(t *testing.T) {
+	tests := map[string]struct {
+		annotations   map[string]string
+		managedFields []metav1.ManagedFieldsEntry
+		want          bool
+	}{
+		"owned": {
+			annotations: map[string]string{"workload.kcp.dev/owner-workspace": "root:org:ws", "workload.kcp.dev/owner-workload-cluster": "us-east1"},
+			want:        true,
+		},
+		"owned by another workspace": {
+			annotations: map[string]string{"workload.kcp.dev/owner-workspace": "root:org:other", "workload.kcp.dev/owner-workload-cluster": "us-east1"},
+		},
+		"owned by another workload cluster": {
+			annotations: map[string]string{"workload.kcp.dev/owner-workspace": "root:org:ws", "workload.kcp.dev/owner-workload-cluster": "us-west1"},
+		},
+		"synced before ownership annotations": {
+			managedFields: []metav1.ManagedFieldsEntry{{Manager: "kube-controller-manager"}, {Manager: "syncer"}},
+			want:          true,
+		},
+		"native object": {
+			managedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		"force adopted": {
+			annotations: map[string]string{"workload.kcp.dev/force-adopt": "true"},
+			want:        true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetAnnotations(tc.annotations)
+			obj.SetManagedFields(tc.managedFields)
+			c := &Controller{upstreamClusterName: logicalcluster.New("root:org:ws"), pclusterID: "us-east1"}
+			require.Equal(t, tc.want, c.ownsDownstream(obj))
+		})
+	}
+}
+
+func TestDeleteFromDownstream(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	configMap := func(name string, annotations map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}
+		obj.SetNamespace("kcp-ns")
+		obj.SetName(name)
+		obj.SetAnnotations(annotations)
+		return obj
+	}
+	owned := map[string]string{"workload.kcp.dev/owner-workspace": "root:org:ws", "workload.kcp.dev/owner-workload-cluster": "us-east1"}
+
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), configMap("owned", owned), configMap("native", nil))
+	c := &Controller{toClient: client, upstreamClusterName: logicalcluster.New("root:org:ws"), pclusterID: "us-east1"}
+
+	require.NoError(t, c.deleteFromDownstream(context.Background(), gvr, "kcp-ns", "owned"))
+	require.NoError(t, c.deleteFromDownstream(context.Background(), gvr, "kcp-ns", "native"))
+	require.NoError(t, c.deleteFromDownstream(context.Background(), gvr, "kcp-ns", "missing"))
+
+	_, err := client.Resource(gvr).Namespace("kcp-ns").Get(context.Background(), "owned", metav1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err), "owned object should be deleted")
+	_, err = client.Resource(gvr).Namespace("kcp-ns").Get(context.Background(), "native", metav1.GetOptions{})
+	require.NoError(t, err, "native object should be kept")
+}
+
+func TestApplyToDownstreamRefusesTakeover(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	upstreamObj := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}
+	upstreamObj.SetNamespace("ns")
+	upstreamObj.SetName("foo")
+	upstreamObj.SetClusterName("root:org:ws")
+	// setting the annotation upstream does not allow the takeover
+	upstreamObj.SetAnnotations(map[string]string{"workload.kcp.dev/force-adopt": "true"})
+	native := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}
+	native.SetNamespace("kcp-ns")
+	native.SetName("foo")
+
+	fromClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), upstreamObj.DeepCopy())
+	toClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), native)
+	c := &Controller{fromClient: fromClient, toClient: toClient, upstreamClusterName: logicalcluster.New("root:org:ws"), pclusterID: "us-east1"}
+
+	require.NoError(t, c.applyToDownstream(context.Background(), gvr, "kcp-ns", upstreamObj))
+
+	for _, action := range toClient.Actions() {
+		require.NotEqual(t, "patch", action.GetVerb(), "the native object should not be patched")
+	}
+	got, err := fromClient.Resource(gvr).Namespace("ns").Get(context.Background(), "foo", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Contains(t, got.GetAnnotations()["apply-conflicts.workload.kcp.dev/us-east1"], `"reason":"NotOwned"`)
+}
+
+func TestApplyToDownstreamLeavesAutoscaledReplicas(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	for _, tc := range []struct {
+		desc         string
+		annotations  map[string]string
+		wantReplicas bool
+	}{{
+		desc:         "replicas are synced",
+		wantReplicas: true,
+	}, {
+		desc:        "replicas are managed by an autoscaler",
+		annotations: map[string]string{"workload.kcp.dev/replicas-managed-downstream": "web"},
+	}} {
+		t.Run(tc.desc, func(t *testing.T) {
+			upstreamObj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"spec":       map[string]interface{}{"replicas": int64(3)},
+			}}
+			upstreamObj.SetNamespace("ns")
+			upstreamObj.SetName("web--us-east1")
+			upstreamObj.SetClusterName("root:org:ws")
+			upstreamObj.SetAnnotations(tc.annotations)
+
+			toClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			c := &Controller{fromClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), toClient: toClient, upstreamClusterName: logicalcluster.New("root:org:ws"), pclusterID: "us-east1"}
+
+			// the fake client does not support server-side apply, only the patch matters
+			_ = c.applyToDownstream(context.Background(), gvr, "kcp-ns", upstreamObj)
+
+			var patched *unstructured.Unstructured
+			for _, action := range toClient.Actions() {
+				if patch, ok := action.(kubetesting.PatchAction); ok {
+					patched = &unstructured.Unstructured{}
+					require.NoError(t, json.Unmarshal(patch.GetPatch(), &patched.Object))
+				}
+			}
+			require.NotNil(t, patched, "the deployment should be applied")
+			_, found, err := unstructured.NestedFieldNoCopy(patched.Object, "spec", "replicas")
+			require.NoError(t, err)
+			require.Equal(t, tc.wantReplicas, found)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// stateSavePeriod is the period at which the sync state is written to disk when it changed.
+const stateSavePeriod = 10 * time.Second
+
+// syncStateFile is the content of the file a syncer persists its state to.
+type syncStateFile struct {
+	// Controller is the name of the syncer controller the state belongs to. A state of another
+	// controller, e.g. after the syncer was pointed to another workspace, is discarded.
+	Controller string `json:"controller"`
+	// ResourceVersions maps resources to the informer keys of the objects to the resource
+	// versions of the objects which have been synced.
+	ResourceVersions map[string]map[string]string `json:"resourceVersions"`
+}
+
+// syncState records the resource versions of the objects a syncer controller has synced. It is
+// persisted to disk, such that a restarted syncer skips the objects which have not changed since
+// they were synced, and catches up with the objects which have been deleted in the meantime.
+//
+// A nil syncState records nothing.
+type syncState struct {
+	path string
+
+	lock  sync.Mutex
+	dirty bool
+	file  syncStateFile
+}
+
+// loadSyncState loads the state of the controller from the file, or starts an empty state if the file
+// does not exist or belongs to another controller.
+func loadSyncState(path, controller string) (*syncState, error) {
+	s := &syncState{
+		path: path,
+		file: syncStateFile{Controller: controller, ResourceVersions: map[string]map[string]string{}},
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var file syncStateFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		klog.Warningf("Discarding invalid sync state %s: %v", path, err)
+		return s, nil
+	}
+	if file.Controller != controller {
+		klog.Infof("Discarding sync state %s of syncer %q", path, file.Controller)
+		return s, nil
+	}
+	if file.ResourceVersions != nil {
+		s.file.ResourceVersions = file.ResourceVersions
+	}
+	return s, nil
+}
+
+// upToDate returns true if the object with the key has been synced at the resource version.
+func (s *syncState) upToDate(gvr schema.GroupVersionResource, key, resourceVersion string) bool {
+	if s == nil || resourceVersion == "" {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.file.ResourceVersions[gvr.String()][key] == resourceVersion
+}
+
+// record records that the object with the key has been synced at the resource version.
+func (s *syncState) record(gvr schema.GroupVersionResource, key, resourceVersion string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	keys, ok := s.file.ResourceVersions[gvr.String()]
+	if !ok {
+		keys = map[string]string{}
+		s.file.ResourceVersions[gvr.String()] = keys
+	}
+	if keys[key] != resourceVersion {
+		keys[key] = resourceVersion
+		s.dirty = true
+	}
+}
+
+// forget removes the object with the key from the state, after its deletion has been synced.
+func (s *syncState) forget(gvr schema.GroupVersionResource, key string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.file.ResourceVersions[gvr.String()][key]; ok {
+		delete(s.file.ResourceVersions[gvr.String()], key)
+		s.dirty = true
+	}
+}
+
+// keys returns the keys of the synced objects of the resource.
+func (s *syncState) keys(gvr schema.GroupVersionResource) []string {
+	if s == nil {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var keys []string
+	for key := range s.file.ResourceVersions[gvr.String()] {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// save writes the state to disk if it changed since the last save. The file is replaced
+// atomically, such that a crash does not leave a truncated state behind.
+func (s *syncState) save() error {
+	if s == nil {
+		return nil
+	}
+	s.lock.Lock()
+	if !s.dirty {
+		s.lock.Unlock()
+		return nil
+	}
+	raw, err := json.Marshal(s.file)
+	s.dirty = false
+	s.lock.Unlock()
+	if err == nil {
+		err = writeFileAtomically(s.path, raw)
+	}
+	if err != nil {
+		// try again at the next save
+		s.lock.Lock()
+		s.dirty = true
+		s.lock.Unlock()
+	}
+	return err
+}
+
+func writeFileAtomically(path string, raw []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close() // nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// start saves the state periodically, and a last time when the context is done.
+func (s *syncState) start(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.save(); err != nil {
+			klog.Errorf("Failed to save sync state %s: %v", s.path, err)
+		}
+	}, stateSavePeriod)
+	if err := s.save(); err != nil {
+		klog.Errorf("Failed to save sync state %s: %v", s.path, err)
+	}
+}
+
+// stateFilePath returns the path of the state file of the syncer controller in the directory.
+func stateFilePath(dir string, direction SyncDirection) string {
+	return filepath.Join(dir, fmt.Sprintf("syncer-%s.json", direction))
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSyncStatePersistence(t *testing.T) {
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	path := stateFilePath(t.TempDir(), SyncDown)
+
+	state, err := loadSyncState(path, "down--root:org:ws--us-east1")
+	require.NoError(t, err)
+	require.False(t, state.upToDate(deployments, "ns/root:org:ws#$#foo", "1"))
+
+	state.record(deployments, "ns/root:org:ws#$#foo", "1")
+	state.record(deployments, "ns/root:org:ws#$#bar", "2")
+	state.forget(deployments, "ns/root:org:ws#$#bar")
+	require.NoError(t, state.save())
+
+	restarted, err := loadSyncState(path, "down--root:org:ws--us-east1")
+	require.NoError(t, err)
+	require.True(t, restarted.upToDate(deployments, "ns/root:org:ws#$#foo", "1"))
+	require.False(t, restarted.upToDate(deployments, "ns/root:org:ws#$#foo", "2"))
+	require.Equal(t, []string{"ns/root:org:ws#$#foo"}, restarted.keys(deployments))
+
+	// the state of another syncer is discarded
+	other, err := loadSyncState(path, "down--root:org:other--us-east1")
+	require.NoError(t, err)
+	require.Empty(t, other.keys(deployments))
+
+	// so is a corrupted one
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+	corrupted, err := loadSyncState(path, "down--root:org:ws--us-east1")
+	require.NoError(t, err)
+	require.Empty(t, corrupted.keys(deployments))
+
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*"))
+	require.NoError(t, err)
+	require.Equal(t, []string{path}, files, "temporary files should be removed")
+}
+
+func TestNilSyncState(t *testing.T) {
+	var state *syncState
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	state.record(deployments, "ns/foo", "1")
+	require.False(t, state.upToDate(deployments, "ns/foo", "1"))
+	require.Empty(t, state.keys(deployments))
+	require.NoError(t, state.save())
+}
+
+func TestHolderForKey(t *testing.T) {
+	for _, h := range []holder{
+		{namespace: "ns", name: "foo"},
+		{clusterName: logicalcluster.New("root:org:ws"), namespace: "ns", name: "foo"},
+	} {
+		got, err := holderForKey(h.gvr, informerKey(h))
+		require.NoError(t, err)
+		require.Equal(t, h, got)
+	}
+}
@@ -25,9 +25,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/features"
 )
 
 func deepEqualStatus(oldObj, newObj interface{}) bool {
@@ -61,7 +65,16 @@ func NewStatusSyncer(from, to *rest.Config, gvrs []string, kcpClusterName logica
 	// Register the default mutators
 	mutatorsMap := getDefaultMutators(from)
 
-	return New(kcpClusterName, pclusterID, fromClient, toClient, SyncUp, gvrs, pclusterID, mutatorsMap)
+	c, err := New(kcpClusterName, pclusterID, fromClient, toClient, SyncUp, gvrs, pclusterID, mutatorsMap)
+	if err != nil {
+		return nil, err
+	}
+	for _, gvr := range c.gvrs {
+		if gvr == servicesGVR && utilfeature.DefaultFeatureGate.Enabled(features.Upsync) {
+			c.watchEndpointSlices()
+		}
+	}
+	return c, nil
 }
 
 func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.GroupVersionResource, upstreamNamespace string, downstreamObj *unstructured.Unstructured) error {
@@ -81,7 +94,7 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 
 	// Run any transformations on the object before we update the status on kcp.
 	if mutator, ok := c.mutators[gvr]; ok {
-		if err := mutator(upstreamObj); err != nil {
+		if err := mutator(upstreamObj, upstreamNamespace); err != nil {
 			return err
 		}
 	}
@@ -96,5 +109,28 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 	}
 	klog.Infof("Updated status of resource %s|%s/%s from pcluster namespace %s", c.upstreamClusterName, upstreamNamespace, upstreamObj.GetName(), downstreamObj.GetNamespace())
 
-	return nil
+	// Expose the fields populated by the pcluster in a view of this pcluster, as the status
+	// is overwritten by the status of every pcluster the resource is synced to. Without the
+	// Upsync feature, the values exposed before are removed.
+	upsync := utilfeature.DefaultFeatureGate.Enabled(features.Upsync)
+	var value string
+	if upsync {
+		if value, err = downstreamFieldsValue(gvr, downstreamObj); err != nil {
+			return err
+		}
+	}
+	if err := patchAnnotation(ctx, c.toClient, gvr, existing, workloadv1alpha1.DownstreamFieldsAnnotationPrefix+c.pclusterID, value); err != nil {
+		return err
+	}
+
+	if gvr != servicesGVR || !c.watchesEndpointSlices {
+		return nil
+	}
+	value = ""
+	if upsync {
+		if value, err = c.endpointsValue(downstreamObj); err != nil {
+			return err
+		}
+	}
+	return patchAnnotation(ctx, c.toClient, gvr, existing, workloadv1alpha1.EndpointsAnnotationPrefix+c.pclusterID, value)
 }
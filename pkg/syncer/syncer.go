@@ -21,32 +21,44 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/transport"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	workloadclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/features"
 	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 	"github.com/kcp-dev/kcp/pkg/syncer/mutators"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
 
 const (
@@ -70,6 +82,26 @@ const SyncDown SyncDirection = "down"
 // SyncUp indicates a syncer watches resources on the target cluster and applies the status to KCP
 const SyncUp SyncDirection = "up"
 
+// SyncTarget is a WorkloadCluster of a kcp workspace whose resources are synced to the physical cluster.
+type SyncTarget struct {
+	// Upstream is the config of kcp, with the credentials of the target.
+	Upstream *rest.Config
+	// Downstream is the config of the physical cluster. If nil, the one shared by all targets is used.
+	Downstream          *rest.Config
+	Resources           sets.String
+	KCPClusterName      logicalcluster.LogicalCluster
+	WorkloadClusterName string
+	// StateDir is the directory the sync state is persisted to. If empty, it is not persisted.
+	StateDir string
+	// UpsyncPods reflects the pods of the physical cluster in the synced namespaces into the
+	// workspace, read-only. The Pod API is imported from the physical cluster for that purpose.
+	UpsyncPods bool
+}
+
+func (t SyncTarget) String() string {
+	return t.KCPClusterName.String() + "|" + t.WorkloadClusterName
+}
+
 func StartSyncer(
 	ctx context.Context,
 	upstream, downstream *rest.Config,
@@ -78,38 +110,155 @@ func StartSyncer(
 	pcluster string,
 	numSyncerThreads int,
 	importPollInterval time.Duration,
+	stateDir string,
+	metricsBindAddress string,
+) error {
+	return StartSyncers(ctx, downstream, []SyncTarget{{
+		Upstream:            upstream,
+		Resources:           resources,
+		KCPClusterName:      kcpClusterName,
+		WorkloadClusterName: pcluster,
+		StateDir:            stateDir,
+	}}, numSyncerThreads, importPollInterval, metricsBindAddress, "", "")
+}
+
+// StartSyncers starts syncing the targets to the physical cluster. Every target has its own
+// informers, queues and state, such that a failing target does not hold up the others. The
+// metrics and health endpoints are shared. If debugToken is not empty, the debug endpoints are
+// served on the loopback address debugBindAddress to the requests presenting it as bearer token.
+func StartSyncers(
+	ctx context.Context,
+	downstream *rest.Config,
+	targets []SyncTarget,
+	numSyncerThreads int,
+	importPollInterval time.Duration,
+	metricsBindAddress string,
+	debugBindAddress string,
+	debugToken string,
 ) error {
+	if err := validateTargets(targets); err != nil {
+		return err
+	}
+
+	syncerHealth, err := newHealth(downstream)
+	if err != nil {
+		return err
+	}
+	if metricsBindAddress != "" {
+		RegisterMetrics()
+		if err := syncerHealth.serve(ctx, metricsBindAddress); err != nil {
+			return err
+		}
+	}
+	if debugToken != "" {
+		if err := syncerHealth.serveDebug(ctx, debugBindAddress, debugToken); err != nil {
+			return err
+		}
+	}
+
+	targetHealths := map[string]*targetHealth{}
+	for _, target := range targets {
+		targetHealths[target.String()] = syncerHealth.addTarget(target.String())
+	}
+	return startTargets(targets, func(target SyncTarget) error {
+		if target.Downstream == nil {
+			target.Downstream = downstream
+		}
+		return startTarget(ctx, target, targetHealths[target.String()], numSyncerThreads, importPollInterval)
+	})
+}
+
+// validateTargets fails if a WorkloadCluster is synced more than once.
+func validateTargets(targets []SyncTarget) error {
+	seen := map[string]bool{}
+	for _, target := range targets {
+		if seen[target.String()] {
+			return fmt.Errorf("WorkloadCluster %s is synced more than once", target)
+		}
+		seen[target.String()] = true
+	}
+	return nil
+}
+
+// startTargets starts the targets concurrently, such that a target whose resources are not
+// served yet does not hold up the others.
+func startTargets(targets []SyncTarget, start func(target SyncTarget) error) error {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var errs []error
+	for _, target := range targets {
+		target := target
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := start(target); err != nil {
+				lock.Lock()
+				defer lock.Unlock()
+				errs = append(errs, fmt.Errorf("failed to start syncing WorkloadCluster %s: %w", target, err))
+			}
+		}()
+	}
+	wg.Wait()
+	return utilerrors.NewAggregate(errs)
+}
+
+func startTarget(ctx context.Context, target SyncTarget, targetHealth *targetHealth, numSyncerThreads int, importPollInterval time.Duration) error {
+	upstream, resources, kcpClusterName, pcluster := target.Upstream, target.Resources, target.KCPClusterName, target.WorkloadClusterName
+
+	// count the failed requests to the physical cluster
+	downstream := rest.CopyConfig(target.Downstream)
+	downstream.WrapTransport = transport.Wrappers(downstream.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+		return &errorCountingRoundTripper{delegate: rt}
+	})
+
 	// Start api import first because spec and status syncers are blocked by
 	// gvr discovery finding all the configured resource types in the kcp
 	// workspace.
-	apiImporter, err := NewAPIImporter(upstream, downstream, resources.List(), kcpClusterName, pcluster)
+	importedResources := resources
+	if target.UpsyncPods {
+		importedResources = resources.Union(sets.NewString("pods"))
+	}
+	apiImporter, err := NewAPIImporter(upstream, downstream, importedResources.List(), kcpClusterName, pcluster)
 	if err != nil {
 		return err
 	}
 	go apiImporter.Start(ctx, importPollInterval)
 
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(upstream)
+	// Block syncer start on gvr discovery completing successfully and
+	// including the resources configured for syncing.
+	gvrs, err := discoverGVRs(target, importedResources)
 	if err != nil {
 		return err
 	}
-	fromDiscovery := discoveryClient.WithCluster(kcpClusterName)
 
-	// Block syncer start on gvr discovery completing successfully and
-	// including the resources configured for syncing. The spec and status
-	// syncers depend on the types being present to start their informers.
-	var gvrs []string
-	err = wait.PollImmediateInfinite(gvrQueryInterval, func() (bool, error) {
-		klog.Info("Attempting to retrieve GVRs from kcp")
+	if target.UpsyncPods && !resources.Has("pods") {
+		// the pods are only upsynced
+		syncedGVRs := gvrs[:0:0]
+		for _, gvr := range gvrs {
+			if gvr != podsGVR.Resource+"."+podsGVR.Version+"." {
+				syncedGVRs = append(syncedGVRs, gvr)
+			}
+		}
+		gvrs = syncedGVRs
+	}
 
-		var err error
-		// Get all types the upstream API server knows about.
-		// TODO: watch this and learn about new types, or forget about old ones.
-		gvrs, err = getAllGVRs(fromDiscovery, resources.List()...)
-		// TODO(marun) Should some of these errors be fatal?
+	kcpClusterClient, err := kcpclient.NewClusterForConfig(upstream)
+	if err != nil {
+		return err
+	}
+	kcpClient := kcpClusterClient.Cluster(kcpClusterName)
+	workloadClustersClient := kcpClient.WorkloadV1alpha1().WorkloadClusters()
+
+	// The classes of the synced workloads are translated from the start, the heartbeat keeps the
+	// mappings up to date afterwards.
+	classMutator := mutators.NewClassMutator()
+	err = wait.PollImmediateInfinite(gvrQueryInterval, func() (bool, error) {
+		workloadCluster, err := workloadClustersClient.Get(ctx, pcluster, metav1.GetOptions{})
 		if err != nil {
-			klog.Errorf("Failed to retrieve GVRs from kcp: %v", err)
+			klog.Errorf("Failed to get WorkloadCluster %s: %v", target, err)
 			return false, nil
 		}
+		classMutator.SetMappings(workloadCluster.Spec)
 		return true, nil
 	})
 	if err != nil {
@@ -118,7 +267,7 @@ func StartSyncer(
 	}
 
 	klog.Infof("Creating spec syncer for clusterName %s to pcluster %s, resources %v", kcpClusterName, pcluster, resources.List())
-	specSyncer, err := NewSpecSyncer(upstream, downstream, gvrs, kcpClusterName, pcluster)
+	specSyncer, err := NewSpecSyncer(upstream, downstream, gvrs, kcpClusterName, pcluster, classMutator)
 	if err != nil {
 		return err
 	}
@@ -129,17 +278,85 @@ func StartSyncer(
 		return err
 	}
 
+	if target.StateDir != "" {
+		if err := os.MkdirAll(target.StateDir, 0700); err != nil {
+			return err
+		}
+		if specSyncer.state, err = loadSyncState(stateFilePath(target.StateDir, SyncDown), specSyncer.name); err != nil {
+			return err
+		}
+		if statusSyncer.state, err = loadSyncState(stateFilePath(target.StateDir, SyncUp), statusSyncer.name); err != nil {
+			return err
+		}
+	}
+	targetHealth.started(specSyncer, statusSyncer)
+
 	go specSyncer.Start(ctx, numSyncerThreads)
 	go statusSyncer.Start(ctx, numSyncerThreads)
 
+	if target.UpsyncPods {
+		klog.Infof("Creating pod upsyncer for clusterName %s from pcluster %s", kcpClusterName, pcluster)
+		podUpsyncer, err := NewPodUpsyncer(downstream, upstream, kcpClusterName, pcluster)
+		if err != nil {
+			return err
+		}
+		go podUpsyncer.Start(ctx, numSyncerThreads)
+	}
+
+	if utilfeature.DefaultFeatureGate.Enabled(features.Upsync) {
+		klog.Infof("Creating event upsyncer for clusterName %s from pcluster %s", kcpClusterName, pcluster)
+		eventUpsyncer, err := NewEventUpsyncer(downstream, upstream, kcpClusterName, pcluster)
+		if err != nil {
+			return err
+		}
+		go eventUpsyncer.Start(ctx, numSyncerThreads)
+	}
+
 	// TODO(marun) Report pcluster connectivity to kcp
-	kcpClusterClient, err := kcpclient.NewClusterForConfig(upstream)
+
+	startHeartbeat(ctx, workloadClustersClient, target, specSyncer, func(workloadCluster *workloadv1alpha1.WorkloadCluster) {
+		targetHealth.heartbeat(time.Now())
+
+		if classMutator.SetMappings(workloadCluster.Spec) {
+			klog.Infof("Class mappings of WorkloadCluster %s changed, resyncing workloads", target)
+			specSyncer.resync(classMutator.GVRs()...)
+		}
+	})
+
+	return nil
+}
+
+// discoverGVRs blocks until the workspace of the target serves the resources, and returns the GVRs
+// to sync. The spec and status syncers depend on the types being present to start their informers.
+func discoverGVRs(target SyncTarget, resources sets.String) ([]string, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(target.Upstream)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	kcpClient := kcpClusterClient.Cluster(kcpClusterName)
-	workloadClustersClient := kcpClient.WorkloadV1alpha1().WorkloadClusters()
+	fromDiscovery := discoveryClient.WithCluster(target.KCPClusterName)
+
+	var gvrs []string
+	err = wait.PollImmediateInfinite(gvrQueryInterval, func() (bool, error) {
+		klog.Infof("Attempting to retrieve GVRs from kcp for WorkloadCluster %s", target)
 
+		var err error
+		// Get all types the upstream API server knows about.
+		// TODO: watch this and learn about new types, or forget about old ones.
+		gvrs, err = getAllGVRs(fromDiscovery, resources.List()...)
+		// TODO(marun) Should some of these errors be fatal?
+		if err != nil {
+			klog.Errorf("Failed to retrieve GVRs from kcp: %v", err)
+			return false, nil
+		}
+		return true, nil
+	})
+	return gvrs, err
+}
+
+// startHeartbeat sets the heartbeat of the WorkloadCluster every heartbeatInterval, and calls
+// onHeartbeat with the WorkloadCluster after every successful heartbeat. Once the WorkloadCluster
+// is deleted, its drain is reported when the spec syncer has removed all the assigned resources.
+func startHeartbeat(ctx context.Context, client workloadclient.WorkloadClusterInterface, target SyncTarget, specSyncer *Controller, onHeartbeat func(workloadCluster *workloadv1alpha1.WorkloadCluster)) {
 	// Attempt to heartbeat every interval
 	go wait.UntilWithContext(ctx, func(ctx context.Context) {
 		var heartbeatTime time.Time
@@ -150,23 +367,42 @@ func StartSyncer(
 		// poll error can be safely ignored.
 		_ = wait.PollImmediateInfiniteWithContext(ctx, 1*time.Second, func(ctx context.Context) (bool, error) {
 			patchBytes := []byte(fmt.Sprintf(`[{"op":"replace","path":"/status/lastSyncerHeartbeatTime","value":%q}]`, time.Now().Format(time.RFC3339)))
-			workloadCluster, err := workloadClustersClient.Patch(ctx, pcluster, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
+			workloadCluster, err := client.Patch(ctx, target.WorkloadClusterName, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
 			if err != nil {
-				klog.Errorf("failed to set status.lastSyncerHeartbeatTime for WorkloadCluster %s|%s: %v", kcpClusterName, pcluster, err)
+				klog.Errorf("failed to set status.lastSyncerHeartbeatTime for WorkloadCluster %s: %v", target, err)
 				return false, nil
 			}
 			heartbeatTime = workloadCluster.Status.LastSyncerHeartbeatTime.Time
+			if onHeartbeat != nil {
+				onHeartbeat(workloadCluster)
+			}
+
+			if err := reportDrained(ctx, client, workloadCluster, specSyncer); err != nil {
+				klog.Errorf("failed to report the drain of WorkloadCluster %s: %v", target, err)
+			}
 			return true, nil
 		})
 
-		klog.V(5).Infof("Heartbeat set for WorkloadCluster %s|%s: %s", kcpClusterName, pcluster, heartbeatTime)
+		klog.V(5).Infof("Heartbeat set for WorkloadCluster %s: %s", target, heartbeatTime)
 
 	}, heartbeatInterval)
+}
 
-	return nil
+// reportDrained marks the WorkloadCluster as drained downstream once it is deleted and the spec
+// syncer has removed all the resources that were assigned to it.
+func reportDrained(ctx context.Context, client workloadclient.WorkloadClusterInterface, workloadCluster *workloadv1alpha1.WorkloadCluster, specSyncer *Controller) error {
+	if workloadCluster.DeletionTimestamp == nil || conditions.IsTrue(workloadCluster, workloadv1alpha1.DownstreamDrained) {
+		return nil
+	}
+	if !specSyncer.drained() {
+		return nil
+	}
+	conditions.MarkTrue(workloadCluster, workloadv1alpha1.DownstreamDrained)
+	_, err := client.UpdateStatus(ctx, workloadCluster, metav1.UpdateOptions{})
+	return err
 }
 
-type mutatorGvrMap map[schema.GroupVersionResource]func(obj *unstructured.Unstructured) error
+type mutatorGvrMap map[schema.GroupVersionResource]func(obj *unstructured.Unstructured, upstreamNamespace string) error
 type UpsertFunc func(ctx context.Context, gvr schema.GroupVersionResource, namespace string, unstrob *unstructured.Unstructured) error
 type DeleteFunc func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) error
 type HandlersProvider func(c *Controller, gvr schema.GroupVersionResource) cache.ResourceEventHandlerFuncs
@@ -176,15 +412,29 @@ type Controller struct {
 	queue workqueue.RateLimitingInterface
 
 	fromInformers dynamicinformer.DynamicSharedInformerFactory
+	fromClient    dynamic.Interface
 	toClient      dynamic.Interface
+	gvrs          []schema.GroupVersionResource
 
 	upsertFn  UpsertFunc
 	deleteFn  DeleteFunc
 	direction SyncDirection
 
 	upstreamClusterName logicalcluster.LogicalCluster
+	pclusterID          string
 	syncerNamespace     string
 	mutators            mutatorGvrMap
+
+	watchesEndpointSlices bool
+
+	// state is nil if the sync state is not persisted.
+	state *syncState
+
+	pending *pendingObjects
+	// synced is 1 once the informers have synced.
+	synced int32
+	// inFlight is the number of objects being synced by the workers.
+	inFlight int32
 }
 
 // New returns a new syncer Controller syncing spec from "from" to "to".
@@ -195,11 +445,14 @@ func New(kcpClusterName logicalcluster.LogicalCluster, pcluster string, fromClie
 	c := Controller{
 		name:                controllerName,
 		queue:               queue,
+		fromClient:          fromClient,
 		toClient:            toClient,
 		direction:           direction,
 		upstreamClusterName: kcpClusterName,
+		pclusterID:          pclusterID,
 		syncerNamespace:     os.Getenv(SyncerNamespaceKey),
 		mutators:            make(mutatorGvrMap),
+		pending:             newPendingObjects(SyncTarget{KCPClusterName: kcpClusterName, WorkloadClusterName: pcluster}.String(), direction),
 	}
 
 	if len(mutators) > 0 {
@@ -219,16 +472,22 @@ func New(kcpClusterName logicalcluster.LogicalCluster, pcluster string, fromClie
 
 	for _, gvrstr := range gvrs {
 		gvr, _ := schema.ParseResourceArg(gvrstr)
+		c.gvrs = append(c.gvrs, *gvr)
 
 		fromInformers.ForResource(*gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) { c.AddToQueue(*gvr, obj) },
+			AddFunc: func(obj interface{}) {
+				// the initial list of a restarted syncer adds all objects, skip those which did not change
+				if !c.syncedAtResourceVersion(*gvr, obj) {
+					c.AddToQueue(*gvr, obj)
+				}
+			},
 			UpdateFunc: func(oldObj, newObj interface{}) {
 				if c.direction == SyncDown {
 					if !deepEqualApartFromStatus(oldObj, newObj) {
 						c.AddToQueue(*gvr, newObj)
 					}
 				} else {
-					if !deepEqualStatus(oldObj, newObj) {
+					if !deepEqualStatus(oldObj, newObj) || !deepEqualDownstreamFields(*gvr, oldObj, newObj) {
 						c.AddToQueue(*gvr, newObj)
 					}
 				}
@@ -331,6 +590,35 @@ type holder struct {
 	name        string
 }
 
+// informerKey returns the key of the object of the holder in the informers.
+func informerKey(h holder) string {
+	return h.namespace + "/" + clusters.ToClusterAwareKey(h.clusterName, h.name)
+}
+
+// holderForKey returns the holder of the object with the informer key.
+func holderForKey(gvr schema.GroupVersionResource, key string) (holder, error) {
+	namespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return holder{}, err
+	}
+	clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
+	return holder{gvr: gvr, clusterName: clusterName, namespace: namespace, name: name}, nil
+}
+
+// syncedAtResourceVersion returns true if the object has been synced at its current resource version
+// according to the persisted sync state.
+func (c *Controller) syncedAtResourceVersion(gvr schema.GroupVersionResource, obj interface{}) bool {
+	if c.state == nil {
+		return false
+	}
+	metaObj, err := meta.Accessor(obj)
+	if err != nil || metaObj.GetNamespace() == "" {
+		return false
+	}
+	key := informerKey(holder{clusterName: logicalcluster.From(metaObj), namespace: metaObj.GetNamespace(), name: metaObj.GetName()})
+	return c.state.upToDate(gvr, key, metaObj.GetResourceVersion())
+}
+
 func (c *Controller) AddToQueue(gvr schema.GroupVersionResource, obj interface{}) {
 	objToCheck := obj
 
@@ -354,7 +642,7 @@ func (c *Controller) AddToQueue(gvr schema.GroupVersionResource, obj interface{}
 	}
 	klog.Infof("Syncer %s: adding %s %s to queue", c.name, gvr, qualifiedName)
 
-	c.queue.Add(
+	c.enqueue(
 		holder{
 			gvr:         gvr,
 			clusterName: logicalcluster.From(metaObj),
@@ -364,6 +652,49 @@ func (c *Controller) AddToQueue(gvr schema.GroupVersionResource, obj interface{}
 	)
 }
 
+// resync enqueues all the objects of the given resources, e.g. when their transformation changed.
+func (c *Controller) resync(gvrs ...schema.GroupVersionResource) {
+	for _, gvr := range gvrs {
+		synced := false
+		for _, syncedGVR := range c.gvrs {
+			if syncedGVR == gvr {
+				synced = true
+				break
+			}
+		}
+		if !synced {
+			continue
+		}
+		for _, obj := range c.fromInformers.ForResource(gvr).Informer().GetStore().List() {
+			c.AddToQueue(gvr, obj)
+		}
+	}
+}
+
+func (c *Controller) enqueue(h holder) {
+	c.pending.add(h)
+	c.queue.Add(h)
+}
+
+// hasSynced returns true once the informers have synced.
+func (c *Controller) hasSynced() bool {
+	return atomic.LoadInt32(&c.synced) == 1
+}
+
+// drained returns true when no resource is left in the informers and all the
+// queued changes have been processed, including those being synced or waiting for a retry.
+func (c *Controller) drained() bool {
+	if c.queue.Len() > 0 || atomic.LoadInt32(&c.inFlight) > 0 || c.pending.len() > 0 {
+		return false
+	}
+	for _, gvr := range c.gvrs {
+		if len(c.fromInformers.ForResource(gvr).Informer().GetStore().ListKeys()) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // Start starts N worker processes processing work items.
 func (c *Controller) Start(ctx context.Context, numThreads int) {
 	defer runtime.HandleCrash()
@@ -372,6 +703,25 @@ func (c *Controller) Start(ctx context.Context, numThreads int) {
 	c.fromInformers.Start(ctx.Done())
 	c.fromInformers.WaitForCacheSync(ctx.Done())
 
+	// objects deleted while the syncer was not running have no deletion event
+	for _, gvr := range c.gvrs {
+		existing := sets.NewString(c.fromInformers.ForResource(gvr).Informer().GetStore().ListKeys()...)
+		for _, key := range c.state.keys(gvr) {
+			if existing.Has(key) {
+				continue
+			}
+			h, err := holderForKey(gvr, key)
+			if err != nil {
+				klog.Errorf("%s: invalid key %q in sync state: %v", c.name, key, err)
+				c.state.forget(gvr, key)
+				continue
+			}
+			c.enqueue(h)
+		}
+	}
+	go c.state.start(ctx)
+	atomic.StoreInt32(&c.synced, 1)
+
 	klog.InfoS("Starting syncer workers", "controller", c.name)
 	defer klog.InfoS("Stopping syncer workers", "controller", c.name)
 	for i := 0; i < numThreads; i++ {
@@ -395,12 +745,20 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	}
 	h := key.(holder)
 
+	// counted before it is removed from the pending objects, for drained to never miss it
+	atomic.AddInt32(&c.inFlight, 1)
+	defer atomic.AddInt32(&c.inFlight, -1)
+
 	// No matter what, tell the queue we're done with this key, to unblock
 	// other workers.
 	defer c.queue.Done(key)
 
-	if err := c.process(ctx, h); err != nil {
+	since := c.pending.done(h)
+	err := c.process(ctx, h)
+	c.pending.observe(h, since, err)
+	if err != nil {
 		runtime.HandleError(fmt.Errorf("syncer %q failed to sync %q, err: %w", c.name, key, err))
+		c.pending.addSince(h, since)
 		c.queue.AddRateLimited(key)
 		return true
 	}
@@ -479,6 +837,7 @@ func (c *Controller) process(ctx context.Context, h holder) error {
 		nsObj, err := nsInformer.Lister().Get(nsKey)
 		if err != nil {
 			klog.Errorf("%s: error retrieving namespace %q from physical cluster lister: %v", c.name, nsKey, err)
+			c.state.forget(h.gvr, informerKey(h))
 			return nil
 		}
 
@@ -494,6 +853,10 @@ func (c *Controller) process(ctx context.Context, h holder) error {
 				klog.Errorf("%s: namespace %q: error decoding annotation: %v", c.name, nsKey, err)
 				return nil
 			}
+			if l.LogicalCluster != c.upstreamClusterName {
+				// the namespace belongs to another workspace syncing to the same physical cluster
+				return nil
+			}
 			toNamespace = l.Namespace
 		} else {
 			// this is not our namespace, silently skipping
@@ -501,13 +864,7 @@ func (c *Controller) process(ctx context.Context, h holder) error {
 		}
 	}
 
-	key := h.name
-
-	if !h.clusterName.Empty() {
-		key = clusters.ToClusterAwareKey(h.clusterName, key)
-	}
-
-	key = fromNamespace + "/" + key
+	key := informerKey(h)
 
 	obj, exists, err := c.fromInformers.ForResource(h.gvr).Informer().GetIndexer().GetByKey(key)
 	if err != nil {
@@ -517,8 +874,11 @@ func (c *Controller) process(ctx context.Context, h holder) error {
 	if !exists {
 		klog.InfoS("Object doesn't exist:", "direction", c.direction, "clusterName", h.clusterName, "namespace", fromNamespace, "name", h.name)
 		if c.deleteFn != nil {
-			return c.deleteFn(ctx, h.gvr, toNamespace, h.name)
+			if err := c.deleteFn(ctx, h.gvr, toNamespace, h.name); err != nil {
+				return err
+			}
 		}
+		c.state.forget(h.gvr, key)
 		return nil
 	}
 
@@ -528,10 +888,13 @@ func (c *Controller) process(ctx context.Context, h holder) error {
 	}
 
 	if c.upsertFn != nil {
-		return c.upsertFn(ctx, h.gvr, toNamespace, unstrob)
+		if err := c.upsertFn(ctx, h.gvr, toNamespace, unstrob); err != nil {
+			return err
+		}
 	}
+	c.state.record(h.gvr, key, unstrob.GetResourceVersion())
 
-	return err
+	return nil
 }
 
 // transformName changes the object name into the desired one based on the Direction:
@@ -550,11 +913,17 @@ func transformName(syncedObject *unstructured.Unstructured, direction SyncDirect
 		if syncedObject.GroupVersionKind() == serviceAccountGVR && syncedObject.GetName() == "default" {
 			syncedObject.SetName("kcp-default")
 		}
-		// TODO(jmprusi): We are rewriting the name of the object into a non random one so we can reference it from the deployment transformer
-		//                but this means that means than more than one default-token-XXXX object will overwrite the same "kcp-default-token"
-		//				  object. This must be fixed.
-		if syncedObject.GroupVersionKind() == secretGVR && strings.Contains(syncedObject.GetName(), "default-token-") {
-			syncedObject.SetName("kcp-default-token")
+		// TODO(jmprusi): We are rewriting the name of the object into a non random one so we can reference it from the pod spec mutator
+		//                but this means that means than more than one <serviceaccount>-token-XXXX object will overwrite the same
+		//				  "kcp-<serviceaccount>-token" object. This must be fixed.
+		if syncedObject.GroupVersionKind() == secretGVR {
+			serviceAccountName := syncedObject.GetAnnotations()[corev1.ServiceAccountNameKey]
+			secretType, _, _ := unstructured.NestedString(syncedObject.Object, "type")
+			if secretType == string(corev1.SecretTypeServiceAccountToken) && serviceAccountName != "" {
+				syncedObject.SetName(mutators.ServiceAccountTokenSecretName(serviceAccountName))
+			} else if strings.Contains(syncedObject.GetName(), "default-token-") {
+				syncedObject.SetName(mutators.ServiceAccountTokenSecretName("default"))
+			}
 		}
 	case SyncUp:
 		if syncedObject.GroupVersionKind() == configMapGVR && syncedObject.GetName() == "kcp-root-ca.crt" {
@@ -569,10 +938,56 @@ func transformName(syncedObject *unstructured.Unstructured, direction SyncDirect
 func getDefaultMutators(from *rest.Config) mutatorGvrMap {
 	mutatorsMap := make(mutatorGvrMap)
 
-	deploymentMutator := mutators.NewDeploymentMutator(from)
+	podSpecMutator := mutators.NewPodSpecMutator(from)
 	secretMutator := mutators.NewSecretMutator()
 
-	mutatorsMap[deploymentMutator.GVR()] = deploymentMutator.Mutate
+	for _, gvr := range podSpecMutator.GVRs() {
+		mutatorsMap[gvr] = podSpecMutator.Mutate
+	}
 	mutatorsMap[secretMutator.GVR()] = secretMutator.Mutate
 	return mutatorsMap
 }
+
+// chain runs the mutator after the ones already registered for the resource.
+func (m mutatorGvrMap) chain(gvr schema.GroupVersionResource, mutator func(obj *unstructured.Unstructured, upstreamNamespace string) error) {
+	previous, ok := m[gvr]
+	if !ok {
+		m[gvr] = mutator
+		return
+	}
+	m[gvr] = func(obj *unstructured.Unstructured, upstreamNamespace string) error {
+		if err := previous(obj, upstreamNamespace); err != nil {
+			return err
+		}
+		return mutator(obj, upstreamNamespace)
+	}
+}
+
+// patchAnnotation sets the annotation on the object through the given client, or removes it if the value is
+// empty. Nothing is written if the annotation already has the value.
+func patchAnnotation(ctx context.Context, client dynamic.Interface, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, key, value string) error {
+	existing, found := obj.GetAnnotations()[key]
+	if (value == "" && !found) || (value != "" && found && existing == value) {
+		return nil
+	}
+
+	var annotationValue interface{}
+	if value != "" {
+		annotationValue = value
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				key: annotationValue,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.Resource(gvr).Namespace(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
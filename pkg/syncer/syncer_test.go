@@ -17,9 +17,19 @@ limitations under the License.
 package syncer
 
 import (
+	"context"
 	"testing"
 
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
 )
 
 func TestTransformName(t *testing.T) {
@@ -118,6 +128,25 @@ func TestTransformName(t *testing.T) {
 			},
 			expectedName: "arbitrary",
 		},
+		{
+			desc:      "Sync service account token secret from KCP to a Pcluster",
+			direction: SyncDown,
+			syncedobject: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":       "Secret",
+					"apiVersion": "v1",
+					"group":      "",
+					"metadata": map[string]interface{}{
+						"name": "my-controller-token-x2bcd",
+						"annotations": map[string]interface{}{
+							"kubernetes.io/service-account.name": "my-controller",
+						},
+					},
+					"type": "kubernetes.io/service-account-token",
+				},
+			},
+			expectedName: "kcp-my-controller-token",
+		},
 		{
 			desc:      "Sync arbitrary configmap from Pcluster to a KCP",
 			direction: SyncUp,
@@ -187,3 +216,45 @@ func TestTransformName(t *testing.T) {
 		})
 	}
 }
+
+func TestStartSyncersRejectsDuplicateTargets(t *testing.T) {
+	target := SyncTarget{
+		Upstream:            &rest.Config{Host: "https://kcp.example.com"},
+		KCPClusterName:      logicalcluster.New("root:org:ws"),
+		WorkloadClusterName: "east",
+	}
+	err := StartSyncers(context.Background(), &rest.Config{Host: "https://pcluster.example.com"}, []SyncTarget{target, target}, 1, 0, "", "", "")
+	require.EqualError(t, err, "WorkloadCluster root:org:ws|east is synced more than once")
+}
+
+func TestDrainedWaitsForInFlightSyncs(t *testing.T) {
+	deleting, unblock := make(chan struct{}), make(chan struct{})
+	c := &Controller{
+		queue:         workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		fromInformers: dynamicinformer.NewDynamicSharedInformerFactory(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), 0),
+		direction:     SyncDown,
+		pending:       newPendingObjects("root:org:ws|east", SyncDown),
+		deleteFn: func(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) error {
+			close(deleting)
+			<-unblock
+			return nil
+		},
+	}
+	require.True(t, c.drained())
+
+	c.enqueue(holder{gvr: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, clusterName: logicalcluster.New("root:org:ws"), namespace: "ns", name: "foo"})
+	require.False(t, c.drained(), "a queued object is not drained")
+
+	done := make(chan struct{})
+	go func() {
+		c.processNextWorkItem(context.Background())
+		close(done)
+	}()
+	<-deleting
+	require.Zero(t, c.queue.Len())
+	require.False(t, c.drained(), "an object being synced is not drained")
+
+	close(unblock)
+	<-done
+	require.True(t, c.drained())
+}
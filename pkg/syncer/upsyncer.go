@@ -0,0 +1,359 @@
+/*
+Copyright 2021 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
+)
+
+var namespacesGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// upsyncedObject identifies an upsynced object by its namespace and name in kcp.
+type upsyncedObject struct {
+	namespace string
+	name      string
+}
+
+// upsyncTransformFunc adapts the object to expose in kcp, built from the object of the physical cluster.
+type upsyncTransformFunc func(ctx context.Context, upstreamObj *unstructured.Unstructured) error
+
+// Upsyncer reflects the objects created on the physical cluster in the namespaces of a workspace
+// into the workspace, so that they can be looked at in kcp although they are not created by kcp.
+// The upsynced objects are read-only: changes made to them in kcp are reverted, and deleted objects
+// are recreated as long as they exist on the physical cluster.
+type Upsyncer struct {
+	name                string
+	gvr                 schema.GroupVersionResource
+	queue               workqueue.RateLimitingInterface
+	upstreamClient      dynamic.Interface
+	upstreamClusterName logicalcluster.LogicalCluster
+	pclusterID          string
+
+	// transform is applied to the objects exposed in kcp, if not nil.
+	transform upsyncTransformFunc
+	// statusSubresource is whether the status of the objects is written through the status subresource.
+	statusSubresource bool
+
+	downstreamInformers          dynamicinformer.DynamicSharedInformerFactory
+	downstreamNamespaceInformers dynamicinformer.DynamicSharedInformerFactory
+	upstreamInformers            dynamicinformer.DynamicSharedInformerFactory
+}
+
+func newUpsyncer(gvr schema.GroupVersionResource, kcpClusterName logicalcluster.LogicalCluster, pclusterID string, fromClient, toClient dynamic.Interface) *Upsyncer {
+	name := gvr.Resource + "-up--" + kcpClusterName.String() + "--" + pclusterID
+	u := &Upsyncer{
+		name:                name,
+		gvr:                 gvr,
+		queue:               workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kcp-"+name),
+		upstreamClient:      toClient,
+		upstreamClusterName: kcpClusterName,
+		pclusterID:          pclusterID,
+
+		// the objects are created by the physical cluster, and do not carry the cluster label of their namespace
+		downstreamInformers: dynamicinformer.NewDynamicSharedInformerFactory(fromClient, resyncPeriod),
+		downstreamNamespaceInformers: dynamicinformer.NewFilteredDynamicSharedInformerFactory(fromClient, resyncPeriod, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+			o.LabelSelector = fmt.Sprintf("%s=%s", nscontroller.ClusterLabel, pclusterID)
+		}),
+		upstreamInformers: dynamicinformer.NewFilteredDynamicSharedInformerFactory(toClient, resyncPeriod, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+			o.LabelSelector = fmt.Sprintf("%s=%s", workloadv1alpha1.UpsyncedFromLabel, pclusterID)
+		}),
+	}
+
+	u.downstreamNamespaceInformers.ForResource(namespacesGVR).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: u.enqueueDownstreamNamespace,
+	})
+	u.downstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    u.enqueueDownstream,
+		UpdateFunc: func(_, newObj interface{}) { u.enqueueDownstream(newObj) },
+		DeleteFunc: u.enqueueDownstream,
+	})
+	u.upstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    u.enqueueUpstream,
+		UpdateFunc: func(_, newObj interface{}) { u.enqueueUpstream(newObj) },
+		DeleteFunc: u.enqueueUpstream,
+	})
+	return u
+}
+
+// upstreamNamespace returns the namespace in kcp of the namespace of the physical cluster, or an
+// empty string if the namespace does not belong to the workspace.
+func (u *Upsyncer) upstreamNamespace(clusterName logicalcluster.LogicalCluster, downstreamNamespace string) string {
+	nsKey := downstreamNamespace
+	if !clusterName.Empty() {
+		nsKey = clusters.ToClusterAwareKey(clusterName, nsKey)
+	}
+	obj, exists, err := u.downstreamNamespaceInformers.ForResource(namespacesGVR).Informer().GetIndexer().GetByKey(nsKey)
+	if err != nil || !exists {
+		return ""
+	}
+	ns, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	var l NamespaceLocator
+	if err := json.Unmarshal([]byte(ns.GetAnnotations()[namespaceLocatorAnnotation]), &l); err != nil {
+		return ""
+	}
+	if l.LogicalCluster != u.upstreamClusterName {
+		return ""
+	}
+	return l.Namespace
+}
+
+func (u *Upsyncer) enqueueDownstream(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	// objects are enqueued again when their namespace shows up
+	if namespace := u.upstreamNamespace(logicalcluster.From(metaObj), metaObj.GetNamespace()); namespace != "" {
+		u.queue.Add(upsyncedObject{namespace: namespace, name: metaObj.GetName()})
+	}
+}
+
+func (u *Upsyncer) enqueueDownstreamNamespace(obj interface{}) {
+	ns, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	objs, err := u.downstreamInformers.ForResource(u.gvr).Informer().GetIndexer().ByIndex(cache.NamespaceIndex, ns.GetName())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, obj := range objs {
+		u.enqueueDownstream(obj)
+	}
+}
+
+func (u *Upsyncer) enqueueUpstream(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	u.queue.Add(upsyncedObject{namespace: metaObj.GetNamespace(), name: metaObj.GetName()})
+}
+
+// Start starts the informers and the workers, and blocks until the context is done.
+func (u *Upsyncer) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer u.queue.ShutDown()
+
+	u.downstreamNamespaceInformers.Start(ctx.Done())
+	u.downstreamInformers.Start(ctx.Done())
+	u.upstreamInformers.Start(ctx.Done())
+	u.downstreamNamespaceInformers.WaitForCacheSync(ctx.Done())
+	u.downstreamInformers.WaitForCacheSync(ctx.Done())
+	u.upstreamInformers.WaitForCacheSync(ctx.Done())
+
+	klog.InfoS("Starting syncer workers", "controller", u.name)
+	defer klog.InfoS("Stopping syncer workers", "controller", u.name)
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, u.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (u *Upsyncer) startWorker(ctx context.Context) {
+	for u.processNextWorkItem(ctx) {
+	}
+}
+
+func (u *Upsyncer) processNextWorkItem(ctx context.Context) bool {
+	key, quit := u.queue.Get()
+	if quit {
+		return false
+	}
+	obj := key.(upsyncedObject)
+	defer u.queue.Done(key)
+
+	if err := u.process(ctx, obj); err != nil {
+		runtime.HandleError(fmt.Errorf("syncer %q failed to upsync %s %s/%s, err: %w", u.name, u.gvr.Resource, obj.namespace, obj.name, err))
+		u.queue.AddRateLimited(key)
+		return true
+	}
+	u.queue.Forget(key)
+	return true
+}
+
+// downstreamObject returns the object on the physical cluster for the object in kcp, or nil if it does not exist.
+func (u *Upsyncer) downstreamObject(obj upsyncedObject) (*unstructured.Unstructured, error) {
+	downstreamNamespace, err := PhysicalClusterNamespaceName(NamespaceLocator{LogicalCluster: u.upstreamClusterName, Namespace: obj.namespace})
+	if err != nil {
+		return nil, err
+	}
+	objs, err := u.downstreamInformers.ForResource(u.gvr).Informer().GetIndexer().ByIndex(cache.NamespaceIndex, downstreamNamespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objs {
+		if downstreamObj, ok := o.(*unstructured.Unstructured); ok && downstreamObj.GetName() == obj.name {
+			return downstreamObj, nil
+		}
+	}
+	return nil, nil
+}
+
+func (u *Upsyncer) process(ctx context.Context, obj upsyncedObject) error {
+	downstreamObj, err := u.downstreamObject(obj)
+	if err != nil {
+		return err
+	}
+
+	key := informerKey(holder{clusterName: u.upstreamClusterName, namespace: obj.namespace, name: obj.name})
+	o, exists, err := u.upstreamInformers.ForResource(u.gvr).Informer().GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	var existing *unstructured.Unstructured
+	if exists {
+		existing = o.(*unstructured.Unstructured)
+	}
+
+	client := u.upstreamClient.Resource(u.gvr).Namespace(obj.namespace)
+	if downstreamObj == nil {
+		if existing == nil {
+			return nil
+		}
+		klog.Infof("Deleting upsynced %s %s|%s/%s", u.gvr.Resource, u.upstreamClusterName, obj.namespace, obj.name)
+		uid := existing.GetUID()
+		err := client.Delete(ctx, obj.name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+		if k8serrors.IsNotFound(err) || k8serrors.IsConflict(err) {
+			return nil
+		}
+		return err
+	}
+
+	upstreamObj := u.upstreamObject(downstreamObj, obj.namespace)
+	if u.transform != nil {
+		if err := u.transform(ctx, upstreamObj); err != nil {
+			return err
+		}
+	}
+	var status interface{}
+	if u.statusSubresource {
+		status = upstreamObj.Object["status"]
+		delete(upstreamObj.Object, "status")
+	}
+
+	if existing == nil {
+		klog.Infof("Creating upsynced %s %s|%s/%s", u.gvr.Resource, u.upstreamClusterName, obj.namespace, obj.name)
+		if existing, err = client.Create(ctx, upstreamObj, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+	} else if !upsyncedObjectEqual(existing, upstreamObj, u.statusSubresource) {
+		// keep the cluster label set by kcp on the objects of scheduled namespaces
+		if clusterLabel, found := existing.GetLabels()[nscontroller.ClusterLabel]; found {
+			labels := upstreamObj.GetLabels()
+			labels[nscontroller.ClusterLabel] = clusterLabel
+			upstreamObj.SetLabels(labels)
+		}
+		upstreamObj.SetResourceVersion(existing.GetResourceVersion())
+		klog.V(2).Infof("Updating upsynced %s %s|%s/%s", u.gvr.Resource, u.upstreamClusterName, obj.namespace, obj.name)
+		if existing, err = client.Update(ctx, upstreamObj, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	if !u.statusSubresource || equality.Semantic.DeepEqual(existing.Object["status"], status) {
+		return nil
+	}
+	existing = existing.DeepCopy()
+	existing.Object["status"] = status
+	_, err = client.UpdateStatus(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+// upstreamObject returns the object to expose in kcp for the object of the physical cluster. Of
+// the metadata, only the labels and annotations are kept. The owner references are dropped as they
+// point to objects of the physical cluster, and would get the object garbage collected in kcp.
+func (u *Upsyncer) upstreamObject(downstreamObj *unstructured.Unstructured, upstreamNamespace string) *unstructured.Unstructured {
+	upstreamObj := downstreamObj.DeepCopy()
+	delete(upstreamObj.Object, "metadata")
+	upstreamObj.SetNamespace(upstreamNamespace)
+	upstreamObj.SetName(downstreamObj.GetName())
+	labels := map[string]string{}
+	for k, v := range downstreamObj.GetLabels() {
+		labels[k] = v
+	}
+	delete(labels, nscontroller.ClusterLabel)
+	labels[workloadv1alpha1.UpsyncedFromLabel] = u.pclusterID
+	upstreamObj.SetLabels(labels)
+	if annotations := downstreamObj.GetAnnotations(); len(annotations) > 0 {
+		upstreamObj.SetAnnotations(annotations)
+	}
+	return upstreamObj
+}
+
+// upsyncedObjectEqual returns whether the existing upsynced object matches the desired one, ignoring
+// the cluster label set by kcp, and the status if it is written through the status subresource.
+func upsyncedObjectEqual(existing, desired *unstructured.Unstructured, statusSubresource bool) bool {
+	labels := map[string]string{}
+	for k, v := range existing.GetLabels() {
+		labels[k] = v
+	}
+	delete(labels, nscontroller.ClusterLabel)
+	if !equality.Semantic.DeepEqual(labels, desired.GetLabels()) ||
+		!equality.Semantic.DeepEqual(existing.GetAnnotations(), desired.GetAnnotations()) ||
+		len(existing.GetOwnerReferences()) != 0 ||
+		len(existing.GetFinalizers()) != 0 {
+		return false
+	}
+	content := func(obj *unstructured.Unstructured) map[string]interface{} {
+		c := map[string]interface{}{}
+		for k, v := range obj.Object {
+			if k != "metadata" && (k != "status" || !statusSubresource) {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	return equality.Semantic.DeepEqual(content(existing), content(desired))
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package builder
+
+import (
+	"context"
+	"strings"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	eventsv1 "k8s.io/api/events/v1"
+	"k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/kubernetes"
+	generatedopenapi "k8s.io/kubernetes/pkg/generated/openapi"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/virtual/events/registry"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework"
+	"github.com/kcp-dev/kcp/pkg/virtual/framework/fixedgvs"
+)
+
+const EventsVirtualWorkspaceName string = "events"
+
+// BuildVirtualWorkspace builds a virtual workspace serving the events of a workspace and of all
+// workspaces below it, under <rootPathPrefix>/<logical cluster of the workspace>.
+func BuildVirtualWorkspace(rootPathPrefix string, kubeClusterClient kubernetes.ClusterInterface) framework.VirtualWorkspace {
+	if !strings.HasSuffix(rootPathPrefix, "/") {
+		rootPathPrefix += "/"
+	}
+
+	return &fixedgvs.FixedGroupVersionsVirtualWorkspace{
+		Name: EventsVirtualWorkspaceName,
+		Ready: func() error {
+			return nil
+		},
+		RootPathResolver: func(urlPath string, requestContext context.Context) (accepted bool, prefixToStrip string, completedContext context.Context) {
+			completedContext = requestContext
+			if path := urlPath; strings.HasPrefix(path, rootPathPrefix) {
+				path = strings.TrimPrefix(path, rootPathPrefix)
+				workspace := strings.SplitN(path, "/", 2)[0]
+				if !registry.InWorkspaceTree(tenancyv1alpha1.RootCluster, logicalcluster.New(workspace)) {
+					return
+				}
+
+				return true, rootPathPrefix + workspace,
+					context.WithValue(requestContext, registry.WorkspaceKey, logicalcluster.New(workspace))
+			}
+			return
+		},
+		GroupVersionAPISets: []fixedgvs.GroupVersionAPISet{
+			{
+				GroupVersion:       eventsv1.SchemeGroupVersion,
+				AddToScheme:        eventsv1.AddToScheme,
+				OpenAPIDefinitions: generatedopenapi.GetOpenAPIDefinitions,
+				BootstrapRestResources: func(mainConfig genericapiserver.CompletedConfig) (map[string]fixedgvs.RestStorageBuilder, error) {
+					eventsRest := registry.NewREST(kubeClusterClient)
+					return map[string]fixedgvs.RestStorageBuilder{
+						"events": func(apiGroupAPIServerConfig genericapiserver.CompletedConfig) (rest.Storage, error) {
+							return eventsRest, nil
+						},
+					}, nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	kprinters "k8s.io/kubernetes/pkg/printers"
+)
+
+func AddEventPrintHandlers(h kprinters.PrintHandler) {
+	eventColumnDefinitions := []metav1.TableColumnDefinition{
+		{
+			Name:        "Workspace",
+			Type:        "string",
+			Description: "Workspace the event was recorded in",
+			Priority:    0,
+		},
+		{
+			Name:        "Last Seen",
+			Type:        "string",
+			Description: "Time the event was last observed",
+			Priority:    0,
+		},
+		{
+			Name:        "Type",
+			Type:        "string",
+			Description: eventsv1.Event{}.SwaggerDoc()["type"],
+			Priority:    0,
+		},
+		{
+			Name:        "Reason",
+			Type:        "string",
+			Description: eventsv1.Event{}.SwaggerDoc()["reason"],
+			Priority:    0,
+		},
+		{
+			Name:        "Object",
+			Type:        "string",
+			Description: eventsv1.Event{}.SwaggerDoc()["regarding"],
+			Priority:    0,
+		},
+		{
+			Name:        "Message",
+			Type:        "string",
+			Description: eventsv1.Event{}.SwaggerDoc()["note"],
+			Priority:    0,
+		},
+		{
+			Name:        "Name",
+			Type:        "string",
+			Format:      "name",
+			Description: metav1.ObjectMeta{}.SwaggerDoc()["name"],
+			Priority:    1,
+		},
+	}
+
+	if err := h.TableHandler(eventColumnDefinitions, printEventList); err != nil {
+		panic(err)
+	}
+	if err := h.TableHandler(eventColumnDefinitions, printEvent); err != nil {
+		panic(err)
+	}
+}
+
+func printEvent(event *eventsv1.Event, options kprinters.GenerateOptions) ([]metav1.TableRow, error) {
+	row := metav1.TableRow{
+		Object: runtime.RawExtension{Object: event},
+	}
+
+	object := strings.ToLower(event.Regarding.Kind)
+	if event.Regarding.Name != "" {
+		object = fmt.Sprintf("%s/%s", object, event.Regarding.Name)
+	}
+
+	row.Cells = append(row.Cells, logicalcluster.From(event).String(), lastSeen(event), event.Type, event.Reason, object, strings.TrimSpace(event.Note), event.Name)
+
+	return []metav1.TableRow{row}, nil
+}
+
+func printEventList(list *eventsv1.EventList, options kprinters.GenerateOptions) ([]metav1.TableRow, error) {
+	rows := make([]metav1.TableRow, 0, len(list.Items))
+	for i := range list.Items {
+		r, err := printEvent(&list.Items[i], options)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, r...)
+	}
+	return rows, nil
+}
+
+// lastSeen returns the time since the event was last observed, falling back to
+// the deprecated timestamps of core/v1 events and to the creation time.
+func lastSeen(event *eventsv1.Event) string {
+	var t time.Time
+	switch {
+	case event.Series != nil:
+		t = event.Series.LastObservedTime.Time
+	case !event.DeprecatedLastTimestamp.IsZero():
+		t = event.DeprecatedLastTimestamp.Time
+	case !event.EventTime.IsZero():
+		t = event.EventTime.Time
+	default:
+		t = event.CreationTimestamp.Time
+	}
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t))
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kprinters "k8s.io/kubernetes/pkg/printers"
+)
+
+func TestPrintEvent(t *testing.T) {
+	event := &eventsv1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			ClusterName: "root:org:team",
+			Namespace:   "default",
+			Name:        "web.16f5",
+		},
+		EventTime: metav1.NewMicroTime(time.Now().Add(-5 * time.Minute)),
+		Type:      corev1.EventTypeWarning,
+		Reason:    "BackOff",
+		Regarding: corev1.ObjectReference{Kind: "Pod", Name: "web"},
+		Note:      "Back-off restarting failed container\n",
+	}
+
+	rows, err := printEvent(event, kprinters.GenerateOptions{})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, []interface{}{"root:org:team", "5m", "Warning", "BackOff", "pod/web", "Back-off restarting failed container", "web.16f5"}, rows[0].Cells)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package registry
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+const (
+	// maxAuthorizers is the number of logical clusters whose authorizers are kept.
+	maxAuthorizers = 1000
+	// authorizerTTL is how long the authorizer of a logical cluster is kept.
+	authorizerTTL = 10 * time.Minute
+)
+
+// authorizerCache keeps one authorizer per logical cluster, such that the decisions
+// cached by the delegated authorizers are reused across requests. It is bounded to
+// the most recently used logical clusters, and authorizers expire after a while.
+type authorizerCache struct {
+	authorizers *utilcache.LRUExpireCache
+	newFunc     func(clusterName logicalcluster.LogicalCluster) (authorizer.Authorizer, error)
+}
+
+func newAuthorizerCache(newFunc func(clusterName logicalcluster.LogicalCluster) (authorizer.Authorizer, error)) *authorizerCache {
+	return &authorizerCache{
+		authorizers: utilcache.NewLRUExpireCache(maxAuthorizers),
+		newFunc:     newFunc,
+	}
+}
+
+func (c *authorizerCache) get(clusterName logicalcluster.LogicalCluster) (authorizer.Authorizer, error) {
+	if authz, found := c.authorizers.Get(clusterName); found {
+		return authz.(authorizer.Authorizer), nil
+	}
+	authz, err := c.newFunc(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	c.authorizers.Add(clusterName, authz, authorizerTTL)
+	return authz, nil
+}
+
+// decisionScope is what an authorization decision for events depends on within one list or watch
+// request, whose user and verb are fixed.
+type decisionScope struct {
+	clusterName logicalcluster.LogicalCluster
+	namespace   string
+}
+
+type cachedDecision struct {
+	allowed bool
+	expires time.Time
+}
+
+// decisionCache caches the authorization decisions of one list or watch request per logical
+// cluster and namespace, such that a watch doesn't evaluate authorization for every event. Decisions
+// expire after ttl, for revoked permissions to apply to long-running watches.
+type decisionCache struct {
+	lock      sync.Mutex
+	decisions map[decisionScope]cachedDecision
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+func newDecisionCache(ttl time.Duration, now func() time.Time) *decisionCache {
+	return &decisionCache{
+		decisions: map[decisionScope]cachedDecision{},
+		ttl:       ttl,
+		now:       now,
+	}
+}
+
+// allowed returns the cached decision for the scope, or computes and caches it with decide. Errors
+// are not cached.
+func (c *decisionCache) allowed(scope decisionScope, decide func() (bool, error)) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	if d, found := c.decisions[scope]; found && now.Before(d.expires) {
+		authorizationCacheLookups.WithLabelValues(cacheHit).Inc()
+		return d.allowed, nil
+	}
+	authorizationCacheLookups.WithLabelValues(cacheMiss).Inc()
+
+	allowed, err := decide()
+	if err != nil {
+		delete(c.decisions, scope)
+		return false, err
+	}
+	c.decisions[scope] = cachedDecision{allowed: allowed, expires: now.Add(c.ttl)}
+	return allowed, nil
+}
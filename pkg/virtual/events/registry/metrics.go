@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package registry
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	cacheHit  = "hit"
+	cacheMiss = "miss"
+)
+
+var (
+	// authorizationCacheLookups counts the lookups of authorization decisions per workspace and
+	// namespace of the events served. The hit rate is hit / (hit + miss).
+	authorizationCacheLookups = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      "kcp",
+			Subsystem:      "events_virtual_workspace",
+			Name:           "authorization_cache_lookups_total",
+			Help:           "Number of lookups of cached authorization decisions for events, by result (hit or miss).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the metrics of the events virtual workspace.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(authorizationCacheLookups)
+	})
+}
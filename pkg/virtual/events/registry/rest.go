@@ -0,0 +1,229 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+
+	eventsv1 "k8s.io/api/events/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/printers"
+	printerstorage "k8s.io/kubernetes/pkg/printers/storage"
+
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+	eventprinters "github.com/kcp-dev/kcp/pkg/virtual/events/printers"
+)
+
+type EventsWorkspaceKeyType string
+
+// WorkspaceKey is the context key of the logical cluster whose workspace tree the events are served for.
+const WorkspaceKey EventsWorkspaceKeyType = "VirtualWorkspaceEventsWorkspace"
+
+// decisionTTL is how long the authorization decisions of a list or watch are reused. It matches
+// the deny TTL of the delegated authorizers, such that revoked permissions apply as quickly.
+const decisionTTL = 30 * time.Second
+
+// REST is a read-only RESTStorage serving the events of a workspace and of all the workspaces
+// below it, as one merged list and watch. Events are only served to users that are authorized
+// to list or watch them in the workspace and namespace they belong to.
+type REST struct {
+	listEvents  func(ctx context.Context, namespace string, options metav1.ListOptions) (*eventsv1.EventList, error)
+	watchEvents func(ctx context.Context, namespace string, options metav1.ListOptions) (watch.Interface, error)
+
+	// authorizerFor returns a cluster-aware authorizer doing SubjectAccessReviews in the given logical cluster
+	authorizerFor func(clusterName logicalcluster.LogicalCluster) (authorizer.Authorizer, error)
+
+	now func() time.Time
+
+	rest.TableConvertor
+}
+
+var _ rest.Lister = &REST{}
+var _ rest.Watcher = &REST{}
+var _ rest.Scoper = &REST{}
+
+// NewREST returns a RESTStorage object that lists and watches events across logical clusters.
+func NewREST(kubeClusterClient kubernetes.ClusterInterface) *REST {
+	wildcardEvents := kubeClusterClient.Cluster(logicalcluster.Wildcard).EventsV1()
+	RegisterMetrics()
+
+	authorizers := newAuthorizerCache(func(clusterName logicalcluster.LogicalCluster) (authorizer.Authorizer, error) {
+		return delegated.NewDelegatedAuthorizer(clusterName, kubeClusterClient)
+	})
+
+	return &REST{
+		listEvents: func(ctx context.Context, namespace string, options metav1.ListOptions) (*eventsv1.EventList, error) {
+			return wildcardEvents.Events(namespace).List(ctx, options)
+		},
+		watchEvents: func(ctx context.Context, namespace string, options metav1.ListOptions) (watch.Interface, error) {
+			return wildcardEvents.Events(namespace).Watch(ctx, options)
+		},
+		authorizerFor: authorizers.get,
+		now:           time.Now,
+
+		TableConvertor: printerstorage.TableConvertor{TableGenerator: printers.NewTableGenerator().With(eventprinters.AddEventPrintHandlers)},
+	}
+}
+
+// New returns a new Event
+func (s *REST) New() runtime.Object {
+	return &eventsv1.Event{}
+}
+
+// NewList returns a new EventList
+func (*REST) NewList() runtime.Object {
+	return &eventsv1.EventList{}
+}
+
+func (s *REST) NamespaceScoped() bool {
+	return true
+}
+
+// List retrieves the events of the workspace tree the user is authorized to list.
+func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (runtime.Object, error) {
+	userInfo, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(eventsv1.Resource("events"), "", fmt.Errorf("unable to list events without a user on the context"))
+	}
+	workspace := ctx.Value(WorkspaceKey).(logicalcluster.LogicalCluster)
+	namespace, _ := apirequest.NamespaceFrom(ctx)
+
+	v1Options := metav1.ListOptions{}
+	if err := metainternal.Convert_internalversion_ListOptions_To_v1_ListOptions(options, &v1Options, nil); err != nil {
+		return nil, err
+	}
+	// Pages of the upstream list are filtered, hence further pages are listed until the limit
+	// is reached. The continue token is the one of the last upstream page, and the number of
+	// remaining items is unknown.
+	visible := s.eventFilter(ctx, userInfo, workspace, "list")
+	filtered := &eventsv1.EventList{}
+	limit := v1Options.Limit
+	for {
+		list, err := s.listEvents(ctx, namespace, v1Options)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			if visible(&list.Items[i]) {
+				filtered.Items = append(filtered.Items, list.Items[i])
+			}
+		}
+		filtered.ResourceVersion = list.ResourceVersion
+		filtered.Continue = list.Continue
+
+		if limit == 0 || list.Continue == "" || int64(len(filtered.Items)) >= limit {
+			return filtered, nil
+		}
+		v1Options.Limit = limit - int64(len(filtered.Items))
+		v1Options.Continue = list.Continue
+		v1Options.ResourceVersion = ""
+		v1Options.ResourceVersionMatch = ""
+	}
+}
+
+// Watch watches the events of the workspace tree the user is authorized to watch.
+func (s *REST) Watch(ctx context.Context, options *metainternal.ListOptions) (watch.Interface, error) {
+	userInfo, ok := apirequest.UserFrom(ctx)
+	if !ok {
+		return nil, kerrors.NewForbidden(eventsv1.Resource("events"), "", fmt.Errorf("unable to watch events without a user on the context"))
+	}
+	workspace := ctx.Value(WorkspaceKey).(logicalcluster.LogicalCluster)
+	namespace, _ := apirequest.NamespaceFrom(ctx)
+
+	v1Options := metav1.ListOptions{}
+	if err := metainternal.Convert_internalversion_ListOptions_To_v1_ListOptions(options, &v1Options, nil); err != nil {
+		return nil, err
+	}
+	w, err := s.watchEvents(ctx, namespace, v1Options)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := s.eventFilter(ctx, userInfo, workspace, "watch")
+	return watch.Filter(w, func(in watch.Event) (watch.Event, bool) {
+		if in.Type == watch.Bookmark || in.Type == watch.Error {
+			return in, true
+		}
+		event, ok := in.Object.(*eventsv1.Event)
+		if !ok {
+			return in, false
+		}
+		return in, visible(event)
+	}), nil
+}
+
+// eventFilter returns a func deciding whether an event is visible to the user, i.e. whether it belongs
+// to the workspace tree, and the user is authorized for verb on events in its logical cluster and namespace.
+// Decisions are cached per logical cluster and namespace for the lifetime of the filter.
+func (s *REST) eventFilter(ctx context.Context, userInfo user.Info, workspace logicalcluster.LogicalCluster, verb string) func(event *eventsv1.Event) bool {
+	now := s.now
+	if now == nil {
+		now = time.Now
+	}
+	decisions := newDecisionCache(decisionTTL, now)
+
+	return func(event *eventsv1.Event) bool {
+		clusterName := logicalcluster.From(event)
+		if !InWorkspaceTree(workspace, clusterName) {
+			return false
+		}
+
+		allowed, err := decisions.allowed(decisionScope{clusterName: clusterName, namespace: event.Namespace}, func() (bool, error) {
+			authz, err := s.authorizerFor(clusterName)
+			if err != nil {
+				return false, fmt.Errorf("failed to get authorizer for logical cluster %s: %w", clusterName, err)
+			}
+			decision, _, err := authz.Authorize(ctx, authorizer.AttributesRecord{
+				User:            userInfo,
+				Verb:            verb,
+				APIGroup:        eventsv1.SchemeGroupVersion.Group,
+				APIVersion:      eventsv1.SchemeGroupVersion.Version,
+				Resource:        "events",
+				Namespace:       event.Namespace,
+				ResourceRequest: true,
+			})
+			if err != nil {
+				return false, err
+			}
+			return decision == authorizer.DecisionAllow, nil
+		})
+		if err != nil {
+			klog.Errorf("failed to authorize user %q to %s events in %s|%s: %v", userInfo.GetName(), verb, clusterName, event.Namespace, err)
+			return false
+		}
+		return allowed
+	}
+}
+
+// InWorkspaceTree returns true if the logical cluster is the workspace or one of the workspaces below it.
+func InWorkspaceTree(workspace, clusterName logicalcluster.LogicalCluster) bool {
+	return clusterName == workspace || strings.HasPrefix(clusterName.String(), workspace.String()+":")
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	eventsv1 "k8s.io/api/events/v1"
+	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/component-base/metrics/testutil"
+	"k8s.io/utils/pointer"
+)
+
+func newEvent(clusterName, namespace, name string) eventsv1.Event {
+	return eventsv1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			ClusterName: clusterName,
+			Namespace:   namespace,
+			Name:        name,
+		},
+	}
+}
+
+// allowedIn returns authorizers allowing the given verb on events in the given logical cluster and namespace pairs.
+func allowedIn(verb string, allowed ...string) func(clusterName logicalcluster.LogicalCluster) (authorizer.Authorizer, error) {
+	return func(clusterName logicalcluster.LogicalCluster) (authorizer.Authorizer, error) {
+		return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+			for _, s := range allowed {
+				if s == clusterName.String()+"/"+a.GetNamespace() && a.GetVerb() == verb && a.GetResource() == "events" {
+					return authorizer.DecisionAllow, "", nil
+				}
+			}
+			return authorizer.DecisionNoOpinion, "", nil
+		}), nil
+	}
+}
+
+func eventNames(events []eventsv1.Event) []string {
+	names := []string{}
+	for _, e := range events {
+		names = append(names, e.ClusterName+"/"+e.Namespace+"/"+e.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestList(t *testing.T) {
+	events := []eventsv1.Event{
+		newEvent("root:org", "default", "a"),
+		newEvent("root:org", "kube-system", "b"),
+		newEvent("root:org:team", "default", "c"),
+		newEvent("root:org:team:sub", "default", "d"),
+		newEvent("root:org2", "default", "e"),
+		newEvent("root", "default", "f"),
+	}
+
+	tests := map[string]struct {
+		workspace string
+		allowed   []string
+		want      []string
+	}{
+		"all events of the tree for an admin of every workspace": {
+			workspace: "root:org",
+			allowed:   []string{"root:org/default", "root:org/kube-system", "root:org:team/default", "root:org:team:sub/default", "root:org2/default", "root/default"},
+			want:      []string{"root:org/default/a", "root:org/kube-system/b", "root:org:team/default/c", "root:org:team:sub/default/d"},
+		},
+		"only authorized namespaces and workspaces": {
+			workspace: "root:org",
+			allowed:   []string{"root:org/default", "root:org:team:sub/default"},
+			want:      []string{"root:org/default/a", "root:org:team:sub/default/d"},
+		},
+		"sub-tree": {
+			workspace: "root:org:team",
+			allowed:   []string{"root:org/default", "root:org:team/default", "root:org:team:sub/default"},
+			want:      []string{"root:org:team/default/c", "root:org:team:sub/default/d"},
+		},
+		"nothing authorized": {
+			workspace: "root:org",
+			want:      []string{},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &REST{
+				listEvents: func(ctx context.Context, namespace string, options metav1.ListOptions) (*eventsv1.EventList, error) {
+					return &eventsv1.EventList{ListMeta: metav1.ListMeta{ResourceVersion: "42"}, Items: events}, nil
+				},
+				authorizerFor: allowedIn("list", tc.allowed...),
+			}
+			ctx := apirequest.WithUser(context.Background(), &user.DefaultInfo{Name: "org-admin"})
+			ctx = context.WithValue(ctx, WorkspaceKey, logicalcluster.New(tc.workspace))
+
+			obj, err := s.List(ctx, &metainternal.ListOptions{})
+			require.NoError(t, err)
+			list := obj.(*eventsv1.EventList)
+			require.Equal(t, "42", list.ResourceVersion)
+			require.Equal(t, tc.want, eventNames(list.Items))
+		})
+	}
+}
+
+func TestListPages(t *testing.T) {
+	pages := map[string]*eventsv1.EventList{
+		"": {
+			ListMeta: metav1.ListMeta{ResourceVersion: "42", Continue: "page-2", RemainingItemCount: pointer.Int64(3)},
+			Items:    []eventsv1.Event{newEvent("root:org", "default", "a"), newEvent("root:org2", "default", "b")},
+		},
+		"page-2": {
+			ListMeta: metav1.ListMeta{ResourceVersion: "42", Continue: "page-3", RemainingItemCount: pointer.Int64(2)},
+			Items:    []eventsv1.Event{newEvent("root:org2", "default", "c"), newEvent("root:org", "default", "d")},
+		},
+		"page-3": {
+			ListMeta: metav1.ListMeta{ResourceVersion: "42", Continue: "page-4", RemainingItemCount: pointer.Int64(1)},
+			Items:    []eventsv1.Event{newEvent("root:org", "default", "e")},
+		},
+		"page-4": {
+			ListMeta: metav1.ListMeta{ResourceVersion: "42"},
+			Items:    []eventsv1.Event{newEvent("root:org", "default", "f")},
+		},
+	}
+	var limits []int64
+	s := &REST{
+		listEvents: func(ctx context.Context, namespace string, options metav1.ListOptions) (*eventsv1.EventList, error) {
+			limits = append(limits, options.Limit)
+			page := pages[options.Continue]
+			if options.Limit < int64(len(page.Items)) {
+				// the fake pages are never split
+				t.Errorf("unexpected limit %d for page %q", options.Limit, options.Continue)
+			}
+			return page, nil
+		},
+		authorizerFor: allowedIn("list", "root:org/default"),
+	}
+	ctx := apirequest.WithUser(context.Background(), &user.DefaultInfo{Name: "org-admin"})
+	ctx = context.WithValue(ctx, WorkspaceKey, logicalcluster.New("root:org"))
+
+	obj, err := s.List(ctx, &metainternal.ListOptions{Limit: 3})
+	require.NoError(t, err)
+	list := obj.(*eventsv1.EventList)
+	require.Equal(t, []string{"root:org/default/a", "root:org/default/d", "root:org/default/e"}, eventNames(list.Items))
+	require.Equal(t, "page-4", list.Continue, "expected the continue token of the last listed page")
+	require.Nil(t, list.RemainingItemCount, "remaining items are unknown after filtering")
+	require.Equal(t, []int64{3, 2, 1}, limits, "expected further pages to be limited to the missing items")
+
+	limits = nil
+	obj, err = s.List(ctx, &metainternal.ListOptions{Limit: 3, Continue: "page-4"})
+	require.NoError(t, err)
+	list = obj.(*eventsv1.EventList)
+	require.Equal(t, []string{"root:org/default/f"}, eventNames(list.Items))
+	require.Empty(t, list.Continue)
+	require.Equal(t, []int64{3}, limits)
+}
+
+func TestAuthorizerCache(t *testing.T) {
+	created := 0
+	c := newAuthorizerCache(func(clusterName logicalcluster.LogicalCluster) (authorizer.Authorizer, error) {
+		created++
+		return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+			return authorizer.DecisionNoOpinion, "", nil
+		}), nil
+	})
+
+	for i := 0; i < maxAuthorizers+1; i++ {
+		_, err := c.get(logicalcluster.New(fmt.Sprintf("root:org-%d", i)))
+		require.NoError(t, err)
+	}
+	require.Equal(t, maxAuthorizers+1, created)
+	require.Equal(t, maxAuthorizers, len(c.authorizers.Keys()), "expected the cache to be bounded")
+
+	_, err := c.get(logicalcluster.New(fmt.Sprintf("root:org-%d", maxAuthorizers)))
+	require.NoError(t, err)
+	require.Equal(t, maxAuthorizers+1, created, "expected the authorizer to be reused")
+}
+
+func TestListWithoutUser(t *testing.T) {
+	s := &REST{}
+	ctx := context.WithValue(context.Background(), WorkspaceKey, logicalcluster.New("root:org"))
+	_, err := s.List(ctx, &metainternal.ListOptions{})
+	require.Error(t, err)
+}
+
+func TestWatch(t *testing.T) {
+	fakeWatcher := watch.NewFakeWithChanSize(10, false)
+	s := &REST{
+		watchEvents: func(ctx context.Context, namespace string, options metav1.ListOptions) (watch.Interface, error) {
+			return fakeWatcher, nil
+		},
+		authorizerFor: allowedIn("watch", "root:org/default", "root:org:team/default"),
+	}
+	ctx := apirequest.WithUser(context.Background(), &user.DefaultInfo{Name: "org-admin"})
+	ctx = context.WithValue(ctx, WorkspaceKey, logicalcluster.New("root:org"))
+
+	w, err := s.Watch(ctx, &metainternal.ListOptions{})
+	require.NoError(t, err)
+	defer w.Stop()
+
+	for _, e := range []eventsv1.Event{
+		newEvent("root:org", "kube-system", "hidden-namespace"),
+		newEvent("root:org2", "default", "other-tree"),
+		newEvent("root:org:team", "default", "visible"),
+	} {
+		e := e
+		fakeWatcher.Add(&e)
+	}
+	fakeWatcher.Action(watch.Bookmark, &eventsv1.Event{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "43"}})
+
+	got := <-w.ResultChan()
+	require.Equal(t, watch.Added, got.Type)
+	require.Equal(t, "visible", got.Object.(*eventsv1.Event).Name)
+
+	got = <-w.ResultChan()
+	require.Equal(t, watch.Bookmark, got.Type)
+}
+
+func TestWatchCachesDecisions(t *testing.T) {
+	RegisterMetrics()
+	hitsBefore, err := testutil.GetCounterMetricValue(authorizationCacheLookups.WithLabelValues(cacheHit))
+	require.NoError(t, err)
+
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	authorizations := 0
+	fakeWatcher := watch.NewFakeWithChanSize(10, false)
+	s := &REST{
+		watchEvents: func(ctx context.Context, namespace string, options metav1.ListOptions) (watch.Interface, error) {
+			return fakeWatcher, nil
+		},
+		authorizerFor: func(clusterName logicalcluster.LogicalCluster) (authorizer.Authorizer, error) {
+			return authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+				authorizations++
+				if a.GetNamespace() == "default" {
+					return authorizer.DecisionAllow, "", nil
+				}
+				return authorizer.DecisionNoOpinion, "", nil
+			}), nil
+		},
+		now: func() time.Time { return now },
+	}
+	ctx := apirequest.WithUser(context.Background(), &user.DefaultInfo{Name: "controller"})
+	ctx = context.WithValue(ctx, WorkspaceKey, logicalcluster.New("root:org"))
+
+	w, err := s.Watch(ctx, &metainternal.ListOptions{})
+	require.NoError(t, err)
+	defer w.Stop()
+
+	send := func(events ...eventsv1.Event) {
+		for _, e := range events {
+			e := e
+			fakeWatcher.Add(&e)
+		}
+		fakeWatcher.Action(watch.Bookmark, &eventsv1.Event{})
+		for got := range w.ResultChan() {
+			if got.Type == watch.Bookmark {
+				return
+			}
+		}
+	}
+
+	send(
+		newEvent("root:org", "default", "a"),
+		newEvent("root:org", "default", "b"),
+		newEvent("root:org", "kube-system", "c"),
+		newEvent("root:org", "kube-system", "d"),
+		newEvent("root:org:team", "default", "e"),
+	)
+	require.Equal(t, 3, authorizations, "one authorization per logical cluster and namespace")
+
+	hitsAfter, err := testutil.GetCounterMetricValue(authorizationCacheLookups.WithLabelValues(cacheHit))
+	require.NoError(t, err)
+	require.Equal(t, float64(2), hitsAfter-hitsBefore)
+
+	now = now.Add(decisionTTL)
+	send(newEvent("root:org", "default", "f"))
+	require.Equal(t, 4, authorizations, "expired decisions are evaluated again")
+}
+
+func TestInWorkspaceTree(t *testing.T) {
+	tests := []struct {
+		workspace   string
+		clusterName string
+		want        bool
+	}{
+		{"root:org", "root:org", true},
+		{"root:org", "root:org:team", true},
+		{"root:org", "root:org:team:sub", true},
+		{"root:org", "root:org2", false},
+		{"root:org", "root", false},
+		{"root", "root:org", true},
+	}
+	for _, tc := range tests {
+		require.Equal(t, tc.want, InWorkspaceTree(logicalcluster.New(tc.workspace), logicalcluster.New(tc.clusterName)), "%s in %s", tc.clusterName, tc.workspace)
+	}
+}
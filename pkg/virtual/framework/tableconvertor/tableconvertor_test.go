@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tableconvertor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func TestForAPIResourceSchema(t *testing.T) {
+	schema := &apisv1alpha1.APIResourceSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: "v1.cowboys.wildwest.dev"},
+		Spec: apisv1alpha1.APIResourceSchemaSpec{
+			Versions: []apisv1alpha1.APIResourceVersion{
+				{
+					Name: "v1alpha1",
+					AdditionalPrinterColumns: []apiextensionsv1.CustomResourceColumnDefinition{
+						{Name: "Intent", Type: "string", JSONPath: ".spec.intent"},
+					},
+				},
+				{
+					Name: "v1",
+				},
+			},
+		},
+	}
+
+	cowboy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "wildwest.dev/v1alpha1",
+		"kind":       "Cowboy",
+		"metadata":   map[string]interface{}{"name": "timothy"},
+		"spec":       map[string]interface{}{"intent": "yeehaw"},
+	}}
+
+	tests := []struct {
+		name        string
+		version     string
+		wantColumns []string
+		wantCells   []interface{}
+		wantErr     bool
+	}{
+		{
+			name:        "additional printer columns",
+			version:     "v1alpha1",
+			wantColumns: []string{"Name", "Intent"},
+			wantCells:   []interface{}{"timothy", "yeehaw"},
+		},
+		{
+			name:        "default columns",
+			version:     "v1",
+			wantColumns: []string{"Name", "Age"},
+			wantCells:   []interface{}{"timothy", nil},
+		},
+		{
+			name:    "unknown version",
+			version: "v2",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convertor, err := ForAPIResourceSchema(schema, tt.version)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			table, err := convertor.ConvertToTable(context.Background(), cowboy, nil)
+			require.NoError(t, err)
+
+			var columns []string
+			for _, c := range table.ColumnDefinitions {
+				columns = append(columns, c.Name)
+			}
+			require.Equal(t, tt.wantColumns, columns)
+			require.Len(t, table.Rows, 1)
+			require.Equal(t, tt.wantCells, table.Rows[0].Cells)
+		})
+	}
+}
@@ -56,6 +56,34 @@ func (i *filteredInterface) ClusterWorkspaceShards() tenancyinformers.ClusterWor
 	return FilterWorkspaceShardInformer(i.clusterName, i.informers.ClusterWorkspaceShards())
 }
 
+func (i *filteredInterface) ClusterWorkspaceQuotas() tenancyinformers.ClusterWorkspaceQuotaInformer {
+	return FilterClusterWorkspaceQuotaInformer(i.clusterName, i.informers.ClusterWorkspaceQuotas())
+}
+
+func (i *filteredInterface) AccessRequests() tenancyinformers.AccessRequestInformer {
+	return FilterAccessRequestInformer(i.clusterName, i.informers.AccessRequests())
+}
+
+func (i *filteredInterface) WorkspaceMigrations() tenancyinformers.WorkspaceMigrationInformer {
+	return FilterWorkspaceMigrationInformer(i.clusterName, i.informers.WorkspaceMigrations())
+}
+
+func (i *filteredInterface) ShardUpgradePlans() tenancyinformers.ShardUpgradePlanInformer {
+	return FilterShardUpgradePlanInformer(i.clusterName, i.informers.ShardUpgradePlans())
+}
+
+func (i *filteredInterface) NameReservations() tenancyinformers.NameReservationInformer {
+	return FilterNameReservationInformer(i.clusterName, i.informers.NameReservations())
+}
+
+func (i *filteredInterface) WorkspaceClones() tenancyinformers.WorkspaceCloneInformer {
+	return FilterWorkspaceCloneInformer(i.clusterName, i.informers.WorkspaceClones())
+}
+
+func (i *filteredInterface) WorkspaceRequestSets() tenancyinformers.WorkspaceRequestSetInformer {
+	return FilterWorkspaceRequestSetInformer(i.clusterName, i.informers.WorkspaceRequestSets())
+}
+
 func FilterClusterWorkspaceTypeInformer(clusterName logicalcluster.LogicalCluster, informer tenancyinformers.ClusterWorkspaceTypeInformer) tenancyinformers.ClusterWorkspaceTypeInformer {
 	return &filteredClusterWorkspaceTypeInformer{
 		clusterName: clusterName,
@@ -268,3 +296,500 @@ func (l *filteredWorkspaceShardLister) GetWithContext(ctx context.Context, name
 	}
 	return l.lister.GetWithContext(ctx, name)
 }
+
+func FilterClusterWorkspaceQuotaInformer(clusterName logicalcluster.LogicalCluster, informer tenancyinformers.ClusterWorkspaceQuotaInformer) tenancyinformers.ClusterWorkspaceQuotaInformer {
+	return &filteredClusterWorkspaceQuotaInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.ClusterWorkspaceQuotaInformer = (*filteredClusterWorkspaceQuotaInformer)(nil)
+var _ tenancylisters.ClusterWorkspaceQuotaLister = (*filteredClusterWorkspaceQuotaLister)(nil)
+
+type filteredClusterWorkspaceQuotaInformer struct {
+	clusterName logicalcluster.LogicalCluster
+	informer    tenancyinformers.ClusterWorkspaceQuotaInformer
+}
+
+type filteredClusterWorkspaceQuotaLister struct {
+	clusterName logicalcluster.LogicalCluster
+	lister      tenancylisters.ClusterWorkspaceQuotaLister
+}
+
+func (i *filteredClusterWorkspaceQuotaInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredClusterWorkspaceQuotaInformer) Lister() tenancylisters.ClusterWorkspaceQuotaLister {
+	return &filteredClusterWorkspaceQuotaLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredClusterWorkspaceQuotaLister) List(selector labels.Selector) (ret []*tenancyapis.ClusterWorkspaceQuota, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredClusterWorkspaceQuotaLister) Get(name string) (*tenancyapis.ClusterWorkspaceQuota, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func (l *filteredClusterWorkspaceQuotaLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyapis.ClusterWorkspaceQuota, err error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredClusterWorkspaceQuotaLister) GetWithContext(ctx context.Context, name string) (*tenancyapis.ClusterWorkspaceQuota, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.GetWithContext(ctx, name)
+}
+
+func FilterAccessRequestInformer(clusterName logicalcluster.LogicalCluster, informer tenancyinformers.AccessRequestInformer) tenancyinformers.AccessRequestInformer {
+	return &filteredAccessRequestInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.AccessRequestInformer = (*filteredAccessRequestInformer)(nil)
+var _ tenancylisters.AccessRequestLister = (*filteredAccessRequestLister)(nil)
+
+type filteredAccessRequestInformer struct {
+	clusterName logicalcluster.LogicalCluster
+	informer    tenancyinformers.AccessRequestInformer
+}
+
+type filteredAccessRequestLister struct {
+	clusterName logicalcluster.LogicalCluster
+	lister      tenancylisters.AccessRequestLister
+}
+
+func (i *filteredAccessRequestInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredAccessRequestInformer) Lister() tenancylisters.AccessRequestLister {
+	return &filteredAccessRequestLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredAccessRequestLister) List(selector labels.Selector) (ret []*tenancyapis.AccessRequest, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredAccessRequestLister) Get(name string) (*tenancyapis.AccessRequest, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func (l *filteredAccessRequestLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyapis.AccessRequest, err error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredAccessRequestLister) GetWithContext(ctx context.Context, name string) (*tenancyapis.AccessRequest, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.GetWithContext(ctx, name)
+}
+
+func FilterWorkspaceMigrationInformer(clusterName logicalcluster.LogicalCluster, informer tenancyinformers.WorkspaceMigrationInformer) tenancyinformers.WorkspaceMigrationInformer {
+	return &filteredWorkspaceMigrationInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.WorkspaceMigrationInformer = (*filteredWorkspaceMigrationInformer)(nil)
+var _ tenancylisters.WorkspaceMigrationLister = (*filteredWorkspaceMigrationLister)(nil)
+
+type filteredWorkspaceMigrationInformer struct {
+	clusterName logicalcluster.LogicalCluster
+	informer    tenancyinformers.WorkspaceMigrationInformer
+}
+
+type filteredWorkspaceMigrationLister struct {
+	clusterName logicalcluster.LogicalCluster
+	lister      tenancylisters.WorkspaceMigrationLister
+}
+
+func (i *filteredWorkspaceMigrationInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredWorkspaceMigrationInformer) Lister() tenancylisters.WorkspaceMigrationLister {
+	return &filteredWorkspaceMigrationLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredWorkspaceMigrationLister) List(selector labels.Selector) (ret []*tenancyapis.WorkspaceMigration, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceMigrationLister) Get(name string) (*tenancyapis.WorkspaceMigration, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func (l *filteredWorkspaceMigrationLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyapis.WorkspaceMigration, err error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceMigrationLister) GetWithContext(ctx context.Context, name string) (*tenancyapis.WorkspaceMigration, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.GetWithContext(ctx, name)
+}
+
+func FilterShardUpgradePlanInformer(clusterName logicalcluster.LogicalCluster, informer tenancyinformers.ShardUpgradePlanInformer) tenancyinformers.ShardUpgradePlanInformer {
+	return &filteredShardUpgradePlanInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.ShardUpgradePlanInformer = (*filteredShardUpgradePlanInformer)(nil)
+var _ tenancylisters.ShardUpgradePlanLister = (*filteredShardUpgradePlanLister)(nil)
+
+type filteredShardUpgradePlanInformer struct {
+	clusterName logicalcluster.LogicalCluster
+	informer    tenancyinformers.ShardUpgradePlanInformer
+}
+
+type filteredShardUpgradePlanLister struct {
+	clusterName logicalcluster.LogicalCluster
+	lister      tenancylisters.ShardUpgradePlanLister
+}
+
+func (i *filteredShardUpgradePlanInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredShardUpgradePlanInformer) Lister() tenancylisters.ShardUpgradePlanLister {
+	return &filteredShardUpgradePlanLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredShardUpgradePlanLister) List(selector labels.Selector) (ret []*tenancyapis.ShardUpgradePlan, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredShardUpgradePlanLister) Get(name string) (*tenancyapis.ShardUpgradePlan, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func (l *filteredShardUpgradePlanLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyapis.ShardUpgradePlan, err error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredShardUpgradePlanLister) GetWithContext(ctx context.Context, name string) (*tenancyapis.ShardUpgradePlan, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.GetWithContext(ctx, name)
+}
+
+func FilterNameReservationInformer(clusterName logicalcluster.LogicalCluster, informer tenancyinformers.NameReservationInformer) tenancyinformers.NameReservationInformer {
+	return &filteredNameReservationInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.NameReservationInformer = (*filteredNameReservationInformer)(nil)
+var _ tenancylisters.NameReservationLister = (*filteredNameReservationLister)(nil)
+
+type filteredNameReservationInformer struct {
+	clusterName logicalcluster.LogicalCluster
+	informer    tenancyinformers.NameReservationInformer
+}
+
+type filteredNameReservationLister struct {
+	clusterName logicalcluster.LogicalCluster
+	lister      tenancylisters.NameReservationLister
+}
+
+func (i *filteredNameReservationInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredNameReservationInformer) Lister() tenancylisters.NameReservationLister {
+	return &filteredNameReservationLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredNameReservationLister) List(selector labels.Selector) (ret []*tenancyapis.NameReservation, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredNameReservationLister) Get(name string) (*tenancyapis.NameReservation, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func (l *filteredNameReservationLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyapis.NameReservation, err error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredNameReservationLister) GetWithContext(ctx context.Context, name string) (*tenancyapis.NameReservation, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.GetWithContext(ctx, name)
+}
+
+func FilterWorkspaceRequestSetInformer(clusterName logicalcluster.LogicalCluster, informer tenancyinformers.WorkspaceRequestSetInformer) tenancyinformers.WorkspaceRequestSetInformer {
+	return &filteredWorkspaceRequestSetInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.WorkspaceRequestSetInformer = (*filteredWorkspaceRequestSetInformer)(nil)
+var _ tenancylisters.WorkspaceRequestSetLister = (*filteredWorkspaceRequestSetLister)(nil)
+
+type filteredWorkspaceRequestSetInformer struct {
+	clusterName logicalcluster.LogicalCluster
+	informer    tenancyinformers.WorkspaceRequestSetInformer
+}
+
+type filteredWorkspaceRequestSetLister struct {
+	clusterName logicalcluster.LogicalCluster
+	lister      tenancylisters.WorkspaceRequestSetLister
+}
+
+func (i *filteredWorkspaceRequestSetInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredWorkspaceRequestSetInformer) Lister() tenancylisters.WorkspaceRequestSetLister {
+	return &filteredWorkspaceRequestSetLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredWorkspaceRequestSetLister) List(selector labels.Selector) (ret []*tenancyapis.WorkspaceRequestSet, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceRequestSetLister) Get(name string) (*tenancyapis.WorkspaceRequestSet, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func (l *filteredWorkspaceRequestSetLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyapis.WorkspaceRequestSet, err error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceRequestSetLister) GetWithContext(ctx context.Context, name string) (*tenancyapis.WorkspaceRequestSet, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.GetWithContext(ctx, name)
+}
+
+func FilterWorkspaceCloneInformer(clusterName logicalcluster.LogicalCluster, informer tenancyinformers.WorkspaceCloneInformer) tenancyinformers.WorkspaceCloneInformer {
+	return &filteredWorkspaceCloneInformer{
+		clusterName: clusterName,
+		informer:    informer,
+	}
+}
+
+var _ tenancyinformers.WorkspaceCloneInformer = (*filteredWorkspaceCloneInformer)(nil)
+var _ tenancylisters.WorkspaceCloneLister = (*filteredWorkspaceCloneLister)(nil)
+
+type filteredWorkspaceCloneInformer struct {
+	clusterName logicalcluster.LogicalCluster
+	informer    tenancyinformers.WorkspaceCloneInformer
+}
+
+type filteredWorkspaceCloneLister struct {
+	clusterName logicalcluster.LogicalCluster
+	lister      tenancylisters.WorkspaceCloneLister
+}
+
+func (i *filteredWorkspaceCloneInformer) Informer() cache.SharedIndexInformer {
+	return i.informer.Informer()
+}
+
+func (i *filteredWorkspaceCloneInformer) Lister() tenancylisters.WorkspaceCloneLister {
+	return &filteredWorkspaceCloneLister{
+		clusterName: i.clusterName,
+		lister:      i.informer.Lister(),
+	}
+}
+
+func (l *filteredWorkspaceCloneLister) List(selector labels.Selector) (ret []*tenancyapis.WorkspaceClone, err error) {
+	items, err := l.lister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceCloneLister) Get(name string) (*tenancyapis.WorkspaceClone, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.Get(name)
+}
+
+func (l *filteredWorkspaceCloneLister) ListWithContext(ctx context.Context, selector labels.Selector) (ret []*tenancyapis.WorkspaceClone, err error) {
+	items, err := l.lister.ListWithContext(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if logicalcluster.From(item) == l.clusterName {
+			ret = append(ret, item)
+		}
+	}
+	return
+}
+
+func (l *filteredWorkspaceCloneLister) GetWithContext(ctx context.Context, name string) (*tenancyapis.WorkspaceClone, error) {
+	if clusterName, _ := clusters.SplitClusterAwareKey(name); clusterName.Empty() {
+		name = clusters.ToClusterAwareKey(l.clusterName, name)
+	}
+	return l.lister.GetWithContext(ctx, name)
+}
@@ -18,6 +18,8 @@ package authorization
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -186,6 +188,21 @@ type AuthorizationCache struct {
 
 	rwMutex sync.RWMutex
 
+	// accessChanged records during a synchronization whether workspaces were deleted, or
+	// access to existing workspaces was granted or revoked.
+	accessChanged bool
+
+	// versionLock protects the resource versions below. It is separate from rwMutex
+	// because watchers read them while being notified during a synchronization.
+	versionLock sync.RWMutex
+	// resourceVersion is the resource version of the workspaces the last synchronization
+	// started from. All changes up to it have been sent to the watchers.
+	resourceVersion string
+	// oldestResumableResourceVersion is the resource version of the last synchronization
+	// that changed access. Watches cannot be resumed from before, as the changes of access
+	// are not visible in the resource versions of the workspaces.
+	oldestResumableResourceVersion string
+
 	watcherLock sync.Mutex
 	watchers    []CacheWatcher
 }
@@ -275,6 +292,11 @@ func (ac *AuthorizationCache) synchronizeWorkspaces(userSubjectRecordStore cache
 		// should never happen
 		panic(err)
 	}
+	// watchers are notified in the order of the resource versions, such that a watch can be
+	// resumed from the resource version of the last event received.
+	sort.SliceStable(workspaces, func(i, j int) bool {
+		return OlderResourceVersion(workspaces[i].ResourceVersion, workspaces[j].ResourceVersion)
+	})
 	for i := range workspaces {
 		workspace := workspaces[i]
 		workspaceKey, err := cache.MetaNamespaceKeyFunc(workspace)
@@ -306,6 +328,7 @@ func (ac *AuthorizationCache) purgeDeletedWorkspaces(oldWorkspaces, newWorkspace
 	}
 
 	for workspace := range oldWorkspaces.Difference(newWorkspaces) {
+		ac.accessChanged = true
 		ac.notifyWatchers(workspace, nil, sets.String{}, sets.String{})
 	}
 }
@@ -357,6 +380,9 @@ func (ac *AuthorizationCache) synchronize() {
 		return
 	}
 
+	// read before listing the workspaces, such that all changes up to it are synchronized
+	resourceVersion := ac.lastSyncResourceVersioner.LastSyncResourceVersion()
+
 	// by default, we update our current caches and do an incremental change
 	userSubjectRecordStore := ac.userSubjectRecordStore
 	groupSubjectRecordStore := ac.groupSubjectRecordStore
@@ -364,6 +390,8 @@ func (ac *AuthorizationCache) synchronize() {
 
 	// if there was a global change that forced complete invalidation, we rebuild our cache and do a fast swap at end
 	invalidateCache := ac.invalidateCache()
+	// access might have changed anywhere on invalidation, and nothing is known before the first synchronization
+	ac.accessChanged = invalidateCache || len(ac.lastState) == 0
 	if invalidateCache {
 		userSubjectRecordStore = cache.NewStore(subjectRecordKeyFn)
 		groupSubjectRecordStore = cache.NewStore(subjectRecordKeyFn)
@@ -382,10 +410,41 @@ func (ac *AuthorizationCache) synchronize() {
 	}
 	ac.allKnownWorkspaces = newKnownWorkspaces
 
+	ac.versionLock.Lock()
+	ac.resourceVersion = resourceVersion
+	if ac.accessChanged {
+		ac.oldestResumableResourceVersion = resourceVersion
+	}
+	ac.versionLock.Unlock()
+
 	// we were able to update our cache since this last observation period
 	ac.lastState = currentState
 }
 
+// ResourceVersions returns the resource version of the workspaces the cache is synchronized
+// to, and the oldest resource version a watch can be resumed from.
+func (ac *AuthorizationCache) ResourceVersions() (current, oldestResumable string) {
+	ac.versionLock.RLock()
+	defer ac.versionLock.RUnlock()
+
+	return ac.resourceVersion, ac.oldestResumableResourceVersion
+}
+
+// OlderResourceVersion returns whether the resource version a is older than b. Resource
+// versions which are not numbers, e.g. empty ones, are older than all others.
+func OlderResourceVersion(a, b string) bool {
+	av, aErr := strconv.ParseUint(a, 10, 64)
+	bv, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr != nil:
+		return bErr == nil
+	case bErr != nil:
+		return false
+	default:
+		return av < bv
+	}
+}
+
 // syncRequest takes a reviewRequest and determines if it should update the caches supplied, it is not thread-safe
 func (ac *AuthorizationCache) syncRequest(request *reviewRequest, userSubjectRecordStore cache.Store, groupSubjectRecordStore cache.Store, reviewRecordStore cache.Store) error {
 	lastKnownValue, err := lastKnown(reviewRecordStore, request.workspace)
@@ -415,6 +474,10 @@ func (ac *AuthorizationCache) syncRequest(request *reviewRequest, userSubjectRec
 		usersToRemove.Delete(review.Users...)
 		groupsToRemove.Insert(lastKnownValue.groups...)
 		groupsToRemove.Delete(review.Groups...)
+
+		if !sets.NewString(lastKnownValue.users...).Equal(sets.NewString(review.Users...)) || !sets.NewString(lastKnownValue.groups...).Equal(sets.NewString(review.Groups...)) {
+			ac.accessChanged = true
+		}
 	}
 
 	deleteWorkspaceFromSubjects(userSubjectRecordStore, usersToRemove.List(), workspace)
@@ -444,6 +507,7 @@ func (ac *AuthorizationCache) ListAllWorkspaces(selector labels.Selector) (*work
 	}
 
 	workspaceList := &workspaceapi.ClusterWorkspaceList{}
+	workspaceList.ResourceVersion, _ = ac.ResourceVersions()
 	for _, key := range keys.List() {
 		workspace, err := ac.workspaceLister.Get(key)
 		if apierrors.IsNotFound(err) {
@@ -485,6 +549,7 @@ func (ac *AuthorizationCache) List(userInfo user.Info, labelSelector labels.Sele
 	}
 
 	workspaceList := &workspaceapi.ClusterWorkspaceList{}
+	workspaceList.ResourceVersion, _ = ac.ResourceVersions()
 	for _, key := range keys.List() {
 		workspace, err := ac.workspaceLister.Get(key)
 		if apierrors.IsNotFound(err) {
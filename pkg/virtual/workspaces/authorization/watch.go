@@ -18,10 +18,14 @@ package authorization
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -51,6 +55,26 @@ type WatchableCache interface {
 	List(userInfo user.Info, labelSelector labels.Selector, fieldSelector fields.Selector) (*workspaceapi.ClusterWorkspaceList, error)
 }
 
+// ResourceVersioner is implemented by caches that know the resource version they are
+// synchronized to. Watches on them send bookmarks, and can be resumed from a resource version.
+type ResourceVersioner interface {
+	// ResourceVersions returns the resource version of the workspaces the cache is
+	// synchronized to, and the oldest resource version a watch can be resumed from.
+	ResourceVersions() (current, oldestResumable string)
+}
+
+// WatchOptions are the options of a watch of workspaces.
+type WatchOptions struct {
+	// ResourceVersion is "0" to start with all existing workspaces, empty to start with the
+	// next change, or the resource version to resume the watch from.
+	ResourceVersion string
+	// AllowWatchBookmarks enables bookmarks with the resource version the cache is synchronized to.
+	AllowWatchBookmarks bool
+}
+
+// bookmarkPeriod is the time between bookmarks of a watch.
+var bookmarkPeriod = time.Minute
+
 // userWorkspaceWatcher converts notifications received from the WorkspaceAuthCache to
 // watch events sent through a watch.Interface.
 type userWorkspaceWatcher struct {
@@ -77,6 +101,9 @@ type userWorkspaceWatcher struct {
 
 	clusterWorkspaceCache *workspacecache.ClusterWorkspaceCache
 	authCache             WatchableCache
+	// versioner is nil if the cache does not know its resource versions.
+	versioner ResourceVersioner
+	bookmarks bool
 
 	initialClusterWorkspaces []workspaceapi.ClusterWorkspace
 	// knownWorkspaces maps name to resourceVersion
@@ -91,17 +118,34 @@ var (
 	watchChannelHWM kstorage.HighWaterMark
 )
 
-func NewUserWorkspaceWatcher(user user.Info, lclusterName logicalcluster.LogicalCluster, clusterWorkspaceCache *workspacecache.ClusterWorkspaceCache, authCache WatchableCache, includeAllExistingWorkspaces bool, predicate kstorage.SelectionPredicate) *userWorkspaceWatcher {
+// NewUserWorkspaceWatcher returns a watcher of the workspaces the user has access to. It fails with
+// a 410 Gone error if the watch cannot be resumed from the resource version of the options anymore.
+func NewUserWorkspaceWatcher(user user.Info, lclusterName logicalcluster.LogicalCluster, clusterWorkspaceCache *workspacecache.ClusterWorkspaceCache, authCache WatchableCache, options WatchOptions, predicate kstorage.SelectionPredicate) (*userWorkspaceWatcher, error) {
+	versioner, _ := authCache.(ResourceVersioner)
+	resume := versioner != nil && options.ResourceVersion != "" && options.ResourceVersion != "0"
+	if resume {
+		if _, err := strconv.ParseUint(options.ResourceVersion, 10, 64); err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid resource version %q", options.ResourceVersion))
+		}
+		// nothing is known before the first synchronization of the cache
+		if _, oldestResumable := versioner.ResourceVersions(); oldestResumable == "" || OlderResourceVersion(options.ResourceVersion, oldestResumable) {
+			return nil, apierrors.NewResourceExpired(fmt.Sprintf("too old resource version: %s (%s)", options.ResourceVersion, oldestResumable))
+		}
+	}
+
 	workspaces, _ := authCache.List(user, labels.Everything(), fields.Everything())
 	knownWorkspaces := map[string]string{}
 	for _, workspace := range workspaces.Items {
 		knownWorkspaces[workspace.Name] = workspace.ResourceVersion
 	}
 
-	// this is optional.  If they don't request it, don't include it.
+	// this is optional.  If they don't request it, don't include it. A resumed watch starts
+	// with the workspaces changed since the resource version.
 	initialWorkspaces := []workspaceapi.ClusterWorkspace{}
-	if includeAllExistingWorkspaces {
-		initialWorkspaces = append(initialWorkspaces, workspaces.Items...)
+	for _, workspace := range workspaces.Items {
+		if options.ResourceVersion == "0" || (resume && OlderResourceVersion(options.ResourceVersion, workspace.ResourceVersion)) {
+			initialWorkspaces = append(initialWorkspaces, workspace)
+		}
 	}
 
 	w := &userWorkspaceWatcher{
@@ -114,6 +158,8 @@ func NewUserWorkspaceWatcher(user user.Info, lclusterName logicalcluster.Logical
 
 		clusterWorkspaceCache:    clusterWorkspaceCache,
 		authCache:                authCache,
+		versioner:                versioner,
+		bookmarks:                options.AllowWatchBookmarks && versioner != nil,
 		initialClusterWorkspaces: initialWorkspaces,
 		knownWorkspaces:          knownWorkspaces,
 
@@ -122,7 +168,7 @@ func NewUserWorkspaceWatcher(user user.Info, lclusterName logicalcluster.Logical
 	w.emit = func(e watch.Event) {
 		// if dealing with workspace events, ensure that we only emit events for workspaces
 		// that match the field or label selector specified by a consumer
-		if workspace, ok := e.Object.(*workspaceapibeta1.Workspace); ok {
+		if workspace, ok := e.Object.(*workspaceapibeta1.Workspace); ok && e.Type != watch.Bookmark {
 			if matches, err := predicate.Matches(workspace); err != nil || !matches {
 				return
 			}
@@ -133,7 +179,7 @@ func NewUserWorkspaceWatcher(user user.Info, lclusterName logicalcluster.Logical
 		case <-w.userStop:
 		}
 	}
-	return w
+	return w, nil
 }
 
 func (w *userWorkspaceWatcher) GroupMembershipChanged(workspaceName string, users, groups sets.String) {
@@ -153,6 +199,13 @@ func (w *userWorkspaceWatcher) GroupMembershipChanged(workspaceName string, user
 	case !hasAccess && known:
 		delete(w.knownWorkspaces, workspaceName)
 
+		// the removal has no resource version of its own. The one of the previous synchronization
+		// is older than the oldest resumable one once this synchronization is done, hence a watch
+		// resumed from it is expired, and the client relists.
+		if w.versioner != nil {
+			workspace.ResourceVersion, _ = w.versioner.ResourceVersions()
+		}
+
 		select {
 		case w.cacheIncoming <- watch.Event{
 			Type:   watch.Deleted,
@@ -231,6 +284,13 @@ func (w *userWorkspaceWatcher) Watch() {
 		})
 	}
 
+	var bookmarkTicks <-chan time.Time
+	if w.bookmarks {
+		ticker := time.NewTicker(bookmarkPeriod)
+		defer ticker.Stop()
+		bookmarkTicks = ticker.C
+	}
+
 	for {
 		select {
 		case err := <-w.cacheError:
@@ -240,6 +300,20 @@ func (w *userWorkspaceWatcher) Watch() {
 		case <-w.userStop:
 			return
 
+		case <-bookmarkTicks:
+			// read before checking for pending events, such that all events up to the
+			// resource version have been emitted.
+			resourceVersion, _ := w.versioner.ResourceVersions()
+			if resourceVersion == "" || len(w.cacheIncoming) > 0 {
+				continue
+			}
+			w.emit(watch.Event{
+				Type: watch.Bookmark,
+				Object: &workspaceapibeta1.Workspace{
+					ObjectMeta: metav1.ObjectMeta{ResourceVersion: resourceVersion},
+				},
+			})
+
 		case event := <-w.cacheIncoming:
 			if curLen := int64(len(w.cacheIncoming)); watchChannelHWM.Update(curLen) {
 				// Monitor if this gets backed up, and how much.
@@ -17,11 +17,14 @@ limitations under the License.
 package authorization
 
 import (
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -53,6 +56,15 @@ func (m *mockClusterClient) Cluster(name logicalcluster.LogicalCluster) kcpclien
 var _ kcpclient.ClusterInterface = (*mockClusterClient)(nil)
 
 func newTestWatcher(username string, groups []string, predicate storage.SelectionPredicate, workspaces ...*workspaceapi.ClusterWorkspace) (*userWorkspaceWatcher, *fakeAuthCache) {
+	fakeAuthCache := &fakeAuthCache{}
+	watcher, err := newTestWatcherWithCache(username, groups, predicate, fakeAuthCache, WatchOptions{}, workspaces...)
+	if err != nil {
+		panic(err)
+	}
+	return watcher, fakeAuthCache
+}
+
+func newTestWatcherWithCache(username string, groups []string, predicate storage.SelectionPredicate, authCache WatchableCache, options WatchOptions, workspaces ...*workspaceapi.ClusterWorkspace) (*userWorkspaceWatcher, error) {
 	objects := []runtime.Object{}
 	for i := range workspaces {
 		objects = append(objects, workspaces[i])
@@ -64,9 +76,8 @@ func newTestWatcher(username string, groups []string, predicate storage.Selectio
 		informers.Tenancy().V1alpha1().ClusterWorkspaces().Informer(),
 		&mockClusterClient{mockClient: mockClient},
 	)
-	fakeAuthCache := &fakeAuthCache{}
 
-	return NewUserWorkspaceWatcher(&user.DefaultInfo{Name: username, Groups: groups}, logicalcluster.New("lclusterName"), workspaceCache, fakeAuthCache, false, predicate), fakeAuthCache
+	return NewUserWorkspaceWatcher(&user.DefaultInfo{Name: username, Groups: groups}, logicalcluster.New("lclusterName"), workspaceCache, authCache, options, predicate)
 }
 
 type fakeAuthCache struct {
@@ -90,6 +101,16 @@ func (w *fakeAuthCache) List(userInfo user.Info, labelSelector labels.Selector,
 	return ret, nil
 }
 
+type fakeVersionedAuthCache struct {
+	fakeAuthCache
+
+	current, oldestResumable string
+}
+
+func (w *fakeVersionedAuthCache) ResourceVersions() (string, string) {
+	return w.current, w.oldestResumable
+}
+
 func TestFullIncoming(t *testing.T) {
 	watcher, fakeAuthCache := newTestWatcher("bob", nil, matchAllPredicate(), newClusterWorkspaces("ns-01")...)
 	watcher.cacheIncoming = make(chan watch.Event)
@@ -280,6 +301,124 @@ func TestAddModifyDeleteEventsByGroup(t *testing.T) {
 	}
 }
 
+func TestResumeWatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		resourceVersion string
+		oldestResumable string
+		wantErr         func(error) bool
+		wantInitial     []string
+	}{
+		{name: "from now", resourceVersion: "", oldestResumable: "8"},
+		{name: "all existing", resourceVersion: "0", oldestResumable: "8", wantInitial: []string{"ns-01", "ns-02", "ns-03"}},
+		{name: "resumed", resourceVersion: "10", oldestResumable: "8", wantInitial: []string{"ns-03"}},
+		{name: "resumed from oldest", resourceVersion: "8", oldestResumable: "8", wantInitial: []string{"ns-02", "ns-03"}},
+		{name: "too old", resourceVersion: "7", oldestResumable: "8", wantErr: apierrors.IsResourceExpired},
+		{name: "not synchronized", resourceVersion: "10", oldestResumable: "", wantErr: apierrors.IsResourceExpired},
+		{name: "invalid", resourceVersion: "abc", oldestResumable: "8", wantErr: apierrors.IsBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authCache := &fakeVersionedAuthCache{current: "15", oldestResumable: tt.oldestResumable}
+			for name, rv := range map[string]string{"ns-01": "5", "ns-02": "10", "ns-03": "15"} {
+				authCache.clusterWorkspaces = append(authCache.clusterWorkspaces, &workspaceapi.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: rv}})
+			}
+			sort.Slice(authCache.clusterWorkspaces, func(i, j int) bool {
+				return authCache.clusterWorkspaces[i].Name < authCache.clusterWorkspaces[j].Name
+			})
+
+			watcher, err := newTestWatcherWithCache("bob", nil, matchAllPredicate(), authCache, WatchOptions{ResourceVersion: tt.resourceVersion})
+			if tt.wantErr != nil {
+				if err == nil || !tt.wantErr(err) {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			var initial []string
+			for _, workspace := range watcher.initialClusterWorkspaces {
+				initial = append(initial, workspace.Name)
+			}
+			if !reflect.DeepEqual(initial, tt.wantInitial) {
+				t.Errorf("expected initial workspaces %v, got %v", tt.wantInitial, initial)
+			}
+		})
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	oldBookmarkPeriod := bookmarkPeriod
+	bookmarkPeriod = 10 * time.Millisecond
+	defer func() { bookmarkPeriod = oldBookmarkPeriod }()
+
+	field := fields.ParseSelectorOrDie("metadata.name=ns-03")
+	authCache := &fakeVersionedAuthCache{current: "42", oldestResumable: "1"}
+	watcher, err := newTestWatcherWithCache("bob", nil, workspaceutil.MatchWorkspace(labels.Everything(), field), authCache, WatchOptions{AllowWatchBookmarks: true}, newClusterWorkspaces("ns-01")...)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	go watcher.Watch()
+	defer watcher.Stop()
+
+	// bookmarks are not filtered by the selector
+	select {
+	case event := <-watcher.ResultChan():
+		if event.Type != watch.Bookmark {
+			t.Fatalf("expected bookmark, got %v", event)
+		}
+		if rv := event.Object.(*workspaceapiv1beta1.Workspace).ResourceVersion; rv != "42" {
+			t.Errorf("expected resource version %v, got %v", "42", rv)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timeout")
+	}
+}
+
+func TestRemovalResourceVersion(t *testing.T) {
+	authCache := &fakeVersionedAuthCache{current: "20", oldestResumable: "1"}
+	authCache.clusterWorkspaces = newClusterWorkspaces("ns-01")
+	watcher, err := newTestWatcherWithCache("bob", nil, matchAllPredicate(), authCache, WatchOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	go watcher.Watch()
+	defer watcher.Stop()
+
+	watcher.GroupMembershipChanged("ns-01", sets.NewString("alice"), sets.String{})
+	select {
+	case event := <-watcher.ResultChan():
+		if event.Type != watch.Deleted {
+			t.Fatalf("expected Deleted, got %v", event)
+		}
+		if rv := event.Object.(*workspaceapiv1beta1.Workspace).ResourceVersion; rv != "20" {
+			t.Errorf("expected resource version %v, got %v", "20", rv)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timeout")
+	}
+}
+
+func TestOlderResourceVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "9", b: "10", want: true},
+		{a: "10", b: "9", want: false},
+		{a: "10", b: "10", want: false},
+		{a: "", b: "1", want: true},
+		{a: "1", b: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := OlderResourceVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("OlderResourceVersion(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
 func newClusterWorkspaces(names ...string) []*workspaceapi.ClusterWorkspace {
 	ret := []*workspaceapi.ClusterWorkspace{}
 	for _, name := range names {
@@ -89,6 +89,10 @@ func (o *authCacheClusterWorkspaces) AddWatcher(watcher workspaceauth.CacheWatch
 	o.authCache.AddWatcher(watcher)
 }
 
+func (o *authCacheClusterWorkspaces) ResourceVersions() (current, oldestResumable string) {
+	return o.authCache.ResourceVersions()
+}
+
 func (o *authCacheClusterWorkspaces) Ready() bool {
 	return o.authCache.ReadyForAccess()
 }
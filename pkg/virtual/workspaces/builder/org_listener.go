@@ -260,6 +260,16 @@ func (cws *preCreationClusterWorkspaces) AddWatcher(watcher authorization.CacheW
 	cws.watchers = append(cws.watchers, watcher)
 }
 
+// ResourceVersions returns the resource versions of the auth cache, or none before it is started.
+func (cws *preCreationClusterWorkspaces) ResourceVersions() (current, oldestResumable string) {
+	cws.lock.RLock()
+	defer cws.lock.RUnlock()
+	if versioner, ok := cws.delegate.(authorization.ResourceVersioner); ok {
+		return versioner.ResourceVersions()
+	}
+	return "", ""
+}
+
 func (cws *preCreationClusterWorkspaces) Stop() {
 	cws.lock.Lock()
 	defer cws.lock.Unlock()
@@ -19,6 +19,7 @@ package registry
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
@@ -37,6 +38,7 @@ import (
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/apiserver/pkg/storage"
 	rbacinformers "k8s.io/client-go/informers/rbac/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -282,6 +284,9 @@ func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (run
 		if err != nil {
 			return nil, err
 		}
+		if err := checkResourceVersionMatch(options, clusterWorkspaceList.ResourceVersion); err != nil {
+			return nil, err
+		}
 	}
 
 	if usePersonalScope {
@@ -318,16 +323,49 @@ func (s *REST) Watch(ctx context.Context, options *metainternal.ListOptions) (wa
 	}
 	clusterWorkspaces := s.getFilteredClusterWorkspaces(orgClusterName)
 
-	includeAllExistingProjects := (options != nil) && options.ResourceVersion == "0"
+	var watchOptions workspaceauth.WatchOptions
+	if options != nil {
+		watchOptions.ResourceVersion = options.ResourceVersion
+		watchOptions.AllowWatchBookmarks = options.AllowWatchBookmarks
+	}
 
 	m := workspaceutil.MatchWorkspace(InternalListOptionsToSelectors(options))
-	watcher := workspaceauth.NewUserWorkspaceWatcher(userInfo, orgClusterName, s.clusterWorkspaceCache, clusterWorkspaces, includeAllExistingProjects, m)
+	watcher, err := workspaceauth.NewUserWorkspaceWatcher(userInfo, orgClusterName, s.clusterWorkspaceCache, clusterWorkspaces, watchOptions, m)
+	if err != nil {
+		return nil, err
+	}
 	clusterWorkspaces.AddWatcher(watcher)
 
 	go watcher.Watch()
 	return watcher, nil
 }
 
+// checkResourceVersionMatch checks that a list served from the cache at the given resource version
+// satisfies the resourceVersion and resourceVersionMatch of the options. Lists of caches which do
+// not know their resource version are served as they are.
+func checkResourceVersionMatch(options *metainternal.ListOptions, listResourceVersion string) error {
+	if options == nil || options.ResourceVersion == "" || options.ResourceVersion == "0" || listResourceVersion == "" {
+		return nil
+	}
+	requested, err := strconv.ParseUint(options.ResourceVersion, 10, 64)
+	if err != nil {
+		return kerrors.NewBadRequest(fmt.Sprintf("invalid resource version %q", options.ResourceVersion))
+	}
+	current, err := strconv.ParseUint(listResourceVersion, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	switch {
+	case current < requested:
+		// the cache has not caught up yet, the client retries
+		return storage.NewTooLargeResourceVersionError(requested, current, 1)
+	case options.ResourceVersionMatch == metav1.ResourceVersionMatchExact && current != requested:
+		return kerrors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", requested, current))
+	}
+	return nil
+}
+
 var _ = rest.Getter(&REST{})
 
 // Get retrieves a Workspace by name
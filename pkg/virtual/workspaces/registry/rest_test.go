@@ -30,6 +30,7 @@ import (
 
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -39,6 +40,7 @@ import (
 	kuser "k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/storage"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
@@ -2055,3 +2057,32 @@ func rbacGroups(names ...string) []rbacv1.Subject {
 
 	return subjects
 }
+
+func TestCheckResourceVersionMatch(t *testing.T) {
+	tests := []struct {
+		name                string
+		options             *metainternal.ListOptions
+		listResourceVersion string
+		wantErr             func(error) bool
+	}{
+		{name: "no options", listResourceVersion: "10"},
+		{name: "any", options: &metainternal.ListOptions{ResourceVersion: "0"}, listResourceVersion: "10"},
+		{name: "unknown list resource version", options: &metainternal.ListOptions{ResourceVersion: "20"}},
+		{name: "not older than", options: &metainternal.ListOptions{ResourceVersion: "5", ResourceVersionMatch: metav1.ResourceVersionMatchNotOlderThan}, listResourceVersion: "10"},
+		{name: "not caught up", options: &metainternal.ListOptions{ResourceVersion: "20"}, listResourceVersion: "10", wantErr: storage.IsTooLargeResourceVersion},
+		{name: "exact", options: &metainternal.ListOptions{ResourceVersion: "10", ResourceVersionMatch: metav1.ResourceVersionMatchExact}, listResourceVersion: "10"},
+		{name: "exact but older", options: &metainternal.ListOptions{ResourceVersion: "5", ResourceVersionMatch: metav1.ResourceVersionMatchExact}, listResourceVersion: "10", wantErr: errors.IsResourceExpired},
+		{name: "invalid", options: &metainternal.ListOptions{ResourceVersion: "abc"}, listResourceVersion: "10", wantErr: errors.IsBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkResourceVersionMatch(tt.options, tt.listResourceVersion)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.True(t, tt.wantErr(err), "unexpected error: %v", err)
+		})
+	}
+}
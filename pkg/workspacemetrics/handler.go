@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspacemetrics
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/endpoints/responsewriter"
+)
+
+// Path is the non-resource URL serving the Summary of a workspace, i.e. /clusters/<workspace>/workspace-metrics.
+// Access is authorized like any non-resource URL in the workspace, hence granted to its admins.
+const Path = "/workspace-metrics"
+
+// WithMetrics records the requests to workspaces in the recorder, and serves the Summary of the
+// workspace of requests to Path. Wildcard requests are neither recorded nor served. It must run
+// after the request info and authorization filters, i.e. within the handler chain.
+func WithMetrics(apiHandler http.Handler, recorder *Recorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		cluster := request.ClusterFrom(req.Context())
+		if cluster == nil || cluster.Name.Empty() || cluster.Wildcard {
+			apiHandler.ServeHTTP(w, req)
+			return
+		}
+
+		if req.URL.Path == Path {
+			if req.Method != http.MethodGet {
+				http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+				return
+			}
+			bs, err := json.Marshal(recorder.Summary(cluster.Name))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(bs) //nolint:errcheck
+			return
+		}
+
+		var resource string
+		if info, ok := request.RequestInfoFrom(req.Context()); ok && info.IsResourceRequest {
+			resource = schema.GroupResource{Group: info.APIGroup, Resource: info.Resource}.String()
+		}
+
+		delegate := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		apiHandler.ServeHTTP(responsewriter.WrapForHTTP1Or2(delegate), req)
+		recorder.Record(cluster.Name, resource, delegate.code)
+	}
+}
+
+// statusRecorder remembers the status code of the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+var _ responsewriter.UserProvidedDecorator = &statusRecorder{}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.code = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspacemetrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestWithMetrics(t *testing.T) {
+	cluster := logicalcluster.New("root:org:team")
+	r := NewRecorder()
+	handler := WithMetrics(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}), r)
+
+	serve := func(path string, c *request.Cluster, info *request.RequestInfo) *httptest.ResponseRecorder {
+		ctx := context.Background()
+		if c != nil {
+			ctx = request.WithCluster(ctx, *c)
+		}
+		if info != nil {
+			ctx = request.WithRequestInfo(ctx, info)
+		}
+		req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+		return rw
+	}
+
+	rw := serve("/api/v1/configmaps/foo", &request.Cluster{Name: cluster}, &request.RequestInfo{IsResourceRequest: true, Resource: "configmaps"})
+	require.Equal(t, http.StatusNotFound, rw.Code)
+	serve("/apis/apps/v1/deployments", &request.Cluster{Name: cluster}, &request.RequestInfo{IsResourceRequest: true, APIGroup: "apps", Resource: "deployments"})
+	serve("/api/v1/configmaps", &request.Cluster{Wildcard: true, Name: logicalcluster.Wildcard}, &request.RequestInfo{IsResourceRequest: true, Resource: "configmaps"})
+	serve("/api/v1/configmaps", nil, &request.RequestInfo{IsResourceRequest: true, Resource: "configmaps"})
+
+	rw = serve(Path, &request.Cluster{Name: cluster}, nil)
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+	var summary Summary
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &summary))
+	require.Equal(t, int64(2), summary.Requests)
+	require.Equal(t, int64(2), summary.Errors)
+	require.Equal(t, []ResourceSummary{
+		{Resource: "configmaps", Requests: 1, Errors: 1},
+		{Resource: "deployments.apps", Requests: 1, Errors: 1},
+	}, summary.TopResources)
+
+	rw = serve(Path, &request.Cluster{Wildcard: true, Name: logicalcluster.Wildcard}, nil)
+	require.Equal(t, http.StatusNotFound, rw.Code)
+}
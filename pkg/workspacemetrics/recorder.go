@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspacemetrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+)
+
+const (
+	// bucketSize is the granularity requests are counted at.
+	bucketSize = time.Minute
+	// numBuckets is the number of buckets kept per workspace, i.e. the window of a Summary.
+	numBuckets = 10
+	// topResources is the number of resources listed in a Summary.
+	topResources = 10
+)
+
+// Window is the amount of time a Summary covers.
+const Window = numBuckets * bucketSize
+
+// Summary is the usage of a workspace within the last Window, as served to the workspace.
+type Summary struct {
+	// Window is the amount of time the summary covers.
+	Window string `json:"window"`
+	// Requests is the number of requests to the workspace.
+	Requests int64 `json:"requests"`
+	// Errors is the number of requests failing with a status code of 400 or above.
+	Errors int64 `json:"errors"`
+	// RequestsPerSecond is the average request rate.
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	// ErrorRatio is the fraction of requests which failed.
+	ErrorRatio float64 `json:"errorRatio"`
+	// TopResources are the resources with the most requests, with the most requested first.
+	TopResources []ResourceSummary `json:"topResources,omitempty"`
+}
+
+// ResourceSummary is the usage of one resource of a workspace.
+type ResourceSummary struct {
+	// Resource is the resource as "<resource>.<group>", or "<resource>" for the core group.
+	Resource string `json:"resource"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`
+}
+
+type bucket struct {
+	start     time.Time
+	requests  int64
+	errors    int64
+	resources map[string]*ResourceSummary
+}
+
+// Recorder counts the requests per workspace in buckets of a minute, keeping the last Window.
+// Workspaces without requests within the Window are forgotten.
+type Recorder struct {
+	now func() time.Time
+
+	lock      sync.Mutex
+	buckets   map[logicalcluster.LogicalCluster]*[numBuckets]bucket
+	lastPrune time.Time
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		now:     time.Now,
+		buckets: map[logicalcluster.LogicalCluster]*[numBuckets]bucket{},
+	}
+}
+
+// Record counts a request to the resource of the workspace, answered with the given status
+// code. Requests to non-resource URLs are recorded with an empty resource.
+func (r *Recorder) Record(cluster logicalcluster.LogicalCluster, resource string, code int) {
+	now := r.now()
+	start := now.Truncate(bucketSize)
+	failed := code >= 400
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.prune(now)
+
+	buckets, found := r.buckets[cluster]
+	if !found {
+		buckets = &[numBuckets]bucket{}
+		r.buckets[cluster] = buckets
+	}
+	b := &buckets[start.Unix()/int64(bucketSize/time.Second)%numBuckets]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start, resources: map[string]*ResourceSummary{}}
+	}
+
+	b.requests++
+	if failed {
+		b.errors++
+	}
+	if resource == "" {
+		return
+	}
+	rs, found := b.resources[resource]
+	if !found {
+		rs = &ResourceSummary{Resource: resource}
+		b.resources[resource] = rs
+	}
+	rs.Requests++
+	if failed {
+		rs.Errors++
+	}
+}
+
+// Summary returns the usage of the workspace within the last Window.
+func (r *Recorder) Summary(cluster logicalcluster.LogicalCluster) Summary {
+	now := r.now()
+	summary := Summary{Window: Window.String()}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	resources := map[string]*ResourceSummary{}
+	if buckets, found := r.buckets[cluster]; found {
+		for i := range buckets {
+			b := &buckets[i]
+			if !current(b, now) {
+				continue
+			}
+			summary.Requests += b.requests
+			summary.Errors += b.errors
+			for name, rs := range b.resources {
+				sum, found := resources[name]
+				if !found {
+					sum = &ResourceSummary{Resource: name}
+					resources[name] = sum
+				}
+				sum.Requests += rs.Requests
+				sum.Errors += rs.Errors
+			}
+		}
+	}
+
+	summary.RequestsPerSecond = float64(summary.Requests) / Window.Seconds()
+	if summary.Requests > 0 {
+		summary.ErrorRatio = float64(summary.Errors) / float64(summary.Requests)
+	}
+	for _, rs := range resources {
+		summary.TopResources = append(summary.TopResources, *rs)
+	}
+	sort.Slice(summary.TopResources, func(i, j int) bool {
+		if summary.TopResources[i].Requests != summary.TopResources[j].Requests {
+			return summary.TopResources[i].Requests > summary.TopResources[j].Requests
+		}
+		return summary.TopResources[i].Resource < summary.TopResources[j].Resource
+	})
+	if len(summary.TopResources) > topResources {
+		summary.TopResources = summary.TopResources[:topResources]
+	}
+
+	return summary
+}
+
+// prune forgets the workspaces without requests within the Window, at most once per Window.
+func (r *Recorder) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < Window {
+		return
+	}
+	r.lastPrune = now
+
+	for cluster, buckets := range r.buckets {
+		active := false
+		for i := range buckets {
+			if current(&buckets[i], now) {
+				active = true
+				break
+			}
+		}
+		if !active {
+			delete(r.buckets, cluster)
+		}
+	}
+}
+
+// current returns whether the bucket is within the Window ending at now.
+func current(b *bucket, now time.Time) bool {
+	return !b.start.IsZero() && now.Sub(b.start) < Window
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package workspacemetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/apimachinery/pkg/logicalcluster"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	start := time.Date(2022, 3, 1, 12, 0, 30, 0, time.UTC)
+	team := logicalcluster.New("root:org:team")
+	other := logicalcluster.New("root:org:other")
+
+	type record struct {
+		after    time.Duration
+		cluster  logicalcluster.LogicalCluster
+		resource string
+		code     int
+	}
+	tests := map[string]struct {
+		records []record
+		after   time.Duration
+
+		want Summary
+	}{
+		"no requests": {
+			want: Summary{Window: "10m0s"},
+		},
+		"requests of the workspace only": {
+			records: []record{
+				{cluster: team, resource: "configmaps", code: 200},
+				{cluster: team, resource: "configmaps", code: 404},
+				{cluster: team, resource: "deployments.apps", code: 201},
+				{cluster: team, code: 200},
+				{cluster: other, resource: "secrets", code: 500},
+			},
+			want: Summary{
+				Window:            "10m0s",
+				Requests:          4,
+				Errors:            1,
+				RequestsPerSecond: 4.0 / 600,
+				ErrorRatio:        0.25,
+				TopResources: []ResourceSummary{
+					{Resource: "configmaps", Requests: 2, Errors: 1},
+					{Resource: "deployments.apps", Requests: 1},
+				},
+			},
+		},
+		"requests summed over buckets": {
+			records: []record{
+				{cluster: team, resource: "configmaps", code: 200},
+				{after: 3 * time.Minute, cluster: team, resource: "configmaps", code: 500},
+				{after: 9 * time.Minute, cluster: team, resource: "secrets", code: 403},
+			},
+			after: 9 * time.Minute,
+			want: Summary{
+				Window:            "10m0s",
+				Requests:          3,
+				Errors:            2,
+				RequestsPerSecond: 3.0 / 600,
+				ErrorRatio:        2.0 / 3,
+				TopResources: []ResourceSummary{
+					{Resource: "configmaps", Requests: 2, Errors: 1},
+					{Resource: "secrets", Requests: 1, Errors: 1},
+				},
+			},
+		},
+		"requests out of the window": {
+			records: []record{
+				{cluster: team, resource: "configmaps", code: 200},
+				{after: 5 * time.Minute, cluster: team, resource: "secrets", code: 200},
+			},
+			after: 10 * time.Minute,
+			want: Summary{
+				Window:            "10m0s",
+				Requests:          1,
+				RequestsPerSecond: 1.0 / 600,
+				TopResources: []ResourceSummary{
+					{Resource: "secrets", Requests: 1},
+				},
+			},
+		},
+		"bucket reused after the window": {
+			records: []record{
+				{cluster: team, resource: "configmaps", code: 200},
+				{after: 10 * time.Minute, cluster: team, resource: "secrets", code: 200},
+			},
+			after: 10 * time.Minute,
+			want: Summary{
+				Window:            "10m0s",
+				Requests:          1,
+				RequestsPerSecond: 1.0 / 600,
+				TopResources: []ResourceSummary{
+					{Resource: "secrets", Requests: 1},
+				},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			now := start
+			r := NewRecorder()
+			r.now = func() time.Time { return now }
+
+			for _, rec := range tc.records {
+				now = start.Add(rec.after)
+				r.Record(rec.cluster, rec.resource, rec.code)
+			}
+			now = start.Add(tc.after)
+
+			require.Equal(t, tc.want, r.Summary(team))
+		})
+	}
+}
+
+func TestRecorderTopResources(t *testing.T) {
+	r := NewRecorder()
+	cluster := logicalcluster.New("root:org:team")
+	for i := 0; i < topResources+2; i++ {
+		for j := 0; j <= i; j++ {
+			r.Record(cluster, string(rune('a'+i)), 200)
+		}
+	}
+
+	summary := r.Summary(cluster)
+	require.Len(t, summary.TopResources, topResources)
+	require.Equal(t, ResourceSummary{Resource: "l", Requests: topResources + 2}, summary.TopResources[0])
+	require.Equal(t, "c", summary.TopResources[topResources-1].Resource)
+}
+
+func TestRecorderPrune(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRecorder()
+	r.now = func() time.Time { return now }
+
+	r.Record(logicalcluster.New("root:org:gone"), "configmaps", 200)
+	now = now.Add(Window)
+	r.Record(logicalcluster.New("root:org:team"), "configmaps", 200)
+
+	require.Len(t, r.buckets, 1)
+	require.Contains(t, r.buckets, logicalcluster.New("root:org:team"))
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/kcp-dev/kcp/config/helpers"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	clientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/test/e2e/fixtures/wildwest/apis/wildwest"
 	wildwestv1alpha1 "github.com/kcp-dev/kcp/test/e2e/fixtures/wildwest/apis/wildwest/v1alpha1"
 	wildwestclientset "github.com/kcp-dev/kcp/test/e2e/fixtures/wildwest/client/clientset/versioned"
@@ -51,9 +52,9 @@ func TestAPIBinding(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	t.Cleanup(cancel)
 
-	orgClusterName := framework.NewOrganizationFixture(t, server)
-	sourceWorkspace := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
-	targetWorkspace := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+	orgClusterName := kcptesting.NewOrganizationFixture(t, server)
+	sourceWorkspace := kcptesting.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+	targetWorkspace := kcptesting.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
 
 	cfg := server.DefaultConfig(t)
 
@@ -41,6 +41,7 @@ import (
 	confighelpers "github.com/kcp-dev/kcp/config/helpers"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	kcp "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 )
 
@@ -63,8 +64,8 @@ func TestAuthorizer(t *testing.T) {
 	dynamicClusterClient, err := dynamic.NewClusterForConfig(cfg)
 	require.NoError(t, err)
 
-	org1 := framework.NewOrganizationFixture(t, server)
-	org2 := framework.NewOrganizationFixture(t, server)
+	org1 := kcptesting.NewOrganizationFixture(t, server)
+	org2 := kcptesting.NewOrganizationFixture(t, server)
 
 	createResources(t, ctx, dynamicClusterClient, kubeClusterClient.DiscoveryClient, org1, "org-resources.yaml")
 	createResources(t, ctx, dynamicClusterClient, kubeClusterClient.DiscoveryClient, org2, "org-resources.yaml")
@@ -77,8 +78,8 @@ func TestAuthorizer(t *testing.T) {
 	createResources(t, ctx, dynamicClusterClient, kubeClusterClient.DiscoveryClient, org1.Join("workspace1"), "workspace1-resources.yaml")
 	createResources(t, ctx, dynamicClusterClient, kubeClusterClient.DiscoveryClient, org2.Join("workspace1"), "workspace1-resources.yaml")
 
-	framework.AdmitWorkspaceAccess(t, ctx, kubeClusterClient, org1, []string{"user-1"}, nil, []string{"member"})
-	framework.AdmitWorkspaceAccess(t, ctx, kubeClusterClient, org1, []string{"user-2", "user-3"}, nil, []string{"access"})
+	kcptesting.AdmitWorkspaceAccess(t, ctx, kubeClusterClient, org1, []string{"user-1"}, nil, []string{"member"})
+	kcptesting.AdmitWorkspaceAccess(t, ctx, kubeClusterClient, org1, []string{"user-2", "user-3"}, nil, []string{"access"})
 
 	user1KubeClusterClient, err := kubernetes.NewClusterForConfig(userConfig("user-1", cfg))
 	require.NoError(t, err)
@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 )
 
@@ -41,8 +42,8 @@ func TestRootCACertConfigmap(t *testing.T) {
 	t.Cleanup(cancelFunc)
 
 	server := framework.SharedKcpServer(t)
-	orgClusterName := framework.NewOrganizationFixture(t, server)
-	clusterName := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+	orgClusterName := kcptesting.NewOrganizationFixture(t, server)
+	clusterName := kcptesting.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
 
 	cfg := server.DefaultConfig(t)
 	kubeClusterClient, err := kubernetes.NewClusterForConfig(cfg)
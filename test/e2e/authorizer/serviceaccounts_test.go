@@ -34,6 +34,7 @@ import (
 	"k8s.io/utils/pointer"
 
 	"github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1/helper"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 )
 
@@ -44,8 +45,8 @@ func TestServiceAccounts(t *testing.T) {
 	t.Cleanup(cancelFunc)
 
 	server := framework.SharedKcpServer(t)
-	orgClusterName := framework.NewOrganizationFixture(t, server)
-	clusterName := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+	orgClusterName := kcptesting.NewOrganizationFixture(t, server)
+	clusterName := kcptesting.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
 
 	cfg := server.DefaultConfig(t)
 	kubeClusterClient, err := kubernetes.NewClusterForConfig(cfg)
@@ -163,7 +164,7 @@ func TestServiceAccounts(t *testing.T) {
 
 			t.Run("Access another workspace in the same org", func(t *testing.T) {
 				t.Log("Create namespace with the same name ")
-				otherClusterName := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+				otherClusterName := kcptesting.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
 				_, err := kubeClusterClient.Cluster(otherClusterName).CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
 					ObjectMeta: metav1.ObjectMeta{
 						Name: namespace.Name,
@@ -178,8 +179,8 @@ func TestServiceAccounts(t *testing.T) {
 
 			t.Run("Access an equally named workspace in another org", func(t *testing.T) {
 				t.Log("Create namespace with the same name")
-				otherOrgClusterName := framework.NewOrganizationFixture(t, server)
-				otherClusterName := framework.NewWorkspaceFixture(t, server, otherOrgClusterName, "Universal")
+				otherOrgClusterName := kcptesting.NewOrganizationFixture(t, server)
+				otherClusterName := kcptesting.NewWorkspaceFixture(t, server, otherOrgClusterName, "Universal")
 				_, err := kubeClusterClient.Cluster(otherClusterName).CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
 					ObjectMeta: metav1.ObjectMeta{
 						Name: namespace.Name,
@@ -43,6 +43,7 @@ import (
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	metadataclient "github.com/kcp-dev/kcp/pkg/metadata"
 	sheriffs "github.com/kcp-dev/kcp/test/e2e/conformance/othersheriffs"
 	othersheriffs "github.com/kcp-dev/kcp/test/e2e/conformance/sheriffs"
@@ -66,8 +67,8 @@ func TestCrossLogicalClusterList(t *testing.T) {
 	clientutils.EnableMultiCluster(cfg, nil, true)
 
 	logicalClusters := []logicalcluster.LogicalCluster{
-		framework.NewOrganizationFixture(t, server),
-		framework.NewOrganizationFixture(t, server),
+		kcptesting.NewOrganizationFixture(t, server),
+		kcptesting.NewOrganizationFixture(t, server),
 	}
 	expectedWorkspaces := sets.NewString()
 	for i, logicalCluster := range logicalClusters {
@@ -127,10 +128,10 @@ func TestCrossLogicalClusterListPartialObjectMetadata(t *testing.T) {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	t.Cleanup(cancelFunc)
 
-	org := framework.NewOrganizationFixture(t, server)
-	w1 := framework.NewWorkspaceFixture(t, server, org, "Universal")
-	w2 := framework.NewWorkspaceFixture(t, server, org, "Universal")
-	w3 := framework.NewWorkspaceFixture(t, server, org, "Universal")
+	org := kcptesting.NewOrganizationFixture(t, server)
+	w1 := kcptesting.NewWorkspaceFixture(t, server, org, "Universal")
+	w2 := kcptesting.NewWorkspaceFixture(t, server, org, "Universal")
+	w3 := kcptesting.NewWorkspaceFixture(t, server, org, "Universal")
 
 	cfg := server.DefaultConfig(t)
 
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dev
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubernetesclientset "k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/server"
+	wildwestv1alpha1 "github.com/kcp-dev/kcp/test/e2e/fixtures/wildwest/apis/wildwest/v1alpha1"
+	wildwestclientset "github.com/kcp-dev/kcp/test/e2e/fixtures/wildwest/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+	conditionsapi "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestDevContent(t *testing.T) {
+	t.Parallel()
+
+	// the namespace scheduler discovers the APIs imported by the simulated syncer by polling
+	kcpServer := framework.PrivateKcpServer(t, "--dev", "--discovery-poll-interval=5s")
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	cfg := kcpServer.DefaultConfig(t)
+	kcpClusterClient, err := kcpclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err)
+	kubeClusterClient, err := kubernetesclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err)
+	wildwestClusterClient, err := wildwestclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err)
+
+	t.Logf("Waiting for the cowboys API to be bound in workspace %q", server.DevConsumerWorkspace)
+	require.Eventually(t, func() bool {
+		binding, err := kcpClusterClient.Cluster(server.DevConsumerWorkspace).ApisV1alpha1().APIBindings().Get(ctx, "cowboys", metav1.GetOptions{})
+		if err != nil {
+			return false
+		}
+		return binding.Status.Phase == apisv1alpha1.APIBindingPhaseBound
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "APIBinding of the cowboys API was not bound")
+
+	t.Log("Creating a cowboy")
+	_, err = wildwestClusterClient.Cluster(server.DevConsumerWorkspace).WildwestV1alpha1().Cowboys("default").Create(ctx, &wildwestv1alpha1.Cowboy{
+		ObjectMeta: metav1.ObjectMeta{Name: "woody"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Logf("Waiting for the WorkloadCluster %q to be ready", server.DevWorkloadCluster)
+	require.Eventually(t, func() bool {
+		cluster, err := kcpClusterClient.Cluster(server.DevConsumerWorkspace).WorkloadV1alpha1().WorkloadClusters().Get(ctx, server.DevWorkloadCluster, metav1.GetOptions{})
+		if err != nil {
+			return false
+		}
+		return conditions.IsTrue(cluster, conditionsapi.ReadyCondition)
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "WorkloadCluster did not become ready")
+
+	t.Log("Creating a deployment")
+	kubeClient := kubeClusterClient.Cluster(server.DevConsumerWorkspace)
+	namespace, err := kubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-dev"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	labels := map[string]string{"app": "nginx"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(2),
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}},
+				},
+			},
+		},
+	}
+	require.Eventually(t, func() bool {
+		// the Deployment API is imported by the simulated syncer asynchronously
+		_, err := kubeClient.AppsV1().Deployments(namespace.Name).Create(ctx, deployment, metav1.CreateOptions{})
+		return err == nil
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "failed to create the deployment")
+
+	t.Log("Waiting for the simulated status of the deployment")
+	require.Eventually(t, func() bool {
+		deployment, err := kubeClient.AppsV1().Deployments(namespace.Name).Get(ctx, deployment.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Errorf("saw an error waiting for the deployment status: %v", err)
+			return false
+		}
+		return deployment.Status.AvailableReplicas == *deployment.Spec.Replicas
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "deployment did not get a simulated status")
+}
@@ -34,6 +34,8 @@ import (
 	"github.com/egymgmbh/go-prefix-writer/prefixer"
 
 	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 )
 
 // NewAccessory creates a new accessory process.
@@ -55,12 +57,12 @@ type Accessory struct {
 	cmd         []string
 }
 
-func (a *Accessory) Run(t *testing.T, opts ...RunOption) error {
-	runOpts := runOptions{}
+func (a *Accessory) Run(t *testing.T, opts ...kcptesting.RunOption) error {
+	runOpts := kcptesting.RunOptions{}
 	for _, opt := range opts {
 		opt(&runOpts)
 	}
-	if runOpts.runInProcess {
+	if runOpts.InProcess {
 		return fmt.Errorf("cannot run arbitrary accessories in process")
 	}
 
@@ -82,7 +84,7 @@ func (a *Accessory) Run(t *testing.T, opts ...RunOption) error {
 	}
 	log := bytes.Buffer{}
 	writers := []io.Writer{&log, logFile}
-	if runOpts.streamLogs {
+	if runOpts.StreamLogs {
 		prefix := fmt.Sprintf("%s: ", a.name)
 		writers = append(writers, prefixer.New(os.Stdout, func() string { return prefix }))
 	}
@@ -39,6 +39,7 @@ import (
 	configcrds "github.com/kcp-dev/kcp/config/crds"
 	"github.com/kcp-dev/kcp/pkg/apis/apiresource"
 	"github.com/kcp-dev/kcp/pkg/apis/workload"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 	"github.com/kcp-dev/kcp/pkg/syncer"
 	fixturewildwest "github.com/kcp-dev/kcp/test/e2e/fixtures/wildwest"
@@ -56,7 +57,7 @@ func TestClusterController(t *testing.T) {
 	t.Parallel()
 
 	type runningServer struct {
-		framework.RunningServer
+		kcptesting.RunningServer
 		client     wildwestclient.WildwestV1alpha1Interface
 		coreClient corev1client.CoreV1Interface
 	}
@@ -140,7 +141,7 @@ func TestClusterController(t *testing.T) {
 	}
 
 	source := framework.SharedKcpServer(t)
-	orgClusterName := framework.NewOrganizationFixture(t, source)
+	orgClusterName := kcptesting.NewOrganizationFixture(t, source)
 
 	for i := range testCases {
 		testCase := testCases[i]
@@ -151,7 +152,7 @@ func TestClusterController(t *testing.T) {
 			t.Cleanup(cancelFunc)
 
 			t.Log("Creating a workspace")
-			wsClusterName := framework.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
+			wsClusterName := kcptesting.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
 
 			// clients
 			sourceConfig := source.DefaultConfig(t)
@@ -175,7 +176,7 @@ func TestClusterController(t *testing.T) {
 			require.NoError(t, err)
 			fixturewildwest.Create(t, sourceCrdClient.ApiextensionsV1().CustomResourceDefinitions(), metav1.GroupResource{Group: wildwest.GroupName, Resource: "cowboys"})
 
-			syncerFixture := framework.NewSyncerFixture(t, sets.NewString("cowboys.wildwest.dev"), source, orgClusterName, wsClusterName)
+			syncerFixture := kcptesting.NewSyncerFixture(t, sets.NewString("cowboys.wildwest.dev"), source, orgClusterName, wsClusterName)
 			sink := syncerFixture.RunningServer
 
 			sinkConfig := sink.DefaultConfig(t)
@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 )
 
@@ -32,15 +33,15 @@ func TestSyncerHeartbeat(t *testing.T) {
 	source := framework.SharedKcpServer(t)
 
 	t.Log("Creating an organization")
-	orgClusterName := framework.NewOrganizationFixture(t, source)
+	orgClusterName := kcptesting.NewOrganizationFixture(t, source)
 
 	t.Log("Creating a workspace")
-	wsClusterName := framework.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
+	wsClusterName := kcptesting.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	t.Cleanup(cancelFunc)
 
-	syncerFixture := framework.NewSyncerFixture(t, sets.NewString(), source, orgClusterName, wsClusterName)
+	syncerFixture := kcptesting.NewSyncerFixture(t, sets.NewString(), source, orgClusterName, wsClusterName)
 	// Initially the heartbeat controller will indicate not ready due to missing heartbeat.
 	syncerFixture.WaitForClusterReadyReason(t, ctx, workloadv1alpha1.ErrorHeartbeatMissedReason)
 	// Fixture start will check for successful heartbeat.
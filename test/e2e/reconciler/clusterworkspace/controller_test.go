@@ -34,6 +34,7 @@ import (
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	clientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 	utilconditions "github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
 )
@@ -42,11 +43,11 @@ func TestWorkspaceController(t *testing.T) {
 	t.Parallel()
 
 	type runningServer struct {
-		framework.RunningServer
+		kcptesting.RunningServer
 		rootKcpClient, orgKcpClient   clientset.Interface
 		rootKubeClient, orgKubeClient kubernetesclientset.Interface
-		orgExpect                     framework.RegisterClusterWorkspaceExpectation
-		rootExpectShard               framework.RegisterWorkspaceShardExpectation
+		orgExpect                     kcptesting.RegisterClusterWorkspaceExpectation
+		rootExpectShard               kcptesting.RegisterWorkspaceShardExpectation
 	}
 	var testCases = []struct {
 		name        string
@@ -192,7 +193,7 @@ func TestWorkspaceController(t *testing.T) {
 
 			cfg := server.DefaultConfig(t)
 
-			orgClusterName := framework.NewOrganizationFixture(t, server)
+			orgClusterName := kcptesting.NewOrganizationFixture(t, server)
 
 			// create clients
 			kcpClusterClient, err := kcpclientset.NewClusterForConfig(cfg)
@@ -200,10 +201,10 @@ func TestWorkspaceController(t *testing.T) {
 			orgKcpClient := kcpClusterClient.Cluster(orgClusterName)
 			rootKcpClient := kcpClusterClient.Cluster(tenancyv1alpha1.RootCluster)
 
-			orgExpect, err := framework.ExpectClusterWorkspaces(ctx, t, orgKcpClient)
+			orgExpect, err := kcptesting.ExpectClusterWorkspaces(ctx, t, orgKcpClient)
 			require.NoError(t, err, "failed to start expecter")
 
-			rootExpectShard, err := framework.ExpectWorkspaceShards(ctx, t, rootKcpClient)
+			rootExpectShard, err := kcptesting.ExpectWorkspaceShards(ctx, t, rootKcpClient)
 			require.NoError(t, err, "failed to start expecter")
 
 			kubeClusterClient, err := kubernetesclientset.NewClusterForConfig(cfg)
@@ -27,6 +27,7 @@ import (
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	tenancyv1alpha1client "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/typed/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 )
 
@@ -34,10 +35,10 @@ func TestWorkspaceShardController(t *testing.T) {
 	t.Parallel()
 
 	type runningServer struct {
-		framework.RunningServer
+		kcptesting.RunningServer
 		rootShardClient               tenancyv1alpha1client.ClusterWorkspaceShardInterface
 		rootKubeClient, orgKubeClient kubernetesclientset.Interface
-		expect                        framework.RegisterWorkspaceShardExpectation
+		expect                        kcptesting.RegisterWorkspaceShardExpectation
 	}
 	var testCases = []struct {
 		name        string
@@ -67,13 +68,13 @@ func TestWorkspaceShardController(t *testing.T) {
 
 			cfg := server.DefaultConfig(t)
 
-			orgClusterName := framework.NewOrganizationFixture(t, server)
+			orgClusterName := kcptesting.NewOrganizationFixture(t, server)
 
 			kcpClients, err := kcpclientset.NewClusterForConfig(cfg)
 			require.NoError(t, err, "failed to construct kcp rootShardClient for server")
 
 			rootKcpClient := kcpClients.Cluster(tenancyv1alpha1.RootCluster)
-			expect, err := framework.ExpectWorkspaceShards(ctx, t, rootKcpClient)
+			expect, err := kcptesting.ExpectWorkspaceShards(ctx, t, rootKcpClient)
 			require.NoError(t, err, "failed to start expecter")
 
 			kubeClients, err := kubernetesclientset.NewClusterForConfig(cfg)
@@ -43,6 +43,7 @@ import (
 	configcrds "github.com/kcp-dev/kcp/config/crds"
 	"github.com/kcp-dev/kcp/pkg/apis/apiresource"
 	"github.com/kcp-dev/kcp/pkg/apis/workload"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/pkg/syncer"
 	kubefixtures "github.com/kcp-dev/kcp/test/e2e/fixtures/kube"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
@@ -59,7 +60,7 @@ func TestIngressController(t *testing.T) {
 	t.Parallel()
 
 	type runningServer struct {
-		framework.RunningServer
+		kcptesting.RunningServer
 		client networkingclient.NetworkingV1Interface
 	}
 	var testCases = []struct {
@@ -96,7 +97,7 @@ func TestIngressController(t *testing.T) {
 					if len(got.Items) != 1 {
 						return false
 					}
-					framework.RequireNoDiff(t, got.Items[0].Spec, rootIngress.Spec)
+					kcptesting.RequireNoDiff(t, got.Items[0].Spec, rootIngress.Spec)
 					return true
 				}, wait.ForeverTestTimeout, time.Second, "did not see the ingress synced on sink cluster")
 
@@ -129,7 +130,7 @@ func TestIngressController(t *testing.T) {
 	}
 
 	source := framework.SharedKcpServer(t)
-	orgClusterName := framework.NewOrganizationFixture(t, source)
+	orgClusterName := kcptesting.NewOrganizationFixture(t, source)
 
 	for i := range testCases {
 		testCase := testCases[i]
@@ -139,7 +140,7 @@ func TestIngressController(t *testing.T) {
 			ctx, cancelFunc := context.WithCancel(context.Background())
 			t.Cleanup(cancelFunc)
 
-			clusterName := framework.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
+			clusterName := kcptesting.NewWorkspaceFixture(t, source, orgClusterName, "Universal")
 
 			// clients
 			sourceConfig := source.DefaultConfig(t)
@@ -165,7 +166,7 @@ func TestIngressController(t *testing.T) {
 			)
 
 			resources := sets.NewString("ingresses.networking.k8s.io", "deployments.apps", "services")
-			syncerFixture := framework.NewSyncerFixture(t, resources, source, orgClusterName, clusterName)
+			syncerFixture := kcptesting.NewSyncerFixture(t, resources, source, orgClusterName, clusterName)
 
 			sink := syncerFixture.RunningServer
 			sinkConfig := sink.DefaultConfig(t)
@@ -224,11 +225,11 @@ func TestIngressController(t *testing.T) {
 			}
 
 			t.Log("Starting ingress-controller...")
-			envoyListenerPort, err := framework.GetFreePort(t)
+			envoyListenerPort, err := kcptesting.GetFreePort(t)
 			require.NoError(t, err, "failed to pick envoy listener port")
-			xdsListenerPort, err := framework.GetFreePort(t)
+			xdsListenerPort, err := kcptesting.GetFreePort(t)
 			require.NoError(t, err, "failed to pick xds listener port")
-			artifactDir, err := framework.CreateTempDirForTest(t, "artifacts")
+			artifactDir, err := kcptesting.CreateTempDirForTest(t, "artifacts")
 			require.NoError(t, err, "failed to create artifact dir for ingress-controller")
 			kubeconfigPath := filepath.Join(artifactDir, "ingress-controller.kubeconfig")
 			adminConfig, err := source.RawConfig()
@@ -259,7 +260,7 @@ func TestIngressController(t *testing.T) {
 				"--envoy-xds-port="+xdsListenerPort,
 			)
 			ingressController := framework.NewAccessory(t, artifactDir, executableName, cmd...)
-			err = ingressController.Run(t, framework.WithLogStreaming)
+			err = ingressController.Run(t, kcptesting.WithLogStreaming)
 			require.NoError(t, err, "failed to start ingress controller")
 
 			t.Log("Starting test...")
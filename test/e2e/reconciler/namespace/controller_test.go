@@ -41,6 +41,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/apis/workload"
 	"github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	clientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	nscontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
@@ -52,7 +53,7 @@ func TestNamespaceScheduler(t *testing.T) {
 	t.Parallel()
 
 	type runningServer struct {
-		framework.RunningServer
+		kcptesting.RunningServer
 		clusterName    logicalcluster.LogicalCluster
 		client         kubernetes.Interface
 		orgKcpClient   versioned.Interface
@@ -85,7 +86,7 @@ func TestNamespaceScheduler(t *testing.T) {
 				require.NoError(t, err, "did not see namespace marked unschedulable")
 
 				// Create and Start a syncer against a workload cluster so that theres a ready cluster to schedule to.
-				syncerFixture := framework.NewSyncerFixture(t, sets.NewString(), server, server.orgClusterName, server.clusterName)
+				syncerFixture := kcptesting.NewSyncerFixture(t, sets.NewString(), server, server.orgClusterName, server.clusterName)
 				syncerFixture.Start(t, ctx)
 				err = server.expect(namespace, scheduledMatcher(syncerFixture.WorkloadClusterName))
 				require.NoError(t, err, "did not see namespace marked scheduled for cluster1 %q", syncerFixture.WorkloadClusterName)
@@ -127,7 +128,7 @@ func TestNamespaceScheduler(t *testing.T) {
 	}
 
 	server := framework.SharedKcpServer(t)
-	orgClusterName := framework.NewOrganizationFixture(t, server)
+	orgClusterName := kcptesting.NewOrganizationFixture(t, server)
 
 	for i := range testCases {
 		testCase := testCases[i]
@@ -146,7 +147,7 @@ func TestNamespaceScheduler(t *testing.T) {
 			require.NoError(t, err, "failed to construct client for server")
 			apiextensionClusterClient, err := apiextensionsclient.NewClusterForConfig(cfg)
 			require.NoError(t, err, "failed to construct client for server")
-			clusterName := framework.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+			clusterName := kcptesting.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
 			err = crds.Create(ctx, apiextensionClusterClient.Cluster(clusterName).ApiextensionsV1().CustomResourceDefinitions(),
 				metav1.GroupResource{Group: apiresource.GroupName, Resource: "apiresourceimports"},
 				metav1.GroupResource{Group: apiresource.GroupName, Resource: "negotiatedapiresources"},
@@ -221,7 +222,7 @@ type registerNamespaceExpectation func(seed *corev1.Namespace, expectation names
 func expectNamespaces(ctx context.Context, t *testing.T, client kubernetes.Interface) (registerNamespaceExpectation, error) {
 	informerFactory := informers.NewSharedInformerFactory(client, 0)
 	informer := informerFactory.Core().V1().Namespaces()
-	expecter := framework.NewExpecter(informer.Informer())
+	expecter := kcptesting.NewExpecter(informer.Informer())
 	informerFactory.Start(ctx.Done())
 	if !cache.WaitForNamedCacheSync(t.Name(), ctx.Done(), informer.Informer().HasSynced) {
 		return nil, errors.New("failed to wait for caches to sync")
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	kubernetesclientset "k8s.io/client-go/kubernetes"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
+	"github.com/kcp-dev/kcp/pkg/syncer"
+	"github.com/kcp-dev/kcp/test/e2e/framework"
+	conditionsapi "github.com/kcp-dev/kcp/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/third_party/conditions/util/conditions"
+)
+
+func TestSimulatedSyncer(t *testing.T) {
+	t.Parallel()
+
+	server := framework.SharedKcpServer(t)
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	orgClusterName := kcptesting.NewOrganizationFixture(t, server)
+	wsClusterName := kcptesting.NewWorkspaceFixture(t, server, orgClusterName, "Universal")
+
+	cfg := server.DefaultConfig(t)
+	kcpClusterClient, err := kcpclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err)
+	kubeClusterClient, err := kubernetesclientset.NewClusterForConfig(cfg)
+	require.NoError(t, err)
+	kubeClient := kubeClusterClient.Cluster(wsClusterName)
+
+	t.Log("Creating a WorkloadCluster without physical cluster")
+	_, err = kcpClusterClient.Cluster(wsClusterName).WorkloadV1alpha1().WorkloadClusters().Create(ctx, &workloadv1alpha1.WorkloadCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "simulated"},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Log("Starting a simulated syncer")
+	err = syncer.StartSimulatedSyncers(ctx, []syncer.SyncTarget{{
+		Upstream:            cfg,
+		Resources:           sets.NewString("deployments.apps"),
+		KCPClusterName:      wsClusterName,
+		WorkloadClusterName: "simulated",
+	}}, 2, 5*time.Second)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		cluster, err := kcpClusterClient.Cluster(wsClusterName).WorkloadV1alpha1().WorkloadClusters().Get(ctx, "simulated", metav1.GetOptions{})
+		if err != nil {
+			t.Errorf("saw an error waiting for the WorkloadCluster to be ready: %v", err)
+			return false
+		}
+		return conditions.IsTrue(cluster, conditionsapi.ReadyCondition)
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "WorkloadCluster did not become ready")
+
+	t.Log("Creating a deployment")
+	namespace, err := kubeClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-simulated"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	deploymentYAML, err := embeddedResources.ReadFile("deployment.yaml")
+	require.NoError(t, err)
+	var deployment *appsv1.Deployment
+	require.NoError(t, yaml.Unmarshal(deploymentYAML, &deployment))
+	deployment, err = kubeClient.AppsV1().Deployments(namespace.Name).Create(ctx, deployment, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	t.Log("Waiting for the simulated status of the deployment")
+	require.Eventually(t, func() bool {
+		deployment, err := kubeClient.AppsV1().Deployments(namespace.Name).Get(ctx, deployment.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Errorf("saw an error waiting for the deployment status: %v", err)
+			return false
+		}
+		return deployment.Status.ObservedGeneration == deployment.Generation && deployment.Status.AvailableReplicas == *deployment.Spec.Replicas
+	}, wait.ForeverTestTimeout, time.Millisecond*100, "deployment did not get a simulated status")
+}
@@ -35,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/yaml"
 	kubernetesclientset "k8s.io/client-go/kubernetes"
 
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/pkg/syncer"
 	kubefixtures "github.com/kcp-dev/kcp/test/e2e/fixtures/kube"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
@@ -49,13 +50,13 @@ func TestSyncerLifecycle(t *testing.T) {
 	upstreamServer := framework.SharedKcpServer(t)
 
 	t.Log("Creating an organization")
-	orgClusterName := framework.NewOrganizationFixture(t, upstreamServer)
+	orgClusterName := kcptesting.NewOrganizationFixture(t, upstreamServer)
 
 	t.Log("Creating a workspace")
-	wsClusterName := framework.NewWorkspaceFixture(t, upstreamServer, orgClusterName, "Universal")
+	wsClusterName := kcptesting.NewWorkspaceFixture(t, upstreamServer, orgClusterName, "Universal")
 
 	resourcesToSync := sets.NewString("deployments.apps")
-	syncerFixture := framework.NewSyncerFixture(t, resourcesToSync, upstreamServer, orgClusterName, wsClusterName)
+	syncerFixture := kcptesting.NewSyncerFixture(t, resourcesToSync, upstreamServer, orgClusterName, wsClusterName)
 
 	downstreamServer := syncerFixture.RunningServer
 	downstreamConfig := downstreamServer.DefaultConfig(t)
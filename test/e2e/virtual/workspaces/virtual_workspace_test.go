@@ -45,6 +45,7 @@ import (
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 )
 
@@ -132,7 +133,7 @@ func testWorkspacesVirtualWorkspaces(t *testing.T, standalone bool) {
 	}
 
 	type runningServer struct {
-		framework.RunningServer
+		kcptesting.RunningServer
 		orgClusterName        logicalcluster.LogicalCluster
 		kubeClusterClient     kubernetes.ClusterInterface
 		kcpClusterClient      kcpclientset.ClusterInterface
@@ -343,7 +344,7 @@ func testWorkspacesVirtualWorkspaces(t *testing.T, standalone bool) {
 				},
 			},
 			work: func(ctx context.Context, t *testing.T, server runningServer) {
-				org2ClusterName := framework.NewOrganizationFixture(t, server)
+				org2ClusterName := kcptesting.NewOrganizationFixture(t, server)
 				createOrgMemberRoleForGroup(t, ctx, server.kubeClusterClient, server.orgClusterName, "team-1")
 				createOrgMemberRoleForGroup(t, ctx, server.kubeClusterClient, org2ClusterName, "team-1")
 
@@ -467,13 +468,13 @@ func testWorkspacesVirtualWorkspaces(t *testing.T, standalone bool) {
 		},
 	}
 
-	var server framework.RunningServer
+	var server kcptesting.RunningServer
 	if standalone {
 		// create port early. We have to hope it is still free when we are ready to start the virtual workspace apiserver.
-		portStr, err := framework.GetFreePort(t)
+		portStr, err := kcptesting.GetFreePort(t)
 		require.NoError(t, err)
 
-		tokenAuthFile := framework.WriteTokenAuthFile(t)
+		tokenAuthFile := kcptesting.WriteTokenAuthFile(t)
 		server = framework.PrivateKcpServer(t,
 			"--run-controllers=false",
 			"--unsupported-run-individual-controllers=workspace-scheduler",
@@ -550,7 +551,7 @@ func testWorkspacesVirtualWorkspaces(t *testing.T, standalone bool) {
 			ctx, cancelFunc := context.WithCancel(context.Background())
 			t.Cleanup(cancelFunc)
 
-			orgClusterName := framework.NewOrganizationFixture(t, server)
+			orgClusterName := kcptesting.NewOrganizationFixture(t, server)
 
 			// create non-virtual clients
 			kcpConfig := server.DefaultConfig(t)
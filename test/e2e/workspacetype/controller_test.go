@@ -33,6 +33,7 @@ import (
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	clientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpclientset "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/kcptesting"
 	"github.com/kcp-dev/kcp/test/e2e/framework"
 )
 
@@ -40,9 +41,9 @@ func TestClusterWorkspaceTypes(t *testing.T) {
 	t.Parallel()
 
 	type runningServer struct {
-		framework.RunningServer
+		kcptesting.RunningServer
 		orgKcpClient clientset.Interface
-		orgExpect    framework.RegisterClusterWorkspaceExpectation
+		orgExpect    kcptesting.RegisterClusterWorkspaceExpectation
 	}
 	var testCases = []struct {
 		name string
@@ -153,9 +154,9 @@ func TestClusterWorkspaceTypes(t *testing.T) {
 		{
 			name: "create a workspace with deeper nesting",
 			work: func(ctx context.Context, t *testing.T, server runningServer) {
-				org := framework.NewOrganizationFixture(t, server)
-				team := framework.NewWorkspaceFixture(t, server, org, "Team")
-				universal := framework.NewWorkspaceFixture(t, server, team, "Universal")
+				org := kcptesting.NewOrganizationFixture(t, server)
+				team := kcptesting.NewWorkspaceFixture(t, server, org, "Team")
+				universal := kcptesting.NewWorkspaceFixture(t, server, team, "Universal")
 
 				require.Len(t, strings.Split(universal.String(), ":"), 4, "expecting root:org:team:universal, i.e. 4 levels")
 				require.True(t, strings.HasPrefix(universal.String(), team.String()), "expecting universal to be a child of team")
@@ -173,13 +174,13 @@ func TestClusterWorkspaceTypes(t *testing.T) {
 			ctx, cancelFunc := context.WithCancel(context.Background())
 			t.Cleanup(cancelFunc)
 
-			orgClusterName := framework.NewOrganizationFixture(t, server)
+			orgClusterName := kcptesting.NewOrganizationFixture(t, server)
 
 			cfg := server.DefaultConfig(t)
 			kcpClusterClient, err := kcpclientset.NewClusterForConfig(cfg)
 			require.NoError(t, err, "failed to construct client for server")
 			orgKcpClient := kcpClusterClient.Cluster(orgClusterName)
-			orgExpect, err := framework.ExpectClusterWorkspaces(ctx, t, orgKcpClient)
+			orgExpect, err := kcptesting.ExpectClusterWorkspaces(ctx, t, orgKcpClient)
 			require.NoError(t, err, "failed to start expecter")
 
 			testCase.work(ctx, t, runningServer{